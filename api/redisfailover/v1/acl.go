@@ -0,0 +1,45 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ACLFileKey is the key of the ACL ConfigMap holding the ACL file of redis.
+const ACLFileKey = "users.acl"
+
+// ACLEnabled returns true when the redis pods load their users from the ACL ConfigMap.
+func (r *RedisFailover) ACLEnabled() bool {
+	return r.Spec.Auth.ACLConfigMapRef != nil
+}
+
+// validateACL checks the ACL ConfigMap is named and the users are not declared elsewhere. The operator
+// runs the ACL commands to load the file, they can't be renamed.
+func (r *RedisFailover) validateACL() error {
+	if !r.ACLEnabled() {
+		return nil
+	}
+	if r.Spec.Auth.ACLConfigMapRef.Name == "" {
+		return errors.New("auth.aclConfigMapRef.name is required")
+	}
+	if r.ExternalNodesEnabled() {
+		return errors.New("auth.aclConfigMapRef can't be used with externalNodes")
+	}
+	for i, config := range r.Spec.Redis.CustomConfig {
+		fields := strings.Fields(config)
+		if len(fields) == 0 {
+			continue
+		}
+		switch directive := strings.ToLower(fields[0]); directive {
+		case "aclfile", "user":
+			return fmt.Errorf("redis.customConfig[%d]: %q can't be set with auth.aclConfigMapRef, the users are loaded from its %s", i, directive, ACLFileKey)
+		}
+	}
+	for i, rename := range r.Spec.Redis.CustomCommandRenames {
+		if strings.EqualFold(rename.From, "acl") {
+			return fmt.Errorf("redis.customCommandRenames[%d]: the ACL command is run by the operator to load auth.aclConfigMapRef", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestValidateACL(t *testing.T) {
+	tests := []struct {
+		name            string
+		ref             *corev1.LocalObjectReference
+		external        bool
+		customConfig    []string
+		renames         []RedisCommandRename
+		expectedEnabled bool
+		expectedError   string
+	}{
+		{
+			name: "Not set",
+		},
+		{
+			name:            "ConfigMap",
+			ref:             &corev1.LocalObjectReference{Name: "redis-acl"},
+			customConfig:    []string{"maxmemory 100mb"},
+			renames:         []RedisCommandRename{{From: "flushall", To: "fa"}},
+			expectedEnabled: true,
+		},
+		{
+			name:          "No name",
+			ref:           &corev1.LocalObjectReference{},
+			expectedError: "auth.aclConfigMapRef.name is required",
+		},
+		{
+			name:          "External nodes",
+			ref:           &corev1.LocalObjectReference{Name: "redis-acl"},
+			external:      true,
+			expectedError: "auth.aclConfigMapRef can't be used with externalNodes",
+		},
+		{
+			name:          "ACL file in the custom config",
+			ref:           &corev1.LocalObjectReference{Name: "redis-acl"},
+			customConfig:  []string{"maxmemory 100mb", "aclfile /data/users.acl"},
+			expectedError: `redis.customConfig[1]: "aclfile" can't be set with auth.aclConfigMapRef, the users are loaded from its users.acl`,
+		},
+		{
+			name:          "User in the custom config",
+			ref:           &corev1.LocalObjectReference{Name: "redis-acl"},
+			customConfig:  []string{"USER bob on >pass ~* +@all"},
+			expectedError: `redis.customConfig[0]: "user" can't be set with auth.aclConfigMapRef, the users are loaded from its users.acl`,
+		},
+		{
+			name:          "ACL renamed",
+			ref:           &corev1.LocalObjectReference{Name: "redis-acl"},
+			renames:       []RedisCommandRename{{From: "ACL", To: ""}},
+			expectedError: "redis.customCommandRenames[0]: the ACL command is run by the operator to load auth.aclConfigMapRef",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Auth.ACLConfigMapRef = test.ref
+			rf.Spec.Redis.CustomConfig = test.customConfig
+			rf.Spec.Redis.CustomCommandRenames = test.renames
+			if test.external {
+				rf.Spec.Redis.ExternalNodes = []RedisExternalNode{{Host: "10.0.0.1", Port: "6379"}}
+			}
+
+			err := rf.validateACL()
+
+			if test.expectedError != "" {
+				assert.EqualError(err, test.expectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedEnabled, rf.ACLEnabled())
+		})
+	}
+}
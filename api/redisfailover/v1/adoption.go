@@ -0,0 +1,35 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// AdoptStatefulSetAnnotation names an existing redis StatefulSet, left by another operator, that the
+// operator takes over instead of creating its own. The generated redis objects are named after it, and
+// the immutable fields of the StatefulSet are kept so it's updated in place with its volumes. It must
+// be kept as long as the RedisFailover exists.
+const AdoptStatefulSetAnnotation = "databases.spotahome.com/adopt-statefulset"
+
+// AdoptedStatefulSet returns the redis StatefulSet adopted by the RedisFailover, empty when there is none.
+func (r *RedisFailover) AdoptedStatefulSet() string {
+	return r.Annotations[AdoptStatefulSetAnnotation]
+}
+
+// validateAdoption checks the adopted StatefulSet is a valid name, and that the RedisFailover deploys
+// redis pods to run in it.
+func (r *RedisFailover) validateAdoption() error {
+	name := r.AdoptedStatefulSet()
+	if name == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("adopted statefulset %q is invalid: %s", name, strings.Join(errs, ", "))
+	}
+	if r.ExternalNodesEnabled() {
+		return fmt.Errorf("the statefulset %s can't be adopted with externalNodes", name)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAdoption(t *testing.T) {
+	tests := []struct {
+		name     string
+		adopted  string
+		external bool
+		expError string
+	}{
+		{
+			name: "No adoption",
+		},
+		{
+			name:    "Adopted statefulset",
+			adopted: "cache",
+		},
+		{
+			name:     "Invalid statefulset name",
+			adopted:  "Cache_1",
+			expError: "adopted statefulset \"Cache_1\" is invalid: a lowercase RFC 1123 label",
+		},
+		{
+			name:     "Adoption with external nodes",
+			adopted:  "cache",
+			external: true,
+			expError: "the statefulset cache can't be adopted with externalNodes",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			if test.adopted != "" {
+				rf.Annotations = map[string]string{AdoptStatefulSetAnnotation: test.adopted}
+			}
+			if test.external {
+				rf.Spec.Redis.ExternalNodes = []RedisExternalNode{{Host: "10.0.0.1", Port: "6379"}}
+			}
+
+			err := rf.validateAdoption()
+
+			if test.expError != "" {
+				assert.ErrorContains(err, test.expError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.adopted, rf.AdoptedStatefulSet())
+		})
+	}
+}
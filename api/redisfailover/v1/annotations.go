@@ -0,0 +1,213 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// AnnotationPrefix prefixes the annotations of the RedisFailover recognized by the operator.
+const AnnotationPrefix = "databases.spotahome.com/"
+
+// KnownAnnotation is an annotation of the RedisFailover recognized by the operator.
+type KnownAnnotation struct {
+	// Key is the key of the annotation.
+	Key string
+	// Parse returns an error when the value can't be used by the operator, it's nil when any value can.
+	Parse func(value string) error
+}
+
+// KnownAnnotations are the annotations of the RedisFailover recognized by the operator, besides the
+// feature gates of FeatureGateAnnotationPrefix. The other annotations under AnnotationPrefix do nothing,
+// the RFs setting them are warned about.
+var KnownAnnotations = []KnownAnnotation{
+	{Key: AdoptStatefulSetAnnotation, Parse: parseDNSLabel},
+	{Key: CheckerStateAnnotation, Parse: parseCheckerState},
+	{Key: CommonLabelsAnnotation, Parse: parseCommonLabels},
+	{Key: DefaultsRevisionAnnotation, Parse: parseDefaultsRevision},
+	{Key: DiagnoseAnnotation, Parse: parseDiagnoseRequest},
+	{Key: ForcePodOwnerAnnotationsAnnotation, Parse: parseBool},
+	{Key: NamePrefixAnnotation, Parse: parseNamePrefix},
+	{Key: OwnerClaimAnnotation, Parse: parseOwnerClaim},
+	{Key: OwnerContenderAnnotation, Parse: parseOwnerClaim},
+	{Key: RotatePasswordAnnotation},
+	{Key: SchemaRevisionAnnotation, Parse: parseRevision},
+	{Key: SupportBundleAnnotation},
+	{Key: UnfreezeAnnotation},
+}
+
+// ParseAnnotation returns an error when the value of a known annotation can't be used by the operator.
+// The values of the other annotations are not checked.
+func ParseAnnotation(key, value string) error {
+	for _, annotation := range KnownAnnotations {
+		if annotation.Key != key || annotation.Parse == nil {
+			continue
+		}
+		if err := annotation.Parse(value); err != nil {
+			return fmt.Errorf("%s annotation is invalid: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// UnknownAnnotations returns, sorted, the annotations of the RedisFailover under AnnotationPrefix that
+// the operator doesn't recognize.
+func (r *RedisFailover) UnknownAnnotations() []string {
+	unknown := []string{}
+	for key := range r.Annotations {
+		if strings.HasPrefix(key, AnnotationPrefix) && !strings.HasPrefix(key, FeatureGateAnnotationPrefix) && !knownAnnotation(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// InvalidAnnotations returns the errors of the known annotations of the RedisFailover whose value can't
+// be used, sorted by annotation.
+func (r *RedisFailover) InvalidAnnotations() []error {
+	keys := []string{}
+	for key := range r.Annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	errs := []error{}
+	for _, key := range keys {
+		if err := ParseAnnotation(key, r.Annotations[key]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// SuggestAnnotation returns the known annotation closest to the key, by edit distance. The keys with a
+// dot after AnnotationPrefix are also compared to the feature gate of the name after the dot.
+func SuggestAnnotation(key string) string {
+	candidates := []string{}
+	for _, annotation := range KnownAnnotations {
+		candidates = append(candidates, annotation.Key)
+	}
+	if _, gate, ok := strings.Cut(strings.TrimPrefix(key, AnnotationPrefix), "."); ok && gate != "" {
+		candidates = append(candidates, FeatureGateAnnotationPrefix+gate)
+	}
+
+	suggestion, best := "", -1
+	for _, candidate := range candidates {
+		if d := editDistance(key, candidate); best < 0 || d < best {
+			suggestion, best = candidate, d
+		}
+	}
+	return suggestion
+}
+
+func knownAnnotation(key string) bool {
+	for _, annotation := range KnownAnnotations {
+		if annotation.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance returns the Levenshtein distance between a and b, the fewest insertions, deletions or
+// substitutions of a character turning a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+func parseDNSLabel(value string) error {
+	if value == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(value); len(errs) > 0 {
+		return fmt.Errorf("%q is not a valid name: %s", value, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func parseCheckerState(value string) error {
+	if value == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(value), &CheckerState{})
+}
+
+func parseCommonLabels(value string) error {
+	_, err := ParseCommonLabels(value)
+	return err
+}
+
+func parseDefaultsRevision(value string) error {
+	if revision, err := strconv.Atoi(value); err != nil || revision < BaseDefaultsRevision {
+		return fmt.Errorf("must be a schema revision, got %q", value)
+	}
+	return nil
+}
+
+func parseDiagnoseRequest(value string) error {
+	request := strings.TrimSpace(value)
+	target, _, _ := strings.Cut(request, "#")
+	if request != "" && strings.TrimSpace(target) == "" {
+		return fmt.Errorf("must be a redis pod or %s, got %q", DiagnoseAll, value)
+	}
+	return nil
+}
+
+func parseBool(value string) error {
+	if value != "true" && value != "false" {
+		return fmt.Errorf("must be true or false, got %q", value)
+	}
+	return nil
+}
+
+func parseNamePrefix(value string) error {
+	if value != "" && !namePrefixRegexp.MatchString(value) {
+		return fmt.Errorf("must be lowercase alphanumeric characters or '-', got %q", value)
+	}
+	return nil
+}
+
+func parseOwnerClaim(value string) error {
+	if value == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(value), &OwnerClaim{})
+}
+
+func parseRevision(value string) error {
+	if _, err := strconv.Atoi(value); err != nil {
+		return fmt.Errorf("must be a schema revision, got %q", value)
+	}
+	return nil
+}
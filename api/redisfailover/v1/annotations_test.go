@@ -0,0 +1,166 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSuggestAnnotation(t *testing.T) {
+	tests := []struct {
+		name          string
+		key           string
+		expSuggestion string
+	}{
+		{
+			name:          "Missing letter",
+			key:           "databases.spotahome.com/suport-bundle",
+			expSuggestion: SupportBundleAnnotation,
+		},
+		{
+			name:          "Swapped letters",
+			key:           "databases.spotahome.com/daignose",
+			expSuggestion: DiagnoseAnnotation,
+		},
+		{
+			name:          "Other word",
+			key:           "databases.spotahome.com/adopt-sts",
+			expSuggestion: AdoptStatefulSetAnnotation,
+		},
+		{
+			name:          "Feature gate",
+			key:           "databases.spotahome.com/featrue.use-evictions",
+			expSuggestion: "databases.spotahome.com/feature.use-evictions",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expSuggestion, SuggestAnnotation(test.key))
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(0, editDistance("diagnose", "diagnose"))
+	assert.Equal(1, editDistance("diagnose", "diagnos"))
+	assert.Equal(1, editDistance("diagnose", "diagmose"))
+	assert.Equal(2, editDistance("diagnose", "daignose"))
+	assert.Equal(8, editDistance("", "diagnose"))
+}
+
+func TestParseAnnotation(t *testing.T) {
+	tests := []struct {
+		key        string
+		valid      []string
+		invalid    []string
+		expInvalid string
+	}{
+		{
+			key:        AdoptStatefulSetAnnotation,
+			valid:      []string{"", "redis-data"},
+			invalid:    []string{"Redis_Data"},
+			expInvalid: `databases.spotahome.com/adopt-statefulset annotation is invalid: "Redis_Data" is not a valid name`,
+		},
+		{
+			key:        CheckerStateAnnotation,
+			valid:      []string{"", `{"master":"10.0.0.1"}`},
+			invalid:    []string{"{"},
+			expInvalid: "databases.spotahome.com/checker-state annotation is invalid: unexpected end of JSON input",
+		},
+		{
+			key:        CommonLabelsAnnotation,
+			valid:      []string{"", "team=a, tier=cache"},
+			invalid:    []string{"team"},
+			expInvalid: `databases.spotahome.com/common-labels annotation is invalid: common label "team" must be a key=value pair`,
+		},
+		{
+			key:        DefaultsRevisionAnnotation,
+			valid:      []string{"1", "14"},
+			invalid:    []string{"0", "latest"},
+			expInvalid: "databases.spotahome.com/defaults-revision annotation is invalid: must be a schema revision",
+		},
+		{
+			key:        DiagnoseAnnotation,
+			valid:      []string{"", "all", "rfr-test-0", "all#2"},
+			invalid:    []string{"#2"},
+			expInvalid: `databases.spotahome.com/diagnose annotation is invalid: must be a redis pod or all, got "#2"`,
+		},
+		{
+			key:        ForcePodOwnerAnnotationsAnnotation,
+			valid:      []string{"true", "false"},
+			invalid:    []string{"yes", ""},
+			expInvalid: "databases.spotahome.com/force-pod-owner-annotations annotation is invalid: must be true or false",
+		},
+		{
+			key:        NamePrefixAnnotation,
+			valid:      []string{"", "team-a-"},
+			invalid:    []string{"Team"},
+			expInvalid: `databases.spotahome.com/name-prefix annotation is invalid: must be lowercase alphanumeric characters or '-', got "Team"`,
+		},
+		{
+			key:        OwnerClaimAnnotation,
+			valid:      []string{"", `{"operator":"ops/redis-operator"}`},
+			invalid:    []string{"ops/redis-operator"},
+			expInvalid: "databases.spotahome.com/owner-claim annotation is invalid: invalid character",
+		},
+		{
+			key:        OwnerContenderAnnotation,
+			valid:      []string{"", `{"operator":"ops/redis-operator"}`},
+			invalid:    []string{"ops/redis-operator"},
+			expInvalid: "databases.spotahome.com/owner-contender annotation is invalid: invalid character",
+		},
+		{
+			key:        SchemaRevisionAnnotation,
+			valid:      []string{"14"},
+			invalid:    []string{"v14"},
+			expInvalid: `databases.spotahome.com/schema-revision annotation is invalid: must be a schema revision, got "v14"`,
+		},
+		{
+			key:   SupportBundleAnnotation,
+			valid: []string{"", "now", "2026-10-16"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			assert := assert.New(t)
+			for _, value := range test.valid {
+				assert.NoError(ParseAnnotation(test.key, value), value)
+			}
+			for _, value := range test.invalid {
+				err := ParseAnnotation(test.key, value)
+				if assert.Error(err, value) {
+					assert.Contains(err.Error(), test.expInvalid)
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownAndInvalidAnnotations(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := &RedisFailover{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		"databases.spotahome.com/suport-bundle":               "now",
+		"databases.spotahome.com/feature.use-evictions":       "true",
+		"databases.spotahome.com/diagnose":                    "all",
+		"databases.spotahome.com/force-pod-owner-annotations": "yes",
+		"databases.spotahome.com/defaults-revision":           "0",
+		"example.com/suport-bundle":                           "now",
+	}}}
+
+	// The feature gates and the annotations of other prefixes are not ours to check.
+	assert.Equal([]string{"databases.spotahome.com/suport-bundle"}, rf.UnknownAnnotations())
+	errs := []string{}
+	for _, err := range rf.InvalidAnnotations() {
+		errs = append(errs, err.Error())
+	}
+	assert.Equal([]string{
+		`databases.spotahome.com/defaults-revision annotation is invalid: must be a schema revision, got "0"`,
+		`databases.spotahome.com/force-pod-owner-annotations annotation is invalid: must be true or false, got "yes"`,
+	}, errs)
+}
@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// RotatePasswordAnnotation requests the operator to rotate the password of the secret it generated, see
+// auth.generateSecret. Its value identifies the request, the password is rotated every time it changes.
+const RotatePasswordAnnotation = "databases.spotahome.com/rotate-password"
+
+// generatedSecretType is the type of the generated password secret in its name, it's named after the redis.
+const generatedSecretType = "r"
+
+// AuthFromFile returns true when the redis pods read the password from the password file instead of
+// getting it from the operator.
+func (r *RedisFailover) AuthFromFile() bool {
+	return r.Spec.Auth.Mode == AuthModeFile
+}
+
+// GeneratedSecretName returns the name of the secret of the password generated by the operator.
+func (r *RedisFailover) GeneratedSecretName() string {
+	return r.GeneratedName(generatedSecretType) + "-auth"
+}
+
+// AuthSecretName returns the name of the secret of the password, the generated one with
+// auth.generateSecret, empty without password. The validation sets it as the secretPath.
+func (r *RedisFailover) AuthSecretName() string {
+	if r.Spec.Auth.GenerateSecret && r.Spec.Auth.SecretPath == "" {
+		return r.GeneratedSecretName()
+	}
+	return r.Spec.Auth.SecretPath
+}
+
+// PasswordRotationRequest returns the password rotation requested on the RedisFailover, empty when there
+// isn't any.
+func (r *RedisFailover) PasswordRotationRequest() string {
+	return r.Annotations[RotatePasswordAnnotation]
+}
+
+// validateAuth checks the password file is only set, and absolute, with the File mode, and sets the
+// secretPath to the generated secret.
+func (r *RedisFailover) validateAuth() error {
+	if err := r.validateGenerateSecret(); err != nil {
+		return err
+	}
+	auth := r.Spec.Auth
+	switch auth.Mode {
+	case "", AuthModeSecret:
+		if auth.PasswordFile != "" {
+			return fmt.Errorf("auth.passwordFile can only be set with the %s mode", AuthModeFile)
+		}
+	case AuthModeFile:
+		if auth.PasswordFile == "" {
+			return fmt.Errorf("auth.passwordFile is required with the %s mode", AuthModeFile)
+		}
+		// The file is included in the redis config, it must be a single argument.
+		if !filepath.IsAbs(auth.PasswordFile) || strings.ContainsAny(auth.PasswordFile, " \t\r\n\"'\\") {
+			return fmt.Errorf("auth.passwordFile %q must be an absolute path without spaces nor quotes", auth.PasswordFile)
+		}
+		if r.ExternalNodesEnabled() {
+			return fmt.Errorf("auth.mode %s can't be used with externalNodes", AuthModeFile)
+		}
+	default:
+		return fmt.Errorf("auth.mode must be %s or %s, got %q", AuthModeSecret, AuthModeFile, auth.Mode)
+	}
+	return nil
+}
+
+// validateGenerateSecret checks the generated secret is the only source of the password, and of the
+// password of the redis nodes, then sets it as the secretPath.
+func (r *RedisFailover) validateGenerateSecret() error {
+	auth := &r.Spec.Auth
+	if !auth.GenerateSecret {
+		return nil
+	}
+	if auth.SecretPath != "" && auth.SecretPath != r.GeneratedSecretName() {
+		return errors.New("auth.secretPath can't be set with auth.generateSecret")
+	}
+	if auth.Mode == AuthModeFile {
+		return fmt.Errorf("auth.generateSecret can't be used with the %s mode", AuthModeFile)
+	}
+	if r.ExternalNodesEnabled() || r.Bootstrapping() {
+		return errors.New("auth.generateSecret can't be used with externalNodes nor bootstrapNode, their password is not generated")
+	}
+	auth.SecretPath = r.GeneratedSecretName()
+	return nil
+}
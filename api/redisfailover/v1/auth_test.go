@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		auth          AuthSettings
+		namePrefix    string
+		external      bool
+		bootstrap     bool
+		expFromFile   bool
+		expSecretPath string
+		expError      string
+	}{
+		{
+			name: "No auth",
+		},
+		{
+			name:          "Secret mode",
+			auth:          AuthSettings{SecretPath: "auth", Mode: AuthModeSecret},
+			expSecretPath: "auth",
+		},
+		{
+			name:          "Generated secret",
+			auth:          AuthSettings{GenerateSecret: true},
+			expSecretPath: "rfr-test-auth",
+		},
+		{
+			name:          "Generated secret with a name prefix",
+			auth:          AuthSettings{GenerateSecret: true},
+			namePrefix:    "team-",
+			expSecretPath: "team-rfr-test-auth",
+		},
+		{
+			name:          "Generated secret validated again",
+			auth:          AuthSettings{GenerateSecret: true, SecretPath: "rfr-test-auth"},
+			expSecretPath: "rfr-test-auth",
+		},
+		{
+			name:     "Generated secret with a secret path",
+			auth:     AuthSettings{GenerateSecret: true, SecretPath: "auth"},
+			expError: "auth.secretPath can't be set with auth.generateSecret",
+		},
+		{
+			name:     "Generated secret with the File mode",
+			auth:     AuthSettings{GenerateSecret: true, Mode: AuthModeFile, PasswordFile: "/vault/secrets/redis.conf"},
+			expError: "auth.generateSecret can't be used with the File mode",
+		},
+		{
+			name:      "Generated secret with a bootstrap node",
+			auth:      AuthSettings{GenerateSecret: true},
+			bootstrap: true,
+			expError:  "auth.generateSecret can't be used with externalNodes nor bootstrapNode, their password is not generated",
+		},
+		{
+			name:        "File mode",
+			auth:        AuthSettings{Mode: AuthModeFile, PasswordFile: "/vault/secrets/redis.conf"},
+			expFromFile: true,
+		},
+		{
+			name:     "Password file without the File mode",
+			auth:     AuthSettings{PasswordFile: "/vault/secrets/redis.conf"},
+			expError: "auth.passwordFile can only be set with the File mode",
+		},
+		{
+			name:     "File mode without password file",
+			auth:     AuthSettings{Mode: AuthModeFile},
+			expError: "auth.passwordFile is required with the File mode",
+		},
+		{
+			name:     "Relative password file",
+			auth:     AuthSettings{Mode: AuthModeFile, PasswordFile: "secrets/redis.conf"},
+			expError: "auth.passwordFile \"secrets/redis.conf\" must be an absolute path without spaces nor quotes",
+		},
+		{
+			name:     "Password file with spaces",
+			auth:     AuthSettings{Mode: AuthModeFile, PasswordFile: "/vault/my secrets/redis.conf"},
+			expError: "auth.passwordFile \"/vault/my secrets/redis.conf\" must be an absolute path without spaces nor quotes",
+		},
+		{
+			name:     "File mode with external nodes",
+			auth:     AuthSettings{Mode: AuthModeFile, PasswordFile: "/vault/secrets/redis.conf"},
+			external: true,
+			expError: "auth.mode File can't be used with externalNodes",
+		},
+		{
+			name:     "Unknown mode",
+			auth:     AuthSettings{Mode: "Vault"},
+			expError: "auth.mode must be Secret or File, got \"Vault\"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Auth = test.auth
+			if test.namePrefix != "" {
+				rf.Annotations = map[string]string{NamePrefixAnnotation: test.namePrefix}
+			}
+			if test.external {
+				rf.Spec.Redis.ExternalNodes = []RedisExternalNode{{Host: "10.0.0.1", Port: "6379"}}
+			}
+			if test.bootstrap {
+				rf.Spec.BootstrapNode = &BootstrapSettings{Host: "10.0.0.1"}
+			}
+
+			err := rf.validateAuth()
+
+			if test.expError != "" {
+				assert.EqualError(err, test.expError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expFromFile, rf.AuthFromFile())
+			assert.Equal(test.expSecretPath, rf.Spec.Auth.SecretPath)
+			assert.Equal(test.expSecretPath, rf.AuthSecretName())
+		})
+	}
+}
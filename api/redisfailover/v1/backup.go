@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultBackupInterval  = 24 * time.Hour
+	defaultBackupRetention = 7
+	minBackupInterval      = time.Minute
+	maxBackupInterval      = 30 * 24 * time.Hour
+)
+
+// BackupEnabled returns true when the redis data is backed up.
+func (r *RedisFailover) BackupEnabled() bool {
+	return r.Spec.Backup != nil
+}
+
+// GetInterval returns the time between two backups.
+func (b *BackupSettings) GetInterval() time.Duration {
+	if b.Interval == nil {
+		return defaultBackupInterval
+	}
+	return b.Interval.Duration
+}
+
+// validateBackup checks the backups can be taken from the persistent volume claims of the replicas,
+// and defaults their interval and retention.
+func (r *RedisFailover) validateBackup() error {
+	backup := r.Spec.Backup
+	if backup.Method != BackupMethodVolumeSnapshot {
+		return fmt.Errorf("backup.method must be %s, got %q", BackupMethodVolumeSnapshot, backup.Method)
+	}
+	if r.ExternalNodesEnabled() {
+		return errors.New("backup can't be used with externalNodes")
+	}
+	if r.Spec.Redis.Storage.PersistentVolumeClaim == nil {
+		return errors.New("backup needs the redis data in redis.storage.persistentVolumeClaim")
+	}
+	if err := validateDuration("backup.interval", backup.Interval, maxBackupInterval); err != nil {
+		return err
+	}
+	if backup.Interval == nil {
+		backup.Interval = &metav1.Duration{Duration: defaultBackupInterval}
+	}
+	if backup.Interval.Duration < minBackupInterval {
+		return fmt.Errorf("backup.interval must be at least %s, got %s", minBackupInterval, backup.Interval.Duration)
+	}
+	if backup.Retention < 0 {
+		return fmt.Errorf("backup.retention can't be negative, got %d", backup.Retention)
+	}
+	if backup.Retention == 0 {
+		backup.Retention = defaultBackupRetention
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateBackup(t *testing.T) {
+	pvc := &EmbeddedPersistentVolumeClaim{}
+
+	tests := []struct {
+		name          string
+		backup        BackupSettings
+		storage       RedisStorage
+		externalNodes []RedisExternalNode
+		expBackup     BackupSettings
+		expectedError string
+	}{
+		{
+			name:      "Populates the default interval and retention",
+			backup:    BackupSettings{Method: BackupMethodVolumeSnapshot},
+			storage:   RedisStorage{PersistentVolumeClaim: pvc},
+			expBackup: BackupSettings{Method: BackupMethodVolumeSnapshot, Interval: &metav1.Duration{Duration: 24 * time.Hour}, Retention: 7},
+		},
+		{
+			name:      "Keeps the interval and retention set",
+			backup:    BackupSettings{Method: BackupMethodVolumeSnapshot, Interval: &metav1.Duration{Duration: time.Hour}, Retention: 2},
+			storage:   RedisStorage{PersistentVolumeClaim: pvc},
+			expBackup: BackupSettings{Method: BackupMethodVolumeSnapshot, Interval: &metav1.Duration{Duration: time.Hour}, Retention: 2},
+		},
+		{
+			name:          "The RDB uploads are rejected",
+			backup:        BackupSettings{Method: "Rdb"},
+			storage:       RedisStorage{PersistentVolumeClaim: pvc},
+			expectedError: `backup.method must be VolumeSnapshot, got "Rdb"`,
+		},
+		{
+			name:          "Data not in a persistent volume claim is rejected",
+			backup:        BackupSettings{Method: BackupMethodVolumeSnapshot},
+			expectedError: "backup needs the redis data in redis.storage.persistentVolumeClaim",
+		},
+		{
+			name:          "External nodes are rejected",
+			backup:        BackupSettings{Method: BackupMethodVolumeSnapshot},
+			externalNodes: []RedisExternalNode{{Host: "10.0.0.1"}},
+			expectedError: "backup can't be used with externalNodes",
+		},
+		{
+			name:          "A too short interval is rejected",
+			backup:        BackupSettings{Method: BackupMethodVolumeSnapshot, Interval: &metav1.Duration{Duration: 10 * time.Second}},
+			storage:       RedisStorage{PersistentVolumeClaim: pvc},
+			expectedError: "backup.interval must be at least 1m0s, got 10s",
+		},
+		{
+			name:          "A negative retention is rejected",
+			backup:        BackupSettings{Method: BackupMethodVolumeSnapshot, Retention: -1},
+			storage:       RedisStorage{PersistentVolumeClaim: pvc},
+			expectedError: "backup.retention can't be negative, got -1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			rf := generateRedisFailover("test", nil)
+			backup := test.backup
+			rf.Spec.Backup = &backup
+			rf.Spec.Redis.Storage = test.storage
+			rf.Spec.Redis.ExternalNodes = test.externalNodes
+
+			err := rf.Validate()
+
+			if test.expectedError == "" {
+				assert.NoError(err)
+				assert.Equal(test.expBackup, *rf.Spec.Backup)
+				// Validating again, as it's done on every resync, keeps the spec valid.
+				assert.NoError(rf.Validate())
+			} else {
+				assert.EqualError(err, test.expectedError)
+			}
+		})
+	}
+}
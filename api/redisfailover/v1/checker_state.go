@@ -0,0 +1,59 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CheckerStateAnnotation holds, as JSON, the state of the checks of the operator on the RF that must
+// survive the restarts of the operator.
+const CheckerStateAnnotation = "databases.spotahome.com/checker-state"
+
+// CheckerState is the state of the checks of the operator on a RF that must survive its restarts.
+// Losing it would count a failover twice or reset the remediations already escalated, so it's stored
+// on the RF. It's bounded by the number of pods of the RF.
+type CheckerState struct {
+	// Master is the master seen on the last check.
+	Master string `json:"master,omitempty"`
+	// MasterChanges counts the master changes seen by the operator.
+	MasterChanges int64 `json:"masterChanges,omitempty"`
+	// StuckReplicas are the replicas stuck with the link to the master down and their remediation.
+	StuckReplicas []StuckReplicaState `json:"stuckReplicas,omitempty"`
+	// UnresponsiveSentinels counts the consecutive checks the sentinel pods have not answered, by pod.
+	UnresponsiveSentinels map[string]int32 `json:"unresponsiveSentinels,omitempty"`
+}
+
+// StuckReplicaState is the remediation of a stuck replica.
+type StuckReplicaState struct {
+	Pod string `json:"pod"`
+	// Checks counts the checks the replica has been stuck since the last escalation step.
+	Checks int32 `json:"checks,omitempty"`
+	// Step is the last escalation step taken, 0 when none was taken yet.
+	Step int32 `json:"step,omitempty"`
+	// PartialSyncOK and PartialSyncErr are the partial sync counters of the master when the replica
+	// got stuck.
+	PartialSyncOK  int64 `json:"partialSyncOK,omitempty"`
+	PartialSyncErr int64 `json:"partialSyncErr,omitempty"`
+}
+
+// ParseCheckerState returns the checker state stored in the annotations, false if it's missing.
+func ParseCheckerState(annotations map[string]string) (CheckerState, bool, error) {
+	value, ok := annotations[CheckerStateAnnotation]
+	if !ok || value == "" {
+		return CheckerState{}, false, nil
+	}
+	state := CheckerState{}
+	if err := json.Unmarshal([]byte(value), &state); err != nil {
+		return CheckerState{}, false, fmt.Errorf("invalid %s annotation: %w", CheckerStateAnnotation, err)
+	}
+	return state, true, nil
+}
+
+// FormatCheckerState returns the checker state as stored in the annotation.
+func FormatCheckerState(state CheckerState) (string, error) {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
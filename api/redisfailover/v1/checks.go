@@ -0,0 +1,36 @@
+package v1
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultReplicationDelay = 500 * time.Millisecond
+	maxReplicationDelay     = 5 * time.Second
+)
+
+// FunctionalProbeEnabled returns true when the operator writes a key on the master on every check and
+// reads it back from the replicas.
+func (r *RedisFailover) FunctionalProbeEnabled() bool {
+	return r.Spec.Checks != nil && r.Spec.Checks.FunctionalProbe
+}
+
+// FunctionalProbeReplicationDelay returns how long the replicas are given to replicate the key of the
+// functional probe before it's read back from them.
+func (r *RedisFailover) FunctionalProbeReplicationDelay() time.Duration {
+	if c := r.Spec.Checks; c != nil && c.ReplicationDelay != nil {
+		return c.ReplicationDelay.Duration
+	}
+	return defaultReplicationDelay
+}
+
+// validateChecks checks the replication delay of the functional probe doesn't hold the checks too long.
+func (r *RedisFailover) validateChecks() error {
+	if c := r.Spec.Checks; c != nil && c.ReplicationDelay != nil {
+		if d := c.ReplicationDelay.Duration; d < 0 || d > maxReplicationDelay {
+			return fmt.Errorf("checks.replicationDelay must be between 0s and %s, got %s", maxReplicationDelay, d)
+		}
+	}
+	return nil
+}
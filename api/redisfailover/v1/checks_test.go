@@ -0,0 +1,63 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateChecks(t *testing.T) {
+	durationPtr := func(d time.Duration) *metav1.Duration { return &metav1.Duration{Duration: d} }
+	tests := []struct {
+		name          string
+		checks        *ChecksSettings
+		expectedProbe bool
+		expectedDelay time.Duration
+		expectedError string
+	}{
+		{
+			name:          "Not set",
+			expectedDelay: 500 * time.Millisecond,
+		},
+		{
+			name:          "Probe with the default delay",
+			checks:        &ChecksSettings{FunctionalProbe: true},
+			expectedProbe: true,
+			expectedDelay: 500 * time.Millisecond,
+		},
+		{
+			name:          "Delay set",
+			checks:        &ChecksSettings{FunctionalProbe: true, ReplicationDelay: durationPtr(0)},
+			expectedProbe: true,
+		},
+		{
+			name:          "Negative delay",
+			checks:        &ChecksSettings{ReplicationDelay: durationPtr(-time.Millisecond)},
+			expectedError: "checks.replicationDelay must be between 0s and 5s, got -1ms",
+		},
+		{
+			name:          "Delay too long",
+			checks:        &ChecksSettings{ReplicationDelay: durationPtr(5001 * time.Millisecond)},
+			expectedError: "checks.replicationDelay must be between 0s and 5s, got 5.001s",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Checks = test.checks
+			err := rf.Validate()
+			if test.expectedError != "" {
+				assert.EqualError(err, test.expectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedProbe, rf.FunctionalProbeEnabled())
+			assert.Equal(test.expectedDelay, rf.FunctionalProbeReplicationDelay())
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package v1
+
+// Condition types set on the RedisFailover status
+const (
+	// ConditionOperatorTooOld is true when the RedisFailover is not reconciled because it's newer than the operator.
+	ConditionOperatorTooOld = "OperatorTooOld"
+	// ConditionNodeTuningWarning is true when redis warned on startup about kernel settings of its nodes.
+	ConditionNodeTuningWarning = "NodeTuningWarning"
+	// ConditionProgressing is true while the RedisFailover is converging and some actions are held.
+	ConditionProgressing = "Progressing"
+	// ConditionPromotionHeld is true while no master answers the operator but it's not confirmed down,
+	// so a new master is not promoted.
+	ConditionPromotionHeld = "PromotionHeld"
+	// ConditionPlacementWarning is true when all the redis pods are in a single failure domain while
+	// the spec spreads them across it.
+	ConditionPlacementWarning = "PlacementWarning"
+	// ConditionQuorumWarning is true when the sentinel quorum set in the spec is below the majority of
+	// the sentinels.
+	ConditionQuorumWarning = "QuorumWarning"
+	// ConditionDeprecatedFieldsInUse is true when the spec sets fields slated for removal.
+	ConditionDeprecatedFieldsInUse = "DeprecatedFieldsInUse"
+	// ConditionManagedByOtherOperator is true while another operator reconciling the RF makes this one
+	// back off.
+	ConditionManagedByOtherOperator = "ManagedByOtherOperator"
+	// ConditionPressure is true while the redis pods are slow to answer the operator.
+	ConditionPressure = "Pressure"
+	// ConditionPodDisruptionBudgetWarning is true when the PodDisruptionBudgets are not written because
+	// the cluster serves no PodDisruptionBudget API.
+	ConditionPodDisruptionBudgetWarning = "PodDisruptionBudgetWarning"
+	// ConditionDegraded is true while the redis pods run but their data is not kept, as they write it
+	// out of their data volume.
+	ConditionDegraded = "Degraded"
+	// ConditionPossibleDataFlush is true from a mass deletion of the keys of the master until it's
+	// acknowledged with the unfreeze annotation.
+	ConditionPossibleDataFlush = "PossibleDataFlush"
+	// ConditionACLLoadFailed is true while the ACL file of auth.aclConfigMapRef can't be loaded by the
+	// redis pods.
+	ConditionACLLoadFailed = "ACLLoadFailed"
+	// ConditionReady is true while the redis and sentinel pods are ready and the last reconcile of the
+	// RedisFailover succeeded.
+	ConditionReady = "Ready"
+	// ConditionScaling is true while the number of redis or sentinel pods differs from the replicas of
+	// the spec.
+	ConditionScaling = "Scaling"
+	// ConditionTerminating is true while the objects of a deleted RedisFailover are deleted in order, its
+	// reason is the stage of the teardown.
+	ConditionTerminating = "Terminating"
+)
+
+// Condition reasons set on the RedisFailover status
+const (
+	ReasonSchemaRevisionNewer = "SchemaRevisionNewer"
+	ReasonKernelSettings      = "KernelSettings"
+	// ReasonStabilizingAfterFailover holds the disruptive actions while a new master stabilizes.
+	ReasonStabilizingAfterFailover = "StabilizingAfterFailover"
+	// ReasonMasterDownNotCorroborated holds the promotion of a new master while neither the sentinels
+	// nor the other probes confirm the master is down.
+	ReasonMasterDownNotCorroborated = "MasterDownNotCorroborated"
+	// ReasonRolloutInProgress holds the promotion of a new master while the redis statefulset rolls out.
+	ReasonRolloutInProgress = "RolloutInProgress"
+	// ReasonSingleFailureDomain warns the redis pods are not spread as the spec requests.
+	ReasonSingleFailureDomain = "SingleFailureDomain"
+	// ReasonQuorumBelowMajority warns a minority of the sentinels can fail the master over.
+	ReasonQuorumBelowMajority = "QuorumBelowMajority"
+	// ReasonDeprecatedFields warns the spec must be migrated before the deprecated fields are removed.
+	ReasonDeprecatedFields = "DeprecatedFields"
+	// ReasonOwnerClaimLive backs off while the claim of the other operator is renewed.
+	ReasonOwnerClaimLive = "OwnerClaimLive"
+	// ReasonHighLatency warns the round-trip of the PING to the redis pods stays above the threshold.
+	ReasonHighLatency = "HighLatency"
+	// ReasonPodDisruptionBudgetsUnavailable warns the pods are not protected from voluntary disruptions.
+	ReasonPodDisruptionBudgetsUnavailable = "PodDisruptionBudgetsUnavailable"
+	// ReasonDataDirNotOnVolume warns the dir of redis is not on the data volume, its data is lost on restart.
+	ReasonDataDirNotOnVolume = "DataDirNotOnVolume"
+	// ReasonMasterKeysDropped warns the master lost most of its keys without a failover nor a restart.
+	ReasonMasterKeysDropped = "MasterKeysDropped"
+	// ReasonACLInvalid warns redis can't parse the ACL file, the users it had are kept.
+	ReasonACLInvalid = "ACLInvalid"
+	// ReasonACLUnsupported warns the redis pods are older than the ACLs.
+	ReasonACLUnsupported = "ACLUnsupported"
+	// ReasonPodsReady reports the pods are ready and the RedisFailover reconciled.
+	ReasonPodsReady = "PodsReady"
+	// ReasonPodsNotReady reports some pods are not ready.
+	ReasonPodsNotReady = "PodsNotReady"
+	// ReasonRedisCrashLooping reports some redis pods are crash looping, with the last lines they printed.
+	ReasonRedisCrashLooping = "RedisCrashLooping"
+	// ReasonEnsureFailed reports the objects of the RedisFailover could not be created or updated.
+	ReasonEnsureFailed = "EnsureFailed"
+	// ReasonStorageClassNotFound reports the redis statefulset is not created, the storage class of its
+	// volumes doesn't exist.
+	ReasonStorageClassNotFound = "StorageClassNotFound"
+	// ReasonCheckFailed reports the check of the redis and sentinel pods failed.
+	ReasonCheckFailed = "CheckFailed"
+	// ReasonPasswordRotationFailed reports the password requested with the rotate-password annotation is
+	// not rotated yet, the rotation is retried on the next reconcile.
+	ReasonPasswordRotationFailed = "PasswordRotationFailed"
+	// ReasonPasswordRotated reports the password is rotated on the pods and in its secret.
+	ReasonPasswordRotated = "PasswordRotated"
+	// ReasonScalingPods reports the pods are being created or removed to match the replicas.
+	ReasonScalingPods = "ScalingPods"
+	// ReasonReplicasReached reports the number of pods matches the replicas.
+	ReasonReplicasReached = "ReplicasReached"
+	// ReasonDeletingSentinels reports the sentinels are deleted first, so they don't fail the master over.
+	ReasonDeletingSentinels = "DeletingSentinels"
+	// ReasonDeletingRedis reports the redis statefulset is deleted, once the sentinels are gone.
+	ReasonDeletingRedis = "DeletingRedis"
+	// ReasonDeletingObjects reports the services, configmaps and the other objects are deleted.
+	ReasonDeletingObjects = "DeletingObjects"
+	// ReasonDeletingVolumes reports the claims of the redis volumes not kept after the deletion are deleted.
+	ReasonDeletingVolumes = "DeletingVolumes"
+)
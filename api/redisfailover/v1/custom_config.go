@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// reservedRedisDirectives are set by the operator, setting them in the custom config would break
+	// the replication, the authentication or the commands of the operator.
+	reservedRedisDirectives = map[string]string{
+		"slaveof":        "the replication is managed by the operator",
+		"replicaof":      "the replication is managed by the operator",
+		"port":           "use spec.redis.port",
+		"requirepass":    "the password is taken from spec.auth.secretPath",
+		"masterauth":     "the password is taken from spec.auth.secretPath",
+		"rename-command": "use spec.redis.customCommandRenames",
+		"include":        "the included files are managed by the operator",
+	}
+	// reservedSentinelDirectives are set by the operator on the sentinels, or run scripts on them.
+	reservedSentinelDirectives = map[string]string{
+		"monitor":                "the monitored master is managed by the operator",
+		"quorum":                 "use spec.sentinel.quorum",
+		"auth-pass":              "the password is taken from spec.auth.secretPath",
+		"auth-user":              "the password is taken from spec.auth.secretPath",
+		"notification-script":    "the sentinels don't run scripts",
+		"client-reconfig-script": "the sentinels don't run scripts",
+		"sentinel":               `drop the "sentinel" prefix and the master name, as in "down-after-milliseconds 5000"`,
+	}
+	// reservedCommandRenames are the commands the operator and the probes run on the redis.
+	reservedCommandRenames = map[string]bool{
+		"auth":      true,
+		"config":    true,
+		"info":      true,
+		"ping":      true,
+		"replicaof": true,
+		"slaveof":   true,
+	}
+
+	commandNameRE = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// validateCustomConfig checks the custom config can't inject other directives than its own, nor
+// override the ones the operator relies on. The errors point to the offending line.
+func (r *RedisFailover) validateCustomConfig() error {
+	if err := validateCustomConfigLines("redis.customConfig", r.Spec.Redis.CustomConfig, reservedRedisDirectives); err != nil {
+		return err
+	}
+	if err := validateCustomConfigLines("sentinel.customConfig", r.Spec.Sentinel.CustomConfig, reservedSentinelDirectives); err != nil {
+		return err
+	}
+	if err := r.validateSentinelGlobalConfig(); err != nil {
+		return err
+	}
+	for i, rename := range r.Spec.Redis.CustomCommandRenames {
+		field := fmt.Sprintf("redis.customCommandRenames[%d]", i)
+		if !commandNameRE.MatchString(rename.From) {
+			return fmt.Errorf("%s.from %q must be a command name", field, rename.From)
+		}
+		// An empty name disables the command.
+		if rename.To != "" && !commandNameRE.MatchString(rename.To) {
+			return fmt.Errorf("%s.to %q must be a command name or empty", field, rename.To)
+		}
+		if reservedCommandRenames[strings.ToLower(rename.From)] {
+			return fmt.Errorf("%s.from %q can't be renamed, the operator runs it", field, rename.From)
+		}
+	}
+	return nil
+}
+
+func validateCustomConfigLines(field string, lines []string, reserved map[string]string) error {
+	for i, line := range lines {
+		if strings.IndexFunc(line, unicode.IsControl) >= 0 {
+			return fmt.Errorf("%s[%d] %q must be a single line without control characters", field, i, line)
+		}
+		directive := strings.ToLower(strings.SplitN(strings.TrimSpace(line), " ", 2)[0])
+		if hint, ok := reserved[directive]; ok {
+			return fmt.Errorf("%s[%d] %q can't set %s: %s", field, i, line, directive, hint)
+		}
+	}
+	return nil
+}
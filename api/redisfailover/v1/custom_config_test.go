@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateCustomConfig(t *testing.T) {
+	tests := []struct {
+		name           string
+		redisConfig    []string
+		sentinelConfig []string
+		renames        []RedisCommandRename
+		expError       string
+	}{
+		{
+			name:           "Plain custom config and renames are valid",
+			redisConfig:    []string{"maxmemory 100mb", "client-output-buffer-limit normal 0 0 0", `save ""`},
+			sentinelConfig: []string{"down-after-milliseconds 2000", "failover-timeout 3000"},
+			renames:        []RedisCommandRename{{From: "flushall", To: "fa"}, {From: "monitor", To: ""}},
+		},
+		{
+			name:        "A newline injecting a sentinel monitor in the redis config",
+			redisConfig: []string{"maxmemory 100mb", "hz 50\nsentinel monitor other 1.2.3.4 6379 1"},
+			expError:    `redis.customConfig[1] "hz 50\nsentinel monitor other 1.2.3.4 6379 1" must be a single line without control characters`,
+		},
+		{
+			name:           "A carriage return injecting a directive in the sentinel config",
+			sentinelConfig: []string{"down-after-milliseconds 5000\r\nmonitor other 1.2.3.4 6379 1"},
+			expError:       `sentinel.customConfig[0] "down-after-milliseconds 5000\r\nmonitor other 1.2.3.4 6379 1" must be a single line without control characters`,
+		},
+		{
+			name:        "A NUL byte truncating the line",
+			redisConfig: []string{"maxmemory 100mb\x00requirepass x"},
+			expError:    `redis.customConfig[0] "maxmemory 100mb\x00requirepass x" must be a single line without control characters`,
+		},
+		{
+			name:        "Overriding the password",
+			redisConfig: []string{"requirepass hijacked"},
+			expError:    `redis.customConfig[0] "requirepass hijacked" can't set requirepass: the password is taken from spec.auth.secretPath`,
+		},
+		{
+			name:        "Replicating from another host, in upper case and indented",
+			redisConfig: []string{"maxmemory 100mb", "  REPLICAOF 1.2.3.4 6379"},
+			expError:    `redis.customConfig[1] "  REPLICAOF 1.2.3.4 6379" can't set replicaof: the replication is managed by the operator`,
+		},
+		{
+			name:        "Renaming a command in the custom config",
+			redisConfig: []string{"rename-command CONFIG hidden"},
+			expError:    `redis.customConfig[0] "rename-command CONFIG hidden" can't set rename-command: use spec.redis.customCommandRenames`,
+		},
+		{
+			name:           "Monitoring another master from the sentinels",
+			sentinelConfig: []string{"monitor other 1.2.3.4 6379 1"},
+			expError:       `sentinel.customConfig[0] "monitor other 1.2.3.4 6379 1" can't set monitor: the monitored master is managed by the operator`,
+		},
+		{
+			name:           "Setting the quorum in the custom config",
+			sentinelConfig: []string{"quorum 2"},
+			expError:       `sentinel.customConfig[0] "quorum 2" can't set quorum: use spec.sentinel.quorum`,
+		},
+		{
+			name:           "A sentinel.conf line",
+			sentinelConfig: []string{"sentinel monitor other 1.2.3.4 6379 1"},
+			expError:       `sentinel.customConfig[0] "sentinel monitor other 1.2.3.4 6379 1" can't set sentinel: drop the "sentinel" prefix and the master name, as in "down-after-milliseconds 5000"`,
+		},
+		{
+			name:           "A script run by the sentinels",
+			sentinelConfig: []string{"notification-script /tmp/evil.sh"},
+			expError:       `sentinel.customConfig[0] "notification-script /tmp/evil.sh" can't set notification-script: the sentinels don't run scripts`,
+		},
+		{
+			name:     "A rename breaking out of the quotes",
+			renames:  []RedisCommandRename{{From: "flushall\" \"x\"\nslaveof 1.2.3.4 6379\nrename-command \"a", To: "b"}},
+			expError: `redis.customCommandRenames[0].from "flushall\" \"x\"\nslaveof 1.2.3.4 6379\nrename-command \"a" must be a command name`,
+		},
+		{
+			name:     "A rename to a name with spaces",
+			renames:  []RedisCommandRename{{From: "flushall", To: "fa"}, {From: "flushdb", To: "fd requirepass x"}},
+			expError: `redis.customCommandRenames[1].to "fd requirepass x" must be a command name or empty`,
+		},
+		{
+			name:     "Renaming a command the operator runs",
+			renames:  []RedisCommandRename{{From: "Config", To: "hidden"}},
+			expError: `redis.customCommandRenames[0].from "Config" can't be renamed, the operator runs it`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := &RedisFailover{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "namespace"},
+				Spec: RedisFailoverSpec{
+					Redis: RedisSettings{
+						CustomConfig:         test.redisConfig,
+						CustomCommandRenames: test.renames,
+					},
+					Sentinel: SentinelSettings{
+						CustomConfig: test.sentinelConfig,
+					},
+				},
+			}
+
+			err := rf.Validate()
+
+			if test.expError != "" {
+				assert.EqualError(err, test.expError)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// defaultRedisDataDir is where the data volume is mounted when the spec doesn't set the data dir.
+const defaultRedisDataDir = "/data"
+
+// redisReservedDirs are the mount paths of the other volumes the operator generates for redis.
+var redisReservedDirs = []string{"/redis", "/redis-shutdown", "/redis-readiness", "/tmp"}
+
+// RedisDataDir returns where the data volume is mounted in the redis container, it must be the dir
+// the redis image writes its data to.
+func (r *RedisFailover) RedisDataDir() string {
+	if r.Spec.Redis.DataDir == "" {
+		return defaultRedisDataDir
+	}
+	return path.Clean(r.Spec.Redis.DataDir)
+}
+
+// validateDataDir checks the data dir is an absolute path not mounted over the other volumes of redis.
+func (r *RedisFailover) validateDataDir() error {
+	dir := r.Spec.Redis.DataDir
+	if dir == "" {
+		return nil
+	}
+	if !path.IsAbs(dir) {
+		return fmt.Errorf("redis.dataDir must be an absolute path, got %q", dir)
+	}
+	dir = path.Clean(dir)
+	if dir == "/" {
+		return errors.New("redis.dataDir can't be the root of the filesystem")
+	}
+	for _, reserved := range redisReservedDirs {
+		if dir == reserved || strings.HasPrefix(dir, reserved+"/") || strings.HasPrefix(reserved, dir+"/") {
+			return fmt.Errorf("redis.dataDir %s overlaps the %s volume of redis", dir, reserved)
+		}
+	}
+	return nil
+}
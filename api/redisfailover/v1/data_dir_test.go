@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDataDir(t *testing.T) {
+	tests := []struct {
+		name          string
+		dataDir       string
+		expectedDir   string
+		expectedError string
+	}{
+		{
+			name:        "Default",
+			expectedDir: "/data",
+		},
+		{
+			name:        "Custom dir of the image",
+			dataDir:     "/bitnami/redis/data/",
+			expectedDir: "/bitnami/redis/data",
+		},
+		{
+			name:          "Relative dir",
+			dataDir:       "data",
+			expectedError: `redis.dataDir must be an absolute path, got "data"`,
+		},
+		{
+			name:          "Root",
+			dataDir:       "/",
+			expectedError: "redis.dataDir can't be the root of the filesystem",
+		},
+		{
+			name:          "Over the config volume",
+			dataDir:       "/redis/data",
+			expectedError: "redis.dataDir /redis/data overlaps the /redis volume of redis",
+		},
+		{
+			name:        "Sharing a prefix with the config volume",
+			dataDir:     "/redis-data",
+			expectedDir: "/redis-data",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Redis.DataDir = test.dataDir
+
+			err := rf.Validate()
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedDir, rf.RedisDataDir())
+		})
+	}
+}
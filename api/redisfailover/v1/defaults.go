@@ -6,7 +6,11 @@ const (
 	defaultSentinelExporterImage = "quay.io/oliver006/redis_exporter:v1.43.0"
 	defaultExporterImage         = "quay.io/oliver006/redis_exporter:v1.43.0"
 	defaultImage                 = "redis:6.2.6-alpine"
+	defaultProxyImage            = "quay.io/spotahome/redis-operator:v1.2.2"
 	defaultRedisPort             = 6379
+	defaultMaxConcurrentSyncs    = 1
+	defaultStabilizationSeconds  = 60
+	defaultStuckReplicaThreshold = 5
 )
 
 var (
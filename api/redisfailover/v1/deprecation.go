@@ -0,0 +1,76 @@
+package v1
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// DeprecatedField is a field of the spec slated for removal.
+type DeprecatedField struct {
+	// Path is the path of the field from the spec, its segments ending with [] are lists whose items
+	// are scanned.
+	Path string
+	// Message tells what to use instead.
+	Message string
+	// RemovedIn is the API version the field is removed in.
+	RemovedIn string
+}
+
+// DeprecatedFields are the fields of the spec slated for removal, the RFs using them are warned about.
+var DeprecatedFields = []DeprecatedField{
+	{Path: "redis.terminationGracePeriod", Message: "use redis.terminationGracePeriodDuration", RemovedIn: "v2"},
+	{Path: "redis.masterDNS.ttl", Message: "use redis.masterDNS.ttlDuration", RemovedIn: "v2"},
+}
+
+// String returns the path of the field with what to use instead and when it's removed.
+func (d DeprecatedField) String() string {
+	return d.Path + " (" + d.Message + ", removed in " + d.RemovedIn + ")"
+}
+
+// DeprecatedFieldsInUse returns the deprecated fields set in the spec of the RF.
+func (r *RedisFailover) DeprecatedFieldsInUse() []DeprecatedField {
+	return scanDeprecatedFields(r.Spec, DeprecatedFields)
+}
+
+// scanDeprecatedFields returns the fields of the registry set in the spec. The spec is scanned in its
+// JSON form, so the paths are the ones the users write and the empty fields are not set.
+func scanDeprecatedFields(spec interface{}, registry []DeprecatedField) []DeprecatedField {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+
+	inUse := []DeprecatedField{}
+	for _, field := range registry {
+		if pathSet(doc, strings.Split(field.Path, ".")) {
+			inUse = append(inUse, field)
+		}
+	}
+	return inUse
+}
+
+// pathSet returns true when the path is set in the JSON value, in any item of its lists.
+func pathSet(value interface{}, path []string) bool {
+	if len(path) == 0 {
+		return value != nil
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	segment := path[0]
+	if !strings.HasSuffix(segment, "[]") {
+		return pathSet(object[segment], path[1:])
+	}
+	items, _ := object[strings.TrimSuffix(segment, "[]")].([]interface{})
+	for _, item := range items {
+		if pathSet(item, path[1:]) {
+			return true
+		}
+	}
+	return false
+}
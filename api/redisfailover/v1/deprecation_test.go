@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestScanDeprecatedFields(t *testing.T) {
+	registry := []DeprecatedField{
+		{Path: "redis.terminationGracePeriod", Message: "use redis.terminationGracePeriodDuration", RemovedIn: "v2"},
+		{Path: "redis.masterDNS.ttl", Message: "use redis.masterDNS.ttlDuration", RemovedIn: "v2"},
+		{Path: "redis.externalNodes[].port", Message: "use the port of the sentinels", RemovedIn: "v2"},
+		{Path: "redis.extraContainers[].env[].value", Message: "use valueFrom", RemovedIn: "v3"},
+	}
+
+	tests := []struct {
+		name     string
+		setSpec  func(spec *RedisFailoverSpec)
+		expPaths []string
+	}{
+		{
+			name:     "No deprecated field",
+			setSpec:  func(spec *RedisFailoverSpec) {},
+			expPaths: []string{},
+		},
+		{
+			name: "Top level field",
+			setSpec: func(spec *RedisFailoverSpec) {
+				spec.Redis.TerminationGracePeriodSeconds = 30
+			},
+			expPaths: []string{"redis.terminationGracePeriod"},
+		},
+		{
+			name: "Nested field",
+			setSpec: func(spec *RedisFailoverSpec) {
+				spec.Redis.MasterDNS = &RedisMasterDNS{Hostname: "redis.example.com", TTL: 30}
+			},
+			expPaths: []string{"redis.masterDNS.ttl"},
+		},
+		{
+			name: "Nested field not set",
+			setSpec: func(spec *RedisFailoverSpec) {
+				spec.Redis.MasterDNS = &RedisMasterDNS{Hostname: "redis.example.com"}
+			},
+			expPaths: []string{},
+		},
+		{
+			name: "Field of a list item",
+			setSpec: func(spec *RedisFailoverSpec) {
+				spec.Redis.ExternalNodes = []RedisExternalNode{{Host: "10.0.0.1"}, {Host: "10.0.0.2", Port: "6379"}}
+			},
+			expPaths: []string{"redis.externalNodes[].port"},
+		},
+		{
+			name: "Field of no list item",
+			setSpec: func(spec *RedisFailoverSpec) {
+				spec.Redis.ExternalNodes = []RedisExternalNode{{Host: "10.0.0.1"}, {Host: "10.0.0.2"}}
+			},
+			expPaths: []string{},
+		},
+		{
+			name: "Field of a nested list item",
+			setSpec: func(spec *RedisFailoverSpec) {
+				spec.Redis.ExtraContainers = []corev1.Container{
+					{Name: "sidecar"},
+					{Name: "agent", Env: []corev1.EnvVar{{Name: "MODE", Value: "agent"}}},
+				}
+			},
+			expPaths: []string{"redis.extraContainers[].env[].value"},
+		},
+		{
+			name: "Several fields in the registry order",
+			setSpec: func(spec *RedisFailoverSpec) {
+				spec.Redis.MasterDNS = &RedisMasterDNS{Hostname: "redis.example.com", TTL: 30}
+				spec.Redis.TerminationGracePeriodSeconds = 30
+			},
+			expPaths: []string{"redis.terminationGracePeriod", "redis.masterDNS.ttl"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			test.setSpec(&rf.Spec)
+
+			paths := []string{}
+			for _, field := range scanDeprecatedFields(rf.Spec, registry) {
+				paths = append(paths, field.Path)
+			}
+			assert.Equal(test.expPaths, paths)
+		})
+	}
+}
+
+func TestDeprecatedFieldsInUse(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRedisFailover("test", nil)
+	rf.Spec.Redis.MasterDNS = &RedisMasterDNS{Hostname: "redis.example.com", TTL: 30}
+	assert.NoError(rf.Validate())
+
+	fields := rf.DeprecatedFieldsInUse()
+	if assert.Len(fields, 1) {
+		assert.Equal("redis.masterDNS.ttl (use redis.masterDNS.ttlDuration, removed in v2)", fields[0].String())
+	}
+}
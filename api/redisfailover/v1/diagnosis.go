@@ -0,0 +1,19 @@
+package v1
+
+import "strings"
+
+// DiagnoseAnnotation requests the operator to run the memory and latency doctors of the redis pods of the
+// RedisFailover. Its value is the redis pod to diagnose, or DiagnoseAll, optionally followed by # and an
+// identifier to diagnose the same pods again. Every value is diagnosed once.
+const DiagnoseAnnotation = "databases.spotahome.com/diagnose"
+
+// DiagnoseAll diagnoses all the redis pods.
+const DiagnoseAll = "all"
+
+// DiagnoseRequest returns the diagnosis requested on the RedisFailover and the pod it targets, both empty
+// when there isn't any.
+func (r *RedisFailover) DiagnoseRequest() (string, string) {
+	request := strings.TrimSpace(r.Annotations[DiagnoseAnnotation])
+	target, _, _ := strings.Cut(request, "#")
+	return request, strings.TrimSpace(target)
+}
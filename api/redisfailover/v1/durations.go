@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	maxTerminationGracePeriod = time.Hour
+	maxMasterDNSTTL           = 24 * time.Hour
+)
+
+// GetTerminationGracePeriod returns the termination grace period of the redis pods, 0 when it's not set.
+// The duration field takes precedence over the deprecated one in seconds.
+func (r *RedisSettings) GetTerminationGracePeriod() time.Duration {
+	if r.TerminationGracePeriodDuration != nil {
+		return r.TerminationGracePeriodDuration.Duration
+	}
+	return time.Duration(r.TerminationGracePeriodSeconds) * time.Second
+}
+
+// GetTTL returns the TTL of the master DNS records, 0 when it's not set. The duration field takes
+// precedence over the deprecated one in seconds.
+func (r *RedisMasterDNS) GetTTL() time.Duration {
+	if r.TTLDuration != nil {
+		return r.TTLDuration.Duration
+	}
+	return time.Duration(r.TTL) * time.Second
+}
+
+// GetStabilizationWindow returns how long the disruptive actions are held after a failover, 0 when
+// it's not set.
+func (f *FailoverSettings) GetStabilizationWindow() time.Duration {
+	if f.StabilizationSeconds == nil {
+		return 0
+	}
+	return time.Duration(*f.StabilizationSeconds) * time.Second
+}
+
+// convertDeprecatedDurations sets the duration fields from the deprecated fields in seconds, so the
+// objects created before the duration fields existed keep working unchanged. Both forms can be set
+// as long as they agree.
+func (r *RedisFailover) convertDeprecatedDurations() error {
+	redis := &r.Spec.Redis
+	grace, err := convertSeconds("terminationGracePeriod", redis.TerminationGracePeriodSeconds, redis.TerminationGracePeriodDuration)
+	if err != nil {
+		return err
+	}
+	redis.TerminationGracePeriodDuration = grace
+
+	if redis.MasterDNS != nil {
+		ttl, err := convertSeconds("masterDNS.ttl", int64(redis.MasterDNS.TTL), redis.MasterDNS.TTLDuration)
+		if err != nil {
+			return err
+		}
+		redis.MasterDNS.TTLDuration = ttl
+	}
+	return nil
+}
+
+// validateDurations checks the duration fields are whole seconds within their allowed ranges.
+func (r *RedisFailover) validateDurations() error {
+	if err := validateDuration("terminationGracePeriodDuration", r.Spec.Redis.TerminationGracePeriodDuration, maxTerminationGracePeriod); err != nil {
+		return err
+	}
+	if r.Spec.Redis.MasterDNS != nil {
+		if err := validateDuration("masterDNS.ttlDuration", r.Spec.Redis.MasterDNS.TTLDuration, maxMasterDNSTTL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertSeconds returns the duration field of a deprecated field in seconds.
+func convertSeconds(field string, seconds int64, duration *metav1.Duration) (*metav1.Duration, error) {
+	if seconds == 0 {
+		return duration, nil
+	}
+	converted := durationFromSeconds(seconds)
+	if duration != nil && duration.Duration != converted.Duration {
+		return nil, fmt.Errorf("%s is %ds but %sDuration is %s, set only %sDuration", field, seconds, field, duration.Duration, field)
+	}
+	return converted, nil
+}
+
+// durationFromSeconds converts a value of a deprecated field in seconds to its duration field.
+func durationFromSeconds(seconds int64) *metav1.Duration {
+	return &metav1.Duration{Duration: time.Duration(seconds) * time.Second}
+}
+
+func validateDuration(field string, duration *metav1.Duration, max time.Duration) error {
+	if duration == nil {
+		return nil
+	}
+	d := duration.Duration
+	switch {
+	case d < time.Second:
+		return fmt.Errorf("%s must be at least 1s, got %s", field, d)
+	case d > max:
+		return fmt.Errorf("%s can't be higher than %s, got %s", field, max, d)
+	case d%time.Second != 0:
+		return fmt.Errorf("%s must be a whole number of seconds, got %s", field, d)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateDurations(t *testing.T) {
+	tests := []struct {
+		name            string
+		redis           RedisSettings
+		expGracePeriod  *metav1.Duration
+		expMasterDNSTTL *metav1.Duration
+		expectedError   string
+	}{
+		{
+			name: "Unset durations are kept unset",
+		},
+		{
+			name: "Deprecated seconds are converted",
+			redis: RedisSettings{
+				TerminationGracePeriodSeconds: 60,
+				MasterDNS:                     &RedisMasterDNS{Hostname: "redis.example.com", TTL: 30},
+			},
+			expGracePeriod:  &metav1.Duration{Duration: time.Minute},
+			expMasterDNSTTL: &metav1.Duration{Duration: 30 * time.Second},
+		},
+		{
+			name: "Durations are kept",
+			redis: RedisSettings{
+				TerminationGracePeriodDuration: &metav1.Duration{Duration: 5 * time.Minute},
+				MasterDNS:                      &RedisMasterDNS{Hostname: "redis.example.com", TTLDuration: &metav1.Duration{Duration: time.Hour}},
+			},
+			expGracePeriod:  &metav1.Duration{Duration: 5 * time.Minute},
+			expMasterDNSTTL: &metav1.Duration{Duration: time.Hour},
+		},
+		{
+			name: "Both forms can be set when they agree",
+			redis: RedisSettings{
+				TerminationGracePeriodSeconds:  60,
+				TerminationGracePeriodDuration: &metav1.Duration{Duration: time.Minute},
+			},
+			expGracePeriod: &metav1.Duration{Duration: time.Minute},
+		},
+		{
+			name: "Both forms can't disagree",
+			redis: RedisSettings{
+				TerminationGracePeriodSeconds:  60,
+				TerminationGracePeriodDuration: &metav1.Duration{Duration: 60 * time.Millisecond},
+			},
+			expectedError: "terminationGracePeriod is 60s but terminationGracePeriodDuration is 60ms, set only terminationGracePeriodDuration",
+		},
+		{
+			name: "Durations below a second are rejected",
+			redis: RedisSettings{
+				TerminationGracePeriodDuration: &metav1.Duration{Duration: 500 * time.Millisecond},
+			},
+			expectedError: "terminationGracePeriodDuration must be at least 1s, got 500ms",
+		},
+		{
+			name: "Negative deprecated seconds are rejected",
+			redis: RedisSettings{
+				TerminationGracePeriodSeconds: -1,
+			},
+			expectedError: "terminationGracePeriodDuration must be at least 1s, got -1s",
+		},
+		{
+			name: "Durations over the maximum are rejected",
+			redis: RedisSettings{
+				MasterDNS: &RedisMasterDNS{Hostname: "redis.example.com", TTLDuration: &metav1.Duration{Duration: 48 * time.Hour}},
+			},
+			expectedError: "masterDNS.ttlDuration can't be higher than 24h0m0s, got 48h0m0s",
+		},
+		{
+			name: "Durations with fractions of seconds are rejected",
+			redis: RedisSettings{
+				MasterDNS: &RedisMasterDNS{Hostname: "redis.example.com", TTLDuration: &metav1.Duration{Duration: 1500 * time.Millisecond}},
+			},
+			expectedError: "masterDNS.ttlDuration must be a whole number of seconds, got 1.5s",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Redis = test.redis
+
+			err := rf.Validate()
+
+			if test.expectedError != "" {
+				assert.EqualError(err, test.expectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expGracePeriod, rf.Spec.Redis.TerminationGracePeriodDuration)
+			if rf.Spec.Redis.MasterDNS != nil {
+				assert.Equal(test.expMasterDNSTTL, rf.Spec.Redis.MasterDNS.TTLDuration)
+			}
+		})
+	}
+}
+
+func TestDeprecatedDurationsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name           string
+		spec           string
+		expGracePeriod time.Duration
+		expTTL         time.Duration
+	}{
+		{
+			name:           "Object using the deprecated fields",
+			spec:           `{"redis":{"terminationGracePeriod":45,"masterDNS":{"hostname":"redis.example.com","ttl":300}}}`,
+			expGracePeriod: 45 * time.Second,
+			expTTL:         5 * time.Minute,
+		},
+		{
+			name:           "Object using the duration fields",
+			spec:           `{"redis":{"terminationGracePeriodDuration":"45s","masterDNS":{"hostname":"redis.example.com","ttlDuration":"5m"}}}`,
+			expGracePeriod: 45 * time.Second,
+			expTTL:         5 * time.Minute,
+		},
+		{
+			name:           "Object using both fields",
+			spec:           `{"redis":{"terminationGracePeriod":45,"terminationGracePeriodDuration":"45s","masterDNS":{"hostname":"redis.example.com","ttl":300,"ttlDuration":"300s"}}}`,
+			expGracePeriod: 45 * time.Second,
+			expTTL:         5 * time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			if !assert.NoError(json.Unmarshal([]byte(test.spec), &rf.Spec)) {
+				return
+			}
+			original := rf.Spec.DeepCopy()
+
+			// The defaulting is run on every reconciliation, on the same object.
+			for i := 0; i < 2; i++ {
+				if !assert.NoError(rf.Validate()) {
+					return
+				}
+				assert.Equal(test.expGracePeriod, rf.Spec.Redis.GetTerminationGracePeriod())
+				assert.Equal(test.expTTL, rf.Spec.Redis.MasterDNS.GetTTL())
+			}
+
+			// The deprecated fields are kept as they were, so the object keeps working unchanged.
+			assert.Equal(original.Redis.TerminationGracePeriodSeconds, rf.Spec.Redis.TerminationGracePeriodSeconds)
+			assert.Equal(original.Redis.MasterDNS.TTL, rf.Spec.Redis.MasterDNS.TTL)
+
+			// The defaulted object survives a serialization round trip.
+			data, err := json.Marshal(rf.Spec)
+			assert.NoError(err)
+			got := generateRedisFailover("test", nil)
+			assert.NoError(json.Unmarshal(data, &got.Spec))
+			assert.NoError(got.Validate())
+			assert.Equal(rf.Spec.Redis.TerminationGracePeriodSeconds, got.Spec.Redis.TerminationGracePeriodSeconds)
+			assert.Equal(rf.Spec.Redis.TerminationGracePeriodDuration, got.Spec.Redis.TerminationGracePeriodDuration)
+			assert.Equal(rf.Spec.Redis.MasterDNS, got.Spec.Redis.MasterDNS)
+		})
+	}
+}
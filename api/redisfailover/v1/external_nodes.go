@@ -0,0 +1,6 @@
+package v1
+
+// ExternalNodesEnabled returns true when the redis are external nodes the operator only deploys sentinels for.
+func (r *RedisFailover) ExternalNodesEnabled() bool {
+	return len(r.Spec.Redis.ExternalNodes) > 0
+}
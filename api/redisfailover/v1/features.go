@@ -0,0 +1,18 @@
+package v1
+
+import "strings"
+
+// FeatureGateAnnotationPrefix prefixes the annotations overriding a feature gate of the operator on the
+// RedisFailover, as in "databases.spotahome.com/feature.use-evictions: true".
+const FeatureGateAnnotationPrefix = "databases.spotahome.com/feature."
+
+// FeatureGateOverrides returns the values of the feature gates overridden on the RedisFailover, by gate.
+func (r *RedisFailover) FeatureGateOverrides() map[string]string {
+	overrides := map[string]string{}
+	for key, value := range r.Annotations {
+		if gate := strings.TrimPrefix(key, FeatureGateAnnotationPrefix); gate != key {
+			overrides[gate] = value
+		}
+	}
+	return overrides
+}
@@ -0,0 +1,20 @@
+package v1
+
+import "errors"
+
+// ReadOnlyRootFilesystem returns true when the containers run with a read-only root filesystem as the
+// user of the redis image.
+func (r *RedisFailover) ReadOnlyRootFilesystem() bool {
+	return r.Spec.Hardening != nil && r.Spec.Hardening.ReadOnlyRootFilesystem
+}
+
+// validateHardening checks the pods can run under the restricted pod security.
+func (r *RedisFailover) validateHardening() error {
+	if !r.ReadOnlyRootFilesystem() {
+		return nil
+	}
+	if r.Spec.Redis.NodeTuningInitContainer {
+		return errors.New("hardening.readOnlyRootFilesystem can't be used with redis.nodeTuningInitContainer, the node tuning runs privileged")
+	}
+	return nil
+}
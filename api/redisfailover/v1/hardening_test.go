@@ -0,0 +1,46 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateHardening(t *testing.T) {
+	tests := []struct {
+		name          string
+		hardening     *HardeningSettings
+		nodeTuning    bool
+		expectedError string
+	}{
+		{
+			name:       "Disabled",
+			nodeTuning: true,
+		},
+		{
+			name:      "Read-only root filesystem",
+			hardening: &HardeningSettings{ReadOnlyRootFilesystem: true},
+		},
+		{
+			name:          "Read-only root filesystem with the node tuning",
+			hardening:     &HardeningSettings{ReadOnlyRootFilesystem: true},
+			nodeTuning:    true,
+			expectedError: "hardening.readOnlyRootFilesystem can't be used with redis.nodeTuningInitContainer, the node tuning runs privileged",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Hardening = test.hardening
+			rf.Spec.Redis.NodeTuningInitContainer = test.nodeTuning
+
+			err := rf.Validate()
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package v1
+
+// Health phases reported on the RedisFailover status
+const (
+	// HealthPhaseHealthy is set when all the pods are ready and the operator is not holding any action.
+	HealthPhaseHealthy = "Healthy"
+	// HealthPhaseProgressing is set while the RedisFailover converges: pods not ready yet, pods
+	// pending a restart or disruptive actions held after a failover.
+	HealthPhaseProgressing = "Progressing"
+	// HealthPhaseDegraded is set when the RedisFailover needs attention: no master is promoted or
+	// the operator can't reconcile it.
+	HealthPhaseDegraded = "Degraded"
+)
+
+// HealthPhases are all the health phases, new phases are only appended.
+var HealthPhases = []string{HealthPhaseHealthy, HealthPhaseProgressing, HealthPhaseDegraded}
+
+// HealthReport is the summary of the RedisFailover health for the GitOps tools and the scripts, so
+// they don't have to interpret the conditions. It's written in the same status update as the
+// conditions it's computed from. Its fields and phases are only added, never removed, renamed or
+// retyped, the schema is committed in testdata/health.schema.json.
+type HealthReport struct {
+	// Phase is one of Healthy, Progressing or Degraded.
+	Phase string `json:"phase"`
+	// Ready is true when all the redis and sentinel pods are ready.
+	Ready bool `json:"ready"`
+	// Message explains the phase.
+	Message string `json:"message"`
+	// ObservedGeneration is the RedisFailover generation the report was computed for.
+	ObservedGeneration int64 `json:"observedGeneration"`
+	// RestartPending is true while some redis pods are not running the statefulset revision.
+	RestartPending bool `json:"restartPending"`
+}
@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// healthSchema is the part of the JSON schema the health report is checked against.
+type healthSchema struct {
+	Required   []string `json:"required"`
+	Properties map[string]struct {
+		Type string   `json:"type"`
+		Enum []string `json:"enum"`
+	} `json:"properties"`
+}
+
+// jsonSchemaType returns the JSON schema type a field is encoded to.
+func jsonSchemaType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "integer"
+	}
+	return kind.String()
+}
+
+// TestHealthReportSchemaCompatibility checks the health report is backward compatible with the committed
+// schema. A field or phase missing from the schema must be added to it, the committed ones must never change.
+func TestHealthReportSchemaCompatibility(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "health.schema.json"))
+	require.NoError(t, err)
+	schema := healthSchema{}
+	require.NoError(t, json.Unmarshal(content, &schema))
+
+	fields := map[string]string{}
+	rt := reflect.TypeOf(HealthReport{})
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		assert.NotContains(t, field.Tag.Get("json"), "omitempty", "health report field %s must always be set", name)
+		fields[name] = jsonSchemaType(field.Type.Kind())
+	}
+
+	for name, property := range schema.Properties {
+		if assert.Contains(t, fields, name, "health report field %s was removed or renamed", name) {
+			assert.Equal(t, property.Type, fields[name], "health report field %s was retyped", name)
+		}
+	}
+	for _, name := range schema.Required {
+		assert.Contains(t, fields, name, "required health report field %s was removed or renamed", name)
+	}
+	for name := range fields {
+		assert.Contains(t, schema.Properties, name, "health report field %s must be added to testdata/health.schema.json", name)
+	}
+
+	phases := schema.Properties["phase"].Enum
+	for _, phase := range phases {
+		assert.Contains(t, HealthPhases, phase, "health phase %s was removed or renamed", phase)
+	}
+	for _, phase := range HealthPhases {
+		assert.Contains(t, phases, phase, "health phase %s must be added to testdata/health.schema.json", phase)
+	}
+}
+
+func TestHealthReportEncodesAllFields(t *testing.T) {
+	content, err := json.Marshal(HealthReport{Phase: HealthPhaseProgressing})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"phase":"Progressing","ready":false,"message":"","observedGeneration":0,"restartPending":false}`, string(content))
+}
@@ -0,0 +1,18 @@
+package v1
+
+import "errors"
+
+// ImmutableConfigEnabled returns true when the redis and the sentinel configurations are written in
+// immutable ConfigMaps named with the hash of their content.
+func (r *RedisFailover) ImmutableConfigEnabled() bool {
+	return r.Spec.ImmutableConfig
+}
+
+// validateImmutableConfig checks the immutable configuration is not used with the zero downtime reload,
+// which waits for the new configuration to be synced in the running pods.
+func (r *RedisFailover) validateImmutableConfig() error {
+	if r.ImmutableConfigEnabled() && r.ZeroDowntimeReloadEnabled() {
+		return errors.New("immutableConfig can't be used with zeroDowntimeReload, the configuration of the running pods is never updated")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateImmutableConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		immutable     bool
+		reload        bool
+		expectedError string
+	}{
+		{
+			name: "Disabled",
+		},
+		{
+			name:      "Enabled",
+			immutable: true,
+		},
+		{
+			name:          "Enabled with the zero downtime reload",
+			immutable:     true,
+			reload:        true,
+			expectedError: "immutableConfig can't be used with zeroDowntimeReload, the configuration of the running pods is never updated",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.ImmutableConfig = test.immutable
+			rf.Spec.Redis.ZeroDowntimeReload.Enabled = test.reload
+
+			err := rf.Validate()
+
+			if test.expectedError != "" {
+				assert.EqualError(err, test.expectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.immutable, rf.ImmutableConfigEnabled())
+		})
+	}
+}
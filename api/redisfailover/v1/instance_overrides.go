@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// reservedInstanceDirectives are set by the fields of the instance overrides rather than their custom config.
+var reservedInstanceDirectives = map[string]string{
+	"replica-priority": "use replicaPriority",
+	"slave-priority":   "use replicaPriority",
+}
+
+// RedisInstanceOverride returns the override of the redis pod of the ordinal, false when it has none.
+func (r *RedisFailover) RedisInstanceOverride(ordinal int) (RedisInstanceOverride, bool) {
+	override, ok := r.Spec.Redis.InstanceOverrides[strconv.Itoa(ordinal)]
+	return override, ok
+}
+
+// RedisInstancePromotable returns false when the redis pod of the ordinal has a replicaPriority of 0, it's
+// never promoted.
+func (r *RedisFailover) RedisInstancePromotable(ordinal int) bool {
+	override, ok := r.RedisInstanceOverride(ordinal)
+	return !ok || override.ReplicaPriority == nil || *override.ReplicaPriority != 0
+}
+
+// NonPromotableRedisOrdinals returns the sorted ordinals of the redis pods with a replicaPriority of 0.
+func (r *RedisFailover) NonPromotableRedisOrdinals() []int {
+	ordinals := []int{}
+	for key := range r.Spec.Redis.InstanceOverrides {
+		ordinal, err := strconv.Atoi(key)
+		if err == nil && !r.RedisInstancePromotable(ordinal) {
+			ordinals = append(ordinals, ordinal)
+		}
+	}
+	sort.Ints(ordinals)
+	return ordinals
+}
+
+// RedisInstanceCounted returns false when the redis pod of the ordinal is left out of the healthy
+// replicas, it's never promoted and redis.countNonPromotable is not set.
+func (r *RedisFailover) RedisInstanceCounted(ordinal int) bool {
+	return r.Spec.Redis.CountNonPromotable || r.RedisInstancePromotable(ordinal)
+}
+
+// validateInstanceOverrides checks the overrides are keyed by the ordinals of the redis replicas, and
+// that a pod is left to promote. The replicas must be defaulted.
+func (r *RedisFailover) validateInstanceOverrides() error {
+	if len(r.Spec.Redis.InstanceOverrides) == 0 {
+		return nil
+	}
+	if r.ExternalNodesEnabled() {
+		return errors.New("redis.instanceOverrides can't be used with externalNodes, their pods are not managed by the operator")
+	}
+	keys := make([]string, 0, len(r.Spec.Redis.InstanceOverrides))
+	for key := range r.Spec.Redis.InstanceOverrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field := fmt.Sprintf("redis.instanceOverrides[%s]", key)
+		ordinal, err := strconv.Atoi(key)
+		if err != nil || strconv.Itoa(ordinal) != key {
+			return fmt.Errorf("%s: the key must be the ordinal of a redis pod", field)
+		}
+		if ordinal < 0 || ordinal >= int(r.Spec.Redis.Replicas) {
+			return fmt.Errorf("%s: the ordinal must be lower than the %d redis replicas", field, r.Spec.Redis.Replicas)
+		}
+
+		override := r.Spec.Redis.InstanceOverrides[key]
+		if override.ReplicaPriority != nil {
+			if *override.ReplicaPriority < 0 {
+				return fmt.Errorf("%s.replicaPriority can't be negative, got %d", field, *override.ReplicaPriority)
+			}
+			if r.Bootstrapping() {
+				return fmt.Errorf("%s.replicaPriority can't be set with a BootstrapNode, the redis pods are not promoted while bootstrapping", field)
+			}
+		}
+		if err := validateCustomConfigLines(field+".customConfig", override.CustomConfig, reservedRedisDirectives); err != nil {
+			return err
+		}
+		if err := validateCustomConfigLines(field+".customConfig", override.CustomConfig, reservedInstanceDirectives); err != nil {
+			return err
+		}
+		for label, value := range override.Labels {
+			if errs := validation.IsQualifiedName(label); len(errs) > 0 {
+				return fmt.Errorf("%s.labels key %q is invalid: %s", field, label, strings.Join(errs, ", "))
+			}
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				return fmt.Errorf("%s.labels %q value %q is invalid: %s", field, label, value, strings.Join(errs, ", "))
+			}
+		}
+	}
+	if len(r.NonPromotableRedisOrdinals()) >= int(r.Spec.Redis.Replicas) {
+		return errors.New("redis.instanceOverrides can't set a replicaPriority of 0 on all the redis pods, one must be left to promote")
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateInstanceOverrides(t *testing.T) {
+	zero, one, negative := int32(0), int32(1), int32(-1)
+
+	tests := []struct {
+		name          string
+		overrides     map[string]RedisInstanceOverride
+		bootstrapNode *BootstrapSettings
+		expectedError string
+	}{
+		{
+			name: "No override",
+		},
+		{
+			name: "One replica excluded from failover for analytics",
+			overrides: map[string]RedisInstanceOverride{
+				"2": {ReplicaPriority: &zero, CustomConfig: []string{"appendonly no"}, Labels: map[string]string{"workload": "analytics"}},
+			},
+		},
+		{
+			name:          "Key that is not an ordinal",
+			overrides:     map[string]RedisInstanceOverride{"rfr-test-2": {}},
+			expectedError: "redis.instanceOverrides[rfr-test-2]: the key must be the ordinal of a redis pod",
+		},
+		{
+			name:          "Key with a leading zero",
+			overrides:     map[string]RedisInstanceOverride{"02": {}},
+			expectedError: "redis.instanceOverrides[02]: the key must be the ordinal of a redis pod",
+		},
+		{
+			name:          "Ordinal out of the replicas",
+			overrides:     map[string]RedisInstanceOverride{"3": {}},
+			expectedError: "redis.instanceOverrides[3]: the ordinal must be lower than the 3 redis replicas",
+		},
+		{
+			name:          "Negative ordinal",
+			overrides:     map[string]RedisInstanceOverride{"-1": {}},
+			expectedError: "redis.instanceOverrides[-1]: the ordinal must be lower than the 3 redis replicas",
+		},
+		{
+			name:          "Negative priority",
+			overrides:     map[string]RedisInstanceOverride{"1": {ReplicaPriority: &negative}},
+			expectedError: "redis.instanceOverrides[1].replicaPriority can't be negative, got -1",
+		},
+		{
+			name:          "Priority while bootstrapping",
+			overrides:     map[string]RedisInstanceOverride{"1": {ReplicaPriority: &one}},
+			bootstrapNode: &BootstrapSettings{Host: "127.0.0.1"},
+			expectedError: "redis.instanceOverrides[1].replicaPriority can't be set with a BootstrapNode, the redis pods are not promoted while bootstrapping",
+		},
+		{
+			name:          "Priority in the custom config",
+			overrides:     map[string]RedisInstanceOverride{"1": {CustomConfig: []string{"replica-priority 0"}}},
+			expectedError: `redis.instanceOverrides[1].customConfig[0] "replica-priority 0" can't set replica-priority: use replicaPriority`,
+		},
+		{
+			name:          "Reserved directive in the custom config",
+			overrides:     map[string]RedisInstanceOverride{"1": {CustomConfig: []string{"appendonly no", "replicaof 10.0.0.1 6379"}}},
+			expectedError: `redis.instanceOverrides[1].customConfig[1] "replicaof 10.0.0.1 6379" can't set replicaof: the replication is managed by the operator`,
+		},
+		{
+			name:          "Invalid label",
+			overrides:     map[string]RedisInstanceOverride{"1": {Labels: map[string]string{"workload": "heavy scans"}}},
+			expectedError: `redis.instanceOverrides[1].labels "workload" value "heavy scans" is invalid: a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+		},
+		{
+			name: "No pod left to promote",
+			overrides: map[string]RedisInstanceOverride{
+				"0": {ReplicaPriority: &zero},
+				"1": {ReplicaPriority: &zero},
+				"2": {ReplicaPriority: &zero},
+			},
+			expectedError: "redis.instanceOverrides can't set a replicaPriority of 0 on all the redis pods, one must be left to promote",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rf := generateRedisFailover("test", test.bootstrapNode)
+			rf.Spec.Redis.InstanceOverrides = test.overrides
+
+			err := rf.Validate()
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNonPromotableRedisOrdinals(t *testing.T) {
+	assert := assert.New(t)
+
+	zero, hundred := int32(0), int32(100)
+	rf := generateRedisFailover("test", nil)
+	rf.Spec.Redis.Replicas = 5
+	rf.Spec.Redis.InstanceOverrides = map[string]RedisInstanceOverride{
+		"4": {ReplicaPriority: &zero},
+		"1": {ReplicaPriority: &zero},
+		"2": {ReplicaPriority: &hundred},
+		"3": {CustomConfig: []string{"appendonly no"}},
+	}
+
+	assert.Equal([]int{1, 4}, rf.NonPromotableRedisOrdinals())
+	assert.True(rf.RedisInstancePromotable(0))
+	assert.False(rf.RedisInstancePromotable(1))
+	assert.True(rf.RedisInstancePromotable(2))
+	assert.True(rf.RedisInstancePromotable(3))
+	assert.False(rf.RedisInstancePromotable(4))
+
+	// The pods never promoted are only counted in the healthy replicas with countNonPromotable.
+	assert.True(rf.RedisInstanceCounted(0))
+	assert.False(rf.RedisInstanceCounted(1))
+	rf.Spec.Redis.CountNonPromotable = true
+	assert.True(rf.RedisInstanceCounted(1))
+}
@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// RedisIPFamilyPolicy returns the ipFamilyPolicy of the Services of the RF, nil when the RF doesn't set
+// it and the cluster default applies.
+func (r *RedisFailover) RedisIPFamilyPolicy() *corev1.IPFamilyPolicyType {
+	if r.Spec.Redis.Service == nil {
+		return nil
+	}
+	return r.Spec.Redis.Service.IPFamilyPolicy
+}
+
+// RedisIPFamilies returns the ipFamilies of the Services of the RF, nil when the RF doesn't set them.
+func (r *RedisFailover) RedisIPFamilies() []corev1.IPFamily {
+	if r.Spec.Redis.Service == nil {
+		return nil
+	}
+	return r.Spec.Redis.Service.IPFamilies
+}
+
+// PreferredPodIP returns the IP of the preferredIPFamily of the pod, or its primary IP when the RF
+// prefers no family or the pod has no IP of the family.
+func (r *RedisFailover) PreferredPodIP(status corev1.PodStatus) string {
+	if family := r.Spec.Redis.PreferredIPFamily; family != "" {
+		for _, podIP := range status.PodIPs {
+			if ipFamily(podIP.IP) == family {
+				return podIP.IP
+			}
+		}
+	}
+	return status.PodIP
+}
+
+// ipFamily returns the family of the IP, empty when it's not one.
+func ipFamily(ip string) corev1.IPFamily {
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return ""
+	case parsed.To4() != nil:
+		return corev1.IPv4Protocol
+	default:
+		return corev1.IPv6Protocol
+	}
+}
+
+// validateIPFamilies checks the ipFamilyPolicy, the ipFamilies and the preferredIPFamily are values
+// kubernetes knows, and the families fit the policy.
+func (r *RedisFailover) validateIPFamilies() error {
+	if family := r.Spec.Redis.PreferredIPFamily; family != "" && family != corev1.IPv4Protocol && family != corev1.IPv6Protocol {
+		return fmt.Errorf("redis.preferredIPFamily must be %s or %s, got %q", corev1.IPv4Protocol, corev1.IPv6Protocol, family)
+	}
+
+	families := r.RedisIPFamilies()
+	if len(families) > 2 {
+		return fmt.Errorf("redis.service.ipFamilies can't have more than 2 families, got %d", len(families))
+	}
+	for i, family := range families {
+		if family != corev1.IPv4Protocol && family != corev1.IPv6Protocol {
+			return fmt.Errorf("redis.service.ipFamilies must be %s or %s, got %q", corev1.IPv4Protocol, corev1.IPv6Protocol, family)
+		}
+		if i > 0 && family == families[0] {
+			return fmt.Errorf("redis.service.ipFamilies has %s twice", family)
+		}
+	}
+
+	policy := r.RedisIPFamilyPolicy()
+	if policy == nil {
+		return nil
+	}
+	switch *policy {
+	case corev1.IPFamilyPolicySingleStack:
+		if len(families) > 1 {
+			return fmt.Errorf("redis.service.ipFamilies can't have 2 families with the %s ipFamilyPolicy", *policy)
+		}
+	case corev1.IPFamilyPolicyPreferDualStack, corev1.IPFamilyPolicyRequireDualStack:
+	default:
+		return fmt.Errorf("redis.service.ipFamilyPolicy must be %s, %s or %s, got %q", corev1.IPFamilyPolicySingleStack, corev1.IPFamilyPolicyPreferDualStack, corev1.IPFamilyPolicyRequireDualStack, *policy)
+	}
+	return nil
+}
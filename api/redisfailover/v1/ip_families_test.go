@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestValidateIPFamilies(t *testing.T) {
+	singleStack := corev1.IPFamilyPolicySingleStack
+	requireDualStack := corev1.IPFamilyPolicyRequireDualStack
+	unknown := corev1.IPFamilyPolicyType("DualStack")
+
+	tests := []struct {
+		name      string
+		service   *RedisServiceSettings
+		preferred corev1.IPFamily
+		expErr    bool
+	}{
+		{
+			name: "No service settings",
+		},
+		{
+			name:      "Dual-stack services preferring IPv6",
+			service:   &RedisServiceSettings{IPFamilyPolicy: &requireDualStack, IPFamilies: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}},
+			preferred: corev1.IPv6Protocol,
+		},
+		{
+			name:    "A single-stack IPv6 service",
+			service: &RedisServiceSettings{IPFamilyPolicy: &singleStack, IPFamilies: []corev1.IPFamily{corev1.IPv6Protocol}},
+		},
+		{
+			name:    "Two families with the SingleStack policy",
+			service: &RedisServiceSettings{IPFamilyPolicy: &singleStack, IPFamilies: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}},
+			expErr:  true,
+		},
+		{
+			name:    "A family twice",
+			service: &RedisServiceSettings{IPFamilies: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv4Protocol}},
+			expErr:  true,
+		},
+		{
+			name:    "An unknown family",
+			service: &RedisServiceSettings{IPFamilies: []corev1.IPFamily{"IPv5"}},
+			expErr:  true,
+		},
+		{
+			name:    "An unknown policy",
+			service: &RedisServiceSettings{IPFamilyPolicy: &unknown},
+			expErr:  true,
+		},
+		{
+			name:      "An unknown preferred family",
+			preferred: "ipv6",
+			expErr:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Redis.Service = test.service
+			rf.Spec.Redis.PreferredIPFamily = test.preferred
+			err := rf.Validate()
+			if test.expErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPreferredPodIP(t *testing.T) {
+	assert := assert.New(t)
+
+	dualStack := corev1.PodStatus{
+		PodIP:  "10.0.0.1",
+		PodIPs: []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+	}
+	singleStack := corev1.PodStatus{
+		PodIP:  "10.0.0.2",
+		PodIPs: []corev1.PodIP{{IP: "10.0.0.2"}},
+	}
+
+	rf := generateRedisFailover("test", nil)
+	assert.Equal("10.0.0.1", rf.PreferredPodIP(dualStack))
+
+	rf.Spec.Redis.PreferredIPFamily = corev1.IPv6Protocol
+	assert.Equal("fd00::1", rf.PreferredPodIP(dualStack))
+	// The pods without an IP of the family are connected to with their primary IP.
+	assert.Equal("10.0.0.2", rf.PreferredPodIP(singleStack))
+	assert.Equal("", rf.PreferredPodIP(corev1.PodStatus{}))
+
+	rf.Spec.Redis.PreferredIPFamily = corev1.IPv4Protocol
+	assert.Equal("10.0.0.1", rf.PreferredPodIP(corev1.PodStatus{PodIP: "fd00::1", PodIPs: []corev1.PodIP{{IP: "fd00::1"}, {IP: "10.0.0.1"}}}))
+}
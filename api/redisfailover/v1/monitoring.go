@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	maxMetricLabels           = 5
+	maxMetricLabelValueLength = 128
+)
+
+var metricLabelNameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedMetricLabels are the labels of the metrics of the operator on the RFs, they can't be
+// overridden by the metric labels.
+var reservedMetricLabels = map[string]bool{
+	"namespace": true,
+	"name":      true,
+	"resource":  true,
+	"indicator": true,
+	"instance":  true,
+	"status":    true,
+	"pod":       true,
+	"container": true,
+	"reason":    true,
+	"step":      true,
+}
+
+// MetricLabels returns the labels added to the metrics of the operator on the RF.
+func (r *RedisFailover) MetricLabels() map[string]string {
+	if r.Spec.Monitoring == nil {
+		return nil
+	}
+	return r.Spec.Monitoring.MetricLabels
+}
+
+// validateMonitoring checks the metric labels are valid prometheus labels, few enough to not raise
+// the cardinality of the metrics.
+func (r *RedisFailover) validateMonitoring() error {
+	labels := r.MetricLabels()
+	if len(labels) > maxMetricLabels {
+		return fmt.Errorf("monitoring.metricLabels can't have more than %d labels, got %d", maxMetricLabels, len(labels))
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !metricLabelNameRE.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("monitoring.metricLabels %q is not a valid prometheus label name", name)
+		}
+		if reservedMetricLabels[name] {
+			return fmt.Errorf("monitoring.metricLabels %q is a label of the metrics of the operator", name)
+		}
+		if len(labels[name]) > maxMetricLabelValueLength {
+			return fmt.Errorf("monitoring.metricLabels %q value can't be longer than %d characters", name, maxMetricLabelValueLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMonitoring(t *testing.T) {
+	tests := []struct {
+		name          string
+		monitoring    *MonitoringSettings
+		expectedError string
+	}{
+		{
+			name: "No monitoring settings",
+		},
+		{
+			name:       "Valid labels",
+			monitoring: &MonitoringSettings{MetricLabels: map[string]string{"team": "payments", "cost_center": "cc-42", "tier": ""}},
+		},
+		{
+			name: "Too many labels",
+			monitoring: &MonitoringSettings{MetricLabels: map[string]string{
+				"a": "1", "b": "2", "c": "3", "d": "4", "e": "5", "f": "6",
+			}},
+			expectedError: "monitoring.metricLabels can't have more than 5 labels, got 6",
+		},
+		{
+			name:          "Invalid label name",
+			monitoring:    &MonitoringSettings{MetricLabels: map[string]string{"cost-center": "cc-42"}},
+			expectedError: `monitoring.metricLabels "cost-center" is not a valid prometheus label name`,
+		},
+		{
+			name:          "Label name reserved by prometheus",
+			monitoring:    &MonitoringSettings{MetricLabels: map[string]string{"__team": "payments"}},
+			expectedError: `monitoring.metricLabels "__team" is not a valid prometheus label name`,
+		},
+		{
+			name:          "Label of the metrics of the operator",
+			monitoring:    &MonitoringSettings{MetricLabels: map[string]string{"namespace": "other"}},
+			expectedError: `monitoring.metricLabels "namespace" is a label of the metrics of the operator`,
+		},
+		{
+			name:          "Too long value",
+			monitoring:    &MonitoringSettings{MetricLabels: map[string]string{"team": strings.Repeat("a", 129)}},
+			expectedError: `monitoring.metricLabels "team" value can't be longer than 128 characters`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Monitoring = test.monitoring
+
+			err := rf.Validate()
+
+			if test.expectedError != "" {
+				assert.EqualError(err, test.expectedError)
+				return
+			}
+			assert.NoError(err)
+			if test.monitoring != nil {
+				assert.Equal(test.monitoring.MetricLabels, rf.MetricLabels())
+			}
+		})
+	}
+}
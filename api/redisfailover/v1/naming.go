@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// NamePrefixAnnotation holds the prefix of the names of the objects generated for the RedisFailover.
+	// The operator renders it from its name prefix template the first time it sees the object and never
+	// changes it, renaming the generated objects would recreate them.
+	NamePrefixAnnotation = "databases.spotahome.com/name-prefix"
+	// CommonLabelsAnnotation holds the labels added to the objects generated for the RedisFailover, as
+	// comma separated key=value pairs. It's pinned like the name prefix.
+	CommonLabelsAnnotation = "databases.spotahome.com/common-labels"
+)
+
+// generatedNameBase starts the names of the objects generated for the RedisFailover, after the name prefix.
+const generatedNameBase = "rf"
+
+var namePrefixRegexp = regexp.MustCompile(`^[a-z0-9][-a-z0-9]*$`)
+
+// NamePrefix returns the prefix of the names of the objects generated for the RedisFailover.
+func (r *RedisFailover) NamePrefix() string {
+	return r.Annotations[NamePrefixAnnotation]
+}
+
+// GeneratedName returns the name of an object of the given type generated for the RedisFailover, with
+// the pinned name prefix.
+func (r *RedisFailover) GeneratedName(typeName string) string {
+	return fmt.Sprintf("%s%s%s-%s", r.NamePrefix(), generatedNameBase, typeName, r.Name)
+}
+
+// CommonLabels returns the labels added to the objects generated for the RedisFailover. An invalid
+// annotation is reported by the validation.
+func (r *RedisFailover) CommonLabels() map[string]string {
+	labels, err := ParseCommonLabels(r.Annotations[CommonLabelsAnnotation])
+	if err != nil {
+		return map[string]string{}
+	}
+	return labels
+}
+
+// ParseCommonLabels parses comma separated key=value pairs.
+func ParseCommonLabels(value string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("common label %q must be a key=value pair", pair)
+		}
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("common label key %q is invalid: %s", key, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(val); len(errs) > 0 {
+			return nil, fmt.Errorf("common label %q value %q is invalid: %s", key, val, strings.Join(errs, ", "))
+		}
+		labels[key] = val
+	}
+	return labels, nil
+}
+
+// FormatCommonLabels returns the labels as comma separated key=value pairs sorted by key, the format
+// of the CommonLabelsAnnotation.
+func FormatCommonLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// validateNaming checks the name prefix and the common labels, the prefix counts towards the maximum
+// length of the name so the generated names stay under the 63 characters of a label value.
+func (r *RedisFailover) validateNaming() error {
+	prefix := r.NamePrefix()
+	if prefix == "" {
+		if len(r.Name) > maxNameLength {
+			return fmt.Errorf("name length can't be higher than %d", maxNameLength)
+		}
+	} else {
+		if !namePrefixRegexp.MatchString(prefix) {
+			return fmt.Errorf("%s annotation must be lowercase alphanumeric characters or '-', got %q", NamePrefixAnnotation, prefix)
+		}
+		if len(prefix)+len(r.Name) > maxNameLength {
+			return fmt.Errorf("name length with the %q prefix can't be higher than %d", prefix, maxNameLength)
+		}
+	}
+	if _, err := ParseCommonLabels(r.Annotations[CommonLabelsAnnotation]); err != nil {
+		return fmt.Errorf("%s annotation is invalid: %w", CommonLabelsAnnotation, err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateNaming(t *testing.T) {
+	tests := []struct {
+		name          string
+		rfName        string
+		annotations   map[string]string
+		expectedError string
+	}{
+		{
+			name:   "No prefix nor common labels",
+			rfName: "test",
+		},
+		{
+			name:        "The prefix and the name at the maximum length",
+			rfName:      strings.Repeat("a", maxNameLength-len("team-a-")),
+			annotations: map[string]string{NamePrefixAnnotation: "team-a-"},
+		},
+		{
+			name:          "The prefix makes the name too long",
+			rfName:        strings.Repeat("a", maxNameLength-len("team-a-")+1),
+			annotations:   map[string]string{NamePrefixAnnotation: "team-a-"},
+			expectedError: `name length with the "team-a-" prefix can't be higher than 48`,
+		},
+		{
+			name:          "A prefix that is not a DNS label",
+			rfName:        "test",
+			annotations:   map[string]string{NamePrefixAnnotation: "Team_A"},
+			expectedError: `databases.spotahome.com/name-prefix annotation must be lowercase alphanumeric characters or '-', got "Team_A"`,
+		},
+		{
+			name:        "Valid common labels",
+			rfName:      "test",
+			annotations: map[string]string{CommonLabelsAnnotation: "example.com/team=a,env=prod"},
+		},
+		{
+			name:          "Common labels that are not pairs",
+			rfName:        "test",
+			annotations:   map[string]string{CommonLabelsAnnotation: "env"},
+			expectedError: `databases.spotahome.com/common-labels annotation is invalid: common label "env" must be a key=value pair`,
+		},
+		{
+			name:          "A common label value too long",
+			rfName:        "test",
+			annotations:   map[string]string{CommonLabelsAnnotation: "env=" + strings.Repeat("a", 64)},
+			expectedError: `databases.spotahome.com/common-labels annotation is invalid: common label "env" value`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			rf := generateRedisFailover(test.rfName, nil)
+			rf.Annotations = test.annotations
+
+			err := rf.Validate()
+
+			if test.expectedError == "" {
+				assert.NoError(err)
+			} else if assert.Error(err) {
+				assert.Contains(err.Error(), test.expectedError)
+			}
+		})
+	}
+}
+
+func TestCommonLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	labels := map[string]string{"env": "prod", "example.com/team": "a"}
+	value := FormatCommonLabels(labels)
+	assert.Equal("env=prod,example.com/team=a", value)
+
+	rf := generateRedisFailover("test", nil)
+	assert.Empty(rf.CommonLabels())
+	rf.Annotations = map[string]string{CommonLabelsAnnotation: value}
+	assert.Equal(labels, rf.CommonLabels())
+}
@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NetworkPolicyEnabled returns true when the redis and the sentinel pods are isolated by NetworkPolicies.
+func (r *RedisFailover) NetworkPolicyEnabled() bool {
+	np := r.Spec.NetworkPolicy
+	return np != nil && (np.Enabled == nil || *np.Enabled)
+}
+
+// validateNetworkPolicy checks the selector of the allowed namespaces can be used by a NetworkPolicy.
+func (r *RedisFailover) validateNetworkPolicy() error {
+	if !r.NetworkPolicyEnabled() || r.Spec.NetworkPolicy.AllowedNamespaces == nil {
+		return nil
+	}
+	if _, err := metav1.LabelSelectorAsSelector(r.Spec.NetworkPolicy.AllowedNamespaces); err != nil {
+		return fmt.Errorf("networkPolicy.allowedNamespaces is not a valid selector: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateNetworkPolicy(t *testing.T) {
+	disabled := false
+	enabled := true
+	tests := []struct {
+		name          string
+		networkPolicy *NetworkPolicySettings
+		expEnabled    bool
+		expectedError string
+	}{
+		{
+			name: "Not set",
+		},
+		{
+			name:          "Set without the flag",
+			networkPolicy: &NetworkPolicySettings{},
+			expEnabled:    true,
+		},
+		{
+			name:          "Enabled",
+			networkPolicy: &NetworkPolicySettings{Enabled: &enabled},
+			expEnabled:    true,
+		},
+		{
+			name:          "Disabled",
+			networkPolicy: &NetworkPolicySettings{Enabled: &disabled},
+		},
+		{
+			name: "Allowed namespaces",
+			networkPolicy: &NetworkPolicySettings{AllowedNamespaces: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "cache"},
+			}},
+			expEnabled: true,
+		},
+		{
+			name: "Invalid allowed namespaces",
+			networkPolicy: &NetworkPolicySettings{AllowedNamespaces: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Near"}},
+			}},
+			expEnabled:    true,
+			expectedError: `networkPolicy.allowedNamespaces is not a valid selector: "Near" is not a valid pod selector operator`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.NetworkPolicy = test.networkPolicy
+
+			assert.Equal(t, test.expEnabled, rf.NetworkPolicyEnabled())
+			err := rf.Validate()
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
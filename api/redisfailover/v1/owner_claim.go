@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// OwnerClaimAnnotation holds, as JSON, the claim of the operator reconciling the RF.
+	OwnerClaimAnnotation = "databases.spotahome.com/owner-claim"
+	// OwnerContenderAnnotation holds, as JSON, the claim of an operator backing off the RF because
+	// another one owns it.
+	OwnerContenderAnnotation = "databases.spotahome.com/owner-contender"
+)
+
+// OwnerClaim is the claim of an operator on a RF, renewed with a heartbeat while it reconciles it.
+type OwnerClaim struct {
+	// Operator is the namespace and the name of the operator deployment, it tells the operators apart.
+	Operator string `json:"operator"`
+	// Holder is the lease identity of the operator pod, only reported.
+	Holder string `json:"holder,omitempty"`
+	// Started is when the operator pod started, the younger operator backs off.
+	Started metav1.Time `json:"started"`
+	// Heartbeat is when the claim was last renewed.
+	Heartbeat metav1.Time `json:"heartbeat"`
+}
+
+// Live returns true when the claim was renewed since staleAfter.
+func (c OwnerClaim) Live(now time.Time, staleAfter time.Duration) bool {
+	return now.Sub(c.Heartbeat.Time) < staleAfter
+}
+
+// ParseOwnerClaim returns the claim stored in the annotation, false if it's missing.
+func ParseOwnerClaim(annotations map[string]string, annotation string) (OwnerClaim, bool, error) {
+	value, ok := annotations[annotation]
+	if !ok || value == "" {
+		return OwnerClaim{}, false, nil
+	}
+	claim := OwnerClaim{}
+	if err := json.Unmarshal([]byte(value), &claim); err != nil {
+		return OwnerClaim{}, false, fmt.Errorf("invalid %s annotation: %w", annotation, err)
+	}
+	return claim, true, nil
+}
+
+// FormatOwnerClaim returns the claim as stored in the annotations.
+func FormatOwnerClaim(claim OwnerClaim) (string, error) {
+	value, err := json.Marshal(claim)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
@@ -0,0 +1,28 @@
+package v1
+
+import "fmt"
+
+// The unhealthyPodEvictionPolicy values of the PodDisruptionBudgets.
+const (
+	UnhealthyPodEvictionIfHealthyBudget = "IfHealthyBudget"
+	UnhealthyPodEvictionAlwaysAllow     = "AlwaysAllow"
+)
+
+// RedisUnhealthyPodEvictionPolicy returns the unhealthyPodEvictionPolicy of the PodDisruptionBudget of
+// the redis pods, empty when the RF doesn't set it and the cluster default applies.
+func (r *RedisFailover) RedisUnhealthyPodEvictionPolicy() string {
+	if r.Spec.Redis.PodDisruptionBudget == nil {
+		return ""
+	}
+	return r.Spec.Redis.PodDisruptionBudget.UnhealthyPodEvictionPolicy
+}
+
+// validatePodDisruptionBudget checks the unhealthyPodEvictionPolicy is one kubernetes knows.
+func (r *RedisFailover) validatePodDisruptionBudget() error {
+	switch policy := r.RedisUnhealthyPodEvictionPolicy(); policy {
+	case "", UnhealthyPodEvictionIfHealthyBudget, UnhealthyPodEvictionAlwaysAllow:
+		return nil
+	default:
+		return fmt.Errorf("redis.podDisruptionBudget.unhealthyPodEvictionPolicy must be %s or %s, got %q", UnhealthyPodEvictionIfHealthyBudget, UnhealthyPodEvictionAlwaysAllow, policy)
+	}
+}
@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePodDisruptionBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		pdb    *RedisPodDisruptionBudget
+		expErr bool
+	}{
+		{
+			name: "No PodDisruptionBudget settings",
+		},
+		{
+			name: "An empty policy",
+			pdb:  &RedisPodDisruptionBudget{},
+		},
+		{
+			name: "AlwaysAllow",
+			pdb:  &RedisPodDisruptionBudget{UnhealthyPodEvictionPolicy: UnhealthyPodEvictionAlwaysAllow},
+		},
+		{
+			name: "IfHealthyBudget",
+			pdb:  &RedisPodDisruptionBudget{UnhealthyPodEvictionPolicy: UnhealthyPodEvictionIfHealthyBudget},
+		},
+		{
+			name:   "An unknown policy",
+			pdb:    &RedisPodDisruptionBudget{UnhealthyPodEvictionPolicy: "Always"},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Redis.PodDisruptionBudget = test.pdb
+			err := rf.Validate()
+			if test.expErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
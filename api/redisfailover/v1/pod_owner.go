@@ -0,0 +1,12 @@
+package v1
+
+// ForcePodOwnerAnnotationsAnnotation writes the owner annotations on the pod templates of the
+// RedisFailover right away when its value is "true", rolling its pods. Without it, the pods of the
+// existing workloads get them with the next change of their template.
+const ForcePodOwnerAnnotationsAnnotation = "databases.spotahome.com/force-pod-owner-annotations"
+
+// ForcePodOwnerAnnotations returns true when the owner annotations are written on the pod templates
+// without waiting for another change of them.
+func (r *RedisFailover) ForcePodOwnerAnnotations() bool {
+	return r.Annotations[ForcePodOwnerAnnotationsAnnotation] == "true"
+}
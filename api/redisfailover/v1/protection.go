@@ -0,0 +1,39 @@
+package v1
+
+import "fmt"
+
+// UnfreezeAnnotation acknowledges the last mass deletion of the keys of the master, see DataFlushStatus.
+// Setting it to a new value removes the PossibleDataFlush condition and lifts the freeze of the replicas.
+const UnfreezeAnnotation = "databases.spotahome.com/unfreeze"
+
+const defaultMassDeletionThresholdPercent = 50
+
+// MassDeletionThreshold returns the share of the keys of the master, in percent, whose deletion between
+// two checks is reported as a possible FLUSHALL.
+func (r *RedisFailover) MassDeletionThreshold() int32 {
+	if p := r.Spec.Protection; p != nil && p.MassDeletionThresholdPercent != nil {
+		return *p.MassDeletionThresholdPercent
+	}
+	return defaultMassDeletionThresholdPercent
+}
+
+// FreezeOnMassDeletionEnabled returns true when the replicas are frozen while a mass deletion of the keys
+// of the master is not acknowledged.
+func (r *RedisFailover) FreezeOnMassDeletionEnabled() bool {
+	return r.Spec.Protection != nil && r.Spec.Protection.FreezeOnMassDeletion
+}
+
+// Unfreeze returns the value of the unfreeze annotation, empty when it's not set.
+func (r *RedisFailover) Unfreeze() string {
+	return r.Annotations[UnfreezeAnnotation]
+}
+
+// validateProtection checks the mass deletion threshold is a percentage.
+func (r *RedisFailover) validateProtection() error {
+	if p := r.Spec.Protection; p != nil && p.MassDeletionThresholdPercent != nil {
+		if t := *p.MassDeletionThresholdPercent; t < 1 || t > 100 {
+			return fmt.Errorf("protection.massDeletionThresholdPercent must be between 1 and 100, got %d", t)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateProtection(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	tests := []struct {
+		name              string
+		protection        *ProtectionSettings
+		expectedThreshold int32
+		expectedFreeze    bool
+		expectedError     string
+	}{
+		{
+			name:              "Not set",
+			expectedThreshold: 50,
+		},
+		{
+			name:              "Freeze with the default threshold",
+			protection:        &ProtectionSettings{FreezeOnMassDeletion: true},
+			expectedThreshold: 50,
+			expectedFreeze:    true,
+		},
+		{
+			name:              "Threshold set",
+			protection:        &ProtectionSettings{MassDeletionThresholdPercent: int32Ptr(90)},
+			expectedThreshold: 90,
+		},
+		{
+			name:          "Threshold zero",
+			protection:    &ProtectionSettings{MassDeletionThresholdPercent: int32Ptr(0)},
+			expectedError: "protection.massDeletionThresholdPercent must be between 1 and 100, got 0",
+		},
+		{
+			name:          "Threshold above 100",
+			protection:    &ProtectionSettings{MassDeletionThresholdPercent: int32Ptr(101)},
+			expectedError: "protection.massDeletionThresholdPercent must be between 1 and 100, got 101",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Protection = test.protection
+			err := rf.Validate()
+			if test.expectedError != "" {
+				assert.EqualError(err, test.expectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedThreshold, rf.MassDeletionThreshold())
+			assert.Equal(test.expectedFreeze, rf.FreezeOnMassDeletionEnabled())
+		})
+	}
+}
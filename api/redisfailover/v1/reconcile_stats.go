@@ -0,0 +1,36 @@
+package v1
+
+// Results of the checks reported on the RedisFailover status
+const (
+	// CheckResultPassed is set when the check ran to the end, healing what it found.
+	CheckResultPassed = "Passed"
+	// CheckResultFailed is set when the check returned an error, the later checks are skipped.
+	CheckResultFailed = "Failed"
+	// CheckResultStopped is set when the check found the cluster converging, as a redis pod being
+	// created, and skipped the later checks without an error.
+	CheckResultStopped = "Stopped"
+	// CheckResultSkipped is set when an earlier check failed or stopped.
+	CheckResultSkipped = "Skipped"
+	// CheckResultDisabled is set when the check is disabled with its feature gate.
+	CheckResultDisabled = "Disabled"
+)
+
+// ReconcileStats are the results of the checks of the last reconcile of the RedisFailover. They hold
+// no durations so the status is not written on every reconcile, the durations are in the metrics and
+// the debug endpoint of the operator.
+type ReconcileStats struct {
+	// Checks are the results of the checks, in the order they ran.
+	Checks []CheckStats `json:"checks,omitempty"`
+}
+
+// CheckStats is the result of a check of the last reconcile
+type CheckStats struct {
+	// Name is the name of the check.
+	Name string `json:"name"`
+	// Result is one of Passed, Failed, Stopped, Skipped or Disabled.
+	Result string `json:"result"`
+	// Actions is the number of actions on the redis pods the check planned.
+	Actions int32 `json:"actions,omitempty"`
+	// Error is the error of the failed check.
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RedisCluster constants
+const (
+	RCKind       = "RedisCluster"
+	RCName       = "rediscluster"
+	RCNamePlural = "redisclusters"
+)
+
+const (
+	defaultClusterVersion = "6.2.6"
+	// minimumClusterMasters is the number of masters redis-cli requires to create a cluster.
+	minimumClusterMasters = 3
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RedisCluster represents a Redis Cluster, its data sharded across several masters
+// +kubebuilder:printcolumn:name="NAME",type="string",JSONPath=".metadata.name"
+// +kubebuilder:printcolumn:name="MASTERS",type="integer",JSONPath=".spec.masters"
+// +kubebuilder:printcolumn:name="REPLICAS",type="integer",JSONPath=".spec.replicasPerMaster"
+// +kubebuilder:printcolumn:name="STATE",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:singular=rediscluster,path=redisclusters,shortName=rc,scope=Namespaced
+// +kubebuilder:subresource:status
+type RedisCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              RedisClusterSpec   `json:"spec"`
+	Status            RedisClusterStatus `json:"status,omitempty"`
+}
+
+// RedisClusterSpec represents a Redis Cluster spec
+type RedisClusterSpec struct {
+	// Masters is the number of shards, each one with a master serving a part of the hash slots. At
+	// least 3, it can be increased but not decreased.
+	Masters int32 `json:"masters,omitempty"`
+	// ReplicasPerMaster is the number of replicas of the master of every shard.
+	ReplicasPerMaster int32 `json:"replicasPerMaster,omitempty"`
+	// Version is the redis version run, with its alpine image. 6.2.6 when it's not set.
+	Version string `json:"version,omitempty"`
+	// Resources are the resources of the redis containers.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// RedisClusterStatus has the status of the cluster
+type RedisClusterStatus struct {
+	// ObservedGeneration is the generation of the spec the status was computed from.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Bootstrapped is true once the cluster has been created with all its hash slots assigned.
+	Bootstrapped bool `json:"bootstrapped,omitempty"`
+	// State is the cluster_state reported by the redis, ok or fail.
+	State string `json:"state,omitempty"`
+	// Shards are the shards of the cluster, in the order of their statefulsets.
+	Shards []RedisClusterShardStatus `json:"shards,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// RedisClusterShardStatus represents a shard of the cluster, the pods of a statefulset
+type RedisClusterShardStatus struct {
+	// Name is the name of the statefulset of the shard.
+	Name string `json:"name"`
+	// Master is the pod serving the hash slots of the shard.
+	Master string `json:"master,omitempty"`
+	// Replicas are the pods replicating the master.
+	Replicas []string `json:"replicas,omitempty"`
+	// Slots is the number of hash slots served by the master.
+	Slots int32 `json:"slots,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RedisClusterList represents a RedisCluster list
+type RedisClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []RedisCluster `json:"items"`
+}
+
+// Condition types set on the RedisCluster status
+const (
+	// ConditionTopologyWarning is true when the shards of the spec can't be reconciled.
+	ConditionTopologyWarning = "TopologyWarning"
+)
+
+// Condition reasons set on the RedisCluster status
+const (
+	// ReasonMastersDecreased is set when the masters are decreased, the shards are not removed.
+	ReasonMastersDecreased = "MastersDecreased"
+)
+
+// Validate checks the spec of the RedisCluster and sets its defaults.
+func (r *RedisCluster) Validate() error {
+	if r.Spec.Masters == 0 {
+		r.Spec.Masters = minimumClusterMasters
+	}
+	if r.Spec.Masters < minimumClusterMasters {
+		return fmt.Errorf("masters must be at least %d, redis can't create a cluster with less", minimumClusterMasters)
+	}
+	if r.Spec.ReplicasPerMaster < 0 {
+		return errors.New("replicasPerMaster can't be negative")
+	}
+	if r.Spec.Version == "" {
+		r.Spec.Version = defaultClusterVersion
+	}
+	return nil
+}
+
+// Image returns the redis image of the version of the cluster.
+func (r *RedisCluster) Image() string {
+	return fmt.Sprintf("redis:%s-alpine", r.Spec.Version)
+}
@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRedisCluster(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     RedisClusterSpec
+		expSpec  RedisClusterSpec
+		expError string
+	}{
+		{
+			name:    "Defaults",
+			expSpec: RedisClusterSpec{Masters: 3, Version: "6.2.6"},
+		},
+		{
+			name:    "Custom",
+			spec:    RedisClusterSpec{Masters: 5, ReplicasPerMaster: 2, Version: "7.0.5"},
+			expSpec: RedisClusterSpec{Masters: 5, ReplicasPerMaster: 2, Version: "7.0.5"},
+		},
+		{
+			name:     "Too few masters",
+			spec:     RedisClusterSpec{Masters: 2},
+			expError: "masters must be at least 3, redis can't create a cluster with less",
+		},
+		{
+			name:     "Negative replicas",
+			spec:     RedisClusterSpec{Masters: 3, ReplicasPerMaster: -1},
+			expError: "replicasPerMaster can't be negative",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rc := &RedisCluster{Spec: test.spec}
+			err := rc.Validate()
+			if test.expError != "" {
+				assert.EqualError(t, err, test.expError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expSpec, rc.Spec)
+			assert.Equal(t, "redis:"+test.expSpec.Version+"-alpine", rc.Image())
+		})
+	}
+}
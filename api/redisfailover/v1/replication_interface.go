@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// The bind addresses resolved when the redis pod starts.
+const (
+	// BindPodIP is the IP of the pod, the operator connects to the redis on it.
+	BindPodIP = "$(POD_IP)"
+	// BindReplicationIP is the address of the replication interface of the node.
+	BindReplicationIP = "$(REPLICATION_IP)"
+)
+
+// bindWildcards are the bind addresses listening on every interface.
+var bindWildcards = map[string]bool{
+	"*":       true,
+	"0.0.0.0": true,
+	"::":      true,
+}
+
+// ReplicationInterfaceEnabled returns true when the redis announce the address of a replication
+// interface of their node instead of their pod IP.
+func (r *RedisFailover) ReplicationInterfaceEnabled() bool {
+	return r.Spec.Redis.ReplicationInterface != nil
+}
+
+// RedisNetworkBootstrapEnabled returns true when the bind addresses or the announced address of the
+// redis are resolved when their pod starts.
+func (r *RedisFailover) RedisNetworkBootstrapEnabled() bool {
+	return len(r.Spec.Redis.BindAddresses) > 0 || r.ReplicationInterfaceEnabled()
+}
+
+// bindsAny returns true when the bind addresses listen on one of the addresses, or on every interface.
+func bindsAny(addresses []string, address string) bool {
+	for _, a := range addresses {
+		if a == address || bindWildcards[a] {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRedisNetwork checks the bind addresses and the replication interface of the redis, and sets
+// the default image of the container resolving them. The redis must keep listening on their pod IP for
+// the operator, and on the replication interface for the replicas and the sentinels.
+func (r *RedisFailover) validateRedisNetwork() error {
+	if !r.RedisNetworkBootstrapEnabled() {
+		return nil
+	}
+	if r.ExternalNodesEnabled() {
+		return errors.New("bindAddresses and replicationInterface can't be used with externalNodes, the operator doesn't run their redis")
+	}
+	if r.ZeroDowntimeReloadEnabled() {
+		return errors.New("bindAddresses and replicationInterface can't be used with zeroDowntimeReload, redis only listens on the socket of the proxy")
+	}
+
+	seen := map[string]bool{}
+	for i, address := range r.Spec.Redis.BindAddresses {
+		switch {
+		case address == BindPodIP || bindWildcards[address]:
+		case address == BindReplicationIP:
+			if !r.ReplicationInterfaceEnabled() {
+				return fmt.Errorf("redis.bindAddresses[%d] %s requires redis.replicationInterface", i, address)
+			}
+		case net.ParseIP(address) == nil:
+			return fmt.Errorf("redis.bindAddresses[%d] %q must be an IP, %s or %s", i, address, BindPodIP, BindReplicationIP)
+		}
+		if seen[address] {
+			return fmt.Errorf("redis.bindAddresses[%d] %s is repeated", i, address)
+		}
+		seen[address] = true
+	}
+	if len(r.Spec.Redis.BindAddresses) > 0 {
+		if !bindsAny(r.Spec.Redis.BindAddresses, BindPodIP) {
+			return fmt.Errorf("redis.bindAddresses must include %s or a wildcard, the operator connects to the redis on their pod IP", BindPodIP)
+		}
+		if r.ReplicationInterfaceEnabled() && !bindsAny(r.Spec.Redis.BindAddresses, BindReplicationIP) {
+			return fmt.Errorf("redis.bindAddresses must include %s or a wildcard, the replicas and the sentinels connect to the redis on it", BindReplicationIP)
+		}
+	}
+
+	if r.ReplicationInterfaceEnabled() {
+		annotation := r.Spec.Redis.ReplicationInterface.NodeAnnotation
+		if annotation == "" {
+			return errors.New("redis.replicationInterface.nodeAnnotation is required, it holds the address of the replication interface of the nodes")
+		}
+		if errs := validation.IsQualifiedName(annotation); len(errs) > 0 {
+			return fmt.Errorf("redis.replicationInterface.nodeAnnotation %q is not a valid annotation: %s", annotation, strings.Join(errs, ", "))
+		}
+		if !r.Spec.Redis.HostNetwork {
+			return errors.New("redis.replicationInterface requires redis.hostNetwork, the redis listen on an address of their node")
+		}
+	}
+
+	for i, line := range r.Spec.Redis.CustomConfig {
+		directive := strings.ToLower(strings.SplitN(strings.TrimSpace(line), " ", 2)[0])
+		if directive == "bind" && len(r.Spec.Redis.BindAddresses) > 0 {
+			return fmt.Errorf("redis.customConfig[%d] %q can't set bind: use spec.redis.bindAddresses", i, line)
+		}
+		if directive == "replica-announce-ip" && r.ReplicationInterfaceEnabled() {
+			return fmt.Errorf("redis.customConfig[%d] %q can't set replica-announce-ip: it's read from the node with replicationInterface", i, line)
+		}
+	}
+
+	if r.ReplicationInterfaceEnabled() && r.Spec.Redis.ReplicationInterface.Image == "" {
+		r.Spec.Redis.ReplicationInterface.Image = defaultProxyImage
+	}
+	return nil
+}
+
+// RedisNetworkBootstrapImage returns the image of the container resolving the network of the redis
+// when their pod starts, the operator one.
+func (r *RedisFailover) RedisNetworkBootstrapImage() string {
+	if r.ReplicationInterfaceEnabled() && r.Spec.Redis.ReplicationInterface.Image != "" {
+		return r.Spec.Redis.ReplicationInterface.Image
+	}
+	return defaultProxyImage
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRedisNetwork(t *testing.T) {
+	iface := func() *RedisReplicationInterface {
+		return &RedisReplicationInterface{NodeAnnotation: "network.example.com/replication-ip"}
+	}
+
+	tests := []struct {
+		name          string
+		bind          []string
+		iface         *RedisReplicationInterface
+		hostNetwork   bool
+		reload        bool
+		customConfig  []string
+		externalNodes []RedisExternalNode
+		expImage      string
+		expectedError string
+	}{
+		{
+			name: "Disabled",
+		},
+		{
+			name: "Bind addresses on the pod IP and another address",
+			bind: []string{BindPodIP, "10.10.0.1", "127.0.0.1"},
+		},
+		{
+			name:        "Replication interface gets the default image",
+			bind:        []string{BindPodIP, BindReplicationIP},
+			iface:       iface(),
+			hostNetwork: true,
+			expImage:    defaultProxyImage,
+		},
+		{
+			name:        "Replication interface behind a wildcard",
+			bind:        []string{"0.0.0.0"},
+			iface:       iface(),
+			hostNetwork: true,
+			expImage:    defaultProxyImage,
+		},
+		{
+			name:          "Bind address that is not an IP",
+			bind:          []string{BindPodIP, "redis.local"},
+			expectedError: `redis.bindAddresses[1] "redis.local" must be an IP, $(POD_IP) or $(REPLICATION_IP)`,
+		},
+		{
+			name:          "Repeated bind address",
+			bind:          []string{BindPodIP, BindPodIP},
+			expectedError: "redis.bindAddresses[1] $(POD_IP) is repeated",
+		},
+		{
+			name:          "Bind addresses without the pod IP",
+			bind:          []string{"10.10.0.1"},
+			expectedError: "redis.bindAddresses must include $(POD_IP) or a wildcard, the operator connects to the redis on their pod IP",
+		},
+		{
+			name:          "Replication IP without replication interface",
+			bind:          []string{BindPodIP, BindReplicationIP},
+			expectedError: "redis.bindAddresses[1] $(REPLICATION_IP) requires redis.replicationInterface",
+		},
+		{
+			name:          "Bind addresses without the replication IP",
+			bind:          []string{BindPodIP},
+			iface:         iface(),
+			hostNetwork:   true,
+			expectedError: "redis.bindAddresses must include $(REPLICATION_IP) or a wildcard, the replicas and the sentinels connect to the redis on it",
+		},
+		{
+			name:          "Replication interface without node annotation",
+			iface:         &RedisReplicationInterface{},
+			hostNetwork:   true,
+			expectedError: "redis.replicationInterface.nodeAnnotation is required, it holds the address of the replication interface of the nodes",
+		},
+		{
+			name:          "Replication interface without host network",
+			iface:         iface(),
+			expectedError: "redis.replicationInterface requires redis.hostNetwork, the redis listen on an address of their node",
+		},
+		{
+			name:          "Bind set in the custom config",
+			bind:          []string{BindPodIP},
+			customConfig:  []string{"bind 0.0.0.0"},
+			expectedError: `redis.customConfig[0] "bind 0.0.0.0" can't set bind: use spec.redis.bindAddresses`,
+		},
+		{
+			name:          "Announced address set in the custom config",
+			iface:         iface(),
+			hostNetwork:   true,
+			customConfig:  []string{"replica-announce-ip 10.10.0.1"},
+			expectedError: `redis.customConfig[0] "replica-announce-ip 10.10.0.1" can't set replica-announce-ip: it's read from the node with replicationInterface`,
+		},
+		{
+			name:          "Zero downtime reload",
+			bind:          []string{BindPodIP},
+			reload:        true,
+			expectedError: "bindAddresses and replicationInterface can't be used with zeroDowntimeReload, redis only listens on the socket of the proxy",
+		},
+		{
+			name:          "External nodes",
+			iface:         iface(),
+			externalNodes: []RedisExternalNode{{Host: "10.0.0.1", Port: "6379"}},
+			expectedError: "bindAddresses and replicationInterface can't be used with externalNodes, the operator doesn't run their redis",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Redis.BindAddresses = test.bind
+			rf.Spec.Redis.ReplicationInterface = test.iface
+			rf.Spec.Redis.HostNetwork = test.hostNetwork
+			rf.Spec.Redis.ZeroDowntimeReload.Enabled = test.reload
+			rf.Spec.Redis.CustomConfig = test.customConfig
+			rf.Spec.Redis.ExternalNodes = test.externalNodes
+
+			err := rf.Validate()
+
+			if test.expectedError != "" {
+				assert.EqualError(err, test.expectedError)
+				return
+			}
+			assert.NoError(err)
+			if test.iface != nil {
+				assert.Equal(test.expImage, rf.Spec.Redis.ReplicationInterface.Image)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"strconv"
+)
+
+const (
+	// SchemaRevision is the revision of the RedisFailover types compiled in the operator.
+	// It must be bumped with every change to the types, together with the CRD annotation.
+	SchemaRevision = 38
+	// SchemaRevisionAnnotation holds the schema revision the CRD was installed with and, on
+	// the RedisFailover objects, the newest schema revision that has reconciled them.
+	SchemaRevisionAnnotation = "databases.spotahome.com/schema-revision"
+)
+
+// ParseSchemaRevision returns the schema revision stored in the annotations, false if it's missing or invalid.
+func ParseSchemaRevision(annotations map[string]string) (int, bool) {
+	value, ok := annotations[SchemaRevisionAnnotation]
+	if !ok {
+		return 0, false
+	}
+	revision, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return revision, true
+}
+
+// NewerThanOperator returns true when the RedisFailover has been reconciled by an operator with a newer
+// schema revision, so it can have fields this operator drops when decoding it.
+func (r *RedisFailover) NewerThanOperator() bool {
+	revision, ok := ParseSchemaRevision(r.Annotations)
+	return ok && revision > SchemaRevision
+}
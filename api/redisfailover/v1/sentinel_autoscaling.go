@@ -0,0 +1,34 @@
+package v1
+
+import "fmt"
+
+const defaultSentinelTargetCPUUtilization = 80
+
+// SentinelAutoScalingEnabled returns true when the sentinels are deployed and scaled by a
+// HorizontalPodAutoscaler.
+func (r *RedisFailover) SentinelAutoScalingEnabled() bool {
+	return r.Spec.SentinelAutoScaling != nil && r.SentinelsAllowed()
+}
+
+// validateSentinelAutoScaling checks the autoscaler can scale the sentinels from sentinel.replicas, and
+// targets the CPU usage when no target is set.
+func (r *RedisFailover) validateSentinelAutoScaling() error {
+	scaling := r.Spec.SentinelAutoScaling
+	if scaling == nil {
+		return nil
+	}
+	if scaling.MaxReplicas < r.Spec.Sentinel.Replicas {
+		return fmt.Errorf("sentinelAutoScaling.maxReplicas can't be lower than the %d sentinel replicas, got %d", r.Spec.Sentinel.Replicas, scaling.MaxReplicas)
+	}
+	if t := scaling.TargetCPUUtilizationPercentage; t != nil && *t <= 0 {
+		return fmt.Errorf("sentinelAutoScaling.targetCPUUtilizationPercentage must be positive, got %d", *t)
+	}
+	if t := scaling.TargetMemoryUtilizationPercentage; t != nil && *t <= 0 {
+		return fmt.Errorf("sentinelAutoScaling.targetMemoryUtilizationPercentage must be positive, got %d", *t)
+	}
+	if scaling.TargetCPUUtilizationPercentage == nil && scaling.TargetMemoryUtilizationPercentage == nil {
+		cpu := int32(defaultSentinelTargetCPUUtilization)
+		scaling.TargetCPUUtilizationPercentage = &cpu
+	}
+	return nil
+}
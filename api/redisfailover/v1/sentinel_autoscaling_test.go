@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSentinelAutoScaling(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	tests := []struct {
+		name          string
+		scaling       *SentinelAutoScalingSettings
+		expectedCPU   *int32
+		expectedError string
+	}{
+		{
+			name: "Disabled",
+		},
+		{
+			name:        "CPU target by default",
+			scaling:     &SentinelAutoScalingSettings{MaxReplicas: 5},
+			expectedCPU: int32Ptr(80),
+		},
+		{
+			name:    "Memory target only",
+			scaling: &SentinelAutoScalingSettings{MaxReplicas: 5, TargetMemoryUtilizationPercentage: int32Ptr(70)},
+		},
+		{
+			name:          "Max replicas below the sentinel replicas",
+			scaling:       &SentinelAutoScalingSettings{MaxReplicas: 2},
+			expectedError: "sentinelAutoScaling.maxReplicas can't be lower than the 3 sentinel replicas, got 2",
+		},
+		{
+			name:          "Negative CPU target",
+			scaling:       &SentinelAutoScalingSettings{MaxReplicas: 5, TargetCPUUtilizationPercentage: int32Ptr(-1)},
+			expectedError: "sentinelAutoScaling.targetCPUUtilizationPercentage must be positive, got -1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.SentinelAutoScaling = test.scaling
+
+			err := rf.Validate()
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.scaling != nil, rf.SentinelAutoScalingEnabled())
+			if test.scaling != nil {
+				assert.Equal(t, test.expectedCPU, rf.Spec.SentinelAutoScaling.TargetCPUUtilizationPercentage)
+			}
+		})
+	}
+}
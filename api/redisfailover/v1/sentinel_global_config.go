@@ -0,0 +1,46 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sentinelGlobalParameters are the global sentinel parameters that can be set in sentinel.globalConfig.
+// They are not bound to the monitored master, so they are set with SENTINEL CONFIG SET instead of
+// SENTINEL SET.
+var sentinelGlobalParameters = map[string]bool{
+	"resolve-hostnames":  true,
+	"announce-hostnames": true,
+}
+
+// SentinelGlobalConfig returns the global sentinel parameters of the spec and their values, lower cased.
+func (r *RedisFailover) SentinelGlobalConfig() map[string]string {
+	config := map[string]string{}
+	for _, line := range r.Spec.Sentinel.GlobalConfig {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		config[strings.ToLower(fields[0])] = strings.ToLower(fields[1])
+	}
+	return config
+}
+
+// validateSentinelGlobalConfig checks every line of the sentinel global config sets a supported
+// parameter to yes or no.
+func (r *RedisFailover) validateSentinelGlobalConfig() error {
+	for i, line := range r.Spec.Sentinel.GlobalConfig {
+		field := fmt.Sprintf("sentinel.globalConfig[%d]", i)
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return fmt.Errorf("%s %q must be a parameter and its value", field, line)
+		}
+		if !sentinelGlobalParameters[strings.ToLower(fields[0])] {
+			return fmt.Errorf("%s %q can't set %s: only resolve-hostnames and announce-hostnames are supported", field, line, fields[0])
+		}
+		if value := strings.ToLower(fields[1]); value != "yes" && value != "no" {
+			return fmt.Errorf("%s %q must set %s to yes or no", field, line, fields[0])
+		}
+	}
+	return nil
+}
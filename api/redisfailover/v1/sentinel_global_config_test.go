@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateSentinelGlobalConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalConfig []string
+		expConfig    map[string]string
+		expError     string
+	}{
+		{
+			name:         "No global config",
+			globalConfig: nil,
+			expConfig:    map[string]string{},
+		},
+		{
+			name:         "Hostnames are resolved and announced",
+			globalConfig: []string{"resolve-hostnames yes", "  Announce-Hostnames   YES "},
+			expConfig:    map[string]string{"resolve-hostnames": "yes", "announce-hostnames": "yes"},
+		},
+		{
+			name:         "A parameter bound to the master",
+			globalConfig: []string{"down-after-milliseconds 5000"},
+			expError:     `sentinel.globalConfig[0] "down-after-milliseconds 5000" can't set down-after-milliseconds: only resolve-hostnames and announce-hostnames are supported`,
+		},
+		{
+			name:         "A value other than yes or no",
+			globalConfig: []string{"resolve-hostnames maybe"},
+			expError:     `sentinel.globalConfig[0] "resolve-hostnames maybe" must set resolve-hostnames to yes or no`,
+		},
+		{
+			name:         "A newline injecting a directive",
+			globalConfig: []string{"resolve-hostnames yes", "announce-hostnames yes\nsentinel monitor other 1.2.3.4 6379 1"},
+			expError:     `sentinel.globalConfig[1] "announce-hostnames yes\nsentinel monitor other 1.2.3.4 6379 1" must be a parameter and its value`,
+		},
+		{
+			name:         "A parameter without value",
+			globalConfig: []string{"resolve-hostnames"},
+			expError:     `sentinel.globalConfig[0] "resolve-hostnames" must be a parameter and its value`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := &RedisFailover{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "namespace"},
+				Spec: RedisFailoverSpec{
+					Sentinel: SentinelSettings{
+						GlobalConfig: test.globalConfig,
+					},
+				},
+			}
+
+			err := rf.Validate()
+
+			if test.expError != "" {
+				assert.EqualError(err, test.expError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expConfig, rf.SentinelGlobalConfig())
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package v1
+
+import "fmt"
+
+// SentinelQuorum returns the number of sentinels that must agree the master is down to fail it over,
+// spec.sentinel.quorum when set, the majority of the sentinels otherwise.
+func (r *RedisFailover) SentinelQuorum() int32 {
+	if r.Spec.Sentinel.Quorum > 0 {
+		return r.Spec.Sentinel.Quorum
+	}
+	return r.sentinelMajority()
+}
+
+// SentinelQuorumBelowMajority returns true when the quorum set in the spec is lower than the majority
+// of the sentinels, so a minority of them can agree the master is down.
+func (r *RedisFailover) SentinelQuorumBelowMajority() bool {
+	return r.Spec.Sentinel.Quorum > 0 && r.Spec.Sentinel.Quorum < r.sentinelMajority()
+}
+
+func (r *RedisFailover) sentinelMajority() int32 {
+	return r.Spec.Sentinel.Replicas/2 + 1
+}
+
+// validateSentinelQuorum checks the quorum can be reached by the sentinels. A quorum below the
+// majority is valid, the stretch clusters set it on purpose, it's only warned about in the status.
+func (r *RedisFailover) validateSentinelQuorum() error {
+	quorum := r.Spec.Sentinel.Quorum
+	if quorum < 0 {
+		return fmt.Errorf("sentinel.quorum can't be negative, got %d", quorum)
+	}
+	if quorum > r.Spec.Sentinel.Replicas {
+		return fmt.Errorf("sentinel.quorum can't be greater than the %d sentinel replicas, got %d", r.Spec.Sentinel.Replicas, quorum)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentinelQuorum(t *testing.T) {
+	tests := []struct {
+		name             string
+		sentinels        int32
+		quorum           int32
+		expQuorum        int32
+		expBelowMajority bool
+		expError         string
+	}{
+		{
+			name:      "Majority of the default sentinels",
+			expQuorum: 2,
+		},
+		{
+			name:      "Majority of the sentinels",
+			sentinels: 5,
+			expQuorum: 3,
+		},
+		{
+			name:      "Quorum above the majority",
+			sentinels: 5,
+			quorum:    5,
+			expQuorum: 5,
+		},
+		{
+			name:             "Quorum below the majority",
+			sentinels:        5,
+			quorum:           2,
+			expQuorum:        2,
+			expBelowMajority: true,
+		},
+		{
+			name:             "Single sentinel quorum",
+			sentinels:        3,
+			quorum:           1,
+			expQuorum:        1,
+			expBelowMajority: true,
+		},
+		{
+			name:      "Quorum greater than the sentinels",
+			sentinels: 3,
+			quorum:    4,
+			expError:  "sentinel.quorum can't be greater than the 3 sentinel replicas, got 4",
+		},
+		{
+			name:     "Quorum greater than the default sentinels",
+			quorum:   4,
+			expError: "sentinel.quorum can't be greater than the 3 sentinel replicas, got 4",
+		},
+		{
+			name:     "Negative quorum",
+			quorum:   -1,
+			expError: "sentinel.quorum can't be negative, got -1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Sentinel.Replicas = test.sentinels
+			rf.Spec.Sentinel.Quorum = test.quorum
+
+			err := rf.Validate()
+
+			if test.expError != "" {
+				assert.EqualError(err, test.expError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expQuorum, rf.SentinelQuorum())
+			assert.Equal(test.expBelowMajority, rf.SentinelQuorumBelowMajority())
+		})
+	}
+}
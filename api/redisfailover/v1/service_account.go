@@ -0,0 +1,8 @@
+package v1
+
+// ManagedServiceAccountEnabled returns true when the operator creates the service account of the redis
+// pods. The redis failovers created before schema revision 17 keep the default service account of their
+// namespace unless it's set.
+func (r *RedisFailover) ManagedServiceAccountEnabled() bool {
+	return r.Spec.Redis.ServiceAccountName == "" && r.Spec.Redis.ManagedServiceAccount != nil && *r.Spec.Redis.ManagedServiceAccount
+}
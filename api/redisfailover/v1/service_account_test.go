@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagedServiceAccountEnabled(t *testing.T) {
+	enabled := true
+	disabled := false
+
+	tests := []struct {
+		name           string
+		managed        *bool
+		serviceAccount string
+		expEnabled     bool
+	}{
+		{
+			name:       "Unset",
+			expEnabled: false,
+		},
+		{
+			name:       "Enabled",
+			managed:    &enabled,
+			expEnabled: true,
+		},
+		{
+			name:       "Disabled",
+			managed:    &disabled,
+			expEnabled: false,
+		},
+		{
+			name:           "Enabled with a service account set",
+			managed:        &enabled,
+			serviceAccount: "redis",
+			expEnabled:     false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Redis.ManagedServiceAccount = test.managed
+			rf.Spec.Redis.ServiceAccountName = test.serviceAccount
+
+			assert.Equal(t, test.expEnabled, rf.ManagedServiceAccountEnabled())
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// prometheusDurationRE matches the durations of prometheus, as 30s or 1m30s.
+var prometheusDurationRE = regexp.MustCompile(`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`)
+
+// RedisServiceMonitorEnabled returns true when the redis exporter is scraped with a ServiceMonitor.
+func (r *RedisFailover) RedisServiceMonitorEnabled() bool {
+	e := r.Spec.Redis.Exporter
+	return e.Enabled && e.ServiceMonitor != nil && e.ServiceMonitor.Enabled
+}
+
+// validateServiceMonitor checks the durations of the ServiceMonitor are prometheus durations. The
+// sentinel exporter has no service to be scraped with a ServiceMonitor.
+func (r *RedisFailover) validateServiceMonitor() error {
+	if r.Spec.Sentinel.Exporter.ServiceMonitor != nil {
+		return errors.New("sentinel.exporter.serviceMonitor is not supported, only the redis exporter has a service")
+	}
+	sm := r.Spec.Redis.Exporter.ServiceMonitor
+	if sm == nil {
+		return nil
+	}
+	if sm.Interval != "" && !prometheusDurationRE.MatchString(sm.Interval) {
+		return fmt.Errorf("redis.exporter.serviceMonitor.interval %q is not a prometheus duration", sm.Interval)
+	}
+	if sm.ScrapeTimeout != "" && !prometheusDurationRE.MatchString(sm.ScrapeTimeout) {
+		return fmt.Errorf("redis.exporter.serviceMonitor.scrapeTimeout %q is not a prometheus duration", sm.ScrapeTimeout)
+	}
+	return nil
+}
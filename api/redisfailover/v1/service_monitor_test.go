@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateServiceMonitor(t *testing.T) {
+	tests := []struct {
+		name            string
+		exporter        bool
+		redis           *ServiceMonitorSettings
+		sentinel        *ServiceMonitorSettings
+		expectedEnabled bool
+		expectedError   string
+	}{
+		{
+			name:     "Not set",
+			exporter: true,
+		},
+		{
+			name:            "Enabled",
+			exporter:        true,
+			redis:           &ServiceMonitorSettings{Enabled: true, Interval: "30s", ScrapeTimeout: "1m30s"},
+			expectedEnabled: true,
+		},
+		{
+			name:  "Enabled without the exporter",
+			redis: &ServiceMonitorSettings{Enabled: true},
+		},
+		{
+			name:     "Disabled",
+			exporter: true,
+			redis:    &ServiceMonitorSettings{Interval: "30s"},
+		},
+		{
+			name:          "Invalid interval",
+			exporter:      true,
+			redis:         &ServiceMonitorSettings{Enabled: true, Interval: "30 seconds"},
+			expectedError: `redis.exporter.serviceMonitor.interval "30 seconds" is not a prometheus duration`,
+		},
+		{
+			name:          "Invalid scrape timeout",
+			exporter:      true,
+			redis:         &ServiceMonitorSettings{Enabled: true, ScrapeTimeout: "1.5s"},
+			expectedError: `redis.exporter.serviceMonitor.scrapeTimeout "1.5s" is not a prometheus duration`,
+		},
+		{
+			name:          "Sentinel service monitor",
+			sentinel:      &ServiceMonitorSettings{Enabled: true},
+			expectedError: "sentinel.exporter.serviceMonitor is not supported, only the redis exporter has a service",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Redis.Exporter.Enabled = test.exporter
+			rf.Spec.Redis.Exporter.ServiceMonitor = test.redis
+			rf.Spec.Sentinel.Exporter.ServiceMonitor = test.sentinel
+			err := rf.Validate()
+			if test.expectedError != "" {
+				assert.EqualError(err, test.expectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedEnabled, rf.RedisServiceMonitorEnabled())
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package v1
+
+// SupportBundleAnnotation requests the operator to collect a support bundle of the RedisFailover into a
+// ConfigMap. Its value identifies the request, a new bundle is collected every time it changes.
+const SupportBundleAnnotation = "databases.spotahome.com/support-bundle"
+
+// SupportBundleRequest returns the support bundle request set on the RedisFailover, empty when there isn't any.
+func (r *RedisFailover) SupportBundleRequest() string {
+	return r.Annotations[SupportBundleAnnotation]
+}
@@ -0,0 +1,116 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RedisTLSPort is the port redis is served over TLS on, besides its plain port.
+const RedisTLSPort = 6380
+
+// The kinds of the cert-manager issuers.
+const (
+	TLSIssuerKind        = "Issuer"
+	TLSClusterIssuerKind = "ClusterIssuer"
+	// TLSIssuerGroup is the API group of the cert-manager issuers.
+	TLSIssuerGroup = "cert-manager.io"
+)
+
+// nativeTLSRedisVersion is the first major version of redis serving TLS itself.
+const nativeTLSRedisVersion = 6
+
+// TLSEnabled returns true when redis is served over TLS too.
+func (r *RedisFailover) TLSEnabled() bool {
+	return r.Spec.TLSConfig != nil && r.Spec.TLSConfig.Enabled
+}
+
+// TLSSidecarEnabled returns true when the TLS port is served by a stunnel sidecar instead of redis.
+func (r *RedisFailover) TLSSidecarEnabled() bool {
+	return r.TLSEnabled() && r.Spec.Redis.TLSSidecar != nil && r.Spec.Redis.TLSSidecar.Enabled
+}
+
+// validateTLS checks the TLS port is free, the redis port is not proxied, and the issuer is one of cert-manager, and defaults its kind
+// and group.
+func (r *RedisFailover) validateTLS() error {
+	if !r.TLSEnabled() {
+		return nil
+	}
+	if r.Spec.Redis.Port == RedisTLSPort {
+		return fmt.Errorf("redis.port can't be %d with tlsConfig.enabled, redis is served over TLS on it", RedisTLSPort)
+	}
+	if r.ZeroDowntimeReloadEnabled() {
+		return errors.New("tlsConfig.enabled can't be set with redis.zeroDowntimeReload, the proxy only serves the plain port")
+	}
+	issuer := r.Spec.TLSConfig.IssuerRef
+	if issuer == nil {
+		return nil
+	}
+	if issuer.Name == "" {
+		return errors.New("tlsConfig.issuerRef.name must be set")
+	}
+	switch issuer.Kind {
+	case "":
+		issuer.Kind = TLSIssuerKind
+	case TLSIssuerKind, TLSClusterIssuerKind:
+	default:
+		return fmt.Errorf("tlsConfig.issuerRef.kind must be %s or %s, got %q", TLSIssuerKind, TLSClusterIssuerKind, issuer.Kind)
+	}
+	if issuer.Group == "" {
+		issuer.Group = TLSIssuerGroup
+	}
+	return nil
+}
+
+// validateTLSSidecar checks the sidecar has the certificate of tlsConfig to serve, and that the redis
+// image can't serve TLS itself. The images without a version in their tag are trusted, the default image
+// serves TLS.
+func (r *RedisFailover) validateTLSSidecar() error {
+	sidecar := r.Spec.Redis.TLSSidecar
+	if sidecar == nil || !sidecar.Enabled {
+		return nil
+	}
+	if !r.TLSEnabled() {
+		return errors.New("redis.tlsSidecar needs tlsConfig.enabled, the sidecar serves its certificate")
+	}
+	if r.ExternalNodesEnabled() {
+		return errors.New("redis.tlsSidecar can't be used with externalNodes, the operator doesn't run their redis")
+	}
+	if sidecar.Image == "" {
+		return errors.New("redis.tlsSidecar.image must be set, with stunnel in its path")
+	}
+	image := r.Spec.Redis.Image
+	if image == "" {
+		image = defaultImage
+	}
+	if major, ok := redisImageMajorVersion(image); ok && major >= nativeTLSRedisVersion {
+		return fmt.Errorf("redis.tlsSidecar can't be used with redis %d, it serves TLS itself: remove redis.tlsSidecar to keep tlsConfig.enabled alone", major)
+	}
+	return nil
+}
+
+// redisImageMajorVersion returns the major version of redis in the tag of the image, as 5 in
+// redis:5.0.14-alpine, false when the tag doesn't start with a version.
+func redisImageMajorVersion(image string) (int, bool) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, "/"); i >= 0 {
+		image = image[i+1:]
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 {
+		return 0, false
+	}
+	tag := image[i+1:]
+	end := 0
+	for end < len(tag) && tag[end] >= '0' && tag[end] <= '9' {
+		end++
+	}
+	major, err := strconv.Atoi(tag[:end])
+	if err != nil {
+		return 0, false
+	}
+	return major, true
+}
@@ -0,0 +1,170 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTLS(t *testing.T) {
+	tests := []struct {
+		name          string
+		tlsConfig     *TLSConfig
+		port          int32
+		zdr           bool
+		expIssuer     *TLSIssuerRef
+		expectedError string
+	}{
+		{
+			name:      "Disabled",
+			tlsConfig: &TLSConfig{IssuerRef: &TLSIssuerRef{Kind: "Vault"}},
+			expIssuer: &TLSIssuerRef{Kind: "Vault"},
+		},
+		{
+			name:      "Secret made by hand",
+			tlsConfig: &TLSConfig{Enabled: true, SecretName: "redis-tls"},
+		},
+		{
+			name:      "Issuer defaulted",
+			tlsConfig: &TLSConfig{Enabled: true, IssuerRef: &TLSIssuerRef{Name: "ca"}},
+			expIssuer: &TLSIssuerRef{Name: "ca", Kind: "Issuer", Group: "cert-manager.io"},
+		},
+		{
+			name:      "Cluster issuer",
+			tlsConfig: &TLSConfig{Enabled: true, IssuerRef: &TLSIssuerRef{Name: "ca", Kind: "ClusterIssuer"}},
+			expIssuer: &TLSIssuerRef{Name: "ca", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+		},
+		{
+			name:          "Issuer without a name",
+			tlsConfig:     &TLSConfig{Enabled: true, IssuerRef: &TLSIssuerRef{}},
+			expectedError: "tlsConfig.issuerRef.name must be set",
+		},
+		{
+			name:          "Unknown issuer kind",
+			tlsConfig:     &TLSConfig{Enabled: true, IssuerRef: &TLSIssuerRef{Name: "ca", Kind: "Vault"}},
+			expectedError: `tlsConfig.issuerRef.kind must be Issuer or ClusterIssuer, got "Vault"`,
+		},
+		{
+			name:          "Redis on the TLS port",
+			tlsConfig:     &TLSConfig{Enabled: true},
+			port:          6380,
+			expectedError: "redis.port can't be 6380 with tlsConfig.enabled, redis is served over TLS on it",
+		},
+		{
+			name:          "Zero downtime reload",
+			tlsConfig:     &TLSConfig{Enabled: true},
+			zdr:           true,
+			expectedError: "tlsConfig.enabled can't be set with redis.zeroDowntimeReload, the proxy only serves the plain port",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.TLSConfig = test.tlsConfig
+			if test.port != 0 {
+				rf.Spec.Redis.Port = test.port
+			}
+			if test.zdr {
+				rf.Spec.Redis.ZeroDowntimeReload = ZeroDowntimeReload{Enabled: true, Image: "proxy"}
+			}
+
+			err := rf.Validate()
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expIssuer, rf.Spec.TLSConfig.IssuerRef)
+		})
+	}
+}
+
+func TestValidateTLSSidecar(t *testing.T) {
+	tests := []struct {
+		name          string
+		tlsConfig     *TLSConfig
+		sidecar       *RedisTLSSidecar
+		image         string
+		expEnabled    bool
+		expectedError string
+	}{
+		{
+			name:      "Disabled",
+			tlsConfig: &TLSConfig{Enabled: true},
+			sidecar:   &RedisTLSSidecar{},
+			image:     "redis:5.0.14-alpine",
+		},
+		{
+			name:       "Redis 5",
+			tlsConfig:  &TLSConfig{Enabled: true},
+			sidecar:    &RedisTLSSidecar{Enabled: true, Image: "stunnel:5"},
+			image:      "redis:5.0.14-alpine",
+			expEnabled: true,
+		},
+		{
+			name:       "Redis 4 from a registry with a port",
+			tlsConfig:  &TLSConfig{Enabled: true},
+			sidecar:    &RedisTLSSidecar{Enabled: true, Image: "stunnel:5"},
+			image:      "registry.example.com:5000/cache/redis:4.0",
+			expEnabled: true,
+		},
+		{
+			name:       "Image without a version",
+			tlsConfig:  &TLSConfig{Enabled: true},
+			sidecar:    &RedisTLSSidecar{Enabled: true, Image: "stunnel:5"},
+			image:      "redis:legacy",
+			expEnabled: true,
+		},
+		{
+			name:          "Redis 6",
+			tlsConfig:     &TLSConfig{Enabled: true},
+			sidecar:       &RedisTLSSidecar{Enabled: true, Image: "stunnel:5"},
+			image:         "redis:6.2.6-alpine",
+			expectedError: "redis.tlsSidecar can't be used with redis 6, it serves TLS itself: remove redis.tlsSidecar to keep tlsConfig.enabled alone",
+		},
+		{
+			name:          "Default redis image",
+			tlsConfig:     &TLSConfig{Enabled: true},
+			sidecar:       &RedisTLSSidecar{Enabled: true, Image: "stunnel:5"},
+			expectedError: "redis.tlsSidecar can't be used with redis 6, it serves TLS itself: remove redis.tlsSidecar to keep tlsConfig.enabled alone",
+		},
+		{
+			name:          "Redis 7 pinned by digest",
+			tlsConfig:     &TLSConfig{Enabled: true},
+			sidecar:       &RedisTLSSidecar{Enabled: true, Image: "stunnel:5"},
+			image:         "redis:7.0@sha256:0123456789abcdef",
+			expectedError: "redis.tlsSidecar can't be used with redis 7, it serves TLS itself: remove redis.tlsSidecar to keep tlsConfig.enabled alone",
+		},
+		{
+			name:          "Without TLS",
+			sidecar:       &RedisTLSSidecar{Enabled: true, Image: "stunnel:5"},
+			image:         "redis:5.0.14-alpine",
+			expectedError: "redis.tlsSidecar needs tlsConfig.enabled, the sidecar serves its certificate",
+		},
+		{
+			name:          "Without an image",
+			tlsConfig:     &TLSConfig{Enabled: true},
+			sidecar:       &RedisTLSSidecar{Enabled: true},
+			image:         "redis:5.0.14-alpine",
+			expectedError: "redis.tlsSidecar.image must be set, with stunnel in its path",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.TLSConfig = test.tlsConfig
+			rf.Spec.Redis.TLSSidecar = test.sidecar
+			rf.Spec.Redis.Image = test.image
+
+			err := rf.Validate()
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expEnabled, rf.TLSSidecarEnabled())
+		})
+	}
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -13,11 +14,16 @@ import (
 // +kubebuilder:printcolumn:name="REDIS",type="integer",JSONPath=".spec.redis.replicas"
 // +kubebuilder:printcolumn:name="SENTINELS",type="integer",JSONPath=".spec.sentinel.replicas"
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:printcolumn:name="RESTARTS",type="integer",JSONPath=".status.restarts",priority=1
+// +kubebuilder:printcolumn:name="LASTREASON",type="string",JSONPath=".status.lastRestartReason",priority=1
 // +kubebuilder:resource:singular=redisfailover,path=redisfailovers,shortName=rf,scope=Namespaced
+// +kubebuilder:subresource:status
+// +kubebuilder:metadata:annotations="databases.spotahome.com/schema-revision=38"
 type RedisFailover struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
-	Spec              RedisFailoverSpec `json:"spec"`
+	Spec              RedisFailoverSpec   `json:"spec"`
+	Status            RedisFailoverStatus `json:"status,omitempty"`
 }
 
 // RedisFailoverSpec represents a Redis failover spec
@@ -27,6 +33,298 @@ type RedisFailoverSpec struct {
 	Auth           AuthSettings       `json:"auth,omitempty"`
 	LabelWhitelist []string           `json:"labelWhitelist,omitempty"`
 	BootstrapNode  *BootstrapSettings `json:"bootstrapNode,omitempty"`
+	Failover       FailoverSettings   `json:"failover,omitempty"`
+	// Backup enables the periodic backups of the redis data.
+	Backup *BackupSettings `json:"backup,omitempty"`
+	// Monitoring defines the metrics exported by the operator on the RF.
+	Monitoring *MonitoringSettings `json:"monitoring,omitempty"`
+	// Hardening runs the redis and the sentinel pods on clusters enforcing the restricted pod security.
+	Hardening *HardeningSettings `json:"hardening,omitempty"`
+	// NetworkPolicy isolates the redis and the sentinel pods, only the pods of the RF and the allowed
+	// clients reach them.
+	NetworkPolicy *NetworkPolicySettings `json:"networkPolicy,omitempty"`
+	// SentinelAutoScaling scales the sentinels with a HorizontalPodAutoscaler, from sentinel.replicas.
+	SentinelAutoScaling *SentinelAutoScalingSettings `json:"sentinelAutoScaling,omitempty"`
+	// Protection defines how the operator reacts to a suspected loss of the redis data.
+	Protection *ProtectionSettings `json:"protection,omitempty"`
+	// TLSConfig serves redis over TLS too, with a certificate issued by cert-manager.
+	TLSConfig *TLSConfig `json:"tlsConfig,omitempty"`
+	// Checks defines the checks the operator runs on the redis pods besides the PING.
+	Checks *ChecksSettings `json:"checks,omitempty"`
+	// ImmutableConfig writes the redis and the sentinel configurations in immutable ConfigMaps named with
+	// the hash of their content, so a change of the configuration rolls the pods.
+	ImmutableConfig bool `json:"immutableConfig,omitempty"`
+}
+
+// ChecksSettings defines the checks of the redis pods
+type ChecksSettings struct {
+	// FunctionalProbe writes a key on the master on every check and reads it back from every replica, so
+	// a redis answering the PING but failing the reads or the writes is found. The key is
+	// _redisoperator:healthcheck:<uid of the RF>, it expires on its own.
+	FunctionalProbe bool `json:"functionalProbe,omitempty"`
+	// ReplicationDelay is how long the replicas are given to replicate the key before it's read back
+	// from them. 500ms when it's not set.
+	ReplicationDelay *metav1.Duration `json:"replicationDelay,omitempty"`
+}
+
+// TLSConfig defines the TLS port of the redis pods
+type TLSConfig struct {
+	// Enabled serves redis over TLS on the port 6380, besides its plain port.
+	Enabled bool `json:"enabled,omitempty"`
+	// SecretName is the secret holding the certificate of the redis pods, in the tls.crt and tls.key
+	// keys. It's rfr-tls-<name> when not set.
+	SecretName string `json:"secretName,omitempty"`
+	// IssuerRef is the cert-manager issuer of the certificate. Without it, the secret is not written by
+	// the operator and must be created by hand.
+	IssuerRef *TLSIssuerRef `json:"issuerRef,omitempty"`
+}
+
+// TLSIssuerRef references a cert-manager issuer
+type TLSIssuerRef struct {
+	Name string `json:"name"`
+	// Kind is Issuer or ClusterIssuer, Issuer when not set.
+	Kind string `json:"kind,omitempty"`
+	// Group is the API group of the issuer, cert-manager.io when not set.
+	Group string `json:"group,omitempty"`
+}
+
+// ProtectionSettings defines how the operator reacts to a mass deletion of the keys of the master
+type ProtectionSettings struct {
+	// MassDeletionThresholdPercent is the share of the keys of the master that, when deleted between two
+	// checks without a failover nor a restart of the master, is reported as a possible FLUSHALL. 50 when
+	// it's not set.
+	MassDeletionThresholdPercent *int32 `json:"massDeletionThresholdPercent,omitempty"`
+	// FreezeOnMassDeletion pauses the reconfiguration, the restarts and the updates of the replicas while
+	// a mass deletion is reported, so the replicas that did not replicate it are kept to recover the data.
+	// The unfreeze annotation lifts it.
+	FreezeOnMassDeletion bool `json:"freezeOnMassDeletion,omitempty"`
+}
+
+// SentinelAutoScalingSettings defines the HorizontalPodAutoscaler of the sentinel deployment
+type SentinelAutoScalingSettings struct {
+	// MaxReplicas is the most sentinels the autoscaler runs, the fewest are sentinel.replicas.
+	MaxReplicas int32 `json:"maxReplicas"`
+	// TargetCPUUtilizationPercentage is the average CPU usage of the sentinels the autoscaler keeps,
+	// relative to their requests. It's 80 when neither target is set.
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// TargetMemoryUtilizationPercentage is the average memory usage of the sentinels the autoscaler
+	// keeps, relative to their requests.
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+}
+
+// NetworkPolicySettings defines the NetworkPolicies of the redis and the sentinel pods
+type NetworkPolicySettings struct {
+	// Enabled generates the NetworkPolicies, it's true when not set. Setting it to false deletes them.
+	Enabled *bool `json:"enabled,omitempty"`
+	// AllowedClients are the peers allowed to connect to the redis and the sentinel ports besides the
+	// redis and the sentinel pods of the RF. The operator connects to the pods too, its pods must be allowed.
+	AllowedClients []networkingv1.NetworkPolicyPeer `json:"allowedClients,omitempty"`
+	// AllowedNamespaces selects the namespaces whose pods are allowed to connect to the redis, the
+	// sentinel and the exporter ports.
+	AllowedNamespaces *metav1.LabelSelector `json:"allowedNamespaces,omitempty"`
+}
+
+// HardeningSettings defines how the redis and the sentinel pods run on hardened clusters
+type HardeningSettings struct {
+	// ReadOnlyRootFilesystem runs the containers with a read-only root filesystem as the user of the
+	// redis image. The directories redis and sentinel write to are mounted from volumes.
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitempty"`
+}
+
+// MonitoringSettings defines the metrics exported by the operator on the RF
+type MonitoringSettings struct {
+	// MetricLabels are added to the metrics of the operator on the RF, as its team or tier. At most
+	// 5 labels, they can't be named as the labels of the metrics.
+	MetricLabels map[string]string `json:"metricLabels,omitempty"`
+}
+
+// BackupSettings defines the periodic backups of the redis data
+type BackupSettings struct {
+	// Method is how the data is backed up. Only VolumeSnapshot is supported: a CSI snapshot of the
+	// persistent volume claim of a replica.
+	Method BackupMethod `json:"method"`
+	// Interval is the time between two backups, 24h when it's not set.
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Retention is the number of backups kept, the oldest ones are deleted. 7 when it's not set.
+	Retention int32 `json:"retention,omitempty"`
+	// VolumeSnapshotClassName is the class of the snapshots, the default class of the CSI driver
+	// when it's not set.
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+}
+
+// BackupMethod is how the redis data is backed up
+type BackupMethod string
+
+// Backup methods
+const (
+	// BackupMethodVolumeSnapshot takes a CSI snapshot of the persistent volume claim of a replica.
+	BackupMethodVolumeSnapshot BackupMethod = "VolumeSnapshot"
+)
+
+// FailoverSettings defines how the operator heals the replication of the redis
+type FailoverSettings struct {
+	// MaxConcurrentSyncs is the number of replicas allowed to make a full sync from the master at
+	// the same time. The replicas over the limit are pointed to the master on later checks. 0 means
+	// no limit, it's kept on the RFs defaulted before the limit existed.
+	MaxConcurrentSyncs int32 `json:"maxConcurrentSyncs,omitempty"`
+	// StabilizationSeconds is how long the disruptive actions on the redis pods are held after a
+	// failover, so the new master is not restarted or reconfigured right away. 0 disables it, it's
+	// kept on the RFs defaulted before it existed.
+	StabilizationSeconds *int32 `json:"stabilizationSeconds,omitempty"`
+	// StuckReplicas defines how the replicas stuck with the link to the master down are remediated.
+	StuckReplicas StuckReplicaSettings `json:"stuckReplicas,omitempty"`
+}
+
+// StuckReplicaSettings defines the remediation of the replicas whose link to the master stays down
+type StuckReplicaSettings struct {
+	// Threshold is the number of consecutive checks a replica must be stuck to escalate its
+	// remediation: its replication is retried first, then it's restarted. 0 disables it, it's
+	// kept on the RFs defaulted before it existed.
+	Threshold *int32 `json:"threshold,omitempty"`
+	// RaiseBacklog doubles the repl-backlog-size of the master, up to 1gb, when its partial syncs
+	// fail more than they succeed while a replica is stuck. It's not raised when repl-backlog-size
+	// is set in the custom config.
+	RaiseBacklog bool `json:"raiseBacklog,omitempty"`
+}
+
+// RedisFailoverStatus represents the observed state of a Redis failover
+type RedisFailoverStatus struct {
+	// Restarts is the restart count of the container that restarted the most.
+	Restarts int32 `json:"restarts,omitempty"`
+	// LastRestartReason is the last termination reason of the container that restarted the most,
+	// prefixed with the pod and container names.
+	LastRestartReason string           `json:"lastRestartReason,omitempty"`
+	Instances         []InstanceStatus `json:"instances,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// LastFailover is the timeline of the last master failover seen by the sentinels.
+	LastFailover *FailoverStatus `json:"lastFailover,omitempty"`
+	// Health is the summary of the conditions and the pods readiness.
+	Health *HealthReport `json:"health,omitempty"`
+	// Backups are the backups of the redis data kept by the operator, oldest first.
+	Backups []BackupStatus `json:"backups,omitempty"`
+	// PlacementSummary counts the redis pods by the failure domains of their nodes.
+	PlacementSummary *PlacementSummary `json:"placementSummary,omitempty"`
+	// SentinelQuorum is the quorum set on the sentinels.
+	SentinelQuorum int32 `json:"sentinelQuorum,omitempty"`
+	// LastDiagnosis is the last diagnosis of the redis pods requested with the diagnose annotation.
+	LastDiagnosis *DiagnosisStatus `json:"lastDiagnosis,omitempty"`
+	// LastDataFlush is the last mass deletion of the keys of the master seen by the operator.
+	LastDataFlush *DataFlushStatus `json:"lastDataFlush,omitempty"`
+	// LastReconcileStats are the results of the checks of the last reconcile.
+	LastReconcileStats *ReconcileStats `json:"lastReconcileStats,omitempty"`
+}
+
+// DataFlushStatus represents a mass deletion of the keys of the master, as a FLUSHALL would do
+type DataFlushStatus struct {
+	// Pod is the master whose keys were deleted.
+	Pod string `json:"pod"`
+	// PreviousKeys and Keys are the keys of the master on the check before the deletion and after it.
+	PreviousKeys int64 `json:"previousKeys"`
+	Keys         int64 `json:"keys"`
+	// DetectedAt is when the operator saw the deletion.
+	DetectedAt metav1.Time `json:"detectedAt"`
+	// Unfreeze is the value of the unfreeze annotation when the deletion was seen, the deletion is
+	// acknowledged when the annotation is set to another value.
+	Unfreeze string `json:"unfreeze,omitempty"`
+	// AcknowledgedAt is when the deletion was acknowledged with the unfreeze annotation.
+	AcknowledgedAt *metav1.Time `json:"acknowledgedAt,omitempty"`
+}
+
+// DiagnosisStatus represents a diagnosis of the redis pods by the redis doctors
+type DiagnosisStatus struct {
+	// Request is the value of the diagnose annotation diagnosed.
+	Request string `json:"request"`
+	// Pods are the redis pods diagnosed.
+	Pods []string `json:"pods,omitempty"`
+	// ConfigMap holds the reports of the memory and latency doctors of every pod.
+	ConfigMap string `json:"configMap"`
+	// Time is when the pods were diagnosed.
+	Time metav1.Time `json:"time"`
+}
+
+// PlacementSummary represents how the redis pods are spread across the failure domains
+type PlacementSummary struct {
+	// Zones counts the redis pods by the zone of their node.
+	Zones map[string]int32 `json:"zones,omitempty"`
+	// Racks counts the redis pods by the rack of their node.
+	Racks map[string]int32 `json:"racks,omitempty"`
+	// Unknown counts the redis pods not scheduled yet, or whose node has neither a zone nor a rack.
+	Unknown int32 `json:"unknown,omitempty"`
+}
+
+// BackupStatus represents a backup of the redis data
+type BackupStatus struct {
+	// Name is the name of the VolumeSnapshot.
+	Name string `json:"name"`
+	// PersistentVolumeClaim is the claim of the replica the backup was taken from.
+	PersistentVolumeClaim string `json:"persistentVolumeClaim"`
+	// CreatedAt is when the backup was requested.
+	CreatedAt metav1.Time `json:"createdAt"`
+	// ReadyToUse is true once the backup can be restored.
+	ReadyToUse bool `json:"readyToUse"`
+}
+
+// FailoverStatus represents the phase timings of a master failover
+type FailoverStatus struct {
+	// DetectedAt is when the master was marked as objectively down.
+	DetectedAt *metav1.MicroTime `json:"detectedAt,omitempty"`
+	// PromotedAt is when the replica was promoted, it's only known when the failover was
+	// driven by the watched sentinel.
+	PromotedAt *metav1.MicroTime `json:"promotedAt,omitempty"`
+	// ReconfiguredAt is when the sentinels switched to the new master.
+	ReconfiguredAt *metav1.MicroTime `json:"reconfiguredAt,omitempty"`
+	// TotalSeconds is the time from the detection to the reconfiguration, rounded to seconds.
+	TotalSeconds int64 `json:"totalSeconds,omitempty"`
+}
+
+// InstanceStatus represents the observed state of a redis or sentinel pod
+type InstanceStatus struct {
+	Name       string                   `json:"name"`
+	Role       string                   `json:"role"`
+	Restarts   int32                    `json:"restarts"`
+	Containers []ContainerRestartStatus `json:"containers,omitempty"`
+	// State is set when the instance is waiting for the operator to act on it, or for kubernetes.
+	State string `json:"state,omitempty"`
+	// Message tells why the instance is in its state.
+	Message string `json:"message,omitempty"`
+	// Node is the node the pod is scheduled on.
+	Node string `json:"node,omitempty"`
+	// Zone and Rack are the failure domains of the pod, from its topology labels or from the labels
+	// of its node.
+	Zone string `json:"zone,omitempty"`
+	Rack string `json:"rack,omitempty"`
+	// Keys are the keys of every database of a redis instance, from the keyspace section of its INFO.
+	Keys map[string]int64 `json:"keys,omitempty"`
+	// Functional is the result of the functional probe of a redis instance, Healthy when it served the
+	// key of the probe, see spec.checks.functionalProbe. An instance answering the PING can be Unhealthy.
+	Functional string `json:"functional,omitempty"`
+	// FunctionalMessage tells why the instance failed the functional probe.
+	FunctionalMessage string `json:"functionalMessage,omitempty"`
+}
+
+// Instance states set on the RedisFailover status
+const (
+	// InstanceStateWaitingForSyncSlot is set on the replicas that wait to be pointed to the master
+	// because it's already making as many full syncs as allowed.
+	InstanceStateWaitingForSyncSlot = "WaitingForSyncSlot"
+	// InstanceStateWaitingForVolume is set on the redis pods whose containers are not created because
+	// their volumes can't be attached or mounted yet.
+	InstanceStateWaitingForVolume = "WaitingForVolume"
+)
+
+// Functional probe results set on the redis instances of the RedisFailover status
+const (
+	InstanceFunctionalHealthy   = "Healthy"
+	InstanceFunctionalUnhealthy = "Unhealthy"
+)
+
+// ContainerRestartStatus represents the restarts of a container of an instance
+type ContainerRestartStatus struct {
+	Name              string `json:"name"`
+	Restarts          int32  `json:"restarts"`
+	LastRestartReason string `json:"lastRestartReason,omitempty"`
 }
 
 // RedisCommandRename defines the specification of a "rename-command" configuration option
@@ -37,35 +335,133 @@ type RedisCommandRename struct {
 
 // RedisSettings defines the specification of the redis cluster
 type RedisSettings struct {
-	Image                         string                            `json:"image,omitempty"`
-	ImagePullPolicy               corev1.PullPolicy                 `json:"imagePullPolicy,omitempty"`
-	Replicas                      int32                             `json:"replicas,omitempty"`
-	Port                          int32                             `json:"port,omitempty"`
-	Resources                     corev1.ResourceRequirements       `json:"resources,omitempty"`
-	CustomConfig                  []string                          `json:"customConfig,omitempty"`
-	CustomCommandRenames          []RedisCommandRename              `json:"customCommandRenames,omitempty"`
-	Command                       []string                          `json:"command,omitempty"`
-	ShutdownConfigMap             string                            `json:"shutdownConfigMap,omitempty"`
-	Storage                       RedisStorage                      `json:"storage,omitempty"`
-	InitContainers                []corev1.Container                `json:"initContainers,omitempty"`
-	Exporter                      Exporter                          `json:"exporter,omitempty"`
-	ExtraContainers               []corev1.Container                `json:"extraContainers,omitempty"`
-	Affinity                      *corev1.Affinity                  `json:"affinity,omitempty"`
-	SecurityContext               *corev1.PodSecurityContext        `json:"securityContext,omitempty"`
-	ContainerSecurityContext      *corev1.SecurityContext           `json:"containerSecurityContext,omitempty"`
-	ImagePullSecrets              []corev1.LocalObjectReference     `json:"imagePullSecrets,omitempty"`
-	Tolerations                   []corev1.Toleration               `json:"tolerations,omitempty"`
-	TopologySpreadConstraints     []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
-	NodeSelector                  map[string]string                 `json:"nodeSelector,omitempty"`
-	PodAnnotations                map[string]string                 `json:"podAnnotations,omitempty"`
-	ServiceAnnotations            map[string]string                 `json:"serviceAnnotations,omitempty"`
-	HostNetwork                   bool                              `json:"hostNetwork,omitempty"`
-	DNSPolicy                     corev1.DNSPolicy                  `json:"dnsPolicy,omitempty"`
-	PriorityClassName             string                            `json:"priorityClassName,omitempty"`
-	ServiceAccountName            string                            `json:"serviceAccountName,omitempty"`
-	TerminationGracePeriodSeconds int64                             `json:"terminationGracePeriod,omitempty"`
-	ExtraVolumes                  []corev1.Volume                   `json:"extraVolumes,omitempty"`
-	ExtraVolumeMounts             []corev1.VolumeMount              `json:"extraVolumeMounts,omitempty"`
+	Image                          string                            `json:"image,omitempty"`
+	ImagePullPolicy                corev1.PullPolicy                 `json:"imagePullPolicy,omitempty"`
+	Replicas                       int32                             `json:"replicas,omitempty"`
+	Port                           int32                             `json:"port,omitempty"`
+	Resources                      corev1.ResourceRequirements       `json:"resources,omitempty"`
+	CustomConfig                   []string                          `json:"customConfig,omitempty"`
+	CustomCommandRenames           []RedisCommandRename              `json:"customCommandRenames,omitempty"`
+	Command                        []string                          `json:"command,omitempty"`
+	ShutdownConfigMap              string                            `json:"shutdownConfigMap,omitempty"`
+	Storage                        RedisStorage                      `json:"storage,omitempty"`
+	DataDir                        string                            `json:"dataDir,omitempty"`
+	InitContainers                 []corev1.Container                `json:"initContainers,omitempty"`
+	Exporter                       Exporter                          `json:"exporter,omitempty"`
+	ExtraContainers                []corev1.Container                `json:"extraContainers,omitempty"`
+	Affinity                       *corev1.Affinity                  `json:"affinity,omitempty"`
+	SecurityContext                *corev1.PodSecurityContext        `json:"securityContext,omitempty"`
+	ContainerSecurityContext       *corev1.SecurityContext           `json:"containerSecurityContext,omitempty"`
+	ImagePullSecrets               []corev1.LocalObjectReference     `json:"imagePullSecrets,omitempty"`
+	Tolerations                    []corev1.Toleration               `json:"tolerations,omitempty"`
+	TopologySpreadConstraints      []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	NodeSelector                   map[string]string                 `json:"nodeSelector,omitempty"`
+	PodAnnotations                 map[string]string                 `json:"podAnnotations,omitempty"`
+	ServiceAnnotations             map[string]string                 `json:"serviceAnnotations,omitempty"`
+	HostNetwork                    bool                              `json:"hostNetwork,omitempty"`
+	DNSPolicy                      corev1.DNSPolicy                  `json:"dnsPolicy,omitempty"`
+	PriorityClassName              string                            `json:"priorityClassName,omitempty"`
+	ServiceAccountName             string                            `json:"serviceAccountName,omitempty"`
+	TerminationGracePeriodSeconds  int64                             `json:"terminationGracePeriod,omitempty"` // Deprecated: use TerminationGracePeriodDuration.
+	TerminationGracePeriodDuration *metav1.Duration                  `json:"terminationGracePeriodDuration,omitempty"`
+	ExtraVolumes                   []corev1.Volume                   `json:"extraVolumes,omitempty"`
+	ExtraVolumeMounts              []corev1.VolumeMount              `json:"extraVolumeMounts,omitempty"`
+	MasterDNS                      *RedisMasterDNS                   `json:"masterDNS,omitempty"`
+	ExternalNodes                  []RedisExternalNode               `json:"externalNodes,omitempty"`
+	NodeTuningInitContainer        bool                              `json:"nodeTuningInitContainer,omitempty"`
+	ZeroDowntimeReload             ZeroDowntimeReload                `json:"zeroDowntimeReload,omitempty"`
+	BindAddresses                  []string                          `json:"bindAddresses,omitempty"`
+	ReplicationInterface           *RedisReplicationInterface        `json:"replicationInterface,omitempty"`
+	PodDisruptionBudget            *RedisPodDisruptionBudget         `json:"podDisruptionBudget,omitempty"`
+	Service                        *RedisServiceSettings             `json:"service,omitempty"`
+	// PreferredIPFamily is the family of the IP the operator connects to the dual-stack pods with, IPv4
+	// or IPv6. The primary IP of the pods is used when it's empty or the pods have no IP of the family.
+	PreferredIPFamily corev1.IPFamily `json:"preferredIPFamily,omitempty"`
+	// ManagedServiceAccount makes the operator create the service account of the redis pods, bound to a
+	// Role with the permissions they need. It's ignored when serviceAccountName is set.
+	ManagedServiceAccount *bool `json:"managedServiceAccount,omitempty"`
+	// TLSSidecar serves the TLS port of tlsConfig with a stunnel sidecar forwarding to redis, for the
+	// redis versions without native TLS.
+	TLSSidecar *RedisTLSSidecar `json:"tlsSidecar,omitempty"`
+	// InstanceOverrides are the settings of single redis pods, keyed by their ordinal in the statefulset.
+	// They are written in the configuration of the pod and applied to it at runtime.
+	InstanceOverrides map[string]RedisInstanceOverride `json:"instanceOverrides,omitempty"`
+	// CountNonPromotable counts the redis pods with a replicaPriority of 0 in instanceOverrides in the
+	// healthy replicas: the pods the PodDisruptionBudget keeps available and the ready pods of the
+	// health report. They are left out by default, so only the replicas that can be promoted are counted.
+	CountNonPromotable bool `json:"countNonPromotable,omitempty"`
+}
+
+// RedisInstanceOverride overrides the settings of the redis pod of an ordinal
+type RedisInstanceOverride struct {
+	// ReplicaPriority is the replica-priority of the pod. A pod with a priority of 0 is never promoted,
+	// neither by the sentinels nor by the operator.
+	ReplicaPriority *int32 `json:"replicaPriority,omitempty"`
+	// CustomConfig is added to the customConfig of the redis pods for this one.
+	CustomConfig []string `json:"customConfig,omitempty"`
+	// Resources replace the requests and limits of the redis container of the pod, by resource. The pod
+	// is resized in place, on the clusters supporting it.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Labels are added to the pod.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// RedisServiceSettings customizes the Services of the redis and the sentinels
+type RedisServiceSettings struct {
+	// IPFamilyPolicy is SingleStack, PreferDualStack or RequireDualStack. It's omitted with the
+	// IPFamilies on the clusters not supporting dual-stack Services.
+	IPFamilyPolicy *corev1.IPFamilyPolicyType `json:"ipFamilyPolicy,omitempty"`
+	// IPFamilies are the IPv4 and IPv6 families of the Services, the first one is their primary family.
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+}
+
+// RedisPodDisruptionBudget customizes the PodDisruptionBudget of the redis pods
+type RedisPodDisruptionBudget struct {
+	// UnhealthyPodEvictionPolicy is IfHealthyBudget or AlwaysAllow, the latter lets the redis pods not
+	// ready, as a crash-looping replica, be evicted when a node is drained. It's omitted on the clusters
+	// older than 1.27.
+	UnhealthyPodEvictionPolicy string `json:"unhealthyPodEvictionPolicy,omitempty"`
+}
+
+// RedisReplicationInterface makes the redis replicate, and the sentinels monitor them, on an address of
+// their node other than the pod one, read from an annotation of the node when the pod starts
+type RedisReplicationInterface struct {
+	NodeAnnotation  string                       `json:"nodeAnnotation,omitempty"`
+	Image           string                       `json:"image,omitempty"`
+	ImagePullPolicy corev1.PullPolicy            `json:"imagePullPolicy,omitempty"`
+	Resources       *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ZeroDowntimeReload runs redis on a socket behind a proxy sidecar holding the redis port, so the redis
+// process is restarted in place for the config redis can't set at runtime, without recreating the pod
+type ZeroDowntimeReload struct {
+	Enabled         bool                         `json:"enabled,omitempty"`
+	Image           string                       `json:"image,omitempty"`
+	ImagePullPolicy corev1.PullPolicy            `json:"imagePullPolicy,omitempty"`
+	Resources       *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// RedisTLSSidecar runs stunnel in the redis pods, serving the certificate of tlsConfig on the TLS port
+// and forwarding the connections to the plain redis port
+type RedisTLSSidecar struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Image runs stunnel, the sidecar runs the stunnel binary of its path with the config of the operator.
+	Image           string                       `json:"image,omitempty"`
+	ImagePullPolicy corev1.PullPolicy            `json:"imagePullPolicy,omitempty"`
+	Resources       *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// RedisExternalNode is a redis instance not managed by the operator that the sentinels monitor
+type RedisExternalNode struct {
+	Host string `json:"host,omitempty"`
+	Port string `json:"port,omitempty"`
+}
+
+// RedisMasterDNS defines the external-dns records pointing to the current redis master
+type RedisMasterDNS struct {
+	Hostname    string           `json:"hostname,omitempty"`
+	TTL         int32            `json:"ttl,omitempty"` // Deprecated: use TTLDuration.
+	TTLDuration *metav1.Duration `json:"ttlDuration,omitempty"`
 }
 
 // SentinelSettings defines the specification of the sentinel cluster
@@ -73,8 +469,10 @@ type SentinelSettings struct {
 	Image                     string                            `json:"image,omitempty"`
 	ImagePullPolicy           corev1.PullPolicy                 `json:"imagePullPolicy,omitempty"`
 	Replicas                  int32                             `json:"replicas,omitempty"`
+	Quorum                    int32                             `json:"quorum,omitempty"`
 	Resources                 corev1.ResourceRequirements       `json:"resources,omitempty"`
 	CustomConfig              []string                          `json:"customConfig,omitempty"`
+	GlobalConfig              []string                          `json:"globalConfig,omitempty"`
 	Command                   []string                          `json:"command,omitempty"`
 	Affinity                  *corev1.Affinity                  `json:"affinity,omitempty"`
 	SecurityContext           *corev1.PodSecurityContext        `json:"securityContext,omitempty"`
@@ -100,8 +498,31 @@ type SentinelSettings struct {
 // AuthSettings contains settings about auth
 type AuthSettings struct {
 	SecretPath string `json:"secretPath,omitempty"`
+	// GenerateSecret has the operator create the secret of the password, named rfr-<name>-auth, with a
+	// random password. It's rotated with the rotate-password annotation.
+	GenerateSecret bool `json:"generateSecret,omitempty"`
+	// Mode is how the redis pods get the password, Secret when it's not set.
+	Mode AuthMode `json:"mode,omitempty"`
+	// PasswordFile is the file of the redis pods setting requirepass and masterauth, maintained by an
+	// agent like the Vault one. It's only used with the File mode.
+	PasswordFile string `json:"passwordFile,omitempty"`
+	// ACLConfigMapRef is a ConfigMap holding the ACL users of redis in its users.acl key, set as the
+	// aclfile of the redis pods. Its changes are loaded with ACL LOAD, the pods are not restarted.
+	ACLConfigMapRef *corev1.LocalObjectReference `json:"aclConfigMapRef,omitempty"`
 }
 
+// AuthMode is how the redis pods get the password
+type AuthMode string
+
+// Auth modes
+const (
+	// AuthModeSecret renders the password of the secret of secretPath in the config of the redis pods.
+	AuthModeSecret AuthMode = "Secret"
+	// AuthModeFile includes the password file in the config of the redis pods, the password is never
+	// rendered by the operator.
+	AuthModeFile AuthMode = "File"
+)
+
 // BootstrapSettings contains settings about a potential bootstrap node
 type BootstrapSettings struct {
 	Host           string `json:"host,omitempty"`
@@ -118,6 +539,22 @@ type Exporter struct {
 	Args                     []string                     `json:"args,omitempty"`
 	Env                      []corev1.EnvVar              `json:"env,omitempty"`
 	Resources                *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// ServiceMonitor scrapes the redis exporter with a prometheus-operator ServiceMonitor. It's only
+	// supported on the redis exporter.
+	ServiceMonitor *ServiceMonitorSettings `json:"serviceMonitor,omitempty"`
+}
+
+// ServiceMonitorSettings defines the prometheus-operator ServiceMonitor of an exporter
+type ServiceMonitorSettings struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Interval is how often prometheus scrapes the exporter, as a prometheus duration. The interval of
+	// prometheus when not set.
+	Interval string `json:"interval,omitempty"`
+	// ScrapeTimeout is how long prometheus waits for the exporter, as a prometheus duration. The
+	// timeout of prometheus when not set.
+	ScrapeTimeout string `json:"scrapeTimeout,omitempty"`
+	// Labels are added to the ServiceMonitor, for the serviceMonitorSelector of prometheus.
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // SentinelConfigCopy defines the specification for the sentinel exporter
@@ -3,17 +3,81 @@ package v1
 import (
 	"errors"
 	"fmt"
+	"net"
 	"strconv"
 )
 
 const (
-	maxNameLength = 48
+	maxNameLength           = 48
+	maxStabilizationSeconds = 3600
 )
 
 // Validate set the values by default if not defined and checks if the values given are valid
 func (r *RedisFailover) Validate() error {
-	if len(r.Name) > maxNameLength {
-		return fmt.Errorf("name length can't be higher than %d", maxNameLength)
+	if err := r.validateNaming(); err != nil {
+		return err
+	}
+
+	if err := r.validateCustomConfig(); err != nil {
+		return err
+	}
+
+	if err := r.validateAuth(); err != nil {
+		return err
+	}
+
+	if err := r.validateACL(); err != nil {
+		return err
+	}
+
+	if err := r.validateAdoption(); err != nil {
+		return err
+	}
+
+	if err := r.validateZeroDowntimeReload(); err != nil {
+		return err
+	}
+
+	if err := r.validateImmutableConfig(); err != nil {
+		return err
+	}
+
+	if err := r.validateRedisNetwork(); err != nil {
+		return err
+	}
+
+	if err := r.validateHardening(); err != nil {
+		return err
+	}
+
+	if err := r.validateDataDir(); err != nil {
+		return err
+	}
+
+	if err := r.validatePodDisruptionBudget(); err != nil {
+		return err
+	}
+
+	if err := r.validateIPFamilies(); err != nil {
+		return err
+	}
+
+	if err := r.validateNetworkPolicy(); err != nil {
+		return err
+	}
+
+	if err := r.validateTLS(); err != nil {
+		return err
+	}
+
+	if err := r.validateTLSSidecar(); err != nil {
+		return err
+	}
+
+	if r.ExternalNodesEnabled() {
+		if err := r.validateExternalNodes(); err != nil {
+			return err
+		}
 	}
 
 	if r.Bootstrapping() {
@@ -29,6 +93,22 @@ func (r *RedisFailover) Validate() error {
 		r.Spec.Redis.CustomConfig = deduplicateStr(append(defaultRedisCustomConfig, r.Spec.Redis.CustomConfig...))
 	}
 
+	if r.Spec.Redis.MasterDNS != nil {
+		if r.Spec.Redis.MasterDNS.Hostname == "" {
+			return errors.New("MasterDNS must include a hostname when provided")
+		}
+		if r.Spec.Redis.MasterDNS.TTL < 0 {
+			return errors.New("MasterDNS ttl can't be negative")
+		}
+	}
+
+	if err := r.convertDeprecatedDurations(); err != nil {
+		return err
+	}
+	if err := r.validateDurations(); err != nil {
+		return err
+	}
+
 	if r.Spec.Redis.Image == "" {
 		r.Spec.Redis.Image = defaultImage
 	}
@@ -49,6 +129,26 @@ func (r *RedisFailover) Validate() error {
 		r.Spec.Sentinel.Replicas = defaultSentinelNumber
 	}
 
+	if err := r.validateInstanceOverrides(); err != nil {
+		return err
+	}
+
+	if err := r.validateSentinelQuorum(); err != nil {
+		return err
+	}
+
+	if err := r.validateSentinelAutoScaling(); err != nil {
+		return err
+	}
+
+	if err := r.validateProtection(); err != nil {
+		return err
+	}
+
+	if err := r.validateChecks(); err != nil {
+		return err
+	}
+
 	if r.Spec.Redis.Exporter.Image == "" {
 		r.Spec.Redis.Exporter.Image = defaultExporterImage
 	}
@@ -61,6 +161,65 @@ func (r *RedisFailover) Validate() error {
 		r.Spec.Sentinel.CustomConfig = defaultSentinelCustomConfig
 	}
 
+	if s := r.Spec.Failover.StabilizationSeconds; s != nil && (*s < 0 || *s > maxStabilizationSeconds) {
+		return fmt.Errorf("failover.stabilizationSeconds must be between 0 and %d, got %d", maxStabilizationSeconds, *s)
+	}
+
+	if t := r.Spec.Failover.StuckReplicas.Threshold; t != nil && *t < 0 {
+		return fmt.Errorf("failover.stuckReplicas.threshold can't be negative, got %d", *t)
+	}
+
+	if r.BackupEnabled() {
+		if err := r.validateBackup(); err != nil {
+			return err
+		}
+	}
+
+	if err := r.validateMonitoring(); err != nil {
+		return err
+	}
+
+	if err := r.validateServiceMonitor(); err != nil {
+		return err
+	}
+
+	return r.applyVersionedDefaults()
+}
+
+// validateExternalNodes checks the external nodes are not mixed with the redis managed by the operator.
+// The replicas are set to the number of external nodes, the sentinel checks rely on it.
+func (r *RedisFailover) validateExternalNodes() error {
+	if r.Bootstrapping() {
+		return errors.New("externalNodes can't be used with a BootstrapNode")
+	}
+	if r.Spec.Redis.MasterDNS != nil {
+		return errors.New("externalNodes can't be used with MasterDNS")
+	}
+	if r.Spec.Redis.Exporter.Enabled {
+		return errors.New("externalNodes can't be used with the redis exporter")
+	}
+	nodes := int32(len(r.Spec.Redis.ExternalNodes))
+	if r.Spec.Redis.Replicas != 0 && r.Spec.Redis.Replicas != nodes {
+		return errors.New("externalNodes can't be mixed with managed redis replicas")
+	}
+
+	seen := map[string]bool{}
+	for i := range r.Spec.Redis.ExternalNodes {
+		node := &r.Spec.Redis.ExternalNodes[i]
+		// The replication info and the sentinels report the nodes by IP.
+		if net.ParseIP(node.Host) == nil {
+			return fmt.Errorf("externalNodes host %q must be an IP address", node.Host)
+		}
+		if node.Port == "" {
+			node.Port = strconv.Itoa(defaultRedisPort)
+		}
+		addr := net.JoinHostPort(node.Host, node.Port)
+		if seen[addr] {
+			return fmt.Errorf("externalNodes %s is duplicated", addr)
+		}
+		seen[addr] = true
+	}
+	r.Spec.Redis.Replicas = nodes
 	return nil
 }
 
@@ -14,6 +14,7 @@ func TestValidate(t *testing.T) {
 		rfBootstrapNode        *BootstrapSettings
 		rfRedisCustomConfig    []string
 		rfSentinelCustomConfig []string
+		rfStabilization        *int32
 		expectedError          string
 		expectedBootstrapNode  *BootstrapSettings
 	}{
@@ -31,6 +32,12 @@ func TestValidate(t *testing.T) {
 			rfName:                 "test",
 			rfSentinelCustomConfig: []string{"failover-timeout 500"},
 		},
+		{
+			name:            "errors on a negative stabilization window",
+			rfName:          "test",
+			rfStabilization: func() *int32 { s := int32(-1); return &s }(),
+			expectedError:   "failover.stabilizationSeconds must be between 0 and 3600, got -1",
+		},
 		{
 			name:            "BootstrapNode provided without a host",
 			rfName:          "test",
@@ -73,6 +80,7 @@ func TestValidate(t *testing.T) {
 			rf := generateRedisFailover(test.rfName, test.rfBootstrapNode)
 			rf.Spec.Redis.CustomConfig = test.rfRedisCustomConfig
 			rf.Spec.Sentinel.CustomConfig = test.rfSentinelCustomConfig
+			rf.Spec.Failover.StabilizationSeconds = test.rfStabilization
 
 			err := rf.Validate()
 
@@ -95,6 +103,9 @@ func TestValidate(t *testing.T) {
 					expectedSentinelCustomConfig = test.rfSentinelCustomConfig
 				}
 
+				stabilizationSeconds := int32(defaultStabilizationSeconds)
+				stuckReplicaThreshold := int32(defaultStuckReplicaThreshold)
+				managedServiceAccount := true
 				expectedRF := &RedisFailover{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      test.rfName,
@@ -108,7 +119,8 @@ func TestValidate(t *testing.T) {
 							Exporter: Exporter{
 								Image: defaultExporterImage,
 							},
-							CustomConfig: expectedRedisCustomConfig,
+							CustomConfig:          expectedRedisCustomConfig,
+							ManagedServiceAccount: &managedServiceAccount,
 						},
 						Sentinel: SentinelSettings{
 							Image:        defaultImage,
@@ -119,6 +131,13 @@ func TestValidate(t *testing.T) {
 							},
 						},
 						BootstrapNode: test.expectedBootstrapNode,
+						Failover: FailoverSettings{
+							MaxConcurrentSyncs:   defaultMaxConcurrentSyncs,
+							StabilizationSeconds: &stabilizationSeconds,
+							StuckReplicas: StuckReplicaSettings{
+								Threshold: &stuckReplicaThreshold,
+							},
+						},
 					},
 				}
 				assert.Equal(expectedRF, rf)
@@ -130,3 +149,90 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateExternalNodes(t *testing.T) {
+	tests := []struct {
+		name          string
+		rfRedis       RedisSettings
+		rfBootstrap   *BootstrapSettings
+		expNodes      []RedisExternalNode
+		expReplicas   int32
+		expectedError string
+	}{
+		{
+			name: "Populates default port and sets the replicas to the number of nodes",
+			rfRedis: RedisSettings{
+				ExternalNodes: []RedisExternalNode{{Host: "10.0.0.1"}, {Host: "10.0.0.2", Port: "6380"}},
+			},
+			expNodes:    []RedisExternalNode{{Host: "10.0.0.1", Port: "6379"}, {Host: "10.0.0.2", Port: "6380"}},
+			expReplicas: 2,
+		},
+		{
+			name: "Replicas matching the number of nodes are allowed",
+			rfRedis: RedisSettings{
+				Replicas:      1,
+				ExternalNodes: []RedisExternalNode{{Host: "10.0.0.1", Port: "6379"}},
+			},
+			expNodes:    []RedisExternalNode{{Host: "10.0.0.1", Port: "6379"}},
+			expReplicas: 1,
+		},
+		{
+			name: "Managed replicas are rejected",
+			rfRedis: RedisSettings{
+				Replicas:      3,
+				ExternalNodes: []RedisExternalNode{{Host: "10.0.0.1"}},
+			},
+			expectedError: "externalNodes can't be mixed with managed redis replicas",
+		},
+		{
+			name: "Hostnames are rejected",
+			rfRedis: RedisSettings{
+				ExternalNodes: []RedisExternalNode{{Host: "redis.example.com"}},
+			},
+			expectedError: "externalNodes host \"redis.example.com\" must be an IP address",
+		},
+		{
+			name: "Duplicated nodes are rejected",
+			rfRedis: RedisSettings{
+				ExternalNodes: []RedisExternalNode{{Host: "10.0.0.1"}, {Host: "10.0.0.1", Port: "6379"}},
+			},
+			expectedError: "externalNodes 10.0.0.1:6379 is duplicated",
+		},
+		{
+			name: "BootstrapNode is rejected",
+			rfRedis: RedisSettings{
+				ExternalNodes: []RedisExternalNode{{Host: "10.0.0.1"}},
+			},
+			rfBootstrap:   &BootstrapSettings{Host: "10.0.0.2"},
+			expectedError: "externalNodes can't be used with a BootstrapNode",
+		},
+		{
+			name: "Exporter is rejected",
+			rfRedis: RedisSettings{
+				ExternalNodes: []RedisExternalNode{{Host: "10.0.0.1"}},
+				Exporter:      Exporter{Enabled: true},
+			},
+			expectedError: "externalNodes can't be used with the redis exporter",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			rf := generateRedisFailover("test", test.rfBootstrap)
+			rf.Spec.Redis = test.rfRedis
+
+			err := rf.Validate()
+
+			if test.expectedError == "" {
+				assert.NoError(err)
+				assert.Equal(test.expNodes, rf.Spec.Redis.ExternalNodes)
+				assert.Equal(test.expReplicas, rf.Spec.Redis.Replicas)
+				// Validating again, as it's done on every resync, keeps the spec valid.
+				assert.NoError(rf.Validate())
+			} else {
+				assert.EqualError(err, test.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// DefaultsRevisionAnnotation holds the schema revision whose defaults apply to the RedisFailover. The
+	// operator sets it the first time it sees the object and never bumps it, so the defaults introduced
+	// later don't change the behavior of the existing objects. It can be raised to opt in to them.
+	DefaultsRevisionAnnotation = "databases.spotahome.com/defaults-revision"
+	// BaseDefaultsRevision is the revision of the objects created before the defaults were versioned.
+	BaseDefaultsRevision = 1
+)
+
+// versionedDefault is a default introduced in a schema revision. It's applied to the objects defaulted
+// with that revision or a newer one, the older ones keep the previous behavior.
+type versionedDefault struct {
+	// field is the path of the defaulted field, it names the default on the messages.
+	field string
+	// revision is the schema revision the default was introduced in.
+	revision int
+	// isSet returns true when the field has been set, then the default is not needed.
+	isSet func(r *RedisFailover) bool
+	// apply sets the default on the field.
+	apply func(r *RedisFailover)
+}
+
+// versionedDefaults are the defaults that changed the behavior of the objects created before them. The
+// defaults of the fields that don't change the behavior when added are set on Validate.
+var versionedDefaults = []versionedDefault{
+	{
+		field:    "failover.maxConcurrentSyncs",
+		revision: 4,
+		isSet:    func(r *RedisFailover) bool { return r.Spec.Failover.MaxConcurrentSyncs > 0 },
+		apply:    func(r *RedisFailover) { r.Spec.Failover.MaxConcurrentSyncs = defaultMaxConcurrentSyncs },
+	},
+	{
+		field:    "failover.stabilizationSeconds",
+		revision: 7,
+		isSet:    func(r *RedisFailover) bool { return r.Spec.Failover.StabilizationSeconds != nil },
+		apply: func(r *RedisFailover) {
+			seconds := int32(defaultStabilizationSeconds)
+			r.Spec.Failover.StabilizationSeconds = &seconds
+		},
+	},
+	{
+		field:    "failover.stuckReplicas.threshold",
+		revision: 8,
+		isSet:    func(r *RedisFailover) bool { return r.Spec.Failover.StuckReplicas.Threshold != nil },
+		apply: func(r *RedisFailover) {
+			threshold := int32(defaultStuckReplicaThreshold)
+			r.Spec.Failover.StuckReplicas.Threshold = &threshold
+		},
+	},
+	{
+		field:    "redis.managedServiceAccount",
+		revision: 34,
+		isSet:    func(r *RedisFailover) bool { return r.Spec.Redis.ManagedServiceAccount != nil },
+		apply: func(r *RedisFailover) {
+			managed := true
+			r.Spec.Redis.ManagedServiceAccount = &managed
+		},
+	},
+}
+
+// DefaultsRevision returns the schema revision whose defaults apply to the RedisFailover, false when it
+// has not been set yet.
+func (r *RedisFailover) DefaultsRevision() (int, bool, error) {
+	value, ok := r.Annotations[DefaultsRevisionAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	revision, err := strconv.Atoi(value)
+	if err != nil || revision < BaseDefaultsRevision {
+		return 0, false, fmt.Errorf("%s annotation must be a schema revision, got %q", DefaultsRevisionAnnotation, value)
+	}
+	return revision, true, nil
+}
+
+// SuppressedDefaults returns the fields whose default is not applied because it was introduced after
+// the defaults revision of the RedisFailover, and they are not set.
+func (r *RedisFailover) SuppressedDefaults() ([]string, error) {
+	revision, err := r.effectiveDefaultsRevision()
+	if err != nil {
+		return nil, err
+	}
+	suppressed := []string{}
+	for _, d := range versionedDefaults {
+		if d.revision > revision && !d.isSet(r) {
+			suppressed = append(suppressed, d.field)
+		}
+	}
+	return suppressed, nil
+}
+
+// applyVersionedDefaults sets the defaults introduced up to the defaults revision of the RedisFailover
+// on the fields not set.
+func (r *RedisFailover) applyVersionedDefaults() error {
+	revision, err := r.effectiveDefaultsRevision()
+	if err != nil {
+		return err
+	}
+	for _, d := range versionedDefaults {
+		if d.revision <= revision && !d.isSet(r) {
+			d.apply(r)
+		}
+	}
+	return nil
+}
+
+// effectiveDefaultsRevision returns the defaults revision of the RedisFailover. The objects without it
+// are new, the operator sets it on the existing ones before defaulting them.
+func (r *RedisFailover) effectiveDefaultsRevision() (int, error) {
+	revision, ok, err := r.DefaultsRevision()
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return SchemaRevision, nil
+	}
+	return revision, nil
+}
@@ -0,0 +1,184 @@
+package v1
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionedDefaults(t *testing.T) {
+	// Every versioned default must have its cases: the value it sets, and how to set the field explicitly.
+	tests := map[string]struct {
+		get      func(rf *RedisFailover) interface{}
+		set      func(rf *RedisFailover)
+		expUnset interface{}
+		expValue interface{}
+		expSet   interface{}
+	}{
+		"failover.maxConcurrentSyncs": {
+			get:      func(rf *RedisFailover) interface{} { return rf.Spec.Failover.MaxConcurrentSyncs },
+			set:      func(rf *RedisFailover) { rf.Spec.Failover.MaxConcurrentSyncs = 3 },
+			expUnset: int32(0),
+			expValue: int32(defaultMaxConcurrentSyncs),
+			expSet:   int32(3),
+		},
+		"failover.stabilizationSeconds": {
+			get: func(rf *RedisFailover) interface{} { return rf.Spec.Failover.StabilizationSeconds },
+			set: func(rf *RedisFailover) {
+				seconds := int32(0)
+				rf.Spec.Failover.StabilizationSeconds = &seconds
+			},
+			expUnset: (*int32)(nil),
+			expValue: func() *int32 { s := int32(defaultStabilizationSeconds); return &s }(),
+			expSet:   func() *int32 { s := int32(0); return &s }(),
+		},
+		"failover.stuckReplicas.threshold": {
+			get: func(rf *RedisFailover) interface{} { return rf.Spec.Failover.StuckReplicas.Threshold },
+			set: func(rf *RedisFailover) {
+				threshold := int32(0)
+				rf.Spec.Failover.StuckReplicas.Threshold = &threshold
+			},
+			expUnset: (*int32)(nil),
+			expValue: func() *int32 { t := int32(defaultStuckReplicaThreshold); return &t }(),
+			expSet:   func() *int32 { t := int32(0); return &t }(),
+		},
+		"redis.managedServiceAccount": {
+			get: func(rf *RedisFailover) interface{} { return rf.Spec.Redis.ManagedServiceAccount },
+			set: func(rf *RedisFailover) {
+				managed := false
+				rf.Spec.Redis.ManagedServiceAccount = &managed
+			},
+			expUnset: (*bool)(nil),
+			expValue: func() *bool { m := true; return &m }(),
+			expSet:   func() *bool { m := false; return &m }(),
+		},
+	}
+
+	for _, d := range versionedDefaults {
+		test, ok := tests[d.field]
+		if !assert.True(t, ok, "versioned default %s has no test", d.field) {
+			continue
+		}
+		assert.LessOrEqual(t, d.revision, SchemaRevision, "versioned default %s is newer than the schema revision", d.field)
+
+		cases := []struct {
+			name          string
+			revision      string
+			setField      bool
+			expValue      interface{}
+			expSuppressed bool
+		}{
+			{
+				name:     "New objects get the default",
+				expValue: test.expValue,
+			},
+			{
+				name:     "Objects defaulted with the revision of the default get it",
+				revision: strconv.Itoa(d.revision),
+				expValue: test.expValue,
+			},
+			{
+				name:          "Objects defaulted with an older revision don't get it",
+				revision:      strconv.Itoa(d.revision - 1),
+				expValue:      test.expUnset,
+				expSuppressed: true,
+			},
+			{
+				name:     "Objects defaulted with an older revision keep the field when it's set",
+				revision: strconv.Itoa(d.revision - 1),
+				setField: true,
+				expValue: test.expSet,
+			},
+			{
+				name:     "Objects defaulted with the revision of the default keep the field when it's set",
+				revision: strconv.Itoa(d.revision),
+				setField: true,
+				expValue: test.expSet,
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(d.field+"/"+c.name, func(t *testing.T) {
+				assert := assert.New(t)
+
+				rf := generateRedisFailover("test", nil)
+				if c.revision != "" {
+					rf.Annotations = map[string]string{DefaultsRevisionAnnotation: c.revision}
+				}
+				if c.setField {
+					test.set(rf)
+				}
+
+				suppressed, err := rf.SuppressedDefaults()
+				assert.NoError(err)
+				assert.Equal(c.expSuppressed, contains(suppressed, d.field))
+
+				// Defaulting is run on every reconciliation, it must give the same result.
+				for i := 0; i < 2; i++ {
+					assert.NoError(rf.Validate())
+					assert.Equal(c.expValue, test.get(rf))
+				}
+			})
+		}
+	}
+}
+
+func TestDefaultsRevision(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expRevision int
+		expOK       bool
+		expErr      bool
+	}{
+		{
+			name: "Missing annotation",
+		},
+		{
+			name:        "Valid annotation",
+			annotations: map[string]string{DefaultsRevisionAnnotation: "1"},
+			expRevision: 1,
+			expOK:       true,
+		},
+		{
+			name:        "Not a number",
+			annotations: map[string]string{DefaultsRevisionAnnotation: "latest"},
+			expErr:      true,
+		},
+		{
+			name:        "Lower than the base revision",
+			annotations: map[string]string{DefaultsRevisionAnnotation: "0"},
+			expErr:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			rf.Annotations = test.annotations
+
+			revision, ok, err := rf.DefaultsRevision()
+
+			assert.Equal(test.expRevision, revision)
+			assert.Equal(test.expOK, ok)
+			if test.expErr {
+				assert.Error(err)
+				assert.Error(rf.Validate())
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
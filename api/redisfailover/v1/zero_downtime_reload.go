@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// restartRequiredRedisDirectives are the redis directives that can't be set at runtime, they are
+	// only read from the config file on startup.
+	restartRequiredRedisDirectives = map[string]bool{
+		"io-threads":          true,
+		"io-threads-do-reads": true,
+		"databases":           true,
+		"tcp-backlog":         true,
+	}
+	// zeroDowntimeReloadDirectives are set by the operator when the redis listen on a socket behind the proxy.
+	zeroDowntimeReloadDirectives = []string{"unixsocket", "unixsocketperm", "replica-announce-ip", "replica-announce-port"}
+)
+
+// ZeroDowntimeReloadEnabled returns true when redis listens on a socket behind a proxy sidecar, and it's
+// restarted in place for the config that can't be set at runtime.
+func (r *RedisFailover) ZeroDowntimeReloadEnabled() bool {
+	return r.Spec.Redis.ZeroDowntimeReload.Enabled
+}
+
+// IsRestartRequiredRedisDirective returns true when the redis directive is only read on startup.
+func IsRestartRequiredRedisDirective(directive string) bool {
+	return restartRequiredRedisDirectives[strings.ToLower(directive)]
+}
+
+// RestartRequiredRedisConfig returns the lines of the redis custom config only read on startup. With
+// the zero downtime reload they are written in the config file instead of being set at runtime.
+func (r *RedisFailover) RestartRequiredRedisConfig() []string {
+	lines := []string{}
+	for _, line := range r.Spec.Redis.CustomConfig {
+		directive := strings.SplitN(strings.TrimSpace(line), " ", 2)[0]
+		if IsRestartRequiredRedisDirective(directive) {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+// validateZeroDowntimeReload checks the redis run by the operator can listen behind the proxy, and
+// sets the default image of the proxy.
+func (r *RedisFailover) validateZeroDowntimeReload() error {
+	if !r.ZeroDowntimeReloadEnabled() {
+		return nil
+	}
+	if r.ExternalNodesEnabled() {
+		return errors.New("zeroDowntimeReload can't be used with externalNodes, the operator doesn't run their redis")
+	}
+	if len(r.Spec.Redis.Command) > 0 {
+		return errors.New("zeroDowntimeReload can't be used with a custom redis command, the operator passes the announced address to redis")
+	}
+	for i, line := range r.Spec.Redis.CustomConfig {
+		directive := strings.ToLower(strings.SplitN(strings.TrimSpace(line), " ", 2)[0])
+		for _, reserved := range zeroDowntimeReloadDirectives {
+			if directive == reserved {
+				return fmt.Errorf("redis.customConfig[%d] %q can't set %s: it's set by the operator with zeroDowntimeReload", i, line, directive)
+			}
+		}
+	}
+	if r.Spec.Redis.ZeroDowntimeReload.Image == "" {
+		r.Spec.Redis.ZeroDowntimeReload.Image = defaultProxyImage
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateZeroDowntimeReload(t *testing.T) {
+	tests := []struct {
+		name          string
+		reload        ZeroDowntimeReload
+		command       []string
+		customConfig  []string
+		externalNodes []RedisExternalNode
+		expImage      string
+		expectedError string
+	}{
+		{
+			name: "Disabled",
+		},
+		{
+			name:     "Enabled gets the default proxy image",
+			reload:   ZeroDowntimeReload{Enabled: true},
+			expImage: defaultProxyImage,
+		},
+		{
+			name:     "Enabled keeps its proxy image",
+			reload:   ZeroDowntimeReload{Enabled: true, Image: "registry.local/redis-operator:dev"},
+			expImage: "registry.local/redis-operator:dev",
+		},
+		{
+			name:          "Custom command",
+			reload:        ZeroDowntimeReload{Enabled: true},
+			command:       []string{"redis-server", "/redis/redis.conf"},
+			expectedError: "zeroDowntimeReload can't be used with a custom redis command, the operator passes the announced address to redis",
+		},
+		{
+			name:          "Socket set in the custom config",
+			reload:        ZeroDowntimeReload{Enabled: true},
+			customConfig:  []string{"unixsocket /tmp/redis.sock"},
+			expectedError: `redis.customConfig[0] "unixsocket /tmp/redis.sock" can't set unixsocket: it's set by the operator with zeroDowntimeReload`,
+		},
+		{
+			name:          "External nodes",
+			reload:        ZeroDowntimeReload{Enabled: true},
+			externalNodes: []RedisExternalNode{{Host: "10.0.0.1", Port: "6379"}},
+			expectedError: "zeroDowntimeReload can't be used with externalNodes, the operator doesn't run their redis",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRedisFailover("test", nil)
+			rf.Spec.Redis.ZeroDowntimeReload = test.reload
+			rf.Spec.Redis.Command = test.command
+			rf.Spec.Redis.CustomConfig = test.customConfig
+			rf.Spec.Redis.ExternalNodes = test.externalNodes
+
+			err := rf.Validate()
+
+			if test.expectedError != "" {
+				assert.EqualError(err, test.expectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expImage, rf.Spec.Redis.ZeroDowntimeReload.Image)
+		})
+	}
+}
+
+func TestRestartRequiredRedisConfig(t *testing.T) {
+	rf := generateRedisFailover("test", nil)
+	rf.Spec.Redis.CustomConfig = []string{"maxmemory 1gb", "io-threads 4", " IO-THREADS-DO-READS yes", "databases 32"}
+
+	assert.Equal(t, []string{"io-threads 4", "IO-THREADS-DO-READS yes", "databases 32"}, rf.RestartRequiredRedisConfig())
+}
@@ -28,6 +28,10 @@ type FakeDatabasesV1 struct {
 	*testing.Fake
 }
 
+func (c *FakeDatabasesV1) RedisClusters(namespace string) v1.RedisClusterInterface {
+	return &FakeRedisClusters{c, namespace}
+}
+
 func (c *FakeDatabasesV1) RedisFailovers(namespace string) v1.RedisFailoverInterface {
 	return &FakeRedisFailovers{c, namespace}
 }
@@ -40,6 +40,7 @@ type RedisFailoversGetter interface {
 type RedisFailoverInterface interface {
 	Create(ctx context.Context, redisFailover *v1.RedisFailover, opts metav1.CreateOptions) (*v1.RedisFailover, error)
 	Update(ctx context.Context, redisFailover *v1.RedisFailover, opts metav1.UpdateOptions) (*v1.RedisFailover, error)
+	UpdateStatus(ctx context.Context, redisFailover *v1.RedisFailover, opts metav1.UpdateOptions) (*v1.RedisFailover, error)
 	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
 	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
 	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.RedisFailover, error)
@@ -135,6 +136,22 @@ func (c *redisFailovers) Update(ctx context.Context, redisFailover *v1.RedisFail
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *redisFailovers) UpdateStatus(ctx context.Context, redisFailover *v1.RedisFailover, opts metav1.UpdateOptions) (result *v1.RedisFailover, err error) {
+	result = &v1.RedisFailover{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("redisfailovers").
+		Name(redisFailover.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(redisFailover).
+		Do(ctx).
+		Into(result)
+	return
+}
+
 // Delete takes name of the redisFailover and deletes it. Returns an error if one occurs.
 func (c *redisFailovers) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
 	return c.client.Delete().
@@ -26,6 +26,7 @@ import (
 
 type DatabasesV1Interface interface {
 	RESTClient() rest.Interface
+	RedisClustersGetter
 	RedisFailoversGetter
 }
 
@@ -34,6 +35,10 @@ type DatabasesV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *DatabasesV1Client) RedisClusters(namespace string) RedisClusterInterface {
+	return newRedisClusters(c, namespace)
+}
+
 func (c *DatabasesV1Client) RedisFailovers(namespace string) RedisFailoverInterface {
 	return newRedisFailovers(c, namespace)
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/yaml"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/cmd/utils"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/operator/redisfailover"
+	"redis-operator/service/k8s"
+)
+
+const diffCommand = "diff"
+
+// Exit codes of the diff command, so CI can gate the changes restarting pods.
+const (
+	diffExitError    = 1
+	diffExitRestart  = 2
+	diffExitRejected = 3
+)
+
+// runDiff prints how the objects generated for a RedisFailover change with the spec of the given file,
+// and returns the exit code.
+func runDiff(ctx context.Context, logger log.Logger, args []string) (int, error) {
+	var file, kubeConfig string
+	fs := flag.NewFlagSet(diffCommand, flag.ExitOnError)
+	fs.StringVar(&file, "f", "", "file with the changed redisfailover")
+	fs.StringVar(&kubeConfig, "kubeconfig", filepath.Join(homedir.HomeDir(), ".kube", "config"), "kubernetes configuration path")
+	if err := fs.Parse(args); err != nil {
+		return diffExitError, err
+	}
+	if file == "" {
+		return diffExitError, fmt.Errorf("the redisfailover file is required")
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return diffExitError, err
+	}
+	changed := &redisfailoverv1.RedisFailover{}
+	if err := yaml.UnmarshalStrict(content, changed); err != nil {
+		return diffExitError, fmt.Errorf("could not parse %s: %w", file, err)
+	}
+	if changed.Namespace == "" {
+		changed.Namespace = "default"
+	}
+
+	flags := &utils.CMDFlags{Development: true, KubeConfig: kubeConfig}
+	k8sClient, customClient, aeClientset, err := utils.CreateKubernetesClients(flags)
+	if err != nil {
+		return diffExitError, err
+	}
+	k8sservice := k8s.New(k8sClient, nil, nil, customClient, nil, aeClientset, k8s.DefaultConflictRetries, logger, metrics.Dummy)
+
+	live, err := k8sservice.GetRedisFailover(ctx, changed.Namespace, changed.Name)
+	if err != nil {
+		return diffExitError, err
+	}
+	// Only the spec and the labels come from the file, the annotations pinned by the operator are kept.
+	desired := live.DeepCopy()
+	desired.Spec = changed.Spec
+	desired.Labels = changed.Labels
+
+	password, err := k8s.GetRedisPassword(ctx, k8sservice, desired)
+	if err != nil {
+		return diffExitError, err
+	}
+
+	diff, err := redisfailover.DiffSpec(live, desired, password, logger)
+	if err != nil {
+		return diffExitError, err
+	}
+	if err := diff.WriteSummary(os.Stdout); err != nil {
+		return diffExitError, err
+	}
+	switch {
+	case diff.Rejected():
+		return diffExitRejected, nil
+	case diff.RestartRequired():
+		return diffExitRestart, nil
+	}
+	return 0, nil
+}
@@ -8,17 +8,20 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/client-go/dynamic"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 
 	"redis-operator/cmd/utils"
 	"redis-operator/log"
 	"redis-operator/metrics"
+	"redis-operator/operator/rediscluster"
 	"redis-operator/operator/redisfailover"
 	"redis-operator/service/k8s"
 	"redis-operator/service/redis"
@@ -29,6 +32,9 @@ const (
 	metricsNamespace = "redis_operator"
 )
 
+// Version is the version of the operator, set when it's built.
+var Version = "dev"
+
 // Main is the  main runner.
 type Main struct {
 	flags  *utils.CMDFlags
@@ -82,25 +88,116 @@ func (m *Main) Run() error {
 		return err
 	}
 
+	// The redisfailovers are written with clients of their own, logging the apiserver warnings.
+	crdWrites, err := utils.CreateRedisFailoverWriteClients(m.flags)
+	if err != nil {
+		return err
+	}
+
+	// The config the commands are run in the pods with.
+	restConfig, err := utils.LoadKubernetesConfig(m.flags)
+	if err != nil {
+		return err
+	}
+
+	// The client of the CSI volume snapshots, handled as unstructured objects.
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
 	// Create kubernetes service.
-	k8sservice := k8s.New(k8sClient, customClient, aeClientset, m.logger, metricsRecorder)
+	k8sservice := k8s.New(k8sClient, restConfig, dynamicClient, customClient, crdWrites, aeClientset, m.flags.ConflictRetries, m.logger, metricsRecorder)
+
+	// Read the pods, the statefulsets and the deployments of the redisfailovers from a cache kept up
+	// to date with watches, instead of listing them on every check.
+	objectCache, err := k8s.NewObjectCache(k8sClient, redisfailover.ManagedObjectsSelector(), 0)
+	if err != nil {
+		return err
+	}
+	if err := objectCache.Start(m.stopC); err != nil {
+		return err
+	}
+	k8sservice = k8s.WithObjectCache(k8sservice, objectCache)
+
+	// Read the labels of the nodes of the pods once, the node watch invalidates them when they change.
+	nodeLabels, err := k8s.NewNodeLabelCache(k8sClient, 0)
+	if err != nil {
+		return err
+	}
+	if err := nodeLabels.Start(m.stopC); err != nil {
+		return err
+	}
+	k8sservice = k8s.WithNodeLabelCache(k8sservice, nodeLabels)
+
+	// The password of the RFs whose redis pods read it from a file is obtained by the provider of the flags.
+	passwordProvider, err := redis.NewPasswordProvider(m.flags.PasswordProvider, m.flags.PasswordCommand, m.flags.PasswordDir)
+	if err != nil {
+		return err
+	}
+	k8s.FileAuthPasswordProvider = passwordProvider
+
+	// The dual-stack support of the cluster is discovered unless the flag forces it.
+	if m.flags.DualStackServices != "" {
+		dualStack, err := strconv.ParseBool(m.flags.DualStackServices)
+		if err != nil {
+			return fmt.Errorf("invalid --dual-stack-services %q, must be true or false", m.flags.DualStackServices)
+		}
+		k8s.DualStackServices = &dualStack
+	}
 
 	// Create the redis clients
 	redisClient := redis.New(metricsRecorder)
+	if m.flags.RedisExecFallback {
+		redisClient = redis.WithExecFallback(redisClient, redisfailover.RedisCLIRunner(k8sservice))
+	}
 
 	// Get lease lock resource namespace
 	lockNamespace := getNamespace()
 
+	// Check the installed CRD knows all the operator fields, the result is logged and exposed
+	// as a metric but a mismatch is not fatal.
+	_ = k8sservice.CheckRedisFailoverSchema(context.Background(), lockNamespace)
+	_ = redisfailover.CheckCRDSchemaRevision(context.Background(), k8sservice, metricsRecorder, m.logger)
+
 	// Create operator and run.
-	redisfailoverOperator, err := redisfailover.New(m.flags.ToRedisOperatorConfig(), k8sservice, k8sClient, lockNamespace, redisClient, metricsRecorder, m.logger)
+	config := m.flags.ToRedisOperatorConfig()
+	switch config.TerminatingNamespace {
+	case redisfailover.TerminatingNamespaceSkip, redisfailover.TerminatingNamespaceReconcile:
+	default:
+		return fmt.Errorf("unknown terminating namespace behavior %q, must be %s or %s", config.TerminatingNamespace, redisfailover.TerminatingNamespaceSkip, redisfailover.TerminatingNamespaceReconcile)
+	}
+	config.OperatorVersion = Version
+	config.OperatorIdentity = lockNamespace + "/" + m.flags.OperatorName
+	// The lease of the leader election is held with the hostname.
+	if hostname, err := os.Hostname(); err == nil {
+		config.LeaseHolder = hostname
+	}
+	redisfailoverOperator, err := redisfailover.New(config, k8sservice, k8sClient, lockNamespace, redisClient, metricsRecorder, m.logger)
 	if err != nil {
 		return err
 	}
 
+	// The operators are stopped through their context, so they drain the reconciles in flight.
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	running := 1
 	go func() {
-		errC <- redisfailoverOperator.Run(context.Background())
+		errC <- redisfailoverOperator.Run(runCtx)
 	}()
 
+	// The redisclusters are reconciled by their own controller, with its own leader election.
+	if m.flags.RedisCluster {
+		redisclusterOperator, err := rediscluster.New(k8sservice, k8sClient, lockNamespace, m.flags.ListPageSize, metricsRecorder, m.logger)
+		if err != nil {
+			return err
+		}
+		running++
+		go func() {
+			errC <- redisclusterOperator.Run(runCtx)
+		}()
+	}
+
 	// Await signals.
 	sigC := m.createSignalCapturer()
 	var finalErr error
@@ -110,8 +207,11 @@ func (m *Main) Run() error {
 	case err := <-errC:
 		m.logger.Errorf("Error received: %s, exiting...", err)
 		finalErr = err
+		running--
 	}
 
+	cancel()
+	m.waitOperators(errC, running)
 	m.stop(m.stopC)
 	return finalErr
 }
@@ -122,6 +222,20 @@ func (m *Main) createSignalCapturer() <-chan os.Signal {
 	return sigC
 }
 
+// waitOperators waits for the operators still running to return after their context is cancelled, while
+// they drain the reconciles in flight, for at most the shutdown grace period and the stop grace period.
+func (m *Main) waitOperators(errC <-chan error, running int) {
+	timeout := time.After(m.flags.ShutdownGracePeriod + gracePeriod)
+	for ; running > 0; running-- {
+		select {
+		case <-errC:
+		case <-timeout:
+			m.logger.Warnf("%d operators are still stopping, exiting...", running)
+			return
+		}
+	}
+}
+
 func (m *Main) stop(stopC chan struct{}) {
 	m.logger.Infof("Stopping everything, waiting %s...", gracePeriod)
 
@@ -153,6 +267,56 @@ func getNamespace() string {
 // Run app.
 func main() {
 	logger := log.Base()
+	ctx := context.Background()
+
+	if len(os.Args) > 1 && os.Args[1] == supportBundleCommand {
+		if err := runSupportBundle(ctx, logger, os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error collecting the support bundle: %s", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == migrateCommand {
+		if err := runMigrate(ctx, logger, os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error migrating: %s", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == proxyCommand {
+		if err := runProxy(logger, os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error running the proxy: %s", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == networkBootstrapCommand {
+		if err := runNetworkBootstrap(ctx, logger, os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error bootstrapping the redis network: %s", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == diffCommand {
+		code, err := runDiff(ctx, logger, os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error computing the diff: %s", err)
+		}
+		os.Exit(code)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == reportCommand {
+		if err := runReport(ctx, logger, os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error building the fleet report: %s", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	m := New(logger)
 
 	if err := m.Run(); err != nil {
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/yaml"
+
+	"redis-operator/cmd/utils"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/operator/redisfailover/migrate"
+	"redis-operator/operator/redisfailover/util"
+	"redis-operator/service/k8s"
+)
+
+const migrateCommand = "migrate"
+
+// runMigrate converts the CRs of another redis operator into RedisFailovers, read from a file or from
+// a namespace of the cluster. The RedisFailovers are printed, and created when applied; the settings
+// that couldn't be mapped are warned about.
+func runMigrate(ctx context.Context, logger log.Logger, args []string) error {
+	var from, file, namespace, kubeConfig string
+	var apply, adopt bool
+	fs := flag.NewFlagSet(migrateCommand, flag.ExitOnError)
+	fs.StringVar(&from, "from", "", fmt.Sprintf("flavor of the CRs to convert, one of %s", strings.Join(migrate.Flavors(), ", ")))
+	fs.StringVar(&file, "f", "", "file with the CRs to convert, they are read from the namespace of the cluster when it's not set")
+	fs.StringVar(&namespace, "namespace", "default", "namespace the CRs are read from when no file is given")
+	fs.StringVar(&kubeConfig, "kubeconfig", filepath.Join(homedir.HomeDir(), ".kube", "config"), "kubernetes configuration path")
+	fs.BoolVar(&adopt, "adopt", false, "adopt the redis statefulsets of the converted CRs, always done for the CRs read from the cluster")
+	fs.BoolVar(&apply, "apply", false, "create or update the redisfailovers converted from the CRs read from the cluster")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if from == "" {
+		return fmt.Errorf("the flavor of the CRs is required")
+	}
+	if file != "" && apply {
+		return fmt.Errorf("only the CRs read from the cluster can be applied, apply the converted file with kubectl")
+	}
+
+	var objs []*unstructured.Unstructured
+	var k8sservice k8s.Services
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if objs, err = migrate.ParseObjects(content); err != nil {
+			return fmt.Errorf("could not parse %s: %w", file, err)
+		}
+	} else {
+		// The pods running in the statefulsets of the CRs must not be recreated.
+		adopt = true
+		flags := &utils.CMDFlags{Development: true, KubeConfig: kubeConfig}
+		restConfig, err := utils.LoadKubernetesConfig(flags)
+		if err != nil {
+			return err
+		}
+		dynamicClient, err := dynamic.NewForConfig(restConfig)
+		if err != nil {
+			return err
+		}
+		if objs, err = listForeignObjects(ctx, dynamicClient, from, namespace); err != nil {
+			return err
+		}
+		k8sClient, customClient, aeClientset, err := utils.CreateKubernetesClients(flags)
+		if err != nil {
+			return err
+		}
+		k8sservice = k8s.New(k8sClient, restConfig, dynamicClient, customClient, nil, aeClientset, k8s.DefaultConflictRetries, logger, metrics.Dummy)
+	}
+
+	results, err := migrate.Convert(from, objs, migrate.Options{Adopt: adopt})
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+		content, err := yaml.Marshal(result.RedisFailover)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("---\n%s", content)
+		if apply {
+			if err := applyMigrated(ctx, k8sservice, result); err != nil {
+				return err
+			}
+			logger.Infof("redisfailover %s/%s applied", result.RedisFailover.Namespace, result.RedisFailover.Name)
+		}
+	}
+	return nil
+}
+
+// listForeignObjects reads the CRs of the flavor from the namespace.
+func listForeignObjects(ctx context.Context, dynamicClient dynamic.Interface, from, namespace string) ([]*unstructured.Unstructured, error) {
+	resources, err := migrate.Resources(from)
+	if err != nil {
+		return nil, err
+	}
+	objs := []*unstructured.Unstructured{}
+	for _, resource := range resources {
+		list, err := dynamicClient.Resource(resource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("could not list the %s: %w", resource.Resource, err)
+		}
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+	}
+	return objs, nil
+}
+
+// applyMigrated creates the converted RedisFailover, or updates the one with the same name when the
+// foreign CRs are RedisFailovers of another release.
+func applyMigrated(ctx context.Context, k8sservice k8s.Services, result migrate.Result) error {
+	rf := result.RedisFailover
+	existing, err := k8sservice.GetRedisFailover(ctx, rf.Namespace, rf.Name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = k8sservice.CreateRedisFailover(ctx, rf.Namespace, rf)
+		return err
+	}
+	// The annotations pinned by the operator are kept.
+	existing.Labels = util.MergeLabels(existing.Labels, rf.Labels)
+	existing.Annotations = util.MergeLabels(existing.Annotations, rf.Annotations)
+	existing.Spec = rf.Spec
+	_, err = k8sservice.UpdateRedisFailover(ctx, rf.Namespace, existing)
+	return err
+}
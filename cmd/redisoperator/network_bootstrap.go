@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+
+	"redis-operator/cmd/utils"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+)
+
+const networkBootstrapCommand = "network-bootstrap"
+
+// runNetworkBootstrap runs in the init container of the redis pods with bindAddresses or a replication
+// interface. It writes the bind addresses and the announced address of redis, read from an annotation
+// of the node of the pod, to the file included by the redis config.
+func runNetworkBootstrap(ctx context.Context, logger log.Logger, args []string) error {
+	var bind, output, nodeAnnotation string
+	fs := flag.NewFlagSet(networkBootstrapCommand, flag.ExitOnError)
+	fs.StringVar(&bind, "bind", "", "comma separated addresses redis listens on, $(POD_IP) and $(REPLICATION_IP) are resolved")
+	fs.StringVar(&nodeAnnotation, "node-annotation", "", "annotation of the node with the address of its replication interface")
+	fs.StringVar(&output, "output", "", "path of the redis config file written")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if output == "" {
+		return fmt.Errorf("the path of the redis config file is required")
+	}
+
+	replicationIP := ""
+	if nodeAnnotation != "" {
+		nodeName := os.Getenv("NODE_NAME")
+		if nodeName == "" {
+			return fmt.Errorf("the name of the node is required to read its replication interface")
+		}
+		restConfig, err := utils.LoadKubernetesConfig(&utils.CMDFlags{})
+		if err != nil {
+			return err
+		}
+		kubeClient, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return err
+		}
+		node, err := k8s.NewNodeService(kubeClient, logger, metrics.Dummy).GetNode(ctx, nodeName)
+		if err != nil {
+			return fmt.Errorf("could not read the node %s: %w", nodeName, err)
+		}
+		if replicationIP = node.Annotations[nodeAnnotation]; replicationIP == "" {
+			return fmt.Errorf("the node %s has no annotation %s with the address of its replication interface", nodeName, nodeAnnotation)
+		}
+	}
+
+	bindAddresses := []string{}
+	if bind != "" {
+		bindAddresses = strings.Split(bind, ",")
+	}
+	config, err := rfservice.RenderRedisNetworkConfig(bindAddresses, os.Getenv("POD_IP"), replicationIP)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, []byte(config), 0644); err != nil {
+		return err
+	}
+	logger.Infof("Wrote the redis network config to %s:\n%s", output, config)
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"redis-operator/log"
+	"redis-operator/service/proxy"
+)
+
+const proxyCommand = "proxy"
+
+// runProxy runs the sidecar of the redis pods with zeroDowntimeReload, forwarding the redis port to the
+// socket redis listens on until it's terminated.
+func runProxy(logger log.Logger, args []string) error {
+	var listen, upstream string
+	p := &proxy.Proxy{Logger: logger}
+	fs := flag.NewFlagSet(proxyCommand, flag.ExitOnError)
+	fs.StringVar(&listen, "listen", ":6379", "address the clients of redis connect to")
+	fs.StringVar(&upstream, "upstream", "", "path of the socket redis listens on")
+	fs.DurationVar(&p.HoldTimeout, "hold-timeout", proxy.DefaultHoldTimeout, "how long a connection is held while redis is restarted")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if upstream == "" {
+		return fmt.Errorf("the socket of redis is required")
+	}
+	p.Upstream = upstream
+
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	// The pod is terminated after redis, the connections are forwarded until then.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logger.Infof("Forwarding %s to %s", listen, upstream)
+	return p.Serve(ctx, l)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/client-go/util/homedir"
+
+	"redis-operator/cmd/utils"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/operator/redisfailover/fleet"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
+)
+
+const reportCommand = "report"
+
+// runReport writes the fleet report of the RedisFailovers of all the namespaces to the standard output.
+// The versions and the memory of the redises are only sampled when the pods are reachable from where it
+// runs.
+func runReport(ctx context.Context, logger log.Logger, args []string) error {
+	var kubeConfig, output, sortBy string
+	var desc bool
+	var pageSize int64
+	fs := flag.NewFlagSet(reportCommand, flag.ExitOnError)
+	fs.StringVar(&kubeConfig, "kubeconfig", filepath.Join(homedir.HomeDir(), ".kube", "config"), "kubernetes configuration path")
+	fs.StringVar(&output, "output", fleet.FormatTable, "format of the report, one of "+strings.Join(fleet.Formats, ", "))
+	fs.StringVar(&sortBy, "sort", "name", "column the redisfailovers are sorted by, one of "+strings.Join(fleet.Columns(), ", "))
+	fs.BoolVar(&desc, "desc", false, "sort the redisfailovers in descending order")
+	fs.Int64Var(&pageSize, "page-size", k8s.DefaultListPageSize, "how many redisfailovers are listed by page")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch output {
+	case fleet.FormatTable, fleet.FormatJSON, fleet.FormatCSV:
+	default:
+		return fmt.Errorf("unknown output %q, one of %s", output, strings.Join(fleet.Formats, ", "))
+	}
+
+	flags := &utils.CMDFlags{Development: true, KubeConfig: kubeConfig}
+	k8sClient, customClient, aeClientset, err := utils.CreateKubernetesClients(flags)
+	if err != nil {
+		return err
+	}
+	k8sservice := k8s.New(k8sClient, nil, nil, customClient, nil, aeClientset, k8s.DefaultConflictRetries, logger, metrics.Dummy)
+	collector := fleet.NewCollector(k8sservice, redis.New(metrics.Dummy), logger)
+
+	report, err := collector.Collect(ctx, pageSize)
+	if err != nil {
+		return err
+	}
+	if err := report.Sort(sortBy, desc); err != nil {
+		return err
+	}
+	return report.Write(os.Stdout, output)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/util/homedir"
+
+	"redis-operator/cmd/utils"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/operator/redisfailover/supportbundle"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
+)
+
+const supportBundleCommand = "support-bundle"
+
+// runSupportBundle collects the support bundle of a RedisFailover into a local file. The redis and
+// sentinel INFO can only be collected when the pods are reachable from where it runs.
+func runSupportBundle(ctx context.Context, logger log.Logger, args []string) error {
+	var namespace, name, kubeConfig, output string
+	fs := flag.NewFlagSet(supportBundleCommand, flag.ExitOnError)
+	fs.StringVar(&namespace, "namespace", "default", "namespace of the redisfailover")
+	fs.StringVar(&name, "name", "", "name of the redisfailover")
+	fs.StringVar(&kubeConfig, "kubeconfig", filepath.Join(homedir.HomeDir(), ".kube", "config"), "kubernetes configuration path")
+	fs.StringVar(&output, "output", "", "file the support bundle is written to, <namespace>-<name>-support-bundle.tar.gz by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("the redisfailover name is required")
+	}
+	if output == "" {
+		output = fmt.Sprintf("%s-%s-%s", namespace, name, supportbundle.FileName)
+	}
+
+	flags := &utils.CMDFlags{Development: true, KubeConfig: kubeConfig}
+	k8sClient, customClient, aeClientset, err := utils.CreateKubernetesClients(flags)
+	if err != nil {
+		return err
+	}
+	k8sservice := k8s.New(k8sClient, nil, nil, customClient, nil, aeClientset, k8s.DefaultConflictRetries, logger, metrics.Dummy)
+	collector := supportbundle.NewCollector(k8sservice, redis.New(metrics.Dummy), logger)
+
+	bundle, err := collector.CollectByName(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := bundle.WriteTarGz(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	logger.Infof("support bundle written to %s", output)
+	return nil
+}
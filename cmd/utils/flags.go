@@ -3,19 +3,48 @@ package utils
 import (
 	"flag"
 	"path/filepath"
+	"time"
 
 	"redis-operator/operator/redisfailover"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
 	"k8s.io/client-go/util/homedir"
 )
 
 // CMDFlags are the flags used by the cmd
 // TODO: improve flags.
 type CMDFlags struct {
-	KubeConfig  string
-	Development bool
-	Debug       bool
-	ListenAddr  string
-	MetricsPath string
+	KubeConfig            string
+	Development           bool
+	Debug                 bool
+	ListenAddr            string
+	MetricsPath           string
+	NamePrefix            string
+	CommonLabels          string
+	FeatureGates          string
+	RackLabel             string
+	DisableMetricLabels   bool
+	VolumeWaitGracePeriod time.Duration
+	PasswordProvider      string
+	PasswordCommand       string
+	PasswordDir           string
+	OperatorName          string
+	TerminatingNamespace  string
+	LatencyPressure       time.Duration
+	ConflictRetries       int
+	RedisCluster          bool
+	NamespaceConcurrency  int
+	WarmUpRamp            time.Duration
+	ServerSideApply       bool
+	CoalesceWindow        time.Duration
+	ListPageSize          int64
+	ShutdownGracePeriod   time.Duration
+	ChecksDebugPath       string
+	FleetReportPath       string
+	CrashLogLines         int64
+	SupportBundleLogLines int
+	RedisExecFallback     bool
+	DualStackServices     string
 }
 
 // Init initializes and parse the flags
@@ -27,6 +56,32 @@ func (c *CMDFlags) Init() {
 	flag.BoolVar(&c.Debug, "debug", false, "enable debug mode")
 	flag.StringVar(&c.ListenAddr, "listen-address", ":9710", "Address to listen on for metrics.")
 	flag.StringVar(&c.MetricsPath, "metrics-path", "/metrics", "Path to serve the metrics.")
+	flag.StringVar(&c.ChecksDebugPath, "checks-debug-path", "/debug/checks", "Path to serve the results and the durations of the last checks of every redisfailover as JSON, on the metrics address. Empty to not serve them.")
+	flag.StringVar(&c.FleetReportPath, "fleet-report-path", "", "Path to serve the fleet report of all the redisfailovers, with their versions, sizes and health, on the metrics address. Every redis is asked for its INFO on each request. Empty to not serve it.")
+	flag.StringVar(&c.NamePrefix, "name-prefix-template", "", "Prefix of the names of the objects generated for the new redisfailovers, templated with {{.Namespace}} and {{.Name}}.")
+	flag.StringVar(&c.CommonLabels, "common-labels", "", "Comma separated key=value labels added to the objects generated for the new redisfailovers, templated with {{.Namespace}} and {{.Name}}.")
+	flag.StringVar(&c.FeatureGates, "feature-gates", "", "Comma separated gate=bool pairs enabling or disabling features on every redisfailover, overridden by the databases.spotahome.com/feature.<gate> annotations.")
+	flag.StringVar(&c.RackLabel, "rack-node-label", redisfailover.DefaultRackLabel, "Label of the nodes holding their rack, reported with their zone on the redisfailover status. Empty to not report the racks.")
+	flag.BoolVar(&c.DisableMetricLabels, "disable-metric-labels", false, "Ignore the spec.monitoring.metricLabels of the redisfailovers, so they are not added to the metrics of the operator.")
+	flag.DurationVar(&c.VolumeWaitGracePeriod, "volume-wait-grace-period", redisfailover.DefaultVolumeWaitGracePeriod, "How long the redis pods waiting for their volumes to be attached or mounted are neither healed nor reported as degraded.")
+	flag.StringVar(&c.PasswordProvider, "auth-password-provider", redis.PasswordProviderSecret, "How the operator obtains the password of the redisfailovers with the File auth mode: secret reads the secret of spec.auth.secretPath, exec runs the --auth-password-command, file reads the <namespace>/<name> file of the --auth-password-dir.")
+	flag.StringVar(&c.PasswordCommand, "auth-password-command", "", "Command run with the namespace and the name of the redisfailover, printing its password, for the exec password provider.")
+	flag.StringVar(&c.OperatorName, "operator-name", "redis-operator", "Name of the operator deployment. With the namespace of the operator it identifies the operator in the claims of the redisfailovers, the ones claimed by another live operator are not reconciled.")
+	flag.StringVar(&c.PasswordDir, "auth-password-dir", "", "Directory of the <namespace>/<name> password files of the redisfailovers, for the file password provider.")
+	flag.StringVar(&c.TerminatingNamespace, "terminating-namespace", redisfailover.TerminatingNamespaceSkip, "How the redisfailovers of a namespace being deleted are handled: skip leaves them to the namespace controller, reconcile keeps reconciling them until they are deleted.")
+	flag.DurationVar(&c.LatencyPressure, "latency-pressure-threshold", redisfailover.DefaultLatencyPressureThreshold, "Mean round-trip of the PING sent by the operator to a redis pod over the last checks above which the redisfailover is under pressure. 0 to disable.")
+	flag.IntVar(&c.ConflictRetries, "k8s-conflict-retries", k8s.DefaultConflictRetries, "How many times an update of a statefulset, deployment, configmap, service or poddisruptionbudget is retried over the latest version of the object after a conflict.")
+	flag.IntVar(&c.NamespaceConcurrency, "namespace-concurrency", 0, "How many redisfailovers of a namespace are reconciled at once, the others wait for their turn without holding a worker. 0 for no limit.")
+	flag.DurationVar(&c.WarmUpRamp, "warm-up-ramp", 0, "Interval between the first reconciles of the redisfailovers found when the operator starts, oldest first. 0 to reconcile them all right away.")
+	flag.BoolVar(&c.ServerSideApply, "server-side-apply", false, "Write the services, statefulsets and deployments of the redisfailovers with a server-side apply of the redis-operator field manager, taking the ownership of the fields it sets, instead of creating or updating them.")
+	flag.DurationVar(&c.CoalesceWindow, "reconcile-coalesce-window", redisfailover.DefaultReconcileCoalesceWindow, "Window the events of a redisfailover are merged in before it's reconciled, its status updates are skipped. 0 to reconcile on every event.")
+	flag.IntVar(&c.SupportBundleLogLines, "support-bundle-log-lines", redisfailover.DefaultSupportBundleLogLines, "How many of the last lines the operator logged about every redisfailover are kept for its support bundles. 0 to not add the operator logs to the support bundles.")
+	flag.Int64Var(&c.CrashLogLines, "crash-log-lines", redisfailover.DefaultCrashLogLines, "How many lines of the previous run of a crash looping redis are logged and reported on the Ready condition of the redisfailover.")
+	flag.Int64Var(&c.ListPageSize, "k8s-list-page-size", k8s.DefaultListPageSize, "How many objects are listed by page when the operator lists the redisfailovers or the statefulsets it manages, so at most a page of them is held at once.")
+	flag.DurationVar(&c.ShutdownGracePeriod, "shutdown-grace-period", redisfailover.DefaultShutdownGracePeriod, "How long the reconciles in flight are waited for when the operator is stopped, so a failover or a rolling update is not cut in the middle. It has to be below the terminationGracePeriodSeconds of the operator pod. 0 to not wait.")
+	flag.BoolVar(&c.RedisCluster, "enable-redis-cluster", false, "Reconcile the redisclusters too, their CRD has to be installed.")
+	flag.BoolVar(&c.RedisExecFallback, "redis-exec-fallback", false, "Run the commands of the redis pods the operator can't connect to with redis-cli through a pod exec, as when the network of the pods isn't reachable from the operator.")
+	flag.StringVar(&c.DualStackServices, "dual-stack-services", "", "Whether the cluster supports the dual-stack Services, true or false. Discovered with a dry-run Service when empty. The spec.redis.service.ipFamilyPolicy and ipFamilies of the redisfailovers are not set on the Services of the single-stack clusters.")
 
 	// Parse flags
 	flag.Parse()
@@ -35,7 +90,25 @@ func (c *CMDFlags) Init() {
 // ToRedisOperatorConfig convert the flags to redisfailover config
 func (c *CMDFlags) ToRedisOperatorConfig() redisfailover.Config {
 	return redisfailover.Config{
-		ListenAddress: c.ListenAddr,
-		MetricsPath:   c.MetricsPath,
+		ListenAddress:         c.ListenAddr,
+		MetricsPath:           c.MetricsPath,
+		NamePrefixTemplate:    c.NamePrefix,
+		CommonLabelsTemplate:  c.CommonLabels,
+		FeatureGates:          c.FeatureGates,
+		RackLabel:             c.RackLabel,
+		DisableMetricLabels:   c.DisableMetricLabels,
+		VolumeWaitGracePeriod: c.VolumeWaitGracePeriod,
+		TerminatingNamespace:  c.TerminatingNamespace,
+		LatencyPressure:       c.LatencyPressure,
+		NamespaceConcurrency:  c.NamespaceConcurrency,
+		WarmUpRamp:            c.WarmUpRamp,
+		ServerSideApply:       c.ServerSideApply,
+		CoalesceWindow:        c.CoalesceWindow,
+		ListPageSize:          c.ListPageSize,
+		ShutdownGracePeriod:   c.ShutdownGracePeriod,
+		ChecksDebugPath:       c.ChecksDebugPath,
+		FleetReportPath:       c.FleetReportPath,
+		CrashLogLines:         c.CrashLogLines,
+		SupportBundleLogLines: c.SupportBundleLogLines,
 	}
 }
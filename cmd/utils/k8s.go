@@ -7,8 +7,10 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/flowcontrol"
 
 	redisfailoverclientset "redis-operator/client/k8s/clientset/versioned"
+	"redis-operator/service/k8s"
 )
 
 const (
@@ -40,7 +42,7 @@ func LoadKubernetesConfig(flags *CMDFlags) (*rest.Config, error) {
 	return cfg, nil
 }
 
-// CreateKubernetesClients create the clients to connect to kubernetes
+// CreateKubernetesClients create the clients to connect to kubernetes.
 func CreateKubernetesClients(flags *CMDFlags) (kubernetes.Interface, redisfailoverclientset.Interface, apiextensionsclientset.Interface, error) {
 	config, err := LoadKubernetesConfig(flags)
 	if err != nil {
@@ -63,3 +65,25 @@ func CreateKubernetesClients(flags *CMDFlags) (kubernetes.Interface, redisfailov
 
 	return clientset, customClientset, aeClientset, nil
 }
+
+// CreateRedisFailoverWriteClients creates the clients the redisfailovers are written with, every one
+// sends the apiserver warnings it receives to a handler of its own. They are kept apart from the client of
+// CreateKubernetesClients, so the warnings of the lists and the watches are not mixed with them. They
+// share a single rate limiter, as if they were a single client.
+func CreateRedisFailoverWriteClients(flags *CMDFlags) (*k8s.RedisFailoverWriteClients, error) {
+	config, err := LoadKubernetesConfig(flags)
+	if err != nil {
+		return nil, err
+	}
+	config.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(config.QPS, config.Burst)
+	return k8s.NewRedisFailoverWriteClients(func() (*k8s.RedisFailoverWriteClient, error) {
+		warnings := k8s.NewWarningHandler()
+		clientConfig := rest.CopyConfig(config)
+		clientConfig.WarningHandler = warnings
+		client, err := redisfailoverclientset.NewForConfig(clientConfig)
+		if err != nil {
+			return nil, err
+		}
+		return &k8s.RedisFailoverWriteClient{Client: client, Warnings: warnings}, nil
+	}), nil
+}
@@ -0,0 +1,78 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RecentLines is a logrus hook keeping the last lines logged about every RedisFailover, the entries with
+// the namespace and redisfailover fields, so they can be added to its support bundle.
+type RecentLines struct {
+	max       int
+	formatter logrus.Formatter
+	mu        sync.Mutex
+	lines     map[string][]string
+}
+
+// NewRecentLines returns a hook keeping the last max lines of every RedisFailover.
+func NewRecentLines(max int) *RecentLines {
+	return &RecentLines{
+		max:       max,
+		formatter: &logrus.TextFormatter{DisableColors: true, FullTimestamp: true},
+		lines:     map[string][]string{},
+	}
+}
+
+// KeepRecentLines keeps the last lines logged by the base logger about every RedisFailover.
+func KeepRecentLines(recent *RecentLines) {
+	baseLogger.entry.Logger.AddHook(recent)
+}
+
+// Levels returns every level, the lines are kept whatever their level.
+func (r *RecentLines) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire keeps the line of the entry when it's about a RedisFailover.
+func (r *RecentLines) Fire(entry *logrus.Entry) error {
+	namespace, ok := entry.Data["namespace"]
+	if !ok {
+		return nil
+	}
+	name, ok := entry.Data["redisfailover"]
+	if !ok {
+		return nil
+	}
+	line, err := r.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%v/%v", namespace, name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines := append(r.lines[key], strings.TrimSuffix(string(line), "\n"))
+	if len(lines) > r.max {
+		lines = lines[len(lines)-r.max:]
+	}
+	r.lines[key] = lines
+	return nil
+}
+
+// Lines returns the last lines logged about the RedisFailover, the oldest first.
+func (r *RecentLines) Lines(namespace, name string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines := r.lines[namespace+"/"+name]
+	return append([]string{}, lines...)
+}
+
+// Forget drops the lines of a RedisFailover, it's called once it's deleted.
+func (r *RecentLines) Forget(namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.lines, namespace+"/"+name)
+}
@@ -0,0 +1,39 @@
+package log_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"redis-operator/log"
+)
+
+func TestRecentLines(t *testing.T) {
+	assert := assert.New(t)
+
+	recent := log.NewRecentLines(2)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(recent)
+
+	rf := logger.WithField("namespace", "testns").WithField("redisfailover", "test")
+	rf.Info("first")
+	rf.Warn("second")
+	logger.WithField("namespace", "testns").WithField("redisfailover", "other").Info("another redisfailover")
+	logger.Info("not about a redisfailover")
+	rf.Error("third")
+
+	// Only the last lines of the redisfailover are kept.
+	lines := recent.Lines("testns", "test")
+	if assert.Len(lines, 2) {
+		assert.Contains(lines[0], `level=warning msg=second`)
+		assert.Contains(lines[1], `level=error msg=third`)
+	}
+	assert.Len(recent.Lines("testns", "other"), 1)
+
+	recent.Forget("testns", "test")
+	assert.Empty(recent.Lines("testns", "test"))
+	assert.Len(recent.Lines("testns", "other"), 1)
+}
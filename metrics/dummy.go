@@ -29,3 +29,28 @@ func (d dummy) RecordK8sOperation(namespace string, kind string, object string,
 }
 func (d dummy) RecordRedisOperation(kind string, IP string, operation string, status string, err string) {
 }
+func (d dummy) SetInstanceRestarts(namespace string, name string, pod string, container string, restarts int32) {
+}
+func (d dummy) DeleteInstanceRestarts(namespace string, name string, pod string, container string) {
+}
+func (d dummy) SetRedisLatency(namespace string, name string, pod string, latency float64, jitter float64) {
+}
+func (d dummy) SetRedisFunctionalHealth(namespace string, name string, pod string, healthy bool) {
+}
+func (d dummy) ResetRedisLatency(namespace string, name string)                          {}
+func (d dummy) ResetRedisFunctionalHealth(namespace string, name string)                 {}
+func (d dummy) ResetInstanceRestarts(namespace string, name string)                      {}
+func (d dummy) RecordReconcileSkipped(namespace string, name string, reason string)      {}
+func (d dummy) SetRedisFailoverSchemaMismatch(mismatch bool)                             {}
+func (d dummy) RecordStuckReplicaRemediation(namespace string, name string, step string) {}
+func (d dummy) SetUnresponsiveSentinels(namespace string, name string, count int)        {}
+func (d dummy) RecordSentinelRestart(namespace string, name string)                      {}
+func (d dummy) SetClusterLabels(namespace string, name string, labels map[string]string) {}
+func (d dummy) SetAPIServerPressure(widening float64)                                    {}
+func (d dummy) SetDeprecatedFieldUses(field string, count int)                           {}
+func (d dummy) SetPodDisruptionBudgetAPIVersion(version string)                          {}
+func (d dummy) RecordK8sConflictRetry(namespace string, kind string, object string)      {}
+func (d dummy) ObserveReconcileQueueWait(namespace string, wait float64)                 {}
+func (d dummy) RecordReconcileCoalesced(namespace string, reason string)                 {}
+func (d dummy) ObserveCheckDuration(namespace string, check string, duration float64)    {}
+func (d dummy) RecordCheckError(namespace string, name string, check string)             {}
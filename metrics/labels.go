@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// clusterLabels are the custom labels every cluster adds to its metrics, by cluster.
+type clusterLabels struct {
+	mu     sync.RWMutex
+	labels map[string]map[string]string
+}
+
+func newClusterLabels() *clusterLabels {
+	return &clusterLabels{
+		labels: map[string]map[string]string{},
+	}
+}
+
+func clusterKey(namespace string, name string) string {
+	return namespace + "/" + name
+}
+
+func (c *clusterLabels) get(namespace string, name string) map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.labels[clusterKey(namespace, name)]
+}
+
+// set stores the labels of the cluster and returns true when they changed.
+func (c *clusterLabels) set(namespace string, name string, labels map[string]string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := clusterKey(namespace, name)
+	current := c.labels[key]
+	if len(current) == len(labels) {
+		changed := false
+		for k, v := range labels {
+			if cv, ok := current[k]; !ok || cv != v {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return false
+		}
+	}
+	if len(labels) == 0 {
+		delete(c.labels, key)
+		return true
+	}
+	stored := make(map[string]string, len(labels))
+	for k, v := range labels {
+		stored[k] = v
+	}
+	c.labels[key] = stored
+	return true
+}
+
+// partialDeleter is implemented by the gauge and counter vectors.
+type partialDeleter interface {
+	DeletePartialMatch(labels prometheus.Labels) int
+}
+
+// clusterVec is a metric vector of the clusters, the namespace and the name of the cluster being its
+// first two labels, that adds the custom labels of every cluster to its series. The clusters with
+// different custom label names get different vectors, so it's registered unchecked.
+type clusterVec struct {
+	labelNames []string
+	custom     *clusterLabels
+	newVec     func(labelNames []string) prometheus.Collector
+
+	mu   sync.Mutex
+	vecs map[string]prometheus.Collector
+}
+
+func newClusterVec(labelNames []string, custom *clusterLabels, newVec func(labelNames []string) prometheus.Collector) *clusterVec {
+	return &clusterVec{
+		labelNames: labelNames,
+		custom:     custom,
+		newVec:     newVec,
+		vecs:       map[string]prometheus.Collector{},
+	}
+}
+
+func newClusterGaugeVec(opts prometheus.GaugeOpts, labelNames []string, custom *clusterLabels) *clusterVec {
+	return newClusterVec(labelNames, custom, func(labelNames []string) prometheus.Collector {
+		return prometheus.NewGaugeVec(opts, labelNames)
+	})
+}
+
+func newClusterCounterVec(opts prometheus.CounterOpts, labelNames []string, custom *clusterLabels) *clusterVec {
+	return newClusterVec(labelNames, custom, func(labelNames []string) prometheus.Collector {
+		return prometheus.NewCounterVec(opts, labelNames)
+	})
+}
+
+// with returns the vector of the custom label names of the cluster and the values of all its labels.
+// The custom labels named as the labels of the metric are ignored.
+func (v *clusterVec) with(values []string) (prometheus.Collector, []string) {
+	custom := v.custom.get(values[0], values[1])
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		if !containsString(v.labelNames, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	all := make([]string, 0, len(values)+len(names))
+	all = append(all, values...)
+	for _, name := range names {
+		all = append(all, custom[name])
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key := strings.Join(names, ",")
+	vec, ok := v.vecs[key]
+	if !ok {
+		labelNames := make([]string, 0, len(v.labelNames)+len(names))
+		labelNames = append(labelNames, v.labelNames...)
+		labelNames = append(labelNames, names...)
+		vec = v.newVec(labelNames)
+		v.vecs[key] = vec
+	}
+	return vec, all
+}
+
+func (v *clusterVec) gauge(values ...string) prometheus.Gauge {
+	vec, all := v.with(values)
+	return vec.(*prometheus.GaugeVec).WithLabelValues(all...)
+}
+
+func (v *clusterVec) counter(values ...string) prometheus.Counter {
+	vec, all := v.with(values)
+	return vec.(*prometheus.CounterVec).WithLabelValues(all...)
+}
+
+// deletePartialMatch deletes the series matching the labels, whatever their custom labels.
+func (v *clusterVec) deletePartialMatch(labels prometheus.Labels) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, vec := range v.vecs {
+		vec.(partialDeleter).DeletePartialMatch(labels)
+	}
+}
+
+// deleteCluster deletes all the series of the cluster.
+func (v *clusterVec) deleteCluster(namespace string, name string) {
+	v.deletePartialMatch(prometheus.Labels{v.labelNames[0]: namespace, v.labelNames[1]: name})
+}
+
+// Describe sends no descriptor, the vector is unchecked as its label names vary by cluster.
+func (v *clusterVec) Describe(chan<- *prometheus.Desc) {}
+
+// Collect collects the series of all the vectors.
+func (v *clusterVec) Collect(ch chan<- prometheus.Metric) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, vec := range v.vecs {
+		vec.Collect(ch)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
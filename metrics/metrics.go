@@ -29,6 +29,7 @@ const (
 	MISC                                   = "MISC_ERROR"
 	SENTINEL_NUMBER_IN_MEMORY_MISMATCH     = "SENTINEL_NUMBER_IN_MEMORY_MISMATCH"
 	REDIS_SLAVES_NUMBER_IN_MEMORY_MISMATCH = "REDIS_SLAVES_NUMBER_IN_MEMORY_MISMATCH"
+	SENTINEL_NOT_RESPONDING                = "SENTINEL_NOT_RESPONDING"
 	// redis connection related errors
 	WRONG_PASSWORD_USED = "WRONG_PASSWORD_USED"
 	NOAUTH              = "AUTH_CREDENTIALS_NOT_PROVIDED"
@@ -36,10 +37,27 @@ const (
 	IO_TIMEOUT          = "CONNECTION_TIMEDOUT"
 	CONNECTION_REFUSED  = "CONNECTION_REFUSED"
 
-	K8S_FORBIDDEN_ERR = "USER_FORBIDDEN_TO_PERFORM_ACTION"
-	K8S_UNAUTH        = "CLIENT_NOT_AUTHORISED"
-	K8S_MISC          = "MISC_ERROR_CHECK_LOGS"
-	K8S_NOT_FOUND     = "RESOURCE_NOT_FOUND"
+	K8S_FORBIDDEN_ERR     = "USER_FORBIDDEN_TO_PERFORM_ACTION"
+	K8S_UNAUTH            = "CLIENT_NOT_AUTHORISED"
+	K8S_MISC              = "MISC_ERROR_CHECK_LOGS"
+	K8S_NOT_FOUND         = "RESOURCE_NOT_FOUND"
+	K8S_TOO_MANY_REQUESTS = "APISERVER_TOO_MANY_REQUESTS"
+	// K8S_NAMESPACE_TERMINATING is expected while the namespace of a redisfailover is deleted.
+	K8S_NAMESPACE_TERMINATING = "NAMESPACE_TERMINATING"
+	// K8S_CONTEXT_DONE is recorded for the operations of a reconcile cancelled or past its deadline.
+	K8S_CONTEXT_DONE = "CONTEXT_DONE"
+	// K8S_EVICTION_BLOCKED is recorded for the evictions refused by the PodDisruptionBudget of the pod.
+	K8S_EVICTION_BLOCKED = "EVICTION_BLOCKED_BY_PDB"
+
+	// reasons to skip the reconciliation of a redisfailover
+	OPERATOR_TOO_OLD          = "OPERATOR_TOO_OLD"
+	MANAGED_BY_OTHER_OPERATOR = "MANAGED_BY_OTHER_OPERATOR"
+	NAMESPACE_TERMINATING     = "NAMESPACE_TERMINATING"
+
+	// escalation steps of the remediation of a stuck replica
+	STUCK_REPLICA_RAISE_BACKLOG = "RAISE_REPL_BACKLOG"
+	STUCK_REPLICA_RETRY         = "RETRY_REPLICATION"
+	STUCK_REPLICA_RESTART       = "RESTART_REPLICA"
 
 	KIND_REDIS                  = "REDIS"
 	KIND_SENTINEL               = "SENTINEL"
@@ -54,7 +72,20 @@ const (
 	MAKE_MASTER                 = "MAKE_INSTANCE_AS_MASTER"
 	MAKE_SLAVE_OF               = "MAKE_SLAVE_OF_GIVEN_MASTER_INSTANCE"
 	GET_SENTINEL_MONITOR        = "SENTINEL_GET_MASTER_INSTANCE"
+	IS_MASTER_DOWN_BY_ADDR      = "SENTINEL_CHECK_IF_MASTER_IS_DOWN"
+	GET_SENTINEL_CONFIG         = "SENTINEL_GET_GLOBAL_CONFIG"
+	SET_SENTINEL_CONFIG         = "SENTINEL_SET_GLOBAL_CONFIG"
 	SLAVE_IS_READY              = "CHECK_IF_SLAVE_IS_READY"
+	GET_SYNCING_REPLICAS        = "GET_NUMBER_OF_REPLICAS_IN_FULL_SYNC"
+	GET_INFO                    = "GET_INSTANCE_INFO"
+	GET_REDIS_CONFIG            = "GET_REDIS_CONFIG"
+	MEMORY_DOCTOR               = "RUN_MEMORY_DOCTOR"
+	LATENCY_DOCTOR              = "RUN_LATENCY_DOCTOR"
+	PING                        = "PING_INSTANCE"
+	SET_KEY                     = "SET_KEY"
+	GET_KEY                     = "GET_KEY"
+	LOAD_ACL                    = "LOAD_ACL"
+	LIST_ACL                    = "LIST_ACL"
 )
 
 // Instrumenter is the interface that will collect the metrics and has ability to send/expose those metrics.
@@ -74,29 +105,101 @@ type Recorder interface {
 
 	RecordK8sOperation(namespace string, kind string, object string, operation string, status string, err string)
 	RecordRedisOperation(kind string, IP string, operation string, status string, err string)
+
+	// Container restarts of the redis and sentinel instances
+	SetInstanceRestarts(namespace string, name string, pod string, container string, restarts int32)
+	DeleteInstanceRestarts(namespace string, name string, pod string, container string)
+	ResetInstanceRestarts(namespace string, name string)
+
+	// Round-trip of the PING the operator sends to the redis instances, and its jitter
+	SetRedisLatency(namespace string, name string, pod string, latency float64, jitter float64)
+	ResetRedisLatency(namespace string, name string)
+
+	// Result of the functional probe of the redis instances, the key written on the master read back
+	SetRedisFunctionalHealth(namespace string, name string, pod string, healthy bool)
+	ResetRedisFunctionalHealth(namespace string, name string)
+
+	// Indicate a redisfailover has not been reconciled
+	RecordReconcileSkipped(namespace string, name string, reason string)
+
+	// Indicate the installed CRD does not match the operator types
+	SetRedisFailoverSchemaMismatch(mismatch bool)
+
+	// Indicate an escalation step taken on a replica stuck with the link to the master down
+	RecordStuckReplicaRemediation(namespace string, name string, step string)
+
+	// Sentinel pods running but not answering on the sentinel port, and their restarts
+	SetUnresponsiveSentinels(namespace string, name string, count int)
+	RecordSentinelRestart(namespace string, name string)
+
+	// Custom labels added to the metrics of a cluster
+	SetClusterLabels(namespace string, name string, labels map[string]string)
+
+	// Seconds the checks are widened by while the apiserver answers 429 Too Many Requests
+	SetAPIServerPressure(widening float64)
+
+	// Number of redisfailovers using a deprecated field
+	SetDeprecatedFieldUses(field string, count int)
+
+	// API version of the PodDisruptionBudgets served by the cluster, none when it serves none
+	SetPodDisruptionBudgetAPIVersion(version string)
+
+	// Indicate an update retried after a conflict with a newer version of the object
+	RecordK8sConflictRetry(namespace string, kind string, object string)
+
+	// Seconds a reconcile waited for a free slot of its namespace
+	ObserveReconcileQueueWait(namespace string, wait float64)
+
+	// Indicate a redisfailover event merged into another reconcile or skipped
+	RecordReconcileCoalesced(namespace string, reason string)
+
+	// Seconds a check of the checker took, and its errors
+	ObserveCheckDuration(namespace string, check string, duration float64)
+	RecordCheckError(namespace string, name string, check string)
 }
 
 // PromMetrics implements the instrumenter so the metrics can be managed by Prometheus.
 type recorder struct {
 	// Metrics fields.
-	clusterOK            *prometheus.GaugeVec   // clusterOk is the status of a cluster
-	ensureResource       *prometheus.CounterVec // number of successful "ensure" operators performed by the controller.
-	redisCheck           *prometheus.CounterVec // indicates any error encountered in managed redis instance(s)
-	sentinelCheck        *prometheus.CounterVec // indicates any error encountered in managed sentinel instance(s)
-	k8sServiceOperations *prometheus.CounterVec // number of operations performed on k8s
-	redisOperations      *prometheus.CounterVec // number of operations performed on redis/sentinel instances
+	clusterOK            *clusterVec              // clusterOk is the status of a cluster
+	ensureResource       *prometheus.CounterVec   // number of successful "ensure" operators performed by the controller.
+	redisCheck           *clusterVec              // indicates any error encountered in managed redis instance(s)
+	sentinelCheck        *clusterVec              // indicates any error encountered in managed sentinel instance(s)
+	k8sServiceOperations *prometheus.CounterVec   // number of operations performed on k8s
+	redisOperations      *prometheus.CounterVec   // number of operations performed on redis/sentinel instances
+	instanceRestarts     *clusterVec              // container restarts of the redis and sentinel instances
+	redisLatency         *clusterVec              // round-trip of the PING sent by the operator to the redis instances
+	redisJitter          *clusterVec              // jitter of the round-trip of the PING sent to the redis instances
+	redisFunctional      *clusterVec              // 1 when the redis instance passed the functional probe
+	reconcileSkipped     *clusterVec              // number of reconciliations skipped by the controller
+	crdSchemaMismatch    prometheus.Gauge         // 1 when the installed CRD schema does not match the operator types
+	stuckReplicas        *clusterVec              // number of escalation steps taken on stuck replicas
+	unresponsive         *clusterVec              // sentinel pods not answering on the sentinel port
+	sentinelRestarts     *clusterVec              // number of unresponsive sentinel pods restarted
+	clusterLabels        *clusterLabels           // custom labels of the clusters added to their metrics
+	apiServerPressure    prometheus.Gauge         // seconds the checks are widened by under apiserver pressure
+	deprecatedFields     *prometheus.GaugeVec     // number of redisfailovers using every deprecated field
+	pdbAPIVersion        *prometheus.GaugeVec     // 1 for the API version of the PodDisruptionBudgets in use
+	k8sConflictRetries   *prometheus.CounterVec   // number of k8s updates retried after a conflict
+	reconcileQueueWait   *prometheus.HistogramVec // seconds the reconciles waited for a slot of their namespace
+	reconcileCoalesced   *prometheus.CounterVec   // number of redisfailover events coalesced into another reconcile
+	checkDuration        *prometheus.HistogramVec // seconds the checks of the checker took
+	checkErrors          *clusterVec              // number of errors of the checks of the checker
 	koopercontroller.MetricsRecorder
 }
 
 // NewPrometheusMetrics returns a new PromMetrics object.
 func NewRecorder(namespace string, reg prometheus.Registerer) Recorder {
+	// The metrics of the clusters get their custom labels.
+	labels := newClusterLabels()
+
 	// Create metrics.
-	clusterOK := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	clusterOK := newClusterGaugeVec(prometheus.GaugeOpts{
 		Namespace: namespace,
 		Subsystem: promControllerSubsystem,
 		Name:      "cluster_ok",
 		Help:      "Number of failover clusters managed by the operator.",
-	}, []string{"namespace", "name"})
+	}, []string{"namespace", "name"}, labels)
 
 	ensureResource := prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: namespace,
@@ -105,19 +208,19 @@ func NewRecorder(namespace string, reg prometheus.Registerer) Recorder {
 		Help:      "number of 'ensure' operations on a resource performed by the controller.",
 	}, []string{"object_namespace", "object_name", "object_kind", "resource_name", "status"})
 
-	redisCheck := prometheus.NewCounterVec(prometheus.CounterOpts{
+	redisCheck := newClusterCounterVec(prometheus.CounterOpts{
 		Namespace: namespace,
 		Subsystem: promControllerSubsystem,
 		Name:      "redis_checks_total",
 		Help:      "indicates any error encountered in managed redis instance(s)",
-	}, []string{"namespace", "resource", "indicator", "instance", "status"})
+	}, []string{"namespace", "resource", "indicator", "instance", "status"}, labels)
 
-	sentinelCheck := prometheus.NewCounterVec(prometheus.CounterOpts{
+	sentinelCheck := newClusterCounterVec(prometheus.CounterOpts{
 		Namespace: namespace,
 		Subsystem: promControllerSubsystem,
 		Name:      "sentinel_checks_total",
 		Help:      "indicates any error encountered in managed sentinel instance(s)",
-	}, []string{"namespace", "resource", "indicator", "instance", "status"})
+	}, []string{"namespace", "resource", "indicator", "instance", "status"}, labels)
 
 	redisOperations := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -134,6 +237,128 @@ func NewRecorder(namespace string, reg prometheus.Registerer) Recorder {
 			Name:      "k8s_operations_total",
 			Help:      "number of operations performed on k8s",
 		}, []string{"namespace", "kind", "object", "operation", "status", "err"})
+
+	instanceRestarts := newClusterGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "instance_restarts",
+		Help:      "Restart count of the containers of the redis and sentinel pods.",
+	}, []string{"namespace", "name", "pod", "container"}, labels)
+
+	redisLatency := newClusterGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "redis_ping_latency_seconds",
+		Help:      "Round-trip of the last PING sent by the operator to the redis pods.",
+	}, []string{"namespace", "name", "pod"}, labels)
+
+	redisJitter := newClusterGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "redis_ping_jitter_seconds",
+		Help:      "Mean difference between the consecutive round-trips of the PING sent by the operator to the redis pods.",
+	}, []string{"namespace", "name", "pod"}, labels)
+
+	redisFunctional := newClusterGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "redis_functional_healthy",
+		Help:      "1 when the redis pod served the key of the functional probe, 0 when it answers the PING but failed it.",
+	}, []string{"namespace", "name", "pod"}, labels)
+
+	reconcileSkipped := newClusterCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "reconcile_skipped_total",
+		Help:      "number of redisfailover reconciliations skipped by the controller.",
+	}, []string{"namespace", "name", "reason"}, labels)
+
+	crdSchemaMismatch := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "crd_schema_mismatch",
+		Help:      "1 when the installed redisfailover CRD drops or rejects fields known by the operator.",
+	})
+
+	stuckReplicas := newClusterCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "stuck_replica_remediations_total",
+		Help:      "number of escalation steps taken on the replicas stuck with the link to the master down.",
+	}, []string{"namespace", "name", "step"}, labels)
+
+	unresponsive := newClusterGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "unresponsive_sentinels",
+		Help:      "Number of running sentinel pods not answering on the sentinel port.",
+	}, []string{"namespace", "name"}, labels)
+
+	sentinelRestarts := newClusterCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "unresponsive_sentinel_restarts_total",
+		Help:      "number of sentinel pods restarted because they were not answering on the sentinel port.",
+	}, []string{"namespace", "name"}, labels)
+
+	apiServerPressure := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "apiserver_pressure_widening_seconds",
+		Help:      "Seconds the check intervals are widened by while the apiserver answers 429 Too Many Requests, 0 without pressure.",
+	})
+
+	deprecatedFields := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "deprecated_field_uses",
+		Help:      "Number of redisfailovers setting a deprecated field of the spec.",
+	}, []string{"field"})
+
+	pdbAPIVersion := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "pod_disruption_budget_api_version",
+		Help:      "1 for the API version the PodDisruptionBudgets are written with, none when the cluster serves none.",
+	}, []string{"version"})
+
+	k8sConflictRetries := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "k8s_conflict_retries_total",
+		Help:      "number of k8s updates retried after a conflict with a newer version of the object.",
+	}, []string{"namespace", "kind", "object"})
+
+	reconcileQueueWait := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "reconcile_queue_wait_seconds",
+		Help:      "Seconds the redisfailover reconciles waited for a free slot of their namespace.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300},
+	}, []string{"namespace"})
+
+	reconcileCoalesced := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "reconcile_coalesced_events_total",
+		Help:      "number of redisfailover events merged into another reconcile or skipped.",
+	}, []string{"namespace", "reason"})
+
+	checkDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "check_duration_seconds",
+		Help:      "Seconds the checks of the redisfailovers took.",
+		Buckets:   []float64{0.005, 0.025, 0.1, 0.5, 1, 5, 15},
+	}, []string{"namespace", "check"})
+
+	checkErrors := newClusterCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: promControllerSubsystem,
+		Name:      "check_errors_total",
+		Help:      "number of errors of the checks of the redisfailovers.",
+	}, []string{"namespace", "name", "check"}, labels)
+
 	// Create the instance.
 	r := recorder{
 		clusterOK:            clusterOK,
@@ -142,6 +367,24 @@ func NewRecorder(namespace string, reg prometheus.Registerer) Recorder {
 		sentinelCheck:        sentinelCheck,
 		k8sServiceOperations: k8sServiceOperations,
 		redisOperations:      redisOperations,
+		instanceRestarts:     instanceRestarts,
+		redisLatency:         redisLatency,
+		redisJitter:          redisJitter,
+		redisFunctional:      redisFunctional,
+		reconcileSkipped:     reconcileSkipped,
+		crdSchemaMismatch:    crdSchemaMismatch,
+		stuckReplicas:        stuckReplicas,
+		unresponsive:         unresponsive,
+		sentinelRestarts:     sentinelRestarts,
+		clusterLabels:        labels,
+		apiServerPressure:    apiServerPressure,
+		deprecatedFields:     deprecatedFields,
+		pdbAPIVersion:        pdbAPIVersion,
+		k8sConflictRetries:   k8sConflictRetries,
+		reconcileQueueWait:   reconcileQueueWait,
+		reconcileCoalesced:   reconcileCoalesced,
+		checkDuration:        checkDuration,
+		checkErrors:          checkErrors,
 		MetricsRecorder: kooperprometheus.New(kooperprometheus.Config{
 			Registerer: reg,
 		}),
@@ -155,6 +398,23 @@ func NewRecorder(namespace string, reg prometheus.Registerer) Recorder {
 		r.sentinelCheck,
 		r.k8sServiceOperations,
 		r.redisOperations,
+		r.instanceRestarts,
+		r.redisLatency,
+		r.redisJitter,
+		r.redisFunctional,
+		r.reconcileSkipped,
+		r.crdSchemaMismatch,
+		r.stuckReplicas,
+		r.unresponsive,
+		r.sentinelRestarts,
+		r.apiServerPressure,
+		r.deprecatedFields,
+		r.pdbAPIVersion,
+		r.k8sConflictRetries,
+		r.reconcileQueueWait,
+		r.reconcileCoalesced,
+		r.checkDuration,
+		r.checkErrors,
 	)
 
 	return r
@@ -162,17 +422,17 @@ func NewRecorder(namespace string, reg prometheus.Registerer) Recorder {
 
 // SetClusterOK set the cluster status to OK
 func (r recorder) SetClusterOK(namespace string, name string) {
-	r.clusterOK.WithLabelValues(namespace, name).Set(1)
+	r.clusterOK.gauge(namespace, name).Set(1)
 }
 
 // SetClusterError set the cluster status to Error
 func (r recorder) SetClusterError(namespace string, name string) {
-	r.clusterOK.WithLabelValues(namespace, name).Set(0)
+	r.clusterOK.gauge(namespace, name).Set(0)
 }
 
 // DeleteCluster set the cluster status to Error
 func (r recorder) DeleteCluster(namespace string, name string) {
-	r.clusterOK.DeleteLabelValues(namespace, name)
+	r.clusterOK.deleteCluster(namespace, name)
 }
 
 func (r recorder) RecordEnsureOperation(objectNamespace string, objectName string, objectKind string, resourceName string, status string) {
@@ -180,11 +440,11 @@ func (r recorder) RecordEnsureOperation(objectNamespace string, objectName strin
 }
 
 func (r recorder) RecordRedisCheck(namespace string, resource string, indicator /* aspect of redis that is unhealthy */ string, instance string, status string) {
-	r.redisCheck.WithLabelValues(namespace, resource, indicator, instance, status).Add(1)
+	r.redisCheck.counter(namespace, resource, indicator, instance, status).Add(1)
 }
 
 func (r recorder) RecordSentinelCheck(namespace string, resource string, indicator /* aspect of sentinel that is unhealthy */ string, instance string, status string) {
-	r.sentinelCheck.WithLabelValues(namespace, resource, indicator, instance, status).Add(1)
+	r.sentinelCheck.counter(namespace, resource, indicator, instance, status).Add(1)
 }
 
 func (r recorder) RecordK8sOperation(namespace string, kind string, object string, operation string, status string, err string) {
@@ -194,3 +454,129 @@ func (r recorder) RecordK8sOperation(namespace string, kind string, object strin
 func (r recorder) RecordRedisOperation(kind /*redis/sentinel? */ string, IP string, operation string, status string, err string) {
 	r.redisOperations.WithLabelValues(kind, IP, operation, status, err).Add(1)
 }
+
+// SetInstanceRestarts sets the restart count of a container of a redis or sentinel pod
+func (r recorder) SetInstanceRestarts(namespace string, name string, pod string, container string, restarts int32) {
+	r.instanceRestarts.gauge(namespace, name, pod, container).Set(float64(restarts))
+}
+
+// DeleteInstanceRestarts removes the restart count of a container of a redis or sentinel pod
+func (r recorder) DeleteInstanceRestarts(namespace string, name string, pod string, container string) {
+	r.instanceRestarts.deletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name, "pod": pod, "container": container})
+}
+
+// ResetInstanceRestarts removes the restart counts of all the pods of a cluster
+func (r recorder) ResetInstanceRestarts(namespace string, name string) {
+	r.instanceRestarts.deletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+}
+
+// SetRedisLatency sets the round-trip of the last PING sent to a redis pod and its jitter, in seconds
+func (r recorder) SetRedisLatency(namespace string, name string, pod string, latency float64, jitter float64) {
+	r.redisLatency.gauge(namespace, name, pod).Set(latency)
+	r.redisJitter.gauge(namespace, name, pod).Set(jitter)
+}
+
+// ResetRedisLatency removes the round-trips of all the redis pods of a cluster
+func (r recorder) ResetRedisLatency(namespace string, name string) {
+	r.redisLatency.deletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+	r.redisJitter.deletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+}
+
+// SetRedisFunctionalHealth sets if a redis pod passed the functional probe
+func (r recorder) SetRedisFunctionalHealth(namespace string, name string, pod string, healthy bool) {
+	if healthy {
+		r.redisFunctional.gauge(namespace, name, pod).Set(1)
+		return
+	}
+	r.redisFunctional.gauge(namespace, name, pod).Set(0)
+}
+
+// ResetRedisFunctionalHealth removes the functional probe results of all the redis pods of a cluster
+func (r recorder) ResetRedisFunctionalHealth(namespace string, name string) {
+	r.redisFunctional.deletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+}
+
+// RecordReconcileSkipped counts a redisfailover reconciliation skipped for the given reason
+func (r recorder) RecordReconcileSkipped(namespace string, name string, reason string) {
+	r.reconcileSkipped.counter(namespace, name, reason).Add(1)
+}
+
+// SetRedisFailoverSchemaMismatch sets if the installed CRD schema does not match the operator types
+func (r recorder) SetRedisFailoverSchemaMismatch(mismatch bool) {
+	if mismatch {
+		r.crdSchemaMismatch.Set(1)
+		return
+	}
+	r.crdSchemaMismatch.Set(0)
+}
+
+// RecordStuckReplicaRemediation counts an escalation step taken on a stuck replica
+func (r recorder) RecordStuckReplicaRemediation(namespace string, name string, step string) {
+	r.stuckReplicas.counter(namespace, name, step).Add(1)
+}
+
+// SetUnresponsiveSentinels sets the number of running sentinel pods not answering on the sentinel port
+func (r recorder) SetUnresponsiveSentinels(namespace string, name string, count int) {
+	r.unresponsive.gauge(namespace, name).Set(float64(count))
+}
+
+// RecordSentinelRestart counts a sentinel pod restarted because it was not answering on the sentinel port
+func (r recorder) RecordSentinelRestart(namespace string, name string) {
+	r.sentinelRestarts.counter(namespace, name).Add(1)
+}
+
+// SetClusterLabels sets the custom labels added to the metrics of a cluster. The series of the cluster
+// are deleted when they change, so the ones with the old labels are not exposed anymore.
+func (r recorder) SetClusterLabels(namespace string, name string, labels map[string]string) {
+	if !r.clusterLabels.set(namespace, name, labels) {
+		return
+	}
+	for _, vec := range []*clusterVec{r.clusterOK, r.redisCheck, r.sentinelCheck, r.instanceRestarts, r.redisLatency, r.redisJitter, r.redisFunctional, r.reconcileSkipped, r.stuckReplicas, r.unresponsive, r.sentinelRestarts} {
+		vec.deleteCluster(namespace, name)
+	}
+}
+
+// SetAPIServerPressure sets the seconds the checks are widened by while the apiserver answers 429 Too
+// Many Requests
+func (r recorder) SetAPIServerPressure(widening float64) {
+	r.apiServerPressure.Set(widening)
+}
+
+// SetDeprecatedFieldUses sets the number of redisfailovers setting a deprecated field of the spec
+func (r recorder) SetDeprecatedFieldUses(field string, count int) {
+	r.deprecatedFields.WithLabelValues(field).Set(float64(count))
+}
+
+// SetPodDisruptionBudgetAPIVersion sets the API version the PodDisruptionBudgets are written with, the
+// previous one is removed
+func (r recorder) SetPodDisruptionBudgetAPIVersion(version string) {
+	r.pdbAPIVersion.Reset()
+	r.pdbAPIVersion.WithLabelValues(version).Set(1)
+}
+
+// RecordK8sConflictRetry records an update of a k8s object retried after a conflict with a newer version
+// of the object
+func (r recorder) RecordK8sConflictRetry(namespace string, kind string, object string) {
+	r.k8sConflictRetries.WithLabelValues(namespace, kind, object).Add(1)
+}
+
+// ObserveReconcileQueueWait records the seconds a reconcile waited for a free slot of its namespace
+func (r recorder) ObserveReconcileQueueWait(namespace string, wait float64) {
+	r.reconcileQueueWait.WithLabelValues(namespace).Observe(wait)
+}
+
+// RecordReconcileCoalesced records a redisfailover event merged into another reconcile or skipped, with
+// the reason
+func (r recorder) RecordReconcileCoalesced(namespace string, reason string) {
+	r.reconcileCoalesced.WithLabelValues(namespace, reason).Add(1)
+}
+
+// ObserveCheckDuration records the seconds a check of the checker took
+func (r recorder) ObserveCheckDuration(namespace string, check string, duration float64) {
+	r.checkDuration.WithLabelValues(namespace, check).Observe(duration)
+}
+
+// RecordCheckError counts an error of a check of the checker
+func (r recorder) RecordCheckError(namespace string, name string, check string) {
+	r.checkErrors.counter(namespace, name, check).Add(1)
+}
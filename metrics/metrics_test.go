@@ -109,6 +109,213 @@ func TestPrometheusMetrics(t *testing.T) {
 			},
 			expCode: http.StatusOK,
 		},
+		{
+			name: "Setting instance restarts should expose them per container",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetInstanceRestarts("testns", "test", "rfr-test-0", "redis", 7)
+				rec.SetInstanceRestarts("testns", "test", "rfr-test-0", "redis-exporter", 2)
+			},
+			expMetrics: []string{
+				`my_metrics_controller_instance_restarts{container="redis",name="test",namespace="testns",pod="rfr-test-0"} 7`,
+				`my_metrics_controller_instance_restarts{container="redis-exporter",name="test",namespace="testns",pod="rfr-test-0"} 2`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Resetting instance restarts should remove only the ones of the cluster",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetInstanceRestarts("testns", "test", "rfr-test-0", "redis", 7)
+				rec.SetInstanceRestarts("testns", "test2", "rfr-test2-0", "redis", 1)
+				rec.ResetInstanceRestarts("testns", "test")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_instance_restarts{container="redis",name="test2",namespace="testns",pod="rfr-test2-0"} 1`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Deleting instance restarts should remove only the ones of the container",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetInstanceRestarts("testns", "test", "rfr-test-0", "redis", 7)
+				rec.SetInstanceRestarts("testns", "test", "rfr-test-0", "redis-exporter", 2)
+				rec.SetInstanceRestarts("testns", "test", "rfs-test-abc", "sentinel", 1)
+				rec.DeleteInstanceRestarts("testns", "test", "rfr-test-0", "redis-exporter")
+				rec.DeleteInstanceRestarts("testns", "test", "rfs-test-abc", "sentinel")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_instance_restarts{container="redis",name="test",namespace="testns",pod="rfr-test-0"} 7`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Setting the redis latency should expose the round-trip and the jitter per pod",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetRedisLatency("testns", "test", "rfr-test-0", 0.25, 0.5)
+				rec.SetRedisLatency("testns", "test2", "rfr-test2-0", 0.125, 0)
+				rec.ResetRedisLatency("testns", "test2")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_redis_ping_latency_seconds{name="test",namespace="testns",pod="rfr-test-0"} 0.25`,
+				`my_metrics_controller_redis_ping_jitter_seconds{name="test",namespace="testns",pod="rfr-test-0"} 0.5`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Setting the redis functional health should expose the probe result per pod",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetRedisFunctionalHealth("testns", "test", "rfr-test-0", true)
+				rec.SetRedisFunctionalHealth("testns", "test", "rfr-test-1", false)
+				rec.SetRedisFunctionalHealth("testns", "test2", "rfr-test2-0", true)
+				rec.ResetRedisFunctionalHealth("testns", "test2")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_redis_functional_healthy{name="test",namespace="testns",pod="rfr-test-0"} 1`,
+				`my_metrics_controller_redis_functional_healthy{name="test",namespace="testns",pod="rfr-test-1"} 0`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Skipping a reconciliation should be counted",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.RecordReconcileSkipped("testns", "test", metrics.OPERATOR_TOO_OLD)
+				rec.RecordReconcileSkipped("testns", "test", metrics.OPERATOR_TOO_OLD)
+			},
+			expMetrics: []string{
+				`my_metrics_controller_reconcile_skipped_total{name="test",namespace="testns",reason="OPERATOR_TOO_OLD"} 2`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Stuck replica remediations should be counted by step",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.RecordStuckReplicaRemediation("testns", "test", metrics.STUCK_REPLICA_RETRY)
+				rec.RecordStuckReplicaRemediation("testns", "test", metrics.STUCK_REPLICA_RESTART)
+				rec.RecordStuckReplicaRemediation("testns", "test", metrics.STUCK_REPLICA_RETRY)
+			},
+			expMetrics: []string{
+				`my_metrics_controller_stuck_replica_remediations_total{name="test",namespace="testns",step="RESTART_REPLICA"} 1`,
+				`my_metrics_controller_stuck_replica_remediations_total{name="test",namespace="testns",step="RETRY_REPLICATION"} 2`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Unresponsive sentinels and their restarts should be exposed",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetUnresponsiveSentinels("testns", "test", 2)
+				rec.SetUnresponsiveSentinels("testns", "test", 1)
+				rec.RecordSentinelRestart("testns", "test")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_unresponsive_sentinels{name="test",namespace="testns"} 1`,
+				`my_metrics_controller_unresponsive_sentinel_restarts_total{name="test",namespace="testns"} 1`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Setting a CRD schema mismatch should be exposed",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetRedisFailoverSchemaMismatch(true)
+			},
+			expMetrics: []string{
+				`my_metrics_controller_crd_schema_mismatch 1`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Clearing a CRD schema mismatch should be exposed",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetRedisFailoverSchemaMismatch(true)
+				rec.SetRedisFailoverSchemaMismatch(false)
+			},
+			expMetrics: []string{
+				`my_metrics_controller_crd_schema_mismatch 0`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Widening the checks under apiserver pressure should be exposed",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetAPIServerPressure(60)
+			},
+			expMetrics: []string{
+				`my_metrics_controller_apiserver_pressure_widening_seconds 60`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Deprecated field uses should be exposed",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetDeprecatedFieldUses("redis.masterDNS.ttl", 2)
+				rec.SetDeprecatedFieldUses("redis.terminationGracePeriod", 0)
+			},
+			expMetrics: []string{
+				`my_metrics_controller_deprecated_field_uses{field="redis.masterDNS.ttl"} 2`,
+				`my_metrics_controller_deprecated_field_uses{field="redis.terminationGracePeriod"} 0`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "The API version of the PodDisruptionBudgets should be exposed",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetPodDisruptionBudgetAPIVersion("policy/v1")
+				rec.SetPodDisruptionBudgetAPIVersion("policy/v1beta1")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_pod_disruption_budget_api_version{version="policy/v1beta1"} 1`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "The k8s updates retried after a conflict should be exposed",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.RecordK8sConflictRetry("testns", "StatefulSet", "rfr-test")
+				rec.RecordK8sConflictRetry("testns", "StatefulSet", "rfr-test")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_k8s_conflict_retries_total{kind="StatefulSet",namespace="testns",object="rfr-test"} 2`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "The wait of the reconciles for a slot of their namespace should be exposed",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.ObserveReconcileQueueWait("testns", 0)
+				rec.ObserveReconcileQueueWait("testns", 2)
+			},
+			expMetrics: []string{
+				`my_metrics_controller_reconcile_queue_wait_seconds_bucket{namespace="testns",le="1"} 1`,
+				`my_metrics_controller_reconcile_queue_wait_seconds_bucket{namespace="testns",le="5"} 2`,
+				`my_metrics_controller_reconcile_queue_wait_seconds_sum{namespace="testns"} 2`,
+				`my_metrics_controller_reconcile_queue_wait_seconds_count{namespace="testns"} 2`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "The redisfailover events coalesced should be exposed",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.RecordReconcileCoalesced("testns", "debounced")
+				rec.RecordReconcileCoalesced("testns", "debounced")
+				rec.RecordReconcileCoalesced("testns", "status-only")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_reconcile_coalesced_events_total{namespace="testns",reason="debounced"} 2`,
+				`my_metrics_controller_reconcile_coalesced_events_total{namespace="testns",reason="status-only"} 1`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "The duration and the errors of the checks should be exposed",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.ObserveCheckDuration("testns", "sentinel-config", 0.02)
+				rec.RecordCheckError("testns", "test", "sentinel-config")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_check_duration_seconds_bucket{check="sentinel-config",namespace="testns",le="0.025"} 1`,
+				`my_metrics_controller_check_duration_seconds_count{check="sentinel-config",namespace="testns"} 1`,
+				`my_metrics_controller_check_errors_total{check="sentinel-config",name="test",namespace="testns"} 1`,
+			},
+			expCode: http.StatusOK,
+		},
 	}
 
 	for _, test := range tests {
@@ -138,3 +345,127 @@ func TestPrometheusMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestPrometheusMetricsClusterLabels(t *testing.T) {
+	tests := []struct {
+		name       string
+		addMetrics func(rec metrics.Recorder)
+		expMetrics []string
+		expMissing []string
+	}{
+		{
+			name: "The labels of a cluster should be added to its metrics",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetClusterLabels("testns", "test", map[string]string{"team": "payments", "tier": "gold"})
+				rec.SetClusterOK("testns", "test")
+				rec.SetInstanceRestarts("testns", "test", "rfr-test-0", "redis", 3)
+				rec.RecordRedisCheck("testns", "test", metrics.SLAVE_WRONG_MASTER, "0.0.0.0", metrics.STATUS_HEALTHY)
+			},
+			expMetrics: []string{
+				`my_metrics_controller_cluster_ok{name="test",namespace="testns",team="payments",tier="gold"} 1`,
+				`my_metrics_controller_instance_restarts{container="redis",name="test",namespace="testns",pod="rfr-test-0",team="payments",tier="gold"} 3`,
+				`my_metrics_controller_redis_checks_total{indicator="SLAVE_IS_CONFIGURED_WITH_WRONG_MASTER_IP",instance="0.0.0.0",namespace="testns",resource="test",status="HEALTHY",team="payments",tier="gold"} 1`,
+			},
+		},
+		{
+			name: "The labels of a cluster should not be added to the metrics of the others",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetClusterLabels("testns", "test", map[string]string{"team": "payments"})
+				rec.SetClusterOK("testns", "test")
+				rec.SetClusterOK("testns", "test2")
+				rec.SetClusterLabels("testns", "test3", map[string]string{"tier": "gold"})
+				rec.SetClusterOK("testns", "test3")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_cluster_ok{name="test",namespace="testns",team="payments"} 1`,
+				`my_metrics_controller_cluster_ok{name="test2",namespace="testns"} 1`,
+				`my_metrics_controller_cluster_ok{name="test3",namespace="testns",tier="gold"} 1`,
+			},
+		},
+		{
+			name: "The series with the old labels should be deleted when they change",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetClusterLabels("testns", "test", map[string]string{"team": "payments"})
+				rec.SetClusterOK("testns", "test")
+				rec.SetUnresponsiveSentinels("testns", "test", 1)
+				rec.RecordSentinelRestart("testns", "test")
+				rec.SetClusterOK("testns", "test2")
+				rec.SetClusterLabels("testns", "test", map[string]string{"team": "billing"})
+				rec.SetClusterOK("testns", "test")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_cluster_ok{name="test",namespace="testns",team="billing"} 1`,
+				`my_metrics_controller_cluster_ok{name="test2",namespace="testns"} 1`,
+			},
+			expMissing: []string{
+				`team="payments"`,
+				`my_metrics_controller_unresponsive_sentinels{`,
+				`my_metrics_controller_unresponsive_sentinel_restarts_total{`,
+			},
+		},
+		{
+			name: "The series should lose the labels removed",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetClusterLabels("testns", "test", map[string]string{"team": "payments"})
+				rec.SetClusterOK("testns", "test")
+				rec.SetClusterLabels("testns", "test", nil)
+				rec.SetClusterOK("testns", "test")
+			},
+			expMetrics: []string{
+				`my_metrics_controller_cluster_ok{name="test",namespace="testns"} 1`,
+			},
+			expMissing: []string{
+				`team="payments"`,
+			},
+		},
+		{
+			name: "The series should be kept when the labels don't change",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetClusterLabels("testns", "test", map[string]string{"team": "payments"})
+				rec.RecordReconcileSkipped("testns", "test", metrics.OPERATOR_TOO_OLD)
+				rec.SetClusterLabels("testns", "test", map[string]string{"team": "payments"})
+				rec.RecordReconcileSkipped("testns", "test", metrics.OPERATOR_TOO_OLD)
+			},
+			expMetrics: []string{
+				`my_metrics_controller_reconcile_skipped_total{name="test",namespace="testns",reason="OPERATOR_TOO_OLD",team="payments"} 2`,
+			},
+		},
+		{
+			name: "The labels named as the labels of a metric should be ignored",
+			addMetrics: func(rec metrics.Recorder) {
+				rec.SetClusterLabels("testns", "test", map[string]string{"step": "other", "team": "payments"})
+				rec.RecordStuckReplicaRemediation("testns", "test", metrics.STUCK_REPLICA_RETRY)
+			},
+			expMetrics: []string{
+				`my_metrics_controller_stuck_replica_remediations_total{name="test",namespace="testns",step="RETRY_REPLICATION",team="payments"} 1`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			reg := prometheus.NewRegistry()
+			rec := metrics.NewRecorder("my_metrics", reg)
+
+			test.addMetrics(rec)
+
+			// The metrics with different labels must be gathered without errors.
+			h := promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			resp := w.Result()
+			if assert.Equal(http.StatusOK, resp.StatusCode) {
+				body, _ := ioutil.ReadAll(resp.Body)
+				for _, expMetric := range test.expMetrics {
+					assert.Contains(string(body), expMetric)
+				}
+				for _, missing := range test.expMissing {
+					assert.NotContains(string(body), missing)
+				}
+			}
+		})
+	}
+}
@@ -19,6 +19,43 @@ type RedisFailover struct {
 	mock.Mock
 }
 
+// CheckRedisFailoverSchema provides a mock function with given fields: ctx, namespace
+func (_m *RedisFailover) CheckRedisFailoverSchema(ctx context.Context, namespace string) error {
+	ret := _m.Called(ctx, namespace)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, namespace)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateRedisFailover provides a mock function with given fields: ctx, namespace, rFailover
+func (_m *RedisFailover) CreateRedisFailover(ctx context.Context, namespace string, rFailover *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+	ret := _m.Called(ctx, namespace, rFailover)
+
+	var r0 *redisfailoverv1.RedisFailover
+	if rf, ok := ret.Get(0).(func(context.Context, string, *redisfailoverv1.RedisFailover) *redisfailoverv1.RedisFailover); ok {
+		r0 = rf(ctx, namespace, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*redisfailoverv1.RedisFailover)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *redisfailoverv1.RedisFailover) error); ok {
+		r1 = rf(ctx, namespace, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListRedisFailovers provides a mock function with given fields: ctx, namespace, opts
 func (_m *RedisFailover) ListRedisFailovers(ctx context.Context, namespace string, opts v1.ListOptions) (*redisfailoverv1.RedisFailoverList, error) {
 	ret := _m.Called(ctx, namespace, opts)
@@ -42,6 +79,66 @@ func (_m *RedisFailover) ListRedisFailovers(ctx context.Context, namespace strin
 	return r0, r1
 }
 
+// PatchRedisFailoverAnnotations provides a mock function with given fields: ctx, namespace, name, annotations
+func (_m *RedisFailover) PatchRedisFailoverAnnotations(ctx context.Context, namespace string, name string, annotations map[string]string) error {
+	ret := _m.Called(ctx, namespace, name, annotations)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, map[string]string) error); ok {
+		r0 = rf(ctx, namespace, name, annotations)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRedisFailover provides a mock function with given fields: ctx, namespace, rFailover
+func (_m *RedisFailover) UpdateRedisFailover(ctx context.Context, namespace string, rFailover *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+	ret := _m.Called(ctx, namespace, rFailover)
+
+	var r0 *redisfailoverv1.RedisFailover
+	if rf, ok := ret.Get(0).(func(context.Context, string, *redisfailoverv1.RedisFailover) *redisfailoverv1.RedisFailover); ok {
+		r0 = rf(ctx, namespace, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*redisfailoverv1.RedisFailover)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *redisfailoverv1.RedisFailover) error); ok {
+		r1 = rf(ctx, namespace, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateRedisFailoverStatus provides a mock function with given fields: ctx, namespace, rFailover
+func (_m *RedisFailover) UpdateRedisFailoverStatus(ctx context.Context, namespace string, rFailover *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+	ret := _m.Called(ctx, namespace, rFailover)
+
+	var r0 *redisfailoverv1.RedisFailover
+	if rf, ok := ret.Get(0).(func(context.Context, string, *redisfailoverv1.RedisFailover) *redisfailoverv1.RedisFailover); ok {
+		r0 = rf(ctx, namespace, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*redisfailoverv1.RedisFailover)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *redisfailoverv1.RedisFailover) error); ok {
+		r1 = rf(ctx, namespace, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WatchRedisFailovers provides a mock function with given fields: ctx, namespace, opts
 func (_m *RedisFailover) WatchRedisFailovers(ctx context.Context, namespace string, opts v1.ListOptions) (watch.Interface, error) {
 	ret := _m.Called(ctx, namespace, opts)
@@ -3,8 +3,12 @@
 package mocks
 
 import (
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
 
+	service "redis-operator/operator/redisfailover/service"
+
 	time "time"
 
 	v1 "redis-operator/api/redisfailover/v1"
@@ -15,13 +19,13 @@ type RedisFailoverCheck struct {
 	mock.Mock
 }
 
-// CheckAllSlavesFromMaster provides a mock function with given fields: master, rFailover
-func (_m *RedisFailoverCheck) CheckAllSlavesFromMaster(master string, rFailover *v1.RedisFailover) error {
-	ret := _m.Called(master, rFailover)
+// CheckAllSlavesFromMaster provides a mock function with given fields: ctx, master, rFailover
+func (_m *RedisFailoverCheck) CheckAllSlavesFromMaster(ctx context.Context, master string, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, master, rFailover)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.RedisFailover) error); ok {
-		r0 = rf(master, rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, master, rFailover)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -29,13 +33,13 @@ func (_m *RedisFailoverCheck) CheckAllSlavesFromMaster(master string, rFailover
 	return r0
 }
 
-// CheckRedisNumber provides a mock function with given fields: rFailover
-func (_m *RedisFailoverCheck) CheckRedisNumber(rFailover *v1.RedisFailover) error {
-	ret := _m.Called(rFailover)
+// CheckExternalSlavesFromMaster provides a mock function with given fields: ctx, master, rFailover
+func (_m *RedisFailoverCheck) CheckExternalSlavesFromMaster(ctx context.Context, master v1.RedisExternalNode, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, master, rFailover)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) error); ok {
-		r0 = rf(rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, v1.RedisExternalNode, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, master, rFailover)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -43,20 +47,34 @@ func (_m *RedisFailoverCheck) CheckRedisNumber(rFailover *v1.RedisFailover) erro
 	return r0
 }
 
-// CheckRedisSlavesReady provides a mock function with given fields: slaveIP, rFailover
-func (_m *RedisFailoverCheck) CheckRedisSlavesReady(slaveIP string, rFailover *v1.RedisFailover) (bool, error) {
-	ret := _m.Called(slaveIP, rFailover)
+// CheckRedisNumber provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) CheckRedisNumber(ctx context.Context, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, rFailover)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, rFailover)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CheckRedisSlavesReady provides a mock function with given fields: ctx, slaveIP, rFailover
+func (_m *RedisFailoverCheck) CheckRedisSlavesReady(ctx context.Context, slaveIP string, rFailover *v1.RedisFailover) (bool, error) {
+	ret := _m.Called(ctx, slaveIP, rFailover)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(string, *v1.RedisFailover) bool); ok {
-		r0 = rf(slaveIP, rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.RedisFailover) bool); ok {
+		r0 = rf(ctx, slaveIP, rFailover)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, *v1.RedisFailover) error); ok {
-		r1 = rf(slaveIP, rFailover)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, slaveIP, rFailover)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -85,13 +103,13 @@ func (_m *RedisFailoverCheck) CheckSentinelMonitor(sentinel string, monitor ...s
 	return r0
 }
 
-// CheckSentinelNumber provides a mock function with given fields: rFailover
-func (_m *RedisFailoverCheck) CheckSentinelNumber(rFailover *v1.RedisFailover) error {
-	ret := _m.Called(rFailover)
+// CheckSentinelNumber provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) CheckSentinelNumber(ctx context.Context, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, rFailover)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) error); ok {
-		r0 = rf(rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, rFailover)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -113,6 +131,20 @@ func (_m *RedisFailoverCheck) CheckSentinelNumberInMemory(sentinel string, rFail
 	return r0
 }
 
+// CheckSentinelResponding provides a mock function with given fields: sentinel
+func (_m *RedisFailoverCheck) CheckSentinelResponding(sentinel string) error {
+	ret := _m.Called(sentinel)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(sentinel)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CheckSentinelSlavesNumberInMemory provides a mock function with given fields: sentinel, rFailover
 func (_m *RedisFailoverCheck) CheckSentinelSlavesNumberInMemory(sentinel string, rFailover *v1.RedisFailover) error {
 	ret := _m.Called(sentinel, rFailover)
@@ -127,20 +159,104 @@ func (_m *RedisFailoverCheck) CheckSentinelSlavesNumberInMemory(sentinel string,
 	return r0
 }
 
-// GetMasterIP provides a mock function with given fields: rFailover
-func (_m *RedisFailoverCheck) GetMasterIP(rFailover *v1.RedisFailover) (string, error) {
-	ret := _m.Called(rFailover)
+// CorroborateMasterDown provides a mock function with given fields: ctx, lastMaster, rFailover
+func (_m *RedisFailoverCheck) CorroborateMasterDown(ctx context.Context, lastMaster string, rFailover *v1.RedisFailover) (bool, string, error) {
+	ret := _m.Called(ctx, lastMaster, rFailover)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.RedisFailover) bool); ok {
+		r0 = rf(ctx, lastMaster, rFailover)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, string, *v1.RedisFailover) string); ok {
+		r1 = rf(ctx, lastMaster, rFailover)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, *v1.RedisFailover) error); ok {
+		r2 = rf(ctx, lastMaster, rFailover)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ForgetNodeTuningWarnings provides a mock function with given fields: rFailover
+func (_m *RedisFailoverCheck) ForgetNodeTuningWarnings(rFailover *v1.RedisFailover) {
+	_m.Called(rFailover)
+}
+
+// ForgetRedisLatency provides a mock function with given fields: rFailover
+func (_m *RedisFailoverCheck) ForgetRedisLatency(rFailover *v1.RedisFailover) {
+	_m.Called(rFailover)
+}
+
+// GetDataDirMismatches provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetDataDirMismatches(ctx context.Context, rFailover *v1.RedisFailover) ([]service.DataDirMismatch, error) {
+	ret := _m.Called(ctx, rFailover)
+
+	var r0 []service.DataDirMismatch
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) []service.DataDirMismatch); ok {
+		r0 = rf(ctx, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.DataDirMismatch)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExternalMasters provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetExternalMasters(ctx context.Context, rFailover *v1.RedisFailover) ([]v1.RedisExternalNode, error) {
+	ret := _m.Called(ctx, rFailover)
+
+	var r0 []v1.RedisExternalNode
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) []v1.RedisExternalNode); ok {
+		r0 = rf(ctx, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]v1.RedisExternalNode)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMasterIP provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetMasterIP(ctx context.Context, rFailover *v1.RedisFailover) (string, error) {
+	ret := _m.Called(ctx, rFailover)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) string); ok {
-		r0 = rf(rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) string); ok {
+		r0 = rf(ctx, rFailover)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*v1.RedisFailover) error); ok {
-		r1 = rf(rFailover)
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -148,20 +264,20 @@ func (_m *RedisFailoverCheck) GetMasterIP(rFailover *v1.RedisFailover) (string,
 	return r0, r1
 }
 
-// GetMinimumRedisPodTime provides a mock function with given fields: rFailover
-func (_m *RedisFailoverCheck) GetMinimumRedisPodTime(rFailover *v1.RedisFailover) (time.Duration, error) {
-	ret := _m.Called(rFailover)
+// GetMinimumRedisPodTime provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetMinimumRedisPodTime(ctx context.Context, rFailover *v1.RedisFailover) (time.Duration, error) {
+	ret := _m.Called(ctx, rFailover)
 
 	var r0 time.Duration
-	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) time.Duration); ok {
-		r0 = rf(rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) time.Duration); ok {
+		r0 = rf(ctx, rFailover)
 	} else {
 		r0 = ret.Get(0).(time.Duration)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*v1.RedisFailover) error); ok {
-		r1 = rf(rFailover)
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -169,20 +285,64 @@ func (_m *RedisFailoverCheck) GetMinimumRedisPodTime(rFailover *v1.RedisFailover
 	return r0, r1
 }
 
-// GetNumberMasters provides a mock function with given fields: rFailover
-func (_m *RedisFailoverCheck) GetNumberMasters(rFailover *v1.RedisFailover) (int, error) {
-	ret := _m.Called(rFailover)
+// GetNodeTuningWarnings provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetNodeTuningWarnings(ctx context.Context, rFailover *v1.RedisFailover) (map[string][]string, error) {
+	ret := _m.Called(ctx, rFailover)
+
+	var r0 map[string][]string
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) map[string][]string); ok {
+		r0 = rf(ctx, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string][]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNumberMasters provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetNumberMasters(ctx context.Context, rFailover *v1.RedisFailover) (int, error) {
+	ret := _m.Called(ctx, rFailover)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) int); ok {
-		r0 = rf(rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) int); ok {
+		r0 = rf(ctx, rFailover)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*v1.RedisFailover) error); ok {
-		r1 = rf(rFailover)
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRedisAddresses provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetRedisAddresses(ctx context.Context, rFailover *v1.RedisFailover) (service.RedisAddresses, error) {
+	ret := _m.Called(ctx, rFailover)
+
+	var r0 service.RedisAddresses
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) service.RedisAddresses); ok {
+		r0 = rf(ctx, rFailover)
+	} else {
+		r0 = ret.Get(0).(service.RedisAddresses)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -190,20 +350,43 @@ func (_m *RedisFailoverCheck) GetNumberMasters(rFailover *v1.RedisFailover) (int
 	return r0, r1
 }
 
-// GetRedisRevisionHash provides a mock function with given fields: podName, rFailover
-func (_m *RedisFailoverCheck) GetRedisRevisionHash(podName string, rFailover *v1.RedisFailover) (string, error) {
-	ret := _m.Called(podName, rFailover)
+// GetRedisKeyspaces provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetRedisKeyspaces(ctx context.Context, rFailover *v1.RedisFailover) ([]service.RedisKeyspace, error) {
+	ret := _m.Called(ctx, rFailover)
+
+	var r0 []service.RedisKeyspace
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) []service.RedisKeyspace); ok {
+		r0 = rf(ctx, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.RedisKeyspace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRedisRevisionHash provides a mock function with given fields: ctx, podName, rFailover
+func (_m *RedisFailoverCheck) GetRedisRevisionHash(ctx context.Context, podName string, rFailover *v1.RedisFailover) (string, error) {
+	ret := _m.Called(ctx, podName, rFailover)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(string, *v1.RedisFailover) string); ok {
-		r0 = rf(podName, rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.RedisFailover) string); ok {
+		r0 = rf(ctx, podName, rFailover)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, *v1.RedisFailover) error); ok {
-		r1 = rf(podName, rFailover)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, podName, rFailover)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -211,13 +394,13 @@ func (_m *RedisFailoverCheck) GetRedisRevisionHash(podName string, rFailover *v1
 	return r0, r1
 }
 
-// GetRedisesIPs provides a mock function with given fields: rFailover
-func (_m *RedisFailoverCheck) GetRedisesIPs(rFailover *v1.RedisFailover) ([]string, error) {
-	ret := _m.Called(rFailover)
+// GetRedisesIPs provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetRedisesIPs(ctx context.Context, rFailover *v1.RedisFailover) ([]string, error) {
+	ret := _m.Called(ctx, rFailover)
 
 	var r0 []string
-	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) []string); ok {
-		r0 = rf(rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) []string); ok {
+		r0 = rf(ctx, rFailover)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]string)
@@ -225,8 +408,8 @@ func (_m *RedisFailoverCheck) GetRedisesIPs(rFailover *v1.RedisFailover) ([]stri
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*v1.RedisFailover) error); ok {
-		r1 = rf(rFailover)
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -234,20 +417,20 @@ func (_m *RedisFailoverCheck) GetRedisesIPs(rFailover *v1.RedisFailover) ([]stri
 	return r0, r1
 }
 
-// GetRedisesMasterPod provides a mock function with given fields: rFailover
-func (_m *RedisFailoverCheck) GetRedisesMasterPod(rFailover *v1.RedisFailover) (string, error) {
-	ret := _m.Called(rFailover)
+// GetRedisesMasterPod provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetRedisesMasterPod(ctx context.Context, rFailover *v1.RedisFailover) (string, error) {
+	ret := _m.Called(ctx, rFailover)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) string); ok {
-		r0 = rf(rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) string); ok {
+		r0 = rf(ctx, rFailover)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*v1.RedisFailover) error); ok {
-		r1 = rf(rFailover)
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -255,13 +438,13 @@ func (_m *RedisFailoverCheck) GetRedisesMasterPod(rFailover *v1.RedisFailover) (
 	return r0, r1
 }
 
-// GetRedisesSlavesPods provides a mock function with given fields: rFailover
-func (_m *RedisFailoverCheck) GetRedisesSlavesPods(rFailover *v1.RedisFailover) ([]string, error) {
-	ret := _m.Called(rFailover)
+// GetRedisesSlavesPods provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetRedisesSlavesPods(ctx context.Context, rFailover *v1.RedisFailover) ([]string, error) {
+	ret := _m.Called(ctx, rFailover)
 
 	var r0 []string
-	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) []string); ok {
-		r0 = rf(rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) []string); ok {
+		r0 = rf(ctx, rFailover)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]string)
@@ -269,8 +452,8 @@ func (_m *RedisFailoverCheck) GetRedisesSlavesPods(rFailover *v1.RedisFailover)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*v1.RedisFailover) error); ok {
-		r1 = rf(rFailover)
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -278,13 +461,13 @@ func (_m *RedisFailoverCheck) GetRedisesSlavesPods(rFailover *v1.RedisFailover)
 	return r0, r1
 }
 
-// GetSentinelsIPs provides a mock function with given fields: rFailover
-func (_m *RedisFailoverCheck) GetSentinelsIPs(rFailover *v1.RedisFailover) ([]string, error) {
-	ret := _m.Called(rFailover)
+// GetSentinelsIPs provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetSentinelsIPs(ctx context.Context, rFailover *v1.RedisFailover) ([]string, error) {
+	ret := _m.Called(ctx, rFailover)
 
 	var r0 []string
-	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) []string); ok {
-		r0 = rf(rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) []string); ok {
+		r0 = rf(ctx, rFailover)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]string)
@@ -292,8 +475,8 @@ func (_m *RedisFailoverCheck) GetSentinelsIPs(rFailover *v1.RedisFailover) ([]st
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*v1.RedisFailover) error); ok {
-		r1 = rf(rFailover)
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -301,20 +484,66 @@ func (_m *RedisFailoverCheck) GetSentinelsIPs(rFailover *v1.RedisFailover) ([]st
 	return r0, r1
 }
 
-// GetStatefulSetUpdateRevision provides a mock function with given fields: rFailover
-func (_m *RedisFailoverCheck) GetStatefulSetUpdateRevision(rFailover *v1.RedisFailover) (string, error) {
-	ret := _m.Called(rFailover)
+// GetStatefulSetUpdateRevision provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) GetStatefulSetUpdateRevision(ctx context.Context, rFailover *v1.RedisFailover) (string, error) {
+	ret := _m.Called(ctx, rFailover)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) string); ok {
-		r0 = rf(rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) string); ok {
+		r0 = rf(ctx, rFailover)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*v1.RedisFailover) error); ok {
-		r1 = rf(rFailover)
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MeasureRedisLatency provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) MeasureRedisLatency(ctx context.Context, rFailover *v1.RedisFailover) ([]service.RedisLatency, error) {
+	ret := _m.Called(ctx, rFailover)
+
+	var r0 []service.RedisLatency
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) []service.RedisLatency); ok {
+		r0 = rf(ctx, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.RedisLatency)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ProbeRedisFunctional provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverCheck) ProbeRedisFunctional(ctx context.Context, rFailover *v1.RedisFailover) ([]service.RedisFunctionalHealth, error) {
+	ret := _m.Called(ctx, rFailover)
+
+	var r0 []service.RedisFunctionalHealth
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) []service.RedisFunctionalHealth); ok {
+		r0 = rf(ctx, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.RedisFunctionalHealth)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
 	} else {
 		r1 = ret.Error(1)
 	}
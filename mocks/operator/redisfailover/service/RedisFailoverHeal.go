@@ -3,8 +3,12 @@
 package mocks
 
 import (
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
 
+	service "redis-operator/operator/redisfailover/service"
+
 	v1 "redis-operator/api/redisfailover/v1"
 )
 
@@ -13,13 +17,18 @@ type RedisFailoverHeal struct {
 	mock.Mock
 }
 
-// DeletePod provides a mock function with given fields: podName, rFailover
-func (_m *RedisFailoverHeal) DeletePod(podName string, rFailover *v1.RedisFailover) error {
-	ret := _m.Called(podName, rFailover)
+// ClearSyncSlotQueue provides a mock function with given fields: rFailover
+func (_m *RedisFailoverHeal) ClearSyncSlotQueue(rFailover *v1.RedisFailover) {
+	_m.Called(rFailover)
+}
+
+// DeletePod provides a mock function with given fields: ctx, podName, rFailover
+func (_m *RedisFailoverHeal) DeletePod(ctx context.Context, podName string, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, podName, rFailover)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.RedisFailover) error); ok {
-		r0 = rf(podName, rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, podName, rFailover)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -27,13 +36,13 @@ func (_m *RedisFailoverHeal) DeletePod(podName string, rFailover *v1.RedisFailov
 	return r0
 }
 
-// MakeMaster provides a mock function with given fields: ip, rFailover
-func (_m *RedisFailoverHeal) MakeMaster(ip string, rFailover *v1.RedisFailover) error {
-	ret := _m.Called(ip, rFailover)
+// EvictPod provides a mock function with given fields: ctx, podName, rFailover
+func (_m *RedisFailoverHeal) EvictPod(ctx context.Context, podName string, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, podName, rFailover)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.RedisFailover) error); ok {
-		r0 = rf(ip, rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, podName, rFailover)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -41,13 +50,43 @@ func (_m *RedisFailoverHeal) MakeMaster(ip string, rFailover *v1.RedisFailover)
 	return r0
 }
 
-// NewSentinelMonitor provides a mock function with given fields: ip, monitor, rFailover
-func (_m *RedisFailoverHeal) NewSentinelMonitor(ip string, monitor string, rFailover *v1.RedisFailover) error {
-	ret := _m.Called(ip, monitor, rFailover)
+// GetRemediationState provides a mock function with given fields: rFailover
+func (_m *RedisFailoverHeal) GetRemediationState(rFailover *v1.RedisFailover) v1.CheckerState {
+	ret := _m.Called(rFailover)
+
+	var r0 v1.CheckerState
+	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) v1.CheckerState); ok {
+		r0 = rf(rFailover)
+	} else {
+		r0 = ret.Get(0).(v1.CheckerState)
+	}
+
+	return r0
+}
+
+// GetSyncSlotQueue provides a mock function with given fields: rFailover
+func (_m *RedisFailoverHeal) GetSyncSlotQueue(rFailover *v1.RedisFailover) []string {
+	ret := _m.Called(rFailover)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) []string); ok {
+		r0 = rf(rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// LoadRedisACL provides a mock function with given fields: ctx, rFailover, ip, users
+func (_m *RedisFailoverHeal) LoadRedisACL(ctx context.Context, rFailover *v1.RedisFailover, ip string, users []string) error {
+	ret := _m.Called(ctx, rFailover, ip, users)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, *v1.RedisFailover) error); ok {
-		r0 = rf(ip, monitor, rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover, string, []string) error); ok {
+		r0 = rf(ctx, rFailover, ip, users)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -55,13 +94,13 @@ func (_m *RedisFailoverHeal) NewSentinelMonitor(ip string, monitor string, rFail
 	return r0
 }
 
-// NewSentinelMonitorWithPort provides a mock function with given fields: ip, monitor, port, rFailover
-func (_m *RedisFailoverHeal) NewSentinelMonitorWithPort(ip string, monitor string, port string, rFailover *v1.RedisFailover) error {
-	ret := _m.Called(ip, monitor, port, rFailover)
+// MakeMaster provides a mock function with given fields: ctx, ip, rFailover
+func (_m *RedisFailoverHeal) MakeMaster(ctx context.Context, ip string, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, ip, rFailover)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, string, *v1.RedisFailover) error); ok {
-		r0 = rf(ip, monitor, port, rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, ip, rFailover)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -69,6 +108,200 @@ func (_m *RedisFailoverHeal) NewSentinelMonitorWithPort(ip string, monitor strin
 	return r0
 }
 
+// NewSentinelMonitor provides a mock function with given fields: ctx, ip, monitor, rFailover
+func (_m *RedisFailoverHeal) NewSentinelMonitor(ctx context.Context, ip string, monitor string, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, ip, monitor, rFailover)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, ip, monitor, rFailover)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewSentinelMonitorWithPort provides a mock function with given fields: ctx, ip, monitor, port, rFailover
+func (_m *RedisFailoverHeal) NewSentinelMonitorWithPort(ctx context.Context, ip string, monitor string, port string, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, ip, monitor, port, rFailover)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, ip, monitor, port, rFailover)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PlanExternalMasterOnAll provides a mock function with given fields: ctx, masterIP, masterPort, rFailover
+func (_m *RedisFailoverHeal) PlanExternalMasterOnAll(ctx context.Context, masterIP string, masterPort string, rFailover *v1.RedisFailover) ([]service.PodAction, error) {
+	ret := _m.Called(ctx, masterIP, masterPort, rFailover)
+
+	var r0 []service.PodAction
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *v1.RedisFailover) []service.PodAction); ok {
+		r0 = rf(ctx, masterIP, masterPort, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.PodAction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, masterIP, masterPort, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlanMasterOnAll provides a mock function with given fields: ctx, masterIP, rFailover
+func (_m *RedisFailoverHeal) PlanMasterOnAll(ctx context.Context, masterIP string, rFailover *v1.RedisFailover) ([]service.PodAction, error) {
+	ret := _m.Called(ctx, masterIP, rFailover)
+
+	var r0 []service.PodAction
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.RedisFailover) []service.PodAction); ok {
+		r0 = rf(ctx, masterIP, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.PodAction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, masterIP, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlanRedisCustomConfig provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverHeal) PlanRedisCustomConfig(ctx context.Context, rFailover *v1.RedisFailover) ([]service.PodAction, error) {
+	ret := _m.Called(ctx, rFailover)
+
+	var r0 []service.PodAction
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) []service.PodAction); ok {
+		r0 = rf(ctx, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.PodAction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlanRedisInPlaceRestarts provides a mock function with given fields: ctx, masterIP, rFailover
+func (_m *RedisFailoverHeal) PlanRedisInPlaceRestarts(ctx context.Context, masterIP string, rFailover *v1.RedisFailover) ([]service.PodAction, error) {
+	ret := _m.Called(ctx, masterIP, rFailover)
+
+	var r0 []service.PodAction
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.RedisFailover) []service.PodAction); ok {
+		r0 = rf(ctx, masterIP, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.PodAction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, masterIP, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlanRoleLabels provides a mock function with given fields: ctx, masterIP, rFailover
+func (_m *RedisFailoverHeal) PlanRoleLabels(ctx context.Context, masterIP string, rFailover *v1.RedisFailover) ([]service.PodAction, error) {
+	ret := _m.Called(ctx, masterIP, rFailover)
+
+	var r0 []service.PodAction
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.RedisFailover) []service.PodAction); ok {
+		r0 = rf(ctx, masterIP, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.PodAction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, masterIP, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlanStuckReplicas provides a mock function with given fields: ctx, masterIP, rFailover
+func (_m *RedisFailoverHeal) PlanStuckReplicas(ctx context.Context, masterIP string, rFailover *v1.RedisFailover) ([]service.PodAction, error) {
+	ret := _m.Called(ctx, masterIP, rFailover)
+
+	var r0 []service.PodAction
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.RedisFailover) []service.PodAction); ok {
+		r0 = rf(ctx, masterIP, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.PodAction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, masterIP, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlanUnresponsiveSentinels provides a mock function with given fields: ctx, unresponsiveIPs, rFailover
+func (_m *RedisFailoverHeal) PlanUnresponsiveSentinels(ctx context.Context, unresponsiveIPs []string, rFailover *v1.RedisFailover) ([]service.PodAction, error) {
+	ret := _m.Called(ctx, unresponsiveIPs, rFailover)
+
+	var r0 []service.PodAction
+	if rf, ok := ret.Get(0).(func(context.Context, []string, *v1.RedisFailover) []service.PodAction); ok {
+		r0 = rf(ctx, unresponsiveIPs, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.PodAction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string, *v1.RedisFailover) error); ok {
+		r1 = rf(ctx, unresponsiveIPs, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreRemediationState provides a mock function with given fields: rFailover, state
+func (_m *RedisFailoverHeal) RestoreRemediationState(rFailover *v1.RedisFailover, state v1.CheckerState) {
+	_m.Called(rFailover, state)
+}
+
 // RestoreSentinel provides a mock function with given fields: ip
 func (_m *RedisFailoverHeal) RestoreSentinel(ip string) error {
 	ret := _m.Called(ip)
@@ -83,13 +316,13 @@ func (_m *RedisFailoverHeal) RestoreSentinel(ip string) error {
 	return r0
 }
 
-// SetExternalMasterOnAll provides a mock function with given fields: masterIP, masterPort, rFailover
-func (_m *RedisFailoverHeal) SetExternalMasterOnAll(masterIP string, masterPort string, rFailover *v1.RedisFailover) error {
-	ret := _m.Called(masterIP, masterPort, rFailover)
+// RotatePassword provides a mock function with given fields: ctx, rFailover, current, next
+func (_m *RedisFailoverHeal) RotatePassword(ctx context.Context, rFailover *v1.RedisFailover, current string, next string) error {
+	ret := _m.Called(ctx, rFailover, current, next)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, *v1.RedisFailover) error); ok {
-		r0 = rf(masterIP, masterPort, rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover, string, string) error); ok {
+		r0 = rf(ctx, rFailover, current, next)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -97,13 +330,13 @@ func (_m *RedisFailoverHeal) SetExternalMasterOnAll(masterIP string, masterPort
 	return r0
 }
 
-// SetMasterOnAll provides a mock function with given fields: masterIP, rFailover
-func (_m *RedisFailoverHeal) SetMasterOnAll(masterIP string, rFailover *v1.RedisFailover) error {
-	ret := _m.Called(masterIP, rFailover)
+// SetExternalNodesMaster provides a mock function with given fields: ctx, master, rFailover
+func (_m *RedisFailoverHeal) SetExternalNodesMaster(ctx context.Context, master v1.RedisExternalNode, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, master, rFailover)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.RedisFailover) error); ok {
-		r0 = rf(masterIP, rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, v1.RedisExternalNode, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, master, rFailover)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -111,13 +344,13 @@ func (_m *RedisFailoverHeal) SetMasterOnAll(masterIP string, rFailover *v1.Redis
 	return r0
 }
 
-// SetOldestAsMaster provides a mock function with given fields: rFailover
-func (_m *RedisFailoverHeal) SetOldestAsMaster(rFailover *v1.RedisFailover) error {
-	ret := _m.Called(rFailover)
+// SetExternalRedisCustomConfig provides a mock function with given fields: ctx, node, rFailover
+func (_m *RedisFailoverHeal) SetExternalRedisCustomConfig(ctx context.Context, node v1.RedisExternalNode, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, node, rFailover)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*v1.RedisFailover) error); ok {
-		r0 = rf(rFailover)
+	if rf, ok := ret.Get(0).(func(context.Context, v1.RedisExternalNode, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, node, rFailover)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -125,8 +358,22 @@ func (_m *RedisFailoverHeal) SetOldestAsMaster(rFailover *v1.RedisFailover) erro
 	return r0
 }
 
-// SetRedisCustomConfig provides a mock function with given fields: ip, rFailover
-func (_m *RedisFailoverHeal) SetRedisCustomConfig(ip string, rFailover *v1.RedisFailover) error {
+// SetOldestAsMaster provides a mock function with given fields: ctx, rFailover
+func (_m *RedisFailoverHeal) SetOldestAsMaster(ctx context.Context, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, rFailover)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, rFailover)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetSentinelCustomConfig provides a mock function with given fields: ip, rFailover
+func (_m *RedisFailoverHeal) SetSentinelCustomConfig(ip string, rFailover *v1.RedisFailover) error {
 	ret := _m.Called(ip, rFailover)
 
 	var r0 error
@@ -139,8 +386,8 @@ func (_m *RedisFailoverHeal) SetRedisCustomConfig(ip string, rFailover *v1.Redis
 	return r0
 }
 
-// SetSentinelCustomConfig provides a mock function with given fields: ip, rFailover
-func (_m *RedisFailoverHeal) SetSentinelCustomConfig(ip string, rFailover *v1.RedisFailover) error {
+// SetSentinelGlobalConfig provides a mock function with given fields: ip, rFailover
+func (_m *RedisFailoverHeal) SetSentinelGlobalConfig(ip string, rFailover *v1.RedisFailover) error {
 	ret := _m.Called(ip, rFailover)
 
 	var r0 error
@@ -152,3 +399,17 @@ func (_m *RedisFailoverHeal) SetSentinelCustomConfig(ip string, rFailover *v1.Re
 
 	return r0
 }
+
+// SnapshotReplica provides a mock function with given fields: ctx, podName, ip, snapshotName, labels, rFailover
+func (_m *RedisFailoverHeal) SnapshotReplica(ctx context.Context, podName string, ip string, snapshotName string, labels map[string]string, rFailover *v1.RedisFailover) error {
+	ret := _m.Called(ctx, podName, ip, snapshotName, labels, rFailover)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, map[string]string, *v1.RedisFailover) error); ok {
+		r0 = rf(ctx, podName, ip, snapshotName, labels, rFailover)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
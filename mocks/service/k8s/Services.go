@@ -3,20 +3,40 @@
 package mocks
 
 import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
 	context "context"
 
+	labels "k8s.io/apimachinery/pkg/labels"
+
 	appsv1 "k8s.io/api/apps/v1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	mock "github.com/stretchr/testify/mock"
 
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+
+	networkingv1 "k8s.io/api/networking/v1"
+
 	policyv1 "k8s.io/api/policy/v1"
 
+	resource "k8s.io/apimachinery/pkg/api/resource"
+
 	rbacv1 "k8s.io/api/rbac/v1"
 
+	schedulingv1 "k8s.io/api/scheduling/v1"
+
+	time "time"
+
+	storagev1 "k8s.io/api/storage/v1"
+
 	redisfailoverv1 "redis-operator/api/redisfailover/v1"
 
+	types "k8s.io/apimachinery/pkg/types"
+
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
 	v1 "k8s.io/api/core/v1"
 
 	watch "k8s.io/apimachinery/pkg/watch"
@@ -27,13 +47,13 @@ type Services struct {
 	mock.Mock
 }
 
-// CreateConfigMap provides a mock function with given fields: namespace, configMap
-func (_m *Services) CreateConfigMap(namespace string, configMap *v1.ConfigMap) error {
-	ret := _m.Called(namespace, configMap)
+// ApplyDeployment provides a mock function with given fields: ctx, namespace, deployment
+func (_m *Services) ApplyDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error {
+	ret := _m.Called(ctx, namespace, deployment)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.ConfigMap) error); ok {
-		r0 = rf(namespace, configMap)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *appsv1.Deployment) error); ok {
+		r0 = rf(ctx, namespace, deployment)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -41,13 +61,13 @@ func (_m *Services) CreateConfigMap(namespace string, configMap *v1.ConfigMap) e
 	return r0
 }
 
-// CreateDeployment provides a mock function with given fields: namespace, deployment
-func (_m *Services) CreateDeployment(namespace string, deployment *appsv1.Deployment) error {
-	ret := _m.Called(namespace, deployment)
+// ApplyService provides a mock function with given fields: ctx, namespace, service
+func (_m *Services) ApplyService(ctx context.Context, namespace string, service *v1.Service) error {
+	ret := _m.Called(ctx, namespace, service)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *appsv1.Deployment) error); ok {
-		r0 = rf(namespace, deployment)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Service) error); ok {
+		r0 = rf(ctx, namespace, service)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -55,13 +75,13 @@ func (_m *Services) CreateDeployment(namespace string, deployment *appsv1.Deploy
 	return r0
 }
 
-// CreateIfNotExistsService provides a mock function with given fields: namespace, service
-func (_m *Services) CreateIfNotExistsService(namespace string, service *v1.Service) error {
-	ret := _m.Called(namespace, service)
+// ApplyStatefulSet provides a mock function with given fields: ctx, namespace, statefulSet
+func (_m *Services) ApplyStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error {
+	ret := _m.Called(ctx, namespace, statefulSet)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.Service) error); ok {
-		r0 = rf(namespace, service)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *appsv1.StatefulSet) error); ok {
+		r0 = rf(ctx, namespace, statefulSet)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -69,13 +89,13 @@ func (_m *Services) CreateIfNotExistsService(namespace string, service *v1.Servi
 	return r0
 }
 
-// CreateOrUpdateConfigMap provides a mock function with given fields: namespace, np
-func (_m *Services) CreateOrUpdateConfigMap(namespace string, np *v1.ConfigMap) error {
-	ret := _m.Called(namespace, np)
+// CheckRedisFailoverSchema provides a mock function with given fields: ctx, namespace
+func (_m *Services) CheckRedisFailoverSchema(ctx context.Context, namespace string) error {
+	ret := _m.Called(ctx, namespace)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.ConfigMap) error); ok {
-		r0 = rf(namespace, np)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, namespace)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -83,13 +103,13 @@ func (_m *Services) CreateOrUpdateConfigMap(namespace string, np *v1.ConfigMap)
 	return r0
 }
 
-// CreateOrUpdateDeployment provides a mock function with given fields: namespace, deployment
-func (_m *Services) CreateOrUpdateDeployment(namespace string, deployment *appsv1.Deployment) error {
-	ret := _m.Called(namespace, deployment)
+// CreateConfigMap provides a mock function with given fields: ctx, namespace, configMap
+func (_m *Services) CreateConfigMap(ctx context.Context, namespace string, configMap *v1.ConfigMap) error {
+	ret := _m.Called(ctx, namespace, configMap)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *appsv1.Deployment) error); ok {
-		r0 = rf(namespace, deployment)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.ConfigMap) error); ok {
+		r0 = rf(ctx, namespace, configMap)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -97,13 +117,13 @@ func (_m *Services) CreateOrUpdateDeployment(namespace string, deployment *appsv
 	return r0
 }
 
-// CreateOrUpdatePod provides a mock function with given fields: namespace, pod
-func (_m *Services) CreateOrUpdatePod(namespace string, pod *v1.Pod) error {
-	ret := _m.Called(namespace, pod)
+// CreateDeployment provides a mock function with given fields: ctx, namespace, deployment
+func (_m *Services) CreateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error {
+	ret := _m.Called(ctx, namespace, deployment)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.Pod) error); ok {
-		r0 = rf(namespace, pod)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *appsv1.Deployment) error); ok {
+		r0 = rf(ctx, namespace, deployment)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -111,13 +131,13 @@ func (_m *Services) CreateOrUpdatePod(namespace string, pod *v1.Pod) error {
 	return r0
 }
 
-// CreateOrUpdatePodDisruptionBudget provides a mock function with given fields: namespace, podDisruptionBudget
-func (_m *Services) CreateOrUpdatePodDisruptionBudget(namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
-	ret := _m.Called(namespace, podDisruptionBudget)
+// CreateEvent provides a mock function with given fields: ctx, namespace, event
+func (_m *Services) CreateEvent(ctx context.Context, namespace string, event *v1.Event) error {
+	ret := _m.Called(ctx, namespace, event)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *policyv1.PodDisruptionBudget) error); ok {
-		r0 = rf(namespace, podDisruptionBudget)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Event) error); ok {
+		r0 = rf(ctx, namespace, event)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -125,13 +145,13 @@ func (_m *Services) CreateOrUpdatePodDisruptionBudget(namespace string, podDisru
 	return r0
 }
 
-// CreateOrUpdateRole provides a mock function with given fields: namespace, binding
-func (_m *Services) CreateOrUpdateRole(namespace string, binding *rbacv1.Role) error {
-	ret := _m.Called(namespace, binding)
+// CreateHPA provides a mock function with given fields: ctx, namespace, hpa
+func (_m *Services) CreateHPA(ctx context.Context, namespace string, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	ret := _m.Called(ctx, namespace, hpa)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *rbacv1.Role) error); ok {
-		r0 = rf(namespace, binding)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *autoscalingv2.HorizontalPodAutoscaler) error); ok {
+		r0 = rf(ctx, namespace, hpa)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -139,13 +159,13 @@ func (_m *Services) CreateOrUpdateRole(namespace string, binding *rbacv1.Role) e
 	return r0
 }
 
-// CreateOrUpdateRoleBinding provides a mock function with given fields: namespace, binding
-func (_m *Services) CreateOrUpdateRoleBinding(namespace string, binding *rbacv1.RoleBinding) error {
-	ret := _m.Called(namespace, binding)
+// CreateIfNotExistsSecret provides a mock function with given fields: ctx, namespace, secret
+func (_m *Services) CreateIfNotExistsSecret(ctx context.Context, namespace string, secret *v1.Secret) error {
+	ret := _m.Called(ctx, namespace, secret)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *rbacv1.RoleBinding) error); ok {
-		r0 = rf(namespace, binding)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Secret) error); ok {
+		r0 = rf(ctx, namespace, secret)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -153,13 +173,13 @@ func (_m *Services) CreateOrUpdateRoleBinding(namespace string, binding *rbacv1.
 	return r0
 }
 
-// CreateOrUpdateService provides a mock function with given fields: namespace, service
-func (_m *Services) CreateOrUpdateService(namespace string, service *v1.Service) error {
-	ret := _m.Called(namespace, service)
+// CreateIfNotExistsService provides a mock function with given fields: ctx, namespace, service
+func (_m *Services) CreateIfNotExistsService(ctx context.Context, namespace string, service *v1.Service) error {
+	ret := _m.Called(ctx, namespace, service)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.Service) error); ok {
-		r0 = rf(namespace, service)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Service) error); ok {
+		r0 = rf(ctx, namespace, service)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -167,13 +187,13 @@ func (_m *Services) CreateOrUpdateService(namespace string, service *v1.Service)
 	return r0
 }
 
-// CreateOrUpdateStatefulSet provides a mock function with given fields: namespace, statefulSet
-func (_m *Services) CreateOrUpdateStatefulSet(namespace string, statefulSet *appsv1.StatefulSet) error {
-	ret := _m.Called(namespace, statefulSet)
+// CreateNetworkPolicy provides a mock function with given fields: ctx, namespace, networkPolicy
+func (_m *Services) CreateNetworkPolicy(ctx context.Context, namespace string, networkPolicy *networkingv1.NetworkPolicy) error {
+	ret := _m.Called(ctx, namespace, networkPolicy)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *appsv1.StatefulSet) error); ok {
-		r0 = rf(namespace, statefulSet)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *networkingv1.NetworkPolicy) error); ok {
+		r0 = rf(ctx, namespace, networkPolicy)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -181,13 +201,13 @@ func (_m *Services) CreateOrUpdateStatefulSet(namespace string, statefulSet *app
 	return r0
 }
 
-// CreatePod provides a mock function with given fields: namespace, pod
-func (_m *Services) CreatePod(namespace string, pod *v1.Pod) error {
-	ret := _m.Called(namespace, pod)
+// CreateOrPatchStatefulSet provides a mock function with given fields: ctx, namespace, statefulSet
+func (_m *Services) CreateOrPatchStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error {
+	ret := _m.Called(ctx, namespace, statefulSet)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.Pod) error); ok {
-		r0 = rf(namespace, pod)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *appsv1.StatefulSet) error); ok {
+		r0 = rf(ctx, namespace, statefulSet)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -195,13 +215,13 @@ func (_m *Services) CreatePod(namespace string, pod *v1.Pod) error {
 	return r0
 }
 
-// CreatePodDisruptionBudget provides a mock function with given fields: namespace, podDisruptionBudget
-func (_m *Services) CreatePodDisruptionBudget(namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
-	ret := _m.Called(namespace, podDisruptionBudget)
+// CreateOrUpdateCertificate provides a mock function with given fields: ctx, namespace, certificate
+func (_m *Services) CreateOrUpdateCertificate(ctx context.Context, namespace string, certificate *unstructured.Unstructured) error {
+	ret := _m.Called(ctx, namespace, certificate)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *policyv1.PodDisruptionBudget) error); ok {
-		r0 = rf(namespace, podDisruptionBudget)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *unstructured.Unstructured) error); ok {
+		r0 = rf(ctx, namespace, certificate)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -209,13 +229,13 @@ func (_m *Services) CreatePodDisruptionBudget(namespace string, podDisruptionBud
 	return r0
 }
 
-// CreateRole provides a mock function with given fields: namespace, role
-func (_m *Services) CreateRole(namespace string, role *rbacv1.Role) error {
-	ret := _m.Called(namespace, role)
+// CreateOrUpdateConfigMap provides a mock function with given fields: ctx, namespace, np
+func (_m *Services) CreateOrUpdateConfigMap(ctx context.Context, namespace string, np *v1.ConfigMap) error {
+	ret := _m.Called(ctx, namespace, np)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *rbacv1.Role) error); ok {
-		r0 = rf(namespace, role)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.ConfigMap) error); ok {
+		r0 = rf(ctx, namespace, np)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -223,13 +243,13 @@ func (_m *Services) CreateRole(namespace string, role *rbacv1.Role) error {
 	return r0
 }
 
-// CreateRoleBinding provides a mock function with given fields: namespace, binding
-func (_m *Services) CreateRoleBinding(namespace string, binding *rbacv1.RoleBinding) error {
-	ret := _m.Called(namespace, binding)
+// CreateOrUpdateDeployment provides a mock function with given fields: ctx, namespace, deployment
+func (_m *Services) CreateOrUpdateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error {
+	ret := _m.Called(ctx, namespace, deployment)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *rbacv1.RoleBinding) error); ok {
-		r0 = rf(namespace, binding)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *appsv1.Deployment) error); ok {
+		r0 = rf(ctx, namespace, deployment)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -237,13 +257,13 @@ func (_m *Services) CreateRoleBinding(namespace string, binding *rbacv1.RoleBind
 	return r0
 }
 
-// CreateService provides a mock function with given fields: namespace, service
-func (_m *Services) CreateService(namespace string, service *v1.Service) error {
-	ret := _m.Called(namespace, service)
+// CreateOrUpdateNetworkPolicy provides a mock function with given fields: ctx, namespace, networkPolicy
+func (_m *Services) CreateOrUpdateNetworkPolicy(ctx context.Context, namespace string, networkPolicy *networkingv1.NetworkPolicy) error {
+	ret := _m.Called(ctx, namespace, networkPolicy)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.Service) error); ok {
-		r0 = rf(namespace, service)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *networkingv1.NetworkPolicy) error); ok {
+		r0 = rf(ctx, namespace, networkPolicy)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -251,13 +271,13 @@ func (_m *Services) CreateService(namespace string, service *v1.Service) error {
 	return r0
 }
 
-// CreateStatefulSet provides a mock function with given fields: namespace, statefulSet
-func (_m *Services) CreateStatefulSet(namespace string, statefulSet *appsv1.StatefulSet) error {
-	ret := _m.Called(namespace, statefulSet)
+// CreateOrUpdateHPA provides a mock function with given fields: ctx, namespace, hpa
+func (_m *Services) CreateOrUpdateHPA(ctx context.Context, namespace string, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	ret := _m.Called(ctx, namespace, hpa)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *appsv1.StatefulSet) error); ok {
-		r0 = rf(namespace, statefulSet)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *autoscalingv2.HorizontalPodAutoscaler) error); ok {
+		r0 = rf(ctx, namespace, hpa)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -265,13 +285,13 @@ func (_m *Services) CreateStatefulSet(namespace string, statefulSet *appsv1.Stat
 	return r0
 }
 
-// DeleteConfigMap provides a mock function with given fields: namespace, name
-func (_m *Services) DeleteConfigMap(namespace string, name string) error {
-	ret := _m.Called(namespace, name)
+// CreateOrUpdatePod provides a mock function with given fields: ctx, namespace, pod
+func (_m *Services) CreateOrUpdatePod(ctx context.Context, namespace string, pod *v1.Pod) error {
+	ret := _m.Called(ctx, namespace, pod)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(namespace, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Pod) error); ok {
+		r0 = rf(ctx, namespace, pod)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -279,13 +299,13 @@ func (_m *Services) DeleteConfigMap(namespace string, name string) error {
 	return r0
 }
 
-// DeleteDeployment provides a mock function with given fields: namespace, name
-func (_m *Services) DeleteDeployment(namespace string, name string) error {
-	ret := _m.Called(namespace, name)
+// CreateOrUpdatePodDisruptionBudget provides a mock function with given fields: ctx, namespace, podDisruptionBudget
+func (_m *Services) CreateOrUpdatePodDisruptionBudget(ctx context.Context, namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
+	ret := _m.Called(ctx, namespace, podDisruptionBudget)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(namespace, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *policyv1.PodDisruptionBudget) error); ok {
+		r0 = rf(ctx, namespace, podDisruptionBudget)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -293,13 +313,13 @@ func (_m *Services) DeleteDeployment(namespace string, name string) error {
 	return r0
 }
 
-// DeletePod provides a mock function with given fields: namespace, name
-func (_m *Services) DeletePod(namespace string, name string) error {
-	ret := _m.Called(namespace, name)
+// CreateOrUpdateRole provides a mock function with given fields: ctx, namespace, binding
+func (_m *Services) CreateOrUpdateRole(ctx context.Context, namespace string, binding *rbacv1.Role) error {
+	ret := _m.Called(ctx, namespace, binding)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(namespace, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *rbacv1.Role) error); ok {
+		r0 = rf(ctx, namespace, binding)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -307,13 +327,13 @@ func (_m *Services) DeletePod(namespace string, name string) error {
 	return r0
 }
 
-// DeletePodDisruptionBudget provides a mock function with given fields: namespace, name
-func (_m *Services) DeletePodDisruptionBudget(namespace string, name string) error {
-	ret := _m.Called(namespace, name)
+// CreateOrUpdateRoleBinding provides a mock function with given fields: ctx, namespace, binding
+func (_m *Services) CreateOrUpdateRoleBinding(ctx context.Context, namespace string, binding *rbacv1.RoleBinding) error {
+	ret := _m.Called(ctx, namespace, binding)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(namespace, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *rbacv1.RoleBinding) error); ok {
+		r0 = rf(ctx, namespace, binding)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -321,13 +341,13 @@ func (_m *Services) DeletePodDisruptionBudget(namespace string, name string) err
 	return r0
 }
 
-// DeleteService provides a mock function with given fields: namespace, name
-func (_m *Services) DeleteService(namespace string, name string) error {
-	ret := _m.Called(namespace, name)
+// CreateOrUpdateService provides a mock function with given fields: ctx, namespace, service
+func (_m *Services) CreateOrUpdateService(ctx context.Context, namespace string, service *v1.Service) error {
+	ret := _m.Called(ctx, namespace, service)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(namespace, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Service) error); ok {
+		r0 = rf(ctx, namespace, service)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -335,13 +355,13 @@ func (_m *Services) DeleteService(namespace string, name string) error {
 	return r0
 }
 
-// DeleteStatefulSet provides a mock function with given fields: namespace, name
-func (_m *Services) DeleteStatefulSet(namespace string, name string) error {
-	ret := _m.Called(namespace, name)
+// CreateOrUpdateServiceAccount provides a mock function with given fields: ctx, namespace, serviceAccount
+func (_m *Services) CreateOrUpdateServiceAccount(ctx context.Context, namespace string, serviceAccount *v1.ServiceAccount) error {
+	ret := _m.Called(ctx, namespace, serviceAccount)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(namespace, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.ServiceAccount) error); ok {
+		r0 = rf(ctx, namespace, serviceAccount)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -349,114 +369,78 @@ func (_m *Services) DeleteStatefulSet(namespace string, name string) error {
 	return r0
 }
 
-// GetClusterRole provides a mock function with given fields: name
-func (_m *Services) GetClusterRole(name string) (*rbacv1.ClusterRole, error) {
-	ret := _m.Called(name)
-
-	var r0 *rbacv1.ClusterRole
-	if rf, ok := ret.Get(0).(func(string) *rbacv1.ClusterRole); ok {
-		r0 = rf(name)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*rbacv1.ClusterRole)
-		}
-	}
+// CreateOrUpdateServiceMonitor provides a mock function with given fields: ctx, namespace, serviceMonitor
+func (_m *Services) CreateOrUpdateServiceMonitor(ctx context.Context, namespace string, serviceMonitor *unstructured.Unstructured) error {
+	ret := _m.Called(ctx, namespace, serviceMonitor)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *unstructured.Unstructured) error); ok {
+		r0 = rf(ctx, namespace, serviceMonitor)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetConfigMap provides a mock function with given fields: namespace, name
-func (_m *Services) GetConfigMap(namespace string, name string) (*v1.ConfigMap, error) {
-	ret := _m.Called(namespace, name)
-
-	var r0 *v1.ConfigMap
-	if rf, ok := ret.Get(0).(func(string, string) *v1.ConfigMap); ok {
-		r0 = rf(namespace, name)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*v1.ConfigMap)
-		}
-	}
+// CreateOrUpdateStatefulSet provides a mock function with given fields: ctx, namespace, statefulSet
+func (_m *Services) CreateOrUpdateStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error {
+	ret := _m.Called(ctx, namespace, statefulSet)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(namespace, name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *appsv1.StatefulSet) error); ok {
+		r0 = rf(ctx, namespace, statefulSet)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetDeployment provides a mock function with given fields: namespace, name
-func (_m *Services) GetDeployment(namespace string, name string) (*appsv1.Deployment, error) {
-	ret := _m.Called(namespace, name)
-
-	var r0 *appsv1.Deployment
-	if rf, ok := ret.Get(0).(func(string, string) *appsv1.Deployment); ok {
-		r0 = rf(namespace, name)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*appsv1.Deployment)
-		}
-	}
+// CreatePod provides a mock function with given fields: ctx, namespace, pod
+func (_m *Services) CreatePod(ctx context.Context, namespace string, pod *v1.Pod) error {
+	ret := _m.Called(ctx, namespace, pod)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(namespace, name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Pod) error); ok {
+		r0 = rf(ctx, namespace, pod)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetDeploymentPods provides a mock function with given fields: namespace, name
-func (_m *Services) GetDeploymentPods(namespace string, name string) (*v1.PodList, error) {
-	ret := _m.Called(namespace, name)
-
-	var r0 *v1.PodList
-	if rf, ok := ret.Get(0).(func(string, string) *v1.PodList); ok {
-		r0 = rf(namespace, name)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*v1.PodList)
-		}
-	}
+// CreatePodDisruptionBudget provides a mock function with given fields: ctx, namespace, podDisruptionBudget
+func (_m *Services) CreatePodDisruptionBudget(ctx context.Context, namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
+	ret := _m.Called(ctx, namespace, podDisruptionBudget)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(namespace, name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *policyv1.PodDisruptionBudget) error); ok {
+		r0 = rf(ctx, namespace, podDisruptionBudget)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetPod provides a mock function with given fields: namespace, name
-func (_m *Services) GetPod(namespace string, name string) (*v1.Pod, error) {
-	ret := _m.Called(namespace, name)
+// CreateRedisFailover provides a mock function with given fields: ctx, namespace, rFailover
+func (_m *Services) CreateRedisFailover(ctx context.Context, namespace string, rFailover *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+	ret := _m.Called(ctx, namespace, rFailover)
 
-	var r0 *v1.Pod
-	if rf, ok := ret.Get(0).(func(string, string) *v1.Pod); ok {
-		r0 = rf(namespace, name)
+	var r0 *redisfailoverv1.RedisFailover
+	if rf, ok := ret.Get(0).(func(context.Context, string, *redisfailoverv1.RedisFailover) *redisfailoverv1.RedisFailover); ok {
+		r0 = rf(ctx, namespace, rFailover)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*v1.Pod)
+			r0 = ret.Get(0).(*redisfailoverv1.RedisFailover)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(namespace, name)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *redisfailoverv1.RedisFailover) error); ok {
+		r1 = rf(ctx, namespace, rFailover)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -464,312 +448,237 @@ func (_m *Services) GetPod(namespace string, name string) (*v1.Pod, error) {
 	return r0, r1
 }
 
-// GetPodDisruptionBudget provides a mock function with given fields: namespace, name
-func (_m *Services) GetPodDisruptionBudget(namespace string, name string) (*policyv1.PodDisruptionBudget, error) {
-	ret := _m.Called(namespace, name)
+// CreateRole provides a mock function with given fields: ctx, namespace, role
+func (_m *Services) CreateRole(ctx context.Context, namespace string, role *rbacv1.Role) error {
+	ret := _m.Called(ctx, namespace, role)
 
-	var r0 *policyv1.PodDisruptionBudget
-	if rf, ok := ret.Get(0).(func(string, string) *policyv1.PodDisruptionBudget); ok {
-		r0 = rf(namespace, name)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*policyv1.PodDisruptionBudget)
-		}
-	}
-
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(namespace, name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *rbacv1.Role) error); ok {
+		r0 = rf(ctx, namespace, role)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetRole provides a mock function with given fields: namespace, name
-func (_m *Services) GetRole(namespace string, name string) (*rbacv1.Role, error) {
-	ret := _m.Called(namespace, name)
-
-	var r0 *rbacv1.Role
-	if rf, ok := ret.Get(0).(func(string, string) *rbacv1.Role); ok {
-		r0 = rf(namespace, name)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*rbacv1.Role)
-		}
-	}
+// CreateRoleBinding provides a mock function with given fields: ctx, namespace, binding
+func (_m *Services) CreateRoleBinding(ctx context.Context, namespace string, binding *rbacv1.RoleBinding) error {
+	ret := _m.Called(ctx, namespace, binding)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(namespace, name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *rbacv1.RoleBinding) error); ok {
+		r0 = rf(ctx, namespace, binding)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetRoleBinding provides a mock function with given fields: namespace, name
-func (_m *Services) GetRoleBinding(namespace string, name string) (*rbacv1.RoleBinding, error) {
-	ret := _m.Called(namespace, name)
-
-	var r0 *rbacv1.RoleBinding
-	if rf, ok := ret.Get(0).(func(string, string) *rbacv1.RoleBinding); ok {
-		r0 = rf(namespace, name)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*rbacv1.RoleBinding)
-		}
-	}
+// CreateSecret provides a mock function with given fields: ctx, namespace, secret
+func (_m *Services) CreateSecret(ctx context.Context, namespace string, secret *v1.Secret) error {
+	ret := _m.Called(ctx, namespace, secret)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(namespace, name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Secret) error); ok {
+		r0 = rf(ctx, namespace, secret)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetSecret provides a mock function with given fields: namespace, name
-func (_m *Services) GetSecret(namespace string, name string) (*v1.Secret, error) {
-	ret := _m.Called(namespace, name)
-
-	var r0 *v1.Secret
-	if rf, ok := ret.Get(0).(func(string, string) *v1.Secret); ok {
-		r0 = rf(namespace, name)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*v1.Secret)
-		}
-	}
+// CreateService provides a mock function with given fields: ctx, namespace, service
+func (_m *Services) CreateService(ctx context.Context, namespace string, service *v1.Service) error {
+	ret := _m.Called(ctx, namespace, service)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(namespace, name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Service) error); ok {
+		r0 = rf(ctx, namespace, service)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetService provides a mock function with given fields: namespace, name
-func (_m *Services) GetService(namespace string, name string) (*v1.Service, error) {
-	ret := _m.Called(namespace, name)
-
-	var r0 *v1.Service
-	if rf, ok := ret.Get(0).(func(string, string) *v1.Service); ok {
-		r0 = rf(namespace, name)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*v1.Service)
-		}
-	}
+// CreateStatefulSet provides a mock function with given fields: ctx, namespace, statefulSet
+func (_m *Services) CreateStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error {
+	ret := _m.Called(ctx, namespace, statefulSet)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(namespace, name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *appsv1.StatefulSet) error); ok {
+		r0 = rf(ctx, namespace, statefulSet)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetStatefulSet provides a mock function with given fields: namespace, name
-func (_m *Services) GetStatefulSet(namespace string, name string) (*appsv1.StatefulSet, error) {
-	ret := _m.Called(namespace, name)
-
-	var r0 *appsv1.StatefulSet
-	if rf, ok := ret.Get(0).(func(string, string) *appsv1.StatefulSet); ok {
-		r0 = rf(namespace, name)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*appsv1.StatefulSet)
-		}
-	}
+// CreateVolumeSnapshot provides a mock function with given fields: ctx, namespace, snapshot
+func (_m *Services) CreateVolumeSnapshot(ctx context.Context, namespace string, snapshot *unstructured.Unstructured) error {
+	ret := _m.Called(ctx, namespace, snapshot)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(namespace, name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *unstructured.Unstructured) error); ok {
+		r0 = rf(ctx, namespace, snapshot)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetStatefulSetPods provides a mock function with given fields: namespace, name
-func (_m *Services) GetStatefulSetPods(namespace string, name string) (*v1.PodList, error) {
-	ret := _m.Called(namespace, name)
-
-	var r0 *v1.PodList
-	if rf, ok := ret.Get(0).(func(string, string) *v1.PodList); ok {
-		r0 = rf(namespace, name)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*v1.PodList)
-		}
-	}
+// DeleteCertificate provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteCertificate(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(namespace, name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// ListConfigMaps provides a mock function with given fields: namespace
-func (_m *Services) ListConfigMaps(namespace string) (*v1.ConfigMapList, error) {
-	ret := _m.Called(namespace)
-
-	var r0 *v1.ConfigMapList
-	if rf, ok := ret.Get(0).(func(string) *v1.ConfigMapList); ok {
-		r0 = rf(namespace)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*v1.ConfigMapList)
-		}
-	}
+// DeleteConfigMap provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteConfigMap(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(namespace)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// ListDeployments provides a mock function with given fields: namespace
-func (_m *Services) ListDeployments(namespace string) (*appsv1.DeploymentList, error) {
-	ret := _m.Called(namespace)
+// DeleteConfigMapRevisions provides a mock function with given fields: ctx, namespace, selector, revisionLabel, current, keep
+func (_m *Services) DeleteConfigMapRevisions(ctx context.Context, namespace string, selector map[string]string, revisionLabel string, current string, keep int) error {
+	ret := _m.Called(ctx, namespace, selector, revisionLabel, current, keep)
 
-	var r0 *appsv1.DeploymentList
-	if rf, ok := ret.Get(0).(func(string) *appsv1.DeploymentList); ok {
-		r0 = rf(namespace)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*appsv1.DeploymentList)
-		}
-	}
-
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(namespace)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string, string, string, int) error); ok {
+		r0 = rf(ctx, namespace, selector, revisionLabel, current, keep)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// ListPods provides a mock function with given fields: namespace
-func (_m *Services) ListPods(namespace string) (*v1.PodList, error) {
-	ret := _m.Called(namespace)
-
-	var r0 *v1.PodList
-	if rf, ok := ret.Get(0).(func(string) *v1.PodList); ok {
-		r0 = rf(namespace)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*v1.PodList)
-		}
-	}
+// DeleteDeployment provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteDeployment(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(namespace)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// ListRedisFailovers provides a mock function with given fields: ctx, namespace, opts
-func (_m *Services) ListRedisFailovers(ctx context.Context, namespace string, opts metav1.ListOptions) (*redisfailoverv1.RedisFailoverList, error) {
-	ret := _m.Called(ctx, namespace, opts)
+// DeleteHPA provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteHPA(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
-	var r0 *redisfailoverv1.RedisFailoverList
-	if rf, ok := ret.Get(0).(func(context.Context, string, metav1.ListOptions) *redisfailoverv1.RedisFailoverList); ok {
-		r0 = rf(ctx, namespace, opts)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*redisfailoverv1.RedisFailoverList)
-		}
+		r0 = ret.Error(0)
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, metav1.ListOptions) error); ok {
-		r1 = rf(ctx, namespace, opts)
+	return r0
+}
+
+// DeleteNetworkPolicy provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteNetworkPolicy(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// ListServices provides a mock function with given fields: namespace
-func (_m *Services) ListServices(namespace string) (*v1.ServiceList, error) {
-	ret := _m.Called(namespace)
+// DeletePVC provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeletePVC(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
-	var r0 *v1.ServiceList
-	if rf, ok := ret.Get(0).(func(string) *v1.ServiceList); ok {
-		r0 = rf(namespace)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*v1.ServiceList)
-		}
+		r0 = ret.Error(0)
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(namespace)
+	return r0
+}
+
+// DeletePod provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeletePod(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// ListStatefulSets provides a mock function with given fields: namespace
-func (_m *Services) ListStatefulSets(namespace string) (*appsv1.StatefulSetList, error) {
-	ret := _m.Called(namespace)
+// DeletePodDisruptionBudget provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeletePodDisruptionBudget(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
-	var r0 *appsv1.StatefulSetList
-	if rf, ok := ret.Get(0).(func(string) *appsv1.StatefulSetList); ok {
-		r0 = rf(namespace)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*appsv1.StatefulSetList)
-		}
+		r0 = ret.Error(0)
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(namespace)
+	return r0
+}
+
+// DeleteRole provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteRole(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// UpdateConfigMap provides a mock function with given fields: namespace, configMap
-func (_m *Services) UpdateConfigMap(namespace string, configMap *v1.ConfigMap) error {
-	ret := _m.Called(namespace, configMap)
+// DeleteRoleBinding provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteRoleBinding(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.ConfigMap) error); ok {
-		r0 = rf(namespace, configMap)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -777,13 +686,13 @@ func (_m *Services) UpdateConfigMap(namespace string, configMap *v1.ConfigMap) e
 	return r0
 }
 
-// UpdateDeployment provides a mock function with given fields: namespace, deployment
-func (_m *Services) UpdateDeployment(namespace string, deployment *appsv1.Deployment) error {
-	ret := _m.Called(namespace, deployment)
+// DeleteService provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteService(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *appsv1.Deployment) error); ok {
-		r0 = rf(namespace, deployment)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -791,13 +700,13 @@ func (_m *Services) UpdateDeployment(namespace string, deployment *appsv1.Deploy
 	return r0
 }
 
-// UpdatePod provides a mock function with given fields: namespace, pod
-func (_m *Services) UpdatePod(namespace string, pod *v1.Pod) error {
-	ret := _m.Called(namespace, pod)
+// DeleteServiceAccount provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteServiceAccount(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.Pod) error); ok {
-		r0 = rf(namespace, pod)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -805,13 +714,13 @@ func (_m *Services) UpdatePod(namespace string, pod *v1.Pod) error {
 	return r0
 }
 
-// UpdatePodDisruptionBudget provides a mock function with given fields: namespace, podDisruptionBudget
-func (_m *Services) UpdatePodDisruptionBudget(namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
-	ret := _m.Called(namespace, podDisruptionBudget)
+// DeleteServiceMonitor provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteServiceMonitor(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *policyv1.PodDisruptionBudget) error); ok {
-		r0 = rf(namespace, podDisruptionBudget)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -819,13 +728,13 @@ func (_m *Services) UpdatePodDisruptionBudget(namespace string, podDisruptionBud
 	return r0
 }
 
-// UpdatePodLabels provides a mock function with given fields: namespace, podName, labels
-func (_m *Services) UpdatePodLabels(namespace string, podName string, labels map[string]string) error {
-	ret := _m.Called(namespace, podName, labels)
+// DeleteStatefulSet provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteStatefulSet(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, map[string]string) error); ok {
-		r0 = rf(namespace, podName, labels)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -833,13 +742,13 @@ func (_m *Services) UpdatePodLabels(namespace string, podName string, labels map
 	return r0
 }
 
-// UpdateRole provides a mock function with given fields: namespace, role
-func (_m *Services) UpdateRole(namespace string, role *rbacv1.Role) error {
-	ret := _m.Called(namespace, role)
+// DeleteVolumeSnapshot provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) DeleteVolumeSnapshot(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *rbacv1.Role) error); ok {
-		r0 = rf(namespace, role)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -847,13 +756,13 @@ func (_m *Services) UpdateRole(namespace string, role *rbacv1.Role) error {
 	return r0
 }
 
-// UpdateRoleBinding provides a mock function with given fields: namespace, binding
-func (_m *Services) UpdateRoleBinding(namespace string, binding *rbacv1.RoleBinding) error {
-	ret := _m.Called(namespace, binding)
+// EachRedisFailover provides a mock function with given fields: ctx, namespace, opts, fn
+func (_m *Services) EachRedisFailover(ctx context.Context, namespace string, opts metav1.ListOptions, fn func(*redisfailoverv1.RedisFailover) error) error {
+	ret := _m.Called(ctx, namespace, opts, fn)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *rbacv1.RoleBinding) error); ok {
-		r0 = rf(namespace, binding)
+	if rf, ok := ret.Get(0).(func(context.Context, string, metav1.ListOptions, func(*redisfailoverv1.RedisFailover) error) error); ok {
+		r0 = rf(ctx, namespace, opts, fn)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -861,13 +770,13 @@ func (_m *Services) UpdateRoleBinding(namespace string, binding *rbacv1.RoleBind
 	return r0
 }
 
-// UpdateService provides a mock function with given fields: namespace, service
-func (_m *Services) UpdateService(namespace string, service *v1.Service) error {
-	ret := _m.Called(namespace, service)
+// EachStatefulSet provides a mock function with given fields: ctx, namespace, opts, fn
+func (_m *Services) EachStatefulSet(ctx context.Context, namespace string, opts metav1.ListOptions, fn func(*appsv1.StatefulSet) error) error {
+	ret := _m.Called(ctx, namespace, opts, fn)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *v1.Service) error); ok {
-		r0 = rf(namespace, service)
+	if rf, ok := ret.Get(0).(func(context.Context, string, metav1.ListOptions, func(*appsv1.StatefulSet) error) error); ok {
+		r0 = rf(ctx, namespace, opts, fn)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -875,13 +784,13 @@ func (_m *Services) UpdateService(namespace string, service *v1.Service) error {
 	return r0
 }
 
-// UpdateStatefulSet provides a mock function with given fields: namespace, statefulSet
-func (_m *Services) UpdateStatefulSet(namespace string, statefulSet *appsv1.StatefulSet) error {
-	ret := _m.Called(namespace, statefulSet)
+// EvictPod provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) EvictPod(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *appsv1.StatefulSet) error); ok {
-		r0 = rf(namespace, statefulSet)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -889,15 +798,1475 @@ func (_m *Services) UpdateStatefulSet(namespace string, statefulSet *appsv1.Stat
 	return r0
 }
 
-// WatchRedisFailovers provides a mock function with given fields: ctx, namespace, opts
-func (_m *Services) WatchRedisFailovers(ctx context.Context, namespace string, opts metav1.ListOptions) (watch.Interface, error) {
-	ret := _m.Called(ctx, namespace, opts)
+// ExecCommand provides a mock function with given fields: ctx, namespace, podName, container, command
+func (_m *Services) ExecCommand(ctx context.Context, namespace string, podName string, container string, command []string) (string, string, error) {
+	ret := _m.Called(ctx, namespace, podName, container, command)
 
-	var r0 watch.Interface
-	if rf, ok := ret.Get(0).(func(context.Context, string, metav1.ListOptions) watch.Interface); ok {
-		r0 = rf(ctx, namespace, opts)
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []string) string); ok {
+		r0 = rf(ctx, namespace, podName, container, command)
 	} else {
-		if ret.Get(0) != nil {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, []string) string); ok {
+		r1 = rf(ctx, namespace, podName, container, command)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, string, []string) error); ok {
+		r2 = rf(ctx, namespace, podName, container, command)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ExecPod provides a mock function with given fields: ctx, namespace, podName, container, command
+func (_m *Services) ExecPod(ctx context.Context, namespace string, podName string, container string, command []string) (string, error) {
+	ret := _m.Called(ctx, namespace, podName, container, command)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []string) string); ok {
+		r0 = rf(ctx, namespace, podName, container, command)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, []string) error); ok {
+		r1 = rf(ctx, namespace, podName, container, command)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCertificate provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetCertificate(ctx context.Context, namespace string, name string) (*unstructured.Unstructured, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *unstructured.Unstructured
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *unstructured.Unstructured); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*unstructured.Unstructured)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetClusterRole provides a mock function with given fields: ctx, name
+func (_m *Services) GetClusterRole(ctx context.Context, name string) (*rbacv1.ClusterRole, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *rbacv1.ClusterRole
+	if rf, ok := ret.Get(0).(func(context.Context, string) *rbacv1.ClusterRole); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rbacv1.ClusterRole)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConfigMap provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetConfigMap(ctx context.Context, namespace string, name string) (*v1.ConfigMap, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *v1.ConfigMap
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *v1.ConfigMap); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.ConfigMap)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCustomResourceDefinition provides a mock function with given fields: ctx, name
+func (_m *Services) GetCustomResourceDefinition(ctx context.Context, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *apiextensionsv1.CustomResourceDefinition
+	if rf, ok := ret.Get(0).(func(context.Context, string) *apiextensionsv1.CustomResourceDefinition); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*apiextensionsv1.CustomResourceDefinition)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeployment provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetDeployment(ctx context.Context, namespace string, name string) (*appsv1.Deployment, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *appsv1.Deployment
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *appsv1.Deployment); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*appsv1.Deployment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeploymentPods provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetDeploymentPods(ctx context.Context, namespace string, name string) (*v1.PodList, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *v1.PodList
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *v1.PodList); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.PodList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHPA provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetHPA(ctx context.Context, namespace string, name string) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *autoscalingv2.HorizontalPodAutoscaler
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *autoscalingv2.HorizontalPodAutoscaler); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscalingv2.HorizontalPodAutoscaler)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNamespace provides a mock function with given fields: ctx, name
+func (_m *Services) GetNamespace(ctx context.Context, name string) (*v1.Namespace, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *v1.Namespace
+	if rf, ok := ret.Get(0).(func(context.Context, string) *v1.Namespace); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.Namespace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNetworkPolicy provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetNetworkPolicy(ctx context.Context, namespace string, name string) (*networkingv1.NetworkPolicy, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *networkingv1.NetworkPolicy
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *networkingv1.NetworkPolicy); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*networkingv1.NetworkPolicy)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNode provides a mock function with given fields: ctx, name
+func (_m *Services) GetNode(ctx context.Context, name string) (*v1.Node, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *v1.Node
+	if rf, ok := ret.Get(0).(func(context.Context, string) *v1.Node); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.Node)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNodeLabels provides a mock function with given fields: ctx, name
+func (_m *Services) GetNodeLabels(ctx context.Context, name string) (map[string]string, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func(context.Context, string) map[string]string); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPVC provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetPVC(ctx context.Context, namespace string, name string) (*v1.PersistentVolumeClaim, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *v1.PersistentVolumeClaim
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *v1.PersistentVolumeClaim); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.PersistentVolumeClaim)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPod provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetPod(ctx context.Context, namespace string, name string) (*v1.Pod, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *v1.Pod
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *v1.Pod); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.Pod)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPodDisruptionBudget provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetPodDisruptionBudget(ctx context.Context, namespace string, name string) (*policyv1.PodDisruptionBudget, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *policyv1.PodDisruptionBudget
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *policyv1.PodDisruptionBudget); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*policyv1.PodDisruptionBudget)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPodLogs provides a mock function with given fields: ctx, namespace, podName, container, tailLines
+func (_m *Services) GetPodLogs(ctx context.Context, namespace string, podName string, container string, tailLines int64) (string, error) {
+	ret := _m.Called(ctx, namespace, podName, container, tailLines)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int64) string); ok {
+		r0 = rf(ctx, namespace, podName, container, tailLines)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, int64) error); ok {
+		r1 = rf(ctx, namespace, podName, container, tailLines)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPodStartupLogs provides a mock function with given fields: ctx, namespace, podName, container, limitBytes
+func (_m *Services) GetPodStartupLogs(ctx context.Context, namespace string, podName string, container string, limitBytes int64) (string, error) {
+	ret := _m.Called(ctx, namespace, podName, container, limitBytes)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int64) string); ok {
+		r0 = rf(ctx, namespace, podName, container, limitBytes)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, int64) error); ok {
+		r1 = rf(ctx, namespace, podName, container, limitBytes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPreviousPodLogs provides a mock function with given fields: ctx, namespace, podName, container, tailLines
+func (_m *Services) GetPreviousPodLogs(ctx context.Context, namespace string, podName string, container string, tailLines int64) (string, error) {
+	ret := _m.Called(ctx, namespace, podName, container, tailLines)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int64) string); ok {
+		r0 = rf(ctx, namespace, podName, container, tailLines)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, int64) error); ok {
+		r1 = rf(ctx, namespace, podName, container, tailLines)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPriorityClass provides a mock function with given fields: ctx, name
+func (_m *Services) GetPriorityClass(ctx context.Context, name string) (*schedulingv1.PriorityClass, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *schedulingv1.PriorityClass
+	if rf, ok := ret.Get(0).(func(context.Context, string) *schedulingv1.PriorityClass); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*schedulingv1.PriorityClass)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRedisCluster provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetRedisCluster(ctx context.Context, namespace string, name string) (*redisfailoverv1.RedisCluster, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *redisfailoverv1.RedisCluster
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *redisfailoverv1.RedisCluster); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*redisfailoverv1.RedisCluster)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRedisFailover provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetRedisFailover(ctx context.Context, namespace string, name string) (*redisfailoverv1.RedisFailover, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *redisfailoverv1.RedisFailover
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *redisfailoverv1.RedisFailover); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*redisfailoverv1.RedisFailover)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRole provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetRole(ctx context.Context, namespace string, name string) (*rbacv1.Role, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *rbacv1.Role
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *rbacv1.Role); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rbacv1.Role)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRoleBinding provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetRoleBinding(ctx context.Context, namespace string, name string) (*rbacv1.RoleBinding, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *rbacv1.RoleBinding
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *rbacv1.RoleBinding); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rbacv1.RoleBinding)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSecret provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetSecret(ctx context.Context, namespace string, name string) (*v1.Secret, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *v1.Secret
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *v1.Secret); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.Secret)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetService provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetService(ctx context.Context, namespace string, name string) (*v1.Service, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *v1.Service
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *v1.Service); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.Service)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetServiceAccount provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetServiceAccount(ctx context.Context, namespace string, name string) (*v1.ServiceAccount, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *v1.ServiceAccount
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *v1.ServiceAccount); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.ServiceAccount)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetServiceMonitor provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetServiceMonitor(ctx context.Context, namespace string, name string) (*unstructured.Unstructured, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *unstructured.Unstructured
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *unstructured.Unstructured); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*unstructured.Unstructured)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStatefulSet provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetStatefulSet(ctx context.Context, namespace string, name string) (*appsv1.StatefulSet, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *appsv1.StatefulSet
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *appsv1.StatefulSet); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*appsv1.StatefulSet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStatefulSetPods provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetStatefulSetPods(ctx context.Context, namespace string, name string) (*v1.PodList, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 *v1.PodList
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *v1.PodList); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.PodList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStatefulSetRolloutStatus provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) GetStatefulSetRolloutStatus(ctx context.Context, namespace string, name string) (bool, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStorageClass provides a mock function with given fields: ctx, name
+func (_m *Services) GetStorageClass(ctx context.Context, name string) (*storagev1.StorageClass, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *storagev1.StorageClass
+	if rf, ok := ret.Get(0).(func(context.Context, string) *storagev1.StorageClass); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storagev1.StorageClass)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsNamespaceTerminating provides a mock function with given fields: ctx, name
+func (_m *Services) IsNamespaceTerminating(ctx context.Context, name string) (bool, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListConfigMaps provides a mock function with given fields: ctx, namespace
+func (_m *Services) ListConfigMaps(ctx context.Context, namespace string) (*v1.ConfigMapList, error) {
+	ret := _m.Called(ctx, namespace)
+
+	var r0 *v1.ConfigMapList
+	if rf, ok := ret.Get(0).(func(context.Context, string) *v1.ConfigMapList); ok {
+		r0 = rf(ctx, namespace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.ConfigMapList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, namespace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListConfigMapsBySelector provides a mock function with given fields: ctx, namespace, selector
+func (_m *Services) ListConfigMapsBySelector(ctx context.Context, namespace string, selector map[string]string) (*v1.ConfigMapList, error) {
+	ret := _m.Called(ctx, namespace, selector)
+
+	var r0 *v1.ConfigMapList
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) *v1.ConfigMapList); ok {
+		r0 = rf(ctx, namespace, selector)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.ConfigMapList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, map[string]string) error); ok {
+		r1 = rf(ctx, namespace, selector)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeployments provides a mock function with given fields: ctx, namespace
+func (_m *Services) ListDeployments(ctx context.Context, namespace string) (*appsv1.DeploymentList, error) {
+	ret := _m.Called(ctx, namespace)
+
+	var r0 *appsv1.DeploymentList
+	if rf, ok := ret.Get(0).(func(context.Context, string) *appsv1.DeploymentList); ok {
+		r0 = rf(ctx, namespace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*appsv1.DeploymentList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, namespace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeploymentsWithSelector provides a mock function with given fields: ctx, namespace, selector
+func (_m *Services) ListDeploymentsWithSelector(ctx context.Context, namespace string, selector labels.Selector) (*appsv1.DeploymentList, error) {
+	ret := _m.Called(ctx, namespace, selector)
+
+	var r0 *appsv1.DeploymentList
+	if rf, ok := ret.Get(0).(func(context.Context, string, labels.Selector) *appsv1.DeploymentList); ok {
+		r0 = rf(ctx, namespace, selector)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*appsv1.DeploymentList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, labels.Selector) error); ok {
+		r1 = rf(ctx, namespace, selector)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListEvents provides a mock function with given fields: ctx, namespace
+func (_m *Services) ListEvents(ctx context.Context, namespace string) (*v1.EventList, error) {
+	ret := _m.Called(ctx, namespace)
+
+	var r0 *v1.EventList
+	if rf, ok := ret.Get(0).(func(context.Context, string) *v1.EventList); ok {
+		r0 = rf(ctx, namespace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.EventList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, namespace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListNetworkPolicies provides a mock function with given fields: ctx, namespace
+func (_m *Services) ListNetworkPolicies(ctx context.Context, namespace string) (*networkingv1.NetworkPolicyList, error) {
+	ret := _m.Called(ctx, namespace)
+
+	var r0 *networkingv1.NetworkPolicyList
+	if rf, ok := ret.Get(0).(func(context.Context, string) *networkingv1.NetworkPolicyList); ok {
+		r0 = rf(ctx, namespace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*networkingv1.NetworkPolicyList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, namespace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPVCs provides a mock function with given fields: ctx, namespace, selector
+func (_m *Services) ListPVCs(ctx context.Context, namespace string, selector labels.Selector) (*v1.PersistentVolumeClaimList, error) {
+	ret := _m.Called(ctx, namespace, selector)
+
+	var r0 *v1.PersistentVolumeClaimList
+	if rf, ok := ret.Get(0).(func(context.Context, string, labels.Selector) *v1.PersistentVolumeClaimList); ok {
+		r0 = rf(ctx, namespace, selector)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.PersistentVolumeClaimList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, labels.Selector) error); ok {
+		r1 = rf(ctx, namespace, selector)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPods provides a mock function with given fields: ctx, namespace
+func (_m *Services) ListPods(ctx context.Context, namespace string) (*v1.PodList, error) {
+	ret := _m.Called(ctx, namespace)
+
+	var r0 *v1.PodList
+	if rf, ok := ret.Get(0).(func(context.Context, string) *v1.PodList); ok {
+		r0 = rf(ctx, namespace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.PodList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, namespace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRedisClusters provides a mock function with given fields: ctx, namespace, opts
+func (_m *Services) ListRedisClusters(ctx context.Context, namespace string, opts metav1.ListOptions) (*redisfailoverv1.RedisClusterList, error) {
+	ret := _m.Called(ctx, namespace, opts)
+
+	var r0 *redisfailoverv1.RedisClusterList
+	if rf, ok := ret.Get(0).(func(context.Context, string, metav1.ListOptions) *redisfailoverv1.RedisClusterList); ok {
+		r0 = rf(ctx, namespace, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*redisfailoverv1.RedisClusterList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, metav1.ListOptions) error); ok {
+		r1 = rf(ctx, namespace, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRedisFailovers provides a mock function with given fields: ctx, namespace, opts
+func (_m *Services) ListRedisFailovers(ctx context.Context, namespace string, opts metav1.ListOptions) (*redisfailoverv1.RedisFailoverList, error) {
+	ret := _m.Called(ctx, namespace, opts)
+
+	var r0 *redisfailoverv1.RedisFailoverList
+	if rf, ok := ret.Get(0).(func(context.Context, string, metav1.ListOptions) *redisfailoverv1.RedisFailoverList); ok {
+		r0 = rf(ctx, namespace, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*redisfailoverv1.RedisFailoverList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, metav1.ListOptions) error); ok {
+		r1 = rf(ctx, namespace, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListServices provides a mock function with given fields: ctx, namespace
+func (_m *Services) ListServices(ctx context.Context, namespace string) (*v1.ServiceList, error) {
+	ret := _m.Called(ctx, namespace)
+
+	var r0 *v1.ServiceList
+	if rf, ok := ret.Get(0).(func(context.Context, string) *v1.ServiceList); ok {
+		r0 = rf(ctx, namespace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.ServiceList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, namespace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListStatefulSetsByLabel provides a mock function with given fields: ctx, namespace, set
+func (_m *Services) ListStatefulSetsByLabel(ctx context.Context, namespace string, set map[string]string) (*appsv1.StatefulSetList, error) {
+	ret := _m.Called(ctx, namespace, set)
+
+	var r0 *appsv1.StatefulSetList
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) *appsv1.StatefulSetList); ok {
+		r0 = rf(ctx, namespace, set)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*appsv1.StatefulSetList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, map[string]string) error); ok {
+		r1 = rf(ctx, namespace, set)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListVolumeSnapshots provides a mock function with given fields: ctx, namespace, selector
+func (_m *Services) ListVolumeSnapshots(ctx context.Context, namespace string, selector labels.Selector) (*unstructured.UnstructuredList, error) {
+	ret := _m.Called(ctx, namespace, selector)
+
+	var r0 *unstructured.UnstructuredList
+	if rf, ok := ret.Get(0).(func(context.Context, string, labels.Selector) *unstructured.UnstructuredList); ok {
+		r0 = rf(ctx, namespace, selector)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*unstructured.UnstructuredList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, labels.Selector) error); ok {
+		r1 = rf(ctx, namespace, selector)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchPVCSize provides a mock function with given fields: ctx, namespace, name, size
+func (_m *Services) PatchPVCSize(ctx context.Context, namespace string, name string, size resource.Quantity) error {
+	ret := _m.Called(ctx, namespace, name, size)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, resource.Quantity) error); ok {
+		r0 = rf(ctx, namespace, name, size)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PatchRedisFailoverAnnotations provides a mock function with given fields: ctx, namespace, name, annotations
+func (_m *Services) PatchRedisFailoverAnnotations(ctx context.Context, namespace string, name string, annotations map[string]string) error {
+	ret := _m.Called(ctx, namespace, name, annotations)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, map[string]string) error); ok {
+		r0 = rf(ctx, namespace, name, annotations)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PatchRedisFailoverFinalizers provides a mock function with given fields: ctx, namespace, name, resourceVersion, finalizers
+func (_m *Services) PatchRedisFailoverFinalizers(ctx context.Context, namespace string, name string, resourceVersion string, finalizers []string) (*redisfailoverv1.RedisFailover, error) {
+	ret := _m.Called(ctx, namespace, name, resourceVersion, finalizers)
+
+	var r0 *redisfailoverv1.RedisFailover
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []string) *redisfailoverv1.RedisFailover); ok {
+		r0 = rf(ctx, namespace, name, resourceVersion, finalizers)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*redisfailoverv1.RedisFailover)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, []string) error); ok {
+		r1 = rf(ctx, namespace, name, resourceVersion, finalizers)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchStatefulSet provides a mock function with given fields: ctx, namespace, name, patch
+func (_m *Services) PatchStatefulSet(ctx context.Context, namespace string, name string, patch []byte) error {
+	ret := _m.Called(ctx, namespace, name, patch)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []byte) error); ok {
+		r0 = rf(ctx, namespace, name, patch)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PatchStatefulSetWithType provides a mock function with given fields: ctx, namespace, name, data, pt
+func (_m *Services) PatchStatefulSetWithType(ctx context.Context, namespace string, name string, data []byte, pt types.PatchType) error {
+	ret := _m.Called(ctx, namespace, name, data, pt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []byte, types.PatchType) error); ok {
+		r0 = rf(ctx, namespace, name, data, pt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResizePod provides a mock function with given fields: ctx, namespace, podName, container, resources
+func (_m *Services) ResizePod(ctx context.Context, namespace string, podName string, container string, resources v1.ResourceRequirements) error {
+	ret := _m.Called(ctx, namespace, podName, container, resources)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, v1.ResourceRequirements) error); ok {
+		r0 = rf(ctx, namespace, podName, container, resources)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RollingRestartStatefulSet provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) RollingRestartStatefulSet(ctx context.Context, namespace string, name string) error {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScaleStatefulSet provides a mock function with given fields: ctx, namespace, name, replicas
+func (_m *Services) ScaleStatefulSet(ctx context.Context, namespace string, name string, replicas int32) error {
+	ret := _m.Called(ctx, namespace, name, replicas)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int32) error); ok {
+		r0 = rf(ctx, namespace, name, replicas)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateConfigMap provides a mock function with given fields: ctx, namespace, configMap
+func (_m *Services) UpdateConfigMap(ctx context.Context, namespace string, configMap *v1.ConfigMap) error {
+	ret := _m.Called(ctx, namespace, configMap)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.ConfigMap) error); ok {
+		r0 = rf(ctx, namespace, configMap)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateDeployment provides a mock function with given fields: ctx, namespace, deployment
+func (_m *Services) UpdateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error {
+	ret := _m.Called(ctx, namespace, deployment)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *appsv1.Deployment) error); ok {
+		r0 = rf(ctx, namespace, deployment)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateHPA provides a mock function with given fields: ctx, namespace, hpa
+func (_m *Services) UpdateHPA(ctx context.Context, namespace string, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	ret := _m.Called(ctx, namespace, hpa)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *autoscalingv2.HorizontalPodAutoscaler) error); ok {
+		r0 = rf(ctx, namespace, hpa)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateNetworkPolicy provides a mock function with given fields: ctx, namespace, networkPolicy
+func (_m *Services) UpdateNetworkPolicy(ctx context.Context, namespace string, networkPolicy *networkingv1.NetworkPolicy) error {
+	ret := _m.Called(ctx, namespace, networkPolicy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *networkingv1.NetworkPolicy) error); ok {
+		r0 = rf(ctx, namespace, networkPolicy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePVC provides a mock function with given fields: ctx, namespace, pvc
+func (_m *Services) UpdatePVC(ctx context.Context, namespace string, pvc *v1.PersistentVolumeClaim) error {
+	ret := _m.Called(ctx, namespace, pvc)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.PersistentVolumeClaim) error); ok {
+		r0 = rf(ctx, namespace, pvc)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePod provides a mock function with given fields: ctx, namespace, pod
+func (_m *Services) UpdatePod(ctx context.Context, namespace string, pod *v1.Pod) error {
+	ret := _m.Called(ctx, namespace, pod)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Pod) error); ok {
+		r0 = rf(ctx, namespace, pod)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePodDisruptionBudget provides a mock function with given fields: ctx, namespace, podDisruptionBudget
+func (_m *Services) UpdatePodDisruptionBudget(ctx context.Context, namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
+	ret := _m.Called(ctx, namespace, podDisruptionBudget)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *policyv1.PodDisruptionBudget) error); ok {
+		r0 = rf(ctx, namespace, podDisruptionBudget)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePodLabels provides a mock function with given fields: ctx, namespace, podName, labels
+func (_m *Services) UpdatePodLabels(ctx context.Context, namespace string, podName string, labels map[string]string) error {
+	ret := _m.Called(ctx, namespace, podName, labels)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, map[string]string) error); ok {
+		r0 = rf(ctx, namespace, podName, labels)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRedisFailover provides a mock function with given fields: ctx, namespace, rFailover
+func (_m *Services) UpdateRedisFailover(ctx context.Context, namespace string, rFailover *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+	ret := _m.Called(ctx, namespace, rFailover)
+
+	var r0 *redisfailoverv1.RedisFailover
+	if rf, ok := ret.Get(0).(func(context.Context, string, *redisfailoverv1.RedisFailover) *redisfailoverv1.RedisFailover); ok {
+		r0 = rf(ctx, namespace, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*redisfailoverv1.RedisFailover)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *redisfailoverv1.RedisFailover) error); ok {
+		r1 = rf(ctx, namespace, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateRedisClusterStatus provides a mock function with given fields: ctx, namespace, rc
+func (_m *Services) UpdateRedisClusterStatus(ctx context.Context, namespace string, rc *redisfailoverv1.RedisCluster) (*redisfailoverv1.RedisCluster, error) {
+	ret := _m.Called(ctx, namespace, rc)
+
+	var r0 *redisfailoverv1.RedisCluster
+	if rf, ok := ret.Get(0).(func(context.Context, string, *redisfailoverv1.RedisCluster) *redisfailoverv1.RedisCluster); ok {
+		r0 = rf(ctx, namespace, rc)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*redisfailoverv1.RedisCluster)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *redisfailoverv1.RedisCluster) error); ok {
+		r1 = rf(ctx, namespace, rc)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateRedisFailoverStatus provides a mock function with given fields: ctx, namespace, rFailover
+func (_m *Services) UpdateRedisFailoverStatus(ctx context.Context, namespace string, rFailover *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+	ret := _m.Called(ctx, namespace, rFailover)
+
+	var r0 *redisfailoverv1.RedisFailover
+	if rf, ok := ret.Get(0).(func(context.Context, string, *redisfailoverv1.RedisFailover) *redisfailoverv1.RedisFailover); ok {
+		r0 = rf(ctx, namespace, rFailover)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*redisfailoverv1.RedisFailover)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *redisfailoverv1.RedisFailover) error); ok {
+		r1 = rf(ctx, namespace, rFailover)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateRole provides a mock function with given fields: ctx, namespace, role
+func (_m *Services) UpdateRole(ctx context.Context, namespace string, role *rbacv1.Role) error {
+	ret := _m.Called(ctx, namespace, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *rbacv1.Role) error); ok {
+		r0 = rf(ctx, namespace, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRoleBinding provides a mock function with given fields: ctx, namespace, binding
+func (_m *Services) UpdateRoleBinding(ctx context.Context, namespace string, binding *rbacv1.RoleBinding) error {
+	ret := _m.Called(ctx, namespace, binding)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *rbacv1.RoleBinding) error); ok {
+		r0 = rf(ctx, namespace, binding)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateSecret provides a mock function with given fields: ctx, namespace, secret
+func (_m *Services) UpdateSecret(ctx context.Context, namespace string, secret *v1.Secret) error {
+	ret := _m.Called(ctx, namespace, secret)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Secret) error); ok {
+		r0 = rf(ctx, namespace, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateService provides a mock function with given fields: ctx, namespace, service
+func (_m *Services) UpdateService(ctx context.Context, namespace string, service *v1.Service) error {
+	ret := _m.Called(ctx, namespace, service)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *v1.Service) error); ok {
+		r0 = rf(ctx, namespace, service)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateStatefulSet provides a mock function with given fields: ctx, namespace, statefulSet
+func (_m *Services) UpdateStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error {
+	ret := _m.Called(ctx, namespace, statefulSet)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *appsv1.StatefulSet) error); ok {
+		r0 = rf(ctx, namespace, statefulSet)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WaitForPodReady provides a mock function with given fields: ctx, namespace, name, interval, timeout
+func (_m *Services) WaitForPodReady(ctx context.Context, namespace string, name string, interval time.Duration, timeout time.Duration) error {
+	ret := _m.Called(ctx, namespace, name, interval, timeout)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration, time.Duration) error); ok {
+		r0 = rf(ctx, namespace, name, interval, timeout)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WatchRedisClusters provides a mock function with given fields: ctx, namespace, opts
+func (_m *Services) WatchRedisClusters(ctx context.Context, namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	ret := _m.Called(ctx, namespace, opts)
+
+	var r0 watch.Interface
+	if rf, ok := ret.Get(0).(func(context.Context, string, metav1.ListOptions) watch.Interface); ok {
+		r0 = rf(ctx, namespace, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(watch.Interface)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, metav1.ListOptions) error); ok {
+		r1 = rf(ctx, namespace, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchRedisFailovers provides a mock function with given fields: ctx, namespace, opts
+func (_m *Services) WatchRedisFailovers(ctx context.Context, namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	ret := _m.Called(ctx, namespace, opts)
+
+	var r0 watch.Interface
+	if rf, ok := ret.Get(0).(func(context.Context, string, metav1.ListOptions) watch.Interface); ok {
+		r0 = rf(ctx, namespace, opts)
+	} else {
+		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(watch.Interface)
 		}
 	}
@@ -911,3 +2280,26 @@ func (_m *Services) WatchRedisFailovers(ctx context.Context, namespace string, o
 
 	return r0, r1
 }
+
+// WatchStatefulSet provides a mock function with given fields: ctx, namespace, name
+func (_m *Services) WatchStatefulSet(ctx context.Context, namespace string, name string) (<-chan watch.Event, error) {
+	ret := _m.Called(ctx, namespace, name)
+
+	var r0 <-chan watch.Event
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) <-chan watch.Event); ok {
+		r0 = rf(ctx, namespace, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan watch.Event)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, namespace, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -2,7 +2,11 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Client is an autogenerated mock type for the Client type
 type Client struct {
@@ -51,6 +55,90 @@ func (_m *Client) GetNumberSentinelsInMemory(ip string) (int32, error) {
 	return r0, r1
 }
 
+// GetRedisConfig provides a mock function with given fields: ip, port, password, parameter
+func (_m *Client) GetRedisConfig(ip string, port string, password string, parameter string) (string, error) {
+	ret := _m.Called(ip, port, password, parameter)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, string, string) string); ok {
+		r0 = rf(ip, port, password, parameter)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, string) error); ok {
+		r1 = rf(ip, port, password, parameter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRedisInfo provides a mock function with given fields: ip, port, password
+func (_m *Client) GetRedisInfo(ip string, port string, password string) (string, error) {
+	ret := _m.Called(ip, port, password)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, string) string); ok {
+		r0 = rf(ip, port, password)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(ip, port, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRedisKey provides a mock function with given fields: ip, port, password, key
+func (_m *Client) GetRedisKey(ip string, port string, password string, key string) (string, error) {
+	ret := _m.Called(ip, port, password, key)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, string, string) string); ok {
+		r0 = rf(ip, port, password, key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, string) error); ok {
+		r1 = rf(ip, port, password, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSentinelInfo provides a mock function with given fields: ip
+func (_m *Client) GetSentinelInfo(ip string) (string, error) {
+	ret := _m.Called(ip)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(ip)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(ip)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetSentinelMonitor provides a mock function with given fields: ip
 func (_m *Client) GetSentinelMonitor(ip string) (string, string, error) {
 	ret := _m.Called(ip)
@@ -100,6 +188,55 @@ func (_m *Client) GetSlaveOf(ip string, port string, password string) (string, e
 	return r0, r1
 }
 
+// GetSlaveOfWithPort provides a mock function with given fields: ip, port, password
+func (_m *Client) GetSlaveOfWithPort(ip string, port string, password string) (string, string, error) {
+	ret := _m.Called(ip, port, password)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, string) string); ok {
+		r0 = rf(ip, port, password)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string, string, string) string); ok {
+		r1 = rf(ip, port, password)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string, string) error); ok {
+		r2 = rf(ip, port, password)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetSyncingReplicas provides a mock function with given fields: ip, port, password
+func (_m *Client) GetSyncingReplicas(ip string, port string, password string) (int, error) {
+	ret := _m.Called(ip, port, password)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(string, string, string) int); ok {
+		r0 = rf(ip, port, password)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(ip, port, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // IsMaster provides a mock function with given fields: ip, port, password
 func (_m *Client) IsMaster(ip string, port string, password string) (bool, error) {
 	ret := _m.Called(ip, port, password)
@@ -121,6 +258,85 @@ func (_m *Client) IsMaster(ip string, port string, password string) (bool, error
 	return r0, r1
 }
 
+// IsMasterDownByAddr provides a mock function with given fields: ip, masterIP, masterPort
+func (_m *Client) IsMasterDownByAddr(ip string, masterIP string, masterPort string) (bool, error) {
+	ret := _m.Called(ip, masterIP, masterPort)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string, string) bool); ok {
+		r0 = rf(ip, masterIP, masterPort)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(ip, masterIP, masterPort)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LatencyDoctor provides a mock function with given fields: ip, port, password
+func (_m *Client) LatencyDoctor(ip string, port string, password string) (string, error) {
+	ret := _m.Called(ip, port, password)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, string) string); ok {
+		r0 = rf(ip, port, password)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(ip, port, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListACLUsers provides a mock function with given fields: ip, port, password
+func (_m *Client) ListACLUsers(ip string, port string, password string) ([]string, error) {
+	ret := _m.Called(ip, port, password)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string, string, string) []string); ok {
+		r0 = rf(ip, port, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(ip, port, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadACL provides a mock function with given fields: ip, port, password
+func (_m *Client) LoadACL(ip string, port string, password string) error {
+	ret := _m.Called(ip, port, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(ip, port, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // MakeMaster provides a mock function with given fields: ip, port, password
 func (_m *Client) MakeMaster(ip string, port string, password string) error {
 	ret := _m.Called(ip, port, password)
@@ -163,6 +379,41 @@ func (_m *Client) MakeSlaveOfWithPort(ip string, masterIP string, masterPort str
 	return r0
 }
 
+// MakeSlaveOfWithPorts provides a mock function with given fields: ip, port, masterIP, masterPort, password
+func (_m *Client) MakeSlaveOfWithPorts(ip string, port string, masterIP string, masterPort string, password string) error {
+	ret := _m.Called(ip, port, masterIP, masterPort, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string, string) error); ok {
+		r0 = rf(ip, port, masterIP, masterPort, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MemoryDoctor provides a mock function with given fields: ip, port, password
+func (_m *Client) MemoryDoctor(ip string, port string, password string) (string, error) {
+	ret := _m.Called(ip, port, password)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, string) string); ok {
+		r0 = rf(ip, port, password)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(ip, port, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // MonitorRedis provides a mock function with given fields: ip, monitor, quorum, password
 func (_m *Client) MonitorRedis(ip string, monitor string, quorum string, password string) error {
 	ret := _m.Called(ip, monitor, quorum, password)
@@ -191,6 +442,34 @@ func (_m *Client) MonitorRedisWithPort(ip string, monitor string, port string, q
 	return r0
 }
 
+// PingRedis provides a mock function with given fields: ip, port, password
+func (_m *Client) PingRedis(ip string, port string, password string) error {
+	ret := _m.Called(ip, port, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(ip, port, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PingSentinel provides a mock function with given fields: ip
+func (_m *Client) PingSentinel(ip string) error {
+	ret := _m.Called(ip)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(ip)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ResetSentinel provides a mock function with given fields: ip
 func (_m *Client) ResetSentinel(ip string) error {
 	ret := _m.Called(ip)
@@ -205,6 +484,43 @@ func (_m *Client) ResetSentinel(ip string) error {
 	return r0
 }
 
+// SentinelConfigGet provides a mock function with given fields: ip, parameter
+func (_m *Client) SentinelConfigGet(ip string, parameter string) (map[string]string, error) {
+	ret := _m.Called(ip, parameter)
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func(string, string) map[string]string); ok {
+		r0 = rf(ip, parameter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(ip, parameter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SentinelConfigSet provides a mock function with given fields: ip, parameter, value
+func (_m *Client) SentinelConfigSet(ip string, parameter string, value string) error {
+	ret := _m.Called(ip, parameter, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(ip, parameter, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetCustomRedisConfig provides a mock function with given fields: ip, port, configs, password
 func (_m *Client) SetCustomRedisConfig(ip string, port string, configs []string, password string) error {
 	ret := _m.Called(ip, port, configs, password)
@@ -233,6 +549,20 @@ func (_m *Client) SetCustomSentinelConfig(ip string, configs []string) error {
 	return r0
 }
 
+// SetRedisKey provides a mock function with given fields: ip, port, password, key, value, ttl
+func (_m *Client) SetRedisKey(ip string, port string, password string, key string, value string, ttl time.Duration) error {
+	ret := _m.Called(ip, port, password, key, value, ttl)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string, string, time.Duration) error); ok {
+		r0 = rf(ip, port, password, key, value, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SlaveIsReady provides a mock function with given fields: ip, port, password
 func (_m *Client) SlaveIsReady(ip string, port string, password string) (bool, error) {
 	ret := _m.Called(ip, port, password)
@@ -0,0 +1,7 @@
+/*
+Redis cluster operator handles a redis cluster, this RC will create a
+redis cluster sharding the data across several masters, each one with
+its replicas, a statefulset per shard.
+*/
+
+package rediscluster
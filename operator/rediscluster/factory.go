@@ -0,0 +1,69 @@
+package rediscluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/spotahome/kooper/v2/controller"
+	"github.com/spotahome/kooper/v2/controller/leaderelection"
+	kooperlog "github.com/spotahome/kooper/v2/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+const (
+	resync  = 30 * time.Second
+	lockKey = "redis-cluster-lease"
+)
+
+// New will create an operator that is responsible of managing all the required stuff
+// to create redis clusters. listPageSize is the number of objects listed by page.
+func New(k8sService k8s.Services, k8sClient kubernetes.Interface, lockNamespace string, listPageSize int64, kooperMetricsRecorder metrics.Recorder, logger log.Logger) (controller.Controller, error) {
+	rcHandler := NewRedisClusterHandler(k8sService, kooperMetricsRecorder, logger)
+	rcHandler.ListPageSize = listPageSize
+	rcRetriever := NewRedisClusterRetriever(k8sService)
+
+	kooperLogger := kooperlogger{Logger: logger.WithField("operator", "rediscluster")}
+	// Leader election service.
+	leSVC, err := leaderelection.NewDefault(lockKey, lockNamespace, k8sClient, kooperLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create our controller.
+	return controller.New(&controller.Config{
+		Handler:         rcHandler,
+		Retriever:       rcRetriever,
+		LeaderElector:   leSVC,
+		MetricsRecorder: kooperMetricsRecorder,
+		Logger:          kooperLogger,
+		Name:            "rediscluster",
+		ResyncInterval:  resync,
+	})
+}
+
+func NewRedisClusterRetriever(cli k8s.Services) controller.Retriever {
+	return controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return cli.ListRedisClusters(context.Background(), "", options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return cli.WatchRedisClusters(context.Background(), "", options)
+		},
+	})
+}
+
+type kooperlogger struct {
+	log.Logger
+}
+
+func (k kooperlogger) WithKV(kv kooperlog.KV) kooperlog.Logger {
+	return kooperlogger{Logger: k.Logger.WithFields(kv)}
+}
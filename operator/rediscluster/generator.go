@@ -0,0 +1,229 @@
+package rediscluster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+const (
+	redisPort        = 6379
+	redisBusPort     = 16379
+	redisContainer   = "redis"
+	redisConfigDir   = "/redis"
+	redisDataDir     = "/data"
+	redisConfigName  = "redis.conf"
+	configVolumeName = "redis-config"
+	dataVolumeName   = "redis-data"
+
+	componentLabel = "rediscluster"
+	appLabel       = "redis-cluster"
+	// shardLabel holds the index of the shard of the statefulsets and the pods.
+	shardLabel = "redisclusters.databases.spotahome.com/shard"
+)
+
+// redisClusterConfig is the config of every redis of a cluster. nodes.conf is rewritten by redis, it
+// lives with the data.
+var redisClusterConfig = []string{
+	"cluster-enabled yes",
+	"cluster-config-file " + redisDataDir + "/nodes.conf",
+	"cluster-node-timeout 5000",
+	"appendonly yes",
+	"port " + strconv.Itoa(redisPort),
+}
+
+// GetRedisClusterName returns the name of the service and the configmap of the cluster.
+func GetRedisClusterName(rc *redisfailoverv1.RedisCluster) string {
+	return fmt.Sprintf("rc-%s", rc.Name)
+}
+
+// GetShardName returns the name of the statefulset of the shard.
+func GetShardName(rc *redisfailoverv1.RedisCluster, shard int) string {
+	return fmt.Sprintf("rc-%s-%d", rc.Name, shard)
+}
+
+// generateSelectorLabels returns the labels of all the pods of the cluster.
+func generateSelectorLabels(rc *redisfailoverv1.RedisCluster) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      rc.Name,
+		"app.kubernetes.io/component": componentLabel,
+		"app.kubernetes.io/part-of":   appLabel,
+	}
+}
+
+// generateShardLabels returns the labels of the pods of a shard.
+func generateShardLabels(rc *redisfailoverv1.RedisCluster, shard int) map[string]string {
+	labels := generateSelectorLabels(rc)
+	labels[shardLabel] = strconv.Itoa(shard)
+	return labels
+}
+
+func mergeLabels(sets ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, set := range sets {
+		for k, v := range set {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+func generateConfigMap(rc *redisfailoverv1.RedisCluster, labels map[string]string, ownerRefs []metav1.OwnerReference) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            GetRedisClusterName(rc),
+			Namespace:       rc.Namespace,
+			Labels:          mergeLabels(labels, generateSelectorLabels(rc)),
+			OwnerReferences: ownerRefs,
+		},
+		Data: map[string]string{
+			redisConfigName: strings.Join(redisClusterConfig, "\n") + "\n",
+		},
+	}
+}
+
+// generateService returns the headless service the pods of the statefulsets are named with.
+func generateService(rc *redisfailoverv1.RedisCluster, labels map[string]string, ownerRefs []metav1.OwnerReference) *corev1.Service {
+	selector := generateSelectorLabels(rc)
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            GetRedisClusterName(rc),
+			Namespace:       rc.Namespace,
+			Labels:          mergeLabels(labels, selector),
+			OwnerReferences: ownerRefs,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP:                corev1.ClusterIPNone,
+			PublishNotReadyAddresses: true,
+			Selector:                 selector,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "redis",
+					Port:       redisPort,
+					TargetPort: intstr.FromInt(redisPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+				{
+					Name:       "cluster-bus",
+					Port:       redisBusPort,
+					TargetPort: intstr.FromInt(redisBusPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// generateShardStatefulSet returns the statefulset of a shard, its master and the replicas of the master.
+func generateShardStatefulSet(rc *redisfailoverv1.RedisCluster, shard int, labels map[string]string, ownerRefs []metav1.OwnerReference) *appsv1.StatefulSet {
+	name := GetShardName(rc, shard)
+	selector := generateShardLabels(rc, shard)
+	replicas := 1 + rc.Spec.ReplicasPerMaster
+
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       rc.Namespace,
+			Labels:          mergeLabels(labels, selector),
+			OwnerReferences: ownerRefs,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName:         GetRedisClusterName(rc),
+			Replicas:            &replicas,
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.RollingUpdateStatefulSetStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selector,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: mergeLabels(labels, selector),
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            redisContainer,
+							Image:           rc.Image(),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Command: []string{
+								"redis-server",
+								fmt.Sprintf("%s/%s", redisConfigDir, redisConfigName),
+								"--cluster-announce-ip",
+								"$(POD_IP)",
+							},
+							Env: []corev1.EnvVar{
+								{
+									Name: "POD_IP",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{
+											FieldPath: "status.podIP",
+										},
+									},
+								},
+							},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "redis",
+									ContainerPort: redisPort,
+									Protocol:      corev1.ProtocolTCP,
+								},
+								{
+									Name:          "cluster-bus",
+									ContainerPort: redisBusPort,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      configVolumeName,
+									MountPath: redisConfigDir,
+								},
+								{
+									Name:      dataVolumeName,
+									MountPath: redisDataDir,
+								},
+							},
+							ReadinessProbe: &corev1.Probe{
+								InitialDelaySeconds: 5,
+								TimeoutSeconds:      5,
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"redis-cli", "ping"},
+									},
+								},
+							},
+							Resources: rc.Spec.Resources,
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: configVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: GetRedisClusterName(rc),
+									},
+								},
+							},
+						},
+						{
+							Name: dataVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
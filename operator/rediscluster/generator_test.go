@@ -0,0 +1,59 @@
+package rediscluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+func TestGenerateShardStatefulSet(t *testing.T) {
+	assert := assert.New(t)
+	rc := &redisfailoverv1.RedisCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "testns"},
+		Spec: redisfailoverv1.RedisClusterSpec{
+			Masters:           3,
+			ReplicasPerMaster: 2,
+			Version:           "7.0.5",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		},
+	}
+	labels := map[string]string{"app.kubernetes.io/managed-by": "redis-operator"}
+
+	ss := generateShardStatefulSet(rc, 1, labels, nil)
+
+	assert.Equal("rc-test-1", ss.Name)
+	assert.Equal("rc-test", ss.Spec.ServiceName)
+	assert.Equal(int32(3), *ss.Spec.Replicas)
+	assert.Equal(appsv1.ParallelPodManagement, ss.Spec.PodManagementPolicy)
+	assert.Equal("1", ss.Spec.Selector.MatchLabels[shardLabel])
+	assert.Equal("redis-operator", ss.Spec.Template.Labels["app.kubernetes.io/managed-by"])
+
+	redis := ss.Spec.Template.Spec.Containers[0]
+	assert.Equal("redis:7.0.5-alpine", redis.Image)
+	assert.Equal([]string{"redis-server", "/redis/redis.conf", "--cluster-announce-ip", "$(POD_IP)"}, redis.Command)
+	assert.Equal(rc.Spec.Resources, redis.Resources)
+	assert.Equal("rc-test", ss.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+}
+
+func TestGenerateConfigMapAndService(t *testing.T) {
+	assert := assert.New(t)
+	rc := &redisfailoverv1.RedisCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "testns"}}
+
+	cm := generateConfigMap(rc, nil, nil)
+	assert.Contains(cm.Data["redis.conf"], "cluster-enabled yes\n")
+	assert.Contains(cm.Data["redis.conf"], "cluster-config-file /data/nodes.conf\n")
+
+	svc := generateService(rc, nil, nil)
+	assert.Equal(corev1.ClusterIPNone, svc.Spec.ClusterIP)
+	assert.True(svc.Spec.PublishNotReadyAddresses)
+	assert.Len(svc.Spec.Ports, 2)
+	assert.NotContains(svc.Spec.Selector, shardLabel)
+}
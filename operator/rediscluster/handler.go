@@ -0,0 +1,200 @@
+package rediscluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+const (
+	// The objects of the clusters get the labels of the objects of the redisfailovers, the object cache
+	// of the operator watches them and indexes their pods by the name of the cluster.
+	rcLabelManagedByKey = "app.kubernetes.io/managed-by"
+	rcLabelNameKey      = k8s.OwnerNameLabel
+	operatorName        = "redis-operator"
+)
+
+// RedisClusterHandler is the Redis Cluster handler. It creates a statefulset per shard of the cluster and
+// reconciles the topology of the redis cluster with the pods of the statefulsets.
+type RedisClusterHandler struct {
+	k8sservice k8s.Services
+	mClient    metrics.Recorder
+	logger     log.Logger
+	// ListPageSize is the number of statefulsets listed by page, k8s.DefaultListPageSize when it's not set.
+	ListPageSize int64
+}
+
+// NewRedisClusterHandler returns a new RC handler
+func NewRedisClusterHandler(k8sservice k8s.Services, mClient metrics.Recorder, logger log.Logger) *RedisClusterHandler {
+	return &RedisClusterHandler{
+		k8sservice: k8sservice,
+		mClient:    mClient,
+		logger:     logger,
+	}
+}
+
+// Handle will ensure the redis cluster is in the expected state.
+func (r *RedisClusterHandler) Handle(ctx context.Context, obj runtime.Object) error {
+	rc, ok := obj.(*redisfailoverv1.RedisCluster)
+	if !ok {
+		return fmt.Errorf("can't handle the received object: not a rediscluster")
+	}
+
+	if err := rc.Validate(); err != nil {
+		return err
+	}
+
+	labels := map[string]string{
+		rcLabelManagedByKey: operatorName,
+		rcLabelNameKey:      rc.Name,
+	}
+	ownerRefs := []metav1.OwnerReference{
+		*metav1.NewControllerRef(rc, redisfailoverv1.VersionKind(redisfailoverv1.RCKind)),
+	}
+
+	if err := r.k8sservice.CreateOrUpdateConfigMap(ctx, rc.Namespace, generateConfigMap(rc, labels, ownerRefs)); err != nil {
+		return err
+	}
+	if err := r.k8sservice.CreateOrUpdateService(ctx, rc.Namespace, generateService(rc, labels, ownerRefs)); err != nil {
+		return err
+	}
+
+	shards := make([][]corev1.Pod, rc.Spec.Masters)
+	for i := range shards {
+		if err := r.k8sservice.CreateOrUpdateStatefulSet(ctx, rc.Namespace, generateShardStatefulSet(rc, i, labels, ownerRefs)); err != nil {
+			return err
+		}
+		pods, err := r.k8sservice.GetStatefulSetPods(ctx, rc.Namespace, GetShardName(rc, i))
+		if err != nil {
+			return err
+		}
+		shards[i] = sortedPods(pods.Items)
+	}
+
+	status := rc.Status.DeepCopy()
+	status.ObservedGeneration = rc.Generation
+
+	extraShards, err := r.extraShards(ctx, rc)
+	if err != nil {
+		return err
+	}
+	if len(extraShards) > 0 {
+		// The hash slots of the shards would have to be moved before they are removed, they are kept.
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               redisfailoverv1.ConditionTopologyWarning,
+			Status:             metav1.ConditionTrue,
+			Reason:             redisfailoverv1.ReasonMastersDecreased,
+			Message:            fmt.Sprintf("masters can't be decreased, the shards %v are kept", extraShards),
+			ObservedGeneration: rc.Generation,
+		})
+	} else {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionTopologyWarning)
+	}
+
+	nodes, err := r.ensureTopology(ctx, rc.Namespace, shards)
+	if err != nil {
+		return err
+	}
+	if nodes != nil {
+		status.Bootstrapped = status.Bootstrapped || assignedSlots(nodes) == clusterSlots
+		status.Shards = shardStatuses(rc, shards, nodes)
+		info, err := redisCLI{handler: r, namespace: rc.Namespace, pod: shards[0][0].Name}.run(ctx, "cluster", "info")
+		if err != nil {
+			return err
+		}
+		status.State = parseClusterState(info)
+	}
+
+	return r.updateStatus(ctx, rc, status)
+}
+
+// extraShards returns the statefulsets of the shards above the masters of the spec. Only the statefulsets
+// managed by the operator are listed, page by page.
+func (r *RedisClusterHandler) extraShards(ctx context.Context, rc *redisfailoverv1.RedisCluster) ([]string, error) {
+	selector := mergeLabels(generateSelectorLabels(rc), map[string]string{rcLabelManagedByKey: operatorName})
+	opts := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String(), Limit: r.ListPageSize}
+	extra := []string{}
+	err := r.k8sservice.EachStatefulSet(ctx, rc.Namespace, opts, func(s *appsv1.StatefulSet) error {
+		shard, err := strconv.Atoi(s.Labels[shardLabel])
+		if err != nil || shard < int(rc.Spec.Masters) {
+			return nil
+		}
+		extra = append(extra, s.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(extra)
+	return extra, nil
+}
+
+// updateStatus writes the status when it changed.
+func (r *RedisClusterHandler) updateStatus(ctx context.Context, rc *redisfailoverv1.RedisCluster, status *redisfailoverv1.RedisClusterStatus) error {
+	if equality.Semantic.DeepEqual(rc.Status, *status) {
+		return nil
+	}
+	rc = rc.DeepCopy()
+	rc.Status = *status
+	_, err := r.k8sservice.UpdateRedisClusterStatus(ctx, rc.Namespace, rc)
+	return err
+}
+
+// shardStatuses returns the master and the replicas of every shard as the cluster knows them.
+func shardStatuses(rc *redisfailoverv1.RedisCluster, shards [][]corev1.Pod, nodes []clusterNode) []redisfailoverv1.RedisClusterShardStatus {
+	known := knownNodes(nodes)
+	statuses := make([]redisfailoverv1.RedisClusterShardStatus, 0, len(shards))
+	for i, pods := range shards {
+		status := redisfailoverv1.RedisClusterShardStatus{Name: GetShardName(rc, i)}
+		for _, pod := range pods {
+			node, ok := known[pod.Status.PodIP]
+			if !ok {
+				continue
+			}
+			if node.isMaster() {
+				status.Master = pod.Name
+				status.Slots = node.Slots
+			} else {
+				status.Replicas = append(status.Replicas, pod.Name)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// sortedPods returns the pods of a statefulset by their ordinal.
+func sortedPods(pods []corev1.Pod) []corev1.Pod {
+	sorted := append([]corev1.Pod{}, pods...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return podOrdinal(sorted[i]) < podOrdinal(sorted[j])
+	})
+	return sorted
+}
+
+func podOrdinal(pod corev1.Pod) int {
+	for i := len(pod.Name) - 1; i >= 0; i-- {
+		if pod.Name[i] == '-' {
+			ordinal, err := strconv.Atoi(pod.Name[i+1:])
+			if err != nil {
+				return -1
+			}
+			return ordinal
+		}
+	}
+	return -1
+}
@@ -0,0 +1,264 @@
+package rediscluster_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	"redis-operator/operator/rediscluster"
+)
+
+const (
+	name      = "test"
+	namespace = "testns"
+)
+
+func generateRC(masters int32) *redisfailoverv1.RedisCluster {
+	return &redisfailoverv1.RedisCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Generation: 1,
+		},
+		Spec: redisfailoverv1.RedisClusterSpec{
+			Masters:           masters,
+			ReplicasPerMaster: 1,
+		},
+	}
+}
+
+// generateShardPods returns the pods of a shard, the IP of the pod <ordinal> of the shard <shard> is
+// 10.0.<shard>.<ordinal+1>.
+func generateShardPods(shard int, ready bool) *corev1.PodList {
+	pods := &corev1.PodList{}
+	for i := 0; i < 2; i++ {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		pods.Items = append(pods.Items, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("rc-%s-%d-%d", name, shard, i),
+				Namespace: namespace,
+			},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				PodIP:      fmt.Sprintf("10.0.%d.%d", shard, i+1),
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+			},
+		})
+	}
+	return pods
+}
+
+func generateShardStatefulSets(shards int) *appsv1.StatefulSetList {
+	sts := &appsv1.StatefulSetList{}
+	for i := 0; i < shards; i++ {
+		sts.Items = append(sts.Items, appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   rediscluster.GetShardName(generateRC(3), i),
+				Labels: map[string]string{"redisclusters.databases.spotahome.com/shard": fmt.Sprint(i)},
+			},
+		})
+	}
+	return sts
+}
+
+// onShardStatefulSets lists the statefulsets of the shards managed by the operator.
+func onShardStatefulSets(ms *mK8SService.Services, shards int) {
+	managed := mock.MatchedBy(func(opts metav1.ListOptions) bool {
+		return strings.Contains(opts.LabelSelector, "app.kubernetes.io/managed-by=redis-operator")
+	})
+	ms.On("EachStatefulSet", mock.Anything, namespace, managed, mock.Anything).Once().Run(func(args mock.Arguments) {
+		fn := args.Get(3).(func(*appsv1.StatefulSet) error)
+		for _, s := range generateShardStatefulSets(shards).Items {
+			s := s
+			_ = fn(&s)
+		}
+	}).Return(nil)
+}
+
+func redisCLI(args ...string) []string {
+	return append([]string{"redis-cli"}, args...)
+}
+
+func TestRedisClusterHandlerBootstrap(t *testing.T) {
+	assert := assert.New(t)
+	rc := generateRC(3)
+
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("CreateOrUpdateService", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("CreateOrUpdateStatefulSet", mock.Anything, namespace, mock.Anything).Times(3).Return(nil)
+	for i := 0; i < 3; i++ {
+		ms.On("GetStatefulSetPods", mock.Anything, namespace, rediscluster.GetShardName(rc, i)).Once().Return(generateShardPods(i, true), nil)
+	}
+	onShardStatefulSets(ms, 3)
+
+	entry := "rc-test-0-0"
+	masters := `a0 10.0.0.1:6379@16379 myself,master - 0 0 1 connected 0-5460
+a1 10.0.1.1:6379@16379 master - 0 0 2 connected 5461-10922
+a2 10.0.2.1:6379@16379 master - 0 0 3 connected 10923-16383
+`
+	replicas := `r0 10.0.0.2:6379@16379 slave a0 0 0 1 connected
+r1 10.0.1.2:6379@16379 slave a1 0 0 2 connected
+r2 10.0.2.2:6379@16379 slave a2 0 0 3 connected
+`
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("cluster", "nodes")).Once().Return("e0 10.0.0.1:6379@16379 myself,master - 0 0 0 connected\n", nil)
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("--cluster", "create", "10.0.0.1:6379", "10.0.1.1:6379", "10.0.2.1:6379", "--cluster-yes")).Once().Return("", nil)
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("cluster", "nodes")).Once().Return(masters, nil)
+	for i := 0; i < 3; i++ {
+		ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("--cluster", "add-node", fmt.Sprintf("10.0.%d.2:6379", i), "10.0.0.1:6379", "--cluster-slave", "--cluster-master-id", fmt.Sprintf("a%d", i))).Once().Return("", nil)
+	}
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("cluster", "nodes")).Once().Return(masters+replicas, nil)
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("cluster", "info")).Once().Return("cluster_state:ok\r\n", nil)
+
+	var status redisfailoverv1.RedisClusterStatus
+	ms.On("UpdateRedisClusterStatus", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+		status = args.Get(2).(*redisfailoverv1.RedisCluster).Status
+	}).Return(nil, nil)
+
+	handler := rediscluster.NewRedisClusterHandler(ms, metrics.Dummy, log.DummyLogger{})
+	err := handler.Handle(context.TODO(), rc)
+	assert.NoError(err)
+	ms.AssertExpectations(t)
+
+	assert.True(status.Bootstrapped)
+	assert.Equal("ok", status.State)
+	assert.Equal(int64(1), status.ObservedGeneration)
+	assert.Equal([]redisfailoverv1.RedisClusterShardStatus{
+		{Name: "rc-test-0", Master: "rc-test-0-0", Replicas: []string{"rc-test-0-1"}, Slots: 5461},
+		{Name: "rc-test-1", Master: "rc-test-1-0", Replicas: []string{"rc-test-1-1"}, Slots: 5462},
+		{Name: "rc-test-2", Master: "rc-test-2-0", Replicas: []string{"rc-test-2-1"}, Slots: 5461},
+	}, status.Shards)
+	assert.Nil(meta.FindStatusCondition(status.Conditions, redisfailoverv1.ConditionTopologyWarning))
+}
+
+func TestRedisClusterHandlerAddShard(t *testing.T) {
+	assert := assert.New(t)
+	rc := generateRC(4)
+	rc.Status.Bootstrapped = true
+
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("CreateOrUpdateService", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("CreateOrUpdateStatefulSet", mock.Anything, namespace, mock.Anything).Times(4).Return(nil)
+	for i := 0; i < 4; i++ {
+		ms.On("GetStatefulSetPods", mock.Anything, namespace, rediscluster.GetShardName(rc, i)).Once().Return(generateShardPods(i, true), nil)
+	}
+	onShardStatefulSets(ms, 4)
+
+	entry := "rc-test-0-0"
+	nodes := `a0 10.0.0.1:6379@16379 myself,master - 0 0 1 connected 0-5460
+a1 10.0.1.1:6379@16379 master - 0 0 2 connected 5461-10922
+a2 10.0.2.1:6379@16379 master - 0 0 3 connected 10923-16383
+r0 10.0.0.2:6379@16379 slave a0 0 0 1 connected
+r1 10.0.1.2:6379@16379 slave a1 0 0 2 connected
+r2 10.0.2.2:6379@16379 slave a2 0 0 3 connected
+`
+	rebalanced := strings.Replace(nodes, "0-5460", "1365-5460", 1) + "a3 10.0.3.1:6379@16379 master - 0 0 4 connected 0-1364\n"
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("cluster", "nodes")).Once().Return(nodes, nil)
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("--cluster", "add-node", "10.0.3.1:6379", "10.0.0.1:6379")).Once().Return("", nil)
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("--cluster", "rebalance", "10.0.0.1:6379", "--cluster-use-empty-masters", "--cluster-yes")).Once().Return("", nil)
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("cluster", "nodes")).Once().Return(rebalanced, nil)
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("--cluster", "add-node", "10.0.3.2:6379", "10.0.0.1:6379", "--cluster-slave", "--cluster-master-id", "a3")).Once().Return("", nil)
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("cluster", "nodes")).Once().Return(rebalanced+"r3 10.0.3.2:6379@16379 slave a3 0 0 4 connected\n", nil)
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("cluster", "info")).Once().Return("cluster_state:ok\r\n", nil)
+
+	var status redisfailoverv1.RedisClusterStatus
+	ms.On("UpdateRedisClusterStatus", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+		status = args.Get(2).(*redisfailoverv1.RedisCluster).Status
+	}).Return(nil, nil)
+
+	handler := rediscluster.NewRedisClusterHandler(ms, metrics.Dummy, log.DummyLogger{})
+	err := handler.Handle(context.TODO(), rc)
+	assert.NoError(err)
+	ms.AssertExpectations(t)
+
+	assert.Len(status.Shards, 4)
+	assert.Equal(redisfailoverv1.RedisClusterShardStatus{Name: "rc-test-3", Master: "rc-test-3-0", Replicas: []string{"rc-test-3-1"}, Slots: 1365}, status.Shards[3])
+}
+
+func TestRedisClusterHandlerForgetsGoneNodes(t *testing.T) {
+	assert := assert.New(t)
+	rc := generateRC(3)
+	rc.Status.Bootstrapped = true
+
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("CreateOrUpdateService", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("CreateOrUpdateStatefulSet", mock.Anything, namespace, mock.Anything).Times(3).Return(nil)
+	for i := 0; i < 3; i++ {
+		ms.On("GetStatefulSetPods", mock.Anything, namespace, rediscluster.GetShardName(rc, i)).Once().Return(generateShardPods(i, true), nil)
+	}
+	onShardStatefulSets(ms, 3)
+
+	// The replica of the shard 2 was recreated with a new address, its old node failed.
+	entry := "rc-test-0-0"
+	nodes := `a0 10.0.0.1:6379@16379 myself,master - 0 0 1 connected 0-5460
+a1 10.0.1.1:6379@16379 master - 0 0 2 connected 5461-10922
+a2 10.0.2.1:6379@16379 master - 0 0 3 connected 10923-16383
+r0 10.0.0.2:6379@16379 slave a0 0 0 1 connected
+r1 10.0.1.2:6379@16379 slave a1 0 0 2 connected
+old 10.0.9.9:6379@16379 slave,fail a2 0 0 3 disconnected
+`
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("cluster", "nodes")).Once().Return(nodes, nil)
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("--cluster", "add-node", "10.0.2.2:6379", "10.0.0.1:6379", "--cluster-slave", "--cluster-master-id", "a2")).Once().Return("", nil)
+	for _, ip := range []string{"10.0.0.1", "10.0.1.1", "10.0.2.1", "10.0.0.2", "10.0.1.2"} {
+		ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("-h", ip, "-p", "6379", "cluster", "forget", "old")).Once().Return("OK", nil)
+	}
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("cluster", "nodes")).Once().Return(strings.Replace(nodes, "old 10.0.9.9:6379@16379 slave,fail", "r2 10.0.2.2:6379@16379 slave", 1), nil)
+	ms.On("ExecPod", mock.Anything, namespace, entry, "redis", redisCLI("cluster", "info")).Once().Return("cluster_state:ok\r\n", nil)
+	ms.On("UpdateRedisClusterStatus", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+
+	handler := rediscluster.NewRedisClusterHandler(ms, metrics.Dummy, log.DummyLogger{})
+	err := handler.Handle(context.TODO(), rc)
+	assert.NoError(err)
+	ms.AssertExpectations(t)
+}
+
+func TestRedisClusterHandlerMastersDecreased(t *testing.T) {
+	assert := assert.New(t)
+	rc := generateRC(3)
+
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("CreateOrUpdateService", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("CreateOrUpdateStatefulSet", mock.Anything, namespace, mock.Anything).Times(3).Return(nil)
+	for i := 0; i < 3; i++ {
+		ms.On("GetStatefulSetPods", mock.Anything, namespace, rediscluster.GetShardName(rc, i)).Once().Return(generateShardPods(i, false), nil)
+	}
+	onShardStatefulSets(ms, 4)
+
+	var status redisfailoverv1.RedisClusterStatus
+	ms.On("UpdateRedisClusterStatus", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+		status = args.Get(2).(*redisfailoverv1.RedisCluster).Status
+	}).Return(nil, nil)
+
+	handler := rediscluster.NewRedisClusterHandler(ms, metrics.Dummy, log.DummyLogger{})
+	err := handler.Handle(context.TODO(), rc)
+	assert.NoError(err)
+	ms.AssertExpectations(t)
+	ms.AssertNotCalled(t, "DeleteStatefulSet", mock.Anything, mock.Anything, mock.Anything)
+	ms.AssertNotCalled(t, "ExecPod", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	cond := meta.FindStatusCondition(status.Conditions, redisfailoverv1.ConditionTopologyWarning)
+	if assert.NotNil(cond) {
+		assert.Equal(metav1.ConditionTrue, cond.Status)
+		assert.Equal(redisfailoverv1.ReasonMastersDecreased, cond.Reason)
+		assert.Contains(cond.Message, "rc-test-3")
+	}
+	assert.False(status.Bootstrapped)
+}
@@ -0,0 +1,280 @@
+package rediscluster
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"redis-operator/service/k8s"
+)
+
+// clusterSlots is the number of hash slots of a redis cluster.
+const clusterSlots = 16384
+
+// clusterNode is a node of the cluster as reported by CLUSTER NODES.
+type clusterNode struct {
+	ID       string
+	IP       string
+	Flags    []string
+	MasterID string
+	Slots    int32
+}
+
+func (n clusterNode) hasFlag(flag string) bool {
+	for _, f := range n.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func (n clusterNode) isMaster() bool {
+	return n.hasFlag("master")
+}
+
+// failed tells the nodes the cluster can't reach anymore, they are forgotten once their pod is gone.
+func (n clusterNode) failed() bool {
+	return n.hasFlag("fail") || n.hasFlag("noaddr") || n.IP == ""
+}
+
+// parseClusterNodes parses the output of CLUSTER NODES, a node per line:
+// <id> <ip:port@cport[,hostname]> <flags> <master> <ping-sent> <pong-recv> <config-epoch> <link-state> <slot> <slot> ...
+func parseClusterNodes(out string) ([]clusterNode, error) {
+	nodes := []clusterNode{}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("invalid cluster node %q", line)
+		}
+		node := clusterNode{
+			ID:    fields[0],
+			Flags: strings.Split(fields[2], ","),
+		}
+		if fields[3] != "-" {
+			node.MasterID = fields[3]
+		}
+		addr := strings.SplitN(fields[1], "@", 2)[0]
+		if i := strings.LastIndex(addr, ":"); i > 0 {
+			node.IP = addr[:i]
+		}
+		for _, slot := range fields[8:] {
+			// The slots being imported or migrated, [slot->-id] and [slot-<-id], are counted by their owner.
+			if strings.HasPrefix(slot, "[") {
+				continue
+			}
+			bounds := strings.SplitN(slot, "-", 2)
+			start, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid slots %q of the cluster node %s", slot, node.ID)
+			}
+			end := start
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid slots %q of the cluster node %s", slot, node.ID)
+				}
+			}
+			node.Slots += int32(end - start + 1)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// parseClusterState returns the cluster_state of the output of CLUSTER INFO.
+func parseClusterState(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if state := strings.TrimPrefix(strings.TrimSpace(line), "cluster_state:"); state != strings.TrimSpace(line) {
+			return state
+		}
+	}
+	return ""
+}
+
+// assignedSlots returns the hash slots served by the nodes.
+func assignedSlots(nodes []clusterNode) int32 {
+	var slots int32
+	for _, node := range nodes {
+		slots += node.Slots
+	}
+	return slots
+}
+
+func redisAddress(ip string) string {
+	return fmt.Sprintf("%s:%d", ip, redisPort)
+}
+
+// isPodReady tells the pods redis-cli can add to the cluster, running, ready and with an address.
+func isPodReady(pod corev1.Pod) bool {
+	return pod.DeletionTimestamp == nil && pod.Status.PodIP != "" && pod.Status.Phase == corev1.PodRunning && k8s.IsPodReady(&pod)
+}
+
+// redisCLI runs redis-cli in the redis container of a pod of the cluster.
+type redisCLI struct {
+	handler   *RedisClusterHandler
+	namespace string
+	pod       string
+}
+
+func (c redisCLI) run(ctx context.Context, args ...string) (string, error) {
+	out, err := c.handler.k8sservice.ExecPod(ctx, c.namespace, c.pod, redisContainer, append([]string{"redis-cli"}, args...))
+	if err != nil {
+		return "", fmt.Errorf("redis-cli %s in %s/%s: %w", strings.Join(args, " "), c.namespace, c.pod, err)
+	}
+	return out, nil
+}
+
+func (c redisCLI) clusterNodes(ctx context.Context) ([]clusterNode, error) {
+	out, err := c.run(ctx, "cluster", "nodes")
+	if err != nil {
+		return nil, err
+	}
+	return parseClusterNodes(out)
+}
+
+// ensureTopology makes the shards the topology of the cluster. The cluster is created with the first pod
+// of every shard as its masters, the shards added later get a master which the hash slots are rebalanced
+// to, the pods missing from the cluster are added as replicas of the master of their shard, and the
+// failed nodes no pod has the address of anymore are forgotten. Nothing is done until all the pods are
+// ready, then nil nodes are returned.
+func (r *RedisClusterHandler) ensureTopology(ctx context.Context, namespace string, shards [][]corev1.Pod) ([]clusterNode, error) {
+	podIPs := map[string]bool{}
+	for _, pods := range shards {
+		if len(pods) == 0 {
+			return nil, nil
+		}
+		for _, pod := range pods {
+			if !isPodReady(pod) {
+				return nil, nil
+			}
+			podIPs[pod.Status.PodIP] = true
+		}
+	}
+
+	entry := shards[0][0]
+	entryAddress := redisAddress(entry.Status.PodIP)
+	cli := redisCLI{handler: r, namespace: namespace, pod: entry.Name}
+
+	nodes, err := cli.clusterNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the cluster, the hash slots are split across the first pod of every shard.
+	if assignedSlots(nodes) == 0 {
+		args := []string{"--cluster", "create"}
+		for _, pods := range shards {
+			args = append(args, redisAddress(pods[0].Status.PodIP))
+		}
+		args = append(args, "--cluster-yes")
+		r.logger.WithField("namespace", namespace).Infof("creating the redis cluster with %d masters", len(shards))
+		if _, err := cli.run(ctx, args...); err != nil {
+			return nil, err
+		}
+		if nodes, err = cli.clusterNodes(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Add a master to the new shards and move them their share of the hash slots.
+	known := knownNodes(nodes)
+	addedMasters := false
+	for _, pods := range shards {
+		if anyKnown(pods, known) {
+			continue
+		}
+		r.logger.WithField("namespace", namespace).WithField("pod", pods[0].Name).Infof("adding a master to the redis cluster")
+		if _, err := cli.run(ctx, "--cluster", "add-node", redisAddress(pods[0].Status.PodIP), entryAddress); err != nil {
+			return nil, err
+		}
+		addedMasters = true
+	}
+	if addedMasters {
+		if _, err := cli.run(ctx, "--cluster", "rebalance", entryAddress, "--cluster-use-empty-masters", "--cluster-yes"); err != nil {
+			return nil, err
+		}
+		if nodes, err = cli.clusterNodes(ctx); err != nil {
+			return nil, err
+		}
+		known = knownNodes(nodes)
+	}
+
+	// Add the pods missing from the cluster as replicas of the master of their shard.
+	addedReplicas := false
+	for _, pods := range shards {
+		master := shardMaster(pods, known)
+		if master == nil {
+			continue
+		}
+		for _, pod := range pods {
+			if _, ok := known[pod.Status.PodIP]; ok {
+				continue
+			}
+			r.logger.WithField("namespace", namespace).WithField("pod", pod.Name).Infof("adding a replica of %s to the redis cluster", master.ID)
+			if _, err := cli.run(ctx, "--cluster", "add-node", redisAddress(pod.Status.PodIP), entryAddress, "--cluster-slave", "--cluster-master-id", master.ID); err != nil {
+				return nil, err
+			}
+			addedReplicas = true
+		}
+	}
+
+	// Forget the failed nodes of the pods that are gone, every node of the cluster has to forget them.
+	forgot := false
+	for _, node := range nodes {
+		if !node.failed() || podIPs[node.IP] {
+			continue
+		}
+		r.logger.WithField("namespace", namespace).Infof("forgetting the failed redis cluster node %s", node.ID)
+		for _, live := range nodes {
+			if live.failed() || live.ID == node.ID {
+				continue
+			}
+			if _, err := cli.run(ctx, "-h", live.IP, "-p", strconv.Itoa(redisPort), "cluster", "forget", node.ID); err != nil {
+				return nil, err
+			}
+		}
+		forgot = true
+	}
+
+	if addedReplicas || forgot {
+		return cli.clusterNodes(ctx)
+	}
+	return nodes, nil
+}
+
+// knownNodes returns the nodes of the cluster that are not failed by their address.
+func knownNodes(nodes []clusterNode) map[string]clusterNode {
+	known := map[string]clusterNode{}
+	for _, node := range nodes {
+		if !node.failed() {
+			known[node.IP] = node
+		}
+	}
+	return known
+}
+
+// shardMaster returns the master node of the pods of a shard, nil when none of them is a master.
+func shardMaster(pods []corev1.Pod, known map[string]clusterNode) *clusterNode {
+	for _, pod := range pods {
+		if node, ok := known[pod.Status.PodIP]; ok && node.isMaster() {
+			return &node
+		}
+	}
+	return nil
+}
+
+func anyKnown(pods []corev1.Pod, known map[string]clusterNode) bool {
+	for _, pod := range pods {
+		if _, ok := known[pod.Status.PodIP]; ok {
+			return true
+		}
+	}
+	return false
+}
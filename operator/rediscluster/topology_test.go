@@ -0,0 +1,45 @@
+package rediscluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseClusterNodes(t *testing.T) {
+	out := `e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 10.0.0.1:6379@16379 myself,master - 0 0 1 connected 0-5460
+67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 10.0.0.2:6379@16379,rc-test-1-0 master - 0 1426238316232 2 connected 5461-10921 [10922->-292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f]
+292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 10.0.0.3:6379@16379 master - 0 1426238318243 3 connected 10922-16383
+07c37dfeb235213a872192d90877d0cd55635b91 10.0.0.4:6379@16379 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 1 connected
+6ec23923021cf3ffec47632106199cb7f496ce01 :0@0 slave,fail,noaddr 292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 1426238317741 1426238316232 3 disconnected
+`
+	nodes, err := parseClusterNodes(out)
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 5)
+
+	assert.Equal(t, "10.0.0.1", nodes[0].IP)
+	assert.True(t, nodes[0].isMaster())
+	assert.Equal(t, int32(5461), nodes[0].Slots)
+	assert.Equal(t, "10.0.0.2", nodes[1].IP)
+	assert.Equal(t, int32(5461), nodes[1].Slots, "the slots being migrated are counted by their owner")
+	assert.Equal(t, int32(clusterSlots), assignedSlots(nodes))
+
+	assert.False(t, nodes[3].isMaster())
+	assert.Equal(t, "e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca", nodes[3].MasterID)
+	assert.False(t, nodes[3].failed())
+	assert.True(t, nodes[4].failed())
+	assert.Equal(t, "", nodes[4].IP)
+}
+
+func TestParseClusterNodesInvalid(t *testing.T) {
+	_, err := parseClusterNodes("e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 10.0.0.1:6379@16379 master - 0 0 1 connected a-b")
+	assert.Error(t, err)
+	_, err = parseClusterNodes("e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 10.0.0.1:6379@16379")
+	assert.Error(t, err)
+}
+
+func TestParseClusterState(t *testing.T) {
+	assert.Equal(t, "ok", parseClusterState("cluster_enabled:1\r\ncluster_state:ok\r\ncluster_slots_assigned:16384\r\n"))
+	assert.Equal(t, "fail", parseClusterState("cluster_state:fail\n"))
+	assert.Equal(t, "", parseClusterState(""))
+}
@@ -0,0 +1,195 @@
+package redisfailover
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/redis"
+)
+
+// aclFailure is why a redis pod can't load the ACL file, reported on the ACLLoadFailed condition.
+type aclFailure struct {
+	reason  string
+	message string
+}
+
+// aclLoad is the last ACL file a redis pod loaded, by the hash of its content, and its failure.
+type aclLoad struct {
+	hash    string
+	failure *aclFailure
+}
+
+// ACLLoads keeps, for every RF, the ACL file loaded by each redis pod, so it's only loaded again when
+// its ConfigMap changes.
+type ACLLoads struct {
+	mu    sync.Mutex
+	loads map[string]map[string]aclLoad
+}
+
+// NewACLLoads returns new ACL loads.
+func NewACLLoads() *ACLLoads {
+	return &ACLLoads{
+		loads: map[string]map[string]aclLoad{},
+	}
+}
+
+// Loaded returns the hash of the ACL file the pod of the RF loaded, empty when it loaded none.
+func (a *ACLLoads) Loaded(key, pod string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.loads[key][pod].hash
+}
+
+// Record records the ACL file the pod of the RF loaded, with its failure when redis refused it.
+func (a *ACLLoads) Record(key, pod, hash string, failure *aclFailure) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.loads[key] == nil {
+		a.loads[key] = map[string]aclLoad{}
+	}
+	a.loads[key][pod] = aclLoad{hash: hash, failure: failure}
+}
+
+// Prune forgets the pods of the RF that are not running anymore, a new pod loads the file on startup.
+func (a *ACLLoads) Prune(key string, running map[string]bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for pod := range a.loads[key] {
+		if !running[pod] {
+			delete(a.loads[key], pod)
+		}
+	}
+}
+
+// Failure returns the failure of the first pod of the RF, by name, that can't load the ACL file, nil
+// when they all loaded it.
+func (a *ACLLoads) Failure(key string) *aclFailure {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	pods := make([]string, 0, len(a.loads[key]))
+	for pod := range a.loads[key] {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+	for _, pod := range pods {
+		if failure := a.loads[key][pod].failure; failure != nil {
+			return failure
+		}
+	}
+	return nil
+}
+
+// Forget forgets the ACL files loaded by the pods of the RF.
+func (a *ACLLoads) Forget(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.loads, key)
+}
+
+// planRedisACLLoad plans loading the ACL file of auth.aclConfigMapRef with ACL LOAD on the running redis
+// pods that have not loaded its current content, instead of restarting them.
+func (r *RedisFailoverHandler) planRedisACLLoad(ctx context.Context, rf *redisfailoverv1.RedisFailover) ([]rfservice.PodAction, error) {
+	key := rfKey(rf)
+	if !rf.ACLEnabled() {
+		r.aclLoads.Forget(key)
+		return nil, nil
+	}
+
+	name := rf.Spec.Auth.ACLConfigMapRef.Name
+	cm, err := r.k8sservice.GetConfigMap(ctx, rf.Namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	acl := cm.Data[redisfailoverv1.ACLFileKey]
+	sum := sha256.Sum256([]byte(acl))
+	hash := hex.EncodeToString(sum[:])
+	users := rfservice.ParseACLUsers(acl)
+
+	pods, err := r.k8sservice.GetStatefulSetPods(ctx, rf.Namespace, rfservice.GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
+	}
+	actions := []rfservice.PodAction{}
+	running := map[string]bool{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil || pod.Status.PodIP == "" {
+			continue
+		}
+		running[pod.Name] = true
+		if r.aclLoads.Loaded(key, pod.Name) == hash {
+			continue
+		}
+		podName, ip := pod.Name, rf.PreferredPodIP(pod.Status)
+		actions = append(actions, rfservice.PodAction{
+			Pod:    podName,
+			Kind:   rfservice.PodActionLoadACL,
+			Reason: fmt.Sprintf("load the ACL file of the ConfigMap %s", name),
+			Apply: func() error {
+				r.loadRedisACL(ctx, rf, podName, ip, acl, hash, users)
+				return nil
+			},
+		})
+	}
+	r.aclLoads.Prune(key, running)
+	return actions, nil
+}
+
+// loadRedisACL loads the ACL file on a redis pod and records the result. The file not propagated yet by
+// the kubelet, or not read by a pod still on the previous configuration, is loaded again on the next
+// check. The file redis can't parse is reported with its offending line and not loaded again until it
+// changes, redis keeps its previous users meanwhile.
+func (r *RedisFailoverHandler) loadRedisACL(ctx context.Context, rf *redisfailoverv1.RedisFailover, pod, ip, acl, hash string, users []string) {
+	key := rfKey(rf)
+	name := rf.Spec.Auth.ACLConfigMapRef.Name
+	logger := r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).WithField("pod", pod)
+
+	err := r.rfHealer.LoadRedisACL(ctx, rf, ip, users)
+	switch {
+	case err == nil:
+		logger.Infof("Loaded the ACL file of the ConfigMap %s", name)
+		r.aclLoads.Record(key, pod, hash, nil)
+	case errors.Is(err, rfservice.ErrACLNotPropagated):
+		logger.Debugf("%s, loading it again on the next check", err)
+	case errors.Is(err, redis.ErrUnsupported):
+		r.aclLoads.Record(key, pod, hash, &aclFailure{
+			reason:  redisfailoverv1.ReasonACLUnsupported,
+			message: fmt.Sprintf("redis %s can't load the ACL file of the ConfigMap %s: %s", pod, name, err),
+		})
+	default:
+		number, line, ok := rfservice.ACLErrorLine(err, acl)
+		if !ok {
+			logger.Warnf("could not load the ACL file of the ConfigMap %s, loading it again on the next check: %s", name, err)
+			return
+		}
+		r.aclLoads.Record(key, pod, hash, &aclFailure{
+			reason:  redisfailoverv1.ReasonACLInvalid,
+			message: fmt.Sprintf("redis %s can't parse line %d of %s in the ConfigMap %s, %q, it keeps its previous users: %s", pod, number, redisfailoverv1.ACLFileKey, name, line, err),
+		})
+	}
+}
+
+// setACLCondition sets the ACLLoadFailed condition from the failure of the ACL loads of the RF.
+func setACLCondition(status *redisfailoverv1.RedisFailoverStatus, failure *aclFailure, generation int64) {
+	if failure == nil {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionACLLoadFailed)
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionACLLoadFailed,
+		Status:             metav1.ConditionTrue,
+		Reason:             failure.reason,
+		Message:            failure.message,
+		ObservedGeneration: generation,
+	})
+}
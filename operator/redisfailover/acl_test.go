@@ -0,0 +1,131 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/redis"
+)
+
+func generateACLConfigMap(acl string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "redis-acl", Namespace: namespace},
+		Data:       map[string]string{redisfailoverv1.ACLFileKey: acl},
+	}
+}
+
+func TestCheckAndHealLoadsRedisACL(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	rf.Spec.Auth.ACLConfigMapRef = &corev1.LocalObjectReference{Name: "redis-acl"}
+	master := "0.0.0.0"
+	pods := &corev1.PodList{Items: []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"},
+			Status:     corev1.PodStatus{PodIP: master, Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"},
+			Status:     corev1.PodStatus{PodIP: "0.0.0.1", Phase: corev1.PodRunning},
+		},
+	}}
+	users := []string{"default", "pinger", "app"}
+	validACL := "user default on >pass ~* &* +@all\nuser pinger on >pingpass -@all +ping\nuser app on >secret ~app:* +@read"
+	brokenACL := "user default on >pass ~* &* +@all\nuser pinger on >pingpass -@all +ping +@wrong\nuser app on >secret ~app:* +@read"
+	fixedACL := validACL + " +@write"
+
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("CheckRedisNumber", mock.Anything, rf).Return(nil)
+	mrfc.On("CheckSentinelNumber", mock.Anything, rf).Return(nil)
+	mrfc.On("GetNumberMasters", mock.Anything, rf).Return(1, nil)
+	mrfc.On("GetMasterIP", mock.Anything, rf).Return(master, nil)
+	mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, rf).Return(nil)
+	// The replica is not ready, so no update is planned.
+	mrfc.On("GetRedisesIPs", mock.Anything, rf).Return([]string{master, "0.0.0.1"}, nil)
+	mrfc.On("CheckRedisSlavesReady", mock.Anything, "0.0.0.1", rf).Return(false, nil)
+	mrfc.On("GetSentinelsIPs", mock.Anything, rf).Return([]string{}, nil)
+	mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Return(map[string][]string{}, nil)
+	mrfc.On("MeasureRedisLatency", mock.Anything, rf).Return([]rfservice.RedisLatency{}, nil)
+	mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Return([]rfservice.RedisKeyspace{}, nil)
+	mrfc.On("GetDataDirMismatches", mock.Anything, rf).Return([]rfservice.DataDirMismatch{}, nil)
+
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Return(nil, nil)
+	mrfh.On("ClearSyncSlotQueue", rf)
+	mrfh.On("GetSyncSlotQueue", rf).Return([]string{})
+	mrfh.On("PlanRoleLabels", mock.Anything, master, rf).Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanStuckReplicas", mock.Anything, master, rf).Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Return(configActions("rfr-test-0", "rfr-test-1"), nil)
+	mrfh.On("PlanRedisInPlaceRestarts", mock.Anything, master, rf).Return([]rfservice.PodAction{}, nil)
+
+	mk := &mK8SService.Services{}
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Return(pods, nil)
+	mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Return(&appsv1.StatefulSet{}, nil)
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Return(&corev1.PodList{}, nil)
+	mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.Anything).Run(func(args mock.Arguments) {
+		rf.Status = args.Get(2).(*redisfailoverv1.RedisFailover).Status
+	}).Return(rf, nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	check := func(acl string) *metav1.Condition {
+		mk.On("GetConfigMap", mock.Anything, namespace, "redis-acl").Once().Return(generateACLConfigMap(acl), nil)
+		assert.NoError(handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{}))
+		assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+		return meta.FindStatusCondition(rf.Status.Conditions, redisfailoverv1.ConditionACLLoadFailed)
+	}
+
+	// The kubelet has not updated the file of the replica yet, it's loaded again on the next check.
+	mrfh.On("LoadRedisACL", mock.Anything, rf, master, users).Once().Return(nil)
+	mrfh.On("LoadRedisACL", mock.Anything, rf, "0.0.0.1", users).Once().Return(rfservice.ErrACLNotPropagated)
+	assert.Nil(check(validACL))
+	mrfh.On("LoadRedisACL", mock.Anything, rf, "0.0.0.1", users).Once().Return(nil)
+	assert.Nil(check(validACL))
+	// The loaded file is not loaded again.
+	assert.Nil(check(validACL))
+	mrfh.AssertNumberOfCalls(t, "LoadRedisACL", 3)
+
+	// The broken file is reported with its offending line, and not loaded again until it changes.
+	parseErr := errors.New("ERR /redis-acl/users.acl:2: Error in applying operation '+@wrong': Unknown command or category name in ACL. ")
+	mrfh.On("LoadRedisACL", mock.Anything, rf, master, users).Once().Return(parseErr)
+	mrfh.On("LoadRedisACL", mock.Anything, rf, "0.0.0.1", users).Once().Return(parseErr)
+	condition := check(brokenACL)
+	if assert.NotNil(condition) {
+		assert.Equal(metav1.ConditionTrue, condition.Status)
+		assert.Equal(redisfailoverv1.ReasonACLInvalid, condition.Reason)
+		assert.Contains(condition.Message, `redis rfr-test-0 can't parse line 2 of users.acl in the ConfigMap redis-acl, "user pinger on >pingpass -@all +ping +@wrong"`)
+	}
+	assert.NotNil(check(brokenACL))
+	mrfh.AssertNumberOfCalls(t, "LoadRedisACL", 5)
+
+	// The fixed file clears the condition.
+	mrfh.On("LoadRedisACL", mock.Anything, rf, master, users).Once().Return(nil)
+	mrfh.On("LoadRedisACL", mock.Anything, rf, "0.0.0.1", users).Once().Return(nil)
+	assert.Nil(check(fixedACL))
+
+	// Redis 5 has no ACLs.
+	mrfh.On("LoadRedisACL", mock.Anything, rf, master, users).Once().Return(redis.ErrUnsupported)
+	mrfh.On("LoadRedisACL", mock.Anything, rf, "0.0.0.1", users).Once().Return(redis.ErrUnsupported)
+	condition = check(validACL)
+	if assert.NotNil(condition) {
+		assert.Equal(redisfailoverv1.ReasonACLUnsupported, condition.Reason)
+	}
+
+	mrfh.AssertExpectations(t)
+	mk.AssertExpectations(t)
+}
@@ -0,0 +1,73 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// The reasons of the events warning about the annotations of a RF.
+const (
+	unknownAnnotationReason = "UnknownAnnotation"
+	invalidAnnotationReason = "InvalidAnnotation"
+)
+
+// annotationWarnings are the annotations of the RFs already warned about since the operator started.
+type annotationWarnings struct {
+	mu     sync.Mutex
+	warned map[string]bool
+}
+
+func newAnnotationWarnings() *annotationWarnings {
+	return &annotationWarnings{warned: map[string]bool{}}
+}
+
+// warn returns true when the warning was not sent yet, and marks it as sent.
+func (a *annotationWarnings) warn(key string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.warned[key] {
+		return false
+	}
+	a.warned[key] = true
+	return true
+}
+
+// unwarn lets the warning be sent again, when it could not be sent.
+func (a *annotationWarnings) unwarn(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.warned, key)
+}
+
+// CheckAnnotations warns with an event about the annotations of the RF under the prefix of the operator
+// that it doesn't recognize, a typo would silently do nothing, naming the closest known annotation. The
+// known annotations whose value can't be used are warned about too. Every annotation, and every value of
+// the invalid ones, is warned about once since the operator started. A failure sending the event is only
+// logged, it's sent again on the next reconcile.
+func (r *RedisFailoverHandler) CheckAnnotations(ctx context.Context, rf *redisfailoverv1.RedisFailover) {
+	for _, annotation := range rf.UnknownAnnotations() {
+		message := fmt.Sprintf("unknown annotation %s, did you mean %s?", annotation, redisfailoverv1.SuggestAnnotation(annotation))
+		r.warnAnnotation(ctx, rf, rfKey(rf)+"/"+annotation, unknownAnnotationReason, message)
+	}
+	for _, err := range rf.InvalidAnnotations() {
+		r.warnAnnotation(ctx, rf, rfKey(rf)+"/"+err.Error(), invalidAnnotationReason, err.Error())
+	}
+}
+
+// warnAnnotation sends the warning about an annotation of the RF unless it was already sent.
+func (r *RedisFailoverHandler) warnAnnotation(ctx context.Context, rf *redisfailoverv1.RedisFailover, key, reason, message string) {
+	if !r.annotationWarnings.warn(key) {
+		return
+	}
+	event := newRFEvent(rfObjectReference(rf), corev1.EventTypeWarning, reason, message, time.Now())
+	if err := r.k8sservice.CreateEvent(ctx, rf.Namespace, event); err != nil {
+		r.annotationWarnings.unwarn(key)
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not warn about the annotations: %s", err)
+	}
+}
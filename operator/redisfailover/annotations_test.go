@@ -0,0 +1,55 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+func TestCheckAnnotations(t *testing.T) {
+	rf := generateRF(false, false)
+	rf.Annotations = map[string]string{
+		"databases.spotahome.com/suport-bundle":            "now",
+		redisfailoverv1.ForcePodOwnerAnnotationsAnnotation: "yes",
+		redisfailoverv1.DiagnoseAnnotation:                 "all",
+	}
+
+	mk := &mK8SService.Services{}
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.Reason == "UnknownAnnotation" && e.Type == corev1.EventTypeWarning && e.InvolvedObject.Name == name &&
+			e.Message == "unknown annotation databases.spotahome.com/suport-bundle, did you mean databases.spotahome.com/support-bundle?"
+	})).Once().Return(errors.New("apiserver unavailable"))
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.Reason == "UnknownAnnotation"
+	})).Once().Return(nil)
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.Reason == "InvalidAnnotation" &&
+			e.Message == `databases.spotahome.com/force-pod-owner-annotations annotation is invalid: must be true or false, got "yes"`
+	})).Once().Return(nil)
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.Reason == "InvalidAnnotation" &&
+			e.Message == `databases.spotahome.com/force-pod-owner-annotations annotation is invalid: must be true or false, got "on"`
+	})).Once().Return(nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.Dummy, log.Dummy)
+
+	// The warning that could not be sent is sent again, then every annotation is warned about once.
+	for i := 0; i < 3; i++ {
+		handler.CheckAnnotations(context.TODO(), rf)
+	}
+
+	// A new invalid value is warned about again.
+	rf.Annotations[redisfailoverv1.ForcePodOwnerAnnotationsAnnotation] = "on"
+	handler.CheckAnnotations(context.TODO(), rf)
+	mk.AssertExpectations(t)
+}
@@ -0,0 +1,124 @@
+package redisfailover
+
+import (
+	"sync"
+	"time"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+const (
+	// minPressureWidening is how much the check intervals are widened on the first 429 of the
+	// apiserver, doubled on every other one up to maxPressureWidening.
+	minPressureWidening = resync
+	maxPressureWidening = 10 * resync
+)
+
+// APIServerBackoff holds the reconciles of the RFs while the apiserver answers 429 Too Many Requests.
+// No RF is reconciled before the Retry-After asked by the apiserver, and the checks of every RF are
+// widened, halving the widening on every resync period without a 429. The reconciles held are not
+// retried, they are made on the first resync or event after the hold.
+type APIServerBackoff struct {
+	pressure *k8s.APIServerPressure
+	now      func() time.Time
+	mClient  metrics.Recorder
+	logger   log.Logger
+
+	mu sync.Mutex
+	// widening is added to the interval of the checks of every RF, throttledAt is the last 429
+	// accounted and cleanSince when the current period without a 429 started.
+	widening    time.Duration
+	throttledAt time.Time
+	cleanSince  time.Time
+	// reconciled is when every RF was last reconciled.
+	reconciled map[string]time.Time
+}
+
+// NewAPIServerBackoff returns a new apiserver backoff on the pressure recorded by the services.
+func NewAPIServerBackoff(pressure *k8s.APIServerPressure, now func() time.Time, mClient metrics.Recorder, logger log.Logger) *APIServerBackoff {
+	return &APIServerBackoff{
+		pressure:   pressure,
+		now:        now,
+		mClient:    mClient,
+		logger:     logger,
+		reconciled: map[string]time.Time{},
+	}
+}
+
+// Wait returns how long the reconcile of the RF must wait for, 0 when it can be made.
+func (b *APIServerBackoff) Wait(key string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.update(now)
+	_, retryUntil := b.pressure.Throttled()
+	wait := retryUntil.Sub(now)
+	if last, ok := b.reconciled[key]; ok && b.widening > 0 {
+		if w := last.Add(b.widening).Sub(now); w > wait {
+			wait = w
+		}
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// Done records the RF was reconciled.
+func (b *APIServerBackoff) Done(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.reconciled[key] = now
+	b.update(now)
+}
+
+// Widening returns how much the check intervals are widened.
+func (b *APIServerBackoff) Widening() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.update(b.now())
+	return b.widening
+}
+
+// update widens the check intervals on a new 429, and narrows them on every period without one.
+func (b *APIServerBackoff) update(now time.Time) {
+	widening := b.widening
+	if throttledAt, _ := b.pressure.Throttled(); throttledAt.After(b.throttledAt) {
+		b.throttledAt = throttledAt
+		b.cleanSince = throttledAt
+		widening *= 2
+		if widening < minPressureWidening {
+			widening = minPressureWidening
+		}
+		if widening > maxPressureWidening {
+			widening = maxPressureWidening
+		}
+	}
+	for widening > 0 && now.Sub(b.cleanSince) >= resync {
+		b.cleanSince = b.cleanSince.Add(resync)
+		widening /= 2
+		if widening < time.Second {
+			widening = 0
+		}
+	}
+	if widening == b.widening {
+		return
+	}
+
+	switch {
+	case b.widening == 0:
+		b.logger.Warnf("The apiserver answered 429 Too Many Requests, widening the check intervals by %s", widening)
+	case widening == 0:
+		b.logger.Infof("The apiserver is not under pressure anymore, the check intervals are back to normal")
+	default:
+		b.logger.Infof("The apiserver is under pressure, widening the check intervals by %s", widening)
+	}
+	b.widening = widening
+	b.mClient.SetAPIServerPressure(widening.Seconds())
+}
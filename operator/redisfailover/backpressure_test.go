@@ -0,0 +1,78 @@
+package redisfailover_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	rfOperator "redis-operator/operator/redisfailover"
+	"redis-operator/service/k8s"
+)
+
+func TestAPIServerBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	pressure := k8s.NewAPIServerPressure(clock.Now)
+	b := rfOperator.NewAPIServerBackoff(pressure, clock.Now, metrics.Dummy, log.Dummy)
+
+	// Without a 429 nothing is held.
+	assert.Equal(time.Duration(0), b.Wait("testns/a"))
+	assert.Equal(time.Duration(0), b.Widening())
+	b.Done("testns/a")
+
+	// A 429 holds every RF for the Retry-After, and the RFs already reconciled for the widening.
+	pressure.Observe(kubeerrors.NewTooManyRequests("the server is busy", 5))
+	assert.Equal(30*time.Second, b.Widening())
+	assert.Equal(30*time.Second, b.Wait("testns/a"))
+	assert.Equal(5*time.Second, b.Wait("testns/b"))
+
+	clock.Advance(10 * time.Second)
+	assert.Equal(20*time.Second, b.Wait("testns/a"))
+	assert.Equal(time.Duration(0), b.Wait("testns/b"))
+
+	// A resync period without a 429 halves the widening.
+	clock.Advance(20 * time.Second)
+	assert.Equal(15*time.Second, b.Widening())
+	assert.Equal(time.Duration(0), b.Wait("testns/a"))
+	b.Done("testns/a")
+
+	// Another 429 widens it again, a 429 without Retry-After holds the RFs for a second.
+	pressure.Observe(kubeerrors.NewTooManyRequests("the server is busy", 0))
+	assert.Equal(30*time.Second, b.Widening())
+	assert.Equal(30*time.Second, b.Wait("testns/a"))
+	assert.Equal(time.Second, b.Wait("testns/b"))
+
+	// And another one doubles it, keeping the longest Retry-After.
+	clock.Advance(time.Second)
+	pressure.Observe(kubeerrors.NewTooManyRequests("the server is busy", 120))
+	assert.Equal(time.Minute, b.Widening())
+	assert.Equal(2*time.Minute, b.Wait("testns/b"))
+
+	// The widening goes back to 0 once halved under a second.
+	clock.Advance(2 * time.Minute)
+	assert.Equal(3750*time.Millisecond, b.Widening())
+	assert.Equal(time.Duration(0), b.Wait("testns/b"))
+	clock.Advance(time.Minute)
+	assert.Equal(time.Duration(0), b.Widening())
+	assert.Equal(time.Duration(0), b.Wait("testns/a"))
+}
+
+func TestAPIServerBackoffMaxWidening(t *testing.T) {
+	assert := assert.New(t)
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	pressure := k8s.NewAPIServerPressure(clock.Now)
+	b := rfOperator.NewAPIServerBackoff(pressure, clock.Now, metrics.Dummy, log.Dummy)
+
+	for i := 0; i < 10; i++ {
+		clock.Advance(time.Second)
+		pressure.Observe(kubeerrors.NewTooManyRequests("the server is busy", 1))
+		b.Widening()
+	}
+	assert.Equal(5*time.Minute, b.Widening())
+}
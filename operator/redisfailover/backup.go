@@ -0,0 +1,94 @@
+package redisfailover
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+)
+
+// EnsureBackups backs up the redis data of a replica when the last backup is older than the backup
+// interval, and deletes the oldest backups over the retention. The newest ready backup is never
+// deleted, so there is always one to restore while the new ones are not ready yet. Without the
+// VolumeSnapshot CRD in the cluster the backups are skipped.
+func (r *RedisFailoverHandler) EnsureBackups(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, now time.Time) error {
+	snapshots, err := r.k8sservice.ListVolumeSnapshots(ctx, rf.Namespace, rfservice.GetBackupSelector(rf))
+	if errors.Is(err, k8s.ErrVolumeSnapshotsUnavailable) {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("skipping the backup: %s", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	backups := rfservice.GetBackups(snapshots)
+
+	if len(backups) == 0 || now.Sub(backups[len(backups)-1].CreatedAt.Time) >= rf.Spec.Backup.GetInterval() {
+		replica, err := r.getBackupReplica(ctx, rf)
+		if err != nil {
+			return err
+		}
+		name := rfservice.GetBackupName(rf, now)
+		if err := r.rfHealer.SnapshotReplica(ctx, replica.Name, rf.PreferredPodIP(replica.Status), name, labels, rf); err != nil {
+			if errors.Is(err, k8s.ErrVolumeSnapshotsUnavailable) {
+				r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("skipping the backup: %s", err)
+				return nil
+			}
+			return err
+		}
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Infof("backup %s requested from %s", name, replica.Name)
+		backups = append(backups, redisfailoverv1.BackupStatus{Name: name})
+	}
+
+	newestReady := ""
+	for _, backup := range backups {
+		if backup.ReadyToUse {
+			newestReady = backup.Name
+		}
+	}
+	excess := len(backups) - int(rf.Spec.Backup.Retention)
+	for _, backup := range backups {
+		if excess <= 0 {
+			break
+		}
+		if backup.Name == newestReady {
+			continue
+		}
+		if err := r.k8sservice.DeleteVolumeSnapshot(ctx, rf.Namespace, backup.Name); err != nil {
+			return err
+		}
+		excess--
+	}
+	return nil
+}
+
+// getBackupReplica returns the first ready replica by name, the master is not backed up so its clients
+// are not affected.
+func (r *RedisFailoverHandler) getBackupReplica(ctx context.Context, rf *redisfailoverv1.RedisFailover) (*corev1.Pod, error) {
+	masterIP, err := r.rfChecker.GetMasterIP(ctx, rf)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := r.k8sservice.GetStatefulSetPods(ctx, rf.Namespace, rfservice.GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
+	}
+	replicas := []corev1.Pod{}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil && pod.Status.PodIP != "" && rf.PreferredPodIP(pod.Status) != masterIP && k8s.IsPodReady(&pod) {
+			replicas = append(replicas, pod)
+		}
+	}
+	if len(replicas) == 0 {
+		return nil, errors.New("no ready replica to back up")
+	}
+	sort.Slice(replicas, func(i, j int) bool {
+		return replicas[i].Name < replicas[j].Name
+	})
+	return &replicas[0], nil
+}
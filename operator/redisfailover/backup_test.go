@@ -0,0 +1,153 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	"redis-operator/service/k8s"
+)
+
+func generateSnapshot(name string, created time.Time, ready bool) unstructured.Unstructured {
+	snapshot := unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"readyToUse": ready},
+	}}
+	snapshot.SetName(name)
+	snapshot.SetCreationTimestamp(metav1.NewTime(created))
+	return snapshot
+}
+
+func TestEnsureBackups(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	replicas := []corev1.Pod{
+		generateReadyPod("rfr-test-0", "", true),
+		generateReadyPod("rfr-test-1", "", true),
+		generateReadyPod("rfr-test-2", "", true),
+	}
+	for i := range replicas {
+		replicas[i].Status.PodIP = []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}[i]
+	}
+
+	tests := []struct {
+		name        string
+		snapshots   []unstructured.Unstructured
+		noCRD       bool
+		pods        []corev1.Pod
+		expSnapshot bool
+		expDeleted  []string
+		expErr      bool
+	}{
+		{
+			name:        "The first backup is taken from the first replica",
+			pods:        replicas,
+			expSnapshot: true,
+		},
+		{
+			name: "No backup is taken before the interval elapses",
+			snapshots: []unstructured.Unstructured{
+				generateSnapshot("rfb-test-1", now.Add(-time.Hour), true),
+			},
+		},
+		{
+			name: "A backup is taken when the interval elapses",
+			snapshots: []unstructured.Unstructured{
+				generateSnapshot("rfb-test-1", now.Add(-25*time.Hour), true),
+			},
+			pods:        replicas,
+			expSnapshot: true,
+		},
+		{
+			name: "The oldest backups over the retention are deleted",
+			snapshots: []unstructured.Unstructured{
+				generateSnapshot("rfb-test-3", now.Add(-25*time.Hour), true),
+				generateSnapshot("rfb-test-1", now.Add(-73*time.Hour), true),
+				generateSnapshot("rfb-test-2", now.Add(-49*time.Hour), true),
+			},
+			pods:        replicas,
+			expSnapshot: true,
+			expDeleted:  []string{"rfb-test-1", "rfb-test-2"},
+		},
+		{
+			name: "The newest ready backup is kept over the retention",
+			snapshots: []unstructured.Unstructured{
+				generateSnapshot("rfb-test-1", now.Add(-73*time.Hour), true),
+				generateSnapshot("rfb-test-2", now.Add(-49*time.Hour), false),
+				generateSnapshot("rfb-test-3", now.Add(-25*time.Hour), false),
+			},
+			pods:        replicas,
+			expSnapshot: true,
+			expDeleted:  []string{"rfb-test-2", "rfb-test-3"},
+		},
+		{
+			name:  "Without the VolumeSnapshot CRD the backups are skipped",
+			noCRD: true,
+		},
+		{
+			name:   "Without a ready replica the backup fails",
+			pods:   []corev1.Pod{replicas[0], generateReadyPod("rfr-test-1", "", false)},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Spec.Backup = &redisfailoverv1.BackupSettings{
+				Method:    redisfailoverv1.BackupMethodVolumeSnapshot,
+				Retention: 2,
+			}
+			labels := map[string]string{"custom": "custom"}
+
+			mk := &mK8SService.Services{}
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfh := &mRFService.RedisFailoverHeal{}
+			if test.noCRD {
+				mk.On("ListVolumeSnapshots", mock.Anything, namespace, mock.Anything).Once().Return(nil, k8s.ErrVolumeSnapshotsUnavailable)
+			} else {
+				mk.On("ListVolumeSnapshots", mock.Anything, namespace, mock.Anything).Once().Return(&unstructured.UnstructuredList{Items: test.snapshots}, nil)
+			}
+			if test.pods != nil {
+				mrfc.On("GetMasterIP", mock.Anything, rf).Once().Return("10.0.0.1", nil)
+				mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: test.pods}, nil)
+			}
+			if test.expSnapshot {
+				mrfh.On("SnapshotReplica", mock.Anything, "rfr-test-1", "10.0.0.2", "rfb-test-20240102-030405", labels, rf).Once().Return(nil)
+			}
+			for _, name := range test.expDeleted {
+				mk.On("DeleteVolumeSnapshot", mock.Anything, namespace, name).Once().Return(nil)
+			}
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.EnsureBackups(context.TODO(), rf, labels, now)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			mk.AssertExpectations(t)
+			mrfc.AssertExpectations(t)
+			mrfh.AssertExpectations(t)
+			if len(test.expDeleted) == 0 {
+				mk.AssertNotCalled(t, "DeleteVolumeSnapshot", mock.Anything, mock.Anything, mock.Anything)
+			}
+			if !test.expSnapshot {
+				mrfh.AssertNotCalled(t, "SnapshotReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
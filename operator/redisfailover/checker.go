@@ -1,297 +1,625 @@
 package redisfailover
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	redisfailoverv1 "redis-operator/api/redisfailover/v1"
 	"redis-operator/metrics"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
 )
 
 const (
 	timeToPrepare = 2 * time.Minute
 )
 
-// UpdateRedisesPods if the running version of pods are equal to the statefulset one
-func (r *RedisFailoverHandler) UpdateRedisesPods(rf *redisfailoverv1.RedisFailover) error {
-	redises, err := r.rfChecker.GetRedisesIPs(rf)
+// PlanRedisesPodsUpdate plans deleting a pod whose running version is not the statefulset one, so it's
+// recreated with it. Only one pod is deleted by check, the slaves first, and only when they are all ready.
+func (r *RedisFailoverHandler) PlanRedisesPodsUpdate(ctx context.Context, rf *redisfailoverv1.RedisFailover, gates rfservice.FeatureGates) ([]rfservice.PodAction, error) {
+	redises, err := r.rfChecker.GetRedisesIPs(ctx, rf)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	masterIP := ""
 	if !rf.Bootstrapping() {
-		masterIP, _ = r.rfChecker.GetMasterIP(rf)
+		masterIP, _ = r.rfChecker.GetMasterIP(ctx, rf)
 	}
 	// No perform updates when nodes are syncing, still not connected, etc.
 	for _, rip := range redises {
 		if rip != masterIP {
-			ready, err := r.rfChecker.CheckRedisSlavesReady(rip, rf)
+			ready, err := r.rfChecker.CheckRedisSlavesReady(ctx, rip, rf)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			if !ready {
-				return nil
+				return nil, nil
 			}
 		}
 	}
 
-	ssUR, err := r.rfChecker.GetStatefulSetUpdateRevision(rf)
+	ssUR, err := r.rfChecker.GetStatefulSetUpdateRevision(ctx, rf)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	redisesPods, err := r.rfChecker.GetRedisesSlavesPods(rf)
+	redisesPods, err := r.rfChecker.GetRedisesSlavesPods(ctx, rf)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Update stale pods with slave role
 	for _, pod := range redisesPods {
-		revision, err := r.rfChecker.GetRedisRevisionHash(pod, rf)
+		revision, err := r.rfChecker.GetRedisRevisionHash(ctx, pod, rf)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if revision != ssUR {
 			//Delete pod and wait next round to check if the new one is synced
-			err = r.rfHealer.DeletePod(pod, rf)
-			if err != nil {
-				return err
-			}
-			return nil
+			return []rfservice.PodAction{r.deletePodAction(ctx, pod, rf, gates)}, nil
 		}
 	}
 
 	if !rf.Bootstrapping() {
 		// Update stale pod with role master
-		master, err := r.rfChecker.GetRedisesMasterPod(rf)
+		master, err := r.rfChecker.GetRedisesMasterPod(ctx, rf)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		masterRevision, err := r.rfChecker.GetRedisRevisionHash(master, rf)
+		masterRevision, err := r.rfChecker.GetRedisRevisionHash(ctx, master, rf)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if masterRevision != ssUR {
-			err = r.rfHealer.DeletePod(master, rf)
-			if err != nil {
+			return []rfservice.PodAction{r.deletePodAction(ctx, master, rf, gates)}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// deletePodAction returns the action deleting the pod, or evicting it, to update it to the statefulset
+// revision. An event is created on the RF when it's taken.
+func (r *RedisFailoverHandler) deletePodAction(ctx context.Context, pod string, rf *redisfailoverv1.RedisFailover, gates rfservice.FeatureGates) rfservice.PodAction {
+	if gates.UseEvictions {
+		return rfservice.PodAction{
+			Pod:    pod,
+			Kind:   rfservice.PodActionDelete,
+			Reason: "evict it to update it to the statefulset revision",
+			Apply: func() error {
+				if err := r.rfHealer.EvictPod(ctx, pod, rf); err != nil {
+					return err
+				}
+				r.events.Record(ctx, rf, corev1.EventTypeNormal, podEvictedReason, fmt.Sprintf("pod %s was evicted to update it to the statefulset revision", pod))
+				return nil
+			},
+		}
+	}
+	return rfservice.PodAction{
+		Pod:    pod,
+		Kind:   rfservice.PodActionDelete,
+		Reason: "update it to the statefulset revision",
+		Apply: func() error {
+			if err := r.rfHealer.DeletePod(ctx, pod, rf); err != nil {
 				return err
 			}
+			r.events.Record(ctx, rf, corev1.EventTypeNormal, podDeletedReason, fmt.Sprintf("pod %s was deleted to update it to the statefulset revision", pod))
 			return nil
-		}
+		},
 	}
-
-	return nil
 }
 
 // CheckAndHeal runs verifcation checks to ensure the RedisFailover is in an expected and healthy state.
 // If the checks do not match up to expectations, an attempt will be made to "heal" the RedisFailover into a healthy state.
-func (r *RedisFailoverHandler) CheckAndHeal(rf *redisfailoverv1.RedisFailover) error {
-	if rf.Bootstrapping() {
-		return r.checkAndHealBootstrapMode(rf)
+// The checks run in order, see checks.
+func (r *RedisFailoverHandler) CheckAndHeal(ctx context.Context, rf *redisfailoverv1.RedisFailover, gates rfservice.FeatureGates) error {
+	snapshot := &TopologySnapshot{
+		RF:    rf,
+		Gates: gates,
+		// The actions on the redis pods are planned and arbitrated before taking any of them, so a pod
+		// being deleted is not reconfigured in the same check.
+		Plan: rfservice.NewPodActionPlan(),
 	}
+	return r.runChecks(ctx, r.checks(rf), snapshot)
+}
 
-	// Number of redis is equal as the set on the RF spec
-	// Number of sentinel is equal as the set on the RF spec
-	// Check only one master
-	// Number of redis master is 1
-	// All redis slaves have the same master
-	// All sentinels points to the same redis master
-	// Sentinel has not death nodes
-	// Sentinel knows the correct slave number
-
-	err := r.rfChecker.CheckRedisNumber(rf)
+// checkRedisNumber stops the checks while the number of redis is not the one of the spec.
+func (r *RedisFailoverHandler) checkRedisNumber(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	rf := s.RF
+	err := r.rfChecker.CheckRedisNumber(ctx, rf)
 	setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.REDIS_REPLICA_MISMATCH, metrics.NOT_APPLICABLE, err)
 	if err != nil {
 		r.logger.Debug("Number of redis mismatch, this could be for a change on the statefulset")
-		return nil
+		return nil, errStopChecks
 	}
+	return nil, nil
+}
 
-	err = r.rfChecker.CheckSentinelNumber(rf)
+// checkSentinelNumber stops the checks while the number of sentinel is not the one of the spec.
+func (r *RedisFailoverHandler) checkSentinelNumber(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	rf := s.RF
+	err := r.rfChecker.CheckSentinelNumber(ctx, rf)
 	setRedisCheckerMetrics(r.mClient, "sentinel", rf.Namespace, rf.Name, metrics.SENTINEL_REPLICA_MISMATCH, metrics.NOT_APPLICABLE, err)
 	if err != nil {
 		r.logger.Debug("Number of sentinel mismatch, this could be for a change on the deployment")
-		return nil
+		return nil, errStopChecks
 	}
+	return nil, nil
+}
 
-	nMasters, err := r.rfChecker.GetNumberMasters(rf)
+// checkMaster promotes a master when there's none, and takes the master and what holds the actions on
+// the redis pods into the snapshot. The checks are stopped while the promotion is held or waited for.
+func (r *RedisFailoverHandler) checkMaster(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	rf := s.RF
+	nMasters, err := r.rfChecker.GetNumberMasters(ctx, rf)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	switch nMasters {
 	case 0:
 		setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.NUMBER_OF_MASTERS, metrics.NOT_APPLICABLE, errors.New("No masters detected"))
-		redisesIP, err := r.rfChecker.GetRedisesIPs(rf)
+		redisesIP, err := r.rfChecker.GetRedisesIPs(ctx, rf)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if len(redisesIP) == 1 {
-			if err := r.rfHealer.MakeMaster(redisesIP[0], rf); err != nil {
-				return err
+			if confirmed, err := r.confirmMasterDown(ctx, rf); err != nil || !confirmed {
+				return nil, orStopChecks(err)
+			}
+			if held, err := r.waitForRollout(ctx, rf); err != nil || held {
+				return nil, orStopChecks(err)
+			}
+			if err := r.rfHealer.MakeMaster(ctx, redisesIP[0], rf); err != nil {
+				return nil, err
 			}
+			r.events.Record(ctx, rf, corev1.EventTypeWarning, masterFailoverReason, fmt.Sprintf("no master found, the only redis %s was promoted", redisesIP[0]))
+			r.stabilizer.Start(rfKey(rf))
 			break
 		}
-		minTime, err2 := r.rfChecker.GetMinimumRedisPodTime(rf)
+		minTime, err2 := r.rfChecker.GetMinimumRedisPodTime(ctx, rf)
 		if err2 != nil {
-			return err2
+			return nil, err2
 		}
 		if minTime > timeToPrepare {
 			r.logger.Debugf("time %.f more than expected. Not even one master, fixing...", minTime.Round(time.Second).Seconds())
 			// We can consider there's an error
-			if err2 := r.rfHealer.SetOldestAsMaster(rf); err2 != nil {
-				return err2
+			if confirmed, err := r.confirmMasterDown(ctx, rf); err != nil || !confirmed {
+				return nil, orStopChecks(err)
 			}
+			if held, err := r.waitForRollout(ctx, rf); err != nil || held {
+				return nil, orStopChecks(err)
+			}
+			if err2 := r.rfHealer.SetOldestAsMaster(ctx, rf); err2 != nil {
+				return nil, err2
+			}
+			r.events.Record(ctx, rf, corev1.EventTypeWarning, masterFailoverReason, fmt.Sprintf("no master found for %s, the oldest redis was promoted", minTime.Round(time.Second)))
+			r.stabilizer.Start(rfKey(rf))
 		} else {
 			// We'll wait until failover is done
 			r.logger.Debug("No master found, wait until failover")
-			return nil
+			return nil, errStopChecks
 		}
 	case 1:
 		setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.NUMBER_OF_MASTERS, metrics.NOT_APPLICABLE, nil)
+		r.promotionHolds.Release(rfKey(rf))
 	default:
 		setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.NUMBER_OF_MASTERS, metrics.NOT_APPLICABLE, errors.New("Multiple masters detected"))
-		return errors.New("More than one master, fix manually")
+		return nil, errors.New("More than one master, fix manually")
 	}
 
-	master, err := r.rfChecker.GetMasterIP(rf)
+	master, err := r.rfChecker.GetMasterIP(ctx, rf)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	s.Master = master
+	r.stabilizer.ObserveMaster(rfKey(rf), master)
+	_, s.Stabilizing = r.stabilizationWindow(rf)
+	logger := r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name)
+	if s.Stabilizing > 0 {
+		logger.Infof("Holding the replication, config and updates of the redis pods for %s while the master stabilizes after a failover", s.Stabilizing.Round(time.Second))
 	}
+	// The replicas are frozen after a mass deletion of the keys of the master until it's acknowledged,
+	// so the ones that did not replicate it yet are not resynced from the emptied master.
+	s.Frozen = r.dataFlushes.Frozen(rf)
+	if s.Frozen {
+		logger.Infof("Holding the replication and updates of the redis pods until the mass deletion of the keys of the master is acknowledged with the %s annotation", redisfailoverv1.UnfreezeAnnotation)
+	}
+	// The redis pods waiting for their volumes have no redis to ask yet, the replication and the
+	// updates are held during the grace period instead of failing on them.
+	s.Waiting = r.volumeWaits.Held(rfKey(rf))
+	if len(s.Waiting) > 0 {
+		logger.Infof("Holding the replication and updates of the redis pods while %s wait for their volumes", strings.Join(s.Waiting, ", "))
+	}
+	return nil, nil
+}
 
-	err2 := r.rfChecker.CheckAllSlavesFromMaster(master, rf)
+// checkReplication plans the slaves replicating another master to replicate the master.
+func (r *RedisFailoverHandler) checkReplication(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	rf := s.RF
+	if len(s.Waiting) > 0 {
+		return nil, nil
+	}
+	err := r.rfChecker.CheckAllSlavesFromMaster(ctx, s.Master, rf)
 	setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.SLAVE_WRONG_MASTER, metrics.NOT_APPLICABLE, err)
-	if err2 != nil {
-		r.logger.Debug("Not all slaves have the same master")
-		if err3 := r.rfHealer.SetMasterOnAll(master, rf); err3 != nil {
-			return err3
-		}
+	if err == nil {
+		r.rfHealer.ClearSyncSlotQueue(rf)
+		return nil, nil
 	}
+	r.logger.Debug("Not all slaves have the same master")
+	if s.Stabilizing > 0 || s.Frozen {
+		return nil, nil
+	}
+	return r.rfHealer.PlanMasterOnAll(ctx, s.Master, rf)
+}
 
-	err = r.applyRedisCustomConfig(rf)
-	setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.APPLY_REDIS_CONFIG, metrics.NOT_APPLICABLE, err)
-	if err != nil {
-		return err
+// checkRoleLabels plans the role labels routing the clients to the master, they are never held.
+func (r *RedisFailoverHandler) checkRoleLabels(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	return r.rfHealer.PlanRoleLabels(ctx, s.Master, s.RF)
+}
+
+// checkStuckReplicas plans the remediation of the stuck replicas. It runs before the custom config,
+// so raising the backlog of the master is not suppressed by it.
+func (r *RedisFailoverHandler) checkStuckReplicas(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	if s.Stabilizing > 0 || s.Frozen {
+		return nil, nil
 	}
+	return r.planStuckReplicas(ctx, s.RF, s.Master)
+}
 
-	err = r.UpdateRedisesPods(rf)
-	if err != nil {
-		return err
+// checkRedisConfig plans setting the custom config on the redis pods.
+func (r *RedisFailoverHandler) checkRedisConfig(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	if s.Stabilizing > 0 {
+		return nil, nil
 	}
+	return r.planRedisCustomConfig(ctx, s.RF)
+}
 
-	sentinels, err := r.rfChecker.GetSentinelsIPs(rf)
-	if err != nil {
-		return err
+// checkRedisACL plans loading the ACL file on the redis pods.
+func (r *RedisFailoverHandler) checkRedisACL(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	if s.Stabilizing > 0 {
+		return nil, nil
 	}
+	return r.planRedisACLLoad(ctx, s.RF)
+}
 
-	port := getRedisPort(rf.Spec.Redis.Port)
-	for _, sip := range sentinels {
-		err = r.rfChecker.CheckSentinelMonitor(sip, master, port)
-		setRedisCheckerMetrics(r.mClient, "sentinel", rf.Namespace, rf.Name, metrics.SENTINEL_WRONG_MASTER, sip, err)
-		if err != nil {
-			r.logger.Debug("Sentinel is not monitoring the correct master")
-			if err := r.rfHealer.NewSentinelMonitor(sip, master, rf); err != nil {
-				return err
-			}
-		}
+// checkRedisUpdates plans updating a redis pod to the statefulset revision.
+func (r *RedisFailoverHandler) checkRedisUpdates(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	if s.Stabilizing > 0 || len(s.Waiting) > 0 || s.Frozen {
+		return nil, nil
 	}
-	return r.checkAndHealSentinels(rf, sentinels)
+	updates, err := r.PlanRedisesPodsUpdate(ctx, s.RF, s.Gates)
+	s.Updating = len(updates) > 0
+	return updates, err
 }
 
-func (r *RedisFailoverHandler) checkAndHealBootstrapMode(rf *redisfailoverv1.RedisFailover) error {
-	err := r.rfChecker.CheckRedisNumber(rf)
-	setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.REDIS_REPLICA_MISMATCH, metrics.NOT_APPLICABLE, err)
+// checkRedisRestarts plans the in place restarts of the redis pods, they wait for the pods to be on
+// the statefulset revision.
+func (r *RedisFailoverHandler) checkRedisRestarts(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	if s.Stabilizing > 0 || len(s.Waiting) > 0 || s.Frozen || s.Updating {
+		return nil, nil
+	}
+	return r.rfHealer.PlanRedisInPlaceRestarts(ctx, s.Master, s.RF)
+}
+
+// checkBootstrapReplication plans the redis pods to replicate the bootstrap node.
+func (r *RedisFailoverHandler) checkBootstrapReplication(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	bootstrapSettings := s.RF.Spec.BootstrapNode
+	return r.rfHealer.PlanExternalMasterOnAll(ctx, bootstrapSettings.Host, bootstrapSettings.Port, s.RF)
+}
+
+// checkPodActions takes the actions on the redis pods planned by the previous checks.
+func (r *RedisFailoverHandler) checkPodActions(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	return nil, r.applyPodActions(ctx, s.RF, s.Plan)
+}
+
+// checkExternalMaster takes the master of the external nodes into the snapshot. There are no pods to
+// delete, so the heal of the external nodes is limited to the replication, the custom config and the
+// sentinels.
+func (r *RedisFailoverHandler) checkExternalMaster(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	rf := s.RF
+	masters, err := r.rfChecker.GetExternalMasters(ctx, rf)
 	if err != nil {
-		r.logger.Debug("Number of redis mismatch, this could be for a change on the statefulset")
-		return nil
+		return nil, err
 	}
+	switch len(masters) {
+	case 0:
+		// The operator has no way to know which node has the freshest data, the failover is left to the sentinels.
+		setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.NUMBER_OF_MASTERS, metrics.NOT_APPLICABLE, errors.New("No masters detected"))
+		r.logger.Debug("No master found among the external nodes, wait until failover")
+		return nil, errStopChecks
+	case 1:
+		setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.NUMBER_OF_MASTERS, metrics.NOT_APPLICABLE, nil)
+	default:
+		setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.NUMBER_OF_MASTERS, metrics.NOT_APPLICABLE, errors.New("Multiple masters detected"))
+		return nil, errors.New("More than one master, fix manually")
+	}
+	s.ExternalMaster = &masters[0]
+	return nil, nil
+}
 
-	err = r.UpdateRedisesPods(rf)
+// checkExternalReplication sets the master on the external nodes replicating another one.
+func (r *RedisFailoverHandler) checkExternalReplication(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	rf := s.RF
+	err := r.rfChecker.CheckExternalSlavesFromMaster(ctx, *s.ExternalMaster, rf)
+	setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.SLAVE_WRONG_MASTER, metrics.NOT_APPLICABLE, err)
 	if err != nil {
-		return err
+		r.logger.Debug("Not all external nodes have the same master")
+		return nil, r.rfHealer.SetExternalNodesMaster(ctx, *s.ExternalMaster, rf)
 	}
-	err = r.applyRedisCustomConfig(rf)
-	setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.APPLY_REDIS_CONFIG, metrics.NOT_APPLICABLE, err)
+	r.rfHealer.ClearSyncSlotQueue(rf)
+	return nil, nil
+}
+
+// checkExternalRedisConfig sets the custom config on the external nodes.
+func (r *RedisFailoverHandler) checkExternalRedisConfig(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	rf := s.RF
+	for _, node := range rf.Spec.Redis.ExternalNodes {
+		err := r.rfHealer.SetExternalRedisCustomConfig(ctx, node, rf)
+		setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.APPLY_REDIS_CONFIG, metrics.NOT_APPLICABLE, err)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// checkSentinels takes the sentinels into the snapshot.
+func (r *RedisFailoverHandler) checkSentinels(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	sentinels, err := r.rfChecker.GetSentinelsIPs(ctx, s.RF)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	s.Sentinels = sentinels
+	return nil, nil
+}
 
-	bootstrapSettings := rf.Spec.BootstrapNode
-	if err := r.rfHealer.SetExternalMasterOnAll(bootstrapSettings.Host, bootstrapSettings.Port, rf); err != nil {
-		return err
+// checkUnresponsiveSentinels restarts the sentinels not answering, only the ones answering are kept
+// in the snapshot.
+func (r *RedisFailoverHandler) checkUnresponsiveSentinels(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	sentinels, err := r.checkAndHealUnresponsiveSentinels(ctx, s.RF, s.Sentinels)
+	if err != nil {
+		return nil, err
 	}
+	s.Sentinels = sentinels
+	return nil, nil
+}
 
-	if rf.SentinelsAllowed() {
-		err = r.rfChecker.CheckSentinelNumber(rf)
-		setRedisCheckerMetrics(r.mClient, "sentinel", rf.Namespace, rf.Name, metrics.SENTINEL_REPLICA_MISMATCH, metrics.NOT_APPLICABLE, err)
+// checkSentinelMonitor makes the sentinels monitor the master: the address announced by the master of
+// the redis pods, the bootstrap node or the master of the external nodes.
+func (r *RedisFailoverHandler) checkSentinelMonitor(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	rf := s.RF
+	host, port, withPort := s.Master, getRedisPort(rf.Spec.Redis.Port), false
+	switch {
+	case rf.Bootstrapping():
+		host, port, withPort = rf.Spec.BootstrapNode.Host, rf.Spec.BootstrapNode.Port, true
+	case s.ExternalMaster != nil:
+		host, port, withPort = s.ExternalMaster.Host, s.ExternalMaster.Port, true
+	case rf.ReplicationInterfaceEnabled():
+		addresses, err := r.rfChecker.GetRedisAddresses(ctx, rf)
 		if err != nil {
-			r.logger.Debug("Number of sentinel mismatch, this could be for a change on the deployment")
-			return nil
+			return nil, err
 		}
+		host = addresses.Announced(s.Master)
+	}
 
-		sentinels, err := r.rfChecker.GetSentinelsIPs(rf)
+	for _, sip := range s.Sentinels {
+		err := r.rfChecker.CheckSentinelMonitor(sip, host, port)
+		setRedisCheckerMetrics(r.mClient, "sentinel", rf.Namespace, rf.Name, metrics.SENTINEL_WRONG_MASTER, sip, err)
+		if err == nil {
+			continue
+		}
+		r.logger.Debug("Sentinel is not monitoring the correct master")
+		if withPort {
+			err = r.rfHealer.NewSentinelMonitorWithPort(ctx, sip, host, port, rf)
+		} else {
+			err = r.rfHealer.NewSentinelMonitor(ctx, sip, host, rf)
+		}
 		if err != nil {
-			return err
+			return nil, err
 		}
-		for _, sip := range sentinels {
-			err = r.rfChecker.CheckSentinelMonitor(sip, bootstrapSettings.Host, bootstrapSettings.Port)
-			setRedisCheckerMetrics(r.mClient, "sentinel", rf.Namespace, rf.Name, metrics.SENTINEL_WRONG_MASTER, sip, err)
-			if err != nil {
-				r.logger.Debug("Sentinel is not monitoring the correct master")
-				if err := r.rfHealer.NewSentinelMonitorWithPort(sip, bootstrapSettings.Host, bootstrapSettings.Port, rf); err != nil {
-					return err
-				}
+	}
+	return nil, nil
+}
+
+// stabilizationWindow returns when the last failover of the RF happened and how long the disruptive
+// actions on its redis pods are still held. The window is taken from the status when the operator
+// doesn't know it, so it survives the restarts of the operator.
+func (r *RedisFailoverHandler) stabilizationWindow(rf *redisfailoverv1.RedisFailover) (time.Time, time.Duration) {
+	key := rfKey(rf)
+	cond := meta.FindStatusCondition(rf.Status.Conditions, redisfailoverv1.ConditionProgressing)
+	if cond != nil && cond.Status == metav1.ConditionTrue && cond.Reason == redisfailoverv1.ReasonStabilizingAfterFailover {
+		r.stabilizer.Seed(key, cond.LastTransitionTime.Time)
+	}
+	return r.stabilizer.Stabilizing(key, rf.Spec.Failover.GetStabilizationWindow())
+}
+
+// stuckReplicaSteps are the event reason and metric step of the actions remediating the stuck
+// replicas, by kind.
+var stuckReplicaSteps = map[rfservice.PodActionKind]struct {
+	reason string
+	step   string
+}{
+	rfservice.PodActionApplyConfig: {reason: "ReplBacklogRaised", step: metrics.STUCK_REPLICA_RAISE_BACKLOG},
+	rfservice.PodActionReconfigure: {reason: "StuckReplicaRetried", step: metrics.STUCK_REPLICA_RETRY},
+	rfservice.PodActionDelete:      {reason: "StuckReplicaRestarted", step: metrics.STUCK_REPLICA_RESTART},
+}
+
+// planStuckReplicas plans the remediation of the stuck replicas. Every escalation step taken is
+// recorded with an event on the RF and on the metrics when the actions are applied.
+func (r *RedisFailoverHandler) planStuckReplicas(ctx context.Context, rf *redisfailoverv1.RedisFailover, master string) ([]rfservice.PodAction, error) {
+	actions, err := r.rfHealer.PlanStuckReplicas(ctx, master, rf)
+	if err != nil {
+		return nil, err
+	}
+	for i := range actions {
+		action, s := actions[i], stuckReplicaSteps[actions[i].Kind]
+		actions[i].Apply = func() error {
+			if err := action.Apply(); err != nil {
+				return err
 			}
+			r.mClient.RecordStuckReplicaRemediation(rf.Namespace, rf.Name, s.step)
+			r.events.Record(ctx, rf, corev1.EventTypeWarning, s.reason, action.String())
+			return nil
 		}
-		return r.checkAndHealSentinels(rf, sentinels)
 	}
-	return nil
+	return actions, nil
 }
 
-func (r *RedisFailoverHandler) applyRedisCustomConfig(rf *redisfailoverv1.RedisFailover) error {
-	redises, err := r.rfChecker.GetRedisesIPs(rf)
+// planRedisCustomConfig plans setting the custom config on the redis pods. The result of setting it is
+// recorded on the metrics when the actions are applied.
+func (r *RedisFailoverHandler) planRedisCustomConfig(ctx context.Context, rf *redisfailoverv1.RedisFailover) ([]rfservice.PodAction, error) {
+	actions, err := r.rfHealer.PlanRedisCustomConfig(ctx, rf)
 	if err != nil {
-		return err
+		setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.APPLY_REDIS_CONFIG, metrics.NOT_APPLICABLE, err)
+		return nil, err
 	}
-	for _, rip := range redises {
-		if err := r.rfHealer.SetRedisCustomConfig(rip, rf); err != nil {
+	for i := range actions {
+		apply := actions[i].Apply
+		actions[i].Apply = func() error {
+			err := apply()
+			setRedisCheckerMetrics(r.mClient, "redis", rf.Namespace, rf.Name, metrics.APPLY_REDIS_CONFIG, metrics.NOT_APPLICABLE, err)
+			return err
+		}
+	}
+	return actions, nil
+}
+
+// applyPodActions takes the planned actions on the redis pods, stopping on the first error. The
+// suppressed actions are logged, they are planned again on the next check if still needed, and so are
+// the evictions refused by the pdb.
+func (r *RedisFailoverHandler) applyPodActions(ctx context.Context, rf *redisfailoverv1.RedisFailover, plan *rfservice.PodActionPlan) error {
+	logger := r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name)
+	for _, suppressed := range plan.Suppressed() {
+		if planned, ok := plan.Planned(suppressed.Pod); ok {
+			logger.Infof("Skipping %s in favor of %s", suppressed, planned)
+		}
+	}
+	for _, action := range plan.Actions() {
+		if err := action.Apply(); err != nil && !r.evictionBlocked(ctx, rf, action, err) {
 			return err
 		}
 	}
 	return nil
 }
 
-func (r *RedisFailoverHandler) checkAndHealSentinels(rf *redisfailoverv1.RedisFailover, sentinels []string) error {
+// evictionBlocked tells the action failed because the eviction of its pod would violate the pdb. It's
+// not an error: the RF is checked again and the eviction retried until the pdb allows it, the event
+// telling why the pod is not restarted yet.
+func (r *RedisFailoverHandler) evictionBlocked(ctx context.Context, rf *redisfailoverv1.RedisFailover, action rfservice.PodAction, err error) bool {
+	if !k8s.IsEvictionBlocked(err) {
+		return false
+	}
+	r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Infof("Holding %s, the eviction would violate the PodDisruptionBudget", action)
+	r.events.Record(ctx, rf, corev1.EventTypeWarning, evictionBlockedReason, fmt.Sprintf("%s: the eviction would violate the PodDisruptionBudget", action))
+	return true
+}
+
+// checkAndHealUnresponsiveSentinels pings every sentinel and restarts the pods of the ones not
+// answering for the consecutive checks of the threshold, see PlanUnresponsiveSentinels. It returns the
+// sentinels answering, the only ones checked and healed afterwards.
+func (r *RedisFailoverHandler) checkAndHealUnresponsiveSentinels(ctx context.Context, rf *redisfailoverv1.RedisFailover, sentinels []string) ([]string, error) {
+	responsive := []string{}
+	unresponsive := []string{}
 	for _, sip := range sentinels {
-		err := r.rfChecker.CheckSentinelNumberInMemory(sip, rf)
+		err := r.rfChecker.CheckSentinelResponding(sip)
+		setRedisCheckerMetrics(r.mClient, "sentinel", rf.Namespace, rf.Name, metrics.SENTINEL_NOT_RESPONDING, sip, err)
+		if err != nil {
+			r.logger.Debugf("Sentinel %s is not answering: %v", sip, err)
+			unresponsive = append(unresponsive, sip)
+			continue
+		}
+		responsive = append(responsive, sip)
+	}
+	r.mClient.SetUnresponsiveSentinels(rf.Namespace, rf.Name, len(unresponsive))
+
+	actions, err := r.rfHealer.PlanUnresponsiveSentinels(ctx, unresponsive, rf)
+	if err != nil {
+		return nil, err
+	}
+	for _, action := range actions {
+		if err := action.Apply(); r.evictionBlocked(ctx, rf, action, err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		r.mClient.RecordSentinelRestart(rf.Namespace, rf.Name)
+		r.events.Record(ctx, rf, corev1.EventTypeWarning, "UnresponsiveSentinelRestarted", action.String())
+	}
+	return responsive, nil
+}
+
+// checkSentinelReset resets the sentinels knowing other sentinels or replicas than the running ones.
+func (r *RedisFailoverHandler) checkSentinelReset(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	rf := s.RF
+	scaled := r.scaledSentinels(ctx, rf)
+	for _, sip := range s.Sentinels {
+		err := r.rfChecker.CheckSentinelNumberInMemory(sip, scaled)
 		setRedisCheckerMetrics(r.mClient, "sentinel", rf.Namespace, rf.Name, metrics.SENTINEL_NUMBER_IN_MEMORY_MISMATCH, sip, err)
 		if err != nil {
 			r.logger.Debug("Sentinel has more sentinel in memory than spected")
 			if err := r.rfHealer.RestoreSentinel(sip); err != nil {
-				return err
+				return nil, err
 			}
+			r.events.Record(ctx, rf, corev1.EventTypeNormal, sentinelResetReason, fmt.Sprintf("sentinel %s was reset, it knew more sentinels than the running ones", sip))
 		}
 
 	}
-	for _, sip := range sentinels {
+	for _, sip := range s.Sentinels {
 		err := r.rfChecker.CheckSentinelSlavesNumberInMemory(sip, rf)
 		setRedisCheckerMetrics(r.mClient, "sentinel", rf.Namespace, rf.Name, metrics.REDIS_SLAVES_NUMBER_IN_MEMORY_MISMATCH, sip, err)
 		if err != nil {
 			r.logger.Debug("Sentinel has more slaves in memory than spected")
 			if err := r.rfHealer.RestoreSentinel(sip); err != nil {
-				return err
+				return nil, err
 			}
+			r.events.Record(ctx, rf, corev1.EventTypeNormal, sentinelResetReason, fmt.Sprintf("sentinel %s was reset, it knew more replicas than the running ones", sip))
 		}
 	}
-	for _, sip := range sentinels {
+	return nil, nil
+}
+
+// checkSentinelConfig sets the custom and the global config on the sentinels.
+func (r *RedisFailoverHandler) checkSentinelConfig(ctx context.Context, s *TopologySnapshot) ([]rfservice.PodAction, error) {
+	rf := s.RF
+	for _, sip := range s.Sentinels {
 		err := r.rfHealer.SetSentinelCustomConfig(sip, rf)
 		setRedisCheckerMetrics(r.mClient, "sentinel", rf.Namespace, rf.Name, metrics.APPLY_SENTINEL_CONFIG, sip, err)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		err = r.rfHealer.SetSentinelGlobalConfig(sip, rf)
+		setRedisCheckerMetrics(r.mClient, "sentinel", rf.Namespace, rf.Name, metrics.SET_SENTINEL_CONFIG, sip, err)
+		if err != nil {
+			return nil, err
 		}
 	}
-	return nil
+	return nil, nil
+}
+
+// scaledSentinels returns the RF with the sentinel replicas set by the autoscaler of the sentinels, the
+// sentinels known by every sentinel are checked against them. The RF is returned as is when the sentinels
+// are not autoscaled or their deployment can't be read.
+func (r *RedisFailoverHandler) scaledSentinels(ctx context.Context, rf *redisfailoverv1.RedisFailover) *redisfailoverv1.RedisFailover {
+	if !rf.SentinelAutoScalingEnabled() {
+		return rf
+	}
+	d, err := r.k8sservice.GetDeployment(ctx, rf.Namespace, rfservice.GetSentinelName(rf))
+	if err != nil || d.Spec.Replicas == nil {
+		return rf
+	}
+	scaled := rf.DeepCopy()
+	scaled.Spec.Sentinel.Replicas = *d.Spec.Replicas
+	return scaled
 }
 
 func getRedisPort(p int32) string {
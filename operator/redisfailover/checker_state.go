@@ -0,0 +1,92 @@
+package redisfailover
+
+import (
+	"context"
+	"sync"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+)
+
+// The checker state kept in memory by the operator is stored on the checker state annotation of the
+// RF, so a restart of the operator doesn't count a failover twice nor start over the remediations
+// already escalated. It holds:
+//   - the last master seen and the master changes counted, by the stabilizer.
+//   - the stuck replicas and their escalation step, by the healer.
+//   - the consecutive checks every unresponsive sentinel has missed, by the healer.
+//
+// The rest of the state is safe to lose, it's rebuilt or it's stored elsewhere:
+//   - the sync slot queue, rebuilt from the replicas syncing on the next checks.
+//   - the promotion holds, taken again on the next check while the master is not confirmed down.
+//   - the stabilization window, seeded from the Progressing condition of the status.
+//   - the failovers reported by the sentinel events, the last one is on the status.
+//   - the node tuning warnings, only logged again.
+
+// checkerStateRestores tells the RFs whose checker state was already restored since the operator
+// started.
+type checkerStateRestores struct {
+	mu       sync.Mutex
+	restored map[string]bool
+}
+
+func newCheckerStateRestores() *checkerStateRestores {
+	return &checkerStateRestores{
+		restored: map[string]bool{},
+	}
+}
+
+// first returns true the first time it's called for the RF.
+func (c *checkerStateRestores) first(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.restored[key] {
+		return false
+	}
+	c.restored[key] = true
+	return true
+}
+
+// RestoreCheckerState restores the checker state stored on the RF, on its first reconcile since the
+// operator started. An invalid annotation is ignored, the state is then started over.
+func (r *RedisFailoverHandler) RestoreCheckerState(rf *redisfailoverv1.RedisFailover) {
+	key := rfKey(rf)
+	if !r.checkerStates.first(key) {
+		return
+	}
+	state, ok, err := redisfailoverv1.ParseCheckerState(rf.Annotations)
+	if err != nil {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("ignoring the checker state: %s", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	r.stabilizer.Restore(key, state.Master, state.MasterChanges)
+	r.rfHealer.RestoreRemediationState(rf, state)
+}
+
+// SaveCheckerState stores the checker state on the RF when it changed.
+func (r *RedisFailoverHandler) SaveCheckerState(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	key := rfKey(rf)
+	state := r.rfHealer.GetRemediationState(rf)
+	state.Master = r.stabilizer.LastMaster(key)
+	state.MasterChanges = r.stabilizer.MasterChanges(key)
+
+	value, err := redisfailoverv1.FormatCheckerState(state)
+	if err != nil {
+		return err
+	}
+	current, ok := rf.Annotations[redisfailoverv1.CheckerStateAnnotation]
+	if current == value || !ok && value == "{}" {
+		return nil
+	}
+
+	annotations := map[string]string{
+		redisfailoverv1.CheckerStateAnnotation: value,
+	}
+	if err := r.k8sservice.PatchRedisFailoverAnnotations(ctx, rf.Namespace, rf.Name, annotations); err != nil {
+		return err
+	}
+	rf.Annotations = util.MergeLabels(rf.Annotations, annotations)
+	return nil
+}
@@ -0,0 +1,184 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	redisfailoverfake "redis-operator/client/k8s/clientset/versioned/fake"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+)
+
+// expectHealthyCheck sets the expectations of a check finding the master healthy and nothing to heal.
+func expectHealthyCheck(mrfc *mRFService.RedisFailoverCheck, mrfh *mRFService.RedisFailoverHeal, master string) {
+	mrfc.On("CheckRedisNumber", mock.Anything, mock.Anything).Once().Return(nil)
+	mrfc.On("CheckSentinelNumber", mock.Anything, mock.Anything).Once().Return(nil)
+	mrfc.On("GetNumberMasters", mock.Anything, mock.Anything).Once().Return(1, nil)
+	mrfc.On("GetMasterIP", mock.Anything, mock.Anything).Twice().Return(master, nil)
+	mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, mock.Anything).Once().Return(nil)
+	mrfc.On("GetRedisesIPs", mock.Anything, mock.Anything).Once().Return([]string{master}, nil)
+	mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, mock.Anything).Once().Return("1", nil)
+	mrfc.On("GetRedisesSlavesPods", mock.Anything, mock.Anything).Once().Return([]string{}, nil)
+	mrfc.On("GetRedisesMasterPod", mock.Anything, mock.Anything).Once().Return("rfr-test-0", nil)
+	mrfc.On("GetRedisRevisionHash", mock.Anything, "rfr-test-0", mock.Anything).Once().Return("1", nil)
+	mrfc.On("GetSentinelsIPs", mock.Anything, mock.Anything).Once().Return([]string{}, nil)
+	mrfh.On("ClearSyncSlotQueue", mock.Anything).Once()
+	mrfh.On("PlanRoleLabels", mock.Anything, master, mock.Anything).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanStuckReplicas", mock.Anything, master, mock.Anything).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanRedisCustomConfig", mock.Anything, mock.Anything).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanRedisInPlaceRestarts", mock.Anything, master, mock.Anything).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, mock.Anything).Once().Return(nil, nil)
+}
+
+func TestCheckerStateSurvivesRestart(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	customCli := redisfailoverfake.NewSimpleClientset(generateRF(false, false))
+	ks := k8s.New(kubernetes.NewSimpleClientset(), nil, nil, customCli, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	getRF := func() *redisfailoverv1.RedisFailover {
+		rf, err := customCli.DatabasesV1().RedisFailovers(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		require.NoError(err)
+		return rf
+	}
+	remediations := redisfailoverv1.CheckerState{
+		StuckReplicas: []redisfailoverv1.StuckReplicaState{
+			{Pod: "rfr-test-1", Checks: 2, Step: 1, PartialSyncOK: 10, PartialSyncErr: 3},
+		},
+		UnresponsiveSentinels: map[string]int32{"rfs-test-1": 2},
+	}
+
+	// The first operator sees the sentinels fail over from 0.0.0.1 to 0.0.0.2, while a replica and
+	// a sentinel are being remediated.
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("GetRemediationState", mock.Anything).Twice().Return(remediations)
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, ks, metrics.Dummy, log.Dummy)
+
+	rf := getRF()
+	handler.RestoreCheckerState(rf)
+	for _, master := range []string{"0.0.0.1", "0.0.0.2"} {
+		expectHealthyCheck(mrfc, mrfh, master)
+		assert.NoError(handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{}))
+		assert.NoError(handler.SaveCheckerState(context.TODO(), rf))
+	}
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+	mrfh.AssertNotCalled(t, "RestoreRemediationState", mock.Anything, mock.Anything)
+
+	stored, ok, err := redisfailoverv1.ParseCheckerState(getRF().Annotations)
+	require.NoError(err)
+	require.True(ok)
+	assert.Equal("0.0.0.2", stored.Master)
+	assert.Equal(int64(1), stored.MasterChanges)
+	assert.Equal(remediations.StuckReplicas, stored.StuckReplicas)
+	assert.Equal(remediations.UnresponsiveSentinels, stored.UnresponsiveSentinels)
+
+	// The operator restarts. The remediations are restored once and the master seen before the
+	// restart is not counted as a failover, the next one is.
+	mrfc = &mRFService.RedisFailoverCheck{}
+	mrfh = &mRFService.RedisFailoverHeal{}
+	mrfh.On("RestoreRemediationState", mock.Anything, stored).Once()
+	mrfh.On("GetRemediationState", mock.Anything).Twice().Return(redisfailoverv1.CheckerState{})
+	handler = rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, ks, metrics.Dummy, log.Dummy)
+
+	rf = getRF()
+	for _, master := range []string{"0.0.0.2", "0.0.0.3"} {
+		handler.RestoreCheckerState(rf)
+		expectHealthyCheck(mrfc, mrfh, master)
+		assert.NoError(handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{}))
+		assert.NoError(handler.SaveCheckerState(context.TODO(), rf))
+	}
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+
+	stored, ok, err = redisfailoverv1.ParseCheckerState(getRF().Annotations)
+	require.NoError(err)
+	require.True(ok)
+	assert.Equal(redisfailoverv1.CheckerState{Master: "0.0.0.3", MasterChanges: 2}, stored)
+}
+
+func TestCheckerStateInterruptedFailover(t *testing.T) {
+	// The operator is stopped after the failover to 0.0.0.2 and before, or after, its checker state is
+	// recorded. The next operator counts the failover once in both orders.
+	tests := []struct {
+		name   string
+		stored redisfailoverv1.CheckerState
+	}{
+		{
+			name:   "killed before recording the marker",
+			stored: redisfailoverv1.CheckerState{Master: "0.0.0.1"},
+		},
+		{
+			name:   "killed after recording the marker",
+			stored: redisfailoverv1.CheckerState{Master: "0.0.0.2", MasterChanges: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rf := generateRF(false, false)
+			value, err := redisfailoverv1.FormatCheckerState(test.stored)
+			require.NoError(err)
+			rf.Annotations = map[string]string{redisfailoverv1.CheckerStateAnnotation: value}
+			customCli := redisfailoverfake.NewSimpleClientset(rf)
+			ks := k8s.New(kubernetes.NewSimpleClientset(), nil, nil, customCli, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("RestoreRemediationState", mock.Anything, test.stored).Once()
+			mrfh.On("GetRemediationState", mock.Anything).Return(redisfailoverv1.CheckerState{})
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, ks, metrics.Dummy, log.Dummy)
+
+			rf, err = customCli.DatabasesV1().RedisFailovers(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+			require.NoError(err)
+			handler.RestoreCheckerState(rf)
+			expectHealthyCheck(mrfc, mrfh, "0.0.0.2")
+			assert.NoError(handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{}))
+			assert.NoError(handler.SaveCheckerState(context.TODO(), rf))
+			mrfc.AssertExpectations(t)
+			mrfh.AssertExpectations(t)
+
+			rf, err = customCli.DatabasesV1().RedisFailovers(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+			require.NoError(err)
+			stored, ok, err := redisfailoverv1.ParseCheckerState(rf.Annotations)
+			require.NoError(err)
+			require.True(ok)
+			assert.Equal(redisfailoverv1.CheckerState{Master: "0.0.0.2", MasterChanges: 1}, stored)
+		})
+	}
+}
+
+func TestSaveCheckerStateUnchanged(t *testing.T) {
+	assert := assert.New(t)
+
+	// Nothing is written while there is no state, nor when it didn't change.
+	rf := generateRF(false, false)
+	customCli := redisfailoverfake.NewSimpleClientset(rf)
+	ks := k8s.New(kubernetes.NewSimpleClientset(), nil, nil, customCli, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("GetRemediationState", mock.Anything).Return(redisfailoverv1.CheckerState{})
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, mrfh, ks, metrics.Dummy, log.Dummy)
+
+	assert.NoError(handler.SaveCheckerState(context.TODO(), rf))
+	assert.NotContains(rf.Annotations, redisfailoverv1.CheckerStateAnnotation)
+
+	rf.Annotations = map[string]string{redisfailoverv1.CheckerStateAnnotation: "{}"}
+	customCli.ClearActions()
+	assert.NoError(handler.SaveCheckerState(context.TODO(), rf))
+	assert.Empty(customCli.Actions())
+}
@@ -1,6 +1,7 @@
 package redisfailover_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -11,15 +12,39 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
 	"redis-operator/log"
 	"redis-operator/metrics"
 	mRFService "redis-operator/mocks/operator/redisfailover/service"
 	mK8SService "redis-operator/mocks/service/k8s"
 	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
 )
 
+// configActions returns the actions setting the custom config on the pods.
+func configActions(pods ...string) []rfservice.PodAction {
+	actions := []rfservice.PodAction{}
+	for _, pod := range pods {
+		actions = append(actions, rfservice.PodAction{
+			Pod:   pod,
+			Kind:  rfservice.PodActionApplyConfig,
+			Apply: func() error { return nil },
+		})
+	}
+	return actions
+}
+
+// eventReason matches the events of the reason.
+func eventReason(reason string) interface{} {
+	return mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.Reason == reason
+	})
+}
+
 func TestCheckAndHeal(t *testing.T) {
 	tests := []struct {
 		name                           string
@@ -265,48 +290,51 @@ func TestCheckAndHeal(t *testing.T) {
 			mrfh := &mRFService.RedisFailoverHeal{}
 
 			if test.redisCheckNumberOK {
-				mrfc.On("CheckRedisNumber", rf).Once().Return(nil)
+				mrfc.On("CheckRedisNumber", mock.Anything, rf).Once().Return(nil)
 			} else {
 				continueTests = false
-				mrfc.On("CheckRedisNumber", rf).Once().Return(errors.New(""))
+				mrfc.On("CheckRedisNumber", mock.Anything, rf).Once().Return(errors.New(""))
 			}
 
 			if allowSentinels {
-				mrfc.On("CheckSentinelNumber", rf).Once().Return(nil)
+				mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
 			}
 
 			if bootstrappingTests && continueTests {
-				// once to get ips for config update, once for the UpdateRedisesPods go right
-				mrfc.On("GetRedisesIPs", rf).Twice().Return([]string{"0.0.0.1", "0.0.0.2", "0.0.0.3"}, nil)
-				mrfh.On("SetRedisCustomConfig", "0.0.0.1", rf).Once().Return(nil)
-				mrfh.On("SetRedisCustomConfig", "0.0.0.2", rf).Once().Return(nil)
-				mrfh.On("SetRedisCustomConfig", "0.0.0.3", rf).Once().Return(nil)
-				mrfc.On("CheckRedisSlavesReady", "0.0.0.1", rf).Once().Return(true, nil)
-				mrfc.On("CheckRedisSlavesReady", "0.0.0.2", rf).Once().Return(true, nil)
-				mrfc.On("CheckRedisSlavesReady", "0.0.0.3", rf).Once().Return(true, nil)
-				mrfc.On("GetStatefulSetUpdateRevision", rf).Once().Return("1", nil)
-				mrfc.On("GetRedisesSlavesPods", rf).Once().Return([]string{}, nil)
+				mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{"0.0.0.1", "0.0.0.2", "0.0.0.3"}, nil)
+				mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Once().Return(configActions("rfr-test-0", "rfr-test-1", "rfr-test-2"), nil)
+				mrfc.On("CheckRedisSlavesReady", mock.Anything, "0.0.0.1", rf).Once().Return(true, nil)
+				mrfc.On("CheckRedisSlavesReady", mock.Anything, "0.0.0.2", rf).Once().Return(true, nil)
+				mrfc.On("CheckRedisSlavesReady", mock.Anything, "0.0.0.3", rf).Once().Return(true, nil)
+				mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Once().Return("1", nil)
+				mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Once().Return([]string{}, nil)
 
 				if test.redisSetMasterOnAllOK {
-					mrfh.On("SetExternalMasterOnAll", bootstrapMaster, bootstrapMasterPort, rf).Once().Return(nil)
+					mrfh.On("PlanExternalMasterOnAll", mock.Anything, bootstrapMaster, bootstrapMasterPort, rf).Once().Return([]rfservice.PodAction{}, nil)
 				} else {
 					expErr = true
-					mrfh.On("SetExternalMasterOnAll", bootstrapMaster, bootstrapMasterPort, rf).Once().Return(errors.New(""))
+					mrfh.On("PlanExternalMasterOnAll", mock.Anything, bootstrapMaster, bootstrapMasterPort, rf).Once().Return(nil, errors.New(""))
 				}
 			} else if continueTests {
-				mrfc.On("GetNumberMasters", rf).Once().Return(test.nMasters, nil)
+				mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(test.nMasters, nil)
 				switch test.nMasters {
 				case 0:
-					mrfc.On("GetRedisesIPs", rf).Once().Return(make([]string, test.nRedis), nil)
+					mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return(make([]string, test.nRedis), nil)
 					if test.nRedis == 1 {
-						mrfh.On("MakeMaster", mock.Anything, rf).Once().Return(nil)
+						mrfc.On("CorroborateMasterDown", mock.Anything, "", rf).Once().Return(true, "no sentinel is reachable and there is no previous master", nil)
+						mk.On("GetStatefulSetRolloutStatus", mock.Anything, namespace, "rfr-test").Once().Return(true, nil)
+						mrfh.On("MakeMaster", mock.Anything, mock.Anything, rf).Once().Return(nil)
+						mk.On("CreateEvent", mock.Anything, namespace, eventReason("MasterFailover")).Once().Return(nil)
 						break
 					}
 					if test.forceNewMaster {
-						mrfc.On("GetMinimumRedisPodTime", rf).Once().Return(1*time.Hour, nil)
-						mrfh.On("SetOldestAsMaster", rf).Once().Return(nil)
+						mrfc.On("GetMinimumRedisPodTime", mock.Anything, rf).Once().Return(1*time.Hour, nil)
+						mrfc.On("CorroborateMasterDown", mock.Anything, "", rf).Once().Return(true, "no sentinel is reachable and there is no previous master", nil)
+						mk.On("GetStatefulSetRolloutStatus", mock.Anything, namespace, "rfr-test").Once().Return(true, nil)
+						mrfh.On("SetOldestAsMaster", mock.Anything, rf).Once().Return(nil)
+						mk.On("CreateEvent", mock.Anything, namespace, eventReason("MasterFailover")).Once().Return(nil)
 					} else {
-						mrfc.On("GetMinimumRedisPodTime", rf).Once().Return(1*time.Second, nil)
+						mrfc.On("GetMinimumRedisPodTime", mock.Anything, rf).Once().Return(1*time.Second, nil)
 						continueTests = false
 					}
 				case 1:
@@ -316,30 +344,38 @@ func TestCheckAndHeal(t *testing.T) {
 					expErr = true
 				}
 				if !expErr && continueTests {
-					mrfc.On("GetMasterIP", rf).Twice().Return(master, nil)
+					mrfc.On("GetMasterIP", mock.Anything, rf).Twice().Return(master, nil)
 					if test.slavesOK {
-						mrfc.On("CheckAllSlavesFromMaster", master, rf).Once().Return(nil)
+						mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, rf).Once().Return(nil)
+						mrfh.On("ClearSyncSlotQueue", rf).Once()
 					} else {
-						mrfc.On("CheckAllSlavesFromMaster", master, rf).Once().Return(errors.New(""))
+						mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, rf).Once().Return(errors.New(""))
 						if test.redisSetMasterOnAllOK {
-							mrfh.On("SetMasterOnAll", master, rf).Once().Return(nil)
+							mrfh.On("PlanMasterOnAll", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
 						} else {
 							expErr = true
-							mrfh.On("SetMasterOnAll", master, rf).Once().Return(errors.New(""))
+							mrfh.On("PlanMasterOnAll", mock.Anything, master, rf).Once().Return(nil, errors.New(""))
 						}
 
 					}
-					mrfc.On("GetRedisesIPs", rf).Twice().Return([]string{master}, nil)
-					mrfc.On("GetStatefulSetUpdateRevision", rf).Once().Return("1", nil)
-					mrfc.On("GetRedisesSlavesPods", rf).Once().Return([]string{}, nil)
-					mrfc.On("GetRedisesMasterPod", rf).Once().Return(master, nil)
-					mrfc.On("GetRedisRevisionHash", master, rf).Once().Return("1", nil)
-					mrfh.On("SetRedisCustomConfig", master, rf).Once().Return(nil)
+					if !expErr {
+						mrfh.On("PlanRoleLabels", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+						mrfh.On("PlanStuckReplicas", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+						mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Once().Return(configActions("rfr-test-0"), nil)
+						mrfh.On("PlanRedisInPlaceRestarts", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+						mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{master}, nil)
+						mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Once().Return("1", nil)
+						mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Once().Return([]string{}, nil)
+						mrfc.On("GetRedisesMasterPod", mock.Anything, rf).Once().Return(master, nil)
+						mrfc.On("GetRedisRevisionHash", mock.Anything, master, rf).Once().Return("1", nil)
+					}
 				}
 			}
 
 			if allowSentinels && !expErr && continueTests {
-				mrfc.On("GetSentinelsIPs", rf).Once().Return([]string{sentinel}, nil)
+				mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{sentinel}, nil)
+				mrfc.On("CheckSentinelResponding", sentinel).Once().Return(nil)
+				mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
 				if test.sentinelMonitorOK {
 					if test.bootstrapping {
 						mrfc.On("CheckSentinelMonitor", sentinel, bootstrapMaster, bootstrapMasterPort).Once().Return(nil)
@@ -349,10 +385,10 @@ func TestCheckAndHeal(t *testing.T) {
 				} else {
 					if test.bootstrapping {
 						mrfc.On("CheckSentinelMonitor", sentinel, bootstrapMaster, bootstrapMasterPort).Once().Return(errors.New(""))
-						mrfh.On("NewSentinelMonitorWithPort", sentinel, bootstrapMaster, bootstrapMasterPort, rf).Once().Return(nil)
+						mrfh.On("NewSentinelMonitorWithPort", mock.Anything, sentinel, bootstrapMaster, bootstrapMasterPort, rf).Once().Return(nil)
 					} else {
 						mrfc.On("CheckSentinelMonitor", sentinel, master, "0").Once().Return(errors.New(""))
-						mrfh.On("NewSentinelMonitor", sentinel, master, rf).Once().Return(nil)
+						mrfh.On("NewSentinelMonitor", mock.Anything, sentinel, master, rf).Once().Return(nil)
 					}
 				}
 				if test.sentinelNumberInMemoryOK {
@@ -360,18 +396,21 @@ func TestCheckAndHeal(t *testing.T) {
 				} else {
 					mrfc.On("CheckSentinelNumberInMemory", sentinel, rf).Once().Return(errors.New(""))
 					mrfh.On("RestoreSentinel", sentinel).Once().Return(nil)
+					mk.On("CreateEvent", mock.Anything, namespace, eventReason("SentinelReset")).Once().Return(nil)
 				}
 				if test.sentinelSlavesNumberInMemoryOK {
 					mrfc.On("CheckSentinelSlavesNumberInMemory", sentinel, rf).Once().Return(nil)
 				} else {
 					mrfc.On("CheckSentinelSlavesNumberInMemory", sentinel, rf).Once().Return(errors.New(""))
 					mrfh.On("RestoreSentinel", sentinel).Once().Return(nil)
+					mk.On("CreateEvent", mock.Anything, namespace, eventReason("SentinelReset")).Once().Return(nil)
 				}
 				mrfh.On("SetSentinelCustomConfig", sentinel, rf).Once().Return(nil)
+				mrfh.On("SetSentinelGlobalConfig", sentinel, rf).Once().Return(nil)
 			}
 
 			handler := rfOperator.NewRedisFailoverHandler(config, mrfs, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
-			err := handler.CheckAndHeal(rf)
+			err := handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{})
 
 			if expErr {
 				assert.Error(err)
@@ -380,6 +419,7 @@ func TestCheckAndHeal(t *testing.T) {
 			}
 			mrfc.AssertExpectations(t)
 			mrfh.AssertExpectations(t)
+			mk.AssertExpectations(t)
 		})
 	}
 }
@@ -834,7 +874,7 @@ func TestUpdate(t *testing.T) {
 			mrfs := &mRFService.RedisFailoverClient{}
 
 			mrfc := &mRFService.RedisFailoverCheck{}
-			mrfc.On("GetRedisesIPs", rf).Once().Return([]string{"0.0.0.0", "0.0.0.1", "1.1.1.1"}, nil)
+			mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{"0.0.0.0", "0.0.0.1", "1.1.1.1"}, nil)
 
 			next := true
 			if !test.bootstrapping {
@@ -842,12 +882,12 @@ func TestUpdate(t *testing.T) {
 				if test.noMaster {
 					master = ""
 				}
-				mrfc.On("GetMasterIP", rf).Once().Return(master, nil)
+				mrfc.On("GetMasterIP", mock.Anything, rf).Once().Return(master, nil)
 			}
 
 			for _, pod := range test.pods {
 				if !pod.master {
-					mrfc.On("CheckRedisSlavesReady", pod.pod.Status.PodIP, rf).Once().Return(pod.ready, nil)
+					mrfc.On("CheckRedisSlavesReady", mock.Anything, pod.pod.Status.PodIP, rf).Once().Return(pod.ready, nil)
 				}
 				if !pod.ready {
 					next = false
@@ -855,19 +895,21 @@ func TestUpdate(t *testing.T) {
 				}
 			}
 			mrfh := &mRFService.RedisFailoverHeal{}
+			mk := &mK8SService.Services{}
 
 			if next {
 				replicas := []string{"slave1", "slave2"}
 				if test.bootstrapping || test.noMaster {
 					replicas = append(replicas, "slave3")
 				}
-				mrfc.On("GetStatefulSetUpdateRevision", rf).Once().Return(test.ssVersion, nil)
-				mrfc.On("GetRedisesSlavesPods", rf).Once().Return(replicas, nil)
+				mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Once().Return(test.ssVersion, nil)
+				mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Once().Return(replicas, nil)
 
 				for _, pod := range test.pods {
-					mrfc.On("GetRedisRevisionHash", pod.pod.ObjectMeta.Name, rf).Once().Return(pod.pod.ObjectMeta.Labels[appsv1.ControllerRevisionHashLabelKey], nil)
+					mrfc.On("GetRedisRevisionHash", mock.Anything, pod.pod.ObjectMeta.Name, rf).Once().Return(pod.pod.ObjectMeta.Labels[appsv1.ControllerRevisionHashLabelKey], nil)
 					if pod.pod.ObjectMeta.Labels[appsv1.ControllerRevisionHashLabelKey] != test.ssVersion {
-						mrfh.On("DeletePod", pod.pod.ObjectMeta.Name, rf).Once().Return(nil)
+						mrfh.On("DeletePod", mock.Anything, pod.pod.ObjectMeta.Name, rf).Once().Return(nil)
+						mk.On("CreateEvent", mock.Anything, namespace, eventReason("PodDeleted")).Once().Return(nil)
 						if pod.master == false {
 							next = false
 							break
@@ -877,17 +919,19 @@ func TestUpdate(t *testing.T) {
 				fmt.Printf("%v - %v\n", test.name, next)
 				if next && !test.bootstrapping {
 					if test.noMaster {
-						mrfc.On("GetRedisesMasterPod", rf).Once().Return("", errors.New(""))
+						mrfc.On("GetRedisesMasterPod", mock.Anything, rf).Once().Return("", errors.New(""))
 					} else {
-						mrfc.On("GetRedisesMasterPod", rf).Once().Return("master", nil)
+						mrfc.On("GetRedisesMasterPod", mock.Anything, rf).Once().Return("master", nil)
 					}
 				}
 			}
 
-			mk := &mK8SService.Services{}
-
 			handler := rfOperator.NewRedisFailoverHandler(config, mrfs, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
-			err := handler.UpdateRedisesPods(rf)
+			actions, err := handler.PlanRedisesPodsUpdate(context.TODO(), rf, rfservice.FeatureGates{})
+			for _, action := range actions {
+				assert.Equal(rfservice.PodActionDelete, action.Kind)
+				assert.NoError(action.Apply())
+			}
 
 			if test.errExpected {
 				assert.Error(err)
@@ -897,7 +941,427 @@ func TestUpdate(t *testing.T) {
 
 			mrfc.AssertExpectations(t)
 			mrfh.AssertExpectations(t)
+			mk.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCheckAndHealArbitratesPodActions(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	master := "0.0.0.0"
+
+	applied := []string{}
+	action := func(pod string, kind rfservice.PodActionKind) rfservice.PodAction {
+		return rfservice.PodAction{
+			Pod:  pod,
+			Kind: kind,
+			Apply: func() error {
+				applied = append(applied, fmt.Sprintf("%s %s", kind, pod))
+				return nil
+			},
+		}
+	}
+
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("CheckRedisNumber", mock.Anything, rf).Once().Return(nil)
+	mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+	mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(1, nil)
+	mrfc.On("GetMasterIP", mock.Anything, rf).Twice().Return(master, nil)
+	mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, rf).Once().Return(errors.New(""))
+	// The updater wants to delete the stale rfr-test-2.
+	mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{master, "0.0.0.1", "0.0.0.2"}, nil)
+	mrfc.On("CheckRedisSlavesReady", mock.Anything, "0.0.0.1", rf).Once().Return(true, nil)
+	mrfc.On("CheckRedisSlavesReady", mock.Anything, "0.0.0.2", rf).Once().Return(true, nil)
+	mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Once().Return("2", nil)
+	mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Once().Return([]string{"rfr-test-1", "rfr-test-2"}, nil)
+	mrfc.On("GetRedisRevisionHash", mock.Anything, "rfr-test-1", rf).Once().Return("2", nil)
+	mrfc.On("GetRedisRevisionHash", mock.Anything, "rfr-test-2", rf).Once().Return("1", nil)
+	mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{}, nil)
+
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
+	// The healer wants to reconfigure the master and rfr-test-2, and to fix the labels of rfr-test-1 and rfr-test-2.
+	mrfh.On("PlanMasterOnAll", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{
+		action("rfr-test-0", rfservice.PodActionReconfigure),
+		action("rfr-test-2", rfservice.PodActionReconfigure),
+	}, nil)
+	mrfh.On("PlanRoleLabels", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{
+		action("rfr-test-1", rfservice.PodActionUpdateLabels),
+		action("rfr-test-2", rfservice.PodActionUpdateLabels),
+	}, nil)
+	mrfh.On("PlanStuckReplicas", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Once().Return([]rfservice.PodAction{
+		action("rfr-test-0", rfservice.PodActionApplyConfig),
+		action("rfr-test-1", rfservice.PodActionApplyConfig),
+		action("rfr-test-2", rfservice.PodActionApplyConfig),
+	}, nil)
+	mrfh.On("DeletePod", mock.Anything, "rfr-test-2", rf).Once().Run(func(mock.Arguments) {
+		applied = append(applied, "delete rfr-test-2")
+	}).Return(nil)
+
+	mk := &mK8SService.Services{}
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.Reason == "PodDeleted" && e.Message == "pod rfr-test-2 was deleted to update it to the statefulset revision"
+	})).Once().Return(nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	err := handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{})
+	assert.NoError(err)
+
+	// Every pod gets a single action: delete wins over reconfigure, reconfigure over the labels and
+	// the labels over the config.
+	assert.Equal([]string{
+		"reconfigure rfr-test-0",
+		"delete rfr-test-2",
+		"update labels rfr-test-1",
+	}, applied)
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+	mk.AssertExpectations(t)
+}
+
+func TestCheckAndHealRemediatesStuckReplicas(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	master := "0.0.0.0"
+
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("CheckRedisNumber", mock.Anything, rf).Once().Return(nil)
+	mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+	mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(1, nil)
+	mrfc.On("GetMasterIP", mock.Anything, rf).Twice().Return(master, nil)
+	mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, rf).Once().Return(nil)
+	// The stuck replica is not ready, so the update is not planned.
+	mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{master, "0.0.0.1"}, nil)
+	mrfc.On("CheckRedisSlavesReady", mock.Anything, "0.0.0.1", rf).Once().Return(false, nil)
+	mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{}, nil)
+
+	restarted := false
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
+	mrfh.On("ClearSyncSlotQueue", rf).Once()
+	mrfh.On("PlanRoleLabels", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanStuckReplicas", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{{
+		Pod:    "rfr-test-1",
+		Kind:   rfservice.PodActionDelete,
+		Reason: "restart it",
+		Apply:  func() error { restarted = true; return nil },
+	}}, nil)
+	mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Once().Return(configActions("rfr-test-0", "rfr-test-1"), nil)
+	mrfh.On("PlanRedisInPlaceRestarts", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+
+	mk := &mK8SService.Services{}
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.Reason == "StuckReplicaRestarted" && e.Type == corev1.EventTypeWarning && e.Message == "delete pod rfr-test-1: restart it"
+	})).Once().Return(nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	err := handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{})
+	assert.NoError(err)
+
+	assert.True(restarted)
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+	mk.AssertExpectations(t)
+}
+
+func TestCheckAndHealRequeuesBlockedEvictions(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	master := "0.0.0.0"
+	blocked := kubeerrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	blocked.ErrStatus.Details.Causes = []metav1.StatusCause{{Type: policyv1.DisruptionBudgetCause}}
+
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("CheckRedisNumber", mock.Anything, rf).Once().Return(nil)
+	mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+	mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(1, nil)
+	mrfc.On("GetMasterIP", mock.Anything, rf).Twice().Return(master, nil)
+	mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, rf).Once().Return(nil)
+	mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{master, "0.0.0.1"}, nil)
+	mrfc.On("CheckRedisSlavesReady", mock.Anything, "0.0.0.1", rf).Once().Return(false, nil)
+	mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{}, nil)
+
+	configured := false
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
+	mrfh.On("ClearSyncSlotQueue", rf).Once()
+	mrfh.On("PlanRoleLabels", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanStuckReplicas", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{{
+		Pod:    "rfr-test-1",
+		Kind:   rfservice.PodActionDelete,
+		Reason: "restart it",
+		Apply:  func() error { return blocked },
+	}}, nil)
+	mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Once().Return([]rfservice.PodAction{{
+		Pod:   "rfr-test-0",
+		Kind:  rfservice.PodActionApplyConfig,
+		Apply: func() error { configured = true; return nil },
+	}}, nil)
+	mrfh.On("PlanRedisInPlaceRestarts", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+
+	mk := &mK8SService.Services{}
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.Reason == "EvictionBlocked" && e.Type == corev1.EventTypeWarning && e.Message == "delete pod rfr-test-1: restart it: the eviction would violate the PodDisruptionBudget"
+	})).Once().Return(nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	err := handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{})
+
+	// The eviction refused by the pdb doesn't fail the checks, the other actions are still taken and
+	// the restart is not recorded.
+	assert.NoError(err)
+	assert.True(configured)
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+	mk.AssertExpectations(t)
+	mk.AssertNotCalled(t, "CreateEvent", mock.Anything, namespace, eventReason("StuckReplicaRestarted"))
+}
+
+func TestCheckAndHealRestartsRedisInPlace(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	rf.Spec.Redis.ZeroDowntimeReload.Enabled = true
+	rf.Spec.Redis.CustomConfig = []string{"io-threads 4"}
+	master := "0.0.0.0"
+
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("CheckRedisNumber", mock.Anything, rf).Once().Return(nil)
+	mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+	mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(1, nil)
+	mrfc.On("GetMasterIP", mock.Anything, rf).Twice().Return(master, nil)
+	mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, rf).Once().Return(nil)
+	// The pods are on the statefulset revision, nothing is deleted.
+	mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{master}, nil)
+	mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Once().Return("1", nil)
+	mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Once().Return([]string{}, nil)
+	mrfc.On("GetRedisesMasterPod", mock.Anything, rf).Once().Return("rfr-test-0", nil)
+	mrfc.On("GetRedisRevisionHash", mock.Anything, "rfr-test-0", rf).Once().Return("1", nil)
+	mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{}, nil)
+
+	restarted := false
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
+	mrfh.On("ClearSyncSlotQueue", rf).Once()
+	mrfh.On("PlanRoleLabels", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanStuckReplicas", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Once().Return(configActions("rfr-test-0"), nil)
+	mrfh.On("PlanRedisInPlaceRestarts", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{{
+		Pod:    "rfr-test-0",
+		Kind:   rfservice.PodActionRestart,
+		Reason: "restart redis in place to apply io-threads 4",
+		Apply:  func() error { restarted = true; return nil },
+	}}, nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, &mK8SService.Services{}, metrics.Dummy, log.Dummy)
+	err := handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{})
+	assert.NoError(err)
+
+	// The restart wins over the custom config of the pod.
+	assert.True(restarted)
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+}
+
+func TestCheckAndHealRestartsUnresponsiveSentinels(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	master := "0.0.0.0"
+	sentinel, unresponsive := "1.1.1.1", "1.1.1.2"
+
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("CheckRedisNumber", mock.Anything, rf).Once().Return(nil)
+	mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+	mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(1, nil)
+	mrfc.On("GetMasterIP", mock.Anything, rf).Twice().Return(master, nil)
+	mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, rf).Once().Return(nil)
+	mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{master}, nil)
+	mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Once().Return("1", nil)
+	mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Once().Return([]string{}, nil)
+	mrfc.On("GetRedisesMasterPod", mock.Anything, rf).Once().Return("rfr-test-0", nil)
+	mrfc.On("GetRedisRevisionHash", mock.Anything, "rfr-test-0", rf).Once().Return("1", nil)
+	mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{sentinel, unresponsive}, nil)
+	mrfc.On("CheckSentinelResponding", sentinel).Once().Return(nil)
+	mrfc.On("CheckSentinelResponding", unresponsive).Once().Return(errors.New("i/o timeout"))
+	// Only the sentinel answering is checked afterwards.
+	mrfc.On("CheckSentinelMonitor", sentinel, master, "0").Once().Return(nil)
+	mrfc.On("CheckSentinelNumberInMemory", sentinel, rf).Once().Return(nil)
+	mrfc.On("CheckSentinelSlavesNumberInMemory", sentinel, rf).Once().Return(nil)
+
+	restarted := false
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("ClearSyncSlotQueue", rf).Once()
+	mrfh.On("PlanRoleLabels", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanStuckReplicas", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Once().Return(configActions("rfr-test-0"), nil)
+	mrfh.On("PlanRedisInPlaceRestarts", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{unresponsive}, rf).Once().Return([]rfservice.PodAction{{
+		Pod:    "rfs-test-1",
+		Kind:   rfservice.PodActionDelete,
+		Reason: "restart it",
+		Apply:  func() error { restarted = true; return nil },
+	}}, nil)
+	mrfh.On("SetSentinelCustomConfig", sentinel, rf).Once().Return(nil)
+	mrfh.On("SetSentinelGlobalConfig", sentinel, rf).Once().Return(nil)
+
+	mk := &mK8SService.Services{}
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.Reason == "UnresponsiveSentinelRestarted" && e.Type == corev1.EventTypeWarning && e.Message == "delete pod rfs-test-1: restart it"
+	})).Once().Return(nil)
 
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	err := handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{})
+	assert.NoError(err)
+
+	assert.True(restarted)
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+	mk.AssertExpectations(t)
+}
+
+func TestCheckAndHealExternalNodes(t *testing.T) {
+	master := redisfailoverv1.RedisExternalNode{Host: "10.0.0.1", Port: "6379"}
+	slave := redisfailoverv1.RedisExternalNode{Host: "10.0.0.2", Port: "6380"}
+	sentinel := "1.1.1.1"
+
+	tests := []struct {
+		name              string
+		masters           []redisfailoverv1.RedisExternalNode
+		slavesOK          bool
+		sentinelMonitorOK bool
+		expErr            bool
+	}{
+		{
+			name:              "Everything ok, no need to heal",
+			masters:           []redisfailoverv1.RedisExternalNode{master},
+			slavesOK:          true,
+			sentinelMonitorOK: true,
+		},
+		{
+			name:              "Slaves from master wrong",
+			masters:           []redisfailoverv1.RedisExternalNode{master},
+			slavesOK:          false,
+			sentinelMonitorOK: true,
+		},
+		{
+			name:              "Sentinels not pointing correct monitor",
+			masters:           []redisfailoverv1.RedisExternalNode{master},
+			slavesOK:          true,
+			sentinelMonitorOK: false,
+		},
+		{
+			name:    "No masters, wait for the sentinels",
+			masters: []redisfailoverv1.RedisExternalNode{},
+		},
+		{
+			name:    "Multiple masters",
+			masters: []redisfailoverv1.RedisExternalNode{master, slave},
+			expErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Spec.Redis.Replicas = 2
+			rf.Spec.Redis.ExternalNodes = []redisfailoverv1.RedisExternalNode{master, slave}
+
+			config := generateConfig()
+			// There is no statefulset, any call to the k8s service about the redis would fail the test.
+			mk := &mK8SService.Services{}
+			mrfs := &mRFService.RedisFailoverClient{}
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfh := &mRFService.RedisFailoverHeal{}
+
+			mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+			mrfc.On("GetExternalMasters", mock.Anything, rf).Once().Return(test.masters, nil)
+			if len(test.masters) == 1 {
+				if test.slavesOK {
+					mrfc.On("CheckExternalSlavesFromMaster", mock.Anything, master, rf).Once().Return(nil)
+					mrfh.On("ClearSyncSlotQueue", rf).Once()
+				} else {
+					mrfc.On("CheckExternalSlavesFromMaster", mock.Anything, master, rf).Once().Return(errors.New(""))
+					mrfh.On("SetExternalNodesMaster", mock.Anything, master, rf).Once().Return(nil)
+				}
+				mrfh.On("SetExternalRedisCustomConfig", mock.Anything, master, rf).Once().Return(nil)
+				mrfh.On("SetExternalRedisCustomConfig", mock.Anything, slave, rf).Once().Return(nil)
+
+				mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{sentinel}, nil)
+				mrfc.On("CheckSentinelResponding", sentinel).Once().Return(nil)
+				mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
+				if test.sentinelMonitorOK {
+					mrfc.On("CheckSentinelMonitor", sentinel, master.Host, master.Port).Once().Return(nil)
+				} else {
+					mrfc.On("CheckSentinelMonitor", sentinel, master.Host, master.Port).Once().Return(errors.New(""))
+					mrfh.On("NewSentinelMonitorWithPort", mock.Anything, sentinel, master.Host, master.Port, rf).Once().Return(nil)
+				}
+				mrfc.On("CheckSentinelNumberInMemory", sentinel, rf).Once().Return(nil)
+				mrfc.On("CheckSentinelSlavesNumberInMemory", sentinel, rf).Once().Return(nil)
+				mrfh.On("SetSentinelCustomConfig", sentinel, rf).Once().Return(nil)
+				mrfh.On("SetSentinelGlobalConfig", sentinel, rf).Once().Return(nil)
+			}
+
+			handler := rfOperator.NewRedisFailoverHandler(config, mrfs, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{})
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			mrfc.AssertExpectations(t)
+			mrfh.AssertExpectations(t)
+			mk.AssertExpectations(t)
 		})
 	}
 }
+
+func TestCheckAndHealAutoScaledSentinels(t *testing.T) {
+	assert := assert.New(t)
+
+	master := redisfailoverv1.RedisExternalNode{Host: "10.0.0.1", Port: "6379"}
+	sentinel := "1.1.1.1"
+	rf := generateRF(false, false)
+	rf.Spec.Redis.ExternalNodes = []redisfailoverv1.RedisExternalNode{master}
+	rf.Spec.SentinelAutoScaling = &redisfailoverv1.SentinelAutoScalingSettings{MaxReplicas: 7}
+
+	// The sentinels known by every sentinel are checked against the replicas set by the autoscaler.
+	scaled := int32(5)
+	mk := &mK8SService.Services{}
+	mk.On("GetDeployment", mock.Anything, namespace, "rfs-test").Once().Return(&appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &scaled}}, nil)
+	isScaled := mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+		return got.Name == rf.Name && got.Spec.Sentinel.Replicas == scaled
+	})
+
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+	mrfc.On("GetExternalMasters", mock.Anything, rf).Once().Return([]redisfailoverv1.RedisExternalNode{master}, nil)
+	mrfc.On("CheckExternalSlavesFromMaster", mock.Anything, master, rf).Once().Return(nil)
+	mrfh.On("ClearSyncSlotQueue", rf).Once()
+	mrfh.On("SetExternalRedisCustomConfig", mock.Anything, master, rf).Once().Return(nil)
+	mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{sentinel}, nil)
+	mrfc.On("CheckSentinelResponding", sentinel).Once().Return(nil)
+	mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
+	mrfc.On("CheckSentinelMonitor", sentinel, master.Host, master.Port).Once().Return(nil)
+	mrfc.On("CheckSentinelNumberInMemory", sentinel, isScaled).Once().Return(nil)
+	mrfc.On("CheckSentinelSlavesNumberInMemory", sentinel, rf).Once().Return(nil)
+	mrfh.On("SetSentinelCustomConfig", sentinel, rf).Once().Return(nil)
+	mrfh.On("SetSentinelGlobalConfig", sentinel, rf).Once().Return(nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	assert.NoError(handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{}))
+
+	assert.Equal(int32(3), rf.Spec.Sentinel.Replicas)
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+	mk.AssertExpectations(t)
+}
@@ -0,0 +1,249 @@
+package redisfailover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// Names of the checks needed by the others, they can't be disabled. The names of the other checks are
+// the ones of rfservice.Checks.
+const (
+	masterCheck     = "master"
+	podActionsCheck = "pod-actions"
+	sentinelsCheck  = "sentinels"
+)
+
+// errStopChecks is returned by a check finding the RF converging, as a redis pod being created or a
+// failover in progress, the later checks are skipped without an error.
+var errStopChecks = errors.New("the checks are stopped")
+
+// orStopChecks returns the error, or errStopChecks when there's none.
+func orStopChecks(err error) error {
+	if err != nil {
+		return err
+	}
+	return errStopChecks
+}
+
+// TopologySnapshot is what the checks of a RF know of its redis and sentinel pods. It's filled in by the
+// checks as they run, the master found by the master check is the one the later checks heal against.
+type TopologySnapshot struct {
+	RF    *redisfailoverv1.RedisFailover
+	Gates rfservice.FeatureGates
+	// Master is the IP of the master of the redis pods.
+	Master string
+	// ExternalMaster is the master of the external nodes.
+	ExternalMaster *redisfailoverv1.RedisExternalNode
+	// Stabilizing is how long the disruptive actions on the redis pods are still held after a failover.
+	Stabilizing time.Duration
+	// Frozen is true while the mass deletion of the keys of the master is not acknowledged.
+	Frozen bool
+	// Waiting are the redis pods waiting for their volumes.
+	Waiting []string
+	// Updating is true when a redis pod is updated to the statefulset revision.
+	Updating bool
+	// Sentinels are the IPs of the sentinels answering.
+	Sentinels []string
+	// Plan holds the actions on the redis pods planned by the checks, they are taken by the pod actions
+	// check.
+	Plan *rfservice.PodActionPlan
+}
+
+// Check is a check of the checker. It heals what it finds wrong, or returns the actions on the redis
+// pods it plans to take.
+type Check interface {
+	Name() string
+	Run(ctx context.Context, snapshot *TopologySnapshot) ([]rfservice.PodAction, error)
+}
+
+// checkFunc is a check run by a function.
+type checkFunc struct {
+	name string
+	run  func(ctx context.Context, snapshot *TopologySnapshot) ([]rfservice.PodAction, error)
+}
+
+func (c checkFunc) Name() string {
+	return c.name
+}
+
+func (c checkFunc) Run(ctx context.Context, snapshot *TopologySnapshot) ([]rfservice.PodAction, error) {
+	return c.run(ctx, snapshot)
+}
+
+// checks returns the checks of the RF in the order they run:
+// Number of redis is equal as the set on the RF spec
+// Number of sentinel is equal as the set on the RF spec
+// Number of redis master is 1
+// All redis slaves have the same master
+// All sentinels points to the same redis master
+// Sentinel has not death nodes
+// Sentinel knows the correct slave number
+func (r *RedisFailoverHandler) checks(rf *redisfailoverv1.RedisFailover) []Check {
+	sentinels := []Check{
+		checkFunc{sentinelsCheck, r.checkSentinels},
+		checkFunc{"unresponsive-sentinels", r.checkUnresponsiveSentinels},
+		checkFunc{"sentinel-monitor", r.checkSentinelMonitor},
+		checkFunc{"sentinel-reset", r.checkSentinelReset},
+		checkFunc{"sentinel-config", r.checkSentinelConfig},
+	}
+
+	switch {
+	case rf.Bootstrapping():
+		checks := []Check{
+			checkFunc{"redis-number", r.checkRedisNumber},
+			checkFunc{"redis-updates", r.checkRedisUpdates},
+			checkFunc{"redis-config", r.checkRedisConfig},
+			checkFunc{"redis-acl", r.checkRedisACL},
+			checkFunc{"replication", r.checkBootstrapReplication},
+			checkFunc{podActionsCheck, r.checkPodActions},
+		}
+		if !rf.SentinelsAllowed() {
+			return checks
+		}
+		checks = append(checks, checkFunc{"sentinel-number", r.checkSentinelNumber})
+		return append(checks, sentinels...)
+	case rf.ExternalNodesEnabled():
+		checks := []Check{
+			checkFunc{"sentinel-number", r.checkSentinelNumber},
+			checkFunc{masterCheck, r.checkExternalMaster},
+			checkFunc{"replication", r.checkExternalReplication},
+			checkFunc{"redis-config", r.checkExternalRedisConfig},
+		}
+		return append(checks, sentinels...)
+	}
+
+	checks := []Check{
+		checkFunc{"redis-number", r.checkRedisNumber},
+		checkFunc{"sentinel-number", r.checkSentinelNumber},
+		checkFunc{masterCheck, r.checkMaster},
+		checkFunc{"replication", r.checkReplication},
+		checkFunc{"role-labels", r.checkRoleLabels},
+		checkFunc{"stuck-replicas", r.checkStuckReplicas},
+		checkFunc{"redis-config", r.checkRedisConfig},
+		checkFunc{"redis-acl", r.checkRedisACL},
+		checkFunc{"redis-updates", r.checkRedisUpdates},
+		checkFunc{"redis-restarts", r.checkRedisRestarts},
+		checkFunc{podActionsCheck, r.checkPodActions},
+	}
+	return append(checks, sentinels...)
+}
+
+// runChecks runs the checks in order on the snapshot, except the ones disabled with their feature gate.
+// The actions planned by a check are added to the plan of the snapshot. A check failing or stopping
+// skips the later ones, the error of the failed one is returned. The duration and the error of every
+// check are recorded on the metrics, and their results on the check runs.
+func (r *RedisFailoverHandler) runChecks(ctx context.Context, checks []Check, snapshot *TopologySnapshot) error {
+	rf := snapshot.RF
+	results := make([]CheckResult, 0, len(checks))
+	var failed error
+	stopped := false
+	for _, check := range checks {
+		result := CheckResult{CheckStats: redisfailoverv1.CheckStats{Name: check.Name()}}
+		switch {
+		case failed != nil || stopped:
+			result.Result = redisfailoverv1.CheckResultSkipped
+		case !snapshot.Gates.CheckEnabled(check.Name()):
+			result.Result = redisfailoverv1.CheckResultDisabled
+		default:
+			start := time.Now()
+			actions, err := check.Run(ctx, snapshot)
+			duration := time.Since(start)
+			result.DurationSeconds = duration.Seconds()
+			r.mClient.ObserveCheckDuration(rf.Namespace, check.Name(), duration.Seconds())
+
+			snapshot.Plan.Add(actions...)
+			result.Actions = int32(len(actions))
+			switch {
+			case errors.Is(err, errStopChecks):
+				result.Result = redisfailoverv1.CheckResultStopped
+				stopped = true
+			case err != nil:
+				result.Result = redisfailoverv1.CheckResultFailed
+				result.Error = err.Error()
+				r.mClient.RecordCheckError(rf.Namespace, rf.Name, check.Name())
+				failed = err
+			default:
+				result.Result = redisfailoverv1.CheckResultPassed
+			}
+		}
+		results = append(results, result)
+	}
+	r.checkRuns.Set(rfKey(rf), results, time.Now())
+	return failed
+}
+
+// CheckResult is the result of a check, with how long it took.
+type CheckResult struct {
+	redisfailoverv1.CheckStats
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+}
+
+// CheckRun are the results of the checks of a reconcile of a RF.
+type CheckRun struct {
+	Time   time.Time     `json:"time"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// CheckRuns are the results of the checks of the last reconcile of the RFs. They are written on the
+// status of the RFs without their duration, and served as they are by the debug endpoint.
+type CheckRuns struct {
+	mu   sync.Mutex
+	runs map[string]CheckRun
+}
+
+// NewCheckRuns returns new check runs.
+func NewCheckRuns() *CheckRuns {
+	return &CheckRuns{
+		runs: map[string]CheckRun{},
+	}
+}
+
+// Set records the results of the checks of the RF.
+func (c *CheckRuns) Set(key string, results []CheckResult, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runs[key] = CheckRun{Time: now, Checks: results}
+}
+
+// Stats returns the results of the last checks of the RF for its status, nil when it was not checked
+// since the operator started.
+func (c *CheckRuns) Stats(key string) *redisfailoverv1.ReconcileStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	run, ok := c.runs[key]
+	if !ok {
+		return nil
+	}
+	stats := &redisfailoverv1.ReconcileStats{}
+	for _, result := range run.Checks {
+		stats.Checks = append(stats.Checks, result.CheckStats)
+	}
+	return stats
+}
+
+// Forget forgets the results of the checks of the RF.
+func (c *CheckRuns) Forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.runs, key)
+}
+
+// ServeHTTP serves the results of the last checks of every RF as JSON, by namespace/name.
+func (c *CheckRuns) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	c.mu.Lock()
+	body, err := json.Marshal(c.runs)
+	c.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
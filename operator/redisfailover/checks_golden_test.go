@@ -0,0 +1,363 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// cannedTopology is what the checker reads from the redis and sentinel pods of the test RF, and the
+// actions the healer plans on them.
+type cannedTopology struct {
+	name string
+	// mode is "bootstrap", "bootstrap-sentinels" or "external", the RF is managed otherwise.
+	mode                 string
+	redisNumberErr       bool
+	sentinelNumberErr    bool
+	masters              int
+	redises              []string
+	minPodTime           time.Duration
+	slavesWrongMaster    bool
+	staleSlavePods       []string
+	staleMaster          bool
+	sentinels            []string
+	unresponsive         []string
+	wrongMonitor         []string
+	tooManySentinels     []string
+	tooManySlaves        []string
+	configPods           []string
+	stuckPods            []string
+	restartPods          []string
+	externalMasters      int
+	externalSlavesWrong  bool
+	disabledChecks       map[string]bool
+	sentinelConfigFailed bool
+}
+
+// cannedTopologies are the topologies the order and the actions of the checks are locked in with.
+var cannedTopologies = []cannedTopology{
+	{
+		name:      "healthy",
+		masters:   1,
+		redises:   []string{"0.0.0.1", "0.0.0.2", "0.0.0.3"},
+		sentinels: []string{"1.0.0.1", "1.0.0.2", "1.0.0.3"},
+	},
+	{
+		name:           "redis number mismatch",
+		redisNumberErr: true,
+	},
+	{
+		name:              "sentinel number mismatch",
+		sentinelNumberErr: true,
+	},
+	{
+		name:      "no master with a single redis",
+		masters:   0,
+		redises:   []string{"0.0.0.1"},
+		sentinels: []string{"1.0.0.1"},
+	},
+	{
+		name:       "no master with old redises",
+		masters:    0,
+		redises:    []string{"0.0.0.1", "0.0.0.2"},
+		minPodTime: time.Hour,
+		sentinels:  []string{"1.0.0.1"},
+	},
+	{
+		name:       "no master with young redises",
+		masters:    0,
+		redises:    []string{"0.0.0.1", "0.0.0.2"},
+		minPodTime: time.Second,
+	},
+	{
+		name:    "several masters",
+		masters: 2,
+	},
+	{
+		name:              "slaves with the wrong master",
+		masters:           1,
+		redises:           []string{"0.0.0.1", "0.0.0.2", "0.0.0.3"},
+		slavesWrongMaster: true,
+		configPods:        []string{"rfr-test-0", "rfr-test-1", "rfr-test-2"},
+		sentinels:         []string{"1.0.0.1"},
+	},
+	{
+		name:           "stale slave",
+		masters:        1,
+		redises:        []string{"0.0.0.1", "0.0.0.2"},
+		staleSlavePods: []string{"rfr-test-1"},
+		configPods:     []string{"rfr-test-1"},
+		sentinels:      []string{"1.0.0.1"},
+	},
+	{
+		name:        "stale master",
+		masters:     1,
+		redises:     []string{"0.0.0.1", "0.0.0.2"},
+		staleMaster: true,
+		sentinels:   []string{"1.0.0.1"},
+	},
+	{
+		name:        "in place restarts",
+		masters:     1,
+		redises:     []string{"0.0.0.1", "0.0.0.2"},
+		restartPods: []string{"rfr-test-1"},
+		sentinels:   []string{"1.0.0.1"},
+	},
+	{
+		name:       "stuck replica",
+		masters:    1,
+		redises:    []string{"0.0.0.1", "0.0.0.2"},
+		stuckPods:  []string{"rfr-test-1"},
+		configPods: []string{"rfr-test-0", "rfr-test-1"},
+		sentinels:  []string{"1.0.0.1"},
+	},
+	{
+		name:             "broken sentinels",
+		masters:          1,
+		redises:          []string{"0.0.0.1"},
+		sentinels:        []string{"1.0.0.1", "1.0.0.2", "1.0.0.3", "1.0.0.4"},
+		unresponsive:     []string{"1.0.0.4"},
+		wrongMonitor:     []string{"1.0.0.1"},
+		tooManySentinels: []string{"1.0.0.2"},
+		tooManySlaves:    []string{"1.0.0.3"},
+	},
+	{
+		name:                 "sentinel config failed",
+		masters:              1,
+		redises:              []string{"0.0.0.1"},
+		sentinels:            []string{"1.0.0.1", "1.0.0.2"},
+		sentinelConfigFailed: true,
+	},
+	{
+		name:           "bootstrap",
+		mode:           "bootstrap",
+		redises:        []string{"0.0.0.1", "0.0.0.2"},
+		staleSlavePods: []string{"rfr-test-1"},
+		configPods:     []string{"rfr-test-0"},
+	},
+	{
+		name:         "bootstrap with sentinels",
+		mode:         "bootstrap-sentinels",
+		redises:      []string{"0.0.0.1"},
+		sentinels:    []string{"1.0.0.1", "1.0.0.2"},
+		wrongMonitor: []string{"1.0.0.2"},
+	},
+	{
+		name:                "external nodes",
+		mode:                "external",
+		externalMasters:     1,
+		externalSlavesWrong: true,
+		sentinels:           []string{"1.0.0.1"},
+		wrongMonitor:        []string{"1.0.0.1"},
+	},
+	{
+		name:            "external nodes without a master",
+		mode:            "external",
+		externalMasters: 0,
+	},
+	{
+		name:           "disabled checks",
+		masters:        1,
+		redises:        []string{"0.0.0.1", "0.0.0.2"},
+		staleSlavePods: []string{"rfr-test-1"},
+		configPods:     []string{"rfr-test-0"},
+		sentinels:      []string{"1.0.0.1"},
+		tooManySlaves:  []string{"1.0.0.1"},
+		disabledChecks: map[string]bool{"redis-config": true, "sentinel-reset": true},
+	},
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// podOf returns the name of the redis pod of the IP of the canned topologies.
+func podOf(ip string) string {
+	var i int
+	fmt.Sscanf(ip, "0.0.0.%d", &i)
+	return fmt.Sprintf("rfr-test-%d", i-1)
+}
+
+// runCannedTopology checks and heals the test RF on the topology, and returns the actions taken on the
+// redis and sentinel pods, in order.
+func runCannedTopology(t *testing.T, topology cannedTopology) []string {
+	rf := generateRF(false, strings.HasPrefix(topology.mode, "bootstrap"))
+	if topology.mode == "bootstrap-sentinels" {
+		rf.Spec.BootstrapNode.AllowSentinels = true
+	}
+	externalMaster := redisfailoverv1.RedisExternalNode{Host: "2.0.0.1", Port: "6379"}
+	externalSlave := redisfailoverv1.RedisExternalNode{Host: "2.0.0.2", Port: "6379"}
+	if topology.mode == "external" {
+		rf.Spec.Redis.ExternalNodes = []redisfailoverv1.RedisExternalNode{externalMaster, externalSlave}
+	}
+	master := ""
+	if len(topology.redises) > 0 {
+		master = topology.redises[0]
+	}
+
+	taken := []string{}
+	record := func(format string, args ...interface{}) {
+		taken = append(taken, fmt.Sprintf(format, args...))
+	}
+	actions := func(kind rfservice.PodActionKind, reason string, pods []string) []rfservice.PodAction {
+		planned := []rfservice.PodAction{}
+		for _, pod := range pods {
+			action := rfservice.PodAction{Pod: pod, Kind: kind, Reason: reason}
+			action.Apply = func() error {
+				record("apply %s", action)
+				return nil
+			}
+			planned = append(planned, action)
+		}
+		return planned
+	}
+	failIf := func(failed bool) error {
+		if failed {
+			return errors.New("canned error")
+		}
+		return nil
+	}
+
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("CheckRedisNumber", mock.Anything, rf).Maybe().Return(failIf(topology.redisNumberErr))
+	mrfc.On("CheckSentinelNumber", mock.Anything, rf).Maybe().Return(failIf(topology.sentinelNumberErr))
+	mrfc.On("GetNumberMasters", mock.Anything, rf).Maybe().Return(topology.masters, nil)
+	mrfc.On("GetRedisesIPs", mock.Anything, rf).Maybe().Return(topology.redises, nil)
+	mrfc.On("CorroborateMasterDown", mock.Anything, mock.Anything, rf).Maybe().Return(true, "canned", nil)
+	mrfc.On("GetMinimumRedisPodTime", mock.Anything, rf).Maybe().Return(topology.minPodTime, nil)
+	mrfc.On("GetMasterIP", mock.Anything, rf).Maybe().Return(master, nil)
+	mrfc.On("CheckAllSlavesFromMaster", mock.Anything, mock.Anything, rf).Maybe().Return(failIf(topology.slavesWrongMaster))
+	mrfc.On("CheckRedisSlavesReady", mock.Anything, mock.Anything, rf).Maybe().Return(true, nil)
+	mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Maybe().Return("2", nil)
+	slavePods := []string{}
+	for i, ip := range topology.redises {
+		if i > 0 {
+			slavePods = append(slavePods, podOf(ip))
+		}
+	}
+	mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Maybe().Return(slavePods, nil)
+	mrfc.On("GetRedisesMasterPod", mock.Anything, rf).Maybe().Return("rfr-test-0", nil)
+	mrfc.On("GetRedisRevisionHash", mock.Anything, mock.Anything, rf).Maybe().Return(func(_ context.Context, pod string, _ *redisfailoverv1.RedisFailover) string {
+		if contains(topology.staleSlavePods, pod) || (pod == "rfr-test-0" && topology.staleMaster) {
+			return "1"
+		}
+		return "2"
+	}, nil)
+	mrfc.On("GetSentinelsIPs", mock.Anything, rf).Maybe().Return(topology.sentinels, nil)
+	mrfc.On("CheckSentinelResponding", mock.Anything).Maybe().Return(func(sip string) error {
+		return failIf(contains(topology.unresponsive, sip))
+	})
+	mrfc.On("CheckSentinelMonitor", mock.Anything, mock.Anything, mock.Anything).Maybe().Return(func(sip string, _ ...string) error {
+		return failIf(contains(topology.wrongMonitor, sip))
+	})
+	mrfc.On("CheckSentinelNumberInMemory", mock.Anything, mock.Anything).Maybe().Return(func(sip string, _ *redisfailoverv1.RedisFailover) error {
+		return failIf(contains(topology.tooManySentinels, sip))
+	})
+	mrfc.On("CheckSentinelSlavesNumberInMemory", mock.Anything, mock.Anything).Maybe().Return(func(sip string, _ *redisfailoverv1.RedisFailover) error {
+		return failIf(contains(topology.tooManySlaves, sip))
+	})
+	externalMasters := []redisfailoverv1.RedisExternalNode{}
+	for i := 0; i < topology.externalMasters; i++ {
+		externalMasters = append(externalMasters, externalMaster)
+	}
+	mrfc.On("GetExternalMasters", mock.Anything, rf).Maybe().Return(externalMasters, nil)
+	mrfc.On("CheckExternalSlavesFromMaster", mock.Anything, mock.Anything, rf).Maybe().Return(failIf(topology.externalSlavesWrong))
+
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("MakeMaster", mock.Anything, mock.Anything, rf).Maybe().Run(func(args mock.Arguments) {
+		record("make master %s", args.String(1))
+	}).Return(nil)
+	mrfh.On("SetOldestAsMaster", mock.Anything, rf).Maybe().Run(func(mock.Arguments) {
+		record("set oldest as master")
+	}).Return(nil)
+	mrfh.On("PlanMasterOnAll", mock.Anything, mock.Anything, rf).Maybe().Return(actions(rfservice.PodActionReconfigure, "replicate the master", slavePods), nil)
+	mrfh.On("PlanExternalMasterOnAll", mock.Anything, mock.Anything, mock.Anything, rf).Maybe().Return(actions(rfservice.PodActionReconfigure, "replicate the bootstrap node", []string{"rfr-test-0"}), nil)
+	mrfh.On("PlanRoleLabels", mock.Anything, mock.Anything, rf).Maybe().Return(actions(rfservice.PodActionUpdateLabels, "label the master", nil), nil)
+	mrfh.On("ClearSyncSlotQueue", rf).Maybe().Run(func(mock.Arguments) {
+		record("clear sync slot queue")
+	})
+	mrfh.On("PlanStuckReplicas", mock.Anything, mock.Anything, rf).Maybe().Return(actions(rfservice.PodActionReconfigure, "retry the stuck replica", topology.stuckPods), nil)
+	mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Maybe().Return(actions(rfservice.PodActionApplyConfig, "apply the custom config", topology.configPods), nil)
+	mrfh.On("PlanRedisInPlaceRestarts", mock.Anything, mock.Anything, rf).Maybe().Return(actions(rfservice.PodActionRestart, "restart in place", topology.restartPods), nil)
+	mrfh.On("DeletePod", mock.Anything, mock.Anything, rf).Maybe().Run(func(args mock.Arguments) {
+		record("delete pod %s", args.String(1))
+	}).Return(nil)
+	mrfh.On("PlanUnresponsiveSentinels", mock.Anything, mock.Anything, rf).Maybe().Return(func(_ context.Context, ips []string, _ *redisfailoverv1.RedisFailover) []rfservice.PodAction {
+		return actions(rfservice.PodActionRestart, "restart the unresponsive sentinel", ips)
+	}, nil)
+	mrfh.On("NewSentinelMonitor", mock.Anything, mock.Anything, mock.Anything, rf).Maybe().Run(func(args mock.Arguments) {
+		record("monitor %s on sentinel %s", args.String(2), args.String(1))
+	}).Return(nil)
+	mrfh.On("NewSentinelMonitorWithPort", mock.Anything, mock.Anything, mock.Anything, mock.Anything, rf).Maybe().Run(func(args mock.Arguments) {
+		record("monitor %s:%s on sentinel %s", args.String(2), args.String(3), args.String(1))
+	}).Return(nil)
+	mrfh.On("RestoreSentinel", mock.Anything).Maybe().Run(func(args mock.Arguments) {
+		record("reset sentinel %s", args.String(0))
+	}).Return(nil)
+	mrfh.On("SetSentinelCustomConfig", mock.Anything, rf).Maybe().Run(func(args mock.Arguments) {
+		record("set custom config on sentinel %s", args.String(0))
+	}).Return(failIf(topology.sentinelConfigFailed))
+	mrfh.On("SetSentinelGlobalConfig", mock.Anything, rf).Maybe().Run(func(args mock.Arguments) {
+		record("set global config on sentinel %s", args.String(0))
+	}).Return(nil)
+	mrfh.On("SetExternalNodesMaster", mock.Anything, mock.Anything, rf).Maybe().Run(func(args mock.Arguments) {
+		record("set external master %s", args.Get(1).(redisfailoverv1.RedisExternalNode).Host)
+	}).Return(nil)
+	mrfh.On("SetExternalRedisCustomConfig", mock.Anything, mock.Anything, rf).Maybe().Run(func(args mock.Arguments) {
+		record("set custom config on external node %s", args.Get(1).(redisfailoverv1.RedisExternalNode).Host)
+	}).Return(nil)
+
+	mk := &mK8SService.Services{}
+	mk.On("GetStatefulSetRolloutStatus", mock.Anything, namespace, "rfr-test").Maybe().Return(true, nil)
+	mk.On("CreateEvent", mock.Anything, namespace, mock.Anything).Maybe().Run(func(args mock.Arguments) {
+		event := args.Get(2).(*corev1.Event)
+		record("event %s: %s", event.Reason, event.Message)
+	}).Return(nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	gates := rfservice.NewFeatureGates()
+	gates.DisabledChecks = topology.disabledChecks
+	if err := handler.CheckAndHeal(context.TODO(), rf, gates); err != nil {
+		record("error %s", err)
+	}
+	return taken
+}
+
+// TestCheckAndHealGolden locks in the order of the checks and the actions they take on a set of canned
+// topologies.
+func TestCheckAndHealGolden(t *testing.T) {
+	var out strings.Builder
+	for _, topology := range cannedTopologies {
+		fmt.Fprintf(&out, "== %s\n", topology.name)
+		for _, action := range runCannedTopology(t, topology) {
+			fmt.Fprintln(&out, action)
+		}
+	}
+
+	expected, err := os.ReadFile(filepath.Join("testdata", "checks.golden"))
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), out.String())
+}
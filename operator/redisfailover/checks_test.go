@@ -0,0 +1,182 @@
+package redisfailover_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// checksRecorder records the checks timed and the ones failing.
+type checksRecorder struct {
+	metrics.Recorder
+	mu     sync.Mutex
+	timed  []string
+	errors []string
+}
+
+func (r *checksRecorder) ObserveCheckDuration(namespace string, check string, duration float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timed = append(r.timed, check)
+}
+
+func (r *checksRecorder) RecordCheckError(namespace string, name string, check string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, check)
+}
+
+func TestCheckAndHealStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		gates    map[string]bool
+		masters  int
+		expErr   bool
+		expStats []redisfailoverv1.CheckStats
+		expTimed []string
+		expErrs  []string
+	}{
+		{
+			name:    "A disabled check is not run, a check stopping skips the later ones",
+			gates:   map[string]bool{"check-redis-number": false},
+			masters: 0,
+			expStats: []redisfailoverv1.CheckStats{
+				{Name: "redis-number", Result: redisfailoverv1.CheckResultDisabled},
+				{Name: "sentinel-number", Result: redisfailoverv1.CheckResultPassed},
+				{Name: "master", Result: redisfailoverv1.CheckResultStopped},
+				{Name: "replication", Result: redisfailoverv1.CheckResultSkipped},
+			},
+			expTimed: []string{"sentinel-number", "master"},
+		},
+		{
+			name:    "A check failing skips the later ones, with its error",
+			masters: 2,
+			expErr:  true,
+			expStats: []redisfailoverv1.CheckStats{
+				{Name: "redis-number", Result: redisfailoverv1.CheckResultPassed},
+				{Name: "sentinel-number", Result: redisfailoverv1.CheckResultPassed},
+				{Name: "master", Result: redisfailoverv1.CheckResultFailed, Error: "More than one master, fix manually"},
+				{Name: "replication", Result: redisfailoverv1.CheckResultSkipped},
+			},
+			expTimed: []string{"redis-number", "sentinel-number", "master"},
+			expErrs:  []string{"master"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("CheckRedisNumber", mock.Anything, rf).Maybe().Return(nil)
+			mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+			mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(test.masters, nil)
+			mrfc.On("GetRedisesIPs", mock.Anything, rf).Maybe().Return([]string{"0.0.0.1", "0.0.0.2"}, nil)
+			mrfc.On("GetMinimumRedisPodTime", mock.Anything, rf).Maybe().Return(time.Second, nil)
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+
+			var stats *redisfailoverv1.ReconcileStats
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+				stats = args.Get(2).(*redisfailoverv1.RedisFailover).Status.LastReconcileStats
+			}).Return(rf, nil)
+			rec := &checksRecorder{Recorder: metrics.Dummy}
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, rec, log.Dummy)
+			err := handler.CheckAndHeal(context.TODO(), rf, rfservice.NewFeatureGates(test.gates))
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expTimed, rec.timed)
+			assert.Equal(test.expErrs, rec.errors)
+
+			// The results of the checks are written on the next status.
+			assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+			if assert.NotNil(stats) {
+				assert.Equal(test.expStats, stats.Checks[:len(test.expStats)])
+				for _, check := range stats.Checks[len(test.expStats):] {
+					assert.Equal(redisfailoverv1.CheckResultSkipped, check.Result)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckGates(t *testing.T) {
+	assert := assert.New(t)
+
+	// Every check but the ones needed by the others can be disabled with its gate.
+	for _, check := range rfservice.Checks {
+		assert.True(rfservice.IsFeatureGate(rfservice.CheckFeatureGate(check)), check)
+	}
+	for _, check := range []string{"master", "pod-actions", "sentinels"} {
+		assert.False(rfservice.IsFeatureGate(rfservice.CheckFeatureGate(check)), check)
+	}
+
+	gates := rfservice.NewFeatureGates(map[string]bool{"check-redis-config": false})
+	assert.False(gates.CheckEnabled("redis-config"))
+	assert.True(gates.CheckEnabled("redis-acl"))
+	assert.True(rfservice.FeatureGates{}.CheckEnabled("redis-config"))
+}
+
+func TestCheckRunsServeHTTP(t *testing.T) {
+	assert := assert.New(t)
+
+	runs := rfOperator.NewCheckRuns()
+	assert.Nil(runs.Stats("ns/rf"))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	runs.Set("ns/rf", []rfOperator.CheckResult{
+		{CheckStats: redisfailoverv1.CheckStats{Name: "redis-number", Result: redisfailoverv1.CheckResultPassed}, DurationSeconds: 0.002},
+		{CheckStats: redisfailoverv1.CheckStats{Name: "master", Result: redisfailoverv1.CheckResultFailed, Error: "no sentinel"}, DurationSeconds: 0.5},
+	}, now)
+
+	// The status gets the results without their duration.
+	assert.Equal(&redisfailoverv1.ReconcileStats{Checks: []redisfailoverv1.CheckStats{
+		{Name: "redis-number", Result: redisfailoverv1.CheckResultPassed},
+		{Name: "master", Result: redisfailoverv1.CheckResultFailed, Error: "no sentinel"},
+	}}, runs.Stats("ns/rf"))
+
+	w := httptest.NewRecorder()
+	runs.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/checks", nil))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("application/json", w.Header().Get("Content-Type"))
+	got := map[string]rfOperator.CheckRun{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(now, got["ns/rf"].Time)
+	assert.Len(got["ns/rf"].Checks, 2)
+	assert.Equal(0.5, got["ns/rf"].Checks[1].DurationSeconds)
+	assert.Equal("no sentinel", got["ns/rf"].Checks[1].Error)
+
+	runs.Forget("ns/rf")
+	assert.Nil(runs.Stats("ns/rf"))
+}
@@ -0,0 +1,122 @@
+package redisfailover
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// reconcileFailure is the phase of the reconcile of a RF that failed, and its error.
+type reconcileFailure struct {
+	reason  string
+	message string
+}
+
+// ReconcileFailures are the RFs whose last reconcile failed, their Ready condition is false until the
+// phase that failed succeeds.
+type ReconcileFailures struct {
+	mu       sync.Mutex
+	failures map[string]reconcileFailure
+}
+
+// NewReconcileFailures returns new reconcile failures.
+func NewReconcileFailures() *ReconcileFailures {
+	return &ReconcileFailures{
+		failures: map[string]reconcileFailure{},
+	}
+}
+
+// Fail records the phase of the reconcile of the RF failed for the reason, explained by the message.
+func (f *ReconcileFailures) Fail(key, reason, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[key] = reconcileFailure{reason: reason, message: message}
+}
+
+// Clear forgets the failure of the RF when the phase that failed is the one of the reason, the failure
+// of another phase is kept until it succeeds.
+func (f *ReconcileFailures) Clear(key, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures[key].reason == reason {
+		delete(f.failures, key)
+	}
+}
+
+// Forget forgets the failure of the RF.
+func (f *ReconcileFailures) Forget(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.failures, key)
+}
+
+// Failed returns the reason and the message of the last failure of the RF, false when it did not fail.
+func (f *ReconcileFailures) Failed(key string) (string, string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	failure, ok := f.failures[key]
+	return failure.reason, failure.message, ok
+}
+
+// failReconcile records a phase of the reconcile of the RF failed, and creates an event with its error.
+// The Ready condition is set false on the next status update.
+func (r *RedisFailoverHandler) failReconcile(ctx context.Context, rf *redisfailoverv1.RedisFailover, reason string, err error) {
+	r.reconcileFailures.Fail(rfKey(rf), reason, err.Error())
+	r.events.Record(ctx, rf, corev1.EventTypeWarning, reason, err.Error())
+}
+
+// writeEnsureFailed sets the Ready condition false with the error of the ensure. The status is not
+// updated on a reconcile failing to ensure the objects of the RF, so it's written alone.
+func (r *RedisFailoverHandler) writeEnsureFailed(ctx context.Context, rf *redisfailoverv1.RedisFailover, err error) {
+	next := rf.DeepCopy()
+	rfservice.SetCondition(next, redisfailoverv1.ConditionReady, metav1.ConditionFalse, redisfailoverv1.ReasonEnsureFailed, err.Error())
+	if err := r.writeStatus(ctx, rf, &next.Status); err != nil {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not update the status: %s", err)
+	}
+}
+
+// setReconcileConditions sets the Ready condition from the last reconcile and the readiness of the
+// pods, and the Scaling one from the number of pods, on the next status of the RF.
+func (r *RedisFailoverHandler) setReconcileConditions(ctx context.Context, rf, next *redisfailoverv1.RedisFailover, health healthInput) {
+	reason, message, failed := r.reconcileFailures.Failed(rfKey(rf))
+	switch {
+	case failed:
+		r.setReconcileCondition(ctx, rf, next, redisfailoverv1.ConditionReady, metav1.ConditionFalse, reason, message)
+	case health.redisCrashes != "":
+		// The pods are not ready as redis crashes, the condition and its event tell what it printed.
+		r.setReconcileCondition(ctx, rf, next, redisfailoverv1.ConditionReady, metav1.ConditionFalse, redisfailoverv1.ReasonRedisCrashLooping, readinessMessage(health)+"\n"+health.redisCrashes)
+	case next.Status.Health == nil || !next.Status.Health.Ready:
+		r.setReconcileCondition(ctx, rf, next, redisfailoverv1.ConditionReady, metav1.ConditionFalse, redisfailoverv1.ReasonPodsNotReady, readinessMessage(health))
+	default:
+		r.setReconcileCondition(ctx, rf, next, redisfailoverv1.ConditionReady, metav1.ConditionTrue, redisfailoverv1.ReasonPodsReady, readinessMessage(health))
+	}
+
+	if health.redisRunning != health.redisWanted || health.sentinelRunning != health.sentinelWanted {
+		r.setReconcileCondition(ctx, rf, next, redisfailoverv1.ConditionScaling, metav1.ConditionTrue, redisfailoverv1.ReasonScalingPods, runningMessage(health))
+	} else {
+		r.setReconcileCondition(ctx, rf, next, redisfailoverv1.ConditionScaling, metav1.ConditionFalse, redisfailoverv1.ReasonReplicasReached, runningMessage(health))
+	}
+}
+
+// setReconcileCondition sets the condition on the next status of the RF, and creates an event when its
+// status or its reason changed from the one of the RF. A new message alone, as the pods get ready one by
+// one, is not worth an event.
+func (r *RedisFailoverHandler) setReconcileCondition(ctx context.Context, rf, next *redisfailoverv1.RedisFailover, condType string, status metav1.ConditionStatus, reason, message string) {
+	previous := rfservice.GetCondition(rf, condType)
+	if !rfservice.SetCondition(next, condType, status, reason, message) || previous == nil {
+		return
+	}
+	if previous.Status == status && previous.Reason == reason {
+		return
+	}
+	eventType := corev1.EventTypeNormal
+	if condType == redisfailoverv1.ConditionReady && status == metav1.ConditionFalse {
+		eventType = corev1.EventTypeWarning
+	}
+	r.events.Record(ctx, rf, eventType, reason, message)
+}
@@ -0,0 +1,142 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestUpdateStatusReconcileConditions(t *testing.T) {
+	sentinelPods := []corev1.Pod{
+		generateReadyPod("rfs-test-a", "", true),
+		generateReadyPod("rfs-test-b", "", true),
+		generateReadyPod("rfs-test-c", "", true),
+	}
+	readyConditions := []metav1.Condition{
+		{Type: redisfailoverv1.ConditionReady, Status: metav1.ConditionTrue, Reason: redisfailoverv1.ReasonPodsReady, Message: "3/3 redis and 3/3 sentinel pods ready"},
+		{Type: redisfailoverv1.ConditionScaling, Status: metav1.ConditionFalse, Reason: redisfailoverv1.ReasonReplicasReached, Message: "3/3 redis and 3/3 sentinel pods running"},
+	}
+
+	tests := []struct {
+		name           string
+		prevConditions []metav1.Condition
+		redisPods      []corev1.Pod
+		expConditions  []metav1.Condition
+		expEvents      []string
+	}{
+		{
+			name: "Ready pods should set the RF ready, without an event on the first status",
+			redisPods: []corev1.Pod{
+				generateReadyPod("rfr-test-0", "", true),
+				generateReadyPod("rfr-test-1", "", true),
+				generateReadyPod("rfr-test-2", "", true),
+			},
+			expConditions: readyConditions,
+		},
+		{
+			name:           "A pod removed should set the RF scaling and not ready, with events",
+			prevConditions: readyConditions,
+			redisPods: []corev1.Pod{
+				generateReadyPod("rfr-test-0", "", true),
+				generateReadyPod("rfr-test-1", "", true),
+			},
+			expConditions: []metav1.Condition{
+				{Type: redisfailoverv1.ConditionReady, Status: metav1.ConditionFalse, Reason: redisfailoverv1.ReasonPodsNotReady, Message: "2/3 redis and 3/3 sentinel pods ready"},
+				{Type: redisfailoverv1.ConditionScaling, Status: metav1.ConditionTrue, Reason: redisfailoverv1.ReasonScalingPods, Message: "2/3 redis and 3/3 sentinel pods running"},
+			},
+			expEvents: []string{redisfailoverv1.ReasonPodsNotReady, redisfailoverv1.ReasonScalingPods},
+		},
+		{
+			name: "A pod getting ready should update the message without an event",
+			prevConditions: []metav1.Condition{
+				{Type: redisfailoverv1.ConditionReady, Status: metav1.ConditionFalse, Reason: redisfailoverv1.ReasonPodsNotReady, Message: "1/3 redis and 3/3 sentinel pods ready"},
+				readyConditions[1],
+			},
+			redisPods: []corev1.Pod{
+				generateReadyPod("rfr-test-0", "", true),
+				generateReadyPod("rfr-test-1", "", true),
+				generateReadyPod("rfr-test-2", "", false),
+			},
+			expConditions: []metav1.Condition{
+				{Type: redisfailoverv1.ConditionReady, Status: metav1.ConditionFalse, Reason: redisfailoverv1.ReasonPodsNotReady, Message: "2/3 redis and 3/3 sentinel pods ready"},
+				readyConditions[1],
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			for _, condition := range test.prevConditions {
+				meta.SetStatusCondition(&rf.Status.Conditions, condition)
+			}
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: test.redisPods}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{Items: sentinelPods}, nil)
+			for _, reason := range test.expEvents {
+				mk.On("CreateEvent", mock.Anything, namespace, eventReason(reason)).Once().Return(nil)
+			}
+			mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+				for _, exp := range test.expConditions {
+					condition := meta.FindStatusCondition(got.Status.Conditions, exp.Type)
+					if !assert.NotNil(condition) ||
+						!assert.Equal(exp.Status, condition.Status) ||
+						!assert.Equal(exp.Reason, condition.Reason) ||
+						!assert.Equal(exp.Message, condition.Message) {
+						return false
+					}
+				}
+				return true
+			})).Once().Return(rf, nil)
+
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+
+			mk.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReconcileFailures(t *testing.T) {
+	assert := assert.New(t)
+
+	failures := rfOperator.NewReconcileFailures()
+	failures.Fail("ns/rf", redisfailoverv1.ReasonCheckFailed, "sentinels unreachable")
+
+	// The ensure succeeding doesn't clear the failure of the check.
+	failures.Clear("ns/rf", redisfailoverv1.ReasonEnsureFailed)
+	reason, message, failed := failures.Failed("ns/rf")
+	assert.True(failed)
+	assert.Equal(redisfailoverv1.ReasonCheckFailed, reason)
+	assert.Equal("sentinels unreachable", message)
+
+	failures.Clear("ns/rf", redisfailoverv1.ReasonCheckFailed)
+	_, _, failed = failures.Failed("ns/rf")
+	assert.False(failed)
+}
@@ -1,7 +1,65 @@
 package redisfailover
 
+import "time"
+
 // Config is the configuration for the redis operator.
 type Config struct {
 	ListenAddress string
 	MetricsPath   string
+	// OperatorVersion is the version of the operator, the desired states rendered by another version
+	// are not reused.
+	OperatorVersion string
+	// NamePrefixTemplate and CommonLabelsTemplate are rendered for the new RFs, see NewNaming.
+	NamePrefixTemplate   string
+	CommonLabelsTemplate string
+	// FeatureGates are the feature gates enabled or disabled on every RF, see NewFeatureGateResolver.
+	FeatureGates string
+	// RackLabel is the label of the nodes holding their rack, the racks are not reported when it's empty.
+	RackLabel string
+	// DisableMetricLabels ignores the metric labels of the RFs, for the clusters worried about the
+	// cardinality of the metrics.
+	DisableMetricLabels bool
+	// VolumeWaitGracePeriod is how long the redis pods waiting for their volumes are neither healed nor
+	// reported as degraded.
+	VolumeWaitGracePeriod time.Duration
+	// OperatorIdentity is the namespace and the name of the operator deployment claiming the RFs, so
+	// another operator doesn't reconcile them too. The RFs are not claimed when it's empty.
+	OperatorIdentity string
+	// LeaseHolder is the lease identity of the operator pod, reported in the claims.
+	LeaseHolder string
+	// TerminatingNamespace is how the RFs of a namespace being deleted are handled, see
+	// CheckNamespaceTerminating. They are skipped when it's empty.
+	TerminatingNamespace string
+	// LatencyPressure is the mean round-trip of the PING to a redis pod over the last checks above
+	// which the RF is under pressure, see setPressureCondition. It's disabled when zero.
+	LatencyPressure time.Duration
+	// NamespaceConcurrency is how many RFs of a namespace are reconciled at once, see ReconcileLimiter.
+	// There's no limit when it's zero.
+	NamespaceConcurrency int
+	// WarmUpRamp is the interval between the first reconciles of the RFs found when the operator starts,
+	// oldest first. They are all reconciled right away when it's zero.
+	WarmUpRamp time.Duration
+	// ServerSideApply writes the services, the statefulsets and the deployments of the RFs with a
+	// server-side apply instead of a create or an update.
+	ServerSideApply bool
+	// CoalesceWindow is how long the events of an RF are merged before it's reconciled, see
+	// ReconcileCoalescer. Every event is reconciled when it's zero.
+	CoalesceWindow time.Duration
+	// ListPageSize is the number of objects listed by page, k8s.DefaultListPageSize when it's zero.
+	ListPageSize int64
+	// ShutdownGracePeriod is how long the reconciles in flight are waited for when the operator is
+	// stopped, see ReconcileDrainer. They are not waited for when it's zero.
+	ShutdownGracePeriod time.Duration
+	// ChecksDebugPath is the path the results of the last checks of the RFs are served on, with the
+	// metrics. They are not served when it's empty.
+	ChecksDebugPath string
+	// FleetReportPath is the path the fleet report of the RFs is served on, with the metrics. It's not
+	// served when it's empty.
+	FleetReportPath string
+	// CrashLogLines is the number of lines of the previous run of a crash looping redis reported,
+	// DefaultCrashLogLines when it's zero.
+	CrashLogLines int64
+	// SupportBundleLogLines is the number of the last lines logged about every RF kept for its support
+	// bundles. The bundles have no operator logs when it's zero.
+	SupportBundleLogLines int
 }
@@ -0,0 +1,124 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+const (
+	// crashLoopReason is the reason of the containers waiting to be restarted after crashing again.
+	crashLoopReason = "CrashLoopBackOff"
+	// DefaultCrashLogLines is the number of lines of the previous run of a crash looping redis read.
+	DefaultCrashLogLines = int64(20)
+	// maxCrashLogSize caps the lines of a crash reported on the Ready condition and its event.
+	maxCrashLogSize = 4 * 1024
+)
+
+// CrashLog is the crash of a crash looping redis pod, with the last lines of its previous run.
+type CrashLog struct {
+	Restarts int32
+	Lines    string
+}
+
+// CrashLogs keeps, for every RF, the crashes of the crash looping redis pods whose logs were read, so
+// the logs of a crash are only read and logged once.
+type CrashLogs struct {
+	mu      sync.Mutex
+	crashes map[string]map[string]CrashLog
+}
+
+// NewCrashLogs returns new crash logs.
+func NewCrashLogs() *CrashLogs {
+	return &CrashLogs{
+		crashes: map[string]map[string]CrashLog{},
+	}
+}
+
+// Logged returns the crashes of the crash looping pods of the RF whose logs were read, by pod.
+func (c *CrashLogs) Logged(key string) map[string]CrashLog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.crashes[key]
+}
+
+// Set records the crashes of the crash looping pods of the RF whose logs were read.
+func (c *CrashLogs) Set(key string, crashes map[string]CrashLog) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(crashes) == 0 {
+		delete(c.crashes, key)
+		return
+	}
+	c.crashes[key] = crashes
+}
+
+// logRedisCrashes logs the last lines of the previous run of the redis containers in CrashLoopBackOff,
+// the logs an operator would read with kubectl logs --previous, and returns them for the Ready condition.
+// They are read and logged once per restart, the pods whose logs can't be read are retried on the next
+// check.
+func (r *RedisFailoverHandler) logRedisCrashes(ctx context.Context, rf *redisfailoverv1.RedisFailover, pods *corev1.PodList) map[string]CrashLog {
+	key := rfKey(rf)
+	logged := r.crashLogs.Logged(key)
+
+	current := map[string]CrashLog{}
+	for _, pod := range pods.Items {
+		cs := getCrashLoopingContainer(pod, rfservice.RedisContainerName)
+		if cs == nil {
+			continue
+		}
+		if crash, ok := logged[pod.Name]; ok && crash.Restarts == cs.RestartCount {
+			current[pod.Name] = crash
+			continue
+		}
+		tailLines := r.config.CrashLogLines
+		if tailLines <= 0 {
+			tailLines = DefaultCrashLogLines
+		}
+		logs, err := r.k8sservice.GetPreviousPodLogs(ctx, rf.Namespace, pod.Name, rfservice.RedisContainerName, tailLines)
+		if err != nil {
+			r.logger.WithField("namespace", rf.Namespace).WithField("pod", pod.Name).Debugf("could not read the log of the crashed redis: %s", err)
+			continue
+		}
+		lines := strings.TrimRight(logs, "\n")
+		current[pod.Name] = CrashLog{Restarts: cs.RestartCount, Lines: sanitizeText(lines, maxCrashLogSize)}
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).WithField("pod", pod.Name).
+			Warnf("redis is crash looping after %d restarts, the last lines of its previous run:\n%s", cs.RestartCount, lines)
+	}
+	// Only the pods still crash looping are kept, the recovered or deleted ones are forgotten.
+	r.crashLogs.Set(key, current)
+	return current
+}
+
+// crashMessage returns the crashes of the redis pods with the last lines of their previous run, by pod
+// name, empty without crashes.
+func crashMessage(crashes map[string]CrashLog) string {
+	names := make([]string, 0, len(crashes))
+	for name := range crashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("redis pod %s is crash looping after %d restarts, the last lines of its previous run:\n%s", name, crashes[name].Restarts, crashes[name].Lines))
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// getCrashLoopingContainer returns the status of the container of the pod waiting in CrashLoopBackOff,
+// nil when it's not.
+func getCrashLoopingContainer(pod corev1.Pod, name string) *corev1.ContainerStatus {
+	for i, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == name && cs.State.Waiting != nil && cs.State.Waiting.Reason == crashLoopReason {
+			return &pod.Status.ContainerStatuses[i]
+		}
+	}
+	return nil
+}
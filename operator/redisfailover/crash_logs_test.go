@@ -0,0 +1,123 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// generateCrashLoopingPod returns a redis pod whose redis container waits to be restarted after crashing.
+func generateCrashLoopingPod(name string, restarts int32) corev1.Pod {
+	cs := generateContainerStatus("redis", restarts, "Error")
+	cs.State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+	return generatePodWithContainerStatuses(name, cs)
+}
+
+func TestUpdateStatusLogsRedisCrashes(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+
+	mk := &mK8SService.Services{}
+	mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Return(&appsv1.StatefulSet{}, nil)
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Return(&corev1.PodList{}, nil)
+	mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.Anything).Return(rf, nil)
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("GetSyncSlotQueue", rf).Return([]string{})
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Return(map[string][]string{}, nil)
+	mrfc.On("MeasureRedisLatency", mock.Anything, rf).Return([]rfservice.RedisLatency{}, nil)
+	mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Return([]rfservice.RedisKeyspace{}, nil)
+	mrfc.On("GetDataDirMismatches", mock.Anything, rf).Return([]rfservice.DataDirMismatch{}, nil)
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+
+	// The logs of the crash looping pod are read, the healthy pod is left alone.
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: []corev1.Pod{
+		generateCrashLoopingPod("rfr-test-0", 3),
+		generatePodWithContainerStatuses("rfr-test-1", generateContainerStatus("redis", 0, "")),
+	}}, nil)
+	mk.On("GetPreviousPodLogs", mock.Anything, namespace, "rfr-test-0", "redis", int64(20)).Once().Return("# Fatal error loading the DB: Invalid argument. Exiting.\n", nil)
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+
+	// The same crash is not logged again, the logs of a new pod that can't be read are retried.
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: []corev1.Pod{
+		generateCrashLoopingPod("rfr-test-0", 3),
+		generateCrashLoopingPod("rfr-test-1", 1),
+	}}, nil)
+	mk.On("GetPreviousPodLogs", mock.Anything, namespace, "rfr-test-1", "redis", int64(20)).Once().Return("", errors.New("wanted error"))
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+
+	// The next crashes are logged.
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: []corev1.Pod{
+		generateCrashLoopingPod("rfr-test-0", 4),
+		generateCrashLoopingPod("rfr-test-1", 1),
+	}}, nil)
+	mk.On("GetPreviousPodLogs", mock.Anything, namespace, "rfr-test-0", "redis", int64(20)).Once().Return("# Fatal error loading the DB: Invalid argument. Exiting.\n", nil)
+	mk.On("GetPreviousPodLogs", mock.Anything, namespace, "rfr-test-1", "redis", int64(20)).Once().Return("# Can't handle RDB format version 11\n", nil)
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+
+	mk.AssertExpectations(t)
+	mk.AssertNumberOfCalls(t, "GetPreviousPodLogs", 4)
+}
+
+func TestUpdateStatusReportsRedisCrashes(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	meta.SetStatusCondition(&rf.Status.Conditions, metav1.Condition{Type: redisfailoverv1.ConditionReady, Status: metav1.ConditionTrue, Reason: redisfailoverv1.ReasonPodsReady})
+	expMessage := "2/3 redis and 3/3 sentinel pods ready\nredis pod rfr-test-0 is crash looping after 3 restarts, the last lines of its previous run:\n# Fatal error loading the DB: Invalid argument. Exiting."
+	crashing := generateCrashLoopingPod("rfr-test-0", 3)
+	ready := generateReadyPod("rfr-test-1", "", true)
+	ready.Status.ContainerStatuses = []corev1.ContainerStatus{generateContainerStatus("redis", 0, "")}
+
+	mk := &mK8SService.Services{}
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: []corev1.Pod{
+		crashing,
+		ready,
+		generateReadyPod("rfr-test-2", "", true),
+	}}, nil)
+	mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Return(&appsv1.StatefulSet{}, nil)
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Return(&corev1.PodList{Items: []corev1.Pod{
+		generateReadyPod("rfs-test-a", "", true),
+		generateReadyPod("rfs-test-b", "", true),
+		generateReadyPod("rfs-test-c", "", true),
+	}}, nil)
+	// The lines read are the ones of the configuration.
+	mk.On("GetPreviousPodLogs", mock.Anything, namespace, "rfr-test-0", "redis", int64(5)).Once().Return("# Fatal error loading the DB: Invalid argument. Exiting.\n", nil)
+	// The condition and its event tell what redis printed before crashing.
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.Reason == redisfailoverv1.ReasonRedisCrashLooping && e.Type == corev1.EventTypeWarning && e.Message == expMessage
+	})).Once().Return(nil)
+	mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+		condition := meta.FindStatusCondition(got.Status.Conditions, redisfailoverv1.ConditionReady)
+		return condition != nil && condition.Reason == redisfailoverv1.ReasonRedisCrashLooping && condition.Message == expMessage
+	})).Once().Return(rf, nil)
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("GetSyncSlotQueue", rf).Return([]string{})
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Return(map[string][]string{}, nil)
+	mrfc.On("MeasureRedisLatency", mock.Anything, rf).Return([]rfservice.RedisLatency{}, nil)
+	mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Return([]rfservice.RedisKeyspace{}, nil)
+	mrfc.On("GetDataDirMismatches", mock.Anything, rf).Return([]rfservice.DataDirMismatch{}, nil)
+
+	config := generateConfig()
+	config.CrashLogLines = 5
+	handler := rfOperator.NewRedisFailoverHandler(config, &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+	mk.AssertExpectations(t)
+}
@@ -0,0 +1,61 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// setDataDirCondition sets the degraded condition listing the redis pods writing their data out of their
+// data volume, or removes it when there are none.
+func setDataDirCondition(status *redisfailoverv1.RedisFailoverStatus, mismatches []rfservice.DataDirMismatch, generation int64) {
+	if len(mismatches) == 0 {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionDegraded)
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionDegraded,
+		Status:             metav1.ConditionTrue,
+		Reason:             redisfailoverv1.ReasonDataDirNotOnVolume,
+		Message:            dataDirMessage(mismatches),
+		ObservedGeneration: generation,
+	})
+}
+
+// dataDirMessage returns the message listing the dir of every redis pod and where its data volume is mounted.
+func dataDirMessage(mismatches []rfservice.DataDirMismatch) string {
+	pods := make([]string, 0, len(mismatches))
+	for _, m := range mismatches {
+		mount := "not mounted"
+		if m.MountPath != "" {
+			mount = "mounted on " + m.MountPath
+		}
+		pods = append(pods, fmt.Sprintf("%s writes to %s, its data volume is %s", m.Pod, m.Dir, mount))
+	}
+	return fmt.Sprintf("persistence is not on the persistent volume, the data is lost when redis restarts: %s; set spec.redis.dataDir to the dir of the redis image", strings.Join(pods, "; "))
+}
+
+// warnDataDir sends a warning event when the degraded condition of the new status is set or its message
+// changed, so the pods writing out of their data volume are reported once. A failure sending the event is
+// only logged.
+func (r *RedisFailoverHandler) warnDataDir(ctx context.Context, rf *redisfailoverv1.RedisFailover, status *redisfailoverv1.RedisFailoverStatus) {
+	degraded := meta.FindStatusCondition(status.Conditions, redisfailoverv1.ConditionDegraded)
+	if degraded == nil || degraded.Status != metav1.ConditionTrue {
+		return
+	}
+	if previous := meta.FindStatusCondition(rf.Status.Conditions, redisfailoverv1.ConditionDegraded); previous != nil && previous.Status == degraded.Status && previous.Message == degraded.Message {
+		return
+	}
+	event := newRFEvent(rfObjectReference(rf), corev1.EventTypeWarning, degraded.Reason, degraded.Message, time.Now())
+	if err := r.k8sservice.CreateEvent(ctx, rf.Namespace, event); err != nil {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not warn about the redis data dir: %s", err)
+	}
+}
@@ -0,0 +1,185 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// dataFlushMinKeys is the fewest keys of the master whose mass deletion is reported, the small datasets
+// are emptied on purpose too often.
+const dataFlushMinKeys = 100
+
+// The reasons of the events about the mass deletions of the keys of the master.
+const (
+	possibleDataFlushReason     = "PossibleDataFlush"
+	dataFlushAcknowledgedReason = "DataFlushAcknowledged"
+)
+
+// masterKeys are the keys of the master seen on a check, with the redis process holding them.
+type masterKeys struct {
+	pod   string
+	runID string
+	keys  int64
+}
+
+// DataFlushes tracks the keys of the master of every RF across the checks, and the last mass deletion of
+// them, so it's reported on the status and freezes the replicas until it's acknowledged.
+type DataFlushes struct {
+	mu      sync.Mutex
+	last    map[string]masterKeys
+	flushes map[string]*redisfailoverv1.DataFlushStatus
+}
+
+// NewDataFlushes returns new data flushes.
+func NewDataFlushes() *DataFlushes {
+	return &DataFlushes{
+		last:    map[string]masterKeys{},
+		flushes: map[string]*redisfailoverv1.DataFlushStatus{},
+	}
+}
+
+// Observe records the keys of the master and returns the keys of the previous check when more than the
+// threshold percent of them were deleted since, with neither a failover nor a restart of the master in
+// between: the same pod and the same redis process. The masters with fewer than dataFlushMinKeys keys
+// are not reported.
+func (d *DataFlushes) Observe(key string, master rfservice.RedisKeyspace, threshold int32) (int64, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current := masterKeys{pod: master.Pod, runID: master.RunID, keys: master.TotalKeys()}
+	previous, ok := d.last[key]
+	d.last[key] = current
+	if !ok || previous.pod != current.pod || previous.runID != current.runID || previous.keys < dataFlushMinKeys {
+		return 0, false
+	}
+	if deleted := previous.keys - current.keys; deleted*100 <= previous.keys*int64(threshold) {
+		return 0, false
+	}
+	return previous.keys, true
+}
+
+// Forget forgets the keys of the master of the RF, the next check starts over.
+func (d *DataFlushes) Forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.last, key)
+}
+
+// Last returns the last mass deletion of the RF, nil when there was none. It's taken from the status
+// when the operator doesn't know it, so it survives the restarts of the operator.
+func (d *DataFlushes) Last(rf *redisfailoverv1.RedisFailover) *redisfailoverv1.DataFlushStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := rfKey(rf)
+	if _, ok := d.flushes[key]; !ok && rf.Status.LastDataFlush != nil {
+		d.flushes[key] = rf.Status.LastDataFlush.DeepCopy()
+	}
+	return d.flushes[key].DeepCopy()
+}
+
+// Set records the last mass deletion of the RF.
+func (d *DataFlushes) Set(key string, flush *redisfailoverv1.DataFlushStatus) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.flushes[key] = flush.DeepCopy()
+}
+
+// Frozen returns true while the replicas of the RF are frozen: the RF freezes them on a mass deletion,
+// and the last one is not acknowledged.
+func (d *DataFlushes) Frozen(rf *redisfailoverv1.RedisFailover) bool {
+	if !rf.FreezeOnMassDeletionEnabled() {
+		return false
+	}
+	flush := d.Last(rf)
+	return flush != nil && flush.AcknowledgedAt == nil
+}
+
+// checkDataFlush reports a mass deletion of the keys of the master on the status, with a warning event,
+// and acknowledges the last one when the unfreeze annotation was set to another value since. The keys
+// of the redis pods are set on their instances.
+func (r *RedisFailoverHandler) checkDataFlush(ctx context.Context, rf *redisfailoverv1.RedisFailover, status *redisfailoverv1.RedisFailoverStatus, instances []redisfailoverv1.InstanceStatus, keyspaces []rfservice.RedisKeyspace, now time.Time) {
+	key := rfKey(rf)
+	unfreeze := rf.Unfreeze()
+	flush := r.dataFlushes.Last(rf)
+	if flush != nil && flush.AcknowledgedAt == nil && unfreeze != flush.Unfreeze {
+		acknowledged := metav1.NewTime(now)
+		flush.AcknowledgedAt = &acknowledged
+		r.dataFlushes.Set(key, flush)
+		r.dataFlushEvent(ctx, rf, corev1.EventTypeNormal, dataFlushAcknowledgedReason, fmt.Sprintf("the mass deletion of the keys of %s was acknowledged", flush.Pod), now)
+	}
+
+	keys := map[string]map[string]int64{}
+	master := false
+	for _, keyspace := range keyspaces {
+		keys[keyspace.Pod] = keyspace.Keys
+		if !keyspace.Master || master {
+			continue
+		}
+		master = true
+		previous, dropped := r.dataFlushes.Observe(key, keyspace, rf.MassDeletionThreshold())
+		if !dropped {
+			continue
+		}
+		flush = &redisfailoverv1.DataFlushStatus{
+			Pod:          keyspace.Pod,
+			PreviousKeys: previous,
+			Keys:         keyspace.TotalKeys(),
+			DetectedAt:   metav1.NewTime(now),
+			Unfreeze:     unfreeze,
+		}
+		r.dataFlushes.Set(key, flush)
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Errorf("The keys of the master %s dropped from %d to %d, a FLUSHALL may have emptied it", keyspace.Pod, previous, flush.Keys)
+		r.dataFlushEvent(ctx, rf, corev1.EventTypeWarning, possibleDataFlushReason, dataFlushMessage(flush, rf.FreezeOnMassDeletionEnabled()), now)
+	}
+	if !master {
+		r.dataFlushes.Forget(key)
+	}
+	for i := range instances {
+		if k, ok := keys[instances[i].Name]; ok && len(k) > 0 {
+			instances[i].Keys = k
+		}
+	}
+
+	status.LastDataFlush = flush
+	setDataFlushCondition(status, flush, rf.FreezeOnMassDeletionEnabled(), rf.Generation)
+}
+
+// dataFlushEvent creates an event about a mass deletion on the RF, a failure is only logged.
+func (r *RedisFailoverHandler) dataFlushEvent(ctx context.Context, rf *redisfailoverv1.RedisFailover, eventType, reason, message string, now time.Time) {
+	if err := r.k8sservice.CreateEvent(ctx, rf.Namespace, newRFEvent(rfObjectReference(rf), eventType, reason, message, now)); err != nil {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not create the %s event: %s", reason, err)
+	}
+}
+
+// dataFlushMessage explains a mass deletion and how to acknowledge it.
+func dataFlushMessage(flush *redisfailoverv1.DataFlushStatus, freeze bool) string {
+	message := fmt.Sprintf("the keys of the master %s dropped from %d to %d without a failover nor a restart, a FLUSHALL may have emptied it", flush.Pod, flush.PreviousKeys, flush.Keys)
+	if freeze {
+		message += "; the replicas are frozen so the ones that did not replicate it keep the data"
+	}
+	return fmt.Sprintf("%s. Set the %s annotation to a new value to acknowledge it", message, redisfailoverv1.UnfreezeAnnotation)
+}
+
+// setDataFlushCondition sets the possible data flush condition while the last mass deletion of the keys
+// of the master is not acknowledged, or removes it.
+func setDataFlushCondition(status *redisfailoverv1.RedisFailoverStatus, flush *redisfailoverv1.DataFlushStatus, freeze bool, generation int64) {
+	if flush == nil || flush.AcknowledgedAt != nil {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionPossibleDataFlush)
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionPossibleDataFlush,
+		Status:             metav1.ConditionTrue,
+		Reason:             redisfailoverv1.ReasonMasterKeysDropped,
+		Message:            dataFlushMessage(flush, freeze),
+		ObservedGeneration: generation,
+	})
+}
@@ -0,0 +1,247 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func masterKeyspace(pod, runID string, keys int64) rfservice.RedisKeyspace {
+	return rfservice.RedisKeyspace{Pod: pod, Master: true, RunID: runID, Keys: map[string]int64{"db0": keys}}
+}
+
+func TestDataFlushesObserve(t *testing.T) {
+	tests := []struct {
+		name        string
+		previous    []rfservice.RedisKeyspace
+		current     rfservice.RedisKeyspace
+		threshold   int32
+		expDropped  bool
+		expPrevious int64
+	}{
+		{
+			name:    "The first observation should not report a drop",
+			current: masterKeyspace("rfr-test-0", "a", 0),
+		},
+		{
+			name:        "A drop above the threshold should be reported",
+			previous:    []rfservice.RedisKeyspace{{Pod: "rfr-test-0", Master: true, RunID: "a", Keys: map[string]int64{"db0": 600, "db1": 400}}},
+			current:     masterKeyspace("rfr-test-0", "a", 10),
+			expDropped:  true,
+			expPrevious: 1000,
+		},
+		{
+			name:     "A drop of the threshold should not be reported",
+			previous: []rfservice.RedisKeyspace{masterKeyspace("rfr-test-0", "a", 1000)},
+			current:  masterKeyspace("rfr-test-0", "a", 500),
+		},
+		{
+			name:        "A lower threshold should report a smaller drop",
+			previous:    []rfservice.RedisKeyspace{masterKeyspace("rfr-test-0", "a", 1000)},
+			current:     masterKeyspace("rfr-test-0", "a", 700),
+			threshold:   20,
+			expDropped:  true,
+			expPrevious: 1000,
+		},
+		{
+			name:     "A failover should not report a drop",
+			previous: []rfservice.RedisKeyspace{masterKeyspace("rfr-test-0", "a", 1000)},
+			current:  masterKeyspace("rfr-test-1", "b", 0),
+		},
+		{
+			name:     "A restart of the master should not report a drop",
+			previous: []rfservice.RedisKeyspace{masterKeyspace("rfr-test-0", "a", 1000)},
+			current:  masterKeyspace("rfr-test-0", "b", 0),
+		},
+		{
+			name:     "A drop of a small dataset should not be reported",
+			previous: []rfservice.RedisKeyspace{masterKeyspace("rfr-test-0", "a", 50)},
+			current:  masterKeyspace("rfr-test-0", "a", 0),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			threshold := test.threshold
+			if threshold == 0 {
+				threshold = 50
+			}
+			flushes := rfOperator.NewDataFlushes()
+			for _, previous := range test.previous {
+				_, dropped := flushes.Observe("ns/test", previous, threshold)
+				assert.False(dropped)
+			}
+			previous, dropped := flushes.Observe("ns/test", test.current, threshold)
+			assert.Equal(test.expDropped, dropped)
+			assert.Equal(test.expPrevious, previous)
+		})
+	}
+}
+
+func TestCheckAndHealFreezesReplicasAfterDataFlush(t *testing.T) {
+	tests := []struct {
+		name         string
+		freeze       bool
+		acknowledged bool
+		expFrozen    bool
+	}{
+		{
+			name:      "An unacknowledged mass deletion should freeze the replicas",
+			freeze:    true,
+			expFrozen: true,
+		},
+		{
+			name:         "An acknowledged mass deletion should unfreeze the replicas",
+			freeze:       true,
+			acknowledged: true,
+		},
+		{
+			name: "A mass deletion should not freeze the replicas unless enabled",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Spec.Protection = &redisfailoverv1.ProtectionSettings{FreezeOnMassDeletion: test.freeze}
+			rf.Status.LastDataFlush = &redisfailoverv1.DataFlushStatus{Pod: "rfr-test-0", PreviousKeys: 1000, Keys: 10}
+			if test.acknowledged {
+				acknowledged := metav1.Now()
+				rf.Status.LastDataFlush.AcknowledgedAt = &acknowledged
+			}
+			master := "0.0.0.0"
+
+			mk := &mK8SService.Services{}
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfc.On("CheckRedisNumber", mock.Anything, rf).Once().Return(nil)
+			mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+			mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(1, nil)
+			mrfc.On("GetMasterIP", mock.Anything, rf).Return(master, nil)
+			mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, rf).Once().Return(errors.New("wanted error"))
+			mrfh.On("PlanRoleLabels", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+			mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Once().Return([]rfservice.PodAction{}, nil)
+			if !test.expFrozen {
+				// The replicas are reconfigured and the stale master is updated.
+				mrfh.On("PlanMasterOnAll", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+				mrfh.On("PlanStuckReplicas", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+				mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{master}, nil)
+				mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Once().Return("2", nil)
+				mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Once().Return([]string{}, nil)
+				mrfc.On("GetRedisesMasterPod", mock.Anything, rf).Once().Return("rfr-test-0", nil)
+				mrfc.On("GetRedisRevisionHash", mock.Anything, "rfr-test-0", rf).Once().Return("1", nil)
+				mrfh.On("DeletePod", mock.Anything, "rfr-test-0", rf).Once().Return(nil)
+				mk.On("CreateEvent", mock.Anything, namespace, eventReason("PodDeleted")).Once().Return(nil)
+			}
+			mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{}, nil)
+			mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{})
+			assert.NoError(err)
+
+			mrfc.AssertExpectations(t)
+			mrfh.AssertExpectations(t)
+			if test.expFrozen {
+				mrfh.AssertNotCalled(t, "PlanMasterOnAll", mock.Anything, mock.Anything, mock.Anything)
+				mrfh.AssertNotCalled(t, "PlanStuckReplicas", mock.Anything, mock.Anything, mock.Anything)
+				mrfh.AssertNotCalled(t, "DeletePod", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUpdateStatusDataFlush(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	rf.Spec.Protection = &redisfailoverv1.ProtectionSettings{FreezeOnMassDeletion: true}
+	keyspaces := []rfservice.RedisKeyspace{masterKeyspace("rfr-test-0", "a", 1000)}
+
+	mk := &mK8SService.Services{}
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Return(&corev1.PodList{Items: []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"}}}}, nil)
+	mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Return(&appsv1.StatefulSet{}, nil)
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Return(&corev1.PodList{}, nil)
+	mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.Anything).Run(func(args mock.Arguments) {
+		rf = args.Get(2).(*redisfailoverv1.RedisFailover)
+	}).Return(nil, nil)
+	events := []*corev1.Event{}
+	mk.On("CreateEvent", mock.Anything, namespace, mock.Anything).Run(func(args mock.Arguments) {
+		events = append(events, args.Get(2).(*corev1.Event))
+	}).Return(nil)
+
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("GetSyncSlotQueue", mock.Anything).Return([]string{})
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("GetNodeTuningWarnings", mock.Anything, mock.Anything).Return(map[string][]string{}, nil)
+	mrfc.On("MeasureRedisLatency", mock.Anything, mock.Anything).Return([]rfservice.RedisLatency{}, nil)
+	mrfc.On("GetRedisKeyspaces", mock.Anything, mock.Anything).Return(func(context.Context, *redisfailoverv1.RedisFailover) []rfservice.RedisKeyspace {
+		return keyspaces
+	}, nil)
+	mrfc.On("GetDataDirMismatches", mock.Anything, mock.Anything).Return([]rfservice.DataDirMismatch{}, nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+
+	// The keys of the master are recorded.
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+	assert.Equal(map[string]int64{"db0": 1000}, rf.Status.Instances[0].Keys)
+	assert.Nil(meta.FindStatusCondition(rf.Status.Conditions, redisfailoverv1.ConditionPossibleDataFlush))
+	assert.Empty(events)
+
+	// Most of them are deleted.
+	keyspaces = []rfservice.RedisKeyspace{masterKeyspace("rfr-test-0", "a", 10)}
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+	condition := meta.FindStatusCondition(rf.Status.Conditions, redisfailoverv1.ConditionPossibleDataFlush)
+	if assert.NotNil(condition) {
+		assert.Equal(metav1.ConditionTrue, condition.Status)
+		assert.Equal(redisfailoverv1.ReasonMasterKeysDropped, condition.Reason)
+		assert.Contains(condition.Message, "the replicas are frozen")
+	}
+	if assert.NotNil(rf.Status.LastDataFlush) {
+		assert.Equal("rfr-test-0", rf.Status.LastDataFlush.Pod)
+		assert.Equal(int64(1000), rf.Status.LastDataFlush.PreviousKeys)
+		assert.Equal(int64(10), rf.Status.LastDataFlush.Keys)
+		assert.Nil(rf.Status.LastDataFlush.AcknowledgedAt)
+	}
+	assert.Equal(redisfailoverv1.HealthPhaseDegraded, rf.Status.Health.Phase)
+	if assert.Len(events, 1) {
+		assert.Equal(corev1.EventTypeWarning, events[0].Type)
+		assert.Equal("PossibleDataFlush", events[0].Reason)
+	}
+
+	// The mass deletion stays reported until it's acknowledged.
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+	assert.NotNil(meta.FindStatusCondition(rf.Status.Conditions, redisfailoverv1.ConditionPossibleDataFlush))
+	assert.Len(events, 1)
+
+	// The unfreeze annotation acknowledges it.
+	rf.Annotations = map[string]string{redisfailoverv1.UnfreezeAnnotation: "1"}
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+	assert.Nil(meta.FindStatusCondition(rf.Status.Conditions, redisfailoverv1.ConditionPossibleDataFlush))
+	if assert.NotNil(rf.Status.LastDataFlush) {
+		assert.NotNil(rf.Status.LastDataFlush.AcknowledgedAt)
+	}
+	if assert.Len(events, 2) {
+		assert.Equal(corev1.EventTypeNormal, events[1].Type)
+		assert.Equal("DataFlushAcknowledged", events[1].Reason)
+	}
+}
@@ -0,0 +1,104 @@
+package redisfailover
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// deprecatedFieldsReason is the reason of the event warning about the deprecated fields of a RF.
+const deprecatedFieldsReason = "DeprecatedFieldsInUse"
+
+// deprecationUses keeps the deprecated fields used by every RF, to count the RFs using each of them,
+// and the RFs already warned about them since the operator started.
+type deprecationUses struct {
+	mu     sync.Mutex
+	fields map[string][]string
+	warned map[string]bool
+}
+
+func newDeprecationUses() *deprecationUses {
+	return &deprecationUses{
+		fields: map[string][]string{},
+		warned: map[string]bool{},
+	}
+}
+
+// set records the deprecated fields used by the RF and returns the number of RFs using every field
+// of the registry.
+func (d *deprecationUses) set(key string, fields []redisfailoverv1.DeprecatedField) map[string]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(fields) == 0 {
+		delete(d.fields, key)
+	} else {
+		paths := []string{}
+		for _, field := range fields {
+			paths = append(paths, field.Path)
+		}
+		d.fields[key] = paths
+	}
+
+	counts := map[string]int{}
+	for _, field := range redisfailoverv1.DeprecatedFields {
+		counts[field.Path] = 0
+	}
+	for _, paths := range d.fields {
+		for _, path := range paths {
+			counts[path]++
+		}
+	}
+	return counts
+}
+
+// warn returns true when the RF was not warned about yet, and marks it as warned.
+func (d *deprecationUses) warn(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.warned[key] {
+		return false
+	}
+	d.warned[key] = true
+	return true
+}
+
+// unwarn lets the RF be warned again, when the warning could not be sent.
+func (d *deprecationUses) unwarn(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.warned, key)
+}
+
+// CheckDeprecatedFields counts the RFs using every deprecated field, and warns with an event the first
+// time the RF is seen using some since the operator started. A failure sending the event is only
+// logged, it's sent again on the next reconcile.
+func (r *RedisFailoverHandler) CheckDeprecatedFields(ctx context.Context, rf *redisfailoverv1.RedisFailover) {
+	key := rfKey(rf)
+	fields := rf.DeprecatedFieldsInUse()
+	for path, count := range r.deprecations.set(key, fields) {
+		r.mClient.SetDeprecatedFieldUses(path, count)
+	}
+
+	if len(fields) == 0 || !r.deprecations.warn(key) {
+		return
+	}
+	event := newRFEvent(rfObjectReference(rf), corev1.EventTypeWarning, deprecatedFieldsReason, deprecatedFieldsMessage(fields), time.Now())
+	if err := r.k8sservice.CreateEvent(ctx, rf.Namespace, event); err != nil {
+		r.deprecations.unwarn(key)
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not warn about the deprecated fields: %s", err)
+	}
+}
+
+// deprecatedFieldsMessage returns the message listing the deprecated fields in use.
+func deprecatedFieldsMessage(fields []redisfailoverv1.DeprecatedField) string {
+	uses := []string{}
+	for _, field := range fields {
+		uses = append(uses, field.String())
+	}
+	return "the spec uses deprecated fields: " + strings.Join(uses, "; ")
+}
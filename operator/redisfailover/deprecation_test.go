@@ -0,0 +1,78 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+// deprecatedFieldUses returns the number of RFs using every deprecated field exposed by the registry.
+func deprecatedFieldUses(t *testing.T, reg *prometheus.Registry) map[string]float64 {
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	uses := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "test_controller_deprecated_field_uses" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			uses[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+		}
+	}
+	return uses
+}
+
+func TestCheckDeprecatedFields(t *testing.T) {
+	assert := assert.New(t)
+
+	deprecated := generateRF(false, false)
+	deprecated.Spec.Redis.MasterDNS = &redisfailoverv1.RedisMasterDNS{Hostname: "redis.example.com", TTL: 30}
+	other := generateRF(false, false)
+	other.Name = "other"
+	other.Spec.Redis.MasterDNS = &redisfailoverv1.RedisMasterDNS{Hostname: "other.example.com", TTL: 30}
+	current := generateRF(false, false)
+	current.Name = "current"
+
+	mk := &mK8SService.Services{}
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.Reason == "DeprecatedFieldsInUse" && e.InvolvedObject.Name == name &&
+			e.Message == "the spec uses deprecated fields: redis.masterDNS.ttl (use redis.masterDNS.ttlDuration, removed in v2)"
+	})).Once().Return(errors.New("apiserver unavailable"))
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.InvolvedObject.Name == name
+	})).Once().Return(nil)
+	mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+		return e.InvolvedObject.Name == "other"
+	})).Once().Return(nil)
+
+	reg := prometheus.NewRegistry()
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.NewRecorder("test", reg), log.Dummy)
+
+	// The event that could not be sent is sent again, then the RF is not warned again until the
+	// operator restarts.
+	for i := 0; i < 3; i++ {
+		handler.CheckDeprecatedFields(context.TODO(), deprecated)
+	}
+	handler.CheckDeprecatedFields(context.TODO(), other)
+	handler.CheckDeprecatedFields(context.TODO(), current)
+	mk.AssertExpectations(t)
+	assert.Equal(map[string]float64{"redis.masterDNS.ttl": 2, "redis.terminationGracePeriod": 0}, deprecatedFieldUses(t, reg))
+
+	// The RFs stop being counted once their spec is migrated.
+	other.Spec.Redis.MasterDNS.TTL = 0
+	handler.CheckDeprecatedFields(context.TODO(), other)
+	assert.Equal(map[string]float64{"redis.masterDNS.ttl": 1, "redis.terminationGracePeriod": 0}, deprecatedFieldUses(t, reg))
+}
@@ -0,0 +1,167 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
+)
+
+const (
+	// DefaultDiagnosisInterval is how long a RF is not diagnosed again after a diagnosis, however often
+	// the diagnose annotation changes.
+	DefaultDiagnosisInterval = 5 * time.Minute
+	// maxDoctorReportSize is the size every doctor report is capped to, so the reports of all the pods
+	// fit in the ConfigMap.
+	maxDoctorReportSize = 16 * 1024
+)
+
+// DiagnosisRequests runs the memory and latency doctors of the redis pods requested with the diagnose
+// annotation, and stores their reports into a ConfigMap next to the RF.
+type DiagnosisRequests struct {
+	k8sService  k8s.Services
+	redisClient redis.Client
+	now         func() time.Time
+	interval    time.Duration
+	logger      log.Logger
+
+	mu   sync.Mutex
+	last map[string]*redisfailoverv1.DiagnosisStatus
+}
+
+// NewDiagnosisRequests returns a new diagnosis request handler.
+func NewDiagnosisRequests(k8sService k8s.Services, redisClient redis.Client, now func() time.Time, logger log.Logger) *DiagnosisRequests {
+	return &DiagnosisRequests{
+		k8sService:  k8sService,
+		redisClient: redisClient,
+		now:         now,
+		interval:    DefaultDiagnosisInterval,
+		logger:      logger,
+		last:        map[string]*redisfailoverv1.DiagnosisStatus{},
+	}
+}
+
+// Last returns the last diagnosis of the RF run since the operator started, nil when there isn't any.
+func (d *DiagnosisRequests) Last(rf *redisfailoverv1.RedisFailover) *redisfailoverv1.DiagnosisStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.last[rfKey(rf)].DeepCopy()
+}
+
+// Ensure diagnoses the redis pods requested on the RF, if any. Every request is diagnosed once, and
+// it's held while the last diagnosis is more recent than the diagnosis interval. A doctor failing on
+// a pod is reported in its place.
+func (d *DiagnosisRequests) Ensure(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+	request, target := rf.DiagnoseRequest()
+	if request == "" {
+		return nil
+	}
+	last := d.Last(rf)
+	if last == nil {
+		last = rf.Status.LastDiagnosis
+	}
+	if last != nil && last.Request == request {
+		return nil
+	}
+	now := d.now()
+	if last != nil && now.Sub(last.Time.Time) < d.interval {
+		d.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Infof("diagnosis %q held, the redis pods were diagnosed less than %s ago", request, d.interval)
+		return nil
+	}
+
+	pods, err := d.getDiagnosedPods(ctx, rf, target)
+	if err != nil {
+		return err
+	}
+	password, err := k8s.GetRedisPassword(ctx, d.k8sService, rf)
+	if err != nil {
+		return err
+	}
+
+	port := getRedisPort(rf.Spec.Redis.Port)
+	data := map[string]string{}
+	names := []string{}
+	for _, pod := range pods {
+		memory, err := d.redisClient.MemoryDoctor(rf.PreferredPodIP(pod.Status), port, password)
+		data[pod.Name+".memory-doctor.txt"] = doctorReport(memory, err)
+		latency, err := d.redisClient.LatencyDoctor(rf.PreferredPodIP(pod.Status), port, password)
+		data[pod.Name+".latency-doctor.txt"] = doctorReport(latency, err)
+		names = append(names, pod.Name)
+	}
+
+	name := rfservice.GetDiagnosisName(rf)
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       rf.Namespace,
+			Labels:          labels,
+			OwnerReferences: ownerRefs,
+			Annotations: map[string]string{
+				redisfailoverv1.DiagnoseAnnotation: request,
+			},
+		},
+		Data: data,
+	}
+	if err := d.k8sService.CreateOrUpdateConfigMap(ctx, rf.Namespace, cm); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.last[rfKey(rf)] = &redisfailoverv1.DiagnosisStatus{
+		Request:   request,
+		Pods:      names,
+		ConfigMap: name,
+		Time:      metav1.NewTime(now),
+	}
+	d.mu.Unlock()
+
+	message := fmt.Sprintf("the redis pods %s were diagnosed, the reports are in the configmap %s", strings.Join(names, ", "), name)
+	if err := d.k8sService.CreateEvent(ctx, rf.Namespace, newRFEvent(rfObjectReference(rf), corev1.EventTypeNormal, "Diagnosed", message, now)); err != nil {
+		d.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not report the diagnosis: %s", err)
+	}
+	d.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Infof("diagnosis %q stored into configmap %s", request, name)
+	return nil
+}
+
+// getDiagnosedPods returns the redis pods targeted by the diagnosis, all the ones with an IP for
+// DiagnoseAll.
+func (d *DiagnosisRequests) getDiagnosedPods(ctx context.Context, rf *redisfailoverv1.RedisFailover, target string) ([]corev1.Pod, error) {
+	rps, err := d.k8sService.GetStatefulSetPods(ctx, rf.Namespace, rfservice.GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
+	}
+	pods := []corev1.Pod{}
+	for _, pod := range rps.Items {
+		switch {
+		case target == redisfailoverv1.DiagnoseAll && pod.Status.PodIP != "":
+			pods = append(pods, pod)
+		case pod.Name == target:
+			if pod.Status.PodIP == "" {
+				return nil, fmt.Errorf("the redis pod %s to diagnose has no IP yet", target)
+			}
+			return []corev1.Pod{pod}, nil
+		}
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no redis pod to diagnose matches %q", target)
+	}
+	return pods, nil
+}
+
+// doctorReport returns the report of a doctor as stored in the ConfigMap, the error when it failed.
+func doctorReport(report string, err error) string {
+	if err != nil {
+		return sanitizeText(fmt.Sprintf("error: %s", err), maxDoctorReportSize)
+	}
+	return sanitizeText(report, maxDoctorReportSize)
+}
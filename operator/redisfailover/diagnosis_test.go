@@ -0,0 +1,188 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+func generateDiagnosedPods() *corev1.PodList {
+	return &corev1.PodList{
+		Items: []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"}, Status: corev1.PodStatus{PodIP: "0.0.0.0"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"}, Status: corev1.PodStatus{PodIP: "0.0.0.1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-2"}},
+		},
+	}
+}
+
+func TestDiagnosisRequestsEnsure(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		request     string
+		last        *redisfailoverv1.DiagnosisStatus
+		expPods     []string
+		expData     map[string]string
+		expError    string
+		expDiagnose bool
+	}{
+		{
+			name: "Without a request nothing is diagnosed",
+		},
+		{
+			name:        "All the redis pods with an IP are diagnosed",
+			request:     "all",
+			expDiagnose: true,
+			expPods:     []string{"rfr-test-0", "rfr-test-1"},
+			expData: map[string]string{
+				"rfr-test-0.memory-doctor.txt":  "Hi Sam, I can't find any memory issue in your instance.",
+				"rfr-test-0.latency-doctor.txt": "Dave, no latency spike was observed.",
+				"rfr-test-1.memory-doctor.txt":  "Hi Sam, I can't find any memory issue in your instance.",
+				"rfr-test-1.latency-doctor.txt": "error: ERR latency monitoring is disabled",
+			},
+		},
+		{
+			name:        "A single redis pod is diagnosed",
+			request:     "rfr-test-0#2",
+			expDiagnose: true,
+			expPods:     []string{"rfr-test-0"},
+			expData: map[string]string{
+				"rfr-test-0.memory-doctor.txt":  "Hi Sam, I can't find any memory issue in your instance.",
+				"rfr-test-0.latency-doctor.txt": "Dave, no latency spike was observed.",
+			},
+		},
+		{
+			name:     "A pod without IP can't be diagnosed",
+			request:  "rfr-test-2",
+			expError: "the redis pod rfr-test-2 to diagnose has no IP yet",
+		},
+		{
+			name:     "A pod that is not a redis pod can't be diagnosed",
+			request:  "rfs-test-0",
+			expError: "no redis pod to diagnose matches \"rfs-test-0\"",
+		},
+		{
+			name:    "An already diagnosed request is not diagnosed again",
+			request: "all",
+			last:    &redisfailoverv1.DiagnosisStatus{Request: "all", Time: metav1.NewTime(now.Add(-time.Hour))},
+		},
+		{
+			name:    "A new request is held after a recent diagnosis",
+			request: "all#2",
+			last:    &redisfailoverv1.DiagnosisStatus{Request: "all", Time: metav1.NewTime(now.Add(-time.Minute))},
+		},
+		{
+			name:        "A new request is diagnosed once the diagnosis interval elapsed",
+			request:     "rfr-test-0#2",
+			last:        &redisfailoverv1.DiagnosisStatus{Request: "rfr-test-0", Time: metav1.NewTime(now.Add(-rfOperator.DefaultDiagnosisInterval))},
+			expDiagnose: true,
+			expPods:     []string{"rfr-test-0"},
+			expData: map[string]string{
+				"rfr-test-0.memory-doctor.txt":  "Hi Sam, I can't find any memory issue in your instance.",
+				"rfr-test-0.latency-doctor.txt": "Dave, no latency spike was observed.",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Spec.Redis.Port = 6379
+			if test.request != "" {
+				rf.Annotations = map[string]string{redisfailoverv1.DiagnoseAnnotation: test.request}
+			}
+			rf.Status.LastDiagnosis = test.last
+			labels := map[string]string{"app": "redis"}
+			oRefs := []metav1.OwnerReference{{Name: name}}
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Maybe().Return(generateDiagnosedPods(), nil)
+			mr := &mRedisService.Client{}
+			mr.On("MemoryDoctor", mock.Anything, "6379", "").Maybe().Return("Hi Sam, I can't find any memory issue in your instance.", nil)
+			mr.On("LatencyDoctor", "0.0.0.0", "6379", "").Maybe().Return("Dave, no latency spike was observed.", nil)
+			mr.On("LatencyDoctor", "0.0.0.1", "6379", "").Maybe().Return("", errors.New("ERR latency monitoring is disabled"))
+			if test.expDiagnose {
+				mk.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.MatchedBy(func(cm *corev1.ConfigMap) bool {
+					return assert.Equal("rfd-test", cm.Name) &&
+						assert.Equal(labels, cm.Labels) &&
+						assert.Equal(oRefs, cm.OwnerReferences) &&
+						assert.Equal(test.request, cm.Annotations[redisfailoverv1.DiagnoseAnnotation]) &&
+						assert.Equal(test.expData, cm.Data)
+				})).Once().Return(nil)
+				mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+					return e.Reason == "Diagnosed" && e.Type == corev1.EventTypeNormal
+				})).Once().Return(nil)
+			}
+
+			diagnoses := rfOperator.NewDiagnosisRequests(mk, mr, func() time.Time { return now }, log.Dummy)
+			err := diagnoses.Ensure(context.TODO(), rf, labels, oRefs)
+
+			if test.expError != "" {
+				assert.EqualError(err, test.expError)
+			} else {
+				assert.NoError(err)
+			}
+			mk.AssertExpectations(t)
+			last := diagnoses.Last(rf)
+			if !test.expDiagnose {
+				assert.Nil(last)
+				mr.AssertNotCalled(t, "MemoryDoctor", mock.Anything, mock.Anything, mock.Anything)
+				mk.AssertNotCalled(t, "CreateOrUpdateConfigMap", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.Equal(&redisfailoverv1.DiagnosisStatus{
+				Request:   test.request,
+				Pods:      test.expPods,
+				ConfigMap: "rfd-test",
+				Time:      metav1.NewTime(now),
+			}, last)
+		})
+	}
+}
+
+func TestDiagnosisReportsAreCapped(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	rf.Spec.Redis.Port = 6379
+	rf.Annotations = map[string]string{redisfailoverv1.DiagnoseAnnotation: "rfr-test-0"}
+
+	var data map[string]string
+	mk := &mK8SService.Services{}
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(generateDiagnosedPods(), nil)
+	mk.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+		data = args.Get(2).(*corev1.ConfigMap).Data
+	}).Return(nil)
+	mk.On("CreateEvent", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	mr := &mRedisService.Client{}
+	mr.On("MemoryDoctor", "0.0.0.0", "6379", "").Once().Return(strings.Repeat("Sam, the memory is fragmented\x1b[0m\r\n", 4096), nil)
+	mr.On("LatencyDoctor", "0.0.0.0", "6379", "").Once().Return("Dave, \x00no latency\xff spike was observed.\r\n", nil)
+
+	diagnoses := rfOperator.NewDiagnosisRequests(mk, mr, time.Now, log.Dummy)
+	assert.NoError(diagnoses.Ensure(context.TODO(), rf, nil, nil))
+
+	memory := data["rfr-test-0.memory-doctor.txt"]
+	assert.LessOrEqual(len(memory), 16*1024)
+	assert.True(strings.HasPrefix(memory, "Sam, the memory is fragmented[0m\n"))
+	assert.True(strings.HasSuffix(memory, "\n[truncated]"))
+	assert.NotContains(memory, "\x1b")
+	assert.NotContains(memory, "\r")
+	assert.Equal("Dave, no latency spike was observed.\n", data["rfr-test-0.latency-doctor.txt"])
+}
@@ -0,0 +1,229 @@
+package redisfailover
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// ChangeImpact tells how the change of a generated object is applied.
+type ChangeImpact string
+
+// Impacts of the change of a generated object.
+const (
+	ImpactInPlace  ChangeImpact = "in place"
+	ImpactRestart  ChangeImpact = "restart"
+	ImpactRejected ChangeImpact = "rejected"
+)
+
+// ObjectChange is a generated object that changes with a new spec.
+type ObjectChange struct {
+	Kind string
+	Name string
+	// Action is create, update or delete.
+	Action string
+	Impact ChangeImpact
+	Reason string
+}
+
+func (c ObjectChange) String() string {
+	return fmt.Sprintf("%s %s: %s (%s): %s", c.Kind, c.Name, c.Action, c.Impact, c.Reason)
+}
+
+// SpecDiff are the changes of the generated objects of a RF when its spec is changed.
+type SpecDiff struct {
+	// Invalid is the validation error of the new spec, then nothing changes.
+	Invalid error
+	Changes []ObjectChange
+	// SuppressedDefaults are the fields of the new spec left unset because their default was introduced
+	// after the defaults revision of the RF.
+	SuppressedDefaults []string
+}
+
+// RestartRequired returns true when a change restarts the redis or the sentinel pods.
+func (d *SpecDiff) RestartRequired() bool {
+	return d.count(ImpactRestart) > 0
+}
+
+// Rejected returns true when the new spec, or a change of a generated object, would be rejected.
+func (d *SpecDiff) Rejected() bool {
+	return d.Invalid != nil || d.count(ImpactRejected) > 0
+}
+
+func (d *SpecDiff) count(impact ChangeImpact) int {
+	n := 0
+	for _, change := range d.Changes {
+		if change.Impact == impact {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteSummary writes a line by changed object and the totals.
+func (d *SpecDiff) WriteSummary(w io.Writer) error {
+	if d.Invalid != nil {
+		_, err := fmt.Fprintf(w, "rejected by validation: %s\n", d.Invalid)
+		return err
+	}
+	for _, change := range d.Changes {
+		if _, err := fmt.Fprintln(w, change.String()); err != nil {
+			return err
+		}
+	}
+	for _, field := range d.SuppressedDefaults {
+		if _, err := fmt.Fprintf(w, "default not applied: %s, set it or raise the %s annotation\n", field, redisfailoverv1.DefaultsRevisionAnnotation); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d changed, %d restarting pods, %d rejected\n", len(d.Changes), d.count(ImpactRestart), d.count(ImpactRejected))
+	return err
+}
+
+// DiffSpec builds the objects generated for the live RF and for the RF with the desired spec, with the
+// generators of the operator, and classifies their changes as the operator applies them. The generated
+// objects are compared instead of the live ones, so the fields defaulted by the API server don't show
+// up as changes.
+func DiffSpec(live, desired *redisfailoverv1.RedisFailover, password string, logger log.Logger) (*SpecDiff, error) {
+	live = live.DeepCopy()
+	if err := live.Validate(); err != nil {
+		return nil, fmt.Errorf("the live redisfailover is invalid: %w", err)
+	}
+	desired = desired.DeepCopy()
+	suppressed, err := desired.SuppressedDefaults()
+	if err != nil {
+		return &SpecDiff{Invalid: err}, nil
+	}
+	if err := desired.Validate(); err != nil {
+		return &SpecDiff{Invalid: err}, nil
+	}
+
+	liveState, err := rfservice.BuildDesiredState(live, GeneratedLabels(live, logger), createOwnerReferences(live), password)
+	if err != nil {
+		return nil, err
+	}
+	desiredState, err := rfservice.BuildDesiredState(desired, GeneratedLabels(desired, logger), createOwnerReferences(desired), password)
+	if err != nil {
+		return &SpecDiff{Invalid: err}, nil
+	}
+
+	liveByRef := map[rfservice.ObjectRef]rfservice.DesiredObject{}
+	for _, o := range liveState.Objects {
+		liveByRef[o.Ref()] = o
+	}
+
+	diff := &SpecDiff{Changes: []ObjectChange{}, SuppressedDefaults: suppressed}
+	desiredRefs := map[rfservice.ObjectRef]bool{}
+	for _, o := range desiredState.Objects {
+		desiredRefs[o.Ref()] = true
+		old, ok := liveByRef[o.Ref()]
+		if !ok {
+			diff.Changes = append(diff.Changes, ObjectChange{Kind: o.Kind, Name: o.Name, Action: "create", Impact: ImpactInPlace, Reason: "the object is created"})
+			continue
+		}
+		if old.Hash == o.Hash {
+			continue
+		}
+		impact, reason := classifyUpdate(desired, old, o)
+		diff.Changes = append(diff.Changes, ObjectChange{Kind: o.Kind, Name: o.Name, Action: "update", Impact: impact, Reason: reason})
+	}
+	for _, o := range liveState.Objects {
+		if desiredRefs[o.Ref()] {
+			continue
+		}
+		change := ObjectChange{Kind: o.Kind, Name: o.Name, Action: "delete", Impact: ImpactInPlace, Reason: "the object is deleted"}
+		if o.Kind == rfservice.KindStatefulSet || o.Kind == rfservice.KindDeployment {
+			change.Impact = ImpactRestart
+			change.Reason = "the object and its pods are deleted"
+		}
+		diff.Changes = append(diff.Changes, change)
+	}
+	return diff, nil
+}
+
+// classifyUpdate returns how the operator applies the update of a generated object. The redis pods
+// are only restarted when the pod template of the statefulset changes, the custom config is set at
+// runtime. With the zero downtime reload the config only read on startup restarts redis in its pod.
+func classifyUpdate(rf *redisfailoverv1.RedisFailover, old, updated rfservice.DesiredObject) (ChangeImpact, string) {
+	switch n := updated.Object.(type) {
+	case *appsv1.StatefulSet:
+		o := old.Object.(*appsv1.StatefulSet)
+		immutable := []struct {
+			field string
+			same  bool
+		}{
+			{"selector", equality.Semantic.DeepEqual(o.Spec.Selector, n.Spec.Selector)},
+			{"serviceName", o.Spec.ServiceName == n.Spec.ServiceName},
+			{"volumeClaimTemplates", equality.Semantic.DeepEqual(o.Spec.VolumeClaimTemplates, n.Spec.VolumeClaimTemplates)},
+			{"podManagementPolicy", o.Spec.PodManagementPolicy == n.Spec.PodManagementPolicy},
+		}
+		for _, f := range immutable {
+			if !f.same {
+				return ImpactRejected, fmt.Sprintf("the %s of a statefulset is immutable", f.field)
+			}
+		}
+		if !equality.Semantic.DeepEqual(o.Spec.Template, n.Spec.Template) {
+			return ImpactRestart, "the pod template changes, the operator restarts the redis pods one by one"
+		}
+		return ImpactInPlace, "the statefulset is updated, its pods are kept"
+	case *appsv1.Deployment:
+		o := old.Object.(*appsv1.Deployment)
+		if !equality.Semantic.DeepEqual(o.Spec.Selector, n.Spec.Selector) {
+			return ImpactRejected, "the selector of a deployment is immutable"
+		}
+		oldHash, err := rfservice.DeploymentTemplateHash(o)
+		if err != nil {
+			return ImpactRestart, fmt.Sprintf("the pod template can't be compared: %s", err)
+		}
+		newHash, err := rfservice.DeploymentTemplateHash(n)
+		if err != nil {
+			return ImpactRestart, fmt.Sprintf("the pod template can't be compared: %s", err)
+		}
+		if oldHash != newHash {
+			return ImpactRestart, "the pod template changes, the sentinel pods are rolled one by one"
+		}
+		return ImpactInPlace, "the deployment is updated, its pods are kept"
+	}
+
+	if updated.Kind == rfservice.KindConfigMap {
+		redisConfig := rfservice.GetRedisName(rf)
+		switch {
+		case updated.Name == redisConfig || strings.HasPrefix(updated.Name, redisConfig+"-part-"):
+			if rf.ZeroDowntimeReloadEnabled() && restartRequiredConfig(old) != restartRequiredConfig(updated) {
+				return ImpactRestart, "the redis config only read on startup changes, the operator restarts redis in place one by one and the pods are kept"
+			}
+			return ImpactInPlace, "the operator sets the redis custom config at runtime"
+		case updated.Name == rfservice.GetSentinelName(rf):
+			return ImpactInPlace, "the operator sets the sentinel custom config at runtime"
+		}
+		return ImpactInPlace, "the mounted files are updated in the pods"
+	}
+	return ImpactInPlace, fmt.Sprintf("the %s is updated", strings.ToLower(updated.Kind))
+}
+
+// restartRequiredConfig returns the lines of a redis config ConfigMap only read on startup.
+func restartRequiredConfig(o rfservice.DesiredObject) string {
+	cm, ok := o.Object.(*corev1.ConfigMap)
+	if !ok {
+		return ""
+	}
+	lines := []string{}
+	for _, data := range cm.Data {
+		for _, line := range strings.Split(data, "\n") {
+			if redisfailoverv1.IsRestartRequiredRedisDirective(strings.SplitN(strings.TrimSpace(line), " ", 2)[0]) {
+				lines = append(lines, strings.TrimSpace(line))
+			}
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,109 @@
+package redisfailover_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+func TestDiffSpec(t *testing.T) {
+	tests := []struct {
+		name        string
+		live        func(rf *redisfailoverv1.RedisFailover)
+		change      func(rf *redisfailoverv1.RedisFailover)
+		golden      string
+		expRestart  bool
+		expRejected bool
+	}{
+		{
+			name: "A custom config change is set at runtime, the generated objects are kept",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.Redis.CustomConfig = []string{"maxmemory 1gb"}
+			},
+			golden: "diff-config-change.golden",
+		},
+		{
+			name: "An image change restarts the redis pods",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.Redis.Image = "redis:7.2"
+			},
+			golden:     "diff-image-change.golden",
+			expRestart: true,
+		},
+		{
+			name: "A config only read on startup restarts redis in place",
+			live: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.Redis.ZeroDowntimeReload.Enabled = true
+			},
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.Redis.CustomConfig = []string{"io-threads 4"}
+			},
+			golden:     "diff-in-place-restart.golden",
+			expRestart: true,
+		},
+		{
+			name: "The defaults introduced after the defaults revision are listed",
+			live: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Annotations = map[string]string{redisfailoverv1.DefaultsRevisionAnnotation: "7"}
+			},
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.Redis.CustomConfig = []string{"maxmemory 1gb"}
+			},
+			golden: "diff-suppressed-defaults.golden",
+		},
+		{
+			name: "An invalid spec is rejected",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				seconds := int32(-1)
+				rf.Spec.Failover.StabilizationSeconds = &seconds
+			},
+			golden:      "diff-invalid.golden",
+			expRejected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			live := generateRF(false, false)
+			if test.live != nil {
+				test.live(live)
+			}
+			desired := live.DeepCopy()
+			test.change(desired)
+
+			diff, err := rfOperator.DiffSpec(live, desired, "", log.Dummy)
+			require.NoError(err)
+
+			var out bytes.Buffer
+			require.NoError(diff.WriteSummary(&out))
+			expected, err := os.ReadFile(filepath.Join("testdata", test.golden))
+			require.NoError(err)
+			assert.Equal(string(expected), out.String())
+			assert.Equal(test.expRestart, diff.RestartRequired())
+			assert.Equal(test.expRejected, diff.Rejected())
+		})
+	}
+}
+
+func TestDiffSpecWithoutChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(true, false)
+	diff, err := rfOperator.DiffSpec(rf, rf, "", log.Dummy)
+
+	assert.NoError(err)
+	assert.Empty(diff.Changes)
+	assert.False(diff.RestartRequired())
+	assert.False(diff.Rejected())
+}
@@ -1,52 +1,32 @@
 package redisfailover
 
 import (
+	"context"
+	"errors"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	redisfailoverv1 "redis-operator/api/redisfailover/v1"
 	"redis-operator/metrics"
+	"redis-operator/service/k8s"
 )
 
-// Ensure is called to ensure all of the resources associated with a RedisFailover are created
-func (w *RedisFailoverHandler) Ensure(rf *redisfailoverv1.RedisFailover, labels map[string]string, or []metav1.OwnerReference, metricsClient metrics.Recorder) error {
-	if rf.Spec.Redis.Exporter.Enabled {
-		if err := w.rfService.EnsureRedisService(rf, labels, or); err != nil {
-			return err
-		}
-	} else {
-		if err := w.rfService.EnsureNotPresentRedisService(rf); err != nil {
-			return err
-		}
-	}
-
-	sentinelsAllowed := rf.SentinelsAllowed()
-	if sentinelsAllowed {
-		if err := w.rfService.EnsureSentinelService(rf, labels, or); err != nil {
-			return err
-		}
-		if err := w.rfService.EnsureSentinelConfigMap(rf, labels, or); err != nil {
-			return err
-		}
-	}
-
-	if err := w.rfService.EnsureRedisShutdownConfigMap(rf, labels, or); err != nil {
-		return err
-	}
-	if err := w.rfService.EnsureRedisReadinessConfigMap(rf, labels, or); err != nil {
-		return err
-	}
-	if err := w.rfService.EnsureRedisConfigMap(rf, labels, or); err != nil {
+// Ensure is called to ensure all of the resources associated with a RedisFailover are created, after the
+// generated secret of the password they are configured with. The objects of the components not deployed,
+// like the exporter service, are deleted. The PodDisruptionBudgets
+// are skipped with a warning condition on the clusters serving no PodDisruptionBudget API. The volumes of
+// the redis pods are then resized to the storage of the RF.
+func (w *RedisFailoverHandler) Ensure(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, or []metav1.OwnerReference, metricsClient metrics.Recorder) error {
+	if err := w.EnsureGeneratedSecret(ctx, rf, labels, or); err != nil {
 		return err
 	}
-	if err := w.rfService.EnsureRedisStatefulset(rf, labels, or); err != nil {
+	err := w.rfService.EnsureDesiredState(ctx, rf, labels, or)
+	skipped := errors.Is(err, k8s.ErrPodDisruptionBudgetsUnavailable)
+	w.pdbSkips.Set(rfKey(rf), skipped)
+	if skipped {
+		w.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("the PodDisruptionBudgets are not written: %s", err)
+	} else if err != nil {
 		return err
 	}
-
-	if sentinelsAllowed {
-		if err := w.rfService.EnsureSentinelDeployment(rf, labels, or); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return w.EnsureRedisVolumesSize(ctx, rf)
 }
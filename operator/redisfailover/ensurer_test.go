@@ -1,10 +1,15 @@
 package redisfailover_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	redisfailoverv1 "redis-operator/api/redisfailover/v1"
@@ -13,6 +18,8 @@ import (
 	mRFService "redis-operator/mocks/operator/redisfailover/service"
 	mK8SService "redis-operator/mocks/service/k8s"
 	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
 )
 
 const (
@@ -60,34 +67,15 @@ func generateRFBootstrappingNode(bootstrapping bool) *redisfailoverv1.BootstrapS
 
 func TestEnsure(t *testing.T) {
 	tests := []struct {
-		name                        string
-		exporter                    bool
-		bootstrapping               bool
-		bootstrappingAllowSentinels bool
+		name      string
+		ensureErr error
 	}{
 		{
-			name:                        "Call everything, use exporter",
-			exporter:                    true,
-			bootstrapping:               false,
-			bootstrappingAllowSentinels: false,
+			name: "Ensure the desired state of the RF",
 		},
 		{
-			name:                        "Call everything, don't use exporter",
-			exporter:                    false,
-			bootstrapping:               false,
-			bootstrappingAllowSentinels: false,
-		},
-		{
-			name:                        "Only ensure Redis when bootstrapping",
-			exporter:                    false,
-			bootstrapping:               true,
-			bootstrappingAllowSentinels: false,
-		},
-		{
-			name:                        "call everything when bootstrapping allows sentinels",
-			exporter:                    false,
-			bootstrapping:               true,
-			bootstrappingAllowSentinels: true,
+			name:      "An error ensuring the desired state is returned",
+			ensureErr: errors.New("wanted error"),
 		},
 	}
 
@@ -95,39 +83,67 @@ func TestEnsure(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			assert := assert.New(t)
 
-			rf := generateRF(test.exporter, test.bootstrapping)
-			if test.bootstrapping {
-				rf.Spec.BootstrapNode.AllowSentinels = test.bootstrappingAllowSentinels
-			}
+			rf := generateRF(true, false)
+			labels := map[string]string{"team": "cache"}
+			ownerRefs := []metav1.OwnerReference{{Kind: "RedisFailover", Name: name}}
 
 			config := generateConfig()
 			mk := &mK8SService.Services{}
 			mrfc := &mRFService.RedisFailoverCheck{}
 			mrfh := &mRFService.RedisFailoverHeal{}
 			mrfs := &mRFService.RedisFailoverClient{}
-			if test.exporter {
-				mrfs.On("EnsureRedisService", rf, mock.Anything, mock.Anything).Once().Return(nil)
-			} else {
-				mrfs.On("EnsureNotPresentRedisService", rf).Once().Return(nil)
-			}
-
-			if !test.bootstrapping || test.bootstrappingAllowSentinels {
-				mrfs.On("EnsureSentinelService", rf, mock.Anything, mock.Anything).Once().Return(nil)
-				mrfs.On("EnsureSentinelConfigMap", rf, mock.Anything, mock.Anything).Once().Return(nil)
-				mrfs.On("EnsureSentinelDeployment", rf, mock.Anything, mock.Anything).Once().Return(nil)
-			}
-
-			mrfs.On("EnsureRedisConfigMap", rf, mock.Anything, mock.Anything).Once().Return(nil)
-			mrfs.On("EnsureRedisShutdownConfigMap", rf, mock.Anything, mock.Anything).Once().Return(nil)
-			mrfs.On("EnsureRedisReadinessConfigMap", rf, mock.Anything, mock.Anything).Once().Return(nil)
-			mrfs.On("EnsureRedisStatefulset", rf, mock.Anything, mock.Anything).Once().Return(nil)
+			mrfs.On("EnsureDesiredState", mock.Anything, rf, labels, ownerRefs).Once().Return(test.ensureErr)
 
-			// Create the Kops client and call the valid logic.
 			handler := rfOperator.NewRedisFailoverHandler(config, mrfs, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
-			err := handler.Ensure(rf, map[string]string{}, []metav1.OwnerReference{}, metrics.Dummy)
+			err := handler.Ensure(context.TODO(), rf, labels, ownerRefs, metrics.Dummy)
 
-			assert.NoError(err)
+			if test.ensureErr != nil {
+				assert.ErrorIs(err, test.ensureErr)
+			} else {
+				assert.NoError(err)
+			}
 			mrfs.AssertExpectations(t)
 		})
 	}
 }
+
+func TestEnsureSkipsUnavailablePodDisruptionBudgets(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	mk := &mK8SService.Services{}
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfs := &mRFService.RedisFailoverClient{}
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), mrfs, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Return(&corev1.PodList{}, nil)
+	mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Return(&appsv1.StatefulSet{}, nil)
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Return(&corev1.PodList{}, nil)
+	mrfh.On("GetSyncSlotQueue", rf).Return([]string{})
+	mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Return(map[string][]string{}, nil)
+	mrfc.On("MeasureRedisLatency", mock.Anything, rf).Return([]rfservice.RedisLatency{}, nil)
+	mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Return([]rfservice.RedisKeyspace{}, nil)
+	mrfc.On("GetDataDirMismatches", mock.Anything, rf).Return([]rfservice.DataDirMismatch{}, nil)
+
+	// The cluster serves no PodDisruptionBudget API, the rest of the desired state is written.
+	mrfs.On("EnsureDesiredState", mock.Anything, rf, mock.Anything, mock.Anything).Once().Return(k8s.ErrPodDisruptionBudgetsUnavailable)
+	assert.NoError(handler.Ensure(context.TODO(), rf, nil, nil, metrics.Dummy))
+	mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+		condition := meta.FindStatusCondition(got.Status.Conditions, redisfailoverv1.ConditionPodDisruptionBudgetWarning)
+		return condition != nil && condition.Reason == redisfailoverv1.ReasonPodDisruptionBudgetsUnavailable
+	})).Once().Return(rf, nil)
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+
+	// The condition is removed once the PodDisruptionBudgets are written.
+	rf.Status.Conditions = []metav1.Condition{{Type: redisfailoverv1.ConditionPodDisruptionBudgetWarning, Status: metav1.ConditionTrue}}
+	mrfs.On("EnsureDesiredState", mock.Anything, rf, mock.Anything, mock.Anything).Once().Return(nil)
+	assert.NoError(handler.Ensure(context.TODO(), rf, nil, nil, metrics.Dummy))
+	mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+		return meta.FindStatusCondition(got.Status.Conditions, redisfailoverv1.ConditionPodDisruptionBudgetWarning) == nil
+	})).Once().Return(rf, nil)
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+
+	mrfs.AssertExpectations(t)
+	mk.AssertExpectations(t)
+}
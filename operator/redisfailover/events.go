@@ -0,0 +1,105 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/service/k8s"
+)
+
+// eventInterval is the shortest time between two events of the same reason and message on a RF.
+const eventInterval = 5 * time.Minute
+
+// The reasons of the events about the lifecycle actions of the operator.
+const (
+	masterFailoverReason = "MasterFailover"
+	sentinelResetReason  = "SentinelReset"
+	podDeletedReason     = "PodDeleted"
+	podEvictedReason     = "PodEvicted"
+	// evictionBlockedReason is the reason of the events of the evictions refused by the pdb.
+	evictionBlockedReason = "EvictionBlocked"
+)
+
+// eventWindow is the last event of a reason and message created on a RF, and the repeats suppressed
+// since.
+type eventWindow struct {
+	at         time.Time
+	suppressed int
+}
+
+// EventRecorder creates the events of the lifecycle actions on the RFs. The same event is created at
+// most once per eventInterval on a RF, the repeats are counted in the next one, so a flapping check
+// doesn't flood the RF with events.
+type EventRecorder struct {
+	k8sService k8s.Services
+	logger     log.Logger
+	now        func() time.Time
+
+	mu      sync.Mutex
+	windows map[string]map[string]*eventWindow
+}
+
+// NewEventRecorder returns a new event recorder.
+func NewEventRecorder(k8sService k8s.Services, now func() time.Time, logger log.Logger) *EventRecorder {
+	return &EventRecorder{
+		k8sService: k8sService,
+		logger:     logger,
+		now:        now,
+		windows:    map[string]map[string]*eventWindow{},
+	}
+}
+
+// Record creates the event on the RF, unless the same one was created in the last eventInterval. A
+// failure creating it is only logged.
+func (e *EventRecorder) Record(ctx context.Context, rf *redisfailoverv1.RedisFailover, eventType, reason, message string) {
+	now := e.now()
+	suppressed, ok := e.allow(rfKey(rf), reason+"/"+message, now)
+	if !ok {
+		return
+	}
+	if suppressed > 0 {
+		message = fmt.Sprintf("%s (repeated %d times in the last %s)", message, suppressed, eventInterval)
+	}
+	if err := e.k8sService.CreateEvent(ctx, rf.Namespace, newRFEvent(rfObjectReference(rf), eventType, reason, message, now)); err != nil {
+		e.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not create the %s event: %s", reason, err)
+	}
+}
+
+// allow returns true when the event can be created, with the number of its repeats suppressed since
+// the last one. The windows over are dropped.
+func (e *EventRecorder) allow(key, event string, now time.Time) (int, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	windows := e.windows[key]
+	if windows == nil {
+		windows = map[string]*eventWindow{}
+		e.windows[key] = windows
+	}
+	for k, w := range windows {
+		if now.Sub(w.at) >= eventInterval && k != event {
+			delete(windows, k)
+		}
+	}
+	w, ok := windows[event]
+	if ok && now.Sub(w.at) < eventInterval {
+		w.suppressed++
+		return 0, false
+	}
+	suppressed := 0
+	if ok {
+		suppressed = w.suppressed
+	}
+	windows[event] = &eventWindow{at: now}
+	return suppressed, true
+}
+
+// Forget forgets the events created on the RF.
+func (e *EventRecorder) Forget(rf *redisfailoverv1.RedisFailover) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.windows, rfKey(rf))
+}
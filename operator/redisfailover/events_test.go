@@ -0,0 +1,60 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+func TestEventRecorderRateLimitsRepeats(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	now := time.Now()
+	clock := func() time.Time { return now }
+	var messages []string
+	mk := &mK8SService.Services{}
+	mk.On("CreateEvent", mock.Anything, namespace, eventReason("CheckFailed")).Run(func(args mock.Arguments) {
+		event := args.Get(2).(*corev1.Event)
+		assert.Equal(corev1.EventTypeWarning, event.Type)
+		assert.Equal(name, event.InvolvedObject.Name)
+		messages = append(messages, event.Message)
+	}).Return(nil)
+	mk.On("CreateEvent", mock.Anything, namespace, eventReason("MasterFailover")).Once().Return(errors.New("forbidden"))
+	events := rfOperator.NewEventRecorder(mk, clock, log.Dummy)
+
+	// The repeats of a flapping check are suppressed for the interval.
+	for i := 0; i < 3; i++ {
+		events.Record(context.TODO(), rf, corev1.EventTypeWarning, "CheckFailed", "sentinels unreachable")
+		now = now.Add(time.Minute)
+	}
+	assert.Equal([]string{"sentinels unreachable"}, messages)
+
+	// Another message is not suppressed by the first one.
+	events.Record(context.TODO(), rf, corev1.EventTypeWarning, "CheckFailed", "redis unreachable")
+	assert.Len(messages, 2)
+
+	// After the interval, the next event counts the repeats suppressed.
+	now = now.Add(3 * time.Minute)
+	events.Record(context.TODO(), rf, corev1.EventTypeWarning, "CheckFailed", "sentinels unreachable")
+	assert.Equal("sentinels unreachable (repeated 2 times in the last 5m0s)", messages[2])
+
+	// A forgotten RF starts over.
+	events.Forget(rf)
+	events.Record(context.TODO(), rf, corev1.EventTypeWarning, "CheckFailed", "redis unreachable")
+	assert.Equal("redis unreachable", messages[3])
+
+	// A failure creating the event is not returned.
+	events.Record(context.TODO(), rf, corev1.EventTypeNormal, "MasterFailover", "promoted")
+
+	mk.AssertExpectations(t)
+}
@@ -2,6 +2,7 @@ package redisfailover
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/spotahome/kooper/v2/controller"
@@ -13,9 +14,12 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
 	"redis-operator/log"
 	"redis-operator/metrics"
+	"redis-operator/operator/redisfailover/fleet"
 	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/operator/redisfailover/supportbundle"
 	"redis-operator/service/k8s"
 	"redis-operator/service/redis"
 )
@@ -29,15 +33,77 @@ const (
 // New will create an operator that is responsible of managing all the required stuff
 // to create redis failovers.
 func New(cfg Config, k8sService k8s.Services, k8sClient kubernetes.Interface, lockNamespace string, redisClient redis.Client, kooperMetricsRecorder metrics.Recorder, logger log.Logger) (controller.Controller, error) {
+	// The naming templates and the feature gates are checked before the handler is created, it ignores
+	// them when invalid.
+	if _, err := NewNaming(cfg.NamePrefixTemplate, cfg.CommonLabelsTemplate); err != nil {
+		return nil, err
+	}
+	featureGates, err := NewFeatureGateResolver(cfg.FeatureGates, logger)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create internal services.
 	rfService := rfservice.NewRedisFailoverKubeClient(k8sService, logger, kooperMetricsRecorder)
+	rfService.DesiredStates = rfservice.NewDesiredStateCache(cfg.OperatorVersion, cfg.FeatureGates)
+	rfService.FeatureGates = featureGates.Resolve
+	rfService.ServerSideApply = cfg.ServerSideApply
 	rfChecker := rfservice.NewRedisFailoverChecker(k8sService, redisClient, logger, kooperMetricsRecorder)
 	rfHealer := rfservice.NewRedisFailoverHealer(k8sService, redisClient, logger)
 
 	// Create the handlers.
 	rfHandler := NewRedisFailoverHandler(cfg, rfService, rfChecker, rfHealer, k8sService, kooperMetricsRecorder, logger)
+	rfService.Events = rfHandler.events.Record
+	rfHandler.sentinelEvents = NewSentinelEventWatcher(k8sService, redis.DialSentinelEvents, logger)
+	collector := supportbundle.NewCollector(k8sService, redisClient, logger)
+	if cfg.SupportBundleLogLines > 0 {
+		rfHandler.recentLogs = log.NewRecentLines(cfg.SupportBundleLogLines)
+		log.KeepRecentLines(rfHandler.recentLogs)
+		collector.OperatorLogs = rfHandler.recentLogs.Lines
+	}
+	rfHandler.supportBundles = NewSupportBundleRequests(k8sService, collector, logger)
+	rfHandler.diagnoses = NewDiagnosisRequests(k8sService, redisClient, time.Now, logger)
+	rfHandler.apiBackoff = NewAPIServerBackoff(k8s.DefaultAPIServerPressure, time.Now, kooperMetricsRecorder, logger)
+	if cfg.ChecksDebugPath != "" {
+		http.Handle(cfg.ChecksDebugPath, rfHandler.checkRuns)
+	}
+	if cfg.FleetReportPath != "" {
+		http.Handle(cfg.FleetReportPath, fleet.NewHandler(fleet.NewCollector(k8sService, redisClient, logger), cfg.ListPageSize))
+	}
+	if cfg.OperatorIdentity != "" {
+		rfHandler.ownerClaims = NewOwnerClaims(k8sService, cfg.OperatorIdentity, cfg.LeaseHolder, time.Now)
+	}
 	rfRetriever := NewRedisFailoverRetriever(k8sService)
 
+	// The reconciles in flight are drained when the operator is stopped.
+	var handler controller.Handler = rfHandler
+	var drainer *ReconcileDrainer
+	if cfg.ShutdownGracePeriod > 0 {
+		drainer = NewReconcileDrainer(rfHandler, logger)
+		handler = drainer
+	}
+	// The reconciles are limited per namespace and ramped up after a restart, on top of the workers.
+	if cfg.NamespaceConcurrency > 0 || cfg.WarmUpRamp > 0 {
+		limiter := NewReconcileLimiter(handler, cfg.NamespaceConcurrency, time.Now, kooperMetricsRecorder, logger)
+		if cfg.WarmUpRamp > 0 {
+			rfs := []redisfailoverv1.RedisFailover{}
+			err := k8sService.EachRedisFailover(context.Background(), "", metav1.ListOptions{Limit: cfg.ListPageSize}, func(rf *redisfailoverv1.RedisFailover) error {
+				rfs = append(rfs, *rf)
+				return nil
+			})
+			if err != nil {
+				logger.Warnf("Skipping the warm-up, the redisfailovers could not be listed: %s", err)
+			} else {
+				limiter.WarmUp(rfs, cfg.WarmUpRamp)
+			}
+		}
+		handler = limiter
+	}
+	// The bursts of events of an RF are merged into one reconcile before they are limited.
+	if cfg.CoalesceWindow > 0 {
+		handler = NewReconcileCoalescer(handler, cfg.CoalesceWindow, kooperMetricsRecorder, logger)
+	}
+
 	kooperLogger := kooperlogger{Logger: logger.WithField("operator", "redisfailover")}
 	// Leader election service.
 	leSVC, err := leaderelection.NewDefault(lockKey, lockNamespace, k8sClient, kooperLogger)
@@ -46,8 +112,8 @@ func New(cfg Config, k8sService k8s.Services, k8sClient kubernetes.Interface, lo
 	}
 
 	// Create our controller.
-	return controller.New(&controller.Config{
-		Handler:         rfHandler,
+	ctrl, err := controller.New(&controller.Config{
+		Handler:         handler,
 		Retriever:       rfRetriever,
 		LeaderElector:   leSVC,
 		MetricsRecorder: kooperMetricsRecorder,
@@ -55,6 +121,10 @@ func New(cfg Config, k8sService k8s.Services, k8sClient kubernetes.Interface, lo
 		Name:            "redisfailover",
 		ResyncInterval:  resync,
 	})
+	if err != nil || drainer == nil {
+		return ctrl, err
+	}
+	return &drainingController{controller: ctrl, drainer: drainer, gracePeriod: cfg.ShutdownGracePeriod, logger: logger}, nil
 }
 
 func NewRedisFailoverRetriever(cli k8s.Services) controller.Retriever {
@@ -0,0 +1,68 @@
+package redisfailover
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// FeatureGateResolver resolves the feature gates of the RFs. The annotations of a RF override the
+// feature gates of the operator, that override the defaults.
+type FeatureGateResolver struct {
+	flags  map[string]bool
+	logger log.Logger
+}
+
+// NewFeatureGateResolver parses the feature gates of the operator, comma separated gate=bool pairs as
+// in "use-evictions=true". The unknown gates are logged and ignored.
+func NewFeatureGateResolver(featureGates string, logger log.Logger) (*FeatureGateResolver, error) {
+	flags := map[string]bool{}
+	for _, pair := range strings.Split(featureGates, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("feature gate %q must be a gate=bool pair", pair)
+		}
+		name = strings.TrimSpace(name)
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("feature gate %s must be true or false, got %q", name, value)
+		}
+		if !rfservice.IsFeatureGate(name) {
+			logger.Warnf("Ignoring the unknown feature gate %s", name)
+			continue
+		}
+		flags[name] = enabled
+	}
+	return &FeatureGateResolver{
+		flags:  flags,
+		logger: logger,
+	}, nil
+}
+
+// Resolve returns the feature gates of the RF. The unknown gates and the invalid values set on its
+// annotations are logged and ignored.
+func (f *FeatureGateResolver) Resolve(rf *redisfailoverv1.RedisFailover) rfservice.FeatureGates {
+	overrides := map[string]bool{}
+	for name, value := range rf.FeatureGateOverrides() {
+		logger := f.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name)
+		if !rfservice.IsFeatureGate(name) {
+			logger.Warnf("ignoring the unknown feature gate %s set on the %s%s annotation", name, redisfailoverv1.FeatureGateAnnotationPrefix, name)
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			logger.Warnf("ignoring the feature gate %s set on the %s%s annotation, it must be true or false, got %q", name, redisfailoverv1.FeatureGateAnnotationPrefix, name, value)
+			continue
+		}
+		overrides[name] = enabled
+	}
+	return rfservice.NewFeatureGates(f.flags, overrides)
+}
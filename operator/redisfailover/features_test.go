@@ -0,0 +1,140 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestFeatureGateResolver(t *testing.T) {
+	tests := []struct {
+		name        string
+		flag        string
+		annotations map[string]string
+		expGates    rfservice.FeatureGates
+		expErr      bool
+	}{
+		{
+			name:     "The defaults without flag nor annotations",
+			expGates: rfservice.FeatureGates{UseEvictions: false, PatchStatefulSets: true},
+		},
+		{
+			name:     "The flag overrides the default",
+			flag:     "use-evictions=true",
+			expGates: rfservice.FeatureGates{UseEvictions: true, PatchStatefulSets: true},
+		},
+		{
+			name: "The annotation overrides the flag",
+			flag: "use-evictions=true",
+			annotations: map[string]string{
+				redisfailoverv1.FeatureGateAnnotationPrefix + "use-evictions": "false",
+			},
+			expGates: rfservice.FeatureGates{UseEvictions: false, PatchStatefulSets: true},
+		},
+		{
+			name: "The annotation overrides the default",
+			annotations: map[string]string{
+				redisfailoverv1.FeatureGateAnnotationPrefix + "use-evictions": "true",
+			},
+			expGates: rfservice.FeatureGates{UseEvictions: true, PatchStatefulSets: true},
+		},
+		{
+			name: "An invalid annotation is ignored",
+			flag: "use-evictions=true",
+			annotations: map[string]string{
+				redisfailoverv1.FeatureGateAnnotationPrefix + "use-evictions": "maybe",
+			},
+			expGates: rfservice.FeatureGates{UseEvictions: true, PatchStatefulSets: true},
+		},
+		{
+			name: "The unknown gates are ignored",
+			flag: "parallel-probes=true,use-evictions=true",
+			annotations: map[string]string{
+				redisfailoverv1.FeatureGateAnnotationPrefix + "patch-updates": "true",
+			},
+			expGates: rfservice.FeatureGates{UseEvictions: true, PatchStatefulSets: true},
+		},
+		{
+			name: "The annotation disables a gate enabled by default",
+			annotations: map[string]string{
+				redisfailoverv1.FeatureGateAnnotationPrefix + "patch-statefulsets": "false",
+			},
+			expGates: rfservice.FeatureGates{UseEvictions: false, PatchStatefulSets: false},
+		},
+		{
+			name: "The flag disables a check, the annotation enables it back",
+			flag: "check-redis-config=false,check-sentinel-reset=false",
+			annotations: map[string]string{
+				redisfailoverv1.FeatureGateAnnotationPrefix + "check-sentinel-reset": "true",
+			},
+			expGates: rfservice.FeatureGates{UseEvictions: false, PatchStatefulSets: true, DisabledChecks: map[string]bool{"redis-config": true}},
+		},
+		{
+			name:   "A flag that is not a pair fails",
+			flag:   "use-evictions",
+			expErr: true,
+		},
+		{
+			name:   "A flag that is not a bool fails",
+			flag:   "use-evictions=maybe",
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			resolver, err := rfOperator.NewFeatureGateResolver(test.flag, log.Dummy)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+
+			rf := generateRF(false, false)
+			rf.Annotations = test.annotations
+			assert.Equal(test.expGates, resolver.Resolve(rf))
+		})
+	}
+}
+
+func TestPlanRedisesPodsUpdateWithEvictions(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{"0.0.0.0", "0.0.0.1"}, nil)
+	mrfc.On("GetMasterIP", mock.Anything, rf).Once().Return("0.0.0.0", nil)
+	mrfc.On("CheckRedisSlavesReady", mock.Anything, "0.0.0.1", rf).Once().Return(true, nil)
+	mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Once().Return("2", nil)
+	mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Once().Return([]string{"rfr-test-1"}, nil)
+	mrfc.On("GetRedisRevisionHash", mock.Anything, "rfr-test-1", rf).Once().Return("1", nil)
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("EvictPod", mock.Anything, "rfr-test-1", rf).Once().Return(nil)
+	mk := &mK8SService.Services{}
+	mk.On("CreateEvent", mock.Anything, namespace, eventReason("PodEvicted")).Once().Return(nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	actions, err := handler.PlanRedisesPodsUpdate(context.TODO(), rf, rfservice.FeatureGates{UseEvictions: true})
+
+	assert.NoError(err)
+	if assert.Len(actions, 1) {
+		assert.Equal(rfservice.PodActionDelete, actions[0].Kind)
+		assert.NoError(actions[0].Apply())
+	}
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+	mrfh.AssertNotCalled(t, "DeletePod", mock.Anything, mock.Anything, mock.Anything)
+	mk.AssertExpectations(t)
+}
@@ -0,0 +1,174 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
+)
+
+const (
+	defaultRedisPort = 6379
+	// phaseUnknown is the phase of the failovers whose status has no health report yet.
+	phaseUnknown  = "Unknown"
+	phaseDegraded = redisfailoverv1.HealthPhaseDegraded
+)
+
+// Collector builds the fleet report of the RedisFailovers of all the namespaces.
+type Collector struct {
+	k8sService  k8s.Services
+	redisClient redis.Client
+	logger      log.Logger
+	now         func() time.Time
+}
+
+// NewCollector returns a new fleet report collector.
+func NewCollector(k8sService k8s.Services, redisClient redis.Client, logger log.Logger) *Collector {
+	return &Collector{
+		k8sService:  k8sService,
+		redisClient: redisClient,
+		logger:      logger.With("service", "fleet"),
+		now:         time.Now,
+	}
+}
+
+// Collect lists the RedisFailovers of all the namespaces by pages of pageSize, and returns their
+// report. A failover whose INFO can't be sampled is reported with its error, only the list failing
+// fails the report.
+func (c *Collector) Collect(ctx context.Context, pageSize int64) (*Report, error) {
+	failovers := []Failover{}
+	err := c.k8sService.EachRedisFailover(ctx, "", metav1.ListOptions{Limit: pageSize}, func(rf *redisfailoverv1.RedisFailover) error {
+		failovers = append(failovers, c.failover(ctx, rf))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewReport(failovers, c.now()), nil
+}
+
+// failover returns the line of the RedisFailover, its status joined with the INFO of its master.
+func (c *Collector) failover(ctx context.Context, rf *redisfailoverv1.RedisFailover) Failover {
+	f := Failover{
+		Namespace:        rf.Namespace,
+		Name:             rf.Name,
+		Phase:            phaseUnknown,
+		RedisReplicas:    rf.Spec.Redis.Replicas,
+		SentinelReplicas: rf.Spec.Sentinel.Replicas,
+		Restarts:         rf.Status.Restarts,
+	}
+	if health := rf.Status.Health; health != nil {
+		f.Phase = health.Phase
+		f.Ready = health.Ready
+	}
+	f.PasswordChangedAt = c.passwordChangedAt(ctx, rf)
+
+	info, err := c.masterInfo(ctx, rf)
+	if err != nil {
+		c.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Debugf("fleet report: error sampling the INFO: %s", err)
+		f.Error = err.Error()
+		return f
+	}
+	f.RedisVersion = infoField(info, "redis_version")
+	f.UsedMemoryBytes, _ = strconv.ParseInt(infoField(info, "used_memory"), 10, 64)
+	return f
+}
+
+// masterInfo returns the INFO of the master of the RedisFailover. The redises are asked in turn until
+// one reports being the master, the INFO of the last one answering is returned when none does.
+func (c *Collector) masterInfo(ctx context.Context, rf *redisfailoverv1.RedisFailover) (string, error) {
+	password, err := k8s.GetRedisPassword(ctx, c.k8sService, rf)
+	if err != nil {
+		return "", fmt.Errorf("getting the redis password: %w", err)
+	}
+	port := strconv.Itoa(defaultRedisPort)
+	if rf.Spec.Redis.Port > 0 {
+		port = strconv.Itoa(int(rf.Spec.Redis.Port))
+	}
+
+	type address struct{ ip, port string }
+	addresses := []address{}
+	if rf.ExternalNodesEnabled() {
+		for _, node := range rf.Spec.Redis.ExternalNodes {
+			addresses = append(addresses, address{node.Host, node.Port})
+		}
+	} else {
+		pods, err := c.k8sService.GetStatefulSetPods(ctx, rf.Namespace, rfservice.GetRedisStatefulSetName(rf))
+		if err != nil {
+			return "", fmt.Errorf("getting the redis pods: %w", err)
+		}
+		for _, pod := range pods.Items {
+			if ip := rf.PreferredPodIP(pod.Status); ip != "" && pod.Status.Phase == corev1.PodRunning {
+				addresses = append(addresses, address{ip, port})
+			}
+		}
+	}
+	if len(addresses) == 0 {
+		return "", errors.New("no running redis")
+	}
+
+	var sample string
+	var lastErr error
+	for _, a := range addresses {
+		info, err := c.redisClient.GetRedisInfo(a.ip, a.port, password)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if infoField(info, "role") == "master" {
+			return info, nil
+		}
+		sample = info
+	}
+	if sample == "" {
+		return "", fmt.Errorf("getting the INFO: %w", lastErr)
+	}
+	return sample, nil
+}
+
+// passwordChangedAt returns when the secret of the password was last written, from its managed fields
+// or its creation when it has none.
+func (c *Collector) passwordChangedAt(ctx context.Context, rf *redisfailoverv1.RedisFailover) *time.Time {
+	// The RFs are not validated, the generated secret is not set as their secretPath.
+	name := rf.AuthSecretName()
+	if name == "" {
+		return nil
+	}
+	secret, err := c.k8sService.GetSecret(ctx, rf.Namespace, name)
+	if err != nil {
+		c.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Debugf("fleet report: error getting the password secret: %s", err)
+		return nil
+	}
+	changedAt := secret.CreationTimestamp.Time
+	for _, field := range secret.ManagedFields {
+		if field.Time != nil && field.Time.After(changedAt) {
+			changedAt = field.Time.Time
+		}
+	}
+	if changedAt.IsZero() {
+		return nil
+	}
+	changedAt = changedAt.UTC()
+	return &changedAt
+}
+
+// infoField returns the value of the field of the INFO reply, empty when it has none.
+func infoField(info, field string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, field+":") {
+			return strings.TrimPrefix(line, field+":")
+		}
+	}
+	return ""
+}
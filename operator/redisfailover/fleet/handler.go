@@ -0,0 +1,66 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// reportTimeout bounds the report served over HTTP, a large fleet with unreachable redises could
+// otherwise hold the request for long.
+const reportTimeout = 2 * time.Minute
+
+// Handler serves the fleet report. The format, sort and desc query parameters pick the format of the
+// report, the column it is sorted by and the order, as the flags of the report command.
+type Handler struct {
+	collector *Collector
+	pageSize  int64
+}
+
+// NewHandler returns the handler of the fleet report, listing the RedisFailovers by pages of pageSize.
+func NewHandler(collector *Collector, pageSize int64) *Handler {
+	return &Handler{collector: collector, pageSize: pageSize}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = FormatJSON
+	}
+	contentType, ok := contentTypes[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+		return
+	}
+	by := query.Get("sort")
+	if by == "" {
+		by = "name"
+	}
+	desc, _ := strconv.ParseBool(query.Get("desc"))
+
+	ctx, cancel := context.WithTimeout(r.Context(), reportTimeout)
+	defer cancel()
+	report, err := h.collector.Collect(ctx, h.pageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := report.Sort(by, desc); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if err := report.Write(w, format); err != nil {
+		h.collector.logger.Errorf("error writing the fleet report: %s", err)
+	}
+}
+
+// contentTypes are the content types of the formats of the report.
+var contentTypes = map[string]string{
+	FormatTable: "text/plain; charset=utf-8",
+	FormatJSON:  "application/json",
+	FormatCSV:   "text/csv",
+}
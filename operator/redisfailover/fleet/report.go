@@ -0,0 +1,285 @@
+package fleet
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// The formats the report is written in.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// Formats are all the formats of the report.
+var Formats = []string{FormatTable, FormatJSON, FormatCSV}
+
+// Failover is the line of a RedisFailover on the fleet report, its status joined with a live INFO
+// sample of its master.
+type Failover struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Phase is the phase of the health report of the status, Unknown when it has none yet.
+	Phase            string `json:"phase"`
+	Ready            bool   `json:"ready"`
+	RedisReplicas    int32  `json:"redisReplicas"`
+	SentinelReplicas int32  `json:"sentinelReplicas"`
+	Restarts         int32  `json:"restarts"`
+	// RedisVersion and UsedMemoryBytes are sampled from the INFO of the master, they are empty when it
+	// can't be reached.
+	RedisVersion    string `json:"redisVersion,omitempty"`
+	UsedMemoryBytes int64  `json:"usedMemoryBytes"`
+	// PasswordChangedAt is when the secret of the password was last written, nil without a password
+	// secret.
+	PasswordChangedAt *time.Time `json:"passwordChangedAt,omitempty"`
+	// Error is why the INFO could not be sampled.
+	Error string `json:"error,omitempty"`
+}
+
+// Password is the password of a RedisFailover, with when it was last changed.
+type Password struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// Summary aggregates the failovers of the report.
+type Summary struct {
+	Failovers int `json:"failovers"`
+	Degraded  int `json:"degraded"`
+	NotReady  int `json:"notReady"`
+	// RedisVersions is the number of failovers by redis version, the ones not sampled are not counted.
+	RedisVersions   map[string]int `json:"redisVersions"`
+	UsedMemoryBytes int64          `json:"usedMemoryBytes"`
+	// OldestPassword is the password not changed for the longest time.
+	OldestPassword *Password `json:"oldestPassword,omitempty"`
+}
+
+// Report is the fleet report, the summary of all the RedisFailovers of the cluster.
+type Report struct {
+	GeneratedAt time.Time  `json:"generatedAt"`
+	Summary     Summary    `json:"summary"`
+	Failovers   []Failover `json:"failovers"`
+}
+
+// NewReport returns the report of the failovers, sorted by namespace and name.
+func NewReport(failovers []Failover, generatedAt time.Time) *Report {
+	r := &Report{
+		GeneratedAt: generatedAt,
+		Summary:     Summary{RedisVersions: map[string]int{}},
+		Failovers:   failovers,
+	}
+	if r.Failovers == nil {
+		r.Failovers = []Failover{}
+	}
+	for _, f := range r.Failovers {
+		r.Summary.Failovers++
+		if f.Phase == phaseDegraded {
+			r.Summary.Degraded++
+		}
+		if !f.Ready {
+			r.Summary.NotReady++
+		}
+		if f.RedisVersion != "" {
+			r.Summary.RedisVersions[f.RedisVersion]++
+		}
+		r.Summary.UsedMemoryBytes += f.UsedMemoryBytes
+		if f.PasswordChangedAt != nil && (r.Summary.OldestPassword == nil || f.PasswordChangedAt.Before(r.Summary.OldestPassword.ChangedAt)) {
+			r.Summary.OldestPassword = &Password{Namespace: f.Namespace, Name: f.Name, ChangedAt: *f.PasswordChangedAt}
+		}
+	}
+	// The sort can't fail on a known column.
+	_ = r.Sort("name", false)
+	return r
+}
+
+// column is a column of the table and CSV reports, the report can be sorted by any of them.
+type column struct {
+	name  string
+	value func(f Failover) string
+	less  func(a, b Failover) bool
+}
+
+func stringColumn(name string, value func(f Failover) string) column {
+	return column{name: name, value: value, less: func(a, b Failover) bool { return value(a) < value(b) }}
+}
+
+func intColumn(name string, value func(f Failover) int64) column {
+	return column{
+		name:  name,
+		value: func(f Failover) string { return strconv.FormatInt(value(f), 10) },
+		less:  func(a, b Failover) bool { return value(a) < value(b) },
+	}
+}
+
+// columns are the columns of the table and CSV reports, in order.
+var columns = []column{
+	stringColumn("namespace", func(f Failover) string { return f.Namespace }),
+	// The failovers are sorted by namespace and name, the names are not unique across namespaces.
+	{
+		name:  "name",
+		value: func(f Failover) string { return f.Name },
+		less: func(a, b Failover) bool {
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		},
+	},
+	stringColumn("phase", func(f Failover) string { return f.Phase }),
+	stringColumn("ready", func(f Failover) string { return strconv.FormatBool(f.Ready) }),
+	intColumn("redis", func(f Failover) int64 { return int64(f.RedisReplicas) }),
+	intColumn("sentinels", func(f Failover) int64 { return int64(f.SentinelReplicas) }),
+	intColumn("restarts", func(f Failover) int64 { return int64(f.Restarts) }),
+	{
+		name:  "version",
+		value: func(f Failover) string { return f.RedisVersion },
+		less:  func(a, b Failover) bool { return versionLess(a.RedisVersion, b.RedisVersion) },
+	},
+	intColumn("memory", func(f Failover) int64 { return f.UsedMemoryBytes }),
+	{
+		name:  "password-changed",
+		value: func(f Failover) string { return formatTime(f.PasswordChangedAt) },
+		less: func(a, b Failover) bool {
+			// The failovers without a password secret go last.
+			if a.PasswordChangedAt == nil || b.PasswordChangedAt == nil {
+				return b.PasswordChangedAt == nil && a.PasswordChangedAt != nil
+			}
+			return a.PasswordChangedAt.Before(*b.PasswordChangedAt)
+		},
+	},
+	stringColumn("error", func(f Failover) string { return f.Error }),
+}
+
+// Columns returns the names of the columns the report can be sorted by.
+func Columns() []string {
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// Sort sorts the failovers by the column, in descending order if desc. The failovers with the same
+// value keep their order.
+func (r *Report) Sort(by string, desc bool) error {
+	for _, c := range columns {
+		if c.name != by {
+			continue
+		}
+		sort.SliceStable(r.Failovers, func(i, j int) bool {
+			if desc {
+				return c.less(r.Failovers[j], r.Failovers[i])
+			}
+			return c.less(r.Failovers[i], r.Failovers[j])
+		})
+		return nil
+	}
+	return fmt.Errorf("unknown column %q, one of %s", by, strings.Join(Columns(), ", "))
+}
+
+// Write writes the report in the format.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatTable:
+		return r.WriteTable(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatCSV:
+		return r.WriteCSV(w)
+	}
+	return fmt.Errorf("unknown format %q, one of %s", format, strings.Join(Formats, ", "))
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes the failovers of the report as CSV, with the names of the columns on the first row.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns()); err != nil {
+		return err
+	}
+	for _, f := range r.Failovers {
+		if err := cw.Write(row(f)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTable writes the summary then the failovers of the report as an aligned table.
+func (r *Report) WriteTable(w io.Writer) error {
+	s := r.Summary
+	versions := make([]string, 0, len(s.RedisVersions))
+	for version := range s.RedisVersions {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versionLess(versions[i], versions[j]) })
+	for i, version := range versions {
+		versions[i] = fmt.Sprintf("%s (%d)", version, s.RedisVersions[version])
+	}
+	oldest := "-"
+	if s.OldestPassword != nil {
+		oldest = fmt.Sprintf("%s/%s, changed at %s", s.OldestPassword.Namespace, s.OldestPassword.Name, formatTime(&s.OldestPassword.ChangedAt))
+	}
+	fmt.Fprintf(w, "Failovers: %d, degraded: %d, not ready: %d\n", s.Failovers, s.Degraded, s.NotReady)
+	fmt.Fprintf(w, "Redis versions: %s\n", strings.Join(versions, ", "))
+	fmt.Fprintf(w, "Used memory: %d bytes\n", s.UsedMemoryBytes)
+	fmt.Fprintf(w, "Oldest password: %s\n\n", oldest)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(Columns(), "\t")))
+	for _, f := range r.Failovers {
+		fmt.Fprintln(tw, strings.Join(row(f), "\t"))
+	}
+	return tw.Flush()
+}
+
+// row returns the values of the columns of the failover.
+func row(f Failover) []string {
+	values := make([]string, 0, len(columns))
+	for _, c := range columns {
+		values = append(values, c.value(f))
+	}
+	return values
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// versionLess compares the redis versions numerically, 6.2.14 comes before 7.0.5.
+func versionLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		if aErr != nil || bErr != nil {
+			if as[i] != bs[i] {
+				return as[i] < bs[i]
+			}
+			continue
+		}
+		if an != bn {
+			return an < bn
+		}
+	}
+	return len(as) < len(bs)
+}
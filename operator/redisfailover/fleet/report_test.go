@@ -0,0 +1,234 @@
+package fleet_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	"redis-operator/operator/redisfailover/fleet"
+)
+
+var (
+	generatedAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	created     = time.Date(2023, 1, 10, 8, 0, 0, 0, time.UTC)
+	rotated     = time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC)
+)
+
+func runningPod(name, ip string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+	}
+}
+
+func generateRF(namespace, name, secret string, health *redisfailoverv1.HealthReport) *redisfailoverv1.RedisFailover {
+	return &redisfailoverv1.RedisFailover{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: redisfailoverv1.RedisFailoverSpec{
+			Auth:     redisfailoverv1.AuthSettings{SecretPath: secret},
+			Redis:    redisfailoverv1.RedisSettings{Replicas: 3},
+			Sentinel: redisfailoverv1.SentinelSettings{Replicas: 3},
+		},
+		Status: redisfailoverv1.RedisFailoverStatus{Health: health},
+	}
+}
+
+// newServices returns the mocked services of a fleet of three failovers: a healthy one whose first redis
+// is a replica, a degraded one whose password was rotated and one whose redises can't be reached.
+func newServices() (*mK8SService.Services, *mRedisService.Client) {
+	rfs := []*redisfailoverv1.RedisFailover{
+		generateRF("payments", "cache", "", &redisfailoverv1.HealthReport{Phase: redisfailoverv1.HealthPhaseHealthy, Ready: true}),
+		generateRF("orders", "sessions", "sessions-auth", &redisfailoverv1.HealthReport{Phase: redisfailoverv1.HealthPhaseDegraded, Message: "no master"}),
+		generateRF("orders", "queue", "queue-auth", nil),
+	}
+	rfs[0].Status.Restarts = 2
+
+	ms := &mK8SService.Services{}
+	ms.On("EachRedisFailover", mock.Anything, "", metav1.ListOptions{Limit: 2}, mock.Anything).Once().Return(
+		func(_ context.Context, _ string, _ metav1.ListOptions, fn func(*redisfailoverv1.RedisFailover) error) error {
+			for _, rf := range rfs {
+				if err := fn(rf); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	ms.On("GetStatefulSetPods", mock.Anything, "payments", "rfr-cache").Return(&corev1.PodList{Items: []corev1.Pod{
+		runningPod("rfr-cache-0", "10.0.0.1"),
+		runningPod("rfr-cache-1", "10.0.0.2"),
+		{ObjectMeta: metav1.ObjectMeta{Name: "rfr-cache-2"}, Status: corev1.PodStatus{Phase: corev1.PodPending}},
+	}}, nil)
+	ms.On("GetStatefulSetPods", mock.Anything, "orders", "rfr-sessions").Return(&corev1.PodList{Items: []corev1.Pod{
+		runningPod("rfr-sessions-0", "10.0.1.1"),
+	}}, nil)
+	ms.On("GetStatefulSetPods", mock.Anything, "orders", "rfr-queue").Return(&corev1.PodList{Items: []corev1.Pod{
+		runningPod("rfr-queue-0", "10.0.2.1"),
+	}}, nil)
+	ms.On("GetSecret", mock.Anything, "orders", "sessions-auth").Return(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "sessions-auth",
+			CreationTimestamp: metav1.NewTime(created),
+			ManagedFields:     []metav1.ManagedFieldsEntry{{Manager: "kubectl", Time: &metav1.Time{Time: rotated}}},
+		},
+		Data: map[string][]byte{"password": []byte("s3cret")},
+	}, nil)
+	ms.On("GetSecret", mock.Anything, "orders", "queue-auth").Return(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "queue-auth", CreationTimestamp: metav1.NewTime(created)},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}, nil)
+
+	mr := &mRedisService.Client{}
+	mr.On("GetRedisInfo", "10.0.0.1", "6379", "").Return("# Server\r\nredis_version:7.0.15\r\n# Memory\r\nused_memory:2048\r\n# Replication\r\nrole:slave\r\n", nil)
+	mr.On("GetRedisInfo", "10.0.0.2", "6379", "").Return("# Server\r\nredis_version:7.0.15\r\n# Memory\r\nused_memory:4096\r\n# Replication\r\nrole:master\r\n", nil)
+	mr.On("GetRedisInfo", "10.0.1.1", "6379", "s3cret").Return("redis_version:6.2.14\r\nused_memory:1024\r\nrole:master\r\n", nil)
+	mr.On("GetRedisInfo", "10.0.2.1", "6379", "hunter2").Return("", errors.New("dial tcp 10.0.2.1:6379: i/o timeout"))
+	return ms, mr
+}
+
+func TestCollectJSONGolden(t *testing.T) {
+	ms, mr := newServices()
+
+	report, err := fleet.NewCollector(ms, mr, log.Dummy).Collect(context.TODO(), 2)
+	require.NoError(t, err)
+	report.GeneratedAt = generatedAt
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteJSON(&out))
+	golden, err := os.ReadFile("testdata/report.golden.json")
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), out.String())
+	ms.AssertExpectations(t)
+}
+
+func TestCollectListError(t *testing.T) {
+	ms := &mK8SService.Services{}
+	ms.On("EachRedisFailover", mock.Anything, "", mock.Anything, mock.Anything).Once().Return(errors.New("forbidden"))
+
+	_, err := fleet.NewCollector(ms, &mRedisService.Client{}, log.Dummy).Collect(context.TODO(), 0)
+	assert.Error(t, err)
+}
+
+func TestReportSort(t *testing.T) {
+	failovers := []fleet.Failover{
+		{Namespace: "b", Name: "one", RedisVersion: "7.0.5", UsedMemoryBytes: 10},
+		{Namespace: "a", Name: "two", RedisVersion: "6.2.14", UsedMemoryBytes: 30, PasswordChangedAt: &rotated},
+		{Namespace: "a", Name: "one", RedisVersion: "7.0.15", UsedMemoryBytes: 20, PasswordChangedAt: &created},
+	}
+	names := func(r *fleet.Report) []string {
+		got := []string{}
+		for _, f := range r.Failovers {
+			got = append(got, f.Namespace+"/"+f.Name)
+		}
+		return got
+	}
+
+	tests := []struct {
+		by       string
+		desc     bool
+		expNames []string
+		expErr   bool
+	}{
+		{by: "name", expNames: []string{"a/one", "a/two", "b/one"}},
+		{by: "memory", desc: true, expNames: []string{"a/two", "a/one", "b/one"}},
+		{by: "version", expNames: []string{"a/two", "b/one", "a/one"}},
+		{by: "password-changed", expNames: []string{"a/one", "a/two", "b/one"}},
+		{by: "size", expErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.by, func(t *testing.T) {
+			report := fleet.NewReport(append([]fleet.Failover{}, failovers...), generatedAt)
+			err := report.Sort(test.by, test.desc)
+			if test.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expNames, names(report))
+		})
+	}
+}
+
+func TestReportWriteCSVAndTable(t *testing.T) {
+	assert := assert.New(t)
+
+	report := fleet.NewReport([]fleet.Failover{
+		{Namespace: "a", Name: "one", Phase: "Healthy", Ready: true, RedisReplicas: 3, SentinelReplicas: 3, RedisVersion: "7.0.15", UsedMemoryBytes: 20, PasswordChangedAt: &created},
+	}, generatedAt)
+
+	var csv bytes.Buffer
+	assert.NoError(report.Write(&csv, fleet.FormatCSV))
+	assert.Equal("namespace,name,phase,ready,redis,sentinels,restarts,version,memory,password-changed,error\n"+
+		"a,one,Healthy,true,3,3,0,7.0.15,20,2023-01-10T08:00:00Z,\n", csv.String())
+
+	var table bytes.Buffer
+	assert.NoError(report.Write(&table, fleet.FormatTable))
+	assert.Contains(table.String(), "Redis versions: 7.0.15 (1)\n")
+	assert.Contains(table.String(), "Oldest password: a/one, changed at 2023-01-10T08:00:00Z\n")
+	assert.Contains(table.String(), "NAMESPACE  NAME")
+
+	assert.Error(report.Write(&table, "yaml"))
+}
+
+func TestHandlerServeHTTP(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expCode        int
+		expContentType string
+		expBody        string
+	}{
+		{
+			name:           "The report is served as JSON by default",
+			expCode:        http.StatusOK,
+			expContentType: "application/json",
+			expBody:        `"failovers": 3`,
+		},
+		{
+			name:           "The report is served sorted in the format of the query",
+			query:          "?format=csv&sort=memory&desc=true",
+			expCode:        http.StatusOK,
+			expContentType: "text/csv",
+			expBody:        "payments,cache,Healthy,true,3,3,2,7.0.15,4096,,\norders,sessions",
+		},
+		{
+			name:    "An unknown format is rejected",
+			query:   "?format=yaml",
+			expCode: http.StatusBadRequest,
+		},
+		{
+			name:    "An unknown column is rejected",
+			query:   "?sort=size",
+			expCode: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			ms, mr := newServices()
+
+			w := httptest.NewRecorder()
+			fleet.NewHandler(fleet.NewCollector(ms, mr, log.Dummy), 2).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/fleet"+test.query, nil))
+			assert.Equal(test.expCode, w.Code)
+			if test.expContentType != "" {
+				assert.Equal(test.expContentType, w.Header().Get("Content-Type"))
+			}
+			assert.True(strings.Contains(w.Body.String(), test.expBody), w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package redisfailover
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+)
+
+func TestHandleForgetsDeletedRF(t *testing.T) {
+	assert := assert.New(t)
+
+	now := metav1.Now()
+	rf := &redisfailoverv1.RedisFailover{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test",
+			Namespace:         "testns",
+			ResourceVersion:   "7",
+			DeletionTimestamp: &now,
+			Finalizers:        []string{CleanupFinalizer},
+		},
+	}
+	patched := rf.DeepCopy()
+	patched.Finalizers = []string{}
+	mk := &mK8SService.Services{}
+	mk.On("PatchRedisFailoverFinalizers", mock.Anything, "testns", "test", "7", []string{}).Once().Return(patched, nil)
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("ForgetRedisLatency", rf).Once()
+	mrfc.On("ForgetNodeTuningWarnings", rf).Once()
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("ClearSyncSlotQueue", rf).Once()
+	handler := NewRedisFailoverHandler(Config{}, &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	handler.sentinelEvents = NewSentinelEventWatcher(mk, nil, log.Dummy)
+
+	key := rfKey(rf)
+	handler.sentinelEvents.lastFailovers[key] = &redisfailoverv1.FailoverStatus{TotalSeconds: 3}
+	handler.stabilizer.ObserveMaster(key, "10.0.0.1")
+	handler.stabilizer.ObserveMaster(key, "10.0.0.2")
+	handler.promotionHolds.Hold(key, "MasterNotConfirmedDown", "held")
+	handler.volumeWaits.Set(key, []string{"rfr-test-0"})
+	handler.pdbSkips.Set(key, true)
+	handler.crashLogs.Set(key, map[string]CrashLog{"rfr-test-0": {Restarts: 3}})
+	handler.aclLoads.Record(key, "rfr-test-0", "abc", nil)
+	handler.reconcileFailures.Fail(key, "EnsureFailed", "failed")
+	handler.checkRuns.Set(key, []CheckResult{{}}, time.Now())
+
+	// The RF deleted without the ordered teardown is forgotten.
+	assert.NoError(handler.Handle(context.TODO(), rf))
+
+	assert.Nil(handler.sentinelEvents.LastFailover(rf))
+	assert.Empty(handler.stabilizer.LastMaster(key))
+	assert.Zero(handler.stabilizer.MasterChanges(key))
+	_, stabilizing := handler.stabilizer.Stabilizing(key, time.Minute)
+	assert.Zero(stabilizing)
+	_, _, held := handler.promotionHolds.Held(key)
+	assert.False(held)
+	assert.Empty(handler.volumeWaits.Held(key))
+	assert.False(handler.pdbSkips.Skipped(key))
+	assert.Empty(handler.crashLogs.Logged(key))
+	assert.Empty(handler.aclLoads.Loaded(key, "rfr-test-0"))
+	_, _, failed := handler.reconcileFailures.Failed(key)
+	assert.False(failed)
+	assert.Nil(handler.checkRuns.Stats(key))
+	assert.Empty(rf.Finalizers)
+	mk.AssertExpectations(t)
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	redisfailoverv1 "redis-operator/api/redisfailover/v1"
@@ -18,7 +21,7 @@ import (
 
 const (
 	rfLabelManagedByKey = "app.kubernetes.io/managed-by"
-	rfLabelNameKey      = "redisfailovers.databases.spotahome.com/name"
+	rfLabelNameKey      = k8s.OwnerNameLabel
 )
 
 var (
@@ -27,20 +30,83 @@ var (
 	}
 )
 
+// ManagedObjectsSelector returns the label selector of the objects generated by the operator.
+func ManagedObjectsSelector() string {
+	return labels.SelectorFromSet(defaultLabels).String()
+}
+
 // RedisFailoverHandler is the Redis Failover handler. This handler will create the required
 // resources that a RF needs.
 type RedisFailoverHandler struct {
 	config     Config
-	k8sservice k8s.Service
+	k8sservice k8s.Services
 	rfService  rfservice.RedisFailoverClient
 	rfChecker  rfservice.RedisFailoverCheck
 	rfHealer   rfservice.RedisFailoverHeal
 	mClient    metrics.Recorder
 	logger     log.Logger
+	// sentinelEvents is optional, without it the sentinel failover events are not watched.
+	sentinelEvents *SentinelEventWatcher
+	// supportBundles is optional, without it the support bundle requests are ignored.
+	supportBundles *SupportBundleRequests
+	// recentLogs is optional, it keeps the last lines logged about every RF for its support bundles.
+	recentLogs *log.RecentLines
+	// diagnoses is optional, without it the diagnose requests are ignored.
+	diagnoses *DiagnosisRequests
+	// startTime tells the RFs created before the operator started, see EnsureDefaultsRevision.
+	startTime time.Time
+	// stabilizer holds the disruptive actions on the redis pods after a failover.
+	stabilizer *FailoverStabilizer
+	// promotionHolds are the promotions of a new master held because the master is not confirmed down.
+	promotionHolds *PromotionHolds
+	// volumeWaits are the redis pods waiting for their volumes whose replication and updates are held.
+	volumeWaits *VolumeWaits
+	// dataFlushes are the mass deletions of the keys of the masters, see checkDataFlush.
+	dataFlushes *DataFlushes
+	// crashLogs are the crashes of the redis pods whose logs were logged, see logRedisCrashes.
+	crashLogs *CrashLogs
+	// aclLoads are the ACL files loaded by the redis pods, see planRedisACLLoad.
+	aclLoads *ACLLoads
+	// events creates the events of the lifecycle actions on the RFs, rate-limited.
+	events *EventRecorder
+	// reconcileFailures are the RFs whose last reconcile failed, see setReconcileConditions.
+	reconcileFailures *ReconcileFailures
+	// checkRuns are the results of the checks of the last reconcile of the RFs, see runChecks.
+	checkRuns *CheckRuns
+	// restartSeries are the containers whose restart count is exposed, see setInstanceRestarts.
+	restartSeries *instanceRestartSeries
+	// pdbSkips are the RFs whose PodDisruptionBudgets were skipped as the cluster serves none.
+	pdbSkips *PodDisruptionBudgetSkips
+	// naming is nil without naming templates, then the generated objects get no name prefix nor
+	// common labels.
+	naming *Naming
+	// featureGates resolves the behaviors enabled on every RF.
+	featureGates *FeatureGateResolver
+	// checkerStates tells the RFs whose checker state was restored, see RestoreCheckerState.
+	checkerStates *checkerStateRestores
+	// deprecations are the deprecated fields used by the RFs, see CheckDeprecatedFields.
+	deprecations *deprecationUses
+	// annotationWarnings are the annotations of the RFs warned about, see CheckAnnotations.
+	annotationWarnings *annotationWarnings
+	// volumeWarnings are the resizes of the redis volumes warned about, see EnsureRedisVolumesSize.
+	volumeWarnings *annotationWarnings
+	// apiBackoff is optional, without it the reconciles are not held under apiserver pressure.
+	apiBackoff *APIServerBackoff
+	// ownerClaims is optional, without it the RFs are reconciled even when another operator claims them.
+	ownerClaims *OwnerClaims
 }
 
 // NewRedisFailoverHandler returns a new RF handler
-func NewRedisFailoverHandler(config Config, rfService rfservice.RedisFailoverClient, rfChecker rfservice.RedisFailoverCheck, rfHealer rfservice.RedisFailoverHeal, k8sservice k8s.Service, mClient metrics.Recorder, logger log.Logger) *RedisFailoverHandler {
+func NewRedisFailoverHandler(config Config, rfService rfservice.RedisFailoverClient, rfChecker rfservice.RedisFailoverCheck, rfHealer rfservice.RedisFailoverHeal, k8sservice k8s.Services, mClient metrics.Recorder, logger log.Logger) *RedisFailoverHandler {
+	naming, err := NewNaming(config.NamePrefixTemplate, config.CommonLabelsTemplate)
+	if err != nil {
+		logger.Errorf("Ignoring the naming templates: %s", err)
+	}
+	featureGates, err := NewFeatureGateResolver(config.FeatureGates, logger)
+	if err != nil {
+		logger.Errorf("Ignoring the feature gates: %s", err)
+		featureGates, _ = NewFeatureGateResolver("", logger)
+	}
 	return &RedisFailoverHandler{
 		config:     config,
 		rfService:  rfService,
@@ -49,44 +115,189 @@ func NewRedisFailoverHandler(config Config, rfService rfservice.RedisFailoverCli
 		mClient:    mClient,
 		k8sservice: k8sservice,
 		logger:     logger,
+		startTime:  time.Now(),
+		stabilizer: NewFailoverStabilizer(time.Now),
+		naming:     naming,
+
+		promotionHolds: NewPromotionHolds(),
+		volumeWaits:    NewVolumeWaits(),
+		dataFlushes:    NewDataFlushes(),
+		crashLogs:      NewCrashLogs(),
+		aclLoads:       NewACLLoads(),
+		events:         NewEventRecorder(k8sservice, time.Now, logger),
+		pdbSkips:       NewPodDisruptionBudgetSkips(),
+		featureGates:   featureGates,
+		checkerStates:  newCheckerStateRestores(),
+		deprecations:   newDeprecationUses(),
+
+		annotationWarnings: newAnnotationWarnings(),
+		volumeWarnings:     newAnnotationWarnings(),
+		reconcileFailures:  NewReconcileFailures(),
+		checkRuns:          NewCheckRuns(),
+		restartSeries:      newInstanceRestartSeries(),
 	}
 }
 
 // Handle will ensure the redis failover is in the expected state.
-func (r *RedisFailoverHandler) Handle(_ context.Context, obj runtime.Object) error {
+func (r *RedisFailoverHandler) Handle(ctx context.Context, obj runtime.Object) error {
 	rf, ok := obj.(*redisfailoverv1.RedisFailover)
 	if !ok {
 		return fmt.Errorf("can't handle the received object: not a redisfailover")
 	}
 
+	// The reconciles are held, not retried, while the apiserver asks to slow down.
+	if r.apiBackoff != nil {
+		key := rfKey(rf)
+		if wait := r.apiBackoff.Wait(key); wait > 0 {
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Debugf("Holding the reconcile for %s while the apiserver is under pressure", wait.Round(time.Second))
+			return nil
+		}
+		defer r.apiBackoff.Done(key)
+	}
+
+	// The deleted RFs are not reconciled nor healed anymore, their objects are torn down and what the
+	// operator keeps for them is forgotten.
+	if rf.DeletionTimestamp != nil {
+		return r.Cleanup(ctx, rf)
+	}
+
+	// The RFs of a namespace being deleted are left to the namespace controller.
+	if r.CheckNamespaceTerminating(ctx, rf) {
+		return nil
+	}
+
+	// The RFs claimed by another live operator are left to it.
+	if reconcile, err := r.CheckOwnerClaim(ctx, rf); !reconcile {
+		return err
+	}
+
+	// The defaults revision and the naming must be stored before the schema revision is bumped, they
+	// are taken from it.
+	if !rf.NewerThanOperator() {
+		if err := r.EnsureDefaultsRevision(ctx, rf); err != nil {
+			return err
+		}
+		if err := r.EnsureNaming(ctx, rf); err != nil {
+			return err
+		}
+	}
+
+	reconcile, err := r.CheckSchemaRevision(ctx, rf)
+	if !reconcile {
+		return err
+	}
+	if err != nil {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not set the schema revision: %s", err)
+	}
+
+	// The finalizers are added before the objects are created, so they are all torn down in order, and
+	// before the validation, so an invalid RF is forgotten too once deleted.
+	gates := r.featureGates.Resolve(rf)
+	if err := r.EnsureFinalizers(ctx, rf, gates.OrderedTeardown); err != nil {
+		return err
+	}
+
+	// The annotations are checked before the validation, a typo can be what makes the RF invalid.
+	r.CheckAnnotations(ctx, rf)
 	if err := rf.Validate(); err != nil {
 		r.mClient.SetClusterError(rf.Namespace, rf.Name)
 		return err
 	}
+	r.CheckDeprecatedFields(ctx, rf)
+	r.CheckPriorityClasses(ctx, rf)
+	if err := r.CheckStorageClass(ctx, rf); err != nil {
+		r.mClient.SetClusterError(rf.Namespace, rf.Name)
+		r.failReconcile(ctx, rf, redisfailoverv1.ReasonStorageClassNotFound, err)
+		return err
+	}
+	r.reconcileFailures.Clear(rfKey(rf), redisfailoverv1.ReasonStorageClassNotFound)
+
+	if r.config.DisableMetricLabels {
+		r.mClient.SetClusterLabels(rf.Namespace, rf.Name, nil)
+	} else {
+		r.mClient.SetClusterLabels(rf.Namespace, rf.Name, rf.MetricLabels())
+	}
 
 	// Create owner refs so the objects manager by this handler have ownership to the
 	// received RF.
-	oRefs := r.createOwnerReferences(rf)
+	oRefs := createOwnerReferences(rf)
 
 	// Create the labels every object derived from this need to have.
-	labels := r.getLabels(rf)
+	labels := GeneratedLabels(rf, r.logger)
 
-	if err := r.Ensure(rf, labels, oRefs, r.mClient); err != nil {
+	// The password is rotated before the objects are ensured, so the redis pods are rolled to read it in
+	// the same reconcile. A rotation held until the pods run, or failing, must not block the healing of
+	// the cluster, it's resumed on the next reconcile.
+	if err := r.EnsurePasswordRotation(ctx, rf); err != nil {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not rotate the password: %s", err)
+		r.events.Record(ctx, rf, corev1.EventTypeWarning, redisfailoverv1.ReasonPasswordRotationFailed, err.Error())
+	}
+
+	if err := r.Ensure(ctx, rf, labels, oRefs, r.mClient); err != nil {
+		if r.isTerminatingNamespaceError(rf, err) {
+			return nil
+		}
 		r.mClient.SetClusterError(rf.Namespace, rf.Name)
+		r.failReconcile(ctx, rf, redisfailoverv1.ReasonEnsureFailed, err)
+		r.writeEnsureFailed(ctx, rf, err)
 		return err
 	}
+	r.reconcileFailures.Clear(rfKey(rf), redisfailoverv1.ReasonEnsureFailed)
+
+	if r.sentinelEvents != nil {
+		if rf.SentinelsAllowed() {
+			r.sentinelEvents.Ensure(rf)
+		} else {
+			r.sentinelEvents.Stop(rf)
+		}
+	}
 
-	if err := r.CheckAndHeal(rf); err != nil {
+	// A failed diagnosis must not block the healing of the cluster, it is retried on the next reconcile.
+	if r.diagnoses != nil {
+		if err := r.diagnoses.Ensure(ctx, rf, labels, oRefs); err != nil {
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not diagnose the redis pods: %s", err)
+		}
+	}
+
+	// A failure updating the status must not block the healing of the cluster.
+	if err := r.UpdateStatus(ctx, rf); err != nil {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not update the status: %s", err)
+	}
+
+	// A failure collecting a support bundle must not block the healing of the cluster either.
+	if r.supportBundles != nil {
+		if err := r.supportBundles.Ensure(ctx, rf, labels, oRefs); err != nil {
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not collect the support bundle: %s", err)
+		}
+	}
+
+	// The checker state is saved even when the check failed, the remediations may have moved on.
+	r.RestoreCheckerState(rf)
+	err = r.CheckAndHeal(ctx, rf, gates)
+	if err := r.SaveCheckerState(ctx, rf); err != nil {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not save the checker state: %s", err)
+	}
+	if err != nil {
 		r.mClient.SetClusterError(rf.Namespace, rf.Name)
+		r.failReconcile(ctx, rf, redisfailoverv1.ReasonCheckFailed, err)
 		return err
 	}
+	r.reconcileFailures.Clear(rfKey(rf), redisfailoverv1.ReasonCheckFailed)
+
+	// A failed backup must not fail the reconcile either, it's retried on the next one.
+	if rf.BackupEnabled() {
+		if err := r.EnsureBackups(ctx, rf, labels, time.Now()); err != nil {
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not back up the redis data: %s", err)
+		}
+	}
 
 	r.mClient.SetClusterOK(rf.Namespace, rf.Name)
 	return nil
 }
 
-// getLabels merges the labels (dynamic and operator static ones).
-func (r *RedisFailoverHandler) getLabels(rf *redisfailoverv1.RedisFailover) map[string]string {
+// GeneratedLabels returns the labels of the objects generated for the RF: its whitelisted labels and
+// the common ones over the labels of the operator.
+func GeneratedLabels(rf *redisfailoverv1.RedisFailover, logger log.Logger) map[string]string {
 	dynLabels := map[string]string{
 		rfLabelNameKey: rf.Name,
 	}
@@ -97,7 +308,7 @@ func (r *RedisFailoverHandler) getLabels(rf *redisfailoverv1.RedisFailover) map[
 		for _, regex := range rf.Spec.LabelWhitelist {
 			compiledRegexp, err := regexp.Compile(regex)
 			if err != nil {
-				r.logger.Errorf("Unable to compile label whitelist regex '%s', ignoring it.", regex)
+				logger.Errorf("Unable to compile label whitelist regex '%s', ignoring it.", regex)
 				continue
 			}
 			for labelKey, labelValue := range rf.Labels {
@@ -110,10 +321,15 @@ func (r *RedisFailoverHandler) getLabels(rf *redisfailoverv1.RedisFailover) map[
 		// If no whitelist is specified then don't filter the labels.
 		filteredCustomLabels = rf.Labels
 	}
-	return util.MergeLabels(defaultLabels, dynLabels, filteredCustomLabels)
+	labels := util.MergeLabels(defaultLabels, dynLabels, filteredCustomLabels, rf.CommonLabels())
+	// The custom and the common labels override the operator ones, but for the ownership of the
+	// generated objects, that is used to list them.
+	labels[rfLabelManagedByKey] = defaultLabels[rfLabelManagedByKey]
+	labels[rfLabelNameKey] = rf.Name
+	return labels
 }
 
-func (w *RedisFailoverHandler) createOwnerReferences(rf *redisfailoverv1.RedisFailover) []metav1.OwnerReference {
+func createOwnerReferences(rf *redisfailoverv1.RedisFailover) []metav1.OwnerReference {
 	rfvk := redisfailoverv1.VersionKind(redisfailoverv1.RFKind)
 	return []metav1.OwnerReference{
 		*metav1.NewControllerRef(rf, rfvk),
@@ -0,0 +1,68 @@
+package redisfailover
+
+import (
+	"sync"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// instanceContainer is a container of a redis or sentinel pod.
+type instanceContainer struct {
+	pod       string
+	container string
+}
+
+// instanceRestartSeries keeps, for every RF, the containers whose restart count is exposed, so the series
+// of the containers gone are deleted.
+type instanceRestartSeries struct {
+	mu     sync.Mutex
+	series map[string]map[instanceContainer]bool
+}
+
+func newInstanceRestartSeries() *instanceRestartSeries {
+	return &instanceRestartSeries{
+		series: map[string]map[instanceContainer]bool{},
+	}
+}
+
+// set records the containers exposed for the RF and returns the ones exposed before that are gone.
+func (s *instanceRestartSeries) set(key string, current map[instanceContainer]bool) []instanceContainer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	gone := []instanceContainer{}
+	for c := range s.series[key] {
+		if !current[c] {
+			gone = append(gone, c)
+		}
+	}
+	if len(current) == 0 {
+		delete(s.series, key)
+	} else {
+		s.series[key] = current
+	}
+	return gone
+}
+
+// forget forgets the containers exposed for the RF.
+func (s *instanceRestartSeries) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.series, key)
+}
+
+// setInstanceRestarts exposes the restart counts of the containers of the instances. The series of the
+// containers gone since the last status update are deleted, as the sentinel pods get new names on every
+// rollout and the redis pods are gone on a scale down. The others are only set, resetting them first
+// would drop them between two scrapes.
+func (r *RedisFailoverHandler) setInstanceRestarts(rf *redisfailoverv1.RedisFailover, instances []redisfailoverv1.InstanceStatus) {
+	current := map[instanceContainer]bool{}
+	for _, instance := range instances {
+		for _, container := range instance.Containers {
+			r.mClient.SetInstanceRestarts(rf.Namespace, rf.Name, instance.Name, container.Name, container.Restarts)
+			current[instanceContainer{pod: instance.Name, container: container.Name}] = true
+		}
+	}
+	for _, gone := range r.restartSeries.set(rfKey(rf), current) {
+		r.mClient.DeleteInstanceRestarts(rf.Namespace, rf.Name, gone.pod, gone.container)
+	}
+}
@@ -0,0 +1,240 @@
+// Package migrate converts the CRs of other redis operators into RedisFailovers.
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// Flavors of the foreign CRs that can be converted.
+const (
+	// FlavorSpotahome are the RedisFailovers of the spotahome/redis-operator releases and forks.
+	FlavorSpotahome = "spotahome"
+	// FlavorOpstree are the RedisReplications and RedisSentinels of the OT-CONTAINER-KIT operator.
+	FlavorOpstree = "ot-container-kit"
+)
+
+// lastAppliedAnnotation is set by kubectl apply, it's not kept on the converted RedisFailovers.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// Options tunes the conversion.
+type Options struct {
+	// Adopt sets the adoption annotation on the converted RedisFailovers, so the operator takes over
+	// the redis StatefulSet of the foreign operator instead of creating its own.
+	Adopt bool
+}
+
+// Result is a RedisFailover converted from foreign CRs, with the warnings about the settings that
+// couldn't be mapped.
+type Result struct {
+	RedisFailover *redisfailoverv1.RedisFailover
+	Warnings      []string
+}
+
+type flavor struct {
+	convert   func(objs []*unstructured.Unstructured, opts Options) ([]Result, error)
+	resources []schema.GroupVersionResource
+}
+
+var flavors = map[string]flavor{
+	FlavorSpotahome: {
+		convert: convertSpotahome,
+		resources: []schema.GroupVersionResource{
+			{Group: "databases.spotahome.com", Version: "v1", Resource: "redisfailovers"},
+		},
+	},
+	FlavorOpstree: {
+		convert: convertOpstree,
+		resources: []schema.GroupVersionResource{
+			{Group: opstreeGroup, Version: "v1beta2", Resource: "redisreplications"},
+			{Group: opstreeGroup, Version: "v1beta2", Resource: "redissentinels"},
+		},
+	},
+}
+
+// Flavors returns the flavors that can be converted.
+func Flavors() []string {
+	names := make([]string, 0, len(flavors))
+	for name := range flavors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resources returns the resources of the foreign CRs of the flavor, to read them from the cluster.
+func Resources(name string) ([]schema.GroupVersionResource, error) {
+	f, ok := flavors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown flavor %q, must be one of %s", name, strings.Join(Flavors(), ", "))
+	}
+	return f.resources, nil
+}
+
+// Convert converts the foreign CRs of the flavor into RedisFailovers.
+func Convert(name string, objs []*unstructured.Unstructured, opts Options) ([]Result, error) {
+	f, ok := flavors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown flavor %q, must be one of %s", name, strings.Join(Flavors(), ", "))
+	}
+	return f.convert(objs, opts)
+}
+
+// ParseObjects parses the YAML or JSON documents of a file, the items of the lists are returned one
+// by one.
+func ParseObjects(content []byte) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 4096)
+	for {
+		doc := map[string]interface{}{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, err
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: doc}
+		if !obj.IsList() {
+			objs = append(objs, obj)
+			continue
+		}
+		list, err := obj.ToList()
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+	}
+}
+
+// newRedisFailover returns a RedisFailover with the metadata of the foreign CR.
+func newRedisFailover(obj *unstructured.Unstructured) *redisfailoverv1.RedisFailover {
+	rf := &redisfailoverv1.RedisFailover{}
+	rf.APIVersion = redisfailoverv1.SchemeGroupVersion.String()
+	rf.Kind = "RedisFailover"
+	rf.Name = obj.GetName()
+	rf.Namespace = obj.GetNamespace()
+	rf.Labels = obj.GetLabels()
+	annotations := obj.GetAnnotations()
+	delete(annotations, lastAppliedAnnotation)
+	if len(annotations) > 0 {
+		rf.Annotations = annotations
+	}
+	return rf
+}
+
+// objectName names a foreign CR in the warnings and the errors.
+func objectName(obj *unstructured.Unstructured) string {
+	return obj.GetKind() + "/" + obj.GetName()
+}
+
+// specFields reads the spec of a foreign CR, and tells which of its fields were not read.
+type specFields struct {
+	spec map[string]interface{}
+	read map[string]bool
+}
+
+func newSpecFields(obj *unstructured.Unstructured) *specFields {
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	return &specFields{spec: spec, read: map[string]bool{}}
+}
+
+// get returns the field at the dotted path and marks it as read.
+func (f *specFields) get(path string) (interface{}, bool) {
+	f.read[path] = true
+	value, ok, _ := unstructured.NestedFieldNoCopy(f.spec, strings.Split(path, ".")...)
+	return value, ok && value != nil
+}
+
+// decode decodes the field at the dotted path into the value, it's left untouched when the field is
+// not set.
+func (f *specFields) decode(path string, into interface{}) error {
+	value, ok := f.get(path)
+	if !ok {
+		return nil
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, into); err != nil {
+		return fmt.Errorf("spec.%s: %w", path, err)
+	}
+	return nil
+}
+
+// unread returns the paths of the fields set that were not read, the fields within a read one are
+// read with it.
+func (f *specFields) unread() []string {
+	paths := []string{}
+	walkFields(f.spec, "", func(path string) bool { return !f.read[path] }, func(path string) {
+		paths = append(paths, path)
+	})
+	sort.Strings(paths)
+	return paths
+}
+
+// walkFields calls leaf with the dotted paths of the fields set of the object, descending into the
+// nested objects for which descend returns true. The lists are leaves.
+func walkFields(obj map[string]interface{}, prefix string, descend func(path string) bool, leaf func(path string)) {
+	for key, value := range obj {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if !descend(path) || isZero(value) {
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			walkFields(nested, path, descend, leaf)
+			continue
+		}
+		leaf(path)
+	}
+}
+
+// isZero returns true for the fields that are set to their zero value, which are the same as unset.
+func isZero(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// unmappedWarnings returns the warnings about the fields of the foreign CR that were not mapped,
+// with the hint given for them or for the field they are within.
+func unmappedWarnings(obj *unstructured.Unstructured, paths []string, hints map[string]string) []string {
+	warnings := []string{}
+	for _, path := range paths {
+		warning := fmt.Sprintf("%s: spec.%s is not mapped", objectName(obj), path)
+		for field, hint := range hints {
+			if path == field || strings.HasPrefix(path, field+".") {
+				warning += ", " + hint
+				break
+			}
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
@@ -0,0 +1,118 @@
+package migrate_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/migrate"
+)
+
+// expectedResult is a converted RedisFailover as written in the fixtures.
+type expectedResult struct {
+	RedisFailover *redisfailoverv1.RedisFailover `json:"redisFailover"`
+	Warnings      []string                       `json:"warnings"`
+}
+
+// TestConvertFixtures converts the CRs of every testdata/<flavor>/<case>.input.yaml, with the adoption
+// enabled, and compares them with the ones of <case>.expected.yaml.
+func TestConvertFixtures(t *testing.T) {
+	for _, flavor := range migrate.Flavors() {
+		inputs, err := filepath.Glob(filepath.Join("testdata", flavor, "*.input.yaml"))
+		require.NoError(t, err)
+		require.NotEmpty(t, inputs, "the flavor %s has no fixture", flavor)
+
+		for _, input := range inputs {
+			input := input
+			t.Run(flavor+"/"+filepath.Base(input), func(t *testing.T) {
+				assert := assert.New(t)
+				require := require.New(t)
+
+				content, err := os.ReadFile(input)
+				require.NoError(err)
+				objs, err := migrate.ParseObjects(content)
+				require.NoError(err)
+
+				results, err := migrate.Convert(flavor, objs, migrate.Options{Adopt: true})
+				require.NoError(err)
+
+				content, err = os.ReadFile(strings.TrimSuffix(input, ".input.yaml") + ".expected.yaml")
+				require.NoError(err)
+				expected := []expectedResult{}
+				require.NoError(yaml.UnmarshalStrict(content, &expected))
+
+				require.Len(results, len(expected))
+				for i, result := range results {
+					assert.Equal(expected[i].RedisFailover, result.RedisFailover)
+					assert.Equal(expected[i].Warnings, result.Warnings)
+				}
+			})
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		flavor   string
+		content  string
+		expError string
+	}{
+		{
+			name:     "Unknown flavor",
+			flavor:   "bitnami",
+			expError: "unknown flavor \"bitnami\", must be one of ot-container-kit, spotahome",
+		},
+		{
+			name:   "Foreign kind",
+			flavor: migrate.FlavorSpotahome,
+			content: `apiVersion: redis.redis.opstreelabs.in/v1beta2
+kind: RedisReplication
+metadata:
+  name: cache
+`,
+			expError: "RedisReplication/cache can't be converted, only RedisFailovers are",
+		},
+		{
+			name:   "Sentinel without its replication",
+			flavor: migrate.FlavorOpstree,
+			content: `apiVersion: redis.redis.opstreelabs.in/v1beta2
+kind: RedisSentinel
+metadata:
+  name: cache-sentinel
+spec:
+  redisSentinelConfig:
+    redisReplicationName: cache
+`,
+			expError: "[RedisSentinel/cache-sentinel] monitor RedisReplications that were not given",
+		},
+		{
+			name:   "Invalid cluster size",
+			flavor: migrate.FlavorOpstree,
+			content: `apiVersion: redis.redis.opstreelabs.in/v1beta2
+kind: RedisReplication
+metadata:
+  name: cache
+spec:
+  clusterSize: three
+`,
+			expError: "RedisReplication/cache: spec.clusterSize: \"three\" is not a number",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs, err := migrate.ParseObjects([]byte(test.content))
+			require.NoError(t, err)
+
+			_, err = migrate.Convert(test.flavor, objs, migrate.Options{})
+			assert.EqualError(t, err, test.expError)
+		})
+	}
+}
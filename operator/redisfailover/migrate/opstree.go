@@ -0,0 +1,282 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+const (
+	opstreeGroup = "redis.redis.opstreelabs.in"
+	// opstreePasswordKey is the key of the password in our auth secrets.
+	opstreePasswordKey = "password"
+	// opstreeMasterName is the name our sentinels monitor the master with.
+	opstreeMasterName = "mymaster"
+)
+
+// opstreeHints tell how to set by hand the fields of the OT-CONTAINER-KIT CRs not mapped.
+var opstreeHints = map[string]string{
+	"redisConfig.additionalRedisConfig": "copy the settings of its ConfigMap into spec.redis.customConfig",
+	"TLS":                               "TLS is not supported",
+	"acl":                               "the ACLs are not supported",
+	"redisSentinelConfig.additionalSentinelConfig": "copy the settings of its ConfigMap into spec.sentinel.customConfig",
+}
+
+// opstreeSentinelSettings are the settings of the RedisSentinels that are sentinel options, rendered
+// in the sentinel customConfig.
+var opstreeSentinelSettings = []struct {
+	field  string
+	option string
+}{
+	{field: "redisSentinelConfig.downAfterMilliseconds", option: "down-after-milliseconds"},
+	{field: "redisSentinelConfig.failoverTimeout", option: "failover-timeout"},
+	{field: "redisSentinelConfig.parallelSyncs", option: "parallel-syncs"},
+}
+
+// convertOpstree converts the RedisReplications of the OT-CONTAINER-KIT operator, with the RedisSentinels
+// monitoring them, into RedisFailovers. The redis StatefulSet of a RedisReplication has its name, as its
+// volume claim template, so the data volumes are kept when it's adopted.
+func convertOpstree(objs []*unstructured.Unstructured, opts Options) ([]Result, error) {
+	replications := []*unstructured.Unstructured{}
+	sentinels := map[string]*unstructured.Unstructured{}
+	for _, obj := range objs {
+		switch obj.GetKind() {
+		case "RedisReplication":
+			replications = append(replications, obj)
+		case "RedisSentinel":
+			replication, _, _ := unstructured.NestedString(obj.Object, "spec", "redisSentinelConfig", "redisReplicationName")
+			sentinels[obj.GetNamespace()+"/"+replication] = obj
+		default:
+			return nil, fmt.Errorf("%s can't be converted, only RedisReplications and RedisSentinels are", objectName(obj))
+		}
+	}
+
+	results := []Result{}
+	for _, replication := range replications {
+		rf := newRedisFailover(replication)
+		warnings, err := convertOpstreeReplication(replication, rf)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", objectName(replication), err)
+		}
+
+		key := replication.GetNamespace() + "/" + replication.GetName()
+		if sentinel, ok := sentinels[key]; ok {
+			delete(sentinels, key)
+			sentinelWarnings, err := convertOpstreeSentinel(sentinel, rf)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", objectName(sentinel), err)
+			}
+			warnings = append(warnings, sentinelWarnings...)
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s: no RedisSentinel monitors it, the default sentinels are deployed", objectName(replication)))
+		}
+
+		if opts.Adopt {
+			if rf.Annotations == nil {
+				rf.Annotations = map[string]string{}
+			}
+			rf.Annotations[redisfailoverv1.AdoptStatefulSetAnnotation] = replication.GetName()
+		}
+		results = append(results, Result{RedisFailover: rf, Warnings: warnings})
+	}
+
+	// The sentinels left monitor a RedisReplication that was not given.
+	orphans := []string{}
+	for _, sentinel := range sentinels {
+		orphans = append(orphans, objectName(sentinel))
+	}
+	if len(orphans) > 0 {
+		sort.Strings(orphans)
+		return nil, fmt.Errorf("%v monitor RedisReplications that were not given", orphans)
+	}
+	return results, nil
+}
+
+// convertOpstreeReplication sets the redis settings of the RF from the RedisReplication.
+func convertOpstreeReplication(obj *unstructured.Unstructured, rf *redisfailoverv1.RedisFailover) ([]string, error) {
+	f := newSpecFields(obj)
+	redis := &rf.Spec.Redis
+	warnings := []string{}
+
+	if size, ok := f.get("clusterSize"); ok {
+		replicas, err := toInt32(size)
+		if err != nil {
+			return nil, fmt.Errorf("spec.clusterSize: %w", err)
+		}
+		redis.Replicas = replicas
+	}
+	if err := decodeOpstreeKubernetesConfig(f, &redis.Image, &redis.ImagePullPolicy, &redis.Resources, &redis.ImagePullSecrets); err != nil {
+		return nil, err
+	}
+	if err := f.decode("kubernetesConfig.service.annotations", &redis.ServiceAnnotations); err != nil {
+		return nil, err
+	}
+
+	if secret, ok := f.get("kubernetesConfig.redisSecret.name"); ok {
+		rf.Spec.Auth.SecretPath = fmt.Sprint(secret)
+		if key, ok := f.get("kubernetesConfig.redisSecret.key"); ok && key != opstreePasswordKey {
+			warnings = append(warnings, fmt.Sprintf("%s: the password is read from the %s key of the secret %s, add it next to the %s key", objectName(obj), opstreePasswordKey, secret, key))
+		}
+	}
+
+	if err := decodeOpstreeExporter(f, &redis.Exporter); err != nil {
+		return nil, err
+	}
+
+	if _, ok := f.get("storage.volumeClaimTemplate"); ok {
+		claim := &redisfailoverv1.EmbeddedPersistentVolumeClaim{}
+		if err := f.decode("storage.volumeClaimTemplate.spec", &claim.Spec); err != nil {
+			return nil, err
+		}
+		// The StatefulSet of the RedisReplication names its volume claim template after it.
+		claim.Name = obj.GetName()
+		redis.Storage.PersistentVolumeClaim = claim
+	}
+	if keep, ok := f.get("storage.keepAfterDelete"); ok {
+		redis.Storage.KeepAfterDeletion, _ = keep.(bool)
+	}
+
+	if err := decodeOpstreeScheduling(f, &redis.Affinity, &redis.Tolerations, &redis.NodeSelector, &redis.PriorityClassName, &redis.ServiceAccountName); err != nil {
+		return nil, err
+	}
+	if err := f.decode("podSecurityContext", &redis.SecurityContext); err != nil {
+		return nil, err
+	}
+	if err := f.decode("securityContext", &redis.ContainerSecurityContext); err != nil {
+		return nil, err
+	}
+	if seconds, ok := f.get("terminationGracePeriodSeconds"); ok {
+		s, err := toInt32(seconds)
+		if err != nil {
+			return nil, fmt.Errorf("spec.terminationGracePeriodSeconds: %w", err)
+		}
+		redis.TerminationGracePeriodDuration = &metav1.Duration{Duration: time.Duration(s) * time.Second}
+	}
+
+	return append(warnings, unmappedWarnings(obj, f.unread(), opstreeHints)...), nil
+}
+
+// convertOpstreeSentinel sets the sentinel settings of the RF from the RedisSentinel monitoring its
+// RedisReplication.
+func convertOpstreeSentinel(obj *unstructured.Unstructured, rf *redisfailoverv1.RedisFailover) ([]string, error) {
+	f := newSpecFields(obj)
+	sentinel := &rf.Spec.Sentinel
+	warnings := []string{}
+
+	f.get("redisSentinelConfig.redisReplicationName")
+	if name, ok := f.get("redisSentinelConfig.masterGroupName"); ok && name != opstreeMasterName {
+		warnings = append(warnings, fmt.Sprintf("%s: the sentinels monitor the master as %s instead of %s", objectName(obj), opstreeMasterName, name))
+	}
+	if size, ok := f.get("clusterSize"); ok {
+		replicas, err := toInt32(size)
+		if err != nil {
+			return nil, fmt.Errorf("spec.clusterSize: %w", err)
+		}
+		sentinel.Replicas = replicas
+	}
+	if quorum, ok := f.get("redisSentinelConfig.quorum"); ok {
+		q, err := toInt32(quorum)
+		if err != nil {
+			return nil, fmt.Errorf("spec.redisSentinelConfig.quorum: %w", err)
+		}
+		sentinel.Quorum = q
+	}
+	for _, setting := range opstreeSentinelSettings {
+		if value, ok := f.get(setting.field); ok {
+			sentinel.CustomConfig = append(sentinel.CustomConfig, fmt.Sprintf("%s %v", setting.option, value))
+		}
+	}
+
+	if err := decodeOpstreeKubernetesConfig(f, &sentinel.Image, &sentinel.ImagePullPolicy, &sentinel.Resources, &sentinel.ImagePullSecrets); err != nil {
+		return nil, err
+	}
+	if err := f.decode("kubernetesConfig.service.annotations", &sentinel.ServiceAnnotations); err != nil {
+		return nil, err
+	}
+	if err := decodeOpstreeExporter(f, &sentinel.Exporter); err != nil {
+		return nil, err
+	}
+	if err := decodeOpstreeScheduling(f, &sentinel.Affinity, &sentinel.Tolerations, &sentinel.NodeSelector, &sentinel.PriorityClassName, &sentinel.ServiceAccountName); err != nil {
+		return nil, err
+	}
+	if err := f.decode("podSecurityContext", &sentinel.SecurityContext); err != nil {
+		return nil, err
+	}
+	if err := f.decode("securityContext", &sentinel.ContainerSecurityContext); err != nil {
+		return nil, err
+	}
+
+	return append(warnings, unmappedWarnings(obj, f.unread(), opstreeHints)...), nil
+}
+
+// decodeOpstreeKubernetesConfig decodes the container settings shared by the OT-CONTAINER-KIT CRs.
+func decodeOpstreeKubernetesConfig(f *specFields, image *string, pullPolicy *corev1.PullPolicy, resources *corev1.ResourceRequirements, pullSecrets *[]corev1.LocalObjectReference) error {
+	if err := f.decode("kubernetesConfig.image", image); err != nil {
+		return err
+	}
+	if err := f.decode("kubernetesConfig.imagePullPolicy", pullPolicy); err != nil {
+		return err
+	}
+	if err := f.decode("kubernetesConfig.resources", resources); err != nil {
+		return err
+	}
+	return f.decode("kubernetesConfig.imagePullSecrets", pullSecrets)
+}
+
+// decodeOpstreeExporter decodes the exporter settings shared by the OT-CONTAINER-KIT CRs.
+func decodeOpstreeExporter(f *specFields, exporter *redisfailoverv1.Exporter) error {
+	if err := f.decode("redisExporter.enabled", &exporter.Enabled); err != nil {
+		return err
+	}
+	if err := f.decode("redisExporter.image", &exporter.Image); err != nil {
+		return err
+	}
+	if err := f.decode("redisExporter.imagePullPolicy", &exporter.ImagePullPolicy); err != nil {
+		return err
+	}
+	if err := f.decode("redisExporter.resources", &exporter.Resources); err != nil {
+		return err
+	}
+	return f.decode("redisExporter.env", &exporter.Env)
+}
+
+// decodeOpstreeScheduling decodes the scheduling settings shared by the OT-CONTAINER-KIT CRs.
+func decodeOpstreeScheduling(f *specFields, affinity **corev1.Affinity, tolerations *[]corev1.Toleration, nodeSelector *map[string]string, priorityClassName, serviceAccountName *string) error {
+	if err := f.decode("affinity", affinity); err != nil {
+		return err
+	}
+	if err := f.decode("tolerations", tolerations); err != nil {
+		return err
+	}
+	if err := f.decode("nodeSelector", nodeSelector); err != nil {
+		return err
+	}
+	if err := f.decode("priorityClassName", priorityClassName); err != nil {
+		return err
+	}
+	return f.decode("serviceAccountName", serviceAccountName)
+}
+
+// toInt32 returns the number of a field, given as a number or as a string.
+func toInt32(value interface{}) (int32, error) {
+	switch v := value.(type) {
+	case int64:
+		return int32(v), nil
+	case float64:
+		return int32(v), nil
+	case string:
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", v)
+		}
+		return int32(n), nil
+	}
+	return 0, fmt.Errorf("%v is not a number", value)
+}
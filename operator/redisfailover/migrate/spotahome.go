@@ -0,0 +1,119 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// spotahomeHints tell how to set by hand the fields of the spotahome RedisFailovers not mapped.
+var spotahomeHints = map[string]string{
+	"hardAntiAffinity": "set a required pod anti-affinity in spec.redis.affinity and spec.sentinel.affinity",
+}
+
+// spotahomeSharedFields are the legacy scheduling fields shared by redis and the sentinels, with the
+// fields of each one they are moved to.
+var spotahomeSharedFields = map[string]string{
+	"tolerations":     "tolerations",
+	"securityContext": "securityContext",
+	"nodeAffinity":    "affinity",
+}
+
+// convertSpotahome converts the RedisFailovers of the spotahome/redis-operator releases and forks. Their
+// current fields are the same as ours, the legacy ones are moved to their current place and the fields
+// added by the forks are reported. Their redis StatefulSets have the names of ours, so they are updated
+// in place without being adopted.
+func convertSpotahome(objs []*unstructured.Unstructured, _ Options) ([]Result, error) {
+	results := []Result{}
+	for _, obj := range objs {
+		if obj.GetKind() != "RedisFailover" {
+			return nil, fmt.Errorf("%s can't be converted, only RedisFailovers are", objectName(obj))
+		}
+		spec, _, err := unstructured.NestedMap(obj.Object, "spec")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", objectName(obj), err)
+		}
+		moveLegacySpotahomeFields(spec)
+
+		rf := newRedisFailover(obj)
+		content, err := json.Marshal(spec)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(content, &rf.Spec); err != nil {
+			return nil, fmt.Errorf("%s: %w", objectName(obj), err)
+		}
+		converted, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&rf.Spec)
+		if err != nil {
+			return nil, err
+		}
+
+		// The fields set on the foreign spec and lost on the way are the ones we don't have.
+		dropped := []string{}
+		walkFields(spec, "", func(string) bool { return true }, func(path string) {
+			if value, ok, _ := unstructured.NestedFieldNoCopy(converted, strings.Split(path, ".")...); !ok || isZero(value) {
+				dropped = append(dropped, path)
+			}
+		})
+		sort.Strings(dropped)
+		results = append(results, Result{
+			RedisFailover: rf,
+			Warnings:      unmappedWarnings(obj, dropped, spotahomeHints),
+		})
+	}
+	return results, nil
+}
+
+// moveLegacySpotahomeFields moves the fields of the first releases to their current place: the redis
+// version was given apart from its image, the exporter was a boolean, and the scheduling settings were
+// shared by redis and the sentinels.
+func moveLegacySpotahomeFields(spec map[string]interface{}) {
+	if redis, ok := spec["redis"].(map[string]interface{}); ok {
+		if version, ok := redis["version"].(string); ok {
+			image, _ := redis["image"].(string)
+			if image == "" {
+				image = "redis"
+			}
+			redis["image"] = image + ":" + version
+			delete(redis, "version")
+		}
+		if enabled, ok := redis["exporter"].(bool); ok {
+			exporter := map[string]interface{}{"enabled": enabled}
+			if image, ok := redis["exporterImage"].(string); ok {
+				if version, ok := redis["exporterVersion"].(string); ok {
+					image += ":" + version
+				}
+				exporter["image"] = image
+			}
+			redis["exporter"] = exporter
+			delete(redis, "exporterImage")
+			delete(redis, "exporterVersion")
+		}
+	}
+
+	for legacy, current := range spotahomeSharedFields {
+		value, ok := spec[legacy]
+		if !ok {
+			continue
+		}
+		if legacy == "nodeAffinity" {
+			value = map[string]interface{}{"nodeAffinity": value}
+		}
+		for _, component := range []string{"redis", "sentinel"} {
+			settings, ok := spec[component].(map[string]interface{})
+			if !ok {
+				settings = map[string]interface{}{}
+				spec[component] = settings
+			}
+			// The settings of the component win over the shared ones.
+			if _, ok := settings[current]; !ok {
+				settings[current] = runtime.DeepCopyJSONValue(value)
+			}
+		}
+		delete(spec, legacy)
+	}
+}
@@ -0,0 +1,84 @@
+package redisfailover
+
+import (
+	"context"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+// Behaviors of the operator with the RFs of a namespace being deleted.
+const (
+	// TerminatingNamespaceSkip skips their reconcile, their objects are deleted with the namespace.
+	TerminatingNamespaceSkip = "skip"
+	// TerminatingNamespaceReconcile reconciles them like the others until they are deleted, the objects
+	// the operator creates meanwhile are refused by the apiserver.
+	TerminatingNamespaceReconcile = "reconcile"
+)
+
+// CheckNamespaceTerminating returns true when the namespace of the RF is being deleted and the RF must
+// not be reconciled. It's neither ensured nor healed, the namespace controller is deleting its objects
+// and the apiserver refuses to create new ones, and what the operator keeps for it is cleaned up. A
+// failure reading the namespace doesn't hold the reconcile.
+func (r *RedisFailoverHandler) CheckNamespaceTerminating(ctx context.Context, rf *redisfailoverv1.RedisFailover) bool {
+	if r.config.TerminatingNamespace == TerminatingNamespaceReconcile {
+		return false
+	}
+	terminating, err := r.k8sservice.IsNamespaceTerminating(ctx, rf.Namespace)
+	if err != nil {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not check if the namespace is terminating: %s", err)
+		return false
+	}
+	if terminating {
+		r.skipTerminatingNamespace(rf)
+	}
+	return terminating
+}
+
+// isTerminatingNamespaceError returns true when the error is the refusal to create an object because
+// the namespace of the RF started terminating during the reconcile, then the RF is skipped like it
+// would have been by CheckNamespaceTerminating.
+func (r *RedisFailoverHandler) isTerminatingNamespaceError(rf *redisfailoverv1.RedisFailover, err error) bool {
+	if r.config.TerminatingNamespace == TerminatingNamespaceReconcile || !k8s.IsNamespaceTerminatingError(err) {
+		return false
+	}
+	r.skipTerminatingNamespace(rf)
+	return true
+}
+
+// skipTerminatingNamespace forgets the RF, see forget. It's an expected condition, it's only logged in
+// debug.
+func (r *RedisFailoverHandler) skipTerminatingNamespace(rf *redisfailoverv1.RedisFailover) {
+	r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Debugf("The namespace is terminating, skipping the redisfailover")
+	r.mClient.RecordReconcileSkipped(rf.Namespace, rf.Name, metrics.NAMESPACE_TERMINATING)
+	r.forget(rf)
+}
+
+// forget stops the sentinel events watch of the RF, and removes its metrics, its redis round-trips and
+// startup warnings, its replicas waiting for a sync slot, its last failover, its held promotion and
+// pods, its skipped PodDisruptionBudgets, its logged crashes, its loaded ACL files, its rate-limited
+// events and its reconcile failure. It's called once the RF is deleted, see Cleanup, or its namespace is terminating.
+func (r *RedisFailoverHandler) forget(rf *redisfailoverv1.RedisFailover) {
+	r.mClient.DeleteCluster(rf.Namespace, rf.Name)
+	r.mClient.ResetInstanceRestarts(rf.Namespace, rf.Name)
+	r.restartSeries.forget(rfKey(rf))
+	r.rfChecker.ForgetRedisLatency(rf)
+	r.rfChecker.ForgetNodeTuningWarnings(rf)
+	r.rfHealer.ClearSyncSlotQueue(rf)
+	r.stabilizer.Forget(rfKey(rf))
+	if r.sentinelEvents != nil {
+		r.sentinelEvents.Forget(rf)
+	}
+	if r.recentLogs != nil {
+		r.recentLogs.Forget(rf.Namespace, rf.Name)
+	}
+	r.promotionHolds.Release(rfKey(rf))
+	r.volumeWaits.Set(rfKey(rf), nil)
+	r.pdbSkips.Set(rfKey(rf), false)
+	r.crashLogs.Set(rfKey(rf), nil)
+	r.aclLoads.Forget(rfKey(rf))
+	r.events.Forget(rf)
+	r.reconcileFailures.Forget(rfKey(rf))
+	r.checkRuns.Forget(rfKey(rf))
+}
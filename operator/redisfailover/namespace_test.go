@@ -0,0 +1,97 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	rfOperator "redis-operator/operator/redisfailover"
+	"redis-operator/service/k8s"
+)
+
+func newNamespace(phase corev1.NamespacePhase) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace},
+		Status:     corev1.NamespaceStatus{Phase: phase},
+	}
+}
+
+func TestCheckNamespaceTerminating(t *testing.T) {
+	tests := []struct {
+		name     string
+		phase    corev1.NamespacePhase
+		behavior string
+		expSkip  bool
+	}{
+		{
+			name:  "The RFs of an active namespace are reconciled",
+			phase: corev1.NamespaceActive,
+		},
+		{
+			name:    "The RFs of a terminating namespace are skipped",
+			phase:   corev1.NamespaceTerminating,
+			expSkip: true,
+		},
+		{
+			name:     "The RFs of a terminating namespace are reconciled when configured",
+			phase:    corev1.NamespaceTerminating,
+			behavior: rfOperator.TerminatingNamespaceReconcile,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ks := k8s.New(kubernetes.NewSimpleClientset(newNamespace(test.phase)), nil, nil, nil, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			config := generateConfig()
+			config.TerminatingNamespace = test.behavior
+			rf := generateRF(false, false)
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfh := &mRFService.RedisFailoverHeal{}
+			if test.expSkip {
+				mrfc.On("ForgetRedisLatency", rf).Once()
+				mrfc.On("ForgetNodeTuningWarnings", rf).Once()
+				mrfh.On("ClearSyncSlotQueue", rf).Once()
+			}
+			handler := rfOperator.NewRedisFailoverHandler(config, &mRFService.RedisFailoverClient{}, mrfc, mrfh, ks, metrics.Dummy, log.Dummy)
+
+			assert.Equal(test.expSkip, handler.CheckNamespaceTerminating(context.TODO(), rf))
+			mrfc.AssertExpectations(t)
+			mrfh.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleTerminatingNamespace(t *testing.T) {
+	assert := assert.New(t)
+
+	cli := kubernetes.NewSimpleClientset(newNamespace(corev1.NamespaceTerminating))
+	ks := k8s.New(cli, nil, nil, nil, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	mrfs := &mRFService.RedisFailoverClient{}
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfh := &mRFService.RedisFailoverHeal{}
+	rf := generateRF(false, false)
+	mrfc.On("ForgetRedisLatency", rf).Once()
+	mrfc.On("ForgetNodeTuningWarnings", rf).Once()
+	mrfh.On("ClearSyncSlotQueue", rf).Once()
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), mrfs, mrfc, mrfh, ks, metrics.Dummy, log.Dummy)
+
+	assert.NoError(handler.Handle(context.TODO(), rf))
+
+	// Only the namespace is read, nothing is created nor updated.
+	for _, action := range cli.Actions() {
+		assert.Equal("get", action.GetVerb(), action.GetResource().Resource)
+		assert.Equal("namespaces", action.GetResource().Resource)
+	}
+	mrfs.AssertExpectations(t)
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+}
@@ -0,0 +1,115 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+)
+
+// namingData is the data the naming templates are rendered with.
+type namingData struct {
+	Namespace string
+	Name      string
+}
+
+// Naming renders the name prefix and the common labels of the objects generated for the RFs from the
+// templates given to the operator.
+type Naming struct {
+	namePrefix   *template.Template
+	commonLabels *template.Template
+}
+
+// NewNaming parses the name prefix template and the common labels template, comma separated
+// key=value pairs. Both are rendered with the namespace and the name of the RF, as in
+// "{{.Namespace}}-". It returns nil without templates.
+func NewNaming(namePrefixTemplate, commonLabelsTemplate string) (*Naming, error) {
+	if namePrefixTemplate == "" && commonLabelsTemplate == "" {
+		return nil, nil
+	}
+	namePrefix, err := template.New("name-prefix").Parse(namePrefixTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name prefix template: %w", err)
+	}
+	commonLabels, err := template.New("common-labels").Parse(commonLabelsTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid common labels template: %w", err)
+	}
+	n := &Naming{
+		namePrefix:   namePrefix,
+		commonLabels: commonLabels,
+	}
+	// The templates are checked with an example RF, so they fail on startup and not on every RF.
+	example := &redisfailoverv1.RedisFailover{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"}}
+	if _, _, err := n.Render(example); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Render returns the name prefix and the common labels of the RF, in the format of their annotations.
+func (n *Naming) Render(rf *redisfailoverv1.RedisFailover) (string, string, error) {
+	data := namingData{Namespace: rf.Namespace, Name: rf.Name}
+
+	var prefix strings.Builder
+	if err := n.namePrefix.Execute(&prefix, data); err != nil {
+		return "", "", fmt.Errorf("could not render the name prefix template: %w", err)
+	}
+	var labels strings.Builder
+	if err := n.commonLabels.Execute(&labels, data); err != nil {
+		return "", "", fmt.Errorf("could not render the common labels template: %w", err)
+	}
+	parsed, err := redisfailoverv1.ParseCommonLabels(labels.String())
+	if err != nil {
+		return "", "", err
+	}
+	return prefix.String(), redisfailoverv1.FormatCommonLabels(parsed), nil
+}
+
+// EnsureNaming pins the name prefix and the common labels on the RFs that don't have them yet. The new
+// RFs get the ones rendered from the templates of the operator. The RFs reconciled by an older
+// operator, or created before this operator started, keep the names and labels they have, renaming
+// their objects would recreate them. Once pinned they are never changed, a change of the templates
+// only applies to the new RFs.
+func (r *RedisFailoverHandler) EnsureNaming(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	if r.naming == nil {
+		return nil
+	}
+
+	_, prefixPinned := rf.Annotations[redisfailoverv1.NamePrefixAnnotation]
+	_, labelsPinned := rf.Annotations[redisfailoverv1.CommonLabelsAnnotation]
+	if prefixPinned || labelsPinned {
+		prefix, labels, err := r.naming.Render(rf)
+		if err == nil && prefix == rf.NamePrefix() && labels == rf.Annotations[redisfailoverv1.CommonLabelsAnnotation] {
+			return nil
+		}
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("the names and labels pinned on the %s and %s annotations don't match the templates of the operator, they are kept as renaming the generated objects would recreate them",
+			redisfailoverv1.NamePrefixAnnotation, redisfailoverv1.CommonLabelsAnnotation)
+		return nil
+	}
+
+	annotations := map[string]string{
+		redisfailoverv1.NamePrefixAnnotation:   "",
+		redisfailoverv1.CommonLabelsAnnotation: "",
+	}
+	if _, ok := redisfailoverv1.ParseSchemaRevision(rf.Annotations); !ok && !rf.CreationTimestamp.Time.Before(r.startTime) {
+		prefix, labels, err := r.naming.Render(rf)
+		if err != nil {
+			return err
+		}
+		annotations[redisfailoverv1.NamePrefixAnnotation] = prefix
+		annotations[redisfailoverv1.CommonLabelsAnnotation] = labels
+	}
+
+	if err := r.k8sservice.PatchRedisFailoverAnnotations(ctx, rf.Namespace, rf.Name, annotations); err != nil {
+		return err
+	}
+	// The RF being reconciled is named with them right away.
+	rf.Annotations = util.MergeLabels(rf.Annotations, annotations)
+	return nil
+}
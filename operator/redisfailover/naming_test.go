@@ -0,0 +1,188 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+func TestNewNaming(t *testing.T) {
+	tests := []struct {
+		name         string
+		namePrefix   string
+		commonLabels string
+		expNil       bool
+		expErr       bool
+	}{
+		{
+			name:   "No templates",
+			expNil: true,
+		},
+		{
+			name:         "Valid templates",
+			namePrefix:   "{{.Namespace}}-",
+			commonLabels: "team={{.Namespace}},instance={{.Name}}",
+		},
+		{
+			name:       "A template that doesn't parse",
+			namePrefix: "{{.Namespace",
+			expErr:     true,
+		},
+		{
+			name:       "A template with an unknown field",
+			namePrefix: "{{.Cluster}}-",
+			expErr:     true,
+		},
+		{
+			name:         "Common labels that are not pairs",
+			commonLabels: "{{.Namespace}}",
+			expErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			naming, err := rfOperator.NewNaming(test.namePrefix, test.commonLabels)
+
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expNil, naming == nil)
+		})
+	}
+}
+
+func TestEnsureNaming(t *testing.T) {
+	tests := []struct {
+		name               string
+		annotations        map[string]string
+		createdBeforeStart bool
+		expPatch           map[string]string
+		expPrefix          string
+		expLabels          map[string]string
+	}{
+		{
+			name: "A new RF gets the rendered prefix and labels",
+			expPatch: map[string]string{
+				redisfailoverv1.NamePrefixAnnotation:   "testns-",
+				redisfailoverv1.CommonLabelsAnnotation: "instance=test,team=testns",
+			},
+			expPrefix: "testns-",
+			expLabels: map[string]string{"instance": "test", "team": "testns"},
+		},
+		{
+			name:               "A RF created before the operator started keeps its names",
+			createdBeforeStart: true,
+			expPatch: map[string]string{
+				redisfailoverv1.NamePrefixAnnotation:   "",
+				redisfailoverv1.CommonLabelsAnnotation: "",
+			},
+			expLabels: map[string]string{},
+		},
+		{
+			name: "A RF reconciled by an older operator keeps its names",
+			annotations: map[string]string{
+				redisfailoverv1.SchemaRevisionAnnotation: "1",
+			},
+			expPatch: map[string]string{
+				redisfailoverv1.NamePrefixAnnotation:   "",
+				redisfailoverv1.CommonLabelsAnnotation: "",
+			},
+			expLabels: map[string]string{},
+		},
+		{
+			name: "The pinned names are not changed by other templates",
+			annotations: map[string]string{
+				redisfailoverv1.NamePrefixAnnotation:   "old-",
+				redisfailoverv1.CommonLabelsAnnotation: "team=old",
+			},
+			expPrefix: "old-",
+			expLabels: map[string]string{"team": "old"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mk := &mK8SService.Services{}
+			if test.expPatch != nil {
+				mk.On("PatchRedisFailoverAnnotations", mock.Anything, namespace, name, test.expPatch).Once().Return(nil)
+			}
+
+			rf := generateRF(false, false)
+			rf.Annotations = test.annotations
+			if test.createdBeforeStart {
+				rf.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+			}
+			config := generateConfig()
+			config.NamePrefixTemplate = "{{.Namespace}}-"
+			config.CommonLabelsTemplate = "team={{.Namespace}},instance={{.Name}}"
+			handler := rfOperator.NewRedisFailoverHandler(config, &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.Dummy, log.Dummy)
+			if !test.createdBeforeStart {
+				rf.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Second))
+			}
+
+			err := handler.EnsureNaming(context.TODO(), rf)
+
+			assert.NoError(err)
+			assert.Equal(test.expPrefix, rf.NamePrefix())
+			assert.Equal(test.expLabels, rf.CommonLabels())
+			assert.NoError(rf.Validate())
+			mk.AssertExpectations(t)
+			if test.expPatch == nil {
+				mk.AssertNotCalled(t, "PatchRedisFailoverAnnotations", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestEnsureNamingWithoutTemplates(t *testing.T) {
+	assert := assert.New(t)
+
+	mk := &mK8SService.Services{}
+	rf := generateRF(false, false)
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.Dummy, log.Dummy)
+
+	assert.NoError(handler.EnsureNaming(context.TODO(), rf))
+	assert.Empty(rf.Annotations)
+	mk.AssertNotCalled(t, "PatchRedisFailoverAnnotations", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGeneratedLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	rf.Labels = map[string]string{
+		"app.kubernetes.io/managed-by":                "someone-else",
+		"redisfailovers.databases.spotahome.com/name": "other",
+		"app.kubernetes.io/part-of":                   "cache",
+		"team":                                        "payments",
+	}
+	rf.Annotations = map[string]string{redisfailoverv1.CommonLabelsAnnotation: "team=platform"}
+
+	labels := rfOperator.GeneratedLabels(rf, log.Dummy)
+
+	// Only the labels owning the generated objects can't be overridden.
+	assert.Equal(map[string]string{
+		"app.kubernetes.io/managed-by":                "redis-operator",
+		"redisfailovers.databases.spotahome.com/name": name,
+		"app.kubernetes.io/part-of":                   "cache",
+		"team":                                        "platform",
+	}, labels)
+}
@@ -0,0 +1,156 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/metrics"
+	"redis-operator/operator/redisfailover/util"
+	"redis-operator/service/k8s"
+)
+
+const (
+	// DefaultOwnerClaimHeartbeat is how often the operators renew their claims on the RFs.
+	DefaultOwnerClaimHeartbeat = time.Minute
+	// DefaultOwnerClaimStaleAfter is how long a claim is kept without being renewed, before another
+	// operator takes the RF over.
+	DefaultOwnerClaimStaleAfter = 5 * time.Minute
+)
+
+// OwnerClaims claims the RFs for the operator, so two operators running side by side don't fight
+// over the same RFs. The younger operator backs off the RFs claimed by a live older one, and takes
+// them over once their claim is stale.
+type OwnerClaims struct {
+	k8sService k8s.Services
+	operator   string
+	holder     string
+	started    time.Time
+	now        func() time.Time
+	heartbeat  time.Duration
+	staleAfter time.Duration
+}
+
+// NewOwnerClaims returns the claims of the operator deployment, given as namespace/name, whose pod
+// holds the lease with the holder identity.
+func NewOwnerClaims(k8sService k8s.Services, operator, holder string, now func() time.Time) *OwnerClaims {
+	return &OwnerClaims{
+		k8sService: k8sService,
+		operator:   operator,
+		holder:     holder,
+		// The claims are stored with a second precision.
+		started:    now().Truncate(time.Second),
+		now:        now,
+		heartbeat:  DefaultOwnerClaimHeartbeat,
+		staleAfter: DefaultOwnerClaimStaleAfter,
+	}
+}
+
+// Claim claims the RF for the operator, and returns the claim of the other operator when it must
+// back off. The claim is only renewed once per heartbeat, and an invalid claim is taken over.
+func (o *OwnerClaims) Claim(ctx context.Context, rf *redisfailoverv1.RedisFailover) (*redisfailoverv1.OwnerClaim, error) {
+	now := o.now()
+	claim, ok, _ := redisfailoverv1.ParseOwnerClaim(rf.Annotations, redisfailoverv1.OwnerClaimAnnotation)
+	if ok && claim.Operator != o.operator && claim.Live(now, o.staleAfter) && !o.olderThan(claim) {
+		return &claim, o.renew(ctx, rf, redisfailoverv1.OwnerContenderAnnotation, now)
+	}
+	if ok && claim.Operator == o.operator && claim.Holder == o.holder && now.Sub(claim.Heartbeat.Time) < o.heartbeat {
+		return nil, nil
+	}
+	return nil, o.write(ctx, rf, redisfailoverv1.OwnerClaimAnnotation, now)
+}
+
+// Contender returns the claim of another live operator backing off the RF, nil when there is none.
+func (o *OwnerClaims) Contender(rf *redisfailoverv1.RedisFailover) *redisfailoverv1.OwnerClaim {
+	contender, ok, _ := redisfailoverv1.ParseOwnerClaim(rf.Annotations, redisfailoverv1.OwnerContenderAnnotation)
+	if !ok || contender.Operator == o.operator || !contender.Live(o.now(), o.staleAfter) {
+		return nil
+	}
+	return &contender
+}
+
+// olderThan returns true when the operator started before the one of the claim, the operators are
+// sorted by their name when they started at the same time.
+func (o *OwnerClaims) olderThan(claim redisfailoverv1.OwnerClaim) bool {
+	if !o.started.Equal(claim.Started.Time) {
+		return o.started.Before(claim.Started.Time)
+	}
+	return o.operator < claim.Operator
+}
+
+// renew writes the claim of the operator in the annotation unless it was written since the heartbeat.
+func (o *OwnerClaims) renew(ctx context.Context, rf *redisfailoverv1.RedisFailover, annotation string, now time.Time) error {
+	claim, ok, _ := redisfailoverv1.ParseOwnerClaim(rf.Annotations, annotation)
+	if ok && claim.Operator == o.operator && claim.Holder == o.holder && now.Sub(claim.Heartbeat.Time) < o.heartbeat {
+		return nil
+	}
+	return o.write(ctx, rf, annotation, now)
+}
+
+func (o *OwnerClaims) write(ctx context.Context, rf *redisfailoverv1.RedisFailover, annotation string, now time.Time) error {
+	value, err := redisfailoverv1.FormatOwnerClaim(redisfailoverv1.OwnerClaim{
+		Operator:  o.operator,
+		Holder:    o.holder,
+		Started:   metav1.NewTime(o.started),
+		Heartbeat: metav1.NewTime(now),
+	})
+	if err != nil {
+		return err
+	}
+	annotations := map[string]string{annotation: value}
+	if err := o.k8sService.PatchRedisFailoverAnnotations(ctx, rf.Namespace, rf.Name, annotations); err != nil {
+		return err
+	}
+	rf.Annotations = util.MergeLabels(rf.Annotations, annotations)
+	return nil
+}
+
+// CheckOwnerClaim returns false when the RF is claimed by another live operator, older than this one,
+// so this one must not reconcile it. The RF is then marked as managed by the other operator, with an
+// event the first time. Without owner claims every RF is reconciled.
+func (r *RedisFailoverHandler) CheckOwnerClaim(ctx context.Context, rf *redisfailoverv1.RedisFailover) (bool, error) {
+	if r.ownerClaims == nil {
+		return true, nil
+	}
+	owner, err := r.ownerClaims.Claim(ctx, rf)
+	if err != nil {
+		return false, err
+	}
+	if owner == nil {
+		return true, nil
+	}
+
+	r.mClient.RecordReconcileSkipped(rf.Namespace, rf.Name, metrics.MANAGED_BY_OTHER_OPERATOR)
+	message := fmt.Sprintf("the redisfailover is managed by the operator %s, the operator %s backs off until its claim is stale", owner.Operator, r.ownerClaims.operator)
+	r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("%s, skipping it", message)
+
+	status := rf.Status.DeepCopy()
+	if !meta.IsStatusConditionTrue(status.Conditions, redisfailoverv1.ConditionManagedByOtherOperator) {
+		event := newRFEvent(rfObjectReference(rf), corev1.EventTypeWarning, redisfailoverv1.ConditionManagedByOtherOperator, message, time.Now())
+		if err := r.k8sservice.CreateEvent(ctx, rf.Namespace, event); err != nil {
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not warn about the other operator: %s", err)
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionManagedByOtherOperator,
+		Status:             metav1.ConditionTrue,
+		Reason:             redisfailoverv1.ReasonOwnerClaimLive,
+		Message:            message,
+		ObservedGeneration: rf.Generation,
+	})
+	return false, r.writeStatus(ctx, rf, status)
+}
+
+// setManagedByOtherCondition removes the condition set by an operator backing off the RF once it
+// stopped contending, it's kept while it does so both operators don't rewrite it in turns.
+func (r *RedisFailoverHandler) setManagedByOtherCondition(status *redisfailoverv1.RedisFailoverStatus, rf *redisfailoverv1.RedisFailover) {
+	if r.ownerClaims != nil && r.ownerClaims.Contender(rf) != nil {
+		return
+	}
+	meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionManagedByOtherOperator)
+}
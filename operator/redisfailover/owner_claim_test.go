@@ -0,0 +1,138 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	redisfailoverfake "redis-operator/client/k8s/clientset/versioned/fake"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	rfOperator "redis-operator/operator/redisfailover"
+	"redis-operator/service/k8s"
+)
+
+func newOwnerClaimsTest(t *testing.T) (k8s.Services, func() *redisfailoverv1.RedisFailover) {
+	customCli := redisfailoverfake.NewSimpleClientset(generateRF(false, false))
+	ks := k8s.New(kubernetes.NewSimpleClientset(), nil, nil, customCli, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	getRF := func() *redisfailoverv1.RedisFailover {
+		rf, err := customCli.DatabasesV1().RedisFailovers(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return rf
+	}
+	return ks, getRF
+}
+
+func storedClaim(t *testing.T, rf *redisfailoverv1.RedisFailover, annotation string) redisfailoverv1.OwnerClaim {
+	claim, ok, err := redisfailoverv1.ParseOwnerClaim(rf.Annotations, annotation)
+	require.NoError(t, err)
+	require.True(t, ok)
+	return claim
+}
+
+func TestOwnerClaimsClaim(t *testing.T) {
+	assert := assert.New(t)
+
+	ks, getRF := newOwnerClaimsTest(t)
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	claims := rfOperator.NewOwnerClaims(ks, "ops/redis-operator", "redis-operator-abc", clock.Now)
+
+	owner, err := claims.Claim(context.TODO(), getRF())
+	assert.NoError(err)
+	assert.Nil(owner)
+	claim := storedClaim(t, getRF(), redisfailoverv1.OwnerClaimAnnotation)
+	assert.Equal("ops/redis-operator", claim.Operator)
+	assert.Equal("redis-operator-abc", claim.Holder)
+	assert.True(claim.Heartbeat.Time.Equal(clock.Now()))
+
+	// The claim is not renewed before the heartbeat.
+	clock.Advance(30 * time.Second)
+	owner, err = claims.Claim(context.TODO(), getRF())
+	assert.NoError(err)
+	assert.Nil(owner)
+	assert.True(storedClaim(t, getRF(), redisfailoverv1.OwnerClaimAnnotation).Heartbeat.Time.Equal(clock.Now().Add(-30 * time.Second)))
+
+	clock.Advance(rfOperator.DefaultOwnerClaimHeartbeat)
+	owner, err = claims.Claim(context.TODO(), getRF())
+	assert.NoError(err)
+	assert.Nil(owner)
+	assert.True(storedClaim(t, getRF(), redisfailoverv1.OwnerClaimAnnotation).Heartbeat.Time.Equal(clock.Now()))
+
+	// A new pod of the same operator takes the claim over right away.
+	restarted := rfOperator.NewOwnerClaims(ks, "ops/redis-operator", "redis-operator-def", clock.Now)
+	owner, err = restarted.Claim(context.TODO(), getRF())
+	assert.NoError(err)
+	assert.Nil(owner)
+	assert.Equal("redis-operator-def", storedClaim(t, getRF(), redisfailoverv1.OwnerClaimAnnotation).Holder)
+}
+
+func TestOwnerClaimsConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	ks, getRF := newOwnerClaimsTest(t)
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	older := rfOperator.NewOwnerClaims(ks, "ops/redis-operator", "redis-operator-abc", clock.Now)
+	clock.Advance(time.Hour)
+	younger := rfOperator.NewOwnerClaims(ks, "other/redis-operator", "redis-operator-xyz", clock.Now)
+
+	// The younger operator claims the RF first, the older one takes it over.
+	owner, err := younger.Claim(context.TODO(), getRF())
+	assert.NoError(err)
+	assert.Nil(owner)
+	owner, err = older.Claim(context.TODO(), getRF())
+	assert.NoError(err)
+	assert.Nil(owner)
+	assert.Equal("ops/redis-operator", storedClaim(t, getRF(), redisfailoverv1.OwnerClaimAnnotation).Operator)
+
+	// The younger operator then backs off, it's reported as contending to the older one.
+	clock.Advance(time.Minute)
+	owner, err = younger.Claim(context.TODO(), getRF())
+	assert.NoError(err)
+	if assert.NotNil(owner) {
+		assert.Equal("ops/redis-operator", owner.Operator)
+	}
+	assert.Equal("ops/redis-operator", storedClaim(t, getRF(), redisfailoverv1.OwnerClaimAnnotation).Operator)
+	if contender := older.Contender(getRF()); assert.NotNil(contender) {
+		assert.Equal("other/redis-operator", contender.Operator)
+	}
+	assert.Nil(younger.Contender(getRF()))
+
+	// The contender stops being reported once it's gone.
+	clock.Advance(rfOperator.DefaultOwnerClaimStaleAfter)
+	assert.Nil(older.Contender(getRF()))
+}
+
+func TestOwnerClaimsStaleTakeover(t *testing.T) {
+	assert := assert.New(t)
+
+	ks, getRF := newOwnerClaimsTest(t)
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	older := rfOperator.NewOwnerClaims(ks, "ops/redis-operator", "redis-operator-abc", clock.Now)
+	owner, err := older.Claim(context.TODO(), getRF())
+	assert.NoError(err)
+	assert.Nil(owner)
+
+	clock.Advance(time.Minute)
+	younger := rfOperator.NewOwnerClaims(ks, "other/redis-operator", "redis-operator-xyz", clock.Now)
+	owner, err = younger.Claim(context.TODO(), getRF())
+	assert.NoError(err)
+	assert.NotNil(owner)
+
+	// The older operator stopped renewing its claim, the younger one takes the RF over once it's stale.
+	clock.Advance(rfOperator.DefaultOwnerClaimStaleAfter - 2*time.Minute)
+	owner, err = younger.Claim(context.TODO(), getRF())
+	assert.NoError(err)
+	assert.NotNil(owner)
+
+	clock.Advance(time.Minute)
+	owner, err = younger.Claim(context.TODO(), getRF())
+	assert.NoError(err)
+	assert.Nil(owner)
+	assert.Equal("other/redis-operator", storedClaim(t, getRF(), redisfailoverv1.OwnerClaimAnnotation).Operator)
+}
@@ -0,0 +1,119 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/service/k8s"
+)
+
+const (
+	// generatedPasswordSize is the number of random bytes of the generated passwords.
+	generatedPasswordSize = 32
+	// passwordKey is the key of the password in its secret.
+	passwordKey = "password"
+	// nextPasswordKey holds the password being rotated in the generated secret, so a rotation interrupted
+	// after it set the password on some pods is resumed with the same one.
+	nextPasswordKey = "next-password"
+)
+
+// EnsureGeneratedSecret creates the secret of the password of the RFs with auth.generateSecret, with a
+// random password. An existing secret is kept, its password is only changed by a rotation. The secret is
+// owned by the RF so it's deleted with it.
+func (r *RedisFailoverHandler) EnsureGeneratedSecret(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+	if !rf.Spec.Auth.GenerateSecret {
+		return nil
+	}
+	password, err := k8s.GenerateRandomSecret(generatedPasswordSize)
+	if err != nil {
+		return err
+	}
+	return r.k8sservice.CreateIfNotExistsSecret(ctx, rf.Namespace, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            rf.GeneratedSecretName(),
+			Namespace:       rf.Namespace,
+			Labels:          labels,
+			OwnerReferences: ownerRefs,
+			// The rotation requested before the secret existed is done, its password is new.
+			Annotations: map[string]string{
+				redisfailoverv1.RotatePasswordAnnotation: rf.PasswordRotationRequest(),
+			},
+		},
+		Data: map[string][]byte{
+			passwordKey: []byte(password),
+		},
+	})
+}
+
+// EnsurePasswordRotation rotates the password of the generated secret requested with the rotate-password
+// annotation. The secret records the last request rotated, every request is rotated once. The next
+// password is staged in the secret first, then set on the pods, see RotatePassword, and it replaces the
+// password of the secret last. A rotation failing midway is resumed with the staged password on the next
+// reconcile. The configuration of the pods gets the new password once it's in the secret, and the redis
+// pods are rolled to read it, see PasswordRevisionAnnotation.
+func (r *RedisFailoverHandler) EnsurePasswordRotation(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	request := rf.PasswordRotationRequest()
+	if request == "" {
+		return nil
+	}
+	if !rf.Spec.Auth.GenerateSecret {
+		r.events.Record(ctx, rf, corev1.EventTypeWarning, redisfailoverv1.ReasonPasswordRotationFailed, "the password is only rotated with auth.generateSecret")
+		return nil
+	}
+
+	name := rf.GeneratedSecretName()
+	secret, err := r.k8sservice.GetSecret(ctx, rf.Namespace, name)
+	if errors.IsNotFound(err) {
+		// The secret is created with a new password, see EnsureGeneratedSecret.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if secret.Annotations[redisfailoverv1.RotatePasswordAnnotation] == request {
+		return nil
+	}
+	current := string(secret.Data[passwordKey])
+	if current == "" {
+		return fmt.Errorf("secret %q does not have a password field", name)
+	}
+	next := string(secret.Data[nextPasswordKey])
+	if next == "" {
+		if next, err = k8s.GenerateRandomSecret(generatedPasswordSize); err != nil {
+			return err
+		}
+		staged := secret.DeepCopy()
+		staged.Data[nextPasswordKey] = []byte(next)
+		if err := r.k8sservice.UpdateSecret(ctx, rf.Namespace, staged); err != nil {
+			return fmt.Errorf("staging the next password in the secret %s: %w", name, err)
+		}
+	}
+
+	if err := r.rfHealer.RotatePassword(ctx, rf, current, next); err != nil {
+		return err
+	}
+
+	// The secret is read again, its resource version changed with the staged password.
+	secret, err = r.k8sservice.GetSecret(ctx, rf.Namespace, name)
+	if err != nil {
+		return err
+	}
+	rotated := secret.DeepCopy()
+	rotated.Data[passwordKey] = []byte(next)
+	delete(rotated.Data, nextPasswordKey)
+	if rotated.Annotations == nil {
+		rotated.Annotations = map[string]string{}
+	}
+	rotated.Annotations[redisfailoverv1.RotatePasswordAnnotation] = request
+	if err := r.k8sservice.UpdateSecret(ctx, rf.Namespace, rotated); err != nil {
+		return fmt.Errorf("writing the rotated password in the secret %s: %w", name, err)
+	}
+	r.events.Record(ctx, rf, corev1.EventTypeNormal, redisfailoverv1.ReasonPasswordRotated, fmt.Sprintf("the password of the secret %s is rotated on the redis and sentinel pods", name))
+	r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Infof("password rotation %q done", request)
+	return nil
+}
@@ -0,0 +1,99 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	rfOperator "redis-operator/operator/redisfailover"
+	"redis-operator/service/k8s"
+)
+
+func TestEnsureGeneratedSecret(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rf := generateRF(false, false)
+	rf.Spec.Auth.GenerateSecret = true
+	rf.Annotations = map[string]string{"databases.spotahome.com/rotate-password": "2024-01"}
+	require.NoError(rf.Validate())
+	assert.Equal("rfr-test-auth", rf.Spec.Auth.SecretPath)
+
+	kubecli := kubernetes.NewSimpleClientset()
+	ks := k8s.New(kubecli, nil, nil, nil, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), nil, nil, nil, ks, metrics.Dummy, log.Dummy)
+
+	oRefs := []metav1.OwnerReference{{Name: "test", Kind: "RedisFailover"}}
+	require.NoError(handler.EnsureGeneratedSecret(context.TODO(), rf, map[string]string{"app": "test"}, oRefs))
+	secret, err := ks.GetSecret(context.TODO(), namespace, "rfr-test-auth")
+	require.NoError(err)
+	assert.Regexp(`^[0-9a-f]{64}$`, string(secret.Data["password"]))
+	assert.Equal(oRefs, secret.OwnerReferences)
+	// The rotation requested before the secret existed is not done again.
+	assert.Equal("2024-01", secret.Annotations["databases.spotahome.com/rotate-password"])
+
+	// The password is kept on the next reconciles.
+	require.NoError(handler.EnsureGeneratedSecret(context.TODO(), rf, map[string]string{"app": "test"}, oRefs))
+	kept, err := ks.GetSecret(context.TODO(), namespace, "rfr-test-auth")
+	require.NoError(err)
+	assert.Equal(secret.Data, kept.Data)
+}
+
+func TestEnsurePasswordRotation(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rf := generateRF(false, false)
+	rf.Spec.Auth.GenerateSecret = true
+	require.NoError(rf.Validate())
+
+	kubecli := kubernetes.NewSimpleClientset()
+	ks := k8s.New(kubecli, nil, nil, nil, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	healer := &mRFService.RedisFailoverHeal{}
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), nil, nil, healer, ks, metrics.Dummy, log.Dummy)
+
+	// The request made before the secret is created is done by its creation.
+	rf.Annotations = map[string]string{"databases.spotahome.com/rotate-password": "2023-12"}
+	require.NoError(handler.EnsurePasswordRotation(context.TODO(), rf))
+	require.NoError(handler.EnsureGeneratedSecret(context.TODO(), rf, nil, nil))
+	secret, err := ks.GetSecret(context.TODO(), namespace, "rfr-test-auth")
+	require.NoError(err)
+	first := string(secret.Data["password"])
+	require.NoError(handler.EnsurePasswordRotation(context.TODO(), rf))
+	healer.AssertNotCalled(t, "RotatePassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// A rotation failing on the pods keeps the current password, the next one is staged.
+	rf.Annotations = map[string]string{"databases.spotahome.com/rotate-password": "2024-01"}
+	var next string
+	healer.On("RotatePassword", mock.Anything, rf, first, mock.Anything).Once().Run(func(args mock.Arguments) {
+		next = args.String(3)
+	}).Return(errors.New("the pod rfr-test-2 is not running, the password is rotated once it is"))
+	assert.Error(handler.EnsurePasswordRotation(context.TODO(), rf))
+	secret, err = ks.GetSecret(context.TODO(), namespace, "rfr-test-auth")
+	require.NoError(err)
+	assert.Equal(first, string(secret.Data["password"]))
+	assert.Regexp(`^[0-9a-f]{64}$`, next)
+	assert.Equal(next, string(secret.Data["next-password"]))
+
+	// It's resumed with the staged password, which replaces the current one once set on the pods.
+	healer.On("RotatePassword", mock.Anything, rf, first, next).Once().Return(nil)
+	require.NoError(handler.EnsurePasswordRotation(context.TODO(), rf))
+	secret, err = ks.GetSecret(context.TODO(), namespace, "rfr-test-auth")
+	require.NoError(err)
+	assert.Equal(next, string(secret.Data["password"]))
+	assert.NotContains(secret.Data, "next-password")
+	assert.Equal("2024-01", secret.Annotations["databases.spotahome.com/rotate-password"])
+
+	// Every request is rotated once.
+	require.NoError(handler.EnsurePasswordRotation(context.TODO(), rf))
+	healer.AssertExpectations(t)
+}
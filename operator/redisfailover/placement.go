@@ -0,0 +1,151 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// DefaultRackLabel is the label of the nodes holding their rack when no other is configured.
+const DefaultRackLabel = "topology.kubernetes.io/rack"
+
+// setInstancesPlacement sets the node, the zone and the rack of the instances of the scheduled pods.
+// The pods labeled with the topology of their node, as the PodTopologyLabels admission does, are not
+// looked up on their node.
+func (r *RedisFailoverHandler) setInstancesPlacement(ctx context.Context, rf *redisfailoverv1.RedisFailover, instances []redisfailoverv1.InstanceStatus, pods *corev1.PodList) {
+	if pods == nil {
+		return
+	}
+	byName := map[string]corev1.Pod{}
+	for _, pod := range pods.Items {
+		byName[pod.Name] = pod
+	}
+	for i := range instances {
+		pod, ok := byName[instances[i].Name]
+		if !ok || pod.Spec.NodeName == "" {
+			continue
+		}
+		instances[i].Node = pod.Spec.NodeName
+		instances[i].Zone = pod.Labels[corev1.LabelTopologyZone]
+		if r.config.RackLabel != "" {
+			instances[i].Rack = pod.Labels[r.config.RackLabel]
+		}
+		if instances[i].Zone != "" && (instances[i].Rack != "" || r.config.RackLabel == "") {
+			continue
+		}
+
+		nodeLabels, err := r.k8sservice.GetNodeLabels(ctx, pod.Spec.NodeName)
+		if err != nil {
+			// The failure domains are left unknown, it's not worth failing the status update.
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not get the labels of the node %s: %s", pod.Spec.NodeName, err)
+			continue
+		}
+		if instances[i].Zone == "" {
+			instances[i].Zone = nodeLabels[corev1.LabelTopologyZone]
+		}
+		if r.config.RackLabel != "" && instances[i].Rack == "" {
+			instances[i].Rack = nodeLabels[r.config.RackLabel]
+		}
+	}
+}
+
+// generatePlacementSummary counts the redis instances by zone and by rack. It's nil until a redis pod
+// is scheduled.
+func generatePlacementSummary(instances []redisfailoverv1.InstanceStatus) *redisfailoverv1.PlacementSummary {
+	summary := &redisfailoverv1.PlacementSummary{}
+	scheduled := false
+	for _, instance := range instances {
+		if instance.Role != instanceRoleRedis {
+			continue
+		}
+		if instance.Node != "" {
+			scheduled = true
+		}
+		if instance.Zone == "" && instance.Rack == "" {
+			summary.Unknown++
+			continue
+		}
+		if instance.Zone != "" {
+			if summary.Zones == nil {
+				summary.Zones = map[string]int32{}
+			}
+			summary.Zones[instance.Zone]++
+		}
+		if instance.Rack != "" {
+			if summary.Racks == nil {
+				summary.Racks = map[string]int32{}
+			}
+			summary.Racks[instance.Rack]++
+		}
+	}
+	if !scheduled {
+		return nil
+	}
+	return summary
+}
+
+// setPlacementCondition sets the placement warning condition when the redis pods with a known zone or
+// rack all share a single one while the spec spreads them across it, or removes it otherwise.
+func setPlacementCondition(status *redisfailoverv1.RedisFailoverStatus, rf *redisfailoverv1.RedisFailover, rackLabel string, generation int64) {
+	summary := status.PlacementSummary
+	if summary == nil {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionPlacementWarning)
+		return
+	}
+	spread := getSpreadTopologyKeys(rf.Spec.Redis)
+	domains := []struct {
+		name  string
+		label string
+		pods  map[string]int32
+	}{
+		{name: "zone", label: corev1.LabelTopologyZone, pods: summary.Zones},
+		{name: "rack", label: rackLabel, pods: summary.Racks},
+	}
+	msgs := []string{}
+	for _, domain := range domains {
+		if domain.label == "" || !spread[domain.label] || len(domain.pods) != 1 {
+			continue
+		}
+		for value, pods := range domain.pods {
+			// A single pod is in a single failure domain anyway.
+			if pods > 1 {
+				msgs = append(msgs, fmt.Sprintf("the %d redis pods with a known %s are all in the %s %s, while the spec spreads them across %s", pods, domain.name, domain.name, value, domain.label))
+			}
+		}
+	}
+	if len(msgs) == 0 {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionPlacementWarning)
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionPlacementWarning,
+		Status:             metav1.ConditionTrue,
+		Reason:             redisfailoverv1.ReasonSingleFailureDomain,
+		Message:            strings.Join(msgs, "; "),
+		ObservedGeneration: generation,
+	})
+}
+
+// getSpreadTopologyKeys returns the topology keys the redis pods are spread across, by their topology
+// spread constraints or their pod anti-affinity.
+func getSpreadTopologyKeys(redis redisfailoverv1.RedisSettings) map[string]bool {
+	keys := map[string]bool{}
+	for _, constraint := range redis.TopologySpreadConstraints {
+		keys[constraint.TopologyKey] = true
+	}
+	if redis.Affinity != nil && redis.Affinity.PodAntiAffinity != nil {
+		for _, term := range redis.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			keys[term.TopologyKey] = true
+		}
+		for _, term := range redis.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			keys[term.PodAffinityTerm.TopologyKey] = true
+		}
+	}
+	return keys
+}
@@ -0,0 +1,250 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func generateScheduledPod(name, node string, labels map[string]string) corev1.Pod {
+	pod := generatePodWithContainerStatuses(name)
+	pod.Labels = labels
+	pod.Spec.NodeName = node
+	return pod
+}
+
+func zoneLabels(zone string) map[string]string {
+	return map[string]string{corev1.LabelTopologyZone: zone}
+}
+
+func TestUpdateStatusPlacement(t *testing.T) {
+	zoneSpread := []corev1.TopologySpreadConstraint{
+		{MaxSkew: 1, TopologyKey: corev1.LabelTopologyZone, WhenUnsatisfiable: corev1.ScheduleAnyway},
+	}
+	rackAntiAffinity := &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{Weight: 100, PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: rfOperator.DefaultRackLabel}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		spread          []corev1.TopologySpreadConstraint
+		affinity        *corev1.Affinity
+		redisPods       []corev1.Pod
+		sentinelPods    []corev1.Pod
+		nodes           map[string]map[string]string
+		missingNodes    []string
+		prevWarning     bool
+		expInstances    map[string][3]string
+		expSummary      *redisfailoverv1.PlacementSummary
+		expConditionMsg string
+		expNoCondition  bool
+	}{
+		{
+			name:   "Pods spread across zones should be counted by zone and by rack",
+			spread: zoneSpread,
+			redisPods: []corev1.Pod{
+				generateScheduledPod("rfr-test-0", "node-a1", nil),
+				generateScheduledPod("rfr-test-1", "node-b1", nil),
+				generateScheduledPod("rfr-test-2", "node-a2", nil),
+			},
+			sentinelPods: []corev1.Pod{
+				generateScheduledPod("rfs-test-abc", "node-c1", nil),
+			},
+			nodes: map[string]map[string]string{
+				"node-a1": {corev1.LabelTopologyZone: "a", rfOperator.DefaultRackLabel: "r1"},
+				"node-a2": {corev1.LabelTopologyZone: "a", rfOperator.DefaultRackLabel: "r2"},
+				"node-b1": zoneLabels("b"),
+				"node-c1": {},
+			},
+			expInstances: map[string][3]string{
+				"rfr-test-0":   {"node-a1", "a", "r1"},
+				"rfr-test-1":   {"node-b1", "b", ""},
+				"rfr-test-2":   {"node-a2", "a", "r2"},
+				"rfs-test-abc": {"node-c1", "", ""},
+			},
+			expSummary: &redisfailoverv1.PlacementSummary{
+				Zones: map[string]int32{"a": 2, "b": 1},
+				Racks: map[string]int32{"r1": 1, "r2": 1},
+			},
+			expNoCondition: true,
+		},
+		{
+			name:   "Pods labeled with their topology should not be looked up on their node",
+			spread: zoneSpread,
+			redisPods: []corev1.Pod{
+				generateScheduledPod("rfr-test-0", "node-a1", map[string]string{corev1.LabelTopologyZone: "a", rfOperator.DefaultRackLabel: "r1"}),
+				generateScheduledPod("rfr-test-1", "node-b1", map[string]string{corev1.LabelTopologyZone: "b", rfOperator.DefaultRackLabel: "r2"}),
+			},
+			expInstances: map[string][3]string{
+				"rfr-test-0": {"node-a1", "a", "r1"},
+				"rfr-test-1": {"node-b1", "b", "r2"},
+			},
+			expSummary: &redisfailoverv1.PlacementSummary{
+				Zones: map[string]int32{"a": 1, "b": 1},
+				Racks: map[string]int32{"r1": 1, "r2": 1},
+			},
+			expNoCondition: true,
+		},
+		{
+			name:   "Pods on nodes missing the labels or not found should be unknown",
+			spread: zoneSpread,
+			redisPods: []corev1.Pod{
+				generateScheduledPod("rfr-test-0", "node-a1", nil),
+				generateScheduledPod("rfr-test-1", "node-x1", nil),
+				generateScheduledPod("rfr-test-2", "node-gone", nil),
+				generatePodWithContainerStatuses("rfr-test-3"),
+			},
+			nodes: map[string]map[string]string{
+				"node-a1": zoneLabels("a"),
+				"node-x1": {},
+			},
+			missingNodes: []string{"node-gone"},
+			expInstances: map[string][3]string{
+				"rfr-test-0": {"node-a1", "a", ""},
+				"rfr-test-1": {"node-x1", "", ""},
+				"rfr-test-2": {"node-gone", "", ""},
+				"rfr-test-3": {"", "", ""},
+			},
+			expSummary: &redisfailoverv1.PlacementSummary{
+				Zones:   map[string]int32{"a": 1},
+				Unknown: 3,
+			},
+			expNoCondition: true,
+		},
+		{
+			name:   "Pods in a single zone while the spec spreads them should set the warning",
+			spread: zoneSpread,
+			redisPods: []corev1.Pod{
+				generateScheduledPod("rfr-test-0", "node-a1", nil),
+				generateScheduledPod("rfr-test-1", "node-a2", nil),
+				generateScheduledPod("rfr-test-2", "node-x1", nil),
+			},
+			nodes: map[string]map[string]string{
+				"node-a1": zoneLabels("a"),
+				"node-a2": zoneLabels("a"),
+				"node-x1": {},
+			},
+			expSummary: &redisfailoverv1.PlacementSummary{
+				Zones:   map[string]int32{"a": 2},
+				Unknown: 1,
+			},
+			expConditionMsg: "the 2 redis pods with a known zone are all in the zone a, while the spec spreads them across topology.kubernetes.io/zone",
+		},
+		{
+			name:     "Pods in a single rack while the anti-affinity spreads them should set the warning",
+			affinity: rackAntiAffinity,
+			redisPods: []corev1.Pod{
+				generateScheduledPod("rfr-test-0", "node-a1", nil),
+				generateScheduledPod("rfr-test-1", "node-b1", nil),
+			},
+			nodes: map[string]map[string]string{
+				"node-a1": {corev1.LabelTopologyZone: "a", rfOperator.DefaultRackLabel: "r1"},
+				"node-b1": {corev1.LabelTopologyZone: "b", rfOperator.DefaultRackLabel: "r1"},
+			},
+			expSummary: &redisfailoverv1.PlacementSummary{
+				Zones: map[string]int32{"a": 1, "b": 1},
+				Racks: map[string]int32{"r1": 2},
+			},
+			expConditionMsg: "the 2 redis pods with a known rack are all in the rack r1, while the spec spreads them across topology.kubernetes.io/rack",
+		},
+		{
+			name: "Pods in a single zone without spreading should not set the warning",
+			redisPods: []corev1.Pod{
+				generateScheduledPod("rfr-test-0", "node-a1", nil),
+				generateScheduledPod("rfr-test-1", "node-a2", nil),
+			},
+			nodes: map[string]map[string]string{
+				"node-a1": zoneLabels("a"),
+				"node-a2": zoneLabels("a"),
+			},
+			prevWarning: true,
+			expSummary: &redisfailoverv1.PlacementSummary{
+				Zones: map[string]int32{"a": 2},
+			},
+			expNoCondition: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Spec.Redis.TopologySpreadConstraints = test.spread
+			rf.Spec.Redis.Affinity = test.affinity
+			if test.prevWarning {
+				meta.SetStatusCondition(&rf.Status.Conditions, metav1.Condition{
+					Type:    redisfailoverv1.ConditionPlacementWarning,
+					Status:  metav1.ConditionTrue,
+					Reason:  redisfailoverv1.ReasonSingleFailureDomain,
+					Message: "previous",
+				})
+			}
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: test.redisPods}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{Items: test.sentinelPods}, nil)
+			for node, labels := range test.nodes {
+				mk.On("GetNodeLabels", mock.Anything, node).Return(labels, nil)
+			}
+			for _, node := range test.missingNodes {
+				mk.On("GetNodeLabels", mock.Anything, node).Return(nil, errors.New("not found"))
+			}
+			mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+				for _, instance := range got.Status.Instances {
+					if exp, ok := test.expInstances[instance.Name]; ok {
+						if !assert.Equal(exp, [3]string{instance.Node, instance.Zone, instance.Rack}, instance.Name) {
+							return false
+						}
+					}
+				}
+				condition := meta.FindStatusCondition(got.Status.Conditions, redisfailoverv1.ConditionPlacementWarning)
+				if test.expNoCondition {
+					return assert.Equal(test.expSummary, got.Status.PlacementSummary) && assert.Nil(condition)
+				}
+				return assert.Equal(test.expSummary, got.Status.PlacementSummary) &&
+					assert.NotNil(condition) &&
+					assert.Equal(metav1.ConditionTrue, condition.Status) &&
+					assert.Equal(redisfailoverv1.ReasonSingleFailureDomain, condition.Reason) &&
+					assert.Equal(test.expConditionMsg, condition.Message)
+			})).Once().Return(rf, nil)
+
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+			config := generateConfig()
+			config.RackLabel = rfOperator.DefaultRackLabel
+			handler := rfOperator.NewRedisFailoverHandler(config, &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.UpdateStatus(context.TODO(), rf)
+
+			assert.NoError(err)
+			mk.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package redisfailover
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// PodDisruptionBudgetSkips keeps the RFs whose PodDisruptionBudgets were not written on the last ensure
+// because the cluster serves no PodDisruptionBudget API, so it's reported on the status.
+type PodDisruptionBudgetSkips struct {
+	mu      sync.Mutex
+	skipped map[string]bool
+}
+
+// NewPodDisruptionBudgetSkips returns new PodDisruptionBudget skips.
+func NewPodDisruptionBudgetSkips() *PodDisruptionBudgetSkips {
+	return &PodDisruptionBudgetSkips{
+		skipped: map[string]bool{},
+	}
+}
+
+// Set records whether the PodDisruptionBudgets were skipped on the last ensure.
+func (s *PodDisruptionBudgetSkips) Set(key string, skipped bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !skipped {
+		delete(s.skipped, key)
+		return
+	}
+	s.skipped[key] = true
+}
+
+// Skipped returns true when the PodDisruptionBudgets were skipped on the last ensure.
+func (s *PodDisruptionBudgetSkips) Skipped(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.skipped[key]
+}
+
+// setPodDisruptionBudgetCondition sets the PodDisruptionBudget warning condition while the
+// PodDisruptionBudgets are not written, or removes it when they are.
+func setPodDisruptionBudgetCondition(status *redisfailoverv1.RedisFailoverStatus, skipped bool, generation int64) {
+	if !skipped {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionPodDisruptionBudgetWarning)
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionPodDisruptionBudgetWarning,
+		Status:             metav1.ConditionTrue,
+		Reason:             redisfailoverv1.ReasonPodDisruptionBudgetsUnavailable,
+		Message:            "the cluster serves neither the policy/v1 nor the policy/v1beta1 PodDisruptionBudgets, the redis and sentinel pods are not protected from voluntary disruptions",
+		ObservedGeneration: generation,
+	})
+}
@@ -0,0 +1,39 @@
+package redisfailover
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// DefaultLatencyPressureThreshold is the default mean round-trip of the PING to a redis pod above which
+// the RF is under pressure.
+const DefaultLatencyPressureThreshold = 100 * time.Millisecond
+
+// setPressureCondition sets the pressure condition listing the redis pods whose round-trip stays above
+// the threshold over the last checks, or removes it when there are none. A disabled threshold removes it.
+func setPressureCondition(status *redisfailoverv1.RedisFailoverStatus, latencies []rfservice.RedisLatency, threshold time.Duration, generation int64) {
+	slow := []string{}
+	for _, l := range latencies {
+		if l.Sustained(threshold) {
+			slow = append(slow, fmt.Sprintf("%s (%s, jitter %s)", l.Pod, l.Mean.Round(time.Millisecond), l.Jitter.Round(time.Millisecond)))
+		}
+	}
+	if len(slow) == 0 {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionPressure)
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionPressure,
+		Status:             metav1.ConditionTrue,
+		Reason:             redisfailoverv1.ReasonHighLatency,
+		Message:            fmt.Sprintf("the round-trip of the PING sent by the operator stays above %s on the redis pods %s", threshold, strings.Join(slow, ", ")),
+		ObservedGeneration: generation,
+	})
+}
@@ -0,0 +1,42 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// priorityClassNotFoundReason is the reason of the event warning about a priority class of a RF that
+// doesn't exist.
+const priorityClassNotFoundReason = "PriorityClassNotFound"
+
+// CheckPriorityClasses warns with an event when the priority class of the redis or the sentinel pods
+// doesn't exist, kubernetes refuses to create their pods until it does. The RF is still reconciled, the
+// class can be created afterwards. The classes that can't be read, as without the permission to, are
+// only logged.
+func (r *RedisFailoverHandler) CheckPriorityClasses(ctx context.Context, rf *redisfailoverv1.RedisFailover) {
+	// The priority classes by pods.
+	classes := [][2]string{{"redis", rf.Spec.Redis.PriorityClassName}}
+	if rf.SentinelsAllowed() {
+		classes = append(classes, [2]string{"sentinel", rf.Spec.Sentinel.PriorityClassName})
+	}
+
+	for _, class := range classes {
+		pods, name := class[0], class[1]
+		if name == "" {
+			continue
+		}
+		_, err := r.k8sservice.GetPriorityClass(ctx, name)
+		switch {
+		case err == nil:
+		case errors.IsNotFound(err):
+			r.events.Record(ctx, rf, corev1.EventTypeWarning, priorityClassNotFoundReason, fmt.Sprintf("the priority class %s of the %s pods doesn't exist, the pods are not created until it does", name, pods))
+		default:
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Debugf("could not check the priority class %s of the %s pods: %s", name, pods, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+func TestCheckPriorityClasses(t *testing.T) {
+	notFound := kubeerrors.NewNotFound(schema.GroupResource{Group: "scheduling.k8s.io", Resource: "priorityclasses"}, "")
+
+	tests := []struct {
+		name         string
+		redis        string
+		sentinel     string
+		classes      map[string]error
+		expWarnings  []string
+		expNotCalled bool
+	}{
+		{
+			name:         "No priority class is not checked",
+			expNotCalled: true,
+		},
+		{
+			name:     "The existing priority classes are not warned about",
+			redis:    "redis-critical",
+			sentinel: "sentinel-critical",
+			classes:  map[string]error{"redis-critical": nil, "sentinel-critical": nil},
+		},
+		{
+			name:     "A missing priority class is warned about",
+			redis:    "redis-critical",
+			sentinel: "sentinel-critical",
+			classes:  map[string]error{"redis-critical": notFound, "sentinel-critical": nil},
+			expWarnings: []string{
+				"the priority class redis-critical of the redis pods doesn't exist, the pods are not created until it does",
+			},
+		},
+		{
+			name:    "A priority class that can't be read is not warned about",
+			redis:   "redis-critical",
+			classes: map[string]error{"redis-critical": errors.New("forbidden")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rf := generateRF(false, false)
+			rf.Spec.Redis.PriorityClassName = test.redis
+			rf.Spec.Sentinel.PriorityClassName = test.sentinel
+
+			mk := &mK8SService.Services{}
+			for name, err := range test.classes {
+				if err != nil {
+					mk.On("GetPriorityClass", mock.Anything, name).Once().Return(nil, err)
+				} else {
+					mk.On("GetPriorityClass", mock.Anything, name).Once().Return(&schedulingv1.PriorityClass{}, nil)
+				}
+			}
+			for _, message := range test.expWarnings {
+				message := message
+				mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+					return e.Reason == "PriorityClassNotFound" && e.Type == corev1.EventTypeWarning && e.Message == message
+				})).Once().Return(nil)
+			}
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.Dummy, log.Dummy)
+			handler.CheckPriorityClasses(context.TODO(), rf)
+
+			mk.AssertExpectations(t)
+			if test.expNotCalled {
+				mk.AssertNotCalled(t, "GetPriorityClass", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
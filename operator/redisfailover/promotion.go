@@ -0,0 +1,112 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// promotionHold is why the promotion of a new master is held, the reason of the condition and its message.
+type promotionHold struct {
+	reason  string
+	message string
+}
+
+// PromotionHolds keeps, for every RF, why the promotion of a new master is held, so it's reported on
+// the status until the master is back or it's promoted.
+type PromotionHolds struct {
+	mu    sync.Mutex
+	holds map[string]promotionHold
+}
+
+// NewPromotionHolds returns new promotion holds.
+func NewPromotionHolds() *PromotionHolds {
+	return &PromotionHolds{
+		holds: map[string]promotionHold{},
+	}
+}
+
+// Hold records the promotion of a new master is held for the reason, explained by the message.
+func (h *PromotionHolds) Hold(key, reason, message string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.holds[key] = promotionHold{reason: reason, message: message}
+}
+
+// Release forgets the promotion held.
+func (h *PromotionHolds) Release(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.holds, key)
+}
+
+// Held returns why the promotion of a new master is held and its message, false when it's not.
+func (h *PromotionHolds) Held(key string) (string, string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hold, ok := h.holds[key]
+	return hold.reason, hold.message, ok
+}
+
+// confirmMasterDown corroborates the master is down before the operator promotes a new one, so a
+// network issue of the operator doesn't fail over a working master. The promotion is held otherwise.
+func (r *RedisFailoverHandler) confirmMasterDown(ctx context.Context, rf *redisfailoverv1.RedisFailover) (bool, error) {
+	key := rfKey(rf)
+	confirmed, reason, err := r.rfChecker.CorroborateMasterDown(ctx, r.stabilizer.LastMaster(key), rf)
+	if err != nil {
+		return false, err
+	}
+	logger := r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name)
+	if !confirmed {
+		logger.Warnf("Holding the promotion of a new master, the master is not confirmed down: %s", reason)
+		r.promotionHolds.Hold(key, redisfailoverv1.ReasonMasterDownNotCorroborated, reason)
+		return false, nil
+	}
+	logger.Infof("Promoting a new master, the master is confirmed down: %s", reason)
+	r.promotionHolds.Release(key)
+	return true, nil
+}
+
+// waitForRollout holds the promotion of a new master while the redis statefulset rolls out, so the pod
+// promoted is not replaced by the rollout right after. The redis pods without a master are not ready, so
+// the promotion is not held when none of them is: they are waiting for it.
+func (r *RedisFailoverHandler) waitForRollout(ctx context.Context, rf *redisfailoverv1.RedisFailover) (bool, error) {
+	name := rfservice.GetRedisStatefulSetName(rf)
+	complete, err := r.k8sservice.GetStatefulSetRolloutStatus(ctx, rf.Namespace, name)
+	if err != nil || complete {
+		return false, err
+	}
+	ss, err := r.k8sservice.GetStatefulSet(ctx, rf.Namespace, name)
+	if err != nil {
+		return false, err
+	}
+	if ss.Status.ReadyReplicas == 0 {
+		return false, nil
+	}
+	message := fmt.Sprintf("the statefulset %s is rolling out, %d ready and %d updated of %d pods", name, ss.Status.ReadyReplicas, ss.Status.UpdatedReplicas, rf.Spec.Redis.Replicas)
+	r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("Holding the promotion of a new master: %s", message)
+	r.promotionHolds.Hold(rfKey(rf), redisfailoverv1.ReasonRolloutInProgress, message)
+	return true, nil
+}
+
+// setPromotionHeldCondition sets the promotion held condition while the master is not confirmed down or
+// the redis statefulset rolls out, or removes it when no promotion is held.
+func setPromotionHeldCondition(status *redisfailoverv1.RedisFailoverStatus, reason, message string, held bool, generation int64) {
+	if !held {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionPromotionHeld)
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionPromotionHeld,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            fmt.Sprintf("no master is answering but a new one is not promoted, %s", message),
+		ObservedGeneration: generation,
+	})
+}
@@ -0,0 +1,162 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestCheckAndHealHoldsUnconfirmedPromotion(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	// The promoted master holds the updates of the redis pods, so they are not checked.
+	seconds := int32(60)
+	rf.Spec.Failover.StabilizationSeconds = &seconds
+	replica := "0.0.0.1"
+	reason := "only 1 of the 3 reachable sentinels see the master down"
+
+	mk := &mK8SService.Services{}
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfc.On("CheckRedisNumber", mock.Anything, rf).Twice().Return(nil)
+	mrfc.On("CheckSentinelNumber", mock.Anything, rf).Twice().Return(nil)
+	mrfc.On("GetNumberMasters", mock.Anything, rf).Twice().Return(0, nil)
+	mrfc.On("GetRedisesIPs", mock.Anything, rf).Twice().Return([]string{replica}, nil)
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+
+	// The master is not confirmed down, the promotion is held and reported.
+	mrfc.On("CorroborateMasterDown", mock.Anything, "", rf).Once().Return(false, reason, nil)
+	assert.NoError(handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{}))
+	mrfh.AssertNotCalled(t, "MakeMaster", mock.Anything, mock.Anything, mock.Anything)
+
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{}, nil)
+	mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+	mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+	mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+	mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+	mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+	mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+	mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+		condition := meta.FindStatusCondition(got.Status.Conditions, redisfailoverv1.ConditionPromotionHeld)
+		return assert.NotNil(condition) &&
+			assert.Equal(redisfailoverv1.ReasonMasterDownNotCorroborated, condition.Reason) &&
+			assert.Contains(condition.Message, reason) &&
+			assert.Equal(redisfailoverv1.HealthPhaseDegraded, got.Status.Health.Phase)
+	})).Once().Return(rf, nil)
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+
+	// The master is confirmed down on the next check, the replica is promoted.
+	mrfc.On("CorroborateMasterDown", mock.Anything, "", rf).Once().Return(true, "3 of the 3 reachable sentinels see the master down", nil)
+	mk.On("GetStatefulSetRolloutStatus", mock.Anything, namespace, "rfr-test").Once().Return(true, nil)
+	mrfh.On("MakeMaster", mock.Anything, replica, rf).Once().Return(nil)
+	mk.On("CreateEvent", mock.Anything, namespace, eventReason("MasterFailover")).Once().Return(nil)
+	mrfc.On("GetMasterIP", mock.Anything, rf).Return(replica, nil)
+	mrfc.On("CheckAllSlavesFromMaster", mock.Anything, replica, rf).Once().Return(nil)
+	mrfh.On("ClearSyncSlotQueue", rf).Once()
+	mrfh.On("PlanRoleLabels", mock.Anything, replica, rf).Once().Return([]rfservice.PodAction{}, nil)
+	mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{}, nil)
+	mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
+	assert.NoError(handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{}))
+
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+	mk.AssertExpectations(t)
+}
+
+func TestCheckAndHealHoldsPromotionDuringRollout(t *testing.T) {
+	tests := []struct {
+		name        string
+		readyPods   int32
+		expPromoted bool
+	}{
+		{
+			name:      "A rollout with ready pods should hold the promotion",
+			readyPods: 2,
+		},
+		{
+			name:        "A rollout without ready pods should not hold the promotion they wait for",
+			readyPods:   0,
+			expPromoted: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			// The promoted master holds the updates of the redis pods, so they are not checked.
+			seconds := int32(60)
+			rf.Spec.Failover.StabilizationSeconds = &seconds
+			replica := "0.0.0.1"
+
+			mk := &mK8SService.Services{}
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfc.On("CheckRedisNumber", mock.Anything, rf).Once().Return(nil)
+			mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+			mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(0, nil)
+			mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{replica}, nil)
+			mrfc.On("CorroborateMasterDown", mock.Anything, "", rf).Once().Return(true, "3 of the 3 reachable sentinels see the master down", nil)
+			mk.On("GetStatefulSetRolloutStatus", mock.Anything, namespace, "rfr-test").Once().Return(false, nil)
+			ss := &appsv1.StatefulSet{}
+			ss.Status.ReadyReplicas = test.readyPods
+			ss.Status.UpdatedReplicas = 1
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(ss, nil)
+			if test.expPromoted {
+				mrfh.On("MakeMaster", mock.Anything, replica, rf).Once().Return(nil)
+				mk.On("CreateEvent", mock.Anything, namespace, eventReason("MasterFailover")).Once().Return(nil)
+				mrfc.On("GetMasterIP", mock.Anything, rf).Return(replica, nil)
+				mrfc.On("CheckAllSlavesFromMaster", mock.Anything, replica, rf).Once().Return(nil)
+				mrfh.On("ClearSyncSlotQueue", rf).Once()
+				mrfh.On("PlanRoleLabels", mock.Anything, replica, rf).Once().Return([]rfservice.PodAction{}, nil)
+				mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{}, nil)
+				mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
+			}
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			assert.NoError(handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{}))
+
+			mrfc.AssertExpectations(t)
+			mrfh.AssertExpectations(t)
+			mk.AssertExpectations(t)
+			if test.expPromoted {
+				return
+			}
+			mrfh.AssertNotCalled(t, "MakeMaster", mock.Anything, mock.Anything, mock.Anything)
+
+			// The promotion held is reported with the progress of the rollout.
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+			mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+				condition := meta.FindStatusCondition(got.Status.Conditions, redisfailoverv1.ConditionPromotionHeld)
+				return assert.NotNil(condition) &&
+					assert.Equal(redisfailoverv1.ReasonRolloutInProgress, condition.Reason) &&
+					assert.Equal("no master is answering but a new one is not promoted, the statefulset rfr-test is rolling out, 2 ready and 1 updated of 3 pods", condition.Message)
+			})).Once().Return(rf, nil)
+			assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+			mk.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package redisfailover
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/spotahome/kooper/v2/controller"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// DefaultReconcileCoalesceWindow is how long the events of an RF are merged before it's reconciled.
+const DefaultReconcileCoalesceWindow = 500 * time.Millisecond
+
+// The reasons of the events coalesced, reported in the metrics.
+const (
+	coalescedDebounced  = "debounced"
+	coalescedStatusOnly = "status-only"
+)
+
+// bookkeepingAnnotations are the annotations the operator writes on the RFs on every reconcile, their
+// changes alone don't need another reconcile.
+var bookkeepingAnnotations = []string{
+	redisfailoverv1.CheckerStateAnnotation,
+	redisfailoverv1.OwnerClaimAnnotation,
+}
+
+// coalescedReconcile is the latest object of an RF waiting for the end of its window.
+type coalescedReconcile struct {
+	obj runtime.Object
+}
+
+// ReconcileCoalescer is layered on the handler run by the workers of the controller. It merges the
+// bursts of events of an RF, as the status updates of the operator itself or the edits of a user
+// applying several changes, into one reconcile of its latest object at the end of a window.
+//
+// The first event of an RF opens its window, the events received until it closes only replace the
+// object. An RF is never reconciled twice at once: the window of the events received while it's
+// being reconciled opens when the reconcile ends.
+//
+// The updates of an RF only changing its status or the bookkeeping annotations of the operator,
+// written by the operator, are skipped: its generation, labels and annotations are the ones of the
+// object last reconciled. The resyncs carry the resource version last seen and are reconciled.
+type ReconcileCoalescer struct {
+	handler controller.Handler
+	window  time.Duration
+	mClient metrics.Recorder
+	logger  log.Logger
+
+	mu sync.Mutex
+	// pending are the RFs waiting for the end of their window, and running the ones being reconciled.
+	pending map[string]*coalescedReconcile
+	running map[string]bool
+	// seen is the metadata of the latest object of every RF reconciled or skipped.
+	seen map[string]metav1.Object
+}
+
+// NewReconcileCoalescer returns a coalescer merging the events of every RF received within the window
+// into one reconcile with the handler.
+func NewReconcileCoalescer(handler controller.Handler, window time.Duration, mClient metrics.Recorder, logger log.Logger) *ReconcileCoalescer {
+	return &ReconcileCoalescer{
+		handler: handler,
+		window:  window,
+		mClient: mClient,
+		logger:  logger,
+		pending: map[string]*coalescedReconcile{},
+		running: map[string]bool{},
+		seen:    map[string]metav1.Object{},
+	}
+}
+
+// Handle satisfies controller.Handler interface.
+func (c *ReconcileCoalescer) Handle(ctx context.Context, obj runtime.Object) error {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return c.handler.Handle(ctx, obj)
+	}
+	namespace := m.GetNamespace()
+	key := namespace + "/" + m.GetName()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.statusOnly(key, m) {
+		c.seen[key] = m
+		c.mClient.RecordReconcileCoalesced(namespace, coalescedStatusOnly)
+		return nil
+	}
+	if pending, ok := c.pending[key]; ok {
+		pending.obj = obj
+		c.mClient.RecordReconcileCoalesced(namespace, coalescedDebounced)
+		return nil
+	}
+	c.pending[key] = &coalescedReconcile{obj: obj}
+	if !c.running[key] {
+		c.schedule(ctx, key)
+	}
+	return nil
+}
+
+// statusOnly returns true when the object only differs from the one last seen of the RF by its status
+// or the bookkeeping annotations. The resyncs, with the same resource version, are not.
+func (c *ReconcileCoalescer) statusOnly(key string, m metav1.Object) bool {
+	seen, ok := c.seen[key]
+	if !ok || seen.GetResourceVersion() == m.GetResourceVersion() {
+		return false
+	}
+	return seen.GetGeneration() == m.GetGeneration() &&
+		seen.GetDeletionTimestamp().Equal(m.GetDeletionTimestamp()) &&
+		reflect.DeepEqual(seen.GetLabels(), m.GetLabels()) &&
+		reflect.DeepEqual(withoutBookkeeping(seen.GetAnnotations()), withoutBookkeeping(m.GetAnnotations())) &&
+		reflect.DeepEqual(seen.GetFinalizers(), m.GetFinalizers())
+}
+
+// withoutBookkeeping returns the annotations without the bookkeeping annotations of the operator.
+func withoutBookkeeping(annotations map[string]string) map[string]string {
+	filtered := map[string]string{}
+	for k, v := range annotations {
+		filtered[k] = v
+	}
+	for _, annotation := range bookkeepingAnnotations {
+		delete(filtered, annotation)
+	}
+	return filtered
+}
+
+// schedule reconciles the latest object of the RF at the end of its window.
+func (c *ReconcileCoalescer) schedule(ctx context.Context, key string) {
+	time.AfterFunc(c.window, func() {
+		c.mu.Lock()
+		pending := c.pending[key]
+		delete(c.pending, key)
+		c.running[key] = true
+		m, _ := meta.Accessor(pending.obj)
+		c.seen[key] = m
+		c.mu.Unlock()
+
+		if err := c.handler.Handle(ctx, pending.obj); err != nil {
+			c.logger.WithField("redisfailover", key).Errorf("error on the coalesced reconcile: %s", err)
+		}
+
+		c.mu.Lock()
+		delete(c.running, key)
+		if _, ok := c.pending[key]; ok {
+			c.schedule(ctx, key)
+		}
+		c.mu.Unlock()
+	})
+}
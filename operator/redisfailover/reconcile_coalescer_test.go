@@ -0,0 +1,131 @@
+package redisfailover_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+const testCoalesceWindow = 50 * time.Millisecond
+
+// coalescedRecorder counts the events coalesced by reason.
+type coalescedRecorder struct {
+	metrics.Recorder
+	mu        sync.Mutex
+	coalesced map[string]int
+}
+
+func newCoalescedRecorder() *coalescedRecorder {
+	return &coalescedRecorder{Recorder: metrics.Dummy, coalesced: map[string]int{}}
+}
+
+func (r *coalescedRecorder) RecordReconcileCoalesced(namespace string, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.coalesced[reason]++
+}
+
+func (r *coalescedRecorder) Coalesced(reason string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.coalesced[reason]
+}
+
+func coalescerRF(resourceVersion string, generation int64) *redisfailoverv1.RedisFailover {
+	rf := limiterRF("ns", "rf", resourceVersion)
+	rf.Generation = generation
+	return rf
+}
+
+// waitStarted waits for the reconciles of the handler, and a few windows more to catch any other one.
+func waitStarted(t *testing.T, h *blockingHandler, count int) []string {
+	assert.Eventually(t, func() bool { return len(h.Started()) >= count }, time.Second, 5*time.Millisecond)
+	time.Sleep(3 * testCoalesceWindow)
+	return h.Started()
+}
+
+func TestReconcileCoalescerBurst(t *testing.T) {
+	assert := assert.New(t)
+
+	h := newBlockingHandler()
+	rec := newCoalescedRecorder()
+	c := rfOperator.NewReconcileCoalescer(h, testCoalesceWindow, rec, log.Dummy)
+
+	// A burst of events is reconciled once, with the latest object, after the window.
+	for i := 1; i <= 20; i++ {
+		assert.NoError(c.Handle(context.TODO(), coalescerRF(strconv.Itoa(i), int64(i))))
+	}
+	assert.Empty(h.Started())
+
+	assert.Equal([]string{"ns/rf@20"}, waitStarted(t, h, 1))
+	assert.Equal(19, rec.Coalesced("debounced"))
+}
+
+func TestReconcileCoalescerStatusOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	h := newBlockingHandler()
+	rec := newCoalescedRecorder()
+	c := rfOperator.NewReconcileCoalescer(h, testCoalesceWindow, rec, log.Dummy)
+
+	rf := coalescerRF("1", 1)
+	rf.Annotations = map[string]string{"team": "a"}
+	assert.NoError(c.Handle(context.TODO(), rf))
+	assert.Equal([]string{"ns/rf@1"}, waitStarted(t, h, 1))
+
+	// The status update and the checker state written by the operator are skipped.
+	statusUpdate := rf.DeepCopy()
+	statusUpdate.ResourceVersion = "2"
+	statusUpdate.Status.Restarts = 1
+	statusUpdate.Annotations[redisfailoverv1.CheckerStateAnnotation] = "{}"
+	assert.NoError(c.Handle(context.TODO(), statusUpdate))
+	assert.Equal([]string{"ns/rf@1"}, waitStarted(t, h, 1))
+	assert.Equal(1, rec.Coalesced("status-only"))
+
+	// The resync of the same object is reconciled.
+	assert.NoError(c.Handle(context.TODO(), statusUpdate.DeepCopy()))
+	assert.Equal([]string{"ns/rf@1", "ns/rf@2"}, waitStarted(t, h, 2))
+
+	// So are the changes of the annotations and of the spec.
+	annotated := statusUpdate.DeepCopy()
+	annotated.ResourceVersion = "3"
+	annotated.Annotations[redisfailoverv1.SupportBundleAnnotation] = "now"
+	assert.NoError(c.Handle(context.TODO(), annotated))
+	assert.Equal([]string{"ns/rf@1", "ns/rf@2", "ns/rf@3"}, waitStarted(t, h, 3))
+
+	specChange := annotated.DeepCopy()
+	specChange.ResourceVersion = "4"
+	specChange.Generation = 2
+	assert.NoError(c.Handle(context.TODO(), specChange))
+	assert.Equal([]string{"ns/rf@1", "ns/rf@2", "ns/rf@3", "ns/rf@4"}, waitStarted(t, h, 4))
+	assert.Equal(1, rec.Coalesced("status-only"))
+}
+
+func TestReconcileCoalescerRunning(t *testing.T) {
+	assert := assert.New(t)
+
+	h := newBlockingHandler("ns/rf")
+	c := rfOperator.NewReconcileCoalescer(h, testCoalesceWindow, metrics.Dummy, log.Dummy)
+
+	assert.NoError(c.Handle(context.TODO(), coalescerRF("1", 1)))
+	assert.Eventually(func() bool { return len(h.Started()) == 1 }, time.Second, 5*time.Millisecond)
+
+	// The events received while the RF is reconciled wait for the end of the reconcile.
+	assert.NoError(c.Handle(context.TODO(), coalescerRF("2", 2)))
+	assert.NoError(c.Handle(context.TODO(), coalescerRF("3", 3)))
+	time.Sleep(3 * testCoalesceWindow)
+	assert.Equal([]string{"ns/rf@1"}, h.Started())
+
+	close(h.gates["ns/rf"])
+	assert.Equal([]string{"ns/rf@1", "ns/rf@3"}, waitStarted(t, h, 2))
+	assert.Equal(1, h.maxRun["ns"])
+}
@@ -0,0 +1,136 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spotahome/kooper/v2/controller"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"redis-operator/log"
+)
+
+// DefaultShutdownGracePeriod is how long the reconciles in flight are waited for when the operator is
+// stopped. It's below the 30 seconds kubernetes gives to the operator pod to exit.
+const DefaultShutdownGracePeriod = 20 * time.Second
+
+// ReconcileDrainer is layered on the handler of the RFs, under the limiter and the coalescer so it sees
+// the reconciles when they actually run. When the operator is stopped, Drain stops starting reconciles
+// and waits for the ones in flight: the multi-step operations they run, as a failover or the rolling
+// update of the redis pods, reach the end of the reconcile where their state is recorded on the RF,
+// instead of being cut between a write and its record.
+//
+// The reconciles not started are not lost: the next leader reconciles every RF when it starts. It only
+// starts once the lease of the leader election, still renewed by the operator draining, has expired.
+type ReconcileDrainer struct {
+	handler controller.Handler
+	logger  log.Logger
+
+	mu       sync.Mutex
+	draining bool
+	// inFlight counts the reconciles running by RF, wg waits for them.
+	inFlight map[string]int
+	wg       sync.WaitGroup
+}
+
+// NewReconcileDrainer returns a drainer running the reconciles with the handler until it's drained.
+func NewReconcileDrainer(handler controller.Handler, logger log.Logger) *ReconcileDrainer {
+	return &ReconcileDrainer{
+		handler:  handler,
+		logger:   logger,
+		inFlight: map[string]int{},
+	}
+}
+
+// Handle satisfies controller.Handler interface.
+func (d *ReconcileDrainer) Handle(ctx context.Context, obj runtime.Object) error {
+	key := "unknown"
+	if m, err := meta.Accessor(obj); err == nil {
+		key = m.GetNamespace() + "/" + m.GetName()
+	}
+
+	d.mu.Lock()
+	if d.draining {
+		d.mu.Unlock()
+		d.logger.WithField("redisfailover", key).Debugf("skipping the reconcile, the operator is stopping")
+		return nil
+	}
+	d.inFlight[key]++
+	d.wg.Add(1)
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		if d.inFlight[key]--; d.inFlight[key] == 0 {
+			delete(d.inFlight, key)
+		}
+		d.mu.Unlock()
+		d.wg.Done()
+	}()
+	return d.handler.Handle(ctx, obj)
+}
+
+// Drain stops starting reconciles and waits for the ones in flight until the context is done, then it
+// returns an error naming the RFs still being reconciled.
+func (d *ReconcileDrainer) Drain(ctx context.Context) error {
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+
+	idle := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	keys := []string{}
+	for key := range d.inFlight {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return fmt.Errorf("the reconciles of %v were still running", keys)
+}
+
+// drainingController drains the reconciles in flight when it's stopped, before stopping the controller.
+type drainingController struct {
+	controller  controller.Controller
+	drainer     *ReconcileDrainer
+	gracePeriod time.Duration
+	logger      log.Logger
+}
+
+// Run satisfies controller.Controller interface. The controller runs until the context is done, then
+// the reconciles in flight are waited for up to the grace period.
+func (c *drainingController) Run(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errC := make(chan error, 1)
+	go func() {
+		errC <- c.controller.Run(runCtx)
+	}()
+
+	select {
+	case err := <-errC:
+		return err
+	case <-ctx.Done():
+	}
+
+	c.logger.Infof("Draining the reconciles in flight, waiting up to %s...", c.gracePeriod)
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), c.gracePeriod)
+	defer cancelDrain()
+	if err := c.drainer.Drain(drainCtx); err != nil {
+		c.logger.Warnf("Stopping without draining: %s", err)
+	}
+	return nil
+}
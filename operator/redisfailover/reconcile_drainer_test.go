@@ -0,0 +1,68 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"redis-operator/log"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+func TestReconcileDrainer(t *testing.T) {
+	assert := assert.New(t)
+
+	h := newBlockingHandler("ns/rf1")
+	d := rfOperator.NewReconcileDrainer(h, log.Dummy)
+
+	done := make(chan struct{})
+	go func() {
+		assert.NoError(d.Handle(context.TODO(), limiterRF("ns", "rf1", "1")))
+		close(done)
+	}()
+	assert.Eventually(func() bool { return len(h.Started()) == 1 }, time.Second, time.Millisecond)
+
+	// The drain waits for the reconcile in flight, the reconciles received meanwhile are skipped.
+	drained := make(chan error)
+	go func() {
+		drained <- d.Drain(context.TODO())
+	}()
+	assert.Eventually(func() bool {
+		assert.NoError(d.Handle(context.TODO(), limiterRF("ns", "rf2", "1")))
+		return len(h.Started()) == 1
+	}, time.Second, time.Millisecond)
+	select {
+	case <-drained:
+		t.Fatal("the drain did not wait for the reconcile in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(h.gates["ns/rf1"])
+	assert.NoError(<-drained)
+	<-done
+	assert.NoError(d.Handle(context.TODO(), limiterRF("ns", "rf2", "2")))
+	assert.Equal([]string{"ns/rf1@1"}, h.Started())
+}
+
+func TestReconcileDrainerTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	h := newBlockingHandler("ns/rf1", "other/rf2")
+	defer close(h.gates["ns/rf1"])
+	defer close(h.gates["other/rf2"])
+	d := rfOperator.NewReconcileDrainer(h, log.Dummy)
+
+	go d.Handle(context.TODO(), limiterRF("ns", "rf1", "1"))
+	go d.Handle(context.TODO(), limiterRF("other", "rf2", "1"))
+	assert.Eventually(func() bool { return len(h.Started()) == 2 }, time.Second, time.Millisecond)
+
+	// The RFs still reconciled when the grace period ends are named.
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	err := d.Drain(ctx)
+	if assert.Error(err) {
+		assert.Equal("the reconciles of [ns/rf1 other/rf2] were still running", err.Error())
+	}
+}
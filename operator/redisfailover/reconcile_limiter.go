@@ -0,0 +1,218 @@
+package redisfailover
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spotahome/kooper/v2/controller"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// pendingReconcile is a reconcile waiting for a slot of its namespace, or for its turn in the warm-up.
+type pendingReconcile struct {
+	key   string
+	obj   runtime.Object
+	since time.Time
+}
+
+// ReconcileLimiter is layered on the handler run by the workers of the controller. It limits the
+// reconciles of the RFs running at once in a namespace, and ramps up the first reconciles of the RFs
+// found when the operator starts.
+//
+// A reconcile over the limit of its namespace doesn't hold a worker: it's queued and the worker moves on
+// to the other namespaces. The queued reconciles of a namespace are run in order by the worker releasing
+// a slot of the namespace, only the latest object of an RF is kept while it waits. The reconciles of an
+// RF already running are queued too, an RF is never reconciled twice at once.
+//
+// During the warm-up the RFs are reconciled oldest first, one every ramp interval since the first
+// reconcile. The reconciles of an RF before its turn are held and run on its turn.
+type ReconcileLimiter struct {
+	handler controller.Handler
+	limit   int
+	now     func() time.Time
+	mClient metrics.Recorder
+	logger  log.Logger
+
+	mu sync.Mutex
+	// running are the reconciles running in every namespace, and queued the ones waiting for a slot.
+	// active are the RFs being reconciled.
+	running map[string]int
+	queued  map[string][]*pendingReconcile
+	active  map[string]bool
+	// warmup is the order of the RFs in the warm-up, their turn is warmupStart plus their position
+	// times ramp. The RFs are removed once they had their turn.
+	warmup      map[string]int
+	ramp        time.Duration
+	warmupStart time.Time
+	held        map[string]*pendingReconcile
+}
+
+// NewReconcileLimiter returns a limiter running at most limit reconciles at once per namespace with the
+// handler, 0 for no limit.
+func NewReconcileLimiter(handler controller.Handler, limit int, now func() time.Time, mClient metrics.Recorder, logger log.Logger) *ReconcileLimiter {
+	return &ReconcileLimiter{
+		handler: handler,
+		limit:   limit,
+		now:     now,
+		mClient: mClient,
+		logger:  logger,
+		running: map[string]int{},
+		queued:  map[string][]*pendingReconcile{},
+		active:  map[string]bool{},
+		warmup:  map[string]int{},
+		held:    map[string]*pendingReconcile{},
+	}
+}
+
+// WarmUp ramps up the first reconciles of the RFs, oldest first, one every ramp interval.
+func (l *ReconcileLimiter) WarmUp(rfs []redisfailoverv1.RedisFailover, ramp time.Duration) {
+	ordered := append([]redisfailoverv1.RedisFailover{}, rfs...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ti, tj := ordered[i].CreationTimestamp, ordered[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return rfKey(&ordered[i]) < rfKey(&ordered[j])
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ramp = ramp
+	l.warmup = map[string]int{}
+	for i := range ordered {
+		l.warmup[rfKey(&ordered[i])] = i
+	}
+}
+
+// Handle satisfies controller.Handler interface.
+func (l *ReconcileLimiter) Handle(ctx context.Context, obj runtime.Object) error {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return l.handler.Handle(ctx, obj)
+	}
+	namespace := m.GetNamespace()
+	key := namespace + "/" + m.GetName()
+
+	l.mu.Lock()
+	if wait := l.warmupWait(key); wait > 0 {
+		l.hold(ctx, key, obj, wait)
+		l.mu.Unlock()
+		return nil
+	}
+	if l.active[key] || (l.limit > 0 && l.running[namespace] >= l.limit) {
+		l.enqueue(namespace, key, obj)
+		l.mu.Unlock()
+		return nil
+	}
+	l.running[namespace]++
+	l.active[key] = true
+	l.mu.Unlock()
+
+	l.mClient.ObserveReconcileQueueWait(namespace, 0)
+	err = l.handler.Handle(ctx, obj)
+	l.release(ctx, namespace, key)
+	return err
+}
+
+// warmupWait returns how long the RF must wait for its turn in the warm-up, 0 when it can be reconciled.
+func (l *ReconcileLimiter) warmupWait(key string) time.Duration {
+	position, ok := l.warmup[key]
+	if !ok {
+		return 0
+	}
+	now := l.now()
+	if l.warmupStart.IsZero() {
+		l.warmupStart = now
+	}
+	wait := l.warmupStart.Add(time.Duration(position) * l.ramp).Sub(now)
+	if wait <= 0 {
+		delete(l.warmup, key)
+		return 0
+	}
+	return wait
+}
+
+// hold keeps the latest object of the RF until its turn in the warm-up, when it's reconciled.
+func (l *ReconcileLimiter) hold(ctx context.Context, key string, obj runtime.Object, wait time.Duration) {
+	if held, ok := l.held[key]; ok {
+		held.obj = obj
+		return
+	}
+	l.held[key] = &pendingReconcile{key: key, obj: obj}
+	time.AfterFunc(wait, func() {
+		l.mu.Lock()
+		held := l.held[key]
+		delete(l.held, key)
+		l.mu.Unlock()
+		if err := l.Handle(ctx, held.obj); err != nil {
+			l.logger.WithField("redisfailover", key).Errorf("error on the warm-up reconcile: %s", err)
+		}
+	})
+}
+
+// enqueue queues the reconcile until a slot of the namespace is free, replacing the object of the RF
+// when it's already queued.
+func (l *ReconcileLimiter) enqueue(namespace, key string, obj runtime.Object) {
+	for _, pending := range l.queued[namespace] {
+		if pending.key == key {
+			pending.obj = obj
+			return
+		}
+	}
+	l.queued[namespace] = append(l.queued[namespace], &pendingReconcile{key: key, obj: obj, since: l.now()})
+}
+
+// release frees the slot of the reconcile of the RF. The reconciles queued meanwhile in the namespace
+// take it in turn, run by the calling worker, skipping the RFs still being reconciled.
+func (l *ReconcileLimiter) release(ctx context.Context, namespace, key string) {
+	for {
+		l.mu.Lock()
+		delete(l.active, key)
+		next := l.dequeue(namespace)
+		if next == nil {
+			if l.running[namespace]--; l.running[namespace] <= 0 {
+				delete(l.running, namespace)
+			}
+			l.mu.Unlock()
+			return
+		}
+		key = next.key
+		l.active[key] = true
+		l.mu.Unlock()
+
+		l.mClient.ObserveReconcileQueueWait(namespace, l.now().Sub(next.since).Seconds())
+		if err := l.handler.Handle(ctx, next.obj); err != nil {
+			l.logger.WithField("redisfailover", key).Errorf("error on the queued reconcile: %s", err)
+		}
+	}
+}
+
+// dequeue removes the first queued reconcile of the namespace whose RF is not being reconciled.
+func (l *ReconcileLimiter) dequeue(namespace string) *pendingReconcile {
+	queued := l.queued[namespace]
+	for i, pending := range queued {
+		if l.active[pending.key] {
+			continue
+		}
+		l.queued[namespace] = append(queued[:i:i], queued[i+1:]...)
+		if len(l.queued[namespace]) == 0 {
+			delete(l.queued, namespace)
+		}
+		return pending
+	}
+	return nil
+}
+
+// Queued returns the number of reconciles waiting for a slot of the namespace.
+func (l *ReconcileLimiter) Queued(namespace string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.queued[namespace])
+}
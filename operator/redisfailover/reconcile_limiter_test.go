@@ -0,0 +1,159 @@
+package redisfailover_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+// blockingHandler records the reconciles, the ones of the RFs with a gate wait until it's opened.
+type blockingHandler struct {
+	mu      sync.Mutex
+	started []string
+	gates   map[string]chan struct{}
+	maxRun  map[string]int
+	active  map[string]int
+}
+
+func newBlockingHandler(gated ...string) *blockingHandler {
+	h := &blockingHandler{gates: map[string]chan struct{}{}, maxRun: map[string]int{}, active: map[string]int{}}
+	for _, key := range gated {
+		h.gates[key] = make(chan struct{})
+	}
+	return h
+}
+
+func (h *blockingHandler) Handle(_ context.Context, obj runtime.Object) error {
+	rf := obj.(*redisfailoverv1.RedisFailover)
+	key := rf.Namespace + "/" + rf.Name
+	h.mu.Lock()
+	h.started = append(h.started, key+"@"+rf.ResourceVersion)
+	h.active[rf.Namespace]++
+	if h.active[rf.Namespace] > h.maxRun[rf.Namespace] {
+		h.maxRun[rf.Namespace] = h.active[rf.Namespace]
+	}
+	gate := h.gates[key]
+	h.mu.Unlock()
+
+	if gate != nil {
+		<-gate
+	}
+
+	h.mu.Lock()
+	h.active[rf.Namespace]--
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *blockingHandler) Started() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string{}, h.started...)
+}
+
+func limiterRF(namespace, name, resourceVersion string) *redisfailoverv1.RedisFailover {
+	return &redisfailoverv1.RedisFailover{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, ResourceVersion: resourceVersion},
+	}
+}
+
+func TestReconcileLimiterFairness(t *testing.T) {
+	assert := assert.New(t)
+
+	h := newBlockingHandler("ns-a/a1")
+	l := rfOperator.NewReconcileLimiter(h, 1, time.Now, metrics.Dummy, log.Dummy)
+
+	// The first reconcile of the namespace a takes its only slot.
+	done := make(chan struct{})
+	go func() {
+		assert.NoError(l.Handle(context.TODO(), limiterRF("ns-a", "a1", "1")))
+		close(done)
+	}()
+	assert.Eventually(func() bool { return len(h.Started()) == 1 }, time.Second, time.Millisecond)
+
+	// The other reconciles of the namespace a are queued without holding the worker, the namespace b
+	// is not held by them.
+	assert.NoError(l.Handle(context.TODO(), limiterRF("ns-a", "a2", "1")))
+	assert.NoError(l.Handle(context.TODO(), limiterRF("ns-a", "a3", "1")))
+	assert.NoError(l.Handle(context.TODO(), limiterRF("ns-a", "a2", "2")))
+	assert.Equal(2, l.Queued("ns-a"))
+	assert.NoError(l.Handle(context.TODO(), limiterRF("ns-b", "b1", "1")))
+	assert.NoError(l.Handle(context.TODO(), limiterRF("ns-b", "b2", "1")))
+	assert.Equal([]string{"ns-a/a1@1", "ns-b/b1@1", "ns-b/b2@1"}, h.Started())
+
+	// Releasing the slot runs the queued reconciles in order, with the latest object of the RFs.
+	close(h.gates["ns-a/a1"])
+	<-done
+	assert.Equal([]string{"ns-a/a1@1", "ns-b/b1@1", "ns-b/b2@1", "ns-a/a2@2", "ns-a/a3@1"}, h.Started())
+	assert.Equal(0, l.Queued("ns-a"))
+	assert.Equal(1, h.maxRun["ns-a"])
+}
+
+func TestReconcileLimiterSameRF(t *testing.T) {
+	assert := assert.New(t)
+
+	// Without a limit the namespaces are not limited, but an RF is not reconciled twice at once.
+	h := newBlockingHandler("ns-a/a1")
+	l := rfOperator.NewReconcileLimiter(h, 0, time.Now, metrics.Dummy, log.Dummy)
+
+	done := make(chan struct{})
+	go func() {
+		assert.NoError(l.Handle(context.TODO(), limiterRF("ns-a", "a1", "1")))
+		close(done)
+	}()
+	assert.Eventually(func() bool { return len(h.Started()) == 1 }, time.Second, time.Millisecond)
+
+	assert.NoError(l.Handle(context.TODO(), limiterRF("ns-a", "a2", "1")))
+	assert.NoError(l.Handle(context.TODO(), limiterRF("ns-a", "a1", "2")))
+	assert.Equal([]string{"ns-a/a1@1", "ns-a/a2@1"}, h.Started())
+	assert.Equal(1, l.Queued("ns-a"))
+
+	// The queued reconcile of the RF is run once the first one is done.
+	close(h.gates["ns-a/a1"])
+	<-done
+	assert.Equal([]string{"ns-a/a1@1", "ns-a/a2@1", "ns-a/a1@2"}, h.Started())
+	assert.Equal(0, l.Queued("ns-a"))
+}
+
+func TestReconcileLimiterWarmUp(t *testing.T) {
+	assert := assert.New(t)
+
+	h := newBlockingHandler()
+	l := rfOperator.NewReconcileLimiter(h, 0, time.Now, metrics.Dummy, log.Dummy)
+
+	created := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	rfs := []redisfailoverv1.RedisFailover{
+		*limiterRF("ns-a", "newest", "1"),
+		*limiterRF("ns-b", "oldest", "1"),
+		*limiterRF("ns-a", "middle", "1"),
+	}
+	rfs[0].CreationTimestamp = metav1.NewTime(created.Add(2 * time.Hour))
+	rfs[1].CreationTimestamp = metav1.NewTime(created)
+	rfs[2].CreationTimestamp = metav1.NewTime(created.Add(time.Hour))
+	l.WarmUp(rfs, 50*time.Millisecond)
+
+	// The RFs are reconciled oldest first whatever the order the controller hands them in, the ones
+	// handed before their turn are held and reconciled on it.
+	for i := range rfs {
+		assert.NoError(l.Handle(context.TODO(), &rfs[i]))
+	}
+	assert.Equal([]string{"ns-b/oldest@1"}, h.Started())
+	assert.Eventually(func() bool { return len(h.Started()) == 3 }, time.Second, time.Millisecond)
+	assert.Equal([]string{"ns-b/oldest@1", "ns-a/middle@1", "ns-a/newest@1"}, h.Started())
+
+	// Once they had their turn, and for the RFs created later, nothing is held.
+	assert.NoError(l.Handle(context.TODO(), limiterRF("ns-a", "newest", "2")))
+	assert.NoError(l.Handle(context.TODO(), limiterRF("ns-c", "new", "1")))
+	assert.Eventually(func() bool { return len(h.Started()) == 5 }, time.Second, time.Millisecond)
+	assert.Equal([]string{"ns-b/oldest@1", "ns-a/middle@1", "ns-a/newest@1", "ns-a/newest@2", "ns-c/new@1"}, h.Started())
+}
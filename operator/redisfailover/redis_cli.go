@@ -0,0 +1,50 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
+)
+
+// RedisCLIRunner returns the runner of redis-cli in the redis container of the redis pod of the
+// operator with the IP. The redis client falls back to it with --redis-exec-fallback.
+func RedisCLIRunner(k8sService k8s.Services) redis.CLIRunner {
+	managed := labels.SelectorFromSet(defaultLabels)
+	return func(ctx context.Context, ip string, args []string) (string, error) {
+		pods, err := k8sService.ListPods(ctx, "")
+		if err != nil {
+			return "", err
+		}
+		for _, pod := range pods.Items {
+			if !hasPodIP(pod.Status, ip) || !managed.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			stdout, stderr, err := k8sService.ExecCommand(ctx, pod.Namespace, pod.Name, rfservice.RedisContainerName, append([]string{"redis-cli"}, args...))
+			if err != nil && stderr != "" {
+				return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+			}
+			return stdout, err
+		}
+		return "", fmt.Errorf("no redis pod with the IP %s", ip)
+	}
+}
+
+// hasPodIP tells the IP is one of the pod, of either family.
+func hasPodIP(status corev1.PodStatus, ip string) bool {
+	if status.PodIP == ip {
+		return true
+	}
+	for _, podIP := range status.PodIPs {
+		if podIP.IP == ip {
+			return true
+		}
+	}
+	return false
+}
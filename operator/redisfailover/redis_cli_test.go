@@ -0,0 +1,42 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+func TestRedisCLIRunner(t *testing.T) {
+	assert := assert.New(t)
+
+	managed := map[string]string{"app.kubernetes.io/managed-by": "redis-operator"}
+	pods := &corev1.PodList{Items: []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "redis-0"}, Status: corev1.PodStatus{PodIP: "10.0.0.2"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "rfr-test-0", Labels: managed}, Status: corev1.PodStatus{PodIP: "10.0.0.2"}},
+	}}
+	mk := &mK8SService.Services{}
+	mk.On("ListPods", context.TODO(), "").Return(pods, nil)
+	mk.On("ExecCommand", context.TODO(), namespace, "rfr-test-0", "redis", []string{"redis-cli", "-p", "6379", "PING"}).Once().Return("PONG\n", "", nil)
+	mk.On("ExecCommand", context.TODO(), namespace, "rfr-test-0", "redis", []string{"redis-cli", "-p", "6380", "PING"}).Once().Return("", "Could not connect to Redis at 127.0.0.1:6380\n", errors.New("command terminated with exit code 1"))
+	run := rfOperator.RedisCLIRunner(mk)
+
+	// The command runs in the redis pod of the operator with the IP.
+	out, err := run(context.TODO(), "10.0.0.2", []string{"-p", "6379", "PING"})
+	assert.NoError(err)
+	assert.Equal("PONG\n", out)
+
+	// The error of redis-cli is returned with what it printed.
+	_, err = run(context.TODO(), "10.0.0.2", []string{"-p", "6380", "PING"})
+	assert.EqualError(err, "command terminated with exit code 1: Could not connect to Redis at 127.0.0.1:6380")
+
+	_, err = run(context.TODO(), "10.0.0.3", []string{"-p", "6379", "PING"})
+	assert.Error(err)
+	mk.AssertExpectations(t)
+}
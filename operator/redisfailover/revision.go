@@ -0,0 +1,116 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/operator/redisfailover/util"
+	"redis-operator/service/k8s"
+)
+
+const (
+	rfCRDName = "redisfailovers.databases.spotahome.com"
+)
+
+// CheckCRDSchemaRevision compares the schema revision the CRD was installed with against the operator one.
+// A different revision is logged and exposed as a schema mismatch, but it's not fatal.
+func CheckCRDSchemaRevision(ctx context.Context, k8sService k8s.Services, mClient metrics.Recorder, logger log.Logger) error {
+	crd, err := k8sService.GetCustomResourceDefinition(ctx, rfCRDName)
+	if err != nil {
+		logger.Warnf("could not check the redisfailover CRD schema revision: %s", err)
+		return err
+	}
+
+	revision, ok := redisfailoverv1.ParseSchemaRevision(crd.Annotations)
+	switch {
+	case !ok:
+		logger.Warnf("the redisfailover CRD has no schema revision, it was installed from an older version")
+	case revision > redisfailoverv1.SchemaRevision:
+		mClient.SetRedisFailoverSchemaMismatch(true)
+		logger.Errorf("the redisfailover CRD schema revision %d is newer than the operator one %d, update the operator", revision, redisfailoverv1.SchemaRevision)
+	case revision < redisfailoverv1.SchemaRevision:
+		mClient.SetRedisFailoverSchemaMismatch(true)
+		logger.Errorf("the redisfailover CRD schema revision %d is older than the operator one %d, update the CRD", revision, redisfailoverv1.SchemaRevision)
+	}
+	return nil
+}
+
+// CheckSchemaRevision returns false when the RF has been reconciled by an operator with a newer schema
+// revision, so this one can't see all its fields and must not reconcile it. Otherwise the operator schema
+// revision is set on the RF.
+func (r *RedisFailoverHandler) CheckSchemaRevision(ctx context.Context, rf *redisfailoverv1.RedisFailover) (bool, error) {
+	if rf.NewerThanOperator() {
+		r.mClient.RecordReconcileSkipped(rf.Namespace, rf.Name, metrics.OPERATOR_TOO_OLD)
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Errorf("redisfailover has been reconciled by a newer operator, skipping it")
+
+		status := rf.Status.DeepCopy()
+		message := fmt.Sprintf("the redisfailover schema revision is newer than the operator one %d", redisfailoverv1.SchemaRevision)
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               redisfailoverv1.ConditionOperatorTooOld,
+			Status:             metav1.ConditionTrue,
+			Reason:             redisfailoverv1.ReasonSchemaRevisionNewer,
+			Message:            message,
+			ObservedGeneration: rf.Generation,
+		})
+		// The pods are not checked, the readiness and pending restarts last reported are kept.
+		health := &redisfailoverv1.HealthReport{}
+		if status.Health != nil {
+			health = status.Health
+		}
+		health.Phase = redisfailoverv1.HealthPhaseDegraded
+		health.Message = message
+		health.ObservedGeneration = rf.Generation
+		status.Health = health
+		return false, r.writeStatus(ctx, rf, status)
+	}
+
+	if revision, ok := redisfailoverv1.ParseSchemaRevision(rf.Annotations); ok && revision == redisfailoverv1.SchemaRevision {
+		return true, nil
+	}
+
+	annotations := map[string]string{
+		redisfailoverv1.SchemaRevisionAnnotation: strconv.Itoa(redisfailoverv1.SchemaRevision),
+	}
+	return true, r.k8sservice.PatchRedisFailoverAnnotations(ctx, rf.Namespace, rf.Name, annotations)
+}
+
+// EnsureDefaultsRevision sets the defaults revision on the RFs that don't have it yet, so the defaults
+// introduced later don't change their behavior. The RFs reconciled by an older operator keep the defaults
+// of its schema revision, and the ones created before this operator started and never reconciled keep
+// the defaults from before they were versioned. The new RFs get the operator schema revision.
+func (r *RedisFailoverHandler) EnsureDefaultsRevision(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	// An invalid revision is reported by the validation.
+	if _, ok, err := rf.DefaultsRevision(); ok || err != nil {
+		return nil
+	}
+
+	revision := redisfailoverv1.SchemaRevision
+	if schemaRevision, ok := redisfailoverv1.ParseSchemaRevision(rf.Annotations); ok {
+		revision = schemaRevision
+	} else if rf.CreationTimestamp.Time.Before(r.startTime) {
+		revision = redisfailoverv1.BaseDefaultsRevision
+	}
+
+	annotations := map[string]string{
+		redisfailoverv1.DefaultsRevisionAnnotation: strconv.Itoa(revision),
+	}
+	if err := r.k8sservice.PatchRedisFailoverAnnotations(ctx, rf.Namespace, rf.Name, annotations); err != nil {
+		return err
+	}
+	// The RF being reconciled is defaulted with it right away.
+	rf.Annotations = util.MergeLabels(rf.Annotations, annotations)
+
+	if suppressed, err := rf.SuppressedDefaults(); err == nil && len(suppressed) > 0 {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Infof("the defaults of %s are not applied as they are newer than the redisfailover, set the %s annotation to %d to apply them",
+			strings.Join(suppressed, ", "), redisfailoverv1.DefaultsRevisionAnnotation, redisfailoverv1.SchemaRevision)
+	}
+	return nil
+}
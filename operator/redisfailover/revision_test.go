@@ -0,0 +1,266 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+func TestCheckSchemaRevision(t *testing.T) {
+	operatorRevision := strconv.Itoa(redisfailoverv1.SchemaRevision)
+	newerRevision := strconv.Itoa(redisfailoverv1.SchemaRevision + 1)
+
+	tests := []struct {
+		name               string
+		revision           string
+		tooOldCondition    bool
+		expReconcile       bool
+		expPatch           bool
+		expTooOldCondition bool
+	}{
+		{
+			name:         "An object without revision should be reconciled and get the operator revision",
+			revision:     "",
+			expReconcile: true,
+			expPatch:     true,
+		},
+		{
+			name:         "An object with the operator revision should be reconciled",
+			revision:     operatorRevision,
+			expReconcile: true,
+		},
+		{
+			name:         "An object with an older revision should be reconciled and get the operator revision",
+			revision:     "0",
+			expReconcile: true,
+			expPatch:     true,
+		},
+		{
+			name:               "An object newer than the operator should not be reconciled and get the operator too old condition",
+			revision:           newerRevision,
+			expReconcile:       false,
+			expTooOldCondition: true,
+		},
+		{
+			name:            "An object newer than the operator with the condition already set should not write the status",
+			revision:        newerRevision,
+			tooOldCondition: true,
+			expReconcile:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			if test.revision != "" {
+				rf.Annotations = map[string]string{
+					redisfailoverv1.SchemaRevisionAnnotation: test.revision,
+				}
+			}
+			if test.tooOldCondition {
+				meta.SetStatusCondition(&rf.Status.Conditions, metav1.Condition{
+					Type:    redisfailoverv1.ConditionOperatorTooOld,
+					Status:  metav1.ConditionTrue,
+					Reason:  redisfailoverv1.ReasonSchemaRevisionNewer,
+					Message: "the redisfailover schema revision is newer than the operator one " + operatorRevision,
+				})
+				rf.Status.Health = &redisfailoverv1.HealthReport{
+					Phase:   redisfailoverv1.HealthPhaseDegraded,
+					Message: "the redisfailover schema revision is newer than the operator one " + operatorRevision,
+				}
+			}
+
+			mk := &mK8SService.Services{}
+			if test.expPatch {
+				mk.On("PatchRedisFailoverAnnotations", mock.Anything, namespace, name, map[string]string{
+					redisfailoverv1.SchemaRevisionAnnotation: operatorRevision,
+				}).Once().Return(nil)
+			}
+			if test.expTooOldCondition {
+				mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+					return meta.IsStatusConditionTrue(got.Status.Conditions, redisfailoverv1.ConditionOperatorTooOld) &&
+						assert.NotNil(got.Status.Health) &&
+						assert.Equal(redisfailoverv1.HealthPhaseDegraded, got.Status.Health.Phase)
+				})).Once().Return(rf, nil)
+			}
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.Dummy, log.Dummy)
+			reconcile, err := handler.CheckSchemaRevision(context.TODO(), rf)
+
+			assert.NoError(err)
+			assert.Equal(test.expReconcile, reconcile)
+			mk.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCheckCRDSchemaRevision(t *testing.T) {
+	tests := []struct {
+		name   string
+		crd    *apiextensionsv1.CustomResourceDefinition
+		getErr error
+		expErr bool
+	}{
+		{
+			name: "A CRD with the operator revision should not fail",
+			crd: &apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						redisfailoverv1.SchemaRevisionAnnotation: strconv.Itoa(redisfailoverv1.SchemaRevision),
+					},
+				},
+			},
+		},
+		{
+			name: "A CRD newer than the operator should not fail",
+			crd: &apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						redisfailoverv1.SchemaRevisionAnnotation: strconv.Itoa(redisfailoverv1.SchemaRevision + 1),
+					},
+				},
+			},
+		},
+		{
+			name:   "An error getting the CRD should be returned",
+			getErr: errors.New("wanted error"),
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mk := &mK8SService.Services{}
+			mk.On("GetCustomResourceDefinition", mock.Anything, "redisfailovers.databases.spotahome.com").Once().Return(test.crd, test.getErr)
+
+			err := rfOperator.CheckCRDSchemaRevision(context.TODO(), mk, metrics.Dummy, log.Dummy)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			mk.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEnsureDefaultsRevision(t *testing.T) {
+	operatorRevision := strconv.Itoa(redisfailoverv1.SchemaRevision)
+
+	tests := []struct {
+		name                  string
+		annotations           map[string]string
+		createdBeforeStart    bool
+		expDefaultsRevision   string
+		expPatch              bool
+		expMaxConcurrentSyncs int32
+	}{
+		{
+			name:                  "A new RF gets the operator revision",
+			expDefaultsRevision:   operatorRevision,
+			expPatch:              true,
+			expMaxConcurrentSyncs: 1,
+		},
+		{
+			name:                  "A RF created before the operator started keeps the base defaults",
+			createdBeforeStart:    true,
+			expDefaultsRevision:   "1",
+			expPatch:              true,
+			expMaxConcurrentSyncs: 0,
+		},
+		{
+			name: "A RF reconciled by an older operator keeps its defaults",
+			annotations: map[string]string{
+				redisfailoverv1.SchemaRevisionAnnotation: "1",
+			},
+			expDefaultsRevision:   "1",
+			expPatch:              true,
+			expMaxConcurrentSyncs: 0,
+		},
+		{
+			name: "The defaults revision is not changed once set",
+			annotations: map[string]string{
+				redisfailoverv1.SchemaRevisionAnnotation:   operatorRevision,
+				redisfailoverv1.DefaultsRevisionAnnotation: "1",
+			},
+			expDefaultsRevision:   "1",
+			expMaxConcurrentSyncs: 0,
+		},
+		{
+			name: "A RF opted in to newer defaults gets them",
+			annotations: map[string]string{
+				redisfailoverv1.SchemaRevisionAnnotation:   "1",
+				redisfailoverv1.DefaultsRevisionAnnotation: operatorRevision,
+			},
+			expDefaultsRevision:   operatorRevision,
+			expMaxConcurrentSyncs: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mk := &mK8SService.Services{}
+			if test.expPatch {
+				mk.On("PatchRedisFailoverAnnotations", mock.Anything, namespace, name, map[string]string{
+					redisfailoverv1.DefaultsRevisionAnnotation: test.expDefaultsRevision,
+				}).Once().Return(nil)
+			}
+
+			rf := generateRF(false, false)
+			rf.Annotations = test.annotations
+			if test.createdBeforeStart {
+				rf.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+			}
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.Dummy, log.Dummy)
+			if !test.createdBeforeStart {
+				rf.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Second))
+			}
+
+			err := handler.EnsureDefaultsRevision(context.TODO(), rf)
+
+			assert.NoError(err)
+			assert.Equal(test.expDefaultsRevision, rf.Annotations[redisfailoverv1.DefaultsRevisionAnnotation])
+			assert.NoError(rf.Validate())
+			assert.Equal(test.expMaxConcurrentSyncs, rf.Spec.Failover.MaxConcurrentSyncs)
+			mk.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEnsureDefaultsRevisionPatchError(t *testing.T) {
+	assert := assert.New(t)
+
+	mk := &mK8SService.Services{}
+	mk.On("PatchRedisFailoverAnnotations", mock.Anything, namespace, name, mock.Anything).Once().Return(errors.New("wanted error"))
+
+	rf := generateRF(false, false)
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.Dummy, log.Dummy)
+	err := handler.EnsureDefaultsRevision(context.TODO(), rf)
+
+	assert.Error(err)
+	// Without the revision stored the RF must not be defaulted, or it would get the newer defaults.
+	assert.Empty(rf.Annotations[redisfailoverv1.DefaultsRevisionAnnotation])
+	mk.AssertExpectations(t)
+}
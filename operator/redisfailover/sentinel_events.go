@@ -0,0 +1,254 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
+)
+
+// sentinelEventReason maps the sentinel channels to the reason and type of the event created on the RF.
+var sentinelEventReason = map[string]struct {
+	reason    string
+	eventType string
+}{
+	redis.SentinelEventSDown:         {reason: "MasterSubjectivelyDown", eventType: corev1.EventTypeWarning},
+	redis.SentinelEventSDownCleared:  {reason: "MasterSubjectivelyUp", eventType: corev1.EventTypeNormal},
+	redis.SentinelEventODown:         {reason: "MasterObjectivelyDown", eventType: corev1.EventTypeWarning},
+	redis.SentinelEventODownCleared:  {reason: "MasterObjectivelyUp", eventType: corev1.EventTypeNormal},
+	redis.SentinelEventTryFailover:   {reason: "FailoverStarted", eventType: corev1.EventTypeWarning},
+	redis.SentinelEventElectedLeader: {reason: "FailoverLeaderElected", eventType: corev1.EventTypeNormal},
+	redis.SentinelEventPromotedSlave: {reason: "ReplicaPromoted", eventType: corev1.EventTypeNormal},
+	redis.SentinelEventFailoverEnd:   {reason: "FailoverEnded", eventType: corev1.EventTypeNormal},
+	redis.SentinelEventSwitchMaster:  {reason: "MasterSwitched", eventType: corev1.EventTypeNormal},
+}
+
+type sentinelEventConsumer struct {
+	cancel context.CancelFunc
+}
+
+// SentinelEventWatcher subscribes to the failover events of the sentinels of every RF, creates
+// a Kubernetes event on the RF for each of them and keeps the timeline of the last failover.
+type SentinelEventWatcher struct {
+	k8sService k8s.Services
+	dial       redis.SentinelDialer
+	logger     log.Logger
+
+	mu        sync.Mutex
+	consumers map[string]*sentinelEventConsumer
+	// failovers are the failovers in progress, lastFailovers the last finished ones.
+	failovers     map[string]*redisfailoverv1.FailoverStatus
+	lastFailovers map[string]*redisfailoverv1.FailoverStatus
+}
+
+// NewSentinelEventWatcher returns a new sentinel event watcher.
+func NewSentinelEventWatcher(k8sService k8s.Services, dial redis.SentinelDialer, logger log.Logger) *SentinelEventWatcher {
+	return &SentinelEventWatcher{
+		k8sService:    k8sService,
+		dial:          dial,
+		logger:        logger,
+		consumers:     map[string]*sentinelEventConsumer{},
+		failovers:     map[string]*redisfailoverv1.FailoverStatus{},
+		lastFailovers: map[string]*redisfailoverv1.FailoverStatus{},
+	}
+}
+
+// Ensure starts consuming the sentinel events of the RF if it's not already being done. The consumer
+// stops by itself when the sentinel deployment is deleted.
+func (s *SentinelEventWatcher) Ensure(rf *redisfailoverv1.RedisFailover) {
+	key := rfKey(rf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.consumers[key]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	running := &sentinelEventConsumer{cancel: cancel}
+	s.consumers[key] = running
+
+	ref := rfObjectReference(rf)
+	logger := s.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name)
+	namespace, name := rf.Namespace, rf.Name
+	sentinelName := rfservice.GetSentinelName(rf)
+	// The password is read on every connection from the latest RF, it may have been rotated, or moved to
+	// another secret, since the consumer started.
+	resolve := func() (string, string, error) {
+		ip, err := s.resolveSentinel(ctx, namespace, sentinelName)
+		if err != nil {
+			return "", "", err
+		}
+		latest, err := s.k8sService.GetRedisFailover(ctx, namespace, name)
+		if err != nil {
+			return "", "", err
+		}
+		password, err := k8s.GetRedisPassword(ctx, s.k8sService, latest)
+		if err != nil {
+			return "", "", err
+		}
+		return ip, password, nil
+	}
+	handle := func(event redis.SentinelEvent) {
+		s.handle(ctx, key, ref, event, logger)
+	}
+	consumer := redis.NewSentinelEventConsumer(resolve, s.dial, handle, logger)
+
+	go func() {
+		consumer.Run(ctx)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// The RF may have been stopped and ensured again meanwhile.
+		if s.consumers[key] == running {
+			delete(s.consumers, key)
+			delete(s.failovers, key)
+		}
+	}()
+}
+
+// Stop stops consuming the sentinel events of the RF.
+func (s *SentinelEventWatcher) Stop(rf *redisfailoverv1.RedisFailover) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := rfKey(rf)
+	if running, ok := s.consumers[key]; ok {
+		running.cancel()
+		delete(s.consumers, key)
+		delete(s.failovers, key)
+	}
+}
+
+// Forget stops consuming the sentinel events of the RF and drops its last failover, it's called once
+// the RF is deleted.
+func (s *SentinelEventWatcher) Forget(rf *redisfailoverv1.RedisFailover) {
+	s.Stop(rf)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.lastFailovers, rfKey(rf))
+}
+
+// LastFailover returns the timeline of the last failover of the RF seen by this operator, or nil.
+func (s *SentinelEventWatcher) LastFailover(rf *redisfailoverv1.RedisFailover) *redisfailoverv1.FailoverStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastFailovers[rfKey(rf)].DeepCopy()
+}
+
+// resolveSentinel returns the IP of a running sentinel.
+func (s *SentinelEventWatcher) resolveSentinel(ctx context.Context, namespace, name string) (string, error) {
+	pods, err := s.k8sService.GetDeploymentPods(ctx, namespace, name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", redis.ErrStopConsuming
+		}
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil && pod.Status.PodIP != "" {
+			return pod.Status.PodIP, nil
+		}
+	}
+	return "", fmt.Errorf("no running sentinel in %s/%s", namespace, name)
+}
+
+func (s *SentinelEventWatcher) handle(ctx context.Context, key string, ref *corev1.ObjectReference, event redis.SentinelEvent, logger log.Logger) {
+	// Only the state of the master matters, sentinels also report replicas and other sentinels going down.
+	isMasterEvent := event.InstanceType == "master" || event.Channel == redis.SentinelEventPromotedSlave
+	r, ok := sentinelEventReason[event.Channel]
+	if !ok || !isMasterEvent {
+		return
+	}
+
+	s.recordFailoverPhase(key, event)
+
+	message := fmt.Sprintf("%s %s:%s reported by sentinel %s at %s", event.InstanceType, event.InstanceIP, event.InstancePort, event.Sentinel, event.Time.UTC().Format(time.RFC3339Nano))
+	if event.Channel == redis.SentinelEventSwitchMaster {
+		message = fmt.Sprintf("master switched from %s:%s to %s:%s, reported by sentinel %s at %s", event.MasterIP, event.MasterPort, event.NewMasterIP, event.NewMasterPort, event.Sentinel, event.Time.UTC().Format(time.RFC3339Nano))
+	}
+	if err := s.k8sService.CreateEvent(ctx, ref.Namespace, newRFEvent(ref, r.eventType, r.reason, message, event.Time)); err != nil {
+		logger.Warnf("could not create the %s event: %s", r.reason, err)
+	}
+}
+
+// recordFailoverPhase keeps the timeline of the failover in progress, it's moved to the last failover
+// when the sentinels switch to the new master.
+func (s *SentinelEventWatcher) recordFailoverPhase(key string, event redis.SentinelEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	at := metav1.NewMicroTime(event.Time)
+	failover := s.failovers[key]
+	switch event.Channel {
+	case redis.SentinelEventODown:
+		s.failovers[key] = &redisfailoverv1.FailoverStatus{DetectedAt: &at}
+	case redis.SentinelEventTryFailover:
+		// The watched sentinel may not have seen the odown before the failover started.
+		if failover == nil {
+			s.failovers[key] = &redisfailoverv1.FailoverStatus{DetectedAt: &at}
+		}
+	case redis.SentinelEventODownCleared:
+		// The master came back before a replica was promoted, there is no failover.
+		if failover != nil && failover.PromotedAt == nil {
+			delete(s.failovers, key)
+		}
+	case redis.SentinelEventPromotedSlave:
+		if failover != nil {
+			failover.PromotedAt = &at
+		}
+	case redis.SentinelEventSwitchMaster:
+		if failover == nil {
+			failover = &redisfailoverv1.FailoverStatus{}
+		}
+		failover.ReconfiguredAt = &at
+		if failover.DetectedAt != nil {
+			failover.TotalSeconds = int64(event.Time.Sub(failover.DetectedAt.Time).Round(time.Second).Seconds())
+		}
+		s.lastFailovers[key] = failover
+		delete(s.failovers, key)
+	}
+}
+
+func newRFEvent(ref *corev1.ObjectReference, eventType, reason, message string, at time.Time) *corev1.Event {
+	t := metav1.NewTime(at)
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", ref.Name, at.UnixNano()),
+			Namespace: ref.Namespace,
+		},
+		InvolvedObject: *ref,
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		FirstTimestamp: t,
+		LastTimestamp:  t,
+		Count:          1,
+		Source: corev1.EventSource{
+			Component: operatorName,
+		},
+	}
+}
+
+func rfKey(rf *redisfailoverv1.RedisFailover) string {
+	return fmt.Sprintf("%s/%s", rf.Namespace, rf.Name)
+}
+
+// rfObjectReference returns the reference of the RF the events are created on.
+func rfObjectReference(rf *redisfailoverv1.RedisFailover) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: redisfailoverv1.SchemeGroupVersion.String(),
+		Kind:       redisfailoverv1.RFKind,
+		Namespace:  rf.Namespace,
+		Name:       rf.Name,
+		UID:        rf.UID,
+	}
+}
@@ -0,0 +1,127 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	"redis-operator/service/redis"
+)
+
+type sentinelMessage struct {
+	channel string
+	payload string
+}
+
+// fakeSentinelSubscription returns its messages, waiting between them so the failover phases have
+// different timestamps, and then fails.
+type fakeSentinelSubscription struct {
+	messages []sentinelMessage
+}
+
+func (f *fakeSentinelSubscription) ReceiveMessage(ctx context.Context) (string, string, error) {
+	if len(f.messages) == 0 {
+		return "", "", errors.New("connection reset by peer")
+	}
+	time.Sleep(time.Millisecond)
+	msg := f.messages[0]
+	f.messages = f.messages[1:]
+	return msg.channel, msg.payload, nil
+}
+
+func (f *fakeSentinelSubscription) Close() error {
+	return nil
+}
+
+func TestSentinelEventWatcher(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	rf.Spec.Auth.SecretPath = "redis-auth"
+	sentinelPods := &corev1.PodList{
+		Items: []corev1.Pod{
+			{
+				Status: corev1.PodStatus{
+					Phase: corev1.PodRunning,
+					PodIP: "10.0.1.1",
+				},
+			},
+		},
+	}
+	sub := &fakeSentinelSubscription{
+		messages: []sentinelMessage{
+			{channel: "+sdown", payload: "slave 10.0.0.3:6379 10.0.0.3 6379 @ mymaster 10.0.0.1 6379"},
+			{channel: "+sdown", payload: "master mymaster 10.0.0.1 6379"},
+			{channel: "+odown", payload: "master mymaster 10.0.0.1 6379 #quorum 2/2"},
+			{channel: "+try-failover", payload: "master mymaster 10.0.0.1 6379"},
+			{channel: "+elected-leader", payload: "master mymaster 10.0.0.1 6379"},
+			{channel: "+promoted-slave", payload: "slave 10.0.0.2:6379 10.0.0.2 6379 @ mymaster 10.0.0.1 6379"},
+			{channel: "+failover-end", payload: "master mymaster 10.0.0.1 6379"},
+			{channel: "+switch-master", payload: "mymaster 10.0.0.1 6379 10.0.0.2 6379"},
+		},
+	}
+	dial := func(_ context.Context, ip, password string) (redis.SentinelSubscription, error) {
+		assert.Equal("10.0.1.1", ip)
+		assert.Equal("hunter2", password)
+		return sub, nil
+	}
+
+	// The password is read from the secret of the latest RF, not the one the watcher was started with.
+	latest := rf.DeepCopy()
+	latest.Spec.Auth.SecretPath = "redis-auth-moved"
+
+	mk := &mK8SService.Services{}
+	mk.On("GetRedisFailover", mock.Anything, namespace, name).Return(latest, nil)
+	mk.On("GetSecret", mock.Anything, namespace, "redis-auth-moved").Return(&corev1.Secret{Data: map[string][]byte{"password": []byte("hunter2")}}, nil)
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(sentinelPods, nil)
+	// Once the sentinels are gone the watcher stops.
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, "rfs-test"))
+	events := make(chan *corev1.Event, 10)
+	mk.On("CreateEvent", mock.Anything, namespace, mock.Anything).Run(func(args mock.Arguments) {
+		events <- args.Get(2).(*corev1.Event)
+	}).Return(nil)
+
+	watcher := rfOperator.NewSentinelEventWatcher(mk, dial, log.Dummy)
+	watcher.Ensure(rf)
+	defer watcher.Stop(rf)
+
+	reasons := []string{}
+	for len(reasons) < 7 {
+		select {
+		case event := <-events:
+			assert.Equal(name, event.InvolvedObject.Name)
+			reasons = append(reasons, event.Reason)
+		case <-time.After(5 * time.Second):
+			assert.FailNow("timeout waiting for the sentinel events")
+		}
+	}
+
+	lastFailover := watcher.LastFailover(rf)
+	if assert.NotNil(lastFailover) && assert.NotNil(lastFailover.DetectedAt) && assert.NotNil(lastFailover.PromotedAt) && assert.NotNil(lastFailover.ReconfiguredAt) {
+		assert.True(lastFailover.DetectedAt.Before(lastFailover.PromotedAt))
+		assert.True(lastFailover.PromotedAt.Before(lastFailover.ReconfiguredAt))
+	}
+	assert.Equal([]string{
+		"MasterSubjectivelyDown",
+		"MasterObjectivelyDown",
+		"FailoverStarted",
+		"FailoverLeaderElected",
+		"ReplicaPromoted",
+		"FailoverEnded",
+		"MasterSwitched",
+	}, reasons)
+
+	// The last failover of a deleted RF is dropped.
+	watcher.Forget(rf)
+	assert.Nil(watcher.LastFailover(rf))
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/service/k8s"
+)
+
+// aclDefaultUser is the user redis creates when the ACL file doesn't declare it.
+const aclDefaultUser = "default"
+
+// aclErrorLineRE matches the line of the ACL file quoted by redis when it can't parse it, as
+// "/redis-acl/users.acl:2: Error in user declaration 'bob': Syntax error".
+var aclErrorLineRE = regexp.MustCompile(`:(\d+): `)
+
+// ErrACLNotPropagated is returned when redis loaded an ACL file without the users of the ConfigMap, the
+// kubelet has not updated the mounted file yet.
+var ErrACLNotPropagated = errors.New("the ACL file is not propagated to the pod yet")
+
+// ParseACLUsers returns the names of the users declared in an ACL file, in order.
+func ParseACLUsers(acl string) []string {
+	users := []string{}
+	for _, line := range strings.Split(acl, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "user" {
+			users = append(users, fields[1])
+		}
+	}
+	return users
+}
+
+// ACLErrorLine returns the number and the content of the line of the ACL file quoted by the error of
+// redis, false when the error quotes no line of it.
+func ACLErrorLine(err error, acl string) (int, string, bool) {
+	match := aclErrorLineRE.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, "", false
+	}
+	number, convErr := strconv.Atoi(match[1])
+	lines := strings.Split(acl, "\n")
+	if convErr != nil || number < 1 || number > len(lines) {
+		return 0, "", false
+	}
+	return number, strings.TrimSpace(lines[number-1]), true
+}
+
+// LoadRedisACL loads the ACL file of a redis pod with ACL LOAD and checks with ACL LIST that it has the
+// users of the file afterwards. ErrACLNotPropagated is returned when it has other users, redis loaded
+// the previous file.
+func (r *RedisFailoverHealer) LoadRedisACL(ctx context.Context, rf *redisfailoverv1.RedisFailover, ip string, users []string) error {
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return err
+	}
+
+	port := getRedisPort(rf.Spec.Redis.Port)
+	r.logger.Debugf("Loading the ACL file on redis %s...", ip)
+	if err := r.redisClient.LoadACL(ip, port, password); err != nil {
+		return err
+	}
+	loaded, err := r.redisClient.ListACLUsers(ip, port, password)
+	if err != nil {
+		return err
+	}
+
+	expected := map[string]bool{}
+	for _, user := range users {
+		expected[user] = true
+	}
+	current := map[string]bool{}
+	unexpected := []string{}
+	for _, user := range loaded {
+		current[user] = true
+		if !expected[user] && user != aclDefaultUser {
+			unexpected = append(unexpected, user)
+		}
+	}
+	missing := []string{}
+	for _, user := range users {
+		if !current[user] {
+			missing = append(missing, user)
+		}
+	}
+	if len(missing) > 0 || len(unexpected) > 0 {
+		return fmt.Errorf("%w: redis %s is missing the users %v and has the users %v", ErrACLNotPropagated, ip, missing, unexpected)
+	}
+	return nil
+}
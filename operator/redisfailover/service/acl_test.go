@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/redis"
+)
+
+const testACL = `user default on >pass ~* &* +@all
+# the liveness probe
+user pinger on >pingpass -@all +ping
+
+user app on >secret ~app:* +@read +@write`
+
+func TestParseACLUsers(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"default", "pinger", "app"}, rfservice.ParseACLUsers(testACL))
+	assert.Empty(rfservice.ParseACLUsers(""))
+}
+
+func TestACLErrorLine(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedNumber int
+		expectedLine   string
+		expectedOK     bool
+	}{
+		{
+			name:           "Syntax error",
+			err:            errors.New("ERR /redis-acl/users.acl:5: Error in applying operation '+@wrong': Unknown command or category name in ACL. "),
+			expectedNumber: 5,
+			expectedLine:   "user app on >secret ~app:* +@read +@write",
+			expectedOK:     true,
+		},
+		{
+			name: "Line out of the file",
+			err:  errors.New("ERR /redis-acl/users.acl:12: Syntax error. "),
+		},
+		{
+			name: "No ACL file",
+			err:  errors.New("ERR This Redis instance is not configured to use an ACL file."),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			number, line, ok := rfservice.ACLErrorLine(test.err, testACL)
+			assert.Equal(test.expectedOK, ok)
+			assert.Equal(test.expectedNumber, number)
+			assert.Equal(test.expectedLine, line)
+		})
+	}
+}
+
+func TestLoadRedisACL(t *testing.T) {
+	tests := []struct {
+		name          string
+		users         []string
+		loadErr       error
+		loaded        []string
+		expectedError error
+	}{
+		{
+			name:   "Loaded",
+			users:  []string{"default", "pinger", "app"},
+			loaded: []string{"default", "pinger", "app"},
+		},
+		{
+			name:   "Default user created by redis",
+			users:  []string{"pinger", "app"},
+			loaded: []string{"default", "pinger", "app"},
+		},
+		{
+			name:          "Previous file",
+			users:         []string{"default", "pinger", "app"},
+			loaded:        []string{"default", "pinger", "legacy"},
+			expectedError: rfservice.ErrACLNotPropagated,
+		},
+		{
+			name:          "Redis 5",
+			users:         []string{"default", "pinger", "app"},
+			loadErr:       redis.ErrUnsupported,
+			expectedError: redis.ErrUnsupported,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+
+			ms := &mK8SService.Services{}
+			mr := &mRedisService.Client{}
+			mr.On("LoadACL", "1.1.1.1", "0", "").Once().Return(test.loadErr)
+			if test.loadErr == nil {
+				mr.On("ListACLUsers", "1.1.1.1", "0", "").Once().Return(test.loaded, nil)
+			}
+
+			healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+			err := healer.LoadRedisACL(context.TODO(), rf, "1.1.1.1", test.users)
+
+			if test.expectedError != nil {
+				assert.True(errors.Is(err, test.expectedError), "unexpected error %v", err)
+			} else {
+				assert.NoError(err)
+			}
+			mr.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRedisStatefulSetACL(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rf := generateRF()
+	rf.Spec.Auth.ACLConfigMapRef = &corev1.LocalObjectReference{Name: "redis-acl"}
+	require.NoError(rf.Validate())
+
+	state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	require.NoError(err)
+	var ss *appsv1.StatefulSet
+	var config *corev1.ConfigMap
+	for _, o := range state.Objects {
+		switch {
+		case o.Kind == rfservice.KindStatefulSet:
+			ss = o.Object.(*appsv1.StatefulSet)
+		case o.Kind == rfservice.KindConfigMap && o.Name == "rfr-test":
+			config = o.Object.(*corev1.ConfigMap)
+		}
+	}
+	require.NotNil(ss)
+	require.NotNil(config)
+
+	// The users of redis.conf can't be mixed with an ACL file, the ACL file declares the pinger.
+	assert.Contains(config.Data["redis.conf"], "\naclfile /redis-acl/users.acl")
+	assert.NotContains(config.Data["redis.conf"], "user pinger")
+	assert.Contains(state.Required, rfservice.ObjectRef{Kind: rfservice.KindConfigMap, Name: "redis-acl"})
+	// Mounted without subPath, so the kubelet updates the file.
+	assert.Contains(ss.Spec.Template.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "redis-acl", MountPath: "/redis-acl", ReadOnly: true})
+	assert.Contains(ss.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: "redis-acl",
+		VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "redis-acl"},
+		}},
+	})
+}
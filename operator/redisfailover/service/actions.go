@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+)
+
+// PodActionKind is the kind of an action on a redis pod. The kinds are sorted by precedence, when
+// several actions are planned for the same pod in a check only the one with the highest kind is
+// taken.
+type PodActionKind int
+
+// Kinds of the actions on the redis pods, from the lowest to the highest precedence. Applying the
+// custom config is the lowest as it's applied on every check, so it can't starve the other actions.
+// Loading the ACL is only planned when the ACL file changed, it takes precedence over the custom config.
+const (
+	PodActionApplyConfig PodActionKind = iota + 1
+	PodActionLoadACL
+	PodActionUpdateLabels
+	PodActionReconfigure
+	PodActionRestart
+	PodActionDelete
+)
+
+func (k PodActionKind) String() string {
+	switch k {
+	case PodActionApplyConfig:
+		return "apply config"
+	case PodActionLoadACL:
+		return "load ACL"
+	case PodActionUpdateLabels:
+		return "update labels"
+	case PodActionReconfigure:
+		return "reconfigure"
+	case PodActionRestart:
+		return "restart"
+	case PodActionDelete:
+		return "delete"
+	}
+	return fmt.Sprintf("unknown(%d)", int(k))
+}
+
+// PodAction is an action the checks want to take on a redis pod. They are planned instead of taken
+// right away, so a pod is not reconfigured while it's being deleted in the same check.
+type PodAction struct {
+	Pod    string
+	Kind   PodActionKind
+	Reason string
+	Apply  func() error
+}
+
+func (a PodAction) String() string {
+	return fmt.Sprintf("%s pod %s: %s", a.Kind, a.Pod, a.Reason)
+}
+
+// PodActionPlan holds the actions on the redis pods of a check, at most one by pod.
+type PodActionPlan struct {
+	planned    map[string]PodAction
+	order      []string
+	suppressed []PodAction
+}
+
+// NewPodActionPlan returns an empty plan.
+func NewPodActionPlan() *PodActionPlan {
+	return &PodActionPlan{
+		planned: map[string]PodAction{},
+	}
+}
+
+// Add plans the actions. An action replaces the one planned for the same pod when it has a higher
+// kind, otherwise it's suppressed. On the same kind the first planned action is kept.
+func (p *PodActionPlan) Add(actions ...PodAction) {
+	for _, action := range actions {
+		current, ok := p.planned[action.Pod]
+		if !ok {
+			p.planned[action.Pod] = action
+			p.order = append(p.order, action.Pod)
+			continue
+		}
+		if action.Kind > current.Kind {
+			p.planned[action.Pod] = action
+			p.suppressed = append(p.suppressed, current)
+			continue
+		}
+		p.suppressed = append(p.suppressed, action)
+	}
+}
+
+// Actions returns the planned actions, in the order their pods were first planned.
+func (p *PodActionPlan) Actions() []PodAction {
+	actions := make([]PodAction, 0, len(p.order))
+	for _, pod := range p.order {
+		actions = append(actions, p.planned[pod])
+	}
+	return actions
+}
+
+// Planned returns the action planned for the pod.
+func (p *PodActionPlan) Planned(pod string) (PodAction, bool) {
+	action, ok := p.planned[pod]
+	return action, ok
+}
+
+// Suppressed returns the actions that lost against a higher or earlier one of the same pod.
+func (p *PodActionPlan) Suppressed() []PodAction {
+	return p.suppressed
+}
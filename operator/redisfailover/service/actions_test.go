@@ -0,0 +1,106 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestPodActionPlan(t *testing.T) {
+	action := func(pod string, kind rfservice.PodActionKind, reason string) rfservice.PodAction {
+		return rfservice.PodAction{Pod: pod, Kind: kind, Reason: reason}
+	}
+	summary := func(actions []rfservice.PodAction) []string {
+		s := []string{}
+		for _, a := range actions {
+			s = append(s, a.String())
+		}
+		return s
+	}
+
+	tests := []struct {
+		name          string
+		intents       []rfservice.PodAction
+		expActions    []string
+		expSuppressed []string
+	}{
+		{
+			name: "Actions on different pods are all taken in planning order",
+			intents: []rfservice.PodAction{
+				action("rfr-test-1", rfservice.PodActionReconfigure, "make it slave"),
+				action("rfr-test-0", rfservice.PodActionDelete, "update it"),
+				action("rfr-test-2", rfservice.PodActionApplyConfig, "set config"),
+			},
+			expActions: []string{
+				"reconfigure pod rfr-test-1: make it slave",
+				"delete pod rfr-test-0: update it",
+				"apply config pod rfr-test-2: set config",
+			},
+			expSuppressed: []string{},
+		},
+		{
+			name: "Delete wins over reconfigure planned before it",
+			intents: []rfservice.PodAction{
+				action("rfr-test-2", rfservice.PodActionReconfigure, "make it slave"),
+				action("rfr-test-2", rfservice.PodActionDelete, "update it"),
+			},
+			expActions:    []string{"delete pod rfr-test-2: update it"},
+			expSuppressed: []string{"reconfigure pod rfr-test-2: make it slave"},
+		},
+		{
+			name: "Reconfigure planned after a delete is suppressed",
+			intents: []rfservice.PodAction{
+				action("rfr-test-2", rfservice.PodActionDelete, "update it"),
+				action("rfr-test-2", rfservice.PodActionReconfigure, "make it slave"),
+			},
+			expActions:    []string{"delete pod rfr-test-2: update it"},
+			expSuppressed: []string{"reconfigure pod rfr-test-2: make it slave"},
+		},
+		{
+			name: "Reconfigure wins over labels and labels over config",
+			intents: []rfservice.PodAction{
+				action("rfr-test-0", rfservice.PodActionApplyConfig, "set config"),
+				action("rfr-test-0", rfservice.PodActionUpdateLabels, "set master label"),
+				action("rfr-test-1", rfservice.PodActionUpdateLabels, "set slave label"),
+				action("rfr-test-0", rfservice.PodActionReconfigure, "make it master"),
+			},
+			expActions: []string{
+				"reconfigure pod rfr-test-0: make it master",
+				"update labels pod rfr-test-1: set slave label",
+			},
+			expSuppressed: []string{
+				"apply config pod rfr-test-0: set config",
+				"update labels pod rfr-test-0: set master label",
+			},
+		},
+		{
+			name: "A pod gets a single disruptive action, the first planned",
+			intents: []rfservice.PodAction{
+				action("rfr-test-1", rfservice.PodActionReconfigure, "make it slave of 0.0.0.0"),
+				action("rfr-test-1", rfservice.PodActionReconfigure, "make it slave of 1.1.1.1"),
+				action("rfr-test-1", rfservice.PodActionDelete, "update it"),
+				action("rfr-test-1", rfservice.PodActionDelete, "update it again"),
+			},
+			expActions: []string{"delete pod rfr-test-1: update it"},
+			expSuppressed: []string{
+				"reconfigure pod rfr-test-1: make it slave of 1.1.1.1",
+				"reconfigure pod rfr-test-1: make it slave of 0.0.0.0",
+				"delete pod rfr-test-1: update it again",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			plan := rfservice.NewPodActionPlan()
+			plan.Add(test.intents...)
+
+			assert.Equal(test.expActions, summary(plan.Actions()))
+			assert.Equal(test.expSuppressed, summary(plan.Suppressed()))
+		})
+	}
+}
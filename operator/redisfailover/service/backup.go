@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+	"redis-operator/service/k8s"
+)
+
+// backupTimeFormat is the time the backups are named after, sorting them by name sorts them by time.
+const backupTimeFormat = "20060102-150405"
+
+// GetBackupName returns the name of the VolumeSnapshot of a backup taken at the given time
+func GetBackupName(rf *redisfailoverv1.RedisFailover, at time.Time) string {
+	return fmt.Sprintf("%s-%s", generateName(backupName, rf), at.UTC().Format(backupTimeFormat))
+}
+
+// GetBackupSelector returns the selector of the VolumeSnapshots of the backups of the RF
+func GetBackupSelector(rf *redisfailoverv1.RedisFailover) labels.Selector {
+	return labels.SelectorFromSet(generateSelectorLabels(backupRoleName, rf.Name))
+}
+
+// GetBackups returns the backups of the VolumeSnapshots, oldest first.
+func GetBackups(snapshots *unstructured.UnstructuredList) []redisfailoverv1.BackupStatus {
+	backups := []redisfailoverv1.BackupStatus{}
+	if snapshots == nil {
+		return backups
+	}
+	for _, snapshot := range snapshots.Items {
+		pvc, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+		ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+		backups = append(backups, redisfailoverv1.BackupStatus{
+			Name:                  snapshot.GetName(),
+			PersistentVolumeClaim: pvc,
+			CreatedAt:             snapshot.GetCreationTimestamp(),
+			ReadyToUse:            ready,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].CreatedAt.Equal(&backups[j].CreatedAt) {
+			return backups[i].CreatedAt.Before(&backups[j].CreatedAt)
+		}
+		return backups[i].Name < backups[j].Name
+	})
+	return backups
+}
+
+// getRedisDataClaimName returns the name of the persistent volume claim the statefulset created for the pod.
+func getRedisDataClaimName(rf *redisfailoverv1.RedisFailover, podName string) string {
+	return fmt.Sprintf("%s-%s", getRedisDataVolumeName(rf), podName)
+}
+
+func generateVolumeSnapshot(rf *redisfailoverv1.RedisFailover, name, claimName string, labels map[string]string) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetAPIVersion(k8s.VolumeSnapshotGVR.GroupVersion().String())
+	snapshot.SetKind("VolumeSnapshot")
+	snapshot.SetName(name)
+	snapshot.SetNamespace(rf.Namespace)
+	// The backups are not owned by the RF, they must survive its deletion.
+	snapshot.SetLabels(util.MergeLabels(labels, generateSelectorLabels(backupRoleName, rf.Name)))
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": claimName,
+		},
+	}
+	if class := rf.Spec.Backup.VolumeSnapshotClassName; class != "" {
+		spec["volumeSnapshotClassName"] = class
+	}
+	snapshot.Object["spec"] = spec
+	return snapshot
+}
+
+// SnapshotReplica takes a VolumeSnapshot of the persistent volume claim of the replica. When the replica
+// writes an append only file, it's disabled while the snapshot is requested so the file is not caught
+// in the middle of a write, and enabled again even when the snapshot fails.
+func (r *RedisFailoverHealer) SnapshotReplica(ctx context.Context, podName string, ip string, snapshotName string, labels map[string]string, rf *redisfailoverv1.RedisFailover) error {
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return err
+	}
+	port := getRedisPort(rf.Spec.Redis.Port)
+
+	info, err := r.redisClient.GetRedisInfo(ip, port, password)
+	if err != nil {
+		return err
+	}
+	aof, _ := getInfoField(info, "aof_enabled")
+	quiesce := aof == "1"
+	if quiesce {
+		if err := r.redisClient.SetCustomRedisConfig(ip, port, []string{"appendonly no"}, password); err != nil {
+			return err
+		}
+	}
+
+	snapshot := generateVolumeSnapshot(rf, snapshotName, getRedisDataClaimName(rf, podName), labels)
+	err = r.k8sService.CreateVolumeSnapshot(ctx, rf.Namespace, snapshot)
+
+	if quiesce {
+		if restoreErr := r.redisClient.SetCustomRedisConfig(ip, port, []string{"appendonly yes"}, password); restoreErr != nil {
+			if err != nil {
+				r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Errorf("could not enable the append only file of %s again: %s", podName, restoreErr)
+				return err
+			}
+			return fmt.Errorf("could not enable the append only file of %s again: %w", podName, restoreErr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func generateBackupRF() *redisfailoverv1.RedisFailover {
+	rf := generateRF()
+	rf.Spec.Redis.Port = 6379
+	rf.Spec.Redis.Storage.PersistentVolumeClaim = &redisfailoverv1.EmbeddedPersistentVolumeClaim{
+		EmbeddedObjectMetadata: redisfailoverv1.EmbeddedObjectMetadata{Name: "redis-data"},
+	}
+	rf.Spec.Backup = &redisfailoverv1.BackupSettings{
+		Method:                  redisfailoverv1.BackupMethodVolumeSnapshot,
+		VolumeSnapshotClassName: "csi-snapclass",
+	}
+	return rf
+}
+
+func TestSnapshotReplica(t *testing.T) {
+	tests := []struct {
+		name       string
+		aof        bool
+		createErr  error
+		restoreErr error
+		expCalls   []string
+		expErr     string
+	}{
+		{
+			name:     "The append only file is disabled while the snapshot is requested",
+			aof:      true,
+			expCalls: []string{"info", "appendonly no", "snapshot", "appendonly yes"},
+		},
+		{
+			name:     "Without append only file the snapshot is requested right away",
+			expCalls: []string{"info", "snapshot"},
+		},
+		{
+			name:      "The append only file is enabled again when the snapshot fails",
+			aof:       true,
+			createErr: errors.New("wanted error"),
+			expCalls:  []string{"info", "appendonly no", "snapshot", "appendonly yes"},
+			expErr:    "wanted error",
+		},
+		{
+			name:       "A failure enabling the append only file again is reported",
+			aof:        true,
+			restoreErr: errors.New("wanted error"),
+			expCalls:   []string{"info", "appendonly no", "snapshot", "appendonly yes"},
+			expErr:     "could not enable the append only file of rfr-test-1 again: wanted error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateBackupRF()
+			calls := []string{}
+			record := func(call string) func(mock.Arguments) {
+				return func(mock.Arguments) { calls = append(calls, call) }
+			}
+
+			info := "# Persistence\r\naof_enabled:0\r\n"
+			if test.aof {
+				info = "# Persistence\r\naof_enabled:1\r\n"
+			}
+			mr := &mRedisService.Client{}
+			mr.On("GetRedisInfo", "10.0.0.2", "6379", "").Once().Run(record("info")).Return(info, nil)
+			if test.aof {
+				mr.On("SetCustomRedisConfig", "10.0.0.2", "6379", []string{"appendonly no"}, "").Once().Run(record("appendonly no")).Return(nil)
+				mr.On("SetCustomRedisConfig", "10.0.0.2", "6379", []string{"appendonly yes"}, "").Once().Run(record("appendonly yes")).Return(test.restoreErr)
+			}
+
+			ms := &mK8SService.Services{}
+			ms.On("CreateVolumeSnapshot", mock.Anything, namespace, mock.MatchedBy(func(snapshot *unstructured.Unstructured) bool {
+				claim, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+				class, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+				return assert.Equal("rfb-test-20240102-030405", snapshot.GetName()) &&
+					assert.Equal("VolumeSnapshot", snapshot.GetKind()) &&
+					assert.Equal("redis-data-rfr-test-1", claim) &&
+					assert.Equal("csi-snapclass", class) &&
+					assert.Equal("backup", snapshot.GetLabels()["app.kubernetes.io/component"]) &&
+					assert.Equal("custom", snapshot.GetLabels()["custom"]) &&
+					assert.Empty(snapshot.GetOwnerReferences())
+			})).Once().Run(record("snapshot")).Return(test.createErr)
+
+			snapshotName := rfservice.GetBackupName(rf, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+			healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+			err := healer.SnapshotReplica(context.TODO(), "rfr-test-1", "10.0.0.2", snapshotName, map[string]string{"custom": "custom"}, rf)
+
+			if test.expErr != "" {
+				assert.EqualError(err, test.expErr)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expCalls, calls)
+			mr.AssertExpectations(t)
+			ms.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetBackups(t *testing.T) {
+	snapshot := func(name string, created time.Time, ready bool) unstructured.Unstructured {
+		s := unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"source": map[string]interface{}{"persistentVolumeClaimName": "redis-data-rfr-test-1"},
+			},
+			"status": map[string]interface{}{"readyToUse": ready},
+		}}
+		s.SetName(name)
+		s.SetCreationTimestamp(metav1.NewTime(created))
+		return s
+	}
+	// The creation timestamps are read back in the local time.
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Local()
+
+	backups := rfservice.GetBackups(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		snapshot("rfb-test-2", now, false),
+		snapshot("rfb-test-1", now.Add(-time.Hour), true),
+	}})
+
+	assert.Equal(t, []redisfailoverv1.BackupStatus{
+		{Name: "rfb-test-1", PersistentVolumeClaim: "redis-data-rfr-test-1", CreatedAt: metav1.NewTime(now.Add(-time.Hour)), ReadyToUse: true},
+		{Name: "rfb-test-2", PersistentVolumeClaim: "redis-data-rfr-test-1", CreatedAt: metav1.NewTime(now)},
+	}, backups)
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
 	"strconv"
 	"time"
 
@@ -18,22 +20,34 @@ import (
 
 // RedisFailoverCheck defines the interface able to check the correct status of a redis failover
 type RedisFailoverCheck interface {
-	CheckRedisNumber(rFailover *redisfailoverv1.RedisFailover) error
-	CheckSentinelNumber(rFailover *redisfailoverv1.RedisFailover) error
-	CheckAllSlavesFromMaster(master string, rFailover *redisfailoverv1.RedisFailover) error
+	CheckRedisNumber(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) error
+	CheckSentinelNumber(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) error
+	CheckAllSlavesFromMaster(ctx context.Context, master string, rFailover *redisfailoverv1.RedisFailover) error
 	CheckSentinelNumberInMemory(sentinel string, rFailover *redisfailoverv1.RedisFailover) error
 	CheckSentinelSlavesNumberInMemory(sentinel string, rFailover *redisfailoverv1.RedisFailover) error
 	CheckSentinelMonitor(sentinel string, monitor ...string) error
-	GetMasterIP(rFailover *redisfailoverv1.RedisFailover) (string, error)
-	GetNumberMasters(rFailover *redisfailoverv1.RedisFailover) (int, error)
-	GetRedisesIPs(rFailover *redisfailoverv1.RedisFailover) ([]string, error)
-	GetSentinelsIPs(rFailover *redisfailoverv1.RedisFailover) ([]string, error)
-	GetMinimumRedisPodTime(rFailover *redisfailoverv1.RedisFailover) (time.Duration, error)
-	GetRedisesSlavesPods(rFailover *redisfailoverv1.RedisFailover) ([]string, error)
-	GetRedisesMasterPod(rFailover *redisfailoverv1.RedisFailover) (string, error)
-	GetStatefulSetUpdateRevision(rFailover *redisfailoverv1.RedisFailover) (string, error)
-	GetRedisRevisionHash(podName string, rFailover *redisfailoverv1.RedisFailover) (string, error)
-	CheckRedisSlavesReady(slaveIP string, rFailover *redisfailoverv1.RedisFailover) (bool, error)
+	CheckSentinelResponding(sentinel string) error
+	GetMasterIP(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) (string, error)
+	GetNumberMasters(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) (int, error)
+	GetRedisesIPs(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) ([]string, error)
+	GetSentinelsIPs(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) ([]string, error)
+	GetMinimumRedisPodTime(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) (time.Duration, error)
+	GetRedisesSlavesPods(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) ([]string, error)
+	GetRedisesMasterPod(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) (string, error)
+	GetStatefulSetUpdateRevision(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) (string, error)
+	GetRedisRevisionHash(ctx context.Context, podName string, rFailover *redisfailoverv1.RedisFailover) (string, error)
+	CheckRedisSlavesReady(ctx context.Context, slaveIP string, rFailover *redisfailoverv1.RedisFailover) (bool, error)
+	GetExternalMasters(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) ([]redisfailoverv1.RedisExternalNode, error)
+	CheckExternalSlavesFromMaster(ctx context.Context, master redisfailoverv1.RedisExternalNode, rFailover *redisfailoverv1.RedisFailover) error
+	GetNodeTuningWarnings(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) (map[string][]string, error)
+	ForgetNodeTuningWarnings(rFailover *redisfailoverv1.RedisFailover)
+	CorroborateMasterDown(ctx context.Context, lastMaster string, rFailover *redisfailoverv1.RedisFailover) (bool, string, error)
+	MeasureRedisLatency(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) ([]RedisLatency, error)
+	ForgetRedisLatency(rFailover *redisfailoverv1.RedisFailover)
+	GetRedisAddresses(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) (RedisAddresses, error)
+	GetDataDirMismatches(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) ([]DataDirMismatch, error)
+	GetRedisKeyspaces(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) ([]RedisKeyspace, error)
+	ProbeRedisFunctional(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) ([]RedisFunctionalHealth, error)
 }
 
 // RedisFailoverChecker is our implementation of RedisFailoverCheck interface
@@ -42,6 +56,10 @@ type RedisFailoverChecker struct {
 	redisClient   redis.Client
 	logger        log.Logger
 	metricsClient metrics.Recorder
+	// startupWarnings keeps the redis startup warnings already read.
+	startupWarnings *startupWarningsCache
+	// redisLatency keeps the last round-trips of the PING to the redis pods.
+	redisLatency *redisLatencyHistory
 }
 
 // NewRedisFailoverChecker creates an object of the RedisFailoverChecker struct
@@ -51,12 +69,18 @@ func NewRedisFailoverChecker(k8sService k8s.Services, redisClient redis.Client,
 		redisClient:   redisClient,
 		logger:        logger,
 		metricsClient: metricsClient,
+		startupWarnings: &startupWarningsCache{
+			warnings: map[string]map[string][]string{},
+		},
+		redisLatency: &redisLatencyHistory{
+			samples: map[string]map[string][]time.Duration{},
+		},
 	}
 }
 
 // CheckRedisNumber controlls that the number of deployed redis is the same than the requested on the spec
-func (r *RedisFailoverChecker) CheckRedisNumber(rf *redisfailoverv1.RedisFailover) error {
-	ss, err := r.k8sService.GetStatefulSet(rf.Namespace, GetRedisName(rf))
+func (r *RedisFailoverChecker) CheckRedisNumber(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	ss, err := r.k8sService.GetStatefulSet(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
 	if err != nil {
 		return err
 	}
@@ -66,69 +90,54 @@ func (r *RedisFailoverChecker) CheckRedisNumber(rf *redisfailoverv1.RedisFailove
 	return nil
 }
 
-// CheckSentinelNumber controlls that the number of deployed sentinel is the same than the requested on the spec
-func (r *RedisFailoverChecker) CheckSentinelNumber(rf *redisfailoverv1.RedisFailover) error {
-	d, err := r.k8sService.GetDeployment(rf.Namespace, GetSentinelName(rf))
+// CheckSentinelNumber controlls that the number of deployed sentinel is the same than the requested on the spec,
+// or within the range of the autoscaler of the sentinels
+func (r *RedisFailoverChecker) CheckSentinelNumber(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	d, err := r.k8sService.GetDeployment(ctx, rf.Namespace, GetSentinelName(rf))
 	if err != nil {
 		return err
 	}
+	if rf.SentinelAutoScalingEnabled() {
+		if replicas := *d.Spec.Replicas; replicas < rf.Spec.Sentinel.Replicas || replicas > rf.Spec.SentinelAutoScaling.MaxReplicas {
+			return errors.New("number of sentinel pods out of the autoscaling range")
+		}
+		return nil
+	}
 	if rf.Spec.Sentinel.Replicas != *d.Spec.Replicas {
 		return errors.New("number of sentinel pods differ from specification")
 	}
 	return nil
 }
 
-func (r *RedisFailoverChecker) setMasterLabelIfNecessary(namespace string, pod corev1.Pod) error {
-	for labelKey, labelValue := range pod.ObjectMeta.Labels {
-		if labelKey == redisRoleLabelKey && labelValue == redisRoleLabelMaster {
-			return nil
-		}
-	}
-	return r.k8sService.UpdatePodLabels(namespace, pod.ObjectMeta.Name, generateRedisMasterRoleLabel())
-}
-
-func (r *RedisFailoverChecker) setSlaveLabelIfNecessary(namespace string, pod corev1.Pod) error {
-	for labelKey, labelValue := range pod.ObjectMeta.Labels {
-		if labelKey == redisRoleLabelKey && labelValue == redisRoleLabelSlave {
-			return nil
-		}
+// CheckAllSlavesFromMaster controlls that all slaves have the same master (the real one)
+func (r *RedisFailoverChecker) CheckAllSlavesFromMaster(ctx context.Context, master string, rf *redisfailoverv1.RedisFailover) error {
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return err
 	}
-	return r.k8sService.UpdatePodLabels(namespace, pod.ObjectMeta.Name, generateRedisSlaveRoleLabel())
-}
 
-// CheckAllSlavesFromMaster controlls that all slaves have the same master (the real one)
-func (r *RedisFailoverChecker) CheckAllSlavesFromMaster(master string, rf *redisfailoverv1.RedisFailover) error {
-	rps, err := r.k8sService.GetStatefulSetPods(rf.Namespace, GetRedisName(rf))
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
 	if err != nil {
 		return err
 	}
 
-	password, err := k8s.GetRedisPassword(r.k8sService, rf)
+	// The replicas point to the address announced by the master.
+	addresses, err := getRedisAddresses(r.redisClient, rf, rps.Items, password)
 	if err != nil {
 		return err
 	}
+	announcedMaster := addresses.Announced(master)
 
 	rport := getRedisPort(rf.Spec.Redis.Port)
 	for _, rp := range rps.Items {
-		if rp.Status.PodIP == master {
-			err = r.setMasterLabelIfNecessary(rf.Namespace, rp)
-			if err != nil {
-				return err
-			}
-		} else {
-			err = r.setSlaveLabelIfNecessary(rf.Namespace, rp)
-			if err != nil {
-				return err
-			}
-		}
-
-		slave, err := r.redisClient.GetSlaveOf(rp.Status.PodIP, rport, password)
+		ip := rf.PreferredPodIP(rp.Status)
+		slave, err := r.redisClient.GetSlaveOf(ip, rport, password)
 		if err != nil {
-			r.logger.Errorf("Get slave of master failed, maybe this node is not ready, pod ip: %s", rp.Status.PodIP)
+			r.logger.Errorf("Get slave of master failed, maybe this node is not ready, pod ip: %s", ip)
 			return err
 		}
-		if slave != "" && slave != master {
-			return fmt.Errorf("slave %s don't have the master %s, has %s", rp.Status.PodIP, master, slave)
+		if slave != "" && slave != announcedMaster {
+			return fmt.Errorf("slave %s don't have the master %s, has %s", ip, master, slave)
 		}
 	}
 	return nil
@@ -173,14 +182,20 @@ func (r *RedisFailoverChecker) CheckSentinelMonitor(sentinel string, monitor ...
 	return nil
 }
 
+// CheckSentinelResponding controls that the sentinel answers on the sentinel port. A sentinel may be
+// running and ready for kubernetes while deadlocked, not accepting connections.
+func (r *RedisFailoverChecker) CheckSentinelResponding(sentinel string) error {
+	return r.redisClient.PingSentinel(sentinel)
+}
+
 // GetMasterIP connects to all redis and returns the master of the redis failover
-func (r *RedisFailoverChecker) GetMasterIP(rf *redisfailoverv1.RedisFailover) (string, error) {
-	rips, err := r.GetRedisesIPs(rf)
+func (r *RedisFailoverChecker) GetMasterIP(ctx context.Context, rf *redisfailoverv1.RedisFailover) (string, error) {
+	rips, err := r.GetRedisesIPs(ctx, rf)
 	if err != nil {
 		return "", err
 	}
 
-	password, err := k8s.GetRedisPassword(r.k8sService, rf)
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
 	if err != nil {
 		return "", err
 	}
@@ -205,14 +220,14 @@ func (r *RedisFailoverChecker) GetMasterIP(rf *redisfailoverv1.RedisFailover) (s
 }
 
 // GetNumberMasters returns the number of redis nodes that are working as a master
-func (r *RedisFailoverChecker) GetNumberMasters(rf *redisfailoverv1.RedisFailover) (int, error) {
+func (r *RedisFailoverChecker) GetNumberMasters(ctx context.Context, rf *redisfailoverv1.RedisFailover) (int, error) {
 	nMasters := 0
-	rips, err := r.GetRedisesIPs(rf)
+	rips, err := r.GetRedisesIPs(ctx, rf)
 	if err != nil {
 		return nMasters, err
 	}
 
-	password, err := k8s.GetRedisPassword(r.k8sService, rf)
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
 	if err != nil {
 		return nMasters, err
 	}
@@ -232,39 +247,39 @@ func (r *RedisFailoverChecker) GetNumberMasters(rf *redisfailoverv1.RedisFailove
 }
 
 // GetRedisesIPs returns the IPs of the Redis nodes
-func (r *RedisFailoverChecker) GetRedisesIPs(rf *redisfailoverv1.RedisFailover) ([]string, error) {
+func (r *RedisFailoverChecker) GetRedisesIPs(ctx context.Context, rf *redisfailoverv1.RedisFailover) ([]string, error) {
 	redises := []string{}
-	rps, err := r.k8sService.GetStatefulSetPods(rf.Namespace, GetRedisName(rf))
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
 	if err != nil {
 		return nil, err
 	}
 	for _, rp := range rps.Items {
 		if rp.Status.Phase == corev1.PodRunning && rp.DeletionTimestamp == nil { // Only work with running pods
-			redises = append(redises, rp.Status.PodIP)
+			redises = append(redises, rf.PreferredPodIP(rp.Status))
 		}
 	}
 	return redises, nil
 }
 
 // GetSentinelsIPs returns the IPs of the Sentinel nodes
-func (r *RedisFailoverChecker) GetSentinelsIPs(rf *redisfailoverv1.RedisFailover) ([]string, error) {
+func (r *RedisFailoverChecker) GetSentinelsIPs(ctx context.Context, rf *redisfailoverv1.RedisFailover) ([]string, error) {
 	sentinels := []string{}
-	rps, err := r.k8sService.GetDeploymentPods(rf.Namespace, GetSentinelName(rf))
+	rps, err := r.k8sService.GetDeploymentPods(ctx, rf.Namespace, GetSentinelName(rf))
 	if err != nil {
 		return nil, err
 	}
 	for _, sp := range rps.Items {
 		if sp.Status.Phase == corev1.PodRunning && sp.DeletionTimestamp == nil { // Only work with running pods
-			sentinels = append(sentinels, sp.Status.PodIP)
+			sentinels = append(sentinels, rf.PreferredPodIP(sp.Status))
 		}
 	}
 	return sentinels, nil
 }
 
 // GetMinimumRedisPodTime returns the minimum time a pod is alive
-func (r *RedisFailoverChecker) GetMinimumRedisPodTime(rf *redisfailoverv1.RedisFailover) (time.Duration, error) {
+func (r *RedisFailoverChecker) GetMinimumRedisPodTime(ctx context.Context, rf *redisfailoverv1.RedisFailover) (time.Duration, error) {
 	minTime := 100000 * time.Hour // More than ten years
-	rps, err := r.k8sService.GetStatefulSetPods(rf.Namespace, GetRedisName(rf))
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
 	if err != nil {
 		return minTime, err
 	}
@@ -274,7 +289,7 @@ func (r *RedisFailoverChecker) GetMinimumRedisPodTime(rf *redisfailoverv1.RedisF
 		}
 		start := redisNode.Status.StartTime.Round(time.Second)
 		alive := time.Since(start)
-		r.logger.Debugf("Pod %s has been alive for %.f seconds", redisNode.Status.PodIP, alive.Seconds())
+		r.logger.Debugf("Pod %s has been alive for %.f seconds", rf.PreferredPodIP(redisNode.Status), alive.Seconds())
 		if alive < minTime {
 			minTime = alive
 		}
@@ -283,14 +298,14 @@ func (r *RedisFailoverChecker) GetMinimumRedisPodTime(rf *redisfailoverv1.RedisF
 }
 
 // GetRedisesSlavesPods returns pods names of the Redis slave nodes
-func (r *RedisFailoverChecker) GetRedisesSlavesPods(rf *redisfailoverv1.RedisFailover) ([]string, error) {
+func (r *RedisFailoverChecker) GetRedisesSlavesPods(ctx context.Context, rf *redisfailoverv1.RedisFailover) ([]string, error) {
 	redises := []string{}
-	rps, err := r.k8sService.GetStatefulSetPods(rf.Namespace, GetRedisName(rf))
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
 	if err != nil {
 		return nil, err
 	}
 
-	password, err := k8s.GetRedisPassword(r.k8sService, rf)
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
 	if err != nil {
 		return redises, err
 	}
@@ -298,7 +313,7 @@ func (r *RedisFailoverChecker) GetRedisesSlavesPods(rf *redisfailoverv1.RedisFai
 	rport := getRedisPort(rf.Spec.Redis.Port)
 	for _, rp := range rps.Items {
 		if rp.Status.Phase == corev1.PodRunning && rp.DeletionTimestamp == nil { // Only work with running
-			master, err := r.redisClient.IsMaster(rp.Status.PodIP, rport, password)
+			master, err := r.redisClient.IsMaster(rf.PreferredPodIP(rp.Status), rport, password)
 			if err != nil {
 				return []string{}, err
 			}
@@ -311,13 +326,13 @@ func (r *RedisFailoverChecker) GetRedisesSlavesPods(rf *redisfailoverv1.RedisFai
 }
 
 // GetRedisesMasterPod returns pods names of the Redis slave nodes
-func (r *RedisFailoverChecker) GetRedisesMasterPod(rFailover *redisfailoverv1.RedisFailover) (string, error) {
-	rps, err := r.k8sService.GetStatefulSetPods(rFailover.Namespace, GetRedisName(rFailover))
+func (r *RedisFailoverChecker) GetRedisesMasterPod(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) (string, error) {
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rFailover.Namespace, GetRedisStatefulSetName(rFailover))
 	if err != nil {
 		return "", err
 	}
 
-	password, err := k8s.GetRedisPassword(r.k8sService, rFailover)
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rFailover)
 	if err != nil {
 		return "", err
 	}
@@ -325,7 +340,7 @@ func (r *RedisFailoverChecker) GetRedisesMasterPod(rFailover *redisfailoverv1.Re
 	rport := getRedisPort(rFailover.Spec.Redis.Port)
 	for _, rp := range rps.Items {
 		if rp.Status.Phase == corev1.PodRunning && rp.DeletionTimestamp == nil { // Only work with running
-			master, err := r.redisClient.IsMaster(rp.Status.PodIP, rport, password)
+			master, err := r.redisClient.IsMaster(rFailover.PreferredPodIP(rp.Status), rport, password)
 			if err != nil {
 				return "", err
 			}
@@ -339,8 +354,8 @@ func (r *RedisFailoverChecker) GetRedisesMasterPod(rFailover *redisfailoverv1.Re
 
 // GetStatefulSetUpdateRevision returns current version for the statefulSet
 // If the label don't exists, we return an empty value and no error, so previous versions don't break
-func (r *RedisFailoverChecker) GetStatefulSetUpdateRevision(rFailover *redisfailoverv1.RedisFailover) (string, error) {
-	ss, err := r.k8sService.GetStatefulSet(rFailover.Namespace, GetRedisName(rFailover))
+func (r *RedisFailoverChecker) GetStatefulSetUpdateRevision(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) (string, error) {
+	ss, err := r.k8sService.GetStatefulSet(ctx, rFailover.Namespace, GetRedisStatefulSetName(rFailover))
 	if err != nil {
 		return "", err
 	}
@@ -353,8 +368,8 @@ func (r *RedisFailoverChecker) GetStatefulSetUpdateRevision(rFailover *redisfail
 }
 
 // GetRedisRevisionHash returns the statefulset uid for the pod
-func (r *RedisFailoverChecker) GetRedisRevisionHash(podName string, rFailover *redisfailoverv1.RedisFailover) (string, error) {
-	pod, err := r.k8sService.GetPod(rFailover.Namespace, podName)
+func (r *RedisFailoverChecker) GetRedisRevisionHash(ctx context.Context, podName string, rFailover *redisfailoverv1.RedisFailover) (string, error) {
+	pod, err := r.k8sService.GetPod(ctx, rFailover.Namespace, podName)
 	if err != nil {
 		return "", err
 	}
@@ -373,8 +388,8 @@ func (r *RedisFailoverChecker) GetRedisRevisionHash(podName string, rFailover *r
 }
 
 // CheckRedisSlavesReady returns true if the slave is ready (sync, connected, etc)
-func (r *RedisFailoverChecker) CheckRedisSlavesReady(ip string, rFailover *redisfailoverv1.RedisFailover) (bool, error) {
-	password, err := k8s.GetRedisPassword(r.k8sService, rFailover)
+func (r *RedisFailoverChecker) CheckRedisSlavesReady(ctx context.Context, ip string, rFailover *redisfailoverv1.RedisFailover) (bool, error) {
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rFailover)
 	if err != nil {
 		return false, err
 	}
@@ -383,6 +398,54 @@ func (r *RedisFailoverChecker) CheckRedisSlavesReady(ip string, rFailover *redis
 	return r.redisClient.SlaveIsReady(ip, port, password)
 }
 
+// GetExternalMasters connects to all the external nodes and returns the ones working as master.
+// The nodes that can't be reached are skipped.
+func (r *RedisFailoverChecker) GetExternalMasters(ctx context.Context, rf *redisfailoverv1.RedisFailover) ([]redisfailoverv1.RedisExternalNode, error) {
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return nil, err
+	}
+
+	masters := []redisfailoverv1.RedisExternalNode{}
+	for _, node := range rf.Spec.Redis.ExternalNodes {
+		master, err := r.redisClient.IsMaster(node.Host, node.Port, password)
+		if err != nil {
+			r.logger.Errorf("Get redis info failed, maybe this node is not reachable, external node: %s", net.JoinHostPort(node.Host, node.Port))
+			continue
+		}
+		if master {
+			masters = append(masters, node)
+		}
+	}
+	return masters, nil
+}
+
+// CheckExternalSlavesFromMaster controls that all the external nodes have the given master. The nodes
+// that can't be reached are skipped.
+func (r *RedisFailoverChecker) CheckExternalSlavesFromMaster(ctx context.Context, master redisfailoverv1.RedisExternalNode, rf *redisfailoverv1.RedisFailover) error {
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return err
+	}
+
+	masterAddr := net.JoinHostPort(master.Host, master.Port)
+	for _, node := range rf.Spec.Redis.ExternalNodes {
+		if node == master {
+			continue
+		}
+		host, port, err := r.redisClient.GetSlaveOfWithPort(node.Host, node.Port, password)
+		if err != nil {
+			r.logger.Errorf("Get slave of master failed, maybe this node is not reachable, external node: %s", net.JoinHostPort(node.Host, node.Port))
+			continue
+		}
+		// The external nodes can share a host, the master is told apart by its port.
+		if slave := net.JoinHostPort(host, port); slave != masterAddr {
+			return fmt.Errorf("slave %s don't have the master %s, has %s", net.JoinHostPort(node.Host, node.Port), masterAddr, slave)
+		}
+	}
+	return nil
+}
+
 func getRedisPort(p int32) string {
 	return strconv.Itoa(int(p))
 }
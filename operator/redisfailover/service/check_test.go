@@ -1,13 +1,13 @@
 package service_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
-	"github.com/stretchr/testify/mock"
-
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -33,6 +33,9 @@ func generateRF() *redisfailoverv1.RedisFailover {
 			Sentinel: redisfailoverv1.SentinelSettings{
 				Replicas: int32(3),
 			},
+			Failover: redisfailoverv1.FailoverSettings{
+				MaxConcurrentSyncs: 1,
+			},
 		},
 	}
 }
@@ -43,12 +46,12 @@ func TestCheckRedisNumberError(t *testing.T) {
 	rf := generateRF()
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSet", namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
+	ms.On("GetStatefulSet", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
 	mr := &mRedisService.Client{}
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	err := checker.CheckRedisNumber(rf)
+	err := checker.CheckRedisNumber(context.TODO(), rf)
 	assert.Error(err)
 }
 
@@ -64,12 +67,12 @@ func TestCheckRedisNumberFalse(t *testing.T) {
 		},
 	}
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSet", namespace, rfservice.GetRedisName(rf)).Once().Return(ss, nil)
+	ms.On("GetStatefulSet", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(ss, nil)
 	mr := &mRedisService.Client{}
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	err := checker.CheckRedisNumber(rf)
+	err := checker.CheckRedisNumber(context.TODO(), rf)
 	assert.Error(err)
 }
 
@@ -85,12 +88,12 @@ func TestCheckRedisNumberTrue(t *testing.T) {
 		},
 	}
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSet", namespace, rfservice.GetRedisName(rf)).Once().Return(ss, nil)
+	ms.On("GetStatefulSet", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(ss, nil)
 	mr := &mRedisService.Client{}
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	err := checker.CheckRedisNumber(rf)
+	err := checker.CheckRedisNumber(context.TODO(), rf)
 	assert.NoError(err)
 }
 
@@ -100,12 +103,12 @@ func TestCheckSentinelNumberError(t *testing.T) {
 	rf := generateRF()
 
 	ms := &mK8SService.Services{}
-	ms.On("GetDeployment", namespace, rfservice.GetSentinelName(rf)).Once().Return(nil, errors.New(""))
+	ms.On("GetDeployment", mock.Anything, namespace, rfservice.GetSentinelName(rf)).Once().Return(nil, errors.New(""))
 	mr := &mRedisService.Client{}
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	err := checker.CheckSentinelNumber(rf)
+	err := checker.CheckSentinelNumber(context.TODO(), rf)
 	assert.Error(err)
 }
 
@@ -121,12 +124,12 @@ func TestCheckSentinelNumberFalse(t *testing.T) {
 		},
 	}
 	ms := &mK8SService.Services{}
-	ms.On("GetDeployment", namespace, rfservice.GetSentinelName(rf)).Once().Return(ss, nil)
+	ms.On("GetDeployment", mock.Anything, namespace, rfservice.GetSentinelName(rf)).Once().Return(ss, nil)
 	mr := &mRedisService.Client{}
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	err := checker.CheckSentinelNumber(rf)
+	err := checker.CheckSentinelNumber(context.TODO(), rf)
 	assert.Error(err)
 }
 
@@ -142,28 +145,64 @@ func TestCheckSentinelNumberTrue(t *testing.T) {
 		},
 	}
 	ms := &mK8SService.Services{}
-	ms.On("GetDeployment", namespace, rfservice.GetSentinelName(rf)).Once().Return(ss, nil)
+	ms.On("GetDeployment", mock.Anything, namespace, rfservice.GetSentinelName(rf)).Once().Return(ss, nil)
 	mr := &mRedisService.Client{}
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	err := checker.CheckSentinelNumber(rf)
+	err := checker.CheckSentinelNumber(context.TODO(), rf)
 	assert.NoError(err)
 }
 
+func TestCheckSentinelNumberAutoScaling(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas int32
+		expErr   bool
+	}{
+		{name: "Below the sentinel replicas", replicas: 2, expErr: true},
+		{name: "Scaled up", replicas: 4},
+		{name: "At the max replicas", replicas: 5},
+		{name: "Above the max replicas", replicas: 6, expErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.SentinelAutoScaling = &redisfailoverv1.SentinelAutoScalingSettings{MaxReplicas: 5}
+
+			replicas := test.replicas
+			d := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &replicas}}
+			ms := &mK8SService.Services{}
+			ms.On("GetDeployment", mock.Anything, namespace, rfservice.GetSentinelName(rf)).Once().Return(d, nil)
+			mr := &mRedisService.Client{}
+
+			checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+			err := checker.CheckSentinelNumber(context.TODO(), rf)
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
 func TestCheckAllSlavesFromMasterGetStatefulSetError(t *testing.T) {
 	assert := assert.New(t)
 
 	rf := generateRF()
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Once().Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
 	mr := &mRedisService.Client{}
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	err := checker.CheckAllSlavesFromMaster("", rf)
+	err := checker.CheckAllSlavesFromMaster(context.TODO(), "", rf)
 	assert.Error(err)
 }
 
@@ -184,14 +223,13 @@ func TestCheckAllSlavesFromMasterGetSlaveOfError(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Once().Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("GetSlaveOf", "", "0", "").Once().Return("", errors.New(""))
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	err := checker.CheckAllSlavesFromMaster("", rf)
+	err := checker.CheckAllSlavesFromMaster(context.TODO(), "", rf)
 	assert.Error(err)
 }
 
@@ -212,14 +250,13 @@ func TestCheckAllSlavesFromMasterDifferentMaster(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Once().Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("GetSlaveOf", "0.0.0.0", "0", "").Once().Return("1.1.1.1", nil)
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	err := checker.CheckAllSlavesFromMaster("0.0.0.0", rf)
+	err := checker.CheckAllSlavesFromMaster(context.TODO(), "0.0.0.0", rf)
 	assert.Error(err)
 }
 
@@ -240,14 +277,13 @@ func TestCheckAllSlavesFromMaster(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Once().Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("GetSlaveOf", "0.0.0.0", "0", "").Once().Return("1.1.1.1", nil)
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	err := checker.CheckAllSlavesFromMaster("1.1.1.1", rf)
+	err := checker.CheckAllSlavesFromMaster(context.TODO(), "1.1.1.1", rf)
 	assert.NoError(err)
 }
 
@@ -396,6 +432,32 @@ func TestCheckSentinelMonitor(t *testing.T) {
 	assert.NoError(err)
 }
 
+func TestCheckSentinelRespondingError(t *testing.T) {
+	assert := assert.New(t)
+
+	ms := &mK8SService.Services{}
+	mr := &mRedisService.Client{}
+	mr.On("PingSentinel", "0.0.0.0").Once().Return(errors.New("i/o timeout"))
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	err := checker.CheckSentinelResponding("0.0.0.0")
+	assert.Error(err)
+}
+
+func TestCheckSentinelResponding(t *testing.T) {
+	assert := assert.New(t)
+
+	ms := &mK8SService.Services{}
+	mr := &mRedisService.Client{}
+	mr.On("PingSentinel", "0.0.0.0").Once().Return(nil)
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	err := checker.CheckSentinelResponding("0.0.0.0")
+	assert.NoError(err)
+}
+
 func TestCheckSentinelMonitorWithPort(t *testing.T) {
 	assert := assert.New(t)
 
@@ -441,12 +503,12 @@ func TestGetMasterIPGetStatefulSetPodsError(t *testing.T) {
 	rf := generateRF()
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
 	mr := &mRedisService.Client{}
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	_, err := checker.GetMasterIP(rf)
+	_, err := checker.GetMasterIP(context.TODO(), rf)
 	assert.Error(err)
 }
 
@@ -467,13 +529,13 @@ func TestGetMasterIPIsMasterError(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("IsMaster", "0.0.0.0", "0", "").Once().Return(false, errors.New(""))
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	_, err := checker.GetMasterIP(rf)
+	_, err := checker.GetMasterIP(context.TODO(), rf)
 	assert.Error(err)
 }
 
@@ -500,14 +562,14 @@ func TestGetMasterIPMultipleMastersError(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("IsMaster", "0.0.0.0", "0", "").Once().Return(true, nil)
 	mr.On("IsMaster", "1.1.1.1", "0", "").Once().Return(true, nil)
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	_, err := checker.GetMasterIP(rf)
+	_, err := checker.GetMasterIP(context.TODO(), rf)
 	assert.Error(err)
 }
 
@@ -534,30 +596,70 @@ func TestGetMasterIP(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("IsMaster", "0.0.0.0", "0", "").Once().Return(true, nil)
 	mr.On("IsMaster", "1.1.1.1", "0", "").Once().Return(false, nil)
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	master, err := checker.GetMasterIP(rf)
+	master, err := checker.GetMasterIP(context.TODO(), rf)
 	assert.NoError(err)
 	assert.Equal("0.0.0.0", master, "the master should be the expected")
 }
 
+func TestGetMasterIPPreferredIPFamily(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.PreferredIPFamily = corev1.IPv6Protocol
+
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			{
+				Status: corev1.PodStatus{
+					PodIP:  "10.0.0.1",
+					PodIPs: []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+					Phase:  corev1.PodRunning,
+				},
+			},
+			{
+				Status: corev1.PodStatus{
+					PodIP:  "10.0.0.2",
+					PodIPs: []corev1.PodIP{{IP: "10.0.0.2"}},
+					Phase:  corev1.PodRunning,
+				},
+			},
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	mr := &mRedisService.Client{}
+	// The dual-stack pod is connected to with its IPv6, the single-stack one with its only IP.
+	mr.On("IsMaster", "fd00::1", "0", "").Once().Return(true, nil)
+	mr.On("IsMaster", "10.0.0.2", "0", "").Once().Return(false, nil)
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	master, err := checker.GetMasterIP(context.TODO(), rf)
+	assert.NoError(err)
+	assert.Equal("fd00::1", master)
+	mr.AssertExpectations(t)
+}
+
 func TestGetNumberMastersGetStatefulSetPodsError(t *testing.T) {
 	assert := assert.New(t)
 
 	rf := generateRF()
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
 	mr := &mRedisService.Client{}
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	_, err := checker.GetNumberMasters(rf)
+	_, err := checker.GetNumberMasters(context.TODO(), rf)
 	assert.Error(err)
 }
 
@@ -578,13 +680,13 @@ func TestGetNumberMastersIsMasterError(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("IsMaster", "0.0.0.0", "0", "").Once().Return(true, errors.New(""))
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	_, err := checker.GetNumberMasters(rf)
+	_, err := checker.GetNumberMasters(context.TODO(), rf)
 	assert.NoError(err)
 }
 
@@ -611,14 +713,14 @@ func TestGetNumberMasters(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("IsMaster", "0.0.0.0", "0", "").Once().Return(true, nil)
 	mr.On("IsMaster", "1.1.1.1", "0", "").Once().Return(false, nil)
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	masterNumber, err := checker.GetNumberMasters(rf)
+	masterNumber, err := checker.GetNumberMasters(context.TODO(), rf)
 	assert.NoError(err)
 	assert.Equal(1, masterNumber, "the master number should be ok")
 }
@@ -646,14 +748,14 @@ func TestGetNumberMastersTwo(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("IsMaster", "0.0.0.0", "0", "").Once().Return(true, nil)
 	mr.On("IsMaster", "1.1.1.1", "0", "").Once().Return(true, nil)
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	masterNumber, err := checker.GetNumberMasters(rf)
+	masterNumber, err := checker.GetNumberMasters(context.TODO(), rf)
 	assert.NoError(err)
 	assert.Equal(2, masterNumber, "the master number should be ok")
 }
@@ -664,12 +766,12 @@ func TestGetMinimumRedisPodTimeGetStatefulSetPodsError(t *testing.T) {
 	rf := generateRF()
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
 	mr := &mRedisService.Client{}
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	_, err := checker.GetMinimumRedisPodTime(rf)
+	_, err := checker.GetMinimumRedisPodTime(context.TODO(), rf)
 	assert.Error(err)
 }
 
@@ -702,12 +804,12 @@ func TestGetMinimumRedisPodTime(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
 
-	minTime, err := checker.GetMinimumRedisPodTime(rf)
+	minTime, err := checker.GetMinimumRedisPodTime(context.TODO(), rf)
 	assert.NoError(err)
 
 	expected := now.Sub(oneMinute).Round(time.Second)
@@ -751,23 +853,23 @@ func TestGetRedisPodsNames(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("IsMaster", "0.0.0.0", "0", "").Twice().Return(false, nil)
 	mr.On("IsMaster", "1.1.1.1", "0", "").Once().Return(true, nil)
 
 	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
-	master, err := checker.GetRedisesMasterPod(rf)
+	master, err := checker.GetRedisesMasterPod(context.TODO(), rf)
 
 	assert.NoError(err)
 
 	assert.Equal(master, "master")
 
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr.On("IsMaster", "0.0.0.0", "0", "").Twice().Return(false, nil)
 	mr.On("IsMaster", "1.1.1.1", "0", "").Once().Return(true, nil)
 
-	namePods, err := checker.GetRedisesSlavesPods(rf)
+	namePods, err := checker.GetRedisesSlavesPods(context.TODO(), rf)
 
 	assert.NoError(err)
 
@@ -804,11 +906,11 @@ func TestGetStatefulSetUpdateRevision(t *testing.T) {
 
 		rf := generateRF()
 		ms := &mK8SService.Services{}
-		ms.On("GetStatefulSet", namespace, rfservice.GetRedisName(rf)).Once().Return(test.ss, nil)
+		ms.On("GetStatefulSet", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(test.ss, nil)
 		mr := &mRedisService.Client{}
 
 		checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
-		version, err := checker.GetStatefulSetUpdateRevision(rf)
+		version, err := checker.GetStatefulSetUpdateRevision(context.TODO(), rf)
 
 		if test.expectedError == nil {
 			assert.NoError(err)
@@ -853,11 +955,11 @@ func TestGetRedisRevisionHash(t *testing.T) {
 
 		rf := generateRF()
 		ms := &mK8SService.Services{}
-		ms.On("GetPod", namespace, "namepod").Once().Return(test.pod, nil)
+		ms.On("GetPod", mock.Anything, namespace, "namepod").Once().Return(test.pod, nil)
 		mr := &mRedisService.Client{}
 
 		checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
-		hash, err := checker.GetRedisRevisionHash("namepod", rf)
+		hash, err := checker.GetRedisRevisionHash(context.TODO(), "namepod", rf)
 
 		if test.expectedError == nil {
 			assert.NoError(err)
@@ -869,3 +971,91 @@ func TestGetRedisRevisionHash(t *testing.T) {
 	}
 
 }
+
+func TestGetExternalMasters(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.ExternalNodes = []redisfailoverv1.RedisExternalNode{
+		{Host: "10.0.0.1", Port: "6379"},
+		{Host: "10.0.0.2", Port: "6380"},
+		{Host: "10.0.0.3", Port: "6379"},
+	}
+
+	// There is no statefulset for the external nodes, the k8s service must not be asked for it.
+	ms := &mK8SService.Services{}
+	mr := &mRedisService.Client{}
+	mr.On("IsMaster", "10.0.0.1", "6379", "").Once().Return(false, nil)
+	mr.On("IsMaster", "10.0.0.2", "6380", "").Once().Return(true, nil)
+	mr.On("IsMaster", "10.0.0.3", "6379", "").Once().Return(false, errors.New("connection refused"))
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	masters, err := checker.GetExternalMasters(context.TODO(), rf)
+	assert.NoError(err)
+	assert.Equal([]redisfailoverv1.RedisExternalNode{{Host: "10.0.0.2", Port: "6380"}}, masters)
+	ms.AssertExpectations(t)
+	mr.AssertExpectations(t)
+}
+
+func TestCheckExternalSlavesFromMaster(t *testing.T) {
+	master := redisfailoverv1.RedisExternalNode{Host: "10.0.0.1", Port: "6379"}
+	tests := []struct {
+		name        string
+		slaveOf     string
+		slaveOfPort string
+		slaveOfErr  error
+		expErr      bool
+	}{
+		{
+			name:        "All the slaves have the master",
+			slaveOf:     "10.0.0.1",
+			slaveOfPort: "6379",
+		},
+		{
+			name:        "A slave has another master",
+			slaveOf:     "10.0.0.9",
+			slaveOfPort: "6379",
+			expErr:      true,
+		},
+		{
+			name:        "A slave has another node of the host of the master as master",
+			slaveOf:     "10.0.0.1",
+			slaveOfPort: "6381",
+			expErr:      true,
+		},
+		{
+			name:    "A slave is a master",
+			slaveOf: "",
+			expErr:  true,
+		},
+		{
+			name:       "A slave not reachable is skipped",
+			slaveOfErr: errors.New("connection refused"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.ExternalNodes = []redisfailoverv1.RedisExternalNode{master, {Host: "10.0.0.2", Port: "6380"}}
+
+			ms := &mK8SService.Services{}
+			mr := &mRedisService.Client{}
+			mr.On("GetSlaveOfWithPort", "10.0.0.2", "6380", "").Once().Return(test.slaveOf, test.slaveOfPort, test.slaveOfErr)
+
+			checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+			err := checker.CheckExternalSlavesFromMaster(context.TODO(), master, rf)
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			ms.AssertExpectations(t)
+			mr.AssertExpectations(t)
+		})
+	}
+}
@@ -1,8 +1,20 @@
 package service
 
 import (
+	"context"
+	goerrors "errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	redisfailoverv1 "redis-operator/api/redisfailover/v1"
 	"redis-operator/log"
@@ -14,15 +26,8 @@ import (
 // RedisFailoverClient has the minimumm methods that a Redis failover controller needs to satisfy
 // in order to talk with K8s
 type RedisFailoverClient interface {
-	EnsureSentinelService(rFailover *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error
-	EnsureSentinelConfigMap(rFailover *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error
-	EnsureSentinelDeployment(rFailover *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error
-	EnsureRedisStatefulset(rFailover *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error
-	EnsureRedisService(rFailover *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error
-	EnsureRedisShutdownConfigMap(rFailover *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error
-	EnsureRedisReadinessConfigMap(rFailover *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error
-	EnsureRedisConfigMap(rFailover *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error
-	EnsureNotPresentRedisService(rFailover *redisfailoverv1.RedisFailover) error
+	EnsureDesiredState(ctx context.Context, rFailover *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error
+	Teardown(ctx context.Context, rFailover *redisfailoverv1.RedisFailover, stage string) (string, error)
 }
 
 // RedisFailoverKubeClient implements the required methods to talk with kubernetes
@@ -30,6 +35,15 @@ type RedisFailoverKubeClient struct {
 	K8SService    k8s.Services
 	logger        log.Logger
 	metricsClient metrics.Recorder
+	// DesiredStates is optional, without it the desired state is rendered on every reconcile.
+	DesiredStates *DesiredStateCache
+	// FeatureGates resolves the feature gates of a RF, without it the gates have their default.
+	FeatureGates func(rf *redisfailoverv1.RedisFailover) FeatureGates
+	// ServerSideApply writes the kinds supporting it with a server-side apply instead of a create or an
+	// update.
+	ServerSideApply bool
+	// Events creates an event on the RF, without it the writes of the redis statefulset are only logged.
+	Events func(ctx context.Context, rf *redisfailoverv1.RedisFailover, eventType, reason, message string)
 }
 
 // NewRedisFailoverKubeClient creates a new RedisFailoverKubeClient
@@ -41,6 +55,172 @@ func NewRedisFailoverKubeClient(k8sService k8s.Services, logger log.Logger, metr
 	}
 }
 
+// kindFuncs are the functions to write, read and delete the objects of a kind. serverSideApply is nil
+// for the kinds always created or updated.
+type kindFuncs struct {
+	apply           func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error
+	serverSideApply func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error
+	get             func(ctx context.Context, s k8s.Services, namespace, name string) error
+	delete          func(ctx context.Context, s k8s.Services, namespace, name string) error
+}
+
+// objectKinds are the functions of every kind generated for a RF.
+var objectKinds = map[string]kindFuncs{
+	KindService: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdateService(ctx, namespace, obj.(*corev1.Service))
+		},
+		serverSideApply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.ApplyService(ctx, namespace, obj.(*corev1.Service))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetService(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeleteService(ctx, namespace, name)
+		},
+	},
+	KindServiceAccount: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdateServiceAccount(ctx, namespace, obj.(*corev1.ServiceAccount))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetServiceAccount(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeleteServiceAccount(ctx, namespace, name)
+		},
+	},
+	KindRole: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdateRole(ctx, namespace, obj.(*rbacv1.Role))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetRole(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeleteRole(ctx, namespace, name)
+		},
+	},
+	KindRoleBinding: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdateRoleBinding(ctx, namespace, obj.(*rbacv1.RoleBinding))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetRoleBinding(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeleteRoleBinding(ctx, namespace, name)
+		},
+	},
+	KindConfigMap: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdateConfigMap(ctx, namespace, obj.(*corev1.ConfigMap))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetConfigMap(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeleteConfigMap(ctx, namespace, name)
+		},
+	},
+	KindPodDisruptionBudget: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdatePodDisruptionBudget(ctx, namespace, obj.(*policyv1.PodDisruptionBudget))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetPodDisruptionBudget(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeletePodDisruptionBudget(ctx, namespace, name)
+		},
+	},
+	KindNetworkPolicy: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdateNetworkPolicy(ctx, namespace, obj.(*networkingv1.NetworkPolicy))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetNetworkPolicy(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeleteNetworkPolicy(ctx, namespace, name)
+		},
+	},
+	KindHPA: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdateHPA(ctx, namespace, obj.(*autoscalingv2.HorizontalPodAutoscaler))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetHPA(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeleteHPA(ctx, namespace, name)
+		},
+	},
+	KindCertificate: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdateCertificate(ctx, namespace, obj.(*unstructured.Unstructured))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetCertificate(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeleteCertificate(ctx, namespace, name)
+		},
+	},
+	KindServiceMonitor: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdateServiceMonitor(ctx, namespace, obj.(*unstructured.Unstructured))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetServiceMonitor(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeleteServiceMonitor(ctx, namespace, name)
+		},
+	},
+	KindStatefulSet: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdateStatefulSet(ctx, namespace, obj.(*appsv1.StatefulSet))
+		},
+		serverSideApply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.ApplyStatefulSet(ctx, namespace, obj.(*appsv1.StatefulSet))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetStatefulSet(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeleteStatefulSet(ctx, namespace, name)
+		},
+	},
+	KindDeployment: {
+		apply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.CreateOrUpdateDeployment(ctx, namespace, obj.(*appsv1.Deployment))
+		},
+		serverSideApply: func(ctx context.Context, s k8s.Services, namespace string, obj runtime.Object) error {
+			return s.ApplyDeployment(ctx, namespace, obj.(*appsv1.Deployment))
+		},
+		get: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			_, err := s.GetDeployment(ctx, namespace, name)
+			return err
+		},
+		delete: func(ctx context.Context, s k8s.Services, namespace, name string) error {
+			return s.DeleteDeployment(ctx, namespace, name)
+		},
+	},
+}
+
 func generateSelectorLabels(component, name string) map[string]string {
 	return map[string]string{
 		"app.kubernetes.io/name":      name,
@@ -65,120 +245,416 @@ func generateRedisSlaveRoleLabel() map[string]string {
 	}
 }
 
+// EnsureDesiredState makes sure the objects of the desired state of the RF exist as generated. The
+// objects given in the spec must exist, and the ones of the components not deployed are deleted.
+func (r *RedisFailoverKubeClient) EnsureDesiredState(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+	password := ""
+	if GetComponents(rf).Redis {
+		p, err := k8s.GetRedisPassword(ctx, r.K8SService, rf)
+		if err != nil {
+			return err
+		}
+		password = p
+	}
+
+	state, err := r.buildDesiredState(rf, labels, ownerRefs, password)
+	if err != nil {
+		return err
+	}
+	for _, ref := range state.Absent {
+		if err := r.ensureAbsent(ctx, rf.Namespace, ref); err != nil {
+			return err
+		}
+	}
+	for _, ref := range state.Required {
+		if err := r.ensurePresent(ctx, rf.Namespace, ref); err != nil {
+			return err
+		}
+	}
+	// The objects are written in the order of their dependencies, a failure stops before the objects
+	// depending on the one that failed.
+	var skipped error
+	for _, o := range state.Objects {
+		if IsWorkload(o.Kind) {
+			if err := r.ensureConfigMapsReadable(ctx, rf, o.Ref(), state); err != nil {
+				return err
+			}
+		}
+		if err := r.apply(ctx, rf, o.Kind, o.Object); err != nil {
+			if isCertificateSkipped(o.Kind, err) {
+				r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("the certificate %s is not written, the secret %s must be created by hand: %s", o.Name, GetRedisTLSSecretName(rf), err)
+				continue
+			}
+			if isServiceMonitorSkipped(o.Kind, err) {
+				r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("the service monitor %s is not written, the prometheus-operator CRDs are not installed: %s", o.Name, err)
+				continue
+			}
+			if !isPodDisruptionBudgetSkipped(o.Kind, err) {
+				return err
+			}
+			skipped = err
+		}
+	}
+	if err := r.deleteStaleConfigMaps(ctx, rf, state); err != nil {
+		return err
+	}
+	return skipped
+}
+
+// deleteStaleConfigMaps deletes the configuration ConfigMaps the pods don't mount anymore: the parts of
+// a previous split in more parts, or the revisions older than the last two with immutableConfig.
+func (r *RedisFailoverKubeClient) deleteStaleConfigMaps(ctx context.Context, rf *redisfailoverv1.RedisFailover, state *DesiredState) error {
+	if !rf.ImmutableConfigEnabled() {
+		if state.Components.Redis {
+			return r.deleteStaleRedisConfigParts(ctx, rf, state.RedisConfigParts)
+		}
+		return nil
+	}
+	if state.Components.Redis {
+		if err := r.deleteConfigRevisions(ctx, rf, redisRoleName, state.RedisConfigRevision, legacyRedisConfigNames(rf)); err != nil {
+			return err
+		}
+	}
+	if state.Components.Sentinel {
+		return r.deleteConfigRevisions(ctx, rf, sentinelRoleName, state.SentinelConfigRevision, []string{GetSentinelName(rf)})
+	}
+	return nil
+}
+
+// isPodDisruptionBudgetSkipped returns true when the object is a PodDisruptionBudget that can't be
+// written because the cluster serves no PodDisruptionBudget API. The workloads are written without it,
+// and ErrPodDisruptionBudgetsUnavailable is returned once the rest of the desired state is written.
+func isPodDisruptionBudgetSkipped(kind string, err error) bool {
+	return kind == KindPodDisruptionBudget && goerrors.Is(err, k8s.ErrPodDisruptionBudgetsUnavailable)
+}
+
+// isCertificateSkipped returns true when the object is a Certificate that can't be written because
+// cert-manager is not installed. The redis pods are written without it and wait for the secret.
+func isCertificateSkipped(kind string, err error) bool {
+	return kind == KindCertificate && goerrors.Is(err, k8s.ErrCertificatesUnavailable)
+}
+
+// isServiceMonitorSkipped returns true when the object is a ServiceMonitor that can't be written
+// because the prometheus-operator is not installed. The exporter is still scraped by its annotations.
+func isServiceMonitorSkipped(kind string, err error) bool {
+	return kind == KindServiceMonitor && goerrors.Is(err, k8s.ErrServiceMonitorsUnavailable)
+}
+
+// buildDesiredState returns the desired state of the RF, from the cache when there's one.
+func (r *RedisFailoverKubeClient) buildDesiredState(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference, password string) (*DesiredState, error) {
+	if r.DesiredStates != nil {
+		return r.DesiredStates.Get(rf, labels, ownerRefs, password)
+	}
+	return BuildDesiredState(rf, labels, ownerRefs, password)
+}
+
 // EnsureSentinelService makes sure the sentinel service exists
-func (r *RedisFailoverKubeClient) EnsureSentinelService(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
-	svc := generateSentinelService(rf, labels, ownerRefs)
-	err := r.K8SService.CreateOrUpdateService(rf.Namespace, svc)
-	r.setEnsureOperationMetrics(svc.Namespace, svc.Name, "Service", rf.Name, err)
-	return err
+func (r *RedisFailoverKubeClient) EnsureSentinelService(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+	return r.apply(ctx, rf, KindService, generateSentinelService(rf, labels, ownerRefs))
 }
 
 // EnsureSentinelConfigMap makes sure the sentinel configmap exists
-func (r *RedisFailoverKubeClient) EnsureSentinelConfigMap(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
-	cm := generateSentinelConfigMap(rf, labels, ownerRefs)
-	err := r.K8SService.CreateOrUpdateConfigMap(rf.Namespace, cm)
-	r.setEnsureOperationMetrics(cm.Namespace, cm.Name, "ConfigMap", rf.Name, err)
-	return err
+func (r *RedisFailoverKubeClient) EnsureSentinelConfigMap(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+	return r.apply(ctx, rf, KindConfigMap, generateSentinelConfigMap(rf, labels, ownerRefs))
 }
 
 // EnsureSentinelDeployment makes sure the sentinel deployment exists in the desired state
-func (r *RedisFailoverKubeClient) EnsureSentinelDeployment(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
-	if err := r.ensurePodDisruptionBudget(rf, sentinelName, sentinelRoleName, labels, ownerRefs); err != nil {
+func (r *RedisFailoverKubeClient) EnsureSentinelDeployment(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+	if err := r.apply(ctx, rf, KindPodDisruptionBudget, generateRedisFailoverPodDisruptionBudget(rf, sentinelName, sentinelRoleName, labels, ownerRefs)); err != nil && !isPodDisruptionBudgetSkipped(KindPodDisruptionBudget, err) {
 		return err
 	}
-	d := generateSentinelDeployment(rf, labels, ownerRefs)
-	err := r.K8SService.CreateOrUpdateDeployment(rf.Namespace, d)
-
-	r.setEnsureOperationMetrics(d.Namespace, d.Name, "Deployment", rf.Name, err)
-	return err
+	return r.apply(ctx, rf, KindDeployment, generateSentinelDeployment(rf, labels, ownerRefs))
 }
 
 // EnsureRedisStatefulset makes sure the redis statefulset exists in the desired state
-func (r *RedisFailoverKubeClient) EnsureRedisStatefulset(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
-	if err := r.ensurePodDisruptionBudget(rf, redisName, redisRoleName, labels, ownerRefs); err != nil {
+func (r *RedisFailoverKubeClient) EnsureRedisStatefulset(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+	if err := r.apply(ctx, rf, KindPodDisruptionBudget, generateRedisFailoverPodDisruptionBudget(rf, redisName, redisRoleName, labels, ownerRefs)); err != nil && !isPodDisruptionBudgetSkipped(KindPodDisruptionBudget, err) {
 		return err
 	}
-	ss := generateRedisStatefulSet(rf, labels, ownerRefs)
-	err := r.K8SService.CreateOrUpdateStatefulSet(rf.Namespace, ss)
-
-	r.setEnsureOperationMetrics(ss.Namespace, ss.Name, "StatefulSet", rf.Name, err)
-	return err
+	configParts, err := renderRedisConfig(rf)
+	if err != nil {
+		return err
+	}
+	configRevision, password := "", ""
+	if rf.ImmutableConfigEnabled() || rf.Spec.Auth.GenerateSecret {
+		// The configuration revision is the hash of the whole configuration, the password included, the
+		// generated password is hashed in the pod template.
+		password, err = k8s.GetRedisPassword(ctx, r.K8SService, rf)
+		if err != nil {
+			return err
+		}
+	}
+	if rf.ImmutableConfigEnabled() {
+		cms, err := generateRedisConfigMaps(rf, labels, ownerRefs, password)
+		if err != nil {
+			return err
+		}
+		configRevision = cms[len(cms)-1].Labels[ConfigRevisionLabel]
+	}
+	ss := generateRedisStatefulSet(rf, labels, ownerRefs, len(configParts), configRevision)
+	setPasswordRevision(rf, ss, password)
+	return r.apply(ctx, rf, KindStatefulSet, ss)
 }
 
 // EnsureRedisConfigMap makes sure the Redis ConfigMap exists
-func (r *RedisFailoverKubeClient) EnsureRedisConfigMap(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+func (r *RedisFailoverKubeClient) EnsureRedisConfigMap(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
 
-	password, err := k8s.GetRedisPassword(r.K8SService, rf)
+	password, err := k8s.GetRedisPassword(ctx, r.K8SService, rf)
 	if err != nil {
 		return err
 	}
 
-	cm := generateRedisConfigMap(rf, labels, ownerRefs, password)
-	err = r.K8SService.CreateOrUpdateConfigMap(rf.Namespace, cm)
+	cms, err := generateRedisConfigMaps(rf, labels, ownerRefs, password)
+	if err != nil {
+		return err
+	}
+	for _, cm := range cms {
+		if err := r.apply(ctx, rf, KindConfigMap, cm); err != nil {
+			return err
+		}
+	}
 
-	r.setEnsureOperationMetrics(cm.Namespace, cm.Name, "ConfigMap", rf.Name, err)
-	return err
+	if rf.ImmutableConfigEnabled() {
+		return r.deleteConfigRevisions(ctx, rf, redisRoleName, cms[len(cms)-1].Labels[ConfigRevisionLabel], legacyRedisConfigNames(rf))
+	}
+	return r.deleteStaleRedisConfigParts(ctx, rf, len(cms)-1)
+}
+
+// deleteStaleRedisConfigParts deletes the parts left by a configuration that was split in more parts
+// than the current one. The parts are numbered in sequence, so it stops at the first one not found.
+func (r *RedisFailoverKubeClient) deleteStaleRedisConfigParts(ctx context.Context, rf *redisfailoverv1.RedisFailover, parts int) error {
+	for i := parts; i < maxRedisConfigParts; i++ {
+		name := GetRedisConfigPartName(rf, i)
+		if _, err := r.K8SService.GetConfigMap(ctx, rf.Namespace, name); err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if err := r.K8SService.DeleteConfigMap(ctx, rf.Namespace, name); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // EnsureRedisShutdownConfigMap makes sure the redis configmap with shutdown script exists
-func (r *RedisFailoverKubeClient) EnsureRedisShutdownConfigMap(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+func (r *RedisFailoverKubeClient) EnsureRedisShutdownConfigMap(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
 	if rf.Spec.Redis.ShutdownConfigMap != "" {
-		if _, err := r.K8SService.GetConfigMap(rf.Namespace, rf.Spec.Redis.ShutdownConfigMap); err != nil {
+		if _, err := r.K8SService.GetConfigMap(ctx, rf.Namespace, rf.Spec.Redis.ShutdownConfigMap); err != nil {
 			return err
 		}
-	} else {
-		cm := generateRedisShutdownConfigMap(rf, labels, ownerRefs)
-		err := r.K8SService.CreateOrUpdateConfigMap(rf.Namespace, cm)
-		r.setEnsureOperationMetrics(cm.Namespace, cm.Name, "ConfigMap", rf.Name, err)
-		return err
+		return nil
 	}
-	return nil
+	return r.apply(ctx, rf, KindConfigMap, generateRedisShutdownConfigMap(rf, labels, ownerRefs))
 }
 
 // EnsureRedisReadinessConfigMap makes sure the redis configmap with shutdown script exists
-func (r *RedisFailoverKubeClient) EnsureRedisReadinessConfigMap(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
-	cm := generateRedisReadinessConfigMap(rf, labels, ownerRefs)
-	err := r.K8SService.CreateOrUpdateConfigMap(rf.Namespace, cm)
-	r.setEnsureOperationMetrics(cm.Namespace, cm.Name, "ConfigMap", rf.Name, err)
-	return err
+func (r *RedisFailoverKubeClient) EnsureRedisReadinessConfigMap(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+	return r.apply(ctx, rf, KindConfigMap, generateRedisReadinessConfigMap(rf, labels, ownerRefs))
 }
 
 // EnsureRedisService makes sure the redis statefulset exists
-func (r *RedisFailoverKubeClient) EnsureRedisService(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
-	svc := generateRedisService(rf, labels, ownerRefs)
-	err := r.K8SService.CreateOrUpdateService(rf.Namespace, svc)
+func (r *RedisFailoverKubeClient) EnsureRedisService(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+	return r.apply(ctx, rf, KindService, generateRedisService(rf, labels, ownerRefs))
+}
 
-	r.setEnsureOperationMetrics(svc.Namespace, svc.Name, "Service", rf.Name, err)
+// EnsureNotPresentRedisService makes sure the redis service is not present
+func (r *RedisFailoverKubeClient) EnsureNotPresentRedisService(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	return r.ensureAbsent(ctx, rf.Namespace, ObjectRef{Kind: KindService, Name: GetRedisName(rf)})
+}
+
+// EnsureRedisMasterService makes sure the redis master service exists
+func (r *RedisFailoverKubeClient) EnsureRedisMasterService(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+	return r.apply(ctx, rf, KindService, generateRedisMasterService(rf, labels, ownerRefs))
+}
+
+// EnsureNotPresentRedisMasterService makes sure the redis master service is not present
+func (r *RedisFailoverKubeClient) EnsureNotPresentRedisMasterService(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	return r.ensureAbsent(ctx, rf.Namespace, ObjectRef{Kind: KindService, Name: GetRedisMasterName(rf)})
+}
+
+// apply creates or updates a generated object of the RF with the functions of its kind.
+func (r *RedisFailoverKubeClient) apply(ctx context.Context, rf *redisfailoverv1.RedisFailover, kind string, obj runtime.Object) error {
+	funcs, ok := objectKinds[kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %s", kind)
+	}
+	var stored *appsv1.StatefulSet
+	if ss, ok := obj.(*appsv1.StatefulSet); ok {
+		var err error
+		if stored, err = r.keepStatefulSetFields(ctx, rf, ss); err != nil {
+			return err
+		}
+	}
+	if d, ok := obj.(*appsv1.Deployment); ok {
+		if err := r.keepDeploymentTemplate(ctx, rf, d); err != nil {
+			return err
+		}
+	}
+	var err error
+	ss, isStatefulSet := obj.(*appsv1.StatefulSet)
+	switch {
+	case r.ServerSideApply && funcs.serverSideApply != nil:
+		err = funcs.serverSideApply(ctx, r.K8SService, rf.Namespace, obj)
+	case isStatefulSet && r.featureGates(rf).PatchStatefulSets:
+		err = r.K8SService.CreateOrPatchStatefulSet(ctx, rf.Namespace, ss)
+	default:
+		err = funcs.apply(ctx, r.K8SService, rf.Namespace, obj)
+	}
+	r.setEnsureOperationMetrics(rf.Namespace, obj.(metav1.Object).GetName(), kind, rf.Name, err)
+	if err == nil && isStatefulSet {
+		r.recordStatefulSetWrite(ctx, rf, ss, stored)
+	}
 	return err
 }
 
-// EnsureNotPresentRedisService makes sure the redis service is not present
-func (r *RedisFailoverKubeClient) EnsureNotPresentRedisService(rf *redisfailoverv1.RedisFailover) error {
-	name := GetRedisName(rf)
-	namespace := rf.Namespace
-	// If the service exists (no get error), delete it
-	if _, err := r.K8SService.GetService(namespace, name); err == nil {
-		return r.K8SService.DeleteService(namespace, name)
+// recordStatefulSetWrite creates an event on the RF when the statefulset was created, or when its pod
+// template changed and its pods are rolled. The stored statefulset is nil when it did not exist.
+func (r *RedisFailoverKubeClient) recordStatefulSetWrite(ctx context.Context, rf *redisfailoverv1.RedisFailover, ss *appsv1.StatefulSet, stored *appsv1.StatefulSet) {
+	if r.Events == nil {
+		return
+	}
+	switch {
+	case stored == nil:
+		r.Events(ctx, rf, corev1.EventTypeNormal, statefulSetCreatedReason, fmt.Sprintf("statefulset %s was created", ss.Name))
+	case stored.Annotations[templateHashAnnotation] != ss.Annotations[templateHashAnnotation]:
+		r.Events(ctx, rf, corev1.EventTypeNormal, statefulSetUpdatedReason, fmt.Sprintf("the pod template of the statefulset %s was updated, its pods are rolled", ss.Name))
+	}
+}
+
+// featureGates returns the feature gates of the RF.
+func (r *RedisFailoverKubeClient) featureGates(rf *redisfailoverv1.RedisFailover) FeatureGates {
+	if r.FeatureGates == nil {
+		return NewFeatureGates()
+	}
+	return r.FeatureGates(rf)
+}
+
+// ensureConfigMapsReadable reads back the ConfigMaps of the desired state before the workload is
+// created, so its first pods don't crash on a ConfigMap that can't be mounted yet. The workload is
+// created on a later reconcile when one of them can't be read. Existing workloads are not checked.
+func (r *RedisFailoverKubeClient) ensureConfigMapsReadable(ctx context.Context, rf *redisfailoverv1.RedisFailover, workload ObjectRef, state *DesiredState) error {
+	err := objectKinds[workload.Kind].get(ctx, r.K8SService, rf.Namespace, workload.Name)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+	for _, o := range state.Objects {
+		if o.Kind != KindConfigMap {
+			continue
+		}
+		if _, err := r.K8SService.GetConfigMap(ctx, rf.Namespace, o.Name); err != nil {
+			return fmt.Errorf("the %s %s is not created before the ConfigMap %s can be read: %w", workload.Kind, workload.Name, o.Name, err)
+		}
 	}
 	return nil
 }
 
-// EnsureRedisStatefulset makes sure the pdb exists in the desired state
-func (r *RedisFailoverKubeClient) ensurePodDisruptionBudget(rf *redisfailoverv1.RedisFailover, name string, component string, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
-	name = generateName(name, rf.Name)
-	namespace := rf.Namespace
+// keepStatefulSetFields writes the hash of the pod template on the metadata of the generated
+// StatefulSet, and keeps the fields of the stored one the generated one must not change. The stored
+// StatefulSet is returned, nil when it does not exist.
+func (r *RedisFailoverKubeClient) keepStatefulSetFields(ctx context.Context, rf *redisfailoverv1.RedisFailover, ss *appsv1.StatefulSet) (*appsv1.StatefulSet, error) {
+	hash, err := StatefulSetTemplateHash(ss)
+	if err != nil {
+		return nil, fmt.Errorf("hashing the pod template of the statefulset %s: %w", ss.Name, err)
+	}
+	ss.Annotations = util.MergeLabels(ss.Annotations, map[string]string{templateHashAnnotation: hash})
+	stored, err := r.K8SService.GetStatefulSet(ctx, rf.Namespace, ss.Name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if ss.Name == rf.AdoptedStatefulSet() {
+		keepAdoptedStatefulSetFields(ss, stored)
+	}
+	keepVolumeClaimTemplates(ss, stored)
+	keepPodOwnerAnnotations(rf, &ss.Spec.Template, stored.Spec.Template, stored.Annotations[templateHashAnnotation], hash)
+	return stored, nil
+}
 
-	minAvailable := intstr.FromInt(2)
-	if rf.Spec.Redis.Replicas <= 2 {
-		minAvailable = intstr.FromInt(1)
+// keepAdoptedStatefulSetFields keeps the immutable fields of the adopted StatefulSet in the generated
+// one, so it's updated in place. Its selector is added to the labels of the pods, the pods already
+// running keep matching it, and they are rolled like any other change of the template.
+func keepAdoptedStatefulSetFields(ss *appsv1.StatefulSet, adopted *appsv1.StatefulSet) {
+	ss.Spec.Selector = adopted.Spec.Selector
+	ss.Spec.ServiceName = adopted.Spec.ServiceName
+	ss.Spec.PodManagementPolicy = adopted.Spec.PodManagementPolicy
+	ss.Spec.VolumeClaimTemplates = adopted.Spec.VolumeClaimTemplates
+	if adopted.Spec.Selector != nil {
+		ss.Spec.Template.Labels = util.MergeLabels(ss.Spec.Template.Labels, adopted.Spec.Selector.MatchLabels)
 	}
+}
 
-	labels = util.MergeLabels(labels, generateSelectorLabels(component, rf.Name))
+// keepVolumeClaimTemplates keeps the volume claim templates of the stored StatefulSet when the generated
+// ones have the same names: they can't be updated, a new size of the volumes would fail the update. The
+// claims are resized instead, and the StatefulSet gets the new templates when it's recreated.
+func keepVolumeClaimTemplates(ss *appsv1.StatefulSet, stored *appsv1.StatefulSet) {
+	if len(ss.Spec.VolumeClaimTemplates) != len(stored.Spec.VolumeClaimTemplates) {
+		return
+	}
+	for i := range ss.Spec.VolumeClaimTemplates {
+		if ss.Spec.VolumeClaimTemplates[i].Name != stored.Spec.VolumeClaimTemplates[i].Name {
+			return
+		}
+	}
+	ss.Spec.VolumeClaimTemplates = stored.Spec.VolumeClaimTemplates
+}
 
-	pdb := generatePodDisruptionBudget(name, namespace, labels, ownerRefs, minAvailable)
-	err := r.K8SService.CreateOrUpdatePodDisruptionBudget(namespace, pdb)
-	r.setEnsureOperationMetrics(pdb.Namespace, pdb.Name, "PodDisruptionBudget" /* pdb.TypeMeta.Kind isnt working;  pdb.Kind isnt working either */, rf.Name, err)
-	return err
+// keepDeploymentTemplate writes the hash of the pod template on the metadata of the generated
+// Deployment, and keeps the stored template when its hash is the same. The pods are only rolled by a
+// change of the template, the labels copied from the RF and the owner annotations are updated with the
+// next one, unless the RF forces the owner annotations. The replicas of the autoscaled sentinels are
+// the ones set by their autoscaler.
+func (r *RedisFailoverKubeClient) keepDeploymentTemplate(ctx context.Context, rf *redisfailoverv1.RedisFailover, d *appsv1.Deployment) error {
+	hash, err := DeploymentTemplateHash(d)
+	if err != nil {
+		return fmt.Errorf("hashing the pod template of the deployment %s: %w", d.Name, err)
+	}
+	d.Annotations = util.MergeLabels(d.Annotations, map[string]string{templateHashAnnotation: hash})
+	stored, err := r.K8SService.GetDeployment(ctx, rf.Namespace, d.Name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	storedHash := stored.Annotations[templateHashAnnotation]
+	keepPodOwnerAnnotations(rf, &d.Spec.Template, stored.Spec.Template, storedHash, hash)
+	if storedHash == hash && hasPodOwnerAnnotations(d.Spec.Template) == hasPodOwnerAnnotations(stored.Spec.Template) {
+		d.Spec.Template = stored.Spec.Template
+	}
+	if rf.SentinelAutoScalingEnabled() && d.Name == GetSentinelName(rf) && stored.Spec.Replicas != nil {
+		replicas := *stored.Spec.Replicas
+		d.Spec.Replicas = &replicas
+	}
+	return nil
+}
+
+// ensurePresent returns the error getting the object, it fails when the object doesn't exist.
+func (r *RedisFailoverKubeClient) ensurePresent(ctx context.Context, namespace string, ref ObjectRef) error {
+	funcs, ok := objectKinds[ref.Kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %s", ref.Kind)
+	}
+	return funcs.get(ctx, r.K8SService, namespace, ref.Name)
+}
+
+// ensureAbsent deletes the object when it exists.
+func (r *RedisFailoverKubeClient) ensureAbsent(ctx context.Context, namespace string, ref ObjectRef) error {
+	funcs, ok := objectKinds[ref.Kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %s", ref.Kind)
+	}
+	// If the object exists (no get error), delete it
+	if err := funcs.get(ctx, r.K8SService, namespace, ref.Name); err == nil {
+		return funcs.delete(ctx, r.K8SService, namespace, ref.Name)
+	}
+	return nil
 }
 
 func (r *RedisFailoverKubeClient) setEnsureOperationMetrics(objectNamespace string, objectName string, objectKind string, ownerName string, err error) {
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// SetCondition sets the condition of the type on the RF status, observed at the RF generation. The
+// transition time is kept while its status doesn't change, so setting the same condition again leaves
+// the status as it was. It returns true when the status, the reason or the message of the condition
+// changed.
+func SetCondition(rf *redisfailoverv1.RedisFailover, condType string, status metav1.ConditionStatus, reason, message string) bool {
+	previous := GetCondition(rf, condType)
+	changed := previous == nil || previous.Status != status || previous.Reason != reason || previous.Message != message
+	meta.SetStatusCondition(&rf.Status.Conditions, metav1.Condition{
+		Type:               condType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: rf.Generation,
+	})
+	return changed
+}
+
+// GetCondition returns the condition of the type on the RF status, nil when it's not set.
+func GetCondition(rf *redisfailoverv1.RedisFailover, condType string) *metav1.Condition {
+	return meta.FindStatusCondition(rf.Status.Conditions, condType)
+}
@@ -0,0 +1,45 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestSetCondition(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Generation = 2
+	assert.Nil(rfservice.GetCondition(rf, redisfailoverv1.ConditionReady))
+
+	assert.True(rfservice.SetCondition(rf, redisfailoverv1.ConditionReady, metav1.ConditionFalse, redisfailoverv1.ReasonPodsNotReady, "1/3 redis pods ready"))
+	condition := rfservice.GetCondition(rf, redisfailoverv1.ConditionReady)
+	if assert.NotNil(condition) {
+		assert.Equal(metav1.ConditionFalse, condition.Status)
+		assert.Equal(redisfailoverv1.ReasonPodsNotReady, condition.Reason)
+		assert.Equal("1/3 redis pods ready", condition.Message)
+		assert.Equal(int64(2), condition.ObservedGeneration)
+	}
+
+	// Setting the same condition again leaves the status as it was.
+	condition.LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	previous := rf.Status.DeepCopy()
+	assert.False(rfservice.SetCondition(rf, redisfailoverv1.ConditionReady, metav1.ConditionFalse, redisfailoverv1.ReasonPodsNotReady, "1/3 redis pods ready"))
+	assert.Equal(*previous, rf.Status)
+
+	// A new message keeps the transition time, a new status moves it.
+	assert.True(rfservice.SetCondition(rf, redisfailoverv1.ConditionReady, metav1.ConditionFalse, redisfailoverv1.ReasonPodsNotReady, "2/3 redis pods ready"))
+	assert.Equal(previous.Conditions[0].LastTransitionTime, rfservice.GetCondition(rf, redisfailoverv1.ConditionReady).LastTransitionTime)
+	assert.True(rfservice.SetCondition(rf, redisfailoverv1.ConditionReady, metav1.ConditionTrue, redisfailoverv1.ReasonPodsReady, "3/3 redis pods ready"))
+	assert.NotEqual(previous.Conditions[0].LastTransitionTime, rfservice.GetCondition(rf, redisfailoverv1.ConditionReady).LastTransitionTime)
+
+	// The other conditions are kept.
+	assert.True(rfservice.SetCondition(rf, redisfailoverv1.ConditionScaling, metav1.ConditionFalse, redisfailoverv1.ReasonReplicasReached, "3/3 redis pods running"))
+	assert.Len(rf.Status.Conditions, 2)
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+)
+
+// ConfigRevisionLabel is written on the immutable configuration ConfigMaps with the revision of the
+// configuration they hold, the hash of its content. Their names end with it, so the pod templates change
+// with the configuration and the pods are rolled to read it.
+const ConfigRevisionLabel = "redisfailovers.databases.spotahome.com/config-revision"
+
+const (
+	// configRevisionLength is the length of the hash of the configuration in the names of the ConfigMaps.
+	configRevisionLength = 10
+	// configRevisionsKept is the number of configuration revisions kept, the current one and the one
+	// the pods not rolled yet still mount.
+	configRevisionsKept = 2
+)
+
+// setConfigRevision makes the configuration ConfigMaps immutable when the RF has immutableConfig, and
+// names them after the revision of their content. The revision is returned, empty when the ConfigMaps are
+// kept with their fixed names.
+func setConfigRevision(rf *redisfailoverv1.RedisFailover, cms []*corev1.ConfigMap) string {
+	if !rf.ImmutableConfigEnabled() {
+		return ""
+	}
+	h := sha256.New()
+	for _, cm := range cms {
+		keys := make([]string, 0, len(cm.Data))
+		for key := range cm.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			h.Write([]byte(key))
+			h.Write([]byte{0})
+			h.Write([]byte(cm.Data[key]))
+			h.Write([]byte{0})
+		}
+	}
+	revision := hex.EncodeToString(h.Sum(nil))[:configRevisionLength]
+
+	immutable := true
+	for _, cm := range cms {
+		cm.Name = revisionName(cm.Name, revision)
+		cm.Labels = util.MergeLabels(cm.Labels, map[string]string{ConfigRevisionLabel: revision})
+		cm.Immutable = &immutable
+	}
+	return revision
+}
+
+// revisionName returns the name of the configuration ConfigMap of the revision, the fixed name without
+// a revision.
+func revisionName(name, revision string) string {
+	if revision == "" {
+		return name
+	}
+	return name + "-" + revision
+}
+
+// sentinelConfigMapName returns the name of the sentinel configuration ConfigMap the sentinel pods mount.
+func sentinelConfigMapName(rf *redisfailoverv1.RedisFailover) string {
+	return generateSentinelConfigMap(rf, nil, nil).Name
+}
+
+// legacyRedisConfigNames returns the fixed names of the redis configuration ConfigMaps, written before
+// immutableConfig was enabled.
+func legacyRedisConfigNames(rf *redisfailoverv1.RedisFailover) []string {
+	names := []string{GetRedisName(rf)}
+	for i := 0; i < maxRedisConfigParts; i++ {
+		names = append(names, GetRedisConfigPartName(rf, i))
+	}
+	return names
+}
+
+// deleteConfigRevisions deletes the configuration ConfigMaps of the component older than the last two
+// revisions. The ConfigMaps with the fixed names, written before immutableConfig was enabled, count as
+// the revision before the first one: they are deleted once a second revision is written.
+func (r *RedisFailoverKubeClient) deleteConfigRevisions(ctx context.Context, rf *redisfailoverv1.RedisFailover, component string, revision string, legacy []string) error {
+	selector := generateSelectorLabels(component, rf.Name)
+	cms, err := r.K8SService.ListConfigMapsBySelector(ctx, rf.Namespace, selector)
+	if err != nil {
+		return err
+	}
+	previous := false
+	for _, cm := range cms.Items {
+		if rev, ok := cm.Labels[ConfigRevisionLabel]; ok && rev != revision {
+			previous = true
+		}
+	}
+	if previous {
+		for _, cm := range cms.Items {
+			if _, ok := cm.Labels[ConfigRevisionLabel]; ok || !contains(legacy, cm.Name) {
+				continue
+			}
+			if err := r.K8SService.DeleteConfigMap(ctx, rf.Namespace, cm.Name); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Infof("configMap %s with a fixed name deleted, replaced by the immutable configuration revisions", cm.Name)
+		}
+	}
+	return r.K8SService.DeleteConfigMapRevisions(ctx, rf.Namespace, selector, ConfigRevisionLabel, revision, configRevisionsKept)
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package service_test
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+)
+
+// desiredConfigMaps returns the ConfigMaps of the desired state by name.
+func desiredConfigMaps(state *rfservice.DesiredState) map[string]*corev1.ConfigMap {
+	cms := map[string]*corev1.ConfigMap{}
+	for _, o := range state.Objects {
+		if o.Kind == rfservice.KindConfigMap {
+			cms[o.Name] = o.Object.(*corev1.ConfigMap)
+		}
+	}
+	return cms
+}
+
+// mountedConfigMaps returns the names of the ConfigMaps mounted by the pods of the spec.
+func mountedConfigMaps(spec corev1.PodSpec) []string {
+	names := []string{}
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil {
+			names = append(names, v.ConfigMap.Name)
+		}
+		if v.Projected != nil {
+			for _, source := range v.Projected.Sources {
+				if source.ConfigMap != nil {
+					names = append(names, source.ConfigMap.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+func TestBuildDesiredStateImmutableConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rf := generateRF()
+	rf.Spec.ImmutableConfig = true
+
+	state, err := rfservice.BuildDesiredState(rf, nil, nil, "pass")
+	require.NoError(err)
+
+	revision := regexp.MustCompile(`^[0-9a-f]{10}$`)
+	assert.Regexp(revision, state.RedisConfigRevision)
+	assert.Regexp(revision, state.SentinelConfigRevision)
+	assert.NotEqual(state.RedisConfigRevision, state.SentinelConfigRevision)
+
+	redisConfig := "rfr-test-" + state.RedisConfigRevision
+	sentinelConfig := "rfs-test-" + state.SentinelConfigRevision
+	cms := desiredConfigMaps(state)
+	for _, name := range []string{redisConfig, sentinelConfig} {
+		cm, ok := cms[name]
+		if assert.True(ok, name) {
+			assert.True(*cm.Immutable)
+		}
+	}
+	assert.Equal(state.RedisConfigRevision, cms[redisConfig].Labels[rfservice.ConfigRevisionLabel])
+	assert.Equal(state.SentinelConfigRevision, cms[sentinelConfig].Labels[rfservice.ConfigRevisionLabel])
+	// The shutdown and readiness scripts keep their names, they are read on every run.
+	assert.Nil(cms["rfr-readiness-test"].Immutable)
+	assert.NotContains(cms, "rfr-test")
+	assert.NotContains(cms, "rfs-test")
+
+	for _, o := range state.Objects {
+		switch o.Kind {
+		case rfservice.KindStatefulSet:
+			assert.Contains(mountedConfigMaps(o.Object.(*appsv1.StatefulSet).Spec.Template.Spec), redisConfig)
+		case rfservice.KindDeployment:
+			assert.Contains(mountedConfigMaps(o.Object.(*appsv1.Deployment).Spec.Template.Spec), sentinelConfig)
+		}
+	}
+
+	// The same configuration keeps its revision.
+	same, err := rfservice.BuildDesiredState(rf, nil, nil, "pass")
+	require.NoError(err)
+	assert.Equal(state.RedisConfigRevision, same.RedisConfigRevision)
+	assert.Equal(state.SentinelConfigRevision, same.SentinelConfigRevision)
+
+	// A new password is written in the redis configuration, the redis pods are rolled to read it.
+	password, err := rfservice.BuildDesiredState(rf, nil, nil, "new-pass")
+	require.NoError(err)
+	assert.NotEqual(state.RedisConfigRevision, password.RedisConfigRevision)
+	assert.Equal(state.SentinelConfigRevision, password.SentinelConfigRevision)
+
+	// Without immutableConfig the ConfigMaps keep their fixed names.
+	rf.Spec.ImmutableConfig = false
+	fixed, err := rfservice.BuildDesiredState(rf, nil, nil, "pass")
+	require.NoError(err)
+	assert.Empty(fixed.RedisConfigRevision)
+	assert.Contains(desiredConfigMaps(fixed), "rfr-test")
+	assert.Nil(desiredConfigMaps(fixed)["rfr-test"].Immutable)
+}
+
+func TestEnsureDesiredStateImmutableConfigUpgrade(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	kubecli := kubernetes.NewSimpleClientset()
+	ks := k8s.New(kubecli, nil, nil, nil, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	client := rfservice.NewRedisFailoverKubeClient(ks, log.Dummy, metrics.Dummy)
+
+	// The fake clientset doesn't set the creation of the objects, every reconcile stamps the ConfigMaps it
+	// created an hour after the previous one. It serves no PodDisruptionBudget API, the rest of the desired
+	// state is written without them.
+	now := time.Now()
+	ensure := func(rf *redisfailoverv1.RedisFailover) {
+		require.ErrorIs(client.EnsureDesiredState(context.TODO(), rf, nil, nil), k8s.ErrPodDisruptionBudgetsUnavailable)
+		now = now.Add(time.Hour)
+		cms, err := kubecli.CoreV1().ConfigMaps(namespace).List(context.TODO(), metav1.ListOptions{})
+		require.NoError(err)
+		for i := range cms.Items {
+			cm := &cms.Items[i]
+			if cm.CreationTimestamp.IsZero() {
+				cm.CreationTimestamp = metav1.NewTime(now)
+				_, err := kubecli.CoreV1().ConfigMaps(namespace).Update(context.TODO(), cm, metav1.UpdateOptions{})
+				require.NoError(err)
+			}
+		}
+	}
+	configMaps := func() []string {
+		cms, err := kubecli.CoreV1().ConfigMaps(namespace).List(context.TODO(), metav1.ListOptions{})
+		require.NoError(err)
+		names := []string{}
+		for _, cm := range cms.Items {
+			names = append(names, cm.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	mounted := func() []string {
+		ss, err := kubecli.AppsV1().StatefulSets(namespace).Get(context.TODO(), "rfr-test", metav1.GetOptions{})
+		require.NoError(err)
+		d, err := kubecli.AppsV1().Deployments(namespace).Get(context.TODO(), "rfs-test", metav1.GetOptions{})
+		require.NoError(err)
+		return append(mountedConfigMaps(ss.Spec.Template.Spec), mountedConfigMaps(d.Spec.Template.Spec)...)
+	}
+
+	rf := generateRF()
+	ensure(rf)
+	assert.Equal([]string{"rfr-readiness-test", "rfr-s-test", "rfr-test", "rfs-test"}, configMaps())
+
+	// The fixed name ConfigMaps are kept while the pods not rolled yet mount them.
+	rf.Spec.ImmutableConfig = true
+	ensure(rf)
+	first, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	require.NoError(err)
+	redisFirst := "rfr-test-" + first.RedisConfigRevision
+	sentinelFirst := "rfs-test-" + first.SentinelConfigRevision
+	assert.Equal([]string{"rfr-readiness-test", "rfr-s-test", "rfr-test", redisFirst, "rfs-test", sentinelFirst}, configMaps())
+	assert.Contains(mounted(), redisFirst)
+	assert.Contains(mounted(), sentinelFirst)
+	assert.NotContains(mounted(), "rfr-test")
+
+	// A new configuration is written in new ConfigMaps, the fixed name ones are now older than the last
+	// two revisions.
+	rf.Spec.Redis.CustomCommandRenames = []redisfailoverv1.RedisCommandRename{{From: "flushall", To: "flushall-1"}}
+	ensure(rf)
+	second, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	require.NoError(err)
+	redisSecond := "rfr-test-" + second.RedisConfigRevision
+	assert.NotEqual(redisFirst, redisSecond)
+	assert.Equal(first.SentinelConfigRevision, second.SentinelConfigRevision)
+	assert.ElementsMatch([]string{"rfr-readiness-test", "rfr-s-test", redisFirst, redisSecond, "rfs-test", sentinelFirst}, configMaps())
+	assert.Contains(mounted(), redisSecond)
+
+	// The first revision is deleted with the third one.
+	rf.Spec.Redis.CustomCommandRenames = []redisfailoverv1.RedisCommandRename{{From: "flushall", To: "flushall-2"}}
+	ensure(rf)
+	third, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	require.NoError(err)
+	redisThird := "rfr-test-" + third.RedisConfigRevision
+	assert.ElementsMatch([]string{"rfr-readiness-test", "rfr-s-test", redisSecond, redisThird, "rfs-test", sentinelFirst}, configMaps())
+}
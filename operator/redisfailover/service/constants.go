@@ -14,19 +14,90 @@ const (
 )
 
 const (
-	baseName               = "rf"
 	sentinelName           = "s"
 	sentinelRoleName       = "sentinel"
 	sentinelConfigFileName = "sentinel.conf"
 	redisConfigFileName    = "redis.conf"
 	redisName              = "r"
+	redisMasterName        = "rm"
 	redisShutdownName      = "r-s"
 	redisReadinessName     = "r-readiness"
+	redisTLSName           = "r-tls"
+	redisTLSSidecarName    = "r-stunnel"
+	supportBundleName      = "sb"
+	diagnosisName          = "d"
+	backupName             = "b"
 	redisRoleName          = "redis"
+	backupRoleName         = "backup"
 	appLabel               = "redis-failover"
 	hostnameTopologyKey    = "kubernetes.io/hostname"
 )
 
+const (
+	// maxConfigMapDataSize is the data a ConfigMap can hold, leaving room for its metadata under
+	// the 1MiB limit of the objects stored in etcd.
+	maxConfigMapDataSize = 1000 * 1024
+	// maxRedisConfigParts is the maximum number of ConfigMaps the redis configuration is split in.
+	maxRedisConfigParts     = 16
+	redisConfigPartFileName = "redis-part-%d.conf"
+)
+
+const (
+	// RedisContainerName is the container of redis in its pods.
+	RedisContainerName      = "redis"
+	nodeTuningContainerName = "node-tuning"
+)
+
+const (
+	// redisImageUser is the user and the group of the redis image, the containers run as it with a
+	// read-only root filesystem.
+	redisImageUser = int64(999)
+	tmpVolumeName  = "tmp"
+)
+
+const (
+	// SentinelContainerName is the container of sentinel in its pods.
+	SentinelContainerName = "sentinel"
+	// SentinelConfigPath is the config of sentinel in its container. It's copied from the ConfigMap by an
+	// init container into a volume, sentinel rewrites it with the state of the monitored redis.
+	SentinelConfigPath = "/redis/" + sentinelConfigFileName
+)
+
+// variables refering to the proxy sidecar of the zero downtime reload
+const (
+	redisProxyContainerName     = "redis-proxy"
+	redisSocketVolumeName       = "redis-socket"
+	redisSocketDir              = "/redis-socket"
+	redisSocketPath             = redisSocketDir + "/redis.sock"
+	redisProxyDefaultRequestCPU = "10m"
+	redisProxyDefaultLimitCPU   = "100m"
+	redisProxyDefaultMemory     = "32Mi"
+)
+
+// variables refering to the init container resolving the bind addresses and the replication interface
+const (
+	redisNetworkContainerName = "redis-network"
+	redisNetworkVolumeName    = "redis-network"
+	redisNetworkDir           = "/redis-network"
+	redisNetworkConfigPath    = redisNetworkDir + "/network.conf"
+)
+
+// templateHashAnnotation is written on the sentinel Deployment with the hash of its pod template, the
+// stored template is kept while the hash is the same.
+const templateHashAnnotation = "databases.spotahome.com/template-hash"
+
+// The reasons of the events about the writes of the redis statefulset.
+const (
+	statefulSetCreatedReason = "StatefulSetCreated"
+	statefulSetUpdatedReason = "StatefulSetUpdated"
+)
+
+// external-dns annotations used to publish the redis master
+const (
+	externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+	externalDNSTTLAnnotation      = "external-dns.alpha.kubernetes.io/ttl"
+)
+
 const (
 	redisRoleLabelKey    = "redisfailovers-role"
 	redisRoleLabelMaster = "master"
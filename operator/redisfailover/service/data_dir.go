@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/service/k8s"
+)
+
+// DataDirMismatch is a redis pod writing its data out of its data volume, to the container layer lost
+// on restart.
+type DataDirMismatch struct {
+	Pod string
+	// Dir is the dir redis writes to, as CONFIG GET dir reports it.
+	Dir string
+	// MountPath is where the data volume is mounted in the redis container of the pod, empty when it's
+	// not mounted.
+	MountPath string
+}
+
+// GetDataDirMismatches asks the running redis pods the dir they write to and returns the ones whose dir
+// is not on the data volume mounted in their redis container, sorted by pod. A pod not answering is
+// skipped, its failure is already reported by the other checks.
+func (r *RedisFailoverChecker) GetDataDirMismatches(ctx context.Context, rf *redisfailoverv1.RedisFailover) ([]DataDirMismatch, error) {
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
+	}
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return nil, err
+	}
+
+	rport := getRedisPort(rf.Spec.Redis.Port)
+	mismatches := []DataDirMismatch{}
+	for _, rp := range rps.Items {
+		if rp.Status.Phase != corev1.PodRunning || rp.DeletionTimestamp != nil || rp.Status.PodIP == "" {
+			continue
+		}
+		dir, err := r.redisClient.GetRedisConfig(rf.PreferredPodIP(rp.Status), rport, password, "dir")
+		if err != nil {
+			r.logger.WithField("namespace", rf.Namespace).WithField("pod", rp.Name).Debugf("could not get the dir of redis: %s", err)
+			continue
+		}
+		mountPath := getDataMountPath(rf, rp)
+		if isDirOnMount(dir, mountPath) {
+			continue
+		}
+		mismatches = append(mismatches, DataDirMismatch{Pod: rp.Name, Dir: dir, MountPath: mountPath})
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].Pod < mismatches[j].Pod
+	})
+	return mismatches, nil
+}
+
+// getDataMountPath returns where the data volume is mounted in the redis container of the pod, the pod
+// spec is used as the pods may not be restarted to the statefulset revision yet.
+func getDataMountPath(rf *redisfailoverv1.RedisFailover, pod corev1.Pod) string {
+	volume := getRedisDataVolumeName(rf)
+	for _, c := range pod.Spec.Containers {
+		if c.Name != RedisContainerName {
+			continue
+		}
+		for _, m := range c.VolumeMounts {
+			if m.Name == volume {
+				return m.MountPath
+			}
+		}
+	}
+	return ""
+}
+
+// isDirOnMount returns true when the dir is the mount path or a dir below it.
+func isDirOnMount(dir, mountPath string) bool {
+	if mountPath == "" || !path.IsAbs(dir) {
+		return false
+	}
+	dir, mountPath = path.Clean(dir), path.Clean(mountPath)
+	return dir == mountPath || mountPath == "/" || strings.HasPrefix(dir, mountPath+"/")
+}
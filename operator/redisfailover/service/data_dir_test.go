@@ -0,0 +1,84 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// generateRedisPodWithData returns a running redis pod mounting its data volume on the mount path, or
+// not mounting it when the mount path is empty.
+func generateRedisPodWithData(name, ip, mountPath string) corev1.Pod {
+	pod := generateRedisPod(name, ip, corev1.PodRunning)
+	mounts := []corev1.VolumeMount{{Name: "redis-config", MountPath: "/redis"}}
+	if mountPath != "" {
+		mounts = append(mounts, corev1.VolumeMount{Name: "redis-data", MountPath: mountPath})
+	}
+	pod.Spec.Containers = []corev1.Container{{Name: "redis", VolumeMounts: mounts}}
+	return pod
+}
+
+func TestGetDataDirMismatches(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			// The image writes to its own dir, out of the data volume.
+			generateRedisPodWithData("rfr-test-2", "10.0.0.3", "/data"),
+			// Below the data volume.
+			generateRedisPodWithData("rfr-test-1", "10.0.0.2", "/bitnami/redis"),
+			generateRedisPodWithData("rfr-test-0", "10.0.0.1", "/data"),
+			// Not answering, it's skipped.
+			generateRedisPodWithData("rfr-test-3", "10.0.0.4", "/data"),
+			// Restarted on a spec not mounting the data volume yet.
+			generateRedisPodWithData("rfr-test-4", "10.0.0.5", ""),
+			// Not running, it's not asked.
+			generateRedisPod("rfr-test-5", "", corev1.PodPending),
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	mr := &mRedisService.Client{}
+	mr.On("GetRedisConfig", "10.0.0.1", "0", "", "dir").Once().Return("/data", nil)
+	mr.On("GetRedisConfig", "10.0.0.2", "0", "", "dir").Once().Return("/bitnami/redis/data", nil)
+	mr.On("GetRedisConfig", "10.0.0.3", "0", "", "dir").Once().Return("/bitnami/redis/data", nil)
+	mr.On("GetRedisConfig", "10.0.0.4", "0", "", "dir").Once().Return("", errors.New("wanted error"))
+	mr.On("GetRedisConfig", "10.0.0.5", "0", "", "dir").Once().Return("/data", nil)
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	mismatches, err := checker.GetDataDirMismatches(context.TODO(), rf)
+	assert.NoError(err)
+	assert.Equal([]rfservice.DataDirMismatch{
+		{Pod: "rfr-test-2", Dir: "/bitnami/redis/data", MountPath: "/data"},
+		{Pod: "rfr-test-4", Dir: "/data"},
+	}, mismatches)
+	ms.AssertExpectations(t)
+	mr.AssertExpectations(t)
+}
+
+func TestGetDataDirMismatchesError(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New("wanted error"))
+	mr := &mRedisService.Client{}
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	_, err := checker.GetDataDirMismatches(context.TODO(), rf)
+	assert.Error(err)
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// Kinds of the objects generated for a RF.
+const (
+	KindService             = "Service"
+	KindConfigMap           = "ConfigMap"
+	KindPodDisruptionBudget = "PodDisruptionBudget"
+	KindNetworkPolicy       = "NetworkPolicy"
+	KindStatefulSet         = "StatefulSet"
+	KindDeployment          = "Deployment"
+	KindHPA                 = "HorizontalPodAutoscaler"
+	KindCertificate         = "Certificate"
+	KindServiceMonitor      = "ServiceMonitor"
+	KindServiceAccount      = "ServiceAccount"
+	KindRole                = "Role"
+	KindRoleBinding         = "RoleBinding"
+)
+
+// kindOrder is the order the objects are written in by kind, the pods of the workloads mount the
+// ConfigMaps and the secrets of the Certificates, run as the ServiceAccounts, and are selected by the
+// Services, the PodDisruptionBudgets and the NetworkPolicies. The ServiceMonitors select the Services and the
+// HorizontalPodAutoscalers scale the workloads.
+var kindOrder = map[string]int{
+	KindConfigMap:           0,
+	KindCertificate:         0,
+	KindServiceAccount:      0,
+	KindRole:                0,
+	KindRoleBinding:         0,
+	KindService:             1,
+	KindPodDisruptionBudget: 2,
+	KindNetworkPolicy:       2,
+	KindServiceMonitor:      2,
+	KindStatefulSet:         3,
+	KindDeployment:          3,
+	KindHPA:                 4,
+}
+
+// IsWorkload returns true for the kinds running the pods of a RF.
+func IsWorkload(kind string) bool {
+	return kind == KindStatefulSet || kind == KindDeployment
+}
+
+// Components tells which parts of a RF the operator deploys.
+type Components struct {
+	// Redis is false when the RF uses external nodes, then only the sentinels are deployed.
+	Redis bool
+	// Sentinel is false when the RF doesn't deploy sentinels.
+	Sentinel bool
+	// RedisService is the service of the redis exporter.
+	RedisService bool
+	// RedisServiceMonitor is the prometheus-operator ServiceMonitor scraping the redis exporter.
+	RedisServiceMonitor bool
+	// RedisMasterService is the service carrying the external-dns annotations of the master.
+	RedisMasterService bool
+	// RedisShutdownConfigMap is false when the spec gives its own shutdown ConfigMap.
+	RedisShutdownConfigMap bool
+	// RedisNetworkPolicy isolates the redis pods.
+	RedisNetworkPolicy bool
+	// SentinelNetworkPolicy isolates the sentinel pods.
+	SentinelNetworkPolicy bool
+	// RedisCertificate is the cert-manager Certificate of the redis pods served over TLS.
+	RedisCertificate bool
+	// SentinelAutoScaling scales the sentinels with a HorizontalPodAutoscaler.
+	SentinelAutoScaling bool
+	// RedisServiceAccount is the service account of the redis pods, with its Role and RoleBinding.
+	RedisServiceAccount bool
+	// RedisTLSSidecar is the stunnel config of the sidecar serving the TLS port of the redis pods.
+	RedisTLSSidecar bool
+}
+
+// GetComponents returns the components of the RF deployed by the operator.
+func GetComponents(rf *redisfailoverv1.RedisFailover) Components {
+	redis := !rf.ExternalNodesEnabled()
+	return Components{
+		Redis:                  redis,
+		Sentinel:               rf.SentinelsAllowed(),
+		RedisService:           rf.Spec.Redis.Exporter.Enabled,
+		RedisServiceMonitor:    rf.RedisServiceMonitorEnabled(),
+		RedisMasterService:     rf.Spec.Redis.MasterDNS != nil,
+		RedisShutdownConfigMap: redis && rf.Spec.Redis.ShutdownConfigMap == "",
+		RedisNetworkPolicy:     redis && rf.NetworkPolicyEnabled(),
+		SentinelNetworkPolicy:  rf.SentinelsAllowed() && rf.NetworkPolicyEnabled(),
+		RedisCertificate:       redis && rf.TLSEnabled() && rf.Spec.TLSConfig.IssuerRef != nil,
+		SentinelAutoScaling:    rf.SentinelAutoScalingEnabled(),
+		RedisServiceAccount:    redis && rf.ManagedServiceAccountEnabled(),
+		RedisTLSSidecar:        redis && rf.TLSSidecarEnabled(),
+	}
+}
+
+// ObjectRef names an object of a RF.
+type ObjectRef struct {
+	Kind string
+	Name string
+}
+
+func (o ObjectRef) String() string {
+	return fmt.Sprintf("%s/%s", o.Kind, o.Name)
+}
+
+// DesiredObject is an object generated for a RF.
+type DesiredObject struct {
+	Kind   string
+	Name   string
+	Object runtime.Object
+	// Hash is the hash of the generated object, two objects generated the same have the same hash.
+	Hash string
+}
+
+// Ref returns the reference of the object.
+func (o DesiredObject) Ref() ObjectRef {
+	return ObjectRef{Kind: o.Kind, Name: o.Name}
+}
+
+// DesiredState is the set of objects the operator keeps for a RF.
+type DesiredState struct {
+	Components Components
+	// Objects are the objects generated for the RF, in the order they are written: the ConfigMaps,
+	// the Services and the PodDisruptionBudgets before the workloads depending on them.
+	Objects []DesiredObject
+	// Required are the objects given in the spec, only read by the operator. They must exist.
+	Required []ObjectRef
+	// Absent are the objects of the components not deployed, they are deleted when found.
+	Absent []ObjectRef
+	// RedisConfigParts is the number of parts the redis configuration is split in, the parts left by a
+	// previous split in more parts are deleted.
+	RedisConfigParts int
+	// RedisConfigRevision and SentinelConfigRevision are the revisions of the immutable configurations,
+	// empty without immutableConfig. The revisions older than the last two are deleted.
+	RedisConfigRevision    string
+	SentinelConfigRevision string
+}
+
+// desiredStateBuilder adds the objects of every component to the desired state of a RF.
+type desiredStateBuilder struct {
+	rf        *redisfailoverv1.RedisFailover
+	labels    map[string]string
+	ownerRefs []metav1.OwnerReference
+	state     *DesiredState
+}
+
+// BuildDesiredState returns the objects the operator keeps for the defaulted RF, with the generators of
+// the operator. The password is written in the redis configuration.
+func BuildDesiredState(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference, password string) (*DesiredState, error) {
+	b := &desiredStateBuilder{
+		rf:        rf,
+		labels:    labels,
+		ownerRefs: ownerRefs,
+		state:     &DesiredState{Components: GetComponents(rf)},
+	}
+	c := b.state.Components
+
+	if c.RedisService {
+		if err := b.add(KindService, generateRedisService(rf, labels, ownerRefs)); err != nil {
+			return nil, err
+		}
+	} else {
+		b.absent(KindService, GetRedisName(rf))
+	}
+	if c.RedisServiceMonitor {
+		if err := b.add(KindServiceMonitor, generateRedisServiceMonitor(rf, labels, ownerRefs)); err != nil {
+			return nil, err
+		}
+	} else {
+		b.absent(KindServiceMonitor, GetRedisName(rf))
+	}
+	if c.RedisMasterService {
+		if err := b.add(KindService, generateRedisMasterService(rf, labels, ownerRefs)); err != nil {
+			return nil, err
+		}
+	} else {
+		b.absent(KindService, GetRedisMasterName(rf))
+	}
+
+	if !c.RedisNetworkPolicy {
+		b.absent(KindNetworkPolicy, GetRedisName(rf))
+	}
+	if !c.SentinelNetworkPolicy {
+		b.absent(KindNetworkPolicy, GetSentinelName(rf))
+	}
+	if !c.RedisCertificate {
+		b.absent(KindCertificate, GetRedisTLSName(rf))
+	}
+	if !c.RedisTLSSidecar {
+		b.absent(KindConfigMap, GetRedisTLSSidecarName(rf))
+	}
+	if !c.SentinelAutoScaling {
+		b.absent(KindHPA, GetSentinelName(rf))
+	}
+	// The service account of the spec can have the name of the managed one, it's not deleted then.
+	if !c.RedisServiceAccount && rf.Spec.Redis.ServiceAccountName != GetRedisName(rf) {
+		b.absent(KindRoleBinding, GetRedisName(rf))
+		b.absent(KindRole, GetRedisName(rf))
+		b.absent(KindServiceAccount, GetRedisName(rf))
+	}
+
+	if c.Sentinel {
+		if err := b.addSentinelConfig(); err != nil {
+			return nil, err
+		}
+	}
+	if c.Redis {
+		if err := b.addRedis(password); err != nil {
+			return nil, err
+		}
+	}
+	if c.Sentinel {
+		if err := b.addSentinel(); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(b.state.Objects, func(i, j int) bool {
+		return kindOrder[b.state.Objects[i].Kind] < kindOrder[b.state.Objects[j].Kind]
+	})
+	return b.state, nil
+}
+
+// addSentinelConfig adds the service and the configuration of the sentinels.
+func (b *desiredStateBuilder) addSentinelConfig() error {
+	if err := b.add(KindService, generateSentinelService(b.rf, b.labels, b.ownerRefs)); err != nil {
+		return err
+	}
+	cm := generateSentinelConfigMap(b.rf, b.labels, b.ownerRefs)
+	b.state.SentinelConfigRevision = cm.Labels[ConfigRevisionLabel]
+	return b.add(KindConfigMap, cm)
+}
+
+// addRedis adds the configuration, the certificate, the TLS sidecar config and the service account of the
+// redis nodes, their statefulset, its disruption budget and its network policy.
+func (b *desiredStateBuilder) addRedis(password string) error {
+	if b.state.Components.RedisShutdownConfigMap {
+		if err := b.add(KindConfigMap, generateRedisShutdownConfigMap(b.rf, b.labels, b.ownerRefs)); err != nil {
+			return err
+		}
+	} else {
+		b.state.Required = append(b.state.Required, ObjectRef{Kind: KindConfigMap, Name: b.rf.Spec.Redis.ShutdownConfigMap})
+	}
+	if err := b.add(KindConfigMap, generateRedisReadinessConfigMap(b.rf, b.labels, b.ownerRefs)); err != nil {
+		return err
+	}
+	if b.rf.ACLEnabled() {
+		b.state.Required = append(b.state.Required, ObjectRef{Kind: KindConfigMap, Name: b.rf.Spec.Auth.ACLConfigMapRef.Name})
+	}
+
+	cms, err := generateRedisConfigMaps(b.rf, b.labels, b.ownerRefs, password)
+	if err != nil {
+		return err
+	}
+	for _, cm := range cms {
+		if err := b.add(KindConfigMap, cm); err != nil {
+			return err
+		}
+	}
+	// The main ConfigMap is generated with its parts.
+	b.state.RedisConfigParts = len(cms) - 1
+	b.state.RedisConfigRevision = cms[len(cms)-1].Labels[ConfigRevisionLabel]
+
+	if b.state.Components.RedisCertificate {
+		if err := b.add(KindCertificate, generateRedisCertificate(b.rf, b.labels, b.ownerRefs)); err != nil {
+			return err
+		}
+	}
+	if b.state.Components.RedisTLSSidecar {
+		if err := b.add(KindConfigMap, generateRedisTLSSidecarConfigMap(b.rf, b.labels, b.ownerRefs)); err != nil {
+			return err
+		}
+	}
+	if b.state.Components.RedisServiceAccount {
+		if err := b.add(KindServiceAccount, generateRedisServiceAccount(b.rf, b.labels, b.ownerRefs)); err != nil {
+			return err
+		}
+		if err := b.add(KindRole, generateRedisRole(b.rf, b.labels, b.ownerRefs)); err != nil {
+			return err
+		}
+		if err := b.add(KindRoleBinding, generateRedisRoleBinding(b.rf, b.labels, b.ownerRefs)); err != nil {
+			return err
+		}
+	}
+
+	if err := b.add(KindPodDisruptionBudget, generateRedisFailoverPodDisruptionBudget(b.rf, redisName, redisRoleName, b.labels, b.ownerRefs)); err != nil {
+		return err
+	}
+	if b.state.Components.RedisNetworkPolicy {
+		if err := b.add(KindNetworkPolicy, generateRedisNetworkPolicy(b.rf, b.labels, b.ownerRefs)); err != nil {
+			return err
+		}
+	}
+	configParts, err := renderRedisConfig(b.rf)
+	if err != nil {
+		return err
+	}
+	ss := generateRedisStatefulSet(b.rf, b.labels, b.ownerRefs, len(configParts), b.state.RedisConfigRevision)
+	setPasswordRevision(b.rf, ss, password)
+	return b.add(KindStatefulSet, ss)
+}
+
+// addSentinel adds the sentinel deployment, its disruption budget, its network policy and its autoscaler.
+func (b *desiredStateBuilder) addSentinel() error {
+	if err := b.add(KindPodDisruptionBudget, generateRedisFailoverPodDisruptionBudget(b.rf, sentinelName, sentinelRoleName, b.labels, b.ownerRefs)); err != nil {
+		return err
+	}
+	if b.state.Components.SentinelNetworkPolicy {
+		if err := b.add(KindNetworkPolicy, generateSentinelNetworkPolicy(b.rf, b.labels, b.ownerRefs)); err != nil {
+			return err
+		}
+	}
+	if err := b.add(KindDeployment, generateSentinelDeployment(b.rf, b.labels, b.ownerRefs)); err != nil {
+		return err
+	}
+	if b.state.Components.SentinelAutoScaling {
+		return b.add(KindHPA, generateSentinelHPA(b.rf, b.labels, b.ownerRefs))
+	}
+	return nil
+}
+
+func (b *desiredStateBuilder) add(kind string, obj metav1.Object) error {
+	hash, err := hashObject(obj)
+	if err != nil {
+		return fmt.Errorf("hashing %s %s: %w", kind, obj.GetName(), err)
+	}
+	b.state.Objects = append(b.state.Objects, DesiredObject{Kind: kind, Name: obj.GetName(), Object: obj.(runtime.Object), Hash: hash})
+	return nil
+}
+
+func (b *desiredStateBuilder) absent(kind, name string) {
+	b.state.Absent = append(b.state.Absent, ObjectRef{Kind: kind, Name: name})
+}
+
+// generateObjectMeta returns the metadata of an object generated for a RF.
+func generateObjectMeta(name, namespace string, labels, annotations map[string]string, ownerRefs []metav1.OwnerReference) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:            name,
+		Namespace:       namespace,
+		Labels:          labels,
+		Annotations:     annotations,
+		OwnerReferences: ownerRefs,
+	}
+}
+
+// hashObject returns the hex sha256 of the JSON of the object. The maps are encoded with sorted keys,
+// so the hash is stable.
+func hashObject(obj interface{}) (string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// desiredStateKey identifies what a desired state is rendered from. The spec is identified by the
+// generation of the RF, the rest by the hash of the inputs not bumping it.
+type desiredStateKey struct {
+	generation int64
+	version    string
+	gates      string
+	// inputs is the hash of the annotations of the RF, the labels and the owner references of the
+	// objects and the password read from the referenced secret.
+	inputs string
+}
+
+type cachedDesiredState struct {
+	key   desiredStateKey
+	state *DesiredState
+}
+
+// DesiredStateCache keeps the last desired state rendered for every RF, so the redis configuration and
+// the pod templates are not rendered and hashed again on every reconcile of a RF whose spec didn't
+// change. A state is rendered again when the generation of the RF, the version of the operator, its
+// feature gates or any other input changes.
+type DesiredStateCache struct {
+	mu      sync.Mutex
+	version string
+	gates   string
+	states  map[string]cachedDesiredState
+}
+
+// NewDesiredStateCache returns a new desired state cache for the version of the operator and the
+// feature gates of its flags.
+func NewDesiredStateCache(version, gates string) *DesiredStateCache {
+	return &DesiredStateCache{
+		version: version,
+		gates:   gates,
+		states:  map[string]cachedDesiredState{},
+	}
+}
+
+// Get returns the desired state of the RF like BuildDesiredState, from the cache when it was rendered
+// from the same inputs. The objects returned are copies, the caller can change them.
+func (c *DesiredStateCache) Get(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference, password string) (*DesiredState, error) {
+	inputs, err := hashObject(struct {
+		Annotations map[string]string
+		Labels      map[string]string
+		OwnerRefs   []metav1.OwnerReference
+		Password    string
+	}{rf.Annotations, labels, ownerRefs, password})
+	if err != nil {
+		return nil, err
+	}
+	key := desiredStateKey{
+		generation: rf.Generation,
+		version:    c.version,
+		gates:      c.gates,
+		inputs:     inputs,
+	}
+	name := rfKey(rf)
+
+	c.mu.Lock()
+	cached, ok := c.states[name]
+	c.mu.Unlock()
+	if ok && cached.key == key {
+		return cached.state.deepCopy(), nil
+	}
+
+	state, err := BuildDesiredState(rf, labels, ownerRefs, password)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.states[name] = cachedDesiredState{key: key, state: state}
+	c.mu.Unlock()
+	return state.deepCopy(), nil
+}
+
+// deepCopy returns a copy of the state whose objects can be changed without changing the state. The
+// workloads are changed before they are written to keep the fields of the live ones.
+func (s *DesiredState) deepCopy() *DesiredState {
+	copied := *s
+	copied.Objects = make([]DesiredObject, len(s.Objects))
+	for i, o := range s.Objects {
+		o.Object = o.Object.DeepCopyObject()
+		copied.Objects[i] = o
+	}
+	copied.Required = append([]ObjectRef(nil), s.Required...)
+	copied.Absent = append([]ObjectRef(nil), s.Absent...)
+	return &copied
+}
@@ -0,0 +1,173 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// redisConfig returns the redis configuration of the desired state.
+func redisConfig(t *testing.T, state *rfservice.DesiredState) string {
+	for _, o := range state.Objects {
+		if o.Kind == rfservice.KindConfigMap && o.Name == "rfr-test" {
+			return o.Object.(*corev1.ConfigMap).Data["redis.conf"]
+		}
+	}
+	require.Fail(t, "no redis ConfigMap")
+	return ""
+}
+
+func TestDesiredStateCache(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rf := generateRF()
+	rf.Generation = 1
+	rf.Spec.Redis.Port = 6379
+	cache := rfservice.NewDesiredStateCache("v1", "")
+
+	state, err := cache.Get(rf, nil, nil, "")
+	require.NoError(err)
+	assert.Contains(redisConfig(t, state), "\nport 6379\n")
+
+	// The objects returned are copies, the cached state is not changed with them.
+	state.Objects[0].Object.(metav1.Object).SetName("changed")
+	cached, err := cache.Get(rf, nil, nil, "")
+	require.NoError(err)
+	assert.NotEqual("changed", cached.Objects[0].Object.(metav1.Object).GetName())
+	built, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	require.NoError(err)
+	assert.Equal(built, cached)
+
+	// The spec is identified by the generation, a spec changed without it is not rendered.
+	rf.Spec.Redis.Port = 6380
+	state, err = cache.Get(rf, nil, nil, "")
+	require.NoError(err)
+	assert.Contains(redisConfig(t, state), "\nport 6379\n")
+
+	rf.Generation = 2
+	state, err = cache.Get(rf, nil, nil, "")
+	require.NoError(err)
+	assert.Contains(redisConfig(t, state), "\nport 6380\n")
+
+	// The inputs not bumping the generation render the state again.
+	state, err = cache.Get(rf, map[string]string{"team": "cache"}, nil, "")
+	require.NoError(err)
+	assert.Equal("cache", state.Objects[0].Object.(metav1.Object).GetLabels()["team"])
+
+	rf.Annotations = map[string]string{redisfailoverv1.AdoptStatefulSetAnnotation: "legacy"}
+	state, err = cache.Get(rf, map[string]string{"team": "cache"}, nil, "")
+	require.NoError(err)
+	built, err = rfservice.BuildDesiredState(rf, map[string]string{"team": "cache"}, nil, "")
+	require.NoError(err)
+	assert.Equal(built, state)
+
+	// A state rendered by another version of the operator or with other feature gates is not reused.
+	for _, other := range []*rfservice.DesiredStateCache{
+		rfservice.NewDesiredStateCache("v2", ""),
+		rfservice.NewDesiredStateCache("v1", "use-evictions=true"),
+	} {
+		state, err := other.Get(rf, map[string]string{"team": "cache"}, nil, "")
+		require.NoError(err)
+		assert.Equal(built, state)
+	}
+}
+
+func TestEnsureDesiredStateRendersAgainOnSecretChange(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Generation = 1
+	rf.Spec.Auth.SecretPath = "redis-auth"
+	notFound := kubeerrors.NewNotFound(schema.GroupResource{}, "")
+
+	configs := []string{}
+	ms := &mK8SService.Services{}
+	ms.On("GetService", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetNetworkPolicy", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetCertificate", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetServiceMonitor", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetHPA", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetRoleBinding", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetRole", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetServiceAccount", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Return(&appsv1.StatefulSet{}, nil)
+	ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Return(&appsv1.Deployment{}, nil)
+	ms.On("CreateOrUpdateService", mock.Anything, namespace, mock.Anything).Return(nil)
+	ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Run(func(args mock.Arguments) {
+		if cm := args.Get(2).(*corev1.ConfigMap); cm.Name == "rfr-test" {
+			configs = append(configs, cm.Data["redis.conf"])
+		}
+	}).Return(nil)
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Return(nil)
+	ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Return(nil)
+	ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	client.DesiredStates = rfservice.NewDesiredStateCache("v1", "")
+
+	for _, password := range []string{"first", "first", "second"} {
+		ms.On("GetSecret", mock.Anything, namespace, "redis-auth").Once().Return(&corev1.Secret{
+			Data: map[string][]byte{"password": []byte(password)},
+		}, nil)
+		assert.NoError(client.EnsureDesiredState(context.TODO(), rf, nil, nil))
+	}
+
+	if assert.Len(configs, 3) {
+		assert.Contains(configs[0], "requirepass first")
+		assert.Equal(configs[0], configs[1])
+		assert.Contains(configs[2], "requirepass second")
+	}
+	ms.AssertExpectations(t)
+}
+
+// BenchmarkDesiredState renders the desired state of a RF with 500 lines of custom config and extra
+// containers, as every reconcile does, and gets it from the cache.
+func BenchmarkDesiredState(b *testing.B) {
+	rf := generateRF()
+	rf.Generation = 1
+	for i := 0; i < 500; i++ {
+		rf.Spec.Redis.CustomConfig = append(rf.Spec.Redis.CustomConfig, fmt.Sprintf("rename-command CMD%d RENAMED%d", i, i))
+	}
+	for i := 0; i < 10; i++ {
+		container := corev1.Container{
+			Name:  fmt.Sprintf("extra-%d", i),
+			Image: "busybox",
+			Env:   []corev1.EnvVar{{Name: "EXTRA", Value: fmt.Sprintf("%d", i)}},
+		}
+		rf.Spec.Redis.ExtraContainers = append(rf.Spec.Redis.ExtraContainers, container)
+		rf.Spec.Sentinel.ExtraContainers = append(rf.Spec.Sentinel.ExtraContainers, container)
+	}
+
+	b.Run("render", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			if _, err := rfservice.BuildDesiredState(rf, nil, nil, "secret"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cache", func(b *testing.B) {
+		cache := rfservice.NewDesiredStateCache("v1", "")
+		for n := 0; n < b.N; n++ {
+			if _, err := cache.Get(rf, nil, nil, "secret"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,726 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+)
+
+func TestBuildDesiredState(t *testing.T) {
+	// The objects are ordered by kind, the ConfigMaps and the Services before the workloads.
+	sentinelConfigMaps := []string{"ConfigMap/rfs-test"}
+	redisConfigMaps := []string{"ConfigMap/rfr-s-test", "ConfigMap/rfr-readiness-test", "ConfigMap/rfr-test"}
+	sentinelService := []string{"Service/rfs-test"}
+	redisServiceAccount := []string{"ServiceAccount/rfr-test", "Role/rfr-test", "RoleBinding/rfr-test"}
+	redisPDB := []string{"PodDisruptionBudget/rfr-test"}
+	sentinelPDB := []string{"PodDisruptionBudget/rfs-test"}
+	redisStatefulSet := []string{"StatefulSet/rfr-test"}
+	sentinelDeployment := []string{"Deployment/rfs-test"}
+	concat := func(refs ...[]string) []string {
+		all := []string{}
+		for _, r := range refs {
+			all = append(all, r...)
+		}
+		return all
+	}
+	everything := concat(sentinelConfigMaps, redisConfigMaps, sentinelService, redisPDB, sentinelPDB, redisStatefulSet, sentinelDeployment)
+
+	tests := []struct {
+		name        string
+		change      func(rf *redisfailoverv1.RedisFailover)
+		expObjects  []string
+		expAbsent   []string
+		expRequired []string
+	}{
+		{
+			name:       "Everything is deployed, without the optional services",
+			change:     func(rf *redisfailoverv1.RedisFailover) {},
+			expObjects: everything,
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "The exporter deploys the redis service",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.Redis.Exporter.Enabled = true
+			},
+			expObjects: concat(sentinelConfigMaps, redisConfigMaps, []string{"Service/rfr-test"}, sentinelService, redisPDB, sentinelPDB, redisStatefulSet, sentinelDeployment),
+			expAbsent:  []string{"ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "The master DNS deploys the master service",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.Redis.MasterDNS = &redisfailoverv1.RedisMasterDNS{Hostname: "redis.example.com"}
+			},
+			expObjects: concat(sentinelConfigMaps, redisConfigMaps, []string{"Service/rfrm-test"}, sentinelService, redisPDB, sentinelPDB, redisStatefulSet, sentinelDeployment),
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "The network policy isolates the redis and the sentinel pods",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.NetworkPolicy = &redisfailoverv1.NetworkPolicySettings{}
+			},
+			expObjects: concat(sentinelConfigMaps, redisConfigMaps, sentinelService, redisPDB, []string{"NetworkPolicy/rfr-test"}, sentinelPDB, []string{"NetworkPolicy/rfs-test"}, redisStatefulSet, sentinelDeployment),
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "The disabled network policy deletes the policies",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				disabled := false
+				rf.Spec.NetworkPolicy = &redisfailoverv1.NetworkPolicySettings{Enabled: &disabled}
+			},
+			expObjects: everything,
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "The sentinel autoscaling scales the sentinel deployment",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.SentinelAutoScaling = &redisfailoverv1.SentinelAutoScalingSettings{MaxReplicas: 5}
+			},
+			expObjects: concat(everything, []string{"HorizontalPodAutoscaler/rfs-test"}),
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "The service monitor scrapes the redis exporter",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.Redis.Exporter = redisfailoverv1.Exporter{Enabled: true, ServiceMonitor: &redisfailoverv1.ServiceMonitorSettings{Enabled: true}}
+			},
+			expObjects: concat(sentinelConfigMaps, redisConfigMaps, []string{"Service/rfr-test"}, sentinelService, []string{"ServiceMonitor/rfr-test"}, redisPDB, sentinelPDB, redisStatefulSet, sentinelDeployment),
+			expAbsent:  []string{"Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "The service monitor is deleted without the exporter",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.Redis.Exporter = redisfailoverv1.Exporter{ServiceMonitor: &redisfailoverv1.ServiceMonitorSettings{Enabled: true}}
+			},
+			expObjects: everything,
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "The TLS issuer deploys the certificate of the redis pods",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.TLSConfig = &redisfailoverv1.TLSConfig{Enabled: true, IssuerRef: &redisfailoverv1.TLSIssuerRef{Name: "ca"}}
+			},
+			expObjects: concat(sentinelConfigMaps, redisConfigMaps, []string{"Certificate/rfr-tls-test"}, sentinelService, redisPDB, sentinelPDB, redisStatefulSet, sentinelDeployment),
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "The TLS secret made by hand deploys no certificate",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.TLSConfig = &redisfailoverv1.TLSConfig{Enabled: true, SecretName: "redis-tls"}
+			},
+			expObjects: everything,
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "Only redis is deployed when bootstrapping",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.BootstrapNode = &redisfailoverv1.BootstrapSettings{Host: "127.0.0.1", Port: "6379"}
+			},
+			expObjects: concat(redisConfigMaps, redisPDB, redisStatefulSet),
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "Everything is deployed when bootstrapping allows sentinels",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.BootstrapNode = &redisfailoverv1.BootstrapSettings{Host: "127.0.0.1", Port: "6379", AllowSentinels: true}
+			},
+			expObjects: everything,
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "Only the sentinels are deployed with external nodes",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.Redis.ExternalNodes = []redisfailoverv1.RedisExternalNode{{Host: "10.0.0.1", Port: "6379"}}
+			},
+			expObjects: concat(sentinelConfigMaps, sentinelService, sentinelPDB, sentinelDeployment),
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+		{
+			name: "The shutdown ConfigMap given in the spec is required",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				rf.Spec.Redis.ShutdownConfigMap = "custom-shutdown"
+			},
+			expObjects:  concat(sentinelConfigMaps, redisConfigMaps[1:], sentinelService, redisPDB, sentinelPDB, redisStatefulSet, sentinelDeployment),
+			expAbsent:   []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+			expRequired: []string{"ConfigMap/custom-shutdown"},
+		},
+		{
+			name: "The managed service account runs the redis pods",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				managed := true
+				rf.Spec.Redis.ManagedServiceAccount = &managed
+			},
+			expObjects: concat(sentinelConfigMaps, redisConfigMaps, redisServiceAccount, sentinelService, redisPDB, sentinelPDB, redisStatefulSet, sentinelDeployment),
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test"},
+		},
+		{
+			name: "The service account given in the spec replaces the managed one",
+			change: func(rf *redisfailoverv1.RedisFailover) {
+				managed := true
+				rf.Spec.Redis.ManagedServiceAccount = &managed
+				rf.Spec.Redis.ServiceAccountName = "redis"
+			},
+			expObjects: everything,
+			expAbsent:  []string{"Service/rfr-test", "ServiceMonitor/rfr-test", "Service/rfrm-test", "NetworkPolicy/rfr-test", "NetworkPolicy/rfs-test", "Certificate/rfr-tls-test", "ConfigMap/rfr-stunnel-test", "HorizontalPodAutoscaler/rfs-test", "RoleBinding/rfr-test", "Role/rfr-test", "ServiceAccount/rfr-test"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			test.change(rf)
+			state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+			assert.NoError(err)
+
+			objects := []string{}
+			for _, o := range state.Objects {
+				objects = append(objects, o.Ref().String())
+				assert.NotEmpty(o.Hash)
+			}
+			refs := func(rs []rfservice.ObjectRef) []string {
+				names := []string{}
+				for _, r := range rs {
+					names = append(names, r.String())
+				}
+				return names
+			}
+			if test.expRequired == nil {
+				test.expRequired = []string{}
+			}
+			assert.Equal(test.expObjects, objects)
+			assert.Equal(test.expAbsent, refs(state.Absent))
+			assert.Equal(test.expRequired, refs(state.Required))
+		})
+	}
+}
+
+func TestBuildDesiredStateGolden(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.Image = "redis:7.0"
+	rf.Spec.Redis.Exporter = redisfailoverv1.Exporter{
+		Enabled: true,
+		Image:   "quay.io/oliver006/redis_exporter:v1.43.0",
+		ServiceMonitor: &redisfailoverv1.ServiceMonitorSettings{
+			Enabled:       true,
+			Interval:      "30s",
+			ScrapeTimeout: "10s",
+			Labels:        map[string]string{"release": "prometheus"},
+		},
+	}
+	rf.Spec.Redis.MasterDNS = &redisfailoverv1.RedisMasterDNS{Hostname: "redis.example.com"}
+	rf.Spec.Redis.ServiceAnnotations = map[string]string{"example.com/owner": "cache"}
+	rf.Spec.Redis.PodAnnotations = map[string]string{"backup": "daily"}
+	rf.Spec.Sentinel.Image = "redis:7.0"
+	rf.Spec.NetworkPolicy = &redisfailoverv1.NetworkPolicySettings{
+		AllowedClients:    []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}}},
+		AllowedNamespaces: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "cache"}},
+	}
+	rf.Spec.SentinelAutoScaling = &redisfailoverv1.SentinelAutoScalingSettings{MaxReplicas: 5}
+	rf.Spec.TLSConfig = &redisfailoverv1.TLSConfig{Enabled: true, IssuerRef: &redisfailoverv1.TLSIssuerRef{Name: "ca", Kind: "ClusterIssuer"}}
+	require.NoError(rf.Validate())
+
+	labels := map[string]string{"team": "cache"}
+	ownerRefs := []metav1.OwnerReference{{APIVersion: "databases.spotahome.com/v1", Kind: "RedisFailover", Name: name}}
+	state, err := rfservice.BuildDesiredState(rf, labels, ownerRefs, "")
+	require.NoError(err)
+
+	var out bytes.Buffer
+	writeDesiredState(&out, state)
+	expected, err := os.ReadFile(filepath.Join("testdata", "desired-state.golden"))
+	require.NoError(err)
+	assert.Equal(string(expected), out.String())
+}
+
+func TestDesiredStateHash(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	assert.NoError(err)
+	again, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	assert.NoError(err)
+
+	rf.Spec.Redis.Image = "redis:7.2"
+	changed, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	assert.NoError(err)
+
+	for i, o := range state.Objects {
+		assert.Equal(o.Hash, again.Objects[i].Hash, o.Ref().String())
+		if o.Kind == rfservice.KindStatefulSet {
+			assert.NotEqual(o.Hash, changed.Objects[i].Hash, o.Ref().String())
+		} else {
+			assert.Equal(o.Hash, changed.Objects[i].Hash, o.Ref().String())
+		}
+	}
+}
+
+func TestEnsureDesiredState(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.ShutdownConfigMap = "custom-shutdown"
+	notFound := kubeerrors.NewNotFound(schema.GroupResource{}, "")
+
+	applied := []string{}
+	record := func(kind string) func(mock.Arguments) {
+		return func(args mock.Arguments) {
+			applied = append(applied, kind+"/"+args.Get(2).(metav1.Object).GetName())
+		}
+	}
+	ms := &mK8SService.Services{}
+	ms.On("GetService", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.Service{}, nil)
+	ms.On("DeleteService", mock.Anything, namespace, "rfr-test").Once().Return(nil)
+	ms.On("GetService", mock.Anything, namespace, "rfrm-test").Once().Return(nil, notFound)
+	ms.On("GetNetworkPolicy", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+	ms.On("GetNetworkPolicy", mock.Anything, namespace, "rfs-test").Once().Return(nil, notFound)
+	ms.On("GetCertificate", mock.Anything, namespace, "rfr-tls-test").Once().Return(nil, notFound)
+	ms.On("GetServiceMonitor", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+	ms.On("GetHPA", mock.Anything, namespace, "rfs-test").Once().Return(nil, notFound)
+	ms.On("GetRoleBinding", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+	ms.On("GetRole", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+	ms.On("GetServiceAccount", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, "custom-shutdown").Once().Return(&corev1.ConfigMap{}, nil)
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-stunnel-test").Once().Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-test-part-0").Once().Return(nil, notFound)
+	ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Twice().Return(&appsv1.StatefulSet{}, nil)
+	ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Twice().Return(&appsv1.Deployment{}, nil)
+	ms.On("CreateOrUpdateService", mock.Anything, namespace, mock.Anything).Run(record(rfservice.KindService)).Return(nil)
+	ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Run(record(rfservice.KindConfigMap)).Return(nil)
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Run(record(rfservice.KindPodDisruptionBudget)).Return(nil)
+	ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Run(record(rfservice.KindStatefulSet)).Return(nil)
+	ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Run(record(rfservice.KindDeployment)).Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	err := client.EnsureDesiredState(context.TODO(), rf, nil, nil)
+
+	assert.NoError(err)
+	assert.Equal([]string{
+		"ConfigMap/rfs-test",
+		"ConfigMap/rfr-readiness-test",
+		"ConfigMap/rfr-test",
+		"Service/rfs-test",
+		"PodDisruptionBudget/rfr-test",
+		"PodDisruptionBudget/rfs-test",
+		"StatefulSet/rfr-test",
+		"Deployment/rfs-test",
+	}, applied)
+	ms.AssertExpectations(t)
+	ms.AssertNotCalled(t, "DeleteService", mock.Anything, namespace, "rfrm-test")
+}
+
+func TestEnsureDesiredStatePodDisruptionBudgetsUnavailable(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	notFound := kubeerrors.NewNotFound(schema.GroupResource{}, "")
+
+	ms := &mK8SService.Services{}
+	ms.On("GetService", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetNetworkPolicy", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetCertificate", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetServiceMonitor", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetHPA", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetRoleBinding", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetRole", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetServiceAccount", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-stunnel-test").Once().Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-test-part-0").Once().Return(nil, notFound)
+	ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Twice().Return(&appsv1.StatefulSet{}, nil)
+	ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Twice().Return(&appsv1.Deployment{}, nil)
+	ms.On("CreateOrUpdateService", mock.Anything, namespace, mock.Anything).Return(nil)
+	ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Return(nil)
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Twice().Return(k8s.ErrPodDisruptionBudgetsUnavailable)
+	ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	err := client.EnsureDesiredState(context.TODO(), rf, nil, nil)
+
+	// The workloads are written without their PodDisruptionBudgets.
+	assert.ErrorIs(err, k8s.ErrPodDisruptionBudgetsUnavailable)
+	ms.AssertExpectations(t)
+}
+
+func TestEnsureDesiredStateCertificatesUnavailable(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.TLSConfig = &redisfailoverv1.TLSConfig{Enabled: true, IssuerRef: &redisfailoverv1.TLSIssuerRef{Name: "ca"}}
+	notFound := kubeerrors.NewNotFound(schema.GroupResource{}, "")
+
+	ms := &mK8SService.Services{}
+	ms.On("GetService", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetNetworkPolicy", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetServiceMonitor", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetHPA", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetRoleBinding", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetRole", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetServiceAccount", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-stunnel-test").Once().Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-test-part-0").Once().Return(nil, notFound)
+	ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Twice().Return(&appsv1.StatefulSet{}, nil)
+	ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Twice().Return(&appsv1.Deployment{}, nil)
+	ms.On("CreateOrUpdateService", mock.Anything, namespace, mock.Anything).Return(nil)
+	ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Return(nil)
+	ms.On("CreateOrUpdateCertificate", mock.Anything, namespace, mock.Anything).Once().Return(k8s.ErrCertificatesUnavailable)
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Twice().Return(nil)
+	ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+
+	// Without cert-manager the workloads are still written, the redis pods wait for the secret.
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	assert.NoError(client.EnsureDesiredState(context.TODO(), rf, nil, nil))
+	ms.AssertExpectations(t)
+}
+
+func TestEnsureDesiredStateServiceMonitorsUnavailable(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.Exporter = redisfailoverv1.Exporter{Enabled: true, ServiceMonitor: &redisfailoverv1.ServiceMonitorSettings{Enabled: true}}
+	notFound := kubeerrors.NewNotFound(schema.GroupResource{}, "")
+
+	ms := &mK8SService.Services{}
+	ms.On("GetService", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetNetworkPolicy", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetCertificate", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetHPA", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetRoleBinding", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetRole", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetServiceAccount", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-stunnel-test").Once().Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-test-part-0").Once().Return(nil, notFound)
+	ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Twice().Return(&appsv1.StatefulSet{}, nil)
+	ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Twice().Return(&appsv1.Deployment{}, nil)
+	ms.On("CreateOrUpdateService", mock.Anything, namespace, mock.Anything).Twice().Return(nil)
+	ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Return(nil)
+	ms.On("CreateOrUpdateServiceMonitor", mock.Anything, namespace, mock.Anything).Once().Return(k8s.ErrServiceMonitorsUnavailable)
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Twice().Return(nil)
+	ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+
+	// Without the prometheus-operator the rest of the desired state is written.
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	assert.NoError(client.EnsureDesiredState(context.TODO(), rf, nil, nil))
+	ms.AssertExpectations(t)
+}
+
+func TestEnsureDesiredStateServerSideApply(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	notFound := kubeerrors.NewNotFound(schema.GroupResource{}, "")
+
+	ms := &mK8SService.Services{}
+	ms.On("GetService", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetNetworkPolicy", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetCertificate", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetServiceMonitor", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetHPA", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetRoleBinding", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetRole", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetServiceAccount", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-stunnel-test").Once().Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-test-part-0").Once().Return(nil, notFound)
+	ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Twice().Return(&appsv1.StatefulSet{}, nil)
+	ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Twice().Return(&appsv1.Deployment{}, nil)
+	ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Return(nil)
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Twice().Return(nil)
+	ms.On("ApplyService", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("ApplyStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("ApplyDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+
+	// The services and the workloads are applied, the other kinds are still created or updated.
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	client.ServerSideApply = true
+	assert.NoError(client.EnsureDesiredState(context.TODO(), rf, nil, nil))
+	ms.AssertExpectations(t)
+}
+
+func TestEnsureDesiredStateRequiredMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.Exporter.Enabled = true
+	rf.Spec.Redis.MasterDNS = &redisfailoverv1.RedisMasterDNS{Hostname: "redis.example.com"}
+	rf.Spec.Redis.ShutdownConfigMap = "custom-shutdown"
+	expErr := errors.New("wanted error")
+
+	ms := &mK8SService.Services{}
+	ms.On("GetNetworkPolicy", mock.Anything, namespace, "rfr-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("GetNetworkPolicy", mock.Anything, namespace, "rfs-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("GetCertificate", mock.Anything, namespace, "rfr-tls-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("GetServiceMonitor", mock.Anything, namespace, "rfr-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("GetHPA", mock.Anything, namespace, "rfs-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("GetRoleBinding", mock.Anything, namespace, "rfr-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("GetRole", mock.Anything, namespace, "rfr-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("GetServiceAccount", mock.Anything, namespace, "rfr-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-stunnel-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("GetConfigMap", mock.Anything, namespace, "custom-shutdown").Once().Return(nil, expErr)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	err := client.EnsureDesiredState(context.TODO(), rf, nil, nil)
+
+	assert.ErrorIs(err, expErr)
+	ms.AssertExpectations(t)
+	ms.AssertNotCalled(t, "CreateOrUpdateService", mock.Anything, mock.Anything, mock.Anything)
+	ms.AssertNotCalled(t, "CreateOrUpdateConfigMap", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEnsureDesiredStateDefersWorkloads(t *testing.T) {
+	notFound := kubeerrors.NewNotFound(schema.GroupResource{}, "")
+	expErr := errors.New("wanted error")
+	isConfigMap := func(name string) interface{} {
+		return mock.MatchedBy(func(cm *corev1.ConfigMap) bool { return cm.Name == name })
+	}
+
+	tests := []struct {
+		name   string
+		mocks  func(ms *mK8SService.Services)
+		expErr error
+	}{
+		{
+			name: "The redis ConfigMap fails to be created",
+			mocks: func(ms *mK8SService.Services) {
+				ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, isConfigMap("rfr-test")).Once().Return(expErr)
+			},
+			expErr: expErr,
+		},
+		{
+			name: "The redis ConfigMap can't be read yet after its creation",
+			mocks: func(ms *mK8SService.Services) {
+				ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, isConfigMap("rfr-test")).Once().Return(nil)
+				ms.On("CreateOrUpdateService", mock.Anything, namespace, mock.Anything).Return(nil)
+				ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Return(nil)
+				ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+				ms.On("GetConfigMap", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.ConfigMap{}, nil)
+				ms.On("GetConfigMap", mock.Anything, namespace, "rfr-readiness-test").Once().Return(&corev1.ConfigMap{}, nil)
+				ms.On("GetConfigMap", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+			},
+			expErr: notFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.ShutdownConfigMap = "custom-shutdown"
+
+			ms := &mK8SService.Services{}
+			ms.On("GetService", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+			ms.On("GetService", mock.Anything, namespace, "rfrm-test").Once().Return(nil, notFound)
+			ms.On("GetNetworkPolicy", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+			ms.On("GetNetworkPolicy", mock.Anything, namespace, "rfs-test").Once().Return(nil, notFound)
+			ms.On("GetCertificate", mock.Anything, namespace, "rfr-tls-test").Once().Return(nil, notFound)
+			ms.On("GetServiceMonitor", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+			ms.On("GetHPA", mock.Anything, namespace, "rfs-test").Once().Return(nil, notFound)
+			ms.On("GetRoleBinding", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+			ms.On("GetRole", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+			ms.On("GetServiceAccount", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+			ms.On("GetConfigMap", mock.Anything, namespace, "rfr-stunnel-test").Once().Return(nil, notFound)
+			ms.On("GetConfigMap", mock.Anything, namespace, "custom-shutdown").Once().Return(&corev1.ConfigMap{}, nil)
+			ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, isConfigMap("rfs-test")).Once().Return(nil)
+			ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, isConfigMap("rfr-readiness-test")).Once().Return(nil)
+			test.mocks(ms)
+
+			client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+			err := client.EnsureDesiredState(context.TODO(), rf, nil, nil)
+
+			assert.ErrorIs(err, test.expErr)
+			ms.AssertExpectations(t)
+			ms.AssertNotCalled(t, "CreateOrPatchStatefulSet", mock.Anything, mock.Anything, mock.Anything)
+			ms.AssertNotCalled(t, "CreateOrUpdateDeployment", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// writeDesiredState writes a summary of the objects of the desired state, with the fields that tell
+// the components apart.
+func writeDesiredState(out *bytes.Buffer, state *rfservice.DesiredState) {
+	for _, o := range state.Objects {
+		meta := o.Object.(metav1.Object)
+		fmt.Fprintf(out, "%s %s\n", o.Kind, o.Name)
+		fmt.Fprintf(out, "  labels: %s\n", formatMap(meta.GetLabels()))
+		if len(meta.GetAnnotations()) > 0 {
+			fmt.Fprintf(out, "  annotations: %s\n", formatMap(meta.GetAnnotations()))
+		}
+		owners := []string{}
+		for _, ref := range meta.GetOwnerReferences() {
+			owners = append(owners, ref.Kind+"/"+ref.Name)
+		}
+		fmt.Fprintf(out, "  owners: %s\n", strings.Join(owners, ", "))
+
+		switch obj := o.Object.(type) {
+		case *corev1.Service:
+			if obj.Spec.Type != "" {
+				fmt.Fprintf(out, "  type: %s\n", obj.Spec.Type)
+			}
+			if obj.Spec.ClusterIP != "" {
+				fmt.Fprintf(out, "  clusterIP: %s\n", obj.Spec.ClusterIP)
+			}
+			for _, p := range obj.Spec.Ports {
+				fmt.Fprintf(out, "  port: %s %d/%s", p.Name, p.Port, p.Protocol)
+				if p.TargetPort != (intstr.IntOrString{}) {
+					fmt.Fprintf(out, "->%s", p.TargetPort.String())
+				}
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "  selector: %s\n", formatMap(obj.Spec.Selector))
+		case *corev1.ConfigMap:
+			keys := []string{}
+			for k := range obj.Data {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			fmt.Fprintf(out, "  data: %s\n", strings.Join(keys, ", "))
+		case *policyv1.PodDisruptionBudget:
+			fmt.Fprintf(out, "  minAvailable: %s\n", obj.Spec.MinAvailable.String())
+			fmt.Fprintf(out, "  selector: %s\n", formatMap(obj.Spec.Selector.MatchLabels))
+		case *networkingv1.NetworkPolicy:
+			fmt.Fprintf(out, "  selector: %s\n", formatMap(obj.Spec.PodSelector.MatchLabels))
+			for _, rule := range obj.Spec.Ingress {
+				ports := []string{}
+				for _, p := range rule.Ports {
+					ports = append(ports, fmt.Sprintf("%s/%s", p.Port.String(), *p.Protocol))
+				}
+				fmt.Fprintf(out, "  ingress: %s\n", strings.Join(ports, ", "))
+				for _, peer := range rule.From {
+					if peer.NamespaceSelector != nil {
+						fmt.Fprintf(out, "    from namespaces: %s\n", formatMap(peer.NamespaceSelector.MatchLabels))
+						continue
+					}
+					fmt.Fprintf(out, "    from: %s\n", formatMap(peer.PodSelector.MatchLabels))
+				}
+			}
+		case *appsv1.StatefulSet:
+			fmt.Fprintf(out, "  replicas: %d\n", *obj.Spec.Replicas)
+			fmt.Fprintf(out, "  serviceName: %s\n", obj.Spec.ServiceName)
+			fmt.Fprintf(out, "  selector: %s\n", formatMap(obj.Spec.Selector.MatchLabels))
+			writePodTemplate(out, obj.Spec.Template)
+		case *appsv1.Deployment:
+			fmt.Fprintf(out, "  replicas: %d\n", *obj.Spec.Replicas)
+			fmt.Fprintf(out, "  selector: %s\n", formatMap(obj.Spec.Selector.MatchLabels))
+			writePodTemplate(out, obj.Spec.Template)
+		case *unstructured.Unstructured:
+			if o.Kind == rfservice.KindServiceMonitor {
+				selector, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "selector", "matchLabels")
+				namespaces, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "namespaceSelector", "matchNames")
+				endpoints, _, _ := unstructured.NestedSlice(obj.Object, "spec", "endpoints")
+				fmt.Fprintf(out, "  selector: %s\n", formatMap(selector))
+				fmt.Fprintf(out, "  namespaces: %s\n", strings.Join(namespaces, ", "))
+				for _, e := range endpoints {
+					endpoint := map[string]string{}
+					for k, v := range e.(map[string]interface{}) {
+						endpoint[k] = v.(string)
+					}
+					fmt.Fprintf(out, "  endpoint: %s\n", formatMap(endpoint))
+				}
+				break
+			}
+			secretName, _, _ := unstructured.NestedString(obj.Object, "spec", "secretName")
+			issuer, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "issuerRef")
+			dnsNames, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "dnsNames")
+			fmt.Fprintf(out, "  secretName: %s\n", secretName)
+			fmt.Fprintf(out, "  issuer: %s\n", formatMap(issuer))
+			fmt.Fprintf(out, "  dnsNames: %s\n", strings.Join(dnsNames, ", "))
+		case *corev1.ServiceAccount:
+			fmt.Fprintf(out, "  automountToken: %t\n", *obj.AutomountServiceAccountToken)
+		case *rbacv1.Role:
+			fmt.Fprintf(out, "  rules: %d\n", len(obj.Rules))
+		case *rbacv1.RoleBinding:
+			fmt.Fprintf(out, "  role: %s/%s\n", obj.RoleRef.Kind, obj.RoleRef.Name)
+			for _, s := range obj.Subjects {
+				fmt.Fprintf(out, "  subject: %s/%s/%s\n", s.Kind, s.Namespace, s.Name)
+			}
+		case *autoscalingv2.HorizontalPodAutoscaler:
+			target := obj.Spec.ScaleTargetRef
+			fmt.Fprintf(out, "  target: %s/%s\n", target.Kind, target.Name)
+			fmt.Fprintf(out, "  replicas: %d-%d\n", *obj.Spec.MinReplicas, obj.Spec.MaxReplicas)
+			for _, m := range obj.Spec.Metrics {
+				fmt.Fprintf(out, "  utilization: %s=%d%%\n", m.Resource.Name, *m.Resource.Target.AverageUtilization)
+			}
+		}
+	}
+	for _, ref := range state.Required {
+		fmt.Fprintf(out, "required %s\n", ref)
+	}
+	for _, ref := range state.Absent {
+		fmt.Fprintf(out, "absent %s\n", ref)
+	}
+	fmt.Fprintf(out, "redis config parts: %d\n", state.RedisConfigParts)
+}
+
+func writePodTemplate(out *bytes.Buffer, template corev1.PodTemplateSpec) {
+	fmt.Fprintf(out, "  pod labels: %s\n", formatMap(template.Labels))
+	if len(template.Annotations) > 0 {
+		fmt.Fprintf(out, "  pod annotations: %s\n", formatMap(template.Annotations))
+	}
+	if len(template.Spec.InitContainers) > 0 {
+		fmt.Fprintf(out, "  init containers: %s\n", formatContainers(template.Spec.InitContainers))
+	}
+	fmt.Fprintf(out, "  containers: %s\n", formatContainers(template.Spec.Containers))
+	if template.Spec.ServiceAccountName != "" {
+		fmt.Fprintf(out, "  serviceAccount: %s\n", template.Spec.ServiceAccountName)
+	}
+	volumes := []string{}
+	for _, v := range template.Spec.Volumes {
+		volumes = append(volumes, v.Name)
+	}
+	fmt.Fprintf(out, "  volumes: %s\n", strings.Join(volumes, ", "))
+}
+
+func formatMap(m map[string]string) string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+m[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func formatContainers(containers []corev1.Container) string {
+	names := []string{}
+	for _, c := range containers {
+		names = append(names, c.Name+"="+c.Image)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,57 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestEnsureRedisStatefulSetEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	var reasons []string
+	var applied *appsv1.StatefulSet
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Return(nil, nil)
+	ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Run(func(args mock.Arguments) {
+		applied = args.Get(2).(*appsv1.StatefulSet)
+	}).Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	client.Events = func(_ context.Context, _ *redisfailoverv1.RedisFailover, _, reason, _ string) {
+		reasons = append(reasons, reason)
+	}
+
+	// The statefulset did not exist, it's created.
+	ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	assert.NoError(client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+	assert.Equal([]string{"StatefulSetCreated"}, reasons)
+
+	// The pod template did not change, nothing is recorded.
+	stored := applied.DeepCopy()
+	ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(stored, nil)
+	assert.NoError(client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+	assert.Equal([]string{"StatefulSetCreated"}, reasons)
+
+	// The pod template changed, its pods are rolled.
+	stored = applied.DeepCopy()
+	stored.Annotations["databases.spotahome.com/template-hash"] = "previous"
+	ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(stored, nil)
+	assert.NoError(client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+	assert.Equal([]string{"StatefulSetCreated", "StatefulSetUpdated"}, reasons)
+
+	ms.AssertExpectations(t)
+}
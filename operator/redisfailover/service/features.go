@@ -0,0 +1,114 @@
+package service
+
+// FeatureGates are the behaviors of the operator enabled on some RFs first, before they become the
+// default. They are resolved once by reconcile.
+type FeatureGates struct {
+	// UseEvictions evicts the redis pods to update them to the statefulset revision instead of deleting
+	// them, so their pdb is honored.
+	UseEvictions bool
+	// PatchStatefulSets patches the redis statefulset with the changes of the desired one instead of
+	// updating it, so the fields set by other controllers are kept. It's the default, disabling it
+	// replaces the whole statefulset again.
+	PatchStatefulSets bool
+	// OrderedTeardown holds the deletion of the RFs with a finalizer until their objects are deleted in
+	// order, the sentinels first, instead of leaving them to the garbage collector.
+	OrderedTeardown bool
+	// DisabledChecks are the checks of the checker disabled with their check-<name> gate, by name. The
+	// checks are all enabled by default.
+	DisabledChecks map[string]bool
+}
+
+// CheckEnabled returns true when the check is not disabled with its feature gate.
+func (g FeatureGates) CheckEnabled(name string) bool {
+	return !g.DisabledChecks[name]
+}
+
+// Checks are the checks of the checker that can be disabled with their feature gate, see
+// CheckFeatureGate. The checks finding the master and the sentinels, and the one applying the actions
+// on the redis pods, are needed by the others and can't be.
+var Checks = []string{
+	"redis-number",
+	"sentinel-number",
+	"replication",
+	"role-labels",
+	"stuck-replicas",
+	"redis-config",
+	"redis-acl",
+	"redis-updates",
+	"redis-restarts",
+	"unresponsive-sentinels",
+	"sentinel-monitor",
+	"sentinel-reset",
+	"sentinel-config",
+}
+
+// CheckFeatureGate returns the name of the feature gate of the check, as in "check-redis-config".
+func CheckFeatureGate(check string) string {
+	return "check-" + check
+}
+
+// featureGate is a known feature gate.
+type featureGate struct {
+	enabled bool
+	set     func(g *FeatureGates, enabled bool)
+}
+
+// featureGates are the known feature gates by name, with their default.
+var featureGates = withCheckGates(map[string]featureGate{
+	"use-evictions": {
+		enabled: false,
+		set:     func(g *FeatureGates, enabled bool) { g.UseEvictions = enabled },
+	},
+	"patch-statefulsets": {
+		enabled: true,
+		set:     func(g *FeatureGates, enabled bool) { g.PatchStatefulSets = enabled },
+	},
+	"ordered-teardown": {
+		enabled: false,
+		set:     func(g *FeatureGates, enabled bool) { g.OrderedTeardown = enabled },
+	},
+})
+
+// withCheckGates adds the feature gates of the checks to the gates, enabled by default.
+func withCheckGates(gates map[string]featureGate) map[string]featureGate {
+	for _, check := range Checks {
+		check := check
+		gates[CheckFeatureGate(check)] = featureGate{
+			enabled: true,
+			set: func(g *FeatureGates, enabled bool) {
+				if enabled {
+					delete(g.DisabledChecks, check)
+					return
+				}
+				if g.DisabledChecks == nil {
+					g.DisabledChecks = map[string]bool{}
+				}
+				g.DisabledChecks[check] = true
+			},
+		}
+	}
+	return gates
+}
+
+// IsFeatureGate returns true when the name is a known feature gate.
+func IsFeatureGate(name string) bool {
+	_, ok := featureGates[name]
+	return ok
+}
+
+// NewFeatureGates returns the feature gates with their default, overridden by the given values of the
+// known gates. The unknown gates are ignored.
+func NewFeatureGates(overrides ...map[string]bool) FeatureGates {
+	gates := FeatureGates{}
+	for _, gate := range featureGates {
+		gate.set(&gates, gate.enabled)
+	}
+	for _, values := range overrides {
+		for name, enabled := range values {
+			if gate, ok := featureGates[name]; ok {
+				gate.set(&gates, enabled)
+			}
+		}
+	}
+	return gates
+}
@@ -0,0 +1,55 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestEnsureRedisStatefulSetPatchGate(t *testing.T) {
+	tests := []struct {
+		name   string
+		gates  map[string]bool
+		expFun string
+	}{
+		{
+			name:   "Patched by default",
+			expFun: "CreateOrPatchStatefulSet",
+		},
+		{
+			name:   "Updated without the gate",
+			gates:  map[string]bool{"patch-statefulsets": false},
+			expFun: "CreateOrUpdateStatefulSet",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			ms := &mK8SService.Services{}
+			ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+			ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+			ms.On(test.expFun, mock.Anything, namespace, mock.Anything).Once().Return(nil)
+
+			client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+			client.FeatureGates = func(*redisfailoverv1.RedisFailover) rfservice.FeatureGates {
+				return rfservice.NewFeatureGates(test.gates)
+			}
+			assert.NoError(client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+			ms.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/service/k8s"
+)
+
+// functionalProbeKeyPrefix is the prefix of the key the functional probe writes on the master, followed
+// by the uid of the RF so the RFs sharing a redis don't overwrite each other's key.
+const functionalProbeKeyPrefix = "_redisoperator:healthcheck:"
+
+// functionalProbeKeyTTL is how long the key of the functional probe lives once written, redis removes
+// it even when the operator stops probing.
+const functionalProbeKeyTTL = 30 * time.Second
+
+// GetFunctionalProbeKey returns the key the functional probe writes on the master of the RF.
+func GetFunctionalProbeKey(rf *redisfailoverv1.RedisFailover) string {
+	return functionalProbeKeyPrefix + string(rf.UID)
+}
+
+// RedisFunctionalHealth is the result of the functional probe on a redis pod.
+type RedisFunctionalHealth struct {
+	Pod string
+	// Master is true for the pod the key was written on.
+	Master bool
+	// Healthy is true when the master took the key, or when the replica returned it.
+	Healthy bool
+	// Message tells why the pod failed the probe.
+	Message string
+}
+
+// ProbeRedisFunctional writes a key with a new value on the master and reads it back from every replica
+// once they were given the replication delay, and returns the result of every pod, sorted by pod. The
+// results are exposed as metrics. A pod whose role can't be read is left out, its failure is already
+// reported by the other checks, and the replicas are not probed when the master doesn't take the key.
+func (r *RedisFailoverChecker) ProbeRedisFunctional(ctx context.Context, rf *redisfailoverv1.RedisFailover) ([]RedisFunctionalHealth, error) {
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
+	}
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return nil, err
+	}
+
+	rport := getRedisPort(rf.Spec.Redis.Port)
+	var master *corev1.Pod
+	replicas := []corev1.Pod{}
+	for i, rp := range rps.Items {
+		if rp.Status.Phase != corev1.PodRunning || rp.DeletionTimestamp != nil || rp.Status.PodIP == "" {
+			continue
+		}
+		isMaster, err := r.redisClient.IsMaster(rf.PreferredPodIP(rp.Status), rport, password)
+		if err != nil {
+			r.logger.WithField("namespace", rf.Namespace).WithField("pod", rp.Name).Debugf("could not read the role of redis to probe it: %s", err)
+			continue
+		}
+		if isMaster && master == nil {
+			master = &rps.Items[i]
+			continue
+		}
+		replicas = append(replicas, rp)
+	}
+	if master == nil {
+		return nil, errors.New("no redis master to write the key of the functional probe on")
+	}
+
+	key := GetFunctionalProbeKey(rf)
+	value := strconv.FormatInt(time.Now().UnixNano(), 10)
+	results := []RedisFunctionalHealth{}
+	if err := r.redisClient.SetRedisKey(rf.PreferredPodIP(master.Status), rport, password, key, value, functionalProbeKeyTTL); err != nil {
+		results = append(results, RedisFunctionalHealth{
+			Pod:     master.Name,
+			Master:  true,
+			Message: fmt.Sprintf("could not write the key of the functional probe: %s", err),
+		})
+	} else {
+		results = append(results, RedisFunctionalHealth{Pod: master.Name, Master: true, Healthy: true})
+
+		delay := rf.FunctionalProbeReplicationDelay()
+		if len(replicas) > 0 && delay > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+		for _, rp := range replicas {
+			result := RedisFunctionalHealth{Pod: rp.Name}
+			got, err := r.redisClient.GetRedisKey(rf.PreferredPodIP(rp.Status), rport, password, key)
+			switch {
+			case err != nil:
+				result.Message = fmt.Sprintf("could not read the key of the functional probe: %s", err)
+			case got != value:
+				result.Message = fmt.Sprintf("the key of the functional probe written on %s was not replicated after %s", master.Name, delay)
+			default:
+				result.Healthy = true
+			}
+			results = append(results, result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Pod < results[j].Pod
+	})
+
+	r.metricsClient.ResetRedisFunctionalHealth(rf.Namespace, rf.Name)
+	for _, result := range results {
+		r.metricsClient.SetRedisFunctionalHealth(rf.Namespace, rf.Name, result.Pod, result.Healthy)
+	}
+	return results, nil
+}
+
+// excludeFunctionalProbeKey removes the key of the functional probe from the keys of a redis pod, so it
+// never counts in the mass deletions. It's only looked up when the pod has keys in its first database.
+func (r *RedisFailoverChecker) excludeFunctionalProbeKey(rf *redisfailoverv1.RedisFailover, ip, port, password string, keys map[string]int64) {
+	if !rf.FunctionalProbeEnabled() || keys["db0"] == 0 {
+		return
+	}
+	value, err := r.redisClient.GetRedisKey(ip, port, password, GetFunctionalProbeKey(rf))
+	if err != nil || value == "" {
+		return
+	}
+	keys["db0"]--
+	if keys["db0"] == 0 {
+		delete(keys, "db0")
+	}
+}
+
+// getFunctionallyUnhealthyPods returns the redis pods that failed the last functional probe, as set on
+// the status of the RF.
+func getFunctionallyUnhealthyPods(rf *redisfailoverv1.RedisFailover) map[string]bool {
+	unhealthy := map[string]bool{}
+	if !rf.FunctionalProbeEnabled() {
+		return unhealthy
+	}
+	for _, instance := range rf.Status.Instances {
+		if instance.Functional == redisfailoverv1.InstanceFunctionalUnhealthy {
+			unhealthy[instance.Name] = true
+		}
+	}
+	return unhealthy
+}
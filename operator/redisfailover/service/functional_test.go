@@ -0,0 +1,211 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func generateFunctionalProbeRF() *redisfailoverv1.RedisFailover {
+	rf := generateRF()
+	rf.UID = "uid"
+	rf.Spec.Checks = &redisfailoverv1.ChecksSettings{FunctionalProbe: true, ReplicationDelay: &metav1.Duration{}}
+	return rf
+}
+
+func TestProbeRedisFunctional(t *testing.T) {
+	key := "_redisoperator:healthcheck:uid"
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			generateRedisPod("rfr-test-0", "10.0.0.1", corev1.PodRunning),
+			generateRedisPod("rfr-test-1", "10.0.0.2", corev1.PodRunning),
+			generateRedisPod("rfr-test-2", "10.0.0.3", corev1.PodRunning),
+			// Not running, it's not probed.
+			generateRedisPod("rfr-test-3", "", corev1.PodPending),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		setErr     error
+		replica1   func(value string) (string, error)
+		roleErr    bool
+		expResults []rfservice.RedisFunctionalHealth
+	}{
+		{
+			name:     "Every pod serves the key",
+			replica1: func(value string) (string, error) { return value, nil },
+			expResults: []rfservice.RedisFunctionalHealth{
+				{Pod: "rfr-test-0", Master: true, Healthy: true},
+				{Pod: "rfr-test-1", Healthy: true},
+				{Pod: "rfr-test-2", Healthy: true},
+			},
+		},
+		{
+			name: "A replica answering the PING doesn't replicate the key",
+			// It still has the key of the previous check.
+			replica1: func(string) (string, error) { return "1", nil },
+			expResults: []rfservice.RedisFunctionalHealth{
+				{Pod: "rfr-test-0", Master: true, Healthy: true},
+				{Pod: "rfr-test-1", Message: "the key of the functional probe written on rfr-test-0 was not replicated after 0s"},
+				{Pod: "rfr-test-2", Healthy: true},
+			},
+		},
+		{
+			name:     "A replica answering the PING fails the reads",
+			replica1: func(string) (string, error) { return "", errors.New("LOADING Redis is loading the dataset in memory") },
+			expResults: []rfservice.RedisFunctionalHealth{
+				{Pod: "rfr-test-0", Master: true, Healthy: true},
+				{Pod: "rfr-test-1", Message: "could not read the key of the functional probe: LOADING Redis is loading the dataset in memory"},
+				{Pod: "rfr-test-2", Healthy: true},
+			},
+		},
+		{
+			name:   "The master answering the PING fails the writes",
+			setErr: errors.New("OOM command not allowed when used memory > 'maxmemory'"),
+			expResults: []rfservice.RedisFunctionalHealth{
+				{Pod: "rfr-test-0", Master: true, Message: "could not write the key of the functional probe: OOM command not allowed when used memory > 'maxmemory'"},
+			},
+		},
+		{
+			name:     "A replica whose role can't be read is left out",
+			roleErr:  true,
+			replica1: func(value string) (string, error) { return value, nil },
+			expResults: []rfservice.RedisFunctionalHealth{
+				{Pod: "rfr-test-0", Master: true, Healthy: true},
+				{Pod: "rfr-test-1", Healthy: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rf := generateFunctionalProbeRF()
+			ms := &mK8SService.Services{}
+			ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Return(pods, nil)
+			mr := &mRedisService.Client{}
+			mr.On("PingRedis", mock.Anything, "0", "").Return(nil)
+			mr.On("IsMaster", "10.0.0.1", "0", "").Return(true, nil)
+			mr.On("IsMaster", "10.0.0.2", "0", "").Return(false, nil)
+			if test.roleErr {
+				mr.On("IsMaster", "10.0.0.3", "0", "").Return(false, errors.New("wanted error"))
+			} else {
+				mr.On("IsMaster", "10.0.0.3", "0", "").Return(false, nil)
+			}
+
+			var value string
+			mr.On("SetRedisKey", "10.0.0.1", "0", "", key, mock.AnythingOfType("string"), mock.Anything).Run(func(args mock.Arguments) {
+				value = args.String(4)
+			}).Return(test.setErr)
+			mr.On("GetRedisKey", "10.0.0.2", "0", "", key).Return(
+				func(string, string, string, string) string { v, _ := test.replica1(value); return v },
+				func(string, string, string, string) error { _, err := test.replica1(value); return err },
+			)
+			mr.On("GetRedisKey", "10.0.0.3", "0", "", key).Return(
+				func(string, string, string, string) string { return value },
+				nil,
+			)
+
+			checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+			// The pods answer the PING whatever the probe finds.
+			latencies, err := checker.MeasureRedisLatency(context.TODO(), rf)
+			require.NoError(err)
+			assert.Len(latencies, 3)
+			results, err := checker.ProbeRedisFunctional(context.TODO(), rf)
+			require.NoError(err)
+			assert.Equal(test.expResults, results)
+			if test.setErr != nil {
+				mr.AssertNotCalled(t, "GetRedisKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestProbeRedisFunctionalWithoutMaster(t *testing.T) {
+	rf := generateFunctionalProbeRF()
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{generateRedisPod("rfr-test-0", "10.0.0.1", corev1.PodRunning)},
+	}
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Return(pods, nil)
+	mr := &mRedisService.Client{}
+	mr.On("IsMaster", "10.0.0.1", "0", "").Return(false, nil)
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+	_, err := checker.ProbeRedisFunctional(context.TODO(), rf)
+	assert.EqualError(t, err, "no redis master to write the key of the functional probe on")
+	mr.AssertNotCalled(t, "SetRedisKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetRedisKeyspacesWithoutFunctionalProbeKey(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateFunctionalProbeRF()
+	key := rfservice.GetFunctionalProbeKey(rf)
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			generateRedisPod("rfr-test-0", "10.0.0.1", corev1.PodRunning),
+			generateRedisPod("rfr-test-1", "10.0.0.2", corev1.PodRunning),
+		},
+	}
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Return(pods, nil)
+	mr := &mRedisService.Client{}
+	mr.On("GetRedisInfo", "10.0.0.1", "0", "").Return("role:master\r\n# Keyspace\r\ndb0:keys=1,expires=1,avg_ttl=0\r\ndb1:keys=10,expires=0,avg_ttl=0\r\n", nil)
+	mr.On("GetRedisKey", "10.0.0.1", "0", "", key).Return("1", nil)
+	// The key has expired on the replica.
+	mr.On("GetRedisInfo", "10.0.0.2", "0", "").Return("role:slave\r\n# Keyspace\r\ndb0:keys=5,expires=0,avg_ttl=0\r\n", nil)
+	mr.On("GetRedisKey", "10.0.0.2", "0", "", key).Return("", nil)
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+	keyspaces, err := checker.GetRedisKeyspaces(context.TODO(), rf)
+	assert.NoError(err)
+	assert.Equal([]rfservice.RedisKeyspace{
+		{Pod: "rfr-test-0", Master: true, Keys: map[string]int64{"db1": 10}},
+		{Pod: "rfr-test-1", Keys: map[string]int64{"db0": 5}},
+	}, keyspaces)
+}
+
+func TestSetOldestAsMasterSkipsFunctionallyUnhealthyPods(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateFunctionalProbeRF()
+	rf.Status.Instances = []redisfailoverv1.InstanceStatus{
+		{Name: "rfr-test-0", Role: "redis", Functional: redisfailoverv1.InstanceFunctionalUnhealthy},
+		{Name: "rfr-test-1", Role: "redis", Functional: redisfailoverv1.InstanceFunctionalHealthy},
+	}
+	oldest := generateRedisPod("rfr-test-0", "0.0.0.0", corev1.PodRunning)
+	oldest.CreationTimestamp = metav1.Unix(1, 0)
+	youngest := generateRedisPod("rfr-test-1", "1.1.1.1", corev1.PodRunning)
+	youngest.CreationTimestamp = metav1.Unix(2, 0)
+	pods := &corev1.PodList{Items: []corev1.Pod{oldest, youngest}}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("UpdatePodLabels", mock.Anything, namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	mr := &mRedisService.Client{}
+	// The youngest pod is promoted, the oldest one failed the functional probe.
+	mr.On("MakeMaster", "1.1.1.1", "0", "").Once().Return(nil)
+	mr.On("MakeSlaveOfWithPort", "0.0.0.0", "1.1.1.1", "0", "").Once().Return(nil)
+
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+	assert.NoError(healer.SetOldestAsMaster(context.TODO(), rf))
+	mr.AssertExpectations(t)
+}
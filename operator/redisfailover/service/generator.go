@@ -3,8 +3,10 @@ package service
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -15,6 +17,7 @@ import (
 
 	redisfailoverv1 "redis-operator/api/redisfailover/v1"
 	"redis-operator/operator/redisfailover/util"
+	"redis-operator/service/k8s"
 )
 
 const (
@@ -25,20 +28,30 @@ port {{.Spec.Redis.Port}}
 tcp-keepalive 60
 save 900 1
 save 300 10
+{{- if not .Spec.Auth.ACLConfigMapRef}}
 user pinger -@all +ping on >pingpass
+{{- end}}
 {{- range .Spec.Redis.CustomCommandRenames}}
-rename-command "{{.From}}" "{{.To}}"
+rename-command {{quote .From}} {{quote .To}}
 {{- end}}
 `
 
-	sentinelConfigTemplate = `sentinel monitor mymaster 127.0.0.1 {{.Spec.Redis.Port}} 2
+	sentinelConfigTemplate = `sentinel monitor mymaster 127.0.0.1 {{.Spec.Redis.Port}} {{.SentinelQuorum}}
 sentinel down-after-milliseconds mymaster 1000
 sentinel failover-timeout mymaster 3000
-sentinel parallel-syncs mymaster 2`
+sentinel parallel-syncs mymaster 2
+sentinel deny-scripts-reconfig yes
+{{- range $parameter, $value := .SentinelGlobalConfig}}
+sentinel {{$parameter}} {{$value}}
+{{- end}}`
 
 	redisShutdownConfigurationVolumeName = "redis-shutdown-config"
 	redisReadinessVolumeName             = "redis-readiness-config"
 	redisStorageVolumeName               = "redis-data"
+	redisACLVolumeName                   = "redis-acl"
+	// redisACLMountPath is mounted without subPath, the kubelet updates the ACL file when its ConfigMap
+	// changes and the operator loads it with ACL LOAD.
+	redisACLMountPath = "/redis-acl"
 
 	graceTime = 30
 )
@@ -52,13 +65,7 @@ func generateSentinelService(rf *redisfailoverv1.RedisFailover, labels map[strin
 	labels = util.MergeLabels(labels, selectorLabels)
 
 	return &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            name,
-			Namespace:       namespace,
-			Labels:          labels,
-			OwnerReferences: ownerRefs,
-			Annotations:     rf.Spec.Sentinel.ServiceAnnotations,
-		},
+		ObjectMeta: generateObjectMeta(name, namespace, labels, rf.Spec.Sentinel.ServiceAnnotations, ownerRefs),
 		Spec: corev1.ServiceSpec{
 			Selector: selectorLabels,
 			Ports: []corev1.ServicePort{
@@ -69,6 +76,8 @@ func generateSentinelService(rf *redisfailoverv1.RedisFailover, labels map[strin
 					Protocol:   "TCP",
 				},
 			},
+			IPFamilyPolicy: rf.RedisIPFamilyPolicy(),
+			IPFamilies:     rf.RedisIPFamilies(),
 		},
 	}
 }
@@ -87,13 +96,7 @@ func generateRedisService(rf *redisfailoverv1.RedisFailover, labels map[string]s
 	annotations := util.MergeLabels(defaultAnnotations, rf.Spec.Redis.ServiceAnnotations)
 
 	return &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            name,
-			Namespace:       namespace,
-			Labels:          labels,
-			OwnerReferences: ownerRefs,
-			Annotations:     annotations,
-		},
+		ObjectMeta: generateObjectMeta(name, namespace, labels, annotations, ownerRefs),
 		Spec: corev1.ServiceSpec{
 			Type:      corev1.ServiceTypeClusterIP,
 			ClusterIP: corev1.ClusterIPNone,
@@ -104,7 +107,48 @@ func generateRedisService(rf *redisfailoverv1.RedisFailover, labels map[string]s
 					Name:     exporterPortName,
 				},
 			},
-			Selector: selectorLabels,
+			Selector:       selectorLabels,
+			IPFamilyPolicy: rf.RedisIPFamilyPolicy(),
+			IPFamilies:     rf.RedisIPFamilies(),
+		},
+	}
+}
+
+// generateRedisMasterService returns a headless service selecting the pod with the master role label,
+// so the external-dns records follow the master when the role labels are updated after a failover.
+func generateRedisMasterService(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *corev1.Service {
+	name := GetRedisMasterName(rf)
+	namespace := rf.Namespace
+
+	selectorLabels := generateSelectorLabels(redisRoleName, rf.Name)
+	labels = util.MergeLabels(labels, selectorLabels)
+	selectorLabels = util.MergeLabels(selectorLabels, generateRedisMasterRoleLabel())
+
+	dnsAnnotations := map[string]string{}
+	if rf.Spec.Redis.MasterDNS != nil {
+		dnsAnnotations[externalDNSHostnameAnnotation] = rf.Spec.Redis.MasterDNS.Hostname
+		if ttl := rf.Spec.Redis.MasterDNS.GetTTL(); ttl > 0 {
+			dnsAnnotations[externalDNSTTLAnnotation] = strconv.Itoa(int(ttl / time.Second))
+		}
+	}
+	annotations := util.MergeLabels(rf.Spec.Redis.ServiceAnnotations, dnsAnnotations)
+
+	return &corev1.Service{
+		ObjectMeta: generateObjectMeta(name, namespace, labels, annotations, ownerRefs),
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: corev1.ClusterIPNone,
+			Ports: []corev1.ServicePort{
+				{
+					Port:       rf.Spec.Redis.Port,
+					TargetPort: intstr.FromInt(int(rf.Spec.Redis.Port)),
+					Protocol:   corev1.ProtocolTCP,
+					Name:       "redis",
+				},
+			},
+			Selector:       selectorLabels,
+			IPFamilyPolicy: rf.RedisIPFamilyPolicy(),
+			IPFamilies:     rf.RedisIPFamilies(),
 		},
 	}
 }
@@ -127,24 +171,41 @@ func generateSentinelConfigMap(rf *redisfailoverv1.RedisFailover, labels map[str
 
 	sentinelConfigFileContent := tplOutput.String()
 
-	return &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            name,
-			Namespace:       namespace,
-			Labels:          labels,
-			OwnerReferences: ownerRefs,
-		},
+	cm := &corev1.ConfigMap{
+		ObjectMeta: generateObjectMeta(name, namespace, labels, nil, ownerRefs),
 		Data: map[string]string{
 			sentinelConfigFileName: sentinelConfigFileContent,
 		},
 	}
+	setConfigRevision(rf, []*corev1.ConfigMap{cm})
+	return cm
 }
 
-func generateRedisConfigMap(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference, password string) *corev1.ConfigMap {
-	name := GetRedisName(rf)
-	labels = util.MergeLabels(labels, generateSelectorLabels(redisRoleName, rf.Name))
+// quoteConfigValue quotes a value of the redis configuration, so it's read as a single argument
+// whatever it contains.
+func quoteConfigValue(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
 
-	tmpl, err := template.New("redis").Parse(redisConfigTemplate)
+// renderRedisConfig returns the redis configuration, without the password, split in the parts that fit
+// in a ConfigMap. There is only one part when it doesn't need to be split.
+func renderRedisConfig(rf *redisfailoverv1.RedisFailover) ([]string, error) {
+	tmpl, err := template.New("redis").Funcs(template.FuncMap{"quote": quoteConfigValue}).Parse(redisConfigTemplate)
 	if err != nil {
 		panic(err)
 	}
@@ -155,22 +216,76 @@ func generateRedisConfigMap(rf *redisfailoverv1.RedisFailover, labels map[string
 	}
 
 	redisConfigFileContent := tplOutput.String()
+	if rf.ZeroDowntimeReloadEnabled() {
+		redisConfigFileContent += renderZeroDowntimeReloadConfig(rf)
+	}
+	if len(redisConfigFileContent) <= maxConfigMapDataSize {
+		return []string{redisConfigFileContent}, nil
+	}
 
-	if password != "" {
-		redisConfigFileContent = fmt.Sprintf("%s\nmasterauth %s\nrequirepass %s", redisConfigFileContent, password, password)
+	parts, err := util.SplitConfig(redisConfigFileContent, maxConfigMapDataSize)
+	if err != nil {
+		return nil, fmt.Errorf("redis configuration can't be split in ConfigMaps: %w", err)
 	}
+	if len(parts) > maxRedisConfigParts {
+		return nil, fmt.Errorf("redis configuration is %d bytes, more than the %d ConfigMaps of %d bytes it can be split in", len(redisConfigFileContent), maxRedisConfigParts, maxConfigMapDataSize)
+	}
+	return parts, nil
+}
 
-	return &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            name,
-			Namespace:       rf.Namespace,
-			Labels:          labels,
-			OwnerReferences: ownerRefs,
-		},
-		Data: map[string]string{
-			redisConfigFileName: redisConfigFileContent,
-		},
+// generateRedisConfigMaps returns the redis configuration ConfigMap. When the configuration doesn't fit
+// in it, it's split in part ConfigMaps that the main one includes in order. With immutableConfig they are
+// all named after the revision of the whole configuration.
+func generateRedisConfigMaps(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference, password string) ([]*corev1.ConfigMap, error) {
+	name := GetRedisName(rf)
+	labels = util.MergeLabels(labels, generateSelectorLabels(redisRoleName, rf.Name))
+
+	parts, err := renderRedisConfig(rf)
+	if err != nil {
+		return nil, err
+	}
+
+	configMaps := []*corev1.ConfigMap{}
+	redisConfigFileContent := parts[0]
+	if len(parts) > 1 {
+		includes := []string{}
+		for i, part := range parts {
+			fileName := fmt.Sprintf(redisConfigPartFileName, i)
+			includes = append(includes, fmt.Sprintf("include /redis/%s", fileName))
+			configMaps = append(configMaps, &corev1.ConfigMap{
+				ObjectMeta: generateObjectMeta(GetRedisConfigPartName(rf, i), rf.Namespace, labels, nil, ownerRefs),
+				Data: map[string]string{
+					fileName: part,
+				},
+			})
+		}
+		redisConfigFileContent = strings.Join(includes, "\n")
+	}
+
+	if rf.AuthFromFile() {
+		// The password file sets requirepass and masterauth, the operator never renders the password.
+		redisConfigFileContent = fmt.Sprintf("%s\ninclude %s", redisConfigFileContent, rf.Spec.Auth.PasswordFile)
+	} else if password != "" {
+		redisConfigFileContent = fmt.Sprintf("%s\nmasterauth %s\nrequirepass %s", redisConfigFileContent, password, password)
+	}
+	if rf.ACLEnabled() {
+		redisConfigFileContent = fmt.Sprintf("%s\naclfile %s/%s", redisConfigFileContent, redisACLMountPath, redisfailoverv1.ACLFileKey)
 	}
+	if rf.RedisNetworkBootstrapEnabled() {
+		// Written by the init container of the pod, with the addresses of its node.
+		redisConfigFileContent = fmt.Sprintf("%s\ninclude %s", redisConfigFileContent, redisNetworkConfigPath)
+	}
+
+	// The main ConfigMap goes last, so the parts it includes already exist when it's written. It holds
+	// the configuration of every redis pod with instance overrides.
+	data := renderRedisInstanceConfigs(rf)
+	data[redisConfigFileName] = redisConfigFileContent
+	configMaps = append(configMaps, &corev1.ConfigMap{
+		ObjectMeta: generateObjectMeta(name, rf.Namespace, labels, nil, ownerRefs),
+		Data:       data,
+	})
+	setConfigRevision(rf, configMaps)
+	return configMaps, nil
 }
 
 func generateRedisShutdownConfigMap(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *corev1.ConfigMap {
@@ -185,20 +300,15 @@ if [ "$master" = "$(hostname -i)" ]; then
   redis-cli -h ${RFS_%[1]v_SERVICE_HOST} -p ${RFS_%[1]v_SERVICE_PORT_SENTINEL} SENTINEL failover mymaster
   sleep 31
 fi
-cmd="redis-cli -p %[2]v"
+%[3]vcmd="redis-cli -p %[2]v"
 if [ ! -z "${REDIS_PASSWORD}" ]; then
     cmd="${cmd} --no-auth-warning -a \"${REDIS_PASSWORD}\""
 fi
 save_command="${cmd} save"
-eval $save_command`, rfName, port)
+eval $save_command`, rfName, port, getPasswordFileScript(rf))
 
 	return &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            name,
-			Namespace:       namespace,
-			Labels:          labels,
-			OwnerReferences: ownerRefs,
-		},
+		ObjectMeta: generateObjectMeta(name, namespace, labels, nil, ownerRefs),
 		Data: map[string]string{
 			"shutdown.sh": shutdownContent,
 		},
@@ -217,7 +327,7 @@ ROLE_SLAVE="role:slave"
 IN_SYNC="master_sync_in_progress:1"
 NO_MASTER="master_host:127.0.0.1"
 
-cmd="redis-cli -p %[1]v"
+%[2]vcmd="redis-cli -p %[1]v"
 if [ ! -z "${REDIS_PASSWORD}" ]; then
 	cmd="${cmd} --no-auth-warning -a \"${REDIS_PASSWORD}\""
 fi
@@ -250,23 +360,18 @@ case $role in
 		*)
 				echo "unespected"
 				exit 1
-esac`, port)
+esac`, port, getPasswordFileScript(rf))
 
 	return &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            name,
-			Namespace:       namespace,
-			Labels:          labels,
-			OwnerReferences: ownerRefs,
-		},
+		ObjectMeta: generateObjectMeta(name, namespace, labels, nil, ownerRefs),
 		Data: map[string]string{
 			"ready.sh": readinessContent,
 		},
 	}
 }
 
-func generateRedisStatefulSet(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *appsv1.StatefulSet {
-	name := GetRedisName(rf)
+func generateRedisStatefulSet(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference, configParts int, configRevision string) *appsv1.StatefulSet {
+	name := GetRedisStatefulSetName(rf)
 	namespace := rf.Namespace
 
 	redisCommand := getRedisCommand(rf)
@@ -275,16 +380,11 @@ func generateRedisStatefulSet(rf *redisfailoverv1.RedisFailover, labels map[stri
 	labels = util.MergeLabels(labels, generateRedisDefaultRoleLabel())
 
 	volumeMounts := getRedisVolumeMounts(rf)
-	volumes := getRedisVolumes(rf)
+	volumes := getRedisVolumes(rf, configParts, configRevision)
 	terminationGracePeriodSeconds := getTerminationGracePeriodSeconds(rf)
 
 	ss := &appsv1.StatefulSet{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            name,
-			Namespace:       namespace,
-			Labels:          labels,
-			OwnerReferences: ownerRefs,
-		},
+		ObjectMeta: generateObjectMeta(name, namespace, labels, nil, ownerRefs),
 		Spec: appsv1.StatefulSetSpec{
 			ServiceName: name,
 			Replicas:    &rf.Spec.Redis.Replicas,
@@ -298,26 +398,26 @@ func generateRedisStatefulSet(rf *redisfailoverv1.RedisFailover, labels map[stri
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels:      labels,
-					Annotations: rf.Spec.Redis.PodAnnotations,
+					Annotations: generatePodAnnotations(rf, redisRoleName, rf.Spec.Redis.PodAnnotations),
 				},
 				Spec: corev1.PodSpec{
 					Affinity:                      getAffinity(rf.Spec.Redis.Affinity, labels),
 					Tolerations:                   rf.Spec.Redis.Tolerations,
 					TopologySpreadConstraints:     rf.Spec.Redis.TopologySpreadConstraints,
 					NodeSelector:                  rf.Spec.Redis.NodeSelector,
-					SecurityContext:               getSecurityContext(rf.Spec.Redis.SecurityContext),
+					SecurityContext:               getSecurityContext(rf, rf.Spec.Redis.SecurityContext),
 					HostNetwork:                   rf.Spec.Redis.HostNetwork,
 					DNSPolicy:                     getDnsPolicy(rf.Spec.Redis.DNSPolicy),
 					ImagePullSecrets:              rf.Spec.Redis.ImagePullSecrets,
 					PriorityClassName:             rf.Spec.Redis.PriorityClassName,
-					ServiceAccountName:            rf.Spec.Redis.ServiceAccountName,
+					ServiceAccountName:            getRedisServiceAccountName(rf),
 					TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
 					Containers: []corev1.Container{
 						{
 							Name:            "redis",
 							Image:           rf.Spec.Redis.Image,
 							ImagePullPolicy: pullPolicy(rf.Spec.Redis.ImagePullPolicy),
-							SecurityContext: getContainerSecurityContext(rf.Spec.Redis.ContainerSecurityContext),
+							SecurityContext: getContainerSecurityContext(rf, rf.Spec.Redis.ContainerSecurityContext),
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "redis",
@@ -396,6 +496,26 @@ func generateRedisStatefulSet(rf *redisfailoverv1.RedisFailover, labels map[stri
 		ss.Spec.Template.Spec.Containers = append(ss.Spec.Template.Spec.Containers, exporter)
 	}
 
+	if rf.ZeroDowntimeReloadEnabled() {
+		addZeroDowntimeReload(ss, rf)
+	}
+
+	if rf.RedisNetworkBootstrapEnabled() {
+		addRedisNetworkBootstrap(ss, rf)
+	}
+
+	if rf.TLSEnabled() {
+		addRedisTLS(ss, rf)
+	}
+
+	if redisInstanceConfigEnabled(rf) {
+		addRedisInstanceConfig(ss)
+	}
+
+	if rf.Spec.Redis.NodeTuningInitContainer {
+		ss.Spec.Template.Spec.InitContainers = append(ss.Spec.Template.Spec.InitContainers, createNodeTuningContainer(rf))
+	}
+
 	if rf.Spec.Redis.InitContainers != nil {
 		initContainers := getInitContainersWithRedisEnv(rf)
 		ss.Spec.Template.Spec.InitContainers = append(ss.Spec.Template.Spec.InitContainers, initContainers...)
@@ -406,7 +526,12 @@ func generateRedisStatefulSet(rf *redisfailoverv1.RedisFailover, labels map[stri
 		ss.Spec.Template.Spec.Containers = append(ss.Spec.Template.Spec.Containers, extraContainers...)
 	}
 
-	if rf.Spec.Auth.SecretPath != "" {
+	if rf.AuthFromFile() {
+		ss.Spec.Template.Spec.Containers[0].Env = append(ss.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{
+			Name:  "REDIS_PASSWORD_FILE",
+			Value: rf.Spec.Auth.PasswordFile,
+		})
+	} else if rf.Spec.Auth.SecretPath != "" {
 		ss.Spec.Template.Spec.Containers[0].Env = append(ss.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{
 			Name: "REDIS_PASSWORD",
 			ValueFrom: &corev1.EnvVarSource{
@@ -425,7 +550,7 @@ func generateRedisStatefulSet(rf *redisfailoverv1.RedisFailover, labels map[stri
 
 func generateSentinelDeployment(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *appsv1.Deployment {
 	name := GetSentinelName(rf)
-	configMapName := GetSentinelName(rf)
+	configMapName := sentinelConfigMapName(rf)
 	namespace := rf.Namespace
 
 	sentinelCommand := getSentinelCommand(rf)
@@ -434,30 +559,35 @@ func generateSentinelDeployment(rf *redisfailoverv1.RedisFailover, labels map[st
 
 	volumeMounts := getSentinelVolumeMounts(rf)
 	volumes := getSentinelVolumes(rf, configMapName)
+	// The sentinels are rolled one by one, restarting them together would lose the quorum.
+	maxUnavailable := intstr.FromInt(1)
+	maxSurge := intstr.FromInt(1)
 
 	sd := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            name,
-			Namespace:       namespace,
-			Labels:          labels,
-			OwnerReferences: ownerRefs,
-		},
+		ObjectMeta: generateObjectMeta(name, namespace, labels, nil, ownerRefs),
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &rf.Spec.Sentinel.Replicas,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: selectorLabels,
 			},
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{
+					MaxUnavailable: &maxUnavailable,
+					MaxSurge:       &maxSurge,
+				},
+			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels:      labels,
-					Annotations: rf.Spec.Sentinel.PodAnnotations,
+					Annotations: generatePodAnnotations(rf, sentinelRoleName, rf.Spec.Sentinel.PodAnnotations),
 				},
 				Spec: corev1.PodSpec{
-					Affinity:                  getAffinity(rf.Spec.Sentinel.Affinity, labels),
+					Affinity:                  getAffinity(rf.Spec.Sentinel.Affinity, selectorLabels),
 					Tolerations:               rf.Spec.Sentinel.Tolerations,
 					TopologySpreadConstraints: rf.Spec.Sentinel.TopologySpreadConstraints,
 					NodeSelector:              rf.Spec.Sentinel.NodeSelector,
-					SecurityContext:           getSecurityContext(rf.Spec.Sentinel.SecurityContext),
+					SecurityContext:           getSecurityContext(rf, rf.Spec.Sentinel.SecurityContext),
 					HostNetwork:               rf.Spec.Sentinel.HostNetwork,
 					DNSPolicy:                 getDnsPolicy(rf.Spec.Sentinel.DNSPolicy),
 					ImagePullSecrets:          rf.Spec.Sentinel.ImagePullSecrets,
@@ -468,7 +598,7 @@ func generateSentinelDeployment(rf *redisfailoverv1.RedisFailover, labels map[st
 							Name:            "sentinel-config-copy",
 							Image:           rf.Spec.Sentinel.Image,
 							ImagePullPolicy: pullPolicy(rf.Spec.Sentinel.ImagePullPolicy),
-							SecurityContext: getContainerSecurityContext(rf.Spec.Sentinel.ConfigCopy.ContainerSecurityContext),
+							SecurityContext: getContainerSecurityContext(rf, rf.Spec.Sentinel.ConfigCopy.ContainerSecurityContext),
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "sentinel-config",
@@ -498,10 +628,10 @@ func generateSentinelDeployment(rf *redisfailoverv1.RedisFailover, labels map[st
 					},
 					Containers: []corev1.Container{
 						{
-							Name:            "sentinel",
+							Name:            SentinelContainerName,
 							Image:           rf.Spec.Sentinel.Image,
 							ImagePullPolicy: pullPolicy(rf.Spec.Sentinel.ImagePullPolicy),
-							SecurityContext: getContainerSecurityContext(rf.Spec.Sentinel.ContainerSecurityContext),
+							SecurityContext: getContainerSecurityContext(rf, rf.Spec.Sentinel.ContainerSecurityContext),
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "sentinel",
@@ -560,14 +690,60 @@ func generateSentinelDeployment(rf *redisfailoverv1.RedisFailover, labels map[st
 	return sd
 }
 
+// DeploymentTemplateHash returns the hash of the pod template of a generated Deployment. The labels
+// other than the ones of its selector are copied from the RF, they are left out so changing them
+// doesn't roll the pods, and so are the owner annotations.
+func DeploymentTemplateHash(d *appsv1.Deployment) (string, error) {
+	template := d.Spec.Template.DeepCopy()
+	template.Labels = d.Spec.Selector.MatchLabels
+	return podTemplateHash(*template)
+}
+
+// StatefulSetTemplateHash returns the hash of the pod template of a generated StatefulSet, without the
+// owner annotations.
+func StatefulSetTemplateHash(ss *appsv1.StatefulSet) (string, error) {
+	return podTemplateHash(*ss.Spec.Template.DeepCopy())
+}
+
+// generateRedisFailoverPodDisruptionBudget returns the pdb of the redis or the sentinel pods of the RF.
+// The redis pods left out of the healthy replicas are left out of it, see RedisPodCounted.
+func generateRedisFailoverPodDisruptionBudget(rf *redisfailoverv1.RedisFailover, typeName string, component string, labels map[string]string, ownerRefs []metav1.OwnerReference) *policyv1.PodDisruptionBudget {
+	replicas := rf.Spec.Redis.Replicas
+	excluded := []string{}
+	if typeName == redisName {
+		for ordinal := 0; ordinal < int(rf.Spec.Redis.Replicas); ordinal++ {
+			if !rf.RedisInstanceCounted(ordinal) {
+				excluded = append(excluded, getRedisPodName(rf, ordinal))
+			}
+		}
+		replicas -= int32(len(excluded))
+	}
+	minAvailable := intstr.FromInt(2)
+	if replicas <= 2 {
+		minAvailable = intstr.FromInt(1)
+	}
+	labels = util.MergeLabels(labels, generateSelectorLabels(component, rf.Name))
+	pdb := generatePodDisruptionBudget(generateName(typeName, rf), rf.Namespace, labels, ownerRefs, minAvailable)
+	if len(excluded) > 0 {
+		pdb.Spec.Selector.MatchExpressions = []metav1.LabelSelectorRequirement{{
+			Key:      appsv1.StatefulSetPodNameLabel,
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   excluded,
+		}}
+	}
+	// The policy is carried by an annotation, the PodDisruptionBudget service sets the field on the
+	// clusters supporting it.
+	if policy := rf.RedisUnhealthyPodEvictionPolicy(); typeName == redisName && policy != "" {
+		pdb.Annotations = util.MergeLabels(pdb.Annotations, map[string]string{
+			k8s.UnhealthyPodEvictionPolicyAnnotation: policy,
+		})
+	}
+	return pdb
+}
+
 func generatePodDisruptionBudget(name string, namespace string, labels map[string]string, ownerRefs []metav1.OwnerReference, minAvailable intstr.IntOrString) *policyv1.PodDisruptionBudget {
 	return &policyv1.PodDisruptionBudget{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            name,
-			Namespace:       namespace,
-			Labels:          labels,
-			OwnerReferences: ownerRefs,
-		},
+		ObjectMeta: generateObjectMeta(name, namespace, labels, nil, ownerRefs),
 		Spec: policyv1.PodDisruptionBudgetSpec{
 			MinAvailable: &minAvailable,
 			Selector: &metav1.LabelSelector{
@@ -597,7 +773,7 @@ func createRedisExporterContainer(rf *redisfailoverv1.RedisFailover) corev1.Cont
 		Name:            exporterContainerName,
 		Image:           rf.Spec.Redis.Exporter.Image,
 		ImagePullPolicy: pullPolicy(rf.Spec.Redis.Exporter.ImagePullPolicy),
-		SecurityContext: getContainerSecurityContext(rf.Spec.Redis.Exporter.ContainerSecurityContext),
+		SecurityContext: getContainerSecurityContext(rf, rf.Spec.Redis.Exporter.ContainerSecurityContext),
 		Args:            rf.Spec.Redis.Exporter.Args,
 		Env: append(rf.Spec.Redis.Exporter.Env, corev1.EnvVar{
 			Name: "REDIS_ALIAS",
@@ -633,7 +809,7 @@ func createSentinelExporterContainer(rf *redisfailoverv1.RedisFailover) corev1.C
 		Name:            sentinelExporterContainerName,
 		Image:           rf.Spec.Sentinel.Exporter.Image,
 		ImagePullPolicy: pullPolicy(rf.Spec.Sentinel.Exporter.ImagePullPolicy),
-		SecurityContext: getContainerSecurityContext(rf.Spec.Sentinel.Exporter.ContainerSecurityContext),
+		SecurityContext: getContainerSecurityContext(rf, rf.Spec.Sentinel.Exporter.ContainerSecurityContext),
 		Args:            rf.Spec.Sentinel.Exporter.Args,
 		Env: append(rf.Spec.Sentinel.Exporter.Env, corev1.EnvVar{
 			Name: "REDIS_ALIAS",
@@ -686,14 +862,38 @@ func getAffinity(affinity *corev1.Affinity, labels map[string]string) *corev1.Af
 	}
 }
 
-func getSecurityContext(secctx *corev1.PodSecurityContext) *corev1.PodSecurityContext {
+func getSecurityContext(rf *redisfailoverv1.RedisFailover, secctx *corev1.PodSecurityContext) *corev1.PodSecurityContext {
 	if secctx != nil {
+		if rf.ReadOnlyRootFilesystem() && secctx.FSGroup == nil {
+			// The volumes redis and sentinel write to have to be owned by the group they run as.
+			secctx = secctx.DeepCopy()
+			fsGroup := redisImageUser
+			if secctx.RunAsGroup != nil {
+				fsGroup = *secctx.RunAsGroup
+			}
+			secctx.FSGroup = &fsGroup
+		}
 		return secctx
 	}
 
 	defaultUserAndGroup := int64(1000)
 	runAsNonRoot := true
 
+	if rf.ReadOnlyRootFilesystem() {
+		defaultUserAndGroup = redisImageUser
+		fsGroupChangePolicy := corev1.FSGroupChangeOnRootMismatch
+		return &corev1.PodSecurityContext{
+			RunAsUser:           &defaultUserAndGroup,
+			RunAsGroup:          &defaultUserAndGroup,
+			RunAsNonRoot:        &runAsNonRoot,
+			FSGroup:             &defaultUserAndGroup,
+			FSGroupChangePolicy: &fsGroupChangePolicy,
+			SeccompProfile: &corev1.SeccompProfile{
+				Type: corev1.SeccompProfileTypeRuntimeDefault,
+			},
+		}
+	}
+
 	return &corev1.PodSecurityContext{
 		RunAsUser:    &defaultUserAndGroup,
 		RunAsGroup:   &defaultUserAndGroup,
@@ -702,8 +902,13 @@ func getSecurityContext(secctx *corev1.PodSecurityContext) *corev1.PodSecurityCo
 	}
 }
 
-func getContainerSecurityContext(secctx *corev1.SecurityContext) *corev1.SecurityContext {
+func getContainerSecurityContext(rf *redisfailoverv1.RedisFailover, secctx *corev1.SecurityContext) *corev1.SecurityContext {
 	if secctx != nil {
+		if rf.ReadOnlyRootFilesystem() && secctx.ReadOnlyRootFilesystem == nil {
+			secctx = secctx.DeepCopy()
+			readOnlyRootFilesystem := true
+			secctx.ReadOnlyRootFilesystem = &readOnlyRootFilesystem
+		}
 		return secctx
 	}
 
@@ -719,7 +924,7 @@ func getContainerSecurityContext(secctx *corev1.SecurityContext) *corev1.Securit
 	allowPrivilegeEscalation := false
 	readOnlyRootFilesystem := true
 
-	return &corev1.SecurityContext{
+	secctx = &corev1.SecurityContext{
 		Capabilities:             capabilities,
 		Privileged:               &privileged,
 		RunAsUser:                &defaultUserAndGroup,
@@ -728,6 +933,15 @@ func getContainerSecurityContext(secctx *corev1.SecurityContext) *corev1.Securit
 		ReadOnlyRootFilesystem:   &readOnlyRootFilesystem,
 		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
 	}
+	if rf.ReadOnlyRootFilesystem() {
+		redisUser := redisImageUser
+		secctx.RunAsUser = &redisUser
+		secctx.RunAsGroup = &redisUser
+		secctx.SeccompProfile = &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		}
+	}
+	return secctx
 }
 
 func getDnsPolicy(dnspolicy corev1.DNSPolicy) corev1.DNSPolicy {
@@ -737,8 +951,9 @@ func getDnsPolicy(dnspolicy corev1.DNSPolicy) corev1.DNSPolicy {
 	return dnspolicy
 }
 
+// getQuorum returns the quorum of the sentinels, the one set in the spec is kept as is.
 func getQuorum(rf *redisfailoverv1.RedisFailover) int32 {
-	return rf.Spec.Sentinel.Replicas/2 + 1
+	return rf.SentinelQuorum()
 }
 
 func getRedisVolumeMounts(rf *redisfailoverv1.RedisFailover) []corev1.VolumeMount {
@@ -757,10 +972,22 @@ func getRedisVolumeMounts(rf *redisfailoverv1.RedisFailover) []corev1.VolumeMoun
 		},
 		{
 			Name:      getRedisDataVolumeName(rf),
-			MountPath: "/data",
+			MountPath: rf.RedisDataDir(),
 		},
 	}
 
+	if rf.ReadOnlyRootFilesystem() {
+		volumeMounts = append(volumeMounts, getTmpVolumeMount())
+	}
+
+	if rf.ACLEnabled() {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      redisACLVolumeName,
+			MountPath: redisACLMountPath,
+			ReadOnly:  true,
+		})
+	}
+
 	if rf.Spec.Redis.ExtraVolumeMounts != nil {
 		volumeMounts = append(volumeMounts, rf.Spec.Redis.ExtraVolumeMounts...)
 	}
@@ -776,6 +1003,10 @@ func getSentinelVolumeMounts(rf *redisfailoverv1.RedisFailover) []corev1.VolumeM
 		},
 	}
 
+	if rf.ReadOnlyRootFilesystem() {
+		volumeMounts = append(volumeMounts, getTmpVolumeMount())
+	}
+
 	if rf.Spec.Sentinel.ExtraVolumeMounts != nil {
 		volumeMounts = append(volumeMounts, rf.Spec.Sentinel.ExtraVolumeMounts...)
 	}
@@ -783,22 +1014,15 @@ func getSentinelVolumeMounts(rf *redisfailoverv1.RedisFailover) []corev1.VolumeM
 	return volumeMounts
 }
 
-func getRedisVolumes(rf *redisfailoverv1.RedisFailover) []corev1.Volume {
-	configMapName := GetRedisName(rf)
+func getRedisVolumes(rf *redisfailoverv1.RedisFailover, configParts int, configRevision string) []corev1.Volume {
 	shutdownConfigMapName := GetRedisShutdownConfigMapName(rf)
 	readinessConfigMapName := GetRedisReadinessName(rf)
 
 	executeMode := int32(0744)
 	volumes := []corev1.Volume{
 		{
-			Name: redisConfigurationVolumeName,
-			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: configMapName,
-					},
-				},
-			},
+			Name:         redisConfigurationVolumeName,
+			VolumeSource: getRedisConfigVolumeSource(rf, configParts, configRevision),
 		},
 		{
 			Name: redisShutdownConfigurationVolumeName,
@@ -824,6 +1048,21 @@ func getRedisVolumes(rf *redisfailoverv1.RedisFailover) []corev1.Volume {
 		},
 	}
 
+	if rf.ReadOnlyRootFilesystem() {
+		volumes = append(volumes, getTmpVolume())
+	}
+
+	if rf.ACLEnabled() {
+		volumes = append(volumes, corev1.Volume{
+			Name: redisACLVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: *rf.Spec.Auth.ACLConfigMapRef,
+				},
+			},
+		})
+	}
+
 	if rf.Spec.Redis.ExtraVolumes != nil {
 		volumes = append(volumes, rf.Spec.Redis.ExtraVolumes...)
 	}
@@ -856,6 +1095,10 @@ func getSentinelVolumes(rf *redisfailoverv1.RedisFailover, configMapName string)
 		},
 	}
 
+	if rf.ReadOnlyRootFilesystem() {
+		volumes = append(volumes, getTmpVolume())
+	}
+
 	if rf.Spec.Sentinel.ExtraVolumes != nil {
 		volumes = append(volumes, rf.Spec.Sentinel.ExtraVolumes...)
 	}
@@ -863,6 +1106,62 @@ func getSentinelVolumes(rf *redisfailoverv1.RedisFailover, configMapName string)
 	return volumes
 }
 
+// getTmpVolume returns the volume of /tmp, the root filesystem of the containers being read-only.
+func getTmpVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: tmpVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+}
+
+func getTmpVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      tmpVolumeName,
+		MountPath: "/tmp",
+	}
+}
+
+// getRedisConfigVolumeSource returns the redis configuration ConfigMap, projected together with its parts
+// when the configuration is split.
+func getRedisConfigVolumeSource(rf *redisfailoverv1.RedisFailover, configParts int, configRevision string) corev1.VolumeSource {
+	configMapName := revisionName(GetRedisName(rf), configRevision)
+	if configParts <= 1 {
+		return corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: configMapName,
+				},
+			},
+		}
+	}
+
+	sources := []corev1.VolumeProjection{
+		{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: configMapName,
+				},
+			},
+		},
+	}
+	for i := 0; i < configParts; i++ {
+		sources = append(sources, corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: revisionName(GetRedisConfigPartName(rf, i), configRevision),
+				},
+			},
+		})
+	}
+	return corev1.VolumeSource{
+		Projected: &corev1.ProjectedVolumeSource{
+			Sources: sources,
+		},
+	}
+}
+
 func getRedisDataVolume(rf *redisfailoverv1.RedisFailover) *corev1.Volume {
 	// This will find the volumed desired by the user. If no volume defined
 	// an EmptyDir will be used by default
@@ -913,7 +1212,7 @@ func getSentinelCommand(rf *redisfailoverv1.RedisFailover) []string {
 	}
 	return []string{
 		"redis-server",
-		fmt.Sprintf("/redis/%s", sentinelConfigFileName),
+		SentinelConfigPath,
 		"--sentinel",
 	}
 }
@@ -926,8 +1225,8 @@ func pullPolicy(specPolicy corev1.PullPolicy) corev1.PullPolicy {
 }
 
 func getTerminationGracePeriodSeconds(rf *redisfailoverv1.RedisFailover) int64 {
-	if rf.Spec.Redis.TerminationGracePeriodSeconds > 0 {
-		return rf.Spec.Redis.TerminationGracePeriodSeconds
+	if grace := rf.Spec.Redis.GetTerminationGracePeriod(); grace > 0 {
+		return int64(grace / time.Second)
 	}
 	return 30
 }
@@ -939,6 +1238,36 @@ func getExtraContainersWithRedisEnv(rf *redisfailoverv1.RedisFailover) []corev1.
 	return extraContainers
 }
 
+// createNodeTuningContainer returns a privileged init container setting the kernel settings redis warns
+// about on startup. They are node wide, so a failure doesn't prevent redis from starting.
+func createNodeTuningContainer(rf *redisfailoverv1.RedisFailover) corev1.Container {
+	privileged := true
+	return corev1.Container{
+		Name:            nodeTuningContainerName,
+		Image:           rf.Spec.Redis.Image,
+		ImagePullPolicy: pullPolicy(rf.Spec.Redis.ImagePullPolicy),
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: &privileged,
+		},
+		Command: []string{
+			"sh",
+			"-c",
+			"sysctl -w vm.overcommit_memory=1 || echo 'could not set vm.overcommit_memory'; " +
+				"echo madvise > /sys/kernel/mm/transparent_hugepage/enabled || echo 'could not set transparent_hugepage'",
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+				corev1.ResourceMemory: resource.MustParse("32Mi"),
+			},
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+				corev1.ResourceMemory: resource.MustParse("32Mi"),
+			},
+		},
+	}
+}
+
 func getInitContainersWithRedisEnv(rf *redisfailoverv1.RedisFailover) []corev1.Container {
 	env := getRedisEnv(rf)
 	initContainers := getContainersWithRedisEnv(rf.Spec.Redis.InitContainers, env)
@@ -974,7 +1303,7 @@ func getRedisEnv(rf *redisfailoverv1.RedisFailover) []corev1.EnvVar {
 		Value: "default",
 	})
 
-	if rf.Spec.Auth.SecretPath != "" {
+	if rf.Spec.Auth.SecretPath != "" && !rf.AuthFromFile() {
 		env = append(env, corev1.EnvVar{
 			Name: "REDIS_PASSWORD",
 			ValueFrom: &corev1.EnvVarSource{
@@ -990,3 +1319,15 @@ func getRedisEnv(rf *redisfailoverv1.RedisFailover) []corev1.EnvVar {
 
 	return env
 }
+
+// getPasswordFileScript returns the lines of the redis scripts reading the password from the password
+// file in the File auth mode, none otherwise.
+func getPasswordFileScript(rf *redisfailoverv1.RedisFailover) string {
+	if !rf.AuthFromFile() {
+		return ""
+	}
+	return `if [ -z "${REDIS_PASSWORD}" ] && [ -r "${REDIS_PASSWORD_FILE}" ]; then
+    REDIS_PASSWORD=$(sed -n 's/^requirepass[[:space:]]*//p' "${REDIS_PASSWORD_FILE}" | tr -d '"')
+fi
+`
+}
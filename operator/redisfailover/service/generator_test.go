@@ -1,14 +1,22 @@
 package service_test
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	redisfailoverv1 "redis-operator/api/redisfailover/v1"
@@ -16,6 +24,7 @@ import (
 	"redis-operator/metrics"
 	mK8SService "redis-operator/mocks/service/k8s"
 	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
 )
 
 func TestRedisStatefulSetStorageGeneration(t *testing.T) {
@@ -526,14 +535,15 @@ func TestRedisStatefulSetStorageGeneration(t *testing.T) {
 		generatedStatefulSet := appsv1.StatefulSet{}
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateStatefulSet", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			ss := args.Get(1).(*appsv1.StatefulSet)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			ss := args.Get(2).(*appsv1.StatefulSet)
 			generatedStatefulSet = *ss
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureRedisStatefulset(rf, nil, test.ownerRefs)
+		err := client.EnsureRedisStatefulset(context.TODO(), rf, nil, test.ownerRefs)
 
 		// Check that the storage-related fields are as spected
 		assert.Equal(test.expectedSS.Spec.Template.Spec.Volumes, generatedStatefulSet.Spec.Template.Spec.Volumes)
@@ -580,14 +590,15 @@ func TestRedisStatefulSetCommands(t *testing.T) {
 		gotCommands := []string{}
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateStatefulSet", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			ss := args.Get(1).(*appsv1.StatefulSet)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			ss := args.Get(2).(*appsv1.StatefulSet)
 			gotCommands = ss.Spec.Template.Spec.Containers[0].Command
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureRedisStatefulset(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{})
 
 		assert.Equal(test.expectedCommands, gotCommands)
 		assert.NoError(err)
@@ -632,14 +643,15 @@ func TestSentinelDeploymentCommands(t *testing.T) {
 		gotCommands := []string{}
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateDeployment", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			d := args.Get(1).(*appsv1.Deployment)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			d := args.Get(2).(*appsv1.Deployment)
 			gotCommands = d.Spec.Template.Spec.Containers[0].Command
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureSentinelDeployment(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureSentinelDeployment(context.TODO(), rf, nil, []metav1.OwnerReference{})
 
 		assert.Equal(test.expectedCommands, gotCommands)
 		assert.NoError(err)
@@ -653,9 +665,13 @@ func TestRedisStatefulSetPodAnnotations(t *testing.T) {
 		expectedPodAnnotations map[string]string
 	}{
 		{
-			name:                   "PodAnnotations was not defined",
-			givenPodAnnotations:    nil,
-			expectedPodAnnotations: nil,
+			name:                "PodAnnotations was not defined",
+			givenPodAnnotations: nil,
+			expectedPodAnnotations: map[string]string{
+				"redisfailover.redis.io/name":      name,
+				"redisfailover.redis.io/namespace": namespace,
+				"redisfailover.redis.io/component": "redis",
+			},
 		},
 		{
 			name: "PodAnnotations is defined",
@@ -664,8 +680,11 @@ func TestRedisStatefulSetPodAnnotations(t *testing.T) {
 				"path/to/annotation": "here",
 			},
 			expectedPodAnnotations: map[string]string{
-				"some":               "annotation",
-				"path/to/annotation": "here",
+				"some":                             "annotation",
+				"path/to/annotation":               "here",
+				"redisfailover.redis.io/name":      name,
+				"redisfailover.redis.io/namespace": namespace,
+				"redisfailover.redis.io/component": "redis",
 			},
 		},
 	}
@@ -680,14 +699,15 @@ func TestRedisStatefulSetPodAnnotations(t *testing.T) {
 		gotPodAnnotations := map[string]string{}
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateStatefulSet", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			ss := args.Get(1).(*appsv1.StatefulSet)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			ss := args.Get(2).(*appsv1.StatefulSet)
 			gotPodAnnotations = ss.Spec.Template.ObjectMeta.Annotations
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureRedisStatefulset(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{})
 
 		assert.Equal(test.expectedPodAnnotations, gotPodAnnotations)
 		assert.NoError(err)
@@ -701,9 +721,13 @@ func TestSentinelDeploymentPodAnnotations(t *testing.T) {
 		expectedPodAnnotations map[string]string
 	}{
 		{
-			name:                   "PodAnnotations was not defined",
-			givenPodAnnotations:    nil,
-			expectedPodAnnotations: nil,
+			name:                "PodAnnotations was not defined",
+			givenPodAnnotations: nil,
+			expectedPodAnnotations: map[string]string{
+				"redisfailover.redis.io/name":      name,
+				"redisfailover.redis.io/namespace": namespace,
+				"redisfailover.redis.io/component": "sentinel",
+			},
 		},
 		{
 			name: "PodAnnotations is defined",
@@ -712,8 +736,11 @@ func TestSentinelDeploymentPodAnnotations(t *testing.T) {
 				"path/to/annotation": "here",
 			},
 			expectedPodAnnotations: map[string]string{
-				"some":               "annotation",
-				"path/to/annotation": "here",
+				"some":                             "annotation",
+				"path/to/annotation":               "here",
+				"redisfailover.redis.io/name":      name,
+				"redisfailover.redis.io/namespace": namespace,
+				"redisfailover.redis.io/component": "sentinel",
 			},
 		},
 	}
@@ -728,14 +755,15 @@ func TestSentinelDeploymentPodAnnotations(t *testing.T) {
 		gotPodAnnotations := map[string]string{}
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateDeployment", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			d := args.Get(1).(*appsv1.Deployment)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			d := args.Get(2).(*appsv1.Deployment)
 			gotPodAnnotations = d.Spec.Template.ObjectMeta.Annotations
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureSentinelDeployment(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureSentinelDeployment(context.TODO(), rf, nil, []metav1.OwnerReference{})
 
 		assert.Equal(test.expectedPodAnnotations, gotPodAnnotations)
 		assert.NoError(err)
@@ -743,9 +771,13 @@ func TestSentinelDeploymentPodAnnotations(t *testing.T) {
 }
 
 func TestRedisStatefulSetServiceAccountName(t *testing.T) {
+	managed := true
+	unmanaged := false
+
 	tests := []struct {
 		name                       string
 		givenServiceAccountName    string
+		givenManaged               *bool
 		expectedServiceAccountName string
 	}{
 		{
@@ -758,6 +790,22 @@ func TestRedisStatefulSetServiceAccountName(t *testing.T) {
 			givenServiceAccountName:    "redis-sa",
 			expectedServiceAccountName: "redis-sa",
 		},
+		{
+			name:                       "ServiceAccountName is managed",
+			givenManaged:               &managed,
+			expectedServiceAccountName: "rfr-test",
+		},
+		{
+			name:                       "ServiceAccountName is not managed",
+			givenManaged:               &unmanaged,
+			expectedServiceAccountName: "",
+		},
+		{
+			name:                       "ServiceAccountName is defined and managed",
+			givenServiceAccountName:    "redis-sa",
+			givenManaged:               &managed,
+			expectedServiceAccountName: "redis-sa",
+		},
 	}
 
 	for _, test := range tests {
@@ -766,24 +814,382 @@ func TestRedisStatefulSetServiceAccountName(t *testing.T) {
 		// Generate a default RedisFailover and attaching the required Service Account
 		rf := generateRF()
 		rf.Spec.Redis.ServiceAccountName = test.givenServiceAccountName
+		rf.Spec.Redis.ManagedServiceAccount = test.givenManaged
 
 		gotServiceAccountName := ""
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateStatefulSet", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			ss := args.Get(1).(*appsv1.StatefulSet)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			ss := args.Get(2).(*appsv1.StatefulSet)
 			gotServiceAccountName = ss.Spec.Template.Spec.ServiceAccountName
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureRedisStatefulset(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{})
 
 		assert.Equal(test.expectedServiceAccountName, gotServiceAccountName)
 		assert.NoError(err)
 	}
 }
 
+func TestRedisStatefulSetPriorityClassName(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.PriorityClassName = "redis-critical"
+
+	gotPriorityClassName := ""
+
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+	ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+		ss := args.Get(2).(*appsv1.StatefulSet)
+		gotPriorityClassName = ss.Spec.Template.Spec.PriorityClassName
+	}).Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	err := client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{})
+
+	assert.NoError(err)
+	assert.Equal("redis-critical", gotPriorityClassName)
+}
+
+func TestRedisStatefulSetTerminationGracePeriod(t *testing.T) {
+	tests := []struct {
+		name                string
+		givenSeconds        int64
+		givenDuration       *metav1.Duration
+		expectedGracePeriod int64
+	}{
+		{
+			name:                "Termination grace period was not defined",
+			expectedGracePeriod: 30,
+		},
+		{
+			name:                "Deprecated termination grace period in seconds",
+			givenSeconds:        60,
+			expectedGracePeriod: 60,
+		},
+		{
+			name:                "Termination grace period duration",
+			givenDuration:       &metav1.Duration{Duration: 2 * time.Minute},
+			expectedGracePeriod: 120,
+		},
+	}
+
+	for _, test := range tests {
+		assert := assert.New(t)
+
+		rf := generateRF()
+		rf.Spec.Redis.TerminationGracePeriodSeconds = test.givenSeconds
+		rf.Spec.Redis.TerminationGracePeriodDuration = test.givenDuration
+
+		var gotGracePeriod int64
+
+		ms := &mK8SService.Services{}
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			ss := args.Get(2).(*appsv1.StatefulSet)
+			gotGracePeriod = *ss.Spec.Template.Spec.TerminationGracePeriodSeconds
+		}).Return(nil)
+
+		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+		err := client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{})
+
+		assert.Equal(test.expectedGracePeriod, gotGracePeriod, test.name)
+		assert.NoError(err)
+	}
+}
+
+func TestRedisStatefulSetNodeTuningInitContainer(t *testing.T) {
+	tests := []struct {
+		name                   string
+		givenNodeTuning        bool
+		givenInitContainers    []corev1.Container
+		expectedInitContainers []string
+	}{
+		{
+			name:                   "Node tuning disabled",
+			expectedInitContainers: []string{},
+		},
+		{
+			name:                   "Node tuning enabled",
+			givenNodeTuning:        true,
+			expectedInitContainers: []string{"node-tuning"},
+		},
+		{
+			name:                   "Node tuning runs before the user init containers",
+			givenNodeTuning:        true,
+			givenInitContainers:    []corev1.Container{{Name: "init"}},
+			expectedInitContainers: []string{"node-tuning", "init"},
+		},
+	}
+
+	for _, test := range tests {
+		assert := assert.New(t)
+
+		rf := generateRF()
+		rf.Spec.Redis.Image = "redis:7.0"
+		rf.Spec.Redis.NodeTuningInitContainer = test.givenNodeTuning
+		rf.Spec.Redis.InitContainers = test.givenInitContainers
+
+		var gotInitContainers []corev1.Container
+
+		ms := &mK8SService.Services{}
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			ss := args.Get(2).(*appsv1.StatefulSet)
+			gotInitContainers = ss.Spec.Template.Spec.InitContainers
+		}).Return(nil)
+
+		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+		err := client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{})
+		assert.NoError(err)
+
+		gotNames := []string{}
+		for _, c := range gotInitContainers {
+			gotNames = append(gotNames, c.Name)
+		}
+		assert.Equal(test.expectedInitContainers, gotNames, test.name)
+
+		if test.givenNodeTuning {
+			nodeTuning := gotInitContainers[0]
+			assert.Equal("redis:7.0", nodeTuning.Image)
+			if assert.NotNil(nodeTuning.SecurityContext) && assert.NotNil(nodeTuning.SecurityContext.Privileged) {
+				assert.True(*nodeTuning.SecurityContext.Privileged)
+			}
+			assert.Contains(nodeTuning.Command[2], "sysctl -w vm.overcommit_memory=1")
+			assert.Contains(nodeTuning.Command[2], "/sys/kernel/mm/transparent_hugepage/enabled")
+		}
+	}
+}
+
+func TestRedisStatefulSetReadOnlyRootFilesystem(t *testing.T) {
+	tests := []struct {
+		name               string
+		givenHardening     *redisfailoverv1.HardeningSettings
+		givenPVC           bool
+		givenSecurity      *corev1.PodSecurityContext
+		expectedMounts     map[string]string
+		expectedUser       int64
+		expectedFSGroup    int64
+		expectedSeccomp    bool
+		expectedTmpVolume  bool
+		expectedDataVolume bool
+	}{
+		{
+			name:               "Hardening disabled",
+			expectedMounts:     map[string]string{"/redis": "redis-config", "/redis-shutdown": "redis-shutdown-config", "/redis-readiness": "redis-readiness-config", "/data": "redis-data"},
+			expectedUser:       1000,
+			expectedFSGroup:    1000,
+			expectedDataVolume: true,
+		},
+		{
+			name:               "Read-only root filesystem mounts /tmp and runs as the redis image user",
+			givenHardening:     &redisfailoverv1.HardeningSettings{ReadOnlyRootFilesystem: true},
+			expectedMounts:     map[string]string{"/redis": "redis-config", "/redis-shutdown": "redis-shutdown-config", "/redis-readiness": "redis-readiness-config", "/data": "redis-data", "/tmp": "tmp"},
+			expectedUser:       999,
+			expectedFSGroup:    999,
+			expectedSeccomp:    true,
+			expectedTmpVolume:  true,
+			expectedDataVolume: true,
+		},
+		{
+			name:              "Read-only root filesystem with a persistent volume claim",
+			givenHardening:    &redisfailoverv1.HardeningSettings{ReadOnlyRootFilesystem: true},
+			givenPVC:          true,
+			expectedMounts:    map[string]string{"/redis": "redis-config", "/redis-shutdown": "redis-shutdown-config", "/redis-readiness": "redis-readiness-config", "/data": "pvc-data", "/tmp": "tmp"},
+			expectedUser:      999,
+			expectedFSGroup:   999,
+			expectedSeccomp:   true,
+			expectedTmpVolume: true,
+		},
+		{
+			name:           "Read-only root filesystem sets the fsGroup of the pod security context to its group",
+			givenHardening: &redisfailoverv1.HardeningSettings{ReadOnlyRootFilesystem: true},
+			givenPVC:       true,
+			givenSecurity: &corev1.PodSecurityContext{
+				RunAsUser:  func() *int64 { v := int64(2000); return &v }(),
+				RunAsGroup: func() *int64 { v := int64(3000); return &v }(),
+			},
+			expectedMounts:    map[string]string{"/redis": "redis-config", "/redis-shutdown": "redis-shutdown-config", "/redis-readiness": "redis-readiness-config", "/data": "pvc-data", "/tmp": "tmp"},
+			expectedUser:      2000,
+			expectedFSGroup:   3000,
+			expectedTmpVolume: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.Hardening = test.givenHardening
+			rf.Spec.Redis.SecurityContext = test.givenSecurity
+			if test.givenPVC {
+				rf.Spec.Redis.Storage.PersistentVolumeClaim = &redisfailoverv1.EmbeddedPersistentVolumeClaim{
+					EmbeddedObjectMetadata: redisfailoverv1.EmbeddedObjectMetadata{Name: "pvc-data"},
+				}
+			}
+
+			var gotSS *appsv1.StatefulSet
+			ms := &mK8SService.Services{}
+			ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+			ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+			ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+				gotSS = args.Get(2).(*appsv1.StatefulSet)
+			}).Return(nil)
+
+			client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+			assert.NoError(client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+
+			spec := gotSS.Spec.Template.Spec
+			redis := spec.Containers[0]
+			gotMounts := map[string]string{}
+			for _, m := range redis.VolumeMounts {
+				gotMounts[m.MountPath] = m.Name
+			}
+			assert.Equal(test.expectedMounts, gotMounts)
+
+			gotVolumes := map[string]corev1.VolumeSource{}
+			for _, v := range spec.Volumes {
+				gotVolumes[v.Name] = v.VolumeSource
+			}
+			tmp, ok := gotVolumes["tmp"]
+			assert.Equal(test.expectedTmpVolume, ok)
+			if ok {
+				assert.NotNil(tmp.EmptyDir)
+			}
+			_, ok = gotVolumes["redis-data"]
+			assert.Equal(test.expectedDataVolume, ok)
+
+			assert.Equal(test.expectedUser, *spec.SecurityContext.RunAsUser)
+			assert.Equal(test.expectedFSGroup, *spec.SecurityContext.FSGroup)
+			assert.Equal(test.expectedSeccomp, spec.SecurityContext.SeccompProfile != nil)
+			assert.True(*redis.SecurityContext.ReadOnlyRootFilesystem)
+			assert.True(*redis.SecurityContext.RunAsNonRoot)
+			assert.Equal(test.givenHardening != nil, redis.SecurityContext.SeccompProfile != nil)
+			if test.givenSecurity == nil {
+				assert.Equal(test.expectedUser, *redis.SecurityContext.RunAsUser)
+			}
+		})
+	}
+}
+
+func TestRedisStatefulSetDataDir(t *testing.T) {
+	tests := []struct {
+		name         string
+		givenDataDir string
+		givenPVC     bool
+		expectedPath string
+		expectedName string
+	}{
+		{
+			name:         "The data volume is mounted on /data by default",
+			expectedPath: "/data",
+			expectedName: "redis-data",
+		},
+		{
+			name:         "The data volume is mounted on the data dir",
+			givenDataDir: "/bitnami/redis/data",
+			expectedPath: "/bitnami/redis/data",
+			expectedName: "redis-data",
+		},
+		{
+			name:         "The persistent volume claim is mounted on the data dir",
+			givenDataDir: "/bitnami/redis/data",
+			givenPVC:     true,
+			expectedPath: "/bitnami/redis/data",
+			expectedName: "pvc-data",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.DataDir = test.givenDataDir
+			if test.givenPVC {
+				rf.Spec.Redis.Storage.PersistentVolumeClaim = &redisfailoverv1.EmbeddedPersistentVolumeClaim{
+					EmbeddedObjectMetadata: redisfailoverv1.EmbeddedObjectMetadata{Name: "pvc-data"},
+				}
+			}
+
+			var gotSS *appsv1.StatefulSet
+			ms := &mK8SService.Services{}
+			ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+			ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+			ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+				gotSS = args.Get(2).(*appsv1.StatefulSet)
+			}).Return(nil)
+
+			client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+			assert.NoError(client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+
+			gotMounts := map[string]string{}
+			for _, m := range gotSS.Spec.Template.Spec.Containers[0].VolumeMounts {
+				gotMounts[m.Name] = m.MountPath
+			}
+			assert.Equal(test.expectedPath, gotMounts[test.expectedName])
+		})
+	}
+}
+
+func TestSentinelDeploymentReadOnlyRootFilesystem(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Hardening = &redisfailoverv1.HardeningSettings{ReadOnlyRootFilesystem: true}
+
+	var gotD *appsv1.Deployment
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+	ms.On("GetDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+		gotD = args.Get(2).(*appsv1.Deployment)
+	}).Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	assert.NoError(client.EnsureSentinelDeployment(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+
+	spec := gotD.Spec.Template.Spec
+	gotVolumes := map[string]corev1.VolumeSource{}
+	for _, v := range spec.Volumes {
+		gotVolumes[v.Name] = v.VolumeSource
+	}
+
+	// The config is copied from the ConfigMap to a volume sentinel can rewrite.
+	configCopy := spec.InitContainers[0]
+	assert.Equal([]string{"cp", "/redis/sentinel.conf", "/redis-writable/sentinel.conf"}, configCopy.Command)
+	assert.Equal([]corev1.VolumeMount{
+		{Name: "sentinel-config", MountPath: "/redis"},
+		{Name: "sentinel-config-writable", MountPath: "/redis-writable"},
+	}, configCopy.VolumeMounts)
+	assert.True(*configCopy.SecurityContext.ReadOnlyRootFilesystem)
+	assert.Equal(int64(999), *configCopy.SecurityContext.RunAsUser)
+
+	sentinel := spec.Containers[0]
+	assert.Equal([]corev1.VolumeMount{
+		{Name: "sentinel-config-writable", MountPath: "/redis"},
+		{Name: "tmp", MountPath: "/tmp"},
+	}, sentinel.VolumeMounts)
+	assert.Equal("/redis/sentinel.conf", sentinel.Command[1])
+	assert.NotNil(gotVolumes["sentinel-config"].ConfigMap)
+	assert.NotNil(gotVolumes["sentinel-config-writable"].EmptyDir)
+	assert.NotNil(gotVolumes["tmp"].EmptyDir)
+
+	assert.True(*sentinel.SecurityContext.ReadOnlyRootFilesystem)
+	assert.Equal(int64(999), *sentinel.SecurityContext.RunAsUser)
+	assert.Equal(corev1.SeccompProfileTypeRuntimeDefault, spec.SecurityContext.SeccompProfile.Type)
+	assert.Equal(int64(999), *spec.SecurityContext.FSGroup)
+}
+
 func TestSentinelDeploymentServiceAccountName(t *testing.T) {
 	tests := []struct {
 		name                       string
@@ -812,20 +1218,44 @@ func TestSentinelDeploymentServiceAccountName(t *testing.T) {
 		gotServiceAccountName := ""
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateDeployment", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			d := args.Get(1).(*appsv1.Deployment)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			d := args.Get(2).(*appsv1.Deployment)
 			gotServiceAccountName = d.Spec.Template.Spec.ServiceAccountName
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureSentinelDeployment(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureSentinelDeployment(context.TODO(), rf, nil, []metav1.OwnerReference{})
 
 		assert.Equal(test.expectedServiceAccountName, gotServiceAccountName)
 		assert.NoError(err)
 	}
 }
 
+func TestSentinelDeploymentPriorityClassName(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Sentinel.PriorityClassName = "sentinel-critical"
+
+	gotPriorityClassName := ""
+
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+	ms.On("GetDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+		d := args.Get(2).(*appsv1.Deployment)
+		gotPriorityClassName = d.Spec.Template.Spec.PriorityClassName
+	}).Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	err := client.EnsureSentinelDeployment(context.TODO(), rf, nil, []metav1.OwnerReference{})
+
+	assert.NoError(err)
+	assert.Equal("sentinel-critical", gotPriorityClassName)
+}
+
 func TestSentinelService(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -1030,13 +1460,13 @@ func TestSentinelService(t *testing.T) {
 			generatedService := corev1.Service{}
 
 			ms := &mK8SService.Services{}
-			ms.On("CreateOrUpdateService", rf.Namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-				s := args.Get(1).(*corev1.Service)
+			ms.On("CreateOrUpdateService", mock.Anything, rf.Namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+				s := args.Get(2).(*corev1.Service)
 				generatedService = *s
 			}).Return(nil)
 
 			client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-			err := client.EnsureSentinelService(rf, test.rfLabels, []metav1.OwnerReference{{Name: "testing"}})
+			err := client.EnsureSentinelService(context.TODO(), rf, test.rfLabels, []metav1.OwnerReference{{Name: "testing"}})
 
 			assert.Equal(test.expectedService, generatedService)
 			assert.NoError(err)
@@ -1278,13 +1708,13 @@ func TestRedisService(t *testing.T) {
 			generatedService := corev1.Service{}
 
 			ms := &mK8SService.Services{}
-			ms.On("CreateOrUpdateService", rf.Namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-				s := args.Get(1).(*corev1.Service)
+			ms.On("CreateOrUpdateService", mock.Anything, rf.Namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+				s := args.Get(2).(*corev1.Service)
 				generatedService = *s
 			}).Return(nil)
 
 			client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-			err := client.EnsureRedisService(rf, test.rfLabels, []metav1.OwnerReference{{Name: "testing"}})
+			err := client.EnsureRedisService(context.TODO(), rf, test.rfLabels, []metav1.OwnerReference{{Name: "testing"}})
 
 			assert.Equal(test.expectedService, generatedService)
 			assert.NoError(err)
@@ -1292,6 +1722,168 @@ func TestRedisService(t *testing.T) {
 	}
 }
 
+func TestRedisMasterService(t *testing.T) {
+	tests := []struct {
+		name                string
+		masterDNS           *redisfailoverv1.RedisMasterDNS
+		rfAnnotations       map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{
+			name: "with hostname",
+			masterDNS: &redisfailoverv1.RedisMasterDNS{
+				Hostname: "redis.example.com",
+			},
+			expectedAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/hostname": "redis.example.com",
+			},
+		},
+		{
+			name: "with hostname and ttl",
+			masterDNS: &redisfailoverv1.RedisMasterDNS{
+				Hostname: "redis.example.com",
+				TTL:      30,
+			},
+			expectedAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/hostname": "redis.example.com",
+				"external-dns.alpha.kubernetes.io/ttl":      "30",
+			},
+		},
+		{
+			name: "with hostname and ttl duration",
+			masterDNS: &redisfailoverv1.RedisMasterDNS{
+				Hostname:    "redis.example.com",
+				TTLDuration: &metav1.Duration{Duration: 5 * time.Minute},
+			},
+			expectedAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/hostname": "redis.example.com",
+				"external-dns.alpha.kubernetes.io/ttl":      "300",
+			},
+		},
+		{
+			name: "with service annotations",
+			masterDNS: &redisfailoverv1.RedisMasterDNS{
+				Hostname: "redis.example.com",
+			},
+			rfAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/hostname": "other.example.com",
+				"foo": "bar",
+			},
+			expectedAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/hostname": "redis.example.com",
+				"foo": "bar",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.Port = 6379
+			rf.Spec.Redis.MasterDNS = test.masterDNS
+			rf.Spec.Redis.ServiceAnnotations = test.rfAnnotations
+
+			expectedService := corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rfrm-" + name,
+					Namespace: namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/component": "redis",
+						"app.kubernetes.io/name":      name,
+						"app.kubernetes.io/part-of":   "redis-failover",
+					},
+					Annotations: test.expectedAnnotations,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							Name: "testing",
+						},
+					},
+				},
+				Spec: corev1.ServiceSpec{
+					Type:      corev1.ServiceTypeClusterIP,
+					ClusterIP: corev1.ClusterIPNone,
+					// The role label makes the endpoints, and the DNS records, follow the master.
+					Selector: map[string]string{
+						"app.kubernetes.io/component": "redis",
+						"app.kubernetes.io/name":      name,
+						"app.kubernetes.io/part-of":   "redis-failover",
+						"redisfailovers-role":         "master",
+					},
+					Ports: []corev1.ServicePort{
+						{
+							Name:       "redis",
+							Port:       6379,
+							TargetPort: intstr.FromInt(6379),
+							Protocol:   corev1.ProtocolTCP,
+						},
+					},
+				},
+			}
+
+			generatedService := corev1.Service{}
+
+			ms := &mK8SService.Services{}
+			ms.On("CreateOrUpdateService", mock.Anything, rf.Namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+				s := args.Get(2).(*corev1.Service)
+				generatedService = *s
+			}).Return(nil)
+
+			client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+			err := client.EnsureRedisMasterService(context.TODO(), rf, nil, []metav1.OwnerReference{{Name: "testing"}})
+
+			assert.Equal(expectedService, generatedService)
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestRedisMasterServiceNotPresent(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+
+	ms := &mK8SService.Services{}
+	ms.On("GetService", mock.Anything, namespace, "rfrm-"+name).Once().Return(&corev1.Service{}, nil)
+	ms.On("DeleteService", mock.Anything, namespace, "rfrm-"+name).Once().Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	err := client.EnsureNotPresentRedisMasterService(context.TODO(), rf)
+
+	assert.NoError(err)
+	ms.AssertExpectations(t)
+}
+
+func TestServicesIPFamilies(t *testing.T) {
+	assert := assert.New(t)
+
+	requireDualStack := corev1.IPFamilyPolicyRequireDualStack
+	families := []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}
+	rf := generateRF()
+	rf.Spec.Redis.Service = &redisfailoverv1.RedisServiceSettings{IPFamilyPolicy: &requireDualStack, IPFamilies: families}
+
+	generated := map[string]*corev1.Service{}
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdateService", mock.Anything, rf.Namespace, mock.Anything).Times(3).Run(func(args mock.Arguments) {
+		s := args.Get(2).(*corev1.Service)
+		generated[s.Name] = s
+	}).Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	assert.NoError(client.EnsureSentinelService(context.TODO(), rf, nil, nil))
+	assert.NoError(client.EnsureRedisService(context.TODO(), rf, nil, nil))
+	assert.NoError(client.EnsureRedisMasterService(context.TODO(), rf, nil, nil))
+
+	// Every service has the IP families of the spec, they are dropped by the k8s service on the
+	// single-stack clusters.
+	assert.Len(generated, 3)
+	for name, s := range generated {
+		assert.Equal(&requireDualStack, s.Spec.IPFamilyPolicy, name)
+		assert.Equal(families, s.Spec.IPFamilies, name)
+	}
+}
+
 func TestRedisHostNetworkAndDnsPolicy(t *testing.T) {
 	tests := []struct {
 		name                string
@@ -1325,15 +1917,16 @@ func TestRedisHostNetworkAndDnsPolicy(t *testing.T) {
 		var actualDnsPolicy corev1.DNSPolicy
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateStatefulSet", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			ss := args.Get(1).(*appsv1.StatefulSet)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			ss := args.Get(2).(*appsv1.StatefulSet)
 			actualHostNetwork = ss.Spec.Template.Spec.HostNetwork
 			actualDnsPolicy = ss.Spec.Template.Spec.DNSPolicy
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureRedisStatefulset(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{})
 		assert.NoError(err)
 
 		assert.Equal(test.expectedHostNetwork, actualHostNetwork)
@@ -1374,15 +1967,16 @@ func TestSentinelHostNetworkAndDnsPolicy(t *testing.T) {
 		var actualDnsPolicy corev1.DNSPolicy
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateDeployment", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			d := args.Get(1).(*appsv1.Deployment)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			d := args.Get(2).(*appsv1.Deployment)
 			actualHostNetwork = d.Spec.Template.Spec.HostNetwork
 			actualDnsPolicy = d.Spec.Template.Spec.DNSPolicy
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureSentinelDeployment(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureSentinelDeployment(context.TODO(), rf, nil, []metav1.OwnerReference{})
 		assert.NoError(err)
 
 		assert.Equal(test.expectedHostNetwork, actualHostNetwork)
@@ -1424,15 +2018,16 @@ func TestRedisImagePullPolicy(t *testing.T) {
 		rf.Spec.Redis.Exporter.ImagePullPolicy = test.expectedExporterPolicy
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateStatefulSet", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			ss := args.Get(1).(*appsv1.StatefulSet)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			ss := args.Get(2).(*appsv1.StatefulSet)
 			policy = ss.Spec.Template.Spec.Containers[0].ImagePullPolicy
 			exporterPolicy = ss.Spec.Template.Spec.Containers[1].ImagePullPolicy
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureRedisStatefulset(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{})
 
 		assert.NoError(err)
 		assert.Equal(string(test.expectedPolicy), string(policy))
@@ -1470,15 +2065,16 @@ func TestSentinelImagePullPolicy(t *testing.T) {
 		rf.Spec.Sentinel.ImagePullPolicy = test.policy
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateDeployment", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			d := args.Get(1).(*appsv1.Deployment)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			d := args.Get(2).(*appsv1.Deployment)
 			policy = d.Spec.Template.Spec.Containers[0].ImagePullPolicy
 			configPolicy = d.Spec.Template.Spec.InitContainers[0].ImagePullPolicy
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureSentinelDeployment(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureSentinelDeployment(context.TODO(), rf, nil, []metav1.OwnerReference{})
 
 		assert.NoError(err)
 		assert.Equal(string(test.expectedPolicy), string(policy))
@@ -1546,15 +2142,16 @@ func TestRedisExtraVolumeMounts(t *testing.T) {
 		rf.Spec.Redis.ExtraVolumeMounts = test.expectedVolumeMounts
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateStatefulSet", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			s := args.Get(1).(*appsv1.StatefulSet)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s := args.Get(2).(*appsv1.StatefulSet)
 			extraVolume = s.Spec.Template.Spec.Volumes[3]
 			extraVolumeMount = s.Spec.Template.Spec.Containers[0].VolumeMounts[4]
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureRedisStatefulset(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{})
 
 		assert.NoError(err)
 		assert.Equal(test.expectedVolumes[0], extraVolume)
@@ -1622,18 +2219,500 @@ func TestSentinelExtraVolumeMounts(t *testing.T) {
 		rf.Spec.Sentinel.ExtraVolumeMounts = test.expectedVolumeMounts
 
 		ms := &mK8SService.Services{}
-		ms.On("CreateOrUpdatePodDisruptionBudget", namespace, mock.Anything).Once().Return(nil, nil)
-		ms.On("CreateOrUpdateDeployment", namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
-			d := args.Get(1).(*appsv1.Deployment)
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+		ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			d := args.Get(2).(*appsv1.Deployment)
 			extraVolume = d.Spec.Template.Spec.Volumes[2]
 			extraVolumeMount = d.Spec.Template.Spec.Containers[0].VolumeMounts[1]
 		}).Return(nil)
 
 		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
-		err := client.EnsureSentinelDeployment(rf, nil, []metav1.OwnerReference{})
+		err := client.EnsureSentinelDeployment(context.TODO(), rf, nil, []metav1.OwnerReference{})
 
 		assert.NoError(err)
 		assert.Equal(test.expectedVolumes[0], extraVolume)
 		assert.Equal(test.expectedVolumeMounts[0], extraVolumeMount)
 	}
 }
+
+func generateCommandRenames(n int) []redisfailoverv1.RedisCommandRename {
+	renames := []redisfailoverv1.RedisCommandRename{}
+	for i := 0; i < n; i++ {
+		renames = append(renames, redisfailoverv1.RedisCommandRename{
+			From: fmt.Sprintf("%0200d", i),
+			To:   fmt.Sprintf("%0200d", n+i),
+		})
+	}
+	return renames
+}
+
+func TestRedisConfigMapSplit(t *testing.T) {
+	tests := []struct {
+		name           string
+		renames        []redisfailoverv1.RedisCommandRename
+		existingParts  int
+		expParts       int
+		expDeleteParts []string
+		expErr         bool
+	}{
+		{
+			name:     "A small configuration should not be split",
+			renames:  generateCommandRenames(10),
+			expParts: 0,
+		},
+		{
+			name:     "A configuration bigger than a ConfigMap should be split",
+			renames:  generateCommandRenames(6000),
+			expParts: 3,
+		},
+		{
+			name:           "Parts left by a bigger configuration should be deleted",
+			renames:        generateCommandRenames(10),
+			existingParts:  2,
+			expParts:       0,
+			expDeleteParts: []string{"rfr-test-part-0", "rfr-test-part-1"},
+		},
+		{
+			name: "A line bigger than a ConfigMap should fail",
+			renames: []redisfailoverv1.RedisCommandRename{
+				{From: strings.Repeat("a", 1024*1024), To: "b"},
+			},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.CustomCommandRenames = test.renames
+
+			cms := []*corev1.ConfigMap{}
+			ms := &mK8SService.Services{}
+			ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Run(func(args mock.Arguments) {
+				cms = append(cms, args.Get(2).(*corev1.ConfigMap))
+			}).Return(nil)
+			for i := test.expParts; i < test.existingParts; i++ {
+				ms.On("GetConfigMap", mock.Anything, namespace, fmt.Sprintf("rfr-test-part-%d", i)).Once().Return(&corev1.ConfigMap{}, nil)
+			}
+			for _, name := range test.expDeleteParts {
+				ms.On("DeleteConfigMap", mock.Anything, namespace, name).Once().Return(nil)
+			}
+			notFoundPart := test.expParts
+			if test.existingParts > notFoundPart {
+				notFoundPart = test.existingParts
+			}
+			ms.On("GetConfigMap", mock.Anything, namespace, fmt.Sprintf("rfr-test-part-%d", notFoundPart)).Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+
+			client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+			err := client.EnsureRedisConfigMap(context.TODO(), rf, nil, []metav1.OwnerReference{})
+
+			if test.expErr {
+				assert.Error(err)
+				assert.Empty(cms)
+				return
+			}
+			assert.NoError(err)
+			ms.AssertExpectations(t)
+
+			// The main ConfigMap is written the last, once the parts it includes exist.
+			if assert.Len(cms, test.expParts+1) {
+				mainCM := cms[len(cms)-1]
+				assert.Equal("rfr-test", mainCM.Name)
+				if test.expParts == 0 {
+					assert.Contains(mainCM.Data["redis.conf"], "slaveof 127.0.0.1")
+					return
+				}
+
+				includes := []string{}
+				config := ""
+				for i, cm := range cms[:len(cms)-1] {
+					fileName := fmt.Sprintf("redis-part-%d.conf", i)
+					assert.Equal(fmt.Sprintf("rfr-test-part-%d", i), cm.Name)
+					assert.LessOrEqual(len(cm.Data[fileName]), 1000*1024)
+					includes = append(includes, "include /redis/"+fileName)
+					config += cm.Data[fileName]
+				}
+				assert.Equal(strings.Join(includes, "\n"), mainCM.Data["redis.conf"])
+
+				// The parts put together are the whole configuration, in order.
+				assert.True(strings.HasPrefix(config, "slaveof 127.0.0.1"))
+				first := strings.Index(config, fmt.Sprintf(`rename-command "%s"`, test.renames[0].From))
+				last := strings.Index(config, fmt.Sprintf(`rename-command "%s"`, test.renames[len(test.renames)-1].From))
+				assert.True(first >= 0 && first < last)
+			}
+		})
+	}
+}
+
+func TestRedisStatefulSetSplitConfigVolume(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.CustomCommandRenames = generateCommandRenames(6000)
+
+	var configVolume corev1.Volume
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+	ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+		ss := args.Get(2).(*appsv1.StatefulSet)
+		configVolume = ss.Spec.Template.Spec.Volumes[0]
+	}).Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	err := client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{})
+
+	assert.NoError(err)
+	assert.Equal("redis-config", configVolume.Name)
+	assert.Nil(configVolume.ConfigMap)
+	if assert.NotNil(configVolume.Projected) {
+		names := []string{}
+		for _, source := range configVolume.Projected.Sources {
+			names = append(names, source.ConfigMap.Name)
+		}
+		assert.Equal([]string{"rfr-test", "rfr-test-part-0", "rfr-test-part-1", "rfr-test-part-2"}, names)
+	}
+}
+
+func TestRedisConfigQuotesCommandRenames(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.CustomCommandRenames = []redisfailoverv1.RedisCommandRename{
+		{From: "flushall", To: ""},
+		{From: "flushdb\" \"x\"\nslaveof 1.2.3.4 6379\nrename-command \"a", To: `b\`},
+	}
+
+	state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	assert.NoError(err)
+
+	config := ""
+	for _, o := range state.Objects {
+		if o.Kind == "ConfigMap" && o.Name == rfservice.GetRedisName(rf) {
+			config = o.Object.(*corev1.ConfigMap).Data["redis.conf"]
+		}
+	}
+	assert.Contains(config, `rename-command "flushall" ""`)
+	assert.Contains(config, `rename-command "flushdb\" \"x\"\x0aslaveof 1.2.3.4 6379\x0arename-command \"a" "b\\"`)
+	assert.NotContains(config, "\nslaveof 1.2.3.4")
+}
+
+func TestSentinelConfigDeniesScriptsReconfig(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	assert.NoError(err)
+
+	for _, o := range state.Objects {
+		if o.Kind == "ConfigMap" && o.Name == rfservice.GetSentinelName(rf) {
+			assert.Contains(o.Object.(*corev1.ConfigMap).Data["sentinel.conf"], "\nsentinel deny-scripts-reconfig yes")
+			return
+		}
+	}
+	assert.Fail("the sentinel ConfigMap is not generated")
+}
+
+func TestSentinelConfigGlobalConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Sentinel.GlobalConfig = []string{"resolve-hostnames yes", "Announce-Hostnames  YES"}
+	state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	assert.NoError(err)
+
+	for _, o := range state.Objects {
+		if o.Kind == "ConfigMap" && o.Name == rfservice.GetSentinelName(rf) {
+			assert.Contains(o.Object.(*corev1.ConfigMap).Data["sentinel.conf"], "\nsentinel deny-scripts-reconfig yes\nsentinel announce-hostnames yes\nsentinel resolve-hostnames yes")
+			return
+		}
+	}
+	assert.Fail("the sentinel ConfigMap is not generated")
+}
+
+func TestSentinelConfigQuorum(t *testing.T) {
+	tests := []struct {
+		name      string
+		sentinels int32
+		quorum    int32
+		expLine   string
+	}{
+		{
+			name:      "Majority of the sentinels by default",
+			sentinels: 3,
+			expLine:   "sentinel monitor mymaster 127.0.0.1 6379 2\n",
+		},
+		{
+			name:      "Majority of more sentinels",
+			sentinels: 5,
+			expLine:   "sentinel monitor mymaster 127.0.0.1 6379 3\n",
+		},
+		{
+			name:      "Quorum set in the spec",
+			sentinels: 5,
+			quorum:    2,
+			expLine:   "sentinel monitor mymaster 127.0.0.1 6379 2\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.Port = 6379
+			rf.Spec.Sentinel.Replicas = test.sentinels
+			rf.Spec.Sentinel.Quorum = test.quorum
+			state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+			assert.NoError(err)
+
+			for _, o := range state.Objects {
+				if o.Kind == "ConfigMap" && o.Name == rfservice.GetSentinelName(rf) {
+					assert.True(strings.HasPrefix(o.Object.(*corev1.ConfigMap).Data["sentinel.conf"], test.expLine))
+					return
+				}
+			}
+			assert.Fail("the sentinel ConfigMap is not generated")
+		})
+	}
+}
+
+func TestRedisAuthFromFile(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Auth = redisfailoverv1.AuthSettings{
+		SecretPath:   "redis-auth",
+		Mode:         redisfailoverv1.AuthModeFile,
+		PasswordFile: "/vault/secrets/redis.conf",
+	}
+	state, err := rfservice.BuildDesiredState(rf, nil, nil, "s3cr3t")
+	assert.NoError(err)
+
+	objects := map[string]runtime.Object{}
+	for _, o := range state.Objects {
+		objects[o.Kind+"/"+o.Name] = o.Object
+	}
+
+	config := objects[rfservice.KindConfigMap+"/"+rfservice.GetRedisName(rf)].(*corev1.ConfigMap).Data["redis.conf"]
+	assert.True(strings.HasSuffix(config, "\ninclude /vault/secrets/redis.conf"))
+	assert.NotContains(config, "s3cr3t")
+
+	for _, name := range []string{rfservice.GetRedisShutdownConfigMapName(rf), rfservice.GetRedisReadinessName(rf)} {
+		for _, script := range objects[rfservice.KindConfigMap+"/"+name].(*corev1.ConfigMap).Data {
+			assert.Contains(script, `REDIS_PASSWORD=$(sed -n 's/^requirepass[[:space:]]*//p' "${REDIS_PASSWORD_FILE}" | tr -d '"')`)
+		}
+	}
+
+	ss := objects[rfservice.KindStatefulSet+"/"+rfservice.GetRedisName(rf)].(*appsv1.StatefulSet)
+	for _, container := range ss.Spec.Template.Spec.Containers {
+		for _, env := range container.Env {
+			assert.NotEqual("REDIS_PASSWORD", env.Name)
+		}
+	}
+	assert.Contains(ss.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "REDIS_PASSWORD_FILE", Value: "/vault/secrets/redis.conf"})
+}
+
+func TestRedisStatefulSetRestoresFromVolumeSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	apiGroup := "snapshot.storage.k8s.io"
+	dataSource := &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "VolumeSnapshot", Name: "rfb-test-20240102-030405"}
+	rf := generateRF()
+	rf.Spec.Redis.Storage.PersistentVolumeClaim = &redisfailoverv1.EmbeddedPersistentVolumeClaim{
+		EmbeddedObjectMetadata: redisfailoverv1.EmbeddedObjectMetadata{Name: "redis-data"},
+		Spec:                   corev1.PersistentVolumeClaimSpec{DataSource: dataSource},
+	}
+
+	state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	assert.NoError(err)
+
+	for _, o := range state.Objects {
+		if o.Kind == "StatefulSet" {
+			templates := o.Object.(*appsv1.StatefulSet).Spec.VolumeClaimTemplates
+			if assert.Len(templates, 1) {
+				assert.Equal(dataSource, templates[0].Spec.DataSource)
+			}
+			return
+		}
+	}
+	assert.Fail("the redis StatefulSet is not generated")
+}
+
+func TestRedisStatefulSetAdopted(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Annotations = map[string]string{redisfailoverv1.AdoptStatefulSetAnnotation: "legacy"}
+	adopted := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: namespace},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName:         "legacy-headless",
+			PodManagementPolicy: appsv1.OrderedReadyPodManagement,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "legacy", "role": "replication"},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "legacy"}}},
+		},
+	}
+
+	var got *appsv1.StatefulSet
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+	ms.On("GetStatefulSet", mock.Anything, namespace, "legacy").Once().Return(adopted, nil)
+	ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+		got = args.Get(2).(*appsv1.StatefulSet)
+	}).Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	assert.NoError(client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+	ms.AssertExpectations(t)
+
+	assert.Equal("legacy", rfservice.GetRedisStatefulSetName(rf))
+	assert.Equal("rfr-test", rfservice.GetRedisName(rf))
+	if assert.NotNil(got) {
+		assert.Equal("legacy", got.Name)
+		assert.Equal(adopted.Spec.Selector, got.Spec.Selector)
+		assert.Equal("legacy-headless", got.Spec.ServiceName)
+		assert.Equal(appsv1.OrderedReadyPodManagement, got.Spec.PodManagementPolicy)
+		assert.Equal(adopted.Spec.VolumeClaimTemplates, got.Spec.VolumeClaimTemplates)
+		// The pods keep matching the adopted selector, and get the labels of the operator.
+		assert.Equal("legacy", got.Spec.Template.Labels["app"])
+		assert.Equal("replication", got.Spec.Template.Labels["role"])
+		assert.Equal("redis", got.Spec.Template.Labels["app.kubernetes.io/component"])
+	}
+}
+
+func TestSentinelDeploymentTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	notFound := kubeerrors.NewNotFound(schema.GroupResource{}, "")
+	ensure := func(rf *redisfailoverv1.RedisFailover, labels map[string]string, stored *appsv1.Deployment) *appsv1.Deployment {
+		var got *appsv1.Deployment
+		ms := &mK8SService.Services{}
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		if stored == nil {
+			ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Once().Return(nil, notFound)
+		} else {
+			ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Once().Return(stored, nil)
+		}
+		ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			got = args.Get(2).(*appsv1.Deployment)
+		}).Return(nil)
+
+		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+		assert.NoError(client.EnsureSentinelDeployment(context.TODO(), rf, labels, []metav1.OwnerReference{}))
+		ms.AssertExpectations(t)
+		return got
+	}
+
+	rf := generateRF()
+	created := ensure(rf, map[string]string{"team": "cache"}, nil)
+	if !assert.NotNil(created) {
+		return
+	}
+	assert.NotEmpty(created.Annotations["databases.spotahome.com/template-hash"])
+	assert.Equal(map[string]string{
+		"redisfailover.redis.io/name":      name,
+		"redisfailover.redis.io/namespace": namespace,
+		"redisfailover.redis.io/component": "sentinel",
+	}, created.Spec.Template.Annotations)
+	maxUnavailable := intstr.FromInt(1)
+	maxSurge := intstr.FromInt(1)
+	assert.Equal(appsv1.DeploymentStrategy{
+		Type:          appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{MaxUnavailable: &maxUnavailable, MaxSurge: &maxSurge},
+	}, created.Spec.Strategy)
+
+	// The labels copied from the RF are only set on the Deployment, its pod template is kept.
+	relabeled := ensure(rf, map[string]string{"team": "cache", "chart": "redis-1.2.4"}, created.DeepCopy())
+	assert.Equal("redis-1.2.4", relabeled.Labels["chart"])
+	assert.Equal(created.Spec.Template, relabeled.Spec.Template)
+	assert.Equal(created.Annotations, relabeled.Annotations)
+
+	// A change of the template rolls the pods, they get the new labels.
+	rf.Spec.Sentinel.Image = "redis:7.2"
+	changed := ensure(rf, map[string]string{"team": "cache", "chart": "redis-1.2.4"}, relabeled.DeepCopy())
+	assert.NotEqual(created.Spec.Template, changed.Spec.Template)
+	assert.NotEqual(created.Annotations, changed.Annotations)
+	assert.Equal("redis-1.2.4", changed.Spec.Template.Labels["chart"])
+}
+
+func TestRedisStatefulSetKeepsVolumeClaimTemplates(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.Storage.PersistentVolumeClaim = &redisfailoverv1.EmbeddedPersistentVolumeClaim{
+		EmbeddedObjectMetadata: redisfailoverv1.EmbeddedObjectMetadata{Name: "redis-data"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")},
+			},
+		},
+	}
+	stored := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: namespace},
+		Spec: appsv1.StatefulSetSpec{
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{Name: "redis-data"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+					},
+				},
+			}},
+		},
+	}
+
+	var got *appsv1.StatefulSet
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+	ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(stored, nil)
+	ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+		got = args.Get(2).(*appsv1.StatefulSet)
+	}).Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	assert.NoError(client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+	ms.AssertExpectations(t)
+
+	// The templates can't be updated, the claims are resized instead.
+	if assert.NotNil(got) {
+		assert.Equal(stored.Spec.VolumeClaimTemplates, got.Spec.VolumeClaimTemplates)
+	}
+}
+
+func TestRedisPodDisruptionBudgetUnhealthyPodEvictionPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.PodDisruptionBudget = &redisfailoverv1.RedisPodDisruptionBudget{UnhealthyPodEvictionPolicy: redisfailoverv1.UnhealthyPodEvictionAlwaysAllow}
+
+	pdbs := map[string]*policyv1.PodDisruptionBudget{}
+	ms := &mK8SService.Services{}
+	ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Twice().Run(func(args mock.Arguments) {
+		pdb := args.Get(2).(*policyv1.PodDisruptionBudget)
+		pdbs[pdb.Name] = pdb
+	}).Return(nil)
+	ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+	ms.On("GetDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+	ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+
+	client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+	assert.NoError(client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+	assert.NoError(client.EnsureSentinelDeployment(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+
+	// Only the redis pods get the policy, the annotation is not in the selector.
+	if assert.Contains(pdbs, "rfr-test") {
+		assert.Equal("AlwaysAllow", pdbs["rfr-test"].Annotations[k8s.UnhealthyPodEvictionPolicyAnnotation])
+		assert.NotContains(pdbs["rfr-test"].Spec.Selector.MatchLabels, k8s.UnhealthyPodEvictionPolicyAnnotation)
+	}
+	if assert.Contains(pdbs, "rfs-test") {
+		assert.NotContains(pdbs["rfs-test"].Annotations, k8s.UnhealthyPodEvictionPolicyAnnotation)
+	}
+	ms.AssertExpectations(t)
+}
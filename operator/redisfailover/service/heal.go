@@ -1,92 +1,131 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	v1 "k8s.io/api/core/v1"
 	redisfailoverv1 "redis-operator/api/redisfailover/v1"
 	"redis-operator/log"
 	"redis-operator/service/k8s"
 	"redis-operator/service/redis"
-	v1 "k8s.io/api/core/v1"
 )
 
 // RedisFailoverHeal defines the interface able to fix the problems on the redis failovers
 type RedisFailoverHeal interface {
-	MakeMaster(ip string, rFailover *redisfailoverv1.RedisFailover) error
-	SetOldestAsMaster(rFailover *redisfailoverv1.RedisFailover) error
-	SetMasterOnAll(masterIP string, rFailover *redisfailoverv1.RedisFailover) error
-	SetExternalMasterOnAll(masterIP string, masterPort string, rFailover *redisfailoverv1.RedisFailover) error
-	NewSentinelMonitor(ip string, monitor string, rFailover *redisfailoverv1.RedisFailover) error
-	NewSentinelMonitorWithPort(ip string, monitor string, port string, rFailover *redisfailoverv1.RedisFailover) error
+	MakeMaster(ctx context.Context, ip string, rFailover *redisfailoverv1.RedisFailover) error
+	SetOldestAsMaster(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) error
+	PlanMasterOnAll(ctx context.Context, masterIP string, rFailover *redisfailoverv1.RedisFailover) ([]PodAction, error)
+	PlanExternalMasterOnAll(ctx context.Context, masterIP string, masterPort string, rFailover *redisfailoverv1.RedisFailover) ([]PodAction, error)
+	PlanRoleLabels(ctx context.Context, masterIP string, rFailover *redisfailoverv1.RedisFailover) ([]PodAction, error)
+	NewSentinelMonitor(ctx context.Context, ip string, monitor string, rFailover *redisfailoverv1.RedisFailover) error
+	NewSentinelMonitorWithPort(ctx context.Context, ip string, monitor string, port string, rFailover *redisfailoverv1.RedisFailover) error
 	RestoreSentinel(ip string) error
 	SetSentinelCustomConfig(ip string, rFailover *redisfailoverv1.RedisFailover) error
-	SetRedisCustomConfig(ip string, rFailover *redisfailoverv1.RedisFailover) error
-	DeletePod(podName string, rFailover *redisfailoverv1.RedisFailover) error
+	SetSentinelGlobalConfig(ip string, rFailover *redisfailoverv1.RedisFailover) error
+	PlanRedisCustomConfig(ctx context.Context, rFailover *redisfailoverv1.RedisFailover) ([]PodAction, error)
+	LoadRedisACL(ctx context.Context, rFailover *redisfailoverv1.RedisFailover, ip string, users []string) error
+	RotatePassword(ctx context.Context, rFailover *redisfailoverv1.RedisFailover, current, next string) error
+	PlanRedisInPlaceRestarts(ctx context.Context, masterIP string, rFailover *redisfailoverv1.RedisFailover) ([]PodAction, error)
+	DeletePod(ctx context.Context, podName string, rFailover *redisfailoverv1.RedisFailover) error
+	EvictPod(ctx context.Context, podName string, rFailover *redisfailoverv1.RedisFailover) error
+	SetExternalNodesMaster(ctx context.Context, master redisfailoverv1.RedisExternalNode, rFailover *redisfailoverv1.RedisFailover) error
+	SetExternalRedisCustomConfig(ctx context.Context, node redisfailoverv1.RedisExternalNode, rFailover *redisfailoverv1.RedisFailover) error
+	GetSyncSlotQueue(rFailover *redisfailoverv1.RedisFailover) []string
+	ClearSyncSlotQueue(rFailover *redisfailoverv1.RedisFailover)
+	PlanStuckReplicas(ctx context.Context, masterIP string, rFailover *redisfailoverv1.RedisFailover) ([]PodAction, error)
+	SnapshotReplica(ctx context.Context, podName string, ip string, snapshotName string, labels map[string]string, rFailover *redisfailoverv1.RedisFailover) error
+	PlanUnresponsiveSentinels(ctx context.Context, unresponsiveIPs []string, rFailover *redisfailoverv1.RedisFailover) ([]PodAction, error)
+	GetRemediationState(rFailover *redisfailoverv1.RedisFailover) redisfailoverv1.CheckerState
+	RestoreRemediationState(rFailover *redisfailoverv1.RedisFailover, state redisfailoverv1.CheckerState)
 }
 
 // RedisFailoverHealer is our implementation of RedisFailoverCheck interface
 type RedisFailoverHealer struct {
-	k8sService  k8s.Services
-	redisClient redis.Client
-	logger      log.Logger
+	k8sService    k8s.Services
+	redisClient   redis.Client
+	logger        log.Logger
+	syncSlots     *SyncSlotQueue
+	stuckReplicas *StuckReplicaTracker
+	// unresponsiveSentinels counts the checks the sentinels have not answered.
+	unresponsiveSentinels *UnresponsiveSentinelTracker
+	inPlaceRestarts       *InPlaceRestartTracker
 }
 
 // NewRedisFailoverHealer creates an object of the RedisFailoverChecker struct
 func NewRedisFailoverHealer(k8sService k8s.Services, redisClient redis.Client, logger log.Logger) *RedisFailoverHealer {
 	return &RedisFailoverHealer{
-		k8sService:  k8sService,
-		redisClient: redisClient,
-		logger:      logger,
+		k8sService:    k8sService,
+		redisClient:   redisClient,
+		logger:        logger,
+		syncSlots:     NewSyncSlotQueue(time.Now),
+		stuckReplicas: NewStuckReplicaTracker(),
+
+		unresponsiveSentinels: NewUnresponsiveSentinelTracker(),
+		inPlaceRestarts:       NewInPlaceRestartTracker(time.Now),
 	}
 }
 
-func (r *RedisFailoverHealer) setMasterLabelIfNecessary(namespace string, pod v1.Pod) error {
+func (r *RedisFailoverHealer) setMasterLabelIfNecessary(ctx context.Context, namespace string, pod v1.Pod) error {
 	for labelKey, labelValue := range pod.ObjectMeta.Labels {
 		if labelKey == redisRoleLabelKey && labelValue == redisRoleLabelMaster {
 			return nil
 		}
 	}
-	return r.k8sService.UpdatePodLabels(namespace, pod.ObjectMeta.Name, generateRedisMasterRoleLabel())
+	return r.k8sService.UpdatePodLabels(ctx, namespace, pod.ObjectMeta.Name, generateRedisMasterRoleLabel())
 }
 
-func (r *RedisFailoverHealer) setSlaveLabelIfNecessary(namespace string, pod v1.Pod) error {
+func (r *RedisFailoverHealer) setSlaveLabelIfNecessary(ctx context.Context, namespace string, pod v1.Pod) error {
 	for labelKey, labelValue := range pod.ObjectMeta.Labels {
 		if labelKey == redisRoleLabelKey && labelValue == redisRoleLabelSlave {
 			return nil
 		}
 	}
-	return r.k8sService.UpdatePodLabels(namespace, pod.ObjectMeta.Name, generateRedisSlaveRoleLabel())
+	return r.k8sService.UpdatePodLabels(ctx, namespace, pod.ObjectMeta.Name, generateRedisSlaveRoleLabel())
 }
 
-func (r *RedisFailoverHealer) MakeMaster(ip string, rf *redisfailoverv1.RedisFailover) error {
-	password, err := k8s.GetRedisPassword(r.k8sService, rf)
+// MakeMaster promotes the redis of the ip. The pods with a replicaPriority of 0 are never promoted.
+func (r *RedisFailoverHealer) MakeMaster(ctx context.Context, ip string, rf *redisfailoverv1.RedisFailover) error {
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
 	if err != nil {
 		return err
 	}
+	var pod *v1.Pod
+	for i := range rps.Items {
+		if rf.PreferredPodIP(rps.Items[i].Status) == ip {
+			pod = &rps.Items[i]
+		}
+	}
+	if pod != nil && !redisPodPromotable(rf, pod.Name) {
+		return fmt.Errorf("redis %s can't be promoted, it has a replicaPriority of 0 in redis.instanceOverrides", pod.Name)
+	}
 
-	port := getRedisPort(rf.Spec.Redis.Port)
-	err = r.redisClient.MakeMaster(ip, port, password)
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
 	if err != nil {
 		return err
 	}
 
-	rps, err := r.k8sService.GetStatefulSetPods(rf.Namespace, GetRedisName(rf))
+	port := getRedisPort(rf.Spec.Redis.Port)
+	err = r.redisClient.MakeMaster(ip, port, password)
 	if err != nil {
 		return err
 	}
-	for _, rp := range rps.Items {
-		if rp.Status.PodIP == ip {
-			return r.setMasterLabelIfNecessary(rf.Namespace, rp)
-		}
+
+	if pod != nil {
+		return r.setMasterLabelIfNecessary(ctx, rf.Namespace, *pod)
 	}
 	return nil
 }
 
 // SetOldestAsMaster puts all redis to the same master, choosen by order of appearance
-func (r *RedisFailoverHealer) SetOldestAsMaster(rf *redisfailoverv1.RedisFailover) error {
-	ssp, err := r.k8sService.GetStatefulSetPods(rf.Namespace, GetRedisName(rf))
+func (r *RedisFailoverHealer) SetOldestAsMaster(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	ssp, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
 	if err != nil {
 		return err
 	}
@@ -94,12 +133,30 @@ func (r *RedisFailoverHealer) SetOldestAsMaster(rf *redisfailoverv1.RedisFailove
 		return errors.New("number of redis pods are 0")
 	}
 
-	// Order the pods so we start by the oldest one
+	// Order the pods so we start by the oldest one, the pods that failed the last functional probe are
+	// only promoted when no other pod can be.
 	sort.Slice(ssp.Items, func(i, j int) bool {
 		return ssp.Items[i].CreationTimestamp.Before(&ssp.Items[j].CreationTimestamp)
 	})
+	unhealthy := getFunctionallyUnhealthyPods(rf)
+	sort.SliceStable(ssp.Items, func(i, j int) bool {
+		return !unhealthy[ssp.Items[i].Name] && unhealthy[ssp.Items[j].Name]
+	})
+	// The pods with a replicaPriority of 0 are never promoted, they're made replicas of the new master.
+	sort.SliceStable(ssp.Items, func(i, j int) bool {
+		return redisPodPromotable(rf, ssp.Items[i].Name) && !redisPodPromotable(rf, ssp.Items[j].Name)
+	})
+	if !redisPodPromotable(rf, ssp.Items[0].Name) {
+		return errors.New("no redis pod can be promoted, they all have a replicaPriority of 0 in redis.instanceOverrides")
+	}
 
-	password, err := k8s.GetRedisPassword(r.k8sService, rf)
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return err
+	}
+
+	// The replicas point to the address announced by the master.
+	addresses, err := getRedisAddresses(r.redisClient, rf, ssp.Items, password)
 	if err != nil {
 		return err
 	}
@@ -107,27 +164,28 @@ func (r *RedisFailoverHealer) SetOldestAsMaster(rf *redisfailoverv1.RedisFailove
 	port := getRedisPort(rf.Spec.Redis.Port)
 	newMasterIP := ""
 	for _, pod := range ssp.Items {
+		podIP := rf.PreferredPodIP(pod.Status)
 		if newMasterIP == "" {
-			newMasterIP = pod.Status.PodIP
+			newMasterIP = podIP
 			r.logger.Debugf("New master is %s with ip %s", pod.Name, newMasterIP)
 			if err := r.redisClient.MakeMaster(newMasterIP, port, password); err != nil {
-				r.logger.Errorf("Make new master failed, master ip: %s, error: %v", pod.Status.PodIP, err)
+				r.logger.Errorf("Make new master failed, master ip: %s, error: %v", podIP, err)
 				continue
 			}
 
-			err = r.setMasterLabelIfNecessary(rf.Namespace, pod)
+			err = r.setMasterLabelIfNecessary(ctx, rf.Namespace, pod)
 			if err != nil {
 				return err
 			}
 
-			newMasterIP = pod.Status.PodIP
+			newMasterIP = podIP
 		} else {
 			r.logger.Debugf("Making pod %s slave of %s", pod.Name, newMasterIP)
-			if err := r.redisClient.MakeSlaveOfWithPort(pod.Status.PodIP, newMasterIP, port, password); err != nil {
-				r.logger.Errorf("Make slave failed, slave pod ip: %s, master ip: %s, error: %v", pod.Status.PodIP, newMasterIP, err)
+			if err := r.redisClient.MakeSlaveOfWithPort(podIP, addresses.Announced(newMasterIP), port, password); err != nil {
+				r.logger.Errorf("Make slave failed, slave pod ip: %s, master ip: %s, error: %v", podIP, newMasterIP, err)
 			}
 
-			err = r.setSlaveLabelIfNecessary(rf.Namespace, pod)
+			err = r.setSlaveLabelIfNecessary(ctx, rf.Namespace, pod)
 			if err != nil {
 				return err
 			}
@@ -136,76 +194,148 @@ func (r *RedisFailoverHealer) SetOldestAsMaster(rf *redisfailoverv1.RedisFailove
 	return nil
 }
 
-// SetMasterOnAll puts all redis nodes as a slave of a given master
-func (r *RedisFailoverHealer) SetMasterOnAll(masterIP string, rf *redisfailoverv1.RedisFailover) error {
-	ssp, err := r.k8sService.GetStatefulSetPods(rf.Namespace, GetRedisName(rf))
+// PlanMasterOnAll plans the actions putting all redis nodes as a slave of a given master
+func (r *RedisFailoverHealer) PlanMasterOnAll(ctx context.Context, masterIP string, rf *redisfailoverv1.RedisFailover) ([]PodAction, error) {
+	ssp, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	password, err := k8s.GetRedisPassword(r.k8sService, rf)
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	// The replicas point to the address announced by the master.
+	addresses, err := getRedisAddresses(r.redisClient, rf, ssp.Items, password)
+	if err != nil {
+		return nil, err
+	}
+	announcedMaster := addresses.Announced(masterIP)
+
 	port := getRedisPort(rf.Spec.Redis.Port)
+	actions := []PodAction{}
+	replicas := []string{}
+	replicaPods := map[string]string{}
 	for _, pod := range ssp.Items {
-		if pod.Status.PodIP == masterIP {
-			r.logger.Debugf("Ensure pod %s is master", pod.Name)
-			if err := r.redisClient.MakeMaster(masterIP, port, password); err != nil {
-				r.logger.Errorf("Make master failed, master ip: %s, error: %v", masterIP, err)
-				return err
-			}
-
-			err = r.setMasterLabelIfNecessary(rf.Namespace, pod)
-			if err != nil {
-				return err
-			}
+		podIP := rf.PreferredPodIP(pod.Status)
+		if podIP == masterIP {
+			podName := pod.Name
+			actions = append(actions, PodAction{
+				Pod:    podName,
+				Kind:   PodActionReconfigure,
+				Reason: "ensure it's the master",
+				Apply: func() error {
+					r.logger.Debugf("Ensure pod %s is master", podName)
+					if err := r.redisClient.MakeMaster(masterIP, port, password); err != nil {
+						r.logger.Errorf("Make master failed, master ip: %s, error: %v", masterIP, err)
+						return err
+					}
+					return nil
+				},
+			})
 		} else {
-			r.logger.Debugf("Making pod %s slave of %s", pod.Name, masterIP)
-			if err := r.redisClient.MakeSlaveOfWithPort(pod.Status.PodIP, masterIP, port, password); err != nil {
-				r.logger.Errorf("Make slave failed, slave ip: %s, master ip: %s, error: %v", pod.Status.PodIP, masterIP, err)
-				return err
-			}
-
-			err = r.setSlaveLabelIfNecessary(rf.Namespace, pod)
+			slaveOf, err := r.redisClient.GetSlaveOf(podIP, port, password)
 			if err != nil {
-				return err
+				r.logger.Errorf("Get slave of master failed, slave ip: %s, error: %v", podIP, err)
+				return nil, err
+			}
+			if slaveOf != announcedMaster {
+				replicas = append(replicas, podIP)
+				replicaPods[podIP] = pod.Name
 			}
 		}
 	}
-	return nil
+
+	// Every replica pointed to the master makes a full sync, they are limited so the master memory
+	// doesn't blow up saving the RDB for all of them at once.
+	admitted, err := r.admitFullSyncs(rf, masterIP, port, password, replicas)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range admitted {
+		ip := ip
+		actions = append(actions, PodAction{
+			Pod:    replicaPods[ip],
+			Kind:   PodActionReconfigure,
+			Reason: fmt.Sprintf("make it slave of %s", masterIP),
+			Apply: func() error {
+				r.logger.Debugf("Making %s slave of %s", ip, masterIP)
+				if err := r.redisClient.MakeSlaveOfWithPort(ip, announcedMaster, port, password); err != nil {
+					r.logger.Errorf("Make slave failed, slave ip: %s, master ip: %s, error: %v", ip, masterIP, err)
+					return err
+				}
+				return nil
+			},
+		})
+	}
+	return actions, nil
 }
 
-// SetExternalMasterOnAll puts all redis nodes as a slave of a given master outside of
-// the current RedisFailover instance
-func (r *RedisFailoverHealer) SetExternalMasterOnAll(masterIP, masterPort string, rf *redisfailoverv1.RedisFailover) error {
-	ssp, err := r.k8sService.GetStatefulSetPods(rf.Namespace, GetRedisName(rf))
+// PlanExternalMasterOnAll plans the actions putting all redis nodes as a slave of a given master
+// outside of the current RedisFailover instance
+func (r *RedisFailoverHealer) PlanExternalMasterOnAll(ctx context.Context, masterIP, masterPort string, rf *redisfailoverv1.RedisFailover) ([]PodAction, error) {
+	ssp, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	password, err := k8s.GetRedisPassword(r.k8sService, rf)
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	actions := []PodAction{}
 	for _, pod := range ssp.Items {
-		r.logger.Debugf("Making pod %s slave of %s:%s", pod.Name, masterIP, masterPort)
-		if err := r.redisClient.MakeSlaveOfWithPort(pod.Status.PodIP, masterIP, masterPort, password); err != nil {
-			return err
-		}
+		podName, podIP := pod.Name, rf.PreferredPodIP(pod.Status)
+		actions = append(actions, PodAction{
+			Pod:    podName,
+			Kind:   PodActionReconfigure,
+			Reason: fmt.Sprintf("make it slave of %s:%s", masterIP, masterPort),
+			Apply: func() error {
+				r.logger.Debugf("Making pod %s slave of %s:%s", podName, masterIP, masterPort)
+				return r.redisClient.MakeSlaveOfWithPort(podIP, masterIP, masterPort, password)
+			},
+		})
+	}
+	return actions, nil
+}
 
+// PlanRoleLabels plans the label updates of the redis pods whose role label doesn't match the given master.
+func (r *RedisFailoverHealer) PlanRoleLabels(ctx context.Context, masterIP string, rf *redisfailoverv1.RedisFailover) ([]PodAction, error) {
+	ssp, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
 	}
-	return nil
+
+	actions := []PodAction{}
+	for _, pod := range ssp.Items {
+		role, labels := redisRoleLabelSlave, generateRedisSlaveRoleLabel()
+		if rf.PreferredPodIP(pod.Status) == masterIP {
+			role, labels = redisRoleLabelMaster, generateRedisMasterRoleLabel()
+		}
+		if pod.ObjectMeta.Labels[redisRoleLabelKey] == role {
+			continue
+		}
+		podName := pod.Name
+		actions = append(actions, PodAction{
+			Pod:    podName,
+			Kind:   PodActionUpdateLabels,
+			Reason: fmt.Sprintf("set the %s role label", role),
+			Apply: func() error {
+				return r.k8sService.UpdatePodLabels(ctx, rf.Namespace, podName, labels)
+			},
+		})
+	}
+	return actions, nil
 }
 
 // NewSentinelMonitor changes the master that Sentinel has to monitor
-func (r *RedisFailoverHealer) NewSentinelMonitor(ip string, monitor string, rf *redisfailoverv1.RedisFailover) error {
+func (r *RedisFailoverHealer) NewSentinelMonitor(ctx context.Context, ip string, monitor string, rf *redisfailoverv1.RedisFailover) error {
 	r.logger.Debug("Sentinel is not monitoring the correct master, changing...")
 	quorum := strconv.Itoa(int(getQuorum(rf)))
 
-	password, err := k8s.GetRedisPassword(r.k8sService, rf)
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
 	if err != nil {
 		return err
 	}
@@ -215,11 +345,11 @@ func (r *RedisFailoverHealer) NewSentinelMonitor(ip string, monitor string, rf *
 }
 
 // NewSentinelMonitorWithPort changes the master that Sentinel has to monitor by the provided IP and Port
-func (r *RedisFailoverHealer) NewSentinelMonitorWithPort(ip string, monitor string, monitorPort string, rf *redisfailoverv1.RedisFailover) error {
+func (r *RedisFailoverHealer) NewSentinelMonitorWithPort(ctx context.Context, ip string, monitor string, monitorPort string, rf *redisfailoverv1.RedisFailover) error {
 	r.logger.Debug("Sentinel is not monitoring the correct master, changing...")
 	quorum := strconv.Itoa(int(getQuorum(rf)))
 
-	password, err := k8s.GetRedisPassword(r.k8sService, rf)
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
 	if err != nil {
 		return err
 	}
@@ -233,27 +363,333 @@ func (r *RedisFailoverHealer) RestoreSentinel(ip string) error {
 	return r.redisClient.ResetSentinel(ip)
 }
 
-// SetSentinelCustomConfig will call sentinel to set the configuration given in config
+// SetSentinelCustomConfig will call sentinel to set the quorum and the configuration given in config.
+// The quorum is set on every check, so a quorum set or removed in the spec reaches the sentinels
+// without monitoring the master again.
 func (r *RedisFailoverHealer) SetSentinelCustomConfig(ip string, rf *redisfailoverv1.RedisFailover) error {
 	r.logger.Debugf("Setting the custom config on sentinel %s...", ip)
-	return r.redisClient.SetCustomSentinelConfig(ip, rf.Spec.Sentinel.CustomConfig)
+	configs := append([]string{fmt.Sprintf("quorum %d", getQuorum(rf))}, rf.Spec.Sentinel.CustomConfig...)
+	return r.redisClient.SetCustomSentinelConfig(ip, configs)
 }
 
-// SetRedisCustomConfig will call redis to set the configuration given in config
-func (r *RedisFailoverHealer) SetRedisCustomConfig(ip string, rf *redisfailoverv1.RedisFailover) error {
-	r.logger.Debugf("Setting the custom config on redis %s...", ip)
+// SetSentinelGlobalConfig sets the global parameters of the spec whose value differs on the sentinel.
+// The sentinels older than 6.2 don't have them, they are skipped with a warning.
+func (r *RedisFailoverHealer) SetSentinelGlobalConfig(ip string, rf *redisfailoverv1.RedisFailover) error {
+	desired := rf.SentinelGlobalConfig()
+	parameters := make([]string, 0, len(desired))
+	for parameter := range desired {
+		parameters = append(parameters, parameter)
+	}
+	sort.Strings(parameters)
+	for _, parameter := range parameters {
+		current, err := r.redisClient.SentinelConfigGet(ip, parameter)
+		if errors.Is(err, redis.ErrUnsupported) {
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("the sentinel.globalConfig is not set on sentinel %s: %s", ip, err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if current[parameter] == desired[parameter] {
+			continue
+		}
+		r.logger.Debugf("Setting %s %s on sentinel %s...", parameter, desired[parameter], ip)
+		if err := r.redisClient.SentinelConfigSet(ip, parameter, desired[parameter]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlanRedisCustomConfig plans setting the configuration given in config on the running redis pods
+func (r *RedisFailoverHealer) PlanRedisCustomConfig(ctx context.Context, rf *redisfailoverv1.RedisFailover) ([]PodAction, error) {
+	ssp, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
+	}
 
-	password, err := k8s.GetRedisPassword(r.k8sService, rf)
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return nil, err
+	}
+
+	port := getRedisPort(rf.Spec.Redis.Port)
+	actions := []PodAction{}
+	for _, pod := range ssp.Items {
+		if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil { // Only work with running pods
+			continue
+		}
+		pod := pod
+		ip := rf.PreferredPodIP(pod.Status)
+		// The override of the pod goes after the custom config, as in its config file.
+		configs := rf.Spec.Redis.CustomConfig
+		if ordinal, ok := getRedisPodOrdinal(rf, pod.Name); ok {
+			configs = append(append([]string{}, configs...), renderRedisInstanceConfig(rf, ordinal)...)
+		}
+		// With the zero downtime reload the config only read on startup is in the config file, it's
+		// applied by the in place restarts.
+		if rf.ZeroDowntimeReloadEnabled() {
+			configs = runtimeRedisConfig(configs)
+		}
+		actions = append(actions, PodAction{
+			Pod:    pod.Name,
+			Kind:   PodActionApplyConfig,
+			Reason: "set the custom config",
+			Apply: func() error {
+				r.logger.Debugf("Setting the custom config on redis %s...", ip)
+				if err := r.redisClient.SetCustomRedisConfig(ip, port, configs, password); err != nil {
+					return err
+				}
+				return r.applyRedisInstanceOverride(ctx, rf, pod)
+			},
+		})
+	}
+	return actions, nil
+}
+
+// runtimeRedisConfig returns the config lines that can be set on a running redis.
+func runtimeRedisConfig(configs []string) []string {
+	runtime := []string{}
+	for _, config := range configs {
+		if !redisfailoverv1.IsRestartRequiredRedisDirective(strings.SplitN(strings.TrimSpace(config), " ", 2)[0]) {
+			runtime = append(runtime, config)
+		}
+	}
+	return runtime
+}
+
+// SetExternalNodesMaster puts all the external nodes as a slave of the given one. The external nodes
+// are not managed by the operator, this is the only fix applied to their replication. The nodes that
+// can't be reached are skipped.
+func (r *RedisFailoverHealer) SetExternalNodesMaster(ctx context.Context, master redisfailoverv1.RedisExternalNode, rf *redisfailoverv1.RedisFailover) error {
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
 	if err != nil {
 		return err
 	}
 
+	nodes := map[string]redisfailoverv1.RedisExternalNode{}
+	replicas := []string{}
+	for _, node := range rf.Spec.Redis.ExternalNodes {
+		if node == master {
+			continue
+		}
+		host, port, err := r.redisClient.GetSlaveOfWithPort(node.Host, node.Port, password)
+		if err != nil {
+			r.logger.Errorf("Get slave of master failed, maybe this node is not reachable, external node: %s:%s, error: %v", node.Host, node.Port, err)
+			continue
+		}
+		if host != master.Host || port != master.Port {
+			addr := net.JoinHostPort(node.Host, node.Port)
+			nodes[addr] = node
+			replicas = append(replicas, addr)
+		}
+	}
+
+	admitted, err := r.admitFullSyncs(rf, master.Host, master.Port, password, replicas)
+	if err != nil {
+		return err
+	}
+	for _, addr := range admitted {
+		node := nodes[addr]
+		r.logger.Debugf("Making external node %s:%s slave of %s:%s", node.Host, node.Port, master.Host, master.Port)
+		if err := r.redisClient.MakeSlaveOfWithPorts(node.Host, node.Port, master.Host, master.Port, password); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetExternalRedisCustomConfig will call the external node to set the configuration given in config
+func (r *RedisFailoverHealer) SetExternalRedisCustomConfig(ctx context.Context, node redisfailoverv1.RedisExternalNode, rf *redisfailoverv1.RedisFailover) error {
+	r.logger.Debugf("Setting the custom config on external node %s:%s...", node.Host, node.Port)
+
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return err
+	}
+
+	return r.redisClient.SetCustomRedisConfig(node.Host, node.Port, rf.Spec.Redis.CustomConfig, password)
+}
+
+// admitFullSyncs returns the replicas that can be pointed to the master without exceeding the full
+// syncs allowed at the same time. The others wait for a later check.
+func (r *RedisFailoverHealer) admitFullSyncs(rf *redisfailoverv1.RedisFailover, masterIP, masterPort, password string, replicas []string) ([]string, error) {
+	key := rfKey(rf)
+	if len(replicas) == 0 {
+		r.syncSlots.Clear(key)
+		return nil, nil
+	}
+
+	// Without a limit, as on the RFs defaulted before it existed, every replica is admitted right away.
+	if rf.Spec.Failover.MaxConcurrentSyncs <= 0 {
+		return r.syncSlots.Admit(key, replicas, len(replicas)), nil
+	}
+
+	syncing, err := r.redisClient.GetSyncingReplicas(masterIP, masterPort, password)
+	if err != nil {
+		return nil, err
+	}
+	free := int(rf.Spec.Failover.MaxConcurrentSyncs) - syncing
+	admitted := r.syncSlots.Admit(key, replicas, free)
+	if waiting := len(replicas) - len(admitted); waiting > 0 {
+		r.logger.Infof("%d replicas waiting for a sync slot, the master %s is making %d full syncs", waiting, masterIP, syncing)
+	}
+	return admitted, nil
+}
+
+// GetSyncSlotQueue returns the replicas waiting for a sync slot, the ones waiting the longest first.
+// The redis are identified by IP, the external nodes by address.
+func (r *RedisFailoverHealer) GetSyncSlotQueue(rf *redisfailoverv1.RedisFailover) []string {
+	return r.syncSlots.Waiting(rfKey(rf))
+}
+
+// ClearSyncSlotQueue forgets the replicas waiting for a sync slot, it's called once all of them
+// have the right master.
+func (r *RedisFailoverHealer) ClearSyncSlotQueue(rf *redisfailoverv1.RedisFailover) {
+	r.syncSlots.Clear(rfKey(rf))
+}
+
+// PlanStuckReplicas plans the remediation of the replicas stuck with the link to the master down for
+// the consecutive checks of the threshold. The replication of a stuck replica is retried first, and
+// the repl-backlog-size of the master raised if allowed and the partial syncs are failing. It's
+// restarted if it's still stuck the next time it reaches the threshold.
+func (r *RedisFailoverHealer) PlanStuckReplicas(ctx context.Context, masterIP string, rf *redisfailoverv1.RedisFailover) ([]PodAction, error) {
+	key := rfKey(rf)
+	threshold := rf.Spec.Failover.StuckReplicas.Threshold
+	if threshold == nil || *threshold <= 0 {
+		r.stuckReplicas.Clear(key)
+		return nil, nil
+	}
+
+	ssp, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return nil, err
+	}
+
 	port := getRedisPort(rf.Spec.Redis.Port)
-	return r.redisClient.SetCustomRedisConfig(ip, port, rf.Spec.Redis.CustomConfig, password)
+	masterInfo, err := r.redisClient.GetRedisInfo(masterIP, port, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// The replicas point to the address announced by the master.
+	addresses, err := getRedisAddresses(r.redisClient, rf, ssp.Items, password)
+	if err != nil {
+		return nil, err
+	}
+	announcedMaster := addresses.Announced(masterIP)
+
+	masterPod := ""
+	stuck := []string{}
+	podIPs := map[string]string{}
+	for _, pod := range ssp.Items {
+		podIP := rf.PreferredPodIP(pod.Status)
+		if podIP == masterIP {
+			masterPod = pod.Name
+			continue
+		}
+		if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		info, err := r.redisClient.GetRedisInfo(podIP, port, password)
+		if err != nil {
+			r.logger.Debugf("Could not get the replication of pod %s: %v", pod.Name, err)
+			continue
+		}
+		if isReplicaStuck(info) {
+			stuck = append(stuck, pod.Name)
+			podIPs[pod.Name] = podIP
+		}
+	}
+
+	escalations := r.stuckReplicas.Observe(key, stuck, getInfoInt(masterInfo, "sync_partial_ok"), getInfoInt(masterInfo, "sync_partial_err"), int(*threshold))
+	actions := []PodAction{}
+	raiseBacklog := false
+	for _, escalation := range escalations {
+		escalation := escalation
+		podName, ip := escalation.Pod, podIPs[escalation.Pod]
+		switch escalation.Step {
+		case StuckReplicaRetry:
+			raiseBacklog = raiseBacklog || (escalation.PartialSyncFailures && rf.Spec.Failover.StuckReplicas.RaiseBacklog)
+			actions = append(actions, PodAction{
+				Pod:    podName,
+				Kind:   PodActionReconfigure,
+				Reason: fmt.Sprintf("retry the replication from %s, the link to the master is down for %d checks", masterIP, *threshold),
+				Apply: func() error {
+					r.logger.Debugf("Retrying the replication of stuck pod %s from %s", podName, masterIP)
+					if err := r.redisClient.MakeSlaveOfWithPort(ip, announcedMaster, port, password); err != nil {
+						return err
+					}
+					r.stuckReplicas.Escalated(key, escalation)
+					return nil
+				},
+			})
+		case StuckReplicaRestart:
+			actions = append(actions, PodAction{
+				Pod:    podName,
+				Kind:   PodActionDelete,
+				Reason: fmt.Sprintf("restart it, the link to the master is still down after retrying the replication from %s", masterIP),
+				Apply: func() error {
+					// The pod is evicted so its pdb is honored, the restart is retried on the next
+					// check while the pdb refuses it.
+					if err := r.EvictPod(ctx, podName, rf); err != nil {
+						return err
+					}
+					r.stuckReplicas.Escalated(key, escalation)
+					return nil
+				},
+			})
+		}
+	}
+
+	if size, ok := raisedReplBacklogSize(masterInfo, rf); raiseBacklog && ok && masterPod != "" {
+		// The backlog is raised before the replication is retried, so the retry can make a partial sync.
+		config := []string{fmt.Sprintf("%s %d", replBacklogSizeParameter, size)}
+		actions = append([]PodAction{{
+			Pod:    masterPod,
+			Kind:   PodActionApplyConfig,
+			Reason: fmt.Sprintf("raise the repl-backlog-size to %d, the partial syncs of the stuck replicas are failing", size),
+			Apply: func() error {
+				r.logger.Debugf("Raising the repl-backlog-size of the master %s to %d", masterIP, size)
+				return r.redisClient.SetCustomRedisConfig(masterIP, port, config, password)
+			},
+		}}, actions...)
+	}
+	return actions, nil
 }
 
-//DeletePod delete a failing pod so kubernetes relaunch it again
-func (r *RedisFailoverHealer) DeletePod(podName string, rFailover *redisfailoverv1.RedisFailover) error {
+// DeletePod delete a failing pod so kubernetes relaunch it again
+func (r *RedisFailoverHealer) DeletePod(ctx context.Context, podName string, rFailover *redisfailoverv1.RedisFailover) error {
 	r.logger.Debugf("Deleting pods %s...", podName)
-	return r.k8sService.DeletePod(rFailover.Namespace, podName)
+	return r.k8sService.DeletePod(ctx, rFailover.Namespace, podName)
+}
+
+// EvictPod evicts a pod so kubernetes relaunch it again, honoring its pdb
+func (r *RedisFailoverHealer) EvictPod(ctx context.Context, podName string, rFailover *redisfailoverv1.RedisFailover) error {
+	r.logger.Debugf("Evicting pod %s...", podName)
+	return r.k8sService.EvictPod(ctx, rFailover.Namespace, podName)
+}
+
+// GetRemediationState returns the stuck replicas and the unresponsive sentinels of the RF being
+// remediated, the state of the healer that must survive the restarts of the operator. The sync slot
+// queue is not returned, it's rebuilt from the replicas syncing on the next checks.
+func (r *RedisFailoverHealer) GetRemediationState(rf *redisfailoverv1.RedisFailover) redisfailoverv1.CheckerState {
+	key := rfKey(rf)
+	return redisfailoverv1.CheckerState{
+		StuckReplicas:         r.stuckReplicas.State(key),
+		UnresponsiveSentinels: r.unresponsiveSentinels.State(key),
+	}
+}
+
+// RestoreRemediationState restores the remediations of the RF stored before a restart of the operator,
+// so they are not started over.
+func (r *RedisFailoverHealer) RestoreRemediationState(rf *redisfailoverv1.RedisFailover, state redisfailoverv1.CheckerState) {
+	key := rfKey(rf)
+	r.stuckReplicas.Restore(key, state.StuckReplicas)
+	r.unresponsiveSentinels.Restore(key, state.UnresponsiveSentinels)
 }
@@ -1,7 +1,10 @@
 package service_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
@@ -9,12 +12,16 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
 	"redis-operator/log"
 	mK8SService "redis-operator/mocks/service/k8s"
 	mRedisService "redis-operator/mocks/service/redis"
 	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/redis"
 )
 
 func TestSetOldestAsMasterNewMasterError(t *testing.T) {
@@ -33,14 +40,14 @@ func TestSetOldestAsMasterNewMasterError(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("UpdatePodLabels", mock.Anything, namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
 	mr := &mRedisService.Client{}
 	mr.On("MakeMaster", "0.0.0.0", "0", "").Once().Return(errors.New(""))
 
 	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
 
-	err := healer.SetOldestAsMaster(rf)
+	err := healer.SetOldestAsMaster(context.TODO(), rf)
 	assert.NoError(err)
 }
 
@@ -60,14 +67,14 @@ func TestSetOldestAsMaster(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Once().Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("UpdatePodLabels", mock.Anything, namespace, mock.AnythingOfType("string"), mock.Anything).Once().Return(nil)
 	mr := &mRedisService.Client{}
 	mr.On("MakeMaster", "0.0.0.0", "0", "").Once().Return(nil)
 
 	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
 
-	err := healer.SetOldestAsMaster(rf)
+	err := healer.SetOldestAsMaster(context.TODO(), rf)
 	assert.NoError(err)
 }
 
@@ -92,15 +99,17 @@ func TestSetOldestAsMasterMultiplePodsMakeSlaveOfError(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("UpdatePodLabels", mock.Anything, namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
 	mr := &mRedisService.Client{}
 	mr.On("MakeMaster", "0.0.0.0", "0", "").Once().Return(nil)
+	mr.On("GetSlaveOf", "1.1.1.1", "0", "").Once().Return("", nil)
+	mr.On("GetSyncingReplicas", "0.0.0.0", "0", "").Once().Return(0, nil)
 	mr.On("MakeSlaveOfWithPort", "1.1.1.1", "0.0.0.0", "0", "").Once().Return(errors.New(""))
 
 	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
 
-	err := healer.SetOldestAsMaster(rf)
+	err := healer.SetOldestAsMaster(context.TODO(), rf)
 	assert.NoError(err)
 }
 
@@ -125,15 +134,15 @@ func TestSetOldestAsMasterMultiplePods(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("UpdatePodLabels", mock.Anything, namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
 	mr := &mRedisService.Client{}
 	mr.On("MakeMaster", "0.0.0.0", "0", "").Once().Return(nil)
 	mr.On("MakeSlaveOfWithPort", "1.1.1.1", "0.0.0.0", "0", "").Once().Return(nil)
 
 	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
 
-	err := healer.SetOldestAsMaster(rf)
+	err := healer.SetOldestAsMaster(context.TODO(), rf)
 	assert.NoError(err)
 }
 
@@ -168,19 +177,38 @@ func TestSetOldestAsMasterOrdering(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("UpdatePodLabels", mock.Anything, namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
 	mr := &mRedisService.Client{}
 	mr.On("MakeMaster", "1.1.1.1", "0", "").Once().Return(nil)
 	mr.On("MakeSlaveOfWithPort", "0.0.0.0", "1.1.1.1", "0", "").Once().Return(nil)
 
 	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
 
-	err := healer.SetOldestAsMaster(rf)
+	err := healer.SetOldestAsMaster(context.TODO(), rf)
 	assert.NoError(err)
 }
 
-func TestSetMasterOnAllMakeMasterError(t *testing.T) {
+// applyPodActions applies the actions in order, as the handler does once they are arbitrated.
+func applyPodActions(actions []rfservice.PodAction) error {
+	for _, action := range actions {
+		if err := action.Apply(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errEvictionBlocked is the 429 answered to an eviction that would violate the PodDisruptionBudget.
+var errEvictionBlocked = &kubeerrors.StatusError{ErrStatus: metav1.Status{
+	Status:  metav1.StatusFailure,
+	Code:    http.StatusTooManyRequests,
+	Reason:  metav1.StatusReasonTooManyRequests,
+	Message: "Cannot evict pod as it would violate the pod's disruption budget.",
+	Details: &metav1.StatusDetails{Causes: []metav1.StatusCause{{Type: policyv1.DisruptionBudgetCause}}},
+}}
+
+func TestPlanMasterOnAllMakeMasterError(t *testing.T) {
 	assert := assert.New(t)
 
 	rf := generateRF()
@@ -188,11 +216,13 @@ func TestSetMasterOnAllMakeMasterError(t *testing.T) {
 	pods := &corev1.PodList{
 		Items: []corev1.Pod{
 			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"},
 				Status: corev1.PodStatus{
 					PodIP: "0.0.0.0",
 				},
 			},
 			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"},
 				Status: corev1.PodStatus{
 					PodIP: "1.1.1.1",
 				},
@@ -201,18 +231,54 @@ func TestSetMasterOnAllMakeMasterError(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Once().Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
+	mr.On("GetSlaveOf", "1.1.1.1", "0", "").Once().Return("0.0.0.0", nil)
 	mr.On("MakeMaster", "0.0.0.0", "0", "").Once().Return(errors.New(""))
 
 	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
 
-	err := healer.SetMasterOnAll("0.0.0.0", rf)
+	actions, err := healer.PlanMasterOnAll(context.TODO(), "0.0.0.0", rf)
+	assert.NoError(err)
+	assert.Error(applyPodActions(actions))
+}
+
+func TestPlanMasterOnAllGetSlaveOfError(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"},
+				Status: corev1.PodStatus{
+					PodIP: "0.0.0.0",
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"},
+				Status: corev1.PodStatus{
+					PodIP: "1.1.1.1",
+				},
+			},
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	mr := &mRedisService.Client{}
+	mr.On("GetSlaveOf", "1.1.1.1", "0", "").Once().Return("", errors.New(""))
+
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+	_, err := healer.PlanMasterOnAll(context.TODO(), "0.0.0.0", rf)
 	assert.Error(err)
+	// Nothing is planned when the replication can't be checked.
+	mr.AssertNotCalled(t, "MakeMaster", "0.0.0.0", "0", "")
 }
 
-func TestSetMasterOnAllMakeSlaveOfError(t *testing.T) {
+func TestPlanMasterOnAllMakeSlaveOfError(t *testing.T) {
 	assert := assert.New(t)
 
 	rf := generateRF()
@@ -220,11 +286,13 @@ func TestSetMasterOnAllMakeSlaveOfError(t *testing.T) {
 	pods := &corev1.PodList{
 		Items: []corev1.Pod{
 			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"},
 				Status: corev1.PodStatus{
 					PodIP: "0.0.0.0",
 				},
 			},
 			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"},
 				Status: corev1.PodStatus{
 					PodIP: "1.1.1.1",
 				},
@@ -233,19 +301,21 @@ func TestSetMasterOnAllMakeSlaveOfError(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("MakeMaster", "0.0.0.0", "0", "").Once().Return(nil)
+	mr.On("GetSlaveOf", "1.1.1.1", "0", "").Once().Return("", nil)
+	mr.On("GetSyncingReplicas", "0.0.0.0", "0", "").Once().Return(0, nil)
 	mr.On("MakeSlaveOfWithPort", "1.1.1.1", "0.0.0.0", "0", "").Once().Return(errors.New(""))
 
 	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
 
-	err := healer.SetMasterOnAll("0.0.0.0", rf)
-	assert.Error(err)
+	actions, err := healer.PlanMasterOnAll(context.TODO(), "0.0.0.0", rf)
+	assert.NoError(err)
+	assert.Error(applyPodActions(actions))
 }
 
-func TestSetMasterOnAll(t *testing.T) {
+func TestPlanMasterOnAll(t *testing.T) {
 	assert := assert.New(t)
 
 	rf := generateRF()
@@ -253,11 +323,13 @@ func TestSetMasterOnAll(t *testing.T) {
 	pods := &corev1.PodList{
 		Items: []corev1.Pod{
 			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"},
 				Status: corev1.PodStatus{
 					PodIP: "0.0.0.0",
 				},
 			},
 			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"},
 				Status: corev1.PodStatus{
 					PodIP: "1.1.1.1",
 				},
@@ -266,19 +338,195 @@ func TestSetMasterOnAll(t *testing.T) {
 	}
 
 	ms := &mK8SService.Services{}
-	ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
-	ms.On("UpdatePodLabels", namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 	mr := &mRedisService.Client{}
 	mr.On("MakeMaster", "0.0.0.0", "0", "").Once().Return(nil)
+	mr.On("GetSlaveOf", "1.1.1.1", "0", "").Once().Return("", nil)
+	mr.On("GetSyncingReplicas", "0.0.0.0", "0", "").Once().Return(0, nil)
 	mr.On("MakeSlaveOfWithPort", "1.1.1.1", "0.0.0.0", "0", "").Once().Return(nil)
 
 	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
 
-	err := healer.SetMasterOnAll("0.0.0.0", rf)
+	actions, err := healer.PlanMasterOnAll(context.TODO(), "0.0.0.0", rf)
 	assert.NoError(err)
+
+	// Nothing is done until the actions are applied.
+	mr.AssertNotCalled(t, "MakeMaster", "0.0.0.0", "0", "")
+	if assert.Len(actions, 2) {
+		assert.Equal("rfr-test-0", actions[0].Pod)
+		assert.Equal(rfservice.PodActionReconfigure, actions[0].Kind)
+		assert.Equal("rfr-test-1", actions[1].Pod)
+		assert.Equal(rfservice.PodActionReconfigure, actions[1].Kind)
+	}
+
+	assert.NoError(applyPodActions(actions))
+	mr.AssertExpectations(t)
 }
 
-func TestSetExternalMasterOnAll(t *testing.T) {
+func TestPlanMasterOnAllWaitsForSyncSlot(t *testing.T) {
+	tests := []struct {
+		name        string
+		unlimited   bool
+		syncing     int
+		expRepoints []string
+		expWaiting  []string
+	}{
+		{
+			name:        "Only one replica is pointed to the master at a time",
+			syncing:     0,
+			expRepoints: []string{"1.1.1.1"},
+			expWaiting:  []string{"2.2.2.2"},
+		},
+		{
+			name:       "No replica is pointed to a master already syncing",
+			syncing:    1,
+			expWaiting: []string{"1.1.1.1", "2.2.2.2"},
+		},
+		{
+			name:        "Without a limit every replica is pointed to the master",
+			unlimited:   true,
+			expRepoints: []string{"1.1.1.1", "2.2.2.2"},
+			expWaiting:  []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			if test.unlimited {
+				rf.Spec.Failover.MaxConcurrentSyncs = 0
+			}
+			pods := &corev1.PodList{
+				Items: []corev1.Pod{
+					{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"}, Status: corev1.PodStatus{PodIP: "0.0.0.0"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"}, Status: corev1.PodStatus{PodIP: "1.1.1.1"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-2"}, Status: corev1.PodStatus{PodIP: "2.2.2.2"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-3"}, Status: corev1.PodStatus{PodIP: "3.3.3.3"}},
+				},
+			}
+
+			ms := &mK8SService.Services{}
+			ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+			mr := &mRedisService.Client{}
+			mr.On("MakeMaster", "0.0.0.0", "0", "").Once().Return(nil)
+			mr.On("GetSlaveOf", "1.1.1.1", "0", "").Once().Return("", nil)
+			mr.On("GetSlaveOf", "2.2.2.2", "0", "").Once().Return("9.9.9.9", nil)
+			// Already replicating from the master, it doesn't need a sync slot.
+			mr.On("GetSlaveOf", "3.3.3.3", "0", "").Once().Return("0.0.0.0", nil)
+			if !test.unlimited {
+				mr.On("GetSyncingReplicas", "0.0.0.0", "0", "").Once().Return(test.syncing, nil)
+			}
+			for _, ip := range test.expRepoints {
+				mr.On("MakeSlaveOfWithPort", ip, "0.0.0.0", "0", "").Once().Return(nil)
+			}
+
+			healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+			actions, err := healer.PlanMasterOnAll(context.TODO(), "0.0.0.0", rf)
+			assert.NoError(err)
+			assert.NoError(applyPodActions(actions))
+			assert.Equal(test.expWaiting, healer.GetSyncSlotQueue(rf))
+			mr.AssertExpectations(t)
+
+			healer.ClearSyncSlotQueue(rf)
+			assert.Empty(healer.GetSyncSlotQueue(rf))
+		})
+	}
+}
+
+func TestPlanRoleLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "rfr-test-0",
+					Labels: map[string]string{"redisfailovers-role": "slave"},
+				},
+				Status: corev1.PodStatus{PodIP: "0.0.0.0"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "rfr-test-1",
+					Labels: map[string]string{"redisfailovers-role": "slave"},
+				},
+				Status: corev1.PodStatus{PodIP: "1.1.1.1"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-2"},
+				Status:     corev1.PodStatus{PodIP: "2.2.2.2"},
+			},
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("UpdatePodLabels", mock.Anything, namespace, "rfr-test-0", map[string]string{"redisfailovers-role": "master"}).Once().Return(nil)
+	ms.On("UpdatePodLabels", mock.Anything, namespace, "rfr-test-2", map[string]string{"redisfailovers-role": "slave"}).Once().Return(nil)
+	mr := &mRedisService.Client{}
+
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+	actions, err := healer.PlanRoleLabels(context.TODO(), "0.0.0.0", rf)
+	assert.NoError(err)
+	if assert.Len(actions, 2) {
+		assert.Equal("rfr-test-0", actions[0].Pod)
+		assert.Equal(rfservice.PodActionUpdateLabels, actions[0].Kind)
+		assert.Equal("rfr-test-2", actions[1].Pod)
+		assert.Equal(rfservice.PodActionUpdateLabels, actions[1].Kind)
+	}
+	assert.NoError(applyPodActions(actions))
+	ms.AssertExpectations(t)
+}
+
+func TestPlanRedisCustomConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.CustomConfig = []string{"maxclients 100"}
+
+	now := metav1.Now()
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"},
+				Status:     corev1.PodStatus{PodIP: "0.0.0.0", Phase: corev1.PodRunning},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"},
+				Status:     corev1.PodStatus{Phase: corev1.PodPending},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-2", DeletionTimestamp: &now},
+				Status:     corev1.PodStatus{PodIP: "2.2.2.2", Phase: corev1.PodRunning},
+			},
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	mr := &mRedisService.Client{}
+	mr.On("SetCustomRedisConfig", "0.0.0.0", "0", []string{"maxclients 100"}, "").Once().Return(nil)
+
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+	actions, err := healer.PlanRedisCustomConfig(context.TODO(), rf)
+	assert.NoError(err)
+	// Only the running pods not being deleted get the config.
+	if assert.Len(actions, 1) {
+		assert.Equal("rfr-test-0", actions[0].Pod)
+		assert.Equal(rfservice.PodActionApplyConfig, actions[0].Kind)
+	}
+	assert.NoError(applyPodActions(actions))
+	mr.AssertExpectations(t)
+}
+
+func TestPlanExternalMasterOnAll(t *testing.T) {
 	tests := []struct {
 		name                  string
 		errorOnGetStatefulSet bool
@@ -304,11 +552,13 @@ func TestSetExternalMasterOnAll(t *testing.T) {
 			pods := &corev1.PodList{
 				Items: []corev1.Pod{
 					{
+						ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"},
 						Status: corev1.PodStatus{
 							PodIP: "0.0.0.0",
 						},
 					},
 					{
+						ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"},
 						Status: corev1.PodStatus{
 							PodIP: "1.1.1.1",
 						},
@@ -321,9 +571,9 @@ func TestSetExternalMasterOnAll(t *testing.T) {
 
 			if test.errorOnGetStatefulSet {
 				expectError = true
-				ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
+				ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New(""))
 			} else {
-				ms.On("GetStatefulSetPods", namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+				ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
 			}
 
 			mr := &mRedisService.Client{}
@@ -339,7 +589,10 @@ func TestSetExternalMasterOnAll(t *testing.T) {
 
 			healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
 
-			err := healer.SetExternalMasterOnAll("5.5.5.5", "6379", rf)
+			actions, err := healer.PlanExternalMasterOnAll(context.TODO(), "5.5.5.5", "6379", rf)
+			if err == nil {
+				err = applyPodActions(actions)
+			}
 
 			if expectError {
 				assert.Error(err)
@@ -383,7 +636,7 @@ func TestNewSentinelMonitor(t *testing.T) {
 
 			healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
 
-			err := healer.NewSentinelMonitor("0.0.0.0", "1.1.1.1", rf)
+			err := healer.NewSentinelMonitor(context.TODO(), "0.0.0.0", "1.1.1.1", rf)
 
 			if errorExpected {
 				assert.Error(err)
@@ -427,7 +680,7 @@ func TestNewSentinelMonitorWithPort(t *testing.T) {
 
 			healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
 
-			err := healer.NewSentinelMonitorWithPort("0.0.0.0", "1.1.1.1", "6379", rf)
+			err := healer.NewSentinelMonitorWithPort(context.TODO(), "0.0.0.0", "1.1.1.1", "6379", rf)
 
 			if errorExpected {
 				assert.Error(err)
@@ -439,3 +692,150 @@ func TestNewSentinelMonitorWithPort(t *testing.T) {
 		})
 	}
 }
+
+func TestSetExternalNodesMaster(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	master := redisfailoverv1.RedisExternalNode{Host: "10.0.0.1", Port: "6379"}
+	rf.Spec.Redis.ExternalNodes = []redisfailoverv1.RedisExternalNode{
+		master,
+		{Host: "10.0.0.2", Port: "6380"},
+		{Host: "10.0.0.1", Port: "6380"},
+		{Host: "10.0.0.3", Port: "6379"},
+		{Host: "10.0.0.4", Port: "6379"},
+	}
+	rf.Spec.Failover.MaxConcurrentSyncs = 2
+
+	ms := &mK8SService.Services{}
+	mr := &mRedisService.Client{}
+	mr.On("GetSlaveOfWithPort", "10.0.0.2", "6380", "").Once().Return("", "", nil)
+	// A node of the host of the master replicating another node of the host.
+	mr.On("GetSlaveOfWithPort", "10.0.0.1", "6380", "").Once().Return("10.0.0.1", "6381", nil)
+	// The nodes not reachable are skipped.
+	mr.On("GetSlaveOfWithPort", "10.0.0.3", "6379", "").Once().Return("", "", errors.New("connection refused"))
+	mr.On("GetSlaveOfWithPort", "10.0.0.4", "6379", "").Once().Return("10.0.0.1", "6379", nil)
+	mr.On("GetSyncingReplicas", "10.0.0.1", "6379", "").Once().Return(0, nil)
+	mr.On("MakeSlaveOfWithPorts", "10.0.0.2", "6380", "10.0.0.1", "6379", "").Once().Return(nil)
+	mr.On("MakeSlaveOfWithPorts", "10.0.0.1", "6380", "10.0.0.1", "6379", "").Once().Return(nil)
+
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+	err := healer.SetExternalNodesMaster(context.TODO(), master, rf)
+	assert.NoError(err)
+	ms.AssertExpectations(t)
+	mr.AssertExpectations(t)
+}
+
+func TestSetSentinelCustomConfigQuorum(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Sentinel.Replicas = 5
+	rf.Spec.Sentinel.CustomConfig = []string{"down-after-milliseconds 2000"}
+	ms := &mK8SService.Services{}
+	mr := &mRedisService.Client{}
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+	// The majority of the sentinels without a quorum in the spec.
+	mr.On("SetCustomSentinelConfig", "0.0.0.0", []string{"quorum 3", "down-after-milliseconds 2000"}).Once().Return(nil)
+	assert.NoError(healer.SetSentinelCustomConfig("0.0.0.0", rf))
+
+	// The quorum of the spec, even below the majority.
+	rf.Spec.Sentinel.Quorum = 2
+	mr.On("SetCustomSentinelConfig", "0.0.0.0", []string{"quorum 2", "down-after-milliseconds 2000"}).Once().Return(nil)
+	assert.NoError(healer.SetSentinelCustomConfig("0.0.0.0", rf))
+
+	// Back to the majority once removed from the spec.
+	rf.Spec.Sentinel.Quorum = 0
+	mr.On("SetCustomSentinelConfig", "0.0.0.0", []string{"quorum 3", "down-after-milliseconds 2000"}).Once().Return(nil)
+	assert.NoError(healer.SetSentinelCustomConfig("0.0.0.0", rf))
+
+	mr.AssertExpectations(t)
+}
+
+func TestSetSentinelGlobalConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalConfig []string
+		current      map[string]map[string]string
+		getErr       error
+		setErr       error
+		expSet       map[string]string
+		expErr       bool
+	}{
+		{
+			name:         "No global config should not call the sentinel",
+			globalConfig: nil,
+		},
+		{
+			name:         "Drifted parameters should be set",
+			globalConfig: []string{"resolve-hostnames yes", "announce-hostnames yes"},
+			current: map[string]map[string]string{
+				"announce-hostnames": {"announce-hostnames": "yes"},
+				"resolve-hostnames":  {"resolve-hostnames": "no"},
+			},
+			expSet: map[string]string{"resolve-hostnames": "yes"},
+		},
+		{
+			name:         "Parameters already set should not be set again",
+			globalConfig: []string{"resolve-hostnames yes"},
+			current: map[string]map[string]string{
+				"resolve-hostnames": {"resolve-hostnames": "yes"},
+			},
+		},
+		{
+			name:         "Sentinels older than 6.2 should be skipped",
+			globalConfig: []string{"resolve-hostnames yes"},
+			getErr:       fmt.Errorf("%w: SENTINEL CONFIG needs redis 6.2, the sentinel runs 6.0.16", redis.ErrUnsupported),
+		},
+		{
+			name:         "An error reading the parameters should be returned",
+			globalConfig: []string{"resolve-hostnames yes"},
+			getErr:       errors.New("connection refused"),
+			expErr:       true,
+		},
+		{
+			name:         "An error setting a parameter should be returned",
+			globalConfig: []string{"resolve-hostnames yes"},
+			current: map[string]map[string]string{
+				"resolve-hostnames": {"resolve-hostnames": "no"},
+			},
+			setErr: errors.New("connection refused"),
+			expSet: map[string]string{"resolve-hostnames": "yes"},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.Sentinel.GlobalConfig = test.globalConfig
+
+			ms := &mK8SService.Services{}
+			mr := &mRedisService.Client{}
+			if test.getErr != nil {
+				mr.On("SentinelConfigGet", "0.0.0.0", "resolve-hostnames").Once().Return(nil, test.getErr)
+			}
+			for parameter, current := range test.current {
+				mr.On("SentinelConfigGet", "0.0.0.0", parameter).Once().Return(current, nil)
+			}
+			for parameter, value := range test.expSet {
+				mr.On("SentinelConfigSet", "0.0.0.0", parameter, value).Once().Return(test.setErr)
+			}
+
+			healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+			err := healer.SetSentinelGlobalConfig("0.0.0.0", rf)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			mr.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/service/k8s"
+)
+
+// inPlaceRestartTimeout is how long a redis restarted in place has to come back ready. It's forgotten
+// afterwards, so it's restarted again if it still runs the old config.
+const inPlaceRestartTimeout = 2 * time.Minute
+
+type inPlaceRestart struct {
+	pod string
+	// restarts is the restart count of the redis container before the restart.
+	restarts int32
+	since    time.Time
+}
+
+// InPlaceRestartTracker remembers, for every RF, the redis restarted in place until its container is
+// back, so a redis is not restarted twice and the next one waits for it.
+type InPlaceRestartTracker struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	restarts map[string]inPlaceRestart
+}
+
+// NewInPlaceRestartTracker returns a new in place restart tracker.
+func NewInPlaceRestartTracker(now func() time.Time) *InPlaceRestartTracker {
+	return &InPlaceRestartTracker{
+		now:      now,
+		restarts: map[string]inPlaceRestart{},
+	}
+}
+
+// Start records the restart of the redis of the pod, with the restart count of its container.
+func (t *InPlaceRestartTracker) Start(key, pod string, restarts int32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.restarts[key] = inPlaceRestart{pod: pod, restarts: restarts, since: t.now()}
+}
+
+// Pending returns the pod of the RF whose restart is not done yet. A restart is done when the redis
+// container has restarted and is ready again, the restarts done, timed out or of a pod gone are
+// forgotten.
+func (t *InPlaceRestartTracker) Pending(key string, pods []v1.Pod) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	restart, ok := t.restarts[key]
+	if !ok {
+		return "", false
+	}
+	if t.now().Sub(restart.since) < inPlaceRestartTimeout {
+		for _, pod := range pods {
+			if pod.Name != restart.pod {
+				continue
+			}
+			ready, restarts := redisContainerStatus(pod)
+			if restarts <= restart.restarts || !ready {
+				return restart.pod, true
+			}
+		}
+	}
+	delete(t.restarts, key)
+	return "", false
+}
+
+// Clear forgets the restart of the RF.
+func (t *InPlaceRestartTracker) Clear(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.restarts, key)
+}
+
+// PlanRedisInPlaceRestarts plans restarting in place the redis whose running config is not the custom
+// config only read on startup, with the zero downtime reload. The redis process is shut down in its
+// container, which is restarted by the kubelet with the config file while the proxy holds the clients
+// connections. Only one redis is restarted at a time and only when all of them are ready, the replicas
+// first, and the master is failed over before.
+func (r *RedisFailoverHealer) PlanRedisInPlaceRestarts(ctx context.Context, masterIP string, rf *redisfailoverv1.RedisFailover) ([]PodAction, error) {
+	key := rfKey(rf)
+	desired := rf.RestartRequiredRedisConfig()
+	if !rf.ZeroDowntimeReloadEnabled() || len(desired) == 0 {
+		r.inPlaceRestarts.Clear(key)
+		return nil, nil
+	}
+
+	ssp, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
+	}
+	if pod, ok := r.inPlaceRestarts.Pending(key, ssp.Items); ok {
+		r.logger.Debugf("Waiting for redis %s to be ready after its in place restart", pod)
+		return nil, nil
+	}
+
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return nil, err
+	}
+
+	port := getRedisPort(rf.Spec.Redis.Port)
+	stale := []v1.Pod{}
+	for _, pod := range ssp.Items {
+		if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil { // Only work with running pods
+			continue
+		}
+		if ready, _ := redisContainerStatus(pod); !ready {
+			return nil, nil
+		}
+		changed, err := r.restartRequiredConfigChanged(rf.PreferredPodIP(pod.Status), port, password, desired)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			stale = append(stale, pod)
+		}
+	}
+	if len(stale) == 0 {
+		return nil, nil
+	}
+
+	// The replicas are restarted first, the master last.
+	sort.SliceStable(stale, func(i, j int) bool {
+		iMaster, jMaster := rf.PreferredPodIP(stale[i].Status) == masterIP, rf.PreferredPodIP(stale[j].Status) == masterIP
+		if iMaster != jMaster {
+			return jMaster
+		}
+		return stale[i].Name < stale[j].Name
+	})
+	pod := stale[0]
+	master := rf.PreferredPodIP(pod.Status) == masterIP
+
+	// The kubelet syncs the ConfigMap in the volume after a while, a redis restarted before would
+	// start with the old config.
+	mounted, err := r.k8sService.ExecPod(ctx, rf.Namespace, pod.Name, RedisContainerName, []string{"sh", "-c", "cat /redis/*.conf"})
+	if err != nil {
+		return nil, err
+	}
+	if !configContains(mounted, desired) {
+		r.logger.Debugf("Waiting for the config of redis %s to be synced before restarting it in place", pod.Name)
+		return nil, nil
+	}
+
+	_, restarts := redisContainerStatus(pod)
+	return []PodAction{{
+		Pod:    pod.Name,
+		Kind:   PodActionRestart,
+		Reason: fmt.Sprintf("restart redis in place to apply %s", strings.Join(desired, ", ")),
+		Apply: func() error {
+			r.logger.Infof("Restarting redis %s in place...", pod.Name)
+			if _, err := r.k8sService.ExecPod(ctx, rf.Namespace, pod.Name, RedisContainerName, inPlaceRestartCommand(rf, master)); err != nil {
+				return err
+			}
+			r.inPlaceRestarts.Start(key, pod.Name, restarts)
+			return nil
+		},
+	}}, nil
+}
+
+// restartRequiredConfigChanged returns true when the running value of a config line differs from it.
+func (r *RedisFailoverHealer) restartRequiredConfigChanged(ip, port, password string, lines []string) (bool, error) {
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		running, err := r.redisClient.GetRedisConfig(ip, port, password, fields[0])
+		if err != nil {
+			return false, err
+		}
+		if !strings.EqualFold(running, strings.Trim(strings.Join(fields[1:], " "), `"`)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// configContains returns true when the redis config file has all the lines, ignoring the case of the
+// directives and the spacing.
+func configContains(config string, lines []string) bool {
+	present := map[string]bool{}
+	for _, line := range strings.Split(config, "\n") {
+		present[normalizeConfigLine(line)] = true
+	}
+	for _, line := range lines {
+		if !present[normalizeConfigLine(line)] {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeConfigLine(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	fields[0] = strings.ToLower(fields[0])
+	return strings.Join(fields, " ")
+}
+
+// redisContainerStatus returns if the redis container of the pod is ready and its restart count.
+func redisContainerStatus(pod v1.Pod) (bool, int32) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == RedisContainerName {
+			return status.Ready, status.RestartCount
+		}
+	}
+	return false, 0
+}
+
+// inPlaceRestartCommand returns the command shutting down the redis process of a pod without saving,
+// its container is restarted by the kubelet. The master is failed over and saved first by the shutdown
+// script of the pod. The shutdown runs in the background so the exec returns before the container
+// is stopped.
+func inPlaceRestartCommand(rf *redisfailoverv1.RedisFailover, master bool) []string {
+	failover := ""
+	if master {
+		failover = "/bin/sh /redis-shutdown/shutdown.sh\n"
+	}
+	script := fmt.Sprintf(`(
+%[1]v%[2]vcmd="redis-cli -s %[3]v"
+if [ ! -z "${REDIS_PASSWORD}" ]; then
+    cmd="${cmd} --no-auth-warning -a \"${REDIS_PASSWORD}\""
+fi
+eval "${cmd} shutdown nosave"
+) >/dev/null 2>&1 &`, getPasswordFileScript(rf), failover, redisSocketPath)
+	return []string{"/bin/sh", "-c", script}
+}
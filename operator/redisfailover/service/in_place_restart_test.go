@@ -0,0 +1,195 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func redisPod(name, ip string, ready bool, restarts int32) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.PodStatus{
+			PodIP: ip,
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "redis", Ready: ready, RestartCount: restarts},
+			},
+		},
+	}
+}
+
+func TestPlanRedisInPlaceRestarts(t *testing.T) {
+	tests := []struct {
+		name        string
+		pods        []corev1.Pod
+		running     map[string]string
+		mounted     string
+		expPod      string
+		expFailover bool
+	}{
+		{
+			name: "Up to date",
+			pods: []corev1.Pod{
+				redisPod("rfr-test-0", "0.0.0.0", true, 0),
+				redisPod("rfr-test-1", "1.1.1.1", true, 0),
+			},
+			running: map[string]string{"0.0.0.0": "4", "1.1.1.1": "4"},
+		},
+		{
+			name: "A redis not ready holds the restarts",
+			pods: []corev1.Pod{
+				redisPod("rfr-test-0", "0.0.0.0", true, 0),
+				redisPod("rfr-test-1", "1.1.1.1", false, 0),
+			},
+			running: map[string]string{"0.0.0.0": "1"},
+		},
+		{
+			name: "The replicas are restarted first",
+			pods: []corev1.Pod{
+				redisPod("rfr-test-0", "0.0.0.0", true, 0),
+				redisPod("rfr-test-1", "1.1.1.1", true, 0),
+			},
+			running: map[string]string{"0.0.0.0": "1", "1.1.1.1": "1"},
+			mounted: "port 0\nio-threads 4\n",
+			expPod:  "rfr-test-1",
+		},
+		{
+			name: "The master is failed over before its restart",
+			pods: []corev1.Pod{
+				redisPod("rfr-test-0", "0.0.0.0", true, 0),
+				redisPod("rfr-test-1", "1.1.1.1", true, 0),
+			},
+			running:     map[string]string{"0.0.0.0": "1", "1.1.1.1": "4"},
+			mounted:     "port 0\nio-threads 4\n",
+			expPod:      "rfr-test-0",
+			expFailover: true,
+		},
+		{
+			name: "The mounted config is not synced yet",
+			pods: []corev1.Pod{
+				redisPod("rfr-test-0", "0.0.0.0", true, 0),
+				redisPod("rfr-test-1", "1.1.1.1", true, 0),
+			},
+			running: map[string]string{"0.0.0.0": "4", "1.1.1.1": "1"},
+			mounted: "port 0\nio-threads 1\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.ZeroDowntimeReload.Enabled = true
+			rf.Spec.Redis.CustomConfig = []string{"maxclients 100", "io-threads 4"}
+
+			ms := &mK8SService.Services{}
+			ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(&corev1.PodList{Items: test.pods}, nil)
+			mr := &mRedisService.Client{}
+			for ip, value := range test.running {
+				mr.On("GetRedisConfig", ip, "0", "", "io-threads").Return(value, nil)
+			}
+			if test.mounted != "" {
+				ms.On("ExecPod", mock.Anything, namespace, mock.Anything, "redis", []string{"sh", "-c", "cat /redis/*.conf"}).Once().Return(test.mounted, nil)
+			}
+			var command []string
+			if test.expPod != "" {
+				ms.On("ExecPod", mock.Anything, namespace, test.expPod, "redis", mock.MatchedBy(func(c []string) bool { return c[0] == "/bin/sh" })).Once().Run(func(args mock.Arguments) {
+					command = args.Get(4).([]string)
+				}).Return("", nil)
+			}
+
+			healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+			actions, err := healer.PlanRedisInPlaceRestarts(context.TODO(), "0.0.0.0", rf)
+			assert.NoError(err)
+
+			if test.expPod == "" {
+				assert.Empty(actions)
+				return
+			}
+			if assert.Len(actions, 1) {
+				assert.Equal(test.expPod, actions[0].Pod)
+				assert.Equal(rfservice.PodActionRestart, actions[0].Kind)
+				assert.Equal("restart redis in place to apply io-threads 4", actions[0].Reason)
+			}
+			assert.NoError(applyPodActions(actions))
+			if assert.Len(command, 3) {
+				assert.Contains(command[2], "redis-cli -s /redis-socket/redis.sock")
+				assert.Contains(command[2], "shutdown nosave")
+				assert.Equal(test.expFailover, strings.Contains(command[2], "/redis-shutdown/shutdown.sh"))
+			}
+			ms.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPlanRedisInPlaceRestartsWaitsForTheRestartedRedis(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.ZeroDowntimeReload.Enabled = true
+	rf.Spec.Redis.CustomConfig = []string{"io-threads 4"}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(&corev1.PodList{Items: []corev1.Pod{
+		redisPod("rfr-test-0", "0.0.0.0", true, 0),
+		redisPod("rfr-test-1", "1.1.1.1", true, 0),
+	}}, nil)
+	ms.On("ExecPod", mock.Anything, namespace, "rfr-test-1", "redis", mock.Anything).Twice().Return("io-threads 4\n", nil)
+	mr := &mRedisService.Client{}
+	mr.On("GetRedisConfig", "0.0.0.0", "0", "", "io-threads").Once().Return("1", nil)
+	mr.On("GetRedisConfig", "1.1.1.1", "0", "", "io-threads").Once().Return("1", nil)
+
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+	actions, err := healer.PlanRedisInPlaceRestarts(context.TODO(), "0.0.0.0", rf)
+	assert.NoError(err)
+	assert.NoError(applyPodActions(actions))
+
+	// The container of the replica has not restarted yet, nothing is planned.
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(&corev1.PodList{Items: []corev1.Pod{
+		redisPod("rfr-test-0", "0.0.0.0", true, 0),
+		redisPod("rfr-test-1", "1.1.1.1", true, 0),
+	}}, nil)
+	actions, err = healer.PlanRedisInPlaceRestarts(context.TODO(), "0.0.0.0", rf)
+	assert.NoError(err)
+	assert.Empty(actions)
+	mr.AssertExpectations(t)
+}
+
+func TestInPlaceRestartTracker(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	tracker := rfservice.NewInPlaceRestartTracker(func() time.Time { return now })
+	tracker.Start("test", "rfr-test-1", 2)
+
+	pending, ok := tracker.Pending("test", []corev1.Pod{redisPod("rfr-test-1", "1.1.1.1", true, 2)})
+	assert.True(ok)
+	assert.Equal("rfr-test-1", pending)
+
+	// Restarted but not ready yet.
+	_, ok = tracker.Pending("test", []corev1.Pod{redisPod("rfr-test-1", "1.1.1.1", false, 3)})
+	assert.True(ok)
+
+	// Restarted and ready, the restart is done and forgotten.
+	_, ok = tracker.Pending("test", []corev1.Pod{redisPod("rfr-test-1", "1.1.1.1", true, 3)})
+	assert.False(ok)
+
+	// A redis not back after the timeout is forgotten.
+	tracker.Start("test", "rfr-test-1", 3)
+	now = now.Add(3 * time.Minute)
+	_, ok = tracker.Pending("test", []corev1.Pod{redisPod("rfr-test-1", "1.1.1.1", false, 3)})
+	assert.False(ok)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+)
+
+// redisInstanceConfigEnv holds the name of the pod in the redis container, the file of its configuration
+// is named after it.
+const redisInstanceConfigEnv = "POD_NAME"
+
+// InstanceOverrideLabelsAnnotation holds the keys of the labels a redis pod got from its override, the
+// ones removed from the override since are removed from the pod.
+const InstanceOverrideLabelsAnnotation = "databases.spotahome.com/instance-override-labels"
+
+// getRedisPodName returns the name of the redis pod of the ordinal.
+func getRedisPodName(rf *redisfailoverv1.RedisFailover, ordinal int) string {
+	return fmt.Sprintf("%s-%d", GetRedisStatefulSetName(rf), ordinal)
+}
+
+// getRedisPodOrdinal returns the ordinal of the redis pod, false when the name is not one of a pod of
+// the redis statefulset.
+func getRedisPodOrdinal(rf *redisfailoverv1.RedisFailover, podName string) (int, bool) {
+	prefix := GetRedisStatefulSetName(rf) + "-"
+	if !strings.HasPrefix(podName, prefix) {
+		return 0, false
+	}
+	ordinal, err := strconv.Atoi(strings.TrimPrefix(podName, prefix))
+	if err != nil || ordinal < 0 {
+		return 0, false
+	}
+	return ordinal, true
+}
+
+// redisPodPromotable returns false when the pod has a replicaPriority of 0 in the instance overrides.
+func redisPodPromotable(rf *redisfailoverv1.RedisFailover, podName string) bool {
+	ordinal, ok := getRedisPodOrdinal(rf, podName)
+	return !ok || rf.RedisInstancePromotable(ordinal)
+}
+
+// RedisPodCounted returns false when the redis pod is left out of the healthy replicas, it has a
+// replicaPriority of 0 in the instance overrides and redis.countNonPromotable is not set.
+func RedisPodCounted(rf *redisfailoverv1.RedisFailover, podName string) bool {
+	ordinal, ok := getRedisPodOrdinal(rf, podName)
+	return !ok || rf.RedisInstanceCounted(ordinal)
+}
+
+// redisInstanceConfigEnabled returns true when the redis pods include the file of their own
+// configuration. A custom command reads its configuration itself.
+func redisInstanceConfigEnabled(rf *redisfailoverv1.RedisFailover) bool {
+	return len(rf.Spec.Redis.InstanceOverrides) > 0 && len(rf.Spec.Redis.Command) == 0
+}
+
+// redisInstanceConfigFileName returns the file of the configuration of the redis pod in the redis
+// ConfigMap.
+func redisInstanceConfigFileName(podName string) string {
+	return podName + ".conf"
+}
+
+// renderRedisInstanceConfig returns the config lines of the override of the redis pod of the ordinal,
+// its custom config then its replica-priority. It's empty for the pods without override.
+func renderRedisInstanceConfig(rf *redisfailoverv1.RedisFailover, ordinal int) []string {
+	override, ok := rf.RedisInstanceOverride(ordinal)
+	if !ok {
+		return nil
+	}
+	lines := append([]string{}, override.CustomConfig...)
+	if override.ReplicaPriority != nil {
+		lines = append(lines, fmt.Sprintf("replica-priority %d", *override.ReplicaPriority))
+	}
+	return lines
+}
+
+// renderRedisInstanceConfigs returns the configuration files of the redis pods by file name. Every pod
+// of the statefulset gets one, empty without override, as they all include the file named after them.
+func renderRedisInstanceConfigs(rf *redisfailoverv1.RedisFailover) map[string]string {
+	files := map[string]string{}
+	if !redisInstanceConfigEnabled(rf) {
+		return files
+	}
+	for ordinal := 0; ordinal < int(rf.Spec.Redis.Replicas); ordinal++ {
+		content := ""
+		if lines := renderRedisInstanceConfig(rf, ordinal); len(lines) > 0 {
+			content = strings.Join(lines, "\n") + "\n"
+		}
+		files[redisInstanceConfigFileName(getRedisPodName(rf, ordinal))] = content
+	}
+	return files
+}
+
+// addRedisInstanceConfig makes redis include the file of the configuration of its pod, after the
+// config file so the overrides win.
+func addRedisInstanceConfig(ss *appsv1.StatefulSet) {
+	redis := &ss.Spec.Template.Spec.Containers[0]
+	redis.Env = append(redis.Env, v1.EnvVar{
+		Name: redisInstanceConfigEnv,
+		ValueFrom: &v1.EnvVarSource{
+			FieldRef: &v1.ObjectFieldSelector{
+				FieldPath: "metadata.name",
+			},
+		},
+	})
+	redis.Args = append(redis.Args, "--include", fmt.Sprintf("/redis/%s", redisInstanceConfigFileName(fmt.Sprintf("$(%s)", redisInstanceConfigEnv))))
+}
+
+// applyRedisInstanceOverride sets the labels of the override of the pod on it, and resizes its redis
+// container to the resources of the override. The clusters not resizing the pods in place keep the
+// resources of the statefulset, it's logged.
+func (r *RedisFailoverHealer) applyRedisInstanceOverride(ctx context.Context, rf *redisfailoverv1.RedisFailover, pod v1.Pod) error {
+	ordinal, ok := getRedisPodOrdinal(rf, pod.Name)
+	if !ok {
+		return nil
+	}
+	// The pods without override may still have the labels of a removed one.
+	override, _ := rf.RedisInstanceOverride(ordinal)
+	if err := r.applyRedisInstanceLabels(ctx, rf, pod, override.Labels); err != nil {
+		return err
+	}
+
+	if override.Resources == nil {
+		return nil
+	}
+	current := v1.ResourceRequirements{}
+	for _, c := range pod.Spec.Containers {
+		if c.Name == RedisContainerName {
+			current = c.Resources
+		}
+	}
+	resources := redisInstanceResources(current, *override.Resources)
+	if equality.Semantic.DeepEqual(current, resources) {
+		return nil
+	}
+	err := r.k8sService.ResizePod(ctx, rf.Namespace, pod.Name, RedisContainerName, resources)
+	if errors.IsNotFound(err) || errors.IsInvalid(err) || errors.IsBadRequest(err) || errors.IsMethodNotSupported(err) {
+		r.logger.Warnf("The redis pod %s can't be resized in place to the resources of its override: %v", pod.Name, err)
+		return nil
+	}
+	return err
+}
+
+// applyRedisInstanceLabels adds the labels of the override to the pod, and removes the ones it got from
+// its override before that are no longer in it. The labels set by others are left alone.
+func (r *RedisFailoverHealer) applyRedisInstanceLabels(ctx context.Context, rf *redisfailoverv1.RedisFailover, pod v1.Pod, labels map[string]string) error {
+	updated := pod.DeepCopy()
+	if applied := pod.Annotations[InstanceOverrideLabelsAnnotation]; applied != "" {
+		for _, key := range strings.Split(applied, ",") {
+			if _, ok := labels[key]; !ok {
+				delete(updated.Labels, key)
+			}
+		}
+	}
+	updated.Labels = util.MergeLabels(updated.Labels, labels)
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		updated.Annotations = util.MergeLabels(updated.Annotations, map[string]string{InstanceOverrideLabelsAnnotation: strings.Join(keys, ",")})
+	} else {
+		delete(updated.Annotations, InstanceOverrideLabelsAnnotation)
+	}
+
+	if equality.Semantic.DeepEqual(pod.Labels, updated.Labels) && equality.Semantic.DeepEqual(pod.Annotations, updated.Annotations) {
+		return nil
+	}
+	return r.k8sService.UpdatePod(ctx, rf.Namespace, updated)
+}
+
+// redisInstanceResources returns the resources of the redis container with the requests and limits of
+// the override replacing them by resource.
+func redisInstanceResources(current, override v1.ResourceRequirements) v1.ResourceRequirements {
+	resources := *current.DeepCopy()
+	if len(override.Requests) > 0 && resources.Requests == nil {
+		resources.Requests = v1.ResourceList{}
+	}
+	for name, quantity := range override.Requests {
+		resources.Requests[name] = quantity
+	}
+	if len(override.Limits) > 0 && resources.Limits == nil {
+		resources.Limits = v1.ResourceList{}
+	}
+	for name, quantity := range override.Limits {
+		resources.Limits[name] = quantity
+	}
+	return resources
+}
@@ -0,0 +1,324 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// generateAnalyticsRF returns a RF whose last redis pod serves analytics, it's never promoted.
+func generateAnalyticsRF() *redisfailoverv1.RedisFailover {
+	zero := int32(0)
+	rf := generateRF()
+	rf.Spec.Redis.CustomConfig = []string{"maxclients 100"}
+	rf.Spec.Redis.InstanceOverrides = map[string]redisfailoverv1.RedisInstanceOverride{
+		"2": {
+			ReplicaPriority: &zero,
+			CustomConfig:    []string{"appendonly no"},
+			Labels:          map[string]string{"workload": "analytics"},
+		},
+	}
+	return rf
+}
+
+func TestRedisInstanceConfigs(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  []string
+		expFiles map[string]string
+		expArgs  []string
+	}{
+		{
+			name: "Every pod includes its own file",
+			expFiles: map[string]string{
+				"rfr-test-0.conf": "",
+				"rfr-test-1.conf": "",
+				"rfr-test-2.conf": "appendonly no\nreplica-priority 0\n",
+			},
+			expArgs: []string{"--include", "/redis/$(POD_NAME).conf"},
+		},
+		{
+			name:     "The custom command reads its configuration itself",
+			command:  []string{"redis-server", "/redis/redis.conf"},
+			expFiles: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rf := generateAnalyticsRF()
+			rf.Spec.Redis.Command = test.command
+			require.NoError(rf.Validate())
+
+			state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+			require.NoError(err)
+			var cm *corev1.ConfigMap
+			var ss *appsv1.StatefulSet
+			for _, o := range state.Objects {
+				switch {
+				case o.Kind == rfservice.KindConfigMap && o.Name == rfservice.GetRedisName(rf):
+					cm = o.Object.(*corev1.ConfigMap)
+				case o.Kind == rfservice.KindStatefulSet:
+					ss = o.Object.(*appsv1.StatefulSet)
+				}
+			}
+			require.NotNil(cm)
+			require.NotNil(ss)
+
+			files := map[string]string{}
+			for key, content := range cm.Data {
+				if key != "redis.conf" {
+					files[key] = content
+				}
+			}
+			assert.Equal(test.expFiles, files)
+
+			redis := ss.Spec.Template.Spec.Containers[0]
+			assert.Equal(test.expArgs, redis.Args)
+			podName := corev1.EnvVar{
+				Name:      "POD_NAME",
+				ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+			}
+			if test.expArgs != nil {
+				assert.Contains(redis.Env, podName)
+			} else {
+				assert.NotContains(redis.Env, podName)
+			}
+		})
+	}
+}
+
+func TestRedisPodDisruptionBudgetNonPromotable(t *testing.T) {
+	tests := []struct {
+		name               string
+		countNonPromotable bool
+		expMinAvailable    intstr.IntOrString
+		expExpressions     []metav1.LabelSelectorRequirement
+	}{
+		{
+			name:            "The pods never promoted are left out",
+			expMinAvailable: intstr.FromInt(1),
+			expExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "statefulset.kubernetes.io/pod-name",
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{"rfr-test-2"},
+			}},
+		},
+		{
+			name:               "The pods never promoted are counted",
+			countNonPromotable: true,
+			expMinAvailable:    intstr.FromInt(2),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rf := generateAnalyticsRF()
+			rf.Spec.Redis.CountNonPromotable = test.countNonPromotable
+			require.NoError(rf.Validate())
+
+			state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+			require.NoError(err)
+			var pdb *policyv1.PodDisruptionBudget
+			for _, o := range state.Objects {
+				if o.Kind == rfservice.KindPodDisruptionBudget && o.Name == rfservice.GetRedisName(rf) {
+					pdb = o.Object.(*policyv1.PodDisruptionBudget)
+				}
+			}
+			require.NotNil(pdb)
+
+			assert.Equal(test.expMinAvailable, *pdb.Spec.MinAvailable)
+			assert.Equal(test.expExpressions, pdb.Spec.Selector.MatchExpressions)
+		})
+	}
+}
+
+func TestSetOldestAsMasterSkipsNonPromotable(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateAnalyticsRF()
+
+	now := time.Now()
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0", CreationTimestamp: metav1.NewTime(now)},
+				Status:     corev1.PodStatus{PodIP: "0.0.0.0"},
+			},
+			{
+				// The oldest pod is never promoted.
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-2", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+				Status:     corev1.PodStatus{PodIP: "2.2.2.2"},
+			},
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("UpdatePodLabels", mock.Anything, namespace, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	mr := &mRedisService.Client{}
+	mr.On("MakeMaster", "0.0.0.0", "0", "").Once().Return(nil)
+	mr.On("MakeSlaveOfWithPort", "2.2.2.2", "0.0.0.0", "0", "").Once().Return(nil)
+
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+	assert.NoError(healer.SetOldestAsMaster(context.TODO(), rf))
+	mr.AssertExpectations(t)
+}
+
+func TestMakeMasterNonPromotable(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateAnalyticsRF()
+
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-2"},
+				Status:     corev1.PodStatus{PodIP: "2.2.2.2"},
+			},
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	mr := &mRedisService.Client{}
+
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+	assert.EqualError(healer.MakeMaster(context.TODO(), "2.2.2.2", rf), "redis rfr-test-2 can't be promoted, it has a replicaPriority of 0 in redis.instanceOverrides")
+	mr.AssertNotCalled(t, "MakeMaster", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPlanRedisCustomConfigInstanceOverride(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateAnalyticsRF()
+	override := rf.Spec.Redis.InstanceOverrides["2"]
+	override.Resources = &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+	rf.Spec.Redis.InstanceOverrides["2"] = override
+
+	redis := corev1.Container{
+		Name: rfservice.RedisContainerName,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		},
+	}
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0", Labels: map[string]string{"app": "redis"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{redis}},
+				Status:     corev1.PodStatus{PodIP: "0.0.0.0", Phase: corev1.PodRunning},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-2", Labels: map[string]string{"app": "redis"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{redis}},
+				Status:     corev1.PodStatus{PodIP: "2.2.2.2", Phase: corev1.PodRunning},
+			},
+		},
+	}
+	resized := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("UpdatePod", mock.Anything, namespace, mock.MatchedBy(func(pod *corev1.Pod) bool {
+		return pod.Name == "rfr-test-2" && pod.Labels["app"] == "redis" && pod.Labels["workload"] == "analytics" &&
+			pod.Annotations[rfservice.InstanceOverrideLabelsAnnotation] == "workload"
+	})).Once().Return(nil)
+	// The cluster doesn't resize the pods in place, the override is still applied.
+	ms.On("ResizePod", mock.Anything, namespace, "rfr-test-2", rfservice.RedisContainerName, resized).Once().Return(kubeerrors.NewNotFound(corev1.Resource("pods/resize"), "rfr-test-2"))
+	mr := &mRedisService.Client{}
+	mr.On("SetCustomRedisConfig", "0.0.0.0", "0", []string{"maxclients 100"}, "").Once().Return(nil)
+	mr.On("SetCustomRedisConfig", "2.2.2.2", "0", []string{"maxclients 100", "appendonly no", "replica-priority 0"}, "").Once().Return(nil)
+
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+	actions, err := healer.PlanRedisCustomConfig(context.TODO(), rf)
+	assert.NoError(err)
+	assert.Len(actions, 2)
+	assert.NoError(applyPodActions(actions))
+	ms.AssertExpectations(t)
+	mr.AssertExpectations(t)
+}
+
+func TestPlanRedisCustomConfigRemovedOverrideLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateAnalyticsRF()
+	rf.Spec.Redis.CustomConfig = nil
+
+	labeled := func(name, ip string, labels map[string]string, applied string) corev1.Pod {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Status:     corev1.PodStatus{PodIP: ip, Phase: corev1.PodRunning},
+		}
+		if applied != "" {
+			pod.Annotations = map[string]string{rfservice.InstanceOverrideLabelsAnnotation: applied, "custom": "annotation"}
+		}
+		return pod
+	}
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			// Never overridden, its labels are left alone.
+			labeled("rfr-test-0", "0.0.0.0", map[string]string{"app": "redis", "tier": "cache"}, ""),
+			// Its override was removed.
+			labeled("rfr-test-1", "1.1.1.1", map[string]string{"app": "redis", "workload": "batch"}, "workload"),
+			// The tier label was removed from its override.
+			labeled("rfr-test-2", "2.2.2.2", map[string]string{"app": "redis", "workload": "analytics", "tier": "cold"}, "tier,workload"),
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(pods, nil)
+	ms.On("UpdatePod", mock.Anything, namespace, mock.MatchedBy(func(pod *corev1.Pod) bool {
+		return pod.Name == "rfr-test-1" && assert.Equal(map[string]string{"app": "redis"}, pod.Labels) &&
+			assert.Equal(map[string]string{"custom": "annotation"}, pod.Annotations)
+	})).Once().Return(nil)
+	ms.On("UpdatePod", mock.Anything, namespace, mock.MatchedBy(func(pod *corev1.Pod) bool {
+		return pod.Name == "rfr-test-2" && assert.Equal(map[string]string{"app": "redis", "workload": "analytics"}, pod.Labels) &&
+			assert.Equal(map[string]string{rfservice.InstanceOverrideLabelsAnnotation: "workload", "custom": "annotation"}, pod.Annotations)
+	})).Once().Return(nil)
+	mr := &mRedisService.Client{}
+	mr.On("SetCustomRedisConfig", mock.Anything, "0", mock.Anything, "").Return(nil)
+
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+	actions, err := healer.PlanRedisCustomConfig(context.TODO(), rf)
+	assert.NoError(err)
+	assert.NoError(applyPodActions(actions))
+	ms.AssertExpectations(t)
+}
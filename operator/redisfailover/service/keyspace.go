@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/service/k8s"
+)
+
+// keyspaceLineRE matches a database of the keyspace section of the INFO, as db0:keys=10,expires=0,avg_ttl=0.
+var keyspaceLineRE = regexp.MustCompile(`(?m)^(db[0-9]+):keys=([0-9]+)`)
+
+// RedisKeyspace is the keyspace of a redis pod, from the INFO the operator reads on every check.
+type RedisKeyspace struct {
+	Pod string
+	// Master is true when the pod has the master role.
+	Master bool
+	// RunID identifies the redis process, it changes on every restart.
+	RunID string
+	// Keys are the keys of every database holding any.
+	Keys map[string]int64
+}
+
+// TotalKeys returns the keys of all the databases.
+func (k RedisKeyspace) TotalKeys() int64 {
+	var total int64
+	for _, keys := range k.Keys {
+		total += keys
+	}
+	return total
+}
+
+// GetRedisKeyspaces returns the keyspace of every running redis pod, sorted by pod. A pod not answering
+// is left out, its failure is already reported by the other checks. The key of the functional probe is
+// not counted.
+func (r *RedisFailoverChecker) GetRedisKeyspaces(ctx context.Context, rf *redisfailoverv1.RedisFailover) ([]RedisKeyspace, error) {
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
+	}
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return nil, err
+	}
+
+	rport := getRedisPort(rf.Spec.Redis.Port)
+	keyspaces := []RedisKeyspace{}
+	for _, rp := range rps.Items {
+		if rp.Status.Phase != corev1.PodRunning || rp.DeletionTimestamp != nil || rp.Status.PodIP == "" {
+			continue
+		}
+		info, err := r.redisClient.GetRedisInfo(rf.PreferredPodIP(rp.Status), rport, password)
+		if err != nil {
+			r.logger.WithField("namespace", rf.Namespace).WithField("pod", rp.Name).Debugf("could not read the keyspace of redis: %s", err)
+			continue
+		}
+		role, _ := getInfoField(info, "role")
+		runID, _ := getInfoField(info, "run_id")
+		keys := parseKeyspace(info)
+		r.excludeFunctionalProbeKey(rf, rf.PreferredPodIP(rp.Status), rport, password, keys)
+		keyspaces = append(keyspaces, RedisKeyspace{
+			Pod:    rp.Name,
+			Master: role == "master",
+			RunID:  runID,
+			Keys:   keys,
+		})
+	}
+	sort.Slice(keyspaces, func(i, j int) bool {
+		return keyspaces[i].Pod < keyspaces[j].Pod
+	})
+	return keyspaces, nil
+}
+
+// parseKeyspace returns the keys of every database of the keyspace section of the INFO. Redis leaves the
+// empty databases out of it.
+func parseKeyspace(info string) map[string]int64 {
+	keys := map[string]int64{}
+	for _, match := range keyspaceLineRE.FindAllStringSubmatch(info, -1) {
+		n, err := strconv.ParseInt(match[2], 10, 64)
+		if err == nil {
+			keys[match[1]] = n
+		}
+	}
+	return keys
+}
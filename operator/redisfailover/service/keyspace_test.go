@@ -0,0 +1,51 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestGetRedisKeyspaces(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			generateRedisPod("rfr-test-1", "10.0.0.2", corev1.PodRunning),
+			generateRedisPod("rfr-test-0", "10.0.0.1", corev1.PodRunning),
+			// Not answering, it's left out.
+			generateRedisPod("rfr-test-2", "10.0.0.3", corev1.PodRunning),
+			// Not running, it's not asked.
+			generateRedisPod("rfr-test-3", "", corev1.PodPending),
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Return(pods, nil)
+	mr := &mRedisService.Client{}
+	mr.On("GetRedisInfo", "10.0.0.1", "0", "").Once().Return("# Server\r\nrun_id:abc\r\n# Replication\r\nrole:master\r\n# Keyspace\r\ndb0:keys=120,expires=3,avg_ttl=0\r\ndb3:keys=5,expires=0,avg_ttl=0\r\n", nil)
+	mr.On("GetRedisInfo", "10.0.0.2", "0", "").Once().Return("# Server\r\nrun_id:def\r\n# Replication\r\nrole:slave\r\n# Keyspace\r\n", nil)
+	mr.On("GetRedisInfo", "10.0.0.3", "0", "").Once().Return("", errors.New("wanted error"))
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+	keyspaces, err := checker.GetRedisKeyspaces(context.TODO(), rf)
+	assert.NoError(err)
+	assert.Equal([]rfservice.RedisKeyspace{
+		{Pod: "rfr-test-0", Master: true, RunID: "abc", Keys: map[string]int64{"db0": 120, "db3": 5}},
+		{Pod: "rfr-test-1", RunID: "def", Keys: map[string]int64{}},
+	}, keyspaces)
+	assert.Equal(int64(125), keyspaces[0].TotalKeys())
+	assert.Zero(keyspaces[1].TotalKeys())
+	mr.AssertExpectations(t)
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/service/k8s"
+)
+
+// redisLatencySamples is the number of round-trips kept by redis pod to compute the jitter.
+const redisLatencySamples = 10
+
+// RedisLatency is the round-trip of the PING the operator sends to a redis pod on every check.
+type RedisLatency struct {
+	Pod string
+	// Last is the round-trip of the last PING.
+	Last time.Duration
+	// Mean is the mean of the round-trips kept.
+	Mean time.Duration
+	// Jitter is the mean difference between the consecutive round-trips kept.
+	Jitter time.Duration
+	// Samples is the number of round-trips kept, the history is full at redisLatencySamples.
+	Samples int
+}
+
+// Sustained returns true when the history is full and its mean reaches the threshold, a single slow
+// PING doesn't make the latency high.
+func (l RedisLatency) Sustained(threshold time.Duration) bool {
+	return threshold > 0 && l.Samples >= redisLatencySamples && l.Mean >= threshold
+}
+
+// redisLatencyHistory keeps the last round-trips of the redis pods, by RF and pod.
+type redisLatencyHistory struct {
+	mu      sync.Mutex
+	samples map[string]map[string][]time.Duration
+}
+
+// observe adds the round-trips of a check to the history of the RF and returns the latency of every
+// pod, sorted by pod. The pods not answering are kept, the ones gone are forgotten.
+func (h *redisLatencyHistory) observe(key string, rtts map[string]time.Duration, pods []string) []RedisLatency {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	known := h.samples[key]
+	samples := make(map[string][]time.Duration, len(pods))
+	for _, pod := range pods {
+		history := known[pod]
+		if rtt, ok := rtts[pod]; ok {
+			history = append(history, rtt)
+			if len(history) > redisLatencySamples {
+				history = history[len(history)-redisLatencySamples:]
+			}
+		}
+		if len(history) > 0 {
+			samples[pod] = history
+		}
+	}
+	if len(samples) == 0 {
+		delete(h.samples, key)
+		return nil
+	}
+	h.samples[key] = samples
+
+	latencies := make([]RedisLatency, 0, len(samples))
+	for pod, history := range samples {
+		latencies = append(latencies, newRedisLatency(pod, history))
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i].Pod < latencies[j].Pod
+	})
+	return latencies
+}
+
+// clear forgets the round-trips of the RF.
+func (h *redisLatencyHistory) clear(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.samples, key)
+}
+
+func newRedisLatency(pod string, history []time.Duration) RedisLatency {
+	var sum, diffs time.Duration
+	for i, rtt := range history {
+		sum += rtt
+		if i > 0 {
+			diff := rtt - history[i-1]
+			if diff < 0 {
+				diff = -diff
+			}
+			diffs += diff
+		}
+	}
+	latency := RedisLatency{
+		Pod:     pod,
+		Last:    history[len(history)-1],
+		Mean:    sum / time.Duration(len(history)),
+		Samples: len(history),
+	}
+	if len(history) > 1 {
+		latency.Jitter = diffs / time.Duration(len(history)-1)
+	}
+	return latency
+}
+
+// MeasureRedisLatency times a PING to every running redis pod and returns the latency of the pods,
+// sorted by pod. The round-trips are exposed as metrics. A pod not answering keeps its previous
+// round-trips, its failure is already reported by the other checks.
+func (r *RedisFailoverChecker) MeasureRedisLatency(ctx context.Context, rf *redisfailoverv1.RedisFailover) ([]RedisLatency, error) {
+	key := rfKey(rf)
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
+	}
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return nil, err
+	}
+
+	rport := getRedisPort(rf.Spec.Redis.Port)
+	pods := []string{}
+	rtts := map[string]time.Duration{}
+	for _, rp := range rps.Items {
+		if rp.Status.Phase != corev1.PodRunning || rp.DeletionTimestamp != nil || rp.Status.PodIP == "" {
+			continue
+		}
+		pods = append(pods, rp.Name)
+		start := time.Now()
+		if err := r.redisClient.PingRedis(rf.PreferredPodIP(rp.Status), rport, password); err != nil {
+			r.logger.WithField("namespace", rf.Namespace).WithField("pod", rp.Name).Debugf("could not time the PING to redis: %s", err)
+			continue
+		}
+		rtts[rp.Name] = time.Since(start)
+	}
+
+	latencies := r.redisLatency.observe(key, rtts, pods)
+	r.metricsClient.ResetRedisLatency(rf.Namespace, rf.Name)
+	for _, l := range latencies {
+		r.metricsClient.SetRedisLatency(rf.Namespace, rf.Name, l.Pod, l.Last.Seconds(), l.Jitter.Seconds())
+	}
+	return latencies, nil
+}
+
+// ForgetRedisLatency forgets the round-trips of the redis pods of the RF and removes their metrics.
+func (r *RedisFailoverChecker) ForgetRedisLatency(rf *redisfailoverv1.RedisFailover) {
+	r.redisLatency.clear(rfKey(rf))
+	r.metricsClient.ResetRedisLatency(rf.Namespace, rf.Name)
+}
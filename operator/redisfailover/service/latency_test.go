@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func generateRedisPod(name, ip string, phase corev1.PodPhase) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Status: corev1.PodStatus{
+			PodIP: ip,
+			Phase: phase,
+		},
+	}
+}
+
+func TestMeasureRedisLatency(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	delay := 20 * time.Millisecond
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			generateRedisPod("rfr-test-0", "10.0.0.1", corev1.PodRunning),
+			generateRedisPod("rfr-test-1", "10.0.0.2", corev1.PodRunning),
+			// Not running, it's not pinged.
+			generateRedisPod("rfr-test-2", "", corev1.PodPending),
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Return(pods, nil)
+	mr := &mRedisService.Client{}
+	mr.On("PingRedis", "10.0.0.1", "0", "").After(delay).Return(nil)
+	mr.On("PingRedis", "10.0.0.2", "0", "").Once().Return(errors.New("wanted error"))
+	mr.On("PingRedis", "10.0.0.2", "0", "").Return(nil)
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	// The pod not answering has no round-trip yet.
+	latencies, err := checker.MeasureRedisLatency(context.TODO(), rf)
+	assert.NoError(err)
+	if assert.Len(latencies, 1) {
+		assert.Equal("rfr-test-0", latencies[0].Pod)
+		assert.Equal(1, latencies[0].Samples)
+		assert.True(latencies[0].Last >= delay)
+		assert.Zero(latencies[0].Jitter)
+		assert.False(latencies[0].Sustained(delay))
+	}
+
+	// The history is capped, and only the pod slow over the whole history is sustained.
+	for i := 0; i < 12; i++ {
+		latencies, err = checker.MeasureRedisLatency(context.TODO(), rf)
+		assert.NoError(err)
+	}
+	if assert.Len(latencies, 2) {
+		assert.Equal("rfr-test-0", latencies[0].Pod)
+		assert.Equal(10, latencies[0].Samples)
+		assert.True(latencies[0].Mean >= delay)
+		assert.True(latencies[0].Sustained(delay))
+		assert.False(latencies[0].Sustained(0))
+		assert.Equal("rfr-test-1", latencies[1].Pod)
+		assert.Equal(10, latencies[1].Samples)
+		assert.False(latencies[1].Sustained(delay))
+	}
+
+	// The pods gone are forgotten.
+	pods.Items = pods.Items[1:]
+	latencies, err = checker.MeasureRedisLatency(context.TODO(), rf)
+	assert.NoError(err)
+	if assert.Len(latencies, 1) {
+		assert.Equal("rfr-test-1", latencies[0].Pod)
+	}
+
+	checker.ForgetRedisLatency(rf)
+	pods.Items = nil
+	latencies, err = checker.MeasureRedisLatency(context.TODO(), rf)
+	assert.NoError(err)
+	assert.Empty(latencies)
+}
+
+func TestMeasureRedisLatencyError(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New("wanted error"))
+	mr := &mRedisService.Client{}
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	_, err := checker.MeasureRedisLatency(context.TODO(), rf)
+	assert.Error(err)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	utilexec "k8s.io/client-go/util/exec"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// masterProbeTimeout is how long the master is waited for on every probe path.
+const masterProbeTimeout = 3 * time.Second
+
+// CorroborateMasterDown checks the master is down from other points of view than the operator one,
+// before the operator promotes a new master. When some sentinels answer, a majority of them must see
+// their master down. When none answer, the last master seen by the operator must be unreachable both
+// connecting to it from the operator and pinging it from another redis pod. It returns if the master
+// is down and the reason.
+func (r *RedisFailoverChecker) CorroborateMasterDown(ctx context.Context, lastMaster string, rf *redisfailoverv1.RedisFailover) (bool, string, error) {
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return false, "", err
+	}
+	// The pod names of the running redis, by IP.
+	running := map[string]string{}
+	for _, rp := range rps.Items {
+		if rp.Status.Phase == corev1.PodRunning && rp.DeletionTimestamp == nil && rp.Status.PodIP != "" {
+			running[rf.PreferredPodIP(rp.Status)] = rp.Name
+		}
+	}
+
+	if rf.SentinelsAllowed() {
+		sentinels, err := r.GetSentinelsIPs(ctx, rf)
+		if err != nil {
+			return false, "", err
+		}
+		// The sentinels monitor the address announced by the master.
+		addresses, err := r.GetRedisAddresses(ctx, rf)
+		if err != nil {
+			return false, "", err
+		}
+		reachable, down := 0, 0
+		for _, sip := range sentinels {
+			masterIP, masterPort, err := r.redisClient.GetSentinelMonitor(sip)
+			if err != nil {
+				continue
+			}
+			if _, ok := running[addresses.PodIP(masterIP)]; !ok {
+				// The master of the sentinel is not a running redis, it can't be up.
+				reachable++
+				down++
+				continue
+			}
+			isDown, err := r.redisClient.IsMasterDownByAddr(sip, masterIP, masterPort)
+			if err != nil {
+				continue
+			}
+			reachable++
+			if isDown {
+				down++
+			}
+		}
+		if reachable > 0 {
+			if down*2 > reachable {
+				return true, fmt.Sprintf("%d of the %d reachable sentinels see the master down", down, reachable), nil
+			}
+			return false, fmt.Sprintf("only %d of the %d reachable sentinels see the master down", down, reachable), nil
+		}
+	}
+
+	if lastMaster == "" {
+		return true, "no sentinel is reachable and there is no previous master", nil
+	}
+	if _, ok := running[lastMaster]; !ok {
+		return true, fmt.Sprintf("no sentinel is reachable and the master %s is not a running redis pod", lastMaster), nil
+	}
+	port := getRedisPort(rf.Spec.Redis.Port)
+	if masterAcceptsConnections(lastMaster, port) {
+		return false, fmt.Sprintf("no sentinel is reachable and the master %s accepts connections from the operator", lastMaster), nil
+	}
+
+	// The master is pinged from the first other redis pod by name.
+	probes := []string{}
+	for ip, name := range running {
+		if ip != lastMaster {
+			probes = append(probes, name)
+		}
+	}
+	if len(probes) == 0 {
+		return false, fmt.Sprintf("no sentinel is reachable and there is no other redis pod to ping the master %s from", lastMaster), nil
+	}
+	sort.Strings(probes)
+	answers, err := r.pingFromPod(ctx, rf.Namespace, probes[0], lastMaster, port)
+	if err != nil {
+		return false, fmt.Sprintf("no sentinel is reachable and the master %s could not be pinged from the pod %s: %s", lastMaster, probes[0], err), nil
+	}
+	if answers {
+		return false, fmt.Sprintf("no sentinel is reachable and the master %s answers the pings of the pod %s", lastMaster, probes[0]), nil
+	}
+	return true, fmt.Sprintf("no sentinel is reachable and the master %s is unreachable from the operator and from the pod %s", lastMaster, probes[0]), nil
+}
+
+// masterAcceptsConnections returns true when a TCP connection to the master can be opened.
+func masterAcceptsConnections(ip, port string) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, port), masterProbeTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// pingFromPod pings the master with redis-cli from the redis container of the pod. A master asking
+// for a password answers too. The error is only returned when the command could not be run.
+func (r *RedisFailoverChecker) pingFromPod(ctx context.Context, namespace, pod, ip, port string) (bool, error) {
+	timeout := strconv.Itoa(int(masterProbeTimeout.Seconds()))
+	out, err := r.k8sService.ExecPod(ctx, namespace, pod, RedisContainerName, []string{"timeout", timeout, "redis-cli", "-h", ip, "-p", port, "ping"})
+	var exitErr utilexec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return false, err
+	}
+	return strings.Contains(out, "PONG") || strings.Contains(out, "NOAUTH"), nil
+}
@@ -0,0 +1,220 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilexec "k8s.io/client-go/util/exec"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// sentinelAnswer is what a sentinel answers about its master.
+type sentinelAnswer struct {
+	unreachable bool
+	master      string
+	down        bool
+	downErr     bool
+}
+
+func runningPods(ips map[string]string) *corev1.PodList {
+	pods := &corev1.PodList{}
+	for name, ip := range ips {
+		pods.Items = append(pods.Items, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+		})
+	}
+	return pods
+}
+
+func TestCorroborateMasterDown(t *testing.T) {
+	master := "127.0.0.1"
+	replica := "10.0.0.2"
+
+	// A port the master listens on, and one nothing listens on.
+	listener, err := net.Listen("tcp", net.JoinHostPort(master, "0"))
+	require.NoError(t, err)
+	defer listener.Close()
+	upPort := int32(listener.Addr().(*net.TCPAddr).Port)
+	closed, err := net.Listen("tcp", net.JoinHostPort(master, "0"))
+	require.NoError(t, err)
+	downPort := int32(closed.Addr().(*net.TCPAddr).Port)
+	closed.Close()
+
+	tests := []struct {
+		name         string
+		sentinels    []sentinelAnswer
+		lastMaster   string
+		masterUp     bool
+		noReplica    bool
+		expExec      bool
+		execOut      string
+		execErr      error
+		expConfirmed bool
+	}{
+		{
+			name: "A majority of the sentinels see the master down",
+			sentinels: []sentinelAnswer{
+				{master: master, down: true},
+				{master: master, down: true},
+				{master: master},
+			},
+			expConfirmed: true,
+		},
+		{
+			name: "A minority of the sentinels see the master down",
+			sentinels: []sentinelAnswer{
+				{master: master, down: true},
+				{master: master},
+				{master: master},
+			},
+			expConfirmed: false,
+		},
+		{
+			name: "Only the reachable sentinels are counted",
+			sentinels: []sentinelAnswer{
+				{master: master, down: true},
+				{unreachable: true},
+				{master: master, downErr: true},
+			},
+			expConfirmed: true,
+		},
+		{
+			name: "Half of the reachable sentinels is not a majority",
+			sentinels: []sentinelAnswer{
+				{master: master, down: true},
+				{master: master},
+				{unreachable: true},
+			},
+			expConfirmed: false,
+		},
+		{
+			name: "A sentinel monitoring a master that is not a running redis sees it down",
+			sentinels: []sentinelAnswer{
+				{master: "10.0.0.9"},
+				{master: "10.0.0.9"},
+			},
+			expConfirmed: true,
+		},
+		{
+			name:         "No sentinel reachable and no previous master",
+			sentinels:    []sentinelAnswer{{unreachable: true}},
+			expConfirmed: true,
+		},
+		{
+			name:         "No sentinel reachable and the previous master is not a running redis",
+			sentinels:    []sentinelAnswer{{unreachable: true}},
+			lastMaster:   "10.0.0.9",
+			expConfirmed: true,
+		},
+		{
+			name:         "No sentinel reachable and the master accepts connections from the operator",
+			lastMaster:   master,
+			masterUp:     true,
+			expConfirmed: false,
+		},
+		{
+			name:         "No sentinel reachable and the master unreachable from both paths",
+			lastMaster:   master,
+			expExec:      true,
+			execOut:      "Could not connect to Redis at 127.0.0.1:6379: Connection refused",
+			execErr:      utilexec.CodeExitError{Err: errors.New("command terminated with exit code 1"), Code: 1},
+			expConfirmed: true,
+		},
+		{
+			name:         "No sentinel reachable and the master answers the pings of a replica",
+			lastMaster:   master,
+			expExec:      true,
+			execOut:      "PONG\n",
+			expConfirmed: false,
+		},
+		{
+			name:         "No sentinel reachable and the master asks a replica for a password",
+			lastMaster:   master,
+			expExec:      true,
+			execOut:      "NOAUTH Authentication required.\n",
+			expConfirmed: false,
+		},
+		{
+			name:         "No sentinel reachable and the master can't be pinged from a replica",
+			lastMaster:   master,
+			expExec:      true,
+			execErr:      errors.New("pods \"rfr-test-1\" is forbidden"),
+			expConfirmed: false,
+		},
+		{
+			name:         "No sentinel reachable and no replica to ping the master from",
+			lastMaster:   master,
+			noReplica:    true,
+			expConfirmed: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.Port = downPort
+			if test.masterUp {
+				rf.Spec.Redis.Port = upPort
+			}
+			port := strconv.Itoa(int(rf.Spec.Redis.Port))
+
+			redises := map[string]string{"rfr-test-0": master, "rfr-test-1": replica}
+			if test.noReplica {
+				redises = map[string]string{"rfr-test-0": master}
+			}
+			sentinels := map[string]string{}
+			ms := &mK8SService.Services{}
+			mr := &mRedisService.Client{}
+			for i, answer := range test.sentinels {
+				sip := "10.0.1." + strconv.Itoa(i)
+				sentinels["rfs-test-"+strconv.Itoa(i)] = sip
+				if answer.unreachable {
+					mr.On("GetSentinelMonitor", sip).Once().Return("", "", errors.New(""))
+					continue
+				}
+				mr.On("GetSentinelMonitor", sip).Once().Return(answer.master, port, nil)
+				if answer.master != master {
+					continue
+				}
+				if answer.downErr {
+					mr.On("IsMasterDownByAddr", sip, answer.master, port).Once().Return(false, errors.New(""))
+				} else {
+					mr.On("IsMasterDownByAddr", sip, answer.master, port).Once().Return(answer.down, nil)
+				}
+			}
+			ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(runningPods(redises), nil)
+			ms.On("GetDeploymentPods", mock.Anything, namespace, rfservice.GetSentinelName(rf)).Once().Return(runningPods(sentinels), nil)
+			if test.expExec {
+				ms.On("ExecPod", mock.Anything, namespace, "rfr-test-1", "redis", mock.Anything).Once().Return(test.execOut, test.execErr)
+			}
+
+			checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+			confirmed, reason, err := checker.CorroborateMasterDown(context.TODO(), test.lastMaster, rf)
+
+			assert.NoError(err)
+			assert.Equal(test.expConfirmed, confirmed, reason)
+			assert.NotEmpty(reason)
+			ms.AssertExpectations(t)
+			mr.AssertExpectations(t)
+			if !test.expExec {
+				ms.AssertNotCalled(t, "ExecPod", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -16,24 +16,68 @@ func GetRedisShutdownConfigMapName(rf *redisfailoverv1.RedisFailover) string {
 
 // GetRedisName returns the name for redis resources
 func GetRedisName(rf *redisfailoverv1.RedisFailover) string {
-	return generateName(redisName, rf.Name)
+	return generateName(redisName, rf)
+}
+
+// GetRedisStatefulSetName returns the name for the redis statefulset, the adopted one if any
+func GetRedisStatefulSetName(rf *redisfailoverv1.RedisFailover) string {
+	if adopted := rf.AdoptedStatefulSet(); adopted != "" {
+		return adopted
+	}
+	return GetRedisName(rf)
+}
+
+// GetRedisConfigPartName returns the name for the ConfigMap holding a part of the redis configuration
+func GetRedisConfigPartName(rf *redisfailoverv1.RedisFailover, part int) string {
+	return fmt.Sprintf("%s-part-%d", GetRedisName(rf), part)
+}
+
+// GetRedisMasterName returns the name for the redis master resources
+func GetRedisMasterName(rf *redisfailoverv1.RedisFailover) string {
+	return generateName(redisMasterName, rf)
 }
 
 // GetRedisShutdownName returns the name for redis resources
 func GetRedisShutdownName(rf *redisfailoverv1.RedisFailover) string {
-	return generateName(redisShutdownName, rf.Name)
+	return generateName(redisShutdownName, rf)
 }
 
 // GetRedisReadinessName returns the name for redis resources
 func GetRedisReadinessName(rf *redisfailoverv1.RedisFailover) string {
-	return generateName(redisReadinessName, rf.Name)
+	return generateName(redisReadinessName, rf)
+}
+
+// GetRedisTLSName returns the name for the certificate of the redis pods
+func GetRedisTLSName(rf *redisfailoverv1.RedisFailover) string {
+	return generateName(redisTLSName, rf)
+}
+
+// GetRedisTLSSidecarName returns the name for the stunnel configuration of the TLS sidecar
+func GetRedisTLSSidecarName(rf *redisfailoverv1.RedisFailover) string {
+	return generateName(redisTLSSidecarName, rf)
 }
 
 // GetSentinelName returns the name for sentinel resources
 func GetSentinelName(rf *redisfailoverv1.RedisFailover) string {
-	return generateName(sentinelName, rf.Name)
+	return generateName(sentinelName, rf)
+}
+
+// GetSupportBundleName returns the name for the ConfigMap holding the support bundle
+func GetSupportBundleName(rf *redisfailoverv1.RedisFailover) string {
+	return generateName(supportBundleName, rf)
+}
+
+// GetDiagnosisName returns the name for the ConfigMap holding the reports of the redis doctors
+func GetDiagnosisName(rf *redisfailoverv1.RedisFailover) string {
+	return generateName(diagnosisName, rf)
+}
+
+// generateName returns the name of a generated object, with the name prefix pinned on the RF.
+func generateName(typeName string, rf *redisfailoverv1.RedisFailover) string {
+	return rf.GeneratedName(typeName)
 }
 
-func generateName(typeName, metaName string) string {
-	return fmt.Sprintf("%s%s-%s", baseName, typeName, metaName)
+// rfKey returns the key of the RF on the state the operator keeps for every RF.
+func rfKey(rf *redisfailoverv1.RedisFailover) string {
+	return fmt.Sprintf("%s/%s", rf.Namespace, rf.Name)
 }
@@ -0,0 +1,61 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestNamesWithPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		rfName string
+	}{
+		{
+			name:   "No prefix",
+			rfName: "test",
+		},
+		{
+			name:   "A prefix",
+			prefix: "team-a-",
+			rfName: "test",
+		},
+		{
+			name:   "A prefix and a name at the maximum length",
+			prefix: "team-a-",
+			rfName: strings.Repeat("a", 48-len("team-a-")),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Name = test.rfName
+			rf.Annotations = map[string]string{redisfailoverv1.NamePrefixAnnotation: test.prefix}
+			assert.NoError(rf.Validate())
+
+			names := []string{
+				rfservice.GetRedisName(rf),
+				rfservice.GetRedisMasterName(rf),
+				rfservice.GetRedisShutdownName(rf),
+				rfservice.GetRedisReadinessName(rf),
+				rfservice.GetSentinelName(rf),
+				rfservice.GetSupportBundleName(rf),
+			}
+			for _, n := range names {
+				assert.True(strings.HasPrefix(n, test.prefix+"rf"), n)
+				assert.True(strings.HasSuffix(n, "-"+test.rfName), n)
+				// The names are used as label values, limited to 63 characters.
+				assert.LessOrEqual(len(n), 63, n)
+			}
+			assert.Equal(test.prefix+"rfr-"+test.rfName, rfservice.GetRedisName(rf))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+)
+
+// generateRedisNetworkPolicy returns the NetworkPolicy of the redis pods of the RF. The redis port is
+// only reached by the redis and the sentinel pods of the RF, selected by the labels the operator sets on
+// them, by the allowed clients and by the pods of the allowed namespaces of the spec, and so is the TLS
+// port when it's enabled. The exporter port stays open to the scrapers.
+func generateRedisNetworkPolicy(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *networkingv1.NetworkPolicy {
+	var exporter *intstr.IntOrString
+	if rf.Spec.Redis.Exporter.Enabled {
+		port := intstr.FromInt(exporterPort)
+		exporter = &port
+	}
+	ports := []intstr.IntOrString{intstr.FromInt(int(rf.Spec.Redis.Port))}
+	if rf.TLSEnabled() {
+		ports = append(ports, intstr.FromInt(redisfailoverv1.RedisTLSPort))
+	}
+	return generateNetworkPolicy(rf, GetRedisName(rf), redisRoleName, ports, exporter, labels, ownerRefs)
+}
+
+// generateSentinelNetworkPolicy returns the NetworkPolicy of the sentinel pods of the RF, reached by the
+// same peers as the redis pods on the sentinel port.
+func generateSentinelNetworkPolicy(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *networkingv1.NetworkPolicy {
+	var exporter *intstr.IntOrString
+	if rf.Spec.Sentinel.Exporter.Enabled {
+		port := intstr.FromInt(sentinelExporterPort)
+		exporter = &port
+	}
+	return generateNetworkPolicy(rf, GetSentinelName(rf), sentinelRoleName, []intstr.IntOrString{intstr.FromInt(26379)}, exporter, labels, ownerRefs)
+}
+
+// generateNetworkPolicy returns the NetworkPolicy of the pods of a component of the RF, the ports are
+// reached by the pods of the RF and the allowed peers, the exporter port, when it's given, by all.
+func generateNetworkPolicy(rf *redisfailoverv1.RedisFailover, name, component string, ports []intstr.IntOrString, exporter *intstr.IntOrString, labels map[string]string, ownerRefs []metav1.OwnerReference) *networkingv1.NetworkPolicy {
+	selectorLabels := generateSelectorLabels(component, rf.Name)
+	labels = util.MergeLabels(labels, selectorLabels)
+
+	tcp := corev1.ProtocolTCP
+	from := []networkingv1.NetworkPolicyPeer{
+		{PodSelector: &metav1.LabelSelector{MatchLabels: generateSelectorLabels(redisRoleName, rf.Name)}},
+		{PodSelector: &metav1.LabelSelector{MatchLabels: generateSelectorLabels(sentinelRoleName, rf.Name)}},
+	}
+	for _, client := range rf.Spec.NetworkPolicy.AllowedClients {
+		from = append(from, *client.DeepCopy())
+	}
+	if namespaces := rf.Spec.NetworkPolicy.AllowedNamespaces; namespaces != nil {
+		from = append(from, networkingv1.NetworkPolicyPeer{NamespaceSelector: namespaces.DeepCopy()})
+	}
+	policyPorts := []networkingv1.NetworkPolicyPort{}
+	for i := range ports {
+		policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: &ports[i]})
+	}
+	ingress := []networkingv1.NetworkPolicyIngressRule{
+		{
+			Ports: policyPorts,
+			From:  from,
+		},
+	}
+	if exporter != nil {
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: exporter}},
+		})
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: generateObjectMeta(name, rf.Namespace, labels, nil, ownerRefs),
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: selectorLabels},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     ingress,
+		},
+	}
+}
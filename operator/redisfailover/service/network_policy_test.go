@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestRedisNetworkPolicy(t *testing.T) {
+	clients := []networkingv1.NetworkPolicyPeer{
+		{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+		{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "redis-operator"}}},
+	}
+	redisPods := networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
+		"app.kubernetes.io/component": "redis",
+		"app.kubernetes.io/name":      name,
+		"app.kubernetes.io/part-of":   "redis-failover",
+	}}}
+	sentinelPods := networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
+		"app.kubernetes.io/component": "sentinel",
+		"app.kubernetes.io/name":      name,
+		"app.kubernetes.io/part-of":   "redis-failover",
+	}}}
+
+	namespaces := networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "cache"}}}
+
+	tests := []struct {
+		name        string
+		exporter    bool
+		namespaces  bool
+		expPorts    []int
+		expSentinel []int
+		expFrom     []networkingv1.NetworkPolicyPeer
+	}{
+		{
+			name:        "Only the pods of the RF and the clients reach the redis and the sentinel ports",
+			expPorts:    []int{12345},
+			expSentinel: []int{26379},
+			expFrom:     []networkingv1.NetworkPolicyPeer{redisPods, sentinelPods, clients[0], clients[1]},
+		},
+		{
+			name:        "The exporter ports are open to all",
+			exporter:    true,
+			expPorts:    []int{12345, 9121},
+			expSentinel: []int{26379, 9355},
+			expFrom:     []networkingv1.NetworkPolicyPeer{redisPods, sentinelPods, clients[0], clients[1]},
+		},
+		{
+			name:        "The pods of the allowed namespaces reach the redis and the sentinel ports",
+			namespaces:  true,
+			expPorts:    []int{12345},
+			expSentinel: []int{26379},
+			expFrom:     []networkingv1.NetworkPolicyPeer{redisPods, sentinelPods, clients[0], clients[1], namespaces},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.Port = 12345
+			rf.Spec.Redis.Exporter.Enabled = test.exporter
+			rf.Spec.Sentinel.Exporter.Enabled = test.exporter
+			rf.Spec.NetworkPolicy = &redisfailoverv1.NetworkPolicySettings{AllowedClients: clients}
+			if test.namespaces {
+				rf.Spec.NetworkPolicy.AllowedNamespaces = namespaces.NamespaceSelector
+			}
+
+			state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+			require.NoError(err)
+			nps := map[string]*networkingv1.NetworkPolicy{}
+			for _, o := range state.Objects {
+				if o.Kind == rfservice.KindNetworkPolicy {
+					nps[o.Name] = o.Object.(*networkingv1.NetworkPolicy)
+				}
+			}
+			require.Len(nps, 2)
+
+			for name, expPorts := range map[string][]int{"rfr-test": test.expPorts, "rfs-test": test.expSentinel} {
+				np := nps[name]
+				require.NotNil(np, name)
+				assert.Equal([]networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, np.Spec.PolicyTypes)
+				require.Len(np.Spec.Ingress, len(expPorts))
+				for i, port := range expPorts {
+					assert.Equal(intstr.FromInt(port), *np.Spec.Ingress[i].Ports[0].Port)
+				}
+				assert.Equal(test.expFrom, np.Spec.Ingress[0].From)
+				if test.exporter {
+					assert.Empty(np.Spec.Ingress[1].From)
+				}
+			}
+			assert.Equal(redisPods.PodSelector.MatchLabels, nps["rfr-test"].Spec.PodSelector.MatchLabels)
+			assert.Equal(sentinelPods.PodSelector.MatchLabels, nps["rfs-test"].Spec.PodSelector.MatchLabels)
+		})
+	}
+}
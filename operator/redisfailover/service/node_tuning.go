@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// Kernel settings of the nodes redis warns about on startup.
+const (
+	NodeTuningOvercommitMemory     = "vm.overcommit_memory"
+	NodeTuningTransparentHugePages = "transparent_hugepage"
+)
+
+// startupLogBytes is the beginning of the redis log read to find the startup warnings.
+const startupLogBytes = int64(64 * 1024)
+
+// nodeTuningWarnings are the startup warnings of the supported redis versions for every kernel setting.
+var nodeTuningWarnings = []struct {
+	setting string
+	re      *regexp.Regexp
+}{
+	{
+		setting: NodeTuningOvercommitMemory,
+		re:      regexp.MustCompile(`(?i)WARNING:? (?:overcommit_memory is set to 0|memory overcommit must be enabled)`),
+	},
+	{
+		setting: NodeTuningTransparentHugePages,
+		re:      regexp.MustCompile(`(?i)WARNING:? you have Transparent Huge Pages \(THP\) support enabled`),
+	},
+}
+
+// DetectNodeTuningWarnings returns the kernel settings redis warned about on its startup log.
+func DetectNodeTuningWarnings(logs string) []string {
+	settings := []string{}
+	for _, w := range nodeTuningWarnings {
+		if w.re.MatchString(logs) {
+			settings = append(settings, w.setting)
+		}
+	}
+	return settings
+}
+
+// startupWarningsCache keeps the startup warnings of the redis containers, by RF and container ID. The
+// startup log of a container doesn't change, so it's only read once.
+type startupWarningsCache struct {
+	mu       sync.Mutex
+	warnings map[string]map[string][]string
+}
+
+// GetNodeTuningWarnings returns, by kernel setting, the nodes whose redis warned about it on startup.
+// The pods whose logs can't be read are skipped, they are retried on the next check.
+func (r *RedisFailoverChecker) GetNodeTuningWarnings(ctx context.Context, rf *redisfailoverv1.RedisFailover) (map[string][]string, error) {
+	pods, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return nil, err
+	}
+
+	key := rfKey(rf)
+	r.startupWarnings.mu.Lock()
+	cached := r.startupWarnings.warnings[key]
+	r.startupWarnings.mu.Unlock()
+
+	current := map[string][]string{}
+	nodes := map[string]map[string]bool{}
+	for _, pod := range pods.Items {
+		containerID := getContainerID(pod, RedisContainerName)
+		if containerID == "" || pod.Spec.NodeName == "" {
+			continue
+		}
+		settings, ok := cached[containerID]
+		if !ok {
+			logs, err := r.k8sService.GetPodStartupLogs(ctx, rf.Namespace, pod.Name, RedisContainerName, startupLogBytes)
+			if err != nil {
+				r.logger.WithField("namespace", rf.Namespace).WithField("pod", pod.Name).Debugf("could not read the redis startup log: %s", err)
+				continue
+			}
+			settings = DetectNodeTuningWarnings(logs)
+		}
+		current[containerID] = settings
+		for _, setting := range settings {
+			if nodes[setting] == nil {
+				nodes[setting] = map[string]bool{}
+			}
+			nodes[setting][pod.Spec.NodeName] = true
+		}
+	}
+
+	// Only the current containers are kept, the restarted or deleted ones are forgotten.
+	r.startupWarnings.mu.Lock()
+	r.startupWarnings.warnings[key] = current
+	r.startupWarnings.mu.Unlock()
+
+	warnings := map[string][]string{}
+	for setting, settingNodes := range nodes {
+		for node := range settingNodes {
+			warnings[setting] = append(warnings[setting], node)
+		}
+		sort.Strings(warnings[setting])
+	}
+	return warnings, nil
+}
+
+// ForgetNodeTuningWarnings forgets the startup warnings read from the redis containers of the RF.
+func (r *RedisFailoverChecker) ForgetNodeTuningWarnings(rf *redisfailoverv1.RedisFailover) {
+	r.startupWarnings.mu.Lock()
+	defer r.startupWarnings.mu.Unlock()
+	delete(r.startupWarnings.warnings, rfKey(rf))
+}
+
+func getContainerID(pod corev1.Pod, name string) string {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == name {
+			return cs.ContainerID
+		}
+	}
+	return ""
+}
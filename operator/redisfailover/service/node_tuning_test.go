@@ -0,0 +1,162 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestDetectNodeTuningWarnings(t *testing.T) {
+	tests := []struct {
+		name        string
+		fixture     string
+		expSettings []string
+	}{
+		{
+			name:        "Redis 6.2 warns about overcommit and THP",
+			fixture:     "redis-6.2-startup.log",
+			expSettings: []string{rfservice.NodeTuningOvercommitMemory, rfservice.NodeTuningTransparentHugePages},
+		},
+		{
+			name:        "Redis 7.0 warns about overcommit with its new message",
+			fixture:     "redis-7.0-startup.log",
+			expSettings: []string{rfservice.NodeTuningOvercommitMemory},
+		},
+		{
+			name:        "Tuned nodes have no warnings",
+			fixture:     "redis-7.0-tuned-startup.log",
+			expSettings: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			logs, err := os.ReadFile(filepath.Join("testdata", test.fixture))
+			assert.NoError(err)
+
+			assert.Equal(test.expSettings, rfservice.DetectNodeTuningWarnings(string(logs)))
+		})
+	}
+}
+
+func generatePodOnNode(name, node, containerID string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "redis", ContainerID: containerID},
+			},
+		},
+	}
+}
+
+func TestGetNodeTuningWarnings(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	oldRedis, err := os.ReadFile(filepath.Join("testdata", "redis-6.2-startup.log"))
+	assert.NoError(err)
+	newRedis, err := os.ReadFile(filepath.Join("testdata", "redis-7.0-startup.log"))
+	assert.NoError(err)
+
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{
+			generatePodOnNode("rfr-test-0", "node-b", "containerd://0"),
+			generatePodOnNode("rfr-test-1", "node-a", "containerd://1"),
+			generatePodOnNode("rfr-test-2", "node-c", "containerd://2"),
+			// Not started yet.
+			generatePodOnNode("rfr-test-3", "node-d", ""),
+		},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Twice().Return(pods, nil)
+	// The startup logs are only read once for every container.
+	ms.On("GetPodStartupLogs", mock.Anything, namespace, "rfr-test-0", "redis", mock.Anything).Once().Return(string(oldRedis), nil)
+	ms.On("GetPodStartupLogs", mock.Anything, namespace, "rfr-test-1", "redis", mock.Anything).Once().Return(string(newRedis), nil)
+	// The logs that can't be read are retried on the next check.
+	ms.On("GetPodStartupLogs", mock.Anything, namespace, "rfr-test-2", "redis", mock.Anything).Once().Return("", errors.New("wanted error"))
+	ms.On("GetPodStartupLogs", mock.Anything, namespace, "rfr-test-2", "redis", mock.Anything).Once().Return(string(newRedis), nil)
+	mr := &mRedisService.Client{}
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	warnings, err := checker.GetNodeTuningWarnings(context.TODO(), rf)
+	assert.NoError(err)
+	assert.Equal(map[string][]string{
+		rfservice.NodeTuningOvercommitMemory:     {"node-a", "node-b"},
+		rfservice.NodeTuningTransparentHugePages: {"node-b"},
+	}, warnings)
+
+	warnings, err = checker.GetNodeTuningWarnings(context.TODO(), rf)
+	assert.NoError(err)
+	assert.Equal(map[string][]string{
+		rfservice.NodeTuningOvercommitMemory:     {"node-a", "node-b", "node-c"},
+		rfservice.NodeTuningTransparentHugePages: {"node-b"},
+	}, warnings)
+
+	ms.AssertExpectations(t)
+}
+
+func TestGetNodeTuningWarningsError(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(nil, errors.New("wanted error"))
+	mr := &mRedisService.Client{}
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	_, err := checker.GetNodeTuningWarnings(context.TODO(), rf)
+	assert.Error(err)
+}
+
+func TestForgetNodeTuningWarnings(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	pods := &corev1.PodList{
+		Items: []corev1.Pod{generatePodOnNode("rfr-test-0", "node-a", "containerd://0")},
+	}
+
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Return(pods, nil)
+	// The startup log is read again once the warnings of the RF are forgotten.
+	ms.On("GetPodStartupLogs", mock.Anything, namespace, "rfr-test-0", "redis", mock.Anything).Twice().Return("", nil)
+	mr := &mRedisService.Client{}
+
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	_, err := checker.GetNodeTuningWarnings(context.TODO(), rf)
+	assert.NoError(err)
+	_, err = checker.GetNodeTuningWarnings(context.TODO(), rf)
+	assert.NoError(err)
+	checker.ForgetNodeTuningWarnings(rf)
+	_, err = checker.GetNodeTuningWarnings(context.TODO(), rf)
+	assert.NoError(err)
+
+	ms.AssertExpectations(t)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+)
+
+// PasswordRevisionAnnotation is written on the pod template of the redis statefulset of the RFs with
+// auth.generateSecret with the hash of the password. The containers read the password from their env on
+// start, the pods are rolled to read a rotated one.
+const PasswordRevisionAnnotation = "redisfailovers.databases.spotahome.com/password-revision"
+
+// setPasswordRevision annotates the pod template of the redis statefulset with the revision of the
+// generated password.
+func setPasswordRevision(rf *redisfailoverv1.RedisFailover, ss *appsv1.StatefulSet, password string) {
+	if !rf.Spec.Auth.GenerateSecret || password == "" {
+		return
+	}
+	sum := sha256.Sum256([]byte(password))
+	revision := hex.EncodeToString(sum[:])[:configRevisionLength]
+	ss.Spec.Template.Annotations = util.MergeLabels(ss.Spec.Template.Annotations, map[string]string{PasswordRevisionAnnotation: revision})
+}
+
+// RotatePassword sets the next password on the redis and sentinel pods of the RF, reached with the
+// current one. The order keeps the replication and the sentinels authenticated at every step: masterauth
+// on all the redis pods first, so the replicas reconnecting to a master already requiring the next
+// password authenticate with it, then requirepass on all of them, and the auth-pass of the sentinels
+// last, once the redis pods require it. A redis pod the current password is refused by is reached with
+// the next one, it was set by a rotation interrupted before the secret was updated. Every pod must be
+// running, one starting meanwhile could read the current password from its configuration.
+func (r *RedisFailoverHealer) RotatePassword(ctx context.Context, rf *redisfailoverv1.RedisFailover, current, next string) error {
+	redisIPs, err := r.runningPodIPs(rf, func() (*v1.PodList, error) {
+		return r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	}, rf.Spec.Redis.Replicas)
+	if err != nil {
+		return err
+	}
+	sentinelIPs := []string{}
+	if rf.SentinelsAllowed() {
+		// The autoscaled sentinels don't run the replicas of the spec.
+		replicas := rf.Spec.Sentinel.Replicas
+		if rf.SentinelAutoScalingEnabled() {
+			replicas = 0
+		}
+		sentinelIPs, err = r.runningPodIPs(rf, func() (*v1.PodList, error) {
+			return r.k8sService.GetDeploymentPods(ctx, rf.Namespace, GetSentinelName(rf))
+		}, replicas)
+		if err != nil {
+			return err
+		}
+	}
+
+	port := getRedisPort(rf.Spec.Redis.Port)
+	for _, parameter := range []string{"masterauth", "requirepass"} {
+		for _, ip := range redisIPs {
+			r.logger.Debugf("Setting the next %s on redis %s...", parameter, ip)
+			config := []string{fmt.Sprintf("%s %s", parameter, next)}
+			if err := r.redisClient.SetCustomRedisConfig(ip, port, config, current); err != nil {
+				if retryErr := r.redisClient.SetCustomRedisConfig(ip, port, config, next); retryErr != nil {
+					return fmt.Errorf("setting the %s of redis %s: %w", parameter, ip, err)
+				}
+			}
+		}
+	}
+	for _, ip := range sentinelIPs {
+		r.logger.Debugf("Setting the next auth-pass on sentinel %s...", ip)
+		if err := r.redisClient.SetCustomSentinelConfig(ip, []string{"auth-pass " + next}); err != nil {
+			return fmt.Errorf("setting the auth-pass of sentinel %s: %w", ip, err)
+		}
+	}
+	return nil
+}
+
+// runningPodIPs returns the IPs of the pods of the list, an error unless the replicas are all running.
+func (r *RedisFailoverHealer) runningPodIPs(rf *redisfailoverv1.RedisFailover, list func() (*v1.PodList, error), replicas int32) ([]string, error) {
+	pods, err := list()
+	if err != nil {
+		return nil, err
+	}
+	ips := []string{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil || pod.Status.PodIP == "" {
+			return nil, fmt.Errorf("the pod %s is not running, the password is rotated once it is", pod.Name)
+		}
+		ips = append(ips, rf.PreferredPodIP(pod.Status))
+	}
+	if int32(len(ips)) < replicas {
+		return nil, fmt.Errorf("%d of the %d pods are running, the password is rotated once they all are", len(ips), replicas)
+	}
+	return ips, nil
+}
@@ -0,0 +1,217 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/redis"
+)
+
+// fakePasswordRedis is a fake of the redis and sentinel pods answering the configuration calls of the
+// password rotation. A redis pod refuses the calls with another password than the one it requires. The
+// parameters set are recorded in order, and the calls of failOn fail once they are applied, as the
+// connection lost before the reply of a redis pod going away.
+type fakePasswordRedis struct {
+	redis.Client
+	requirepass map[string]string
+	masterauth  map[string]string
+	authPass    map[string]string
+	calls       []string
+	failOn      string
+}
+
+func newFakePasswordRedis(redisIPs, sentinelIPs []string, password string) *fakePasswordRedis {
+	f := &fakePasswordRedis{requirepass: map[string]string{}, masterauth: map[string]string{}, authPass: map[string]string{}}
+	for _, ip := range redisIPs {
+		f.requirepass[ip] = password
+		f.masterauth[ip] = password
+	}
+	for _, ip := range sentinelIPs {
+		f.authPass[ip] = password
+	}
+	return f
+}
+
+func (f *fakePasswordRedis) SetCustomRedisConfig(ip, port string, configs []string, password string) error {
+	if f.requirepass[ip] != password {
+		return errors.New("WRONGPASS invalid username-password pair or user is disabled")
+	}
+	for _, config := range configs {
+		parameter, value, _ := strings.Cut(config, " ")
+		switch parameter {
+		case "requirepass":
+			f.requirepass[ip] = value
+		case "masterauth":
+			f.masterauth[ip] = value
+		}
+		call := "redis " + ip + " " + parameter
+		f.calls = append(f.calls, call)
+		if call == f.failOn {
+			return errors.New("connection reset by peer")
+		}
+	}
+	return nil
+}
+
+func (f *fakePasswordRedis) SetCustomSentinelConfig(ip string, configs []string) error {
+	for _, config := range configs {
+		parameter, value, _ := strings.Cut(config, " ")
+		f.authPass[ip] = value
+		f.calls = append(f.calls, "sentinel "+ip+" "+parameter)
+	}
+	return nil
+}
+
+func passwordRotationPods(prefix string, ips ...string) *corev1.PodList {
+	pods := &corev1.PodList{}
+	for i, ip := range ips {
+		pods.Items = append(pods.Items, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: prefix + string(rune('0'+i))},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+		})
+	}
+	return pods
+}
+
+func TestRotatePassword(t *testing.T) {
+	redisIPs := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	sentinelIPs := []string{"10.0.1.1", "10.0.1.2", "10.0.1.3"}
+
+	tests := []struct {
+		name     string
+		failOn   string
+		expCalls []string
+	}{
+		{
+			name: "masterauth before requirepass, sentinels last",
+			expCalls: []string{
+				"redis 10.0.0.1 masterauth",
+				"redis 10.0.0.2 masterauth",
+				"redis 10.0.0.3 masterauth",
+				"redis 10.0.0.1 requirepass",
+				"redis 10.0.0.2 requirepass",
+				"redis 10.0.0.3 requirepass",
+				"sentinel 10.0.1.1 auth-pass",
+				"sentinel 10.0.1.2 auth-pass",
+				"sentinel 10.0.1.3 auth-pass",
+			},
+		},
+		{
+			// The redis pod is reached with the next password once it requires it, the rotation run
+			// again reaches the redis pods requiring the next password with it.
+			name:   "interrupted after the requirepass of a redis pod",
+			failOn: "redis 10.0.0.2 requirepass",
+			expCalls: []string{
+				"redis 10.0.0.1 masterauth",
+				"redis 10.0.0.2 masterauth",
+				"redis 10.0.0.3 masterauth",
+				"redis 10.0.0.1 requirepass",
+				"redis 10.0.0.2 requirepass",
+				"redis 10.0.0.2 requirepass",
+				"redis 10.0.0.1 masterauth",
+				"redis 10.0.0.2 masterauth",
+				"redis 10.0.0.3 masterauth",
+				"redis 10.0.0.1 requirepass",
+				"redis 10.0.0.2 requirepass",
+				"redis 10.0.0.3 requirepass",
+				"sentinel 10.0.1.1 auth-pass",
+				"sentinel 10.0.1.2 auth-pass",
+				"sentinel 10.0.1.3 auth-pass",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rf := generateRF()
+			ms := &mK8SService.Services{}
+			ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Return(passwordRotationPods("rfr-test-", redisIPs...), nil)
+			ms.On("GetDeploymentPods", mock.Anything, namespace, rfservice.GetSentinelName(rf)).Return(passwordRotationPods("rfs-test-", sentinelIPs...), nil)
+			fake := newFakePasswordRedis(redisIPs, sentinelIPs, "current")
+			fake.failOn = test.failOn
+			healer := rfservice.NewRedisFailoverHealer(ms, fake, log.DummyLogger{})
+
+			err := healer.RotatePassword(context.TODO(), rf, "current", "next")
+			if test.failOn != "" {
+				require.Error(err)
+				fake.failOn = ""
+				err = healer.RotatePassword(context.TODO(), rf, "current", "next")
+			}
+			require.NoError(err)
+
+			assert.Equal(test.expCalls, fake.calls)
+			for _, ip := range redisIPs {
+				assert.Equal("next", fake.requirepass[ip], ip)
+				assert.Equal("next", fake.masterauth[ip], ip)
+			}
+			for _, ip := range sentinelIPs {
+				assert.Equal("next", fake.authPass[ip], ip)
+			}
+		})
+	}
+}
+
+func TestRotatePasswordWaitsForThePods(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	redisPods := passwordRotationPods("rfr-test-", "10.0.0.1", "10.0.0.2", "10.0.0.3")
+	redisPods.Items[2].Status = corev1.PodStatus{Phase: corev1.PodPending}
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Return(redisPods, nil)
+	fake := newFakePasswordRedis([]string{"10.0.0.1", "10.0.0.2"}, nil, "current")
+	healer := rfservice.NewRedisFailoverHealer(ms, fake, log.DummyLogger{})
+
+	err := healer.RotatePassword(context.TODO(), rf, "current", "next")
+	assert.EqualError(err, "the pod rfr-test-2 is not running, the password is rotated once it is")
+	assert.Empty(fake.calls)
+
+	// A missing pod holds the rotation too.
+	redisPods.Items = redisPods.Items[:2]
+	err = healer.RotatePassword(context.TODO(), rf, "current", "next")
+	assert.EqualError(err, "2 of the 3 pods are running, the password is rotated once they all are")
+	assert.Empty(fake.calls)
+}
+
+func TestBuildDesiredStatePasswordRevision(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	podAnnotations := func(rf *redisfailoverv1.RedisFailover, password string) map[string]string {
+		state, err := rfservice.BuildDesiredState(rf, nil, nil, password)
+		require.NoError(err)
+		for _, o := range state.Objects {
+			if o.Kind == rfservice.KindStatefulSet {
+				return o.Object.(*appsv1.StatefulSet).Spec.Template.Annotations
+			}
+		}
+		return nil
+	}
+
+	rf := generateRF()
+	rf.Spec.Auth.SecretPath = "auth"
+	assert.NotContains(podAnnotations(rf, "current"), rfservice.PasswordRevisionAnnotation)
+
+	// The redis pods of a generated password are rolled when it's rotated.
+	rf.Spec.Auth = redisfailoverv1.AuthSettings{GenerateSecret: true, SecretPath: "rfr-test-auth"}
+	current := podAnnotations(rf, "current")[rfservice.PasswordRevisionAnnotation]
+	assert.Regexp(`^[0-9a-f]{10}$`, current)
+	assert.Equal(current, podAnnotations(rf, "current")[rfservice.PasswordRevisionAnnotation])
+	assert.NotEqual(current, podAnnotations(rf, "next")[rfservice.PasswordRevisionAnnotation])
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+)
+
+// The owner annotations are written on the pods generated for a RF, so the tools keyed off the pod
+// annotations, like the logging and the cost attribution, find the RF of every pod. The exporters run
+// as containers of the redis and sentinel pods, they have the component of their pod.
+const (
+	PodOwnerNameAnnotation      = "redisfailover.redis.io/name"
+	PodOwnerNamespaceAnnotation = "redisfailover.redis.io/namespace"
+	PodOwnerComponentAnnotation = "redisfailover.redis.io/component"
+)
+
+var podOwnerAnnotations = []string{PodOwnerNameAnnotation, PodOwnerNamespaceAnnotation, PodOwnerComponentAnnotation}
+
+// PodOwner is the RF a pod is generated for, and the component of the RF the pod runs.
+type PodOwner struct {
+	Namespace string
+	Name      string
+	Component string
+}
+
+// generatePodAnnotations returns the annotations of the spec with the owner annotations of the pods of
+// the component.
+func generatePodAnnotations(rf *redisfailoverv1.RedisFailover, component string, annotations map[string]string) map[string]string {
+	return util.MergeLabels(annotations, map[string]string{
+		PodOwnerNameAnnotation:      rf.Name,
+		PodOwnerNamespaceAnnotation: rf.Namespace,
+		PodOwnerComponentAnnotation: component,
+	})
+}
+
+// hasPodOwnerAnnotations returns true when the pod template has all the owner annotations.
+func hasPodOwnerAnnotations(template corev1.PodTemplateSpec) bool {
+	for _, a := range podOwnerAnnotations {
+		if _, ok := template.Annotations[a]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// removePodOwnerAnnotations removes the owner annotations from the pod template, leaving the
+// annotations nil when there is no other, as they were generated before them.
+func removePodOwnerAnnotations(template *corev1.PodTemplateSpec) {
+	if len(template.Annotations) == 0 {
+		return
+	}
+	annotations := make(map[string]string, len(template.Annotations))
+	for k, v := range template.Annotations {
+		annotations[k] = v
+	}
+	for _, a := range podOwnerAnnotations {
+		delete(annotations, a)
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	template.Annotations = annotations
+}
+
+// podTemplateHash returns the hash of a pod template without its owner annotations, so adding them to
+// the workloads generated before them doesn't change it.
+func podTemplateHash(template corev1.PodTemplateSpec) (string, error) {
+	removePodOwnerAnnotations(&template)
+	return hashObject(template)
+}
+
+// keepPodOwnerAnnotations leaves the owner annotations out of the generated pod template when the
+// stored one doesn't have them and is otherwise the same, comparing their hashes: adding them alone
+// would roll the pods of every existing RF. They are added with the next change of the template, or
+// right away when the RF forces them. A stored workload without a hash is taken as unchanged.
+func keepPodOwnerAnnotations(rf *redisfailoverv1.RedisFailover, template *corev1.PodTemplateSpec, stored corev1.PodTemplateSpec, storedHash, hash string) {
+	if rf.ForcePodOwnerAnnotations() || hasPodOwnerAnnotations(stored) {
+		return
+	}
+	if storedHash != "" && storedHash != hash {
+		return
+	}
+	removePodOwnerAnnotations(template)
+}
+
+// GetPodOwner returns the RF a pod is generated for from its owner annotations, or from the selector
+// labels of the pods generated before them. It returns false when the pod isn't generated for a RF.
+func GetPodOwner(pod *corev1.Pod) (PodOwner, bool) {
+	if name := pod.Annotations[PodOwnerNameAnnotation]; name != "" {
+		namespace := pod.Annotations[PodOwnerNamespaceAnnotation]
+		if namespace == "" {
+			namespace = pod.Namespace
+		}
+		return PodOwner{Namespace: namespace, Name: name, Component: pod.Annotations[PodOwnerComponentAnnotation]}, true
+	}
+	labels := pod.Labels
+	if labels["app.kubernetes.io/part-of"] != appLabel || labels["app.kubernetes.io/name"] == "" {
+		return PodOwner{}, false
+	}
+	return PodOwner{Namespace: pod.Namespace, Name: labels["app.kubernetes.io/name"], Component: labels["app.kubernetes.io/component"]}, true
+}
@@ -0,0 +1,235 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// withoutPodOwnerAnnotations returns the pod template as it was generated before the owner annotations.
+func withoutPodOwnerAnnotations(template corev1.PodTemplateSpec) corev1.PodTemplateSpec {
+	template = *template.DeepCopy()
+	delete(template.Annotations, rfservice.PodOwnerNameAnnotation)
+	delete(template.Annotations, rfservice.PodOwnerNamespaceAnnotation)
+	delete(template.Annotations, rfservice.PodOwnerComponentAnnotation)
+	if len(template.Annotations) == 0 {
+		template.Annotations = nil
+	}
+	return template
+}
+
+func TestPodOwnerAnnotationsKeepTheTemplateHash(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	for _, podAnnotations := range []map[string]string{nil, {"some": "annotation"}} {
+		rf := generateRF()
+		rf.Spec.Redis.PodAnnotations = podAnnotations
+		rf.Spec.Sentinel.PodAnnotations = podAnnotations
+		state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+		require.NoError(err)
+
+		for _, o := range state.Objects {
+			switch obj := o.Object.(type) {
+			case *appsv1.StatefulSet:
+				assert.Equal("redis", obj.Spec.Template.Annotations[rfservice.PodOwnerComponentAnnotation])
+				hash, err := rfservice.StatefulSetTemplateHash(obj)
+				require.NoError(err)
+				before := obj.DeepCopy()
+				before.Spec.Template = withoutPodOwnerAnnotations(obj.Spec.Template)
+				beforeHash, err := rfservice.StatefulSetTemplateHash(before)
+				require.NoError(err)
+				assert.Equal(beforeHash, hash)
+			case *appsv1.Deployment:
+				assert.Equal("sentinel", obj.Spec.Template.Annotations[rfservice.PodOwnerComponentAnnotation])
+				hash, err := rfservice.DeploymentTemplateHash(obj)
+				require.NoError(err)
+				before := obj.DeepCopy()
+				before.Spec.Template = withoutPodOwnerAnnotations(obj.Spec.Template)
+				beforeHash, err := rfservice.DeploymentTemplateHash(before)
+				require.NoError(err)
+				assert.Equal(beforeHash, hash)
+			}
+		}
+	}
+}
+
+func TestRedisStatefulSetPodOwnerAnnotations(t *testing.T) {
+	assert := assert.New(t)
+
+	notFound := kubeerrors.NewNotFound(schema.GroupResource{}, "")
+	ensure := func(rf *redisfailoverv1.RedisFailover, stored *appsv1.StatefulSet) *appsv1.StatefulSet {
+		var got *appsv1.StatefulSet
+		ms := &mK8SService.Services{}
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		if stored == nil {
+			ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(nil, notFound)
+		} else {
+			ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(stored, nil)
+		}
+		ms.On("CreateOrPatchStatefulSet", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			got = args.Get(2).(*appsv1.StatefulSet)
+		}).Return(nil)
+
+		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+		assert.NoError(client.EnsureRedisStatefulset(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+		ms.AssertExpectations(t)
+		return got
+	}
+
+	rf := generateRF()
+	created := ensure(rf, nil)
+	if !assert.NotNil(created) {
+		return
+	}
+	assert.NotEmpty(created.Annotations["databases.spotahome.com/template-hash"])
+	assert.Equal(map[string]string{
+		rfservice.PodOwnerNameAnnotation:      name,
+		rfservice.PodOwnerNamespaceAnnotation: namespace,
+		rfservice.PodOwnerComponentAnnotation: "redis",
+	}, created.Spec.Template.Annotations)
+
+	// A StatefulSet generated before the owner annotations, with or without the hash of its template.
+	legacy := created.DeepCopy()
+	legacy.Spec.Template = withoutPodOwnerAnnotations(created.Spec.Template)
+	unhashed := legacy.DeepCopy()
+	unhashed.Annotations = nil
+
+	// They are not added alone, the pods are not rolled.
+	assert.Equal(legacy.Spec.Template, ensure(rf, legacy.DeepCopy()).Spec.Template)
+	assert.Equal(legacy.Spec.Template, ensure(rf, unhashed.DeepCopy()).Spec.Template)
+
+	// Once added, they are kept.
+	assert.Equal(created.Spec.Template, ensure(rf, created.DeepCopy()).Spec.Template)
+
+	// The RF forces them.
+	forced := rf.DeepCopy()
+	forced.Annotations = map[string]string{redisfailoverv1.ForcePodOwnerAnnotationsAnnotation: "true"}
+	assert.Equal(created.Spec.Template, ensure(forced, legacy.DeepCopy()).Spec.Template)
+
+	// They are added with the next change of the template.
+	changed := rf.DeepCopy()
+	changed.Spec.Redis.Image = "redis:7.2"
+	got := ensure(changed, legacy.DeepCopy())
+	assert.NotEqual(created.Annotations, got.Annotations)
+	assert.Equal("redis", got.Spec.Template.Annotations[rfservice.PodOwnerComponentAnnotation])
+}
+
+func TestSentinelDeploymentPodOwnerAnnotations(t *testing.T) {
+	assert := assert.New(t)
+
+	ensure := func(rf *redisfailoverv1.RedisFailover, stored *appsv1.Deployment) *appsv1.Deployment {
+		var got *appsv1.Deployment
+		ms := &mK8SService.Services{}
+		ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil, nil)
+		ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Once().Return(stored, nil)
+		ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+			got = args.Get(2).(*appsv1.Deployment)
+		}).Return(nil)
+
+		client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+		assert.NoError(client.EnsureSentinelDeployment(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+		ms.AssertExpectations(t)
+		return got
+	}
+
+	rf := generateRF()
+	state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	if !assert.NoError(err) {
+		return
+	}
+	var generated *appsv1.Deployment
+	for _, o := range state.Objects {
+		if d, ok := o.Object.(*appsv1.Deployment); ok {
+			generated = d
+		}
+	}
+	if !assert.NotNil(generated) {
+		return
+	}
+	legacy := generated.DeepCopy()
+	legacy.Spec.Template = withoutPodOwnerAnnotations(generated.Spec.Template)
+	hash, err := rfservice.DeploymentTemplateHash(legacy)
+	if !assert.NoError(err) {
+		return
+	}
+	legacy.Annotations = map[string]string{"databases.spotahome.com/template-hash": hash}
+
+	// The Deployment generated before the owner annotations keeps its pod template.
+	assert.Equal(legacy.Spec.Template, ensure(rf, legacy.DeepCopy()).Spec.Template)
+
+	// The RF forces them.
+	forced := rf.DeepCopy()
+	forced.Annotations = map[string]string{redisfailoverv1.ForcePodOwnerAnnotationsAnnotation: "true"}
+	got := ensure(forced, legacy.DeepCopy())
+	assert.Equal(generated.Spec.Template, got.Spec.Template)
+	assert.Equal(legacy.Annotations, got.Annotations)
+
+	// Once added, they are kept without the RF forcing them.
+	assert.Equal(generated.Spec.Template, ensure(rf, got.DeepCopy()).Spec.Template)
+}
+
+func TestGetPodOwner(t *testing.T) {
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		expOwner rfservice.PodOwner
+		expOK    bool
+	}{
+		{
+			name: "A pod with the owner annotations",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Annotations: map[string]string{
+					rfservice.PodOwnerNameAnnotation:      name,
+					rfservice.PodOwnerNamespaceAnnotation: namespace,
+					rfservice.PodOwnerComponentAnnotation: "sentinel",
+				},
+			}},
+			expOwner: rfservice.PodOwner{Namespace: namespace, Name: name, Component: "sentinel"},
+			expOK:    true,
+		},
+		{
+			name: "A pod generated before the owner annotations",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/part-of":   "redis-failover",
+					"app.kubernetes.io/name":      name,
+					"app.kubernetes.io/component": "redis",
+				},
+			}},
+			expOwner: rfservice.PodOwner{Namespace: namespace, Name: name, Component: "redis"},
+			expOK:    true,
+		},
+		{
+			name:  "A pod of another app",
+			pod:   &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Labels: map[string]string{"app": "other"}}},
+			expOK: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			owner, ok := rfservice.GetPodOwner(test.pod)
+			assert.Equal(test.expOK, ok)
+			assert.Equal(test.expOwner, owner)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
+)
+
+// RenderRedisNetworkConfig returns the redis config written by the init container of a redis pod. The
+// bind addresses are resolved with the pod IP and the address of the replication interface of its
+// node, which is announced to the master instead of the pod IP when it's given.
+func RenderRedisNetworkConfig(bindAddresses []string, podIP, replicationIP string) (string, error) {
+	lines := []string{}
+	if len(bindAddresses) > 0 {
+		resolved := make([]string, 0, len(bindAddresses))
+		for _, address := range bindAddresses {
+			switch address {
+			case redisfailoverv1.BindPodIP:
+				if podIP == "" {
+					return "", fmt.Errorf("the pod IP is required to bind to %s", address)
+				}
+				address = podIP
+			case redisfailoverv1.BindReplicationIP:
+				if replicationIP == "" {
+					return "", fmt.Errorf("the replication interface address is required to bind to %s", address)
+				}
+				address = replicationIP
+			}
+			resolved = append(resolved, address)
+		}
+		lines = append(lines, fmt.Sprintf("bind %s", strings.Join(resolved, " ")))
+	}
+	if replicationIP != "" {
+		if net.ParseIP(replicationIP) == nil {
+			return "", fmt.Errorf("the replication interface address %q is not an IP", replicationIP)
+		}
+		lines = append(lines, fmt.Sprintf("replica-announce-ip %s", replicationIP))
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// addRedisNetworkBootstrap adds the init container writing the bind addresses and the announced address
+// of the redis, resolved on the node the pod runs on, to the file included by the redis config.
+func addRedisNetworkBootstrap(ss *appsv1.StatefulSet, rf *redisfailoverv1.RedisFailover) {
+	networkMount := corev1.VolumeMount{
+		Name:      redisNetworkVolumeName,
+		MountPath: redisNetworkDir,
+	}
+
+	redis := &ss.Spec.Template.Spec.Containers[0]
+	redis.VolumeMounts = append(redis.VolumeMounts, networkMount)
+
+	args := []string{"network-bootstrap", fmt.Sprintf("--output=%s", redisNetworkConfigPath)}
+	if len(rf.Spec.Redis.BindAddresses) > 0 {
+		args = append(args, fmt.Sprintf("--bind=%s", strings.Join(rf.Spec.Redis.BindAddresses, ",")))
+	}
+	var imagePullPolicy corev1.PullPolicy
+	resources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+	}
+	if iface := rf.Spec.Redis.ReplicationInterface; iface != nil {
+		args = append(args, fmt.Sprintf("--node-annotation=%s", iface.NodeAnnotation))
+		imagePullPolicy = iface.ImagePullPolicy
+		if iface.Resources != nil {
+			resources = *iface.Resources
+		}
+	}
+
+	ss.Spec.Template.Spec.InitContainers = append(ss.Spec.Template.Spec.InitContainers, corev1.Container{
+		Name:            redisNetworkContainerName,
+		Image:           rf.RedisNetworkBootstrapImage(),
+		ImagePullPolicy: pullPolicy(imagePullPolicy),
+		SecurityContext: getContainerSecurityContext(rf, rf.Spec.Redis.ContainerSecurityContext),
+		Args:            args,
+		Env: []corev1.EnvVar{
+			{
+				Name: "POD_IP",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath: "status.podIP",
+					},
+				},
+			},
+			{
+				Name: "NODE_NAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath: "spec.nodeName",
+					},
+				},
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{networkMount},
+		Resources:    resources,
+	})
+	ss.Spec.Template.Spec.Volumes = append(ss.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: redisNetworkVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+}
+
+// RedisAddresses maps the pod IPs of the redis, the operator connects to, to the addresses they announce
+// to their master and the sentinels monitor. Both are the same without a replication interface.
+type RedisAddresses struct {
+	announced map[string]string
+	podIPs    map[string]string
+}
+
+// NewRedisAddresses returns the addresses announced by the redis, by pod IP.
+func NewRedisAddresses(announced map[string]string) RedisAddresses {
+	a := RedisAddresses{announced: map[string]string{}, podIPs: map[string]string{}}
+	for podIP, address := range announced {
+		a.announced[podIP] = address
+		a.podIPs[address] = podIP
+	}
+	return a
+}
+
+// Announced returns the address announced by the redis with the pod IP.
+func (a RedisAddresses) Announced(podIP string) string {
+	if address, ok := a.announced[podIP]; ok {
+		return address
+	}
+	return podIP
+}
+
+// PodIP returns the pod IP of the redis announcing the address.
+func (a RedisAddresses) PodIP(address string) string {
+	if podIP, ok := a.podIPs[address]; ok {
+		return podIP
+	}
+	return address
+}
+
+// getRedisAddresses reads the address announced by every running redis pod. The redis not announcing
+// one, or that can't be reached, keep their pod IP.
+func getRedisAddresses(redisClient redis.Client, rf *redisfailoverv1.RedisFailover, pods []corev1.Pod, password string) (RedisAddresses, error) {
+	announced := map[string]string{}
+	if !rf.ReplicationInterfaceEnabled() {
+		return NewRedisAddresses(announced), nil
+	}
+
+	port := getRedisPort(rf.Spec.Redis.Port)
+	owners := map[string]string{}
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil || pod.Status.PodIP == "" {
+			continue
+		}
+		ip := rf.PreferredPodIP(pod.Status)
+		address, err := redisClient.GetRedisConfig(ip, port, password, "replica-announce-ip")
+		if err != nil || address == "" {
+			continue
+		}
+		if owner, ok := owners[address]; ok {
+			return RedisAddresses{}, fmt.Errorf("pods %s and %s announce the same address %s", owner, pod.Name, address)
+		}
+		owners[address] = pod.Name
+		announced[ip] = address
+	}
+	return NewRedisAddresses(announced), nil
+}
+
+// GetRedisAddresses returns the addresses announced by the redis of the RF, by pod IP.
+func (r *RedisFailoverChecker) GetRedisAddresses(ctx context.Context, rf *redisfailoverv1.RedisFailover) (RedisAddresses, error) {
+	if !rf.ReplicationInterfaceEnabled() {
+		return NewRedisAddresses(nil), nil
+	}
+	rps, err := r.k8sService.GetStatefulSetPods(ctx, rf.Namespace, GetRedisStatefulSetName(rf))
+	if err != nil {
+		return RedisAddresses{}, err
+	}
+	password, err := k8s.GetRedisPassword(ctx, r.k8sService, rf)
+	if err != nil {
+		return RedisAddresses{}, err
+	}
+	return getRedisAddresses(r.redisClient, rf, rps.Items, password)
+}
@@ -0,0 +1,249 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// generateDualHomedRF returns a RF whose redis replicate on the replication interface of their node.
+func generateDualHomedRF() *redisfailoverv1.RedisFailover {
+	rf := generateRF()
+	rf.Spec.Redis.HostNetwork = true
+	rf.Spec.Redis.BindAddresses = []string{redisfailoverv1.BindPodIP, redisfailoverv1.BindReplicationIP}
+	rf.Spec.Redis.ReplicationInterface = &redisfailoverv1.RedisReplicationInterface{
+		NodeAnnotation: "network.example.com/replication-ip",
+	}
+	return rf
+}
+
+// dualHomedPods are the redis pods, by name, and the addresses of the replication interface of their
+// node, by pod IP.
+var (
+	dualHomedPods = map[string]string{
+		"rfr-test-0": "10.0.0.1",
+		"rfr-test-1": "10.0.0.2",
+		"rfr-test-2": "10.0.0.3",
+	}
+	dualHomedAddresses = map[string]string{
+		"10.0.0.1": "192.168.0.1",
+		"10.0.0.2": "192.168.0.2",
+		"10.0.0.3": "192.168.0.3",
+	}
+)
+
+func TestRenderRedisNetworkConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		bind          []string
+		replicationIP string
+		expConfig     string
+		expErr        bool
+	}{
+		{
+			name:      "Bind addresses",
+			bind:      []string{redisfailoverv1.BindPodIP, "127.0.0.1"},
+			expConfig: "bind 10.0.0.1 127.0.0.1\n",
+		},
+		{
+			name:          "Replication interface",
+			bind:          []string{redisfailoverv1.BindPodIP, redisfailoverv1.BindReplicationIP},
+			replicationIP: "192.168.0.1",
+			expConfig:     "bind 10.0.0.1 192.168.0.1\nreplica-announce-ip 192.168.0.1\n",
+		},
+		{
+			name:          "Replication interface without bind addresses",
+			replicationIP: "192.168.0.1",
+			expConfig:     "replica-announce-ip 192.168.0.1\n",
+		},
+		{
+			name:   "Replication IP without the node address",
+			bind:   []string{redisfailoverv1.BindReplicationIP},
+			expErr: true,
+		},
+		{
+			name:          "Node address that is not an IP",
+			replicationIP: "replication.local",
+			expErr:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			config, err := rfservice.RenderRedisNetworkConfig(test.bind, "10.0.0.1", test.replicationIP)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expConfig, config)
+		})
+	}
+}
+
+func TestRedisNetworkBootstrap(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	state, err := rfservice.BuildDesiredState(generateDualHomedRF(), nil, nil, "")
+	require.NoError(err)
+
+	var ss *appsv1.StatefulSet
+	var config string
+	for _, o := range state.Objects {
+		switch obj := o.Object.(type) {
+		case *appsv1.StatefulSet:
+			ss = obj
+		case *corev1.ConfigMap:
+			if obj.Name == rfservice.GetRedisName(generateRF()) {
+				config = obj.Data["redis.conf"]
+			}
+		}
+	}
+	require.NotNil(ss)
+
+	assert.Contains(config, "\ninclude /redis-network/network.conf")
+	require.Len(ss.Spec.Template.Spec.InitContainers, 1)
+	init := ss.Spec.Template.Spec.InitContainers[0]
+	assert.Equal("redis-network", init.Name)
+	assert.Equal([]string{
+		"network-bootstrap",
+		"--output=/redis-network/network.conf",
+		"--bind=$(POD_IP),$(REPLICATION_IP)",
+		"--node-annotation=network.example.com/replication-ip",
+	}, init.Args)
+	assert.Equal("spec.nodeName", init.Env[1].ValueFrom.FieldRef.FieldPath)
+	assert.Contains(ss.Spec.Template.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "redis-network", MountPath: "/redis-network"})
+	assert.Contains(ss.Spec.Template.Spec.Containers[0].VolumeMounts, init.VolumeMounts[0])
+
+	// Without them the pods don't change.
+	state, err = rfservice.BuildDesiredState(generateRF(), nil, nil, "")
+	require.NoError(err)
+	for _, o := range state.Objects {
+		if obj, ok := o.Object.(*appsv1.StatefulSet); ok {
+			assert.Empty(obj.Spec.Template.Spec.InitContainers)
+		}
+	}
+}
+
+func TestGetRedisAddresses(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateDualHomedRF()
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Return(runningPods(dualHomedPods), nil)
+	mr := &mRedisService.Client{}
+	for podIP, address := range dualHomedAddresses {
+		mr.On("GetRedisConfig", podIP, "0", "", "replica-announce-ip").Once().Return(address, nil)
+	}
+	checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+	addresses, err := checker.GetRedisAddresses(context.TODO(), rf)
+	assert.NoError(err)
+	for podIP, address := range dualHomedAddresses {
+		assert.Equal(address, addresses.Announced(podIP))
+		assert.Equal(podIP, addresses.PodIP(address))
+	}
+	// The unknown addresses are kept.
+	assert.Equal("10.0.0.9", addresses.Announced("10.0.0.9"))
+	assert.Equal("10.0.0.9", addresses.PodIP("10.0.0.9"))
+
+	// Two redis can't announce the same address.
+	mr = &mRedisService.Client{}
+	for podIP := range dualHomedAddresses {
+		mr.On("GetRedisConfig", podIP, "0", "", "replica-announce-ip").Return("192.168.0.1", nil)
+	}
+	checker = rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+	_, err = checker.GetRedisAddresses(context.TODO(), rf)
+	assert.Error(err)
+
+	// Without a replication interface nothing is read.
+	addresses, err = rfservice.NewRedisFailoverChecker(&mK8SService.Services{}, &mRedisService.Client{}, log.DummyLogger{}, metrics.Dummy).GetRedisAddresses(context.TODO(), generateRF())
+	assert.NoError(err)
+	assert.Equal("10.0.0.1", addresses.Announced("10.0.0.1"))
+}
+
+func TestCheckAllSlavesFromMasterReplicationInterface(t *testing.T) {
+	tests := []struct {
+		name     string
+		slaveOf  string
+		expError bool
+	}{
+		{
+			name:    "The replicas point to the announced address of the master",
+			slaveOf: "192.168.0.1",
+		},
+		{
+			name:     "The replicas point to the pod IP of the master",
+			slaveOf:  "10.0.0.1",
+			expError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateDualHomedRF()
+			ms := &mK8SService.Services{}
+			ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Return(runningPods(dualHomedPods), nil)
+			mr := &mRedisService.Client{}
+			for podIP, address := range dualHomedAddresses {
+				mr.On("GetRedisConfig", podIP, "0", "", "replica-announce-ip").Return(address, nil)
+				if podIP == "10.0.0.1" {
+					mr.On("GetSlaveOf", podIP, "0", "").Return("", nil)
+				} else {
+					mr.On("GetSlaveOf", podIP, "0", "").Return(test.slaveOf, nil)
+				}
+			}
+			checker := rfservice.NewRedisFailoverChecker(ms, mr, log.DummyLogger{}, metrics.Dummy)
+
+			err := checker.CheckAllSlavesFromMaster(context.TODO(), "10.0.0.1", rf)
+			if test.expError {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestPlanMasterOnAllReplicationInterface(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateDualHomedRF()
+	rf.Spec.Failover.MaxConcurrentSyncs = 0
+	ms := &mK8SService.Services{}
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Once().Return(runningPods(dualHomedPods), nil)
+	mr := &mRedisService.Client{}
+	for podIP, address := range dualHomedAddresses {
+		mr.On("GetRedisConfig", podIP, "0", "", "replica-announce-ip").Once().Return(address, nil)
+	}
+	mr.On("MakeMaster", "10.0.0.1", "0", "").Once().Return(nil)
+	// One replica already points to the announced address of the master, the other to its pod IP.
+	mr.On("GetSlaveOf", "10.0.0.2", "0", "").Once().Return("192.168.0.1", nil)
+	mr.On("GetSlaveOf", "10.0.0.3", "0", "").Once().Return("10.0.0.1", nil)
+	mr.On("MakeSlaveOfWithPort", "10.0.0.3", "192.168.0.1", "0", "").Once().Return(nil)
+
+	healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+	actions, err := healer.PlanMasterOnAll(context.TODO(), "10.0.0.1", rf)
+	assert.NoError(err)
+	assert.Len(actions, 2)
+	assert.NoError(applyPodActions(actions))
+	mr.AssertExpectations(t)
+}
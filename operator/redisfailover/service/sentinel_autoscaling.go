@@ -0,0 +1,58 @@
+package service
+
+import (
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+)
+
+// generateSentinelHPA returns the HorizontalPodAutoscaler of the sentinel deployment of the RF. It
+// scales the sentinels from sentinel.replicas, the replicas the quorum is computed from, to the maximum
+// replicas of the spec on the average usage of their requests.
+func generateSentinelHPA(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *autoscalingv2.HorizontalPodAutoscaler {
+	name := GetSentinelName(rf)
+	labels = util.MergeLabels(labels, generateSelectorLabels(sentinelRoleName, rf.Name))
+	scaling := rf.Spec.SentinelAutoScaling
+	minReplicas := rf.Spec.Sentinel.Replicas
+
+	metrics := []autoscalingv2.MetricSpec{}
+	for _, target := range []struct {
+		resource    corev1.ResourceName
+		utilization *int32
+	}{
+		{resource: corev1.ResourceCPU, utilization: scaling.TargetCPUUtilizationPercentage},
+		{resource: corev1.ResourceMemory, utilization: scaling.TargetMemoryUtilizationPercentage},
+	} {
+		if target.utilization == nil {
+			continue
+		}
+		utilization := *target.utilization
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: target.resource,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: &utilization,
+				},
+			},
+		})
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: generateObjectMeta(name, rf.Namespace, labels, nil, ownerRefs),
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: scaling.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+}
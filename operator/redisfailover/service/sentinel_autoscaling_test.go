@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestSentinelHPA(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	tests := []struct {
+		name       string
+		scaling    *redisfailoverv1.SentinelAutoScalingSettings
+		expMetrics map[corev1.ResourceName]int32
+	}{
+		{
+			name:       "The CPU is targeted by default",
+			scaling:    &redisfailoverv1.SentinelAutoScalingSettings{MaxReplicas: 5},
+			expMetrics: map[corev1.ResourceName]int32{corev1.ResourceCPU: 80},
+		},
+		{
+			name:       "The CPU and the memory are targeted",
+			scaling:    &redisfailoverv1.SentinelAutoScalingSettings{MaxReplicas: 7, TargetCPUUtilizationPercentage: int32Ptr(60), TargetMemoryUtilizationPercentage: int32Ptr(75)},
+			expMetrics: map[corev1.ResourceName]int32{corev1.ResourceCPU: 60, corev1.ResourceMemory: 75},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rf := generateRF()
+			rf.Spec.SentinelAutoScaling = test.scaling
+			require.NoError(rf.Validate())
+
+			state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+			require.NoError(err)
+			var hpa *autoscalingv2.HorizontalPodAutoscaler
+			for _, o := range state.Objects {
+				if o.Kind == rfservice.KindHPA {
+					hpa = o.Object.(*autoscalingv2.HorizontalPodAutoscaler)
+				}
+			}
+			require.NotNil(hpa)
+
+			assert.Equal("rfs-test", hpa.Name)
+			assert.Equal(autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "rfs-test"}, hpa.Spec.ScaleTargetRef)
+			assert.Equal(int32(3), *hpa.Spec.MinReplicas)
+			assert.Equal(test.scaling.MaxReplicas, hpa.Spec.MaxReplicas)
+			gotMetrics := map[corev1.ResourceName]int32{}
+			for _, m := range hpa.Spec.Metrics {
+				assert.Equal(autoscalingv2.ResourceMetricSourceType, m.Type)
+				assert.Equal(autoscalingv2.UtilizationMetricType, m.Resource.Target.Type)
+				gotMetrics[m.Resource.Name] = *m.Resource.Target.AverageUtilization
+			}
+			assert.Equal(test.expMetrics, gotMetrics)
+		})
+	}
+}
+
+func TestSentinelDeploymentAutoScaledReplicas(t *testing.T) {
+	scaled := int32(5)
+	tests := []struct {
+		name        string
+		scaling     *redisfailoverv1.SentinelAutoScalingSettings
+		stored      *appsv1.Deployment
+		expReplicas int32
+	}{
+		{
+			name:        "A new deployment gets the sentinel replicas",
+			scaling:     &redisfailoverv1.SentinelAutoScalingSettings{MaxReplicas: 7},
+			expReplicas: 3,
+		},
+		{
+			name:        "The replicas set by the autoscaler are kept",
+			scaling:     &redisfailoverv1.SentinelAutoScalingSettings{MaxReplicas: 7},
+			stored:      &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &scaled}},
+			expReplicas: 5,
+		},
+		{
+			name:        "The replicas are reset without autoscaling",
+			stored:      &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &scaled}},
+			expReplicas: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.SentinelAutoScaling = test.scaling
+
+			var gotD *appsv1.Deployment
+			ms := &mK8SService.Services{}
+			ms.On("CreateOrUpdatePodDisruptionBudget", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+			if test.stored != nil {
+				ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Once().Return(test.stored, nil)
+			} else {
+				ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, ""))
+			}
+			ms.On("CreateOrUpdateDeployment", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+				gotD = args.Get(2).(*appsv1.Deployment)
+			}).Return(nil)
+
+			client := rfservice.NewRedisFailoverKubeClient(ms, log.Dummy, metrics.Dummy)
+			assert.NoError(client.EnsureSentinelDeployment(context.TODO(), rf, nil, []metav1.OwnerReference{}))
+			assert.Equal(test.expReplicas, *gotD.Spec.Replicas)
+			// The spec of the RF is left as is.
+			assert.Equal(int32(3), rf.Spec.Sentinel.Replicas)
+		})
+	}
+}
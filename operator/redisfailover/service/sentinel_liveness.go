@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/service/k8s"
+)
+
+// unresponsiveSentinelThreshold is the number of consecutive checks a sentinel must not answer
+// before its pod is restarted.
+const unresponsiveSentinelThreshold = 3
+
+// UnresponsiveSentinelTracker counts, for every RF, the consecutive checks its sentinel pods have
+// not answered on the sentinel port.
+type UnresponsiveSentinelTracker struct {
+	mu        sync.Mutex
+	sentinels map[string]map[string]int
+}
+
+// NewUnresponsiveSentinelTracker returns a new unresponsive sentinel tracker.
+func NewUnresponsiveSentinelTracker() *UnresponsiveSentinelTracker {
+	return &UnresponsiveSentinelTracker{
+		sentinels: map[string]map[string]int{},
+	}
+}
+
+// Observe records the sentinel pods not answering on a check, and returns the ones that reached the
+// threshold, sorted by pod. The pods answering again are forgotten.
+func (t *UnresponsiveSentinelTracker) Observe(key string, unresponsive []string, threshold int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	known := t.sentinels[key]
+	sentinels := make(map[string]int, len(unresponsive))
+	for _, pod := range unresponsive {
+		sentinels[pod] = known[pod] + 1
+	}
+	if len(sentinels) == 0 {
+		delete(t.sentinels, key)
+		return nil
+	}
+	t.sentinels[key] = sentinels
+
+	due := []string{}
+	for pod, checks := range sentinels {
+		if checks >= threshold {
+			due = append(due, pod)
+		}
+	}
+	sort.Strings(due)
+	return due
+}
+
+// Forget resets the count of a sentinel pod, so it must reach the threshold again before it's restarted again.
+func (t *UnresponsiveSentinelTracker) Forget(key string, pod string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sentinels[key], pod)
+}
+
+// State returns the consecutive checks the sentinel pods of the RF have not answered, by pod.
+func (t *UnresponsiveSentinelTracker) State(key string) map[string]int32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sentinels := t.sentinels[key]
+	if len(sentinels) == 0 {
+		return nil
+	}
+	state := make(map[string]int32, len(sentinels))
+	for pod, checks := range sentinels {
+		state[pod] = int32(checks)
+	}
+	return state
+}
+
+// Restore sets the unresponsive sentinels of the RF stored before a restart of the operator, unless
+// the tracker already knows them.
+func (t *UnresponsiveSentinelTracker) Restore(key string, state map[string]int32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.sentinels[key]; ok || len(state) == 0 {
+		return
+	}
+	sentinels := make(map[string]int, len(state))
+	for pod, checks := range state {
+		sentinels[pod] = int(checks)
+	}
+	t.sentinels[key] = sentinels
+}
+
+// Clear forgets the unresponsive sentinels of the RF.
+func (t *UnresponsiveSentinelTracker) Clear(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sentinels, key)
+}
+
+// PlanUnresponsiveSentinels plans the restart of the sentinel pods running but not answering on the
+// sentinel port for the consecutive checks of the threshold. Only one sentinel is restarted by check,
+// and only when the sentinels answering still reach the quorum without it, so the restart doesn't
+// leave the RF without failovers.
+func (r *RedisFailoverHealer) PlanUnresponsiveSentinels(ctx context.Context, unresponsiveIPs []string, rf *redisfailoverv1.RedisFailover) ([]PodAction, error) {
+	key := rfKey(rf)
+	if len(unresponsiveIPs) == 0 {
+		r.unresponsiveSentinels.Clear(key)
+		return nil, nil
+	}
+
+	sps, err := r.k8sService.GetDeploymentPods(ctx, rf.Namespace, GetSentinelName(rf))
+	if err != nil {
+		return nil, err
+	}
+
+	isUnresponsive := make(map[string]bool, len(unresponsiveIPs))
+	for _, ip := range unresponsiveIPs {
+		isUnresponsive[ip] = true
+	}
+	unresponsive := []string{}
+	responsive := 0
+	for _, pod := range sps.Items {
+		if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if isUnresponsive[rf.PreferredPodIP(pod.Status)] {
+			unresponsive = append(unresponsive, pod.Name)
+		} else {
+			responsive++
+		}
+	}
+
+	due := r.unresponsiveSentinels.Observe(key, unresponsive, unresponsiveSentinelThreshold)
+	if len(due) == 0 {
+		return nil, nil
+	}
+	quorum := int(getQuorum(rf))
+	if responsive < quorum {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("Holding the restart of the unresponsive sentinel %s, only %d sentinels answer and the quorum is %d", due[0], responsive, quorum)
+		return nil, nil
+	}
+
+	podName := due[0]
+	return []PodAction{{
+		Pod:    podName,
+		Kind:   PodActionDelete,
+		Reason: fmt.Sprintf("restart it, it has not answered on the sentinel port for %d checks", unresponsiveSentinelThreshold),
+		Apply: func() error {
+			// The pod is evicted so its pdb is honored. The sentinel stays due while the pdb refuses
+			// the eviction, so it's retried on the next check.
+			err := r.EvictPod(ctx, podName, rf)
+			if !k8s.IsEvictionBlocked(err) {
+				r.unresponsiveSentinels.Forget(key, podName)
+			}
+			return err
+		},
+	}}, nil
+}
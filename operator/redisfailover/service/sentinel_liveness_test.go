@@ -0,0 +1,222 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestUnresponsiveSentinelTracker(t *testing.T) {
+	assert := assert.New(t)
+
+	tracker := rfservice.NewUnresponsiveSentinelTracker()
+
+	// The sentinels are due once they reach the threshold.
+	assert.Empty(tracker.Observe("ns/rf", []string{"rfs-test-1"}, 2))
+	assert.Equal([]string{"rfs-test-1"}, tracker.Observe("ns/rf", []string{"rfs-test-1", "rfs-test-2"}, 2))
+	assert.Equal([]string{"rfs-test-1", "rfs-test-2"}, tracker.Observe("ns/rf", []string{"rfs-test-1", "rfs-test-2"}, 2))
+
+	// A sentinel restarted must reach the threshold again.
+	tracker.Forget("ns/rf", "rfs-test-1")
+	assert.Equal([]string{"rfs-test-2"}, tracker.Observe("ns/rf", []string{"rfs-test-1", "rfs-test-2"}, 2))
+
+	// A sentinel answering again is forgotten, the failures must be consecutive.
+	assert.Equal([]string{"rfs-test-2"}, tracker.Observe("ns/rf", []string{"rfs-test-2"}, 2))
+	assert.Empty(tracker.Observe("ns/rf", []string{"rfs-test-1"}, 2))
+	assert.Equal([]string{"rfs-test-1"}, tracker.Observe("ns/rf", []string{"rfs-test-1"}, 2))
+
+	// The sentinels are all forgotten once they all answer.
+	assert.Empty(tracker.Observe("ns/rf", nil, 2))
+	assert.Empty(tracker.Observe("ns/rf", []string{"rfs-test-1"}, 2))
+
+	// The RFs are tracked apart.
+	assert.Empty(tracker.Observe("ns/other", []string{"rfs-other-1"}, 2))
+	tracker.Clear("ns/rf")
+	assert.Empty(tracker.Observe("ns/rf", []string{"rfs-test-1"}, 2))
+	assert.Equal([]string{"rfs-other-1"}, tracker.Observe("ns/other", []string{"rfs-other-1"}, 2))
+}
+
+func TestPlanUnresponsiveSentinels(t *testing.T) {
+	sentinels := map[string]string{
+		"rfs-test-0": "10.0.0.1",
+		"rfs-test-1": "10.0.0.2",
+		"rfs-test-2": "10.0.0.3",
+	}
+
+	tests := []struct {
+		name string
+		// checks are the sentinels not answering on every check.
+		checks     [][]string
+		expActions []string
+	}{
+		{
+			name:   "A sentinel not answering is restarted when it reaches the threshold",
+			checks: [][]string{{"10.0.0.2"}, {"10.0.0.2"}, {"10.0.0.2"}},
+			expActions: []string{
+				"delete pod rfs-test-1: restart it, it has not answered on the sentinel port for 3 checks",
+			},
+		},
+		{
+			name:   "A sentinel answering again starts over",
+			checks: [][]string{{"10.0.0.2"}, {"10.0.0.2"}, {}, {"10.0.0.2"}, {"10.0.0.2"}},
+		},
+		{
+			name:   "A sentinel is not restarted when the others don't reach the quorum",
+			checks: [][]string{{"10.0.0.2", "10.0.0.3"}, {"10.0.0.2", "10.0.0.3"}, {"10.0.0.2", "10.0.0.3"}, {"10.0.0.2", "10.0.0.3"}},
+		},
+		{
+			name:   "The restart held by the quorum is made when a sentinel answers again",
+			checks: [][]string{{"10.0.0.2", "10.0.0.3"}, {"10.0.0.2", "10.0.0.3"}, {"10.0.0.2", "10.0.0.3"}, {"10.0.0.2"}},
+			expActions: []string{
+				"delete pod rfs-test-1: restart it, it has not answered on the sentinel port for 3 checks",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			ms := &mK8SService.Services{}
+			ms.On("GetDeploymentPods", mock.Anything, namespace, rfservice.GetSentinelName(rf)).Return(runningPods(sentinels), nil)
+			ms.On("EvictPod", mock.Anything, namespace, mock.Anything).Return(nil)
+
+			healer := rfservice.NewRedisFailoverHealer(ms, &mRedisService.Client{}, log.DummyLogger{})
+			actions := []string{}
+			for _, unresponsive := range test.checks {
+				planned, err := healer.PlanUnresponsiveSentinels(context.TODO(), unresponsive, rf)
+				assert.NoError(err)
+				for _, action := range planned {
+					actions = append(actions, action.String())
+					assert.NoError(action.Apply())
+				}
+			}
+
+			assert.Equal(len(test.expActions) > 0, len(actions) > 0)
+			if len(test.expActions) > 0 {
+				assert.Equal(test.expActions, actions)
+				ms.AssertCalled(t, "EvictPod", mock.Anything, namespace, "rfs-test-1")
+			} else {
+				ms.AssertNotCalled(t, "EvictPod", mock.Anything, mock.Anything, mock.Anything)
+			}
+			ms.AssertNotCalled(t, "DeletePod", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestPlanUnresponsiveSentinelsRestartsOneByCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	rf.Spec.Sentinel.Replicas = 5
+	ms := &mK8SService.Services{}
+	ms.On("GetDeploymentPods", mock.Anything, namespace, rfservice.GetSentinelName(rf)).Return(runningPods(map[string]string{
+		"rfs-test-0": "10.0.0.1",
+		"rfs-test-1": "10.0.0.2",
+		"rfs-test-2": "10.0.0.3",
+		"rfs-test-3": "10.0.0.4",
+		"rfs-test-4": "10.0.0.5",
+	}), nil)
+	ms.On("EvictPod", mock.Anything, namespace, mock.Anything).Return(nil)
+
+	healer := rfservice.NewRedisFailoverHealer(ms, &mRedisService.Client{}, log.DummyLogger{})
+	unresponsive := []string{"10.0.0.2", "10.0.0.3"}
+	pods := []string{}
+	for i := 0; i < 4; i++ {
+		planned, err := healer.PlanUnresponsiveSentinels(context.TODO(), unresponsive, rf)
+		assert.NoError(err)
+		assert.LessOrEqual(len(planned), 1)
+		for _, action := range planned {
+			pods = append(pods, action.Pod)
+			assert.NoError(action.Apply())
+		}
+	}
+	// Both reach the threshold on the third check, the second one waits for the next check.
+	assert.Equal([]string{"rfs-test-1", "rfs-test-2"}, pods)
+}
+
+func TestPlanUnresponsiveSentinelsRetriesBlockedEviction(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	ms := &mK8SService.Services{}
+	ms.On("GetDeploymentPods", mock.Anything, namespace, rfservice.GetSentinelName(rf)).Return(runningPods(map[string]string{
+		"rfs-test-0": "10.0.0.1",
+		"rfs-test-1": "10.0.0.2",
+		"rfs-test-2": "10.0.0.3",
+	}), nil)
+	ms.On("EvictPod", mock.Anything, namespace, "rfs-test-1").Once().Return(errEvictionBlocked)
+	ms.On("EvictPod", mock.Anything, namespace, "rfs-test-1").Once().Return(nil)
+
+	healer := rfservice.NewRedisFailoverHealer(ms, &mRedisService.Client{}, log.DummyLogger{})
+	evictions := []error{}
+	for i := 0; i < 4; i++ {
+		planned, err := healer.PlanUnresponsiveSentinels(context.TODO(), []string{"10.0.0.2"}, rf)
+		assert.NoError(err)
+		for _, action := range planned {
+			evictions = append(evictions, action.Apply())
+		}
+	}
+	// The eviction refused by the pdb on the third check is retried on the next one, without waiting
+	// for the threshold again.
+	assert.Equal([]error{errEvictionBlocked, nil}, evictions)
+	ms.AssertExpectations(t)
+}
+
+func TestUnresponsiveSentinelTrackerState(t *testing.T) {
+	assert := assert.New(t)
+
+	tracker := rfservice.NewUnresponsiveSentinelTracker()
+	assert.Nil(tracker.State("ns/rf"))
+	tracker.Observe("ns/rf", []string{"rfs-test-1", "rfs-test-2"}, 3)
+	tracker.Observe("ns/rf", []string{"rfs-test-1"}, 3)
+	assert.Equal(map[string]int32{"rfs-test-1": 2}, tracker.State("ns/rf"))
+
+	// The state is restored on a tracker not knowing the RF, the checks go on from it.
+	restored := rfservice.NewUnresponsiveSentinelTracker()
+	restored.Restore("ns/rf", tracker.State("ns/rf"))
+	assert.Equal([]string{"rfs-test-1"}, restored.Observe("ns/rf", []string{"rfs-test-1"}, 3))
+
+	// The sentinels already tracked are kept.
+	restored.Restore("ns/rf", map[string]int32{"rfs-test-2": 2})
+	assert.Empty(restored.Observe("ns/rf", []string{"rfs-test-2"}, 3))
+}
+
+func TestPlanUnresponsiveSentinelsAfterRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF()
+	ms := &mK8SService.Services{}
+	ms.On("GetDeploymentPods", mock.Anything, namespace, rfservice.GetSentinelName(rf)).Return(runningPods(map[string]string{
+		"rfs-test-0": "10.0.0.1",
+		"rfs-test-1": "10.0.0.2",
+		"rfs-test-2": "10.0.0.3",
+	}), nil)
+
+	// The sentinel misses two checks before the operator restarts.
+	healer := rfservice.NewRedisFailoverHealer(ms, &mRedisService.Client{}, log.DummyLogger{})
+	for i := 0; i < 2; i++ {
+		planned, err := healer.PlanUnresponsiveSentinels(context.TODO(), []string{"10.0.0.2"}, rf)
+		assert.NoError(err)
+		assert.Empty(planned)
+	}
+	state := healer.GetRemediationState(rf)
+	assert.Equal(map[string]int32{"rfs-test-1": 2}, state.UnresponsiveSentinels)
+
+	// The new healer restarts it on the third check, not three checks later.
+	healer = rfservice.NewRedisFailoverHealer(ms, &mRedisService.Client{}, log.DummyLogger{})
+	healer.RestoreRemediationState(rf, state)
+	planned, err := healer.PlanUnresponsiveSentinels(context.TODO(), []string{"10.0.0.2"}, rf)
+	assert.NoError(err)
+	if assert.Len(planned, 1) {
+		assert.Equal("rfs-test-1", planned[0].Pod)
+	}
+}
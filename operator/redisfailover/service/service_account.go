@@ -0,0 +1,61 @@
+package service
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+)
+
+// generateRedisServiceAccount returns the service account of the redis pods of the RF. Redis doesn't
+// call the kubernetes API, its token is not mounted.
+func generateRedisServiceAccount(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *corev1.ServiceAccount {
+	labels = util.MergeLabels(labels, generateSelectorLabels(redisRoleName, rf.Name))
+	automount := false
+	return &corev1.ServiceAccount{
+		ObjectMeta:                   generateObjectMeta(GetRedisName(rf), rf.Namespace, labels, nil, ownerRefs),
+		AutomountServiceAccountToken: &automount,
+	}
+}
+
+// generateRedisRole returns the Role of the redis pods of the RF. It grants nothing, the pods don't need
+// any permission, and the ones given to the default service account of the namespace are not inherited.
+func generateRedisRole(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *rbacv1.Role {
+	labels = util.MergeLabels(labels, generateSelectorLabels(redisRoleName, rf.Name))
+	return &rbacv1.Role{
+		ObjectMeta: generateObjectMeta(GetRedisName(rf), rf.Namespace, labels, nil, ownerRefs),
+		Rules:      []rbacv1.PolicyRule{},
+	}
+}
+
+// generateRedisRoleBinding returns the RoleBinding of the Role of the redis pods to their service account.
+func generateRedisRoleBinding(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *rbacv1.RoleBinding {
+	name := GetRedisName(rf)
+	labels = util.MergeLabels(labels, generateSelectorLabels(redisRoleName, rf.Name))
+	return &rbacv1.RoleBinding{
+		ObjectMeta: generateObjectMeta(name, rf.Namespace, labels, nil, ownerRefs),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      name,
+				Namespace: rf.Namespace,
+			},
+		},
+	}
+}
+
+// getRedisServiceAccountName returns the service account of the redis pods: the one of the spec, the
+// one of the operator when it's managed, or the default one of the namespace.
+func getRedisServiceAccountName(rf *redisfailoverv1.RedisFailover) string {
+	if rf.ManagedServiceAccountEnabled() {
+		return GetRedisName(rf)
+	}
+	return rf.Spec.Redis.ServiceAccountName
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+	"redis-operator/service/k8s"
+)
+
+// generateRedisServiceMonitor returns the prometheus-operator ServiceMonitor scraping the exporter port
+// of the redis service. The labels of the spec are added so a Prometheus selects it.
+func generateRedisServiceMonitor(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *unstructured.Unstructured {
+	settings := rf.Spec.Redis.Exporter.ServiceMonitor
+	selectorLabels := generateSelectorLabels(redisRoleName, rf.Name)
+	labels = util.MergeLabels(labels, selectorLabels, settings.Labels)
+
+	matchLabels := map[string]interface{}{}
+	for k, v := range selectorLabels {
+		matchLabels[k] = v
+	}
+	endpoint := map[string]interface{}{
+		"port": exporterPortName,
+		"path": "/metrics",
+	}
+	if settings.Interval != "" {
+		endpoint["interval"] = settings.Interval
+	}
+	if settings.ScrapeTimeout != "" {
+		endpoint["scrapeTimeout"] = settings.ScrapeTimeout
+	}
+
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(k8s.ServiceMonitorGVR.GroupVersion().WithKind(KindServiceMonitor))
+	serviceMonitor.SetName(GetRedisName(rf))
+	serviceMonitor.SetNamespace(rf.Namespace)
+	serviceMonitor.SetLabels(labels)
+	serviceMonitor.SetOwnerReferences(ownerRefs)
+	serviceMonitor.Object["spec"] = map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": matchLabels,
+		},
+		"namespaceSelector": map[string]interface{}{
+			"matchNames": []interface{}{rf.Namespace},
+		},
+		"endpoints": []interface{}{endpoint},
+	}
+	return serviceMonitor
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+const (
+	replBacklogSizeParameter = "repl-backlog-size"
+	maxReplBacklogSize       = 1 << 30
+)
+
+// StuckReplicaStep is an escalation step of the remediation of a stuck replica.
+type StuckReplicaStep int
+
+// Escalation steps of the remediation of a stuck replica, in the order they are taken.
+const (
+	StuckReplicaRetry StuckReplicaStep = iota + 1
+	StuckReplicaRestart
+)
+
+func (s StuckReplicaStep) String() string {
+	switch s {
+	case StuckReplicaRetry:
+		return "retry"
+	case StuckReplicaRestart:
+		return "restart"
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// StuckReplicaEscalation is the escalation step due for a stuck replica on a check.
+type StuckReplicaEscalation struct {
+	Pod  string
+	Step StuckReplicaStep
+	// PartialSyncFailures is true when the partial syncs of the master failed more than they
+	// succeeded while the replica was stuck, a sign of a too small backlog.
+	PartialSyncFailures bool
+}
+
+type stuckReplica struct {
+	checks int
+	step   StuckReplicaStep
+	// The partial sync counters of the master when the replica got stuck.
+	partialOK  int64
+	partialErr int64
+}
+
+// StuckReplicaTracker counts, for every RF, the consecutive checks its replicas have been stuck
+// with the link to the master down, and escalates their remediation every time a replica reaches
+// the threshold.
+type StuckReplicaTracker struct {
+	mu       sync.Mutex
+	replicas map[string]map[string]*stuckReplica
+}
+
+// NewStuckReplicaTracker returns a new stuck replica tracker.
+func NewStuckReplicaTracker() *StuckReplicaTracker {
+	return &StuckReplicaTracker{
+		replicas: map[string]map[string]*stuckReplica{},
+	}
+}
+
+// Observe records the replicas stuck on a check with the partial sync counters of the master, and
+// returns the escalations due, sorted by pod. The replicas not stuck anymore are forgotten. Only
+// one replica is restarted by check, the others due a restart wait for the next one. An escalation
+// stays due, on every check, until it's recorded with Escalated.
+func (t *StuckReplicaTracker) Observe(key string, stuck []string, partialOK, partialErr int64, threshold int) []StuckReplicaEscalation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	known := t.replicas[key]
+	replicas := make(map[string]*stuckReplica, len(stuck))
+	for _, pod := range stuck {
+		replica, ok := known[pod]
+		if !ok {
+			replica = &stuckReplica{partialOK: partialOK, partialErr: partialErr}
+		}
+		replica.checks++
+		replicas[pod] = replica
+	}
+	if len(replicas) == 0 {
+		delete(t.replicas, key)
+		return nil
+	}
+	t.replicas[key] = replicas
+
+	pods := make([]string, 0, len(replicas))
+	for pod := range replicas {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+
+	escalations := []StuckReplicaEscalation{}
+	restarted := false
+	for _, pod := range pods {
+		replica := replicas[pod]
+		if replica.checks < threshold {
+			continue
+		}
+		step := replica.step + 1
+		if step > StuckReplicaRestart {
+			step = StuckReplicaRestart
+		}
+		if step == StuckReplicaRestart {
+			if restarted {
+				continue
+			}
+			restarted = true
+		}
+		escalations = append(escalations, StuckReplicaEscalation{
+			Pod:                 pod,
+			Step:                step,
+			PartialSyncFailures: partialErr-replica.partialErr > partialOK-replica.partialOK,
+		})
+	}
+	return escalations
+}
+
+// Escalated records the escalation of a stuck replica was applied, its next step is due the next time
+// it reaches the threshold. An escalation not applied, because another action on the pod won or it
+// failed, as an eviction refused by the PodDisruptionBudget, is due again on the next check.
+func (t *StuckReplicaTracker) Escalated(key string, escalation StuckReplicaEscalation) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if replica, ok := t.replicas[key][escalation.Pod]; ok {
+		replica.step = escalation.Step
+		replica.checks = 0
+	}
+}
+
+// State returns the stuck replicas of the RF and their remediation, sorted by pod.
+func (t *StuckReplicaTracker) State(key string) []redisfailoverv1.StuckReplicaState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	replicas := t.replicas[key]
+	if len(replicas) == 0 {
+		return nil
+	}
+	pods := make([]string, 0, len(replicas))
+	for pod := range replicas {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+	state := make([]redisfailoverv1.StuckReplicaState, 0, len(pods))
+	for _, pod := range pods {
+		replica := replicas[pod]
+		state = append(state, redisfailoverv1.StuckReplicaState{
+			Pod:            pod,
+			Checks:         int32(replica.checks),
+			Step:           int32(replica.step),
+			PartialSyncOK:  replica.partialOK,
+			PartialSyncErr: replica.partialErr,
+		})
+	}
+	return state
+}
+
+// Restore sets the stuck replicas of the RF stored before a restart of the operator, unless the
+// tracker already knows them.
+func (t *StuckReplicaTracker) Restore(key string, state []redisfailoverv1.StuckReplicaState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.replicas[key]; ok || len(state) == 0 {
+		return
+	}
+	replicas := make(map[string]*stuckReplica, len(state))
+	for _, replica := range state {
+		replicas[replica.Pod] = &stuckReplica{
+			checks:     int(replica.Checks),
+			step:       StuckReplicaStep(replica.Step),
+			partialOK:  replica.PartialSyncOK,
+			partialErr: replica.PartialSyncErr,
+		}
+	}
+	t.replicas[key] = replicas
+}
+
+// Clear forgets the stuck replicas of the RF.
+func (t *StuckReplicaTracker) Clear(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.replicas, key)
+}
+
+// getInfoField returns the value of a field of the output of the INFO command.
+func getInfoField(info, field string) (string, bool) {
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if value := strings.TrimPrefix(line, field+":"); value != line {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// getInfoInt returns the value of a numeric field of the output of the INFO command, 0 when it's missing.
+func getInfoInt(info, field string) int64 {
+	value, ok := getInfoField(info, field)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(value, 10, 64)
+	return n
+}
+
+// isReplicaStuck returns true when the INFO of a replica reports the link to the master down and
+// it's not making a full sync, which also keeps the link down until it's done.
+func isReplicaStuck(info string) bool {
+	link, ok := getInfoField(info, "master_link_status")
+	if !ok || link != "down" {
+		return false
+	}
+	syncing, _ := getInfoField(info, "master_sync_in_progress")
+	return syncing != "1"
+}
+
+// raisedReplBacklogSize returns the repl-backlog-size to set on the master, the double of the
+// current one up to 1gb. It returns false when it can't be raised, because it's already at the
+// maximum or it's set in the custom config, which would revert it on the next check.
+func raisedReplBacklogSize(masterInfo string, rf *redisfailoverv1.RedisFailover) (int64, bool) {
+	for _, config := range rf.Spec.Redis.CustomConfig {
+		if strings.HasPrefix(strings.TrimSpace(config), replBacklogSizeParameter+" ") {
+			return 0, false
+		}
+	}
+	current := getInfoInt(masterInfo, "repl_backlog_size")
+	if current <= 0 || current >= maxReplBacklogSize {
+		return 0, false
+	}
+	raised := current * 2
+	if raised > maxReplBacklogSize {
+		raised = maxReplBacklogSize
+	}
+	return raised, true
+}
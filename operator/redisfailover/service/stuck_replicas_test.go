@@ -0,0 +1,284 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+)
+
+// observe observes the stuck replicas and applies the escalations due.
+func observe(tracker *rfservice.StuckReplicaTracker, key string, stuck []string, partialOK, partialErr int64, threshold int) []rfservice.StuckReplicaEscalation {
+	escalations := tracker.Observe(key, stuck, partialOK, partialErr, threshold)
+	for _, escalation := range escalations {
+		tracker.Escalated(key, escalation)
+	}
+	return escalations
+}
+
+func TestStuckReplicaTracker(t *testing.T) {
+	assert := assert.New(t)
+
+	tracker := rfservice.NewStuckReplicaTracker()
+	retry := func(pod string) rfservice.StuckReplicaEscalation {
+		return rfservice.StuckReplicaEscalation{Pod: pod, Step: rfservice.StuckReplicaRetry}
+	}
+	restart := func(pod string) rfservice.StuckReplicaEscalation {
+		return rfservice.StuckReplicaEscalation{Pod: pod, Step: rfservice.StuckReplicaRestart}
+	}
+
+	// The replication is retried when the threshold is reached, then the replica is restarted.
+	assert.Empty(observe(tracker, "ns/rf", []string{"rfr-test-1"}, 0, 0, 2))
+	assert.Equal([]rfservice.StuckReplicaEscalation{retry("rfr-test-1")}, observe(tracker, "ns/rf", []string{"rfr-test-1"}, 0, 0, 2))
+	assert.Empty(observe(tracker, "ns/rf", []string{"rfr-test-1", "rfr-test-2"}, 0, 0, 2))
+	assert.Equal([]rfservice.StuckReplicaEscalation{restart("rfr-test-1"), retry("rfr-test-2")}, observe(tracker, "ns/rf", []string{"rfr-test-1", "rfr-test-2"}, 0, 0, 2))
+
+	// A single replica is restarted by check.
+	assert.Empty(observe(tracker, "ns/rf", []string{"rfr-test-1", "rfr-test-2"}, 0, 0, 2))
+	assert.Equal([]rfservice.StuckReplicaEscalation{restart("rfr-test-1")}, observe(tracker, "ns/rf", []string{"rfr-test-1", "rfr-test-2"}, 0, 0, 2))
+	assert.Equal([]rfservice.StuckReplicaEscalation{restart("rfr-test-2")}, observe(tracker, "ns/rf", []string{"rfr-test-1", "rfr-test-2"}, 0, 0, 2))
+
+	// A replica recovered is forgotten, its ladder starts over.
+	assert.Empty(observe(tracker, "ns/rf", []string{"rfr-test-2"}, 0, 0, 2))
+	assert.Empty(observe(tracker, "ns/rf", []string{"rfr-test-1"}, 0, 0, 2))
+	assert.Equal([]rfservice.StuckReplicaEscalation{retry("rfr-test-1")}, observe(tracker, "ns/rf", []string{"rfr-test-1"}, 0, 0, 2))
+
+	// The partial syncs failing more than succeeding since the replica got stuck are reported.
+	assert.Empty(observe(tracker, "ns/other", []string{"rfr-other-1"}, 10, 5, 2))
+	assert.Equal([]rfservice.StuckReplicaEscalation{{Pod: "rfr-other-1", Step: rfservice.StuckReplicaRetry, PartialSyncFailures: true}}, observe(tracker, "ns/other", []string{"rfr-other-1"}, 11, 8, 2))
+}
+
+func TestStuckReplicaTrackerEscalationNotApplied(t *testing.T) {
+	assert := assert.New(t)
+
+	tracker := rfservice.NewStuckReplicaTracker()
+	retry := rfservice.StuckReplicaEscalation{Pod: "rfr-test-1", Step: rfservice.StuckReplicaRetry}
+
+	// The retry not applied is due again on the next check, the replica is not restarted before it.
+	assert.Empty(tracker.Observe("ns/rf", []string{"rfr-test-1"}, 0, 0, 2))
+	assert.Equal([]rfservice.StuckReplicaEscalation{retry}, tracker.Observe("ns/rf", []string{"rfr-test-1"}, 0, 0, 2))
+	assert.Equal([]rfservice.StuckReplicaEscalation{retry}, tracker.Observe("ns/rf", []string{"rfr-test-1"}, 0, 0, 2))
+	tracker.Escalated("ns/rf", retry)
+	assert.Empty(tracker.Observe("ns/rf", []string{"rfr-test-1"}, 0, 0, 2))
+	assert.Equal([]rfservice.StuckReplicaEscalation{{Pod: "rfr-test-1", Step: rfservice.StuckReplicaRestart}}, tracker.Observe("ns/rf", []string{"rfr-test-1"}, 0, 0, 2))
+}
+
+func TestStuckReplicaTrackerState(t *testing.T) {
+	assert := assert.New(t)
+
+	tracker := rfservice.NewStuckReplicaTracker()
+	assert.Nil(tracker.State("ns/rf"))
+	observe(tracker, "ns/rf", []string{"rfr-test-2", "rfr-test-1"}, 10, 5, 2)
+	observe(tracker, "ns/rf", []string{"rfr-test-2", "rfr-test-1"}, 10, 5, 2)
+	observe(tracker, "ns/rf", []string{"rfr-test-2", "rfr-test-1"}, 10, 5, 2)
+	state := tracker.State("ns/rf")
+	assert.Equal([]redisfailoverv1.StuckReplicaState{
+		{Pod: "rfr-test-1", Checks: 1, Step: int32(rfservice.StuckReplicaRetry), PartialSyncOK: 10, PartialSyncErr: 5},
+		{Pod: "rfr-test-2", Checks: 1, Step: int32(rfservice.StuckReplicaRetry), PartialSyncOK: 10, PartialSyncErr: 5},
+	}, state)
+
+	// The ladder goes on from the state restored, the replica is restarted on the next threshold.
+	restored := rfservice.NewStuckReplicaTracker()
+	restored.Restore("ns/rf", state)
+	assert.Equal(state, restored.State("ns/rf"))
+	assert.Equal([]rfservice.StuckReplicaEscalation{{Pod: "rfr-test-1", Step: rfservice.StuckReplicaRestart}}, restored.Observe("ns/rf", []string{"rfr-test-1", "rfr-test-2"}, 10, 5, 2))
+}
+
+func TestPlanStuckReplicas(t *testing.T) {
+	linkDown := "# Replication\r\nrole:slave\r\nmaster_link_status:down\r\nmaster_sync_in_progress:0\r\n"
+	linkUp := "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nmaster_sync_in_progress:0\r\n"
+	syncing := "# Replication\r\nrole:slave\r\nmaster_link_status:down\r\nmaster_sync_in_progress:1\r\n"
+	masterInfo := func(partialOK, partialErr int) string {
+		return fmt.Sprintf("# Stats\r\nsync_partial_ok:%d\r\nsync_partial_err:%d\r\n# Replication\r\nrole:master\r\nrepl_backlog_size:1048576\r\n", partialOK, partialErr)
+	}
+
+	tests := []struct {
+		name         string
+		customConfig []string
+		threshold    int32
+		// checks are the INFO of the master and the replica on every check.
+		checks     [][2]string
+		expActions []string
+		expBacklog bool
+		// blocked is the number of evictions of the replica refused by the pdb.
+		blocked int
+		// suppressed are the checks whose actions lose against a deletion of the pod planned first.
+		suppressed map[int]bool
+	}{
+		{
+			name:      "A stuck replica is retried then restarted",
+			threshold: 2,
+			checks: [][2]string{
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkDown},
+			},
+			expActions: []string{
+				"reconfigure pod rfr-test-1: retry the replication from 0.0.0.0, the link to the master is down for 2 checks",
+				"delete pod rfr-test-1: restart it, the link to the master is still down after retrying the replication from 0.0.0.0",
+			},
+		},
+		{
+			name:      "A restart refused by the pdb is retried on the next check",
+			threshold: 2,
+			checks: [][2]string{
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkDown},
+			},
+			expActions: []string{
+				"reconfigure pod rfr-test-1: retry the replication from 0.0.0.0, the link to the master is down for 2 checks",
+				"delete pod rfr-test-1: restart it, the link to the master is still down after retrying the replication from 0.0.0.0",
+				"delete pod rfr-test-1: restart it, the link to the master is still down after retrying the replication from 0.0.0.0",
+			},
+			blocked: 1,
+		},
+		{
+			name:      "A retry suppressed by another action is planned again before the restart",
+			threshold: 2,
+			checks: [][2]string{
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkDown},
+			},
+			expActions: []string{
+				"reconfigure pod rfr-test-1: retry the replication from 0.0.0.0, the link to the master is down for 2 checks",
+				"reconfigure pod rfr-test-1: retry the replication from 0.0.0.0, the link to the master is down for 2 checks",
+				"delete pod rfr-test-1: restart it, the link to the master is still down after retrying the replication from 0.0.0.0",
+			},
+			suppressed: map[int]bool{1: true},
+		},
+		{
+			name:      "The backlog is raised when the partial syncs fail",
+			threshold: 2,
+			checks: [][2]string{
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 3), linkDown},
+			},
+			expActions: []string{
+				"apply config pod rfr-test-0: raise the repl-backlog-size to 2097152, the partial syncs of the stuck replicas are failing",
+				"reconfigure pod rfr-test-1: retry the replication from 0.0.0.0, the link to the master is down for 2 checks",
+			},
+			expBacklog: true,
+		},
+		{
+			name:         "The backlog set in the custom config is not raised",
+			customConfig: []string{"repl-backlog-size 1mb"},
+			threshold:    2,
+			checks: [][2]string{
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 3), linkDown},
+			},
+			expActions: []string{
+				"reconfigure pod rfr-test-1: retry the replication from 0.0.0.0, the link to the master is down for 2 checks",
+			},
+		},
+		{
+			name:      "Replicas recovering or making a full sync are not stuck",
+			threshold: 2,
+			checks: [][2]string{
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), linkUp},
+				{masterInfo(0, 0), linkDown},
+				{masterInfo(0, 0), syncing},
+				{masterInfo(0, 0), linkDown},
+			},
+			expActions: []string{},
+		},
+		{
+			name:      "A disabled threshold remediates nothing",
+			threshold: 0,
+			checks: [][2]string{
+				{},
+				{},
+				{},
+			},
+			expActions: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.CustomConfig = test.customConfig
+			rf.Spec.Failover.StuckReplicas.Threshold = &test.threshold
+			rf.Spec.Failover.StuckReplicas.RaiseBacklog = true
+
+			pods := &corev1.PodList{
+				Items: []corev1.Pod{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"},
+						Status:     corev1.PodStatus{PodIP: "0.0.0.0", Phase: corev1.PodRunning},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"},
+						Status:     corev1.PodStatus{PodIP: "0.0.0.1", Phase: corev1.PodRunning},
+					},
+				},
+			}
+
+			check := 0
+			ms := &mK8SService.Services{}
+			mr := &mRedisService.Client{}
+			if test.threshold > 0 {
+				ms.On("GetStatefulSetPods", mock.Anything, namespace, rfservice.GetRedisName(rf)).Return(pods, nil)
+				mr.On("GetRedisInfo", "0.0.0.0", "0", "").Return(func(string, string, string) string { return test.checks[check][0] }, nil)
+				mr.On("GetRedisInfo", "0.0.0.1", "0", "").Return(func(string, string, string) string { return test.checks[check][1] }, nil)
+			}
+			mr.On("MakeSlaveOfWithPort", "0.0.0.1", "0.0.0.0", "0", "").Maybe().Return(nil)
+			if test.blocked > 0 {
+				ms.On("EvictPod", mock.Anything, namespace, "rfr-test-1").Times(test.blocked).Return(errEvictionBlocked)
+			}
+			ms.On("EvictPod", mock.Anything, namespace, "rfr-test-1").Maybe().Return(nil)
+			if test.expBacklog {
+				mr.On("SetCustomRedisConfig", "0.0.0.0", "0", []string{"repl-backlog-size 2097152"}, "").Once().Return(nil)
+			}
+
+			healer := rfservice.NewRedisFailoverHealer(ms, mr, log.DummyLogger{})
+
+			taken := []string{}
+			for check = range test.checks {
+				actions, err := healer.PlanStuckReplicas(context.TODO(), "0.0.0.0", rf)
+				assert.NoError(err)
+				for _, action := range actions {
+					taken = append(taken, action.String())
+				}
+				plan := rfservice.NewPodActionPlan()
+				if test.suppressed[check] {
+					// The deletion of the updater wins over the retry, its pod is left to the statefulset.
+					plan.Add(rfservice.PodAction{Pod: "rfr-test-1", Kind: rfservice.PodActionDelete, Reason: "update it", Apply: func() error { return nil }})
+				}
+				plan.Add(actions...)
+				if err := applyPodActions(plan.Actions()); !k8s.IsEvictionBlocked(err) {
+					assert.NoError(err)
+				}
+			}
+
+			assert.Equal(test.expActions, taken)
+			mr.AssertExpectations(t)
+			ms.AssertExpectations(t)
+			ms.AssertNotCalled(t, "DeletePod", mock.Anything, mock.Anything, mock.Anything)
+			if test.threshold <= 0 {
+				mr.AssertNotCalled(t, "GetRedisInfo", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// SyncSlotQueue keeps, for every RF, the replicas waiting to be pointed to the master because it's
+// already making as many full syncs as allowed. The replicas waiting the longest are admitted
+// first, so a replica can't be starved by others that broke later.
+type SyncSlotQueue struct {
+	now func() time.Time
+
+	mu sync.Mutex
+	// waiting holds when every waiting replica was first deferred, by RF.
+	waiting map[string]map[string]time.Time
+}
+
+// NewSyncSlotQueue returns a new sync slot queue that uses now to know when the replicas started waiting.
+func NewSyncSlotQueue(now func() time.Time) *SyncSlotQueue {
+	return &SyncSlotQueue{
+		now:     now,
+		waiting: map[string]map[string]time.Time{},
+	}
+}
+
+// Admit returns the replicas that can start a full sync now given the free sync slots, the ones
+// waiting the longest first. The rest are kept waiting. The replicas not in the list don't need
+// to be pointed to the master anymore and are forgotten.
+func (q *SyncSlotQueue) Admit(key string, replicas []string, free int) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	waiting := make(map[string]time.Time, len(replicas))
+	for _, replica := range replicas {
+		since, ok := q.waiting[key][replica]
+		if !ok {
+			since = now
+		}
+		waiting[replica] = since
+	}
+
+	queue := make([]string, 0, len(waiting))
+	for replica := range waiting {
+		queue = append(queue, replica)
+	}
+	sortByWaitingTime(queue, waiting)
+
+	if free < 0 {
+		free = 0
+	}
+	if free > len(queue) {
+		free = len(queue)
+	}
+	admitted := queue[:free]
+	for _, replica := range admitted {
+		delete(waiting, replica)
+	}
+
+	if len(waiting) == 0 {
+		delete(q.waiting, key)
+	} else {
+		q.waiting[key] = waiting
+	}
+	return admitted
+}
+
+// Waiting returns the replicas waiting for a sync slot, the ones waiting the longest first.
+func (q *SyncSlotQueue) Waiting(key string) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue := []string{}
+	for replica := range q.waiting[key] {
+		queue = append(queue, replica)
+	}
+	sortByWaitingTime(queue, q.waiting[key])
+	return queue
+}
+
+// Clear forgets the replicas waiting for a sync slot.
+func (q *SyncSlotQueue) Clear(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.waiting, key)
+}
+
+// sortByWaitingTime sorts the replicas by the time they started waiting, ties are broken by name
+// so the order is stable between checks.
+func sortByWaitingTime(replicas []string, since map[string]time.Time) {
+	sort.Slice(replicas, func(i, j int) bool {
+		ti, tj := since[replicas[i]], since[replicas[j]]
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return replicas[i] < replicas[j]
+	})
+}
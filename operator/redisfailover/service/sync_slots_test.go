@@ -0,0 +1,117 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.now = f.now.Add(d)
+}
+
+func TestSyncSlotQueueAdmitsOldestFirst(t *testing.T) {
+	assert := assert.New(t)
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	q := rfservice.NewSyncSlotQueue(clock.Now)
+
+	// The master is syncing, nobody is admitted.
+	assert.Empty(q.Admit("ns/rf", []string{"10.0.0.3"}, 0))
+	clock.Advance(time.Minute)
+	assert.Empty(q.Admit("ns/rf", []string{"10.0.0.3", "10.0.0.1"}, 0))
+	clock.Advance(time.Minute)
+	assert.Empty(q.Admit("ns/rf", []string{"10.0.0.2", "10.0.0.3", "10.0.0.1"}, 0))
+	assert.Equal([]string{"10.0.0.3", "10.0.0.1", "10.0.0.2"}, q.Waiting("ns/rf"))
+
+	// A slot is freed, the replica waiting the longest goes first even if it sorts last.
+	clock.Advance(time.Minute)
+	assert.Equal([]string{"10.0.0.3"}, q.Admit("ns/rf", []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, 1))
+	assert.Equal([]string{"10.0.0.1", "10.0.0.2"}, q.Waiting("ns/rf"))
+
+	// A replica breaking later can't jump ahead of the ones already waiting.
+	clock.Advance(time.Minute)
+	assert.Equal([]string{"10.0.0.1"}, q.Admit("ns/rf", []string{"10.0.0.0", "10.0.0.1", "10.0.0.2"}, 1))
+	clock.Advance(time.Minute)
+	assert.Equal([]string{"10.0.0.2"}, q.Admit("ns/rf", []string{"10.0.0.0", "10.0.0.2"}, 1))
+	clock.Advance(time.Minute)
+	assert.Equal([]string{"10.0.0.0"}, q.Admit("ns/rf", []string{"10.0.0.0"}, 1))
+	assert.Empty(q.Waiting("ns/rf"))
+}
+
+func TestSyncSlotQueueForgetsFixedReplicas(t *testing.T) {
+	assert := assert.New(t)
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	q := rfservice.NewSyncSlotQueue(clock.Now)
+
+	assert.Empty(q.Admit("ns/rf", []string{"10.0.0.1"}, 0))
+	clock.Advance(time.Minute)
+	assert.Empty(q.Admit("ns/rf", []string{"10.0.0.1", "10.0.0.2"}, 0))
+
+	// 10.0.0.1 got the right master by other means, when it breaks again it waits from scratch.
+	clock.Advance(time.Minute)
+	assert.Empty(q.Admit("ns/rf", []string{"10.0.0.2"}, 0))
+	clock.Advance(time.Minute)
+	assert.Equal([]string{"10.0.0.2"}, q.Admit("ns/rf", []string{"10.0.0.1", "10.0.0.2"}, 1))
+	assert.Equal([]string{"10.0.0.1"}, q.Waiting("ns/rf"))
+}
+
+func TestSyncSlotQueueAdmit(t *testing.T) {
+	tests := []struct {
+		name        string
+		replicas    []string
+		free        int
+		expAdmitted []string
+		expWaiting  []string
+	}{
+		{
+			name:        "More free slots than replicas admits all of them",
+			replicas:    []string{"10.0.0.2", "10.0.0.1"},
+			free:        3,
+			expAdmitted: []string{"10.0.0.1", "10.0.0.2"},
+			expWaiting:  []string{},
+		},
+		{
+			name:        "Negative free slots, when more replicas than allowed are syncing, admits none",
+			replicas:    []string{"10.0.0.1"},
+			free:        -1,
+			expAdmitted: []string{},
+			expWaiting:  []string{"10.0.0.1"},
+		},
+		{
+			name:        "No replicas clears the queue",
+			replicas:    []string{},
+			free:        1,
+			expAdmitted: []string{},
+			expWaiting:  []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+			q := rfservice.NewSyncSlotQueue(clock.Now)
+			q.Admit("ns/rf", []string{"10.0.0.9"}, 0)
+			q.Admit("ns/other", []string{"10.0.0.9"}, 0)
+
+			assert.Equal(test.expAdmitted, q.Admit("ns/rf", test.replicas, test.free))
+			assert.Equal(test.expWaiting, q.Waiting("ns/rf"))
+			// Other RFs have their own queue.
+			assert.Equal([]string{"10.0.0.9"}, q.Waiting("ns/other"))
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// TeardownStages are the stages of the ordered teardown of a deleted RF, by the reason of the Terminating
+// condition they set. The sentinels go first so they don't fail the master over while the redis pods are
+// deleted, the claims of the redis volumes last once no pod mounts them.
+var TeardownStages = []string{
+	redisfailoverv1.ReasonDeletingSentinels,
+	redisfailoverv1.ReasonDeletingRedis,
+	redisfailoverv1.ReasonDeletingObjects,
+	redisfailoverv1.ReasonDeletingVolumes,
+}
+
+// Teardown deletes the objects of the stage of the teardown of the deleted RF, and returns what the stage
+// waits for, empty once it's done. A stage only deletes the objects still there, it's run again until it's
+// done, after a restart of the operator too.
+func (r *RedisFailoverKubeClient) Teardown(ctx context.Context, rf *redisfailoverv1.RedisFailover, stage string) (string, error) {
+	// The names of the objects don't depend on the password, only the configuration does.
+	state, err := BuildDesiredState(rf, nil, nil, "")
+	if err != nil {
+		return "", err
+	}
+	switch stage {
+	case redisfailoverv1.ReasonDeletingSentinels:
+		return r.teardownWorkload(ctx, rf, state, KindDeployment, sentinelRoleName)
+	case redisfailoverv1.ReasonDeletingRedis:
+		return r.teardownWorkload(ctx, rf, state, KindStatefulSet, redisRoleName)
+	case redisfailoverv1.ReasonDeletingObjects:
+		return "", r.teardownObjects(ctx, rf, state)
+	case redisfailoverv1.ReasonDeletingVolumes:
+		return "", r.teardownVolumes(ctx, rf, state)
+	}
+	return "", fmt.Errorf("unknown teardown stage %s", stage)
+}
+
+// teardownWorkload deletes the workload of the kind with its autoscaler and its PodDisruptionBudget, and
+// waits for its pods to be gone.
+func (r *RedisFailoverKubeClient) teardownWorkload(ctx context.Context, rf *redisfailoverv1.RedisFailover, state *DesiredState, kind string, component string) (string, error) {
+	workload, ok := desiredObject(state, kind)
+	if !ok {
+		return "", nil
+	}
+	// The autoscaler goes first so it doesn't scale the workload being deleted.
+	for _, k := range []string{KindHPA, kind, KindPodDisruptionBudget} {
+		if _, ok := desiredObjectNamed(state, k, workload.Name); !ok {
+			continue
+		}
+		if err := r.ensureAbsent(ctx, rf.Namespace, ObjectRef{Kind: k, Name: workload.Name}); err != nil {
+			return "", err
+		}
+	}
+
+	var selector map[string]string
+	switch w := workload.Object.(type) {
+	case *appsv1.Deployment:
+		selector = w.Spec.Selector.MatchLabels
+	case *appsv1.StatefulSet:
+		selector = w.Spec.Selector.MatchLabels
+	}
+	pods, err := r.K8SService.ListPods(ctx, rf.Namespace)
+	if err != nil {
+		return "", err
+	}
+	left := 0
+	for _, pod := range pods.Items {
+		if labels.SelectorFromSet(selector).Matches(labels.Set(pod.Labels)) {
+			left++
+		}
+	}
+	if left > 0 {
+		return fmt.Sprintf("waiting for %d %s pods to terminate", left, component), nil
+	}
+	return "", nil
+}
+
+// teardownObjects deletes the objects of the RF other than the workloads, and the configuration revisions
+// of the immutableConfig.
+func (r *RedisFailoverKubeClient) teardownObjects(ctx context.Context, rf *redisfailoverv1.RedisFailover, state *DesiredState) error {
+	for _, o := range state.Objects {
+		if IsWorkload(o.Kind) {
+			continue
+		}
+		if err := r.ensureAbsent(ctx, rf.Namespace, o.Ref()); err != nil {
+			return err
+		}
+	}
+	for _, component := range []string{redisRoleName, sentinelRoleName} {
+		cms, err := r.K8SService.ListConfigMapsBySelector(ctx, rf.Namespace, generateSelectorLabels(component, rf.Name))
+		if err != nil {
+			return err
+		}
+		for _, cm := range cms.Items {
+			if _, ok := cm.Labels[ConfigRevisionLabel]; !ok {
+				continue
+			}
+			if err := r.K8SService.DeleteConfigMap(ctx, rf.Namespace, cm.Name); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// teardownVolumes deletes the claims of the redis volumes, unless they are kept after the deletion.
+func (r *RedisFailoverKubeClient) teardownVolumes(ctx context.Context, rf *redisfailoverv1.RedisFailover, state *DesiredState) error {
+	if rf.Spec.Redis.Storage.KeepAfterDeletion {
+		return nil
+	}
+	o, ok := desiredObject(state, KindStatefulSet)
+	if !ok {
+		return nil
+	}
+	ss := o.Object.(*appsv1.StatefulSet)
+	if len(ss.Spec.VolumeClaimTemplates) == 0 {
+		return nil
+	}
+	pvcs, err := r.K8SService.ListPVCs(ctx, rf.Namespace, labels.SelectorFromSet(ss.Spec.Selector.MatchLabels))
+	if err != nil {
+		return err
+	}
+	for _, pvc := range pvcs.Items {
+		if pvc.DeletionTimestamp != nil {
+			continue
+		}
+		for _, template := range ss.Spec.VolumeClaimTemplates {
+			// The claims of a statefulset are named <template>-<statefulset>-<ordinal>.
+			if !strings.HasPrefix(pvc.Name, template.Name+"-"+ss.Name+"-") {
+				continue
+			}
+			if err := r.K8SService.DeletePVC(ctx, rf.Namespace, pvc.Name); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// desiredObject returns the first object of the kind of the desired state.
+func desiredObject(state *DesiredState, kind string) (DesiredObject, bool) {
+	for _, o := range state.Objects {
+		if o.Kind == kind {
+			return o, true
+		}
+	}
+	return DesiredObject{}, false
+}
+
+// desiredObjectNamed returns the object of the kind and the name of the desired state.
+func desiredObjectNamed(state *DesiredState, kind, name string) (DesiredObject, bool) {
+	for _, o := range state.Objects {
+		if o.Kind == kind && o.Name == name {
+			return o, true
+		}
+	}
+	return DesiredObject{}, false
+}
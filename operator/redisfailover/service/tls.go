@@ -0,0 +1,207 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/operator/redisfailover/util"
+	"redis-operator/service/k8s"
+)
+
+// variables refering to the TLS port of the redis pods
+const (
+	redisTLSPortName   = "redis-tls"
+	redisTLSVolumeName = "redis-tls"
+	redisTLSDir        = "/tls"
+)
+
+// variables refering to the stunnel sidecar serving the TLS port for redis
+const (
+	redisTLSSidecarContainerName     = "redis-stunnel"
+	redisTLSSidecarVolumeName        = "redis-stunnel-config"
+	redisTLSSidecarDir               = "/stunnel"
+	redisTLSSidecarConfigFileName    = "stunnel.conf"
+	redisTLSSidecarDefaultRequestCPU = "10m"
+	redisTLSSidecarDefaultLimitCPU   = "100m"
+	redisTLSSidecarDefaultMemory     = "32Mi"
+)
+
+var redisTLSSidecarDefaultResourceRequirements = corev1.ResourceRequirements{
+	Limits: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(redisTLSSidecarDefaultLimitCPU),
+		corev1.ResourceMemory: resource.MustParse(redisTLSSidecarDefaultMemory),
+	},
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(redisTLSSidecarDefaultRequestCPU),
+		corev1.ResourceMemory: resource.MustParse(redisTLSSidecarDefaultMemory),
+	},
+}
+
+// GetRedisTLSSecretName returns the name of the secret holding the certificate of the redis pods.
+func GetRedisTLSSecretName(rf *redisfailoverv1.RedisFailover) string {
+	if rf.Spec.TLSConfig != nil && rf.Spec.TLSConfig.SecretName != "" {
+		return rf.Spec.TLSConfig.SecretName
+	}
+	return GetRedisTLSName(rf)
+}
+
+// generateRedisCertificate returns the cert-manager Certificate of the redis pods, written by the issuer
+// of the spec in the TLS secret. It's valid for the pods behind the redis service, the master service
+// and the external hostname of the master.
+func generateRedisCertificate(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *unstructured.Unstructured {
+	labels = util.MergeLabels(labels, generateSelectorLabels(redisRoleName, rf.Name))
+
+	redisService := fmt.Sprintf("%s.%s.svc", GetRedisName(rf), rf.Namespace)
+	dnsNames := []interface{}{
+		redisService,
+		"*." + redisService,
+		fmt.Sprintf("%s.%s.svc", GetRedisMasterName(rf), rf.Namespace),
+	}
+	if rf.Spec.Redis.MasterDNS != nil && rf.Spec.Redis.MasterDNS.Hostname != "" {
+		dnsNames = append(dnsNames, rf.Spec.Redis.MasterDNS.Hostname)
+	}
+	issuer := rf.Spec.TLSConfig.IssuerRef
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(k8s.CertificateGVR.GroupVersion().WithKind(KindCertificate))
+	certificate.SetName(GetRedisTLSName(rf))
+	certificate.SetNamespace(rf.Namespace)
+	certificate.SetLabels(labels)
+	certificate.SetOwnerReferences(ownerRefs)
+	certificate.Object["spec"] = map[string]interface{}{
+		"secretName": GetRedisTLSSecretName(rf),
+		"dnsNames":   dnsNames,
+		"issuerRef": map[string]interface{}{
+			"name":  issuer.Name,
+			"kind":  issuer.Kind,
+			"group": issuer.Group,
+		},
+	}
+	return certificate
+}
+
+// addRedisTLS mounts the TLS secret in the redis container and serves redis over TLS on its port. The
+// redis arguments are only added to the default command, a custom command reads the certificate itself.
+// With the TLS sidecar, the secret is mounted in the sidecar serving the port instead.
+func addRedisTLS(ss *appsv1.StatefulSet, rf *redisfailoverv1.RedisFailover) {
+	ss.Spec.Template.Spec.Volumes = append(ss.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: redisTLSVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: GetRedisTLSSecretName(rf),
+			},
+		},
+	})
+	if rf.TLSSidecarEnabled() {
+		addRedisTLSSidecar(ss, rf)
+		return
+	}
+
+	redis := &ss.Spec.Template.Spec.Containers[0]
+	redis.VolumeMounts = append(redis.VolumeMounts, corev1.VolumeMount{
+		Name:      redisTLSVolumeName,
+		MountPath: redisTLSDir,
+		ReadOnly:  true,
+	})
+	redis.Ports = append(redis.Ports, corev1.ContainerPort{
+		Name:          redisTLSPortName,
+		ContainerPort: redisfailoverv1.RedisTLSPort,
+		Protocol:      corev1.ProtocolTCP,
+	})
+	if len(rf.Spec.Redis.Command) == 0 {
+		redis.Args = append(redis.Args,
+			"--tls-port", strconv.Itoa(redisfailoverv1.RedisTLSPort),
+			"--tls-cert-file", redisTLSDir+"/"+corev1.TLSCertKey,
+			"--tls-key-file", redisTLSDir+"/"+corev1.TLSPrivateKeyKey,
+			"--tls-auth-clients", "no",
+		)
+	}
+}
+
+// renderRedisTLSSidecarConfig returns the stunnel config of the TLS sidecar. It serves the certificate
+// on the TLS port of the pod, on IPv6 too when it's the preferred family, and forwards the connections to
+// the plain port of redis on the loopback. It runs in the foreground without a pid file, the root
+// filesystem can be read-only.
+func renderRedisTLSSidecarConfig(rf *redisfailoverv1.RedisFailover) string {
+	accept := strconv.Itoa(redisfailoverv1.RedisTLSPort)
+	if rf.Spec.Redis.PreferredIPFamily == corev1.IPv6Protocol {
+		accept = fmt.Sprintf(":::%d", redisfailoverv1.RedisTLSPort)
+	}
+	lines := []string{
+		"foreground = yes",
+		"pid =",
+		"",
+		"[redis]",
+		"accept = " + accept,
+		fmt.Sprintf("connect = 127.0.0.1:%d", rf.Spec.Redis.Port),
+		fmt.Sprintf("cert = %s/%s", redisTLSDir, corev1.TLSCertKey),
+		fmt.Sprintf("key = %s/%s", redisTLSDir, corev1.TLSPrivateKeyKey),
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// generateRedisTLSSidecarConfigMap returns the ConfigMap holding the stunnel config of the TLS sidecar.
+func generateRedisTLSSidecarConfigMap(rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) *corev1.ConfigMap {
+	labels = util.MergeLabels(labels, generateSelectorLabels(redisRoleName, rf.Name))
+	return &corev1.ConfigMap{
+		ObjectMeta: generateObjectMeta(GetRedisTLSSidecarName(rf), rf.Namespace, labels, nil, ownerRefs),
+		Data: map[string]string{
+			redisTLSSidecarConfigFileName: renderRedisTLSSidecarConfig(rf),
+		},
+	}
+}
+
+// addRedisTLSSidecar adds the stunnel sidecar serving the TLS port of the redis pods, for the redis
+// versions without native TLS.
+func addRedisTLSSidecar(ss *appsv1.StatefulSet, rf *redisfailoverv1.RedisFailover) {
+	sidecar := rf.Spec.Redis.TLSSidecar
+	resources := redisTLSSidecarDefaultResourceRequirements
+	if sidecar.Resources != nil {
+		resources = *sidecar.Resources
+	}
+	ss.Spec.Template.Spec.Containers = append(ss.Spec.Template.Spec.Containers, corev1.Container{
+		Name:            redisTLSSidecarContainerName,
+		Image:           sidecar.Image,
+		ImagePullPolicy: pullPolicy(sidecar.ImagePullPolicy),
+		SecurityContext: getContainerSecurityContext(rf, rf.Spec.Redis.ContainerSecurityContext),
+		Command:         []string{"stunnel", redisTLSSidecarDir + "/" + redisTLSSidecarConfigFileName},
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          redisTLSPortName,
+				ContainerPort: redisfailoverv1.RedisTLSPort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      redisTLSVolumeName,
+				MountPath: redisTLSDir,
+				ReadOnly:  true,
+			},
+			{
+				Name:      redisTLSSidecarVolumeName,
+				MountPath: redisTLSSidecarDir,
+				ReadOnly:  true,
+			},
+		},
+		Resources: resources,
+	})
+	ss.Spec.Template.Spec.Volumes = append(ss.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: redisTLSSidecarVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: GetRedisTLSSidecarName(rf),
+				},
+			},
+		},
+	})
+}
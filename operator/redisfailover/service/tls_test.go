@@ -0,0 +1,233 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestRedisStatefulSetTLS(t *testing.T) {
+	tests := []struct {
+		name      string
+		tlsConfig *redisfailoverv1.TLSConfig
+		command   []string
+		expSecret string
+		expArgs   []string
+	}{
+		{
+			name:      "Certificate issued by cert-manager",
+			tlsConfig: &redisfailoverv1.TLSConfig{Enabled: true, IssuerRef: &redisfailoverv1.TLSIssuerRef{Name: "ca"}},
+			expSecret: "rfr-tls-test",
+			expArgs:   []string{"--tls-port", "6380", "--tls-cert-file", "/tls/tls.crt", "--tls-key-file", "/tls/tls.key", "--tls-auth-clients", "no"},
+		},
+		{
+			name:      "Secret made by hand",
+			tlsConfig: &redisfailoverv1.TLSConfig{Enabled: true, SecretName: "redis-tls"},
+			expSecret: "redis-tls",
+			expArgs:   []string{"--tls-port", "6380", "--tls-cert-file", "/tls/tls.crt", "--tls-key-file", "/tls/tls.key", "--tls-auth-clients", "no"},
+		},
+		{
+			name:      "The custom command reads the certificate itself",
+			tlsConfig: &redisfailoverv1.TLSConfig{Enabled: true, IssuerRef: &redisfailoverv1.TLSIssuerRef{Name: "ca"}},
+			command:   []string{"redis-server", "/redis/redis.conf", "--tls-port", "6380"},
+			expSecret: "rfr-tls-test",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rf := generateRF()
+			rf.Spec.TLSConfig = test.tlsConfig
+			rf.Spec.Redis.Command = test.command
+			require.NoError(rf.Validate())
+
+			state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+			require.NoError(err)
+			var ss *appsv1.StatefulSet
+			for _, o := range state.Objects {
+				if o.Kind == rfservice.KindStatefulSet {
+					ss = o.Object.(*appsv1.StatefulSet)
+				}
+			}
+			require.NotNil(ss)
+
+			redis := ss.Spec.Template.Spec.Containers[0]
+			assert.Equal(test.expArgs, redis.Args)
+			assert.Contains(redis.Ports, corev1.ContainerPort{Name: "redis-tls", ContainerPort: 6380, Protocol: corev1.ProtocolTCP})
+			assert.Contains(redis.VolumeMounts, corev1.VolumeMount{Name: "redis-tls", MountPath: "/tls", ReadOnly: true})
+			assert.Contains(ss.Spec.Template.Spec.Volumes, corev1.Volume{
+				Name:         "redis-tls",
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: test.expSecret}},
+			})
+		})
+	}
+}
+
+func TestRedisTLSSidecarConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		port      int32
+		family    corev1.IPFamily
+		expConfig string
+	}{
+		{
+			name: "Default port",
+			port: 6379,
+			expConfig: `foreground = yes
+pid =
+
+[redis]
+accept = 6380
+connect = 127.0.0.1:6379
+cert = /tls/tls.crt
+key = /tls/tls.key
+`,
+		},
+		{
+			name:   "Custom port on IPv6",
+			port:   7000,
+			family: corev1.IPv6Protocol,
+			expConfig: `foreground = yes
+pid =
+
+[redis]
+accept = :::6380
+connect = 127.0.0.1:7000
+cert = /tls/tls.crt
+key = /tls/tls.key
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.Image = "redis:5.0.14-alpine"
+			rf.Spec.Redis.Port = test.port
+			rf.Spec.Redis.PreferredIPFamily = test.family
+			rf.Spec.TLSConfig = &redisfailoverv1.TLSConfig{Enabled: true, SecretName: "redis-tls"}
+			rf.Spec.Redis.TLSSidecar = &redisfailoverv1.RedisTLSSidecar{Enabled: true, Image: "stunnel:5"}
+			require.NoError(rf.Validate())
+
+			state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+			require.NoError(err)
+			cm, ok := desiredConfigMaps(state)["rfr-stunnel-test"]
+			require.True(ok)
+			assert.Equal(map[string]string{"stunnel.conf": test.expConfig}, cm.Data)
+			assert.NotContains(state.Absent, rfservice.ObjectRef{Kind: rfservice.KindConfigMap, Name: "rfr-stunnel-test"})
+		})
+	}
+}
+
+func TestRedisStatefulSetTLSSidecar(t *testing.T) {
+	resources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")},
+	}
+
+	tests := []struct {
+		name         string
+		sidecar      *redisfailoverv1.RedisTLSSidecar
+		expSidecar   bool
+		expResources corev1.ResourceRequirements
+	}{
+		{
+			name:       "Native TLS",
+			sidecar:    &redisfailoverv1.RedisTLSSidecar{Image: "stunnel:5"},
+			expSidecar: false,
+		},
+		{
+			name:       "Sidecar with the default resources",
+			sidecar:    &redisfailoverv1.RedisTLSSidecar{Enabled: true, Image: "stunnel:5"},
+			expSidecar: true,
+			expResources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("32Mi"),
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("10m"),
+					corev1.ResourceMemory: resource.MustParse("32Mi"),
+				},
+			},
+		},
+		{
+			name:         "Sidecar with its resources",
+			sidecar:      &redisfailoverv1.RedisTLSSidecar{Enabled: true, Image: "stunnel:5", Resources: &resources},
+			expSidecar:   true,
+			expResources: resources,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rf := generateRF()
+			rf.Spec.Redis.Image = "redis:5.0.14-alpine"
+			rf.Spec.TLSConfig = &redisfailoverv1.TLSConfig{Enabled: true, SecretName: "redis-tls"}
+			rf.Spec.Redis.TLSSidecar = test.sidecar
+			require.NoError(rf.Validate())
+
+			state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+			require.NoError(err)
+			var ss *appsv1.StatefulSet
+			for _, o := range state.Objects {
+				if o.Kind == rfservice.KindStatefulSet {
+					ss = o.Object.(*appsv1.StatefulSet)
+				}
+			}
+			require.NotNil(ss)
+
+			tlsPort := corev1.ContainerPort{Name: "redis-tls", ContainerPort: 6380, Protocol: corev1.ProtocolTCP}
+			tlsMount := corev1.VolumeMount{Name: "redis-tls", MountPath: "/tls", ReadOnly: true}
+			assert.Contains(ss.Spec.Template.Spec.Volumes, corev1.Volume{
+				Name:         "redis-tls",
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "redis-tls"}},
+			})
+			redis := ss.Spec.Template.Spec.Containers[0]
+			var sidecar *corev1.Container
+			for i, c := range ss.Spec.Template.Spec.Containers {
+				if c.Name == "redis-stunnel" {
+					sidecar = &ss.Spec.Template.Spec.Containers[i]
+				}
+			}
+			if !test.expSidecar {
+				assert.Nil(sidecar)
+				assert.Contains(redis.Ports, tlsPort)
+				assert.Contains(redis.Args, "--tls-port")
+				return
+			}
+
+			// Redis only serves its plain port, the sidecar serves the TLS one.
+			require.NotNil(sidecar)
+			assert.NotContains(redis.Ports, tlsPort)
+			assert.NotContains(redis.VolumeMounts, tlsMount)
+			assert.NotContains(redis.Args, "--tls-port")
+			assert.Equal("stunnel:5", sidecar.Image)
+			assert.Equal([]string{"stunnel", "/stunnel/stunnel.conf"}, sidecar.Command)
+			assert.Equal([]corev1.ContainerPort{tlsPort}, sidecar.Ports)
+			assert.Equal([]corev1.VolumeMount{tlsMount, {Name: "redis-stunnel-config", MountPath: "/stunnel", ReadOnly: true}}, sidecar.VolumeMounts)
+			assert.Equal(test.expResources, sidecar.Resources)
+			assert.Contains(ss.Spec.Template.Spec.Volumes, corev1.Volume{
+				Name: "redis-stunnel-config",
+				VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "rfr-stunnel-test"},
+				}},
+			})
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+var redisProxyDefaultResourceRequirements = corev1.ResourceRequirements{
+	Limits: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(redisProxyDefaultLimitCPU),
+		corev1.ResourceMemory: resource.MustParse(redisProxyDefaultMemory),
+	},
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(redisProxyDefaultRequestCPU),
+		corev1.ResourceMemory: resource.MustParse(redisProxyDefaultMemory),
+	},
+}
+
+// renderZeroDowntimeReloadConfig returns the redis config appended with the zero downtime reload. Redis
+// only listens on the socket shared with the proxy, and announces the address of its pod to the master
+// as the proxy hides it. The custom config only read on startup is written in the file, the in place
+// restarts apply it.
+func renderZeroDowntimeReloadConfig(rf *redisfailoverv1.RedisFailover) string {
+	lines := []string{
+		"port 0",
+		fmt.Sprintf("unixsocket %s", redisSocketPath),
+		"unixsocketperm 777",
+		fmt.Sprintf("replica-announce-port %d", rf.Spec.Redis.Port),
+	}
+	lines = append(lines, rf.RestartRequiredRedisConfig()...)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// addZeroDowntimeReload puts the proxy sidecar in front of the redis of the statefulset. The proxy takes
+// the redis port and forwards it to the socket redis listens on, so the redis process can be restarted
+// in its container while the clients connections are held.
+func addZeroDowntimeReload(ss *appsv1.StatefulSet, rf *redisfailoverv1.RedisFailover) {
+	socketMount := corev1.VolumeMount{
+		Name:      redisSocketVolumeName,
+		MountPath: redisSocketDir,
+	}
+
+	redis := &ss.Spec.Template.Spec.Containers[0]
+	redis.VolumeMounts = append(redis.VolumeMounts, socketMount)
+	redis.Env = append(redis.Env, corev1.EnvVar{
+		Name: "POD_IP",
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				FieldPath: "status.podIP",
+			},
+		},
+	})
+	redis.Args = []string{"--replica-announce-ip", "$(POD_IP)"}
+	ports := redis.Ports
+	redis.Ports = nil
+
+	resources := redisProxyDefaultResourceRequirements
+	if rf.Spec.Redis.ZeroDowntimeReload.Resources != nil {
+		resources = *rf.Spec.Redis.ZeroDowntimeReload.Resources
+	}
+	ss.Spec.Template.Spec.Containers = append(ss.Spec.Template.Spec.Containers, corev1.Container{
+		Name:            redisProxyContainerName,
+		Image:           rf.Spec.Redis.ZeroDowntimeReload.Image,
+		ImagePullPolicy: pullPolicy(rf.Spec.Redis.ZeroDowntimeReload.ImagePullPolicy),
+		SecurityContext: getContainerSecurityContext(rf, rf.Spec.Redis.ContainerSecurityContext),
+		Args: []string{
+			"proxy",
+			fmt.Sprintf("--listen=:%d", rf.Spec.Redis.Port),
+			fmt.Sprintf("--upstream=%s", redisSocketPath),
+		},
+		Ports:        ports,
+		VolumeMounts: []corev1.VolumeMount{socketMount},
+		Resources:    resources,
+	})
+	ss.Spec.Template.Spec.Volumes = append(ss.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: redisSocketVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+}
@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func TestRedisStatefulSetZeroDowntimeReload(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rf := generateRF()
+	rf.Spec.Redis.Port = 6379
+	rf.Spec.Redis.ZeroDowntimeReload.Enabled = true
+	rf.Spec.Redis.ZeroDowntimeReload.Image = "quay.io/spotahome/redis-operator:latest"
+	rf.Spec.Redis.CustomConfig = []string{"maxclients 100", "io-threads 4"}
+
+	state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+	require.NoError(err)
+
+	var ss *appsv1.StatefulSet
+	config := ""
+	for _, o := range state.Objects {
+		switch {
+		case o.Kind == rfservice.KindStatefulSet:
+			ss = o.Object.(*appsv1.StatefulSet)
+		case o.Kind == "ConfigMap" && o.Name == rfservice.GetRedisName(rf):
+			config = o.Object.(*corev1.ConfigMap).Data["redis.conf"]
+		}
+	}
+	require.NotNil(ss)
+
+	// Redis only listens on the socket, the config only read on startup is in the file.
+	assert.Contains(config, "\nport 0\nunixsocket /redis-socket/redis.sock\nunixsocketperm 777\nreplica-announce-port 6379\nio-threads 4\n")
+	assert.NotContains(config, "maxclients")
+
+	containers := ss.Spec.Template.Spec.Containers
+	require.Len(containers, 2)
+	redis, proxy := containers[0], containers[1]
+	assert.Empty(redis.Ports)
+	assert.Equal([]string{"--replica-announce-ip", "$(POD_IP)"}, redis.Args)
+	assert.Contains(redis.Env, corev1.EnvVar{
+		Name:      "POD_IP",
+		ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.podIP"}},
+	})
+	assert.Contains(redis.VolumeMounts, corev1.VolumeMount{Name: "redis-socket", MountPath: "/redis-socket"})
+
+	assert.Equal("redis-proxy", proxy.Name)
+	assert.Equal("quay.io/spotahome/redis-operator:latest", proxy.Image)
+	assert.Equal([]string{"proxy", "--listen=:6379", "--upstream=/redis-socket/redis.sock"}, proxy.Args)
+	assert.Equal([]corev1.ContainerPort{{Name: "redis", ContainerPort: 6379, Protocol: corev1.ProtocolTCP}}, proxy.Ports)
+	assert.Equal([]corev1.VolumeMount{{Name: "redis-socket", MountPath: "/redis-socket"}}, proxy.VolumeMounts)
+	assert.Contains(ss.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name:         "redis-socket",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+}
+
+func TestRedisStatefulSetZeroDowntimeReloadKeepsThePodTemplate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	template := func(customConfig ...string) corev1.PodTemplateSpec {
+		rf := generateRF()
+		rf.Spec.Redis.ZeroDowntimeReload.Enabled = true
+		rf.Spec.Redis.CustomConfig = customConfig
+		state, err := rfservice.BuildDesiredState(rf, nil, nil, "")
+		require.NoError(err)
+		for _, o := range state.Objects {
+			if o.Kind == rfservice.KindStatefulSet {
+				return o.Object.(*appsv1.StatefulSet).Spec.Template
+			}
+		}
+		require.Fail("no statefulset")
+		return corev1.PodTemplateSpec{}
+	}
+
+	// A change of io-threads is applied by an in place restart, the pods are not recreated.
+	assert.Equal(template("io-threads 1"), template("io-threads 4"))
+}
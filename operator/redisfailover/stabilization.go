@@ -0,0 +1,111 @@
+package redisfailover
+
+import (
+	"sync"
+	"time"
+)
+
+// FailoverStabilizer keeps, for every RF, when its master last changed, so the disruptive actions
+// on the redis pods are held while the new master stabilizes. The master changes made by the
+// sentinels are found comparing the master of every check with the one of the previous check.
+type FailoverStabilizer struct {
+	now func() time.Time
+
+	mu sync.Mutex
+	// masters are the masters seen on the last check, since when the last failover happened, by RF.
+	masters map[string]string
+	since   map[string]time.Time
+	// masterChanges counts the master changes seen, by RF.
+	masterChanges map[string]int64
+}
+
+// NewFailoverStabilizer returns a new failover stabilizer that uses now to know when the failovers happened.
+func NewFailoverStabilizer(now func() time.Time) *FailoverStabilizer {
+	return &FailoverStabilizer{
+		now:     now,
+		masters: map[string]string{},
+		since:   map[string]time.Time{},
+
+		masterChanges: map[string]int64{},
+	}
+}
+
+// Seed sets when the last failover happened if it's not known yet, so the window of a failover
+// survives the restarts of the operator.
+func (s *FailoverStabilizer) Seed(key string, since time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.since[key]; !ok {
+		s.since[key] = since
+	}
+}
+
+// ObserveMaster records the master of a check. The window starts when it's not the master of the
+// previous check, the first master seen is not a failover.
+func (s *FailoverStabilizer) ObserveMaster(key, master string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if previous, ok := s.masters[key]; ok && previous != master {
+		s.since[key] = s.now()
+		s.masterChanges[key]++
+	}
+	s.masters[key] = master
+}
+
+// LastMaster returns the master seen on the last check with one, empty when none was seen.
+func (s *FailoverStabilizer) LastMaster(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.masters[key]
+}
+
+// MasterChanges returns the master changes seen since the first master of the RF.
+func (s *FailoverStabilizer) MasterChanges(key string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.masterChanges[key]
+}
+
+// Restore sets the master and the master changes stored before a restart of the operator, unless a
+// master was already seen. A master changed while the operator was down is then seen as a failover.
+func (s *FailoverStabilizer) Restore(key, master string, changes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.masters[key]; ok || master == "" {
+		return
+	}
+	s.masters[key] = master
+	s.masterChanges[key] = changes
+}
+
+// Start starts the window because the operator promoted a master.
+func (s *FailoverStabilizer) Start(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.since[key] = s.now()
+}
+
+// Stabilizing returns when the last failover happened and how long the disruptive actions are still
+// held given the window. The remaining time is 0 when there's no failover in the window.
+func (s *FailoverStabilizer) Stabilizing(key string, window time.Duration) (time.Time, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	since, ok := s.since[key]
+	if !ok {
+		return time.Time{}, 0
+	}
+	remaining := since.Add(window).Sub(s.now())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return since, remaining
+}
+
+// Forget drops the masters seen and the last failover of a RF, it's called once it's deleted.
+func (s *FailoverStabilizer) Forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.masters, key)
+	delete(s.since, key)
+	delete(s.masterChanges, key)
+}
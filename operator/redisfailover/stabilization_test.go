@@ -0,0 +1,255 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.now = f.now.Add(d)
+}
+
+func TestFailoverStabilizer(t *testing.T) {
+	assert := assert.New(t)
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := rfOperator.NewFailoverStabilizer(clock.Now)
+
+	// The first master seen is not a failover.
+	s.ObserveMaster("ns/rf", "10.0.0.1")
+	_, remaining := s.Stabilizing("ns/rf", time.Minute)
+	assert.Zero(remaining)
+
+	// The sentinels promoted another master.
+	clock.Advance(time.Hour)
+	s.ObserveMaster("ns/rf", "10.0.0.2")
+	since, remaining := s.Stabilizing("ns/rf", time.Minute)
+	assert.Equal(clock.now, since)
+	assert.Equal(time.Minute, remaining)
+
+	clock.Advance(40 * time.Second)
+	s.ObserveMaster("ns/rf", "10.0.0.2")
+	_, remaining = s.Stabilizing("ns/rf", time.Minute)
+	assert.Equal(20*time.Second, remaining)
+
+	// The window is over.
+	clock.Advance(time.Minute)
+	_, remaining = s.Stabilizing("ns/rf", time.Minute)
+	assert.Zero(remaining)
+
+	// The operator promoted a master, a disabled window holds nothing.
+	s.Start("ns/rf")
+	_, remaining = s.Stabilizing("ns/rf", time.Minute)
+	assert.Equal(time.Minute, remaining)
+	_, remaining = s.Stabilizing("ns/rf", 0)
+	assert.Zero(remaining)
+
+	// The seed only sets the window of a RF not known yet.
+	s.Seed("ns/rf", clock.now.Add(-time.Hour))
+	_, remaining = s.Stabilizing("ns/rf", time.Minute)
+	assert.Equal(time.Minute, remaining)
+	s.Seed("ns/other", clock.now.Add(-30*time.Second))
+	_, remaining = s.Stabilizing("ns/other", time.Minute)
+	assert.Equal(30*time.Second, remaining)
+}
+
+func TestFailoverStabilizerMasterChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := rfOperator.NewFailoverStabilizer(clock.Now)
+
+	s.ObserveMaster("ns/rf", "10.0.0.1")
+	assert.Zero(s.MasterChanges("ns/rf"))
+	s.ObserveMaster("ns/rf", "10.0.0.2")
+	s.ObserveMaster("ns/rf", "10.0.0.2")
+	assert.Equal(int64(1), s.MasterChanges("ns/rf"))
+
+	// The state restored only sets a RF not known yet.
+	s.Restore("ns/rf", "10.0.0.3", 5)
+	assert.Equal("10.0.0.2", s.LastMaster("ns/rf"))
+	assert.Equal(int64(1), s.MasterChanges("ns/rf"))
+	s.Restore("ns/other", "10.0.1.1", 5)
+	assert.Equal("10.0.1.1", s.LastMaster("ns/other"))
+	assert.Equal(int64(5), s.MasterChanges("ns/other"))
+
+	// The master changed while the operator was down is a failover.
+	s.ObserveMaster("ns/other", "10.0.1.2")
+	assert.Equal(int64(6), s.MasterChanges("ns/other"))
+	_, remaining := s.Stabilizing("ns/other", time.Minute)
+	assert.Equal(time.Minute, remaining)
+
+	// A forgotten RF starts over.
+	s.Forget("ns/other")
+	assert.Empty(s.LastMaster("ns/other"))
+	assert.Zero(s.MasterChanges("ns/other"))
+	_, remaining = s.Stabilizing("ns/other", time.Minute)
+	assert.Zero(remaining)
+	assert.Equal("10.0.0.2", s.LastMaster("ns/rf"))
+}
+
+func TestCheckAndHealHoldsUpdatesAfterFailover(t *testing.T) {
+	tests := []struct {
+		name          string
+		failoverAgo   time.Duration
+		promoted      bool
+		expMasterKept bool
+	}{
+		{
+			name:          "A pending update must not touch the new master in the window",
+			failoverAgo:   10 * time.Second,
+			expMasterKept: true,
+		},
+		{
+			name:          "A pending update is applied to the master once the window elapses",
+			failoverAgo:   2 * time.Minute,
+			expMasterKept: false,
+		},
+		{
+			name:          "A master promoted by the operator starts the window",
+			promoted:      true,
+			expMasterKept: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			seconds := int32(60)
+			rf.Spec.Failover.StabilizationSeconds = &seconds
+			if test.failoverAgo != 0 {
+				rf.Status.Conditions = []metav1.Condition{{
+					Type:               redisfailoverv1.ConditionProgressing,
+					Status:             metav1.ConditionTrue,
+					Reason:             redisfailoverv1.ReasonStabilizingAfterFailover,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-test.failoverAgo)),
+				}}
+			}
+			master := "0.0.0.0"
+
+			mk := &mK8SService.Services{}
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfc.On("CheckRedisNumber", mock.Anything, rf).Once().Return(nil)
+			mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+			if test.promoted {
+				mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(0, nil)
+				mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{master}, nil)
+				mrfc.On("CorroborateMasterDown", mock.Anything, "", rf).Once().Return(true, "", nil)
+				mk.On("GetStatefulSetRolloutStatus", mock.Anything, namespace, "rfr-test").Once().Return(true, nil)
+				mrfh.On("MakeMaster", mock.Anything, master, rf).Once().Return(nil)
+				mk.On("CreateEvent", mock.Anything, namespace, eventReason("MasterFailover")).Once().Return(nil)
+			} else {
+				mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(1, nil)
+			}
+			mrfc.On("GetMasterIP", mock.Anything, rf).Return(master, nil)
+			mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, rf).Once().Return(nil)
+			mrfh.On("ClearSyncSlotQueue", rf).Once()
+			mrfh.On("PlanRoleLabels", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+			if !test.expMasterKept {
+				// The slaves are updated, the master is stale.
+				mrfh.On("PlanStuckReplicas", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+				mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Once().Return(configActions("rfr-test-0"), nil)
+				mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{master}, nil)
+				mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Once().Return("2", nil)
+				mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Once().Return([]string{}, nil)
+				mrfc.On("GetRedisesMasterPod", mock.Anything, rf).Once().Return("rfr-test-0", nil)
+				mrfc.On("GetRedisRevisionHash", mock.Anything, "rfr-test-0", rf).Once().Return("1", nil)
+				mrfh.On("DeletePod", mock.Anything, "rfr-test-0", rf).Once().Return(nil)
+				mk.On("CreateEvent", mock.Anything, namespace, eventReason("PodDeleted")).Once().Return(nil)
+			}
+			mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{}, nil)
+			mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{})
+			assert.NoError(err)
+
+			mrfc.AssertExpectations(t)
+			mrfh.AssertExpectations(t)
+			if test.expMasterKept {
+				mrfh.AssertNotCalled(t, "DeletePod", mock.Anything, mock.Anything, mock.Anything)
+				mrfh.AssertNotCalled(t, "PlanRedisCustomConfig", mock.Anything, mock.Anything)
+				mrfh.AssertNotCalled(t, "PlanStuckReplicas", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestCheckAndHealHoldsUpdatesAfterSentinelFailover(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	seconds := int32(60)
+	rf.Spec.Failover.StabilizationSeconds = &seconds
+	oldMaster := "0.0.0.0"
+	newMaster := "0.0.0.1"
+
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfc.On("CheckRedisNumber", mock.Anything, rf).Twice().Return(nil)
+	mrfc.On("CheckSentinelNumber", mock.Anything, rf).Twice().Return(nil)
+	mrfc.On("GetNumberMasters", mock.Anything, rf).Twice().Return(1, nil)
+	mrfc.On("GetSentinelsIPs", mock.Anything, rf).Twice().Return([]string{}, nil)
+	mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Twice().Return(nil, nil)
+	mrfh.On("ClearSyncSlotQueue", rf).Twice()
+
+	// First check, everything is up to date.
+	mrfc.On("GetMasterIP", mock.Anything, rf).Twice().Return(oldMaster, nil)
+	mrfc.On("CheckAllSlavesFromMaster", mock.Anything, oldMaster, rf).Once().Return(nil)
+	mrfh.On("PlanRoleLabels", mock.Anything, oldMaster, rf).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanStuckReplicas", mock.Anything, oldMaster, rf).Once().Return([]rfservice.PodAction{}, nil)
+	mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Once().Return(configActions("rfr-test-0", "rfr-test-1"), nil)
+	mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{oldMaster, newMaster}, nil)
+	mrfc.On("CheckRedisSlavesReady", mock.Anything, newMaster, rf).Once().Return(true, nil)
+	mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Once().Return("1", nil)
+	mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Once().Return([]string{"rfr-test-1"}, nil)
+	mrfc.On("GetRedisesMasterPod", mock.Anything, rf).Once().Return("rfr-test-0", nil)
+	mrfc.On("GetRedisRevisionHash", mock.Anything, "rfr-test-1", rf).Once().Return("1", nil)
+	mrfc.On("GetRedisRevisionHash", mock.Anything, "rfr-test-0", rf).Once().Return("1", nil)
+	mrfh.On("PlanRedisInPlaceRestarts", mock.Anything, oldMaster, rf).Once().Return([]rfservice.PodAction{}, nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, &mK8SService.Services{}, metrics.Dummy, log.Dummy)
+	assert.NoError(handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{}))
+
+	// The sentinels promoted rfr-test-1, only its role labels are fixed.
+	labeled := false
+	mrfc.On("GetMasterIP", mock.Anything, rf).Once().Return(newMaster, nil)
+	mrfc.On("CheckAllSlavesFromMaster", mock.Anything, newMaster, rf).Once().Return(nil)
+	mrfh.On("PlanRoleLabels", mock.Anything, newMaster, rf).Once().Return([]rfservice.PodAction{{
+		Pod:   "rfr-test-1",
+		Kind:  rfservice.PodActionUpdateLabels,
+		Apply: func() error { labeled = true; return nil },
+	}}, nil)
+
+	assert.NoError(handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{}))
+	assert.True(labeled)
+
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+	mrfh.AssertNumberOfCalls(t, "PlanRedisCustomConfig", 1)
+	mrfh.AssertNotCalled(t, "DeletePod", mock.Anything, mock.Anything, mock.Anything)
+}
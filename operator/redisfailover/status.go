@@ -0,0 +1,503 @@
+package redisfailover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+)
+
+const (
+	instanceRoleRedis    = "redis"
+	instanceRoleSentinel = "sentinel"
+)
+
+// UpdateStatus aggregates the observed state of the redis and sentinel pods into the
+// RF status. The status is only written when it has changed.
+func (r *RedisFailoverHandler) UpdateStatus(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	// The conditions are set on a copy of the RF, the status is the one of the copy.
+	next := rf.DeepCopy()
+	status := &next.Status
+
+	instances := []redisfailoverv1.InstanceStatus{}
+	health := healthInput{}
+	// External nodes have no pods, only the sentinels are reported.
+	if !rf.ExternalNodesEnabled() {
+		redisPods, err := r.k8sservice.GetStatefulSetPods(ctx, rf.Namespace, rfservice.GetRedisStatefulSetName(rf))
+		if err != nil {
+			return err
+		}
+		ss, err := r.k8sservice.GetStatefulSet(ctx, rf.Namespace, rfservice.GetRedisStatefulSetName(rf))
+		if err != nil {
+			return err
+		}
+		health.redisWanted = rf.Spec.Redis.Replicas
+		health.redisUncounted = countUncountedRedis(rf)
+		health.redisReady = countReadyRedisPods(rf, redisPods)
+		health.redisRunning = int32(len(redisPods.Items))
+		health.restartPending = countPodsNotAtRevision(redisPods, ss.Status.UpdateRevision)
+		instances = generateInstancesStatus(instanceRoleRedis, redisPods)
+		health.redisCrashes = crashMessage(r.logRedisCrashes(ctx, rf, redisPods))
+		setACLCondition(status, r.aclLoads.Failure(rfKey(rf)), rf.Generation)
+		setInstancesWaitingForSyncSlot(rf, instances, redisPods, r.rfHealer.GetSyncSlotQueue(rf))
+		waits, err := r.getVolumeWaits(ctx, rf, redisPods)
+		if err != nil {
+			// The pods are not held, it's not worth failing the status update.
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not check the redis pods waiting for their volumes: %s", err)
+		}
+		held, expired := setInstancesWaitingForVolume(instances, waits, r.config.VolumeWaitGracePeriod, time.Now())
+		r.volumeWaits.Set(rfKey(rf), held)
+		health.volumeWaitExpired = expired
+		health.volumeWaitGrace = r.config.VolumeWaitGracePeriod
+		r.setInstancesPlacement(ctx, rf, instances, redisPods)
+		status.PlacementSummary = generatePlacementSummary(instances)
+		setPlacementCondition(status, rf, r.config.RackLabel, rf.Generation)
+
+		warnings, err := r.rfChecker.GetNodeTuningWarnings(ctx, rf)
+		if err != nil {
+			// The condition is kept as it was, it's not worth failing the status update.
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not check the redis startup warnings: %s", err)
+		} else {
+			setNodeTuningCondition(status, warnings, rf.Generation)
+		}
+
+		latencies, err := r.rfChecker.MeasureRedisLatency(ctx, rf)
+		if err != nil {
+			// The condition is kept as it was, it's not worth failing the status update.
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not measure the redis latency: %s", err)
+		} else {
+			setPressureCondition(status, latencies, r.config.LatencyPressure, rf.Generation)
+		}
+
+		if rf.FunctionalProbeEnabled() {
+			results, err := r.rfChecker.ProbeRedisFunctional(ctx, rf)
+			if err != nil {
+				// The instances are left without a result, it's not worth failing the status update.
+				r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not run the functional probe of redis: %s", err)
+			} else {
+				setInstancesFunctionalHealth(instances, results)
+			}
+		} else {
+			r.mClient.ResetRedisFunctionalHealth(rf.Namespace, rf.Name)
+		}
+
+		mismatches, err := r.rfChecker.GetDataDirMismatches(ctx, rf)
+		if err != nil {
+			// The condition is kept as it was, it's not worth failing the status update.
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not check the redis data dir: %s", err)
+		} else {
+			setDataDirCondition(status, mismatches, rf.Generation)
+			r.warnDataDir(ctx, rf, status)
+		}
+
+		keyspaces, err := r.rfChecker.GetRedisKeyspaces(ctx, rf)
+		if err != nil {
+			// The condition is kept as it was, it's not worth failing the status update.
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not read the redis keyspaces: %s", err)
+		} else {
+			r.checkDataFlush(ctx, rf, status, instances, keyspaces, time.Now())
+		}
+	} else {
+		r.dataFlushes.Forget(rfKey(rf))
+		r.volumeWaits.Set(rfKey(rf), nil)
+		r.crashLogs.Set(rfKey(rf), nil)
+		r.aclLoads.Forget(rfKey(rf))
+		r.rfChecker.ForgetRedisLatency(rf)
+		r.mClient.ResetRedisFunctionalHealth(rf.Namespace, rf.Name)
+		status.PlacementSummary = nil
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionPlacementWarning)
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionPressure)
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionDegraded)
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionPossibleDataFlush)
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionACLLoadFailed)
+	}
+	since, stabilizing := r.stabilizationWindow(rf)
+	setStabilizingCondition(status, since, rf.Spec.Failover.GetStabilizationWindow(), stabilizing, rf.Generation)
+	reason, message, held := r.promotionHolds.Held(rfKey(rf))
+	setPromotionHeldCondition(status, reason, message, held, rf.Generation)
+	setPodDisruptionBudgetCondition(status, r.pdbSkips.Skipped(rfKey(rf)), rf.Generation)
+
+	if rf.SentinelsAllowed() {
+		sentinelPods, err := r.k8sservice.GetDeploymentPods(ctx, rf.Namespace, rfservice.GetSentinelName(rf))
+		if err != nil {
+			return err
+		}
+		sentinelInstances := generateInstancesStatus(instanceRoleSentinel, sentinelPods)
+		r.setInstancesPlacement(ctx, rf, sentinelInstances, sentinelPods)
+		instances = append(instances, sentinelInstances...)
+		health.sentinelWanted = rf.Spec.Sentinel.Replicas
+		health.sentinelReady = countReadyPods(sentinelPods)
+		health.sentinelRunning = int32(len(sentinelPods.Items))
+		status.SentinelQuorum = rf.SentinelQuorum()
+	} else {
+		status.SentinelQuorum = 0
+	}
+	setQuorumCondition(status, rf, rf.Generation)
+	setDeprecationCondition(status, rf.DeprecatedFieldsInUse(), rf.Generation)
+
+	if rf.BackupEnabled() {
+		snapshots, err := r.k8sservice.ListVolumeSnapshots(ctx, rf.Namespace, rfservice.GetBackupSelector(rf))
+		switch {
+		case errors.Is(err, k8s.ErrVolumeSnapshotsUnavailable):
+			status.Backups = nil
+		case err != nil:
+			return err
+		default:
+			status.Backups = rfservice.GetBackups(snapshots)
+		}
+	} else {
+		status.Backups = nil
+	}
+
+	status.Instances = instances
+	status.Restarts, status.LastRestartReason = getWorstRestartedContainer(instances)
+	if r.sentinelEvents != nil {
+		if lastFailover := r.sentinelEvents.LastFailover(rf); lastFailover != nil {
+			status.LastFailover = lastFailover
+		}
+	}
+	if r.diagnoses != nil {
+		if lastDiagnosis := r.diagnoses.Last(rf); lastDiagnosis != nil {
+			status.LastDiagnosis = lastDiagnosis
+		}
+	}
+	if stats := r.checkRuns.Stats(rfKey(rf)); stats != nil {
+		status.LastReconcileStats = stats
+	}
+	// The RF is being reconciled, so the operator is not too old for it.
+	meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionOperatorTooOld)
+	r.setManagedByOtherCondition(status, rf)
+	// The health is computed last from the conditions, so both are written together.
+	status.Health = generateHealthReport(status, health, rf.Generation)
+	r.setReconcileConditions(ctx, rf, next, health)
+
+	r.setInstanceRestarts(rf, instances)
+
+	return r.writeStatus(ctx, rf, status)
+}
+
+// writeStatus updates the RF status only when it's different from the current one.
+func (r *RedisFailoverHandler) writeStatus(ctx context.Context, rf *redisfailoverv1.RedisFailover, status *redisfailoverv1.RedisFailoverStatus) error {
+	if equality.Semantic.DeepEqual(rf.Status, *status) {
+		return nil
+	}
+
+	newRF := rf.DeepCopy()
+	newRF.Status = *status
+	_, err := r.k8sservice.UpdateRedisFailoverStatus(ctx, rf.Namespace, newRF)
+	return err
+}
+
+// setNodeTuningCondition sets the node tuning warning condition listing the nodes of every kernel
+// setting redis warned about, or removes it when there are no warnings.
+func setNodeTuningCondition(status *redisfailoverv1.RedisFailoverStatus, warnings map[string][]string, generation int64) {
+	if len(warnings) == 0 {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionNodeTuningWarning)
+		return
+	}
+	settings := make([]string, 0, len(warnings))
+	for setting := range warnings {
+		settings = append(settings, setting)
+	}
+	sort.Strings(settings)
+	msgs := make([]string, 0, len(settings))
+	for _, setting := range settings {
+		msgs = append(msgs, fmt.Sprintf("%s on nodes %s", setting, strings.Join(warnings[setting], ", ")))
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionNodeTuningWarning,
+		Status:             metav1.ConditionTrue,
+		Reason:             redisfailoverv1.ReasonKernelSettings,
+		Message:            fmt.Sprintf("redis warned on startup about the kernel settings %s, set them on the nodes or enable spec.redis.nodeTuningInitContainer", strings.Join(msgs, "; ")),
+		ObservedGeneration: generation,
+	})
+}
+
+// setQuorumCondition sets the quorum warning condition when the quorum set in the spec lets a minority
+// of the sentinels fail the master over, or removes it otherwise.
+func setQuorumCondition(status *redisfailoverv1.RedisFailoverStatus, rf *redisfailoverv1.RedisFailover, generation int64) {
+	if !rf.SentinelsAllowed() || !rf.SentinelQuorumBelowMajority() {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionQuorumWarning)
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionQuorumWarning,
+		Status:             metav1.ConditionTrue,
+		Reason:             redisfailoverv1.ReasonQuorumBelowMajority,
+		Message:            fmt.Sprintf("the sentinel quorum is %d, below the majority of the %d sentinels, a minority of them can fail the master over", rf.Spec.Sentinel.Quorum, rf.Spec.Sentinel.Replicas),
+		ObservedGeneration: generation,
+	})
+}
+
+// setDeprecationCondition sets the deprecation condition listing the deprecated fields the spec sets,
+// or removes it when there are none.
+func setDeprecationCondition(status *redisfailoverv1.RedisFailoverStatus, fields []redisfailoverv1.DeprecatedField, generation int64) {
+	if len(fields) == 0 {
+		meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionDeprecatedFieldsInUse)
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionDeprecatedFieldsInUse,
+		Status:             metav1.ConditionTrue,
+		Reason:             redisfailoverv1.ReasonDeprecatedFields,
+		Message:            deprecatedFieldsMessage(fields),
+		ObservedGeneration: generation,
+	})
+}
+
+// setStabilizingCondition sets the progressing condition while the disruptive actions are held after
+// a failover, or removes it when they are not. The transition time is when the failover happened.
+func setStabilizingCondition(status *redisfailoverv1.RedisFailoverStatus, since time.Time, window, remaining time.Duration, generation int64) {
+	// The condition is replaced instead of updated, as a failover in the window of the previous one
+	// moves the transition time without changing the status.
+	meta.RemoveStatusCondition(&status.Conditions, redisfailoverv1.ConditionProgressing)
+	if remaining <= 0 {
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               redisfailoverv1.ConditionProgressing,
+		Status:             metav1.ConditionTrue,
+		Reason:             redisfailoverv1.ReasonStabilizingAfterFailover,
+		Message:            fmt.Sprintf("the master changed, the disruptive actions on the redis pods are held until %s", since.Add(window).UTC().Format(time.RFC3339)),
+		LastTransitionTime: metav1.NewTime(since.Truncate(time.Second)),
+		ObservedGeneration: generation,
+	})
+}
+
+// healthInput is the pods readiness the health report is computed from.
+type healthInput struct {
+	redisWanted     int32
+	redisReady      int32
+	redisRunning    int32
+	sentinelWanted  int32
+	sentinelReady   int32
+	sentinelRunning int32
+	restartPending  int32
+	// redisUncounted are the redis pods left out of the healthy replicas, see rfservice.RedisPodCounted,
+	// the RF is ready without them.
+	redisUncounted int32
+	// volumeWaitExpired are the redis pods waiting for their volumes for longer than the grace period.
+	volumeWaitExpired []string
+	volumeWaitGrace   time.Duration
+	// redisCrashes are the redis pods crash looping with the last lines of their previous run, see
+	// crashMessage.
+	redisCrashes string
+}
+
+// generateHealthReport summarizes the conditions and the pods readiness. A held promotion, a redis pod
+// waiting for its volumes past the grace period or writing out of its data volume degrades the RF,
+// while the pods are not ready or restarted, or the disruptive actions are held, it's progressing.
+func generateHealthReport(status *redisfailoverv1.RedisFailoverStatus, health healthInput, generation int64) *redisfailoverv1.HealthReport {
+	report := &redisfailoverv1.HealthReport{
+		Phase:              redisfailoverv1.HealthPhaseHealthy,
+		Ready:              health.redisReady >= health.redisWanted-health.redisUncounted && health.sentinelReady >= health.sentinelWanted,
+		Message:            readinessMessage(health),
+		ObservedGeneration: generation,
+		RestartPending:     health.restartPending > 0,
+	}
+	progressing := meta.FindStatusCondition(status.Conditions, redisfailoverv1.ConditionProgressing)
+	degraded := meta.FindStatusCondition(status.Conditions, redisfailoverv1.ConditionDegraded)
+	flushed := meta.FindStatusCondition(status.Conditions, redisfailoverv1.ConditionPossibleDataFlush)
+	switch held := meta.FindStatusCondition(status.Conditions, redisfailoverv1.ConditionPromotionHeld); {
+	case held != nil && held.Status == metav1.ConditionTrue:
+		report.Phase = redisfailoverv1.HealthPhaseDegraded
+		report.Message = held.Message
+	case len(health.volumeWaitExpired) > 0:
+		report.Phase = redisfailoverv1.HealthPhaseDegraded
+		report.Message = fmt.Sprintf("the redis pods %s are waiting for their volumes for more than %s", strings.Join(health.volumeWaitExpired, ", "), health.volumeWaitGrace)
+	case degraded != nil && degraded.Status == metav1.ConditionTrue:
+		report.Phase = redisfailoverv1.HealthPhaseDegraded
+		report.Message = degraded.Message
+	case flushed != nil && flushed.Status == metav1.ConditionTrue:
+		report.Phase = redisfailoverv1.HealthPhaseDegraded
+		report.Message = flushed.Message
+	case !report.Ready:
+		report.Phase = redisfailoverv1.HealthPhaseProgressing
+	case report.RestartPending:
+		report.Phase = redisfailoverv1.HealthPhaseProgressing
+		report.Message = fmt.Sprintf("%d redis pods are pending a restart to the statefulset revision", health.restartPending)
+	case progressing != nil && progressing.Status == metav1.ConditionTrue:
+		report.Phase = redisfailoverv1.HealthPhaseProgressing
+		report.Message = progressing.Message
+	}
+	return report
+}
+
+// readinessMessage returns the ready pods of every role the RF has.
+func readinessMessage(health healthInput) string {
+	return podsMessage(health, health.redisReady, health.redisWanted-health.redisUncounted, health.sentinelReady, "ready")
+}
+
+// runningMessage returns the pods of every role the RF has, ready or not.
+func runningMessage(health healthInput) string {
+	return podsMessage(health, health.redisRunning, health.redisWanted, health.sentinelRunning, "running")
+}
+
+// podsMessage returns the redis and sentinel pods counted out of the wanted ones, in the state.
+func podsMessage(health healthInput, redis, redisWanted, sentinel int32, state string) string {
+	msgs := []string{}
+	if redisWanted > 0 {
+		msgs = append(msgs, fmt.Sprintf("%d/%d redis", redis, redisWanted))
+	}
+	if health.sentinelWanted > 0 {
+		msgs = append(msgs, fmt.Sprintf("%d/%d sentinel", sentinel, health.sentinelWanted))
+	}
+	if len(msgs) == 0 {
+		return "no pods to run"
+	}
+	return strings.Join(msgs, " and ") + " pods " + state
+}
+
+// countReadyPods returns the pods with the ready condition that are not being deleted.
+func countReadyPods(pods *corev1.PodList) int32 {
+	var ready int32
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil && k8s.IsPodReady(&pod) {
+			ready++
+		}
+	}
+	return ready
+}
+
+// countReadyRedisPods returns the ready redis pods counted in the healthy replicas.
+func countReadyRedisPods(rf *redisfailoverv1.RedisFailover, pods *corev1.PodList) int32 {
+	counted := &corev1.PodList{}
+	for _, pod := range pods.Items {
+		if rfservice.RedisPodCounted(rf, pod.Name) {
+			counted.Items = append(counted.Items, pod)
+		}
+	}
+	return countReadyPods(counted)
+}
+
+// countUncountedRedis returns the redis pods of the spec left out of the healthy replicas.
+func countUncountedRedis(rf *redisfailoverv1.RedisFailover) int32 {
+	var uncounted int32
+	for ordinal := 0; ordinal < int(rf.Spec.Redis.Replicas); ordinal++ {
+		if !rf.RedisInstanceCounted(ordinal) {
+			uncounted++
+		}
+	}
+	return uncounted
+}
+
+// countPodsNotAtRevision returns the pods that have not been restarted to the statefulset update revision yet.
+func countPodsNotAtRevision(pods *corev1.PodList, revision string) int32 {
+	if revision == "" {
+		return 0
+	}
+	var pending int32
+	for _, pod := range pods.Items {
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] != revision {
+			pending++
+		}
+	}
+	return pending
+}
+
+// generateInstancesStatus returns the restarts of every container of the pods, sorted by pod name.
+func generateInstancesStatus(role string, pods *corev1.PodList) []redisfailoverv1.InstanceStatus {
+	instances := []redisfailoverv1.InstanceStatus{}
+	if pods == nil {
+		return instances
+	}
+	for _, pod := range pods.Items {
+		instance := redisfailoverv1.InstanceStatus{
+			Name: pod.Name,
+			Role: role,
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			container := redisfailoverv1.ContainerRestartStatus{
+				Name:     cs.Name,
+				Restarts: cs.RestartCount,
+			}
+			if cs.LastTerminationState.Terminated != nil {
+				container.LastRestartReason = cs.LastTerminationState.Terminated.Reason
+			}
+			instance.Restarts += cs.RestartCount
+			instance.Containers = append(instance.Containers, container)
+		}
+		sort.Slice(instance.Containers, func(i, j int) bool {
+			return instance.Containers[i].Name < instance.Containers[j].Name
+		})
+		instances = append(instances, instance)
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Name < instances[j].Name
+	})
+	return instances
+}
+
+// setInstancesWaitingForSyncSlot sets the state of the instances whose pod IP is waiting for a sync slot.
+func setInstancesWaitingForSyncSlot(rf *redisfailoverv1.RedisFailover, instances []redisfailoverv1.InstanceStatus, pods *corev1.PodList, waiting []string) {
+	if pods == nil || len(waiting) == 0 {
+		return
+	}
+	waitingIPs := map[string]bool{}
+	for _, ip := range waiting {
+		waitingIPs[ip] = true
+	}
+	waitingPods := map[string]bool{}
+	for _, pod := range pods.Items {
+		if ip := rf.PreferredPodIP(pod.Status); ip != "" && waitingIPs[ip] {
+			waitingPods[pod.Name] = true
+		}
+	}
+	for i := range instances {
+		if waitingPods[instances[i].Name] {
+			instances[i].State = redisfailoverv1.InstanceStateWaitingForSyncSlot
+		}
+	}
+}
+
+// setInstancesFunctionalHealth sets the result of the functional probe on the redis instances probed.
+func setInstancesFunctionalHealth(instances []redisfailoverv1.InstanceStatus, results []rfservice.RedisFunctionalHealth) {
+	byPod := map[string]rfservice.RedisFunctionalHealth{}
+	for _, result := range results {
+		byPod[result.Pod] = result
+	}
+	for i := range instances {
+		result, ok := byPod[instances[i].Name]
+		if !ok {
+			continue
+		}
+		instances[i].Functional = redisfailoverv1.InstanceFunctionalUnhealthy
+		if result.Healthy {
+			instances[i].Functional = redisfailoverv1.InstanceFunctionalHealthy
+		}
+		instances[i].FunctionalMessage = result.Message
+	}
+}
+
+// getWorstRestartedContainer returns the restarts and the last restart reason of the container
+// that restarted the most. The reason is prefixed with the pod and container names, so a crash
+// looping exporter is not mistaken with a crash looping redis.
+func getWorstRestartedContainer(instances []redisfailoverv1.InstanceStatus) (int32, string) {
+	var restarts int32
+	reason := ""
+	for _, instance := range instances {
+		for _, container := range instance.Containers {
+			if container.Restarts <= restarts {
+				continue
+			}
+			restarts = container.Restarts
+			reason = fmt.Sprintf("%s/%s", instance.Name, container.Name)
+			if container.LastRestartReason != "" {
+				reason = fmt.Sprintf("%s: %s", reason, container.LastRestartReason)
+			}
+		}
+	}
+	return restarts, reason
+}
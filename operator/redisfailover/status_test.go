@@ -0,0 +1,1043 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+func generateContainerStatus(name string, restarts int32, reason string) corev1.ContainerStatus {
+	cs := corev1.ContainerStatus{
+		Name:         name,
+		RestartCount: restarts,
+	}
+	if reason != "" {
+		cs.LastTerminationState = corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{
+				Reason: reason,
+			},
+		}
+	}
+	return cs
+}
+
+func generatePodWithContainerStatuses(name string, statuses ...corev1.ContainerStatus) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: statuses,
+		},
+	}
+}
+
+func generatePodWithIP(name string, ip string) corev1.Pod {
+	pod := generatePodWithContainerStatuses(name)
+	pod.Status.PodIP = ip
+	return pod
+}
+
+// notReadyHealth is the health of the test RF when none of its pods are ready.
+func notReadyHealth() *redisfailoverv1.HealthReport {
+	return &redisfailoverv1.HealthReport{
+		Phase:   redisfailoverv1.HealthPhaseProgressing,
+		Message: "0/3 redis and 0/3 sentinel pods ready",
+	}
+}
+
+// notReadyConditions are the Ready and Scaling conditions of the test RF when none of its pods are
+// ready, with the redis and sentinel pods running. The transition times are not compared.
+func notReadyConditions(redis, sentinel int) []metav1.Condition {
+	return []metav1.Condition{
+		{
+			Type:    redisfailoverv1.ConditionReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  redisfailoverv1.ReasonPodsNotReady,
+			Message: "0/3 redis and 0/3 sentinel pods ready",
+		},
+		{
+			Type:    redisfailoverv1.ConditionScaling,
+			Status:  metav1.ConditionTrue,
+			Reason:  redisfailoverv1.ReasonScalingPods,
+			Message: fmt.Sprintf("%d/3 redis and %d/3 sentinel pods running", redis, sentinel),
+		},
+	}
+}
+
+func TestUpdateStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		redisPods    []corev1.Pod
+		sentinelPods []corev1.Pod
+		waiting      []string
+		prevStatus   redisfailoverv1.RedisFailoverStatus
+		expStatus    *redisfailoverv1.RedisFailoverStatus
+	}{
+		{
+			name: "OOMKilled redis should be the worst offender",
+			redisPods: []corev1.Pod{
+				generatePodWithContainerStatuses("rfr-test-1",
+					generateContainerStatus("redis", 7, "OOMKilled"),
+					generateContainerStatus("redis-exporter", 1, "Error"),
+				),
+				generatePodWithContainerStatuses("rfr-test-0",
+					generateContainerStatus("redis", 0, ""),
+				),
+			},
+			sentinelPods: []corev1.Pod{
+				generatePodWithContainerStatuses("rfs-test-abc",
+					generateContainerStatus("sentinel", 2, "Completed"),
+				),
+			},
+			expStatus: &redisfailoverv1.RedisFailoverStatus{
+				Restarts:          7,
+				LastRestartReason: "rfr-test-1/redis: OOMKilled",
+				Instances: []redisfailoverv1.InstanceStatus{
+					{
+						Name:     "rfr-test-0",
+						Role:     "redis",
+						Restarts: 0,
+						Containers: []redisfailoverv1.ContainerRestartStatus{
+							{Name: "redis", Restarts: 0},
+						},
+					},
+					{
+						Name:     "rfr-test-1",
+						Role:     "redis",
+						Restarts: 8,
+						Containers: []redisfailoverv1.ContainerRestartStatus{
+							{Name: "redis", Restarts: 7, LastRestartReason: "OOMKilled"},
+							{Name: "redis-exporter", Restarts: 1, LastRestartReason: "Error"},
+						},
+					},
+					{
+						Name:     "rfs-test-abc",
+						Role:     "sentinel",
+						Restarts: 2,
+						Containers: []redisfailoverv1.ContainerRestartStatus{
+							{Name: "sentinel", Restarts: 2, LastRestartReason: "Completed"},
+						},
+					},
+				},
+				Conditions:     notReadyConditions(2, 1),
+				Health:         notReadyHealth(),
+				SentinelQuorum: 2,
+			},
+		},
+		{
+			name: "Crash looping exporter should be distinguishable from redis",
+			redisPods: []corev1.Pod{
+				generatePodWithContainerStatuses("rfr-test-0",
+					generateContainerStatus("redis", 1, "Completed"),
+					generateContainerStatus("redis-exporter", 12, "Error"),
+				),
+			},
+			expStatus: &redisfailoverv1.RedisFailoverStatus{
+				Restarts:          12,
+				LastRestartReason: "rfr-test-0/redis-exporter: Error",
+				Instances: []redisfailoverv1.InstanceStatus{
+					{
+						Name:     "rfr-test-0",
+						Role:     "redis",
+						Restarts: 13,
+						Containers: []redisfailoverv1.ContainerRestartStatus{
+							{Name: "redis", Restarts: 1, LastRestartReason: "Completed"},
+							{Name: "redis-exporter", Restarts: 12, LastRestartReason: "Error"},
+						},
+					},
+				},
+				Conditions:     notReadyConditions(1, 0),
+				Health:         notReadyHealth(),
+				SentinelQuorum: 2,
+			},
+		},
+		{
+			name: "Replicas waiting for a sync slot should be reported",
+			redisPods: []corev1.Pod{
+				generatePodWithIP("rfr-test-0", "10.0.0.1"),
+				generatePodWithIP("rfr-test-1", "10.0.0.2"),
+			},
+			waiting: []string{"10.0.0.2"},
+			expStatus: &redisfailoverv1.RedisFailoverStatus{
+				Instances: []redisfailoverv1.InstanceStatus{
+					{
+						Name: "rfr-test-0",
+						Role: "redis",
+					},
+					{
+						Name:  "rfr-test-1",
+						Role:  "redis",
+						State: redisfailoverv1.InstanceStateWaitingForSyncSlot,
+					},
+				},
+				Conditions:     notReadyConditions(2, 0),
+				Health:         notReadyHealth(),
+				SentinelQuorum: 2,
+			},
+		},
+		{
+			name: "Unchanged status should not be written",
+			redisPods: []corev1.Pod{
+				generatePodWithContainerStatuses("rfr-test-0",
+					generateContainerStatus("redis", 3, "OOMKilled"),
+				),
+			},
+			prevStatus: redisfailoverv1.RedisFailoverStatus{
+				Restarts:          3,
+				LastRestartReason: "rfr-test-0/redis: OOMKilled",
+				Instances: []redisfailoverv1.InstanceStatus{
+					{
+						Name:     "rfr-test-0",
+						Role:     "redis",
+						Restarts: 3,
+						Containers: []redisfailoverv1.ContainerRestartStatus{
+							{Name: "redis", Restarts: 3, LastRestartReason: "OOMKilled"},
+						},
+					},
+				},
+				Conditions:     notReadyConditions(1, 0),
+				Health:         notReadyHealth(),
+				SentinelQuorum: 2,
+			},
+			expStatus: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Status = test.prevStatus
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: test.redisPods}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{Items: test.sentinelPods}, nil)
+			if test.expStatus != nil {
+				mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+					status := got.Status.DeepCopy()
+					for i := range status.Conditions {
+						status.Conditions[i].LastTransitionTime = metav1.Time{}
+					}
+					return assert.Equal(*test.expStatus, *status)
+				})).Once().Return(rf, nil)
+			}
+
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return(test.waiting)
+
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.UpdateStatus(context.TODO(), rf)
+
+			assert.NoError(err)
+			mk.AssertExpectations(t)
+		})
+	}
+}
+
+// instanceRestartSeries returns the exposed restart counts, by pod/container.
+func instanceRestartSeries(t *testing.T, reg *prometheus.Registry) map[string]float64 {
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	series := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "test_controller_instance_restarts" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			series[labels["pod"]+"/"+labels["container"]] = metric.GetGauge().GetValue()
+		}
+	}
+	return series
+}
+
+func TestUpdateStatusInstanceRestartSeries(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	mk := &mK8SService.Services{}
+	mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Return(&appsv1.StatefulSet{}, nil)
+	mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.Anything).Return(rf, nil)
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("GetSyncSlotQueue", rf).Return([]string{})
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Return(map[string][]string{}, nil)
+	mrfc.On("MeasureRedisLatency", mock.Anything, rf).Return([]rfservice.RedisLatency{}, nil)
+	mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Return([]rfservice.RedisKeyspace{}, nil)
+	mrfc.On("GetDataDirMismatches", mock.Anything, rf).Return([]rfservice.DataDirMismatch{}, nil)
+
+	reg := prometheus.NewRegistry()
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.NewRecorder("test", reg), log.Dummy)
+
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: []corev1.Pod{
+		generatePodWithContainerStatuses("rfr-test-0", generateContainerStatus("redis", 2, "OOMKilled")),
+		generatePodWithContainerStatuses("rfr-test-1", generateContainerStatus("redis", 1, "Error")),
+	}}, nil)
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{Items: []corev1.Pod{
+		generatePodWithContainerStatuses("rfs-test-abc", generateContainerStatus("sentinel", 1, "Error")),
+	}}, nil)
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+	assert.Equal(map[string]float64{"rfr-test-0/redis": 2, "rfr-test-1/redis": 1, "rfs-test-abc/sentinel": 1}, instanceRestartSeries(t, reg))
+
+	// The redis statefulset is scaled down and the sentinels rolled out, the series of the pods gone are
+	// deleted.
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: []corev1.Pod{
+		generatePodWithContainerStatuses("rfr-test-0", generateContainerStatus("redis", 3, "OOMKilled")),
+	}}, nil)
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{Items: []corev1.Pod{
+		generatePodWithContainerStatuses("rfs-test-def", generateContainerStatus("sentinel", 0, "")),
+	}}, nil)
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+	assert.Equal(map[string]float64{"rfr-test-0/redis": 3, "rfs-test-def/sentinel": 0}, instanceRestartSeries(t, reg))
+}
+
+func TestUpdateStatusNodeTuningCondition(t *testing.T) {
+	tests := []struct {
+		name         string
+		prevWarning  bool
+		warnings     map[string][]string
+		warningsErr  error
+		expNoWrite   bool
+		expCondition *metav1.Condition
+	}{
+		{
+			name: "Startup warnings should set the condition listing the nodes",
+			warnings: map[string][]string{
+				rfservice.NodeTuningTransparentHugePages: {"node-a"},
+				rfservice.NodeTuningOvercommitMemory:     {"node-a", "node-b"},
+			},
+			expCondition: &metav1.Condition{
+				Type:    redisfailoverv1.ConditionNodeTuningWarning,
+				Status:  metav1.ConditionTrue,
+				Reason:  redisfailoverv1.ReasonKernelSettings,
+				Message: "redis warned on startup about the kernel settings transparent_hugepage on nodes node-a; vm.overcommit_memory on nodes node-a, node-b, set them on the nodes or enable spec.redis.nodeTuningInitContainer",
+			},
+		},
+		{
+			name:         "No startup warnings should remove the condition",
+			prevWarning:  true,
+			warnings:     map[string][]string{},
+			expCondition: nil,
+		},
+		{
+			name:        "An error checking the warnings should keep the condition",
+			prevWarning: true,
+			warningsErr: errors.New("wanted error"),
+			expNoWrite:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			if test.prevWarning {
+				meta.SetStatusCondition(&rf.Status.Conditions, metav1.Condition{
+					Type:    redisfailoverv1.ConditionNodeTuningWarning,
+					Status:  metav1.ConditionTrue,
+					Reason:  redisfailoverv1.ReasonKernelSettings,
+					Message: "previous",
+				})
+			}
+
+			if test.expNoWrite {
+				rf.Status.Conditions = append(rf.Status.Conditions, notReadyConditions(0, 0)...)
+				rf.Status.Health = notReadyHealth()
+				rf.Status.SentinelQuorum = 2
+			}
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+			if !test.expNoWrite {
+				mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+					condition := meta.FindStatusCondition(got.Status.Conditions, redisfailoverv1.ConditionNodeTuningWarning)
+					if test.expCondition == nil {
+						return assert.Nil(condition)
+					}
+					return assert.NotNil(condition) &&
+						assert.Equal(test.expCondition.Status, condition.Status) &&
+						assert.Equal(test.expCondition.Reason, condition.Reason) &&
+						assert.Equal(test.expCondition.Message, condition.Message)
+				})).Once().Return(rf, nil)
+			}
+
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(test.warnings, test.warningsErr)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.UpdateStatus(context.TODO(), rf)
+
+			assert.NoError(err)
+			mk.AssertExpectations(t)
+			mrfc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateStatusPressureCondition(t *testing.T) {
+	slow := rfservice.RedisLatency{Pod: "rfr-test-1", Last: 180 * time.Millisecond, Mean: 150 * time.Millisecond, Jitter: 20 * time.Millisecond, Samples: 10}
+	tests := []struct {
+		name         string
+		prevPressure bool
+		threshold    time.Duration
+		latencies    []rfservice.RedisLatency
+		latencyErr   error
+		expNoWrite   bool
+		expCondition *metav1.Condition
+	}{
+		{
+			name:      "A sustained high latency should set the condition listing the pods",
+			threshold: 100 * time.Millisecond,
+			latencies: []rfservice.RedisLatency{
+				{Pod: "rfr-test-0", Last: time.Millisecond, Mean: time.Millisecond, Samples: 10},
+				slow,
+			},
+			expCondition: &metav1.Condition{
+				Type:    redisfailoverv1.ConditionPressure,
+				Status:  metav1.ConditionTrue,
+				Reason:  redisfailoverv1.ReasonHighLatency,
+				Message: "the round-trip of the PING sent by the operator stays above 100ms on the redis pods rfr-test-1 (150ms, jitter 20ms)",
+			},
+		},
+		{
+			name:      "A high latency over a partial history should not set the condition",
+			threshold: 100 * time.Millisecond,
+			latencies: []rfservice.RedisLatency{
+				{Pod: "rfr-test-1", Last: time.Second, Mean: time.Second, Samples: 3},
+			},
+			expCondition: nil,
+		},
+		{
+			name:         "A disabled threshold should remove the condition",
+			prevPressure: true,
+			latencies:    []rfservice.RedisLatency{slow},
+			expCondition: nil,
+		},
+		{
+			name:         "An error measuring the latency should keep the condition",
+			prevPressure: true,
+			threshold:    100 * time.Millisecond,
+			latencyErr:   errors.New("wanted error"),
+			expNoWrite:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			if test.prevPressure {
+				meta.SetStatusCondition(&rf.Status.Conditions, metav1.Condition{
+					Type:    redisfailoverv1.ConditionPressure,
+					Status:  metav1.ConditionTrue,
+					Reason:  redisfailoverv1.ReasonHighLatency,
+					Message: "previous",
+				})
+			}
+
+			if test.expNoWrite {
+				rf.Status.Conditions = append(rf.Status.Conditions, notReadyConditions(0, 0)...)
+				rf.Status.Health = notReadyHealth()
+				rf.Status.SentinelQuorum = 2
+			}
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+			if !test.expNoWrite {
+				mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+					condition := meta.FindStatusCondition(got.Status.Conditions, redisfailoverv1.ConditionPressure)
+					if test.expCondition == nil {
+						return assert.Nil(condition)
+					}
+					return assert.NotNil(condition) &&
+						assert.Equal(test.expCondition.Status, condition.Status) &&
+						assert.Equal(test.expCondition.Reason, condition.Reason) &&
+						assert.Equal(test.expCondition.Message, condition.Message)
+				})).Once().Return(rf, nil)
+			}
+
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return(test.latencies, test.latencyErr)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+			config := generateConfig()
+			config.LatencyPressure = test.threshold
+			handler := rfOperator.NewRedisFailoverHandler(config, &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.UpdateStatus(context.TODO(), rf)
+
+			assert.NoError(err)
+			mk.AssertExpectations(t)
+			mrfc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateStatusDataDirCondition(t *testing.T) {
+	mismatch := rfservice.DataDirMismatch{Pod: "rfr-test-1", Dir: "/bitnami/redis/data", MountPath: "/data"}
+	message := "persistence is not on the persistent volume, the data is lost when redis restarts: rfr-test-1 writes to /bitnami/redis/data, its data volume is mounted on /data; set spec.redis.dataDir to the dir of the redis image"
+	tests := []struct {
+		name         string
+		prevMessage  string
+		mismatches   []rfservice.DataDirMismatch
+		mismatchErr  error
+		expNoWrite   bool
+		expEvent     bool
+		expCondition bool
+	}{
+		{
+			name:         "A redis writing out of its data volume should set the condition and warn with an event",
+			mismatches:   []rfservice.DataDirMismatch{mismatch},
+			expEvent:     true,
+			expCondition: true,
+		},
+		{
+			name:         "An unchanged condition should not warn again",
+			prevMessage:  message,
+			mismatches:   []rfservice.DataDirMismatch{mismatch},
+			expCondition: true,
+		},
+		{
+			name:        "The dirs on the data volumes should remove the condition",
+			prevMessage: message,
+			mismatches:  []rfservice.DataDirMismatch{},
+		},
+		{
+			name:        "An error checking the dirs should keep the condition",
+			prevMessage: message,
+			mismatchErr: errors.New("wanted error"),
+			expNoWrite:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			if test.prevMessage != "" {
+				meta.SetStatusCondition(&rf.Status.Conditions, metav1.Condition{
+					Type:    redisfailoverv1.ConditionDegraded,
+					Status:  metav1.ConditionTrue,
+					Reason:  redisfailoverv1.ReasonDataDirNotOnVolume,
+					Message: test.prevMessage,
+				})
+			}
+			if test.expNoWrite {
+				rf.Status.Conditions = append(rf.Status.Conditions, notReadyConditions(0, 0)...)
+				rf.Status.Health = &redisfailoverv1.HealthReport{
+					Phase:   redisfailoverv1.HealthPhaseDegraded,
+					Message: message,
+				}
+				rf.Status.SentinelQuorum = 2
+			}
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+			if test.expEvent {
+				mk.On("CreateEvent", mock.Anything, namespace, mock.MatchedBy(func(e *corev1.Event) bool {
+					return e.Type == corev1.EventTypeWarning && e.Reason == redisfailoverv1.ReasonDataDirNotOnVolume && e.Message == message
+				})).Once().Return(nil)
+			}
+			if !test.expNoWrite {
+				mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+					condition := meta.FindStatusCondition(got.Status.Conditions, redisfailoverv1.ConditionDegraded)
+					if !test.expCondition {
+						return assert.Nil(condition) && assert.NotEqual(redisfailoverv1.HealthPhaseDegraded, got.Status.Health.Phase)
+					}
+					return assert.NotNil(condition) &&
+						assert.Equal(metav1.ConditionTrue, condition.Status) &&
+						assert.Equal(redisfailoverv1.ReasonDataDirNotOnVolume, condition.Reason) &&
+						assert.Equal(message, condition.Message) &&
+						assert.Equal(redisfailoverv1.HealthPhaseDegraded, got.Status.Health.Phase) &&
+						assert.Equal(message, got.Status.Health.Message)
+				})).Once().Return(rf, nil)
+			}
+
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return(test.mismatches, test.mismatchErr)
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.UpdateStatus(context.TODO(), rf)
+
+			assert.NoError(err)
+			mk.AssertExpectations(t)
+			mrfc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateStatusFunctionalProbe(t *testing.T) {
+	tests := []struct {
+		name         string
+		probe        bool
+		results      []rfservice.RedisFunctionalHealth
+		probeErr     error
+		expInstances []redisfailoverv1.InstanceStatus
+	}{
+		{
+			name:  "A pod answering the PING but failing the probe is unhealthy",
+			probe: true,
+			results: []rfservice.RedisFunctionalHealth{
+				{Pod: "rfr-test-0", Master: true, Healthy: true},
+				{Pod: "rfr-test-1", Message: "could not read the key of the functional probe: wanted error"},
+			},
+			expInstances: []redisfailoverv1.InstanceStatus{
+				{Name: "rfr-test-0", Role: "redis", Functional: redisfailoverv1.InstanceFunctionalHealthy},
+				{Name: "rfr-test-1", Role: "redis", Functional: redisfailoverv1.InstanceFunctionalUnhealthy, FunctionalMessage: "could not read the key of the functional probe: wanted error"},
+			},
+		},
+		{
+			name:     "An error running the probe leaves the instances without a result",
+			probe:    true,
+			probeErr: errors.New("wanted error"),
+			expInstances: []redisfailoverv1.InstanceStatus{
+				{Name: "rfr-test-0", Role: "redis"},
+				{Name: "rfr-test-1", Role: "redis"},
+			},
+		},
+		{
+			name: "The probe is not run when it's not enabled",
+			expInstances: []redisfailoverv1.InstanceStatus{
+				{Name: "rfr-test-0", Role: "redis"},
+				{Name: "rfr-test-1", Role: "redis"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Spec.Checks = &redisfailoverv1.ChecksSettings{FunctionalProbe: test.probe}
+			pods := &corev1.PodList{
+				Items: []corev1.Pod{
+					{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"}},
+				},
+			}
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(pods, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+				return assert.Equal(test.expInstances, got.Status.Instances)
+			})).Once().Return(rf, nil)
+
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			if test.probe {
+				mrfc.On("ProbeRedisFunctional", mock.Anything, rf).Once().Return(test.results, test.probeErr)
+			}
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.UpdateStatus(context.TODO(), rf)
+
+			assert.NoError(err)
+			mk.AssertExpectations(t)
+			mrfc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateStatusSentinelQuorum(t *testing.T) {
+	tests := []struct {
+		name         string
+		sentinels    int32
+		quorum       int32
+		prevWarning  bool
+		expQuorum    int32
+		expCondition bool
+	}{
+		{
+			name:      "The majority of the sentinels should be reported without a quorum in the spec",
+			sentinels: 5,
+			expQuorum: 3,
+		},
+		{
+			name:      "The quorum of the spec should be reported",
+			sentinels: 5,
+			quorum:    4,
+			expQuorum: 4,
+		},
+		{
+			name:         "A quorum below the majority should be warned about",
+			sentinels:    5,
+			quorum:       2,
+			expQuorum:    2,
+			expCondition: true,
+		},
+		{
+			name:        "Removing the quorum from the spec should remove the warning",
+			sentinels:   5,
+			prevWarning: true,
+			expQuorum:   3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Spec.Sentinel.Replicas = test.sentinels
+			rf.Spec.Sentinel.Quorum = test.quorum
+			if test.prevWarning {
+				meta.SetStatusCondition(&rf.Status.Conditions, metav1.Condition{
+					Type:    redisfailoverv1.ConditionQuorumWarning,
+					Status:  metav1.ConditionTrue,
+					Reason:  redisfailoverv1.ReasonQuorumBelowMajority,
+					Message: "previous",
+				})
+			}
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+				condition := meta.FindStatusCondition(got.Status.Conditions, redisfailoverv1.ConditionQuorumWarning)
+				if !test.expCondition {
+					return assert.Equal(test.expQuorum, got.Status.SentinelQuorum) && assert.Nil(condition)
+				}
+				return assert.Equal(test.expQuorum, got.Status.SentinelQuorum) &&
+					assert.NotNil(condition) &&
+					assert.Equal(metav1.ConditionTrue, condition.Status) &&
+					assert.Equal(redisfailoverv1.ReasonQuorumBelowMajority, condition.Reason) &&
+					assert.Equal("the sentinel quorum is 2, below the majority of the 5 sentinels, a minority of them can fail the master over", condition.Message)
+			})).Once().Return(rf, nil)
+
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.UpdateStatus(context.TODO(), rf)
+
+			assert.NoError(err)
+			mk.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateStatusDeprecatedFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		ttl          int32
+		prevWarning  bool
+		expCondition bool
+	}{
+		{
+			name: "No deprecated field should not be warned about",
+		},
+		{
+			name:         "A deprecated field should be warned about",
+			ttl:          30,
+			expCondition: true,
+		},
+		{
+			name:        "Migrating the spec should remove the warning",
+			prevWarning: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Spec.Redis.MasterDNS = &redisfailoverv1.RedisMasterDNS{Hostname: "redis.example.com", TTL: test.ttl}
+			if test.prevWarning {
+				meta.SetStatusCondition(&rf.Status.Conditions, metav1.Condition{
+					Type:    redisfailoverv1.ConditionDeprecatedFieldsInUse,
+					Status:  metav1.ConditionTrue,
+					Reason:  redisfailoverv1.ReasonDeprecatedFields,
+					Message: "previous",
+				})
+			}
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+				condition := meta.FindStatusCondition(got.Status.Conditions, redisfailoverv1.ConditionDeprecatedFieldsInUse)
+				if !test.expCondition {
+					return assert.Nil(condition)
+				}
+				return assert.NotNil(condition) &&
+					assert.Equal(metav1.ConditionTrue, condition.Status) &&
+					assert.Equal(redisfailoverv1.ReasonDeprecatedFields, condition.Reason) &&
+					assert.Equal("the spec uses deprecated fields: redis.masterDNS.ttl (use redis.masterDNS.ttlDuration, removed in v2)", condition.Message)
+			})).Once().Return(rf, nil)
+
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.UpdateStatus(context.TODO(), rf)
+
+			assert.NoError(err)
+			mk.AssertExpectations(t)
+		})
+	}
+}
+
+func generateReadyPod(name string, revision string, ready bool) corev1.Pod {
+	pod := generatePodWithContainerStatuses(name)
+	pod.Labels = map[string]string{appsv1.ControllerRevisionHashLabelKey: revision}
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+	return pod
+}
+
+func TestUpdateStatusHealth(t *testing.T) {
+	sentinelPods := []corev1.Pod{
+		generateReadyPod("rfs-test-a", "", true),
+		generateReadyPod("rfs-test-b", "", true),
+		generateReadyPod("rfs-test-c", "", true),
+	}
+	now := metav1.Now()
+
+	tests := []struct {
+		name               string
+		nonPromotable      bool
+		countNonPromotable bool
+		redisPods          []corev1.Pod
+		expHealth          redisfailoverv1.HealthReport
+	}{
+		{
+			name: "All the pods ready at the statefulset revision should be healthy",
+			redisPods: []corev1.Pod{
+				generateReadyPod("rfr-test-0", "rev-2", true),
+				generateReadyPod("rfr-test-1", "rev-2", true),
+				generateReadyPod("rfr-test-2", "rev-2", true),
+			},
+			expHealth: redisfailoverv1.HealthReport{
+				Phase:              redisfailoverv1.HealthPhaseHealthy,
+				Ready:              true,
+				Message:            "3/3 redis and 3/3 sentinel pods ready",
+				ObservedGeneration: 4,
+			},
+		},
+		{
+			name: "A pod not ready should be progressing",
+			redisPods: []corev1.Pod{
+				generateReadyPod("rfr-test-0", "rev-2", true),
+				generateReadyPod("rfr-test-1", "rev-2", false),
+				generateReadyPod("rfr-test-2", "rev-2", true),
+			},
+			expHealth: redisfailoverv1.HealthReport{
+				Phase:              redisfailoverv1.HealthPhaseProgressing,
+				Message:            "2/3 redis and 3/3 sentinel pods ready",
+				ObservedGeneration: 4,
+			},
+		},
+		{
+			name: "A ready pod being deleted should not be counted",
+			redisPods: func() []corev1.Pod {
+				deleted := generateReadyPod("rfr-test-2", "rev-2", true)
+				deleted.DeletionTimestamp = &now
+				return []corev1.Pod{
+					generateReadyPod("rfr-test-0", "rev-2", true),
+					generateReadyPod("rfr-test-1", "rev-2", true),
+					deleted,
+				}
+			}(),
+			expHealth: redisfailoverv1.HealthReport{
+				Phase:              redisfailoverv1.HealthPhaseProgressing,
+				Message:            "2/3 redis and 3/3 sentinel pods ready",
+				ObservedGeneration: 4,
+			},
+		},
+		{
+			name: "Ready pods at an older revision should be progressing with a restart pending",
+			redisPods: []corev1.Pod{
+				generateReadyPod("rfr-test-0", "rev-1", true),
+				generateReadyPod("rfr-test-1", "rev-1", true),
+				generateReadyPod("rfr-test-2", "rev-2", true),
+			},
+			expHealth: redisfailoverv1.HealthReport{
+				Phase:              redisfailoverv1.HealthPhaseProgressing,
+				Ready:              true,
+				Message:            "2 redis pods are pending a restart to the statefulset revision",
+				ObservedGeneration: 4,
+				RestartPending:     true,
+			},
+		},
+		{
+			name:          "A pod never promoted should not be waited for",
+			nonPromotable: true,
+			redisPods: []corev1.Pod{
+				generateReadyPod("rfr-test-0", "rev-2", true),
+				generateReadyPod("rfr-test-1", "rev-2", true),
+				generateReadyPod("rfr-test-2", "rev-2", false),
+			},
+			expHealth: redisfailoverv1.HealthReport{
+				Phase:              redisfailoverv1.HealthPhaseHealthy,
+				Ready:              true,
+				Message:            "2/2 redis and 3/3 sentinel pods ready",
+				ObservedGeneration: 4,
+			},
+		},
+		{
+			name:               "A pod never promoted should be waited for when it's counted",
+			nonPromotable:      true,
+			countNonPromotable: true,
+			redisPods: []corev1.Pod{
+				generateReadyPod("rfr-test-0", "rev-2", true),
+				generateReadyPod("rfr-test-1", "rev-2", true),
+				generateReadyPod("rfr-test-2", "rev-2", false),
+			},
+			expHealth: redisfailoverv1.HealthReport{
+				Phase:              redisfailoverv1.HealthPhaseProgressing,
+				Message:            "2/3 redis and 3/3 sentinel pods ready",
+				ObservedGeneration: 4,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Generation = 4
+			if test.nonPromotable {
+				zero := int32(0)
+				rf.Spec.Redis.InstanceOverrides = map[string]redisfailoverv1.RedisInstanceOverride{"2": {ReplicaPriority: &zero}}
+				rf.Spec.Redis.CountNonPromotable = test.countNonPromotable
+			}
+
+			ss := &appsv1.StatefulSet{Status: appsv1.StatefulSetStatus{UpdateRevision: "rev-2"}}
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: test.redisPods}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(ss, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{Items: sentinelPods}, nil)
+			mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+				return assert.NotNil(got.Status.Health) && assert.Equal(test.expHealth, *got.Status.Health)
+			})).Once().Return(rf, nil)
+
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			err := handler.UpdateStatus(context.TODO(), rf)
+
+			assert.NoError(err)
+			mk.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateStatusBackups(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	rf.Spec.Backup = &redisfailoverv1.BackupSettings{Method: redisfailoverv1.BackupMethodVolumeSnapshot}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Local()
+	snapshots := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		generateSnapshot("rfb-test-20240102-030405", created, true),
+	}}
+
+	mk := &mK8SService.Services{}
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{}, nil)
+	mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+	mk.On("ListVolumeSnapshots", mock.Anything, namespace, mock.Anything).Once().Return(snapshots, nil)
+	mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+		return assert.Equal([]redisfailoverv1.BackupStatus{
+			{Name: "rfb-test-20240102-030405", CreatedAt: metav1.NewTime(created), ReadyToUse: true},
+		}, got.Status.Backups)
+	})).Once().Return(rf, nil)
+
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+	mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+	mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+	mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+	mk.AssertExpectations(t)
+}
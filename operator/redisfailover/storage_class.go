@@ -0,0 +1,40 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// CheckStorageClass returns an error when the storage class of the redis claims doesn't exist and the
+// redis statefulset is not created yet: its claims would never bind, the pods pending without a word.
+// The statefulset is created once the class is. The existing statefulsets keep their claims and are
+// reconciled, as the classes that can't be read, as without the permission to, which are only logged.
+func (r *RedisFailoverHandler) CheckStorageClass(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	pvc := rf.Spec.Redis.Storage.PersistentVolumeClaim
+	if pvc == nil || pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return nil
+	}
+	name := *pvc.Spec.StorageClassName
+	_, err := r.k8sservice.GetStorageClass(ctx, name)
+	switch {
+	case err == nil:
+		return nil
+	case !errors.IsNotFound(err):
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Debugf("could not check the storage class %s of the redis volumes: %s", name, err)
+		return nil
+	}
+
+	_, err = r.k8sservice.GetStatefulSet(ctx, rf.Namespace, rfservice.GetRedisStatefulSetName(rf))
+	switch {
+	case err == nil:
+		return nil
+	case !errors.IsNotFound(err):
+		return err
+	}
+	return fmt.Errorf("the storage class %s of the redis volumes doesn't exist, the redis statefulset is not created until it does", name)
+}
@@ -0,0 +1,117 @@
+package redisfailover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+func TestCheckStorageClass(t *testing.T) {
+	classNotFound := kubeerrors.NewNotFound(schema.GroupResource{Group: "storage.k8s.io", Resource: "storageclasses"}, "ssd")
+	statefulSetNotFound := kubeerrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "statefulsets"}, "rfr-test")
+	ssd := "ssd"
+	defaultClass := ""
+
+	tests := []struct {
+		name           string
+		storageClass   *string
+		classErr       error
+		statefulSetErr error
+		expGetClass    bool
+		expStatefulSet bool
+		expErr         string
+	}{
+		{
+			name: "No storage class is not checked",
+		},
+		{
+			name:         "The default storage class is not checked",
+			storageClass: &defaultClass,
+		},
+		{
+			name:         "An existing storage class passes",
+			storageClass: &ssd,
+			expGetClass:  true,
+		},
+		{
+			name:         "A storage class that can't be read passes",
+			storageClass: &ssd,
+			classErr:     errors.New("forbidden"),
+			expGetClass:  true,
+		},
+		{
+			name:           "A missing storage class fails before the statefulset is created",
+			storageClass:   &ssd,
+			classErr:       classNotFound,
+			statefulSetErr: statefulSetNotFound,
+			expGetClass:    true,
+			expStatefulSet: true,
+			expErr:         "the storage class ssd of the redis volumes doesn't exist, the redis statefulset is not created until it does",
+		},
+		{
+			name:           "A missing storage class passes once the statefulset is created",
+			storageClass:   &ssd,
+			classErr:       classNotFound,
+			expGetClass:    true,
+			expStatefulSet: true,
+		},
+		{
+			name:           "A statefulset that can't be read fails",
+			storageClass:   &ssd,
+			classErr:       classNotFound,
+			statefulSetErr: errors.New("timeout"),
+			expGetClass:    true,
+			expStatefulSet: true,
+			expErr:         "timeout",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			if test.storageClass != nil {
+				rf.Spec.Redis.Storage.PersistentVolumeClaim = &redisfailoverv1.EmbeddedPersistentVolumeClaim{
+					Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: test.storageClass},
+				}
+			}
+
+			mk := &mK8SService.Services{}
+			if test.expGetClass {
+				mk.On("GetStorageClass", mock.Anything, "ssd").Once().Return(&storagev1.StorageClass{}, test.classErr)
+			}
+			if test.expStatefulSet {
+				mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, test.statefulSetErr)
+			}
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.Dummy, log.Dummy)
+			err := handler.CheckStorageClass(context.TODO(), rf)
+
+			if test.expErr != "" {
+				assert.EqualError(err, test.expErr)
+			} else {
+				assert.NoError(err)
+			}
+			mk.AssertExpectations(t)
+			if !test.expGetClass {
+				mk.AssertNotCalled(t, "GetStorageClass", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package redisfailover
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/operator/redisfailover/supportbundle"
+	"redis-operator/service/k8s"
+)
+
+const (
+	// DefaultSupportBundleLogLines is the number of the last lines logged about every RF kept for its
+	// support bundles.
+	DefaultSupportBundleLogLines = 200
+	// maxSupportBundleSize is the size a support bundle can have to fit in a ConfigMap.
+	maxSupportBundleSize = 1000 * 1024
+	// supportBundleErrorKey holds, instead of the bundle, why the requested bundle could not be stored.
+	supportBundleErrorKey = "error.txt"
+	// supportBundleGenerationAnnotation holds the generation of the RF a failed request was collected for.
+	supportBundleGenerationAnnotation = "databases.spotahome.com/support-bundle-generation"
+)
+
+// SupportBundleRequests collects the support bundles requested with the support bundle annotation into
+// a ConfigMap next to the RF.
+type SupportBundleRequests struct {
+	k8sService k8s.Services
+	collector  *supportbundle.Collector
+	logger     log.Logger
+}
+
+// NewSupportBundleRequests returns a new support bundle request handler.
+func NewSupportBundleRequests(k8sService k8s.Services, collector *supportbundle.Collector, logger log.Logger) *SupportBundleRequests {
+	return &SupportBundleRequests{
+		k8sService: k8sService,
+		collector:  collector,
+		logger:     logger,
+	}
+}
+
+// Ensure collects the support bundle requested on the RF, if any. The request is stored on the ConfigMap
+// holding the bundle, so every request is collected once. A bundle too big for the ConfigMap is stored as
+// its error, and collected again once the request or the spec of the RF change.
+func (s *SupportBundleRequests) Ensure(ctx context.Context, rf *redisfailoverv1.RedisFailover, labels map[string]string, ownerRefs []metav1.OwnerReference) error {
+	request := rf.SupportBundleRequest()
+	if request == "" {
+		return nil
+	}
+
+	name := rfservice.GetSupportBundleName(rf)
+	stored, err := s.k8sService.GetConfigMap(ctx, rf.Namespace, name)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && collectedBundle(stored, rf, request) {
+		return nil
+	}
+
+	var archive bytes.Buffer
+	if err := s.collector.Collect(ctx, rf).WriteTarGz(&archive); err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       rf.Namespace,
+			Labels:          labels,
+			OwnerReferences: ownerRefs,
+			Annotations: map[string]string{
+				redisfailoverv1.SupportBundleAnnotation: request,
+			},
+		},
+		BinaryData: map[string][]byte{
+			supportbundle.FileName: archive.Bytes(),
+		},
+	}
+	logger := s.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name)
+	if archive.Len() > maxSupportBundleSize {
+		err := fmt.Errorf("support bundle is %d bytes, more than the %d bytes a ConfigMap can hold", archive.Len(), maxSupportBundleSize)
+		cm.Annotations[supportBundleGenerationAnnotation] = strconv.FormatInt(rf.Generation, 10)
+		cm.BinaryData = nil
+		cm.Data = map[string]string{supportBundleErrorKey: err.Error() + "\n"}
+		if err := s.k8sService.CreateOrUpdateConfigMap(ctx, rf.Namespace, cm); err != nil {
+			return err
+		}
+		logger.Warnf("support bundle %q could not be collected into configmap %s: %s", request, name, err)
+		return nil
+	}
+	if err := s.k8sService.CreateOrUpdateConfigMap(ctx, rf.Namespace, cm); err != nil {
+		return err
+	}
+	logger.Infof("support bundle %q collected into configmap %s", request, name)
+	return nil
+}
+
+// collectedBundle returns true when the ConfigMap holds the bundle of the request, or its error when
+// the spec of the RF has not changed since.
+func collectedBundle(stored *corev1.ConfigMap, rf *redisfailoverv1.RedisFailover, request string) bool {
+	if stored.Annotations[redisfailoverv1.SupportBundleAnnotation] != request {
+		return false
+	}
+	if _, failed := stored.Data[supportBundleErrorKey]; !failed {
+		return true
+	}
+	return stored.Annotations[supportBundleGenerationAnnotation] == strconv.FormatInt(rf.Generation, 10)
+}
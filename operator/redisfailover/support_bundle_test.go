@@ -0,0 +1,152 @@
+package redisfailover_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	rfOperator "redis-operator/operator/redisfailover"
+	"redis-operator/operator/redisfailover/supportbundle"
+)
+
+func TestSupportBundleRequestsEnsure(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       string
+		storedRequest string
+		// storedFailure is the generation of the RF the stored request failed for, if it failed.
+		storedFailure string
+		tooBig        bool
+		expCollect    bool
+		expFailure    bool
+	}{
+		{
+			name: "Without a request nothing is collected",
+		},
+		{
+			name:       "A new request is collected",
+			request:    "1",
+			expCollect: true,
+		},
+		{
+			name:          "A changed request is collected again",
+			request:       "2",
+			storedRequest: "1",
+			expCollect:    true,
+		},
+		{
+			name:          "An already collected request is not collected again",
+			request:       "1",
+			storedRequest: "1",
+		},
+		{
+			name:       "A bundle too big for the configmap is stored as its error",
+			request:    "1",
+			tooBig:     true,
+			expCollect: true,
+			expFailure: true,
+		},
+		{
+			name:          "A failed request is not collected again while the spec doesn't change",
+			request:       "1",
+			storedRequest: "1",
+			storedFailure: "3",
+		},
+		{
+			name:          "A failed request is collected again once the spec changed",
+			request:       "1",
+			storedRequest: "1",
+			storedFailure: "2",
+			expCollect:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Generation = 3
+			if test.request != "" {
+				rf.Annotations = map[string]string{redisfailoverv1.SupportBundleAnnotation: test.request}
+			}
+			notFound := kubeerrors.NewNotFound(schema.GroupResource{}, "")
+			labels := map[string]string{"app": "redis"}
+			oRefs := []metav1.OwnerReference{{Name: name}}
+
+			ms := &mK8SService.Services{}
+			if test.request != "" {
+				if test.storedRequest != "" {
+					stored := &corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{redisfailoverv1.SupportBundleAnnotation: test.storedRequest},
+						},
+					}
+					if test.storedFailure != "" {
+						stored.Annotations["databases.spotahome.com/support-bundle-generation"] = test.storedFailure
+						stored.Data = map[string]string{"error.txt": "support bundle is too big\n"}
+					}
+					ms.On("GetConfigMap", mock.Anything, namespace, "rfsb-test").Once().Return(stored, nil)
+				} else {
+					ms.On("GetConfigMap", mock.Anything, namespace, "rfsb-test").Once().Return(nil, notFound)
+				}
+			}
+			if test.expCollect {
+				ms.On("GetStatefulSet", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+				ms.On("GetDeployment", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+				ms.On("GetService", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+				ms.On("GetPodDisruptionBudget", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+				ms.On("GetConfigMap", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+				ms.On("GetStatefulSetPods", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+				ms.On("GetDeploymentPods", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+				ms.On("ListEvents", mock.Anything, namespace).Return(&corev1.EventList{}, nil)
+				ms.On("CreateOrUpdateConfigMap", mock.Anything, namespace, mock.Anything).Once().Run(func(args mock.Arguments) {
+					cm := args.Get(2).(*corev1.ConfigMap)
+					assert.Equal("rfsb-test", cm.Name)
+					assert.Equal(labels, cm.Labels)
+					assert.Equal(oRefs, cm.OwnerReferences)
+					assert.Equal(test.request, cm.Annotations[redisfailoverv1.SupportBundleAnnotation])
+					if test.expFailure {
+						assert.Equal("3", cm.Annotations["databases.spotahome.com/support-bundle-generation"])
+						assert.Contains(cm.Data["error.txt"], "more than the 1024000 bytes a ConfigMap can hold")
+						assert.Empty(cm.BinaryData)
+					} else {
+						assert.NotEmpty(cm.BinaryData[supportbundle.FileName])
+						assert.Empty(cm.Data)
+					}
+				}).Return(nil)
+			}
+
+			collector := supportbundle.NewCollector(ms, &mRedisService.Client{}, log.Dummy)
+			if test.tooBig {
+				// Random lines, that can't be compressed below the size a ConfigMap holds.
+				collector.OperatorLogs = func(string, string) []string {
+					lines := []string{}
+					for i := 0; i < 1500; i++ {
+						line := make([]byte, 1024)
+						_, _ = rand.Read(line)
+						lines = append(lines, base64.StdEncoding.EncodeToString(line))
+					}
+					return lines
+				}
+			}
+			requests := rfOperator.NewSupportBundleRequests(ms, collector, log.Dummy)
+			err := requests.Ensure(context.TODO(), rf, labels, oRefs)
+
+			assert.NoError(err)
+			ms.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package supportbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// FileName is the name given to the support bundles.
+const FileName = "support-bundle.tar.gz"
+
+type file struct {
+	name    string
+	content []byte
+}
+
+// Bundle holds the files of a support bundle. Every file added is redacted, so the bundle never holds a password.
+type Bundle struct {
+	// dir is the directory the files are written in on the archive.
+	dir      string
+	created  time.Time
+	redactor *Redactor
+	files    []file
+}
+
+// NewBundle returns an empty bundle whose files are written in dir, redacted by the given redactor.
+func NewBundle(dir string, created time.Time, redactor *Redactor) *Bundle {
+	return &Bundle{
+		dir:      dir,
+		created:  created,
+		redactor: redactor,
+	}
+}
+
+// Add adds a file to the bundle, replacing the one with the same name if any.
+func (b *Bundle) Add(name string, content []byte) {
+	content = b.redactor.Redact(content)
+	for i, f := range b.files {
+		if f.name == name {
+			b.files[i].content = content
+			return
+		}
+	}
+	b.files = append(b.files, file{name: name, content: content})
+}
+
+// AddJSON adds a file to the bundle with the object serialized as JSON.
+func (b *Bundle) AddJSON(name string, obj interface{}) error {
+	content, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not serialize %s: %w", name, err)
+	}
+	b.Add(name, append(content, '\n'))
+	return nil
+}
+
+// Files returns the names of the files of the bundle, in the order they were added.
+func (b *Bundle) Files() []string {
+	names := make([]string, 0, len(b.files))
+	for _, f := range b.files {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+// File returns the content of a file of the bundle, false if it doesn't exist.
+func (b *Bundle) File(name string) ([]byte, bool) {
+	for _, f := range b.files {
+		if f.name == name {
+			return f.content, true
+		}
+	}
+	return nil, false
+}
+
+// WriteTarGz writes the bundle as a gzipped tar archive.
+func (b *Bundle) WriteTarGz(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, f := range b.files {
+		header := &tar.Header{
+			Name:    path.Join(b.dir, f.name),
+			Mode:    0644,
+			Size:    int64(len(f.content)),
+			ModTime: b.created,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
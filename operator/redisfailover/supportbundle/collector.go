@@ -0,0 +1,353 @@
+package supportbundle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
+)
+
+const (
+	defaultRedisPort    = 6379
+	errorsFileName      = "errors.txt"
+	operatorLogFileName = "operator.log"
+)
+
+// Collector gathers the support bundle of a RedisFailover: the object with its status, the objects
+// generated for it, their events, the INFO of every redis and sentinel, the rendered configurations,
+// the configs the sentinels rewrote and the last lines the operator logged about it.
+type Collector struct {
+	// OperatorLogs returns the last lines the operator logged about the RedisFailover. It's optional,
+	// without it the bundle has no operator logs, as when it's collected from outside the operator.
+	OperatorLogs func(namespace, name string) []string
+
+	k8sService  k8s.Services
+	redisClient redis.Client
+	logger      log.Logger
+	now         func() time.Time
+}
+
+// NewCollector returns a new support bundle collector.
+func NewCollector(k8sService k8s.Services, redisClient redis.Client, logger log.Logger) *Collector {
+	return &Collector{
+		k8sService:  k8sService,
+		redisClient: redisClient,
+		logger:      logger.With("service", "supportbundle"),
+		now:         time.Now,
+	}
+}
+
+// CollectByName gets the RedisFailover and collects its support bundle.
+func (c *Collector) CollectByName(ctx context.Context, namespace, name string) (*Bundle, error) {
+	rf, err := c.k8sService.GetRedisFailover(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Collect(ctx, rf), nil
+}
+
+// Collect collects the support bundle of the RedisFailover. The collection goes on when some part of it
+// fails, the failures are stored in the bundle errors file.
+func (c *Collector) Collect(ctx context.Context, rf *redisfailoverv1.RedisFailover) *Bundle {
+	col := &collection{
+		Collector: c,
+		rf:        rf,
+		names:     map[string]bool{rf.Name: true},
+	}
+
+	password, err := k8s.GetRedisPassword(ctx, c.k8sService, rf)
+	if err != nil {
+		col.fail("getting the redis password", err)
+	}
+	col.redactor = NewRedactor(password)
+	col.bundle = NewBundle(fmt.Sprintf("%s-%s", rf.Namespace, rf.Name), c.now(), col.redactor)
+
+	col.addObject("redisfailover.json", rf.DeepCopy())
+	col.collectObjects(ctx)
+	redisPods, sentinelPods := col.collectPods(ctx)
+	col.collectEvents(ctx)
+	col.collectRedisInfo(redisPods, password)
+	col.collectSentinelInfo(sentinelPods)
+	col.collectSentinelConfig(ctx, sentinelPods)
+	col.collectOperatorLogs()
+
+	if len(col.errors) > 0 {
+		col.bundle.Add(errorsFileName, []byte(strings.Join(col.errors, "\n")+"\n"))
+	}
+	return col.bundle
+}
+
+// collection holds the state of the collection of a bundle.
+type collection struct {
+	*Collector
+	rf       *redisfailoverv1.RedisFailover
+	redactor *Redactor
+	bundle   *Bundle
+	// names holds the names of the collected objects, to pick their events.
+	names  map[string]bool
+	errors []string
+}
+
+func (c *collection) fail(action string, err error) {
+	c.logger.WithField("namespace", c.rf.Namespace).WithField("redisfailover", c.rf.Name).Warnf("support bundle: error %s: %s", action, err)
+	c.errors = append(c.errors, fmt.Sprintf("error %s: %s", action, err))
+}
+
+func (c *collection) addObject(name string, obj metav1.Object) {
+	obj.SetManagedFields(nil)
+	c.names[obj.GetName()] = true
+	if err := c.bundle.AddJSON(name, obj); err != nil {
+		c.fail("adding "+name, err)
+	}
+}
+
+// collected returns true when the object was found. Missing objects are skipped, as not every
+// RedisFailover has all of them.
+func (c *collection) collected(kind, name string, err error) bool {
+	if err == nil {
+		return true
+	}
+	if !errors.IsNotFound(err) {
+		c.fail(fmt.Sprintf("getting %s %s", kind, name), err)
+	}
+	return false
+}
+
+func (c *collection) collectObjects(ctx context.Context) {
+	ns := c.rf.Namespace
+
+	if auth := c.rf.Spec.Auth.SecretPath; auth != "" {
+		secret, err := c.k8sService.GetSecret(ctx, ns, auth)
+		if c.collected("secret", auth, err) {
+			RedactSecret(secret)
+			c.addObject(fmt.Sprintf("secrets/%s.json", auth), secret)
+		}
+	}
+
+	redisName := rfservice.GetRedisName(c.rf)
+	sentinelName := rfservice.GetSentinelName(c.rf)
+
+	statefulSetName := rfservice.GetRedisStatefulSetName(c.rf)
+	ss, err := c.k8sService.GetStatefulSet(ctx, ns, statefulSetName)
+	ssFound := c.collected("statefulset", statefulSetName, err)
+	if ssFound {
+		RedactPodSpec(&ss.Spec.Template.Spec)
+		c.addObject(fmt.Sprintf("statefulsets/%s.json", statefulSetName), ss)
+	}
+	d, err := c.k8sService.GetDeployment(ctx, ns, sentinelName)
+	dFound := c.collected("deployment", sentinelName, err)
+	if dFound {
+		RedactPodSpec(&d.Spec.Template.Spec)
+		c.addObject(fmt.Sprintf("deployments/%s.json", sentinelName), d)
+	}
+
+	for _, name := range []string{redisName, sentinelName, rfservice.GetRedisMasterName(c.rf)} {
+		svc, err := c.k8sService.GetService(ctx, ns, name)
+		if c.collected("service", name, err) {
+			c.addObject(fmt.Sprintf("services/%s.json", name), svc)
+		}
+	}
+	for _, name := range []string{redisName, sentinelName} {
+		pdb, err := c.k8sService.GetPodDisruptionBudget(ctx, ns, name)
+		if c.collected("poddisruptionbudget", name, err) {
+			c.addObject(fmt.Sprintf("poddisruptionbudgets/%s.json", name), pdb)
+		}
+	}
+
+	configMaps := []string{
+		rfservice.GetRedisShutdownConfigMapName(c.rf),
+		rfservice.GetRedisReadinessName(c.rf),
+	}
+	if c.rf.ImmutableConfigEnabled() {
+		// The configurations are named after their revision, the ones the workloads mount are collected.
+		if ssFound {
+			configMaps = appendMountedConfigMaps(configMaps, ss.Spec.Template.Spec, redisName+"-")
+		}
+		if dFound {
+			configMaps = appendMountedConfigMaps(configMaps, d.Spec.Template.Spec, sentinelName+"-")
+		}
+		for _, name := range configMaps {
+			c.collectConfigMap(ctx, name)
+		}
+		return
+	}
+	configMaps = append(configMaps, redisName, sentinelName)
+	for _, name := range configMaps {
+		c.collectConfigMap(ctx, name)
+	}
+	// The parts of a split redis configuration are numbered from 0.
+	for i := 0; ; i++ {
+		if !c.collectConfigMap(ctx, rfservice.GetRedisConfigPartName(c.rf, i)) {
+			break
+		}
+	}
+}
+
+// appendMountedConfigMaps appends the ConfigMaps with the prefix mounted by the pods of the spec, projected
+// or not, that are not in names yet.
+func appendMountedConfigMaps(names []string, spec corev1.PodSpec, prefix string) []string {
+	add := func(name string) {
+		if !strings.HasPrefix(name, prefix) {
+			return
+		}
+		for _, n := range names {
+			if n == name {
+				return
+			}
+		}
+		names = append(names, name)
+	}
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil {
+			add(v.ConfigMap.Name)
+		}
+		if v.Projected != nil {
+			for _, source := range v.Projected.Sources {
+				if source.ConfigMap != nil {
+					add(source.ConfigMap.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// collectConfigMap adds the ConfigMap and the configurations it holds, returning if it was found.
+func (c *collection) collectConfigMap(ctx context.Context, name string) bool {
+	cm, err := c.k8sService.GetConfigMap(ctx, c.rf.Namespace, name)
+	if !c.collected("configmap", name, err) {
+		return false
+	}
+	// The configurations are redacted before serializing the ConfigMap, as their directives can't be
+	// told apart once their lines are escaped.
+	keys := make([]string, 0, len(cm.Data))
+	for key, content := range cm.Data {
+		keys = append(keys, key)
+		cm.Data[key] = string(c.redactor.Redact([]byte(content)))
+	}
+	sort.Strings(keys)
+	c.addObject(fmt.Sprintf("configmaps/%s.json", name), cm)
+	for _, key := range keys {
+		c.bundle.Add(fmt.Sprintf("configs/%s/%s", name, key), []byte(cm.Data[key]))
+	}
+	return true
+}
+
+func (c *collection) collectPods(ctx context.Context) (redisPods, sentinelPods []corev1.Pod) {
+	ns := c.rf.Namespace
+
+	if !c.rf.ExternalNodesEnabled() {
+		pods, err := c.k8sService.GetStatefulSetPods(ctx, ns, rfservice.GetRedisStatefulSetName(c.rf))
+		if c.collected("redis pods", rfservice.GetRedisStatefulSetName(c.rf), err) {
+			redisPods = pods.Items
+		}
+	}
+	pods, err := c.k8sService.GetDeploymentPods(ctx, ns, rfservice.GetSentinelName(c.rf))
+	if c.collected("sentinel pods", rfservice.GetSentinelName(c.rf), err) {
+		sentinelPods = pods.Items
+	}
+
+	for _, pod := range append(append([]corev1.Pod{}, redisPods...), sentinelPods...) {
+		pod := pod
+		RedactPodSpec(&pod.Spec)
+		c.addObject(fmt.Sprintf("pods/%s.json", pod.Name), &pod)
+	}
+	return redisPods, sentinelPods
+}
+
+func (c *collection) collectEvents(ctx context.Context) {
+	events, err := c.k8sService.ListEvents(ctx, c.rf.Namespace)
+	if err != nil {
+		c.fail("listing the events", err)
+		return
+	}
+	rfEvents := &corev1.EventList{}
+	for _, event := range events.Items {
+		if c.names[event.InvolvedObject.Name] {
+			rfEvents.Items = append(rfEvents.Items, event)
+		}
+	}
+	if err := c.bundle.AddJSON("events.json", rfEvents); err != nil {
+		c.fail("adding events.json", err)
+	}
+}
+
+func (c *collection) collectRedisInfo(pods []corev1.Pod, password string) {
+	port := strconv.Itoa(defaultRedisPort)
+	if c.rf.Spec.Redis.Port > 0 {
+		port = strconv.Itoa(int(c.rf.Spec.Redis.Port))
+	}
+	for _, pod := range pods {
+		if pod.Status.PodIP == "" {
+			continue
+		}
+		info, err := c.redisClient.GetRedisInfo(pod.Status.PodIP, port, password)
+		if err != nil {
+			c.fail(fmt.Sprintf("getting the INFO of redis %s", pod.Name), err)
+			continue
+		}
+		c.bundle.Add(fmt.Sprintf("redis/%s.info", pod.Name), []byte(info))
+	}
+	for _, node := range c.rf.Spec.Redis.ExternalNodes {
+		name := fmt.Sprintf("%s:%s", node.Host, node.Port)
+		info, err := c.redisClient.GetRedisInfo(node.Host, node.Port, password)
+		if err != nil {
+			c.fail(fmt.Sprintf("getting the INFO of redis %s", name), err)
+			continue
+		}
+		c.bundle.Add(fmt.Sprintf("redis/%s.info", name), []byte(info))
+	}
+}
+
+func (c *collection) collectSentinelInfo(pods []corev1.Pod) {
+	for _, pod := range pods {
+		if pod.Status.PodIP == "" {
+			continue
+		}
+		info, err := c.redisClient.GetSentinelInfo(pod.Status.PodIP)
+		if err != nil {
+			c.fail(fmt.Sprintf("getting the INFO of sentinel %s", pod.Name), err)
+			continue
+		}
+		c.bundle.Add(fmt.Sprintf("sentinel/%s.info", pod.Name), []byte(info))
+	}
+}
+
+// collectSentinelConfig adds the config of every sentinel as it rewrote it, the ConfigMap only holds
+// the config it started with.
+func (c *collection) collectSentinelConfig(ctx context.Context, pods []corev1.Pod) {
+	for _, pod := range pods {
+		if pod.Status.PodIP == "" {
+			continue
+		}
+		config, err := c.k8sService.ExecPod(ctx, c.rf.Namespace, pod.Name, rfservice.SentinelContainerName, []string{"cat", rfservice.SentinelConfigPath})
+		if err != nil {
+			c.fail(fmt.Sprintf("getting the config of sentinel %s", pod.Name), err)
+			continue
+		}
+		c.bundle.Add(fmt.Sprintf("sentinel/%s.conf", pod.Name), []byte(config))
+	}
+}
+
+func (c *collection) collectOperatorLogs() {
+	if c.OperatorLogs == nil {
+		return
+	}
+	lines := c.OperatorLogs(c.rf.Namespace, c.rf.Name)
+	if len(lines) == 0 {
+		return
+	}
+	c.bundle.Add(operatorLogFileName, []byte(strings.Join(lines, "\n")+"\n"))
+}
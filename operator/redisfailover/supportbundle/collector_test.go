@@ -0,0 +1,186 @@
+package supportbundle_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	mK8SService "redis-operator/mocks/service/k8s"
+	mRedisService "redis-operator/mocks/service/redis"
+	"redis-operator/operator/redisfailover/supportbundle"
+)
+
+const (
+	name      = "test"
+	namespace = "testns"
+	password  = "hunter2"
+)
+
+func TestCollect(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := &redisfailoverv1.RedisFailover{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: redisfailoverv1.RedisFailoverSpec{
+			Auth: redisfailoverv1.AuthSettings{SecretPath: "redis-auth"},
+			Redis: redisfailoverv1.RedisSettings{
+				Port:         6379,
+				CustomConfig: []string{"requirepass " + password},
+			},
+		},
+	}
+	notFound := kubeerrors.NewNotFound(schema.GroupResource{}, "")
+
+	ms := &mK8SService.Services{}
+	ms.On("GetSecret", mock.Anything, namespace, "redis-auth").Return(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "redis-auth"},
+		Data:       map[string][]byte{"password": []byte(password)},
+	}, nil)
+	ms.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "rfr-test"},
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "redis", Env: []corev1.EnvVar{{Name: "AUTH_TOKEN", Value: "other-secret"}}},
+					},
+				},
+			},
+		},
+	}, nil)
+	ms.On("GetDeployment", mock.Anything, namespace, "rfs-test").Once().Return(nil, errors.New("forbidden"))
+	ms.On("GetService", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetPodDisruptionBudget", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetConfigMap", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "rfr-test"},
+		Data:       map[string]string{"redis.conf": "port 6379\nmasterauth " + password + "\nrequirepass " + password},
+	}, nil)
+	ms.On("GetConfigMap", mock.Anything, namespace, mock.Anything).Return(nil, notFound)
+	ms.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{
+		Items: []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0"}, Status: corev1.PodStatus{PodIP: "10.0.0.1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1"}},
+		},
+	}, nil)
+	ms.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{
+		Items: []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "rfs-test-abc"}, Status: corev1.PodStatus{PodIP: "10.0.0.2"}},
+		},
+	}, nil)
+	ms.On("ExecPod", mock.Anything, namespace, "rfs-test-abc", "sentinel", []string{"cat", "/redis/sentinel.conf"}).Once().Return("sentinel monitor mymaster 10.0.0.1 6379 2\nsentinel auth-pass mymaster "+password+"\n", nil)
+	ms.On("ListEvents", mock.Anything, namespace).Once().Return(&corev1.EventList{
+		Items: []corev1.Event{
+			{ObjectMeta: metav1.ObjectMeta{Name: "e1"}, InvolvedObject: corev1.ObjectReference{Name: "rfr-test-0"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "e2"}, InvolvedObject: corev1.ObjectReference{Name: "other"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "e3"}, InvolvedObject: corev1.ObjectReference{Name: name}},
+		},
+	}, nil)
+
+	mr := &mRedisService.Client{}
+	mr.On("GetRedisInfo", "10.0.0.1", "6379", password).Once().Return("# Replication\r\nrole:master\r\n", nil)
+	mr.On("GetSentinelInfo", "10.0.0.2").Once().Return("", errors.New("i/o timeout"))
+
+	collector := supportbundle.NewCollector(ms, mr, log.Dummy)
+	collector.OperatorLogs = func(ns, n string) []string {
+		assert.Equal(namespace, ns)
+		assert.Equal(name, n)
+		return []string{
+			`level=info msg="reconciled" namespace=testns redisfailover=test`,
+			`level=warning msg="could not auth with ` + password + `" namespace=testns redisfailover=test`,
+		}
+	}
+	bundle := collector.Collect(context.TODO(), rf)
+
+	assert.Equal([]string{
+		"redisfailover.json",
+		"secrets/redis-auth.json",
+		"statefulsets/rfr-test.json",
+		"configmaps/rfr-test.json",
+		"configs/rfr-test/redis.conf",
+		"pods/rfr-test-0.json",
+		"pods/rfr-test-1.json",
+		"pods/rfs-test-abc.json",
+		"events.json",
+		"redis/rfr-test-0.info",
+		"sentinel/rfs-test-abc.conf",
+		"operator.log",
+		"errors.txt",
+	}, bundle.Files())
+
+	config, _ := bundle.File("configs/rfr-test/redis.conf")
+	assert.Equal("port 6379\nmasterauth <redacted>\nrequirepass <redacted>", string(config))
+
+	// The config rewritten by sentinel is read from its pod.
+	sentinelConfig, _ := bundle.File("sentinel/rfs-test-abc.conf")
+	assert.Equal("sentinel monitor mymaster 10.0.0.1 6379 2\nsentinel auth-pass mymaster <redacted>\n", string(sentinelConfig))
+
+	operatorLog, _ := bundle.File("operator.log")
+	assert.Equal("level=info msg=\"reconciled\" namespace=testns redisfailover=test\nlevel=warning msg=\"could not auth with <redacted>\" namespace=testns redisfailover=test\n", string(operatorLog))
+
+	events, _ := bundle.File("events.json")
+	assert.Contains(string(events), `"e1"`)
+	assert.NotContains(string(events), `"e2"`)
+	assert.Contains(string(events), `"e3"`)
+
+	errs, _ := bundle.File("errors.txt")
+	assert.Equal("error getting deployment rfs-test: forbidden\nerror getting the INFO of sentinel rfs-test-abc: i/o timeout\n", string(errs))
+
+	// The RF on the cache is not modified.
+	assert.Equal([]string{"requirepass " + password}, rf.Spec.Redis.CustomConfig)
+
+	var buf bytes.Buffer
+	require.NoError(t, bundle.WriteTarGz(&buf))
+	gz, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+
+		files++
+		assert.Regexp(`^testns-test/`, header.Name)
+		assert.NotContains(string(content), password, header.Name)
+		assert.NotContains(string(content), "other-secret", header.Name)
+	}
+	assert.Equal(len(bundle.Files()), files)
+
+	ms.AssertExpectations(t)
+	mr.AssertExpectations(t)
+}
+
+func TestCollectByNameNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	ms := &mK8SService.Services{}
+	ms.On("GetRedisFailover", mock.Anything, namespace, name).Once().Return(nil, kubeerrors.NewNotFound(schema.GroupResource{}, name))
+
+	collector := supportbundle.NewCollector(ms, &mRedisService.Client{}, log.Dummy)
+	_, err := collector.CollectByName(context.TODO(), namespace, name)
+
+	assert.Error(err)
+	ms.AssertExpectations(t)
+}
@@ -0,0 +1,105 @@
+package supportbundle
+
+import (
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Redacted replaces the passwords and the secret data on the support bundle files.
+const Redacted = "<redacted>"
+
+var (
+	// secretDirectiveRE matches the redis and sentinel directives, commands and flags followed by a
+	// password. The sentinel auth-pass directive goes first as the password follows the master name.
+	secretDirectiveRE = regexp.MustCompile(`(?i)(\bsentinel[ \t]+auth-pass[ \t]+\S+[ \t]+|\b(?:requirepass|masterauth|sentinel-pass|auth-pass)[ \t]+|(?:^|\s)-a[ \t]+)("[^"]*"|'[^']*'|[^\s"'\\]+)`)
+	// secretAssignmentRE matches the assignments of a password to a variable, like the ones in scripts.
+	secretAssignmentRE = regexp.MustCompile(`(?i)(\b\w*(?:password|passwd|secret|token)\w*=)("[^"]*"|'[^']*'|[^\s"'\\]+)`)
+	// aclUserRE matches the ACL users, as a directive or a command.
+	aclUserRE = regexp.MustCompile(`(?im)^.*\b(?:acl[ \t]+setuser|user)[ \t]+\S+[ \t].*$`)
+	// aclPasswordRE matches the rules of an ACL user adding or removing passwords and their hashes.
+	aclPasswordRE = regexp.MustCompile(`([ \t])([><#!])[^\s"'\\]+`)
+	// secretEnvRE matches the names of the environment variables that can hold a secret.
+	secretEnvRE = regexp.MustCompile(`(?i)pass|auth|secret|token|credential`)
+	// secretFlags are the redis command line flags followed by a password.
+	secretFlags = []string{"--requirepass", "--masterauth", "-a"}
+)
+
+// Redactor removes the passwords and the secret data from the support bundle contents.
+type Redactor struct {
+	secrets []string
+}
+
+// NewRedactor returns a redactor that, besides the well-known password directives, removes every
+// occurrence of the given secrets.
+func NewRedactor(secrets ...string) *Redactor {
+	r := &Redactor{}
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	return r
+}
+
+// Redact returns the content without passwords.
+func (r *Redactor) Redact(content []byte) []byte {
+	redacted := string(content)
+	for _, secret := range r.secrets {
+		redacted = strings.ReplaceAll(redacted, secret, Redacted)
+	}
+	redacted = secretDirectiveRE.ReplaceAllString(redacted, "${1}"+Redacted)
+	redacted = secretAssignmentRE.ReplaceAllString(redacted, "${1}"+Redacted)
+	redacted = aclUserRE.ReplaceAllStringFunc(redacted, func(line string) string {
+		return aclPasswordRE.ReplaceAllString(line, "${1}${2}"+Redacted)
+	})
+	return []byte(redacted)
+}
+
+// RedactPodSpec removes the passwords given to the containers as literal environment variables or
+// command line arguments. The environment variables taken from secrets only hold a reference and are kept.
+func RedactPodSpec(spec *corev1.PodSpec) {
+	for i := range spec.InitContainers {
+		redactContainer(&spec.InitContainers[i])
+	}
+	for i := range spec.Containers {
+		redactContainer(&spec.Containers[i])
+	}
+}
+
+// RedactSecret removes the data of a secret, keeping its keys.
+func RedactSecret(secret *corev1.Secret) {
+	redacted := map[string]string{}
+	for key := range secret.Data {
+		redacted[key] = Redacted
+	}
+	for key := range secret.StringData {
+		redacted[key] = Redacted
+	}
+	secret.Data = nil
+	secret.StringData = redacted
+}
+
+func redactContainer(container *corev1.Container) {
+	for i, env := range container.Env {
+		if env.Value != "" && secretEnvRE.MatchString(env.Name) {
+			container.Env[i].Value = Redacted
+		}
+	}
+	redactArgs(container.Command)
+	redactArgs(container.Args)
+}
+
+func redactArgs(args []string) {
+	for i, arg := range args {
+		for _, flag := range secretFlags {
+			switch {
+			case arg == flag && i+1 < len(args):
+				args[i+1] = Redacted
+			case strings.HasPrefix(arg, flag+"="):
+				args[i] = flag + "=" + Redacted
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package supportbundle_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"redis-operator/operator/redisfailover/supportbundle"
+)
+
+func TestRedactFixtures(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixture  string
+		expected string
+	}{
+		{
+			name:    "Redis configuration",
+			fixture: "redis.conf",
+			expected: `slaveof 127.0.0.1 6379
+tcp-keepalive 60
+save 900 1
+save 300 10
+user pinger -@all +ping on ><redacted>
+user default on #<redacted> ~* &* +@all
+masterauth <redacted>
+requirepass <redacted>
+`,
+		},
+		{
+			name:    "Sentinel configuration",
+			fixture: "sentinel.conf",
+			expected: `sentinel monitor mymaster 127.0.0.1 6379 2
+sentinel down-after-milliseconds mymaster 1000
+sentinel failover-timeout mymaster 3000
+sentinel parallel-syncs mymaster 2
+sentinel auth-pass mymaster <redacted>
+sentinel sentinel-pass <redacted>
+`,
+		},
+		{
+			name:    "Script",
+			fixture: "shutdown.sh",
+			expected: `master=$(redis-cli -h ${RFS_REDIS_SERVICE_HOST} -p ${RFS_REDIS_SERVICE_PORT_SENTINEL} --csv SENTINEL get-master-addr-by-name mymaster | tr ',' ' ' | tr -d '\"' |cut -d' ' -f1)
+REDIS_PASSWORD=<redacted>
+if [ ! -z "${REDIS_PASSWORD}" ]; then
+	redis-cli --no-auth-warning -a <redacted> SAVE
+fi
+redis-cli -a <redacted> CONFIG SET requirepass <redacted>
+redis-cli SENTINEL SET mymaster auth-pass <redacted>
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			content, err := os.ReadFile(filepath.Join("testdata", test.fixture))
+			require.NoError(t, err)
+
+			redacted := supportbundle.NewRedactor().Redact(content)
+
+			assert.Equal(test.expected, string(redacted))
+			// Redacting twice leaves the content as it is.
+			assert.Equal(test.expected, string(supportbundle.NewRedactor().Redact(redacted)))
+		})
+	}
+}
+
+func TestRedactKnownSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	content := []byte(`{"redis.conf": "port 6379\nrequirepass s3cr3tpass\nmasterauth s3cr3tpass\n", "note": "the password is s3cr3tpass"}`)
+
+	redacted := supportbundle.NewRedactor("s3cr3tpass", "").Redact(content)
+
+	assert.Equal(`{"redis.conf": "port 6379\nrequirepass <redacted>\nmasterauth <redacted>\n", "note": "the password is <redacted>"}`, string(redacted))
+}
+
+func TestRedactPodSpec(t *testing.T) {
+	assert := assert.New(t)
+
+	secretRef := &corev1.EnvVarSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "redis-auth"},
+			Key:                  "password",
+		},
+	}
+	spec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{
+			{
+				Name:    "init",
+				Command: []string{"redis-cli", "-a", "hunter2", "PING"},
+				Env: []corev1.EnvVar{
+					{Name: "AUTH_TOKEN", Value: "hunter2"},
+				},
+			},
+		},
+		Containers: []corev1.Container{
+			{
+				Name:    "redis",
+				Command: []string{"redis-server", "--masterauth=hunter2"},
+				Args:    []string{"--port", "6379", "--requirepass", "hunter2"},
+				Env: []corev1.EnvVar{
+					{Name: "REDIS_ADDR", Value: "redis://127.0.0.1:6379"},
+					{Name: "REDIS_PASSWORD", Value: "hunter2"},
+					{Name: "REDIS_PASSWORD_FROM_SECRET", ValueFrom: secretRef},
+					{Name: "SENTINEL_SECRET", Value: "hunter2"},
+				},
+			},
+		},
+	}
+
+	supportbundle.RedactPodSpec(spec)
+
+	assert.Equal([]string{"redis-cli", "-a", "<redacted>", "PING"}, spec.InitContainers[0].Command)
+	assert.Equal([]corev1.EnvVar{{Name: "AUTH_TOKEN", Value: "<redacted>"}}, spec.InitContainers[0].Env)
+	assert.Equal([]string{"redis-server", "--masterauth=<redacted>"}, spec.Containers[0].Command)
+	assert.Equal([]string{"--port", "6379", "--requirepass", "<redacted>"}, spec.Containers[0].Args)
+	assert.Equal([]corev1.EnvVar{
+		{Name: "REDIS_ADDR", Value: "redis://127.0.0.1:6379"},
+		{Name: "REDIS_PASSWORD", Value: "<redacted>"},
+		{Name: "REDIS_PASSWORD_FROM_SECRET", ValueFrom: secretRef},
+		{Name: "SENTINEL_SECRET", Value: "<redacted>"},
+	}, spec.Containers[0].Env)
+}
+
+func TestRedactSecret(t *testing.T) {
+	assert := assert.New(t)
+
+	secret := &corev1.Secret{
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+		StringData: map[string]string{"username": "default"},
+	}
+
+	supportbundle.RedactSecret(secret)
+
+	assert.Nil(secret.Data)
+	assert.Equal(map[string]string{"password": "<redacted>", "username": "<redacted>"}, secret.StringData)
+}
@@ -0,0 +1,7 @@
+master=$(redis-cli -h ${RFS_REDIS_SERVICE_HOST} -p ${RFS_REDIS_SERVICE_PORT_SENTINEL} --csv SENTINEL get-master-addr-by-name mymaster | tr ',' ' ' | tr -d '\"' |cut -d' ' -f1)
+REDIS_PASSWORD=s3cr3tpass
+if [ ! -z "${REDIS_PASSWORD}" ]; then
+	redis-cli --no-auth-warning -a "${REDIS_PASSWORD}" SAVE
+fi
+redis-cli -a hunter2 CONFIG SET requirepass hunter3
+redis-cli SENTINEL SET mymaster auth-pass hunter4
@@ -0,0 +1,179 @@
+package redisfailover
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// TeardownFinalizer holds the deletion of the RFs with the ordered-teardown feature gate until their
+// objects are deleted in order, see Teardown.
+const TeardownFinalizer = "databases.spotahome.com/ordered-teardown"
+
+// CleanupFinalizer holds the deletion of every RF until the operator forgot what it keeps for it, see
+// Cleanup. It's removed last, once the objects are torn down.
+const CleanupFinalizer = "databases.spotahome.com/cleanup"
+
+// teardownMessages are the messages of the Terminating condition of the teardown stages, once done.
+var teardownMessages = map[string]string{
+	redisfailoverv1.ReasonDeletingSentinels: "the sentinels are deleted, the healing of the redis pods is paused",
+	redisfailoverv1.ReasonDeletingRedis:     "the redis statefulset is deleted",
+	redisfailoverv1.ReasonDeletingObjects:   "the services, the configmaps and the other objects are deleted",
+	redisfailoverv1.ReasonDeletingVolumes:   "the claims of the redis volumes are deleted, unless storage.keepAfterDeletion is set",
+}
+
+// EnsureFinalizers adds the cleanup finalizer to the RF, and the teardown finalizer when the ordered
+// teardown is enabled, removing it when it's not. The finalizers and the resource version of the RF are
+// updated with the patch.
+func (r *RedisFailoverHandler) EnsureFinalizers(ctx context.Context, rf *redisfailoverv1.RedisFailover, orderedTeardown bool) error {
+	if hasFinalizer(rf, CleanupFinalizer) && orderedTeardown == hasFinalizer(rf, TeardownFinalizer) {
+		return nil
+	}
+	finalizers := withoutFinalizer(rf.Finalizers, TeardownFinalizer)
+	if !hasFinalizer(rf, CleanupFinalizer) {
+		finalizers = append(finalizers, CleanupFinalizer)
+	}
+	if orderedTeardown {
+		finalizers = append(finalizers, TeardownFinalizer)
+	}
+	return r.patchFinalizers(ctx, rf, finalizers)
+}
+
+// Cleanup tears down the deleted RF when it has the teardown finalizer, then forgets what the operator
+// keeps for it, see forget, and removes its cleanup finalizer. An RF deleted before the operator added
+// the cleanup finalizer is gone before it's seen deleting, what's kept for it is dropped on the next
+// restart of the operator.
+func (r *RedisFailoverHandler) Cleanup(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	if err := r.Teardown(ctx, rf); err != nil {
+		return err
+	}
+	// The teardown waits for the objects to be deleted.
+	if hasFinalizer(rf, TeardownFinalizer) {
+		return nil
+	}
+	r.forget(rf)
+	if !hasFinalizer(rf, CleanupFinalizer) {
+		return nil
+	}
+	return r.patchFinalizers(ctx, rf, withoutFinalizer(rf.Finalizers, CleanupFinalizer))
+}
+
+// Teardown deletes the objects of the deleted RF in order, and removes its teardown finalizer once they
+// are: the sentinels first so no failover is started, the redis statefulset once they are gone, then the
+// other objects and the claims of the redis volumes. The RF is not healed anymore. The stage is set on the
+// Terminating condition, and every stage only deletes what's left, so the teardown resumes where it
+// stopped after a restart of the operator. The RFs without the finalizer are left to the garbage
+// collector.
+func (r *RedisFailoverHandler) Teardown(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	if !hasFinalizer(rf, TeardownFinalizer) {
+		return nil
+	}
+	if r.sentinelEvents != nil {
+		r.sentinelEvents.Stop(rf)
+	}
+
+	// The objects are named from the spec with its defaults, set by the validation. An invalid spec is
+	// torn down as it is.
+	defaulted := rf.DeepCopy()
+	if err := defaulted.Validate(); err != nil {
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Debugf("teardown of an invalid redisfailover: %s", err)
+	}
+
+	for i, stage := range rfservice.TeardownStages {
+		waiting, err := r.rfService.Teardown(ctx, defaulted, stage)
+		if err != nil {
+			return err
+		}
+		message := teardownMessages[stage]
+		if waiting != "" {
+			message = waiting
+		}
+		// The stages done before a restart are run again, the condition doesn't go back to them.
+		if i >= teardownStage(rf) {
+			if err := r.setTerminatingCondition(ctx, rf, stage, message); err != nil {
+				return err
+			}
+		}
+		if waiting != "" {
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Debugf("teardown: %s", waiting)
+			return nil
+		}
+	}
+
+	if err := r.patchFinalizers(ctx, rf, withoutFinalizer(rf.Finalizers, TeardownFinalizer)); err != nil {
+		return err
+	}
+	r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Infof("the objects of the redisfailover are deleted, the teardown finalizer is removed")
+	return nil
+}
+
+// patchFinalizers sets the finalizers of the RF. Its finalizers and resource version are updated with the
+// patch.
+func (r *RedisFailoverHandler) patchFinalizers(ctx context.Context, rf *redisfailoverv1.RedisFailover, finalizers []string) error {
+	patched, err := r.k8sservice.PatchRedisFailoverFinalizers(ctx, rf.Namespace, rf.Name, rf.ResourceVersion, finalizers)
+	if err != nil {
+		return err
+	}
+	rf.Finalizers = patched.Finalizers
+	rf.ResourceVersion = patched.ResourceVersion
+	return nil
+}
+
+// setTerminatingCondition writes the Terminating condition of the teardown stage, with a Normal event when
+// the stage starts. The status and the resource version of the RF are updated with the write.
+func (r *RedisFailoverHandler) setTerminatingCondition(ctx context.Context, rf *redisfailoverv1.RedisFailover, stage, message string) error {
+	previous := rfservice.GetCondition(rf, redisfailoverv1.ConditionTerminating)
+	next := rf.DeepCopy()
+	if !rfservice.SetCondition(next, redisfailoverv1.ConditionTerminating, metav1.ConditionTrue, stage, message) {
+		return nil
+	}
+	updated, err := r.k8sservice.UpdateRedisFailoverStatus(ctx, rf.Namespace, next)
+	if err != nil {
+		return err
+	}
+	rf.Status = updated.Status
+	rf.ResourceVersion = updated.ResourceVersion
+	if previous == nil || previous.Reason != stage {
+		r.events.Record(ctx, rf, corev1.EventTypeNormal, stage, message)
+	}
+	return nil
+}
+
+// teardownStage returns the index of the teardown stage of the Terminating condition of the RF, -1
+// before the teardown.
+func teardownStage(rf *redisfailoverv1.RedisFailover) int {
+	cond := rfservice.GetCondition(rf, redisfailoverv1.ConditionTerminating)
+	if cond == nil {
+		return -1
+	}
+	for i, stage := range rfservice.TeardownStages {
+		if stage == cond.Reason {
+			return i
+		}
+	}
+	return -1
+}
+
+func hasFinalizer(rf *redisfailoverv1.RedisFailover, finalizer string) bool {
+	for _, f := range rf.Finalizers {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutFinalizer returns the finalizers without the given one.
+func withoutFinalizer(finalizers []string, finalizer string) []string {
+	kept := []string{}
+	for _, f := range finalizers {
+		if f != finalizer {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
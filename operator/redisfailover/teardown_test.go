@@ -0,0 +1,300 @@
+package redisfailover_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	redisfailoverfake "redis-operator/client/k8s/clientset/versioned/fake"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+	"redis-operator/service/k8s"
+)
+
+// teardownTest is a RF with its objects written in fake clientsets, with 3 redis and 3 sentinel pods and
+// the claims of the redis volumes.
+type teardownTest struct {
+	t         *testing.T
+	kubecli   *kubernetes.Clientset
+	customCli *redisfailoverfake.Clientset
+	ks        k8s.Services
+}
+
+func newTeardownTest(t *testing.T, keepVolumes bool) *teardownTest {
+	rf := generateRF(false, false)
+	rf.Spec.Redis.Storage.KeepAfterDeletion = keepVolumes
+	rf.Spec.Redis.Storage.PersistentVolumeClaim = &redisfailoverv1.EmbeddedPersistentVolumeClaim{
+		EmbeddedObjectMetadata: redisfailoverv1.EmbeddedObjectMetadata{Name: "redis-data"},
+	}
+	require.NoError(t, rf.Validate())
+
+	kubecli := kubernetes.NewSimpleClientset()
+	customCli := redisfailoverfake.NewSimpleClientset(rf)
+	ks := k8s.New(kubecli, nil, nil, customCli, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	// The fake clientset serves no PodDisruptionBudget API, the rest of the desired state is written.
+	client := rfservice.NewRedisFailoverKubeClient(ks, log.Dummy, metrics.Dummy)
+	require.ErrorIs(t, client.EnsureDesiredState(context.TODO(), rf, nil, nil), k8s.ErrPodDisruptionBudgetsUnavailable)
+
+	test := &teardownTest{t: t, kubecli: kubecli, customCli: customCli, ks: ks}
+	ss, err := kubecli.AppsV1().StatefulSets(namespace).Get(context.TODO(), "rfr-test", metav1.GetOptions{})
+	require.NoError(t, err)
+	d, err := kubecli.AppsV1().Deployments(namespace).Get(context.TODO(), "rfs-test", metav1.GetOptions{})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		test.create(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("rfr-test-%d", i), Labels: ss.Spec.Selector.MatchLabels}})
+		test.create(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("rfs-test-%d", i), Labels: d.Spec.Selector.MatchLabels}})
+		test.create(&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("redis-data-rfr-test-%d", i), Labels: ss.Spec.Selector.MatchLabels}})
+	}
+	return test
+}
+
+func (c *teardownTest) create(obj interface{}) {
+	var err error
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		_, err = c.kubecli.CoreV1().Pods(namespace).Create(context.TODO(), o, metav1.CreateOptions{})
+	case *corev1.PersistentVolumeClaim:
+		_, err = c.kubecli.CoreV1().PersistentVolumeClaims(namespace).Create(context.TODO(), o, metav1.CreateOptions{})
+	}
+	require.NoError(c.t, err)
+}
+
+// handler returns the handler of a new operator, as after a restart.
+func (c *teardownTest) handler() *rfOperator.RedisFailoverHandler {
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("ForgetRedisLatency", mock.Anything).Maybe()
+	mrfc.On("ForgetNodeTuningWarnings", mock.Anything).Maybe()
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("ClearSyncSlotQueue", mock.Anything).Maybe()
+	client := rfservice.NewRedisFailoverKubeClient(c.ks, log.Dummy, metrics.Dummy)
+	return rfOperator.NewRedisFailoverHandler(generateConfig(), client, mrfc, mrfh, c.ks, metrics.Dummy, log.Dummy)
+}
+
+func (c *teardownTest) rf() *redisfailoverv1.RedisFailover {
+	rf, err := c.customCli.DatabasesV1().RedisFailovers(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	require.NoError(c.t, err)
+	return rf
+}
+
+// delete marks the RF deleted with the teardown finalizer, the fake clientset doesn't do it on a delete.
+func (c *teardownTest) delete() {
+	rf := c.rf()
+	now := metav1.Now()
+	rf.DeletionTimestamp = &now
+	rf.Finalizers = []string{"other", rfOperator.CleanupFinalizer, rfOperator.TeardownFinalizer}
+	_, err := c.customCli.DatabasesV1().RedisFailovers(namespace).Update(context.TODO(), rf, metav1.UpdateOptions{})
+	require.NoError(c.t, err)
+}
+
+// deletePods deletes the pods of the component, as their workload controller would.
+func (c *teardownTest) deletePods(prefix string) {
+	for i := 0; i < 3; i++ {
+		require.NoError(c.t, c.kubecli.CoreV1().Pods(namespace).Delete(context.TODO(), fmt.Sprintf("%s-%d", prefix, i), metav1.DeleteOptions{}))
+	}
+}
+
+func (c *teardownTest) exists(kind, objName string) bool {
+	var err error
+	switch kind {
+	case rfservice.KindDeployment:
+		_, err = c.kubecli.AppsV1().Deployments(namespace).Get(context.TODO(), objName, metav1.GetOptions{})
+	case rfservice.KindStatefulSet:
+		_, err = c.kubecli.AppsV1().StatefulSets(namespace).Get(context.TODO(), objName, metav1.GetOptions{})
+	case rfservice.KindService:
+		_, err = c.kubecli.CoreV1().Services(namespace).Get(context.TODO(), objName, metav1.GetOptions{})
+	case rfservice.KindConfigMap:
+		_, err = c.kubecli.CoreV1().ConfigMaps(namespace).Get(context.TODO(), objName, metav1.GetOptions{})
+	case "PersistentVolumeClaim":
+		_, err = c.kubecli.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), objName, metav1.GetOptions{})
+	}
+	if kubeerrors.IsNotFound(err) {
+		return false
+	}
+	require.NoError(c.t, err)
+	return true
+}
+
+func (c *teardownTest) terminating() (string, string) {
+	cond := rfservice.GetCondition(c.rf(), redisfailoverv1.ConditionTerminating)
+	if cond == nil {
+		return "", ""
+	}
+	return cond.Reason, cond.Message
+}
+
+func TestTeardownResumesAfterRestarts(t *testing.T) {
+	assert := assert.New(t)
+	test := newTeardownTest(t, false)
+	test.delete()
+
+	// The sentinels are deleted first, the redis statefulset waits for their pods to be gone.
+	assert.NoError(test.handler().Handle(context.TODO(), test.rf()))
+	assert.False(test.exists(rfservice.KindDeployment, "rfs-test"))
+	assert.True(test.exists(rfservice.KindStatefulSet, "rfr-test"))
+	reason, message := test.terminating()
+	assert.Equal(redisfailoverv1.ReasonDeletingSentinels, reason)
+	assert.Equal("waiting for 3 sentinel pods to terminate", message)
+
+	// A new operator waits the same.
+	assert.NoError(test.handler().Handle(context.TODO(), test.rf()))
+	assert.True(test.exists(rfservice.KindStatefulSet, "rfr-test"))
+
+	// Once the sentinels are gone, the redis statefulset is deleted and the services wait for its pods.
+	test.deletePods("rfs-test")
+	assert.NoError(test.handler().Handle(context.TODO(), test.rf()))
+	assert.False(test.exists(rfservice.KindStatefulSet, "rfr-test"))
+	assert.True(test.exists(rfservice.KindService, "rfs-test"))
+	assert.True(test.exists(rfservice.KindConfigMap, "rfr-test"))
+	assert.True(test.exists("PersistentVolumeClaim", "redis-data-rfr-test-0"))
+	reason, message = test.terminating()
+	assert.Equal(redisfailoverv1.ReasonDeletingRedis, reason)
+	assert.Equal("waiting for 3 redis pods to terminate", message)
+	assert.Contains(test.rf().Finalizers, rfOperator.TeardownFinalizer)
+
+	// Once the redis pods are gone, the other objects and the claims are deleted, and the finalizer
+	// removed. The finalizers of the other controllers are kept.
+	test.deletePods("rfr-test")
+	assert.NoError(test.handler().Handle(context.TODO(), test.rf()))
+	for _, ref := range []rfservice.ObjectRef{
+		{Kind: rfservice.KindService, Name: "rfs-test"},
+		{Kind: rfservice.KindConfigMap, Name: "rfs-test"},
+		{Kind: rfservice.KindConfigMap, Name: "rfr-test"},
+		{Kind: rfservice.KindConfigMap, Name: "rfr-readiness-test"},
+		{Kind: "PersistentVolumeClaim", Name: "redis-data-rfr-test-0"},
+		{Kind: "PersistentVolumeClaim", Name: "redis-data-rfr-test-2"},
+	} {
+		assert.False(test.exists(ref.Kind, ref.Name), ref.String())
+	}
+	reason, _ = test.terminating()
+	assert.Equal(redisfailoverv1.ReasonDeletingVolumes, reason)
+	assert.Equal([]string{"other"}, test.rf().Finalizers)
+
+	// Every stage started with an event.
+	events, err := test.kubecli.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	reasons := []string{}
+	for _, e := range events.Items {
+		reasons = append(reasons, e.Reason)
+	}
+	assert.ElementsMatch(rfservice.TeardownStages, reasons)
+}
+
+func TestTeardownKeepsVolumes(t *testing.T) {
+	assert := assert.New(t)
+	test := newTeardownTest(t, true)
+	test.delete()
+	test.deletePods("rfs-test")
+	test.deletePods("rfr-test")
+
+	assert.NoError(test.handler().Handle(context.TODO(), test.rf()))
+	assert.False(test.exists(rfservice.KindStatefulSet, "rfr-test"))
+	assert.True(test.exists("PersistentVolumeClaim", "redis-data-rfr-test-0"))
+	assert.Equal([]string{"other"}, test.rf().Finalizers)
+}
+
+func TestTeardownWithoutFinalizer(t *testing.T) {
+	rf := generateRF(false, false)
+	now := metav1.Now()
+	rf.DeletionTimestamp = &now
+	rf.ResourceVersion = "7"
+	rf.Finalizers = []string{"other", rfOperator.CleanupFinalizer}
+
+	mrfs := &mRFService.RedisFailoverClient{}
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("ForgetRedisLatency", rf).Once()
+	mrfc.On("ForgetNodeTuningWarnings", rf).Once()
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("ClearSyncSlotQueue", rf).Once()
+	mk := &mK8SService.Services{}
+	patched := rf.DeepCopy()
+	patched.Finalizers = []string{"other"}
+	mk.On("PatchRedisFailoverFinalizers", mock.Anything, namespace, name, "7", []string{"other"}).Once().Return(patched, nil)
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), mrfs, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+
+	// The objects of the deleted RF are left to the garbage collector, nothing is reconciled. It's
+	// forgotten and its cleanup finalizer removed.
+	assert.NoError(t, handler.Handle(context.TODO(), rf))
+	assert.Equal(t, []string{"other"}, rf.Finalizers)
+	mrfs.AssertExpectations(t)
+	mrfc.AssertExpectations(t)
+	mrfh.AssertExpectations(t)
+	mk.AssertExpectations(t)
+}
+
+func TestEnsureFinalizers(t *testing.T) {
+	tests := []struct {
+		name          string
+		finalizers    []string
+		enabled       bool
+		expFinalizers []string
+	}{
+		{
+			name:    "The finalizers are added with the ordered teardown",
+			enabled: true, finalizers: []string{"other"},
+			expFinalizers: []string{"other", rfOperator.CleanupFinalizer, rfOperator.TeardownFinalizer},
+		},
+		{
+			name:    "The finalizers are not added twice",
+			enabled: true, finalizers: []string{rfOperator.CleanupFinalizer, rfOperator.TeardownFinalizer},
+		},
+		{
+			name:          "The teardown finalizer is added to the cleanup one",
+			enabled:       true,
+			finalizers:    []string{rfOperator.CleanupFinalizer},
+			expFinalizers: []string{rfOperator.CleanupFinalizer, rfOperator.TeardownFinalizer},
+		},
+		{
+			name:          "The teardown finalizer is removed without the ordered teardown",
+			finalizers:    []string{rfOperator.CleanupFinalizer, rfOperator.TeardownFinalizer, "other"},
+			expFinalizers: []string{rfOperator.CleanupFinalizer, "other"},
+		},
+		{
+			name:          "Only the cleanup finalizer is added without the ordered teardown",
+			expFinalizers: []string{rfOperator.CleanupFinalizer},
+		},
+		{
+			name:       "Nothing is patched with the cleanup finalizer without the ordered teardown",
+			finalizers: []string{rfOperator.CleanupFinalizer},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.ResourceVersion = "7"
+			rf.Finalizers = test.finalizers
+			mk := &mK8SService.Services{}
+			if test.expFinalizers != nil {
+				patched := rf.DeepCopy()
+				patched.Finalizers = test.expFinalizers
+				patched.ResourceVersion = "8"
+				mk.On("PatchRedisFailoverFinalizers", mock.Anything, namespace, name, "7", test.expFinalizers).Once().Return(patched, nil)
+			}
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.Dummy, log.Dummy)
+
+			assert.NoError(handler.EnsureFinalizers(context.TODO(), rf, test.enabled))
+			mk.AssertExpectations(t)
+			if test.expFinalizers != nil {
+				assert.Equal(test.expFinalizers, rf.Finalizers)
+				assert.Equal("8", rf.ResourceVersion)
+			} else {
+				mk.AssertNotCalled(t, "PatchRedisFailoverFinalizers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
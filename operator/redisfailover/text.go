@@ -0,0 +1,30 @@
+package redisfailover
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// truncatedText ends the texts capped by sanitizeText.
+const truncatedText = "\n[truncated]"
+
+// sanitizeText keeps the printable characters and the line breaks of a text read from redis or its logs,
+// which is free text, and caps it to max bytes.
+func sanitizeText(text string, max int) string {
+	var b strings.Builder
+	for _, r := range strings.ToValidUTF8(text, "") {
+		if r == '\n' || r == '\t' || unicode.IsPrint(r) {
+			b.WriteRune(r)
+		}
+	}
+	sanitized := b.String()
+	if len(sanitized) <= max {
+		return sanitized
+	}
+	cut := max - len(truncatedText)
+	for cut > 0 && !utf8.RuneStart(sanitized[cut]) {
+		cut--
+	}
+	return sanitized[:cut] + truncatedText
+}
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitConfig splits a configuration file in parts of at most maxSize bytes. Lines are never split and
+// the parts keep the order of the lines, as a later directive can override an earlier one.
+func SplitConfig(content string, maxSize int) ([]string, error) {
+	parts := []string{}
+	var part strings.Builder
+	for i, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		if len(line) > maxSize {
+			return nil, fmt.Errorf("line %d is %d bytes, more than the %d bytes a part can hold", i+1, len(line), maxSize)
+		}
+		if part.Len()+len(line) > maxSize {
+			parts = append(parts, part.String())
+			part.Reset()
+		}
+		part.WriteString(line)
+	}
+	if part.Len() > 0 {
+		parts = append(parts, part.String())
+	}
+	return parts, nil
+}
@@ -0,0 +1,67 @@
+package util_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"redis-operator/operator/redisfailover/util"
+)
+
+func TestSplitConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		maxSize  int
+		expParts []string
+		expErr   bool
+	}{
+		{
+			name:     "Empty content should not have parts",
+			content:  "",
+			maxSize:  10,
+			expParts: []string{},
+		},
+		{
+			name:     "Content of the max size should not be split",
+			content:  "port 6379\n",
+			maxSize:  10,
+			expParts: []string{"port 6379\n"},
+		},
+		{
+			name:     "Content one byte over the max size should be split",
+			content:  "port 6379\na\n",
+			maxSize:  11,
+			expParts: []string{"port 6379\n", "a\n"},
+		},
+		{
+			name:     "Lines should be kept in order",
+			content:  "save 900 1\nsave 300 10\nsave 60 100\nport 6379",
+			maxSize:  24,
+			expParts: []string{"save 900 1\nsave 300 10\n", "save 60 100\nport 6379"},
+		},
+		{
+			name:    "A line bigger than the max size should fail",
+			content: "port 6379\n" + strings.Repeat("a", 11) + "\n",
+			maxSize: 11,
+			expErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			parts, err := util.SplitConfig(test.content, test.maxSize)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+				assert.Equal(test.expParts, parts)
+				assert.Equal(test.content, strings.Join(parts, ""))
+			}
+		})
+	}
+}
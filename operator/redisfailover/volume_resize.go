@@ -0,0 +1,118 @@
+package redisfailover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+// The reasons of the events about the resize of the volumes of the redis pods.
+const (
+	volumeExpandedReason             = "VolumeExpanded"
+	volumeShrinkRejectedReason       = "VolumeShrinkRejected"
+	volumeExpansionUnsupportedReason = "VolumeExpansionUnsupported"
+)
+
+// EnsureRedisVolumesSize resizes the volumes of the redis pods to the storage requested by the RF. The
+// volume claim templates of a statefulset can't be changed, so the claims it already created are patched
+// instead, the statefulset gets the new template when it's recreated. The volumes are only expanded, and
+// only when their storage class allows it, otherwise a warning event is sent once by claim and size.
+func (r *RedisFailoverHandler) EnsureRedisVolumesSize(ctx context.Context, rf *redisfailoverv1.RedisFailover) error {
+	claim := rf.Spec.Redis.Storage.PersistentVolumeClaim
+	if rf.ExternalNodesEnabled() || claim == nil || claim.Name == "" {
+		return nil
+	}
+	size, ok := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	if !ok || size.IsZero() {
+		return nil
+	}
+
+	name := rfservice.GetRedisStatefulSetName(rf)
+	ss, err := r.k8sservice.GetStatefulSet(ctx, rf.Namespace, name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if ss.Spec.Selector == nil {
+		return nil
+	}
+	// The statefulset labels its claims with its selector, they are named after their template.
+	pvcs, err := r.k8sservice.ListPVCs(ctx, rf.Namespace, labels.SelectorFromSet(ss.Spec.Selector.MatchLabels))
+	if err != nil {
+		return err
+	}
+	prefix := fmt.Sprintf("%s-%s-", claim.Name, name)
+	for _, pvc := range pvcs.Items {
+		if !strings.HasPrefix(pvc.Name, prefix) || pvc.DeletionTimestamp != nil {
+			continue
+		}
+		current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		switch size.Cmp(current) {
+		case 0:
+			continue
+		case -1:
+			message := fmt.Sprintf("the volume %s is not shrunk from %s to %s, kubernetes can't shrink volumes", pvc.Name, current.String(), size.String())
+			r.warnVolume(ctx, rf, pvc.Name, volumeShrinkRejectedReason, size, message)
+			continue
+		}
+
+		allowed, err := r.volumeExpansionAllowed(ctx, pvc)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			message := fmt.Sprintf("the volume %s is not expanded from %s to %s, its storage class does not allow the volume expansion", pvc.Name, current.String(), size.String())
+			r.warnVolume(ctx, rf, pvc.Name, volumeExpansionUnsupportedReason, size, message)
+			continue
+		}
+		if err := r.k8sservice.PatchPVCSize(ctx, rf.Namespace, pvc.Name, size); err != nil {
+			return err
+		}
+		message := fmt.Sprintf("the volume %s is expanded from %s to %s", pvc.Name, current.String(), size.String())
+		if err := r.k8sservice.CreateEvent(ctx, rf.Namespace, newRFEvent(rfObjectReference(rf), corev1.EventTypeNormal, volumeExpandedReason, message, time.Now())); err != nil {
+			r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not create the %s event: %s", volumeExpandedReason, err)
+		}
+	}
+	return nil
+}
+
+// volumeExpansionAllowed returns true when the storage class of the claim allows the expansion of its
+// volume. The claims without a storage class, or with one that does not exist, can't be expanded.
+func (r *RedisFailoverHandler) volumeExpansionAllowed(ctx context.Context, pvc corev1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	storageClass, err := r.k8sservice.GetStorageClass(ctx, *pvc.Spec.StorageClassName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+}
+
+// warnVolume sends a warning about the resize of a volume, once by claim, reason and size since the
+// operator started.
+func (r *RedisFailoverHandler) warnVolume(ctx context.Context, rf *redisfailoverv1.RedisFailover, pvc, reason string, size resource.Quantity, message string) {
+	key := fmt.Sprintf("%s/%s/%s/%s", rfKey(rf), pvc, reason, size.String())
+	if !r.volumeWarnings.warn(key) {
+		return
+	}
+	r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("%s", message)
+	if err := r.k8sservice.CreateEvent(ctx, rf.Namespace, newRFEvent(rfObjectReference(rf), corev1.EventTypeWarning, reason, message, time.Now())); err != nil {
+		r.volumeWarnings.unwarn(key)
+		r.logger.WithField("namespace", rf.Namespace).WithField("redisfailover", rf.Name).Warnf("could not create the %s event: %s", reason, err)
+	}
+}
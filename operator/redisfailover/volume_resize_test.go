@@ -0,0 +1,157 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+)
+
+func TestEnsureRedisVolumesSize(t *testing.T) {
+	expand := true
+	noExpand := false
+	tests := []struct {
+		name         string
+		size         string
+		storageClass *storagev1.StorageClass
+		expResized   bool
+		expEvent     string
+	}{
+		{
+			name:         "A bigger storage should expand the volumes",
+			size:         "2Gi",
+			storageClass: &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "ssd"}, AllowVolumeExpansion: &expand},
+			expResized:   true,
+			expEvent:     "VolumeExpanded",
+		},
+		{
+			name:     "A smaller storage should not shrink the volumes",
+			size:     "512Mi",
+			expEvent: "VolumeShrinkRejected",
+		},
+		{
+			name:         "A storage class without the volume expansion should not expand the volumes",
+			size:         "2Gi",
+			storageClass: &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "ssd"}, AllowVolumeExpansion: &noExpand},
+			expEvent:     "VolumeExpansionUnsupported",
+		},
+		{
+			name: "The same storage should not resize the volumes",
+			size: "1Gi",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			rf.Spec.Redis.Storage.PersistentVolumeClaim = &redisfailoverv1.EmbeddedPersistentVolumeClaim{
+				EmbeddedObjectMetadata: redisfailoverv1.EmbeddedObjectMetadata{Name: "redis-data"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(test.size)},
+					},
+				},
+			}
+			selector := map[string]string{"app.kubernetes.io/name": "test", "app.kubernetes.io/component": "redis"}
+			storageClass := "ssd"
+			pvc := func(name string) corev1.PersistentVolumeClaim {
+				return corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: selector},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						StorageClassName: &storageClass,
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+						},
+					},
+				}
+			}
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{
+				Spec: appsv1.StatefulSetSpec{Selector: &metav1.LabelSelector{MatchLabels: selector}},
+			}, nil)
+			mk.On("ListPVCs", mock.Anything, namespace, labels.SelectorFromSet(selector)).Once().Return(&corev1.PersistentVolumeClaimList{
+				Items: []corev1.PersistentVolumeClaim{pvc("redis-data-rfr-test-0"), pvc("redis-data-rfr-test-1"), pvc("other-rfr-test-0")},
+			}, nil)
+			if test.storageClass != nil {
+				mk.On("GetStorageClass", mock.Anything, "ssd").Twice().Return(test.storageClass, nil)
+			}
+			if test.expResized {
+				mk.On("PatchPVCSize", mock.Anything, namespace, "redis-data-rfr-test-0", resource.MustParse(test.size)).Once().Return(nil)
+				mk.On("PatchPVCSize", mock.Anything, namespace, "redis-data-rfr-test-1", resource.MustParse(test.size)).Once().Return(nil)
+			}
+			events := []string{}
+			if test.expEvent != "" {
+				mk.On("CreateEvent", mock.Anything, namespace, mock.Anything).Run(func(args mock.Arguments) {
+					events = append(events, args.Get(2).(*corev1.Event).Reason)
+				}).Return(nil)
+			}
+
+			handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.Dummy, log.Dummy)
+			assert.NoError(handler.EnsureRedisVolumesSize(context.TODO(), rf))
+
+			mk.AssertExpectations(t)
+			if !test.expResized {
+				mk.AssertNotCalled(t, "PatchPVCSize", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+			if test.expEvent == "" {
+				assert.Empty(events)
+			} else {
+				assert.Equal([]string{test.expEvent, test.expEvent}, events)
+			}
+		})
+	}
+}
+
+func TestEnsureRedisVolumesSizeWarnsOnce(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	rf.Spec.Redis.Storage.PersistentVolumeClaim = &redisfailoverv1.EmbeddedPersistentVolumeClaim{
+		EmbeddedObjectMetadata: redisfailoverv1.EmbeddedObjectMetadata{Name: "redis-data"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("512Mi")},
+			},
+		},
+	}
+	selector := map[string]string{"app.kubernetes.io/name": "test"}
+
+	mk := &mK8SService.Services{}
+	mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Return(&appsv1.StatefulSet{
+		Spec: appsv1.StatefulSetSpec{Selector: &metav1.LabelSelector{MatchLabels: selector}},
+	}, nil)
+	mk.On("ListPVCs", mock.Anything, namespace, mock.Anything).Return(&corev1.PersistentVolumeClaimList{
+		Items: []corev1.PersistentVolumeClaim{{
+			ObjectMeta: metav1.ObjectMeta{Name: "redis-data-rfr-test-0", Namespace: namespace},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+				},
+			},
+		}},
+	}, nil)
+	mk.On("CreateEvent", mock.Anything, namespace, mock.Anything).Once().Return(nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, &mRFService.RedisFailoverCheck{}, &mRFService.RedisFailoverHeal{}, mk, metrics.Dummy, log.Dummy)
+	assert.NoError(handler.EnsureRedisVolumesSize(context.TODO(), rf))
+	assert.NoError(handler.EnsureRedisVolumesSize(context.TODO(), rf))
+
+	mk.AssertExpectations(t)
+}
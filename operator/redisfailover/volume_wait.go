@@ -0,0 +1,179 @@
+package redisfailover
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// DefaultVolumeWaitGracePeriod is how long the redis pods waiting for their volumes are neither healed
+// nor reported as degraded when no other grace period is configured.
+const DefaultVolumeWaitGracePeriod = 10 * time.Minute
+
+// creatingReasons are the reasons of the containers waiting for the pod to be set up, before they
+// are created.
+var creatingReasons = map[string]bool{
+	"ContainerCreating": true,
+	"PodInitializing":   true,
+}
+
+// volumeEventReasons are the reasons of the events of the pods whose volumes can't be attached or
+// mounted yet.
+var volumeEventReasons = map[string]bool{
+	"FailedAttachVolume": true,
+	"FailedMount":        true,
+}
+
+// volumeWait is a redis pod waiting for its volumes, since it was scheduled, with the message of the
+// last volume event of the pod.
+type volumeWait struct {
+	since   time.Time
+	message string
+}
+
+// VolumeWaits keeps, for every RF, the redis pods waiting for their volumes in the grace period, so
+// their replication and updates are held by the checks.
+type VolumeWaits struct {
+	mu   sync.Mutex
+	pods map[string][]string
+}
+
+// NewVolumeWaits returns new volume waits.
+func NewVolumeWaits() *VolumeWaits {
+	return &VolumeWaits{
+		pods: map[string][]string{},
+	}
+}
+
+// Set records the pods of the RF waiting for their volumes in the grace period.
+func (w *VolumeWaits) Set(key string, pods []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(pods) == 0 {
+		delete(w.pods, key)
+		return
+	}
+	w.pods[key] = pods
+}
+
+// Held returns the pods of the RF waiting for their volumes in the grace period.
+func (w *VolumeWaits) Held(key string) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.pods[key]
+}
+
+// getVolumeWaits returns the redis pods creating their containers whose last event is a failure to
+// attach or mount a volume. The events are only listed when a pod is creating its containers.
+func (r *RedisFailoverHandler) getVolumeWaits(ctx context.Context, rf *redisfailoverv1.RedisFailover, pods *corev1.PodList) (map[string]volumeWait, error) {
+	creating := []corev1.Pod{}
+	for _, pod := range pods.Items {
+		if isPodCreatingContainers(pod) {
+			creating = append(creating, pod)
+		}
+	}
+	if len(creating) == 0 {
+		return nil, nil
+	}
+	events, err := r.k8sservice.ListEvents(ctx, rf.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	waits := map[string]volumeWait{}
+	for _, pod := range creating {
+		event := lastPodEvent(pod, events)
+		if event == nil || !isVolumeEvent(*event) {
+			continue
+		}
+		since := pod.CreationTimestamp.Time
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionTrue {
+				since = condition.LastTransitionTime.Time
+			}
+		}
+		waits[pod.Name] = volumeWait{since: since, message: event.Message}
+	}
+	return waits, nil
+}
+
+// setInstancesWaitingForVolume sets the state of the instances waiting for their volumes, and returns
+// the ones still in the grace period and the ones past it.
+func setInstancesWaitingForVolume(instances []redisfailoverv1.InstanceStatus, waits map[string]volumeWait, grace time.Duration, now time.Time) ([]string, []string) {
+	held, expired := []string{}, []string{}
+	for i := range instances {
+		wait, ok := waits[instances[i].Name]
+		if !ok {
+			continue
+		}
+		instances[i].State = redisfailoverv1.InstanceStateWaitingForVolume
+		instances[i].Message = wait.message
+		if now.Sub(wait.since) < grace {
+			held = append(held, instances[i].Name)
+		} else {
+			expired = append(expired, instances[i].Name)
+		}
+	}
+	sort.Strings(held)
+	sort.Strings(expired)
+	return held, expired
+}
+
+// isPodCreatingContainers returns true when the pod is scheduled but its containers are not created yet.
+func isPodCreatingContainers(pod corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodPending || pod.Spec.NodeName == "" {
+		return false
+	}
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	for _, cs := range append(statuses, pod.Status.ContainerStatuses...) {
+		if cs.State.Waiting == nil || !creatingReasons[cs.State.Waiting.Reason] {
+			return false
+		}
+	}
+	return true
+}
+
+// lastPodEvent returns the last event of the pod, ignoring the ones of a previous pod with its name.
+func lastPodEvent(pod corev1.Pod, events *corev1.EventList) *corev1.Event {
+	var last *corev1.Event
+	for i := range events.Items {
+		event := &events.Items[i]
+		if event.InvolvedObject.Kind != "Pod" || event.InvolvedObject.Name != pod.Name {
+			continue
+		}
+		if event.InvolvedObject.UID != "" && event.InvolvedObject.UID != pod.UID {
+			continue
+		}
+		if last == nil || eventTime(*event).After(eventTime(*last)) {
+			last = event
+		}
+	}
+	return last
+}
+
+// isVolumeEvent returns true when the event is a failure to attach or mount a volume.
+func isVolumeEvent(event corev1.Event) bool {
+	if event.Type != corev1.EventTypeWarning {
+		return false
+	}
+	return volumeEventReasons[event.Reason] || strings.Contains(event.Message, "AttachVolume") || strings.Contains(event.Message, "MountVolume")
+}
+
+// eventTime returns when the event was last seen.
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case event.Series != nil:
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
@@ -0,0 +1,269 @@
+package redisfailover_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mRFService "redis-operator/mocks/operator/redisfailover/service"
+	mK8SService "redis-operator/mocks/service/k8s"
+	rfOperator "redis-operator/operator/redisfailover"
+	rfservice "redis-operator/operator/redisfailover/service"
+)
+
+const failedMountMessage = "Unable to attach or mount volumes: unmounted volumes=[redis-data], unattached volumes=[redis-data]: timed out waiting for the condition"
+
+// generateCreatingPod returns a redis pod scheduled the given time ago whose containers are being created.
+func generateCreatingPod(name string, scheduledAgo time.Duration) corev1.Pod {
+	pod := generatePodWithContainerStatuses(name, corev1.ContainerStatus{
+		Name:  "redis",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+	})
+	pod.UID = types.UID(name + "-uid")
+	pod.Spec.NodeName = "node-a"
+	pod.Status.Phase = corev1.PodPending
+	pod.Status.Conditions = []corev1.PodCondition{{
+		Type:               corev1.PodScheduled,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(time.Now().Add(-scheduledAgo)),
+	}}
+	return pod
+}
+
+func generatePodEvent(pod corev1.Pod, eventType, reason, message string, ago time.Duration) corev1.Event {
+	return corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: pod.Name + "." + reason, Namespace: namespace},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod.Name, Namespace: namespace, UID: pod.UID},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		LastTimestamp:  metav1.NewTime(time.Now().Add(-ago)),
+	}
+}
+
+func TestUpdateStatusWaitingForVolume(t *testing.T) {
+	tests := []struct {
+		name         string
+		scheduledAgo time.Duration
+		events       func(pod corev1.Pod) []corev1.Event
+		expWaiting   bool
+		expHealth    *redisfailoverv1.HealthReport
+	}{
+		{
+			name:         "A failed mount in the grace period should be reported as progressing",
+			scheduledAgo: time.Minute,
+			events: func(pod corev1.Pod) []corev1.Event {
+				return []corev1.Event{
+					generatePodEvent(pod, corev1.EventTypeNormal, "Scheduled", "Successfully assigned testns/rfr-test-1 to node-a", time.Minute),
+					generatePodEvent(pod, corev1.EventTypeWarning, "FailedMount", failedMountMessage, 10*time.Second),
+				}
+			},
+			expWaiting: true,
+			expHealth:  notReadyHealth(),
+		},
+		{
+			name:         "A failed attach in the grace period should be reported as progressing",
+			scheduledAgo: time.Minute,
+			events: func(pod corev1.Pod) []corev1.Event {
+				return []corev1.Event{
+					generatePodEvent(pod, corev1.EventTypeWarning, "FailedAttachVolume", failedMountMessage, 10*time.Second),
+				}
+			},
+			expWaiting: true,
+			expHealth:  notReadyHealth(),
+		},
+		{
+			name:         "A failed mount past the grace period should degrade the RF",
+			scheduledAgo: 20 * time.Minute,
+			events: func(pod corev1.Pod) []corev1.Event {
+				return []corev1.Event{
+					generatePodEvent(pod, corev1.EventTypeWarning, "FailedMount", failedMountMessage, 10*time.Second),
+				}
+			},
+			expWaiting: true,
+			expHealth: &redisfailoverv1.HealthReport{
+				Phase:   redisfailoverv1.HealthPhaseDegraded,
+				Message: "the redis pods rfr-test-1 are waiting for their volumes for more than 10m0s",
+			},
+		},
+		{
+			name:         "An attached volume should not be reported",
+			scheduledAgo: 20 * time.Minute,
+			events: func(pod corev1.Pod) []corev1.Event {
+				return []corev1.Event{
+					generatePodEvent(pod, corev1.EventTypeWarning, "FailedMount", failedMountMessage, time.Minute),
+					generatePodEvent(pod, corev1.EventTypeNormal, "SuccessfulAttachVolume", `AttachVolume.Attach succeeded for volume "pvc-1"`, 10*time.Second),
+				}
+			},
+			expHealth: notReadyHealth(),
+		},
+		{
+			name:         "The failed mounts of a previous pod should not be reported",
+			scheduledAgo: 20 * time.Minute,
+			events: func(pod corev1.Pod) []corev1.Event {
+				event := generatePodEvent(pod, corev1.EventTypeWarning, "FailedMount", failedMountMessage, time.Minute)
+				event.InvolvedObject.UID = "previous-uid"
+				return []corev1.Event{event}
+			},
+			expHealth: notReadyHealth(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			pod := generateCreatingPod("rfr-test-1", test.scheduledAgo)
+			config := generateConfig()
+			config.VolumeWaitGracePeriod = 10 * time.Minute
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: []corev1.Pod{pod}}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("ListEvents", mock.Anything, namespace).Once().Return(&corev1.EventList{Items: test.events(pod)}, nil)
+			mk.On("GetNodeLabels", mock.Anything, "node-a").Return(map[string]string{}, nil)
+			mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.MatchedBy(func(got *redisfailoverv1.RedisFailover) bool {
+				instance := got.Status.Instances[0]
+				if !test.expWaiting {
+					return assert.Empty(instance.State) && assert.Empty(instance.Message) &&
+						assert.Equal(*test.expHealth, *got.Status.Health)
+				}
+				return assert.Equal(redisfailoverv1.InstanceStateWaitingForVolume, instance.State) &&
+					assert.Equal(failedMountMessage, instance.Message) &&
+					assert.Equal(*test.expHealth, *got.Status.Health)
+			})).Once().Return(rf, nil)
+
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+			handler := rfOperator.NewRedisFailoverHandler(config, &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+
+			mk.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateStatusRunningPodsDontListEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := generateRF(false, false)
+	pod := generatePodWithIP("rfr-test-0", "10.0.0.1")
+	pod.Status.Phase = corev1.PodRunning
+
+	mk := &mK8SService.Services{}
+	mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: []corev1.Pod{pod}}, nil)
+	mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+	mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+	mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.Anything).Once().Return(rf, nil)
+
+	mrfh := &mRFService.RedisFailoverHeal{}
+	mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+	mrfc := &mRFService.RedisFailoverCheck{}
+	mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+	mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+	mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+	mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+
+	handler := rfOperator.NewRedisFailoverHandler(generateConfig(), &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+	assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+
+	mk.AssertExpectations(t)
+	mk.AssertNotCalled(t, "ListEvents", mock.Anything, mock.Anything)
+}
+
+func TestCheckAndHealHoldsPodsWaitingForVolume(t *testing.T) {
+	tests := []struct {
+		name         string
+		scheduledAgo time.Duration
+		expHeld      bool
+	}{
+		{
+			name:         "The replication and updates are held in the grace period",
+			scheduledAgo: time.Minute,
+			expHeld:      true,
+		},
+		{
+			name:         "The replication and updates are checked past the grace period",
+			scheduledAgo: 20 * time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rf := generateRF(false, false)
+			pod := generateCreatingPod("rfr-test-1", test.scheduledAgo)
+			config := generateConfig()
+			config.VolumeWaitGracePeriod = 10 * time.Minute
+			master := "0.0.0.0"
+
+			mk := &mK8SService.Services{}
+			mk.On("GetStatefulSetPods", mock.Anything, namespace, "rfr-test").Once().Return(&corev1.PodList{Items: []corev1.Pod{pod}}, nil)
+			mk.On("GetStatefulSet", mock.Anything, namespace, "rfr-test").Once().Return(&appsv1.StatefulSet{}, nil)
+			mk.On("GetDeploymentPods", mock.Anything, namespace, "rfs-test").Once().Return(&corev1.PodList{}, nil)
+			mk.On("ListEvents", mock.Anything, namespace).Once().Return(&corev1.EventList{Items: []corev1.Event{
+				generatePodEvent(pod, corev1.EventTypeWarning, "FailedMount", failedMountMessage, 10*time.Second),
+			}}, nil)
+			mk.On("GetNodeLabels", mock.Anything, "node-a").Return(map[string]string{}, nil)
+			mk.On("UpdateRedisFailoverStatus", mock.Anything, namespace, mock.Anything).Once().Return(rf, nil)
+
+			mrfc := &mRFService.RedisFailoverCheck{}
+			mrfh := &mRFService.RedisFailoverHeal{}
+			mrfh.On("GetSyncSlotQueue", rf).Once().Return([]string{})
+			mrfc.On("GetNodeTuningWarnings", mock.Anything, rf).Once().Return(map[string][]string{}, nil)
+			mrfc.On("MeasureRedisLatency", mock.Anything, rf).Once().Return([]rfservice.RedisLatency{}, nil)
+			mrfc.On("GetRedisKeyspaces", mock.Anything, rf).Once().Return([]rfservice.RedisKeyspace{}, nil)
+			mrfc.On("GetDataDirMismatches", mock.Anything, rf).Once().Return([]rfservice.DataDirMismatch{}, nil)
+			mrfc.On("CheckRedisNumber", mock.Anything, rf).Once().Return(nil)
+			mrfc.On("CheckSentinelNumber", mock.Anything, rf).Once().Return(nil)
+			mrfc.On("GetNumberMasters", mock.Anything, rf).Once().Return(1, nil)
+			mrfc.On("GetMasterIP", mock.Anything, rf).Once().Return(master, nil)
+			mrfh.On("PlanRoleLabels", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+			mrfh.On("PlanStuckReplicas", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+			mrfh.On("PlanRedisCustomConfig", mock.Anything, rf).Once().Return([]rfservice.PodAction{}, nil)
+			if !test.expHeld {
+				mrfc.On("CheckAllSlavesFromMaster", mock.Anything, master, rf).Once().Return(nil)
+				mrfh.On("ClearSyncSlotQueue", rf).Once()
+				mrfc.On("GetRedisesIPs", mock.Anything, rf).Once().Return([]string{master}, nil)
+				mrfc.On("GetMasterIP", mock.Anything, rf).Once().Return(master, nil)
+				mrfc.On("GetStatefulSetUpdateRevision", mock.Anything, rf).Once().Return("1", nil)
+				mrfc.On("GetRedisesSlavesPods", mock.Anything, rf).Once().Return([]string{}, nil)
+				mrfc.On("GetRedisesMasterPod", mock.Anything, rf).Once().Return("rfr-test-0", nil)
+				mrfc.On("GetRedisRevisionHash", mock.Anything, "rfr-test-0", rf).Once().Return("1", nil)
+				mrfh.On("PlanRedisInPlaceRestarts", mock.Anything, master, rf).Once().Return([]rfservice.PodAction{}, nil)
+			}
+			mrfc.On("GetSentinelsIPs", mock.Anything, rf).Once().Return([]string{}, nil)
+			mrfh.On("PlanUnresponsiveSentinels", mock.Anything, []string{}, rf).Once().Return(nil, nil)
+
+			handler := rfOperator.NewRedisFailoverHandler(config, &mRFService.RedisFailoverClient{}, mrfc, mrfh, mk, metrics.Dummy, log.Dummy)
+			assert.NoError(handler.UpdateStatus(context.TODO(), rf))
+			assert.NoError(handler.CheckAndHeal(context.TODO(), rf, rfservice.FeatureGates{}))
+
+			mrfc.AssertExpectations(t)
+			mrfh.AssertExpectations(t)
+			if test.expHeld {
+				mrfc.AssertNotCalled(t, "CheckAllSlavesFromMaster", mock.Anything, mock.Anything, mock.Anything)
+				mrfc.AssertNotCalled(t, "GetStatefulSetUpdateRevision", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package k8s_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+// serverSideApplyReactor makes the fake clientset create the applied objects, or merge them into the
+// stored ones with a strategic merge patch. Unlike a real apiserver, the fields left out of an apply are
+// not removed.
+func serverSideApplyReactor(mcli *kubernetes.Clientset, newObject func() runtime.Object) kubetesting.ReactionFunc {
+	return func(action kubetesting.Action) (bool, runtime.Object, error) {
+		patch, ok := action.(kubetesting.PatchAction)
+		if !ok || patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		gvr, ns := action.GetResource(), action.GetNamespace()
+		obj := newObject()
+		stored, err := mcli.Tracker().Get(gvr, ns, patch.GetName())
+		if kubeerrors.IsNotFound(err) {
+			if err := json.Unmarshal(patch.GetPatch(), obj); err != nil {
+				return true, nil, err
+			}
+			return true, obj, mcli.Tracker().Create(gvr, obj, ns)
+		}
+		if err != nil {
+			return true, nil, err
+		}
+		old, err := json.Marshal(stored)
+		if err != nil {
+			return true, nil, err
+		}
+		merged, err := strategicpatch.StrategicMergePatch(old, patch.GetPatch(), newObject())
+		if err != nil {
+			return true, nil, err
+		}
+		if err := json.Unmarshal(merged, obj); err != nil {
+			return true, nil, err
+		}
+		return true, obj, mcli.Tracker().Update(gvr, obj, ns)
+	}
+}
+
+// newApplyClientset returns a fake clientset applying the statefulsets, the deployments and the services.
+func newApplyClientset() *kubernetes.Clientset {
+	mcli := kubernetes.NewSimpleClientset()
+	mcli.PrependReactor("patch", "statefulsets", serverSideApplyReactor(mcli, func() runtime.Object { return &appsv1.StatefulSet{} }))
+	mcli.PrependReactor("patch", "deployments", serverSideApplyReactor(mcli, func() runtime.Object { return &appsv1.Deployment{} }))
+	mcli.PrependReactor("patch", "services", serverSideApplyReactor(mcli, func() runtime.Object { return &corev1.Service{} }))
+	return mcli
+}
+
+// writeVerbs returns the verbs of the writes of the fake clientset, with the patch type of the patches.
+func writeVerbs(mcli *kubernetes.Clientset) []string {
+	verbs := []string{}
+	for _, action := range mcli.Actions() {
+		switch a := action.(type) {
+		case kubetesting.PatchAction:
+			verbs = append(verbs, "patch "+string(a.GetPatchType()))
+		case kubetesting.CreateAction, kubetesting.UpdateAction:
+			verbs = append(verbs, action.GetVerb())
+		}
+	}
+	return verbs
+}
+
+func applyTestStatefulSet(image string) *appsv1.StatefulSet {
+	replicas := int32(3)
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: "testns", Labels: map[string]string{"app": "redis"}},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "redis", Image: image}}},
+			},
+		},
+	}
+}
+
+func TestStatefulSetServiceApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		apply    bool
+		expVerbs []string
+	}{
+		{
+			name:     "Created then updated",
+			expVerbs: []string{"create", "update"},
+		},
+		{
+			name:     "Applied",
+			apply:    true,
+			expVerbs: []string{"patch " + string(types.ApplyPatchType), "patch " + string(types.ApplyPatchType)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mcli := newApplyClientset()
+			service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			write := service.CreateOrUpdateStatefulSet
+			if test.apply {
+				write = service.ApplyStatefulSet
+			}
+
+			require.NoError(write(context.TODO(), "testns", applyTestStatefulSet("redis:6")))
+			require.NoError(write(context.TODO(), "testns", applyTestStatefulSet("redis:7")))
+
+			stored, err := service.GetStatefulSet(context.TODO(), "testns", "rfr-test")
+			require.NoError(err)
+			assert.Equal("redis:7", stored.Spec.Template.Spec.Containers[0].Image)
+			assert.Equal(int32(3), *stored.Spec.Replicas)
+			assert.Equal("redis", stored.Labels["app"])
+			assert.NotEmpty(stored.Annotations[k8s.SpecHashAnnotation])
+			assert.Equal(test.expVerbs, writeVerbs(mcli))
+
+			// The applies name the kind, without the status nor the fields not set.
+			for _, action := range mcli.Actions() {
+				if patch, ok := action.(kubetesting.PatchAction); ok {
+					applied := map[string]interface{}{}
+					require.NoError(json.Unmarshal(patch.GetPatch(), &applied))
+					assert.Equal("StatefulSet", applied["kind"])
+					assert.Equal("apps/v1", applied["apiVersion"])
+					assert.NotContains(applied, "status")
+					assert.NotContains(applied["metadata"], "creationTimestamp")
+				}
+			}
+		})
+	}
+}
+
+func TestDeploymentServiceApply(t *testing.T) {
+	deployment := func(image string) *appsv1.Deployment {
+		replicas := int32(3)
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfs-test", Namespace: "testns"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "sentinel", Image: image}}},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name     string
+		apply    bool
+		expVerbs []string
+	}{
+		{
+			name:     "Created then updated",
+			expVerbs: []string{"create", "update"},
+		},
+		{
+			name:     "Applied",
+			apply:    true,
+			expVerbs: []string{"patch " + string(types.ApplyPatchType), "patch " + string(types.ApplyPatchType)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mcli := newApplyClientset()
+			service := k8s.NewDeploymentService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			write := service.CreateOrUpdateDeployment
+			if test.apply {
+				write = service.ApplyDeployment
+			}
+
+			require.NoError(write(context.TODO(), "testns", deployment("redis:6")))
+			require.NoError(write(context.TODO(), "testns", deployment("redis:7")))
+
+			stored, err := service.GetDeployment(context.TODO(), "testns", "rfs-test")
+			require.NoError(err)
+			assert.Equal("redis:7", stored.Spec.Template.Spec.Containers[0].Image)
+			assert.NotEmpty(stored.Annotations[k8s.SpecHashAnnotation])
+			assert.Equal(test.expVerbs, writeVerbs(mcli))
+		})
+	}
+}
+
+func TestServiceServiceApply(t *testing.T) {
+	svc := func(port int32) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfs-test", Namespace: "testns"},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "sentinel"},
+				Ports:    []corev1.ServicePort{{Name: "sentinel", Port: port, Protocol: corev1.ProtocolTCP}},
+			},
+		}
+	}
+	tests := []struct {
+		name     string
+		apply    bool
+		expVerbs []string
+	}{
+		{
+			name:     "Created then updated",
+			expVerbs: []string{"create", "update"},
+		},
+		{
+			name:     "Applied",
+			apply:    true,
+			expVerbs: []string{"patch " + string(types.ApplyPatchType), "patch " + string(types.ApplyPatchType)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mcli := newApplyClientset()
+			service := k8s.NewServiceService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			write := service.CreateOrUpdateService
+			if test.apply {
+				write = service.ApplyService
+			}
+
+			require.NoError(write(context.TODO(), "testns", svc(26379)))
+			// The cluster IP allocated by the apiserver is kept by the applies.
+			stored, err := service.GetService(context.TODO(), "testns", "rfs-test")
+			require.NoError(err)
+			stored.Spec.ClusterIP = "10.0.0.10"
+			_, err = mcli.CoreV1().Services("testns").Update(context.TODO(), stored, metav1.UpdateOptions{})
+			require.NoError(err)
+			mcli.ClearActions()
+
+			require.NoError(write(context.TODO(), "testns", svc(26380)))
+			stored, err = service.GetService(context.TODO(), "testns", "rfs-test")
+			require.NoError(err)
+			assert.Equal(int32(26380), stored.Spec.Ports[0].Port)
+			if test.apply {
+				assert.Equal("10.0.0.10", stored.Spec.ClusterIP)
+			}
+			assert.Equal(test.expVerbs[1:], writeVerbs(mcli))
+		})
+	}
+}
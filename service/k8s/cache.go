@@ -0,0 +1,213 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// ownerIndex indexes the cached objects by the namespace and the name of the RedisFailover they
+	// are generated for.
+	ownerIndex = "owner"
+	// OwnerNameLabel is the label holding the name of the RedisFailover on the generated objects.
+	OwnerNameLabel = "redisfailovers.databases.spotahome.com/name"
+)
+
+// ObjectCache holds the pods, the statefulsets, the deployments and the services generated by the
+// operator, kept up to date with watches. The checks of every RedisFailover read them from it instead of listing them
+// from the apiserver, a get and a list by RedisFailover on every call otherwise. Only the objects
+// matching the selector are cached, without their managed fields.
+type ObjectCache struct {
+	factory      informers.SharedInformerFactory
+	pods         cache.SharedIndexInformer
+	statefulSets cache.SharedIndexInformer
+	deployments  cache.SharedIndexInformer
+	services     cache.SharedIndexInformer
+}
+
+// NewObjectCache returns a cache of the pods, the statefulsets, the deployments and the services matching
+// the label selector, resynced every resync period.
+func NewObjectCache(kubeClient kubernetes.Interface, selector string, resync time.Duration) (*ObjectCache, error) {
+	factory := informers.NewSharedInformerFactoryWithOptions(kubeClient, resync, informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+		opts.LabelSelector = selector
+	}))
+	c := &ObjectCache{
+		factory:      factory,
+		pods:         factory.Core().V1().Pods().Informer(),
+		statefulSets: factory.Apps().V1().StatefulSets().Informer(),
+		deployments:  factory.Apps().V1().Deployments().Informer(),
+		services:     factory.Core().V1().Services().Informer(),
+	}
+	for _, informer := range []cache.SharedIndexInformer{c.pods, c.statefulSets, c.deployments, c.services} {
+		if err := informer.AddIndexers(cache.Indexers{ownerIndex: indexByOwner}); err != nil {
+			return nil, err
+		}
+		if err := informer.SetTransform(dropManagedFields); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Start starts the watches and waits for the cache to be filled.
+func (c *ObjectCache) Start(stopC <-chan struct{}) error {
+	c.factory.Start(stopC)
+	for informer, synced := range c.factory.WaitForCacheSync(stopC) {
+		if !synced {
+			return fmt.Errorf("could not fill the cache of %s", informer)
+		}
+	}
+	return nil
+}
+
+// indexByOwner indexes an object by its namespace and the name of its RedisFailover.
+func indexByOwner(obj interface{}) ([]string, error) {
+	meta, ok := obj.(metav1.Object)
+	if !ok {
+		return nil, nil
+	}
+	owner, ok := meta.GetLabels()[OwnerNameLabel]
+	if !ok {
+		return nil, nil
+	}
+	return []string{meta.GetNamespace() + "/" + owner}, nil
+}
+
+// dropManagedFields removes the managed fields of the cached objects, the operator doesn't read them
+// and they are a big part of the objects.
+func dropManagedFields(obj interface{}) (interface{}, error) {
+	if meta, ok := obj.(metav1.Object); ok {
+		meta.SetManagedFields(nil)
+	}
+	return obj, nil
+}
+
+// getStatefulSet returns the statefulset, false when it's not cached.
+func (c *ObjectCache) getStatefulSet(namespace, name string) (*appsv1.StatefulSet, bool) {
+	obj, ok, err := c.statefulSets.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil || !ok {
+		return nil, false
+	}
+	return obj.(*appsv1.StatefulSet).DeepCopy(), true
+}
+
+// getDeployment returns the deployment, false when it's not cached.
+func (c *ObjectCache) getDeployment(namespace, name string) (*appsv1.Deployment, bool) {
+	obj, ok, err := c.deployments.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil || !ok {
+		return nil, false
+	}
+	return obj.(*appsv1.Deployment).DeepCopy(), true
+}
+
+// getPod returns the pod, false when it's not cached.
+func (c *ObjectCache) getPod(namespace, name string) (*corev1.Pod, bool) {
+	obj, ok, err := c.pods.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil || !ok {
+		return nil, false
+	}
+	return obj.(*corev1.Pod).DeepCopy(), true
+}
+
+// getService returns the service, false when it's not cached.
+func (c *ObjectCache) getService(namespace, name string) (*corev1.Service, bool) {
+	obj, ok, err := c.services.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil || !ok {
+		return nil, false
+	}
+	return obj.(*corev1.Service).DeepCopy(), true
+}
+
+// getPods returns the pods of the RedisFailover that owns the object with the given labels matching the
+// selector. Only the pods of that RedisFailover are looked at, false when the object has no owner.
+func (c *ObjectCache) getPods(namespace string, objLabels map[string]string, selector *metav1.LabelSelector) (*corev1.PodList, bool) {
+	owner, ok := objLabels[OwnerNameLabel]
+	if !ok || selector == nil {
+		return nil, false
+	}
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, false
+	}
+	objs, err := c.pods.GetIndexer().ByIndex(ownerIndex, namespace+"/"+owner)
+	if err != nil {
+		return nil, false
+	}
+	pods := &corev1.PodList{Items: []corev1.Pod{}}
+	for _, obj := range objs {
+		pod := obj.(*corev1.Pod)
+		if sel.Matches(labels.Set(pod.Labels)) {
+			pods.Items = append(pods.Items, *pod.DeepCopy())
+		}
+	}
+	return pods, true
+}
+
+// cachedServices reads the statefulsets, the deployments, the services and the pods from the cache, and
+// from the apiserver the ones not cached yet, as the ones just created. The rest of the calls, the lists
+// by selector and the writes go to the apiserver.
+type cachedServices struct {
+	Services
+	cache *ObjectCache
+}
+
+// WithObjectCache returns the services reading the statefulsets, the deployments, the services and the
+// pods from the cache.
+func WithObjectCache(s Services, objectCache *ObjectCache) Services {
+	return &cachedServices{Services: s, cache: objectCache}
+}
+
+func (s *cachedServices) GetStatefulSet(ctx context.Context, namespace, name string) (*appsv1.StatefulSet, error) {
+	if ss, ok := s.cache.getStatefulSet(namespace, name); ok {
+		return ss, nil
+	}
+	return s.Services.GetStatefulSet(ctx, namespace, name)
+}
+
+func (s *cachedServices) GetStatefulSetPods(ctx context.Context, namespace, name string) (*corev1.PodList, error) {
+	if ss, ok := s.cache.getStatefulSet(namespace, name); ok {
+		if pods, ok := s.cache.getPods(namespace, ss.Labels, ss.Spec.Selector); ok {
+			return pods, nil
+		}
+	}
+	return s.Services.GetStatefulSetPods(ctx, namespace, name)
+}
+
+func (s *cachedServices) GetDeployment(ctx context.Context, namespace, name string) (*appsv1.Deployment, error) {
+	if deployment, ok := s.cache.getDeployment(namespace, name); ok {
+		return deployment, nil
+	}
+	return s.Services.GetDeployment(ctx, namespace, name)
+}
+
+func (s *cachedServices) GetDeploymentPods(ctx context.Context, namespace, name string) (*corev1.PodList, error) {
+	if deployment, ok := s.cache.getDeployment(namespace, name); ok {
+		if pods, ok := s.cache.getPods(namespace, deployment.Labels, deployment.Spec.Selector); ok {
+			return pods, nil
+		}
+	}
+	return s.Services.GetDeploymentPods(ctx, namespace, name)
+}
+
+func (s *cachedServices) GetPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	if pod, ok := s.cache.getPod(namespace, name); ok {
+		return pod, nil
+	}
+	return s.Services.GetPod(ctx, namespace, name)
+}
+
+func (s *cachedServices) GetService(ctx context.Context, namespace, name string) (*corev1.Service, error) {
+	if service, ok := s.cache.getService(namespace, name); ok {
+		return service, nil
+	}
+	return s.Services.GetService(ctx, namespace, name)
+}
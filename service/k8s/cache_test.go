@@ -0,0 +1,189 @@
+package k8s_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+const (
+	cacheTestNamespace = "testns"
+	cacheTestSelector  = "app.kubernetes.io/managed-by=redis-operator"
+	cacheTestFailovers = 200
+)
+
+// generateFailoverObjects returns the statefulset, the deployment and the pods of a RF, three redis
+// pods and three sentinel pods.
+func generateFailoverObjects(rfName string, managed bool) []runtime.Object {
+	objLabels := func(component string) map[string]string {
+		l := map[string]string{
+			k8s.OwnerNameLabel:            rfName,
+			"app.kubernetes.io/component": component,
+		}
+		if managed {
+			l["app.kubernetes.io/managed-by"] = "redis-operator"
+		}
+		return l
+	}
+	selector := func(component string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{
+			k8s.OwnerNameLabel:            rfName,
+			"app.kubernetes.io/component": component,
+		}}
+	}
+
+	objs := []runtime.Object{
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfr-" + rfName, Namespace: cacheTestNamespace, Labels: objLabels("redis")},
+			Spec:       appsv1.StatefulSetSpec{Selector: selector("redis")},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfs-" + rfName, Namespace: cacheTestNamespace, Labels: objLabels("sentinel")},
+			Spec:       appsv1.DeploymentSpec{Selector: selector("sentinel")},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfs-" + rfName, Namespace: cacheTestNamespace, Labels: objLabels("sentinel")},
+		},
+	}
+	for _, component := range []string{"redis", "sentinel"} {
+		for i := 0; i < 3; i++ {
+			objs = append(objs, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:          fmt.Sprintf("%s-%s-%d", rfName, component, i),
+					Namespace:     cacheTestNamespace,
+					Labels:        objLabels(component),
+					ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubelet"}},
+				},
+			})
+		}
+	}
+	return objs
+}
+
+func newCachedServices(t testing.TB, objs []runtime.Object) (*kubernetes.Clientset, k8s.Services) {
+	cli := kubernetes.NewSimpleClientset(objs...)
+	s := k8s.New(cli, nil, nil, nil, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	objectCache, err := k8s.NewObjectCache(cli, cacheTestSelector, 0)
+	require.NoError(t, err)
+	stopC := make(chan struct{})
+	t.Cleanup(func() { close(stopC) })
+	require.NoError(t, objectCache.Start(stopC))
+	return cli, k8s.WithObjectCache(s, objectCache)
+}
+
+func TestObjectCache(t *testing.T) {
+	assert := assert.New(t)
+
+	objs := append(generateFailoverObjects("rf1", true), generateFailoverObjects("rf2", true)...)
+	objs = append(objs, generateFailoverObjects("unmanaged", false)...)
+	cli, s := newCachedServices(t, objs)
+	cli.ClearActions()
+
+	redisPods, err := s.GetStatefulSetPods(context.TODO(), cacheTestNamespace, "rfr-rf1")
+	assert.NoError(err)
+	assert.Len(redisPods.Items, 3)
+	for _, pod := range redisPods.Items {
+		assert.Equal("rf1", pod.Labels[k8s.OwnerNameLabel])
+		assert.Equal("redis", pod.Labels["app.kubernetes.io/component"])
+		assert.Empty(pod.ManagedFields)
+	}
+
+	sentinelPods, err := s.GetDeploymentPods(context.TODO(), cacheTestNamespace, "rfs-rf2")
+	assert.NoError(err)
+	assert.Len(sentinelPods.Items, 3)
+	for _, pod := range sentinelPods.Items {
+		assert.Equal("rf2", pod.Labels[k8s.OwnerNameLabel])
+		assert.Equal("sentinel", pod.Labels["app.kubernetes.io/component"])
+	}
+
+	pod, err := s.GetPod(context.TODO(), cacheTestNamespace, "rf1-redis-0")
+	assert.NoError(err)
+	assert.Equal("rf1", pod.Labels[k8s.OwnerNameLabel])
+	service, err := s.GetService(context.TODO(), cacheTestNamespace, "rfs-rf1")
+	assert.NoError(err)
+	assert.Equal("rfs-rf1", service.Name)
+	assert.Empty(cli.Actions(), "the cached objects must not be read from the apiserver")
+
+	// The objects not cached are read from the apiserver.
+	pods, err := s.GetStatefulSetPods(context.TODO(), cacheTestNamespace, "rfr-unmanaged")
+	assert.NoError(err)
+	assert.Len(pods.Items, 3)
+	assert.Len(cli.Actions(), 2)
+	_, err = s.GetDeployment(context.TODO(), cacheTestNamespace, "rfs-missing")
+	assert.Error(err)
+	_, err = s.GetService(context.TODO(), cacheTestNamespace, "rfs-unmanaged")
+	assert.NoError(err)
+	assert.Len(cli.Actions(), 4)
+}
+
+func TestObjectCacheMiss(t *testing.T) {
+	assert := assert.New(t)
+
+	// The apiserver has a pod the cache didn't see yet, as one created before its watch event arrives.
+	objs := generateFailoverObjects("rf1", true)
+	created := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "rf1-redis-3", Namespace: cacheTestNamespace}}
+	cli := kubernetes.NewSimpleClientset(append(objs, created)...)
+	objectCache, err := k8s.NewObjectCache(kubernetes.NewSimpleClientset(objs...), cacheTestSelector, 0)
+	require.NoError(t, err)
+	stopC := make(chan struct{})
+	defer close(stopC)
+	require.NoError(t, objectCache.Start(stopC))
+	s := k8s.WithObjectCache(k8s.New(cli, nil, nil, nil, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy), objectCache)
+
+	pod, err := s.GetPod(context.TODO(), cacheTestNamespace, "rf1-redis-0")
+	assert.NoError(err)
+	assert.Equal("rf1-redis-0", pod.Name)
+	assert.Empty(cli.Actions())
+
+	// The pod not cached is read from the apiserver.
+	pod, err = s.GetPod(context.TODO(), cacheTestNamespace, "rf1-redis-3")
+	assert.NoError(err)
+	assert.Equal("rf1-redis-3", pod.Name)
+	assert.Len(cli.Actions(), 1)
+}
+
+// BenchmarkPodsByFailover reads the redis and the sentinel pods of 200 RFs, as every check does, and
+// reports the apiserver calls by round.
+func BenchmarkPodsByFailover(b *testing.B) {
+	objs := []runtime.Object{}
+	for i := 0; i < cacheTestFailovers; i++ {
+		objs = append(objs, generateFailoverObjects(fmt.Sprintf("rf%d", i), true)...)
+	}
+
+	run := func(b *testing.B, cli *kubernetes.Clientset, s k8s.Services) {
+		cli.ClearActions()
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			for i := 0; i < cacheTestFailovers; i++ {
+				if _, err := s.GetStatefulSetPods(context.TODO(), cacheTestNamespace, fmt.Sprintf("rfr-rf%d", i)); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := s.GetDeploymentPods(context.TODO(), cacheTestNamespace, fmt.Sprintf("rfs-rf%d", i)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(len(cli.Actions()))/float64(b.N), "apicalls/op")
+	}
+
+	b.Run("apiserver", func(b *testing.B) {
+		cli := kubernetes.NewSimpleClientset(objs...)
+		run(b, cli, k8s.New(cli, nil, nil, nil, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy))
+	})
+	b.Run("cache", func(b *testing.B) {
+		cli, s := newCachedServices(b, objs)
+		run(b, cli, s)
+	})
+}
@@ -0,0 +1,138 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// CertificateGVR is the resource of the cert-manager certificates, they are handled as unstructured
+// objects so the operator doesn't depend on the cert-manager client.
+var CertificateGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+// ErrCertificatesUnavailable is returned when the cert-manager CRDs are not installed in the cluster.
+var ErrCertificatesUnavailable = errors.New("the cert-manager.io/v1 Certificate CRD is not installed")
+
+// Certificate the service that knows how to interact with k8s to manage the cert-manager certificates
+type Certificate interface {
+	GetCertificate(ctx context.Context, namespace, name string) (*unstructured.Unstructured, error)
+	CreateOrUpdateCertificate(ctx context.Context, namespace string, certificate *unstructured.Unstructured) error
+	DeleteCertificate(ctx context.Context, namespace, name string) error
+}
+
+// CertificateService is the Certificate service implementation using the dynamic client.
+type CertificateService struct {
+	dynamicClient   dynamic.Interface
+	conflictRetries int
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+	// discovery tells whether the cluster serves the certificates.
+	discovery *crdDiscovery
+}
+
+// NewCertificateService returns a new Certificate KubeService. The dynamic client can be nil for the
+// commands that don't manage certificates, then the CRD is reported as not installed.
+func NewCertificateService(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, conflictRetries int, logger log.Logger, metricsRecorder metrics.Recorder) *CertificateService {
+	logger = logger.With("service", "k8s.certificate")
+	return &CertificateService{
+		dynamicClient:   dynamicClient,
+		conflictRetries: conflictRetries,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+		discovery:       &crdDiscovery{kubeClient: kubeClient, gvr: CertificateGVR},
+	}
+}
+
+// available returns ErrCertificatesUnavailable when the cluster doesn't serve the certificates.
+func (c *CertificateService) available() error {
+	if c.dynamicClient == nil {
+		return ErrCertificatesUnavailable
+	}
+	served, discovered, err := c.discovery.served()
+	if err != nil {
+		return err
+	}
+	if !served {
+		if discovered {
+			c.logger.Warnf("the cluster serves no cert-manager certificates, the TLS certificates are not written")
+		}
+		return ErrCertificatesUnavailable
+	}
+	return nil
+}
+
+func (c *CertificateService) GetCertificate(ctx context.Context, namespace, name string) (*unstructured.Unstructured, error) {
+	if err := c.available(); err != nil {
+		return nil, err
+	}
+	certificate, err := c.dynamicClient.Resource(CertificateGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "Certificate", name, "GET", err, c.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+// CreateOrUpdateCertificate will update the certificate or create it if does not exist. Nothing is
+// written while the desired certificate has the hash of the stored one.
+func (c *CertificateService) CreateOrUpdateCertificate(ctx context.Context, namespace string, certificate *unstructured.Unstructured) error {
+	stored, err := c.GetCertificate(ctx, namespace, certificate.GetName())
+	if err != nil {
+		// If no resource we need to create.
+		if apierrors.IsNotFound(err) {
+			if _, err := setSpecHash(certificate, certificate.Object["spec"]); err != nil {
+				return err
+			}
+			_, err = c.dynamicClient.Resource(CertificateGVR).Namespace(namespace).Create(ctx, certificate, metav1.CreateOptions{})
+			err = recordMetrics(ctx, namespace, "Certificate", certificate.GetName(), "CREATE", err, c.metricsRecorder)
+			if err != nil {
+				return err
+			}
+			c.logger.WithField("namespace", namespace).WithField("certificate", certificate.GetName()).Infof("certificate created")
+			return nil
+		}
+		return err
+	}
+
+	storedHash := stored.GetAnnotations()[SpecHashAnnotation]
+	hash, err := setSpecHash(certificate, certificate.Object["spec"])
+	if err != nil {
+		return err
+	}
+	if storedHash == hash {
+		return nil
+	}
+
+	certificate.SetResourceVersion(stored.GetResourceVersion())
+	err = updateOnConflict(c.conflictRetries, namespace, "Certificate", certificate.GetName(), c.metricsRecorder, certificate, func() (*unstructured.Unstructured, error) {
+		return c.GetCertificate(ctx, namespace, certificate.GetName())
+	}, func(stored *unstructured.Unstructured) {
+		setDesiredMeta(stored, certificate)
+		stored.Object["spec"] = certificate.Object["spec"]
+	}, func(certificate *unstructured.Unstructured) error {
+		_, err := c.dynamicClient.Resource(CertificateGVR).Namespace(namespace).Update(ctx, certificate, metav1.UpdateOptions{})
+		return recordMetrics(ctx, namespace, "Certificate", certificate.GetName(), "UPDATE", err, c.metricsRecorder)
+	})
+	if err != nil {
+		return err
+	}
+	c.logger.WithField("namespace", namespace).WithField("certificate", certificate.GetName()).Infof("certificate updated")
+	return nil
+}
+
+func (c *CertificateService) DeleteCertificate(ctx context.Context, namespace, name string) error {
+	if err := c.available(); err != nil {
+		return err
+	}
+	err := c.dynamicClient.Resource(CertificateGVR).Namespace(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	return recordMetrics(ctx, namespace, "Certificate", name, "DELETE", err, c.metricsRecorder)
+}
@@ -0,0 +1,101 @@
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+func newCertManagerKubeClient() *kubernetes.Clientset {
+	client := kubernetes.NewSimpleClientset()
+	client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: k8s.CertificateGVR.GroupVersion().String(),
+			APIResources: []metav1.APIResource{{Name: "certificates", Kind: "Certificate", Namespaced: true}},
+		},
+	}
+	return client
+}
+
+func generateCertificate(dnsNames ...interface{}) *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetAPIVersion("cert-manager.io/v1")
+	certificate.SetKind("Certificate")
+	certificate.SetName("rfr-tls-test")
+	certificate.SetNamespace(snapshotTestNamespace)
+	certificate.Object["spec"] = map[string]interface{}{
+		"secretName": "rfr-tls-test",
+		"dnsNames":   dnsNames,
+	}
+	return certificate
+}
+
+func TestCertificateLifecycle(t *testing.T) {
+	assert := assert.New(t)
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		k8s.CertificateGVR: "CertificateList",
+	})
+	service := k8s.NewCertificateService(newCertManagerKubeClient(), dynamicClient, 0, log.Dummy, metrics.Dummy)
+
+	require.NoError(t, service.CreateOrUpdateCertificate(context.TODO(), snapshotTestNamespace, generateCertificate("rfr-test")))
+	// The same certificate is not written again.
+	require.NoError(t, service.CreateOrUpdateCertificate(context.TODO(), snapshotTestNamespace, generateCertificate("rfr-test")))
+	updates := 0
+	for _, action := range dynamicClient.Actions() {
+		if action.GetVerb() == "update" {
+			updates++
+		}
+	}
+	assert.Zero(updates)
+
+	require.NoError(t, service.CreateOrUpdateCertificate(context.TODO(), snapshotTestNamespace, generateCertificate("rfr-test", "rfrm-test")))
+	certificate, err := service.GetCertificate(context.TODO(), snapshotTestNamespace, "rfr-tls-test")
+	require.NoError(t, err)
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	assert.Equal([]string{"rfr-test", "rfrm-test"}, dnsNames)
+
+	assert.NoError(service.DeleteCertificate(context.TODO(), snapshotTestNamespace, "rfr-tls-test"))
+	_, err = service.GetCertificate(context.TODO(), snapshotTestNamespace, "rfr-tls-test")
+	assert.True(apierrors.IsNotFound(err))
+}
+
+func TestCertificateWithoutCRD(t *testing.T) {
+	assert := assert.New(t)
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		k8s.CertificateGVR: "CertificateList",
+	})
+	dynamicClient.PrependReactor("*", "certificates", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		t.Errorf("unexpected %s of a certificate without the CRD", action.GetVerb())
+		return true, nil, nil
+	})
+	service := k8s.NewCertificateService(kubernetes.NewSimpleClientset(), dynamicClient, 0, log.Dummy, metrics.Dummy)
+
+	_, err := service.GetCertificate(context.TODO(), snapshotTestNamespace, "rfr-tls-test")
+	assert.ErrorIs(err, k8s.ErrCertificatesUnavailable)
+	err = service.CreateOrUpdateCertificate(context.TODO(), snapshotTestNamespace, generateCertificate("rfr-test"))
+	assert.ErrorIs(err, k8s.ErrCertificatesUnavailable)
+	err = service.DeleteCertificate(context.TODO(), snapshotTestNamespace, "rfr-tls-test")
+	assert.ErrorIs(err, k8s.ErrCertificatesUnavailable)
+
+	// Without a dynamic client the certificates are not managed.
+	service = k8s.NewCertificateService(newCertManagerKubeClient(), nil, 0, log.Dummy, metrics.Dummy)
+	_, err = service.GetCertificate(context.TODO(), snapshotTestNamespace, "rfr-tls-test")
+	assert.ErrorIs(err, k8s.ErrCertificatesUnavailable)
+}
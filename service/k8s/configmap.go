@@ -2,10 +2,12 @@ package k8s
 
 import (
 	"context"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 
 	"redis-operator/log"
@@ -14,84 +16,170 @@ import (
 
 // ConfigMap the ServiceAccount service that knows how to interact with k8s to manage them
 type ConfigMap interface {
-	GetConfigMap(namespace string, name string) (*corev1.ConfigMap, error)
-	CreateConfigMap(namespace string, configMap *corev1.ConfigMap) error
-	UpdateConfigMap(namespace string, configMap *corev1.ConfigMap) error
-	CreateOrUpdateConfigMap(namespace string, np *corev1.ConfigMap) error
-	DeleteConfigMap(namespace string, name string) error
-	ListConfigMaps(namespace string) (*corev1.ConfigMapList, error)
+	GetConfigMap(ctx context.Context, namespace string, name string) (*corev1.ConfigMap, error)
+	CreateConfigMap(ctx context.Context, namespace string, configMap *corev1.ConfigMap) error
+	UpdateConfigMap(ctx context.Context, namespace string, configMap *corev1.ConfigMap) error
+	CreateOrUpdateConfigMap(ctx context.Context, namespace string, np *corev1.ConfigMap) error
+	DeleteConfigMap(ctx context.Context, namespace string, name string) error
+	ListConfigMaps(ctx context.Context, namespace string) (*corev1.ConfigMapList, error)
+	// ListConfigMapsBySelector lists the configMaps with all the labels of the selector.
+	ListConfigMapsBySelector(ctx context.Context, namespace string, selector map[string]string) (*corev1.ConfigMapList, error)
+	// DeleteConfigMapRevisions deletes the configMaps with all the labels of the selector but the ones of
+	// the current revision and of the keep-1 newest others, the revisions the pods not rolled yet may still
+	// mount. The revision of a configMap is the value of its revisionLabel.
+	DeleteConfigMapRevisions(ctx context.Context, namespace string, selector map[string]string, revisionLabel string, current string, keep int) error
 }
 
 // ConfigMapService is the configMap service implementation using API calls to kubernetes.
 type ConfigMapService struct {
 	kubeClient      kubernetes.Interface
+	conflictRetries int
 	logger          log.Logger
 	metricsRecorder metrics.Recorder
 }
 
 // NewConfigMapService returns a new ConfigMap KubeService.
-func NewConfigMapService(kubeClient kubernetes.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *ConfigMapService {
+func NewConfigMapService(kubeClient kubernetes.Interface, conflictRetries int, logger log.Logger, metricsRecorder metrics.Recorder) *ConfigMapService {
 	logger = logger.With("service", "k8s.configMap")
 	return &ConfigMapService{
 		kubeClient:      kubeClient,
+		conflictRetries: conflictRetries,
 		logger:          logger,
 		metricsRecorder: metricsRecorder,
 	}
 }
 
-func (p *ConfigMapService) GetConfigMap(namespace string, name string) (*corev1.ConfigMap, error) {
-	configMap, err := p.kubeClient.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
-	recordMetrics(namespace, "ConfigMap", name, "GET", err, p.metricsRecorder)
+func (p *ConfigMapService) GetConfigMap(ctx context.Context, namespace string, name string) (*corev1.ConfigMap, error) {
+	configMap, err := p.kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "ConfigMap", name, "GET", err, p.metricsRecorder)
 	if err != nil {
 		return nil, err
 	}
 	return configMap, err
 }
 
-func (p *ConfigMapService) CreateConfigMap(namespace string, configMap *corev1.ConfigMap) error {
-	_, err := p.kubeClient.CoreV1().ConfigMaps(namespace).Create(context.TODO(), configMap, metav1.CreateOptions{})
-	recordMetrics(namespace, "ConfigMap", configMap.GetName(), "CREATE", err, p.metricsRecorder)
+func (p *ConfigMapService) CreateConfigMap(ctx context.Context, namespace string, configMap *corev1.ConfigMap) error {
+	_, err := p.kubeClient.CoreV1().ConfigMaps(namespace).Create(ctx, configMap, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "ConfigMap", configMap.GetName(), "CREATE", err, p.metricsRecorder)
 	if err != nil {
 		return err
 	}
 	p.logger.WithField("namespace", namespace).WithField("configMap", configMap.Name).Infof("configMap created")
 	return nil
 }
-func (p *ConfigMapService) UpdateConfigMap(namespace string, configMap *corev1.ConfigMap) error {
-	_, err := p.kubeClient.CoreV1().ConfigMaps(namespace).Update(context.TODO(), configMap, metav1.UpdateOptions{})
-	recordMetrics(namespace, "ConfigMap", configMap.GetName(), "UPDATE", err, p.metricsRecorder)
+func (p *ConfigMapService) UpdateConfigMap(ctx context.Context, namespace string, configMap *corev1.ConfigMap) error {
+	err := updateOnConflict(p.conflictRetries, namespace, "ConfigMap", configMap.GetName(), p.metricsRecorder, configMap, func() (*corev1.ConfigMap, error) {
+		return p.GetConfigMap(ctx, namespace, configMap.Name)
+	}, func(stored *corev1.ConfigMap) {
+		setDesiredMeta(stored, configMap)
+		stored.Data = configMap.Data
+		stored.BinaryData = configMap.BinaryData
+	}, func(configMap *corev1.ConfigMap) error {
+		_, err := p.kubeClient.CoreV1().ConfigMaps(namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+		return recordMetrics(ctx, namespace, "ConfigMap", configMap.GetName(), "UPDATE", err, p.metricsRecorder)
+	})
 	if err != nil {
 		return err
 	}
 	p.logger.WithField("namespace", namespace).WithField("configMap", configMap.Name).Infof("configMap updated")
 	return nil
 }
-func (p *ConfigMapService) CreateOrUpdateConfigMap(namespace string, configMap *corev1.ConfigMap) error {
-	storedConfigMap, err := p.GetConfigMap(namespace, configMap.Name)
+func (p *ConfigMapService) CreateOrUpdateConfigMap(ctx context.Context, namespace string, configMap *corev1.ConfigMap) error {
+	storedConfigMap, err := p.GetConfigMap(ctx, namespace, configMap.Name)
 	if err != nil {
 		// If no resource we need to create.
 		if errors.IsNotFound(err) {
-			return p.CreateConfigMap(namespace, configMap)
+			if _, err := setSpecHash(configMap, []interface{}{configMap.Data, configMap.BinaryData}); err != nil {
+				return err
+			}
+			return p.CreateConfigMap(ctx, namespace, configMap)
 		}
 		return err
 	}
 
+	// Nothing is written while the desired configmap has the hash of the stored one.
+	storedHash := storedConfigMap.Annotations[SpecHashAnnotation]
+	hash, err := setSpecHash(configMap, []interface{}{configMap.Data, configMap.BinaryData})
+	if err != nil {
+		return err
+	}
+	if storedHash == hash {
+		return nil
+	}
+
 	// Already exists, need to Update.
 	// Set the correct resource version to ensure we are on the latest version. This way the only valid
 	// namespace is our spec(https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#concurrency-control-and-consistency),
 	// we will replace the current namespace state.
 	configMap.ResourceVersion = storedConfigMap.ResourceVersion
-	return p.UpdateConfigMap(namespace, configMap)
+	return p.UpdateConfigMap(ctx, namespace, configMap)
 }
 
-func (p *ConfigMapService) DeleteConfigMap(namespace string, name string) error {
-	err := p.kubeClient.CoreV1().ConfigMaps(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
-	recordMetrics(namespace, "ConfigMap", name, "DELETE", err, p.metricsRecorder)
+func (p *ConfigMapService) DeleteConfigMap(ctx context.Context, namespace string, name string) error {
+	err := p.kubeClient.CoreV1().ConfigMaps(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err = recordMetrics(ctx, namespace, "ConfigMap", name, "DELETE", err, p.metricsRecorder)
 	return err
 }
 
-func (p *ConfigMapService) ListConfigMaps(namespace string) (*corev1.ConfigMapList, error) {
-	objects, err := p.kubeClient.CoreV1().ConfigMaps(namespace).List(context.TODO(), metav1.ListOptions{})
-	recordMetrics(namespace, "ConfigMap", metrics.NOT_APPLICABLE, "LIST", err, p.metricsRecorder)
+func (p *ConfigMapService) ListConfigMaps(ctx context.Context, namespace string) (*corev1.ConfigMapList, error) {
+	objects, err := p.kubeClient.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+	err = recordMetrics(ctx, namespace, "ConfigMap", metrics.NOT_APPLICABLE, "LIST", err, p.metricsRecorder)
+	return objects, err
+}
+
+// ListConfigMapsBySelector will retrieve the configMaps in the given namespace with all the labels of the selector
+func (p *ConfigMapService) ListConfigMapsBySelector(ctx context.Context, namespace string, selector map[string]string) (*corev1.ConfigMapList, error) {
+	opts := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String()}
+	objects, err := p.kubeClient.CoreV1().ConfigMaps(namespace).List(ctx, opts)
+	err = recordMetrics(ctx, namespace, "ConfigMap", metrics.NOT_APPLICABLE, "LIST", err, p.metricsRecorder)
 	return objects, err
 }
+
+// DeleteConfigMapRevisions will delete the configMaps of the selector older than the last keep revisions.
+// The configMaps of a revision share the value of their revisionLabel, the revisions are ordered by their
+// newest configMap. The current revision is always kept, even when an older configuration came back, and
+// the configMaps without the revisionLabel are never deleted.
+func (p *ConfigMapService) DeleteConfigMapRevisions(ctx context.Context, namespace string, selector map[string]string, revisionLabel string, current string, keep int) error {
+	configMaps, err := p.ListConfigMapsBySelector(ctx, namespace, selector)
+	if err != nil {
+		return err
+	}
+	revisions := map[string][]corev1.ConfigMap{}
+	created := map[string]metav1.Time{}
+	for _, cm := range configMaps.Items {
+		revision, ok := cm.Labels[revisionLabel]
+		if !ok || revision == current {
+			continue
+		}
+		revisions[revision] = append(revisions[revision], cm)
+		if t, ok := created[revision]; !ok || t.Before(&cm.CreationTimestamp) {
+			created[revision] = cm.CreationTimestamp
+		}
+	}
+	order := make([]string, 0, len(revisions))
+	for revision := range revisions {
+		order = append(order, revision)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		ti, tj := created[order[i]], created[order[j]]
+		if !ti.Equal(&tj) {
+			return tj.Before(&ti)
+		}
+		return order[i] > order[j]
+	})
+	if current != "" {
+		keep--
+	}
+	for i, revision := range order {
+		if i < keep {
+			continue
+		}
+		for _, cm := range revisions[revision] {
+			if err := p.DeleteConfigMap(ctx, namespace, cm.Name); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			p.logger.WithField("namespace", namespace).WithField("configMap", cm.Name).Infof("old configMap revision deleted")
+		}
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package k8s_test
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
@@ -42,6 +44,9 @@ func TestConfigMapServiceGetCreateOrUpdate(t *testing.T) {
 		},
 	}
 
+	// The stored configmap was written before the hash of the desired one.
+	storedConfigMap := testConfigMap.DeepCopy()
+
 	testns := "testns"
 
 	tests := []struct {
@@ -80,7 +85,7 @@ func TestConfigMapServiceGetCreateOrUpdate(t *testing.T) {
 		{
 			name:               "An existent configmap should update the configmap.",
 			configMap:          testConfigMap,
-			getConfigMapResult: testConfigMap,
+			getConfigMapResult: storedConfigMap,
 			errorOnGet:         nil,
 			errorOnCreation:    nil,
 			expActions: []kubetesting.Action{
@@ -104,8 +109,8 @@ func TestConfigMapServiceGetCreateOrUpdate(t *testing.T) {
 				return true, nil, test.errorOnCreation
 			})
 
-			service := k8s.NewConfigMapService(mcli, log.Dummy, metrics.Dummy)
-			err := service.CreateOrUpdateConfigMap(testns, test.configMap)
+			service := k8s.NewConfigMapService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			err := service.CreateOrUpdateConfigMap(context.TODO(), testns, test.configMap)
 
 			if test.expErr {
 				assert.Error(err)
@@ -117,3 +122,107 @@ func TestConfigMapServiceGetCreateOrUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigMapServiceCreateOrUpdateSpecHash(t *testing.T) {
+	assert := assert.New(t)
+
+	testns := "testns"
+	generate := func(config string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns},
+			Data:       map[string]string{"redis.conf": config},
+		}
+	}
+
+	mcli := kubernetes.NewSimpleClientset()
+	service := k8s.NewConfigMapService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	assert.NoError(service.CreateOrUpdateConfigMap(context.TODO(), testns, generate("maxmemory 1gb")))
+
+	// An unchanged configmap is not updated.
+	assert.NoError(service.CreateOrUpdateConfigMap(context.TODO(), testns, generate("maxmemory 1gb")))
+	assert.Equal(0, countUpdates(mcli))
+
+	// A changed data updates the configmap.
+	assert.NoError(service.CreateOrUpdateConfigMap(context.TODO(), testns, generate("maxmemory 2gb")))
+	assert.Equal(1, countUpdates(mcli))
+	updated, err := service.GetConfigMap(context.TODO(), testns, "rfr-test")
+	assert.NoError(err)
+	assert.Equal("maxmemory 2gb", updated.Data["redis.conf"])
+}
+
+func TestConfigMapServiceDeleteConfigMapRevisions(t *testing.T) {
+	testns := "testns"
+	revisionLabel := "revision"
+	selector := map[string]string{"app": "redis"}
+	now := time.Now()
+	generate := func(name, revision string, age time.Duration) runtime.Object {
+		labels := map[string]string{"app": "redis"}
+		if revision != "" {
+			labels[revisionLabel] = revision
+		}
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         testns,
+			Labels:            labels,
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		current     string
+		keep        int
+		expRemained []string
+	}{
+		{
+			name:        "The current and the previous revisions are kept",
+			current:     "c",
+			keep:        2,
+			expRemained: []string{"legacy", "other", "rfr-b", "rfr-b-part-0", "rfr-c", "rfr-c-part-0"},
+		},
+		{
+			name:        "The current revision is kept even when it's the oldest one",
+			current:     "a",
+			keep:        2,
+			expRemained: []string{"legacy", "other", "rfr-a", "rfr-c", "rfr-c-part-0"},
+		},
+		{
+			name:        "Without a current revision the newest ones are kept",
+			keep:        1,
+			expRemained: []string{"legacy", "other", "rfr-c", "rfr-c-part-0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mcli := kubernetes.NewSimpleClientset(
+				generate("legacy", "", 4*time.Hour),
+				generate("rfr-a", "a", 3*time.Hour),
+				generate("rfr-b", "b", 2*time.Hour),
+				generate("rfr-b-part-0", "b", 2*time.Hour),
+				generate("rfr-c", "c", time.Hour),
+				generate("rfr-c-part-0", "c", time.Hour),
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: testns, Labels: map[string]string{revisionLabel: "z"}}},
+			)
+			service := k8s.NewConfigMapService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+			err := service.DeleteConfigMapRevisions(context.TODO(), testns, selector, revisionLabel, test.current, test.keep)
+			assert.NoError(err)
+
+			remained, err := service.ListConfigMaps(context.TODO(), testns)
+			assert.NoError(err)
+			names := []string{}
+			for _, cm := range remained.Items {
+				names = append(names, cm.Name)
+			}
+			assert.ElementsMatch(test.expRemained, names)
+
+			// The configMaps without the labels of the selector are not listed.
+			selected, err := service.ListConfigMapsBySelector(context.TODO(), testns, selector)
+			assert.NoError(err)
+			assert.Len(selected.Items, len(test.expRemained)-1)
+		})
+	}
+}
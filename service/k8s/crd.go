@@ -0,0 +1,44 @@
+package k8s
+
+import (
+	"context"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionscli "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// CustomResourceDefinition interacts with k8s to get the installed CRDs
+type CustomResourceDefinition interface {
+	GetCustomResourceDefinition(ctx context.Context, name string) (*apiextensionsv1.CustomResourceDefinition, error)
+}
+
+// CustomResourceDefinitionService is the CRD service implementation using API calls to kubernetes.
+type CustomResourceDefinitionService struct {
+	apiextCli       apiextensionscli.Interface
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+}
+
+// NewCustomResourceDefinitionService returns a new CustomResourceDefinition KubeService.
+func NewCustomResourceDefinitionService(apiextCli apiextensionscli.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *CustomResourceDefinitionService {
+	logger = logger.With("service", "k8s.crd")
+	return &CustomResourceDefinitionService{
+		apiextCli:       apiextCli,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+	}
+}
+
+// GetCustomResourceDefinition will retrieve the requested CRD
+func (c *CustomResourceDefinitionService) GetCustomResourceDefinition(ctx context.Context, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+	crd, err := c.apiextCli.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, metrics.NOT_APPLICABLE, "CustomResourceDefinition", name, "GET", err, c.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return crd, nil
+}
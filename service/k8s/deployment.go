@@ -9,6 +9,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"redis-operator/log"
@@ -17,36 +19,41 @@ import (
 
 // Deployment the Deployment service that knows how to interact with k8s to manage them
 type Deployment interface {
-	GetDeployment(namespace, name string) (*appsv1.Deployment, error)
-	GetDeploymentPods(namespace, name string) (*corev1.PodList, error)
-	CreateDeployment(namespace string, deployment *appsv1.Deployment) error
-	UpdateDeployment(namespace string, deployment *appsv1.Deployment) error
-	CreateOrUpdateDeployment(namespace string, deployment *appsv1.Deployment) error
-	DeleteDeployment(namespace string, name string) error
-	ListDeployments(namespace string) (*appsv1.DeploymentList, error)
+	GetDeployment(ctx context.Context, namespace, name string) (*appsv1.Deployment, error)
+	GetDeploymentPods(ctx context.Context, namespace, name string) (*corev1.PodList, error)
+	CreateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error
+	UpdateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error
+	CreateOrUpdateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error
+	// ApplyDeployment writes the deployment with a server-side apply, see FieldManager.
+	ApplyDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error
+	DeleteDeployment(ctx context.Context, namespace string, name string) error
+	ListDeployments(ctx context.Context, namespace string) (*appsv1.DeploymentList, error)
+	ListDeploymentsWithSelector(ctx context.Context, namespace string, selector labels.Selector) (*appsv1.DeploymentList, error)
 }
 
 // DeploymentService is the service account service implementation using API calls to kubernetes.
 type DeploymentService struct {
 	kubeClient      kubernetes.Interface
+	conflictRetries int
 	logger          log.Logger
 	metricsRecorder metrics.Recorder
 }
 
 // NewDeploymentService returns a new Deployment KubeService.
-func NewDeploymentService(kubeClient kubernetes.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *DeploymentService {
+func NewDeploymentService(kubeClient kubernetes.Interface, conflictRetries int, logger log.Logger, metricsRecorder metrics.Recorder) *DeploymentService {
 	logger = logger.With("service", "k8s.deployment")
 	return &DeploymentService{
 		kubeClient:      kubeClient,
+		conflictRetries: conflictRetries,
 		logger:          logger,
 		metricsRecorder: metricsRecorder,
 	}
 }
 
 // GetDeployment will retrieve the requested deployment based on namespace and name
-func (d *DeploymentService) GetDeployment(namespace, name string) (*appsv1.Deployment, error) {
-	deployment, err := d.kubeClient.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
-	recordMetrics(namespace, "Deployment", name, "GET", err, d.metricsRecorder)
+func (d *DeploymentService) GetDeployment(ctx context.Context, namespace, name string) (*appsv1.Deployment, error) {
+	deployment, err := d.kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "Deployment", name, "GET", err, d.metricsRecorder)
 	if err != nil {
 		return nil, err
 	}
@@ -54,9 +61,9 @@ func (d *DeploymentService) GetDeployment(namespace, name string) (*appsv1.Deplo
 }
 
 // GetDeploymentPods will retrieve the pods managed by a given deployment
-func (d *DeploymentService) GetDeploymentPods(namespace, name string) (*corev1.PodList, error) {
-	deployment, err := d.kubeClient.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
-	recordMetrics(namespace, "Deployment", name, "GET", err, d.metricsRecorder)
+func (d *DeploymentService) GetDeploymentPods(ctx context.Context, namespace, name string) (*corev1.PodList, error) {
+	deployment, err := d.kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "Deployment", name, "GET", err, d.metricsRecorder)
 	if err != nil {
 		return nil, err
 	}
@@ -65,13 +72,13 @@ func (d *DeploymentService) GetDeploymentPods(namespace, name string) (*corev1.P
 		labels = append(labels, fmt.Sprintf("%s=%s", k, v))
 	}
 	selector := strings.Join(labels, ",")
-	return d.kubeClient.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	return d.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
 }
 
 // CreateDeployment will create the given deployment
-func (d *DeploymentService) CreateDeployment(namespace string, deployment *appsv1.Deployment) error {
-	_, err := d.kubeClient.AppsV1().Deployments(namespace).Create(context.TODO(), deployment, metav1.CreateOptions{})
-	recordMetrics(namespace, "Deployment", deployment.GetName(), "CREATE", err, d.metricsRecorder)
+func (d *DeploymentService) CreateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error {
+	_, err := d.kubeClient.AppsV1().Deployments(namespace).Create(ctx, deployment, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "Deployment", deployment.GetName(), "CREATE", err, d.metricsRecorder)
 	if err != nil {
 		return err
 	}
@@ -79,10 +86,17 @@ func (d *DeploymentService) CreateDeployment(namespace string, deployment *appsv
 	return err
 }
 
-// UpdateDeployment will update the given deployment
-func (d *DeploymentService) UpdateDeployment(namespace string, deployment *appsv1.Deployment) error {
-	_, err := d.kubeClient.AppsV1().Deployments(namespace).Update(context.TODO(), deployment, metav1.UpdateOptions{})
-	recordMetrics(namespace, "Deployment", deployment.GetName(), "UPDATE", err, d.metricsRecorder)
+// UpdateDeployment will update the given deployment, retrying over its latest version on a conflict
+func (d *DeploymentService) UpdateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error {
+	err := updateOnConflict(d.conflictRetries, namespace, "Deployment", deployment.GetName(), d.metricsRecorder, deployment, func() (*appsv1.Deployment, error) {
+		return d.GetDeployment(ctx, namespace, deployment.Name)
+	}, func(stored *appsv1.Deployment) {
+		setDesiredMeta(stored, deployment)
+		stored.Spec = deployment.Spec
+	}, func(deployment *appsv1.Deployment) error {
+		_, err := d.kubeClient.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{})
+		return recordMetrics(ctx, namespace, "Deployment", deployment.GetName(), "UPDATE", err, d.metricsRecorder)
+	})
 	if err != nil {
 		return err
 	}
@@ -91,35 +105,74 @@ func (d *DeploymentService) UpdateDeployment(namespace string, deployment *appsv
 }
 
 // CreateOrUpdateDeployment will update the given deployment or create it if does not exist
-func (d *DeploymentService) CreateOrUpdateDeployment(namespace string, deployment *appsv1.Deployment) error {
-	storedDeployment, err := d.GetDeployment(namespace, deployment.Name)
+func (d *DeploymentService) CreateOrUpdateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error {
+	storedDeployment, err := d.GetDeployment(ctx, namespace, deployment.Name)
 	if err != nil {
 		// If no resource we need to create.
 		if errors.IsNotFound(err) {
-			return d.CreateDeployment(namespace, deployment)
+			if _, err := setSpecHash(deployment, deployment.Spec); err != nil {
+				return err
+			}
+			return d.CreateDeployment(ctx, namespace, deployment)
 		}
 		return err
 	}
 
+	// Nothing is written while the desired deployment has the hash of the stored one.
+	storedHash := storedDeployment.Annotations[SpecHashAnnotation]
+	hash, err := setSpecHash(deployment, deployment.Spec)
+	if err != nil {
+		return err
+	}
+	if storedHash == hash {
+		return nil
+	}
+
 	// Already exists, need to Update.
 	// Set the correct resource version to ensure we are on the latest version. This way the only valid
 	// namespace is our spec(https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#concurrency-control-and-consistency),
 	// we will replace the current namespace state.
 	deployment.ResourceVersion = storedDeployment.ResourceVersion
-	return d.UpdateDeployment(namespace, deployment)
+	return d.UpdateDeployment(ctx, namespace, deployment)
+}
+
+// ApplyDeployment writes the deployment with a server-side apply of the operator field manager, creating
+// it when it does not exist.
+func (d *DeploymentService) ApplyDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) error {
+	if _, err := setSpecHash(deployment, deployment.Spec); err != nil {
+		return err
+	}
+	config := appsv1ac.Deployment(deployment.Name, namespace)
+	if err := toApplyConfiguration(deployment, config); err != nil {
+		return err
+	}
+	_, err := d.kubeClient.AppsV1().Deployments(namespace).Apply(ctx, config, applyOptions)
+	err = recordMetrics(ctx, namespace, "Deployment", deployment.Name, "APPLY", err, d.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	d.logger.WithField("namespace", namespace).WithField("deployment", deployment.Name).Debugf("deployment applied")
+	return nil
 }
 
 // DeleteDeployment will delete the given deployment
-func (d *DeploymentService) DeleteDeployment(namespace, name string) error {
+func (d *DeploymentService) DeleteDeployment(ctx context.Context, namespace, name string) error {
 	propagation := metav1.DeletePropagationForeground
-	err := d.kubeClient.AppsV1().Deployments(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{PropagationPolicy: &propagation})
-	recordMetrics(namespace, "Deployment", name, "DELETE", err, d.metricsRecorder)
+	err := d.kubeClient.AppsV1().Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	err = recordMetrics(ctx, namespace, "Deployment", name, "DELETE", err, d.metricsRecorder)
 	return err
 }
 
 // ListDeployments will give all the deployments on a given namespace
-func (d *DeploymentService) ListDeployments(namespace string) (*appsv1.DeploymentList, error) {
-	deployments, err := d.kubeClient.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
-	recordMetrics(namespace, "Deployment", metrics.NOT_APPLICABLE, "LIST", err, d.metricsRecorder)
+func (d *DeploymentService) ListDeployments(ctx context.Context, namespace string) (*appsv1.DeploymentList, error) {
+	deployments, err := d.kubeClient.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	err = recordMetrics(ctx, namespace, "Deployment", metrics.NOT_APPLICABLE, "LIST", err, d.metricsRecorder)
+	return deployments, err
+}
+
+// ListDeploymentsWithSelector will retrieve the deployments in the given namespace matching the label selector
+func (d *DeploymentService) ListDeploymentsWithSelector(ctx context.Context, namespace string, selector labels.Selector) (*appsv1.DeploymentList, error) {
+	deployments, err := d.kubeClient.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	err = recordMetrics(ctx, namespace, "Deployment", metrics.NOT_APPLICABLE, "LIST", err, d.metricsRecorder)
 	return deployments, err
 }
@@ -1,13 +1,17 @@
 package k8s_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	kubernetes "k8s.io/client-go/kubernetes/fake"
@@ -42,6 +46,9 @@ func TestDeploymentServiceGetCreateOrUpdate(t *testing.T) {
 		},
 	}
 
+	// The stored deployment was written before the hash of the desired one.
+	storedDeployment := testDeployment.DeepCopy()
+
 	testns := "testns"
 
 	tests := []struct {
@@ -80,7 +87,7 @@ func TestDeploymentServiceGetCreateOrUpdate(t *testing.T) {
 		{
 			name:                "An existent deployment should update the deployment.",
 			deployment:          testDeployment,
-			getDeploymentResult: testDeployment,
+			getDeploymentResult: storedDeployment,
 			errorOnGet:          nil,
 			errorOnCreation:     nil,
 			expActions: []kubetesting.Action{
@@ -104,8 +111,8 @@ func TestDeploymentServiceGetCreateOrUpdate(t *testing.T) {
 				return true, nil, test.errorOnCreation
 			})
 
-			service := k8s.NewDeploymentService(mcli, log.Dummy, metrics.Dummy)
-			err := service.CreateOrUpdateDeployment(testns, test.deployment)
+			service := k8s.NewDeploymentService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			err := service.CreateOrUpdateDeployment(context.TODO(), testns, test.deployment)
 
 			if test.expErr {
 				assert.Error(err)
@@ -117,3 +124,79 @@ func TestDeploymentServiceGetCreateOrUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestDeploymentServiceCreateOrUpdateSpecHash(t *testing.T) {
+	testns := "testns"
+	generate := func() *appsv1.Deployment {
+		d := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rfs-test", Namespace: testns}}
+		d.Spec.Template.Spec.Containers = []corev1.Container{{Name: "sentinel", Image: "redis:7.0"}}
+		return d
+	}
+
+	tests := []struct {
+		name      string
+		change    func(d *appsv1.Deployment)
+		expUpdate bool
+	}{
+		{
+			name:   "An unchanged deployment is not updated.",
+			change: func(d *appsv1.Deployment) {},
+		},
+		{
+			name:      "A changed image updates the deployment.",
+			change:    func(d *appsv1.Deployment) { d.Spec.Template.Spec.Containers[0].Image = "redis:7.2" },
+			expUpdate: true,
+		},
+		{
+			name: "Changed resources update the deployment.",
+			change: func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}
+			},
+			expUpdate: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mcli := kubernetes.NewSimpleClientset()
+			service := k8s.NewDeploymentService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			assert.NoError(service.CreateOrUpdateDeployment(context.TODO(), testns, generate()))
+
+			desired := generate()
+			test.change(desired)
+			assert.NoError(service.CreateOrUpdateDeployment(context.TODO(), testns, desired))
+			if !test.expUpdate {
+				assert.Equal(0, countUpdates(mcli))
+				return
+			}
+			assert.Equal(1, countUpdates(mcli))
+			updated, err := service.GetDeployment(context.TODO(), testns, "rfs-test")
+			assert.NoError(err)
+			assert.Equal(desired.Spec, updated.Spec)
+		})
+	}
+}
+
+func TestDeploymentServiceListWithSelector(t *testing.T) {
+	assert := assert.New(t)
+
+	selector := labels.SelectorFromSet(map[string]string{
+		"app.kubernetes.io/managed-by":                "redis-operator",
+		"redisfailovers.databases.spotahome.com/name": "test",
+	})
+
+	var gotSelector string
+	mcli := &kubernetes.Clientset{}
+	mcli.AddReactor("list", "deployments", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		gotSelector = action.(kubetesting.ListAction).GetListRestrictions().Labels.String()
+		return true, &appsv1.DeploymentList{}, nil
+	})
+
+	service := k8s.NewDeploymentService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	_, err := service.ListDeploymentsWithSelector(context.TODO(), "testns", selector)
+
+	assert.NoError(err)
+	assert.Equal(selector.String(), gotSelector)
+}
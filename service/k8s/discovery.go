@@ -0,0 +1,52 @@
+package k8s
+
+import (
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// crdDiscoveryInterval is how long the resource of a CRD is known as missing before it's discovered
+// again, so it's found when the CRD is installed after the operator.
+const crdDiscoveryInterval = 5 * time.Minute
+
+// crdDiscovery tells whether the cluster serves the resource of a CRD the operator doesn't depend on,
+// discovered on the first call. A served resource is never discovered again.
+type crdDiscovery struct {
+	kubeClient kubernetes.Interface
+	gvr        schema.GroupVersionResource
+
+	mu           sync.Mutex
+	installed    bool
+	discoveredAt time.Time
+}
+
+// served returns true when the cluster serves the resource. discovered tells whether it was
+// discovered by this call, so the missing resource is only reported once per discovery.
+func (d *crdDiscovery) served() (served bool, discovered bool, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.installed || (!d.discoveredAt.IsZero() && time.Since(d.discoveredAt) < crdDiscoveryInterval) {
+		return d.installed, false, nil
+	}
+
+	resources, err := d.kubeClient.Discovery().ServerResourcesForGroupVersion(d.gvr.GroupVersion().String())
+	if err != nil && !apierrors.IsNotFound(err) {
+		return false, false, err
+	}
+	installed := false
+	if err == nil {
+		for _, resource := range resources.APIResources {
+			if resource.Name == d.gvr.Resource {
+				installed = true
+				break
+			}
+		}
+	}
+	d.installed = installed
+	d.discoveredAt = time.Now()
+	return installed, true, nil
+}
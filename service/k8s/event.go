@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// Event the Event service that knows how to interact with k8s to manage them
+type Event interface {
+	CreateEvent(ctx context.Context, namespace string, event *corev1.Event) error
+	ListEvents(ctx context.Context, namespace string) (*corev1.EventList, error)
+}
+
+// EventService is the event service implementation using API calls to kubernetes.
+type EventService struct {
+	kubeClient      kubernetes.Interface
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+}
+
+// NewEventService returns a new Event KubeService.
+func NewEventService(kubeClient kubernetes.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *EventService {
+	logger = logger.With("service", "k8s.event")
+	return &EventService{
+		kubeClient:      kubeClient,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+	}
+}
+
+func (e *EventService) CreateEvent(ctx context.Context, namespace string, event *corev1.Event) error {
+	_, err := e.kubeClient.CoreV1().Events(namespace).Create(ctx, event, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "Event", event.GetName(), "CREATE", err, e.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	e.logger.WithField("namespace", namespace).WithField("event", event.Name).Debugf("event created")
+	return nil
+}
+
+func (e *EventService) ListEvents(ctx context.Context, namespace string) (*corev1.EventList, error) {
+	events, err := e.kubeClient.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	err = recordMetrics(ctx, namespace, "Event", metrics.NOT_APPLICABLE, "LIST", err, e.metricsRecorder)
+	return events, err
+}
@@ -0,0 +1,105 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// PodExec runs commands in the containers of the pods.
+type PodExec interface {
+	// ExecPod runs the command in the container and returns its output. The error is a
+	// k8s.io/client-go/util/exec.ExitError when the command ran and failed.
+	ExecPod(ctx context.Context, namespace, podName, container string, command []string) (string, error)
+	// ExecCommand runs the command in the container like ExecPod, and returns what it wrote on its
+	// standard output and on its standard error.
+	ExecCommand(ctx context.Context, namespace, podName, container string, command []string) (stdout, stderr string, err error)
+}
+
+// CommandExecutor streams the command of the exec request of a pod to its standard output and error.
+type CommandExecutor interface {
+	Execute(ctx context.Context, url *url.URL, stdout, stderr io.Writer) error
+}
+
+// spdyExecutor executes the commands over SPDY with the rest config of the cluster.
+type spdyExecutor struct {
+	config *rest.Config
+}
+
+func (s spdyExecutor) Execute(ctx context.Context, url *url.URL, stdout, stderr io.Writer) error {
+	executor, err := remotecommand.NewSPDYExecutor(s.config, "POST", url)
+	if err != nil {
+		return err
+	}
+	return executor.Stream(remotecommand.StreamOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
+
+// PodExecService is the pod exec service implementation using API calls to kubernetes.
+type PodExecService struct {
+	kubeClient      kubernetes.Interface
+	executor        CommandExecutor
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+}
+
+// NewPodExecService returns a new PodExec KubeService. The commands can't be run without the rest
+// config of the cluster, it can be nil for the commands that don't run them.
+func NewPodExecService(kubeClient kubernetes.Interface, config *rest.Config, logger log.Logger, metricsRecorder metrics.Recorder) *PodExecService {
+	var executor CommandExecutor
+	if config != nil {
+		executor = spdyExecutor{config: config}
+	}
+	return NewPodExecServiceWithExecutor(kubeClient, executor, logger, metricsRecorder)
+}
+
+// NewPodExecServiceWithExecutor returns a new PodExec KubeService running the commands with the
+// executor.
+func NewPodExecServiceWithExecutor(kubeClient kubernetes.Interface, executor CommandExecutor, logger log.Logger, metricsRecorder metrics.Recorder) *PodExecService {
+	logger = logger.With("service", "k8s.podexec")
+	return &PodExecService{
+		kubeClient:      kubeClient,
+		executor:        executor,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+	}
+}
+
+func (p *PodExecService) ExecPod(ctx context.Context, namespace, podName, container string, command []string) (string, error) {
+	stdout, _, err := p.ExecCommand(ctx, namespace, podName, container, command)
+	return stdout, err
+}
+
+func (p *PodExecService) ExecCommand(ctx context.Context, namespace, podName, container string, command []string) (string, string, error) {
+	if p.executor == nil {
+		return "", "", errors.New("the commands can't be run in the pods without the rest config of the cluster")
+	}
+	req := p.kubeClient.CoreV1().RESTClient().Post().
+		Namespace(namespace).
+		Resource("pods").
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	var stdout, stderr bytes.Buffer
+	err := p.executor.Execute(ctx, req.URL(), &stdout, &stderr)
+	err = recordMetrics(ctx, namespace, "Pod", podName, "EXEC", err, p.metricsRecorder)
+	return stdout.String(), stderr.String(), err
+}
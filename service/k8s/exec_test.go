@@ -0,0 +1,67 @@
+package k8s_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8sclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+// fakeExecutor records the exec request and writes its outputs.
+type fakeExecutor struct {
+	url    *url.URL
+	stdout string
+	stderr string
+	err    error
+}
+
+func (f *fakeExecutor) Execute(ctx context.Context, url *url.URL, stdout, stderr io.Writer) error {
+	f.url = url
+	fmt.Fprint(stdout, f.stdout)
+	fmt.Fprint(stderr, f.stderr)
+	return f.err
+}
+
+func TestPodExecServiceExecCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	kubeClient, err := k8sclient.NewForConfig(&rest.Config{Host: "https://kubernetes.test"})
+	require.NoError(t, err)
+	executor := &fakeExecutor{stdout: "10.0.0.1\n", stderr: "Warning: Using a password\n", err: errors.New("command terminated with exit code 1")}
+	service := k8s.NewPodExecServiceWithExecutor(kubeClient, executor, log.Dummy, metrics.Dummy)
+
+	stdout, stderr, err := service.ExecCommand(context.TODO(), "ns", "rfr-test-0", "redis", []string{"redis-cli", "ping"})
+	assert.Error(err)
+	assert.Equal("10.0.0.1\n", stdout)
+	assert.Equal("Warning: Using a password\n", stderr)
+
+	assert.Equal("/api/v1/namespaces/ns/pods/rfr-test-0/exec", executor.url.Path)
+	query := executor.url.Query()
+	assert.Equal("redis", query.Get("container"))
+	assert.Equal([]string{"redis-cli", "ping"}, query["command"])
+	assert.Equal("true", query.Get("stdout"))
+	assert.Equal("true", query.Get("stderr"))
+
+	// ExecPod returns the standard output only.
+	executor.err = nil
+	out, err := service.ExecPod(context.TODO(), "ns", "rfr-test-0", "redis", []string{"redis-cli", "ping"})
+	assert.NoError(err)
+	assert.Equal("10.0.0.1\n", out)
+}
+
+func TestPodExecServiceWithoutConfig(t *testing.T) {
+	service := k8s.NewPodExecService(nil, nil, log.Dummy, metrics.Dummy)
+	_, _, err := service.ExecCommand(context.TODO(), "ns", "rfr-test-0", "redis", []string{"redis-cli", "ping"})
+	assert.Error(t, err)
+}
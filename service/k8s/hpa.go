@@ -0,0 +1,114 @@
+package k8s
+
+import (
+	"context"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// HPA the HorizontalPodAutoscaler service that knows how to interact with k8s to manage them
+type HPA interface {
+	GetHPA(ctx context.Context, namespace string, name string) (*autoscalingv2.HorizontalPodAutoscaler, error)
+	CreateHPA(ctx context.Context, namespace string, hpa *autoscalingv2.HorizontalPodAutoscaler) error
+	UpdateHPA(ctx context.Context, namespace string, hpa *autoscalingv2.HorizontalPodAutoscaler) error
+	CreateOrUpdateHPA(ctx context.Context, namespace string, hpa *autoscalingv2.HorizontalPodAutoscaler) error
+	DeleteHPA(ctx context.Context, namespace string, name string) error
+}
+
+// HPAService is the HorizontalPodAutoscaler service implementation using API calls to kubernetes.
+type HPAService struct {
+	kubeClient      kubernetes.Interface
+	conflictRetries int
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+}
+
+// NewHPAService returns a new HPA KubeService.
+func NewHPAService(kubeClient kubernetes.Interface, conflictRetries int, logger log.Logger, metricsRecorder metrics.Recorder) *HPAService {
+	logger = logger.With("service", "k8s.hpa")
+	return &HPAService{
+		kubeClient:      kubeClient,
+		conflictRetries: conflictRetries,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+	}
+}
+
+func (h *HPAService) GetHPA(ctx context.Context, namespace string, name string) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpa, err := h.kubeClient.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "HorizontalPodAutoscaler", name, "GET", err, h.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return hpa, nil
+}
+
+func (h *HPAService) CreateHPA(ctx context.Context, namespace string, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	_, err := h.kubeClient.AutoscalingV2().HorizontalPodAutoscalers(namespace).Create(ctx, hpa, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "HorizontalPodAutoscaler", hpa.GetName(), "CREATE", err, h.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	h.logger.WithField("namespace", namespace).WithField("hpa", hpa.Name).Infof("hpa created")
+	return nil
+}
+
+func (h *HPAService) UpdateHPA(ctx context.Context, namespace string, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	err := updateOnConflict(h.conflictRetries, namespace, "HorizontalPodAutoscaler", hpa.GetName(), h.metricsRecorder, hpa, func() (*autoscalingv2.HorizontalPodAutoscaler, error) {
+		return h.GetHPA(ctx, namespace, hpa.Name)
+	}, func(stored *autoscalingv2.HorizontalPodAutoscaler) {
+		setDesiredMeta(stored, hpa)
+		stored.Spec = hpa.Spec
+	}, func(hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+		_, err := h.kubeClient.AutoscalingV2().HorizontalPodAutoscalers(namespace).Update(ctx, hpa, metav1.UpdateOptions{})
+		return recordMetrics(ctx, namespace, "HorizontalPodAutoscaler", hpa.GetName(), "UPDATE", err, h.metricsRecorder)
+	})
+	if err != nil {
+		return err
+	}
+	h.logger.WithField("namespace", namespace).WithField("hpa", hpa.Name).Infof("hpa updated")
+	return nil
+}
+
+func (h *HPAService) CreateOrUpdateHPA(ctx context.Context, namespace string, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	storedHPA, err := h.GetHPA(ctx, namespace, hpa.Name)
+	if err != nil {
+		// If no resource we need to create.
+		if errors.IsNotFound(err) {
+			if _, err := setSpecHash(hpa, hpa.Spec); err != nil {
+				return err
+			}
+			return h.CreateHPA(ctx, namespace, hpa)
+		}
+		return err
+	}
+
+	// Nothing is written while the desired hpa has the hash of the stored one.
+	storedHash := storedHPA.Annotations[SpecHashAnnotation]
+	hash, err := setSpecHash(hpa, hpa.Spec)
+	if err != nil {
+		return err
+	}
+	if storedHash == hash {
+		return nil
+	}
+
+	// Already exists, need to Update.
+	// Set the correct resource version to ensure we are on the latest version. This way the only valid
+	// namespace is our spec(https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#concurrency-control-and-consistency),
+	// we will replace the current namespace state.
+	hpa.ResourceVersion = storedHPA.ResourceVersion
+	return h.UpdateHPA(ctx, namespace, hpa)
+}
+
+func (h *HPAService) DeleteHPA(ctx context.Context, namespace string, name string) error {
+	err := h.kubeClient.AutoscalingV2().HorizontalPodAutoscalers(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err = recordMetrics(ctx, namespace, "HorizontalPodAutoscaler", name, "DELETE", err, h.metricsRecorder)
+	return err
+}
@@ -0,0 +1,210 @@
+package k8s_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+var (
+	hpasGroup = schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}
+)
+
+func newHPAUpdateAction(ns string, hpa *autoscalingv2.HorizontalPodAutoscaler) kubetesting.UpdateActionImpl {
+	return kubetesting.NewUpdateAction(hpasGroup, ns, hpa)
+}
+
+func newHPAGetAction(ns, name string) kubetesting.GetActionImpl {
+	return kubetesting.NewGetAction(hpasGroup, ns, name)
+}
+
+func newHPACreateAction(ns string, hpa *autoscalingv2.HorizontalPodAutoscaler) kubetesting.CreateActionImpl {
+	return kubetesting.NewCreateAction(hpasGroup, ns, hpa)
+}
+
+func newHPADeleteAction(ns, name string) kubetesting.DeleteActionImpl {
+	return kubetesting.NewDeleteAction(hpasGroup, ns, name)
+}
+
+func TestHPAServiceGetCreateOrUpdate(t *testing.T) {
+	testHPA := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "testhpa1",
+			ResourceVersion: "10",
+		},
+	}
+	storedHPA := testHPA.DeepCopy()
+
+	testns := "testns"
+
+	tests := []struct {
+		name            string
+		hpa             *autoscalingv2.HorizontalPodAutoscaler
+		getHPAResult    *autoscalingv2.HorizontalPodAutoscaler
+		errorOnGet      error
+		errorOnCreation error
+		expActions      []kubetesting.Action
+		expErr          bool
+	}{
+		{
+			name:            "A new hpa should create a new hpa.",
+			hpa:             testHPA,
+			getHPAResult:    nil,
+			errorOnGet:      kubeerrors.NewNotFound(schema.GroupResource{}, ""),
+			errorOnCreation: nil,
+			expActions: []kubetesting.Action{
+				newHPAGetAction(testns, testHPA.ObjectMeta.Name),
+				newHPACreateAction(testns, testHPA),
+			},
+			expErr: false,
+		},
+		{
+			name:            "A new hpa should error when create a new hpa fails.",
+			hpa:             testHPA,
+			getHPAResult:    nil,
+			errorOnGet:      kubeerrors.NewNotFound(schema.GroupResource{}, ""),
+			errorOnCreation: errors.New("wanted error"),
+			expActions: []kubetesting.Action{
+				newHPAGetAction(testns, testHPA.ObjectMeta.Name),
+				newHPACreateAction(testns, testHPA),
+			},
+			expErr: true,
+		},
+		{
+			name:            "An hpa that can't be read should error.",
+			hpa:             testHPA,
+			getHPAResult:    nil,
+			errorOnGet:      errors.New("wanted error"),
+			errorOnCreation: nil,
+			expActions: []kubetesting.Action{
+				newHPAGetAction(testns, testHPA.ObjectMeta.Name),
+			},
+			expErr: true,
+		},
+		{
+			name:            "An existent hpa should update the hpa.",
+			hpa:             testHPA,
+			getHPAResult:    storedHPA,
+			errorOnGet:      nil,
+			errorOnCreation: nil,
+			expActions: []kubetesting.Action{
+				newHPAGetAction(testns, testHPA.ObjectMeta.Name),
+				newHPAUpdateAction(testns, testHPA),
+			},
+			expErr: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mock.
+			mcli := kubernetes.NewSimpleClientset()
+			mcli.PrependReactor("get", "horizontalpodautoscalers", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, test.getHPAResult, test.errorOnGet
+			})
+			mcli.PrependReactor("create", "horizontalpodautoscalers", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, nil, test.errorOnCreation
+			})
+			mcli.PrependReactor("update", "horizontalpodautoscalers", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, nil, nil
+			})
+
+			service := k8s.NewHPAService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			err := service.CreateOrUpdateHPA(context.TODO(), testns, test.hpa)
+
+			// Check calls to kubernetes.
+			assert.Equal(test.expActions, mcli.Actions())
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestHPAServiceCreateOrUpdateSpecHash(t *testing.T) {
+	assert := assert.New(t)
+
+	testns := "testns"
+	desired := func() *autoscalingv2.HorizontalPodAutoscaler {
+		return &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfs-test", Namespace: testns},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "rfs-test"},
+				MaxReplicas:    5,
+			},
+		}
+	}
+	mcli := kubernetes.NewSimpleClientset()
+	service := k8s.NewHPAService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+	// The same hpa is not written again.
+	assert.NoError(service.CreateOrUpdateHPA(context.TODO(), testns, desired()))
+	assert.NoError(service.CreateOrUpdateHPA(context.TODO(), testns, desired()))
+	for _, action := range mcli.Actions() {
+		assert.NotEqual("update", action.GetVerb())
+	}
+
+	// A changed spec is.
+	changed := desired()
+	changed.Spec.MaxReplicas = 7
+	assert.NoError(service.CreateOrUpdateHPA(context.TODO(), testns, changed))
+	stored, err := service.GetHPA(context.TODO(), testns, "rfs-test")
+	assert.NoError(err)
+	assert.Equal(int32(7), stored.Spec.MaxReplicas)
+}
+
+func TestHPAServiceDelete(t *testing.T) {
+	testns := "testns"
+
+	tests := []struct {
+		name     string
+		stored   []runtime.Object
+		expFound bool
+	}{
+		{
+			name:     "An existent hpa should be deleted.",
+			stored:   []runtime.Object{&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "rfs-test", Namespace: testns}}},
+			expFound: true,
+		},
+		{
+			name:     "A missing hpa should return a not found error.",
+			expFound: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mcli := kubernetes.NewSimpleClientset(test.stored...)
+			service := k8s.NewHPAService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+			err := service.DeleteHPA(context.TODO(), testns, "rfs-test")
+			assert.Equal([]kubetesting.Action{newHPADeleteAction(testns, "rfs-test")}, mcli.Actions())
+			if test.expFound {
+				assert.NoError(err)
+			} else {
+				assert.True(kubeerrors.IsNotFound(err))
+			}
+			_, err = service.GetHPA(context.TODO(), testns, "rfs-test")
+			assert.True(kubeerrors.IsNotFound(err))
+		})
+	}
+}
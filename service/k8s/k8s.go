@@ -2,7 +2,9 @@ package k8s
 
 import (
 	apiextensionscli "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 
 	redisfailoverclientset "redis-operator/client/k8s/clientset/versioned"
 	"redis-operator/log"
@@ -14,37 +16,82 @@ type Services interface {
 	ConfigMap
 	Secret
 	Pod
+	PodExec
+	PersistentVolumeClaim
+	Node
+	PriorityClass
+	Namespace
 	PodDisruptionBudget
+	NetworkPolicy
+	HPA
 	RedisFailover
+	RedisCluster
 	Service
 	RBAC
 	Deployment
 	StatefulSet
+	CustomResourceDefinition
+	Event
+	VolumeSnapshot
+	Certificate
+	ServiceMonitor
 }
 
 type services struct {
 	ConfigMap
 	Secret
 	Pod
+	PodExec
+	PersistentVolumeClaim
+	Node
+	PriorityClass
+	Namespace
 	PodDisruptionBudget
+	NetworkPolicy
+	HPA
 	RedisFailover
+	RedisCluster
 	Service
 	RBAC
 	Deployment
 	StatefulSet
+	CustomResourceDefinition
+	Event
+	VolumeSnapshot
+	Certificate
+	ServiceMonitor
 }
 
 // New returns a new Kubernetes service.
-func New(kubecli kubernetes.Interface, crdcli redisfailoverclientset.Interface, apiextcli apiextensionscli.Interface, logger log.Logger, metricsRecorder metrics.Recorder) Services {
+// restConfig is the config the commands are run in the pods with, it can be nil when they are not run.
+// dynamiccli manages the CSI volume snapshots, the cert-manager certificates and the prometheus-operator service monitors,
+// it can be nil when they are not managed.
+// crdWrites are the clients the redisfailovers are written with, it can be nil to write them with crdcli
+// without logging the apiserver warnings.
+// conflictRetries is the number of times an update is retried after a conflict with a newer version of the object.
+func New(kubecli kubernetes.Interface, restConfig *rest.Config, dynamiccli dynamic.Interface, crdcli redisfailoverclientset.Interface, crdWrites *RedisFailoverWriteClients, apiextcli apiextensionscli.Interface, conflictRetries int, logger log.Logger, metricsRecorder metrics.Recorder) Services {
 	return &services{
-		ConfigMap:           NewConfigMapService(kubecli, logger, metricsRecorder),
-		Secret:              NewSecretService(kubecli, logger, metricsRecorder),
-		Pod:                 NewPodService(kubecli, logger, metricsRecorder),
-		PodDisruptionBudget: NewPodDisruptionBudgetService(kubecli, logger, metricsRecorder),
-		RedisFailover:       NewRedisFailoverService(crdcli, logger, metricsRecorder),
-		Service:             NewServiceService(kubecli, logger, metricsRecorder),
-		RBAC:                NewRBACService(kubecli, logger, metricsRecorder),
-		Deployment:          NewDeploymentService(kubecli, logger, metricsRecorder),
-		StatefulSet:         NewStatefulSetService(kubecli, logger, metricsRecorder),
+		ConfigMap:                NewConfigMapService(kubecli, conflictRetries, logger, metricsRecorder),
+		Secret:                   NewSecretService(kubecli, logger, metricsRecorder),
+		Pod:                      NewPodService(kubecli, logger, metricsRecorder),
+		PodExec:                  NewPodExecService(kubecli, restConfig, logger, metricsRecorder),
+		PersistentVolumeClaim:    NewPersistentVolumeClaimService(kubecli, logger, metricsRecorder),
+		Node:                     NewNodeService(kubecli, logger, metricsRecorder),
+		PriorityClass:            NewPriorityClassService(kubecli, logger, metricsRecorder),
+		Namespace:                NewNamespaceService(kubecli, logger, metricsRecorder),
+		PodDisruptionBudget:      NewPodDisruptionBudgetService(kubecli, conflictRetries, logger, metricsRecorder),
+		NetworkPolicy:            NewNetworkPolicyService(kubecli, conflictRetries, logger, metricsRecorder),
+		HPA:                      NewHPAService(kubecli, conflictRetries, logger, metricsRecorder),
+		RedisFailover:            NewRedisFailoverService(crdcli, crdWrites, logger, metricsRecorder),
+		RedisCluster:             NewRedisClusterService(crdcli, logger, metricsRecorder),
+		Service:                  NewServiceService(kubecli, conflictRetries, logger, metricsRecorder),
+		RBAC:                     NewRBACService(kubecli, logger, metricsRecorder),
+		Deployment:               NewDeploymentService(kubecli, conflictRetries, logger, metricsRecorder),
+		StatefulSet:              NewStatefulSetService(kubecli, conflictRetries, logger, metricsRecorder),
+		CustomResourceDefinition: NewCustomResourceDefinitionService(apiextcli, logger, metricsRecorder),
+		Event:                    NewEventService(kubecli, logger, metricsRecorder),
+		VolumeSnapshot:           NewVolumeSnapshotService(dynamiccli, logger, metricsRecorder),
+		Certificate:              NewCertificateService(kubecli, dynamiccli, conflictRetries, logger, metricsRecorder),
+		ServiceMonitor:           NewServiceMonitorService(kubecli, dynamiccli, conflictRetries, logger, metricsRecorder),
 	}
 }
@@ -0,0 +1,68 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// Namespace the Namespace service that knows how to interact with k8s to read them
+type Namespace interface {
+	GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error)
+	// IsNamespaceTerminating returns true when the namespace is being deleted.
+	IsNamespaceTerminating(ctx context.Context, name string) (bool, error)
+}
+
+// NamespaceService is the namespace service implementation using API calls to kubernetes.
+type NamespaceService struct {
+	kubeClient      kubernetes.Interface
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+}
+
+// NewNamespaceService returns a new Namespace KubeService.
+func NewNamespaceService(kubeClient kubernetes.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *NamespaceService {
+	logger = logger.With("service", "k8s.namespace")
+	return &NamespaceService{
+		kubeClient:      kubeClient,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+	}
+}
+
+// GetNamespace will retrieve the requested namespace
+func (n *NamespaceService) GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	namespace, err := n.kubeClient.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, metrics.NOT_APPLICABLE, "Namespace", name, "GET", err, n.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return namespace, nil
+}
+
+// IsNamespaceTerminating returns true when the namespace is in the Terminating phase, or already
+// deleted.
+func (n *NamespaceService) IsNamespaceTerminating(ctx context.Context, name string) (bool, error) {
+	namespace, err := n.GetNamespace(ctx, name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return namespace.Status.Phase == corev1.NamespaceTerminating, nil
+}
+
+// IsNamespaceTerminatingError returns true when the error, or the error it wraps, is the refusal of the
+// apiserver to create an object in a namespace being deleted.
+func IsNamespaceTerminatingError(err error) bool {
+	var statusErr *apierrors.StatusError
+	return errors.As(err, &statusErr) && apierrors.HasStatusCause(statusErr, corev1.NamespaceTerminatingCause)
+}
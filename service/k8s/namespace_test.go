@@ -0,0 +1,70 @@
+package k8s_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+func TestNamespaceServiceIsNamespaceTerminating(t *testing.T) {
+	tests := []struct {
+		name           string
+		namespaces     []corev1.Namespace
+		expTerminating bool
+	}{
+		{
+			name:       "An active namespace",
+			namespaces: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "test"}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceActive}}},
+		},
+		{
+			name:           "A namespace being deleted",
+			namespaces:     []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "test"}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating}}},
+			expTerminating: true,
+		},
+		{
+			name:           "A deleted namespace",
+			expTerminating: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			cli := kubernetes.NewSimpleClientset()
+			for i := range test.namespaces {
+				assert.NoError(cli.Tracker().Add(&test.namespaces[i]))
+			}
+
+			service := k8s.NewNamespaceService(cli, log.Dummy, metrics.Dummy)
+			terminating, err := service.IsNamespaceTerminating(context.TODO(), "test")
+
+			assert.NoError(err)
+			assert.Equal(test.expTerminating, terminating)
+		})
+	}
+}
+
+func TestIsNamespaceTerminatingError(t *testing.T) {
+	assert := assert.New(t)
+
+	terminating := kubeerrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "rfr-test", errors.New("unable to create new content in namespace test because it is being terminated"))
+	terminating.ErrStatus.Details.Causes = []metav1.StatusCause{{Type: corev1.NamespaceTerminatingCause}}
+
+	assert.True(k8s.IsNamespaceTerminatingError(terminating))
+	assert.True(k8s.IsNamespaceTerminatingError(fmt.Errorf("creating the ConfigMap rfr-test: %w", terminating)))
+	assert.False(k8s.IsNamespaceTerminatingError(kubeerrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "rfr-test", errors.New("denied"))))
+	assert.False(k8s.IsNamespaceTerminatingError(nil))
+}
@@ -0,0 +1,121 @@
+package k8s
+
+import (
+	"context"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// NetworkPolicy the NetworkPolicy service that knows how to interact with k8s to manage them
+type NetworkPolicy interface {
+	GetNetworkPolicy(ctx context.Context, namespace string, name string) (*networkingv1.NetworkPolicy, error)
+	CreateNetworkPolicy(ctx context.Context, namespace string, networkPolicy *networkingv1.NetworkPolicy) error
+	UpdateNetworkPolicy(ctx context.Context, namespace string, networkPolicy *networkingv1.NetworkPolicy) error
+	CreateOrUpdateNetworkPolicy(ctx context.Context, namespace string, networkPolicy *networkingv1.NetworkPolicy) error
+	DeleteNetworkPolicy(ctx context.Context, namespace string, name string) error
+	ListNetworkPolicies(ctx context.Context, namespace string) (*networkingv1.NetworkPolicyList, error)
+}
+
+// NetworkPolicyService is the networkPolicy service implementation using API calls to kubernetes.
+type NetworkPolicyService struct {
+	kubeClient      kubernetes.Interface
+	conflictRetries int
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+}
+
+// NewNetworkPolicyService returns a new NetworkPolicy KubeService.
+func NewNetworkPolicyService(kubeClient kubernetes.Interface, conflictRetries int, logger log.Logger, metricsRecorder metrics.Recorder) *NetworkPolicyService {
+	logger = logger.With("service", "k8s.networkPolicy")
+	return &NetworkPolicyService{
+		kubeClient:      kubeClient,
+		conflictRetries: conflictRetries,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+	}
+}
+
+func (n *NetworkPolicyService) GetNetworkPolicy(ctx context.Context, namespace string, name string) (*networkingv1.NetworkPolicy, error) {
+	networkPolicy, err := n.kubeClient.NetworkingV1().NetworkPolicies(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "NetworkPolicy", name, "GET", err, n.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return networkPolicy, nil
+}
+
+func (n *NetworkPolicyService) CreateNetworkPolicy(ctx context.Context, namespace string, networkPolicy *networkingv1.NetworkPolicy) error {
+	_, err := n.kubeClient.NetworkingV1().NetworkPolicies(namespace).Create(ctx, networkPolicy, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "NetworkPolicy", networkPolicy.GetName(), "CREATE", err, n.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	n.logger.WithField("namespace", namespace).WithField("networkPolicy", networkPolicy.Name).Infof("networkPolicy created")
+	return nil
+}
+
+func (n *NetworkPolicyService) UpdateNetworkPolicy(ctx context.Context, namespace string, networkPolicy *networkingv1.NetworkPolicy) error {
+	err := updateOnConflict(n.conflictRetries, namespace, "NetworkPolicy", networkPolicy.GetName(), n.metricsRecorder, networkPolicy, func() (*networkingv1.NetworkPolicy, error) {
+		return n.GetNetworkPolicy(ctx, namespace, networkPolicy.Name)
+	}, func(stored *networkingv1.NetworkPolicy) {
+		setDesiredMeta(stored, networkPolicy)
+		stored.Spec = networkPolicy.Spec
+	}, func(networkPolicy *networkingv1.NetworkPolicy) error {
+		_, err := n.kubeClient.NetworkingV1().NetworkPolicies(namespace).Update(ctx, networkPolicy, metav1.UpdateOptions{})
+		return recordMetrics(ctx, namespace, "NetworkPolicy", networkPolicy.GetName(), "UPDATE", err, n.metricsRecorder)
+	})
+	if err != nil {
+		return err
+	}
+	n.logger.WithField("namespace", namespace).WithField("networkPolicy", networkPolicy.Name).Infof("networkPolicy updated")
+	return nil
+}
+
+func (n *NetworkPolicyService) CreateOrUpdateNetworkPolicy(ctx context.Context, namespace string, networkPolicy *networkingv1.NetworkPolicy) error {
+	storedNetworkPolicy, err := n.GetNetworkPolicy(ctx, namespace, networkPolicy.Name)
+	if err != nil {
+		// If no resource we need to create.
+		if errors.IsNotFound(err) {
+			if _, err := setSpecHash(networkPolicy, networkPolicy.Spec); err != nil {
+				return err
+			}
+			return n.CreateNetworkPolicy(ctx, namespace, networkPolicy)
+		}
+		return err
+	}
+
+	// Nothing is written while the desired networkPolicy has the hash of the stored one.
+	storedHash := storedNetworkPolicy.Annotations[SpecHashAnnotation]
+	hash, err := setSpecHash(networkPolicy, networkPolicy.Spec)
+	if err != nil {
+		return err
+	}
+	if storedHash == hash {
+		return nil
+	}
+
+	// Already exists, need to Update.
+	// Set the correct resource version to ensure we are on the latest version. This way the only valid
+	// namespace is our spec(https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#concurrency-control-and-consistency),
+	// we will replace the current namespace state.
+	networkPolicy.ResourceVersion = storedNetworkPolicy.ResourceVersion
+	return n.UpdateNetworkPolicy(ctx, namespace, networkPolicy)
+}
+
+func (n *NetworkPolicyService) DeleteNetworkPolicy(ctx context.Context, namespace string, name string) error {
+	err := n.kubeClient.NetworkingV1().NetworkPolicies(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err = recordMetrics(ctx, namespace, "NetworkPolicy", name, "DELETE", err, n.metricsRecorder)
+	return err
+}
+
+func (n *NetworkPolicyService) ListNetworkPolicies(ctx context.Context, namespace string) (*networkingv1.NetworkPolicyList, error) {
+	networkPolicies, err := n.kubeClient.NetworkingV1().NetworkPolicies(namespace).List(ctx, metav1.ListOptions{})
+	err = recordMetrics(ctx, namespace, "NetworkPolicy", metrics.NOT_APPLICABLE, "LIST", err, n.metricsRecorder)
+	return networkPolicies, err
+}
@@ -0,0 +1,194 @@
+package k8s_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	networkingv1 "k8s.io/api/networking/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+var (
+	networkPoliciesGroup = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}
+)
+
+func newNetworkPolicyUpdateAction(ns string, networkPolicy *networkingv1.NetworkPolicy) kubetesting.UpdateActionImpl {
+	return kubetesting.NewUpdateAction(networkPoliciesGroup, ns, networkPolicy)
+}
+
+func newNetworkPolicyGetAction(ns, name string) kubetesting.GetActionImpl {
+	return kubetesting.NewGetAction(networkPoliciesGroup, ns, name)
+}
+
+func newNetworkPolicyCreateAction(ns string, networkPolicy *networkingv1.NetworkPolicy) kubetesting.CreateActionImpl {
+	return kubetesting.NewCreateAction(networkPoliciesGroup, ns, networkPolicy)
+}
+
+func newNetworkPolicyDeleteAction(ns, name string) kubetesting.DeleteActionImpl {
+	return kubetesting.NewDeleteAction(networkPoliciesGroup, ns, name)
+}
+
+func TestNetworkPolicyServiceGetCreateOrUpdate(t *testing.T) {
+	testNetworkPolicy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "testnetworkPolicy1",
+			ResourceVersion: "10",
+		},
+	}
+	storedNetworkPolicy := testNetworkPolicy.DeepCopy()
+
+	testns := "testns"
+
+	tests := []struct {
+		name                   string
+		networkPolicy          *networkingv1.NetworkPolicy
+		getNetworkPolicyResult *networkingv1.NetworkPolicy
+		errorOnGet             error
+		errorOnCreation        error
+		expActions             []kubetesting.Action
+		expErr                 bool
+	}{
+		{
+			name:                   "A new networkPolicy should create a new networkPolicy.",
+			networkPolicy:          testNetworkPolicy,
+			getNetworkPolicyResult: nil,
+			errorOnGet:             kubeerrors.NewNotFound(schema.GroupResource{}, ""),
+			errorOnCreation:        nil,
+			expActions: []kubetesting.Action{
+				newNetworkPolicyGetAction(testns, testNetworkPolicy.ObjectMeta.Name),
+				newNetworkPolicyCreateAction(testns, testNetworkPolicy),
+			},
+			expErr: false,
+		},
+		{
+			name:                   "A new networkPolicy should error when create a new networkPolicy fails.",
+			networkPolicy:          testNetworkPolicy,
+			getNetworkPolicyResult: nil,
+			errorOnGet:             kubeerrors.NewNotFound(schema.GroupResource{}, ""),
+			errorOnCreation:        errors.New("wanted error"),
+			expActions: []kubetesting.Action{
+				newNetworkPolicyGetAction(testns, testNetworkPolicy.ObjectMeta.Name),
+				newNetworkPolicyCreateAction(testns, testNetworkPolicy),
+			},
+			expErr: true,
+		},
+		{
+			name:                   "An existent networkPolicy should update the networkPolicy.",
+			networkPolicy:          testNetworkPolicy,
+			getNetworkPolicyResult: storedNetworkPolicy,
+			errorOnGet:             nil,
+			errorOnCreation:        nil,
+			expActions: []kubetesting.Action{
+				newNetworkPolicyGetAction(testns, testNetworkPolicy.ObjectMeta.Name),
+				newNetworkPolicyUpdateAction(testns, testNetworkPolicy),
+			},
+			expErr: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mock.
+			mcli := kubernetes.NewSimpleClientset()
+			mcli.PrependReactor("get", "networkpolicies", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, test.getNetworkPolicyResult, test.errorOnGet
+			})
+			mcli.PrependReactor("create", "networkpolicies", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, nil, test.errorOnCreation
+			})
+			mcli.PrependReactor("update", "networkpolicies", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, nil, nil
+			})
+
+			service := k8s.NewNetworkPolicyService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			err := service.CreateOrUpdateNetworkPolicy(context.TODO(), testns, test.networkPolicy)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+				// Check calls to kubernetes.
+				assert.Equal(test.expActions, mcli.Actions())
+			}
+		})
+	}
+}
+
+func TestNetworkPolicyServiceCreateOrUpdateSpecHash(t *testing.T) {
+	assert := assert.New(t)
+
+	testns := "testns"
+	desired := func() *networkingv1.NetworkPolicy {
+		return &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "redis"}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		}
+	}
+	mcli := kubernetes.NewSimpleClientset()
+	service := k8s.NewNetworkPolicyService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+	// The same networkPolicy is not written again.
+	assert.NoError(service.CreateOrUpdateNetworkPolicy(context.TODO(), testns, desired()))
+	assert.NoError(service.CreateOrUpdateNetworkPolicy(context.TODO(), testns, desired()))
+	for _, action := range mcli.Actions() {
+		assert.NotEqual("update", action.GetVerb())
+	}
+
+	// A changed spec is.
+	changed := desired()
+	changed.Spec.PodSelector.MatchLabels["tier"] = "cache"
+	assert.NoError(service.CreateOrUpdateNetworkPolicy(context.TODO(), testns, changed))
+	stored, err := service.GetNetworkPolicy(context.TODO(), testns, "rfr-test")
+	assert.NoError(err)
+	assert.Equal("cache", stored.Spec.PodSelector.MatchLabels["tier"])
+}
+
+func TestNetworkPolicyServiceDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	testns := "testns"
+	stored := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns}}
+	mcli := kubernetes.NewSimpleClientset(stored)
+	service := k8s.NewNetworkPolicyService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+	assert.NoError(service.DeleteNetworkPolicy(context.TODO(), testns, "rfr-test"))
+	assert.Equal([]kubetesting.Action{newNetworkPolicyDeleteAction(testns, "rfr-test")}, mcli.Actions())
+	_, err := service.GetNetworkPolicy(context.TODO(), testns, "rfr-test")
+	assert.True(kubeerrors.IsNotFound(err))
+}
+
+func TestNetworkPolicyServiceList(t *testing.T) {
+	assert := assert.New(t)
+
+	testns := "testns"
+	mcli := kubernetes.NewSimpleClientset(
+		&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns}},
+		&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "rfs-test", Namespace: testns}},
+		&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "rfr-other", Namespace: "otherns"}},
+	)
+	service := k8s.NewNetworkPolicyService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+	networkPolicies, err := service.ListNetworkPolicies(context.TODO(), testns)
+	assert.NoError(err)
+	names := []string{}
+	for _, networkPolicy := range networkPolicies.Items {
+		names = append(names, networkPolicy.Name)
+	}
+	assert.ElementsMatch([]string{"rfr-test", "rfs-test"}, names)
+}
@@ -0,0 +1,53 @@
+package k8s
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// Node the Node service that knows how to interact with k8s to read them
+type Node interface {
+	GetNode(ctx context.Context, name string) (*corev1.Node, error)
+	// GetNodeLabels returns the labels of the node, see WithNodeLabelCache.
+	GetNodeLabels(ctx context.Context, name string) (map[string]string, error)
+}
+
+// NodeService is the node service implementation using API calls to kubernetes.
+type NodeService struct {
+	kubeClient      kubernetes.Interface
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+}
+
+// NewNodeService returns a new Node KubeService.
+func NewNodeService(kubeClient kubernetes.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *NodeService {
+	logger = logger.With("service", "k8s.node")
+	return &NodeService{
+		kubeClient:      kubeClient,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+	}
+}
+
+func (n *NodeService) GetNode(ctx context.Context, name string) (*corev1.Node, error) {
+	node, err := n.kubeClient.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, metrics.NOT_APPLICABLE, "Node", name, "GET", err, n.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (n *NodeService) GetNodeLabels(ctx context.Context, name string) (map[string]string, error) {
+	node, err := n.GetNode(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return node.Labels, nil
+}
@@ -0,0 +1,154 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NodeLabelCache holds the labels of the nodes the operator read, the status reports the failure domains
+// of the pods from them on every check. The nodes are watched to invalidate the labels of a node when
+// they change or the node is deleted, then they are read again from the apiserver on the next call.
+type NodeLabelCache struct {
+	factory informers.SharedInformerFactory
+	nodes   cache.SharedIndexInformer
+
+	mu     sync.Mutex
+	labels map[string]map[string]string
+	// invalidations counts the invalidations of every node, the labels read while the node was
+	// invalidated are not cached.
+	invalidations map[string]uint64
+}
+
+// NewNodeLabelCache returns a cache of the node labels invalidated by the node watch, resynced every
+// resync period.
+func NewNodeLabelCache(kubeClient kubernetes.Interface, resync time.Duration) (*NodeLabelCache, error) {
+	factory := informers.NewSharedInformerFactory(kubeClient, resync)
+	c := &NodeLabelCache{
+		factory: factory,
+		nodes:   factory.Core().V1().Nodes().Informer(),
+		labels:  map[string]map[string]string{},
+
+		invalidations: map[string]uint64{},
+	}
+	// Only the names and the labels of the watched nodes are kept, they are only used to invalidate.
+	if err := c.nodes.SetTransform(keepNodeLabels); err != nil {
+		return nil, err
+	}
+	c.nodes.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNode, ok := oldObj.(*corev1.Node)
+			if !ok {
+				return
+			}
+			newNode, ok := newObj.(*corev1.Node)
+			if !ok {
+				return
+			}
+			if !equality.Semantic.DeepEqual(oldNode.Labels, newNode.Labels) {
+				c.Invalidate(newNode.Name)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if node, ok := obj.(*corev1.Node); ok {
+				c.Invalidate(node.Name)
+			}
+		},
+	})
+	return c, nil
+}
+
+// Start starts the node watch and waits for it to be synced.
+func (c *NodeLabelCache) Start(stopC <-chan struct{}) error {
+	c.factory.Start(stopC)
+	for informer, synced := range c.factory.WaitForCacheSync(stopC) {
+		if !synced {
+			return fmt.Errorf("could not watch the %s", informer)
+		}
+	}
+	return nil
+}
+
+// Invalidate forgets the labels of the node, they are read again on the next call.
+func (c *NodeLabelCache) Invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.labels, name)
+	c.invalidations[name]++
+}
+
+// get returns the cached labels of the node, or the invalidations of the node to set them with when
+// they are not cached.
+func (c *NodeLabelCache) get(name string) (map[string]string, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	labels, ok := c.labels[name]
+	return labels, c.invalidations[name], ok
+}
+
+// set caches the labels of the node, unless it was invalidated since they were read.
+func (c *NodeLabelCache) set(name string, labels map[string]string, invalidations uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.invalidations[name] == invalidations {
+		c.labels[name] = labels
+	}
+}
+
+// keepNodeLabels drops all but the name and the labels of the watched nodes.
+func keepNodeLabels(obj interface{}) (interface{}, error) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return obj, nil
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            node.Name,
+			Labels:          node.Labels,
+			ResourceVersion: node.ResourceVersion,
+		},
+	}, nil
+}
+
+// nodeLabelServices reads the node labels from the cache, and from the apiserver the ones not cached yet.
+type nodeLabelServices struct {
+	Services
+	cache *NodeLabelCache
+}
+
+// WithNodeLabelCache returns the services reading the node labels from the cache.
+func WithNodeLabelCache(s Services, nodeLabelCache *NodeLabelCache) Services {
+	return &nodeLabelServices{Services: s, cache: nodeLabelCache}
+}
+
+func (s *nodeLabelServices) GetNodeLabels(ctx context.Context, name string) (map[string]string, error) {
+	labels, invalidations, ok := s.cache.get(name)
+	if ok {
+		return copyLabels(labels), nil
+	}
+	labels, err := s.Services.GetNodeLabels(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.set(name, copyLabels(labels), invalidations)
+	return labels, nil
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
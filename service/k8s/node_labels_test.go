@@ -0,0 +1,98 @@
+package k8s_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+func newNodeLabelServices(t *testing.T, nodes ...*corev1.Node) (*kubernetes.Clientset, k8s.Services) {
+	cli := kubernetes.NewSimpleClientset()
+	for _, node := range nodes {
+		_, err := cli.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	s := k8s.New(cli, nil, nil, nil, nil, nil, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	nodeLabels, err := k8s.NewNodeLabelCache(cli, 0)
+	require.NoError(t, err)
+	stopC := make(chan struct{})
+	t.Cleanup(func() { close(stopC) })
+	require.NoError(t, nodeLabels.Start(stopC))
+	return cli, k8s.WithNodeLabelCache(s, nodeLabels)
+}
+
+// countNodeGets returns the nodes read from the apiserver, the watch list is not counted.
+func countNodeGets(cli *kubernetes.Clientset) int {
+	gets := 0
+	for _, action := range cli.Actions() {
+		if action.GetVerb() == "get" && action.GetResource().Resource == "nodes" {
+			gets++
+		}
+	}
+	return gets
+}
+
+func TestNodeLabelCache(t *testing.T) {
+	assert := assert.New(t)
+
+	cli, s := newNodeLabelServices(t,
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{corev1.LabelTopologyZone: "a"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}},
+	)
+	cli.ClearActions()
+
+	for i := 0; i < 3; i++ {
+		labels, err := s.GetNodeLabels(context.TODO(), "node-a")
+		assert.NoError(err)
+		assert.Equal("a", labels[corev1.LabelTopologyZone])
+		labels, err = s.GetNodeLabels(context.TODO(), "node-b")
+		assert.NoError(err)
+		assert.Empty(labels)
+	}
+	assert.Equal(2, countNodeGets(cli), "the node labels must be read once")
+
+	// The missing nodes are not cached.
+	_, err := s.GetNodeLabels(context.TODO(), "node-missing")
+	assert.Error(err)
+	_, err = s.GetNodeLabels(context.TODO(), "node-missing")
+	assert.Error(err)
+	assert.Equal(4, countNodeGets(cli))
+}
+
+func TestNodeLabelCacheInvalidatedOnNodeEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{corev1.LabelTopologyZone: "a"}}}
+	cli, s := newNodeLabelServices(t, node)
+
+	labels, err := s.GetNodeLabels(context.TODO(), "node-a")
+	assert.NoError(err)
+	assert.Equal("a", labels[corev1.LabelTopologyZone])
+
+	// A relabeled node is read again.
+	node = node.DeepCopy()
+	node.Labels[corev1.LabelTopologyZone] = "b"
+	_, err = cli.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+	assert.NoError(err)
+	assert.Eventually(func() bool {
+		labels, err := s.GetNodeLabels(context.TODO(), "node-a")
+		return err == nil && labels[corev1.LabelTopologyZone] == "b"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A deleted node is not read from the cache.
+	assert.NoError(cli.CoreV1().Nodes().Delete(context.TODO(), "node-a", metav1.DeleteOptions{}))
+	assert.Eventually(func() bool {
+		_, err := s.GetNodeLabels(context.TODO(), "node-a")
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,109 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// PersistentVolumeClaim the PersistentVolumeClaim service that knows how to interact with k8s to manage them
+type PersistentVolumeClaim interface {
+	GetPVC(ctx context.Context, namespace, name string) (*corev1.PersistentVolumeClaim, error)
+	ListPVCs(ctx context.Context, namespace string, selector labels.Selector) (*corev1.PersistentVolumeClaimList, error)
+	UpdatePVC(ctx context.Context, namespace string, pvc *corev1.PersistentVolumeClaim) error
+	DeletePVC(ctx context.Context, namespace, name string) error
+	// PatchPVCSize sets the storage requested by the claim, its storage class resizes the volume when it
+	// allows the expansion.
+	PatchPVCSize(ctx context.Context, namespace, name string, size resource.Quantity) error
+	// GetStorageClass returns the storage class of the claims, it tells whether their volumes can be expanded.
+	GetStorageClass(ctx context.Context, name string) (*storagev1.StorageClass, error)
+}
+
+// PersistentVolumeClaimService is the PersistentVolumeClaim service implementation using API calls to kubernetes.
+type PersistentVolumeClaimService struct {
+	kubeClient      kubernetes.Interface
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+}
+
+// NewPersistentVolumeClaimService returns a new PersistentVolumeClaim KubeService.
+func NewPersistentVolumeClaimService(kubeClient kubernetes.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *PersistentVolumeClaimService {
+	logger = logger.With("service", "k8s.persistentVolumeClaim")
+	return &PersistentVolumeClaimService{
+		kubeClient:      kubeClient,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+	}
+}
+
+func (p *PersistentVolumeClaimService) GetPVC(ctx context.Context, namespace, name string) (*corev1.PersistentVolumeClaim, error) {
+	pvc, err := p.kubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "PersistentVolumeClaim", name, "GET", err, p.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return pvc, nil
+}
+
+func (p *PersistentVolumeClaimService) ListPVCs(ctx context.Context, namespace string, selector labels.Selector) (*corev1.PersistentVolumeClaimList, error) {
+	pvcs, err := p.kubeClient.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	err = recordMetrics(ctx, namespace, "PersistentVolumeClaim", metrics.NOT_APPLICABLE, "LIST", err, p.metricsRecorder)
+	return pvcs, err
+}
+
+func (p *PersistentVolumeClaimService) UpdatePVC(ctx context.Context, namespace string, pvc *corev1.PersistentVolumeClaim) error {
+	_, err := p.kubeClient.CoreV1().PersistentVolumeClaims(namespace).Update(ctx, pvc, metav1.UpdateOptions{})
+	err = recordMetrics(ctx, namespace, "PersistentVolumeClaim", pvc.Name, "UPDATE", err, p.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	p.logger.WithField("namespace", namespace).WithField("persistentVolumeClaim", pvc.Name).Infof("persistentVolumeClaim updated")
+	return nil
+}
+
+func (p *PersistentVolumeClaimService) DeletePVC(ctx context.Context, namespace, name string) error {
+	err := p.kubeClient.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	return recordMetrics(ctx, namespace, "PersistentVolumeClaim", name, "DELETE", err, p.metricsRecorder)
+}
+
+// PatchPVCSize patches the storage requested by the claim, leaving the rest of its spec as it is
+func (p *PersistentVolumeClaimService) PatchPVCSize(ctx context.Context, namespace, name string, size resource.Quantity) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{
+					string(corev1.ResourceStorage): size.String(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = p.kubeClient.CoreV1().PersistentVolumeClaims(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+	err = recordMetrics(ctx, namespace, "PersistentVolumeClaim", name, "PATCH", err, p.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	p.logger.WithField("namespace", namespace).WithField("persistentVolumeClaim", name).Infof("persistentVolumeClaim resized to %s", size.String())
+	return nil
+}
+
+func (p *PersistentVolumeClaimService) GetStorageClass(ctx context.Context, name string) (*storagev1.StorageClass, error) {
+	storageClass, err := p.kubeClient.StorageV1().StorageClasses().Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, metrics.NOT_APPLICABLE, "StorageClass", name, "GET", err, p.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return storageClass, nil
+}
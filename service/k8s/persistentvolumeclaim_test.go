@@ -0,0 +1,97 @@
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+func newPVC(name, size string, lbls map[string]string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "testns", Labels: lbls},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			},
+		},
+	}
+}
+
+func TestPersistentVolumeClaimServicePatchSize(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	mcli := kubernetes.NewSimpleClientset(newPVC("data-rfr-test-0", "1Gi", nil))
+	service := k8s.NewPersistentVolumeClaimService(mcli, log.Dummy, metrics.Dummy)
+
+	err := service.PatchPVCSize(context.TODO(), "testns", "data-rfr-test-0", resource.MustParse("2Gi"))
+	require.NoError(err)
+
+	pvc, err := service.GetPVC(context.TODO(), "testns", "data-rfr-test-0")
+	require.NoError(err)
+	size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal("2Gi", size.String())
+	assert.Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, pvc.Spec.AccessModes)
+
+	err = service.PatchPVCSize(context.TODO(), "testns", "data-rfr-test-1", resource.MustParse("2Gi"))
+	assert.True(kubeerrors.IsNotFound(err))
+}
+
+func TestPersistentVolumeClaimServiceList(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	redis := map[string]string{"app.kubernetes.io/name": "test", "app.kubernetes.io/component": "redis"}
+	mcli := kubernetes.NewSimpleClientset(
+		newPVC("data-rfr-test-0", "1Gi", redis),
+		newPVC("data-rfr-test-1", "1Gi", redis),
+		newPVC("other", "1Gi", map[string]string{"app.kubernetes.io/name": "other"}),
+	)
+	service := k8s.NewPersistentVolumeClaimService(mcli, log.Dummy, metrics.Dummy)
+
+	pvcs, err := service.ListPVCs(context.TODO(), "testns", labels.SelectorFromSet(redis))
+	require.NoError(err)
+	names := []string{}
+	for _, pvc := range pvcs.Items {
+		names = append(names, pvc.Name)
+	}
+	assert.ElementsMatch([]string{"data-rfr-test-0", "data-rfr-test-1"}, names)
+
+	require.NoError(service.DeletePVC(context.TODO(), "testns", "data-rfr-test-1"))
+	pvcs, err = service.ListPVCs(context.TODO(), "testns", labels.SelectorFromSet(redis))
+	require.NoError(err)
+	assert.Len(pvcs.Items, 1)
+}
+
+func TestPersistentVolumeClaimServiceGetStorageClass(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	expand := true
+	mcli := kubernetes.NewSimpleClientset(&storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: "ssd"},
+		AllowVolumeExpansion: &expand,
+	})
+	service := k8s.NewPersistentVolumeClaimService(mcli, log.Dummy, metrics.Dummy)
+
+	storageClass, err := service.GetStorageClass(context.TODO(), "ssd")
+	require.NoError(err)
+	assert.True(*storageClass.AllowVolumeExpansion)
+
+	_, err = service.GetStorageClass(context.TODO(), "hdd")
+	assert.True(kubeerrors.IsNotFound(err))
+}
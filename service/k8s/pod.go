@@ -3,10 +3,14 @@ package k8s
 import (
 	"context"
 	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"time"
 
 	"k8s.io/apimachinery/pkg/types"
 
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -17,15 +21,24 @@ import (
 
 // Pod the ServiceAccount service that knows how to interact with k8s to manage them
 type Pod interface {
-	GetPod(namespace string, name string) (*corev1.Pod, error)
-	CreatePod(namespace string, pod *corev1.Pod) error
-	UpdatePod(namespace string, pod *corev1.Pod) error
-	CreateOrUpdatePod(namespace string, pod *corev1.Pod) error
-	DeletePod(namespace string, name string) error
-	ListPods(namespace string) (*corev1.PodList, error)
-	UpdatePodLabels(namespace, podName string, labels map[string]string) error
+	GetPod(ctx context.Context, namespace string, name string) (*corev1.Pod, error)
+	CreatePod(ctx context.Context, namespace string, pod *corev1.Pod) error
+	UpdatePod(ctx context.Context, namespace string, pod *corev1.Pod) error
+	CreateOrUpdatePod(ctx context.Context, namespace string, pod *corev1.Pod) error
+	DeletePod(ctx context.Context, namespace string, name string) error
+	EvictPod(ctx context.Context, namespace string, name string) error
+	ListPods(ctx context.Context, namespace string) (*corev1.PodList, error)
+	UpdatePodLabels(ctx context.Context, namespace, podName string, labels map[string]string) error
+	GetPodLogs(ctx context.Context, namespace, podName, container string, tailLines int64) (string, error)
+	GetPreviousPodLogs(ctx context.Context, namespace, podName, container string, tailLines int64) (string, error)
+	GetPodStartupLogs(ctx context.Context, namespace, podName, container string, limitBytes int64) (string, error)
+	ResizePod(ctx context.Context, namespace, podName, container string, resources corev1.ResourceRequirements) error
+	WaitForPodReady(ctx context.Context, namespace, name string, interval, timeout time.Duration) error
 }
 
+// ErrPodNotReady is the error of WaitForPodReady when the pod is not ready before the timeout.
+var ErrPodNotReady = goerrors.New("the pod is not ready")
+
 // PodService is the pod service implementation using API calls to kubernetes.
 type PodService struct {
 	kubeClient      kubernetes.Interface
@@ -43,39 +56,39 @@ func NewPodService(kubeClient kubernetes.Interface, logger log.Logger, metricsRe
 	}
 }
 
-func (p *PodService) GetPod(namespace string, name string) (*corev1.Pod, error) {
-	pod, err := p.kubeClient.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
-	recordMetrics(namespace, "Pod", name, "GET", err, p.metricsRecorder)
+func (p *PodService) GetPod(ctx context.Context, namespace string, name string) (*corev1.Pod, error) {
+	pod, err := p.kubeClient.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "Pod", name, "GET", err, p.metricsRecorder)
 	if err != nil {
 		return nil, err
 	}
 	return pod, err
 }
 
-func (p *PodService) CreatePod(namespace string, pod *corev1.Pod) error {
-	_, err := p.kubeClient.CoreV1().Pods(namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
-	recordMetrics(namespace, "Pod", pod.GetName(), "CREATE", err, p.metricsRecorder)
+func (p *PodService) CreatePod(ctx context.Context, namespace string, pod *corev1.Pod) error {
+	_, err := p.kubeClient.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "Pod", pod.GetName(), "CREATE", err, p.metricsRecorder)
 	if err != nil {
 		return err
 	}
 	p.logger.WithField("namespace", namespace).WithField("pod", pod.Name).Infof("pod created")
 	return nil
 }
-func (p *PodService) UpdatePod(namespace string, pod *corev1.Pod) error {
-	_, err := p.kubeClient.CoreV1().Pods(namespace).Update(context.TODO(), pod, metav1.UpdateOptions{})
-	recordMetrics(namespace, "Pod", pod.GetName(), "UPDATE", err, p.metricsRecorder)
+func (p *PodService) UpdatePod(ctx context.Context, namespace string, pod *corev1.Pod) error {
+	_, err := p.kubeClient.CoreV1().Pods(namespace).Update(ctx, pod, metav1.UpdateOptions{})
+	err = recordMetrics(ctx, namespace, "Pod", pod.GetName(), "UPDATE", err, p.metricsRecorder)
 	if err != nil {
 		return err
 	}
 	p.logger.WithField("namespace", namespace).WithField("pod", pod.Name).Infof("pod updated")
 	return nil
 }
-func (p *PodService) CreateOrUpdatePod(namespace string, pod *corev1.Pod) error {
-	storedPod, err := p.GetPod(namespace, pod.Name)
+func (p *PodService) CreateOrUpdatePod(ctx context.Context, namespace string, pod *corev1.Pod) error {
+	storedPod, err := p.GetPod(ctx, namespace, pod.Name)
 	if err != nil {
 		// If no resource we need to create.
 		if errors.IsNotFound(err) {
-			return p.CreatePod(namespace, pod)
+			return p.CreatePod(ctx, namespace, pod)
 		}
 		return err
 	}
@@ -85,18 +98,38 @@ func (p *PodService) CreateOrUpdatePod(namespace string, pod *corev1.Pod) error
 	// namespace is our spec(https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#concurrency-control-and-consistency),
 	// we will replace the current namespace state.
 	pod.ResourceVersion = storedPod.ResourceVersion
-	return p.UpdatePod(namespace, pod)
+	return p.UpdatePod(ctx, namespace, pod)
 }
 
-func (p *PodService) DeletePod(namespace string, name string) error {
-	err := p.kubeClient.CoreV1().Pods(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
-	recordMetrics(namespace, "Pod", name, "DELETE", err, p.metricsRecorder)
+func (p *PodService) DeletePod(ctx context.Context, namespace string, name string) error {
+	err := p.kubeClient.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err = recordMetrics(ctx, namespace, "Pod", name, "DELETE", err, p.metricsRecorder)
+	return err
+}
+
+// EvictPod evicts the pod, the eviction is refused while it would break its pdb, see IsEvictionBlocked.
+func (p *PodService) EvictPod(ctx context.Context, namespace string, name string) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	err := p.kubeClient.CoreV1().Pods(namespace).EvictV1(ctx, eviction)
+	err = recordMetrics(ctx, namespace, "Pod", name, "EVICT", err, p.metricsRecorder)
 	return err
 }
 
-func (p *PodService) ListPods(namespace string) (*corev1.PodList, error) {
-	pods, err := p.kubeClient.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
-	recordMetrics(namespace, "Pod", metrics.NOT_APPLICABLE, "LIST", err, p.metricsRecorder)
+// IsEvictionBlocked tells the error is an eviction refused because it would violate the
+// PodDisruptionBudget of the pod, a 429 telling to try again later rather than the apiserver being
+// overloaded.
+func IsEvictionBlocked(err error) bool {
+	return errors.IsTooManyRequests(err) && errors.HasStatusCause(err, policyv1.DisruptionBudgetCause)
+}
+
+func (p *PodService) ListPods(ctx context.Context, namespace string) (*corev1.PodList, error) {
+	pods, err := p.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	err = recordMetrics(ctx, namespace, "Pod", metrics.NOT_APPLICABLE, "LIST", err, p.metricsRecorder)
 	return pods, err
 }
 
@@ -107,7 +140,7 @@ type PatchStringValue struct {
 	Value interface{} `json:"value"`
 }
 
-func (p *PodService) UpdatePodLabels(namespace, podName string, labels map[string]string) error {
+func (p *PodService) UpdatePodLabels(ctx context.Context, namespace, podName string, labels map[string]string) error {
 	p.logger.Infof("Update pod label, namespace: %s, pod name: %s, labels: %v", namespace, podName, labels)
 
 	var payloads []interface{}
@@ -121,10 +154,100 @@ func (p *PodService) UpdatePodLabels(namespace, podName string, labels map[strin
 	}
 	payloadBytes, _ := json.Marshal(payloads)
 
-	_, err := p.kubeClient.CoreV1().Pods(namespace).Patch(context.TODO(), podName, types.JSONPatchType, payloadBytes, metav1.PatchOptions{})
-	recordMetrics(namespace, "Pod", podName, "PATCH", err, p.metricsRecorder)
+	_, err := p.kubeClient.CoreV1().Pods(namespace).Patch(ctx, podName, types.JSONPatchType, payloadBytes, metav1.PatchOptions{})
+	err = recordMetrics(ctx, namespace, "Pod", podName, "PATCH", err, p.metricsRecorder)
 	if err != nil {
 		p.logger.Errorf("Update pod labels failed, namespace: %s, pod name: %s, error: %v", namespace, podName, err)
 	}
 	return err
 }
+
+// GetPodLogs returns the last lines of the log of a pod container.
+func (p *PodService) GetPodLogs(ctx context.Context, namespace, podName, container string, tailLines int64) (string, error) {
+	return p.getPodLogs(ctx, namespace, podName, &corev1.PodLogOptions{Container: container, TailLines: &tailLines})
+}
+
+// GetPreviousPodLogs returns the last lines of the log of the previous run of a pod container, the one
+// before its last restart.
+func (p *PodService) GetPreviousPodLogs(ctx context.Context, namespace, podName, container string, tailLines int64) (string, error) {
+	return p.getPodLogs(ctx, namespace, podName, &corev1.PodLogOptions{Container: container, Previous: true, TailLines: &tailLines})
+}
+
+// GetPodStartupLogs returns the first bytes of the log of a pod container, written when it started.
+func (p *PodService) GetPodStartupLogs(ctx context.Context, namespace, podName, container string, limitBytes int64) (string, error) {
+	return p.getPodLogs(ctx, namespace, podName, &corev1.PodLogOptions{Container: container, LimitBytes: &limitBytes})
+}
+
+// getPodLogs returns the logs of a pod container, as selected by the options.
+func (p *PodService) getPodLogs(ctx context.Context, namespace, podName string, opts *corev1.PodLogOptions) (string, error) {
+	logs, err := p.kubeClient.CoreV1().Pods(namespace).GetLogs(podName, opts).DoRaw(ctx)
+	err = recordMetrics(ctx, namespace, "Pod", podName, "GET_LOGS", err, p.metricsRecorder)
+	if err != nil {
+		return "", err
+	}
+	return string(logs), nil
+}
+
+// ResizePod sets the resources of a container of the pod in place, through the resize subresource of the
+// pods. The clusters not resizing the pods in place refuse it.
+func (p *PodService) ResizePod(ctx context.Context, namespace, podName, container string, resources corev1.ResourceRequirements) error {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":      container,
+					"resources": resources,
+				},
+			},
+		},
+	}
+	payload, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = p.kubeClient.CoreV1().Pods(namespace).Patch(ctx, podName, types.StrategicMergePatchType, payload, metav1.PatchOptions{}, "resize")
+	err = recordMetrics(ctx, namespace, "Pod", podName, "RESIZE", err, p.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	p.logger.WithField("namespace", namespace).WithField("pod", podName).Infof("pod container %s resized", container)
+	return nil
+}
+
+// WaitForPodReady polls the pod every interval until its Ready condition is true, ErrPodNotReady once the
+// timeout elapsed. A missing pod is waited for, it may be recreated by its controller. The context
+// cancelled before the timeout ends the wait with its error.
+func (p *PodService) WaitForPodReady(ctx context.Context, namespace, name string, interval, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pod, err := p.GetPod(ctx, namespace, name)
+		switch {
+		case err == nil && IsPodReady(pod):
+			return nil
+		case err != nil && !errors.IsNotFound(err) && ctx.Err() == nil:
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("%w: %s/%s after %s", ErrPodNotReady, namespace, name, timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsPodReady tells the Ready condition of the pod is true.
+func IsPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
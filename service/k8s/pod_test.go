@@ -1,16 +1,24 @@
 package k8s_test
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sclient "k8s.io/client-go/kubernetes"
 	kubernetes "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 	kubetesting "k8s.io/client-go/testing"
 
 	"redis-operator/log"
@@ -105,7 +113,7 @@ func TestPodServiceGetCreateOrUpdate(t *testing.T) {
 			})
 
 			service := k8s.NewPodService(mcli, log.Dummy, metrics.Dummy)
-			err := service.CreateOrUpdatePod(testns, test.pod)
+			err := service.CreateOrUpdatePod(context.TODO(), testns, test.pod)
 
 			if test.expErr {
 				assert.Error(err)
@@ -117,3 +125,264 @@ func TestPodServiceGetCreateOrUpdate(t *testing.T) {
 		})
 	}
 }
+
+// k8sOperationRecorder records the k8s operations and the updates retried after a conflict.
+type k8sOperationRecorder struct {
+	metrics.Recorder
+	operations      []string
+	statuses        []string
+	errs            []string
+	conflictRetries int
+}
+
+func (r *k8sOperationRecorder) RecordK8sOperation(namespace string, kind string, object string, operation string, status string, err string) {
+	r.operations = append(r.operations, operation)
+	r.statuses = append(r.statuses, status)
+	r.errs = append(r.errs, err)
+}
+
+func (r *k8sOperationRecorder) RecordK8sConflictRetry(namespace string, kind string, object string) {
+	r.conflictRetries++
+}
+
+func TestPodServiceCancelledContext(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mcli := kubernetes.NewSimpleClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0", Namespace: "testns"}})
+	recorder := &k8sOperationRecorder{Recorder: metrics.Dummy}
+	service := k8s.NewPodService(mcli, log.Dummy, recorder)
+
+	// A call done before the context was cancelled is a success.
+	_, err := service.GetPod(ctx, "testns", "rfr-test-0")
+	assert.NoError(err)
+
+	// A call failing on the cancelled context returns its error.
+	mcli.PrependReactor("get", "pods", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("client rate limiter Wait returned an error: context canceled")
+	})
+	_, err = service.GetPod(ctx, "testns", "rfr-test-0")
+	assert.ErrorIs(err, context.Canceled)
+	assert.Equal([]string{metrics.SUCCESS, metrics.FAIL}, recorder.statuses)
+	assert.Equal([]string{metrics.NOT_APPLICABLE, metrics.K8S_CONTEXT_DONE}, recorder.errs)
+}
+
+func TestPodServiceEvictPod(t *testing.T) {
+	blocked := kubeerrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	blocked.ErrStatus.Details.Causes = []metav1.StatusCause{{
+		Type:    policyv1.DisruptionBudgetCause,
+		Message: "The disruption budget rfr-test needs 2 healthy pods and has 2 currently",
+	}}
+
+	tests := []struct {
+		name        string
+		evictErr    error
+		expBlocked  bool
+		expErr      string
+		expThrottle bool
+	}{
+		{
+			name: "The pod is evicted",
+		},
+		{
+			name:       "The eviction is refused by the PodDisruptionBudget",
+			evictErr:   blocked,
+			expBlocked: true,
+			expErr:     metrics.K8S_EVICTION_BLOCKED,
+		},
+		{
+			name:        "A 429 of an overloaded apiserver doesn't block the eviction",
+			evictErr:    kubeerrors.NewTooManyRequests("the server has received too many requests", 1),
+			expErr:      metrics.K8S_TOO_MANY_REQUESTS,
+			expThrottle: true,
+		},
+	}
+
+	defaultPressure := k8s.DefaultAPIServerPressure
+	defer func() { k8s.DefaultAPIServerPressure = defaultPressure }()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			k8s.DefaultAPIServerPressure = k8s.NewAPIServerPressure(time.Now)
+			mcli := &kubernetes.Clientset{}
+			mcli.AddReactor("create", "pods", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return action.GetSubresource() == "eviction", nil, test.evictErr
+			})
+			recorder := &k8sOperationRecorder{Recorder: metrics.Dummy}
+			service := k8s.NewPodService(mcli, log.Dummy, recorder)
+
+			err := service.EvictPod(context.TODO(), "testns", "rfr-test-1")
+			if test.evictErr != nil {
+				assert.Error(err)
+				assert.Equal([]string{test.expErr}, recorder.errs)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expBlocked, k8s.IsEvictionBlocked(err))
+			// The operator doesn't back off from the apiserver for a pod protected by its pdb.
+			throttledAt, _ := k8s.DefaultAPIServerPressure.Throttled()
+			assert.Equal(test.expThrottle, !throttledAt.IsZero())
+
+			// The pod is evicted through the eviction subresource, not deleted.
+			actions := mcli.Actions()
+			if assert.Len(actions, 1) {
+				create, ok := actions[0].(kubetesting.CreateActionImpl)
+				if assert.True(ok) {
+					assert.Equal("testns", create.GetNamespace())
+					assert.Equal("eviction", create.GetSubresource())
+					assert.Equal(&policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-1", Namespace: "testns"}}, create.GetObject())
+				}
+			}
+			assert.Equal([]string{"EVICT"}, recorder.operations)
+		})
+	}
+}
+
+func TestPodServiceGetPodLogs(t *testing.T) {
+	assert := assert.New(t)
+
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/testns/pods/rfr-test-0/log" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		w.Write([]byte("1:M 01 Jan 2024 00:00:00.000 # Fatal error loading the DB: Invalid argument. Exiting.\n"))
+	}))
+	defer server.Close()
+
+	kubeClient, err := k8sclient.NewForConfig(&rest.Config{Host: server.URL})
+	if !assert.NoError(err) {
+		return
+	}
+	recorder := &k8sOperationRecorder{Recorder: metrics.Dummy}
+	service := k8s.NewPodService(kubeClient, log.Dummy, recorder)
+
+	logs, err := service.GetPreviousPodLogs(context.TODO(), "testns", "rfr-test-0", "redis", 20)
+	assert.NoError(err)
+	assert.Equal("1:M 01 Jan 2024 00:00:00.000 # Fatal error loading the DB: Invalid argument. Exiting.\n", logs)
+	assert.Equal("redis", query.Get("container"))
+	assert.Equal("true", query.Get("previous"))
+	assert.Equal("20", query.Get("tailLines"))
+	assert.Equal([]string{"GET_LOGS"}, recorder.operations)
+
+	_, err = service.GetPodLogs(context.TODO(), "testns", "rfr-test-0", "redis", 5)
+	assert.NoError(err)
+	assert.Equal("", query.Get("previous"))
+	assert.Equal("5", query.Get("tailLines"))
+
+	_, err = service.GetPodStartupLogs(context.TODO(), "testns", "rfr-test-0", "redis", 1024)
+	assert.NoError(err)
+	assert.Equal("1024", query.Get("limitBytes"))
+	assert.Equal("", query.Get("tailLines"))
+
+	_, err = service.GetPodLogs(context.TODO(), "testns", "rfr-test-1", "redis", 5)
+	assert.True(kubeerrors.IsNotFound(err))
+}
+
+func TestPodServiceWaitForPodReady(t *testing.T) {
+	forbidden := kubeerrors.NewForbidden(podsGroup.GroupResource(), "rfr-test-0", errors.New("forbidden"))
+	readyPod := func(ready corev1.ConditionStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfr-test-0", Namespace: "testns"},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		readyAfter int
+		notFound   int
+		getErr     error
+		timeout    time.Duration
+		cancel     bool
+		expGets    int
+		expErr     error
+	}{
+		{
+			name:       "The pod is ready on the first poll",
+			readyAfter: 1,
+			timeout:    time.Minute,
+			expGets:    1,
+		},
+		{
+			name:       "The pod becomes ready before the timeout",
+			readyAfter: 3,
+			timeout:    time.Minute,
+			expGets:    3,
+		},
+		{
+			name:       "The pod recreated by its controller is waited for",
+			notFound:   2,
+			readyAfter: 3,
+			timeout:    time.Minute,
+			expGets:    3,
+		},
+		{
+			name:    "The pod never becomes ready",
+			timeout: 50 * time.Millisecond,
+			expErr:  k8s.ErrPodNotReady,
+		},
+		{
+			name:    "The context ends the wait before the timeout",
+			timeout: time.Hour,
+			cancel:  true,
+			expErr:  context.Canceled,
+		},
+		{
+			name:    "An error of the apiserver ends the wait",
+			getErr:  forbidden,
+			timeout: time.Minute,
+			expGets: 1,
+			expErr:  forbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			gets := 0
+			mcli := &kubernetes.Clientset{}
+			mcli.AddReactor("get", "pods", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				gets++
+				if test.cancel && gets == 2 {
+					cancel()
+				}
+				switch {
+				case test.getErr != nil:
+					return true, nil, test.getErr
+				case gets <= test.notFound:
+					return true, nil, kubeerrors.NewNotFound(podsGroup.GroupResource(), "rfr-test-0")
+				case test.readyAfter > 0 && gets >= test.readyAfter:
+					return true, readyPod(corev1.ConditionTrue), nil
+				}
+				return true, readyPod(corev1.ConditionFalse), nil
+			})
+			service := k8s.NewPodService(mcli, log.Dummy, metrics.Dummy)
+
+			err := service.WaitForPodReady(ctx, "testns", "rfr-test-0", time.Millisecond, test.timeout)
+			if test.expErr != nil {
+				assert.ErrorIs(err, test.expErr)
+			} else {
+				assert.NoError(err)
+			}
+			if test.expGets > 0 {
+				assert.Equal(test.expGets, gets)
+			} else {
+				assert.Greater(gets, 1)
+			}
+		})
+	}
+}
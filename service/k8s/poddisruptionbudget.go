@@ -2,54 +2,204 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
+	goerrors "errors"
+	"strconv"
+	"strings"
+	"sync"
 
 	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 
 	"redis-operator/log"
 	"redis-operator/metrics"
 )
 
+// The API versions of the PodDisruptionBudgets, policy/v1beta1 is only used on the clusters that don't
+// serve policy/v1.
+const (
+	PodDisruptionBudgetV1      = "policy/v1"
+	PodDisruptionBudgetV1beta1 = "policy/v1beta1"
+	// podDisruptionBudgetNone is recorded when the cluster serves no PodDisruptionBudget API.
+	podDisruptionBudgetNone = "none"
+)
+
+// UnhealthyPodEvictionPolicyAnnotation carries the unhealthyPodEvictionPolicy of a desired
+// PodDisruptionBudget. The field is patched on the clusters supporting it, kubernetes 1.27 and later,
+// and the annotation is dropped on the others.
+const UnhealthyPodEvictionPolicyAnnotation = "databases.spotahome.com/unhealthy-pod-eviction-policy"
+
+// ErrPodDisruptionBudgetsUnavailable is returned when the cluster serves no PodDisruptionBudget API.
+var ErrPodDisruptionBudgetsUnavailable = goerrors.New("the cluster serves neither the policy/v1 nor the policy/v1beta1 PodDisruptionBudgets")
+
 // PodDisruptionBudget the ServiceAccount service that knows how to interact with k8s to manage them
 type PodDisruptionBudget interface {
-	GetPodDisruptionBudget(namespace string, name string) (*policyv1.PodDisruptionBudget, error)
-	CreatePodDisruptionBudget(namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error
-	UpdatePodDisruptionBudget(namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error
-	CreateOrUpdatePodDisruptionBudget(namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error
-	DeletePodDisruptionBudget(namespace string, name string) error
+	GetPodDisruptionBudget(ctx context.Context, namespace string, name string) (*policyv1.PodDisruptionBudget, error)
+	CreatePodDisruptionBudget(ctx context.Context, namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error
+	UpdatePodDisruptionBudget(ctx context.Context, namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error
+	CreateOrUpdatePodDisruptionBudget(ctx context.Context, namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error
+	DeletePodDisruptionBudget(ctx context.Context, namespace string, name string) error
 }
 
 // PodDisruptionBudgetService is the podDisruptionBudget service implementation using API calls to kubernetes.
 type PodDisruptionBudgetService struct {
 	kubeClient      kubernetes.Interface
+	conflictRetries int
 	logger          log.Logger
 	metricsRecorder metrics.Recorder
+
+	// apiVersion is the API version served by the cluster, discovered on the first call.
+	mu         sync.Mutex
+	apiVersion string
+	// evictionPolicySupported tells whether the cluster supports the unhealthyPodEvictionPolicy,
+	// discovered on the first PodDisruptionBudget asking for it.
+	evictionPolicySupported *bool
 }
 
 // NewPodDisruptionBudgetService returns a new PodDisruptionBudget KubeService.
-func NewPodDisruptionBudgetService(kubeClient kubernetes.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *PodDisruptionBudgetService {
+func NewPodDisruptionBudgetService(kubeClient kubernetes.Interface, conflictRetries int, logger log.Logger, metricsRecorder metrics.Recorder) *PodDisruptionBudgetService {
 	logger = logger.With("service", "k8s.podDisruptionBudget")
 	return &PodDisruptionBudgetService{
 		kubeClient:      kubeClient,
+		conflictRetries: conflictRetries,
 		logger:          logger,
 		metricsRecorder: metricsRecorder,
 	}
 }
 
-func (p *PodDisruptionBudgetService) GetPodDisruptionBudget(namespace string, name string) (*policyv1.PodDisruptionBudget, error) {
-	podDisruptionBudget, err := p.kubeClient.PolicyV1().PodDisruptionBudgets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
-	recordMetrics(namespace, "PodDisruptionBudget", name, "GET", err, p.metricsRecorder)
+// APIVersion returns the API version of the PodDisruptionBudgets served by the cluster, discovered on
+// the first call. It's empty when the cluster serves none. The discovery is retried on the next call
+// when it fails.
+func (p *PodDisruptionBudgetService) APIVersion() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.apiVersion != "" {
+		if p.apiVersion == podDisruptionBudgetNone {
+			return "", nil
+		}
+		return p.apiVersion, nil
+	}
+
+	version := podDisruptionBudgetNone
+	for _, groupVersion := range []string{PodDisruptionBudgetV1, PodDisruptionBudgetV1beta1} {
+		served, err := p.servesPodDisruptionBudgets(groupVersion)
+		if err != nil {
+			return "", err
+		}
+		if served {
+			version = groupVersion
+			break
+		}
+	}
+	p.apiVersion = version
+	p.metricsRecorder.SetPodDisruptionBudgetAPIVersion(version)
+	if version == podDisruptionBudgetNone {
+		p.logger.Warnf("the cluster serves no PodDisruptionBudget API, the PodDisruptionBudgets are not written")
+		return "", nil
+	}
+	p.logger.Infof("writing the PodDisruptionBudgets with %s", version)
+	return version, nil
+}
+
+// SupportsUnhealthyPodEvictionPolicy returns true when the cluster supports the unhealthyPodEvictionPolicy
+// of the PodDisruptionBudgets, it needs policy/v1 and kubernetes 1.27 or later. It's discovered on the
+// first call, and retried on the next call when it fails.
+func (p *PodDisruptionBudgetService) SupportsUnhealthyPodEvictionPolicy() (bool, error) {
+	apiVersion, err := p.APIVersion()
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.evictionPolicySupported != nil {
+		return *p.evictionPolicySupported, nil
+	}
+	supported := false
+	if apiVersion == PodDisruptionBudgetV1 {
+		info, err := p.kubeClient.Discovery().ServerVersion()
+		if err != nil {
+			return false, err
+		}
+		major, majorErr := strconv.Atoi(strings.TrimSuffix(info.Major, "+"))
+		minor, minorErr := strconv.Atoi(strings.TrimSuffix(info.Minor, "+"))
+		supported = majorErr == nil && minorErr == nil && (major > 1 || major == 1 && minor >= 27)
+	}
+	p.evictionPolicySupported = &supported
+	if !supported {
+		p.logger.Warnf("the cluster does not support the unhealthyPodEvictionPolicy of the PodDisruptionBudgets, it's not set")
+	}
+	return supported, nil
+}
+
+// servesPodDisruptionBudgets returns true when the cluster serves the PodDisruptionBudgets in the
+// group version.
+func (p *PodDisruptionBudgetService) servesPodDisruptionBudgets(groupVersion string) (bool, error) {
+	resources, err := p.kubeClient.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == "poddisruptionbudgets" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// servedAPIVersion returns the API version of the PodDisruptionBudgets served by the cluster, or
+// ErrPodDisruptionBudgetsUnavailable.
+func (p *PodDisruptionBudgetService) servedAPIVersion() (string, error) {
+	version, err := p.APIVersion()
+	if err != nil {
+		return "", err
+	}
+	if version == "" {
+		return "", ErrPodDisruptionBudgetsUnavailable
+	}
+	return version, nil
+}
+
+func (p *PodDisruptionBudgetService) GetPodDisruptionBudget(ctx context.Context, namespace string, name string) (*policyv1.PodDisruptionBudget, error) {
+	version, err := p.servedAPIVersion()
+	if err != nil {
+		return nil, err
+	}
+	var podDisruptionBudget *policyv1.PodDisruptionBudget
+	if version == PodDisruptionBudgetV1beta1 {
+		var v1beta1 *policyv1beta1.PodDisruptionBudget
+		v1beta1, err = p.kubeClient.PolicyV1beta1().PodDisruptionBudgets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			podDisruptionBudget = fromV1beta1PodDisruptionBudget(v1beta1)
+		}
+	} else {
+		podDisruptionBudget, err = p.kubeClient.PolicyV1().PodDisruptionBudgets(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	err = recordMetrics(ctx, namespace, "PodDisruptionBudget", name, "GET", err, p.metricsRecorder)
 	if err != nil {
 		return nil, err
 	}
 	return podDisruptionBudget, nil
 }
 
-func (p *PodDisruptionBudgetService) CreatePodDisruptionBudget(namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
-	_, err := p.kubeClient.PolicyV1().PodDisruptionBudgets(namespace).Create(context.TODO(), podDisruptionBudget, metav1.CreateOptions{})
-	recordMetrics(namespace, "PodDisruptionBudget", podDisruptionBudget.GetName(), "CREATE", err, p.metricsRecorder)
+func (p *PodDisruptionBudgetService) CreatePodDisruptionBudget(ctx context.Context, namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
+	version, err := p.servedAPIVersion()
+	if err != nil {
+		return err
+	}
+	if version == PodDisruptionBudgetV1beta1 {
+		_, err = p.kubeClient.PolicyV1beta1().PodDisruptionBudgets(namespace).Create(ctx, toV1beta1PodDisruptionBudget(podDisruptionBudget), metav1.CreateOptions{})
+	} else {
+		_, err = p.kubeClient.PolicyV1().PodDisruptionBudgets(namespace).Create(ctx, podDisruptionBudget, metav1.CreateOptions{})
+	}
+	err = recordMetrics(ctx, namespace, "PodDisruptionBudget", podDisruptionBudget.GetName(), "CREATE", err, p.metricsRecorder)
 	if err != nil {
 		return err
 	}
@@ -57,9 +207,25 @@ func (p *PodDisruptionBudgetService) CreatePodDisruptionBudget(namespace string,
 	return nil
 }
 
-func (p *PodDisruptionBudgetService) UpdatePodDisruptionBudget(namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
-	_, err := p.kubeClient.PolicyV1().PodDisruptionBudgets(namespace).Update(context.TODO(), podDisruptionBudget, metav1.UpdateOptions{})
-	recordMetrics(namespace, "PodDisruptionBudget", podDisruptionBudget.GetName(), "UPDATE", err, p.metricsRecorder)
+func (p *PodDisruptionBudgetService) UpdatePodDisruptionBudget(ctx context.Context, namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
+	version, err := p.servedAPIVersion()
+	if err != nil {
+		return err
+	}
+	err = updateOnConflict(p.conflictRetries, namespace, "PodDisruptionBudget", podDisruptionBudget.GetName(), p.metricsRecorder, podDisruptionBudget, func() (*policyv1.PodDisruptionBudget, error) {
+		return p.GetPodDisruptionBudget(ctx, namespace, podDisruptionBudget.Name)
+	}, func(stored *policyv1.PodDisruptionBudget) {
+		setDesiredMeta(stored, podDisruptionBudget)
+		stored.Spec = podDisruptionBudget.Spec
+	}, func(podDisruptionBudget *policyv1.PodDisruptionBudget) error {
+		var err error
+		if version == PodDisruptionBudgetV1beta1 {
+			_, err = p.kubeClient.PolicyV1beta1().PodDisruptionBudgets(namespace).Update(ctx, toV1beta1PodDisruptionBudget(podDisruptionBudget), metav1.UpdateOptions{})
+		} else {
+			_, err = p.kubeClient.PolicyV1().PodDisruptionBudgets(namespace).Update(ctx, podDisruptionBudget, metav1.UpdateOptions{})
+		}
+		return recordMetrics(ctx, namespace, "PodDisruptionBudget", podDisruptionBudget.GetName(), "UPDATE", err, p.metricsRecorder)
+	})
 	if err != nil {
 		return err
 	}
@@ -67,26 +233,140 @@ func (p *PodDisruptionBudgetService) UpdatePodDisruptionBudget(namespace string,
 	return nil
 }
 
-func (p *PodDisruptionBudgetService) CreateOrUpdatePodDisruptionBudget(namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
-	storedPodDisruptionBudget, err := p.GetPodDisruptionBudget(namespace, podDisruptionBudget.Name)
+// CreateOrUpdatePodDisruptionBudget will update the podDisruptionBudget or create it if does not exist.
+// The unhealthyPodEvictionPolicy asked by its annotation is patched after the write, the annotation is
+// dropped on the clusters not supporting it.
+func (p *PodDisruptionBudgetService) CreateOrUpdatePodDisruptionBudget(ctx context.Context, namespace string, podDisruptionBudget *policyv1.PodDisruptionBudget) error {
+	policy, err := p.unhealthyPodEvictionPolicy(podDisruptionBudget)
+	if err != nil {
+		return err
+	}
+
+	storedPodDisruptionBudget, err := p.GetPodDisruptionBudget(ctx, namespace, podDisruptionBudget.Name)
 	if err != nil {
 		// If no resource we need to create.
 		if errors.IsNotFound(err) {
-			return p.CreatePodDisruptionBudget(namespace, podDisruptionBudget)
+			if _, err := setSpecHash(podDisruptionBudget, podDisruptionBudget.Spec); err != nil {
+				return err
+			}
+			if err := p.CreatePodDisruptionBudget(ctx, namespace, podDisruptionBudget); err != nil {
+				return err
+			}
+			return p.patchUnhealthyPodEvictionPolicy(ctx, namespace, podDisruptionBudget.Name, policy)
 		}
 		return err
 	}
 
+	// Nothing is written while the desired podDisruptionBudget has the hash of the stored one. The policy
+	// is in the hash through its annotation, so the field dropped or defaulted by the server doesn't
+	// update the podDisruptionBudget again.
+	storedHash := storedPodDisruptionBudget.Annotations[SpecHashAnnotation]
+	hash, err := setSpecHash(podDisruptionBudget, podDisruptionBudget.Spec)
+	if err != nil {
+		return err
+	}
+	if storedHash == hash {
+		return nil
+	}
+
 	// Already exists, need to Update.
 	// Set the correct resource version to ensure we are on the latest version. This way the only valid
 	// namespace is our spec(https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#concurrency-control-and-consistency),
 	// we will replace the current namespace state.
 	podDisruptionBudget.ResourceVersion = storedPodDisruptionBudget.ResourceVersion
-	return p.UpdatePodDisruptionBudget(namespace, podDisruptionBudget)
+	if err := p.UpdatePodDisruptionBudget(ctx, namespace, podDisruptionBudget); err != nil {
+		return err
+	}
+	return p.patchUnhealthyPodEvictionPolicy(ctx, namespace, podDisruptionBudget.Name, policy)
 }
 
-func (p *PodDisruptionBudgetService) DeletePodDisruptionBudget(namespace string, name string) error {
-	err := p.kubeClient.PolicyV1().PodDisruptionBudgets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
-	recordMetrics(namespace, "PodDisruptionBudget", name, "DELETE", err, p.metricsRecorder)
+// unhealthyPodEvictionPolicy returns the unhealthyPodEvictionPolicy asked by the annotation of the
+// podDisruptionBudget. The annotation is removed, and the policy is empty, when the cluster doesn't
+// support it.
+func (p *PodDisruptionBudgetService) unhealthyPodEvictionPolicy(podDisruptionBudget *policyv1.PodDisruptionBudget) (string, error) {
+	policy, ok := podDisruptionBudget.Annotations[UnhealthyPodEvictionPolicyAnnotation]
+	if !ok {
+		return "", nil
+	}
+	supported, err := p.SupportsUnhealthyPodEvictionPolicy()
+	if err != nil {
+		return "", err
+	}
+	if supported {
+		return policy, nil
+	}
+	annotations := map[string]string{}
+	for k, v := range podDisruptionBudget.Annotations {
+		if k != UnhealthyPodEvictionPolicyAnnotation {
+			annotations[k] = v
+		}
+	}
+	podDisruptionBudget.Annotations = annotations
+	return "", nil
+}
+
+// patchUnhealthyPodEvictionPolicy sets the unhealthyPodEvictionPolicy of the podDisruptionBudget, the
+// typed policy/v1 client doesn't know the field yet.
+func (p *PodDisruptionBudgetService) patchUnhealthyPodEvictionPolicy(ctx context.Context, namespace, name, policy string) error {
+	if policy == "" {
+		return nil
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"unhealthyPodEvictionPolicy": policy,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = p.kubeClient.PolicyV1().PodDisruptionBudgets(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+	return recordMetrics(ctx, namespace, "PodDisruptionBudget", name, "PATCH", err, p.metricsRecorder)
+}
+
+func (p *PodDisruptionBudgetService) DeletePodDisruptionBudget(ctx context.Context, namespace string, name string) error {
+	version, err := p.servedAPIVersion()
+	if err != nil {
+		return err
+	}
+	if version == PodDisruptionBudgetV1beta1 {
+		err = p.kubeClient.PolicyV1beta1().PodDisruptionBudgets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	} else {
+		err = p.kubeClient.PolicyV1().PodDisruptionBudgets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	}
+	err = recordMetrics(ctx, namespace, "PodDisruptionBudget", name, "DELETE", err, p.metricsRecorder)
 	return err
 }
+
+// toV1beta1PodDisruptionBudget returns the policy/v1beta1 PodDisruptionBudget written on the clusters
+// not serving policy/v1. The fields only known by policy/v1 are dropped.
+func toV1beta1PodDisruptionBudget(pdb *policyv1.PodDisruptionBudget) *policyv1beta1.PodDisruptionBudget {
+	return &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: *pdb.ObjectMeta.DeepCopy(),
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable:   pdb.Spec.MinAvailable,
+			Selector:       pdb.Spec.Selector,
+			MaxUnavailable: pdb.Spec.MaxUnavailable,
+		},
+	}
+}
+
+// fromV1beta1PodDisruptionBudget returns the policy/v1 PodDisruptionBudget read from a policy/v1beta1 one.
+func fromV1beta1PodDisruptionBudget(pdb *policyv1beta1.PodDisruptionBudget) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: *pdb.ObjectMeta.DeepCopy(),
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable:   pdb.Spec.MinAvailable,
+			Selector:       pdb.Spec.Selector,
+			MaxUnavailable: pdb.Spec.MaxUnavailable,
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			ObservedGeneration: pdb.Status.ObservedGeneration,
+			DisruptedPods:      pdb.Status.DisruptedPods,
+			DisruptionsAllowed: pdb.Status.DisruptionsAllowed,
+			CurrentHealthy:     pdb.Status.CurrentHealthy,
+			DesiredHealthy:     pdb.Status.DesiredHealthy,
+			ExpectedPods:       pdb.Status.ExpectedPods,
+			Conditions:         pdb.Status.Conditions,
+		},
+	}
+}
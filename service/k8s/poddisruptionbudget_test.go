@@ -1,15 +1,19 @@
 package k8s_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	policyv1 "k8s.io/api/policy/v1"
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	kubernetes "k8s.io/client-go/kubernetes/fake"
 	kubetesting "k8s.io/client-go/testing"
 
@@ -18,7 +22,28 @@ import (
 	"redis-operator/service/k8s"
 )
 
-var podDisruptionBudgetsGroup = schema.GroupVersionResource{Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"}
+var (
+	podDisruptionBudgetsGroup        = schema.GroupVersionResource{Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"}
+	podDisruptionBudgetsV1beta1Group = schema.GroupVersionResource{Group: "policy", Version: "v1beta1", Resource: "poddisruptionbudgets"}
+)
+
+// newPodDisruptionBudgetResources returns the discovery of a cluster serving the PodDisruptionBudgets
+// in the group versions.
+func newPodDisruptionBudgetResources(groupVersions ...string) []*metav1.APIResourceList {
+	resources := []*metav1.APIResourceList{}
+	for _, groupVersion := range groupVersions {
+		resources = append(resources, &metav1.APIResourceList{
+			GroupVersion: groupVersion,
+			APIResources: []metav1.APIResource{{Name: "poddisruptionbudgets", Namespaced: true, Kind: "PodDisruptionBudget"}},
+		})
+	}
+	return resources
+}
+
+// newDiscoveryAction is the action recorded by the fake discovery for every group version read.
+func newDiscoveryAction() kubetesting.ActionImpl {
+	return kubetesting.ActionImpl{Verb: "get", Resource: schema.GroupVersionResource{Resource: "resource"}}
+}
 
 func newPodDisruptionBudgetUpdateAction(ns string, podDisruptionBudget *policyv1.PodDisruptionBudget) kubetesting.UpdateActionImpl {
 	return kubetesting.NewUpdateAction(podDisruptionBudgetsGroup, ns, podDisruptionBudget)
@@ -58,6 +83,7 @@ func TestPodDisruptionBudgetServiceGetCreateOrUpdate(t *testing.T) {
 			errorOnGet:                   kubeerrors.NewNotFound(schema.GroupResource{}, ""),
 			errorOnCreation:              nil,
 			expActions: []kubetesting.Action{
+				newDiscoveryAction(),
 				newPodDisruptionBudgetGetAction(testns, testPodDisruptionBudget.ObjectMeta.Name),
 				newPodDisruptionBudgetCreateAction(testns, testPodDisruptionBudget),
 			},
@@ -70,6 +96,7 @@ func TestPodDisruptionBudgetServiceGetCreateOrUpdate(t *testing.T) {
 			errorOnGet:                   kubeerrors.NewNotFound(schema.GroupResource{}, ""),
 			errorOnCreation:              errors.New("wanted error"),
 			expActions: []kubetesting.Action{
+				newDiscoveryAction(),
 				newPodDisruptionBudgetGetAction(testns, testPodDisruptionBudget.ObjectMeta.Name),
 				newPodDisruptionBudgetCreateAction(testns, testPodDisruptionBudget),
 			},
@@ -78,10 +105,11 @@ func TestPodDisruptionBudgetServiceGetCreateOrUpdate(t *testing.T) {
 		{
 			name:                         "An existent podDisruptionBudget should update the podDisruptionBudget.",
 			podDisruptionBudget:          testPodDisruptionBudget,
-			getPodDisruptionBudgetResult: testPodDisruptionBudget,
+			getPodDisruptionBudgetResult: testPodDisruptionBudget.DeepCopy(),
 			errorOnGet:                   nil,
 			errorOnCreation:              nil,
 			expActions: []kubetesting.Action{
+				newDiscoveryAction(),
 				newPodDisruptionBudgetGetAction(testns, testPodDisruptionBudget.ObjectMeta.Name),
 				newPodDisruptionBudgetUpdateAction(testns, testPodDisruptionBudget),
 			},
@@ -94,16 +122,20 @@ func TestPodDisruptionBudgetServiceGetCreateOrUpdate(t *testing.T) {
 			assert := assert.New(t)
 
 			// Mock.
-			mcli := &kubernetes.Clientset{}
-			mcli.AddReactor("get", "poddisruptionbudgets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+			mcli := kubernetes.NewSimpleClientset()
+			mcli.Resources = newPodDisruptionBudgetResources(k8s.PodDisruptionBudgetV1)
+			mcli.PrependReactor("get", "poddisruptionbudgets", func(action kubetesting.Action) (bool, runtime.Object, error) {
 				return true, test.getPodDisruptionBudgetResult, test.errorOnGet
 			})
-			mcli.AddReactor("create", "poddisruptionbudgets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+			mcli.PrependReactor("create", "poddisruptionbudgets", func(action kubetesting.Action) (bool, runtime.Object, error) {
 				return true, nil, test.errorOnCreation
 			})
+			mcli.PrependReactor("update", "poddisruptionbudgets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, nil, nil
+			})
 
-			service := k8s.NewPodDisruptionBudgetService(mcli, log.Dummy, metrics.Dummy)
-			err := service.CreateOrUpdatePodDisruptionBudget(testns, test.podDisruptionBudget)
+			service := k8s.NewPodDisruptionBudgetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			err := service.CreateOrUpdatePodDisruptionBudget(context.TODO(), testns, test.podDisruptionBudget)
 
 			if test.expErr {
 				assert.Error(err)
@@ -115,3 +147,149 @@ func TestPodDisruptionBudgetServiceGetCreateOrUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestPodDisruptionBudgetServiceAPIVersion(t *testing.T) {
+	testPodDisruptionBudget := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "testpodDisruptionBudget1",
+		},
+	}
+
+	testns := "testns"
+
+	tests := []struct {
+		name          string
+		groupVersions []string
+		expVersion    string
+		expResource   *schema.GroupVersionResource
+		expErr        error
+	}{
+		{
+			name:          "A cluster serving policy/v1 should get its PodDisruptionBudgets written with policy/v1.",
+			groupVersions: []string{k8s.PodDisruptionBudgetV1, k8s.PodDisruptionBudgetV1beta1},
+			expVersion:    k8s.PodDisruptionBudgetV1,
+			expResource:   &podDisruptionBudgetsGroup,
+		},
+		{
+			name:          "A cluster only serving policy/v1beta1 should get its PodDisruptionBudgets written with policy/v1beta1.",
+			groupVersions: []string{k8s.PodDisruptionBudgetV1beta1},
+			expVersion:    k8s.PodDisruptionBudgetV1beta1,
+			expResource:   &podDisruptionBudgetsV1beta1Group,
+		},
+		{
+			name:   "A cluster serving no PodDisruptionBudget API should get none written.",
+			expErr: k8s.ErrPodDisruptionBudgetsUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mock.
+			mcli := kubernetes.NewSimpleClientset()
+			mcli.Resources = newPodDisruptionBudgetResources(test.groupVersions...)
+			mcli.PrependReactor("get", "poddisruptionbudgets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, nil, kubeerrors.NewNotFound(schema.GroupResource{}, "")
+			})
+			mcli.PrependReactor("create", "poddisruptionbudgets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, nil, nil
+			})
+
+			service := k8s.NewPodDisruptionBudgetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			version, err := service.APIVersion()
+			assert.NoError(err)
+			assert.Equal(test.expVersion, version)
+
+			mcli.ClearActions()
+			err = service.CreateOrUpdatePodDisruptionBudget(context.TODO(), testns, testPodDisruptionBudget)
+			if test.expErr != nil {
+				assert.ErrorIs(err, test.expErr)
+				assert.ErrorIs(service.DeletePodDisruptionBudget(context.TODO(), testns, testPodDisruptionBudget.Name), test.expErr)
+				assert.Empty(mcli.Actions())
+				return
+			}
+			assert.NoError(err)
+			// The version is discovered once and every call uses it.
+			if assert.Len(mcli.Actions(), 2) {
+				for _, action := range mcli.Actions() {
+					assert.Equal(*test.expResource, action.GetResource())
+				}
+			}
+		})
+	}
+}
+
+func TestPodDisruptionBudgetServiceUnhealthyPodEvictionPolicy(t *testing.T) {
+	testns := "testns"
+
+	tests := []struct {
+		name          string
+		groupVersions []string
+		serverVersion *version.Info
+		expPolicy     bool
+	}{
+		{
+			name:          "A kubernetes 1.27 cluster should get the unhealthyPodEvictionPolicy patched.",
+			groupVersions: []string{k8s.PodDisruptionBudgetV1},
+			serverVersion: &version.Info{Major: "1", Minor: "27"},
+			expPolicy:     true,
+		},
+		{
+			name:          "A managed kubernetes 1.28 cluster should get the unhealthyPodEvictionPolicy patched.",
+			groupVersions: []string{k8s.PodDisruptionBudgetV1},
+			serverVersion: &version.Info{Major: "1", Minor: "28+"},
+			expPolicy:     true,
+		},
+		{
+			name:          "A kubernetes 1.26 cluster should not get the unhealthyPodEvictionPolicy.",
+			groupVersions: []string{k8s.PodDisruptionBudgetV1},
+			serverVersion: &version.Info{Major: "1", Minor: "26"},
+		},
+		{
+			name:          "A cluster only serving policy/v1beta1 should not get the unhealthyPodEvictionPolicy.",
+			groupVersions: []string{k8s.PodDisruptionBudgetV1beta1},
+			serverVersion: &version.Info{Major: "1", Minor: "20"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mcli := kubernetes.NewSimpleClientset()
+			mcli.Resources = newPodDisruptionBudgetResources(test.groupVersions...)
+			mcli.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = test.serverVersion
+			service := k8s.NewPodDisruptionBudgetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+			desired := &policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "rfr-test",
+					Annotations: map[string]string{k8s.UnhealthyPodEvictionPolicyAnnotation: "AlwaysAllow"},
+				},
+			}
+			require.NoError(service.CreateOrUpdatePodDisruptionBudget(context.TODO(), testns, desired.DeepCopy()))
+
+			patched := false
+			for _, action := range mcli.Actions() {
+				if patch, ok := action.(kubetesting.PatchAction); ok {
+					patched = true
+					assert.Equal(`{"spec":{"unhealthyPodEvictionPolicy":"AlwaysAllow"}}`, string(patch.GetPatch()))
+				}
+			}
+			assert.Equal(test.expPolicy, patched)
+			stored, err := service.GetPodDisruptionBudget(context.TODO(), testns, "rfr-test")
+			require.NoError(err)
+			_, ok := stored.Annotations[k8s.UnhealthyPodEvictionPolicyAnnotation]
+			assert.Equal(test.expPolicy, ok)
+
+			// The stored object doesn't know the field, as a server dropping it, and is not written again.
+			mcli.ClearActions()
+			require.NoError(service.CreateOrUpdatePodDisruptionBudget(context.TODO(), testns, desired.DeepCopy()))
+			for _, action := range mcli.Actions() {
+				assert.Equal("get", action.GetVerb())
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// defaultRetryAfter is how long the apiserver is left alone after a 429 without Retry-After.
+const defaultRetryAfter = time.Second
+
+// DefaultAPIServerPressure is the apiserver pressure recorded by all the services, they share the
+// apiserver.
+var DefaultAPIServerPressure = NewAPIServerPressure(time.Now)
+
+// APIServerPressure records the 429 Too Many Requests answered by the apiserver, with the Retry-After
+// it asked for, so the operator backs off instead of retrying right away.
+type APIServerPressure struct {
+	mu  sync.Mutex
+	now func() time.Time
+	// throttledAt is when the last 429 was answered, retryUntil until when the apiserver asked to
+	// not be called again.
+	throttledAt time.Time
+	retryUntil  time.Time
+}
+
+// NewAPIServerPressure returns a new apiserver pressure.
+func NewAPIServerPressure(now func() time.Time) *APIServerPressure {
+	return &APIServerPressure{now: now}
+}
+
+// Observe records the error when it's a 429, and returns true if so.
+func (p *APIServerPressure) Observe(err error) bool {
+	if err == nil || !errors.IsTooManyRequests(err) {
+		return false
+	}
+	retryAfter := defaultRetryAfter
+	if seconds, ok := errors.SuggestsClientDelay(err); ok && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	p.throttledAt = now
+	if until := now.Add(retryAfter); until.After(p.retryUntil) {
+		p.retryUntil = until
+	}
+	return true
+}
+
+// Throttled returns when the last 429 was answered and until when the apiserver asked to not be
+// called again, zero when it never was.
+func (p *APIServerPressure) Throttled() (time.Time, time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.throttledAt, p.retryUntil
+}
@@ -0,0 +1,142 @@
+package k8s_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+func TestAPIServerPressureObserve(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		err           error
+		expThrottled  bool
+		expRetryAfter time.Duration
+	}{
+		{
+			name:          "429 with a Retry-After",
+			err:           kubeerrors.NewTooManyRequests("the server is busy", 5),
+			expThrottled:  true,
+			expRetryAfter: 5 * time.Second,
+		},
+		{
+			name:          "429 without a Retry-After",
+			err:           kubeerrors.NewTooManyRequests("the server is busy", 0),
+			expThrottled:  true,
+			expRetryAfter: time.Second,
+		},
+		{
+			name:          "429 without details",
+			err:           &kubeerrors.StatusError{ErrStatus: metav1.Status{Status: metav1.StatusFailure, Code: 429}},
+			expThrottled:  true,
+			expRetryAfter: time.Second,
+		},
+		{
+			name: "Not found",
+			err:  kubeerrors.NewNotFound(podsGroup.GroupResource(), "rfr-test-0"),
+		},
+		{
+			name: "Other error",
+			err:  errors.New("connection refused"),
+		},
+		{
+			name: "No error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			pressure := k8s.NewAPIServerPressure(func() time.Time { return now })
+			assert.Equal(test.expThrottled, pressure.Observe(test.err))
+
+			throttledAt, retryUntil := pressure.Throttled()
+			if !test.expThrottled {
+				assert.True(throttledAt.IsZero())
+				assert.True(retryUntil.IsZero())
+				return
+			}
+			assert.Equal(now, throttledAt)
+			assert.Equal(now.Add(test.expRetryAfter), retryUntil)
+		})
+	}
+}
+
+func TestAPIServerPressureKeepsTheLongestRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	pressure := k8s.NewAPIServerPressure(func() time.Time { return now })
+
+	pressure.Observe(kubeerrors.NewTooManyRequests("the server is busy", 30))
+	now = now.Add(time.Second)
+	pressure.Observe(kubeerrors.NewTooManyRequests("the server is busy", 2))
+
+	throttledAt, retryUntil := pressure.Throttled()
+	assert.Equal(now, throttledAt)
+	assert.Equal(now.Add(29*time.Second), retryUntil)
+}
+
+func TestServicesRecordAPIServerPressure(t *testing.T) {
+	tests := []struct {
+		name          string
+		retryAfter    int
+		expRetryAfter time.Duration
+	}{
+		{
+			name:          "No Retry-After",
+			retryAfter:    0,
+			expRetryAfter: time.Second,
+		},
+		{
+			name:          "Short Retry-After",
+			retryAfter:    3,
+			expRetryAfter: 3 * time.Second,
+		},
+		{
+			name:          "Long Retry-After",
+			retryAfter:    120,
+			expRetryAfter: 2 * time.Minute,
+		},
+	}
+
+	defaultPressure := k8s.DefaultAPIServerPressure
+	defer func() { k8s.DefaultAPIServerPressure = defaultPressure }()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+			k8s.DefaultAPIServerPressure = k8s.NewAPIServerPressure(func() time.Time { return now })
+
+			mcli := &kubernetes.Clientset{}
+			mcli.AddReactor("get", "pods", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, nil, kubeerrors.NewTooManyRequests("the server is busy", test.retryAfter)
+			})
+			service := k8s.NewPodService(mcli, log.Dummy, metrics.Dummy)
+
+			_, err := service.GetPod(context.TODO(), "testns", "rfr-test-0")
+			assert.True(kubeerrors.IsTooManyRequests(err))
+
+			throttledAt, retryUntil := k8s.DefaultAPIServerPressure.Throttled()
+			assert.Equal(now, throttledAt)
+			assert.Equal(now.Add(test.expRetryAfter), retryUntil)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package k8s
+
+import (
+	"context"
+
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// PriorityClass the PriorityClass service that knows how to interact with k8s to read them
+type PriorityClass interface {
+	// GetPriorityClass returns the priority class, the pods naming one that doesn't exist are refused.
+	GetPriorityClass(ctx context.Context, name string) (*schedulingv1.PriorityClass, error)
+}
+
+// PriorityClassService is the priority class service implementation using API calls to kubernetes.
+type PriorityClassService struct {
+	kubeClient      kubernetes.Interface
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+}
+
+// NewPriorityClassService returns a new PriorityClass KubeService.
+func NewPriorityClassService(kubeClient kubernetes.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *PriorityClassService {
+	logger = logger.With("service", "k8s.priorityClass")
+	return &PriorityClassService{
+		kubeClient:      kubeClient,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+	}
+}
+
+func (p *PriorityClassService) GetPriorityClass(ctx context.Context, name string) (*schedulingv1.PriorityClass, error) {
+	priorityClass, err := p.kubeClient.SchedulingV1().PriorityClasses().Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, metrics.NOT_APPLICABLE, "PriorityClass", name, "GET", err, p.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return priorityClass, nil
+}
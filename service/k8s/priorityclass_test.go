@@ -0,0 +1,32 @@
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+func TestPriorityClassServiceGetPriorityClass(t *testing.T) {
+	assert := assert.New(t)
+
+	mcli := kubernetes.NewSimpleClientset(&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "redis-critical"}, Value: 1000000})
+	recorder := &k8sOperationRecorder{Recorder: metrics.Dummy}
+	service := k8s.NewPriorityClassService(mcli, log.Dummy, recorder)
+
+	priorityClass, err := service.GetPriorityClass(context.TODO(), "redis-critical")
+	assert.NoError(err)
+	assert.Equal(int32(1000000), priorityClass.Value)
+
+	_, err = service.GetPriorityClass(context.TODO(), "missing")
+	assert.True(kubeerrors.IsNotFound(err))
+	assert.Equal([]string{metrics.NOT_APPLICABLE, metrics.K8S_NOT_FOUND}, recorder.errs)
+}
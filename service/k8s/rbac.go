@@ -3,6 +3,7 @@ package k8s
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,15 +15,20 @@ import (
 
 // RBAC is the service that knows how to interact with k8s to manage RBAC related resources.
 type RBAC interface {
-	GetClusterRole(name string) (*rbacv1.ClusterRole, error)
-	GetRole(namespace, name string) (*rbacv1.Role, error)
-	GetRoleBinding(namespace, name string) (*rbacv1.RoleBinding, error)
-	CreateRole(namespace string, role *rbacv1.Role) error
-	UpdateRole(namespace string, role *rbacv1.Role) error
-	CreateOrUpdateRole(namespace string, binding *rbacv1.Role) error
-	CreateRoleBinding(namespace string, binding *rbacv1.RoleBinding) error
-	UpdateRoleBinding(namespace string, binding *rbacv1.RoleBinding) error
-	CreateOrUpdateRoleBinding(namespace string, binding *rbacv1.RoleBinding) error
+	GetClusterRole(ctx context.Context, name string) (*rbacv1.ClusterRole, error)
+	GetRole(ctx context.Context, namespace, name string) (*rbacv1.Role, error)
+	GetRoleBinding(ctx context.Context, namespace, name string) (*rbacv1.RoleBinding, error)
+	CreateRole(ctx context.Context, namespace string, role *rbacv1.Role) error
+	UpdateRole(ctx context.Context, namespace string, role *rbacv1.Role) error
+	CreateOrUpdateRole(ctx context.Context, namespace string, binding *rbacv1.Role) error
+	CreateRoleBinding(ctx context.Context, namespace string, binding *rbacv1.RoleBinding) error
+	UpdateRoleBinding(ctx context.Context, namespace string, binding *rbacv1.RoleBinding) error
+	CreateOrUpdateRoleBinding(ctx context.Context, namespace string, binding *rbacv1.RoleBinding) error
+	DeleteRole(ctx context.Context, namespace, name string) error
+	DeleteRoleBinding(ctx context.Context, namespace, name string) error
+	GetServiceAccount(ctx context.Context, namespace, name string) (*corev1.ServiceAccount, error)
+	CreateOrUpdateServiceAccount(ctx context.Context, namespace string, serviceAccount *corev1.ServiceAccount) error
+	DeleteServiceAccount(ctx context.Context, namespace, name string) error
 }
 
 // NamespaceService is the Namespace service implementation using API calls to kubernetes.
@@ -42,27 +48,27 @@ func NewRBACService(kubeClient kubernetes.Interface, logger log.Logger, metricsR
 	}
 }
 
-func (r *RBACService) GetClusterRole(name string) (*rbacv1.ClusterRole, error) {
-	clusterRole, err := r.kubeClient.RbacV1().ClusterRoles().Get(context.TODO(), name, metav1.GetOptions{})
-	recordMetrics(metrics.NOT_APPLICABLE, "ClusterRole", name, "GET", err, r.metricsRecorder)
+func (r *RBACService) GetClusterRole(ctx context.Context, name string) (*rbacv1.ClusterRole, error) {
+	clusterRole, err := r.kubeClient.RbacV1().ClusterRoles().Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, metrics.NOT_APPLICABLE, "ClusterRole", name, "GET", err, r.metricsRecorder)
 	return clusterRole, err
 }
 
-func (r *RBACService) GetRole(namespace, name string) (*rbacv1.Role, error) {
-	role, err := r.kubeClient.RbacV1().Roles(namespace).Get(context.TODO(), name, metav1.GetOptions{})
-	recordMetrics(namespace, "Role", name, "GET", err, r.metricsRecorder)
+func (r *RBACService) GetRole(ctx context.Context, namespace, name string) (*rbacv1.Role, error) {
+	role, err := r.kubeClient.RbacV1().Roles(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "Role", name, "GET", err, r.metricsRecorder)
 	return role, err
 }
 
-func (r *RBACService) GetRoleBinding(namespace, name string) (*rbacv1.RoleBinding, error) {
-	rolbinding, err := r.kubeClient.RbacV1().RoleBindings(namespace).Get(context.TODO(), name, metav1.GetOptions{})
-	recordMetrics(namespace, "RoleBinding", name, "GET", err, r.metricsRecorder)
+func (r *RBACService) GetRoleBinding(ctx context.Context, namespace, name string) (*rbacv1.RoleBinding, error) {
+	rolbinding, err := r.kubeClient.RbacV1().RoleBindings(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "RoleBinding", name, "GET", err, r.metricsRecorder)
 	return rolbinding, err
 }
 
-func (r *RBACService) DeleteRole(namespace, name string) error {
-	err := r.kubeClient.RbacV1().Roles(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
-	recordMetrics(namespace, "Role", name, "DELETE", err, r.metricsRecorder)
+func (r *RBACService) DeleteRole(ctx context.Context, namespace, name string) error {
+	err := r.kubeClient.RbacV1().Roles(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err = recordMetrics(ctx, namespace, "Role", name, "DELETE", err, r.metricsRecorder)
 	if err != nil {
 		return err
 	}
@@ -70,9 +76,9 @@ func (r *RBACService) DeleteRole(namespace, name string) error {
 	return nil
 }
 
-func (r *RBACService) CreateRole(namespace string, role *rbacv1.Role) error {
-	_, err := r.kubeClient.RbacV1().Roles(namespace).Create(context.TODO(), role, metav1.CreateOptions{})
-	recordMetrics(namespace, "Role", role.GetName(), "CREATE", err, r.metricsRecorder)
+func (r *RBACService) CreateRole(ctx context.Context, namespace string, role *rbacv1.Role) error {
+	_, err := r.kubeClient.RbacV1().Roles(namespace).Create(ctx, role, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "Role", role.GetName(), "CREATE", err, r.metricsRecorder)
 	if err != nil {
 		return err
 	}
@@ -80,9 +86,9 @@ func (r *RBACService) CreateRole(namespace string, role *rbacv1.Role) error {
 	return nil
 }
 
-func (s *RBACService) UpdateRole(namespace string, role *rbacv1.Role) error {
-	_, err := s.kubeClient.RbacV1().Roles(namespace).Update(context.TODO(), role, metav1.UpdateOptions{})
-	recordMetrics(namespace, "Role", role.GetName(), "UPDATE", err, s.metricsRecorder)
+func (s *RBACService) UpdateRole(ctx context.Context, namespace string, role *rbacv1.Role) error {
+	_, err := s.kubeClient.RbacV1().Roles(namespace).Update(ctx, role, metav1.UpdateOptions{})
+	err = recordMetrics(ctx, namespace, "Role", role.GetName(), "UPDATE", err, s.metricsRecorder)
 	if err != nil {
 		return err
 	}
@@ -90,27 +96,40 @@ func (s *RBACService) UpdateRole(namespace string, role *rbacv1.Role) error {
 	return err
 }
 
-func (r *RBACService) CreateOrUpdateRole(namespace string, role *rbacv1.Role) error {
-	storedRole, err := r.GetRole(namespace, role.Name)
+func (r *RBACService) CreateOrUpdateRole(ctx context.Context, namespace string, role *rbacv1.Role) error {
+	storedRole, err := r.GetRole(ctx, namespace, role.Name)
 	if err != nil {
 		// If no resource we need to create.
 		if errors.IsNotFound(err) {
-			return r.CreateRole(namespace, role)
+			if _, err := setSpecHash(role, role.Rules); err != nil {
+				return err
+			}
+			return r.CreateRole(ctx, namespace, role)
 		}
 		return err
 	}
 
+	// Nothing is written while the desired role has the hash of the stored one.
+	storedHash := storedRole.Annotations[SpecHashAnnotation]
+	hash, err := setSpecHash(role, role.Rules)
+	if err != nil {
+		return err
+	}
+	if storedHash == hash {
+		return nil
+	}
+
 	// Already exists, need to Update.
 	// Set the correct resource version to ensure we are on the latest version. This way the only valid
 	// namespace is our spec(https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#concurrency-control-and-consistency),
 	// we will replace the current namespace state.
 	role.ResourceVersion = storedRole.ResourceVersion
-	return r.UpdateRole(namespace, role)
+	return r.UpdateRole(ctx, namespace, role)
 }
 
-func (r *RBACService) DeleteRoleBinding(namespace, name string) error {
-	err := r.kubeClient.RbacV1().RoleBindings(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
-	recordMetrics(namespace, "RoleBinding", name, "DELETE", err, r.metricsRecorder)
+func (r *RBACService) DeleteRoleBinding(ctx context.Context, namespace, name string) error {
+	err := r.kubeClient.RbacV1().RoleBindings(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err = recordMetrics(ctx, namespace, "RoleBinding", name, "DELETE", err, r.metricsRecorder)
 	if err != nil {
 		return err
 	}
@@ -118,9 +137,9 @@ func (r *RBACService) DeleteRoleBinding(namespace, name string) error {
 	return nil
 }
 
-func (r *RBACService) CreateRoleBinding(namespace string, binding *rbacv1.RoleBinding) error {
-	_, err := r.kubeClient.RbacV1().RoleBindings(namespace).Create(context.TODO(), binding, metav1.CreateOptions{})
-	recordMetrics(namespace, "RoleBinding", binding.GetName(), "CREATE", err, r.metricsRecorder)
+func (r *RBACService) CreateRoleBinding(ctx context.Context, namespace string, binding *rbacv1.RoleBinding) error {
+	_, err := r.kubeClient.RbacV1().RoleBindings(namespace).Create(ctx, binding, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "RoleBinding", binding.GetName(), "CREATE", err, r.metricsRecorder)
 	if err != nil {
 		return err
 	}
@@ -128,9 +147,9 @@ func (r *RBACService) CreateRoleBinding(namespace string, binding *rbacv1.RoleBi
 	return nil
 }
 
-func (r *RBACService) UpdateRoleBinding(namespace string, binding *rbacv1.RoleBinding) error {
-	_, err := r.kubeClient.RbacV1().RoleBindings(namespace).Update(context.TODO(), binding, metav1.UpdateOptions{})
-	recordMetrics(namespace, "Role", binding.GetName(), "UPDATE", err, r.metricsRecorder)
+func (r *RBACService) UpdateRoleBinding(ctx context.Context, namespace string, binding *rbacv1.RoleBinding) error {
+	_, err := r.kubeClient.RbacV1().RoleBindings(namespace).Update(ctx, binding, metav1.UpdateOptions{})
+	err = recordMetrics(ctx, namespace, "Role", binding.GetName(), "UPDATE", err, r.metricsRecorder)
 	if err != nil {
 		return err
 	}
@@ -138,24 +157,37 @@ func (r *RBACService) UpdateRoleBinding(namespace string, binding *rbacv1.RoleBi
 	return nil
 }
 
-func (r *RBACService) CreateOrUpdateRoleBinding(namespace string, binding *rbacv1.RoleBinding) error {
-	storedBinding, err := r.GetRoleBinding(namespace, binding.Name)
+func (r *RBACService) CreateOrUpdateRoleBinding(ctx context.Context, namespace string, binding *rbacv1.RoleBinding) error {
+	storedBinding, err := r.GetRoleBinding(ctx, namespace, binding.Name)
 	if err != nil {
 		// If no resource we need to create.
 		if errors.IsNotFound(err) {
-			return r.CreateRoleBinding(namespace, binding)
+			if _, err := setSpecHash(binding, []interface{}{binding.RoleRef, binding.Subjects}); err != nil {
+				return err
+			}
+			return r.CreateRoleBinding(ctx, namespace, binding)
 		}
 		return err
 	}
+	storedHash := storedBinding.Annotations[SpecHashAnnotation]
+	hash, err := setSpecHash(binding, []interface{}{binding.RoleRef, binding.Subjects})
+	if err != nil {
+		return err
+	}
 
 	// Check if the role ref has changed, roleref updates are not allowed, if changed then delete and create again the role binding.
 	// https://github.com/kubernetes/kubernetes/blob/0f0a5223dfc75337d03c9b80ae552ae8ef138eeb/pkg/apis/rbac/validation/validation.go#L157-L159
 	if storedBinding.RoleRef != binding.RoleRef {
 		r.logger.WithField("namespace", namespace).WithField("binding", binding.Name).Infof("roleref changed, need to recreate role binding resource")
-		if err := r.DeleteRoleBinding(namespace, binding.Name); err != nil {
+		if err := r.DeleteRoleBinding(ctx, namespace, binding.Name); err != nil {
 			return err
 		}
-		return r.CreateRoleBinding(namespace, binding)
+		return r.CreateRoleBinding(ctx, namespace, binding)
+	}
+
+	// Nothing is written while the desired role binding has the hash of the stored one.
+	if storedHash == hash {
+		return nil
 	}
 
 	// Already exists, need to Update.
@@ -163,5 +195,83 @@ func (r *RBACService) CreateOrUpdateRoleBinding(namespace string, binding *rbacv
 	// namespace is our spec(https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#concurrency-control-and-consistency),
 	// we will replace the current namespace state.
 	binding.ResourceVersion = storedBinding.ResourceVersion
-	return r.UpdateRoleBinding(namespace, binding)
+	return r.UpdateRoleBinding(ctx, namespace, binding)
+}
+
+func (r *RBACService) GetServiceAccount(ctx context.Context, namespace, name string) (*corev1.ServiceAccount, error) {
+	serviceAccount, err := r.kubeClient.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "ServiceAccount", name, "GET", err, r.metricsRecorder)
+	return serviceAccount, err
+}
+
+func (r *RBACService) DeleteServiceAccount(ctx context.Context, namespace, name string) error {
+	err := r.kubeClient.CoreV1().ServiceAccounts(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err = recordMetrics(ctx, namespace, "ServiceAccount", name, "DELETE", err, r.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	r.logger.WithField("namespace", namespace).WithField("serviceAccount", name).Infof("service account deleted")
+	return nil
+}
+
+func (r *RBACService) createServiceAccount(ctx context.Context, namespace string, serviceAccount *corev1.ServiceAccount) error {
+	_, err := r.kubeClient.CoreV1().ServiceAccounts(namespace).Create(ctx, serviceAccount, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "ServiceAccount", serviceAccount.GetName(), "CREATE", err, r.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	r.logger.WithField("namespace", namespace).WithField("serviceAccount", serviceAccount.Name).Infof("service account created")
+	return nil
+}
+
+func (r *RBACService) updateServiceAccount(ctx context.Context, namespace string, serviceAccount *corev1.ServiceAccount) error {
+	_, err := r.kubeClient.CoreV1().ServiceAccounts(namespace).Update(ctx, serviceAccount, metav1.UpdateOptions{})
+	err = recordMetrics(ctx, namespace, "ServiceAccount", serviceAccount.GetName(), "UPDATE", err, r.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	r.logger.WithField("namespace", namespace).WithField("serviceAccount", serviceAccount.Name).Infof("service account updated")
+	return nil
+}
+
+func (r *RBACService) CreateOrUpdateServiceAccount(ctx context.Context, namespace string, serviceAccount *corev1.ServiceAccount) error {
+	storedServiceAccount, err := r.GetServiceAccount(ctx, namespace, serviceAccount.Name)
+	if err != nil {
+		// If no resource we need to create.
+		if errors.IsNotFound(err) {
+			if _, err := setSpecHash(serviceAccount, serviceAccountSpec(serviceAccount)); err != nil {
+				return err
+			}
+			return r.createServiceAccount(ctx, namespace, serviceAccount)
+		}
+		return err
+	}
+
+	// Nothing is written while the desired service account has the hash of the stored one.
+	storedHash := storedServiceAccount.Annotations[SpecHashAnnotation]
+	hash, err := setSpecHash(serviceAccount, serviceAccountSpec(serviceAccount))
+	if err != nil {
+		return err
+	}
+	if storedHash == hash {
+		return nil
+	}
+
+	// The token secrets are added by the token controller of the clusters older than 1.24, they are kept
+	// so it doesn't create new ones on every update.
+	if serviceAccount.Secrets == nil {
+		serviceAccount.Secrets = storedServiceAccount.Secrets
+	}
+	// Already exists, need to Update.
+	// Set the correct resource version to ensure we are on the latest version. This way the only valid
+	// namespace is our spec(https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#concurrency-control-and-consistency),
+	// we will replace the current namespace state.
+	serviceAccount.ResourceVersion = storedServiceAccount.ResourceVersion
+	return r.updateServiceAccount(ctx, namespace, serviceAccount)
+}
+
+// serviceAccountSpec returns the fields of the service account hashed by CreateOrUpdateServiceAccount. The
+// token secrets are not, they are written by the token controller.
+func serviceAccountSpec(serviceAccount *corev1.ServiceAccount) interface{} {
+	return []interface{}{serviceAccount.AutomountServiceAccountToken, serviceAccount.ImagePullSecrets}
 }
@@ -1,10 +1,12 @@
 package k8s_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -20,6 +22,7 @@ import (
 
 var (
 	rbGroup = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}
+	saGroup = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "serviceaccounts"}
 )
 
 func newRBUpdateAction(ns string, rb *rbacv1.RoleBinding) kubetesting.UpdateActionImpl {
@@ -37,6 +40,22 @@ func newRBDeleteAction(ns string, name string) kubetesting.DeleteActionImpl {
 	return kubetesting.NewDeleteAction(rbGroup, ns, name)
 }
 
+func newSAUpdateAction(ns string, sa *corev1.ServiceAccount) kubetesting.UpdateActionImpl {
+	return kubetesting.NewUpdateAction(saGroup, ns, sa)
+}
+
+func newSAGetAction(ns, name string) kubetesting.GetActionImpl {
+	return kubetesting.NewGetAction(saGroup, ns, name)
+}
+
+func newSACreateAction(ns string, sa *corev1.ServiceAccount) kubetesting.CreateActionImpl {
+	return kubetesting.NewCreateAction(saGroup, ns, sa)
+}
+
+func newSADeleteAction(ns string, name string) kubetesting.DeleteActionImpl {
+	return kubetesting.NewDeleteAction(saGroup, ns, name)
+}
+
 func TestRBACServiceGetCreateOrUpdateRoleBinding(t *testing.T) {
 	testRB := &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
@@ -84,9 +103,17 @@ func TestRBACServiceGetCreateOrUpdateRoleBinding(t *testing.T) {
 			expErr: true,
 		},
 		{
-			name:            "An existent role binding should update the role binding.",
-			rb:              testRB,
-			getRBResult:     testRB,
+			name: "An existent role binding should update the role binding.",
+			rb:   testRB,
+			getRBResult: &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test1",
+					ResourceVersion: "15",
+				},
+				RoleRef: rbacv1.RoleRef{
+					Name: "test1",
+				},
+			},
 			errorOnGet:      nil,
 			errorOnCreation: nil,
 			expActions: []kubetesting.Action{
@@ -132,7 +159,7 @@ func TestRBACServiceGetCreateOrUpdateRoleBinding(t *testing.T) {
 			})
 
 			service := k8s.NewRBACService(mcli, log.Dummy, metrics.Dummy)
-			err := service.CreateOrUpdateRoleBinding(testns, test.rb)
+			err := service.CreateOrUpdateRoleBinding(context.TODO(), testns, test.rb)
 
 			if test.expErr {
 				assert.Error(err)
@@ -144,3 +171,149 @@ func TestRBACServiceGetCreateOrUpdateRoleBinding(t *testing.T) {
 		})
 	}
 }
+
+func TestRBACServiceGetCreateOrUpdateServiceAccount(t *testing.T) {
+	testns := "testns"
+	automount := false
+	generate := func() *corev1.ServiceAccount {
+		return &corev1.ServiceAccount{
+			ObjectMeta:                   metav1.ObjectMeta{Name: "rfr-test"},
+			AutomountServiceAccountToken: &automount,
+		}
+	}
+	tokens := []corev1.ObjectReference{{Name: "rfr-test-token-x1b2c"}}
+
+	tests := []struct {
+		name            string
+		getSAResult     *corev1.ServiceAccount
+		errorOnGet      error
+		errorOnCreation error
+		expActions      func(written *corev1.ServiceAccount) []kubetesting.Action
+		expErr          bool
+	}{
+		{
+			name:        "A new service account should create a new service account.",
+			getSAResult: nil,
+			errorOnGet:  kubeerrors.NewNotFound(schema.GroupResource{}, ""),
+			expActions: func(written *corev1.ServiceAccount) []kubetesting.Action {
+				return []kubetesting.Action{
+					newSAGetAction(testns, "rfr-test"),
+					newSACreateAction(testns, written),
+				}
+			},
+		},
+		{
+			name:            "A new service account should error when create a new service account fails.",
+			getSAResult:     nil,
+			errorOnGet:      kubeerrors.NewNotFound(schema.GroupResource{}, ""),
+			errorOnCreation: errors.New("wanted error"),
+			expErr:          true,
+		},
+		{
+			name:        "An existent service account should error when it can't be read.",
+			getSAResult: nil,
+			errorOnGet:  errors.New("wanted error"),
+			expErr:      true,
+		},
+		{
+			name:        "An existent service account should update the service account, keeping its token secrets.",
+			getSAResult: &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", ResourceVersion: "15"}, Secrets: tokens},
+			expActions: func(written *corev1.ServiceAccount) []kubetesting.Action {
+				return []kubetesting.Action{
+					newSAGetAction(testns, "rfr-test"),
+					newSAUpdateAction(testns, written),
+				}
+			},
+		},
+		{
+			name: "An unchanged service account should not be updated.",
+			expActions: func(written *corev1.ServiceAccount) []kubetesting.Action {
+				return []kubetesting.Action{
+					newSAGetAction(testns, "rfr-test"),
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			sa := generate()
+			getSAResult := test.getSAResult
+			if getSAResult == nil && test.errorOnGet == nil {
+				// The stored service account was written with the same spec.
+				stored := generate()
+				service := k8s.NewRBACService(kubernetes.NewSimpleClientset(), log.Dummy, metrics.Dummy)
+				assert.NoError(service.CreateOrUpdateServiceAccount(context.TODO(), testns, stored))
+				getSAResult = stored
+			}
+
+			// Mock.
+			mcli := &kubernetes.Clientset{}
+			mcli.AddReactor("get", "serviceaccounts", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, getSAResult, test.errorOnGet
+			})
+			mcli.AddReactor("create", "serviceaccounts", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, nil, test.errorOnCreation
+			})
+
+			service := k8s.NewRBACService(mcli, log.Dummy, metrics.Dummy)
+			err := service.CreateOrUpdateServiceAccount(context.TODO(), testns, sa)
+
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			// Check calls to kubernetes.
+			assert.Equal(test.expActions(sa), mcli.Actions())
+			assert.NotEmpty(sa.Annotations[k8s.SpecHashAnnotation])
+			if test.getSAResult != nil {
+				assert.Equal(test.getSAResult.ResourceVersion, sa.ResourceVersion)
+				assert.Equal(test.getSAResult.Secrets, sa.Secrets)
+			}
+		})
+	}
+}
+
+func TestRBACServiceDeleteServiceAccount(t *testing.T) {
+	testns := "testns"
+
+	tests := []struct {
+		name          string
+		errorOnDelete error
+		expErr        bool
+	}{
+		{
+			name: "An existent service account should be deleted.",
+		},
+		{
+			name:          "A missing service account should return the not found error.",
+			errorOnDelete: kubeerrors.NewNotFound(schema.GroupResource{}, ""),
+			expErr:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mock.
+			mcli := &kubernetes.Clientset{}
+			mcli.AddReactor("delete", "serviceaccounts", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, nil, test.errorOnDelete
+			})
+
+			service := k8s.NewRBACService(mcli, log.Dummy, metrics.Dummy)
+			err := service.DeleteServiceAccount(context.TODO(), testns, "rfr-test")
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal([]kubetesting.Action{newSADeleteAction(testns, "rfr-test")}, mcli.Actions())
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package k8s
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	redisfailoverclientset "redis-operator/client/k8s/clientset/versioned"
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// RedisCluster the RC service that knows how to interact with k8s to get them
+type RedisCluster interface {
+	// GetRedisCluster gets a rediscluster.
+	GetRedisCluster(ctx context.Context, namespace string, name string) (*redisfailoverv1.RedisCluster, error)
+	// ListRedisClusters lists the redisclusters on a cluster.
+	ListRedisClusters(ctx context.Context, namespace string, opts metav1.ListOptions) (*redisfailoverv1.RedisClusterList, error)
+	// WatchRedisClusters watches the redisclusters on a cluster.
+	WatchRedisClusters(ctx context.Context, namespace string, opts metav1.ListOptions) (watch.Interface, error)
+	// UpdateRedisClusterStatus updates the status subresource of a rediscluster.
+	UpdateRedisClusterStatus(ctx context.Context, namespace string, rc *redisfailoverv1.RedisCluster) (*redisfailoverv1.RedisCluster, error)
+}
+
+// RedisClusterService is the RedisCluster service implementation using API calls to kubernetes.
+type RedisClusterService struct {
+	k8sCli          redisfailoverclientset.Interface
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+}
+
+// NewRedisClusterService returns a new RedisCluster KubeService.
+func NewRedisClusterService(k8scli redisfailoverclientset.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *RedisClusterService {
+	logger = logger.With("service", "k8s.rediscluster")
+	return &RedisClusterService{
+		k8sCli:          k8scli,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+	}
+}
+
+// GetRedisCluster satisfies rediscluster.Service interface.
+func (r *RedisClusterService) GetRedisCluster(ctx context.Context, namespace string, name string) (*redisfailoverv1.RedisCluster, error) {
+	rc, err := r.k8sCli.DatabasesV1().RedisClusters(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "RedisCluster", name, "GET", err, r.metricsRecorder)
+	return rc, err
+}
+
+// ListRedisClusters satisfies rediscluster.Service interface.
+func (r *RedisClusterService) ListRedisClusters(ctx context.Context, namespace string, opts metav1.ListOptions) (*redisfailoverv1.RedisClusterList, error) {
+	redisClusterList, err := r.k8sCli.DatabasesV1().RedisClusters(namespace).List(ctx, opts)
+	err = recordMetrics(ctx, namespace, "RedisCluster", metrics.NOT_APPLICABLE, "LIST", err, r.metricsRecorder)
+	return redisClusterList, err
+}
+
+// WatchRedisClusters satisfies rediscluster.Service interface.
+func (r *RedisClusterService) WatchRedisClusters(ctx context.Context, namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	watcher, err := r.k8sCli.DatabasesV1().RedisClusters(namespace).Watch(ctx, opts)
+	err = recordMetrics(ctx, namespace, "RedisCluster", metrics.NOT_APPLICABLE, "WATCH", err, r.metricsRecorder)
+	return watcher, err
+}
+
+// UpdateRedisClusterStatus satisfies rediscluster.Service interface.
+func (r *RedisClusterService) UpdateRedisClusterStatus(ctx context.Context, namespace string, rc *redisfailoverv1.RedisCluster) (*redisfailoverv1.RedisCluster, error) {
+	updated, err := r.k8sCli.DatabasesV1().RedisClusters(namespace).UpdateStatus(ctx, rc, metav1.UpdateOptions{})
+	err = recordMetrics(ctx, namespace, "RedisCluster", rc.Name, "UPDATE_STATUS", err, r.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.WithField("namespace", namespace).WithField("rediscluster", rc.Name).Debugf("rediscluster status updated")
+	return updated, nil
+}
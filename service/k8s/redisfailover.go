@@ -2,8 +2,14 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 
 	redisfailoverv1 "redis-operator/api/redisfailover/v1"
@@ -12,41 +18,265 @@ import (
 	"redis-operator/metrics"
 )
 
+const (
+	// fieldValidationStrict makes the apiserver reject unknown or duplicated fields instead of dropping them.
+	fieldValidationStrict = "Strict"
+)
+
 // RedisFailover the RF service that knows how to interact with k8s to get them
 type RedisFailover interface {
+	// GetRedisFailover gets a redisfailover.
+	GetRedisFailover(ctx context.Context, namespace string, name string) (*redisfailoverv1.RedisFailover, error)
 	// ListRedisFailovers lists the redisfailovers on a cluster.
 	ListRedisFailovers(ctx context.Context, namespace string, opts metav1.ListOptions) (*redisfailoverv1.RedisFailoverList, error)
+	// EachRedisFailover calls fn with every redisfailover matching the options, listed in pages of their
+	// Limit, DefaultListPageSize when it's not set. It stops at the first error of fn.
+	EachRedisFailover(ctx context.Context, namespace string, opts metav1.ListOptions, fn func(*redisfailoverv1.RedisFailover) error) error
 	// WatchRedisFailovers watches the redisfailovers on a cluster.
 	WatchRedisFailovers(ctx context.Context, namespace string, opts metav1.ListOptions) (watch.Interface, error)
+	// CreateRedisFailover creates a redisfailover using strict field validation.
+	CreateRedisFailover(ctx context.Context, namespace string, rFailover *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error)
+	// UpdateRedisFailover updates a redisfailover using strict field validation.
+	UpdateRedisFailover(ctx context.Context, namespace string, rFailover *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error)
+	// UpdateRedisFailoverStatus updates the status subresource of a redisfailover using strict field validation.
+	UpdateRedisFailoverStatus(ctx context.Context, namespace string, rFailover *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error)
+	// PatchRedisFailoverAnnotations sets the given annotations on a redisfailover, leaving the rest of the object untouched.
+	PatchRedisFailoverAnnotations(ctx context.Context, namespace string, name string, annotations map[string]string) error
+	// PatchRedisFailoverFinalizers sets the finalizers of a redisfailover, leaving the rest of the object untouched.
+	// The patch fails with a conflict when the redisfailover changed since its resource version.
+	PatchRedisFailoverFinalizers(ctx context.Context, namespace string, name string, resourceVersion string, finalizers []string) (*redisfailoverv1.RedisFailover, error)
+	// CheckRedisFailoverSchema checks that the installed CRD schema knows every field the operator sets.
+	CheckRedisFailoverSchema(ctx context.Context, namespace string) error
 }
 
 // RedisFailoverService is the RedisFailover service implementation using API calls to kubernetes.
 type RedisFailoverService struct {
 	k8sCli          redisfailoverclientset.Interface
+	writes          *RedisFailoverWriteClients
 	logger          log.Logger
 	metricsRecorder metrics.Recorder
 }
 
 // NewRedisFailoverService returns a new Workspace KubeService.
-func NewRedisFailoverService(k8scli redisfailoverclientset.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *RedisFailoverService {
+// The redisfailovers are read with k8scli and written with the clients of writes, with k8scli when it's nil.
+func NewRedisFailoverService(k8scli redisfailoverclientset.Interface, writes *RedisFailoverWriteClients, logger log.Logger, metricsRecorder metrics.Recorder) *RedisFailoverService {
 	logger = logger.With("service", "k8s.redisfailover")
+	if writes == nil {
+		writes = NewRedisFailoverWriteClients(func() (*RedisFailoverWriteClient, error) {
+			return &RedisFailoverWriteClient{Client: k8scli, Warnings: NewWarningHandler()}, nil
+		})
+	}
 	return &RedisFailoverService{
 		k8sCli:          k8scli,
+		writes:          writes,
 		logger:          logger,
 		metricsRecorder: metricsRecorder,
 	}
 }
 
+// GetRedisFailover satisfies redisfailover.Service interface.
+func (r *RedisFailoverService) GetRedisFailover(ctx context.Context, namespace string, name string) (*redisfailoverv1.RedisFailover, error) {
+	rf, err := r.k8sCli.DatabasesV1().RedisFailovers(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "RedisFailover", name, "GET", err, r.metricsRecorder)
+	return rf, err
+}
+
 // ListRedisFailovers satisfies redisfailover.Service interface.
 func (r *RedisFailoverService) ListRedisFailovers(ctx context.Context, namespace string, opts metav1.ListOptions) (*redisfailoverv1.RedisFailoverList, error) {
 	redisFailoverList, err := r.k8sCli.DatabasesV1().RedisFailovers(namespace).List(ctx, opts)
-	recordMetrics(namespace, "RedisFailover", metrics.NOT_APPLICABLE, "LIST", err, r.metricsRecorder)
+	err = recordMetrics(ctx, namespace, "RedisFailover", metrics.NOT_APPLICABLE, "LIST", err, r.metricsRecorder)
 	return redisFailoverList, err
 }
 
+// EachRedisFailover satisfies redisfailover.Service interface.
+func (r *RedisFailoverService) EachRedisFailover(ctx context.Context, namespace string, opts metav1.ListOptions, fn func(*redisfailoverv1.RedisFailover) error) error {
+	return eachPage(opts, func(opts metav1.ListOptions) (string, error) {
+		page, err := r.ListRedisFailovers(ctx, namespace, opts)
+		if err != nil {
+			return "", err
+		}
+		for i := range page.Items {
+			if err := fn(&page.Items[i]); err != nil {
+				return "", err
+			}
+		}
+		return page.Continue, nil
+	})
+}
+
 // WatchRedisFailovers satisfies redisfailover.Service interface.
 func (r *RedisFailoverService) WatchRedisFailovers(ctx context.Context, namespace string, opts metav1.ListOptions) (watch.Interface, error) {
 	watcher, err := r.k8sCli.DatabasesV1().RedisFailovers(namespace).Watch(ctx, opts)
-	recordMetrics(namespace, "RedisFailover", metrics.NOT_APPLICABLE, "WATCH", err, r.metricsRecorder)
+	err = recordMetrics(ctx, namespace, "RedisFailover", metrics.NOT_APPLICABLE, "WATCH", err, r.metricsRecorder)
 	return watcher, err
 }
+
+// CreateRedisFailover satisfies redisfailover.Service interface.
+func (r *RedisFailoverService) CreateRedisFailover(ctx context.Context, namespace string, rf *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+	var created *redisfailoverv1.RedisFailover
+	err := r.write(namespace, rf.Name, func(cli redisfailoverclientset.Interface) error {
+		var err error
+		created, err = cli.DatabasesV1().RedisFailovers(namespace).Create(ctx, rf, metav1.CreateOptions{FieldValidation: fieldValidationStrict})
+		return recordMetrics(ctx, namespace, "RedisFailover", rf.Name, "CREATE", err, r.metricsRecorder)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.logger.WithField("namespace", namespace).WithField("redisfailover", rf.Name).Debugf("redisfailover created")
+	return created, nil
+}
+
+// UpdateRedisFailover satisfies redisfailover.Service interface.
+func (r *RedisFailoverService) UpdateRedisFailover(ctx context.Context, namespace string, rf *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+	var updated *redisfailoverv1.RedisFailover
+	err := r.write(namespace, rf.Name, func(cli redisfailoverclientset.Interface) error {
+		var err error
+		updated, err = cli.DatabasesV1().RedisFailovers(namespace).Update(ctx, rf, metav1.UpdateOptions{FieldValidation: fieldValidationStrict})
+		return recordMetrics(ctx, namespace, "RedisFailover", rf.Name, "UPDATE", err, r.metricsRecorder)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.logger.WithField("namespace", namespace).WithField("redisfailover", rf.Name).Debugf("redisfailover updated")
+	return updated, nil
+}
+
+// UpdateRedisFailoverStatus satisfies redisfailover.Service interface.
+func (r *RedisFailoverService) UpdateRedisFailoverStatus(ctx context.Context, namespace string, rf *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+	var updated *redisfailoverv1.RedisFailover
+	err := r.write(namespace, rf.Name, func(cli redisfailoverclientset.Interface) error {
+		var err error
+		updated, err = cli.DatabasesV1().RedisFailovers(namespace).UpdateStatus(ctx, rf, metav1.UpdateOptions{FieldValidation: fieldValidationStrict})
+		return recordMetrics(ctx, namespace, "RedisFailover", rf.Name, "UPDATE_STATUS", err, r.metricsRecorder)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.logger.WithField("namespace", namespace).WithField("redisfailover", rf.Name).Debugf("redisfailover status updated")
+	return updated, nil
+}
+
+// PatchRedisFailoverAnnotations satisfies redisfailover.Service interface.
+// A merge patch is used so the fields the operator can't decode are kept.
+func (r *RedisFailoverService) PatchRedisFailoverAnnotations(ctx context.Context, namespace string, name string, annotations map[string]string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	err = r.write(namespace, name, func(cli redisfailoverclientset.Interface) error {
+		_, err := cli.DatabasesV1().RedisFailovers(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{FieldValidation: fieldValidationStrict})
+		return recordMetrics(ctx, namespace, "RedisFailover", name, "PATCH", err, r.metricsRecorder)
+	})
+	if err != nil {
+		return err
+	}
+	r.logger.WithField("namespace", namespace).WithField("redisfailover", name).Debugf("redisfailover annotations patched")
+	return nil
+}
+
+// PatchRedisFailoverFinalizers satisfies redisfailover.Service interface.
+// A merge patch is used so the fields the operator can't decode are kept, the resource version makes it
+// fail instead of overwriting the finalizers written by another controller meanwhile.
+func (r *RedisFailoverService) PatchRedisFailoverFinalizers(ctx context.Context, namespace string, name string, resourceVersion string, finalizers []string) (*redisfailoverv1.RedisFailover, error) {
+	if finalizers == nil {
+		finalizers = []string{}
+	}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": resourceVersion,
+			"finalizers":      finalizers,
+		},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched *redisfailoverv1.RedisFailover
+	err = r.write(namespace, name, func(cli redisfailoverclientset.Interface) error {
+		var err error
+		patched, err = cli.DatabasesV1().RedisFailovers(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{FieldValidation: fieldValidationStrict})
+		return recordMetrics(ctx, namespace, "RedisFailover", name, "PATCH", err, r.metricsRecorder)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.logger.WithField("namespace", namespace).WithField("redisfailover", name).Debugf("redisfailover finalizers patched")
+	return patched, nil
+}
+
+// CheckRedisFailoverSchema satisfies redisfailover.Service interface.
+// A synthetic redisfailover with all the checked fields set is created in a dry run, that is not persisted.
+// If the apiserver rejects unknown fields or prunes any of them from the returned object, the installed
+// CRD is older than the operator types.
+func (r *RedisFailoverService) CheckRedisFailoverSchema(ctx context.Context, namespace string) error {
+	probe, err := newRedisFailoverSchemaProbe(namespace)
+	if err != nil {
+		return err
+	}
+	opts := metav1.CreateOptions{
+		DryRun:          []string{metav1.DryRunAll},
+		FieldValidation: fieldValidationStrict,
+	}
+	var got *redisfailoverv1.RedisFailover
+	err = r.write(namespace, probe.Name, func(cli redisfailoverclientset.Interface) error {
+		var err error
+		got, err = cli.DatabasesV1().RedisFailovers(namespace).Create(ctx, probe, opts)
+		return recordMetrics(ctx, namespace, "RedisFailover", probe.Name, "CREATE", err, r.metricsRecorder)
+	})
+
+	var mismatch error
+	switch {
+	case isStrictValidationError(err):
+		mismatch = err
+	case err != nil:
+		r.logger.WithField("namespace", namespace).Warnf("could not check the redisfailover CRD schema: %s", err)
+		return err
+	case !equality.Semantic.DeepEqual(probe.Spec, got.Spec):
+		mismatch = fmt.Errorf("fields of the spec were pruned by the apiserver")
+	}
+
+	if mismatch != nil {
+		r.metricsRecorder.SetRedisFailoverSchemaMismatch(true)
+		r.logger.Errorf("the installed redisfailover CRD does not match the operator version, update the CRD: %s", mismatch)
+		return fmt.Errorf("redisfailover CRD schema mismatch: %w", mismatch)
+	}
+
+	r.metricsRecorder.SetRedisFailoverSchemaMismatch(false)
+	return nil
+}
+
+// write calls fn with a client no other write is using and logs the apiserver warnings it received with
+// the identity of the redisfailover.
+func (r *RedisFailoverService) write(namespace string, name string, fn func(cli redisfailoverclientset.Interface) error) error {
+	cli, err := r.writes.get()
+	if err != nil {
+		return err
+	}
+	defer r.writes.put(cli)
+	err = fn(cli.Client)
+	r.logWarnings(namespace, name, cli.Warnings)
+	return err
+}
+
+// logWarnings logs the apiserver warnings received by the handler with the identity of the redisfailover.
+func (r *RedisFailoverService) logWarnings(namespace string, name string, warnings *WarningHandler) {
+	for _, warning := range warnings.Flush() {
+		r.logger.WithField("namespace", namespace).WithField("redisfailover", name).Warnf("apiserver warning: %s", warning)
+	}
+}
+
+// isStrictValidationError returns true if the error is an apiserver rejection due to unknown or duplicated fields.
+func isStrictValidationError(err error) bool {
+	if !errors.IsBadRequest(err) && !errors.IsInvalid(err) {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "strict decoding error") || strings.Contains(msg, "unknown field")
+}
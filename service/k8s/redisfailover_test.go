@@ -0,0 +1,309 @@
+package k8s_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubetesting "k8s.io/client-go/testing"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	redisfailoverfake "redis-operator/client/k8s/clientset/versioned/fake"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	mLog "redis-operator/mocks/log"
+	"redis-operator/service/k8s"
+)
+
+func newStrictValidationError() error {
+	return kubeerrors.NewBadRequest(`strict decoding error: unknown field "spec.bootstrapNode"`)
+}
+
+func TestRedisFailoverServiceWriteWarnings(t *testing.T) {
+	testns := "testns"
+	testRF := &redisfailoverv1.RedisFailover{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: testns,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		verb     string
+		status   bool
+		warnings []string
+		err      error
+		expErr   bool
+	}{
+		{
+			name: "Creating without warnings should not log anything.",
+			verb: "create",
+		},
+		{
+			name:     "Creating with warnings should log all of them.",
+			verb:     "create",
+			warnings: []string{"unknown field \"spec.foo\"", "duplicate field \"spec.bar\""},
+		},
+		{
+			name:     "Updating with warnings should log all of them.",
+			verb:     "update",
+			warnings: []string{"unknown field \"spec.foo\""},
+		},
+		{
+			name:     "Updating the status with warnings should log all of them.",
+			verb:     "update",
+			status:   true,
+			warnings: []string{"unknown field \"status.foo\""},
+		},
+		{
+			name:     "Updating with a strict validation error should return the error and log the warnings.",
+			verb:     "update",
+			warnings: []string{"unknown field \"spec.foo\""},
+			err:      newStrictValidationError(),
+			expErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			warnings := k8s.NewWarningHandler()
+			mcli := &redisfailoverfake.Clientset{}
+			mcli.AddReactor(test.verb, "redisfailovers", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				// The rest client calls the warning handler before returning the response.
+				for _, w := range test.warnings {
+					warnings.HandleWarningHeader(299, "-", w)
+				}
+				return true, testRF, test.err
+			})
+
+			mlogger := &mLog.Logger{}
+			mlogger.On("With", mock.Anything, mock.Anything).Return(mlogger)
+			mlogger.On("WithField", "namespace", testns).Return(mlogger)
+			mlogger.On("WithField", "redisfailover", testRF.Name).Return(mlogger)
+			mlogger.On("Debugf", mock.Anything).Maybe()
+			for _, w := range test.warnings {
+				mlogger.On("Warnf", "apiserver warning: %s", w).Once()
+			}
+
+			writes := k8s.NewRedisFailoverWriteClients(func() (*k8s.RedisFailoverWriteClient, error) {
+				return &k8s.RedisFailoverWriteClient{Client: mcli, Warnings: warnings}, nil
+			})
+			service := k8s.NewRedisFailoverService(mcli, writes, mlogger, metrics.Dummy)
+			var err error
+			switch {
+			case test.verb == "create":
+				_, err = service.CreateRedisFailover(context.TODO(), testns, testRF)
+			case test.status:
+				_, err = service.UpdateRedisFailoverStatus(context.TODO(), testns, testRF)
+			default:
+				_, err = service.UpdateRedisFailover(context.TODO(), testns, testRF)
+			}
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			mlogger.AssertExpectations(t)
+			assert.Empty(warnings.Flush())
+		})
+	}
+}
+
+func TestRedisFailoverServiceConcurrentWriteWarnings(t *testing.T) {
+	assert := assert.New(t)
+
+	names := []string{"a", "b"}
+	// Every write waits for the other one to be sent before returning, they are never serialized.
+	var sent sync.WaitGroup
+	sent.Add(len(names))
+	var created int32
+	writes := k8s.NewRedisFailoverWriteClients(func() (*k8s.RedisFailoverWriteClient, error) {
+		atomic.AddInt32(&created, 1)
+		warnings := k8s.NewWarningHandler()
+		mcli := &redisfailoverfake.Clientset{}
+		mcli.AddReactor("update", "redisfailovers", func(action kubetesting.Action) (bool, runtime.Object, error) {
+			rf := action.(kubetesting.UpdateAction).GetObject().(*redisfailoverv1.RedisFailover)
+			warnings.HandleWarningHeader(299, "-", "warning of "+rf.Name)
+			sent.Done()
+			sent.Wait()
+			return true, rf, nil
+		})
+		return &k8s.RedisFailoverWriteClient{Client: mcli, Warnings: warnings}, nil
+	})
+
+	mlogger := &mLog.Logger{}
+	mlogger.On("With", mock.Anything, mock.Anything).Return(mlogger)
+	mlogger.On("WithField", "namespace", "testns").Return(mlogger)
+	rfLoggers := map[string]*mLog.Logger{}
+	for _, name := range names {
+		rfLogger := &mLog.Logger{}
+		rfLogger.On("Debugf", mock.Anything).Maybe()
+		rfLogger.On("Warnf", "apiserver warning: %s", "warning of "+name).Once()
+		mlogger.On("WithField", "redisfailover", name).Return(rfLogger)
+		rfLoggers[name] = rfLogger
+	}
+
+	service := k8s.NewRedisFailoverService(&redisfailoverfake.Clientset{}, writes, mlogger, metrics.Dummy)
+	var done sync.WaitGroup
+	for _, name := range names {
+		done.Add(1)
+		go func(name string) {
+			defer done.Done()
+			rf := &redisfailoverv1.RedisFailover{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "testns"}}
+			_, err := service.UpdateRedisFailover(context.TODO(), "testns", rf)
+			assert.NoError(err)
+		}(name)
+	}
+	done.Wait()
+
+	// Each write got a client of its own, the warnings are logged with the RF that received them.
+	assert.Equal(int32(len(names)), atomic.LoadInt32(&created))
+	for _, rfLogger := range rfLoggers {
+		rfLogger.AssertExpectations(t)
+	}
+}
+
+func TestRedisFailoverServiceCheckSchema(t *testing.T) {
+	testns := "testns"
+
+	tests := []struct {
+		name    string
+		reactor func(rf *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error)
+		expErr  bool
+	}{
+		{
+			name: "A CRD that keeps all the fields should not report a mismatch.",
+			reactor: func(rf *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+				return rf, nil
+			},
+			expErr: false,
+		},
+		{
+			name: "A CRD that rejects unknown fields should report a mismatch.",
+			reactor: func(rf *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+				return nil, newStrictValidationError()
+			},
+			expErr: true,
+		},
+		{
+			name: "A CRD that prunes unknown fields should report a mismatch.",
+			reactor: func(rf *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+				pruned := rf.DeepCopy()
+				pruned.Spec.BootstrapNode = nil
+				return pruned, nil
+			},
+			expErr: true,
+		},
+		{
+			name: "An error calling the apiserver should be returned.",
+			reactor: func(rf *redisfailoverv1.RedisFailover) (*redisfailoverv1.RedisFailover, error) {
+				return nil, kubeerrors.NewForbidden(redisfailoverv1.SchemeGroupVersion.WithResource("redisfailovers").GroupResource(), rf.Name, errors.New("wanted error"))
+			},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mcli := redisfailoverfake.NewSimpleClientset()
+			mcli.PrependReactor("create", "redisfailovers", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				rf := action.(kubetesting.CreateAction).GetObject().(*redisfailoverv1.RedisFailover)
+				got, err := test.reactor(rf)
+				return true, got, err
+			})
+
+			service := k8s.NewRedisFailoverService(mcli, nil, log.Dummy, metrics.Dummy)
+			err := service.CheckRedisFailoverSchema(context.TODO(), testns)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			// Nothing but the dry run is sent.
+			if assert.Len(mcli.Actions(), 1) {
+				assert.Equal("create", mcli.Actions()[0].GetVerb())
+			}
+		})
+	}
+}
+
+func TestRedisFailoverServiceWritesWithWriteClient(t *testing.T) {
+	assert := assert.New(t)
+
+	testRF := &redisfailoverv1.RedisFailover{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "testns"}}
+	readCli := redisfailoverfake.NewSimpleClientset(testRF)
+	writeCli := redisfailoverfake.NewSimpleClientset(testRF)
+	warnings := k8s.NewWarningHandler()
+
+	writes := k8s.NewRedisFailoverWriteClients(func() (*k8s.RedisFailoverWriteClient, error) {
+		return &k8s.RedisFailoverWriteClient{Client: writeCli, Warnings: warnings}, nil
+	})
+	service := k8s.NewRedisFailoverService(readCli, writes, log.Dummy, metrics.Dummy)
+	_, err := service.ListRedisFailovers(context.TODO(), "testns", metav1.ListOptions{})
+	assert.NoError(err)
+	_, err = service.UpdateRedisFailover(context.TODO(), "testns", testRF)
+	assert.NoError(err)
+
+	// The warnings of the write client only come from the writes.
+	verbs := func(actions []kubetesting.Action) []string {
+		got := []string{}
+		for _, action := range actions {
+			got = append(got, action.GetVerb())
+		}
+		return got
+	}
+	assert.Equal([]string{"list"}, verbs(readCli.Actions()))
+	assert.Equal([]string{"update"}, verbs(writeCli.Actions()))
+}
+
+func TestRedisFailoverServicePatchFinalizers(t *testing.T) {
+	tests := []struct {
+		name       string
+		finalizers []string
+		expPatch   string
+	}{
+		{
+			name:       "The finalizers are patched with the resource version",
+			finalizers: []string{"databases.spotahome.com/ordered-teardown"},
+			expPatch:   `{"metadata":{"finalizers":["databases.spotahome.com/ordered-teardown"],"resourceVersion":"7"}}`,
+		},
+		{
+			name:     "No finalizer removes them all",
+			expPatch: `{"metadata":{"finalizers":[],"resourceVersion":"7"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			patched := &redisfailoverv1.RedisFailover{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "testns", ResourceVersion: "8"}}
+			var patch string
+			mcli := &redisfailoverfake.Clientset{}
+			mcli.AddReactor("patch", "redisfailovers", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				patch = string(action.(kubetesting.PatchAction).GetPatch())
+				return true, patched, nil
+			})
+
+			service := k8s.NewRedisFailoverService(mcli, nil, log.Dummy, metrics.Dummy)
+			rf, err := service.PatchRedisFailoverFinalizers(context.TODO(), "testns", "test", "7", test.finalizers)
+			assert.NoError(err)
+			assert.Equal(patched, rf)
+			assert.JSONEq(test.expPatch, patch)
+		})
+	}
+}
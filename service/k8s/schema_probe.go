@@ -0,0 +1,153 @@
+package k8s
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// schemaProbeName is the name of the synthetic object used to check the installed CRD schema.
+const schemaProbeName = "rf-schema-probe"
+
+// schemaProbeFields are the values of the fields of the operator types the CRD only accepts some values
+// for, by type and field name.
+var schemaProbeFields = map[string]interface{}{
+	"RedisPodDisruptionBudget.UnhealthyPodEvictionPolicy": redisfailoverv1.UnhealthyPodEvictionAlwaysAllow,
+}
+
+// schemaProbeValues returns the values of the kubernetes types used in the operator types. They only need
+// to be accepted by the apiserver, their schema comes with them.
+func schemaProbeValues() map[reflect.Type]interface{} {
+	enabled := true
+	labels := map[string]string{"probe": "probe"}
+	values := []interface{}{
+		corev1.PullIfNotPresent,
+		corev1.DNSClusterFirstWithHostNet,
+		corev1.IPFamilyPolicySingleStack,
+		corev1.IPv4Protocol,
+		corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+		},
+		corev1.EmptyDirVolumeSource{},
+		corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		},
+		corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		corev1.Container{Name: "probe", Image: "probe:probe"},
+		corev1.SecurityContext{RunAsNonRoot: &enabled},
+		corev1.PodSecurityContext{RunAsNonRoot: &enabled},
+		corev1.EnvVar{Name: "PROBE", Value: "probe"},
+		corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+					Weight:          100,
+					PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname"},
+				}},
+			},
+		},
+		corev1.LocalObjectReference{Name: "probe"},
+		corev1.Toleration{Key: "probe", Operator: corev1.TolerationOpExists},
+		corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       "topology.kubernetes.io/zone",
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+		},
+		corev1.Volume{Name: "probe", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		corev1.VolumeMount{Name: "probe", MountPath: "/probe"},
+		networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{MatchLabels: labels}},
+		metav1.LabelSelector{MatchLabels: labels},
+		metav1.Duration{Duration: time.Second},
+		// The type of the embedded objects is not set by the users.
+		metav1.TypeMeta{},
+	}
+	byType := map[reflect.Type]interface{}{}
+	for _, value := range values {
+		byType[reflect.TypeOf(value)] = value
+	}
+	return byType
+}
+
+// newRedisFailoverSchemaProbe returns the synthetic redisfailover used to check the installed CRD schema.
+// Every field of the spec is set, walking into the operator types, so the fields added to them are
+// checked without being listed anywhere. A kubernetes type without a value in schemaProbeValues is an
+// error.
+func newRedisFailoverSchemaProbe(namespace string) (*redisfailoverv1.RedisFailover, error) {
+	probe := &redisfailoverv1.RedisFailover{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      schemaProbeName,
+			Namespace: namespace,
+		},
+	}
+	if err := fillSchemaProbe(reflect.ValueOf(&probe.Spec).Elem(), schemaProbeValues()); err != nil {
+		return nil, fmt.Errorf("could not build the redisfailover CRD schema probe: %w", err)
+	}
+	return probe, nil
+}
+
+// fillSchemaProbe sets v and everything it holds: a single element in the slices and the maps, a
+// placeholder in the basic types and the values of the kubernetes types.
+func fillSchemaProbe(v reflect.Value, values map[reflect.Type]interface{}) error {
+	t := v.Type()
+	if value, ok := values[t]; ok {
+		v.Set(reflect.ValueOf(value))
+		return nil
+	}
+	if t.PkgPath() != "" && t.PkgPath() != reflect.TypeOf(redisfailoverv1.RedisFailover{}).PkgPath() {
+		return fmt.Errorf("no value for the type %s", t)
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(t.Elem()))
+		return fillSchemaProbe(v.Elem(), values)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(t, 1, 1))
+		return fillSchemaProbe(v.Index(0), values)
+	case reflect.Map:
+		key := reflect.New(t.Key()).Elem()
+		if err := fillSchemaProbe(key, values); err != nil {
+			return err
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if err := fillSchemaProbe(elem, values); err != nil {
+			return err
+		}
+		v.Set(reflect.MakeMap(t))
+		v.SetMapIndex(key, elem)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() || strings.Split(field.Tag.Get("json"), ",")[0] == "-" {
+				continue
+			}
+			if value, ok := schemaProbeFields[t.Name()+"."+field.Name]; ok {
+				v.Field(i).Set(reflect.ValueOf(value).Convert(field.Type))
+				continue
+			}
+			if err := fillSchemaProbe(v.Field(i), values); err != nil {
+				return fmt.Errorf("%s: %w", field.Name, err)
+			}
+		}
+	case reflect.String:
+		v.SetString("probe")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	default:
+		return fmt.Errorf("no value for the type %s", t)
+	}
+	return nil
+}
@@ -2,17 +2,23 @@ package k8s
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 
 	"redis-operator/log"
 	"redis-operator/metrics"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
 // Secret interacts with k8s to get secrets
 type Secret interface {
-	GetSecret(namespace, name string) (*corev1.Secret, error)
+	GetSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error)
+	CreateSecret(ctx context.Context, namespace string, secret *corev1.Secret) error
+	CreateIfNotExistsSecret(ctx context.Context, namespace string, secret *corev1.Secret) error
+	UpdateSecret(ctx context.Context, namespace string, secret *corev1.Secret) error
 }
 
 // SecretService is the secret service implementation using API calls to kubernetes.
@@ -32,13 +38,57 @@ func NewSecretService(kubeClient kubernetes.Interface, logger log.Logger, metric
 	}
 }
 
-func (s *SecretService) GetSecret(namespace, name string) (*corev1.Secret, error) {
+func (s *SecretService) GetSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
 
-	secret, err := s.kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
-	recordMetrics(namespace, "Secret", name, "GET", err, s.metricsRecorder)
+	secret, err := s.kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "Secret", name, "GET", err, s.metricsRecorder)
 	if err != nil {
 		return nil, err
 	}
 
 	return secret, err
 }
+
+func (s *SecretService) CreateSecret(ctx context.Context, namespace string, secret *corev1.Secret) error {
+	_, err := s.kubeClient.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "Secret", secret.GetName(), "CREATE", err, s.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	s.logger.WithField("namespace", namespace).WithField("secret", secret.Name).Infof("secret created")
+	return nil
+}
+
+// CreateIfNotExistsSecret creates the secret unless it exists, an existing secret is never changed.
+func (s *SecretService) CreateIfNotExistsSecret(ctx context.Context, namespace string, secret *corev1.Secret) error {
+	if _, err := s.GetSecret(ctx, namespace, secret.Name); err != nil {
+		// If no resource we need to create.
+		if errors.IsNotFound(err) {
+			return s.CreateSecret(ctx, namespace, secret)
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateSecret updates the secret at its resource version, it fails with a conflict when the secret was
+// changed since it was read.
+func (s *SecretService) UpdateSecret(ctx context.Context, namespace string, secret *corev1.Secret) error {
+	_, err := s.kubeClient.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	err = recordMetrics(ctx, namespace, "Secret", secret.GetName(), "UPDATE", err, s.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	s.logger.WithField("namespace", namespace).WithField("secret", secret.Name).Infof("secret updated")
+	return nil
+}
+
+// GenerateRandomSecret returns size random bytes from the system's secure generator, hex encoded so
+// they can be written in the redis configuration as they are.
+func GenerateRandomSecret(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
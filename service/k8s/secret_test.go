@@ -4,10 +4,10 @@ import (
 	"context"
 	"testing"
 
-	"redis-operator/log"
-	"redis-operator/metrics"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	"redis-operator/log"
+	"redis-operator/metrics"
 
 	errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -48,13 +48,54 @@ func TestSecretServiceGet(t *testing.T) {
 
 		// test getting the secret
 		service := NewSecretService(mcli, log.Dummy, metrics.Dummy)
-		ss, err := service.GetSecret(secret.ObjectMeta.Namespace, secret.ObjectMeta.Name)
+		ss, err := service.GetSecret(context.TODO(), secret.ObjectMeta.Namespace, secret.ObjectMeta.Name)
 		assert.NotNil(ss)
 		assert.NoError(err)
 
 		// test getting a nonexistent secret
-		_, err = service.GetSecret(secret.ObjectMeta.Namespace, secret.ObjectMeta.Name+"nonexistent")
+		_, err = service.GetSecret(context.TODO(), secret.ObjectMeta.Namespace, secret.ObjectMeta.Name+"nonexistent")
 		assert.Error(err)
 		assert.True(errors.IsNotFound(err))
 	})
 }
+
+func TestSecretServiceCreateIfNotExists(t *testing.T) {
+	assert := assert.New(t)
+
+	stored := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "stored", Namespace: "test_namespace"},
+		Data:       map[string][]byte{"password": []byte("stored")},
+	}
+	mcli := kubernetes.NewSimpleClientset(stored)
+	service := NewSecretService(mcli, log.Dummy, metrics.Dummy)
+
+	// A missing secret is created.
+	created := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "created", Namespace: "test_namespace"},
+		Data:       map[string][]byte{"password": []byte("created")},
+	}
+	assert.NoError(service.CreateIfNotExistsSecret(context.TODO(), "test_namespace", created))
+	got, err := service.GetSecret(context.TODO(), "test_namespace", "created")
+	assert.NoError(err)
+	assert.Equal("created", string(got.Data["password"]))
+
+	// An existing secret is kept as it is.
+	replaced := stored.DeepCopy()
+	replaced.Data["password"] = []byte("replaced")
+	assert.NoError(service.CreateIfNotExistsSecret(context.TODO(), "test_namespace", replaced))
+	got, err = service.GetSecret(context.TODO(), "test_namespace", "stored")
+	assert.NoError(err)
+	assert.Equal("stored", string(got.Data["password"]))
+}
+
+func TestGenerateRandomSecret(t *testing.T) {
+	assert := assert.New(t)
+
+	first, err := GenerateRandomSecret(32)
+	assert.NoError(err)
+	assert.Regexp(`^[0-9a-f]{64}$`, first)
+
+	second, err := GenerateRandomSecret(32)
+	assert.NoError(err)
+	assert.NotEqual(first, second)
+}
@@ -2,47 +2,66 @@ package k8s
 
 import (
 	"context"
+	"sync"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"redis-operator/log"
 	"redis-operator/metrics"
 )
 
+// dualStackProbeName is the name of the Service created with a dry-run to discover whether the cluster
+// supports the dual-stack Services.
+const dualStackProbeName = "redis-operator-dual-stack-probe"
+
+// DualStackServices forces whether the cluster supports the dual-stack Services, set by the
+// --dual-stack-services flag. It's discovered when nil, see SupportsDualStack.
+var DualStackServices *bool
+
 // Service the ServiceAccount service that knows how to interact with k8s to manage them
 type Service interface {
-	GetService(namespace string, name string) (*corev1.Service, error)
-	CreateService(namespace string, service *corev1.Service) error
-	CreateIfNotExistsService(namespace string, service *corev1.Service) error
-	UpdateService(namespace string, service *corev1.Service) error
-	CreateOrUpdateService(namespace string, service *corev1.Service) error
-	DeleteService(namespace string, name string) error
-	ListServices(namespace string) (*corev1.ServiceList, error)
+	GetService(ctx context.Context, namespace string, name string) (*corev1.Service, error)
+	CreateService(ctx context.Context, namespace string, service *corev1.Service) error
+	CreateIfNotExistsService(ctx context.Context, namespace string, service *corev1.Service) error
+	UpdateService(ctx context.Context, namespace string, service *corev1.Service) error
+	CreateOrUpdateService(ctx context.Context, namespace string, service *corev1.Service) error
+	// ApplyService writes the service with a server-side apply, see FieldManager.
+	ApplyService(ctx context.Context, namespace string, service *corev1.Service) error
+	DeleteService(ctx context.Context, namespace string, name string) error
+	ListServices(ctx context.Context, namespace string) (*corev1.ServiceList, error)
 }
 
 // ServiceService is the service service implementation using API calls to kubernetes.
 type ServiceService struct {
 	kubeClient      kubernetes.Interface
+	conflictRetries int
 	logger          log.Logger
 	metricsRecorder metrics.Recorder
+
+	// dualStack tells whether the cluster supports the dual-stack Services, discovered on the first
+	// service asking for an IP family.
+	mu        sync.Mutex
+	dualStack *bool
 }
 
 // NewServiceService returns a new Service KubeService.
-func NewServiceService(kubeClient kubernetes.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *ServiceService {
+func NewServiceService(kubeClient kubernetes.Interface, conflictRetries int, logger log.Logger, metricsRecorder metrics.Recorder) *ServiceService {
 	logger = logger.With("service", "k8s.service")
 	return &ServiceService{
 		kubeClient:      kubeClient,
+		conflictRetries: conflictRetries,
 		logger:          logger,
 		metricsRecorder: metricsRecorder,
 	}
 }
 
-func (s *ServiceService) GetService(namespace string, name string) (*corev1.Service, error) {
-	service, err := s.kubeClient.CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{})
-	recordMetrics(namespace, "Service", name, "GET", err, s.metricsRecorder)
+func (s *ServiceService) GetService(ctx context.Context, namespace string, name string) (*corev1.Service, error) {
+	service, err := s.kubeClient.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "Service", name, "GET", err, s.metricsRecorder)
 	if err != nil {
 		log.Errorf("Error while getting service %v in %v namespace : %v", name, namespace, err)
 		return nil, err
@@ -50,9 +69,12 @@ func (s *ServiceService) GetService(namespace string, name string) (*corev1.Serv
 	return service, err
 }
 
-func (s *ServiceService) CreateService(namespace string, service *corev1.Service) error {
-	_, err := s.kubeClient.CoreV1().Services(namespace).Create(context.TODO(), service, metav1.CreateOptions{})
-	recordMetrics(namespace, "Service", service.GetName(), "CREATE", err, s.metricsRecorder)
+func (s *ServiceService) CreateService(ctx context.Context, namespace string, service *corev1.Service) error {
+	if err := s.dropIPFamilies(ctx, namespace, service); err != nil {
+		return err
+	}
+	_, err := s.kubeClient.CoreV1().Services(namespace).Create(ctx, service, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "Service", service.GetName(), "CREATE", err, s.metricsRecorder)
 	if err != nil {
 		return err
 	}
@@ -60,32 +82,42 @@ func (s *ServiceService) CreateService(namespace string, service *corev1.Service
 	return nil
 }
 
-func (s *ServiceService) CreateIfNotExistsService(namespace string, service *corev1.Service) error {
-	if _, err := s.GetService(namespace, service.Name); err != nil {
+func (s *ServiceService) CreateIfNotExistsService(ctx context.Context, namespace string, service *corev1.Service) error {
+	if _, err := s.GetService(ctx, namespace, service.Name); err != nil {
 		// If no resource we need to create.
 		if errors.IsNotFound(err) {
-			return s.CreateService(namespace, service)
+			return s.CreateService(ctx, namespace, service)
 		}
 		return err
 	}
 	return nil
 }
 
-func (s *ServiceService) UpdateService(namespace string, service *corev1.Service) error {
-	_, err := s.kubeClient.CoreV1().Services(namespace).Update(context.TODO(), service, metav1.UpdateOptions{})
-	recordMetrics(namespace, "Service", service.GetName(), "UPDATE", err, s.metricsRecorder)
+func (s *ServiceService) UpdateService(ctx context.Context, namespace string, service *corev1.Service) error {
+	if err := s.dropIPFamilies(ctx, namespace, service); err != nil {
+		return err
+	}
+	err := updateOnConflict(s.conflictRetries, namespace, "Service", service.GetName(), s.metricsRecorder, service, func() (*corev1.Service, error) {
+		return s.GetService(ctx, namespace, service.Name)
+	}, func(stored *corev1.Service) {
+		setDesiredMeta(stored, service)
+		stored.Spec = service.Spec
+	}, func(service *corev1.Service) error {
+		_, err := s.kubeClient.CoreV1().Services(namespace).Update(ctx, service, metav1.UpdateOptions{})
+		return recordMetrics(ctx, namespace, "Service", service.GetName(), "UPDATE", err, s.metricsRecorder)
+	})
 	if err != nil {
 		return err
 	}
 	s.logger.WithField("namespace", namespace).WithField("serviceName", service.Name).Infof("service updated")
 	return nil
 }
-func (s *ServiceService) CreateOrUpdateService(namespace string, service *corev1.Service) error {
-	storedService, err := s.GetService(namespace, service.Name)
+func (s *ServiceService) CreateOrUpdateService(ctx context.Context, namespace string, service *corev1.Service) error {
+	storedService, err := s.GetService(ctx, namespace, service.Name)
 	if err != nil {
 		// If no resource we need to create.
 		if errors.IsNotFound(err) {
-			return s.CreateService(namespace, service)
+			return s.CreateService(ctx, namespace, service)
 		}
 		log.Errorf("Error while updating service %v in %v namespace : %v", service.GetName(), namespace, err)
 		return err
@@ -96,18 +128,90 @@ func (s *ServiceService) CreateOrUpdateService(namespace string, service *corev1
 	// namespace is our spec(https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#concurrency-control-and-consistency),
 	// we will replace the current namespace state.
 	service.ResourceVersion = storedService.ResourceVersion
-	return s.UpdateService(namespace, service)
+	return s.UpdateService(ctx, namespace, service)
+}
+
+// ApplyService writes the service with a server-side apply of the operator field manager, creating it
+// when it does not exist. The cluster IP allocated to the service is not set by the operator, it's kept.
+func (s *ServiceService) ApplyService(ctx context.Context, namespace string, service *corev1.Service) error {
+	if err := s.dropIPFamilies(ctx, namespace, service); err != nil {
+		return err
+	}
+	config := corev1ac.Service(service.Name, namespace)
+	if err := toApplyConfiguration(service, config); err != nil {
+		return err
+	}
+	_, err := s.kubeClient.CoreV1().Services(namespace).Apply(ctx, config, applyOptions)
+	err = recordMetrics(ctx, namespace, "Service", service.Name, "APPLY", err, s.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	s.logger.WithField("namespace", namespace).WithField("serviceName", service.Name).Debugf("service applied")
+	return nil
+}
+
+// SupportsDualStack returns true when the cluster supports the dual-stack Services. Unless forced by
+// DualStackServices, it's discovered on the first call by creating a RequireDualStack Service in the
+// namespace with a dry-run, the single-stack clusters refuse it as invalid. It's retried on the next
+// call when it fails.
+func (s *ServiceService) SupportsDualStack(ctx context.Context, namespace string) (bool, error) {
+	if DualStackServices != nil {
+		return *DualStackServices, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dualStack != nil {
+		return *s.dualStack, nil
+	}
+	requireDualStack := corev1.IPFamilyPolicyRequireDualStack
+	probe := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: dualStackProbeName},
+		Spec: corev1.ServiceSpec{
+			IPFamilyPolicy: &requireDualStack,
+			Ports:          []corev1.ServicePort{{Port: 6379}},
+		},
+	}
+	_, err := s.kubeClient.CoreV1().Services(namespace).Create(ctx, probe, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	// The probe already existing passed the validation.
+	supported := err == nil || errors.IsAlreadyExists(err)
+	if !supported && !errors.IsInvalid(err) {
+		return false, err
+	}
+	s.dualStack = &supported
+	if !supported {
+		s.logger.Warnf("the cluster does not support the dual-stack Services, their ipFamilyPolicy and ipFamilies are not set")
+	}
+	return supported, nil
+}
+
+// dropIPFamilies removes the ipFamilyPolicy and the ipFamilies of the service on the clusters not
+// supporting the dual-stack Services, where the ipFamilyPolicy fails the service. The ones of a
+// stored service are kept by an update without them.
+func (s *ServiceService) dropIPFamilies(ctx context.Context, namespace string, service *corev1.Service) error {
+	if service.Spec.IPFamilyPolicy == nil && len(service.Spec.IPFamilies) == 0 {
+		return nil
+	}
+	supported, err := s.SupportsDualStack(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		service.Spec.IPFamilyPolicy = nil
+		service.Spec.IPFamilies = nil
+	}
+	return nil
 }
 
-func (s *ServiceService) DeleteService(namespace string, name string) error {
+func (s *ServiceService) DeleteService(ctx context.Context, namespace string, name string) error {
 	propagation := metav1.DeletePropagationForeground
-	err := s.kubeClient.CoreV1().Services(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{PropagationPolicy: &propagation})
-	recordMetrics(namespace, "Service", name, "DELETE", err, s.metricsRecorder)
+	err := s.kubeClient.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	err = recordMetrics(ctx, namespace, "Service", name, "DELETE", err, s.metricsRecorder)
 	return err
 }
 
-func (s *ServiceService) ListServices(namespace string) (*corev1.ServiceList, error) {
-	serviceList, err := s.kubeClient.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
-	recordMetrics(namespace, "Service", metrics.NOT_APPLICABLE, "LIST", err, s.metricsRecorder)
+func (s *ServiceService) ListServices(ctx context.Context, namespace string) (*corev1.ServiceList, error) {
+	serviceList, err := s.kubeClient.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	err = recordMetrics(ctx, namespace, "Service", metrics.NOT_APPLICABLE, "LIST", err, s.metricsRecorder)
 	return serviceList, err
 }
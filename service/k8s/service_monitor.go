@@ -0,0 +1,139 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+)
+
+// ServiceMonitorGVR is the resource of the prometheus-operator service monitors. Like the certificates,
+// they are handled as unstructured objects so the operator doesn't depend on the prometheus-operator
+// client.
+var ServiceMonitorGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors"}
+
+// ErrServiceMonitorsUnavailable is returned when the prometheus-operator CRDs are not installed in the cluster.
+var ErrServiceMonitorsUnavailable = errors.New("the monitoring.coreos.com/v1 ServiceMonitor CRD is not installed")
+
+// ServiceMonitor the service that knows how to interact with k8s to manage the prometheus-operator service monitors
+type ServiceMonitor interface {
+	GetServiceMonitor(ctx context.Context, namespace, name string) (*unstructured.Unstructured, error)
+	CreateOrUpdateServiceMonitor(ctx context.Context, namespace string, serviceMonitor *unstructured.Unstructured) error
+	DeleteServiceMonitor(ctx context.Context, namespace, name string) error
+}
+
+// ServiceMonitorService is the ServiceMonitor service implementation using the dynamic client.
+type ServiceMonitorService struct {
+	dynamicClient   dynamic.Interface
+	conflictRetries int
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
+	// discovery tells whether the cluster serves the service monitors.
+	discovery *crdDiscovery
+}
+
+// NewServiceMonitorService returns a new ServiceMonitor KubeService. The dynamic client can be nil for
+// the commands that don't manage service monitors, then the CRD is reported as not installed.
+func NewServiceMonitorService(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, conflictRetries int, logger log.Logger, metricsRecorder metrics.Recorder) *ServiceMonitorService {
+	logger = logger.With("service", "k8s.serviceMonitor")
+	return &ServiceMonitorService{
+		dynamicClient:   dynamicClient,
+		conflictRetries: conflictRetries,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+		discovery:       &crdDiscovery{kubeClient: kubeClient, gvr: ServiceMonitorGVR},
+	}
+}
+
+// available returns ErrServiceMonitorsUnavailable when the cluster doesn't serve the service monitors.
+func (s *ServiceMonitorService) available() error {
+	if s.dynamicClient == nil {
+		return ErrServiceMonitorsUnavailable
+	}
+	served, discovered, err := s.discovery.served()
+	if err != nil {
+		return err
+	}
+	if !served {
+		if discovered {
+			s.logger.Warnf("the cluster serves no prometheus-operator service monitors, the service monitors of the exporters are not written")
+		}
+		return ErrServiceMonitorsUnavailable
+	}
+	return nil
+}
+
+func (s *ServiceMonitorService) GetServiceMonitor(ctx context.Context, namespace, name string) (*unstructured.Unstructured, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	serviceMonitor, err := s.dynamicClient.Resource(ServiceMonitorGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "ServiceMonitor", name, "GET", err, s.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return serviceMonitor, nil
+}
+
+// CreateOrUpdateServiceMonitor will update the service monitor or create it if does not exist. Nothing
+// is written while the desired service monitor has the hash of the stored one.
+func (s *ServiceMonitorService) CreateOrUpdateServiceMonitor(ctx context.Context, namespace string, serviceMonitor *unstructured.Unstructured) error {
+	stored, err := s.GetServiceMonitor(ctx, namespace, serviceMonitor.GetName())
+	if err != nil {
+		// If no resource we need to create.
+		if apierrors.IsNotFound(err) {
+			if _, err := setSpecHash(serviceMonitor, serviceMonitor.Object["spec"]); err != nil {
+				return err
+			}
+			_, err = s.dynamicClient.Resource(ServiceMonitorGVR).Namespace(namespace).Create(ctx, serviceMonitor, metav1.CreateOptions{})
+			err = recordMetrics(ctx, namespace, "ServiceMonitor", serviceMonitor.GetName(), "CREATE", err, s.metricsRecorder)
+			if err != nil {
+				return err
+			}
+			s.logger.WithField("namespace", namespace).WithField("serviceMonitor", serviceMonitor.GetName()).Infof("service monitor created")
+			return nil
+		}
+		return err
+	}
+
+	storedHash := stored.GetAnnotations()[SpecHashAnnotation]
+	hash, err := setSpecHash(serviceMonitor, serviceMonitor.Object["spec"])
+	if err != nil {
+		return err
+	}
+	if storedHash == hash {
+		return nil
+	}
+
+	serviceMonitor.SetResourceVersion(stored.GetResourceVersion())
+	err = updateOnConflict(s.conflictRetries, namespace, "ServiceMonitor", serviceMonitor.GetName(), s.metricsRecorder, serviceMonitor, func() (*unstructured.Unstructured, error) {
+		return s.GetServiceMonitor(ctx, namespace, serviceMonitor.GetName())
+	}, func(stored *unstructured.Unstructured) {
+		setDesiredMeta(stored, serviceMonitor)
+		stored.Object["spec"] = serviceMonitor.Object["spec"]
+	}, func(serviceMonitor *unstructured.Unstructured) error {
+		_, err := s.dynamicClient.Resource(ServiceMonitorGVR).Namespace(namespace).Update(ctx, serviceMonitor, metav1.UpdateOptions{})
+		return recordMetrics(ctx, namespace, "ServiceMonitor", serviceMonitor.GetName(), "UPDATE", err, s.metricsRecorder)
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.WithField("namespace", namespace).WithField("serviceMonitor", serviceMonitor.GetName()).Infof("service monitor updated")
+	return nil
+}
+
+func (s *ServiceMonitorService) DeleteServiceMonitor(ctx context.Context, namespace, name string) error {
+	if err := s.available(); err != nil {
+		return err
+	}
+	err := s.dynamicClient.Resource(ServiceMonitorGVR).Namespace(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	return recordMetrics(ctx, namespace, "ServiceMonitor", name, "DELETE", err, s.metricsRecorder)
+}
@@ -0,0 +1,105 @@
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+func newPrometheusOperatorKubeClient() *kubernetes.Clientset {
+	client := kubernetes.NewSimpleClientset()
+	client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: k8s.ServiceMonitorGVR.GroupVersion().String(),
+			APIResources: []metav1.APIResource{{Name: "servicemonitors", Kind: "ServiceMonitor", Namespaced: true}},
+		},
+	}
+	return client
+}
+
+func generateServiceMonitor(interval string) *unstructured.Unstructured {
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetAPIVersion("monitoring.coreos.com/v1")
+	serviceMonitor.SetKind("ServiceMonitor")
+	serviceMonitor.SetName("rfr-test")
+	serviceMonitor.SetNamespace(snapshotTestNamespace)
+	serviceMonitor.Object["spec"] = map[string]interface{}{
+		"endpoints": []interface{}{
+			map[string]interface{}{"port": "http-metrics", "interval": interval},
+		},
+	}
+	return serviceMonitor
+}
+
+func TestServiceMonitorLifecycle(t *testing.T) {
+	assert := assert.New(t)
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		k8s.ServiceMonitorGVR: "ServiceMonitorList",
+	})
+	service := k8s.NewServiceMonitorService(newPrometheusOperatorKubeClient(), dynamicClient, 0, log.Dummy, metrics.Dummy)
+
+	require.NoError(t, service.CreateOrUpdateServiceMonitor(context.TODO(), snapshotTestNamespace, generateServiceMonitor("30s")))
+	// The same service monitor is not written again.
+	require.NoError(t, service.CreateOrUpdateServiceMonitor(context.TODO(), snapshotTestNamespace, generateServiceMonitor("30s")))
+	updates := 0
+	for _, action := range dynamicClient.Actions() {
+		if action.GetVerb() == "update" {
+			updates++
+		}
+	}
+	assert.Zero(updates)
+
+	require.NoError(t, service.CreateOrUpdateServiceMonitor(context.TODO(), snapshotTestNamespace, generateServiceMonitor("10s")))
+	serviceMonitor, err := service.GetServiceMonitor(context.TODO(), snapshotTestNamespace, "rfr-test")
+	require.NoError(t, err)
+	endpoints, _, _ := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+	if assert.Len(endpoints, 1) {
+		assert.Equal("10s", endpoints[0].(map[string]interface{})["interval"])
+	}
+
+	assert.NoError(service.DeleteServiceMonitor(context.TODO(), snapshotTestNamespace, "rfr-test"))
+	_, err = service.GetServiceMonitor(context.TODO(), snapshotTestNamespace, "rfr-test")
+	assert.True(apierrors.IsNotFound(err))
+}
+
+func TestServiceMonitorWithoutCRD(t *testing.T) {
+	assert := assert.New(t)
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		k8s.ServiceMonitorGVR: "ServiceMonitorList",
+	})
+	dynamicClient.PrependReactor("*", "servicemonitors", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		t.Errorf("unexpected %s of a service monitor without the CRD", action.GetVerb())
+		return true, nil, nil
+	})
+	// The cert-manager CRDs are installed, not the prometheus-operator ones.
+	service := k8s.NewServiceMonitorService(newCertManagerKubeClient(), dynamicClient, 0, log.Dummy, metrics.Dummy)
+
+	_, err := service.GetServiceMonitor(context.TODO(), snapshotTestNamespace, "rfr-test")
+	assert.ErrorIs(err, k8s.ErrServiceMonitorsUnavailable)
+	err = service.CreateOrUpdateServiceMonitor(context.TODO(), snapshotTestNamespace, generateServiceMonitor("30s"))
+	assert.ErrorIs(err, k8s.ErrServiceMonitorsUnavailable)
+	err = service.DeleteServiceMonitor(context.TODO(), snapshotTestNamespace, "rfr-test")
+	assert.ErrorIs(err, k8s.ErrServiceMonitorsUnavailable)
+
+	// Without a dynamic client the service monitors are not managed.
+	service = k8s.NewServiceMonitorService(newPrometheusOperatorKubeClient(), nil, 0, log.Dummy, metrics.Dummy)
+	_, err = service.GetServiceMonitor(context.TODO(), snapshotTestNamespace, "rfr-test")
+	assert.ErrorIs(err, k8s.ErrServiceMonitorsUnavailable)
+}
@@ -1,6 +1,7 @@
 package k8s_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -104,8 +105,8 @@ func TestServiceServiceGetCreateOrUpdate(t *testing.T) {
 				return true, nil, test.errorOnCreation
 			})
 
-			service := k8s.NewServiceService(mcli, log.Dummy, metrics.Dummy)
-			err := service.CreateOrUpdateService(testns, test.service)
+			service := k8s.NewServiceService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			err := service.CreateOrUpdateService(context.TODO(), testns, test.service)
 
 			if test.expErr {
 				assert.Error(err)
@@ -117,3 +118,89 @@ func TestServiceServiceGetCreateOrUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceServiceIPFamilies(t *testing.T) {
+	requireDualStack := corev1.IPFamilyPolicyRequireDualStack
+	dualStackService := func() *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: "ns"},
+			Spec: corev1.ServiceSpec{
+				IPFamilyPolicy: &requireDualStack,
+				IPFamilies:     []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		dualStack   bool
+		expPolicy   *corev1.IPFamilyPolicyType
+		expFamilies []corev1.IPFamily
+	}{
+		{
+			name:        "A dual-stack cluster keeps the IP families",
+			dualStack:   true,
+			expPolicy:   &requireDualStack,
+			expFamilies: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol},
+		},
+		{
+			name:      "A single-stack cluster drops the IP families",
+			dualStack: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			probes := 0
+			mcli := &kubernetes.Clientset{}
+			mcli.AddReactor("create", "services", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				service := action.(kubetesting.CreateAction).GetObject().(*corev1.Service)
+				if service.Name != "redis-operator-dual-stack-probe" {
+					return true, service, nil
+				}
+				probes++
+				if !test.dualStack {
+					return true, nil, kubeerrors.NewInvalid(schema.GroupKind{Kind: "Service"}, service.Name, nil)
+				}
+				return true, service, nil
+			})
+			service := k8s.NewServiceService(mcli, 0, log.Dummy, metrics.Dummy)
+
+			created := dualStackService()
+			assert.NoError(service.CreateService(context.TODO(), "ns", created))
+			assert.Equal(test.expPolicy, created.Spec.IPFamilyPolicy)
+			assert.Equal(test.expFamilies, created.Spec.IPFamilies)
+
+			// The support of the cluster is only discovered once.
+			assert.NoError(service.CreateService(context.TODO(), "ns", dualStackService()))
+			assert.Equal(1, probes)
+
+			// The services without IP families don't need it.
+			assert.NoError(k8s.NewServiceService(mcli, 0, log.Dummy, metrics.Dummy).CreateService(context.TODO(), "ns", &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "rfs-test"}}))
+			assert.Equal(1, probes)
+		})
+	}
+}
+
+func TestServiceServiceForcedDualStack(t *testing.T) {
+	assert := assert.New(t)
+
+	singleStack := false
+	k8s.DualStackServices = &singleStack
+	defer func() { k8s.DualStackServices = nil }()
+
+	mcli := &kubernetes.Clientset{}
+	mcli.AddReactor("create", "services", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		return true, action.(kubetesting.CreateAction).GetObject(), nil
+	})
+	service := k8s.NewServiceService(mcli, 0, log.Dummy, metrics.Dummy)
+
+	preferDualStack := corev1.IPFamilyPolicyPreferDualStack
+	created := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test"}, Spec: corev1.ServiceSpec{IPFamilyPolicy: &preferDualStack}}
+	assert.NoError(service.CreateService(context.TODO(), "ns", created))
+	assert.Nil(created.Spec.IPFamilyPolicy)
+	// The flag spares the probe.
+	assert.Len(mcli.Actions(), 1)
+}
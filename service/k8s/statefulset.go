@@ -4,49 +4,83 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"redis-operator/log"
 	"redis-operator/metrics"
 )
 
+// RestartedAtAnnotation is set on the pod template of a statefulset to restart its pods, as
+// kubectl rollout restart does.
+const RestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
 // StatefulSet the StatefulSet service that knows how to interact with k8s to manage them
 type StatefulSet interface {
-	GetStatefulSet(namespace, name string) (*appsv1.StatefulSet, error)
-	GetStatefulSetPods(namespace, name string) (*corev1.PodList, error)
-	CreateStatefulSet(namespace string, statefulSet *appsv1.StatefulSet) error
-	UpdateStatefulSet(namespace string, statefulSet *appsv1.StatefulSet) error
-	CreateOrUpdateStatefulSet(namespace string, statefulSet *appsv1.StatefulSet) error
-	DeleteStatefulSet(namespace string, name string) error
-	ListStatefulSets(namespace string) (*appsv1.StatefulSetList, error)
+	GetStatefulSet(ctx context.Context, namespace, name string) (*appsv1.StatefulSet, error)
+	GetStatefulSetPods(ctx context.Context, namespace, name string) (*corev1.PodList, error)
+	CreateStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error
+	UpdateStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error
+	CreateOrUpdateStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error
+	PatchStatefulSet(ctx context.Context, namespace, name string, patch []byte) error
+	PatchStatefulSetWithType(ctx context.Context, namespace, name string, data []byte, pt types.PatchType) error
+	// ApplyStatefulSet writes the statefulset with a server-side apply, see FieldManager.
+	ApplyStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error
+	// CreateOrPatchStatefulSet patches the statefulset with the changes of the desired one, keeping the
+	// fields set by others.
+	CreateOrPatchStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error
+	DeleteStatefulSet(ctx context.Context, namespace string, name string) error
+	// ListStatefulSetsByLabel lists the statefulsets with all the given labels, the ones of an RF among the
+	// others of the namespace, in pages of DefaultListPageSize.
+	ListStatefulSetsByLabel(ctx context.Context, namespace string, set map[string]string) (*appsv1.StatefulSetList, error)
+	// EachStatefulSet calls fn with every statefulset matching the options, listed in pages of their Limit,
+	// DefaultListPageSize when it's not set. It stops at the first error of fn.
+	EachStatefulSet(ctx context.Context, namespace string, opts metav1.ListOptions, fn func(*appsv1.StatefulSet) error) error
+	// WatchStatefulSet streams the events of a statefulset until the context is cancelled.
+	WatchStatefulSet(ctx context.Context, namespace, name string) (<-chan watch.Event, error)
+	// RollingRestartStatefulSet restarts the pods of a statefulset one by one, keeping the others serving.
+	RollingRestartStatefulSet(ctx context.Context, namespace, name string) error
+	// GetStatefulSetRolloutStatus returns true when all the replicas of the statefulset are updated and ready.
+	GetStatefulSetRolloutStatus(ctx context.Context, namespace, name string) (bool, error)
+	// ScaleStatefulSet sets the replicas of the statefulset through its scale subresource, leaving the
+	// rest of its spec as it is.
+	ScaleStatefulSet(ctx context.Context, namespace, name string, replicas int32) error
 }
 
 // StatefulSetService is the service account service implementation using API calls to kubernetes.
 type StatefulSetService struct {
 	kubeClient      kubernetes.Interface
+	conflictRetries int
 	logger          log.Logger
 	metricsRecorder metrics.Recorder
 }
 
 // NewStatefulSetService returns a new StatefulSet KubeService.
-func NewStatefulSetService(kubeClient kubernetes.Interface, logger log.Logger, metricsRecorder metrics.Recorder) *StatefulSetService {
+func NewStatefulSetService(kubeClient kubernetes.Interface, conflictRetries int, logger log.Logger, metricsRecorder metrics.Recorder) *StatefulSetService {
 	logger = logger.With("service", "k8s.statefulSet")
 	return &StatefulSetService{
 		kubeClient:      kubeClient,
+		conflictRetries: conflictRetries,
 		logger:          logger,
 		metricsRecorder: metricsRecorder,
 	}
 }
 
 // GetStatefulSet will retrieve the requested statefulset based on namespace and name
-func (s *StatefulSetService) GetStatefulSet(namespace, name string) (*appsv1.StatefulSet, error) {
-	statefulSet, err := s.kubeClient.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
-	recordMetrics(namespace, "StatefulSet", name, "GET", err, s.metricsRecorder)
+func (s *StatefulSetService) GetStatefulSet(ctx context.Context, namespace, name string) (*appsv1.StatefulSet, error) {
+	statefulSet, err := s.kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	err = recordMetrics(ctx, namespace, "StatefulSet", name, "GET", err, s.metricsRecorder)
 	if err != nil {
 		return nil, err
 	}
@@ -54,8 +88,8 @@ func (s *StatefulSetService) GetStatefulSet(namespace, name string) (*appsv1.Sta
 }
 
 // GetStatefulSetPods will give a list of pods that are managed by the statefulset
-func (s *StatefulSetService) GetStatefulSetPods(namespace, name string) (*corev1.PodList, error) {
-	statefulSet, err := s.GetStatefulSet(namespace, name)
+func (s *StatefulSetService) GetStatefulSetPods(ctx context.Context, namespace, name string) (*corev1.PodList, error) {
+	statefulSet, err := s.GetStatefulSet(ctx, namespace, name)
 	if err != nil {
 		return nil, err
 	}
@@ -64,13 +98,13 @@ func (s *StatefulSetService) GetStatefulSetPods(namespace, name string) (*corev1
 		labels = append(labels, fmt.Sprintf("%s=%s", k, v))
 	}
 	selector := strings.Join(labels, ",")
-	return s.kubeClient.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	return s.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
 }
 
 // CreateStatefulSet will create the given statefulset
-func (s *StatefulSetService) CreateStatefulSet(namespace string, statefulSet *appsv1.StatefulSet) error {
-	_, err := s.kubeClient.AppsV1().StatefulSets(namespace).Create(context.TODO(), statefulSet, metav1.CreateOptions{})
-	recordMetrics(namespace, "StatefulSet", statefulSet.GetName(), "CREATE", err, s.metricsRecorder)
+func (s *StatefulSetService) CreateStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error {
+	_, err := s.kubeClient.AppsV1().StatefulSets(namespace).Create(ctx, statefulSet, metav1.CreateOptions{})
+	err = recordMetrics(ctx, namespace, "StatefulSet", statefulSet.GetName(), "CREATE", err, s.metricsRecorder)
 	if err != nil {
 		return err
 	}
@@ -78,10 +112,17 @@ func (s *StatefulSetService) CreateStatefulSet(namespace string, statefulSet *ap
 	return err
 }
 
-// UpdateStatefulSet will update the given statefulset
-func (s *StatefulSetService) UpdateStatefulSet(namespace string, statefulSet *appsv1.StatefulSet) error {
-	_, err := s.kubeClient.AppsV1().StatefulSets(namespace).Update(context.TODO(), statefulSet, metav1.UpdateOptions{})
-	recordMetrics(namespace, "StatefulSet", statefulSet.GetName(), "UPDATE", err, s.metricsRecorder)
+// UpdateStatefulSet will update the given statefulset, retrying over its latest version on a conflict
+func (s *StatefulSetService) UpdateStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error {
+	err := updateOnConflict(s.conflictRetries, namespace, "StatefulSet", statefulSet.GetName(), s.metricsRecorder, statefulSet, func() (*appsv1.StatefulSet, error) {
+		return s.GetStatefulSet(ctx, namespace, statefulSet.Name)
+	}, func(stored *appsv1.StatefulSet) {
+		setDesiredMeta(stored, statefulSet)
+		stored.Spec = statefulSet.Spec
+	}, func(statefulSet *appsv1.StatefulSet) error {
+		_, err := s.kubeClient.AppsV1().StatefulSets(namespace).Update(ctx, statefulSet, metav1.UpdateOptions{})
+		return recordMetrics(ctx, namespace, "StatefulSet", statefulSet.GetName(), "UPDATE", err, s.metricsRecorder)
+	})
 	if err != nil {
 		return err
 	}
@@ -90,35 +131,242 @@ func (s *StatefulSetService) UpdateStatefulSet(namespace string, statefulSet *ap
 }
 
 // CreateOrUpdateStatefulSet will update the statefulset or create it if does not exist
-func (s *StatefulSetService) CreateOrUpdateStatefulSet(namespace string, statefulSet *appsv1.StatefulSet) error {
-	storedStatefulSet, err := s.GetStatefulSet(namespace, statefulSet.Name)
+func (s *StatefulSetService) CreateOrUpdateStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error {
+	storedStatefulSet, err := s.GetStatefulSet(ctx, namespace, statefulSet.Name)
 	if err != nil {
 		// If no resource we need to create.
 		if errors.IsNotFound(err) {
-			return s.CreateStatefulSet(namespace, statefulSet)
+			if _, err := setSpecHash(statefulSet, statefulSet.Spec); err != nil {
+				return err
+			}
+			return s.CreateStatefulSet(ctx, namespace, statefulSet)
 		}
 		return err
 	}
 
+	// Nothing is written while the desired statefulset has the hash of the stored one. The hash is the one
+	// of the desired statefulset, without the restart kept from the stored one.
+	storedHash := storedStatefulSet.Annotations[SpecHashAnnotation]
+	hash, err := setSpecHash(statefulSet, statefulSet.Spec)
+	if err != nil {
+		return err
+	}
+	if storedHash == hash {
+		return nil
+	}
+
 	// Already exists, need to Update.
 	// Set the correct resource version to ensure we are on the latest version. This way the only valid
 	// namespace is our spec(https://github.com/kubernetes/community/blob/master/contributors/devel/api-conventions.md#concurrency-control-and-consistency),
 	// we will replace the current namespace state.
 	statefulSet.ResourceVersion = storedStatefulSet.ResourceVersion
-	return s.UpdateStatefulSet(namespace, statefulSet)
+	// The restart of the pods asked on the stored statefulset is kept, dropping it would restart them again.
+	if restartedAt, ok := storedStatefulSet.Spec.Template.Annotations[RestartedAtAnnotation]; ok {
+		if _, ok := statefulSet.Spec.Template.Annotations[RestartedAtAnnotation]; !ok {
+			if statefulSet.Spec.Template.Annotations == nil {
+				statefulSet.Spec.Template.Annotations = map[string]string{}
+			}
+			statefulSet.Spec.Template.Annotations[RestartedAtAnnotation] = restartedAt
+		}
+	}
+	return s.UpdateStatefulSet(ctx, namespace, statefulSet)
+}
+
+// PatchStatefulSet will patch the statefulset with the given strategic merge patch
+func (s *StatefulSetService) PatchStatefulSet(ctx context.Context, namespace, name string, patch []byte) error {
+	return s.PatchStatefulSetWithType(ctx, namespace, name, patch, types.StrategicMergePatchType)
+}
+
+// PatchStatefulSetWithType will patch the statefulset with the data of the patch type
+func (s *StatefulSetService) PatchStatefulSetWithType(ctx context.Context, namespace, name string, data []byte, pt types.PatchType) error {
+	_, err := s.kubeClient.AppsV1().StatefulSets(namespace).Patch(ctx, name, pt, data, metav1.PatchOptions{})
+	err = recordMetrics(ctx, namespace, "StatefulSet", name, "PATCH", err, s.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	s.logger.WithField("namespace", namespace).WithField("statefulSet", name).Infof("statefulSet patched")
+	return nil
+}
+
+// CreateOrPatchStatefulSet will patch the statefulset or create it if does not exist. Unlike an update,
+// the patch only holds the fields of the desired statefulset that differ from the stored one and the
+// ones removed since the last one applied, so the fields set by other controllers are kept, as the
+// restart of the pods asked on the stored statefulset.
+func (s *StatefulSetService) CreateOrPatchStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error {
+	storedStatefulSet, err := s.GetStatefulSet(ctx, namespace, statefulSet.Name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			if _, err := setSpecHash(statefulSet, statefulSet.Spec); err != nil {
+				return err
+			}
+			if err := setLastApplied(statefulSet); err != nil {
+				return err
+			}
+			return s.CreateStatefulSet(ctx, namespace, statefulSet)
+		}
+		return err
+	}
+
+	storedHash := storedStatefulSet.Annotations[SpecHashAnnotation]
+	hash, err := setSpecHash(statefulSet, statefulSet.Spec)
+	if err != nil {
+		return err
+	}
+	if storedHash == hash {
+		return nil
+	}
+
+	if err := setLastApplied(statefulSet); err != nil {
+		return err
+	}
+	data, err := createThreeWayMergePatch(storedStatefulSet, statefulSet, appsv1.StatefulSet{})
+	if err != nil {
+		return err
+	}
+	if string(data) == "{}" {
+		return nil
+	}
+	return s.PatchStatefulSet(ctx, namespace, statefulSet.Name, data)
+}
+
+// ApplyStatefulSet writes the statefulset with a server-side apply of the operator field manager,
+// creating it when it does not exist. The fields set by the operator on a previous apply and not anymore
+// are removed, the ones set by others are kept.
+func (s *StatefulSetService) ApplyStatefulSet(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) error {
+	if _, err := setSpecHash(statefulSet, statefulSet.Spec); err != nil {
+		return err
+	}
+	config := appsv1ac.StatefulSet(statefulSet.Name, namespace)
+	if err := toApplyConfiguration(statefulSet, config); err != nil {
+		return err
+	}
+	_, err := s.kubeClient.AppsV1().StatefulSets(namespace).Apply(ctx, config, applyOptions)
+	err = recordMetrics(ctx, namespace, "StatefulSet", statefulSet.Name, "APPLY", err, s.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	s.logger.WithField("namespace", namespace).WithField("statefulSet", statefulSet.Name).Debugf("statefulSet applied")
+	return nil
+}
+
+// RollingRestartStatefulSet sets the restartedAt annotation of the pod template of the statefulset to the
+// current time, kubernetes then rolls its pods with the update strategy of the statefulset
+func (s *StatefulSetService) RollingRestartStatefulSet(ctx context.Context, namespace, name string) error {
+	statefulSet, err := s.GetStatefulSet(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	if statefulSet.Spec.Template.Annotations == nil {
+		statefulSet.Spec.Template.Annotations = map[string]string{}
+	}
+	statefulSet.Spec.Template.Annotations[RestartedAtAnnotation] = time.Now().Format(time.RFC3339)
+	if err := s.UpdateStatefulSet(ctx, namespace, statefulSet); err != nil {
+		return err
+	}
+	s.logger.WithField("namespace", namespace).WithField("statefulSet", name).Infof("statefulSet pods restarting")
+	return nil
+}
+
+// ScaleStatefulSet updates the scale subresource of the statefulset with the replicas. The scale is written
+// without a resource version, so the concurrent changes of the spec are neither overwritten nor conflicting
+func (s *StatefulSetService) ScaleStatefulSet(ctx context.Context, namespace, name string, replicas int32) error {
+	scale := &autoscalingv1.Scale{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       autoscalingv1.ScaleSpec{Replicas: replicas},
+	}
+	_, err := s.kubeClient.AppsV1().StatefulSets(namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{})
+	err = recordMetrics(ctx, namespace, "StatefulSet", name, "UPDATE_SCALE", err, s.metricsRecorder)
+	if err != nil {
+		return err
+	}
+	s.logger.WithField("namespace", namespace).WithField("statefulSet", name).Infof("statefulSet scaled to %d replicas", replicas)
+	return nil
+}
+
+// GetStatefulSetRolloutStatus returns true when the rollout of the statefulset is complete: its controller
+// observed the last spec, and all its replicas are updated to it and ready
+func (s *StatefulSetService) GetStatefulSetRolloutStatus(ctx context.Context, namespace, name string) (bool, error) {
+	statefulSet, err := s.GetStatefulSet(ctx, namespace, name)
+	if err != nil {
+		return false, err
+	}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	status := statefulSet.Status
+	return status.ObservedGeneration >= statefulSet.Generation && status.ReadyReplicas == replicas && status.UpdatedReplicas == replicas, nil
 }
 
 // DeleteStatefulSet will delete the statefulset
-func (s *StatefulSetService) DeleteStatefulSet(namespace, name string) error {
+func (s *StatefulSetService) DeleteStatefulSet(ctx context.Context, namespace, name string) error {
 	propagation := metav1.DeletePropagationForeground
-	err := s.kubeClient.AppsV1().StatefulSets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{PropagationPolicy: &propagation})
-	recordMetrics(namespace, "StatefulSet", name, "DELETE", err, s.metricsRecorder)
+	err := s.kubeClient.AppsV1().StatefulSets(namespace).Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	err = recordMetrics(ctx, namespace, "StatefulSet", name, "DELETE", err, s.metricsRecorder)
 	return err
 }
 
-// ListStatefulSets will retrieve a list of statefulset in the given namespace
-func (s *StatefulSetService) ListStatefulSets(namespace string) (*appsv1.StatefulSetList, error) {
-	stsList, err := s.kubeClient.AppsV1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{})
-	recordMetrics(namespace, "StatefulSet", metrics.NOT_APPLICABLE, "LIST", err, s.metricsRecorder)
-	return stsList, err
+// ListStatefulSetsByLabel will retrieve the statefulsets in the given namespace with all the given labels, page by page
+func (s *StatefulSetService) ListStatefulSetsByLabel(ctx context.Context, namespace string, set map[string]string) (*appsv1.StatefulSetList, error) {
+	stsList := &appsv1.StatefulSetList{}
+	opts := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(set).String()}
+	err := s.EachStatefulSet(ctx, namespace, opts, func(statefulSet *appsv1.StatefulSet) error {
+		stsList.Items = append(stsList.Items, *statefulSet)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stsList, nil
+}
+
+// EachStatefulSet will call fn with the statefulsets in the given namespace matching the options, page by page
+func (s *StatefulSetService) EachStatefulSet(ctx context.Context, namespace string, opts metav1.ListOptions, fn func(*appsv1.StatefulSet) error) error {
+	return eachPage(opts, func(opts metav1.ListOptions) (string, error) {
+		page, err := s.kubeClient.AppsV1().StatefulSets(namespace).List(ctx, opts)
+		err = recordMetrics(ctx, namespace, "StatefulSet", metrics.NOT_APPLICABLE, "LIST", err, s.metricsRecorder)
+		if err != nil {
+			return "", err
+		}
+		for i := range page.Items {
+			if err := fn(&page.Items[i]); err != nil {
+				return "", err
+			}
+		}
+		return page.Continue, nil
+	})
+}
+
+// WatchStatefulSet will stream the events of the statefulset with the given name. The channel is closed
+// when the context is cancelled or the apiserver ends the watch, the caller watches again then.
+func (s *StatefulSetService) WatchStatefulSet(ctx context.Context, namespace, name string) (<-chan watch.Event, error) {
+	watcher, err := s.kubeClient.AppsV1().StatefulSets(namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String(),
+	})
+	err = recordMetrics(ctx, namespace, "StatefulSet", name, "WATCH", err, s.metricsRecorder)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan watch.Event)
+	go func() {
+		defer close(events)
+		defer watcher.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					return
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
 }
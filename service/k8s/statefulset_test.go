@@ -1,15 +1,24 @@
 package k8s_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
 	kubernetes "k8s.io/client-go/kubernetes/fake"
 	kubetesting "k8s.io/client-go/testing"
 
@@ -42,6 +51,9 @@ func TestStatefulSetServiceGetCreateOrUpdate(t *testing.T) {
 		},
 	}
 
+	// The stored statefulSet was written before the hash of the desired one.
+	storedStatefulSet := testStatefulSet.DeepCopy()
+
 	testns := "testns"
 
 	tests := []struct {
@@ -80,7 +92,7 @@ func TestStatefulSetServiceGetCreateOrUpdate(t *testing.T) {
 		{
 			name:                 "An existent statefulSet should update the statefulSet.",
 			statefulSet:          testStatefulSet,
-			getStatefulSetResult: testStatefulSet,
+			getStatefulSetResult: storedStatefulSet,
 			errorOnGet:           nil,
 			errorOnCreation:      nil,
 			expActions: []kubetesting.Action{
@@ -104,8 +116,8 @@ func TestStatefulSetServiceGetCreateOrUpdate(t *testing.T) {
 				return true, nil, test.errorOnCreation
 			})
 
-			service := k8s.NewStatefulSetService(mcli, log.Dummy, metrics.Dummy)
-			err := service.CreateOrUpdateStatefulSet(testns, test.statefulSet)
+			service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			err := service.CreateOrUpdateStatefulSet(context.TODO(), testns, test.statefulSet)
 
 			if test.expErr {
 				assert.Error(err)
@@ -117,3 +129,654 @@ func TestStatefulSetServiceGetCreateOrUpdate(t *testing.T) {
 		})
 	}
 }
+
+// countUpdates returns the number of updates sent to the fake clientset.
+func countUpdates(mcli *kubernetes.Clientset) int {
+	n := 0
+	for _, action := range mcli.Actions() {
+		if action.GetVerb() == "update" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestStatefulSetServiceCreateOrUpdateSpecHash(t *testing.T) {
+	testns := "testns"
+	generate := func() *appsv1.StatefulSet {
+		ss := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns, Labels: map[string]string{"app": "redis"}}}
+		ss.Spec.Template.Spec.Containers = []corev1.Container{{Name: "redis", Image: "redis:7.0"}}
+		return ss
+	}
+
+	tests := []struct {
+		name      string
+		change    func(ss *appsv1.StatefulSet)
+		expUpdate bool
+	}{
+		{
+			name:   "An unchanged statefulSet is not updated.",
+			change: func(ss *appsv1.StatefulSet) {},
+		},
+		{
+			name:      "A changed image updates the statefulSet.",
+			change:    func(ss *appsv1.StatefulSet) { ss.Spec.Template.Spec.Containers[0].Image = "redis:7.2" },
+			expUpdate: true,
+		},
+		{
+			name: "Changed resources update the statefulSet.",
+			change: func(ss *appsv1.StatefulSet) {
+				ss.Spec.Template.Spec.Containers[0].Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+			},
+			expUpdate: true,
+		},
+		{
+			name:      "Changed labels update the statefulSet.",
+			change:    func(ss *appsv1.StatefulSet) { ss.Labels["team"] = "cache" },
+			expUpdate: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mcli := kubernetes.NewSimpleClientset()
+			service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			assert.NoError(service.CreateOrUpdateStatefulSet(context.TODO(), testns, generate()))
+			created, err := service.GetStatefulSet(context.TODO(), testns, "rfr-test")
+			assert.NoError(err)
+			assert.NotEmpty(created.Annotations[k8s.SpecHashAnnotation])
+
+			desired := generate()
+			test.change(desired)
+			assert.NoError(service.CreateOrUpdateStatefulSet(context.TODO(), testns, desired))
+			if !test.expUpdate {
+				assert.Equal(0, countUpdates(mcli))
+				return
+			}
+			assert.Equal(1, countUpdates(mcli))
+			updated, err := service.GetStatefulSet(context.TODO(), testns, "rfr-test")
+			assert.NoError(err)
+			assert.Equal(desired.Spec, updated.Spec)
+			assert.NotEqual(created.Annotations[k8s.SpecHashAnnotation], updated.Annotations[k8s.SpecHashAnnotation])
+
+			// The statefulset is not updated again once it has the new spec.
+			again := generate()
+			test.change(again)
+			assert.NoError(service.CreateOrUpdateStatefulSet(context.TODO(), testns, again))
+			assert.Equal(1, countUpdates(mcli))
+		})
+	}
+}
+
+func TestStatefulSetServiceListByLabel(t *testing.T) {
+	tests := []struct {
+		name        string
+		set         map[string]string
+		expSelector string
+	}{
+		{
+			name:        "No labels lists every statefulset",
+			set:         map[string]string{},
+			expSelector: "",
+		},
+		{
+			name:        "One label",
+			set:         map[string]string{"app.kubernetes.io/managed-by": "redis-operator"},
+			expSelector: "app.kubernetes.io/managed-by=redis-operator",
+		},
+		{
+			name: "Labels of an RF, sorted by key",
+			set: map[string]string{
+				"redisfailovers.databases.spotahome.com/name": "test",
+				"app.kubernetes.io/component":                 "redis",
+			},
+			expSelector: "app.kubernetes.io/component=redis,redisfailovers.databases.spotahome.com/name=test",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var gotNamespace, gotSelector string
+			mcli := &kubernetes.Clientset{}
+			mcli.AddReactor("list", "statefulsets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				gotNamespace = action.GetNamespace()
+				gotSelector = action.(kubetesting.ListAction).GetListRestrictions().Labels.String()
+				return true, &appsv1.StatefulSetList{Items: []appsv1.StatefulSet{{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Labels: test.set}}}}, nil
+			})
+
+			service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			stsList, err := service.ListStatefulSetsByLabel(context.TODO(), "testns", test.set)
+
+			assert.NoError(err)
+			assert.Equal("testns", gotNamespace)
+			assert.Equal(test.expSelector, gotSelector)
+			assert.Len(stsList.Items, 1)
+		})
+	}
+}
+
+func TestStatefulSetServiceListByLabelPages(t *testing.T) {
+	assert := assert.New(t)
+
+	managed := map[string]string{"app.kubernetes.io/managed-by": "redis-operator"}
+	pages := []*appsv1.StatefulSetList{
+		{ListMeta: metav1.ListMeta{Continue: "page-2"}, Items: []appsv1.StatefulSet{{ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: managed}}, {ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: managed}}}},
+		{ListMeta: metav1.ListMeta{Continue: "page-3"}, Items: []appsv1.StatefulSet{{ObjectMeta: metav1.ObjectMeta{Name: "c", Labels: managed}}}},
+		{Items: []appsv1.StatefulSet{{ObjectMeta: metav1.ObjectMeta{Name: "d", Labels: managed}}}},
+	}
+	selectors := []string{}
+	mcli := &kubernetes.Clientset{}
+	mcli.AddReactor("list", "statefulsets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		selectors = append(selectors, action.(kubetesting.ListAction).GetListRestrictions().Labels.String())
+		return true, pages[len(selectors)-1], nil
+	})
+
+	// Every page is listed with the selector until the last one.
+	service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	stsList, err := service.ListStatefulSetsByLabel(context.TODO(), "testns", managed)
+
+	assert.NoError(err)
+	names := []string{}
+	for _, sts := range stsList.Items {
+		names = append(names, sts.Name)
+	}
+	assert.Equal([]string{"a", "b", "c", "d"}, names)
+	assert.Equal([]string{"app.kubernetes.io/managed-by=redis-operator", "app.kubernetes.io/managed-by=redis-operator", "app.kubernetes.io/managed-by=redis-operator"}, selectors)
+}
+
+func TestStatefulSetServiceEachStops(t *testing.T) {
+	assert := assert.New(t)
+
+	lists := 0
+	mcli := &kubernetes.Clientset{}
+	mcli.AddReactor("list", "statefulsets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		lists++
+		return true, &appsv1.StatefulSetList{ListMeta: metav1.ListMeta{Continue: "next"}, Items: []appsv1.StatefulSet{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}}}, nil
+	})
+
+	// The listing stops at the first error of the function.
+	service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	errStop := errors.New("stop")
+	err := service.EachStatefulSet(context.TODO(), "testns", metav1.ListOptions{Limit: 1}, func(*appsv1.StatefulSet) error {
+		return errStop
+	})
+
+	assert.Equal(errStop, err)
+	assert.Equal(1, lists)
+}
+
+func TestStatefulSetServiceWatch(t *testing.T) {
+	assert := assert.New(t)
+
+	var gotSelector string
+	fakeWatcher := watch.NewFake()
+	mcli := &kubernetes.Clientset{}
+	mcli.AddWatchReactor("statefulsets", func(action kubetesting.Action) (bool, watch.Interface, error) {
+		gotSelector = action.(kubetesting.WatchAction).GetWatchRestrictions().Fields.String()
+		return true, fakeWatcher, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	events, err := service.WatchStatefulSet(ctx, "testns", "rfr-test")
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("metadata.name=rfr-test", gotSelector)
+
+	// The events are forwarded in order.
+	ss := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: "testns"}}
+	go func() {
+		fakeWatcher.Add(ss)
+		fakeWatcher.Modify(ss)
+	}()
+	for _, expType := range []watch.EventType{watch.Added, watch.Modified} {
+		select {
+		case event := <-events:
+			assert.Equal(expType, event.Type)
+			assert.Equal(ss, event.Object)
+		case <-time.After(5 * time.Second):
+			assert.Fail("the event was not forwarded")
+			return
+		}
+	}
+
+	// Cancelling the context stops the watch and closes the channel.
+	cancel()
+	select {
+	case _, ok := <-events:
+		for ok {
+			_, ok = <-events
+		}
+	case <-time.After(5 * time.Second):
+		assert.Fail("the channel was not closed")
+	}
+	assert.True(fakeWatcher.IsStopped())
+}
+
+func TestStatefulSetServiceWatchError(t *testing.T) {
+	assert := assert.New(t)
+
+	mcli := &kubernetes.Clientset{}
+	mcli.AddWatchReactor("statefulsets", func(action kubetesting.Action) (bool, watch.Interface, error) {
+		return true, nil, errors.New("wanted error")
+	})
+
+	service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	events, err := service.WatchStatefulSet(context.Background(), "testns", "rfr-test")
+	assert.Error(err)
+	assert.Nil(events)
+}
+
+func TestStatefulSetServiceUpdateRetriesOnConflict(t *testing.T) {
+	tests := []struct {
+		name          string
+		conflicts     int
+		expErr        bool
+		expRetries    int
+		expLastUpdate string
+		expFinalizers []string
+	}{
+		{
+			name:          "No conflict",
+			expLastUpdate: "10",
+		},
+		{
+			name:          "Conflicts below the retries",
+			conflicts:     2,
+			expRetries:    2,
+			expLastUpdate: "12",
+			expFinalizers: []string{"other"},
+		},
+		{
+			name:       "Conflicts past the retries",
+			conflicts:  4,
+			expErr:     true,
+			expRetries: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			testns := "testns"
+			stored := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns, ResourceVersion: "10"}}
+			mcli := kubernetes.NewSimpleClientset(stored)
+
+			// Every conflict comes from a newer version written by someone else.
+			conflicts := 0
+			var lastUpdate *appsv1.StatefulSet
+			mcli.PrependReactor("update", "statefulsets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				updated := action.(kubetesting.UpdateAction).GetObject().(*appsv1.StatefulSet)
+				if conflicts < test.conflicts {
+					conflicts++
+					stored.ResourceVersion = fmt.Sprintf("%d", 10+conflicts)
+					stored.Finalizers = []string{"other"}
+					return true, nil, kubeerrors.NewConflict(statefulSetsGroup.GroupResource(), updated.Name, errors.New("the object has been modified"))
+				}
+				lastUpdate = updated
+				return true, updated, nil
+			})
+			mcli.PrependReactor("get", "statefulsets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				return true, stored.DeepCopy(), nil
+			})
+
+			recorder := &k8sOperationRecorder{Recorder: metrics.Dummy}
+			service := k8s.NewStatefulSetService(mcli, 3, log.Dummy, recorder)
+			replicas := int32(3)
+			desired := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns, ResourceVersion: "10"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+			}
+			err := service.UpdateStatefulSet(context.TODO(), testns, desired)
+
+			if test.expErr {
+				assert.True(kubeerrors.IsConflict(err))
+			} else {
+				assert.NoError(err)
+				// Our spec is applied over the latest version, what others wrote on it is kept.
+				assert.Equal(test.expLastUpdate, lastUpdate.ResourceVersion)
+				assert.Equal(desired.Spec, lastUpdate.Spec)
+				assert.Equal(test.expFinalizers, lastUpdate.Finalizers)
+			}
+			assert.Equal(test.expRetries, recorder.conflictRetries)
+		})
+	}
+}
+
+func TestStatefulSetServiceRollingRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	testns := "testns"
+	stored := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns, ResourceVersion: "10"}}
+	stored.Spec.Template.Annotations = map[string]string{"custom": "annotation"}
+	mcli := kubernetes.NewSimpleClientset(stored)
+
+	before := time.Now().Truncate(time.Second)
+	service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	err := service.RollingRestartStatefulSet(context.TODO(), testns, "rfr-test")
+	assert.NoError(err)
+
+	updates := []*appsv1.StatefulSet{}
+	for _, action := range mcli.Actions() {
+		if action.GetVerb() == "update" {
+			updates = append(updates, action.(kubetesting.UpdateAction).GetObject().(*appsv1.StatefulSet))
+		}
+	}
+	if assert.Len(updates, 1) {
+		annotations := updates[0].Spec.Template.Annotations
+		assert.Equal("annotation", annotations["custom"])
+		restartedAt, err := time.Parse(time.RFC3339, annotations[k8s.RestartedAtAnnotation])
+		assert.NoError(err)
+		assert.False(restartedAt.Before(before))
+	}
+
+	// The restart is kept when the statefulset is updated without it.
+	restarted, err := service.GetStatefulSet(context.TODO(), testns, "rfr-test")
+	assert.NoError(err)
+	desired := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns}}
+	assert.NoError(service.CreateOrUpdateStatefulSet(context.TODO(), testns, desired))
+	assert.Equal(restarted.Spec.Template.Annotations[k8s.RestartedAtAnnotation], desired.Spec.Template.Annotations[k8s.RestartedAtAnnotation])
+}
+
+func TestStatefulSetServiceRollingRestartRetriesOnConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	testns := "testns"
+	stored := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns, ResourceVersion: "10"}}
+	mcli := kubernetes.NewSimpleClientset(stored)
+
+	// The first update conflicts with a newer version written by someone else.
+	conflicted := false
+	updates := []*appsv1.StatefulSet{}
+	mcli.PrependReactor("update", "statefulsets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		updated := action.(kubetesting.UpdateAction).GetObject().(*appsv1.StatefulSet)
+		if !conflicted {
+			conflicted = true
+			stored.ResourceVersion = "11"
+			return true, nil, kubeerrors.NewConflict(statefulSetsGroup.GroupResource(), updated.Name, errors.New("the object has been modified"))
+		}
+		updates = append(updates, updated)
+		return true, updated, nil
+	})
+	mcli.PrependReactor("get", "statefulsets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		return true, stored.DeepCopy(), nil
+	})
+
+	recorder := &k8sOperationRecorder{Recorder: metrics.Dummy}
+	service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, recorder)
+	assert.NoError(service.RollingRestartStatefulSet(context.TODO(), testns, "rfr-test"))
+
+	// The restart is applied over the latest version.
+	if assert.Len(updates, 1) {
+		assert.Equal("11", updates[0].ResourceVersion)
+		assert.NotEmpty(updates[0].Spec.Template.Annotations[k8s.RestartedAtAnnotation])
+	}
+	assert.Equal(1, recorder.conflictRetries)
+}
+
+func TestStatefulSetServiceRollingRestartNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	mcli := kubernetes.NewSimpleClientset()
+	service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	err := service.RollingRestartStatefulSet(context.TODO(), "testns", "rfr-test")
+	assert.True(kubeerrors.IsNotFound(err))
+	for _, action := range mcli.Actions() {
+		assert.NotEqual("update", action.GetVerb())
+	}
+}
+
+func TestStatefulSetServiceScale(t *testing.T) {
+	tests := []struct {
+		name        string
+		stored      int32
+		replicas    int32
+		notFound    bool
+		expStatuses []string
+		expErrs     []string
+	}{
+		{
+			name:        "Scale up",
+			stored:      3,
+			replicas:    5,
+			expStatuses: []string{metrics.SUCCESS},
+			expErrs:     []string{metrics.NOT_APPLICABLE},
+		},
+		{
+			name:        "Scale down",
+			stored:      3,
+			replicas:    1,
+			expStatuses: []string{metrics.SUCCESS},
+			expErrs:     []string{metrics.NOT_APPLICABLE},
+		},
+		{
+			name:        "Not found",
+			replicas:    3,
+			notFound:    true,
+			expStatuses: []string{metrics.FAIL},
+			expErrs:     []string{metrics.K8S_NOT_FOUND},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			testns := "testns"
+			mcli := kubernetes.NewSimpleClientset()
+			if !test.notFound {
+				stored := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns}}
+				stored.Spec.Replicas = &test.stored
+				stored.Spec.Template.Annotations = map[string]string{"custom": "annotation"}
+				assert.NoError(mcli.Tracker().Add(stored))
+			}
+			// The fake clientset doesn't serve the scale subresource, it's set on the stored statefulset.
+			mcli.PrependReactor("update", "statefulsets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "scale" {
+					return false, nil, nil
+				}
+				scale := action.(kubetesting.UpdateAction).GetObject().(*autoscalingv1.Scale)
+				obj, err := mcli.Tracker().Get(statefulSetsGroup, testns, scale.Name)
+				if err != nil {
+					return true, nil, err
+				}
+				statefulSet := obj.(*appsv1.StatefulSet)
+				statefulSet.Spec.Replicas = &scale.Spec.Replicas
+				return true, scale, mcli.Tracker().Update(statefulSetsGroup, statefulSet, testns)
+			})
+
+			recorder := &k8sOperationRecorder{Recorder: metrics.Dummy}
+			service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, recorder)
+			err := service.ScaleStatefulSet(context.TODO(), testns, "rfr-test", test.replicas)
+
+			if test.notFound {
+				assert.True(kubeerrors.IsNotFound(err))
+			} else {
+				assert.NoError(err)
+				scaled, err := mcli.AppsV1().StatefulSets(testns).Get(context.TODO(), "rfr-test", metav1.GetOptions{})
+				assert.NoError(err)
+				assert.Equal(test.replicas, *scaled.Spec.Replicas)
+				assert.Equal("annotation", scaled.Spec.Template.Annotations["custom"])
+			}
+			assert.Equal(test.expStatuses, recorder.statuses)
+			assert.Equal(test.expErrs, recorder.errs)
+
+			// Only the scale subresource is written, never the whole statefulset.
+			for _, action := range mcli.Actions() {
+				if action.GetVerb() == "update" {
+					assert.Equal("scale", action.GetSubresource())
+				}
+			}
+		})
+	}
+}
+
+func TestStatefulSetServiceGetRolloutStatus(t *testing.T) {
+	replicas := int32(3)
+	tests := []struct {
+		name     string
+		status   appsv1.StatefulSetStatus
+		expReady bool
+	}{
+		{
+			name:     "All the replicas updated and ready",
+			status:   appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 3},
+			expReady: true,
+		},
+		{
+			name:   "A replica not updated yet",
+			status: appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 2},
+		},
+		{
+			name:   "An updated replica not ready yet",
+			status: appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 2, UpdatedReplicas: 3},
+		},
+		{
+			name:   "The last spec not observed yet",
+			status: appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, UpdatedReplicas: 3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			testns := "testns"
+			stored := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: testns, Generation: 2}}
+			stored.Spec.Replicas = &replicas
+			stored.Status = test.status
+			mcli := kubernetes.NewSimpleClientset(stored)
+
+			service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+			ready, err := service.GetStatefulSetRolloutStatus(context.TODO(), testns, "rfr-test")
+			assert.NoError(err)
+			assert.Equal(test.expReady, ready)
+		})
+	}
+}
+
+func TestStatefulSetServiceGetRolloutStatusError(t *testing.T) {
+	assert := assert.New(t)
+
+	mcli := kubernetes.NewSimpleClientset()
+	service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	ready, err := service.GetStatefulSetRolloutStatus(context.TODO(), "testns", "rfr-test")
+	assert.True(kubeerrors.IsNotFound(err))
+	assert.False(ready)
+}
+
+func patchedStatefulSet(image string, labels map[string]string) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Namespace: "testns", Labels: labels},
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "redis", Image: image}},
+				},
+			},
+		},
+	}
+}
+
+func statefulSetPatches(mcli *kubernetes.Clientset) []kubetesting.PatchAction {
+	patches := []kubetesting.PatchAction{}
+	for _, action := range mcli.Actions() {
+		if patch, ok := action.(kubetesting.PatchAction); ok {
+			patches = append(patches, patch)
+		}
+	}
+	return patches
+}
+
+func TestStatefulSetServiceCreateOrPatch(t *testing.T) {
+	assert := assert.New(t)
+
+	testns := "testns"
+	mcli := kubernetes.NewSimpleClientset()
+	service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+	// The statefulset is created with the last applied one.
+	err := service.CreateOrPatchStatefulSet(context.TODO(), testns, patchedStatefulSet("redis:6", map[string]string{"app": "redis", "tier": "cache"}))
+	assert.NoError(err)
+	created, err := service.GetStatefulSet(context.TODO(), testns, "rfr-test")
+	assert.NoError(err)
+	assert.NotEmpty(created.Annotations[k8s.LastAppliedAnnotation])
+	assert.NotEmpty(created.Annotations[k8s.SpecHashAnnotation])
+
+	// Others annotate it and restart its pods.
+	created.Annotations["custom"] = "annotation"
+	_, err = mcli.AppsV1().StatefulSets(testns).Update(context.TODO(), created, metav1.UpdateOptions{})
+	assert.NoError(err)
+	assert.NoError(service.RollingRestartStatefulSet(context.TODO(), testns, "rfr-test"))
+
+	// The same statefulset is not patched.
+	assert.NoError(service.CreateOrPatchStatefulSet(context.TODO(), testns, patchedStatefulSet("redis:6", map[string]string{"app": "redis", "tier": "cache"})))
+	assert.Empty(statefulSetPatches(mcli))
+
+	// The changes are patched, the fields removed since the last apply are deleted and the ones set by
+	// others are kept.
+	assert.NoError(service.CreateOrPatchStatefulSet(context.TODO(), testns, patchedStatefulSet("redis:7", map[string]string{"app": "redis"})))
+	patches := statefulSetPatches(mcli)
+	if assert.Len(patches, 1) {
+		assert.Equal(types.StrategicMergePatchType, patches[0].GetPatchType())
+		// Only the fields of the operator are sent, with the last applied statefulset and its hash.
+		patch := map[string]interface{}{}
+		assert.NoError(json.Unmarshal(patches[0].GetPatch(), &patch))
+		annotations := patch["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+		assert.Contains(annotations, k8s.LastAppliedAnnotation)
+		assert.Contains(annotations, k8s.SpecHashAnnotation)
+		delete(patch["metadata"].(map[string]interface{}), "annotations")
+		owned, err := json.Marshal(patch)
+		assert.NoError(err)
+		assert.Equal(`{"metadata":{"labels":{"tier":null}},"spec":{"template":{"spec":{"$setElementOrder/containers":[{"name":"redis"}],"containers":[{"image":"redis:7","name":"redis"}]}}}}`, string(owned))
+	}
+	patched, err := service.GetStatefulSet(context.TODO(), testns, "rfr-test")
+	assert.NoError(err)
+	assert.Equal("redis:7", patched.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(map[string]string{"app": "redis"}, patched.Labels)
+	assert.Equal("annotation", patched.Annotations["custom"])
+	assert.NotEmpty(patched.Spec.Template.Annotations[k8s.RestartedAtAnnotation])
+}
+
+func TestStatefulSetServicePatch(t *testing.T) {
+	assert := assert.New(t)
+
+	mcli := kubernetes.NewSimpleClientset(patchedStatefulSet("redis:6", map[string]string{"app": "redis"}))
+	recorder := &k8sOperationRecorder{Recorder: metrics.Dummy}
+	service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, recorder)
+
+	data := []byte(`{"spec":{"replicas":3,"template":{"spec":{"containers":[{"image":"redis:7","name":"redis"}]}}}}`)
+	assert.NoError(service.PatchStatefulSet(context.TODO(), "testns", "rfr-test", data))
+
+	// The bytes are sent as given, without a read nor a full update of the statefulset.
+	if assert.Len(mcli.Actions(), 1) {
+		patch := mcli.Actions()[0].(kubetesting.PatchAction)
+		assert.Equal("rfr-test", patch.GetName())
+		assert.Equal(types.StrategicMergePatchType, patch.GetPatchType())
+		assert.Equal(data, patch.GetPatch())
+	}
+	assert.Equal([]string{"PATCH"}, recorder.operations)
+	assert.Equal([]string{metrics.SUCCESS}, recorder.statuses)
+
+	patched, err := service.GetStatefulSet(context.TODO(), "testns", "rfr-test")
+	assert.NoError(err)
+	assert.Equal(int32(3), *patched.Spec.Replicas)
+	assert.Equal("redis:7", patched.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(map[string]string{"app": "redis"}, patched.Labels)
+}
+
+func TestStatefulSetServicePatchNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	mcli := kubernetes.NewSimpleClientset()
+	service := k8s.NewStatefulSetService(mcli, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+	err := service.PatchStatefulSet(context.TODO(), "testns", "rfr-test", []byte(`{"metadata":{"labels":{"app":"redis"}}}`))
+	assert.True(kubeerrors.IsNotFound(err))
+}
@@ -1,44 +1,244 @@
 package k8s
 
 import (
-	"fmt"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/util/retry"
 	redisfailoverv1 "redis-operator/api/redisfailover/v1"
 	"redis-operator/metrics"
-	"k8s.io/apimachinery/pkg/api/errors"
+	"redis-operator/service/redis"
 )
 
+// FileAuthPasswordProvider obtains the password of the RFs with the File auth mode, whose redis pods
+// read it from a file the operator can't see. The secret of spec.auth.secretPath is read when it's nil.
+var FileAuthPasswordProvider redis.PasswordProvider
+
 // GetRedisPassword retreives password from kubernetes secret or, if
 // unspecified, returns a blank string
-func GetRedisPassword(s Services, rf *redisfailoverv1.RedisFailover) (string, error) {
-
-	if rf.Spec.Auth.SecretPath == "" {
-		// no auth settings specified, return blank password
-		return "", nil
-	}
-
-	secret, err := s.GetSecret(rf.ObjectMeta.Namespace, rf.Spec.Auth.SecretPath)
-	if err != nil {
-		return "", err
+func GetRedisPassword(ctx context.Context, s Services, rf *redisfailoverv1.RedisFailover) (string, error) {
+	if rf.AuthFromFile() && FileAuthPasswordProvider != nil {
+		return FileAuthPasswordProvider.GetPassword(ctx, rf)
 	}
-
-	if password, ok := secret.Data["password"]; ok {
-		return string(password), nil
-	}
-
-	return "", fmt.Errorf("secret \"%s\" does not have a password field", rf.Spec.Auth.SecretPath)
+	return redis.NewSecretPasswordProvider(s).GetPassword(ctx, rf)
 }
 
-func recordMetrics(namespace string, kind string, object string, operation string, err error, metricsRecorder metrics.Recorder) {
+// recordMetrics records the operation and returns its error. A failed operation returns the error of the
+// context when it's done, so the operations interrupted by a cancelled reconcile return ctx.Err(). An
+// operation that succeeded is recorded and returned as a success even if the context is done since.
+func recordMetrics(ctx context.Context, namespace string, kind string, object string, operation string, err error, metricsRecorder metrics.Recorder) error {
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		err = ctxErr
+	}
 	if nil == err {
 		metricsRecorder.RecordK8sOperation(namespace, kind, object, operation, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	} else if err == context.Canceled || err == context.DeadlineExceeded {
+		metricsRecorder.RecordK8sOperation(namespace, kind, object, operation, metrics.FAIL, metrics.K8S_CONTEXT_DONE)
 	} else if errors.IsForbidden(err) {
 		metricsRecorder.RecordK8sOperation(namespace, kind, object, operation, metrics.FAIL, metrics.K8S_FORBIDDEN_ERR)
 	} else if errors.IsUnauthorized(err) {
 		metricsRecorder.RecordK8sOperation(namespace, kind, object, operation, metrics.FAIL, metrics.K8S_UNAUTH)
 	} else if errors.IsNotFound(err) {
 		metricsRecorder.RecordK8sOperation(namespace, kind, object, operation, metrics.FAIL, metrics.K8S_NOT_FOUND)
+	} else if IsNamespaceTerminatingError(err) {
+		metricsRecorder.RecordK8sOperation(namespace, kind, object, operation, metrics.FAIL, metrics.K8S_NAMESPACE_TERMINATING)
+	} else if IsEvictionBlocked(err) {
+		metricsRecorder.RecordK8sOperation(namespace, kind, object, operation, metrics.FAIL, metrics.K8S_EVICTION_BLOCKED)
+	} else if DefaultAPIServerPressure.Observe(err) {
+		metricsRecorder.RecordK8sOperation(namespace, kind, object, operation, metrics.FAIL, metrics.K8S_TOO_MANY_REQUESTS)
 	} else {
 		metricsRecorder.RecordK8sOperation(namespace, kind, object, operation, metrics.FAIL, metrics.K8S_MISC)
 	}
+	return err
+}
+
+// DefaultConflictRetries is the number of times an update is retried after a conflict with a newer
+// version of the object.
+const DefaultConflictRetries = 5
+
+// updateOnConflict updates the object until it doesn't conflict with a newer version of it, retrying at
+// most retries times. Before every retry get reads the latest version of the object and mutate applies our
+// changes over it, so what others wrote on the fields we don't set is kept, and the retry is recorded.
+func updateOnConflict[T any](retries int, namespace string, kind string, name string, metricsRecorder metrics.Recorder, object T, get func() (T, error), mutate func(stored T), update func(object T) error) error {
+	if retries < 0 {
+		retries = 0
+	}
+	backoff := retry.DefaultRetry
+	backoff.Steps = retries + 1
+	attempt := 0
+	return retry.RetryOnConflict(backoff, func() error {
+		if attempt > 0 {
+			metricsRecorder.RecordK8sConflictRetry(namespace, kind, name)
+			stored, err := get()
+			if err != nil {
+				return err
+			}
+			mutate(stored)
+			object = stored
+		}
+		attempt++
+		return update(object)
+	})
+}
+
+// setDesiredMeta sets the labels, annotations and owner references of the desired object on the stored
+// one, the rest of its metadata is the one of the stored object.
+func setDesiredMeta(stored, desired metav1.Object) {
+	stored.SetLabels(desired.GetLabels())
+	stored.SetAnnotations(desired.GetAnnotations())
+	stored.SetOwnerReferences(desired.GetOwnerReferences())
+}
+
+// DefaultListPageSize is the number of objects listed by page when the list options don't set a limit.
+const DefaultListPageSize int64 = 500
+
+// eachPage lists the objects matching the options page by page, following the continue token of every
+// page until the last one, so only one page is held at once. list lists a page and returns its continue
+// token. An expired continue token fails the listing, it's listed again from the start on the next call.
+func eachPage(opts metav1.ListOptions, list func(opts metav1.ListOptions) (string, error)) error {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultListPageSize
+	}
+	for {
+		next, err := list(opts)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		opts.Continue = next
+	}
+}
+
+// SpecHashAnnotation is written by the CreateOrUpdate functions with the hash of the desired object. The
+// update is skipped while the desired object has the same hash, so the objects that didn't change are
+// not written on every reconcile. The changes made by others on an object are kept until the desired
+// object changes.
+const SpecHashAnnotation = "databases.spotahome.com/spec-hash"
+
+// setSpecHash writes on the annotations of the desired object the hash of its spec, its labels, its
+// annotations and its owners, and returns it.
+func setSpecHash(object metav1.Object, spec interface{}) (string, error) {
+	annotations := map[string]string{}
+	for k, v := range object.GetAnnotations() {
+		if k != SpecHashAnnotation && k != LastAppliedAnnotation {
+			annotations[k] = v
+		}
+	}
+	data, err := json.Marshal(struct {
+		Spec            interface{}
+		Labels          map[string]string
+		Annotations     map[string]string
+		OwnerReferences []metav1.OwnerReference
+	}{spec, object.GetLabels(), annotations, object.GetOwnerReferences()})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	annotations[SpecHashAnnotation] = hash
+	object.SetAnnotations(annotations)
+	return hash, nil
+}
+
+// LastAppliedAnnotation is written by the CreateOrPatch functions with the desired object they applied.
+// The fields removed from the desired object since are deleted from the stored one, while the fields
+// set by others are kept.
+const LastAppliedAnnotation = "databases.spotahome.com/last-applied"
+
+// setLastApplied writes on the annotations of the desired object the object itself, without its previous
+// last applied annotation, so the next patches know the fields it had.
+func setLastApplied(object metav1.Object) error {
+	annotations := map[string]string{}
+	for k, v := range object.GetAnnotations() {
+		if k != LastAppliedAnnotation {
+			annotations[k] = v
+		}
+	}
+	object.SetAnnotations(annotations)
+	lastApplied, err := patchableJSON(object)
+	if err != nil {
+		return err
+	}
+	annotations[LastAppliedAnnotation] = string(lastApplied)
+	return nil
+}
+
+// createThreeWayMergePatch returns the strategic merge patch applying the desired object, with its last
+// applied annotation set by setLastApplied, over the stored one. The fields are only deleted when the
+// object last applied had them, nothing is deleted from the objects without a last applied annotation.
+func createThreeWayMergePatch(stored, desired metav1.Object, dataStruct interface{}) ([]byte, error) {
+	modified, err := patchableJSON(desired)
+	if err != nil {
+		return nil, err
+	}
+	current, err := patchableJSON(stored)
+	if err != nil {
+		return nil, err
+	}
+	original := []byte(stored.GetAnnotations()[LastAppliedAnnotation])
+	if len(original) == 0 {
+		original = []byte(desired.GetAnnotations()[LastAppliedAnnotation])
+	}
+	schema, err := strategicpatch.NewPatchMetaFromStruct(dataStruct)
+	if err != nil {
+		return nil, err
+	}
+	return strategicpatch.CreateThreeWayMergePatch(original, modified, current, schema, true)
+}
+
+// patchableJSON returns the JSON the patches are computed on: the object without its status, written by
+// its controller, nor the null fields the desired objects have for the fields they don't set.
+func patchableJSON(object interface{}) ([]byte, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "status")
+	return json.Marshal(pruneNulls(fields))
+}
+
+func pruneNulls(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if field == nil {
+				delete(v, k)
+				continue
+			}
+			v[k] = pruneNulls(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = pruneNulls(item)
+		}
+	}
+	return value
+}
+
+// FieldManager is the field manager of the objects the operator writes with a server-side apply.
+const FieldManager = "redis-operator"
+
+// applyOptions force the server-side applies, the operator takes the ownership of the fields it sets
+// that other managers set too.
+var applyOptions = metav1.ApplyOptions{FieldManager: FieldManager, Force: true}
+
+// toApplyConfiguration fills the apply configuration of the object with the fields it sets, its status
+// and the fields it doesn't set are left to the other managers.
+func toApplyConfiguration(object, config interface{}) error {
+	data, err := patchableJSON(object)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, config)
 }
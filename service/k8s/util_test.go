@@ -0,0 +1,30 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateThreeWayMergePatchLeavesDesired(t *testing.T) {
+	assert := assert.New(t)
+
+	desired := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test", Labels: map[string]string{"app": "redis"}}}
+	assert.NoError(setLastApplied(desired))
+	lastApplied := desired.Annotations[LastAppliedAnnotation]
+	assert.NotEmpty(lastApplied)
+
+	// The patch is computed without touching the desired object.
+	stored := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "rfr-test"}}
+	want := desired.DeepCopy()
+	patch, err := createThreeWayMergePatch(stored, desired, appsv1.StatefulSet{})
+	assert.NoError(err)
+	assert.Equal(want, desired)
+	assert.Contains(string(patch), `"app":"redis"`)
+
+	// The last applied annotation of a desired object is replaced, not nested.
+	assert.NoError(setLastApplied(desired))
+	assert.Equal(lastApplied, desired.Annotations[LastAppliedAnnotation])
+}
@@ -0,0 +1,87 @@
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/service/k8s"
+)
+
+const snapshotTestNamespace = "testns"
+
+func newFakeDynamicClient() *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		k8s.VolumeSnapshotGVR: "VolumeSnapshotList",
+	})
+}
+
+func generateVolumeSnapshot(name string, rfName string) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetAPIVersion("snapshot.storage.k8s.io/v1")
+	snapshot.SetKind("VolumeSnapshot")
+	snapshot.SetName(name)
+	snapshot.SetNamespace(snapshotTestNamespace)
+	snapshot.SetLabels(map[string]string{k8s.OwnerNameLabel: rfName})
+	return snapshot
+}
+
+func TestVolumeSnapshotLifecycle(t *testing.T) {
+	assert := assert.New(t)
+
+	service := k8s.NewVolumeSnapshotService(newFakeDynamicClient(), log.Dummy, metrics.Dummy)
+	selector := labels.SelectorFromSet(map[string]string{k8s.OwnerNameLabel: "test"})
+
+	require.NoError(t, service.CreateVolumeSnapshot(context.TODO(), snapshotTestNamespace, generateVolumeSnapshot("rfr-test-1", "test")))
+	require.NoError(t, service.CreateVolumeSnapshot(context.TODO(), snapshotTestNamespace, generateVolumeSnapshot("rfr-test-2", "test")))
+	require.NoError(t, service.CreateVolumeSnapshot(context.TODO(), snapshotTestNamespace, generateVolumeSnapshot("rfr-other-1", "other")))
+
+	snapshots, err := service.ListVolumeSnapshots(context.TODO(), snapshotTestNamespace, selector)
+	require.NoError(t, err)
+	names := []string{}
+	for _, snapshot := range snapshots.Items {
+		names = append(names, snapshot.GetName())
+	}
+	assert.ElementsMatch([]string{"rfr-test-1", "rfr-test-2"}, names)
+
+	assert.NoError(service.DeleteVolumeSnapshot(context.TODO(), snapshotTestNamespace, "rfr-test-1"))
+	// A snapshot already deleted is not an error.
+	assert.NoError(service.DeleteVolumeSnapshot(context.TODO(), snapshotTestNamespace, "rfr-test-1"))
+
+	snapshots, err = service.ListVolumeSnapshots(context.TODO(), snapshotTestNamespace, selector)
+	require.NoError(t, err)
+	if assert.Len(snapshots.Items, 1) {
+		assert.Equal("rfr-test-2", snapshots.Items[0].GetName())
+	}
+}
+
+func TestVolumeSnapshotWithoutCRD(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newFakeDynamicClient()
+	client.PrependReactor("*", "volumesnapshots", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(k8s.VolumeSnapshotGVR.GroupResource(), "")
+	})
+	service := k8s.NewVolumeSnapshotService(client, log.Dummy, metrics.Dummy)
+
+	_, err := service.ListVolumeSnapshots(context.TODO(), snapshotTestNamespace, labels.Everything())
+	assert.ErrorIs(err, k8s.ErrVolumeSnapshotsUnavailable)
+	err = service.CreateVolumeSnapshot(context.TODO(), snapshotTestNamespace, generateVolumeSnapshot("rfr-test-1", "test"))
+	assert.ErrorIs(err, k8s.ErrVolumeSnapshotsUnavailable)
+
+	// Without a dynamic client the snapshots are not available either.
+	service = k8s.NewVolumeSnapshotService(nil, log.Dummy, metrics.Dummy)
+	_, err = service.ListVolumeSnapshots(context.TODO(), snapshotTestNamespace, labels.Everything())
+	assert.ErrorIs(err, k8s.ErrVolumeSnapshotsUnavailable)
+}
@@ -0,0 +1,84 @@
+package k8s
+
+import (
+	"sync"
+
+	redisfailoverclientset "redis-operator/client/k8s/clientset/versioned"
+)
+
+// warningCode is the HTTP warning code used by the apiserver for its warnings (RFC 7234).
+const warningCode = 299
+
+// WarningHandler collects the warnings the apiserver returns on the responses so they can
+// be logged together with the identity of the object that triggered them.
+// It satisfies the client-go rest.WarningHandler interface.
+type WarningHandler struct {
+	mu       sync.Mutex
+	warnings []string
+}
+
+// NewWarningHandler returns a new WarningHandler.
+func NewWarningHandler() *WarningHandler {
+	return &WarningHandler{}
+}
+
+// HandleWarningHeader satisfies rest.WarningHandler interface.
+func (w *WarningHandler) HandleWarningHeader(code int, agent string, text string) {
+	if code != warningCode || text == "" {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.warnings = append(w.warnings, text)
+}
+
+// Flush returns the warnings received since the last flush and forgets them.
+func (w *WarningHandler) Flush() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	warnings := w.warnings
+	w.warnings = nil
+	return warnings
+}
+
+// RedisFailoverWriteClient is a client the redisfailover writes are sent with, Warnings is the warning
+// handler of its rest config. It's only used for the writes, one at a time, so the warnings it collects
+// belong to the redisfailover being written, not to the lists and watches of the others.
+type RedisFailoverWriteClient struct {
+	Client   redisfailoverclientset.Interface
+	Warnings *WarningHandler
+}
+
+// RedisFailoverWriteClients hands out the clients the redisfailover writes are sent with. Every write in
+// flight gets one of its own, so the writes are not serialized for their warnings to be attributed. A
+// client is created with newClient when all the others are in use, they are reused afterwards.
+type RedisFailoverWriteClients struct {
+	newClient func() (*RedisFailoverWriteClient, error)
+	mu        sync.Mutex
+	idle      []*RedisFailoverWriteClient
+}
+
+// NewRedisFailoverWriteClients returns a new RedisFailoverWriteClients creating its clients with newClient.
+func NewRedisFailoverWriteClients(newClient func() (*RedisFailoverWriteClient, error)) *RedisFailoverWriteClients {
+	return &RedisFailoverWriteClients{newClient: newClient}
+}
+
+// get returns a client no other write is using.
+func (c *RedisFailoverWriteClients) get() (*RedisFailoverWriteClient, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		cli := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cli, nil
+	}
+	c.mu.Unlock()
+	return c.newClient()
+}
+
+// put gives back a client got for a write, once its warnings are flushed.
+func (c *RedisFailoverWriteClients) put(cli *RedisFailoverWriteClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.idle = append(c.idle, cli)
+}
@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"redis-operator/log"
+)
+
+// dialRetryInterval is the wait between the connections to the redis socket while it's restarted.
+const dialRetryInterval = 100 * time.Millisecond
+
+// DefaultHoldTimeout is how long a client connection is held while redis doesn't accept it.
+const DefaultHoldTimeout = 30 * time.Second
+
+// Proxy forwards the connections of the redis port to the socket redis listens on. While the redis
+// process is restarted the new connections are held until it accepts them again, so the clients see
+// a pause instead of a refused connection. The connections established before the restart are closed
+// by redis when it shuts down, as its clients state is lost.
+type Proxy struct {
+	// Upstream is the path of the socket redis listens on.
+	Upstream string
+	// HoldTimeout is how long a connection waits for redis to accept it, it's closed afterwards.
+	HoldTimeout time.Duration
+	Logger      log.Logger
+}
+
+// Serve forwards the connections accepted by the listener until the context is done.
+func (p *Proxy) Serve(ctx context.Context, l net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		client, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.handle(ctx, client)
+		}()
+	}
+}
+
+// handle connects the client to redis and copies the data both ways until either side closes.
+func (p *Proxy) handle(ctx context.Context, client net.Conn) {
+	defer client.Close()
+
+	upstream, err := p.dialUpstream(ctx)
+	if err != nil {
+		p.Logger.Warnf("closing the connection of %s, redis didn't accept it: %s", client.RemoteAddr(), err)
+		return
+	}
+	defer upstream.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(upstream, client)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, upstream)
+		done <- struct{}{}
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// dialUpstream connects to the redis socket, retrying while redis is not accepting connections for
+// the hold timeout.
+func (p *Proxy) dialUpstream(ctx context.Context) (net.Conn, error) {
+	timeout := p.HoldTimeout
+	if timeout <= 0 {
+		timeout = DefaultHoldTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	for {
+		conn, err := dialer.DialContext(ctx, "unix", p.Upstream)
+		if err == nil {
+			return conn, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %s", ctx.Err(), err)
+		case <-time.After(dialRetryInterval):
+		}
+	}
+}
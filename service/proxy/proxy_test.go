@@ -0,0 +1,94 @@
+package proxy_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"redis-operator/log"
+	"redis-operator/service/proxy"
+)
+
+// serveRedis answers +PONG to every line read on the connections of the socket.
+func serveRedis(t *testing.T, socket string) net.Listener {
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					if _, err := r.ReadString('\n'); err != nil {
+						return
+					}
+					if _, err := conn.Write([]byte("+PONG\r\n")); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return l
+}
+
+func startProxy(t *testing.T, socket string, hold time.Duration) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	p := &proxy.Proxy{Upstream: socket, HoldTimeout: hold, Logger: log.Dummy}
+	go p.Serve(ctx, l)
+	return l.Addr().String()
+}
+
+func ping(conn net.Conn) (string, error) {
+	if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+		return "", err
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return bufio.NewReader(conn).ReadString('\n')
+}
+
+func TestProxyHoldsConnectionsWhileRedisRestarts(t *testing.T) {
+	assert := assert.New(t)
+
+	socket := filepath.Join(t.TempDir(), "redis.sock")
+	addr := startProxy(t, socket, 5*time.Second)
+
+	// Redis is not listening yet, the connection is accepted and held.
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	time.Sleep(300 * time.Millisecond)
+	redis := serveRedis(t, socket)
+	defer redis.Close()
+
+	reply, err := ping(conn)
+	assert.NoError(err)
+	assert.Equal("+PONG\r\n", reply)
+}
+
+func TestProxyClosesConnectionsAfterHoldTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	socket := filepath.Join(t.TempDir(), "redis.sock")
+	addr := startProxy(t, socket, 200*time.Millisecond)
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = ping(conn)
+	assert.Error(err)
+}
@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	rediscli "github.com/go-redis/redis/v8"
+
+	"redis-operator/metrics"
+)
+
+// aclMajor is the first redis version with the ACLs.
+const aclMajor = 6
+
+// LoadACL reloads the users of the ACL file of a redis node with ACL LOAD. ErrUnsupported is returned
+// by the redis older than 6, the error of redis quotes the line of the file it can't parse.
+func (c *client) LoadACL(ip, port, password string) error {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, port),
+		Password: password,
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	info, err := rClient.Info(context.TODO(), "server").Result()
+	if err == nil {
+		err = aclSupported(info)
+	}
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.LOAD_ACL, metrics.FAIL, getRedisError(err))
+		return err
+	}
+	if err := rClient.Do(context.TODO(), "ACL", "LOAD").Err(); err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.LOAD_ACL, metrics.FAIL, getRedisError(err))
+		return err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.LOAD_ACL, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return nil
+}
+
+// ListACLUsers returns the users of a redis node, from ACL LIST.
+func (c *client) ListACLUsers(ip, port, password string) ([]string, error) {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, port),
+		Password: password,
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	rules, err := rClient.Do(context.TODO(), "ACL", "LIST").StringSlice()
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.LIST_ACL, metrics.FAIL, getRedisError(err))
+		return nil, err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.LIST_ACL, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return parseACLListUsers(rules), nil
+}
+
+func aclSupported(info string) error {
+	version, major, _, ok := parseRedisVersion(info)
+	if ok && major < aclMajor {
+		return fmt.Errorf("%w: the ACLs need redis %d, redis runs %s", ErrUnsupported, aclMajor, version)
+	}
+	return nil
+}
+
+// parseACLListUsers returns the names of the users of the rules replied by ACL LIST, as
+// "user default on nopass ~* &* +@all".
+func parseACLListUsers(rules []string) []string {
+	users := []string{}
+	for _, rule := range rules {
+		fields := strings.Fields(rule)
+		if len(fields) >= 2 && fields[0] == "user" {
+			users = append(users, fields[1])
+		}
+	}
+	return users
+}
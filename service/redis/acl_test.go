@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestACLSupported(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     string
+		expError string
+	}{
+		{
+			name:     "Redis 5",
+			info:     "# Server\r\nredis_version:5.0.14\r\nredis_mode:standalone\r\n",
+			expError: "unsupported by the server version: the ACLs need redis 6, redis runs 5.0.14",
+		},
+		{
+			name: "Redis 6",
+			info: "# Server\r\nredis_version:6.0.16\r\nredis_mode:standalone\r\n",
+		},
+		{
+			name: "Redis 7",
+			info: "# Server\r\nredis_version:7.2.4\r\nredis_mode:standalone\r\n",
+		},
+		{
+			name: "Unknown version",
+			info: "# Server\r\nredis_mode:standalone\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := aclSupported(test.info)
+			if test.expError == "" {
+				assert.NoError(err)
+				return
+			}
+			assert.EqualError(err, test.expError)
+			assert.True(errors.Is(err, ErrUnsupported))
+		})
+	}
+}
+
+func TestParseACLListUsers(t *testing.T) {
+	users := parseACLListUsers([]string{
+		"user app on #0b14d501a594442a01c6859541bcb3e8164d183d32937b851835442f69d5c94e ~app:* &* -@all +get +set",
+		"user default on nopass sanitize-payload ~* &* +@all",
+		"",
+	})
+	assert.Equal(t, []string{"app", "default"}, users)
+}
@@ -3,11 +3,10 @@ package redis
 import (
 	"context"
 	"errors"
-	"fmt"
 	"net"
 	"regexp"
-	"strconv"
 	"strings"
+	"time"
 
 	rediscli "github.com/go-redis/redis/v8"
 	"redis-operator/log"
@@ -20,26 +19,45 @@ type Client interface {
 	GetNumberSentinelSlavesInMemory(ip string) (int32, error)
 	ResetSentinel(ip string) error
 	GetSlaveOf(ip, port, password string) (string, error)
+	GetSlaveOfWithPort(ip, port, password string) (string, string, error)
 	IsMaster(ip, port, password string) (bool, error)
 	MonitorRedis(ip, monitor, quorum, password string) error
 	MonitorRedisWithPort(ip, monitor, port, quorum, password string) error
 	MakeMaster(ip, port, password string) error
 	MakeSlaveOf(ip, masterIP, password string) error
 	MakeSlaveOfWithPort(ip, masterIP, masterPort, password string) error
+	MakeSlaveOfWithPorts(ip, port, masterIP, masterPort, password string) error
 	GetSentinelMonitor(ip string) (string, string, error)
+	IsMasterDownByAddr(ip, masterIP, masterPort string) (bool, error)
 	SetCustomSentinelConfig(ip string, configs []string) error
+	SentinelConfigGet(ip, parameter string) (map[string]string, error)
+	SentinelConfigSet(ip, parameter, value string) error
 	SetCustomRedisConfig(ip string, port string, configs []string, password string) error
 	SlaveIsReady(ip, port, password string) (bool, error)
+	GetSyncingReplicas(ip, port, password string) (int, error)
+	GetRedisInfo(ip, port, password string) (string, error)
+	GetRedisConfig(ip, port, password, parameter string) (string, error)
+	MemoryDoctor(ip, port, password string) (string, error)
+	LatencyDoctor(ip, port, password string) (string, error)
+	GetSentinelInfo(ip string) (string, error)
+	PingSentinel(ip string) error
+	PingRedis(ip, port, password string) error
+	SetRedisKey(ip, port, password, key, value string, ttl time.Duration) error
+	GetRedisKey(ip, port, password, key string) (string, error)
+	LoadACL(ip, port, password string) error
+	ListACLUsers(ip, port, password string) ([]string, error)
 }
 
 type client struct {
 	metricsRecorder metrics.Recorder
 }
 
-// New returns a redis client
+// New returns a redis client. The panics while reading the replies are returned as errors.
 func New(metricsRecorder metrics.Recorder) Client {
-	return &client{
-		metricsRecorder: metricsRecorder,
+	return &recoveringClient{
+		client: &client{
+			metricsRecorder: metricsRecorder,
+		},
 	}
 }
 
@@ -48,6 +66,8 @@ const (
 	slaveNumberREString     = "slaves=([0-9]+)"
 	sentinelStatusREString  = "status=([a-z]+)"
 	redisMasterHostREString = "master_host:([0-9.]+)"
+	redisMasterPortREString = "master_port:([0-9]+)"
+	redisFullSyncREString   = "(?m)^slave[0-9]+:.*state=(wait_bgsave|send_bulk)"
 	redisRoleMaster         = "role:master"
 	redisSyncing            = "master_sync_in_progress:1"
 	redisMasterSillPending  = "master_host:127.0.0.1"
@@ -55,6 +75,9 @@ const (
 	redisPort               = "6379"
 	sentinelPort            = "26379"
 	masterName              = "mymaster"
+	sentinelPingTimeout     = 2 * time.Second
+	redisPingTimeout        = 2 * time.Second
+	redisKeyTimeout         = 2 * time.Second
 )
 
 var (
@@ -62,6 +85,8 @@ var (
 	sentinelStatusRE  = regexp.MustCompile(sentinelStatusREString)
 	slaveNumberRE     = regexp.MustCompile(slaveNumberREString)
 	redisMasterHostRE = regexp.MustCompile(redisMasterHostREString)
+	redisMasterPortRE = regexp.MustCompile(redisMasterPortREString)
+	redisFullSyncRE   = regexp.MustCompile(redisFullSyncREString)
 )
 
 // GetNumberSentinelsInMemory return the number of sentinels that the requested sentinel has
@@ -78,22 +103,13 @@ func (c *client) GetNumberSentinelsInMemory(ip string) (int32, error) {
 		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_SENTINELS_IN_MEM, metrics.FAIL, getRedisError(err))
 		return 0, err
 	}
-	if err2 := isSentinelReady(info); err2 != nil {
-		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_SENTINELS_IN_MEM, metrics.FAIL, metrics.SENTINEL_NOT_READY)
-		return 0, err2
-	}
-	match := sentinelNumberRE.FindStringSubmatch(info)
-	if len(match) == 0 {
-		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_SENTINELS_IN_MEM, metrics.FAIL, metrics.REGEX_NOT_FOUND)
-		return 0, errors.New("seninel regex not found")
-	}
-	nSentinels, err := strconv.Atoi(match[1])
+	nSentinels, err := parseSentinelInfoCount(info, sentinelNumberRE, "sentinels")
 	if err != nil {
-		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_SENTINELS_IN_MEM, metrics.FAIL, metrics.MISC)
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_SENTINELS_IN_MEM, metrics.FAIL, sentinelInfoError(err))
 		return 0, err
 	}
 	c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_SENTINELS_IN_MEM, metrics.SUCCESS, metrics.NOT_APPLICABLE)
-	return int32(nSentinels), nil
+	return nSentinels, nil
 }
 
 // GetNumberSentinelsInMemory return the number of sentinels that the requested sentinel has
@@ -110,30 +126,25 @@ func (c *client) GetNumberSentinelSlavesInMemory(ip string) (int32, error) {
 		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_REDIS_SLAVES_IN_MEM, metrics.FAIL, getRedisError(err))
 		return 0, err
 	}
-	if err2 := isSentinelReady(info); err2 != nil {
-		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_REDIS_SLAVES_IN_MEM, metrics.FAIL, metrics.SENTINEL_NOT_READY)
-		return 0, err2
-	}
-	match := slaveNumberRE.FindStringSubmatch(info)
-	if len(match) == 0 {
-		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_REDIS_SLAVES_IN_MEM, metrics.FAIL, metrics.REGEX_NOT_FOUND)
-		return 0, errors.New("slaves regex not found")
-	}
-	nSlaves, err := strconv.Atoi(match[1])
+	nSlaves, err := parseSentinelInfoCount(info, slaveNumberRE, "slaves")
 	if err != nil {
-		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_REDIS_SLAVES_IN_MEM, metrics.FAIL, metrics.MISC)
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_REDIS_SLAVES_IN_MEM, metrics.FAIL, sentinelInfoError(err))
 		return 0, err
 	}
 	c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_NUM_REDIS_SLAVES_IN_MEM, metrics.SUCCESS, metrics.NOT_APPLICABLE)
-	return int32(nSlaves), nil
+	return nSlaves, nil
 }
 
-func isSentinelReady(info string) error {
-	matchStatus := sentinelStatusRE.FindStringSubmatch(info)
-	if len(matchStatus) == 0 || matchStatus[1] != "ok" {
-		return errors.New("sentinels not ready")
+// sentinelInfoError returns the metric error of a sentinel INFO reply that can't be read.
+func sentinelInfoError(err error) string {
+	switch {
+	case errors.Is(err, errSentinelNotReady):
+		return metrics.SENTINEL_NOT_READY
+	case errors.Is(err, errRegexNotFound):
+		return metrics.REGEX_NOT_FOUND
+	default:
+		return metrics.MISC
 	}
-	return nil
 }
 
 // ResetSentinel sends a sentinel reset * for the given sentinel
@@ -176,13 +187,27 @@ func (c *client) GetSlaveOf(ip, port, password string) (string, error) {
 		log.Errorf("error while getting masterIP : Failed to get info replication while querying redis instance %v", ip)
 		return "", err
 	}
-	match := redisMasterHostRE.FindStringSubmatch(info)
-	if len(match) == 0 {
-		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_SLAVE_OF, metrics.SUCCESS, metrics.NOT_APPLICABLE)
-		return "", nil
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_SLAVE_OF, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return parseSlaveOf(info), nil
+}
+
+// GetSlaveOfWithPort returns the host and the port of the master of the given redis, empty if it's master
+func (c *client) GetSlaveOfWithPort(ip, port, password string) (string, string, error) {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, port),
+		Password: password,
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	info, err := rClient.Info(context.TODO(), "replication").Result()
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_SLAVE_OF, metrics.FAIL, getRedisError(err))
+		return "", "", err
 	}
 	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_SLAVE_OF, metrics.SUCCESS, metrics.NOT_APPLICABLE)
-	return match[1], nil
+	host, masterPort := parseSlaveOfWithPort(info)
+	return host, masterPort, nil
 }
 
 func (c *client) IsMaster(ip, port, password string) (bool, error) {
@@ -266,8 +291,13 @@ func (c *client) MakeSlaveOf(ip, masterIP, password string) error {
 }
 
 func (c *client) MakeSlaveOfWithPort(ip, masterIP, masterPort, password string) error {
+	return c.MakeSlaveOfWithPorts(ip, masterPort, masterIP, masterPort, password) // the RedisFailover redis listen on the master port
+}
+
+// MakeSlaveOfWithPorts makes the redis listening on ip:port a slave of masterIP:masterPort.
+func (c *client) MakeSlaveOfWithPorts(ip, port, masterIP, masterPort, password string) error {
 	options := &rediscli.Options{
-		Addr:     net.JoinHostPort(ip, masterPort), // this is IP and Port for the RedisFailover redis
+		Addr:     net.JoinHostPort(ip, port),
 		Password: password,
 		DB:       0,
 	}
@@ -300,12 +330,45 @@ func (c *client) GetSentinelMonitor(ip string) (string, string, error) {
 		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_SENTINEL_MONITOR, metrics.FAIL, getRedisError(err))
 		return "", "", err
 	}
-	masterIP := res[3].(string)
-	masterPort := res[5].(string)
+	masterIP, masterPort, err := parseSentinelMasterReply(res)
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_SENTINEL_MONITOR, metrics.FAIL, metrics.NOT_APPLICABLE)
+		return "", "", err
+	}
 	c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_SENTINEL_MONITOR, metrics.SUCCESS, metrics.NOT_APPLICABLE)
 	return masterIP, masterPort, nil
 }
 
+// IsMasterDownByAddr asks the sentinel if it sees the master listening on masterIP:masterPort down.
+// The sentinel is only asked for its view, it's not asked to vote for a leader.
+func (c *client) IsMasterDownByAddr(ip, masterIP, masterPort string) (bool, error) {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, sentinelPort),
+		Password: "",
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	cmd := rediscli.NewSliceCmd(context.TODO(), "SENTINEL", "IS-MASTER-DOWN-BY-ADDR", masterIP, masterPort, "0", "*")
+	err := rClient.Process(context.TODO(), cmd)
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.IS_MASTER_DOWN_BY_ADDR, metrics.FAIL, getRedisError(err))
+		return false, err
+	}
+	res, err := cmd.Result()
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.IS_MASTER_DOWN_BY_ADDR, metrics.FAIL, getRedisError(err))
+		return false, err
+	}
+	down, err := parseIsMasterDownReply(res)
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.IS_MASTER_DOWN_BY_ADDR, metrics.FAIL, metrics.NOT_APPLICABLE)
+		return false, err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.IS_MASTER_DOWN_BY_ADDR, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return down, nil
+}
+
 func (c *client) SetCustomSentinelConfig(ip string, configs []string) error {
 	options := &rediscli.Options{
 		Addr:     net.JoinHostPort(ip, sentinelPort),
@@ -316,7 +379,7 @@ func (c *client) SetCustomSentinelConfig(ip string, configs []string) error {
 	defer rClient.Close()
 
 	for _, config := range configs {
-		param, value, err := c.getConfigParameters(config)
+		param, value, err := getConfigParameters(config)
 		if err != nil {
 			return err
 		}
@@ -337,7 +400,7 @@ func (c *client) SetCustomRedisConfig(ip string, port string, configs []string,
 	defer rClient.Close()
 
 	for _, config := range configs {
-		param, value, err := c.getConfigParameters(config)
+		param, value, err := getConfigParameters(config)
 		if err != nil {
 			return err
 		}
@@ -374,17 +437,6 @@ func (c *client) applySentinelConfig(parameter string, value string, rClient *re
 	return cmd.Err()
 }
 
-func (c *client) getConfigParameters(config string) (parameter string, value string, err error) {
-	s := strings.Split(config, " ")
-	if len(s) < 2 {
-		return "", "", fmt.Errorf("configuration '%s' malformed", config)
-	}
-	if len(s) == 2 && s[1] == `""` {
-		return s[0], "", nil
-	}
-	return s[0], strings.Join(s[1:], " "), nil
-}
-
 func (c *client) SlaveIsReady(ip, port, password string) (bool, error) {
 	options := &rediscli.Options{
 		Addr:     net.JoinHostPort(ip, port),
@@ -399,13 +451,200 @@ func (c *client) SlaveIsReady(ip, port, password string) (bool, error) {
 		return false, err
 	}
 
-	ok := !strings.Contains(info, redisSyncing) &&
-		!strings.Contains(info, redisMasterSillPending) &&
-		strings.Contains(info, redisLinkUp)
+	ok := parseSlaveIsReady(info)
 	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, strings.Split(rClient.Options().Addr, ":")[0], metrics.SLAVE_IS_READY, metrics.SUCCESS, metrics.NOT_APPLICABLE)
 	return ok, nil
 }
 
+// GetSyncingReplicas returns the number of replicas the master is making a full sync to, either
+// waiting for the RDB to be saved or receiving it.
+func (c *client) GetSyncingReplicas(ip, port, password string) (int, error) {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, port),
+		Password: password,
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	info, err := rClient.Info(context.TODO(), "replication").Result()
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_SYNCING_REPLICAS, metrics.FAIL, getRedisError(err))
+		return 0, err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_SYNCING_REPLICAS, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return parseSyncingReplicas(info), nil
+}
+
+// GetRedisInfo returns the output of the INFO command of a redis instance.
+func (c *client) GetRedisInfo(ip, port, password string) (string, error) {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, port),
+		Password: password,
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	info, err := rClient.Info(context.TODO()).Result()
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_INFO, metrics.FAIL, getRedisError(err))
+		return "", err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_INFO, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return info, nil
+}
+
+// GetRedisConfig returns the value of a config parameter of a redis, as it's running.
+func (c *client) GetRedisConfig(ip, port, password, parameter string) (string, error) {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, port),
+		Password: password,
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	reply, err := rClient.ConfigGet(context.TODO(), parameter).Result()
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_REDIS_CONFIG, metrics.FAIL, getRedisError(err))
+		return "", err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_REDIS_CONFIG, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return parseConfigGetReply(reply, parameter)
+}
+
+// MemoryDoctor returns the report of the MEMORY DOCTOR command of a redis.
+func (c *client) MemoryDoctor(ip, port, password string) (string, error) {
+	return c.doctor(ip, port, password, metrics.MEMORY_DOCTOR, "MEMORY")
+}
+
+// LatencyDoctor returns the report of the LATENCY DOCTOR command of a redis.
+func (c *client) LatencyDoctor(ip, port, password string) (string, error) {
+	return c.doctor(ip, port, password, metrics.LATENCY_DOCTOR, "LATENCY")
+}
+
+func (c *client) doctor(ip, port, password, operation, command string) (string, error) {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, port),
+		Password: password,
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	report, err := rClient.Do(context.TODO(), command, "DOCTOR").Text()
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, operation, metrics.FAIL, getRedisError(err))
+		return "", err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, operation, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return report, nil
+}
+
+// GetSentinelInfo returns the output of the INFO command of a sentinel.
+func (c *client) GetSentinelInfo(ip string) (string, error) {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, sentinelPort),
+		Password: "",
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	info, err := rClient.Info(context.TODO()).Result()
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_INFO, metrics.FAIL, getRedisError(err))
+		return "", err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_INFO, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return info, nil
+}
+
+// PingSentinel sends a PING to a sentinel. A sentinel not accepting connections or not answering is
+// given up on after a short timeout and without retries, so a deadlocked one doesn't hold the check.
+func (c *client) PingSentinel(ip string) error {
+	options := &rediscli.Options{
+		Addr:         net.JoinHostPort(ip, sentinelPort),
+		Password:     "",
+		DB:           0,
+		DialTimeout:  sentinelPingTimeout,
+		ReadTimeout:  sentinelPingTimeout,
+		WriteTimeout: sentinelPingTimeout,
+		MaxRetries:   -1,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	if err := rClient.Ping(context.TODO()).Err(); err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.PING, metrics.FAIL, getRedisError(err))
+		return err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.PING, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return nil
+}
+
+// PingRedis sends a PING to a redis node, with a short timeout and without retries like PingSentinel,
+// so the time it takes is the round-trip seen by the operator.
+func (c *client) PingRedis(ip, port, password string) error {
+	options := &rediscli.Options{
+		Addr:         net.JoinHostPort(ip, port),
+		Password:     password,
+		DB:           0,
+		DialTimeout:  redisPingTimeout,
+		ReadTimeout:  redisPingTimeout,
+		WriteTimeout: redisPingTimeout,
+		MaxRetries:   -1,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	if err := rClient.Ping(context.TODO()).Err(); err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.PING, metrics.FAIL, getRedisError(err))
+		return err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.PING, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return nil
+}
+
+// SetRedisKey sets a key expiring after the ttl on a redis node, with a short timeout and without
+// retries, so a redis not serving the writes is found in the time of a check.
+func (c *client) SetRedisKey(ip, port, password, key, value string, ttl time.Duration) error {
+	options := &rediscli.Options{
+		Addr:         net.JoinHostPort(ip, port),
+		Password:     password,
+		DB:           0,
+		DialTimeout:  redisKeyTimeout,
+		ReadTimeout:  redisKeyTimeout,
+		WriteTimeout: redisKeyTimeout,
+		MaxRetries:   -1,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	if err := rClient.Set(context.TODO(), key, value, ttl).Err(); err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.SET_KEY, metrics.FAIL, getRedisError(err))
+		return err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.SET_KEY, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return nil
+}
+
+// GetRedisKey returns the value of a key of a redis node, empty when the key doesn't exist. It has the
+// short timeout of SetRedisKey.
+func (c *client) GetRedisKey(ip, port, password, key string) (string, error) {
+	options := &rediscli.Options{
+		Addr:         net.JoinHostPort(ip, port),
+		Password:     password,
+		DB:           0,
+		DialTimeout:  redisKeyTimeout,
+		ReadTimeout:  redisKeyTimeout,
+		WriteTimeout: redisKeyTimeout,
+		MaxRetries:   -1,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	value, err := rClient.Get(context.TODO(), key).Result()
+	if err != nil && err != rediscli.Nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_KEY, metrics.FAIL, getRedisError(err))
+		return "", err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_REDIS, ip, metrics.GET_KEY, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return value, nil
+}
+
 func getRedisError(err error) string {
 	if strings.Contains(err.Error(), "NOAUTH") {
 		return metrics.NOAUTH
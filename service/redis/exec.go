@@ -0,0 +1,181 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"redis-operator/log"
+)
+
+// CLIRunner runs redis-cli with the arguments in the redis pod with the IP, and returns what it printed.
+type CLIRunner func(ctx context.Context, ip string, args []string) (string, error)
+
+// cliErrorReply matches the error replies printed by redis-cli, as "ERR unknown command" or
+// "WRONGPASS invalid username-password pair". None of the replies read by the exec client starts with
+// an upper case word followed by a space otherwise.
+var cliErrorReply = regexp.MustCompile(`^[A-Z]+ `)
+
+// execClient runs the commands of the redis pods with redis-cli in their pods when their IP can't be
+// connected to, as from an operator outside the network of the pods. The commands of the sentinels
+// and the ones not listed here are not run through exec.
+type execClient struct {
+	Client
+	run CLIRunner
+}
+
+// WithExecFallback returns the client running the commands of the redis pods with redis-cli through
+// the runner when the TCP connection to their IP fails.
+func WithExecFallback(c Client, run CLIRunner) Client {
+	return &execClient{Client: c, run: run}
+}
+
+// isDialError tells the error is the operator not reaching the redis, rather than the redis failing
+// the command.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// redisCLI runs the command on the redis listening on the port of the pod with the IP, and returns its
+// reply without the trailing new line.
+func (c *execClient) redisCLI(ip, port, password string, command ...string) (string, error) {
+	args := []string{"-p", port}
+	if password != "" {
+		args = append(args, "--no-auth-warning", "-a", password)
+	}
+	log.Debugf("%s can't be connected to, running %s through exec", ip, command[0])
+	out, err := c.run(context.TODO(), ip, append(args, command...))
+	if err != nil {
+		return "", fmt.Errorf("redis-cli %s in the pod of %s: %w", command[0], ip, err)
+	}
+	out = strings.TrimSuffix(out, "\n")
+	if cliErrorReply.MatchString(out) {
+		return "", errors.New(out)
+	}
+	return out, nil
+}
+
+func (c *execClient) GetSlaveOf(ip, port, password string) (string, error) {
+	master, err := c.Client.GetSlaveOf(ip, port, password)
+	if !isDialError(err) {
+		return master, err
+	}
+	info, err := c.redisCLI(ip, port, password, "INFO", "replication")
+	if err != nil {
+		return "", err
+	}
+	return parseSlaveOf(info), nil
+}
+
+func (c *execClient) GetSlaveOfWithPort(ip, port, password string) (string, string, error) {
+	host, masterPort, err := c.Client.GetSlaveOfWithPort(ip, port, password)
+	if !isDialError(err) {
+		return host, masterPort, err
+	}
+	info, err := c.redisCLI(ip, port, password, "INFO", "replication")
+	if err != nil {
+		return "", "", err
+	}
+	host, masterPort = parseSlaveOfWithPort(info)
+	return host, masterPort, nil
+}
+
+func (c *execClient) IsMaster(ip, port, password string) (bool, error) {
+	master, err := c.Client.IsMaster(ip, port, password)
+	if !isDialError(err) {
+		return master, err
+	}
+	info, err := c.redisCLI(ip, port, password, "INFO", "replication")
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(info, redisRoleMaster), nil
+}
+
+func (c *execClient) MakeMaster(ip, port, password string) error {
+	err := c.Client.MakeMaster(ip, port, password)
+	if !isDialError(err) {
+		return err
+	}
+	_, err = c.redisCLI(ip, port, password, "SLAVEOF", "NO", "ONE")
+	return err
+}
+
+func (c *execClient) MakeSlaveOf(ip, masterIP, password string) error {
+	return c.MakeSlaveOfWithPorts(ip, redisPort, masterIP, redisPort, password)
+}
+
+func (c *execClient) MakeSlaveOfWithPort(ip, masterIP, masterPort, password string) error {
+	return c.MakeSlaveOfWithPorts(ip, masterPort, masterIP, masterPort, password)
+}
+
+func (c *execClient) MakeSlaveOfWithPorts(ip, port, masterIP, masterPort, password string) error {
+	err := c.Client.MakeSlaveOfWithPorts(ip, port, masterIP, masterPort, password)
+	if !isDialError(err) {
+		return err
+	}
+	_, err = c.redisCLI(ip, port, password, "SLAVEOF", masterIP, masterPort)
+	return err
+}
+
+func (c *execClient) SlaveIsReady(ip, port, password string) (bool, error) {
+	ready, err := c.Client.SlaveIsReady(ip, port, password)
+	if !isDialError(err) {
+		return ready, err
+	}
+	info, err := c.redisCLI(ip, port, password, "INFO", "replication")
+	if err != nil {
+		return false, err
+	}
+	return parseSlaveIsReady(info), nil
+}
+
+func (c *execClient) GetSyncingReplicas(ip, port, password string) (int, error) {
+	syncing, err := c.Client.GetSyncingReplicas(ip, port, password)
+	if !isDialError(err) {
+		return syncing, err
+	}
+	info, err := c.redisCLI(ip, port, password, "INFO", "replication")
+	if err != nil {
+		return 0, err
+	}
+	return parseSyncingReplicas(info), nil
+}
+
+func (c *execClient) GetRedisInfo(ip, port, password string) (string, error) {
+	info, err := c.Client.GetRedisInfo(ip, port, password)
+	if !isDialError(err) {
+		return info, err
+	}
+	return c.redisCLI(ip, port, password, "INFO")
+}
+
+func (c *execClient) GetRedisConfig(ip, port, password, parameter string) (string, error) {
+	value, err := c.Client.GetRedisConfig(ip, port, password, parameter)
+	if !isDialError(err) {
+		return value, err
+	}
+	out, err := c.redisCLI(ip, port, password, "CONFIG", "GET", parameter)
+	if err != nil {
+		return "", err
+	}
+	// The parameter and its value are printed on a line each.
+	reply := []interface{}{}
+	for _, line := range strings.Split(out, "\n") {
+		reply = append(reply, line)
+	}
+	return parseConfigGetReply(reply, parameter)
+}
+
+func (c *execClient) PingRedis(ip, port, password string) error {
+	err := c.Client.PingRedis(ip, port, password)
+	if !isDialError(err) {
+		return err
+	}
+	_, err = c.redisCLI(ip, port, password, "PING")
+	return err
+}
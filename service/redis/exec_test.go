@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unreachableClient fails to connect to every redis, or fails the commands with err when it's set.
+type unreachableClient struct {
+	Client
+	err error
+}
+
+func (c unreachableClient) fail() error {
+	if c.err != nil {
+		return c.err
+	}
+	return &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connect: no route to host")}
+}
+
+func (c unreachableClient) GetSlaveOf(ip, port, password string) (string, error) {
+	return "", c.fail()
+}
+
+func (c unreachableClient) GetRedisConfig(ip, port, password, parameter string) (string, error) {
+	return "", c.fail()
+}
+
+func (c unreachableClient) PingRedis(ip, port, password string) error {
+	return c.fail()
+}
+
+func (c unreachableClient) MakeSlaveOfWithPorts(ip, port, masterIP, masterPort, password string) error {
+	return c.fail()
+}
+
+// cliRun is a redis-cli run by the exec client.
+type cliRun struct {
+	ip   string
+	args []string
+}
+
+func TestExecClient(t *testing.T) {
+	replies := map[string]string{
+		"INFO":   "# Replication\r\nrole:slave\r\nmaster_host:10.0.0.1\r\nmaster_link_status:up\r\n\n",
+		"CONFIG": "maxmemory\n104857600\n",
+		"PING":   "NOAUTH Authentication required.\n",
+	}
+	var runs []cliRun
+	run := func(ctx context.Context, ip string, args []string) (string, error) {
+		runs = append(runs, cliRun{ip: ip, args: args})
+		for _, arg := range args {
+			if reply, ok := replies[arg]; ok {
+				return reply, nil
+			}
+		}
+		return "OK\n", nil
+	}
+
+	t.Run("The commands of a redis that can't be connected to are run with redis-cli", func(t *testing.T) {
+		assert := assert.New(t)
+		runs = nil
+		c := WithExecFallback(unreachableClient{}, run)
+
+		master, err := c.GetSlaveOf("10.0.0.2", "6379", "pass")
+		assert.NoError(err)
+		assert.Equal("10.0.0.1", master)
+
+		value, err := c.GetRedisConfig("10.0.0.2", "6379", "", "maxmemory")
+		assert.NoError(err)
+		assert.Equal("104857600", value)
+
+		assert.NoError(c.MakeSlaveOf("10.0.0.2", "10.0.0.1", ""))
+
+		assert.Equal([]cliRun{
+			{ip: "10.0.0.2", args: []string{"-p", "6379", "--no-auth-warning", "-a", "pass", "INFO", "replication"}},
+			{ip: "10.0.0.2", args: []string{"-p", "6379", "CONFIG", "GET", "maxmemory"}},
+			{ip: "10.0.0.2", args: []string{"-p", "6379", "SLAVEOF", "10.0.0.1", "6379"}},
+		}, runs)
+	})
+
+	t.Run("The error replies printed by redis-cli are errors", func(t *testing.T) {
+		c := WithExecFallback(unreachableClient{}, run)
+		err := c.PingRedis("10.0.0.2", "6379", "")
+		assert.EqualError(t, err, "NOAUTH Authentication required.")
+	})
+
+	t.Run("A redis failing the command is not retried through exec", func(t *testing.T) {
+		assert := assert.New(t)
+		runs = nil
+		c := WithExecFallback(unreachableClient{err: errors.New("WRONGPASS invalid username-password pair")}, run)
+
+		_, err := c.GetSlaveOf("10.0.0.2", "6379", "pass")
+		assert.EqualError(err, "WRONGPASS invalid username-password pair")
+		assert.Empty(runs)
+	})
+}
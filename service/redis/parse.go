@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The replies of the instances are read by the functions below, apart from the client, so they can be
+// fuzzed. They return an error on a reply they can't read and never panic.
+
+var (
+	errSentinelNotReady = errors.New("sentinels not ready")
+	errRegexNotFound    = errors.New("regex not found")
+)
+
+// parseSentinelInfoCount reads the number captured by the regex in the INFO sentinel reply of a
+// sentinel, named by what it counts. The sentinel has to be ready.
+func parseSentinelInfoCount(info string, re *regexp.Regexp, name string) (int32, error) {
+	if err := isSentinelReady(info); err != nil {
+		return 0, err
+	}
+	match := re.FindStringSubmatch(info)
+	if len(match) < 2 {
+		return 0, fmt.Errorf("%s %w", name, errRegexNotFound)
+	}
+	n, err := strconv.ParseInt(match[1], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("malformed number of %s %q: %w", name, match[1], err)
+	}
+	return int32(n), nil
+}
+
+func isSentinelReady(info string) error {
+	matchStatus := sentinelStatusRE.FindStringSubmatch(info)
+	if len(matchStatus) < 2 || matchStatus[1] != "ok" {
+		return errSentinelNotReady
+	}
+	return nil
+}
+
+// parseSlaveOf reads the master host in the INFO replication reply of a redis, empty when it's master.
+func parseSlaveOf(info string) string {
+	match := redisMasterHostRE.FindStringSubmatch(info)
+	if len(match) < 2 {
+		return ""
+	}
+	return match[1]
+}
+
+// parseSlaveOfWithPort reads the master host and port in the INFO replication reply of a redis, empty
+// when it's master.
+func parseSlaveOfWithPort(info string) (string, string) {
+	host := parseSlaveOf(info)
+	if host == "" {
+		return "", ""
+	}
+	match := redisMasterPortRE.FindStringSubmatch(info)
+	if len(match) < 2 {
+		return host, ""
+	}
+	return host, match[1]
+}
+
+// parseSlaveIsReady returns true when the INFO replication reply of a redis shows it's synced with its
+// master.
+func parseSlaveIsReady(info string) bool {
+	return !strings.Contains(info, redisSyncing) &&
+		!strings.Contains(info, redisMasterSillPending) &&
+		strings.Contains(info, redisLinkUp)
+}
+
+// parseSyncingReplicas counts the replicas in a full sync in the INFO replication reply of a master.
+func parseSyncingReplicas(info string) int {
+	return len(redisFullSyncRE.FindAllString(info, -1))
+}
+
+// parseSentinelMasterReply reads the address of the master in the flat field and value pairs replied
+// by SENTINEL MASTER.
+func parseSentinelMasterReply(reply []interface{}) (string, string, error) {
+	var ip, port string
+	for i := 0; i+1 < len(reply); i += 2 {
+		field, ok := reply[i].(string)
+		if !ok {
+			continue
+		}
+		switch field {
+		case "ip":
+			ip, _ = reply[i+1].(string)
+		case "port":
+			port, _ = reply[i+1].(string)
+		}
+	}
+	if ip == "" || port == "" {
+		return "", "", fmt.Errorf("unexpected reply of sentinel master: %v", reply)
+	}
+	return ip, port, nil
+}
+
+// parseIsMasterDownReply reads the down state of the master replied by SENTINEL IS-MASTER-DOWN-BY-ADDR,
+// followed by the leader and the leader epoch.
+func parseIsMasterDownReply(reply []interface{}) (bool, error) {
+	var down int64
+	ok := len(reply) == 3
+	if ok {
+		down, ok = reply[0].(int64)
+	}
+	if !ok {
+		return false, fmt.Errorf("unexpected reply of sentinel is-master-down-by-addr: %v", reply)
+	}
+	return down == 1, nil
+}
+
+// parseConfigGetReply reads the value of the parameter in the flat parameter and value pairs replied by
+// CONFIG GET.
+func parseConfigGetReply(reply []interface{}, parameter string) (string, error) {
+	for i := 0; i+1 < len(reply); i += 2 {
+		name, _ := reply[i].(string)
+		if strings.EqualFold(name, parameter) {
+			value, ok := reply[i+1].(string)
+			if !ok {
+				return "", fmt.Errorf("unexpected value of redis config parameter %s: %v", parameter, reply[i+1])
+			}
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("redis config parameter %s not found", parameter)
+}
+
+// getConfigParameters splits a custom config line in its parameter and value.
+func getConfigParameters(config string) (parameter string, value string, err error) {
+	s := strings.Split(config, " ")
+	if len(s) < 2 {
+		return "", "", fmt.Errorf("configuration '%s' malformed", config)
+	}
+	if len(s) == 2 && s[1] == `""` {
+		return s[0], "", nil
+	}
+	return s[0], strings.Join(s[1:], " "), nil
+}
@@ -0,0 +1,194 @@
+package redis
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The fuzz targets only run their seeds and the corpus in testdata/fuzz with go test, make ci-fuzz-test
+// fuzzes each of them for FUZZ_TIME. A failing input found by the fuzzer is written in testdata/fuzz and
+// kept there as a regression.
+
+// fuzzReply builds a reply from its elements separated by new lines: ":<n>" is an integer, "~" a
+// nested array and anything else a bulk string, so the fuzzer can mix the types replied by redis.
+func fuzzReply(s string) []interface{} {
+	if s == "" {
+		return nil
+	}
+	reply := []interface{}{}
+	for _, element := range strings.Split(s, "\n") {
+		switch {
+		case element == "~":
+			reply = append(reply, []interface{}{})
+		case strings.HasPrefix(element, ":"):
+			n, err := strconv.ParseInt(element[1:], 10, 64)
+			if err != nil {
+				reply = append(reply, element)
+				continue
+			}
+			reply = append(reply, n)
+		default:
+			reply = append(reply, element)
+		}
+	}
+	return reply
+}
+
+func FuzzParseSentinelInfoCount(f *testing.F) {
+	f.Add("# Sentinel\r\nsentinel_masters:1\r\nmaster0:name=mymaster,status=ok,address=10.0.0.1:6379,slaves=2,sentinels=3\r\n")
+	f.Add("# Sentinel\r\nsentinel_masters:1\r\nmaster0:name=mymaster,status=sdown,address=10.0.0.1:6379,slaves=2,sentinels=3\r\n")
+	f.Add("# Sentinel\r\nsentinel_masters:1\r\nmaster0:name=mymaster,status=ok,address=10.0.0.1:6379,sla")
+	f.Add("master0:name=mymaster,status=ok,slaves=99999999999999999999,sentinels=3")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, info string) {
+		for name, re := range map[string]*regexp.Regexp{"sentinels": sentinelNumberRE, "slaves": slaveNumberRE} {
+			n, err := parseSentinelInfoCount(info, re, name)
+			if err != nil && n != 0 {
+				t.Errorf("%s: got %d with the error %v", name, n, err)
+			}
+			if n < 0 {
+				t.Errorf("%s: got the negative count %d", name, n)
+			}
+		}
+	})
+}
+
+func FuzzParseSlaveOf(f *testing.F) {
+	f.Add("# Replication\r\nrole:slave\r\nmaster_host:10.0.0.1\r\nmaster_port:6379\r\nmaster_link_status:up\r\n")
+	f.Add("# Replication\r\nrole:master\r\nconnected_slaves:0\r\n")
+	f.Add("# Replication\r\nrole:slave\r\nmaster_ho")
+	f.Add("master_host:")
+	f.Fuzz(func(t *testing.T, info string) {
+		master := parseSlaveOf(info)
+		if master != "" && !strings.Contains(info, "master_host:"+master) {
+			t.Errorf("got the master %q not in the info", master)
+		}
+		if host, port := parseSlaveOfWithPort(info); host != master || (port != "" && !strings.Contains(info, "master_port:"+port)) {
+			t.Errorf("got the master %q:%q not in the info", host, port)
+		}
+		parseSlaveIsReady(info)
+		if n := parseSyncingReplicas(info); n < 0 {
+			t.Errorf("got %d syncing replicas", n)
+		}
+	})
+}
+
+func FuzzParseSyncingReplicas(f *testing.F) {
+	f.Add("# Replication\r\nrole:master\r\nconnected_slaves:2\r\nslave0:ip=10.0.0.2,port=6379,state=online,offset=1,lag=0\r\nslave1:ip=10.0.0.3,port=6379,state=wait_bgsave,offset=0,lag=0\r\n")
+	f.Add("# Replication\r\nrole:master\r\nconnected_slaves:1\r\nslave0:ip=10.0.0.2,port=6379,state=send_bu")
+	f.Add("slave0:state=send_bulk\nslave1:state=wait_bgsave\n")
+	f.Fuzz(func(t *testing.T, info string) {
+		n := parseSyncingReplicas(info)
+		if n < 0 || n > strings.Count(info, "slave") {
+			t.Errorf("got %d syncing replicas", n)
+		}
+	})
+}
+
+func FuzzParseSentinelMasterReply(f *testing.F) {
+	f.Add("name\nmymaster\nip\n10.0.0.1\nport\n6379\nrunid\nabc\nflags\nmaster")
+	f.Add("name\nmymaster\nip\n10.0.0.1\npo")
+	f.Add("name\nmymaster\nip\n:1\nport\n~")
+	f.Add("ip")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, s string) {
+		ip, port, err := parseSentinelMasterReply(fuzzReply(s))
+		if err == nil && (ip == "" || port == "") {
+			t.Errorf("got the empty address %q:%q without error", ip, port)
+		}
+	})
+}
+
+func FuzzParseIsMasterDownReply(f *testing.F) {
+	f.Add(":1\n*\n:0")
+	f.Add(":0\n*\n:0")
+	f.Add(":1\n*")
+	f.Add("1\n*\n0")
+	f.Add("~\n~\n~")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, s string) {
+		down, err := parseIsMasterDownReply(fuzzReply(s))
+		if err != nil && down {
+			t.Errorf("got the master down with the error %v", err)
+		}
+	})
+}
+
+func FuzzParseConfigGetReply(f *testing.F) {
+	f.Add("io-threads\n4", "io-threads")
+	f.Add("io-threads", "io-threads")
+	f.Add("io-threads\n:4", "io-threads")
+	f.Add("maxmemory\n0\nio-threads\n~", "IO-THREADS")
+	f.Add("", "maxmemory")
+	f.Fuzz(func(t *testing.T, s, parameter string) {
+		value, err := parseConfigGetReply(fuzzReply(s), parameter)
+		if err != nil && value != "" {
+			t.Errorf("got the value %q with the error %v", value, err)
+		}
+	})
+}
+
+func FuzzParseSentinelConfigReply(f *testing.F) {
+	f.Add("resolve-hostnames\nno\nannounce-hostnames\nno")
+	f.Add("resolve-hostnames\nno\nannounce-hostnames")
+	f.Add("resolve-hostnames\n:0")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, s string) {
+		reply := fuzzReply(s)
+		config, err := parseSentinelConfigReply(reply)
+		if err == nil && len(config) > len(reply)/2 {
+			t.Errorf("got %d parameters from a reply of %d elements", len(config), len(reply))
+		}
+	})
+}
+
+func FuzzSentinelConfigSupported(f *testing.F) {
+	f.Add("# Server\r\nredis_version:7.0.5\r\nredis_mode:sentinel\r\n")
+	f.Add("# Server\r\nredis_version:6.0.16\r\nredis_mode:sentinel\r\n")
+	f.Add("# Server\r\nredis_version:6.")
+	f.Add("redis_version:99999999999999999999.1")
+	f.Fuzz(func(t *testing.T, info string) {
+		if err := sentinelConfigSupported(info); err != nil && !errors.Is(err, ErrUnsupported) {
+			t.Errorf("got the error %v not wrapping ErrUnsupported", err)
+		}
+	})
+}
+
+func FuzzGetConfigParameters(f *testing.F) {
+	f.Add("maxmemory 100mb")
+	f.Add(`save ""`)
+	f.Add("client-output-buffer-limit normal 0 0 0")
+	f.Add("maxmemory")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, config string) {
+		parameter, value, err := getConfigParameters(config)
+		if err != nil {
+			return
+		}
+		if !strings.HasPrefix(config, parameter+" ") {
+			t.Errorf("got the parameter %q not starting %q", parameter, config)
+		}
+		if value != "" && !strings.HasSuffix(config, value) {
+			t.Errorf("got the value %q not ending %q", value, config)
+		}
+	})
+}
+
+func FuzzParseSentinelEvent(f *testing.F) {
+	f.Add(SentinelEventSwitchMaster, "mymaster 10.0.0.1 6379 10.0.0.2 6379")
+	f.Add(SentinelEventSwitchMaster, "mymaster 10.0.0.1 6379 10.0.0.2")
+	f.Add("+sdown", "slave 10.0.0.2:6379 10.0.0.2 6379 @ mymaster 10.0.0.1 6379")
+	f.Add("+odown", "master mymaster 10.0.0.1 6379 #quorum 2/2")
+	f.Add("+sdown", "slave 10.0.0.2:6379 10.0.0.2 6379 @ mym")
+	f.Add("+sdown", "")
+	f.Fuzz(func(t *testing.T, channel, payload string) {
+		event, err := ParseSentinelEvent(channel, payload, time.Time{})
+		if err == nil && event.Channel != channel {
+			t.Errorf("got the channel %q for %q", event.Channel, channel)
+		}
+	})
+}
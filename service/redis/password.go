@@ -0,0 +1,139 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+)
+
+// Password providers of the operator flags.
+const (
+	PasswordProviderSecret = "secret"
+	PasswordProviderExec   = "exec"
+	PasswordProviderFile   = "file"
+)
+
+// defaultPasswordCommandTimeout is how long the password helper can run.
+const defaultPasswordCommandTimeout = 10 * time.Second
+
+// PasswordProvider obtains the password the operator authenticates to the redis of a RF with.
+type PasswordProvider interface {
+	GetPassword(ctx context.Context, rf *redisfailoverv1.RedisFailover) (string, error)
+}
+
+// SecretGetter gets the secrets holding the passwords, the k8s services are one.
+type SecretGetter interface {
+	GetSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error)
+}
+
+// NewPasswordProvider returns the password provider of the operator flags. The secret provider is
+// given the secrets on every call, see NewSecretPasswordProvider, so nil is returned for it.
+func NewPasswordProvider(provider, command, dir string) (PasswordProvider, error) {
+	switch provider {
+	case "", PasswordProviderSecret:
+		return nil, nil
+	case PasswordProviderExec:
+		if command == "" {
+			return nil, errors.New("the exec password provider needs a command")
+		}
+		return NewExecPasswordProvider(command, defaultPasswordCommandTimeout), nil
+	case PasswordProviderFile:
+		if dir == "" {
+			return nil, errors.New("the file password provider needs a directory")
+		}
+		return NewFilePasswordProvider(dir), nil
+	}
+	return nil, fmt.Errorf("unknown password provider %q, must be %s, %s or %s", provider, PasswordProviderSecret, PasswordProviderExec, PasswordProviderFile)
+}
+
+type secretPasswordProvider struct {
+	secrets SecretGetter
+}
+
+// NewSecretPasswordProvider returns a provider reading the password field of the secret of
+// spec.auth.secretPath, the password is blank without it.
+func NewSecretPasswordProvider(secrets SecretGetter) PasswordProvider {
+	return &secretPasswordProvider{secrets: secrets}
+}
+
+func (p *secretPasswordProvider) GetPassword(ctx context.Context, rf *redisfailoverv1.RedisFailover) (string, error) {
+	if rf.Spec.Auth.SecretPath == "" {
+		// no auth settings specified, return blank password
+		return "", nil
+	}
+
+	secret, err := p.secrets.GetSecret(ctx, rf.ObjectMeta.Namespace, rf.Spec.Auth.SecretPath)
+	if err != nil {
+		return "", err
+	}
+
+	if password, ok := secret.Data["password"]; ok {
+		return string(password), nil
+	}
+
+	return "", fmt.Errorf("secret \"%s\" does not have a password field", rf.Spec.Auth.SecretPath)
+}
+
+type execPasswordProvider struct {
+	command string
+	timeout time.Duration
+}
+
+// NewExecPasswordProvider returns a provider running the command with the namespace and the name of
+// the RF as arguments, the password is what it writes on its standard output.
+func NewExecPasswordProvider(command string, timeout time.Duration) PasswordProvider {
+	return &execPasswordProvider{command: command, timeout: timeout}
+}
+
+func (p *execPasswordProvider) GetPassword(ctx context.Context, rf *redisfailoverv1.RedisFailover) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command, rf.Namespace, rf.Name)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// The standard output is never logged, it may hold the password.
+		return "", fmt.Errorf("password command for %s/%s failed: %w: %s", rf.Namespace, rf.Name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nonEmptyPassword(stdout.String(), fmt.Sprintf("password command for %s/%s", rf.Namespace, rf.Name))
+}
+
+type filePasswordProvider struct {
+	dir string
+}
+
+// NewFilePasswordProvider returns a provider reading the password of a RF from the <namespace>/<name>
+// file of the directory, as the files of a secret or an agent mounted in the operator pod.
+func NewFilePasswordProvider(dir string) PasswordProvider {
+	return &filePasswordProvider{dir: dir}
+}
+
+func (p *filePasswordProvider) GetPassword(ctx context.Context, rf *redisfailoverv1.RedisFailover) (string, error) {
+	path := filepath.Join(p.dir, rf.Namespace, rf.Name)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return nonEmptyPassword(string(content), fmt.Sprintf("password file %s", path))
+}
+
+// nonEmptyPassword returns the password without its trailing newline, an error when it's empty.
+func nonEmptyPassword(password, source string) (string, error) {
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("%s is empty", source)
+	}
+	return password, nil
+}
@@ -0,0 +1,216 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/service/redis"
+)
+
+type fakeSecrets map[string]*corev1.Secret
+
+func (f fakeSecrets) GetSecret(_ context.Context, namespace, name string) (*corev1.Secret, error) {
+	secret, ok := f[namespace+"/"+name]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return secret, nil
+}
+
+func passwordRF(secretPath string) *redisfailoverv1.RedisFailover {
+	return &redisfailoverv1.RedisFailover{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "testns",
+		},
+		Spec: redisfailoverv1.RedisFailoverSpec{
+			Auth: redisfailoverv1.AuthSettings{
+				SecretPath: secretPath,
+			},
+		},
+	}
+}
+
+func TestSecretPasswordProvider(t *testing.T) {
+	secrets := fakeSecrets{
+		"testns/auth": &corev1.Secret{Data: map[string][]byte{"password": []byte("s3cr3t")}},
+		"testns/nopw": &corev1.Secret{Data: map[string][]byte{"user": []byte("default")}},
+	}
+
+	tests := []struct {
+		name        string
+		secretPath  string
+		expPassword string
+		expError    string
+	}{
+		{
+			name: "No secret",
+		},
+		{
+			name:        "Password of the secret",
+			secretPath:  "auth",
+			expPassword: "s3cr3t",
+		},
+		{
+			name:       "Secret without password",
+			secretPath: "nopw",
+			expError:   "secret \"nopw\" does not have a password field",
+		},
+		{
+			name:       "Missing secret",
+			secretPath: "missing",
+			expError:   "not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			password, err := redis.NewSecretPasswordProvider(secrets).GetPassword(context.Background(), passwordRF(test.secretPath))
+
+			if test.expError != "" {
+				assert.EqualError(err, test.expError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expPassword, password)
+		})
+	}
+}
+
+func TestExecPasswordProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		expPassword string
+		expError    string
+	}{
+		{
+			name:        "Password of the command",
+			script:      "#!/bin/sh\necho \"pw-$1-$2\"\n",
+			expPassword: "pw-testns-test",
+		},
+		{
+			name:     "Failing command",
+			script:   "#!/bin/sh\necho s3cr3t\necho denied >&2\nexit 3\n",
+			expError: "password command for testns/test failed: exit status 3: denied",
+		},
+		{
+			name:     "Empty output",
+			script:   "#!/bin/sh\necho\n",
+			expError: "password command for testns/test is empty",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			command := filepath.Join(t.TempDir(), "password")
+			require.NoError(os.WriteFile(command, []byte(test.script), 0o755))
+
+			password, err := redis.NewExecPasswordProvider(command, 5*time.Second).GetPassword(context.Background(), passwordRF(""))
+
+			if test.expError != "" {
+				assert.EqualError(err, test.expError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expPassword, password)
+		})
+	}
+}
+
+func TestFilePasswordProvider(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir := t.TempDir()
+	provider := redis.NewFilePasswordProvider(dir)
+
+	_, err := provider.GetPassword(context.Background(), passwordRF(""))
+	assert.Error(err)
+
+	require.NoError(os.MkdirAll(filepath.Join(dir, "testns"), 0o755))
+	require.NoError(os.WriteFile(filepath.Join(dir, "testns", "test"), []byte("\n"), 0o600))
+	_, err = provider.GetPassword(context.Background(), passwordRF(""))
+	assert.EqualError(err, "password file "+filepath.Join(dir, "testns", "test")+" is empty")
+
+	require.NoError(os.WriteFile(filepath.Join(dir, "testns", "test"), []byte("s3cr3t\n"), 0o600))
+	password, err := provider.GetPassword(context.Background(), passwordRF(""))
+	assert.NoError(err)
+	assert.Equal("s3cr3t", password)
+}
+
+func TestNewPasswordProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    string
+		command     string
+		dir         string
+		expProvider bool
+		expError    string
+	}{
+		{
+			name: "Default provider",
+		},
+		{
+			name:     "Secret provider",
+			provider: "secret",
+		},
+		{
+			name:        "Exec provider",
+			provider:    "exec",
+			command:     "/bin/password",
+			expProvider: true,
+		},
+		{
+			name:     "Exec provider without command",
+			provider: "exec",
+			expError: "the exec password provider needs a command",
+		},
+		{
+			name:        "File provider",
+			provider:    "file",
+			dir:         "/etc/passwords",
+			expProvider: true,
+		},
+		{
+			name:     "File provider without directory",
+			provider: "file",
+			expError: "the file password provider needs a directory",
+		},
+		{
+			name:     "Unknown provider",
+			provider: "vault",
+			expError: "unknown password provider \"vault\", must be secret, exec or file",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			provider, err := redis.NewPasswordProvider(test.provider, test.command, test.dir)
+
+			if test.expError != "" {
+				assert.EqualError(err, test.expError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expProvider, provider != nil)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"redis-operator/log"
+)
+
+// ErrMalformedReply is returned when reading the reply of an instance panicked.
+var ErrMalformedReply = errors.New("malformed reply")
+
+// recoveringClient turns the panics of the client into errors. The checks probe every redis and
+// sentinel on each cycle, a reply of one of them that can't be read fails its probe instead of
+// crashing the operator and the reconciliation of every failover with it.
+type recoveringClient struct {
+	client Client
+}
+
+// recoverReply sets the error of a probe of the instance listening on ip when it panicked.
+func recoverReply(ip, probe string, err *error) {
+	if r := recover(); r != nil {
+		log.Errorf("%s of %s panicked: %v\n%s", probe, ip, r, debug.Stack())
+		*err = fmt.Errorf("%w: %s of %s: %v", ErrMalformedReply, probe, ip, r)
+	}
+}
+
+func (c *recoveringClient) GetNumberSentinelsInMemory(ip string) (_ int32, err error) {
+	defer recoverReply(ip, "GetNumberSentinelsInMemory", &err)
+	return c.client.GetNumberSentinelsInMemory(ip)
+}
+
+func (c *recoveringClient) GetNumberSentinelSlavesInMemory(ip string) (_ int32, err error) {
+	defer recoverReply(ip, "GetNumberSentinelSlavesInMemory", &err)
+	return c.client.GetNumberSentinelSlavesInMemory(ip)
+}
+
+func (c *recoveringClient) ResetSentinel(ip string) (err error) {
+	defer recoverReply(ip, "ResetSentinel", &err)
+	return c.client.ResetSentinel(ip)
+}
+
+func (c *recoveringClient) GetSlaveOf(ip, port, password string) (_ string, err error) {
+	defer recoverReply(ip, "GetSlaveOf", &err)
+	return c.client.GetSlaveOf(ip, port, password)
+}
+
+func (c *recoveringClient) GetSlaveOfWithPort(ip, port, password string) (_ string, _ string, err error) {
+	defer recoverReply(ip, "GetSlaveOfWithPort", &err)
+	return c.client.GetSlaveOfWithPort(ip, port, password)
+}
+
+func (c *recoveringClient) IsMaster(ip, port, password string) (_ bool, err error) {
+	defer recoverReply(ip, "IsMaster", &err)
+	return c.client.IsMaster(ip, port, password)
+}
+
+func (c *recoveringClient) MonitorRedis(ip, monitor, quorum, password string) (err error) {
+	defer recoverReply(ip, "MonitorRedis", &err)
+	return c.client.MonitorRedis(ip, monitor, quorum, password)
+}
+
+func (c *recoveringClient) MonitorRedisWithPort(ip, monitor, port, quorum, password string) (err error) {
+	defer recoverReply(ip, "MonitorRedisWithPort", &err)
+	return c.client.MonitorRedisWithPort(ip, monitor, port, quorum, password)
+}
+
+func (c *recoveringClient) MakeMaster(ip, port, password string) (err error) {
+	defer recoverReply(ip, "MakeMaster", &err)
+	return c.client.MakeMaster(ip, port, password)
+}
+
+func (c *recoveringClient) MakeSlaveOf(ip, masterIP, password string) (err error) {
+	defer recoverReply(ip, "MakeSlaveOf", &err)
+	return c.client.MakeSlaveOf(ip, masterIP, password)
+}
+
+func (c *recoveringClient) MakeSlaveOfWithPort(ip, masterIP, masterPort, password string) (err error) {
+	defer recoverReply(ip, "MakeSlaveOfWithPort", &err)
+	return c.client.MakeSlaveOfWithPort(ip, masterIP, masterPort, password)
+}
+
+func (c *recoveringClient) MakeSlaveOfWithPorts(ip, port, masterIP, masterPort, password string) (err error) {
+	defer recoverReply(ip, "MakeSlaveOfWithPorts", &err)
+	return c.client.MakeSlaveOfWithPorts(ip, port, masterIP, masterPort, password)
+}
+
+func (c *recoveringClient) GetSentinelMonitor(ip string) (_ string, _ string, err error) {
+	defer recoverReply(ip, "GetSentinelMonitor", &err)
+	return c.client.GetSentinelMonitor(ip)
+}
+
+func (c *recoveringClient) IsMasterDownByAddr(ip, masterIP, masterPort string) (_ bool, err error) {
+	defer recoverReply(ip, "IsMasterDownByAddr", &err)
+	return c.client.IsMasterDownByAddr(ip, masterIP, masterPort)
+}
+
+func (c *recoveringClient) SetCustomSentinelConfig(ip string, configs []string) (err error) {
+	defer recoverReply(ip, "SetCustomSentinelConfig", &err)
+	return c.client.SetCustomSentinelConfig(ip, configs)
+}
+
+func (c *recoveringClient) SentinelConfigGet(ip, parameter string) (_ map[string]string, err error) {
+	defer recoverReply(ip, "SentinelConfigGet", &err)
+	return c.client.SentinelConfigGet(ip, parameter)
+}
+
+func (c *recoveringClient) SentinelConfigSet(ip, parameter, value string) (err error) {
+	defer recoverReply(ip, "SentinelConfigSet", &err)
+	return c.client.SentinelConfigSet(ip, parameter, value)
+}
+
+func (c *recoveringClient) SetCustomRedisConfig(ip string, port string, configs []string, password string) (err error) {
+	defer recoverReply(ip, "SetCustomRedisConfig", &err)
+	return c.client.SetCustomRedisConfig(ip, port, configs, password)
+}
+
+func (c *recoveringClient) SlaveIsReady(ip, port, password string) (_ bool, err error) {
+	defer recoverReply(ip, "SlaveIsReady", &err)
+	return c.client.SlaveIsReady(ip, port, password)
+}
+
+func (c *recoveringClient) GetSyncingReplicas(ip, port, password string) (_ int, err error) {
+	defer recoverReply(ip, "GetSyncingReplicas", &err)
+	return c.client.GetSyncingReplicas(ip, port, password)
+}
+
+func (c *recoveringClient) GetRedisInfo(ip, port, password string) (_ string, err error) {
+	defer recoverReply(ip, "GetRedisInfo", &err)
+	return c.client.GetRedisInfo(ip, port, password)
+}
+
+func (c *recoveringClient) GetRedisConfig(ip, port, password, parameter string) (_ string, err error) {
+	defer recoverReply(ip, "GetRedisConfig", &err)
+	return c.client.GetRedisConfig(ip, port, password, parameter)
+}
+
+func (c *recoveringClient) MemoryDoctor(ip, port, password string) (_ string, err error) {
+	defer recoverReply(ip, "MemoryDoctor", &err)
+	return c.client.MemoryDoctor(ip, port, password)
+}
+
+func (c *recoveringClient) LatencyDoctor(ip, port, password string) (_ string, err error) {
+	defer recoverReply(ip, "LatencyDoctor", &err)
+	return c.client.LatencyDoctor(ip, port, password)
+}
+
+func (c *recoveringClient) GetSentinelInfo(ip string) (_ string, err error) {
+	defer recoverReply(ip, "GetSentinelInfo", &err)
+	return c.client.GetSentinelInfo(ip)
+}
+
+func (c *recoveringClient) PingSentinel(ip string) (err error) {
+	defer recoverReply(ip, "PingSentinel", &err)
+	return c.client.PingSentinel(ip)
+}
+
+func (c *recoveringClient) PingRedis(ip, port, password string) (err error) {
+	defer recoverReply(ip, "PingRedis", &err)
+	return c.client.PingRedis(ip, port, password)
+}
+
+func (c *recoveringClient) SetRedisKey(ip, port, password, key, value string, ttl time.Duration) (err error) {
+	defer recoverReply(ip, "SetRedisKey", &err)
+	return c.client.SetRedisKey(ip, port, password, key, value, ttl)
+}
+
+func (c *recoveringClient) GetRedisKey(ip, port, password, key string) (_ string, err error) {
+	defer recoverReply(ip, "GetRedisKey", &err)
+	return c.client.GetRedisKey(ip, port, password, key)
+}
+
+func (c *recoveringClient) LoadACL(ip, port, password string) (err error) {
+	defer recoverReply(ip, "LoadACL", &err)
+	return c.client.LoadACL(ip, port, password)
+}
+
+func (c *recoveringClient) ListACLUsers(ip, port, password string) (_ []string, err error) {
+	defer recoverReply(ip, "ListACLUsers", &err)
+	return c.client.ListACLUsers(ip, port, password)
+}
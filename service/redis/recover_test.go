@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// truncatedClient panics reading the reply of SENTINEL MASTER like a sentinel shutting down did.
+type truncatedClient struct {
+	Client
+}
+
+func (truncatedClient) GetSentinelMonitor(ip string) (string, string, error) {
+	reply := []interface{}{"name", "mymaster", "ip"}
+	return reply[3].(string), reply[5].(string), nil
+}
+
+func (truncatedClient) GetSlaveOf(ip, port, password string) (string, error) {
+	return "10.0.0.1", nil
+}
+
+func TestRecoveringClient(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &recoveringClient{client: truncatedClient{}}
+
+	ip, port, err := c.GetSentinelMonitor("10.0.0.2")
+	assert.True(errors.Is(err, ErrMalformedReply))
+	assert.Contains(err.Error(), "GetSentinelMonitor of 10.0.0.2")
+	assert.Empty(ip)
+	assert.Empty(port)
+
+	master, err := c.GetSlaveOf("10.0.0.3", "6379", "")
+	assert.NoError(err)
+	assert.Equal("10.0.0.1", master)
+}
@@ -0,0 +1,147 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	rediscli "github.com/go-redis/redis/v8"
+
+	"redis-operator/metrics"
+)
+
+// ErrUnsupported is returned when the server is too old for the command, as the sentinels older than
+// 6.2 for SENTINEL CONFIG.
+var ErrUnsupported = errors.New("unsupported by the server version")
+
+const (
+	// sentinelConfigMajor and sentinelConfigMinor are the first redis version whose sentinels have the
+	// SENTINEL CONFIG command.
+	sentinelConfigMajor = 6
+	sentinelConfigMinor = 2
+)
+
+var redisVersionRE = regexp.MustCompile(`(?m)^redis_version:(([0-9]+)\.([0-9]+)[^\s]*)`)
+
+// SentinelConfigGet returns the global parameters of the sentinel matching the parameter, which can be
+// a glob pattern. ErrUnsupported is returned by the sentinels older than 6.2.
+func (c *client) SentinelConfigGet(ip, parameter string) (map[string]string, error) {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, sentinelPort),
+		Password: "",
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	if err := checkSentinelConfigSupported(rClient); err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_SENTINEL_CONFIG, metrics.FAIL, getRedisError(err))
+		return nil, err
+	}
+	cmd := rediscli.NewSliceCmd(context.TODO(), "SENTINEL", "CONFIG", "GET", parameter)
+	if err := rClient.Process(context.TODO(), cmd); err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_SENTINEL_CONFIG, metrics.FAIL, getRedisError(err))
+		return nil, sentinelConfigError(err)
+	}
+	config, err := parseSentinelConfigReply(cmd.Val())
+	if err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_SENTINEL_CONFIG, metrics.FAIL, metrics.NOT_APPLICABLE)
+		return nil, err
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.GET_SENTINEL_CONFIG, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return config, nil
+}
+
+// SentinelConfigSet sets a global parameter of the sentinel. ErrUnsupported is returned by the sentinels
+// older than 6.2.
+func (c *client) SentinelConfigSet(ip, parameter, value string) error {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, sentinelPort),
+		Password: "",
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	defer rClient.Close()
+	if err := checkSentinelConfigSupported(rClient); err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.SET_SENTINEL_CONFIG, metrics.FAIL, getRedisError(err))
+		return err
+	}
+	cmd := rediscli.NewStatusCmd(context.TODO(), "SENTINEL", "CONFIG", "SET", parameter, value)
+	if err := rClient.Process(context.TODO(), cmd); err != nil {
+		c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.SET_SENTINEL_CONFIG, metrics.FAIL, getRedisError(err))
+		return sentinelConfigError(err)
+	}
+	c.metricsRecorder.RecordRedisOperation(metrics.KIND_SENTINEL, ip, metrics.SET_SENTINEL_CONFIG, metrics.SUCCESS, metrics.NOT_APPLICABLE)
+	return nil
+}
+
+// checkSentinelConfigSupported returns ErrUnsupported when the sentinel is older than 6.2, so SENTINEL
+// CONFIG is not sent to it. A sentinel not reporting its version is left to reply with an error.
+func checkSentinelConfigSupported(rClient *rediscli.Client) error {
+	info, err := rClient.Info(context.TODO(), "server").Result()
+	if err != nil {
+		return err
+	}
+	return sentinelConfigSupported(info)
+}
+
+func sentinelConfigSupported(info string) error {
+	version, major, minor, ok := parseRedisVersion(info)
+	if !ok {
+		return nil
+	}
+	if major < sentinelConfigMajor || major == sentinelConfigMajor && minor < sentinelConfigMinor {
+		return fmt.Errorf("%w: SENTINEL CONFIG needs redis %d.%d, the sentinel runs %s", ErrUnsupported, sentinelConfigMajor, sentinelConfigMinor, version)
+	}
+	return nil
+}
+
+// parseRedisVersion returns the version of the server section of the INFO, ok is false when it's not
+// reported.
+func parseRedisVersion(info string) (version string, major, minor int, ok bool) {
+	match := redisVersionRE.FindStringSubmatch(info)
+	if match == nil {
+		return "", 0, 0, false
+	}
+	major, err := strconv.Atoi(match[2])
+	if err != nil {
+		return "", 0, 0, false
+	}
+	minor, err = strconv.Atoi(match[3])
+	if err != nil {
+		return "", 0, 0, false
+	}
+	return match[1], major, minor, true
+}
+
+// sentinelConfigError maps the error reply of the sentinels not knowing SENTINEL CONFIG to ErrUnsupported.
+func sentinelConfigError(err error) error {
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "unknown sentinel subcommand") || strings.Contains(msg, "unknown subcommand") {
+		return fmt.Errorf("%w: %s", ErrUnsupported, err)
+	}
+	return err
+}
+
+// parseSentinelConfigReply reads the flat parameter and value pairs replied by SENTINEL CONFIG GET.
+func parseSentinelConfigReply(reply []interface{}) (map[string]string, error) {
+	if len(reply)%2 != 0 {
+		return nil, fmt.Errorf("unexpected reply of sentinel config get: %v", reply)
+	}
+	config := make(map[string]string, len(reply)/2)
+	for i := 0; i < len(reply); i += 2 {
+		parameter, ok := reply[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected reply of sentinel config get: %v", reply)
+		}
+		value, ok := reply[i+1].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected reply of sentinel config get: %v", reply)
+		}
+		config[parameter] = value
+	}
+	return config, nil
+}
@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentinelConfigSupported(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     string
+		expError string
+	}{
+		{
+			name:     "Redis 6.0 sentinel",
+			info:     "# Server\r\nredis_version:6.0.16\r\nredis_mode:sentinel\r\n",
+			expError: "unsupported by the server version: SENTINEL CONFIG needs redis 6.2, the sentinel runs 6.0.16",
+		},
+		{
+			name:     "Redis 5 sentinel",
+			info:     "# Server\r\nredis_version:5.0.14\r\nredis_mode:sentinel\r\n",
+			expError: "unsupported by the server version: SENTINEL CONFIG needs redis 6.2, the sentinel runs 5.0.14",
+		},
+		{
+			name: "Redis 6.2 sentinel",
+			info: "# Server\r\nredis_version:6.2.6\r\nredis_mode:sentinel\r\n",
+		},
+		{
+			name: "Redis 7 sentinel",
+			info: "# Server\r\nredis_version:7.0.5\r\nredis_mode:sentinel\r\n",
+		},
+		{
+			name: "Unknown version",
+			info: "# Server\r\nredis_mode:sentinel\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := sentinelConfigSupported(test.info)
+
+			if test.expError != "" {
+				assert.EqualError(err, test.expError)
+				assert.True(errors.Is(err, ErrUnsupported))
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestSentinelConfigError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expUnsupported bool
+	}{
+		{
+			name:           "Redis 6.0 sentinel not knowing the subcommand",
+			err:            errors.New("ERR Unknown sentinel subcommand 'config'"),
+			expUnsupported: true,
+		},
+		{
+			name:           "Sentinel replying with the generic unknown subcommand error",
+			err:            errors.New("ERR unknown subcommand 'config'. Try SENTINEL HELP."),
+			expUnsupported: true,
+		},
+		{
+			name: "Invalid parameter on a 6.2 sentinel",
+			err:  errors.New("ERR Invalid argument 'maybe' to SENTINEL CONFIG SET 'resolve-hostnames'"),
+		},
+		{
+			name: "Connection error",
+			err:  errors.New("dial tcp 10.0.0.1:26379: connect: connection refused"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := sentinelConfigError(test.err)
+
+			assert.Equal(test.expUnsupported, errors.Is(err, ErrUnsupported))
+			assert.Contains(err.Error(), test.err.Error())
+		})
+	}
+}
+
+func TestParseSentinelConfigReply(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     []interface{}
+		expConfig map[string]string
+		expError  bool
+	}{
+		{
+			name:      "Single parameter",
+			reply:     []interface{}{"resolve-hostnames", "yes"},
+			expConfig: map[string]string{"resolve-hostnames": "yes"},
+		},
+		{
+			name:      "Glob pattern",
+			reply:     []interface{}{"resolve-hostnames", "yes", "announce-hostnames", "no"},
+			expConfig: map[string]string{"resolve-hostnames": "yes", "announce-hostnames": "no"},
+		},
+		{
+			name:      "No parameter matching",
+			reply:     []interface{}{},
+			expConfig: map[string]string{},
+		},
+		{
+			name:     "Missing value",
+			reply:    []interface{}{"resolve-hostnames"},
+			expError: true,
+		},
+		{
+			name:     "Not a string",
+			reply:    []interface{}{"resolve-hostnames", int64(1)},
+			expError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			config, err := parseSentinelConfigReply(test.reply)
+
+			if test.expError {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expConfig, config)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	rediscli "github.com/go-redis/redis/v8"
+
+	"redis-operator/log"
+)
+
+// Channels where the sentinels publish the state transitions of a master failover.
+const (
+	SentinelEventSDown         = "+sdown"
+	SentinelEventSDownCleared  = "-sdown"
+	SentinelEventODown         = "+odown"
+	SentinelEventODownCleared  = "-odown"
+	SentinelEventTryFailover   = "+try-failover"
+	SentinelEventElectedLeader = "+elected-leader"
+	SentinelEventPromotedSlave = "+promoted-slave"
+	SentinelEventFailoverEnd   = "+failover-end"
+	SentinelEventSwitchMaster  = "+switch-master"
+)
+
+const (
+	sentinelEventsPingInterval   = 30 * time.Second
+	sentinelEventsMinBackoff     = time.Second
+	sentinelEventsMaxBackoff     = 30 * time.Second
+	sentinelInstanceTypeMaster   = "master"
+	sentinelEventMasterSeparator = "@"
+)
+
+var sentinelEventChannels = []string{
+	SentinelEventSDown,
+	SentinelEventSDownCleared,
+	SentinelEventODown,
+	SentinelEventODownCleared,
+	SentinelEventTryFailover,
+	SentinelEventElectedLeader,
+	SentinelEventPromotedSlave,
+	SentinelEventFailoverEnd,
+	SentinelEventSwitchMaster,
+}
+
+// ErrStopConsuming is returned by the sentinel resolver of a SentinelEventConsumer when there are no
+// sentinels to consume events from anymore.
+var ErrStopConsuming = errors.New("no sentinel to consume events from")
+
+// SentinelEvent is a state transition published by a sentinel.
+type SentinelEvent struct {
+	// Channel is the sentinel channel the event was published on, one of the SentinelEvent constants.
+	Channel string
+	// InstanceType is the type of the instance the event refers to: master, slave or sentinel.
+	InstanceType string
+	InstanceIP   string
+	InstancePort string
+	// MasterName, MasterIP and MasterPort identify the master the instance belongs to. On a
+	// +switch-master they refer to the old master.
+	MasterName string
+	MasterIP   string
+	MasterPort string
+	// NewMasterIP and NewMasterPort are only set on a +switch-master.
+	NewMasterIP   string
+	NewMasterPort string
+	// Sentinel is the address of the sentinel that published the event.
+	Sentinel string
+	// Time is the time the event was received.
+	Time time.Time
+}
+
+// ParseSentinelEvent parses a message published by a sentinel on one of the failover channels.
+// The payload is "<instance-type> <name> <ip> <port> @ <master-name> <master-ip> <master-port>", the
+// master part is omitted when the instance is the master. +switch-master payload is
+// "<master-name> <old-ip> <old-port> <new-ip> <new-port>".
+func ParseSentinelEvent(channel, payload string, t time.Time) (SentinelEvent, error) {
+	fields := strings.Fields(payload)
+	event := SentinelEvent{
+		Channel: channel,
+		Time:    t,
+	}
+
+	if channel == SentinelEventSwitchMaster {
+		if len(fields) != 5 {
+			return SentinelEvent{}, fmt.Errorf("malformed %s event: %q", channel, payload)
+		}
+		event.InstanceType = sentinelInstanceTypeMaster
+		event.MasterName, event.MasterIP, event.MasterPort = fields[0], fields[1], fields[2]
+		event.InstanceIP, event.InstancePort = fields[1], fields[2]
+		event.NewMasterIP, event.NewMasterPort = fields[3], fields[4]
+		return event, nil
+	}
+
+	if len(fields) < 4 {
+		return SentinelEvent{}, fmt.Errorf("malformed %s event: %q", channel, payload)
+	}
+	event.InstanceType, event.InstanceIP, event.InstancePort = fields[0], fields[2], fields[3]
+
+	// The trailing fields after the address are either the master the instance belongs to or
+	// extra information like the odown quorum, that is ignored.
+	if len(fields) >= 8 && fields[4] == sentinelEventMasterSeparator {
+		event.MasterName, event.MasterIP, event.MasterPort = fields[5], fields[6], fields[7]
+	} else if event.InstanceType == sentinelInstanceTypeMaster {
+		event.MasterName, event.MasterIP, event.MasterPort = fields[1], fields[2], fields[3]
+	}
+	return event, nil
+}
+
+// SentinelSubscription receives the messages published on the sentinel failover channels.
+type SentinelSubscription interface {
+	ReceiveMessage(ctx context.Context) (channel string, payload string, err error)
+	Close() error
+}
+
+// SentinelDialer subscribes to the failover channels of the sentinel listening on ip, authenticated
+// with the password when it's set.
+type SentinelDialer func(ctx context.Context, ip, password string) (SentinelSubscription, error)
+
+type sentinelSubscription struct {
+	client *rediscli.Client
+	pubsub *rediscli.PubSub
+}
+
+// DialSentinelEvents subscribes to the failover channels of the sentinel listening on ip.
+func DialSentinelEvents(ctx context.Context, ip, password string) (SentinelSubscription, error) {
+	options := &rediscli.Options{
+		Addr:     net.JoinHostPort(ip, sentinelPort),
+		Password: password,
+		DB:       0,
+	}
+	rClient := rediscli.NewClient(options)
+	pubsub := rClient.Subscribe(ctx, sentinelEventChannels...)
+	// Wait for the subscription confirmation, so an unreachable sentinel is reported to the caller.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		rClient.Close()
+		return nil, err
+	}
+	return &sentinelSubscription{
+		client: rClient,
+		pubsub: pubsub,
+	}, nil
+}
+
+// ReceiveMessage blocks until a message is published. The connection is pinged when it's idle, so a
+// sentinel that went away is reported as an error instead of blocking forever.
+func (s *sentinelSubscription) ReceiveMessage(ctx context.Context) (string, string, error) {
+	pingPending := false
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", "", err
+		}
+		msg, err := s.pubsub.ReceiveTimeout(ctx, sentinelEventsPingInterval)
+		if err != nil {
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				return "", "", err
+			}
+			if pingPending {
+				return "", "", errors.New("sentinel did not answer the ping")
+			}
+			if err := s.pubsub.Ping(ctx); err != nil {
+				return "", "", err
+			}
+			pingPending = true
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *rediscli.Message:
+			return m.Channel, m.Payload, nil
+		case *rediscli.Pong:
+			pingPending = false
+		}
+	}
+}
+
+func (s *sentinelSubscription) Close() error {
+	s.pubsub.Close()
+	return s.client.Close()
+}
+
+// SentinelEventConsumer keeps a subscription to the failover channels of one of the sentinels and
+// hands the received events over. When the subscription is lost, a sentinel is resolved again and
+// the consumer reconnects to it with an exponential backoff.
+type SentinelEventConsumer struct {
+	// MinBackoff and MaxBackoff bound the time waited between reconnections.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	resolve func() (string, string, error)
+	dial    SentinelDialer
+	handle  func(SentinelEvent)
+	logger  log.Logger
+}
+
+// NewSentinelEventConsumer returns a consumer that subscribes to the sentinel returned by resolve, with
+// its IP and password, and calls handle for every event received. resolve returns ErrStopConsuming to
+// stop the consumer.
+func NewSentinelEventConsumer(resolve func() (string, string, error), dial SentinelDialer, handle func(SentinelEvent), logger log.Logger) *SentinelEventConsumer {
+	return &SentinelEventConsumer{
+		MinBackoff: sentinelEventsMinBackoff,
+		MaxBackoff: sentinelEventsMaxBackoff,
+		resolve:    resolve,
+		dial:       dial,
+		handle:     handle,
+		logger:     logger,
+	}
+}
+
+// Run consumes sentinel events until the context is done or there are no sentinels left.
+func (c *SentinelEventConsumer) Run(ctx context.Context) {
+	backoff := c.MinBackoff
+	for {
+		subscribed, err := c.consume(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, ErrStopConsuming) {
+			c.logger.Debugf("stopping sentinel events consumer: %s", err)
+			return
+		}
+
+		// A subscription that worked for a while resets the backoff, the sentinel may just have been restarted.
+		if subscribed {
+			backoff = c.MinBackoff
+		}
+		c.logger.Warnf("sentinel events subscription lost, reconnecting in %s: %s", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > c.MaxBackoff {
+			backoff = c.MaxBackoff
+		}
+	}
+}
+
+// consume subscribes to a sentinel and handles its events until the subscription fails. It returns
+// if the subscription was established.
+func (c *SentinelEventConsumer) consume(ctx context.Context) (bool, error) {
+	ip, password, err := c.resolve()
+	if err != nil {
+		return false, err
+	}
+
+	sub, err := c.dial(ctx, ip, password)
+	if err != nil {
+		return false, err
+	}
+	defer sub.Close()
+
+	for {
+		channel, payload, err := sub.ReceiveMessage(ctx)
+		if err != nil {
+			return true, err
+		}
+		event, err := ParseSentinelEvent(channel, payload, time.Now())
+		if err != nil {
+			c.logger.Warnf("ignoring sentinel event from %s: %s", ip, err)
+			continue
+		}
+		event.Sentinel = ip
+		c.handle(event)
+	}
+}
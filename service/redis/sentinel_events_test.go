@@ -0,0 +1,246 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"redis-operator/log"
+	"redis-operator/service/redis"
+)
+
+type sentinelMessage struct {
+	channel string
+	payload string
+}
+
+// fakeSubscription returns its messages and then fails, like a sentinel going away.
+type fakeSubscription struct {
+	messages []sentinelMessage
+	closed   bool
+}
+
+func (f *fakeSubscription) ReceiveMessage(ctx context.Context) (string, string, error) {
+	if len(f.messages) == 0 {
+		return "", "", errors.New("connection reset by peer")
+	}
+	msg := f.messages[0]
+	f.messages = f.messages[1:]
+	return msg.channel, msg.payload, nil
+}
+
+func (f *fakeSubscription) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestParseSentinelEvent(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		channel  string
+		payload  string
+		expEvent redis.SentinelEvent
+		expErr   bool
+	}{
+		{
+			name:    "Master subjectively down",
+			channel: "+sdown",
+			payload: "master mymaster 10.0.0.1 6379",
+			expEvent: redis.SentinelEvent{
+				Channel:      "+sdown",
+				InstanceType: "master",
+				InstanceIP:   "10.0.0.1",
+				InstancePort: "6379",
+				MasterName:   "mymaster",
+				MasterIP:     "10.0.0.1",
+				MasterPort:   "6379",
+				Time:         now,
+			},
+		},
+		{
+			name:    "Master objectively down with quorum",
+			channel: "+odown",
+			payload: "master mymaster 10.0.0.1 6379 #quorum 2/2",
+			expEvent: redis.SentinelEvent{
+				Channel:      "+odown",
+				InstanceType: "master",
+				InstanceIP:   "10.0.0.1",
+				InstancePort: "6379",
+				MasterName:   "mymaster",
+				MasterIP:     "10.0.0.1",
+				MasterPort:   "6379",
+				Time:         now,
+			},
+		},
+		{
+			name:    "Slave promoted",
+			channel: "+promoted-slave",
+			payload: "slave 10.0.0.2:6379 10.0.0.2 6379 @ mymaster 10.0.0.1 6379",
+			expEvent: redis.SentinelEvent{
+				Channel:      "+promoted-slave",
+				InstanceType: "slave",
+				InstanceIP:   "10.0.0.2",
+				InstancePort: "6379",
+				MasterName:   "mymaster",
+				MasterIP:     "10.0.0.1",
+				MasterPort:   "6379",
+				Time:         now,
+			},
+		},
+		{
+			name:    "Master switched",
+			channel: "+switch-master",
+			payload: "mymaster 10.0.0.1 6379 10.0.0.2 6379",
+			expEvent: redis.SentinelEvent{
+				Channel:       "+switch-master",
+				InstanceType:  "master",
+				InstanceIP:    "10.0.0.1",
+				InstancePort:  "6379",
+				MasterName:    "mymaster",
+				MasterIP:      "10.0.0.1",
+				MasterPort:    "6379",
+				NewMasterIP:   "10.0.0.2",
+				NewMasterPort: "6379",
+				Time:          now,
+			},
+		},
+		{
+			name:    "Malformed switch master",
+			channel: "+switch-master",
+			payload: "mymaster 10.0.0.1 6379",
+			expErr:  true,
+		},
+		{
+			name:    "Malformed event",
+			channel: "+sdown",
+			payload: "master mymaster",
+			expErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			event, err := redis.ParseSentinelEvent(test.channel, test.payload, now)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+				assert.Equal(test.expEvent, event)
+			}
+		})
+	}
+}
+
+func TestSentinelEventConsumerReconnects(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	subscriptions := []*fakeSubscription{
+		{messages: []sentinelMessage{
+			{channel: "+sdown", payload: "master mymaster 10.0.0.1 6379"},
+			{channel: "+odown", payload: "master mymaster 10.0.0.1 6379 #quorum 2/2"},
+		}},
+		{messages: []sentinelMessage{
+			{channel: "+switch-master", payload: "mymaster 10.0.0.1 6379 10.0.0.2 6379"},
+		}},
+	}
+	opened := append([]*fakeSubscription{}, subscriptions...)
+	sentinels := []string{"10.0.1.1", "10.0.1.1", "10.0.1.2", "10.0.1.3"}
+	dialErrs := []error{nil, errors.New("connection refused"), nil}
+
+	var mu sync.Mutex
+	var dialed []string
+	resolve := func() (string, string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(sentinels) == 0 {
+			return "", "", redis.ErrStopConsuming
+		}
+		ip := sentinels[0]
+		sentinels = sentinels[1:]
+		return ip, "pass", nil
+	}
+	dial := func(_ context.Context, ip, password string) (redis.SentinelSubscription, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal("pass", password)
+		dialed = append(dialed, ip)
+		if len(dialErrs) > 0 {
+			err := dialErrs[0]
+			dialErrs = dialErrs[1:]
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(subscriptions) == 0 {
+			return nil, errors.New("connection refused")
+		}
+		sub := subscriptions[0]
+		subscriptions = subscriptions[1:]
+		return sub, nil
+	}
+	var events []string
+	handle := func(event redis.SentinelEvent) {
+		events = append(events, event.Channel)
+	}
+
+	consumer := redis.NewSentinelEventConsumer(resolve, dial, handle, log.Dummy)
+	consumer.MinBackoff = time.Millisecond
+	consumer.MaxBackoff = 2 * time.Millisecond
+
+	done := make(chan struct{})
+	go func() {
+		consumer.Run(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow("consumer didn't stop when there were no sentinels left")
+	}
+
+	assert.Equal([]string{"+sdown", "+odown", "+switch-master"}, events)
+	assert.Equal([]string{"10.0.1.1", "10.0.1.1", "10.0.1.2", "10.0.1.3"}, dialed)
+	for _, sub := range opened {
+		assert.True(sub.closed)
+	}
+}
+
+func TestSentinelEventConsumerStopsOnContextDone(t *testing.T) {
+	require := require.New(t)
+
+	resolve := func() (string, string, error) {
+		return "10.0.1.1", "", nil
+	}
+	dial := func(_ context.Context, ip, password string) (redis.SentinelSubscription, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	consumer := redis.NewSentinelEventConsumer(resolve, dial, func(redis.SentinelEvent) {}, log.Dummy)
+	consumer.MinBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		consumer.Run(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow("consumer didn't stop when the context was done")
+	}
+}
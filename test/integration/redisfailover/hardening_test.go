@@ -0,0 +1,118 @@
+//go:build integration
+// +build integration
+
+package redisfailover_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/homedir"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/cmd/utils"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/operator/redisfailover"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
+)
+
+const (
+	hardenedName      = "hardened"
+	hardenedNamespace = "rf-hardened-integration-tests"
+)
+
+// TestRedisFailoverReadOnlyRootFilesystem runs a RF with a read-only root filesystem in a namespace
+// enforcing the restricted pod security standard, the pods are rejected when they don't comply.
+func TestRedisFailoverReadOnlyRootFilesystem(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	flags := &utils.CMDFlags{
+		KubeConfig:  filepath.Join(homedir.HomeDir(), ".kube", "config"),
+		Development: true,
+	}
+	k8sClient, customClient, aeClientset, err := utils.CreateKubernetesClients(flags, nil)
+	require.NoError(err)
+	redisClient := redis.New(metrics.Dummy)
+	k8sservice := k8s.New(k8sClient, nil, nil, customClient, nil, aeClientset, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+	_, err = k8sClient.CoreV1().Namespaces().Create(context.Background(), &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: hardenedNamespace,
+			Labels: map[string]string{
+				"pod-security.kubernetes.io/enforce": "restricted",
+			},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(err)
+	defer k8sClient.CoreV1().Namespaces().Delete(context.Background(), hardenedNamespace, metav1.DeleteOptions{})
+	time.Sleep(15 * time.Second)
+
+	operator, err := redisfailover.New(redisfailover.Config{}, k8sservice, k8sClient, hardenedNamespace, redisClient, metrics.Dummy, log.Dummy)
+	require.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go operator.Run(ctx)
+	time.Sleep(15 * time.Second)
+
+	rf := &redisfailoverv1.RedisFailover{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hardenedName,
+			Namespace: hardenedNamespace,
+		},
+		Spec: redisfailoverv1.RedisFailoverSpec{
+			Redis: redisfailoverv1.RedisSettings{
+				Replicas: redisSize,
+			},
+			Sentinel: redisfailoverv1.SentinelSettings{
+				Replicas: sentinelSize,
+			},
+			Hardening: &redisfailoverv1.HardeningSettings{
+				ReadOnlyRootFilesystem: true,
+			},
+		},
+	}
+	_, err = customClient.DatabasesV1().RedisFailovers(hardenedNamespace).Create(context.Background(), rf, metav1.CreateOptions{})
+	require.NoError(err)
+	time.Sleep(3 * time.Minute)
+
+	redisPods := redisPods(t, k8sClient, hardenedNamespace, hardenedName)
+	require.Len(redisPods, int(redisSize))
+	master := ""
+	for _, pod := range redisPods {
+		isMaster, err := redisClient.IsMaster(pod.Status.PodIP, "6379", "")
+		require.NoError(err)
+		if isMaster {
+			master = pod.Status.PodIP
+		}
+	}
+	require.NotEmpty(master, "a redis has to be the master")
+
+	// The sentinels rewrite their config in the volume it's copied to, and monitor the master.
+	sentinelD, err := k8sClient.AppsV1().Deployments(hardenedNamespace).Get(context.Background(), fmt.Sprintf("rfs-%s", hardenedName), metav1.GetOptions{})
+	require.NoError(err)
+	sentinelPods, err := k8sClient.CoreV1().Pods(hardenedNamespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: labels.FormatLabels(sentinelD.Spec.Selector.MatchLabels),
+	})
+	require.NoError(err)
+	require.Len(sentinelPods.Items, int(sentinelSize))
+	for _, pod := range sentinelPods.Items {
+		monitor, _, err := redisClient.GetSentinelMonitor(pod.Status.PodIP)
+		require.NoError(err)
+		assert.Equal(master, monitor, "sentinel %s monitors another master", pod.Name)
+
+		config, err := k8sservice.ExecPod(context.Background(), hardenedNamespace, pod.Name, "sentinel", []string{"cat", "/redis/sentinel.conf"})
+		require.NoError(err)
+		assert.Contains(config, fmt.Sprintf("sentinel monitor mymaster %s", master), "sentinel %s has not rewritten its config", pod.Name)
+	}
+}
@@ -0,0 +1,201 @@
+//go:build integration
+// +build integration
+
+package redisfailover_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/homedir"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/cmd/utils"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/operator/redisfailover"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
+)
+
+const (
+	tlsSidecarName      = "tls-sidecar"
+	tlsSidecarNamespace = "rf-tls-sidecar-integration-tests"
+	tlsSidecarSecret    = "redis-tls"
+)
+
+// TestRedisFailoverTLSSidecar runs redis 5 behind the stunnel sidecar of STUNNEL_IMAGE, and checks every
+// redis answers over TLS with the certificate of the secret, and only with it.
+func TestRedisFailoverTLSSidecar(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	image := os.Getenv("STUNNEL_IMAGE")
+	if image == "" {
+		t.Skip("STUNNEL_IMAGE is not set")
+	}
+
+	flags := &utils.CMDFlags{
+		KubeConfig:  filepath.Join(homedir.HomeDir(), ".kube", "config"),
+		Development: true,
+	}
+	k8sClient, customClient, aeClientset, err := utils.CreateKubernetesClients(flags, nil)
+	require.NoError(err)
+	redisClient := redis.New(metrics.Dummy)
+	k8sservice := k8s.New(k8sClient, nil, nil, customClient, nil, aeClientset, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+	_, err = k8sClient.CoreV1().Namespaces().Create(context.Background(), &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: tlsSidecarNamespace},
+	}, metav1.CreateOptions{})
+	require.NoError(err)
+	defer k8sClient.CoreV1().Namespaces().Delete(context.Background(), tlsSidecarNamespace, metav1.DeleteOptions{})
+	time.Sleep(15 * time.Second)
+
+	serverName := fmt.Sprintf("rfr-%s.%s.svc", tlsSidecarName, tlsSidecarNamespace)
+	certPEM, keyPEM, err := selfSignedCertificate(serverName)
+	require.NoError(err)
+	_, err = k8sClient.CoreV1().Secrets(tlsSidecarNamespace).Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: tlsSidecarSecret},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(err)
+
+	operator, err := redisfailover.New(redisfailover.Config{}, k8sservice, k8sClient, tlsSidecarNamespace, redisClient, metrics.Dummy, log.Dummy)
+	require.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go operator.Run(ctx)
+	time.Sleep(15 * time.Second)
+
+	rf := &redisfailoverv1.RedisFailover{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tlsSidecarName,
+			Namespace: tlsSidecarNamespace,
+		},
+		Spec: redisfailoverv1.RedisFailoverSpec{
+			TLSConfig: &redisfailoverv1.TLSConfig{
+				Enabled:    true,
+				SecretName: tlsSidecarSecret,
+			},
+			Redis: redisfailoverv1.RedisSettings{
+				Image:    "redis:5.0.14-alpine",
+				Replicas: redisSize,
+				TLSSidecar: &redisfailoverv1.RedisTLSSidecar{
+					Enabled: true,
+					Image:   image,
+				},
+			},
+			Sentinel: redisfailoverv1.SentinelSettings{
+				Image:    "redis:5.0.14-alpine",
+				Replicas: sentinelSize,
+			},
+		},
+	}
+	_, err = customClient.DatabasesV1().RedisFailovers(tlsSidecarNamespace).Create(context.Background(), rf, metav1.CreateOptions{})
+	require.NoError(err)
+	time.Sleep(3 * time.Minute)
+
+	roots := x509.NewCertPool()
+	require.True(roots.AppendCertsFromPEM(certPEM))
+	pods := redisPods(t, k8sClient, tlsSidecarNamespace, tlsSidecarName)
+	require.Len(pods, int(redisSize))
+	for name, pod := range pods {
+		address := net.JoinHostPort(pod.Status.PodIP, "6380")
+
+		// The certificate of the secret is served, and the redis behind answers.
+		reply, err := tlsPing(address, &tls.Config{RootCAs: roots, ServerName: serverName})
+		if assert.NoError(err, "redis %s over TLS", name) {
+			assert.Equal("+PONG", reply, "redis %s over TLS", name)
+		}
+		// A client not trusting it can't connect.
+		_, err = tlsPing(address, &tls.Config{ServerName: serverName})
+		assert.Error(err, "redis %s over TLS with an unknown certificate", name)
+		// The port doesn't serve plain redis.
+		reply, _ = plainPing(address)
+		assert.NotEqual("+PONG", reply, "redis %s over the TLS port without TLS", name)
+	}
+}
+
+// selfSignedCertificate returns a self-signed certificate of the DNS name and of its subdomains, with its
+// key, PEM encoded.
+func selfSignedCertificate(dnsName string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: dnsName},
+		DNSNames:              []string{dnsName, "*." + dnsName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// tlsPing sends a PING over TLS to the address and returns the reply.
+func tlsPing(address string, config *tls.Config) (string, error) {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", address, config)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return ping(conn)
+}
+
+// plainPing sends a PING without TLS to the address and returns the reply.
+func plainPing(address string) (string, error) {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return ping(conn)
+}
+
+func ping(conn net.Conn) (string, error) {
+	if err := conn.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return "", err
+	}
+	if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+		return "", err
+	}
+	line, _, err := bufio.NewReader(conn).ReadLine()
+	return string(line), err
+}
@@ -0,0 +1,147 @@
+//go:build integration
+// +build integration
+
+package redisfailover_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/homedir"
+
+	redisfailoverv1 "redis-operator/api/redisfailover/v1"
+	"redis-operator/cmd/utils"
+	"redis-operator/log"
+	"redis-operator/metrics"
+	"redis-operator/operator/redisfailover"
+	"redis-operator/service/k8s"
+	"redis-operator/service/redis"
+)
+
+const (
+	reloadName      = "reload"
+	reloadNamespace = "rf-reload-integration-tests"
+)
+
+// TestRedisFailoverZeroDowntimeReload changes io-threads, which redis only reads on startup, and checks
+// it's applied restarting the redis containers without recreating the pods. The proxy runs the image
+// of the operator set in REDIS_OPERATOR_IMAGE, built from this tree.
+func TestRedisFailoverZeroDowntimeReload(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	flags := &utils.CMDFlags{
+		KubeConfig:  filepath.Join(homedir.HomeDir(), ".kube", "config"),
+		Development: true,
+	}
+	k8sClient, customClient, aeClientset, err := utils.CreateKubernetesClients(flags, nil)
+	require.NoError(err)
+	redisClient := redis.New(metrics.Dummy)
+	k8sservice := k8s.New(k8sClient, nil, nil, customClient, nil, aeClientset, k8s.DefaultConflictRetries, log.Dummy, metrics.Dummy)
+
+	_, err = k8sClient.CoreV1().Namespaces().Create(context.Background(), &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: reloadNamespace},
+	}, metav1.CreateOptions{})
+	require.NoError(err)
+	defer k8sClient.CoreV1().Namespaces().Delete(context.Background(), reloadNamespace, metav1.DeleteOptions{})
+	time.Sleep(15 * time.Second)
+
+	operator, err := redisfailover.New(redisfailover.Config{}, k8sservice, k8sClient, reloadNamespace, redisClient, metrics.Dummy, log.Dummy)
+	require.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go operator.Run(ctx)
+	time.Sleep(15 * time.Second)
+
+	rf := &redisfailoverv1.RedisFailover{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      reloadName,
+			Namespace: reloadNamespace,
+		},
+		Spec: redisfailoverv1.RedisFailoverSpec{
+			Redis: redisfailoverv1.RedisSettings{
+				Replicas:     redisSize,
+				CustomConfig: []string{"io-threads 2"},
+				ZeroDowntimeReload: redisfailoverv1.ZeroDowntimeReload{
+					Enabled: true,
+					Image:   os.Getenv("REDIS_OPERATOR_IMAGE"),
+				},
+			},
+			Sentinel: redisfailoverv1.SentinelSettings{
+				Replicas: sentinelSize,
+			},
+		},
+	}
+	_, err = customClient.DatabasesV1().RedisFailovers(reloadNamespace).Create(context.Background(), rf, metav1.CreateOptions{})
+	require.NoError(err)
+	time.Sleep(3 * time.Minute)
+
+	before := redisPods(t, k8sClient, reloadNamespace, reloadName)
+	require.Len(before, int(redisSize))
+	for _, pod := range before {
+		threads, err := redisClient.GetRedisConfig(pod.Status.PodIP, "6379", "", "io-threads")
+		require.NoError(err)
+		require.Equal("2", threads)
+	}
+
+	patch := []byte(`{"spec":{"redis":{"customConfig":["io-threads 4"]}}}`)
+	_, err = customClient.DatabasesV1().RedisFailovers(reloadNamespace).Patch(context.Background(), reloadName, types.MergePatchType, patch, metav1.PatchOptions{})
+	require.NoError(err)
+
+	// The ConfigMap is synced in the pods and the redis are restarted one by one.
+	applied := false
+	for deadline := time.Now().Add(10 * time.Minute); time.Now().Before(deadline) && !applied; time.Sleep(15 * time.Second) {
+		applied = true
+		for _, pod := range redisPods(t, k8sClient, reloadNamespace, reloadName) {
+			threads, err := redisClient.GetRedisConfig(pod.Status.PodIP, "6379", "", "io-threads")
+			if err != nil || threads != "4" {
+				applied = false
+			}
+		}
+	}
+	require.True(applied, "io-threads has to be applied to all the redis")
+
+	after := redisPods(t, k8sClient, reloadNamespace, reloadName)
+	for name, pod := range before {
+		restarted, ok := after[name]
+		if !assert.True(ok, "pod %s is gone", name) {
+			continue
+		}
+		assert.Equal(pod.UID, restarted.UID, "pod %s has been recreated", name)
+		assert.Greater(redisRestarts(restarted), redisRestarts(pod), "the redis container of %s has not been restarted", name)
+	}
+}
+
+func redisPods(t *testing.T, k8sClient kubernetes.Interface, namespace, name string) map[string]corev1.Pod {
+	redisSS, err := k8sClient.AppsV1().StatefulSets(namespace).Get(context.Background(), fmt.Sprintf("rfr-%s", name), metav1.GetOptions{})
+	require.NoError(t, err)
+	podList, err := k8sClient.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: labels.FormatLabels(redisSS.Spec.Selector.MatchLabels),
+	})
+	require.NoError(t, err)
+	pods := map[string]corev1.Pod{}
+	for _, pod := range podList.Items {
+		pods[pod.Name] = pod
+	}
+	return pods
+}
+
+func redisRestarts(pod corev1.Pod) int32 {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "redis" {
+			return status.RestartCount
+		}
+	}
+	return 0
+}